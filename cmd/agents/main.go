@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"net"
 	"net/http"
 	"os"
@@ -9,47 +10,59 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/agentops"
 	"github.com/snappy-loop/stories/internal/agents"
 	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/buildinfo"
+	"github.com/snappy-loop/stories/internal/cache"
 	"github.com/snappy-loop/stories/internal/config"
 	"github.com/snappy-loop/stories/internal/database"
 	"github.com/snappy-loop/stories/internal/grpcserver"
+	"github.com/snappy-loop/stories/internal/inputtype"
 	"github.com/snappy-loop/stories/internal/llm"
+	"github.com/snappy-loop/stories/internal/logging"
 	"github.com/snappy-loop/stories/internal/mcpserver"
+	"github.com/snappy-loop/stories/internal/proxy"
+	"github.com/snappy-loop/stories/internal/quota"
+	"github.com/snappy-loop/stories/internal/ratelimit"
 	"github.com/snappy-loop/stories/internal/storage"
 	"github.com/snappy-loop/stories/migrations"
 	audiov1 "github.com/snappy-loop/stories/gen/audio/v1"
 	factcheckv1 "github.com/snappy-loop/stories/gen/factcheck/v1"
 	imagev1 "github.com/snappy-loop/stories/gen/image/v1"
 	segmentationv1 "github.com/snappy-loop/stories/gen/segmentation/v1"
+	segmentationv2 "github.com/snappy-loop/stories/gen/segmentation/v2"
 	"google.golang.org/grpc"
 )
 
 func main() {
-	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
-
-	logLevel := os.Getenv("LOG_LEVEL")
-	if logLevel == "" {
-		logLevel = "info"
-	}
-	level, err := zerolog.ParseLevel(logLevel)
+	cfg := config.Load()
+	closeLogs, err := logging.Setup("agents", logging.Options{
+		Level: cfg.LogLevel, Levels: cfg.LogLevels, Format: cfg.LogFormat, File: cfg.LogFile, Syslog: cfg.LogSyslog,
+	})
 	if err != nil {
-		level = zerolog.InfoLevel
+		log.Fatal().Err(err).Msg("Invalid logging configuration")
 	}
-	zerolog.SetGlobalLevel(level)
+	defer closeLogs()
 
-	log.Info().Msg("Starting Stories Agents (gRPC + MCP)")
+	build := buildinfo.Get()
+	log.Info().Str("commit", build.Commit).Str("build_time", build.BuildTime).Str("go_version", build.GoVersion).
+		Msg("Starting Stories Agents (gRPC + MCP)")
 
-	cfg := config.Load()
+	if err := proxy.Validate(cfg.GeminiProxy); err != nil {
+		log.Fatal().Err(err).Msg("Invalid GEMINI_PROXY")
+	}
+	if err := proxy.Validate(cfg.S3Proxy); err != nil {
+		log.Fatal().Err(err).Msg("Invalid S3_PROXY")
+	}
 
 	db, err := database.Connect(cfg.DatabaseURL)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to connect to database")
 	}
 	defer db.Close()
+	db.SetSlowQueryThreshold(cfg.SlowDBQueryThreshold)
 
 	if err := migrations.Run(db.SQLDB()); err != nil {
 		log.Fatal().Err(err).Msg("Failed to run migrations")
@@ -60,8 +73,21 @@ func main() {
 	// Initialize boundary cache repository
 	boundaryCacheRepo := database.NewBoundaryCacheRepository(db)
 
+	var vertexAI *llm.VertexAI
+	switch cfg.GeminiBackend {
+	case "gemini":
+	case "vertex":
+		if cfg.VertexProject == "" {
+			log.Fatal().Msg("VERTEX_PROJECT is required when GEMINI_BACKEND=vertex")
+		}
+		vertexAI = &llm.VertexAI{Project: cfg.VertexProject, Location: cfg.VertexLocation}
+	default:
+		log.Fatal().Str("backend", cfg.GeminiBackend).Msg("Invalid GEMINI_BACKEND (want gemini or vertex)")
+	}
+
 	llmClient := llm.NewClient(
 		cfg.GeminiAPIKey,
+		vertexAI,
 		cfg.GeminiModelFlash,
 		cfg.GeminiModelPro,
 		cfg.GeminiModelImage,
@@ -71,9 +97,56 @@ func main() {
 		cfg.GeminiModelSegmentPrimary,
 		cfg.GeminiModelSegmentFallback,
 		boundaryCacheRepo,
+		cfg.GeminiProxy,
 	)
 
-	segmentAgent := agents.NewSegmentationAgent(llmClient)
+	if err := ratelimit.CheckBackend(cfg.LimitsBackend, cfg.RedisURL); err != nil {
+		log.Fatal().Err(err).Msg("Invalid limits configuration")
+	}
+
+	// Optional Redis cache shared by all workers (boundaries, narration, image prompts)
+	var redisCache *cache.Redis
+	if cfg.RedisURL != "" {
+		redisCache, err = cache.NewRedis(cfg.RedisURL, cfg.MaxConcurrentSegments+1)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid REDIS_URL")
+		}
+		defer redisCache.Close()
+		if err := redisCache.Ping(context.Background()); err != nil {
+			log.Warn().Err(err).Msg("Redis not reachable; cache lookups will fail over to Postgres/Gemini")
+		}
+		llmClient.SetSharedCache(redisCache, cfg.RedisCacheTTL)
+	}
+	inputTypes, err := inputtype.Parse(cfg.InputTypeProfiles)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid input type profiles")
+	}
+	llmClient.SetInputTypes(inputTypes)
+	llmClient.SetExtractionModel(cfg.GeminiModelExtract)
+	geminiLimits := map[string]int{
+		llm.ModelFamilyPro:   cfg.GeminiMaxConcurrentPro,
+		llm.ModelFamilyFlash: cfg.GeminiMaxConcurrentFlash,
+		llm.ModelFamilyImage: cfg.GeminiMaxConcurrentImage,
+		llm.ModelFamilyTTS:   cfg.GeminiMaxConcurrentTTS,
+	}
+	llmClient.SetConcurrencyLimits(geminiLimits)
+	// With LIMITS_BACKEND=redis the limits hold across all workers and agents instead of per process
+	if cfg.LimitsBackend == ratelimit.BackendRedis {
+		shared := map[string]llm.SharedLimiter{}
+		for family, limit := range geminiLimits {
+			if limit > 0 {
+				shared[family] = ratelimit.NewRedisSemaphore(redisCache, "gemini_"+family, int64(limit), ratelimit.DefaultLease)
+			}
+		}
+		llmClient.SetSharedConcurrencyLimits(shared)
+	}
+	llmClient.SetAdaptiveRateLimit(cfg.GeminiAdaptiveRateLimit)
+	llmClient.SetSlowCallThreshold(cfg.SlowLLMCallThreshold)
+	if err := llmClient.SetContentLogging(cfg.GeminiLogContent, cfg.GeminiLogSampleRate); err != nil {
+		log.Fatal().Err(err).Msg("Invalid GEMINI_LOG_CONTENT or GEMINI_LOG_SAMPLE_RATE")
+	}
+
+	segmentAgent := agents.NewSegmentationAgent(llmClient, cfg.MaxFileSize)
 	audioAgent := agents.NewAudioAgent(llmClient)
 	imageAgent := agents.NewImageAgent(llmClient)
 	factCheckAgent := agents.NewFactCheckAgent(llmClient)
@@ -83,18 +156,68 @@ func main() {
 		var err error
 		storageClient, err = storage.NewClient(
 			cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket,
-			cfg.S3AccessKey, cfg.S3SecretKey, cfg.S3UseSSL, cfg.S3PublicURL,
+			cfg.S3AccessKey, cfg.S3SecretKey, cfg.S3UseSSL, cfg.S3PublicURL, cfg.S3Proxy,
 		)
 		if err != nil {
 			log.Warn().Err(err).Msg("S3 not available; audio/image will be returned inline (may hit gRPC size limits)")
+		} else {
+			storageClient.SetSlowOpThreshold(cfg.SlowS3OpThreshold)
+			storageClient.SetUploadRetries(cfg.S3UploadRetries, cfg.S3UploadRetryDelay, cfg.S3UploadVerify)
+			storageClient.SetFallbackBucket(cfg.S3FallbackBucket)
+		}
+	}
+
+	// Optional request signing: nonces go to Redis when shared, so a replay to another replica is refused
+	var signatureVerifier *auth.SignatureVerifier
+	if cfg.AgentsRequestSigning != auth.SigningOff {
+		var nonces auth.NonceStore = auth.NewMemoryNonceStore()
+		if redisCache != nil {
+			nonces = auth.NewRedisNonceStore(redisCache)
+		}
+		signatureVerifier, err = auth.NewSignatureVerifier(cfg.AgentsRequestSigning, cfg.AgentsSignatureWindow, nonces)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid AGENTS_REQUEST_SIGNING or AGENTS_SIGNATURE_WINDOW")
 		}
+		log.Info().Str("mode", cfg.AgentsRequestSigning).Dur("window", cfg.AgentsSignatureWindow).Msg("Agent request signing enabled")
 	}
 
-	// gRPC server with auth
-	grpcSrv := grpc.NewServer(grpc.UnaryInterceptor(grpcserver.AuthUnaryInterceptor(authService)))
+	// Agent calls count against the caller's character quota, so they are no free way around job quotas
+	var quotaService *quota.Service
+	if cfg.AgentsQuota {
+		weights, err := quota.ParseWeights(cfg.AgentsQuotaWeights)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid AGENTS_QUOTA_WEIGHTS")
+		}
+		quotaService = quota.NewService(db)
+		quotaService.SetAgentCharges(weights, int64(cfg.CharsPerFile))
+	} else {
+		log.Warn().Msg("AGENTS_QUOTA=false: agent calls are not charged to API key quotas")
+	}
+
+	// Async agent calls: results are kept in Postgres (large ones in S3) so any replica answers the polls
+	operations := agentops.NewManager(database.NewAgentOperationRepository(db), cfg.AgentsOperationTTL, cfg.AgentsOperationTimeout)
+	if storageClient != nil {
+		operations.SetStorage(storageClient)
+	}
+	operations.StartPruning(context.Background())
+
+	// gRPC server with auth. ExtractContent requests carry whole documents (up to MAX_FILE_SIZE), above
+	// gRPC's default 4 MB receive limit.
+	grpcSrv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			grpcserver.AuthUnaryInterceptor(authService, signatureVerifier, quotaService),
+			grpcserver.DeprecationUnaryInterceptor(),
+		),
+		grpc.StreamInterceptor(grpcserver.AuthStreamInterceptor(authService, signatureVerifier, quotaService)),
+		grpc.MaxRecvMsgSize(int(cfg.MaxFileSize)+64*1024),
+	)
+	// segmentation.v1 is deprecated and served until clients have moved to v2
 	segmentationv1.RegisterSegmentationServiceServer(grpcSrv, grpcserver.NewSegmentationServer(segmentAgent))
+	segmentationv2.RegisterSegmentationServiceServer(grpcSrv, grpcserver.NewSegmentationServerV2(segmentAgent))
 	audiov1.RegisterAudioServiceServer(grpcSrv, grpcserver.NewAudioServer(audioAgent, storageClient))
-	imagev1.RegisterImageServiceServer(grpcSrv, grpcserver.NewImageServer(imageAgent, storageClient))
+	imageSrv := grpcserver.NewImageServer(imageAgent, storageClient)
+	imageSrv.SetOperations(operations)
+	imagev1.RegisterImageServiceServer(grpcSrv, imageSrv)
 	factcheckv1.RegisterFactCheckServiceServer(grpcSrv, grpcserver.NewFactCheckServer(factCheckAgent))
 
 	lis, err := net.Listen("tcp", cfg.GRPCAddr)
@@ -110,10 +233,19 @@ func main() {
 
 	// MCP HTTP server with auth
 	mcpSrv := mcpserver.NewServer(segmentAgent, imageAgent, factCheckAgent)
-	mcpHandler := mcpserver.AuthMiddleware(authService)(mcpSrv.Handler())
+	mcpSrv.SetOperations(operations)
+	mcpSrv.SetInlineImageLimit(cfg.AgentsMCPInlineImageLimit)
+	if storageClient != nil {
+		mcpSrv.SetStorage(storageClient)
+	}
+	mcpMux := http.NewServeMux()
+	mcpMux.Handle("/version", buildinfo.Handler(nil)) // unauthenticated, for operators
+	// extract_content arguments carry whole documents (up to MAX_FILE_SIZE) base64-encoded
+	mcpMaxBody := int64(base64.StdEncoding.EncodedLen(int(cfg.MaxFileSize))) + 64*1024
+	mcpMux.Handle("/", mcpserver.AuthMiddleware(authService, signatureVerifier, quotaService, mcpMaxBody)(mcpSrv.Handler()))
 	mcpHTTP := &http.Server{
 		Addr:         cfg.MCPAddr,
-		Handler:      mcpHandler,
+		Handler:      mcpMux,
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
 	}
@@ -151,6 +283,7 @@ func main() {
 	if err := mcpHTTP.Shutdown(ctx); err != nil {
 		log.Error().Err(err).Msg("MCP HTTP shutdown error")
 	}
+	operations.Stop(ctx)
 
 	log.Info().Msg("Agents exited")
 }
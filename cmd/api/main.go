@@ -9,62 +9,92 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
-	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/snappy-loop/stories/internal/agentsclient"
 	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/buildinfo"
+	"github.com/snappy-loop/stories/internal/cache"
 	"github.com/snappy-loop/stories/internal/config"
 	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/egress"
+	"github.com/snappy-loop/stories/internal/features"
 	"github.com/snappy-loop/stories/internal/handlers"
+	"github.com/snappy-loop/stories/internal/inputtype"
 	"github.com/snappy-loop/stories/internal/kafka"
+	"github.com/snappy-loop/stories/internal/leader"
+	"github.com/snappy-loop/stories/internal/llm"
+	"github.com/snappy-loop/stories/internal/logging"
+	"github.com/snappy-loop/stories/internal/maintenance"
+	"github.com/snappy-loop/stories/internal/proxy"
+	"github.com/snappy-loop/stories/internal/ratelimit"
 	"github.com/snappy-loop/stories/internal/services"
 	"github.com/snappy-loop/stories/internal/storage"
 	"github.com/snappy-loop/stories/migrations"
 )
 
 func main() {
-	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
-
-	logLevel := os.Getenv("LOG_LEVEL")
-	if logLevel == "" {
-		logLevel = "info"
-	}
-	level, err := zerolog.ParseLevel(logLevel)
+	cfg := config.Load()
+	closeLogs, err := logging.Setup("api", logging.Options{
+		Level: cfg.LogLevel, Levels: cfg.LogLevels, Format: cfg.LogFormat, File: cfg.LogFile, Syslog: cfg.LogSyslog,
+	})
 	if err != nil {
-		level = zerolog.InfoLevel
+		log.Fatal().Err(err).Msg("Invalid logging configuration")
 	}
-	zerolog.SetGlobalLevel(level)
+	defer closeLogs()
 
-	log.Info().Msg("Starting Stories API")
+	build := buildinfo.Get()
+	log.Info().Str("commit", build.Commit).Str("build_time", build.BuildTime).Str("go_version", build.GoVersion).
+		Msg("Starting Stories API")
 
-	cfg := config.Load()
+	if err := proxy.Validate(cfg.S3Proxy); err != nil {
+		log.Fatal().Err(err).Msg("Invalid S3_PROXY")
+	}
 
 	db, err := database.Connect(cfg.DatabaseURL)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to connect to database")
 	}
 	defer db.Close()
+	db.SetSlowQueryThreshold(cfg.SlowDBQueryThreshold)
 
 	if err := migrations.Run(db.SQLDB()); err != nil {
 		log.Fatal().Err(err).Msg("Failed to run migrations")
 	}
 
-	kafkaProducer := kafka.NewProducer(cfg.KafkaBrokers, cfg.KafkaTopicJobs)
+	// Jobs of enterprise tenants go to their pool's topic (KAFKA_TENANT_ROUTES)
+	tenantRouting, err := kafka.ParseTenantRouting(cfg.KafkaTenantRoutes, cfg.KafkaTenantTopicTemplate, cfg.KafkaTenantGroupTemplate)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid tenant routing")
+	}
+	kafkaProducer := kafka.NewTenantProducer(cfg.KafkaBrokers, cfg.KafkaTopicJobs, tenantRouting, database.NewJobRepository(db).GetOwner)
 	defer kafkaProducer.Close()
 
+	// Job events of jobs with require_review are published by the API when a reviewer approves them
+	webhookProducer := kafka.NewProducer(cfg.KafkaBrokers, cfg.KafkaTopicEvents)
+	defer webhookProducer.Close()
+
 	jobService := services.NewJobServiceFromDB(db, kafkaProducer, cfg)
+	inputTypes, err := inputtype.Parse(cfg.InputTypeProfiles)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid input type profiles")
+	}
+	jobService.SetInputTypes(inputTypes)
+	jobService.SetWebhookPublisher(webhookProducer)
 	storageClient, err := storage.NewClient(
 		cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket,
-		cfg.S3AccessKey, cfg.S3SecretKey, cfg.S3UseSSL, cfg.S3PublicURL,
+		cfg.S3AccessKey, cfg.S3SecretKey, cfg.S3UseSSL, cfg.S3PublicURL, cfg.S3Proxy,
 	)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to initialize storage client")
 	}
+	storageClient.SetSlowOpThreshold(cfg.SlowS3OpThreshold)
+	storageClient.SetUploadRetries(cfg.S3UploadRetries, cfg.S3UploadRetryDelay, cfg.S3UploadVerify)
+	storageClient.SetFallbackBucket(cfg.S3FallbackBucket)
+	jobService.SetAssetStorage(storageClient)
 	userRepo := database.NewUserRepository(db)
 	apiKeyRepo := database.NewAPIKeyRepository(db)
 	fileRepo := database.NewFileRepository(db)
-	fileService := services.NewFileService(fileRepo, storageClient, cfg.S3Bucket, cfg)
+	fileService := services.NewFileService(fileRepo, storageClient, cfg)
 
 	var agentsClient *agentsclient.Client
 	if cfg.AgentsGRPCURL != "" || cfg.AgentsMCPURL != "" {
@@ -92,38 +122,183 @@ func main() {
 		cfg.AgentsMCPURL,
 	)
 
+	notificationService := services.NewNotificationService(database.NewNotificationSinkRepository(db))
+	notificationService.SetEgress(egress.NewPolicy(cfg.EgressAllowHosts, cfg.EgressDenyHosts, cfg.EgressAllowPrivate), userRepo)
+	h.SetNotificationService(notificationService)
+	h.SetVoiceService(services.NewVoiceService(database.NewVoiceRepository(db), llm.GeminiVoiceProvider{}))
+	h.SetDisclaimerService(services.NewDisclaimerService(database.NewDisclaimerRepository(db)), cfg.AdminAPIToken)
+	rollouts, err := features.ParseRollouts(cfg.FeatureFlags)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid FEATURE_FLAGS")
+	}
+	featureFlagRepo := database.NewFeatureFlagRepository(db)
+	featureFlags := features.New(rollouts, featureFlagRepo, cfg.FeatureFlagsRefresh)
+	h.SetFeatureFlags(featureFlags, services.NewFeatureFlagService(featureFlagRepo, featureFlags))
+	maintenanceRepo := database.NewMaintenanceRepository(db)
+	maintenanceSwitch := maintenance.New(maintenanceRepo, cfg.MaintenanceRefresh)
+	h.SetMaintenance(maintenanceSwitch, services.NewMaintenanceService(maintenanceRepo, maintenanceSwitch))
+	h.SetStatsService(services.NewStatsService(database.NewJobRepository(db)))
+	h.SetUsageExportService(services.NewUsageExportService(database.NewUsageLedgerRepository(db)))
+	if cfg.LazyImages {
+		h.SetLazyImages(services.NewLazyImageService(database.NewAssetRepository(db), newImageGenerator(cfg), storageClient))
+	}
+
+	// S3 objects of deleted jobs are removed in the background (in the elected replica), with retries
+	storageCleanup := services.NewStorageCleanupService(database.NewStorageDeletionRepository(db), storageClient, cfg.StorageCleanupInterval)
+	cleanupCtx, stopCleanup := context.WithCancel(context.Background())
+	defer stopCleanup()
+	leader.Start(cleanupCtx, db.SQLDB(), "storage-cleanup", cfg.LeaderElectionInterval, storageCleanup.Start)
+	defer storageCleanup.Stop()
+
+	// Jobs whose retention (expires_at, retention_days) has ended are expired in the elected replica; their
+	// S3 objects go through the storage cleanup
+	jobExpiry := services.NewJobExpiryService(database.NewJobRepository(db), cfg.JobExpiryInterval)
+	leader.Start(cleanupCtx, db.SQLDB(), "job-expiry", cfg.LeaderElectionInterval, jobExpiry.Start)
+	defer jobExpiry.Stop()
+
+	// S3 drop-folder ingestion (INGEST_PREFIX): poll the prefix (in the elected replica) and accept S3 event
+	// notifications
+	if cfg.IngestPrefix != "" {
+		ingestService, err := services.NewIngestService(db, fileService, jobService, storageClient, cfg)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid S3 ingestion configuration")
+		}
+		ingestCtx, stopIngest := context.WithCancel(context.Background())
+		defer stopIngest()
+		ingestService.SetMaintenance(maintenanceSwitch)
+		leader.Start(ingestCtx, db.SQLDB(), "s3-ingest-poll", cfg.LeaderElectionInterval, ingestService.Start)
+		defer ingestService.Stop()
+		h.SetIngestService(ingestService, cfg.IngestWebhookToken)
+	}
+
 	authService := auth.NewService(db)
 
+	// Per-key rate limits, kept in Redis with LIMITS_BACKEND=redis so all replicas share them
+	if err := ratelimit.CheckBackend(cfg.LimitsBackend, cfg.RedisURL); err != nil {
+		log.Fatal().Err(err).Msg("Invalid limits configuration")
+	}
+	var limiter ratelimit.Limiter
+	if cfg.RateLimitPerMinute > 0 {
+		if cfg.LimitsBackend == ratelimit.BackendRedis {
+			redisClient, err := cache.NewRedis(cfg.RedisURL, 16)
+			if err != nil {
+				log.Fatal().Err(err).Msg("Invalid REDIS_URL")
+			}
+			defer redisClient.Close()
+			if err := redisClient.Ping(context.Background()); err != nil {
+				log.Warn().Err(err).Msg("Redis not reachable; requests are not rate limited until it is")
+			}
+			limiter = ratelimit.NewRedisLimiter(redisClient, "limits:rate:", cfg.RateLimitPerMinute, cfg.RateLimitBurst)
+		} else {
+			limiter = ratelimit.NewMemoryLimiter(cfg.RateLimitPerMinute, cfg.RateLimitBurst)
+		}
+	}
+
 	r := mux.NewRouter()
+	// Deadlines are per route: ordinary endpoints are bounded by HTTP_REQUEST_TIMEOUT, uploads, downloads
+	// and the agents WebSocket (handlers.Upload, Stream, Unbounded) get longer ones
+	r.Use(handlers.Timeouts{
+		Request: cfg.HTTPRequestTimeout,
+		Upload:  cfg.HTTPUploadTimeout,
+		Stream:  cfg.HTTPStreamTimeout,
+	}.Middleware)
+	r.Handle("/version", buildinfo.Handler(featureFlags.Rollouts)).Methods("GET", "HEAD")
 	r.HandleFunc("/", h.Index).Methods("GET")
 	r.HandleFunc("/generation", h.Generation).Methods("GET")
 	r.HandleFunc("/agents", h.AgentsPage).Methods("GET")
-	r.HandleFunc("/agents/ws", h.AgentsWS)
+	r.Handle("/agents/ws", handlers.Unbounded(h.AgentsWS))
 	r.HandleFunc("/agents/call", h.AgentsCall).Methods("POST")
 	// POST /users (CreateUser) not registered; handler kept for later use
-	r.HandleFunc("/view/asset/{id}", h.ViewAsset).Methods("GET")
+	r.Handle("/view/asset/{id}", handlers.Stream(h.ViewAsset)).Methods("GET")
+	r.HandleFunc("/view/asset/{id}/peaks", h.ViewAssetPeaks).Methods("GET")
 	r.HandleFunc("/view/{id}", h.ViewJob).Methods("GET")
+	r.HandleFunc("/view/{id}/podcast.rss", h.ViewPodcastFeed).Methods("GET")
+	r.HandleFunc("/ingest/s3-events", h.IngestS3Events).Methods("POST")
+	r.HandleFunc("/admin/disclaimers", h.ListDisclaimers).Methods("GET")
+	r.HandleFunc("/admin/disclaimers/{jurisdiction}", h.ListDisclaimerVersions).Methods("GET")
+	r.HandleFunc("/admin/disclaimers/{jurisdiction}", h.PutDisclaimer).Methods("PUT")
+	r.HandleFunc("/admin/disclaimers/{jurisdiction}", h.RetireDisclaimer).Methods("DELETE")
+	r.HandleFunc("/admin/maintenance", h.GetMaintenance).Methods("GET")
+	r.HandleFunc("/admin/maintenance", h.PutMaintenance).Methods("PUT")
+	r.HandleFunc("/admin/stats", h.GetAdminStats).Methods("GET")
+	r.HandleFunc("/admin/usage/export", h.ExportUsage).Methods("GET")
+	r.HandleFunc("/admin/feature-flags", h.ListFeatureFlags).Methods("GET")
+	r.HandleFunc("/admin/feature-flags/{name}", h.PutFeatureFlag).Methods("PUT")
+	r.HandleFunc("/admin/feature-flags/{name}", h.DeleteFeatureFlag).Methods("DELETE")
+	r.HandleFunc("/admin/feature-flags/{name}/users/{user_id}", h.PutFeatureFlagOverride).Methods("PUT")
+	r.HandleFunc("/admin/feature-flags/{name}/users/{user_id}", h.DeleteFeatureFlagOverride).Methods("DELETE")
 
 	api := r.PathPrefix("/v1").Subrouter()
 	api.Use(authService.Middleware)
+	if limiter != nil {
+		api.Use(handlers.RateLimit{Limiter: limiter, PerMinute: cfg.RateLimitPerMinute}.Middleware)
+	}
 	api.HandleFunc("/jobs", h.CreateJob).Methods("POST")
 	api.HandleFunc("/jobs/{id}", h.GetJob).Methods("GET")
+	api.HandleFunc("/jobs/{id}", h.UpdateJob).Methods("PATCH")
+	api.HandleFunc("/jobs/{id}", h.DeleteJob).Methods("DELETE")
+	api.HandleFunc("/jobs/{id}/clone", h.CloneJob).Methods("POST")
+	api.HandleFunc("/jobs/{id}/cancel", h.CancelJob).Methods("POST")
+	api.HandleFunc("/jobs/{id}/retry", h.RetryJob).Methods("POST")
+	api.HandleFunc("/jobs/{id}/segments", h.ListJobSegments).Methods("GET")
+	api.HandleFunc("/jobs/{id}/segments/{idx}", h.UpdateSegment).Methods("PATCH")
+	api.HandleFunc("/jobs/{id}/assets", h.ListJobAssets).Methods("GET")
+	api.HandleFunc("/jobs/{id}/events", h.ListJobEvents).Methods("GET")
+	api.HandleFunc("/jobs/{id}/review/approve", h.ApproveJob).Methods("POST")
+	api.HandleFunc("/jobs/{id}/review/regenerate", h.RegenerateJobSegments).Methods("POST")
+	api.HandleFunc("/jobs/{id}/versions", h.ListOutputVersions).Methods("GET")
+	api.HandleFunc("/jobs/{id}/versions/{version}", h.GetOutputVersion).Methods("GET")
+	api.HandleFunc("/jobs/{id}/versions/{version}/restore", h.RestoreOutputVersion).Methods("POST")
+	api.HandleFunc("/jobs/{id}/script", h.GetJobScript).Methods("GET")
+	api.HandleFunc("/jobs/{id}/narration-diff", h.GetNarrationDiff).Methods("GET")
 	api.HandleFunc("/jobs", h.ListJobs).Methods("GET")
-	api.HandleFunc("/files", h.UploadFile).Methods("POST")
+	api.Handle("/files", handlers.Upload(h.UploadFile)).Methods("POST")
 	api.HandleFunc("/files", h.ListFiles).Methods("GET")
+	api.HandleFunc("/files/presign", h.PresignFile).Methods("POST")
+	api.HandleFunc("/files/{id}/confirm", h.ConfirmFile).Methods("POST")
 	api.HandleFunc("/files/{id}", h.DeleteFile).Methods("DELETE")
 	api.HandleFunc("/assets/{id}", h.GetAsset).Methods("GET")
-	api.HandleFunc("/assets/{id}/content", h.GetAssetContent).Methods("GET")
+	api.Handle("/assets/{id}/content", handlers.Stream(h.GetAssetContent)).Methods("GET")
+	api.Handle("/assets/{id}/content", handlers.Upload(h.ReplaceAssetContent)).Methods("PUT")
+	api.HandleFunc("/notification-sinks", h.CreateNotificationSink).Methods("POST")
+	api.HandleFunc("/notification-sinks", h.ListNotificationSinks).Methods("GET")
+	api.HandleFunc("/notification-sinks/{id}", h.DeleteNotificationSink).Methods("DELETE")
+	api.HandleFunc("/email-notifications", h.GetEmailNotifications).Methods("GET")
+	api.HandleFunc("/email-notifications", h.UpdateEmailNotifications).Methods("PUT")
+	api.HandleFunc("/lexicon", h.GetLexicon).Methods("GET")
+	api.HandleFunc("/lexicon", h.UpdateLexicon).Methods("PUT")
+	api.HandleFunc("/settings", h.GetSettings).Methods("GET")
+	api.HandleFunc("/settings", h.UpdateSettings).Methods("PUT")
+	api.HandleFunc("/egress-allowlist", h.GetEgressAllowlist).Methods("GET")
+	api.HandleFunc("/egress-allowlist", h.UpdateEgressAllowlist).Methods("PUT")
+	api.HandleFunc("/webhook-deliveries/{id}", h.GetWebhookDelivery).Methods("GET")
+	api.HandleFunc("/webhook-deliveries/{id}/redeliver", h.RedeliverWebhook).Methods("POST")
+	api.HandleFunc("/voices", h.CreateVoice).Methods("POST")
+	api.HandleFunc("/voices", h.ListVoices).Methods("GET")
+	api.HandleFunc("/voices/{id}", h.DeleteVoice).Methods("DELETE")
+	api.HandleFunc("/stats", h.GetStats).Methods("GET")
 
+	// HTTP/1.1 with keep-alive, plus h2c when enabled (TLS is terminated in front of the API)
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(cfg.HTTPH2C)
+	conns := handlers.NewConnMetrics()
 	srv := &http.Server{
-		Addr:         cfg.HTTPAddr,
-		Handler:      r,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+		Addr:              cfg.HTTPAddr,
+		Handler:           r,
+		ReadHeaderTimeout: 15 * time.Second,
+		IdleTimeout:       cfg.HTTPIdleTimeout,
+		Protocols:         protocols,
+		ConnState:         conns.ConnState,
+	}
+	if cfg.HTTPConnMetricsInterval > 0 {
+		metricsCtx, stopMetrics := context.WithCancel(context.Background())
+		defer stopMetrics()
+		go conns.Run(metricsCtx, cfg.HTTPConnMetricsInterval)
 	}
 
 	go func() {
-		log.Info().Str("addr", cfg.HTTPAddr).Msg("API listening")
+		log.Info().Str("addr", cfg.HTTPAddr).Bool("h2c", cfg.HTTPH2C).Msg("API listening")
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatal().Err(err).Msg("Server failed")
 		}
@@ -141,3 +316,39 @@ func main() {
 	}
 	log.Info().Msg("API exited")
 }
+
+// newImageGenerator returns the Gemini client generating the images of lazy_images jobs (LAZY_IMAGES).
+func newImageGenerator(cfg *config.Config) *llm.Client {
+	var vertexAI *llm.VertexAI
+	switch cfg.GeminiBackend {
+	case "gemini":
+	case "vertex":
+		if cfg.VertexProject == "" {
+			log.Fatal().Msg("VERTEX_PROJECT is required when GEMINI_BACKEND=vertex")
+		}
+		vertexAI = &llm.VertexAI{Project: cfg.VertexProject, Location: cfg.VertexLocation}
+	default:
+		log.Fatal().Str("backend", cfg.GeminiBackend).Msg("Invalid GEMINI_BACKEND (want gemini or vertex)")
+	}
+	client := llm.NewClient(
+		cfg.GeminiAPIKey,
+		vertexAI,
+		cfg.GeminiModelFlash,
+		cfg.GeminiModelPro,
+		cfg.GeminiModelImage,
+		cfg.GeminiModelTTS,
+		cfg.GeminiTTSVoice,
+		cfg.GeminiAPIEndpoint,
+		cfg.GeminiModelSegmentPrimary,
+		cfg.GeminiModelSegmentFallback,
+		nil,
+		cfg.GeminiProxy,
+	)
+	client.SetConcurrencyLimits(map[string]int{llm.ModelFamilyImage: cfg.GeminiMaxConcurrentImage})
+	client.SetAdaptiveRateLimit(cfg.GeminiAdaptiveRateLimit)
+	client.SetSlowCallThreshold(cfg.SlowLLMCallThreshold)
+	if err := client.SetContentLogging(cfg.GeminiLogContent, cfg.GeminiLogSampleRate); err != nil {
+		log.Fatal().Err(err).Msg("Invalid GEMINI_LOG_CONTENT or GEMINI_LOG_SAMPLE_RATE")
+	}
+	return client
+}
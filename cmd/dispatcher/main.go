@@ -2,55 +2,48 @@ package main
 
 import (
 	"context"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
 	"time"
 
-	"github.com/rs/zerolog"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/buildinfo"
 	"github.com/snappy-loop/stories/internal/config"
 	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/egress"
 	"github.com/snappy-loop/stories/internal/kafka"
+	"github.com/snappy-loop/stories/internal/leader"
+	"github.com/snappy-loop/stories/internal/logging"
+	"github.com/snappy-loop/stories/internal/metering"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/notify"
+	"github.com/snappy-loop/stories/internal/proxy"
 	"github.com/snappy-loop/stories/internal/webhook"
 	"github.com/snappy-loop/stories/migrations"
 )
 
-// WebhookHandler implements kafka.MessageHandler
-type WebhookHandler struct {
-	deliveryService *webhook.DeliveryService
-}
-
-func (h *WebhookHandler) HandleMessage(ctx context.Context, msg *kafka.WebhookMessage) error {
-	log.Info().
-		Str("job_id", msg.JobID.String()).
-		Str("event", msg.Event).
-		Msg("Processing webhook event")
-
-	// Deliver webhook for the job
-	return h.deliveryService.DeliverWebhook(ctx, msg.JobID)
-}
-
 func main() {
-	// Setup logging
-	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
-
-	logLevel := os.Getenv("LOG_LEVEL")
-	if logLevel == "" {
-		logLevel = "info"
-	}
-	level, err := zerolog.ParseLevel(logLevel)
+	// Load configuration and set up logging
+	cfg := config.Load()
+	closeLogs, err := logging.Setup("dispatcher", logging.Options{
+		Level: cfg.LogLevel, Levels: cfg.LogLevels, Format: cfg.LogFormat, File: cfg.LogFile, Syslog: cfg.LogSyslog,
+	})
 	if err != nil {
-		level = zerolog.InfoLevel
+		log.Fatal().Err(err).Msg("Invalid logging configuration")
 	}
-	zerolog.SetGlobalLevel(level)
+	defer closeLogs()
 
-	log.Info().Msg("Starting Stories Webhook Dispatcher")
+	build := buildinfo.Get()
+	log.Info().Str("commit", build.Commit).Str("build_time", build.BuildTime).Str("go_version", build.GoVersion).
+		Msg("Starting Stories Webhook Dispatcher")
 
-	// Load configuration
-	cfg := config.Load()
+	if err := proxy.Validate(cfg.WebhookProxy); err != nil {
+		log.Fatal().Err(err).Msg("Invalid WEBHOOK_PROXY")
+	}
 
 	// Initialize database connection
 	db, err := database.Connect(cfg.DatabaseURL)
@@ -58,46 +51,84 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to connect to database")
 	}
 	defer db.Close()
+	db.SetSlowQueryThreshold(cfg.SlowDBQueryThreshold)
 
 	if err := migrations.Run(db.SQLDB()); err != nil {
 		log.Fatal().Err(err).Msg("Failed to run migrations")
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	// Initialize webhook delivery service
 	deliveryService := webhook.NewDeliveryService(db, cfg)
 
-	// Create webhook handler
-	handler := &WebhookHandler{
-		deliveryService: deliveryService,
+	// Route job events to job webhooks and users' notification sinks
+	router := notify.NewRouter(db, cfg, deliveryService)
+	sinkClient := &http.Client{Timeout: 30 * time.Second}
+	// Chat sinks post to user-supplied URLs, so they go through the egress policy like job webhooks
+	chatEgress := egress.NewPolicy(cfg.EgressAllowHosts, cfg.EgressDenyHosts, cfg.EgressAllowPrivate)
+	chatEgress.SetProxy(proxy.Func(cfg.WebhookProxy))
+	chatClient := chatEgress.Client(30 * time.Second)
+	router.Register(models.SinkTypeWebhook, notify.NewWebhookSink(deliveryService))
+	router.Register(models.SinkTypeSlack, notify.NewSlackSink(chatClient))
+	router.Register(models.SinkTypeTeams, notify.NewTeamsSink(chatClient))
+	if cfg.SMTPHost != "" {
+		router.Register(models.SinkTypeEmail, notify.NewEmailSink(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom))
+	} else {
+		log.Warn().Msg("SMTP_HOST not set, email notification sinks will fail")
+	}
+	if awsCfg, err := awsconfig.LoadDefaultConfig(ctx); err != nil {
+		log.Warn().Err(err).Msg("AWS configuration not available, SNS notification sinks will fail")
+	} else {
+		router.Register(models.SinkTypeSNS, notify.NewSNSSink(awsCfg.Credentials, cfg.SNSEndpoint, sinkClient))
 	}
 
-	// Initialize Kafka consumer for webhook events
-	consumer := kafka.NewConsumer(
-		cfg.KafkaBrokers,
-		cfg.KafkaTopicWebhooks,
-		"webhook-dispatcher",
-		handler,
-	)
-	defer consumer.Close()
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	// Consume the events topic, and drain events published to the legacy webhooks topic before upgrading
+	// (with its existing group, so its committed offsets still apply)
+	consumers := []*kafka.Consumer{
+		kafka.NewConsumer(cfg.KafkaBrokers, cfg.KafkaTopicEvents, "event-router", router),
+		kafka.NewConsumer(cfg.KafkaBrokers, cfg.KafkaTopicWebhooks, "webhook-dispatcher", router),
+	}
+	for _, c := range consumers {
+		defer c.Close()
+	}
 
-	// Start retry worker for failed webhook deliveries
-	deliveryService.Start(ctx)
+	// Start retry workers for failed webhook and notification deliveries, in the elected replica only
+	leader.Start(ctx, db.SQLDB(), "webhook-retries", cfg.LeaderElectionInterval, deliveryService.Start)
 	defer deliveryService.Stop()
+	leader.Start(ctx, db.SQLDB(), "notification-retries", cfg.LeaderElectionInterval, router.Start)
+	defer router.Stop()
+
+	// Report usage to Stripe metered billing (STRIPE_API_KEY), in the elected replica only
+	meteringConfig := metering.Config{
+		APIKey:            cfg.StripeAPIKey,
+		APIURL:            cfg.StripeAPIURL,
+		Interval:          cfg.StripeReportInterval,
+		JobsEvent:         cfg.StripeMeterJobs,
+		CharsEvent:        cfg.StripeMeterChars,
+		AudioSecondsEvent: cfg.StripeMeterAudioSeconds,
+	}
+	if err := meteringConfig.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("Invalid STRIPE_API_URL")
+	}
+	stripeReporter := metering.NewReporter(meteringConfig, database.NewUsageLedgerRepository(db))
+	leader.Start(ctx, db.SQLDB(), "stripe-usage", cfg.LeaderElectionInterval, stripeReporter.Start)
+	defer stripeReporter.Stop()
 
-	// Start Kafka consumer in goroutine
+	// Start Kafka consumers in goroutines
 	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		if err := consumer.Start(ctx); err != nil && err != context.Canceled {
-			log.Error().Err(err).Msg("Kafka consumer error")
-		}
-	}()
+	for _, c := range consumers {
+		wg.Add(1)
+		go func(c *kafka.Consumer) {
+			defer wg.Done()
+			if err := c.Start(ctx); err != nil && err != context.Canceled {
+				log.Error().Err(err).Msg("Kafka consumer error")
+			}
+		}(c)
+	}
 
-	log.Info().Msg("Dispatcher started, waiting for webhook events...")
+	log.Info().Msg("Dispatcher started, waiting for job events...")
 
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
@@ -0,0 +1,336 @@
+// Command loadgen drives a running stack through its public API: it creates jobs at a configurable
+// concurrency and rate, polls each until it finishes, and reports latency percentiles and error rates
+// for job creation, status polls and end-to-end completion. With -max-p95 or -max-error-rate it exits
+// non-zero when the run is over budget, so it can gate a deployment or a nightly performance check.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+const defaultText = `The Sun is a star at the center of the Solar System. It is a nearly perfect ball of hot plasma, heated by nuclear fusion in its core.
+
+Eight planets orbit the Sun. The four inner planets are small and rocky, while the four outer planets are giants made mostly of gas and ice.
+
+Earth is the third planet from the Sun and the only one known to support life. Its atmosphere and liquid water make it unique in the Solar System.`
+
+// options are the command-line flags.
+type options struct {
+	url          string
+	apiKey       string
+	jobs         int
+	concurrency  int
+	rate         float64
+	text         string
+	inputType    string
+	segments     int
+	audioType    string
+	strategy     string
+	pollInterval time.Duration
+	jobTimeout   time.Duration
+	maxP95       time.Duration
+	maxErrorRate float64
+}
+
+// results collects the measurements of a run; safe for concurrent use.
+type results struct {
+	mu           sync.Mutex
+	create       []time.Duration // POST /v1/jobs latency of successful requests
+	poll         []time.Duration // GET /v1/jobs/{id} latency of successful requests
+	completion   []time.Duration // creation to terminal status of succeeded jobs
+	createErrors int
+	pollErrors   int
+	statuses     map[string]int // final status per job; "timeout" when -job-timeout passed first
+}
+
+func main() {
+	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+
+	var opts options
+	var textFile string
+	flag.StringVar(&opts.url, "url", getEnv("STORIES_API_URL", "http://localhost:8080"), "API base URL (STORIES_API_URL)")
+	flag.StringVar(&opts.apiKey, "api-key", os.Getenv("STORIES_API_KEY"), "API key (STORIES_API_KEY); create one with storiesctl create-user")
+	flag.IntVar(&opts.jobs, "jobs", 20, "total jobs to create")
+	flag.IntVar(&opts.concurrency, "concurrency", 4, "jobs in flight at once")
+	flag.Float64Var(&opts.rate, "rate", 0, "maximum job creations per second (0 = as fast as -concurrency allows)")
+	flag.StringVar(&opts.text, "text", defaultText, "job input text")
+	flag.StringVar(&textFile, "text-file", "", "read the job input text from this file instead of -text")
+	flag.StringVar(&opts.inputType, "type", "educational", "job type (educational, financial, fictional)")
+	flag.IntVar(&opts.segments, "segments", 3, "segments per job")
+	flag.StringVar(&opts.audioType, "audio-type", "free_speech", "audio type (free_speech, podcast)")
+	flag.StringVar(&opts.strategy, "segmentation-strategy", "", "segmentation strategy (llm, heuristic; default the server's)")
+	flag.DurationVar(&opts.pollInterval, "poll-interval", 2*time.Second, "delay between status polls of a job")
+	flag.DurationVar(&opts.jobTimeout, "job-timeout", 10*time.Minute, "stop polling a job that has not finished after this long")
+	flag.DurationVar(&opts.maxP95, "max-p95", 0, "fail when the p95 job completion time exceeds this (0 = no budget)")
+	flag.Float64Var(&opts.maxErrorRate, "max-error-rate", -1, "fail when the share of jobs that errored, failed or timed out exceeds this, 0-1 (negative = no budget)")
+	flag.Parse()
+
+	if opts.apiKey == "" {
+		log.Fatal().Msg("-api-key or STORIES_API_KEY is required")
+	}
+	if opts.jobs < 1 || opts.concurrency < 1 {
+		log.Fatal().Msg("-jobs and -concurrency must be at least 1")
+	}
+	if textFile != "" {
+		b, err := os.ReadFile(textFile)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to read -text-file")
+		}
+		opts.text = string(b)
+	}
+	opts.url = strings.TrimRight(opts.url, "/")
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	log.Info().
+		Str("url", opts.url).
+		Int("jobs", opts.jobs).
+		Int("concurrency", opts.concurrency).
+		Float64("rate", opts.rate).
+		Msg("Starting load run")
+
+	start := time.Now()
+	res := run(ctx, &opts)
+	elapsed := time.Since(start)
+
+	res.print(os.Stdout, opts.jobs, elapsed)
+	if violations := res.checkBudget(&opts); len(violations) > 0 {
+		for _, v := range violations {
+			fmt.Fprintf(os.Stderr, "budget exceeded: %s\n", v)
+		}
+		os.Exit(1)
+	}
+}
+
+// run creates opts.jobs jobs with opts.concurrency workers and polls each to completion.
+func run(ctx context.Context, opts *options) *results {
+	res := &results{statuses: make(map[string]int)}
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	var ticker *time.Ticker
+	if opts.rate > 0 {
+		ticker = time.NewTicker(time.Duration(float64(time.Second) / opts.rate))
+		defer ticker.Stop()
+	}
+
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range work {
+				runJob(ctx, client, opts, res)
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < opts.jobs; i++ {
+		if ticker != nil {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				break feed
+			}
+		}
+		select {
+		case work <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+	return res
+}
+
+// runJob creates one job and polls it until it reaches a terminal status or opts.jobTimeout passes.
+func runJob(ctx context.Context, client *http.Client, opts *options, res *results) {
+	req := &models.CreateJobRequest{
+		Text:                 opts.text,
+		Type:                 opts.inputType,
+		SegmentsCount:        opts.segments,
+		AudioType:            opts.audioType,
+		SegmentationStrategy: opts.strategy,
+		Tags:                 []string{"loadgen"},
+	}
+	var created models.CreateJobResponse
+	start := time.Now()
+	if err := doJSON(ctx, client, opts, http.MethodPost, "/v1/jobs", req, &created); err != nil {
+		log.Warn().Err(err).Msg("Failed to create job")
+		res.mu.Lock()
+		res.createErrors++
+		res.mu.Unlock()
+		return
+	}
+	res.mu.Lock()
+	res.create = append(res.create, time.Since(start))
+	res.mu.Unlock()
+
+	deadline := time.Now().Add(opts.jobTimeout)
+	for {
+		select {
+		case <-time.After(opts.pollInterval):
+		case <-ctx.Done():
+			res.finish("interrupted", 0)
+			return
+		}
+
+		var status models.JobStatusResponse
+		pollStart := time.Now()
+		err := doJSON(ctx, client, opts, http.MethodGet, "/v1/jobs/"+created.JobID.String(), nil, &status)
+		res.mu.Lock()
+		if err != nil {
+			res.pollErrors++
+		} else {
+			res.poll = append(res.poll, time.Since(pollStart))
+		}
+		res.mu.Unlock()
+		if err != nil {
+			log.Warn().Err(err).Str("job_id", created.JobID.String()).Msg("Failed to poll job")
+		} else if models.IsTerminalJobStatus(status.Job.Status) {
+			res.finish(status.Job.Status, time.Since(start))
+			return
+		}
+
+		if time.Now().After(deadline) {
+			log.Warn().Str("job_id", created.JobID.String()).Msg("Job did not finish within -job-timeout")
+			res.finish("timeout", 0)
+			return
+		}
+	}
+}
+
+// finish records a job's final status, and its completion time when it succeeded.
+func (r *results) finish(status string, completion time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statuses[status]++
+	if status == models.JobStatusSucceeded {
+		r.completion = append(r.completion, completion)
+	}
+}
+
+// doJSON sends body (if any) as JSON and decodes a 2xx response into out.
+func doJSON(ctx context.Context, client *http.Client, opts *options, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, opts.url+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+opts.apiKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: status %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// errorRate is the share of attempted jobs that could not be created, failed or timed out.
+func (r *results) errorRate(total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	bad := r.createErrors + r.statuses[models.JobStatusFailed] + r.statuses["timeout"]
+	return float64(bad) / float64(total)
+}
+
+func (r *results) print(w io.Writer, total int, elapsed time.Duration) {
+	fmt.Fprintf(w, "jobs:        %d attempted in %s (%.2f jobs/s)\n", total, elapsed.Round(time.Millisecond), float64(total)/elapsed.Seconds())
+	statuses := make([]string, 0, len(r.statuses))
+	for s := range r.statuses {
+		statuses = append(statuses, s)
+	}
+	sort.Strings(statuses)
+	for _, s := range statuses {
+		fmt.Fprintf(w, "  %-10s %d\n", s, r.statuses[s])
+	}
+	fmt.Fprintf(w, "errors:      %d create, %d poll; error rate %.1f%%\n", r.createErrors, r.pollErrors, 100*r.errorRate(total))
+	printLatencies(w, "create", r.create)
+	printLatencies(w, "poll", r.poll)
+	printLatencies(w, "completion", r.completion)
+}
+
+func printLatencies(w io.Writer, name string, d []time.Duration) {
+	if len(d) == 0 {
+		fmt.Fprintf(w, "%-12s no samples\n", name+":")
+		return
+	}
+	sorted := append([]time.Duration(nil), d...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	fmt.Fprintf(w, "%-12s n=%d p50=%s p90=%s p95=%s p99=%s max=%s\n", name+":", len(sorted),
+		percentile(sorted, 50), percentile(sorted, 90), percentile(sorted, 95), percentile(sorted, 99), sorted[len(sorted)-1])
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted, rounded to the millisecond.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(float64(len(sorted))*p/100)) - 1
+	rank = max(0, min(rank, len(sorted)-1))
+	return sorted[rank].Round(time.Millisecond)
+}
+
+// checkBudget returns the budgets the run exceeded.
+func (r *results) checkBudget(opts *options) []string {
+	var violations []string
+	if opts.maxP95 > 0 {
+		sorted := append([]time.Duration(nil), r.completion...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		if len(sorted) == 0 {
+			violations = append(violations, "no job completed, p95 completion time unknown")
+		} else if p95 := percentile(sorted, 95); p95 > opts.maxP95 {
+			violations = append(violations, fmt.Sprintf("p95 completion %s > %s", p95, opts.maxP95))
+		}
+	}
+	if opts.maxErrorRate >= 0 {
+		if rate := r.errorRate(opts.jobs); rate > opts.maxErrorRate {
+			violations = append(violations, fmt.Sprintf("error rate %.3f > %.3f", rate, opts.maxErrorRate))
+		}
+	}
+	return violations
+}
+
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
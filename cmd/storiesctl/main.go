@@ -0,0 +1,459 @@
+// Command storiesctl performs administrative operations directly against the database and Kafka:
+// requeueing stuck jobs, reprocessing jobs with a different segmentation model, creating users and
+// API keys, capping API keys' LLM cost, linking users to Stripe customers, and dumping job diagnostics.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/config"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/kafka"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+const usage = `Usage: storiesctl <command> [flags]
+
+Commands:
+  requeue              republish jobs stuck in queued/running
+  reprocess            reset and republish jobs created in a time range, optionally with a new segmentation model
+  create-user          create a user and an API key
+  create-key           create an API key for an existing user
+  set-cost-cap         set or remove the estimated LLM cost an API key may use per quota period
+  set-stripe-customer  link a user to a Stripe customer for metered billing, or unlink it
+  set-scopes           restrict an API key to the REST API and/or specific agent tools, or lift it
+  inspect              print job diagnostics (job, segments, assets, files, events, webhook deliveries) as JSON
+
+Run "storiesctl <command> -h" for command flags.
+`
+
+func main() {
+	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+
+	logLevel := os.Getenv("LOG_LEVEL")
+	if logLevel == "" {
+		logLevel = "info"
+	}
+	level, err := zerolog.ParseLevel(logLevel)
+	if err != nil {
+		level = zerolog.InfoLevel
+	}
+	zerolog.SetGlobalLevel(level)
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	commands := map[string]func(ctx context.Context, cfg *config.Config, db *database.DB, args []string) error{
+		"requeue":             runRequeue,
+		"reprocess":           runReprocess,
+		"create-user":         runCreateUser,
+		"create-key":          runCreateKey,
+		"set-cost-cap":        runSetCostCap,
+		"set-stripe-customer": runSetStripeCustomer,
+		"set-scopes":          runSetScopes,
+		"inspect":             runInspect,
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	cfg := config.Load()
+
+	db, err := database.Connect(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to connect to database")
+	}
+	defer db.Close()
+
+	if err := cmd(context.Background(), cfg, db, os.Args[2:]); err != nil {
+		log.Error().Err(err).Str("command", os.Args[1]).Msg("Command failed")
+		db.Close()
+		os.Exit(1)
+	}
+}
+
+// runRequeue republishes jobs that have sat in queued or running for longer than -older-than, except jobs
+// whose retention has ended. The worker treats a running job as a crashed attempt and restarts it from scratch.
+func runRequeue(ctx context.Context, cfg *config.Config, db *database.DB, args []string) error {
+	fs := flag.NewFlagSet("requeue", flag.ExitOnError)
+	statuses := fs.String("status", "queued,running", "comma-separated job statuses to consider (queued, running)")
+	olderThan := fs.Duration("older-than", 30*time.Minute, "only jobs with no progress for at least this long")
+	limit := fs.Int("limit", 100, "maximum jobs per status")
+	dryRun := fs.Bool("dry-run", false, "list matching jobs without publishing")
+	fs.Parse(args)
+
+	jobRepo := database.NewJobRepository(db)
+	before := time.Now().Add(-*olderThan)
+
+	var jobs []*models.Job
+	for _, status := range strings.Split(*statuses, ",") {
+		status = strings.TrimSpace(status)
+		if status != "queued" && status != "running" {
+			return fmt.Errorf("invalid status %q: must be queued or running", status)
+		}
+		found, err := jobRepo.ListStuck(ctx, status, before, *limit)
+		if err != nil {
+			return fmt.Errorf("failed to list %s jobs: %w", status, err)
+		}
+		// Jobs whose retention has ended would expire as soon as they finish; running them again is wasted.
+		for _, job := range found {
+			if !job.Expired(time.Now()) {
+				jobs = append(jobs, job)
+			}
+		}
+	}
+
+	printJobs(jobs)
+	if *dryRun || len(jobs) == 0 {
+		fmt.Printf("%d job(s) matched\n", len(jobs))
+		return nil
+	}
+
+	producer, err := newJobsProducer(cfg, db)
+	if err != nil {
+		return err
+	}
+	defer producer.Close()
+	eventRepo := database.NewJobEventRepository(db)
+
+	published := 0
+	for _, job := range jobs {
+		if err := producer.PublishJob(ctx, job.ID, ""); err != nil {
+			log.Error().Err(err).Str("job_id", job.ID.String()).Msg("Failed to republish job")
+			continue
+		}
+		recordRequeued(ctx, eventRepo, job.ID, "requeued stuck "+job.Status+" job", nil)
+		published++
+	}
+	fmt.Printf("%d of %d job(s) republished\n", published, len(jobs))
+	return nil
+}
+
+// runReprocess resets jobs created in [-from, -to) to queued (dropping segments, assets and markup)
+// and republishes them, optionally asking the worker to segment with -model. Expired jobs are skipped.
+func runReprocess(ctx context.Context, cfg *config.Config, db *database.DB, args []string) error {
+	fs := flag.NewFlagSet("reprocess", flag.ExitOnError)
+	fromStr := fs.String("from", "", "start of created_at range, RFC3339 (required)")
+	toStr := fs.String("to", "", "end of created_at range, RFC3339 (default now)")
+	status := fs.String("status", "", "only jobs in this status: succeeded, failed or canceled (default any of them)")
+	model := fs.String("model", "", "segmentation model to use instead of the configured ones")
+	limit := fs.Int("limit", 500, "maximum jobs to reprocess")
+	dryRun := fs.Bool("dry-run", false, "list matching jobs without resetting or publishing")
+	fs.Parse(args)
+
+	if *fromStr == "" {
+		return fmt.Errorf("-from is required")
+	}
+	from, err := time.Parse(time.RFC3339, *fromStr)
+	if err != nil {
+		return fmt.Errorf("invalid -from: %w", err)
+	}
+	to := time.Now()
+	if *toStr != "" {
+		if to, err = time.Parse(time.RFC3339, *toStr); err != nil {
+			return fmt.Errorf("invalid -to: %w", err)
+		}
+	}
+	if !to.After(from) {
+		return fmt.Errorf("-to must be after -from")
+	}
+
+	if *status != "" && !models.IsTerminalJobStatus(*status) {
+		return fmt.Errorf("invalid -status %q: only finished jobs can be reprocessed; use requeue for queued/running jobs", *status)
+	}
+
+	jobRepo := database.NewJobRepository(db)
+	found, err := jobRepo.ListCreatedBetween(ctx, from, to, *status, *limit)
+	if err != nil {
+		return fmt.Errorf("failed to list jobs: %w", err)
+	}
+	// In-flight jobs are left alone; use requeue for those.
+	var jobs []*models.Job
+	for _, job := range found {
+		if models.IsTerminalJobStatus(job.Status) {
+			jobs = append(jobs, job)
+		}
+	}
+
+	printJobs(jobs)
+	if *dryRun || len(jobs) == 0 {
+		fmt.Printf("%d job(s) matched\n", len(jobs))
+		return nil
+	}
+
+	producer, err := newJobsProducer(cfg, db)
+	if err != nil {
+		return err
+	}
+	defer producer.Close()
+	eventRepo := database.NewJobEventRepository(db)
+
+	var data map[string]interface{}
+	if *model != "" {
+		data = map[string]interface{}{"segment_model": *model}
+	}
+
+	published := 0
+	for _, job := range jobs {
+		if err := jobRepo.ResetForReprocess(ctx, job.ID); err != nil {
+			log.Error().Err(err).Str("job_id", job.ID.String()).Msg("Failed to reset job")
+			continue
+		}
+		msg := kafka.JobMessage{JobID: job.ID, SegmentModel: *model}
+		if err := producer.PublishJobMessage(ctx, msg); err != nil {
+			log.Error().Err(err).Str("job_id", job.ID.String()).Msg("Failed to publish job; it is queued and can be picked up with requeue")
+			continue
+		}
+		recordRequeued(ctx, eventRepo, job.ID, "reprocess requested", data)
+		published++
+	}
+	fmt.Printf("%d of %d job(s) reprocessed\n", published, len(jobs))
+	return nil
+}
+
+// runCreateUser creates a user and its first API key, printing the plain key once.
+func runCreateUser(ctx context.Context, cfg *config.Config, db *database.DB, args []string) error {
+	fs := flag.NewFlagSet("create-user", flag.ExitOnError)
+	email := fs.String("email", "", "user email (optional)")
+	quotaChars := fs.Int64("quota-chars", cfg.DefaultQuotaChars, "API key quota in characters per period")
+	quotaPeriod := fs.String("quota-period", cfg.DefaultQuotaPeriod, "quota period (daily, weekly, monthly, yearly)")
+	fs.Parse(args)
+
+	user := &models.User{ID: uuid.New(), CreatedAt: time.Now()}
+	if *email != "" {
+		user.Email = email
+	}
+	if err := database.NewUserRepository(db).Create(ctx, user); err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	plainKey, key, err := database.NewAPIKeyRepository(db).CreateAPIKey(ctx, user.ID, *quotaChars, *quotaPeriod)
+	if err != nil {
+		return fmt.Errorf("failed to create API key for user %s: %w", user.ID, err)
+	}
+
+	fmt.Printf("user_id:    %s\n", user.ID)
+	fmt.Printf("api_key_id: %s\n", key.ID)
+	fmt.Printf("api_key:    %s\n", plainKey)
+	return nil
+}
+
+// runCreateKey creates an additional API key for an existing user.
+func runCreateKey(ctx context.Context, cfg *config.Config, db *database.DB, args []string) error {
+	fs := flag.NewFlagSet("create-key", flag.ExitOnError)
+	userIDStr := fs.String("user-id", "", "user ID (required)")
+	quotaChars := fs.Int64("quota-chars", cfg.DefaultQuotaChars, "API key quota in characters per period")
+	quotaPeriod := fs.String("quota-period", cfg.DefaultQuotaPeriod, "quota period (daily, weekly, monthly, yearly)")
+	fs.Parse(args)
+
+	userID, err := uuid.Parse(*userIDStr)
+	if err != nil {
+		return fmt.Errorf("invalid -user-id: %w", err)
+	}
+
+	plainKey, key, err := database.NewAPIKeyRepository(db).CreateAPIKey(ctx, userID, *quotaChars, *quotaPeriod)
+	if err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	fmt.Printf("api_key_id: %s\n", key.ID)
+	fmt.Printf("api_key:    %s\n", plainKey)
+	return nil
+}
+
+// runSetCostCap sets the cost cap of an API key: the estimated LLM cost (USD, from LLM_PRICING) its jobs
+// may use per quota period. -usd 0 removes the cap.
+func runSetCostCap(ctx context.Context, cfg *config.Config, db *database.DB, args []string) error {
+	fs := flag.NewFlagSet("set-cost-cap", flag.ExitOnError)
+	keyIDStr := fs.String("api-key-id", "", "API key ID (required)")
+	usd := fs.Float64("usd", 0, "cost cap in USD per quota period; 0 removes the cap")
+	fs.Parse(args)
+
+	keyID, err := uuid.Parse(*keyIDStr)
+	if err != nil {
+		return fmt.Errorf("invalid -api-key-id: %w", err)
+	}
+	if *usd < 0 {
+		return fmt.Errorf("-usd must not be negative")
+	}
+	var capUSD *float64
+	if *usd > 0 {
+		capUSD = usd
+	}
+	if err := database.NewAPIKeyRepository(db).SetCostCap(ctx, keyID, capUSD); err != nil {
+		return fmt.Errorf("failed to set cost cap: %w", err)
+	}
+	if capUSD == nil {
+		fmt.Printf("cost cap of %s removed\n", keyID)
+	} else {
+		fmt.Printf("cost cap of %s: %.2f USD per period\n", keyID, *capUSD)
+	}
+	return nil
+}
+
+// runSetStripeCustomer links a user to the Stripe customer their usage is reported to (STRIPE_API_KEY).
+// An empty -customer unlinks the user; their usage is no longer reported.
+func runSetStripeCustomer(ctx context.Context, cfg *config.Config, db *database.DB, args []string) error {
+	fs := flag.NewFlagSet("set-stripe-customer", flag.ExitOnError)
+	userIDStr := fs.String("user-id", "", "user ID (required)")
+	customer := fs.String("customer", "", "Stripe customer ID (cus_...); empty unlinks the user")
+	fs.Parse(args)
+
+	userID, err := uuid.Parse(*userIDStr)
+	if err != nil {
+		return fmt.Errorf("invalid -user-id: %w", err)
+	}
+	if *customer != "" && !strings.HasPrefix(*customer, "cus_") {
+		return fmt.Errorf("-customer must be a Stripe customer ID (cus_...)")
+	}
+	if err := database.NewUserRepository(db).SetStripeCustomer(ctx, userID, *customer); err != nil {
+		return fmt.Errorf("failed to set Stripe customer: %w", err)
+	}
+	if *customer == "" {
+		fmt.Printf("user %s unlinked from Stripe\n", userID)
+	} else {
+		fmt.Printf("user %s linked to Stripe customer %s\n", userID, *customer)
+	}
+	return nil
+}
+
+// runSetScopes restricts an API key to scopes: api for the REST API, agents:<tool> or agents:* for the
+// agents service (MCP tools, gRPC methods). An empty -scopes lifts the restriction.
+func runSetScopes(ctx context.Context, cfg *config.Config, db *database.DB, args []string) error {
+	fs := flag.NewFlagSet("set-scopes", flag.ExitOnError)
+	keyIDStr := fs.String("api-key-id", "", "API key ID (required)")
+	scopesStr := fs.String("scopes", "", "comma-separated scopes (api, agents:*, agents:segment_text, ...); empty lifts the restriction")
+	fs.Parse(args)
+
+	keyID, err := uuid.Parse(*keyIDStr)
+	if err != nil {
+		return fmt.Errorf("invalid -api-key-id: %w", err)
+	}
+	var scopes []string
+	for _, s := range strings.Split(*scopesStr, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			scopes = append(scopes, s)
+		}
+	}
+	if err := models.ValidateScopes(scopes); err != nil {
+		return fmt.Errorf("invalid -scopes: %w", err)
+	}
+	if err := database.NewAPIKeyRepository(db).SetScopes(ctx, keyID, scopes); err != nil {
+		return fmt.Errorf("failed to set scopes: %w", err)
+	}
+	if scopes == nil {
+		fmt.Printf("scopes of %s removed: the key is unrestricted\n", keyID)
+	} else {
+		fmt.Printf("scopes of %s: %s\n", keyID, strings.Join(scopes, ", "))
+	}
+	return nil
+}
+
+// jobDiagnostics is the JSON document printed by inspect.
+type jobDiagnostics struct {
+	Job               *models.Job                `json:"job"`
+	Segments          []*models.Segment          `json:"segments"`
+	Assets            []*models.Asset            `json:"assets"`
+	Files             []*models.JobFile          `json:"files"`
+	FactChecks        []*models.SegmentFactCheck `json:"fact_checks"`
+	Events            []*models.JobEvent         `json:"events"`
+	WebhookDeliveries []*models.WebhookDelivery  `json:"webhook_deliveries"`
+}
+
+// runInspect prints everything stored about a job. Webhook secrets are redacted.
+func runInspect(ctx context.Context, cfg *config.Config, db *database.DB, args []string) error {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: storiesctl inspect <job-id>")
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("job ID is required")
+	}
+	jobID, err := uuid.Parse(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid job ID: %w", err)
+	}
+
+	job, err := database.NewJobRepository(db).GetByID(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
+	}
+	if job.WebhookSecret != nil {
+		redacted := "[redacted]"
+		job.WebhookSecret = &redacted
+	}
+
+	d := &jobDiagnostics{Job: job}
+	if d.Segments, err = database.NewSegmentRepository(db).ListByJob(ctx, jobID); err != nil {
+		return fmt.Errorf("failed to list segments: %w", err)
+	}
+	if d.Assets, err = database.NewAssetRepository(db).ListByJob(ctx, jobID); err != nil {
+		return fmt.Errorf("failed to list assets: %w", err)
+	}
+	if d.Files, err = database.NewJobFileRepository(db).ListByJob(ctx, jobID); err != nil {
+		return fmt.Errorf("failed to list job files: %w", err)
+	}
+	if d.FactChecks, err = database.NewFactCheckRepository(db).ListByJob(ctx, jobID); err != nil {
+		return fmt.Errorf("failed to list fact checks: %w", err)
+	}
+	if d.Events, err = database.NewJobEventRepository(db).ListByJob(ctx, jobID); err != nil {
+		return fmt.Errorf("failed to list events: %w", err)
+	}
+	if d.WebhookDeliveries, err = database.NewWebhookDeliveryRepository(db).GetByJobID(ctx, jobID); err != nil {
+		return fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// newJobsProducer returns a producer publishing to the jobs topic of each job's tenant pool.
+func newJobsProducer(cfg *config.Config, db *database.DB) (*kafka.TenantProducer, error) {
+	routing, err := kafka.ParseTenantRouting(cfg.KafkaTenantRoutes, cfg.KafkaTenantTopicTemplate, cfg.KafkaTenantGroupTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return kafka.NewTenantProducer(cfg.KafkaBrokers, cfg.KafkaTopicJobs, routing, database.NewJobRepository(db).GetOwner), nil
+}
+
+// recordRequeued appends a requeued event to the job timeline; failures are logged only.
+func recordRequeued(ctx context.Context, repo *database.JobEventRepository, jobID uuid.UUID, message string, data map[string]interface{}) {
+	ev := &models.JobEvent{
+		ID:        uuid.New(),
+		JobID:     jobID,
+		Type:      models.JobEventRequeued,
+		Message:   message,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+	if err := repo.Create(ctx, ev); err != nil {
+		log.Warn().Err(err).Str("job_id", jobID.String()).Msg("Failed to record requeued event")
+	}
+}
+
+func printJobs(jobs []*models.Job) {
+	for _, job := range jobs {
+		started := "-"
+		if job.StartedAt != nil {
+			started = job.StartedAt.Format(time.RFC3339)
+		}
+		fmt.Printf("%s  %-9s  created=%s  started=%s\n", job.ID, job.Status, job.CreatedAt.Format(time.RFC3339), started)
+	}
+}
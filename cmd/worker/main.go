@@ -4,17 +4,30 @@ import (
 	"context"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
-	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/anomaly"
+	"github.com/snappy-loop/stories/internal/buildinfo"
+	"github.com/snappy-loop/stories/internal/cache"
 	"github.com/snappy-loop/stories/internal/config"
 	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/egress"
+	"github.com/snappy-loop/stories/internal/features"
+	"github.com/snappy-loop/stories/internal/hooks"
+	"github.com/snappy-loop/stories/internal/inputtype"
 	"github.com/snappy-loop/stories/internal/kafka"
+	"github.com/snappy-loop/stories/internal/leader"
 	"github.com/snappy-loop/stories/internal/llm"
+	"github.com/snappy-loop/stories/internal/logging"
+	"github.com/snappy-loop/stories/internal/maintenance"
+	"github.com/snappy-loop/stories/internal/models"
 	"github.com/snappy-loop/stories/internal/processor"
+	"github.com/snappy-loop/stories/internal/proxy"
+	"github.com/snappy-loop/stories/internal/ratelimit"
 	"github.com/snappy-loop/stories/internal/storage"
 )
 
@@ -28,29 +41,43 @@ func (h *JobHandler) HandleMessage(ctx context.Context, msg *kafka.JobMessage) e
 		Str("job_id", msg.JobID.String()).
 		Msg("Processing job message")
 
+	if msg.SegmentModel != "" {
+		log.Info().
+			Str("job_id", msg.JobID.String()).
+			Str("segment_model", msg.SegmentModel).
+			Msg("Using segmentation model override")
+		ctx = llm.WithSegmentModel(ctx, msg.SegmentModel)
+	}
+
+	if msg.SegmentIdx != nil {
+		return h.processor.ProcessSegmentEdit(ctx, msg.JobID, *msg.SegmentIdx, msg.RegenerateImage)
+	}
+
 	// Process the job
 	return h.processor.ProcessJob(ctx, msg.JobID)
 }
 
 func main() {
-	// Setup logging
-	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
-
-	logLevel := os.Getenv("LOG_LEVEL")
-	if logLevel == "" {
-		logLevel = "info"
-	}
-	level, err := zerolog.ParseLevel(logLevel)
+	// Load configuration and set up logging
+	cfg := config.Load()
+	closeLogs, err := logging.Setup("worker", logging.Options{
+		Level: cfg.LogLevel, Levels: cfg.LogLevels, Format: cfg.LogFormat, File: cfg.LogFile, Syslog: cfg.LogSyslog,
+	})
 	if err != nil {
-		level = zerolog.InfoLevel
+		log.Fatal().Err(err).Msg("Invalid logging configuration")
 	}
-	zerolog.SetGlobalLevel(level)
+	defer closeLogs()
 
-	log.Info().Msg("Starting Stories Worker")
+	build := buildinfo.Get()
+	log.Info().Str("commit", build.Commit).Str("build_time", build.BuildTime).Str("go_version", build.GoVersion).
+		Msg("Starting Stories Worker")
 
-	// Load configuration
-	cfg := config.Load()
+	if err := proxy.Validate(cfg.GeminiProxy); err != nil {
+		log.Fatal().Err(err).Msg("Invalid GEMINI_PROXY")
+	}
+	if err := proxy.Validate(cfg.S3Proxy); err != nil {
+		log.Fatal().Err(err).Msg("Invalid S3_PROXY")
+	}
 
 	// Initialize database connection
 	db, err := database.Connect(cfg.DatabaseURL)
@@ -58,6 +85,7 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to connect to database")
 	}
 	defer db.Close()
+	db.SetSlowQueryThreshold(cfg.SlowDBQueryThreshold)
 
 	// Initialize S3 storage client
 	storageClient, err := storage.NewClient(
@@ -68,17 +96,34 @@ func main() {
 		cfg.S3SecretKey,
 		cfg.S3UseSSL,
 		cfg.S3PublicURL,
+		cfg.S3Proxy,
 	)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to initialize storage client")
 	}
+	storageClient.SetSlowOpThreshold(cfg.SlowS3OpThreshold)
+	storageClient.SetUploadRetries(cfg.S3UploadRetries, cfg.S3UploadRetryDelay, cfg.S3UploadVerify)
+	storageClient.SetFallbackBucket(cfg.S3FallbackBucket)
 
 	// Initialize boundary cache repository
 	boundaryCacheRepo := database.NewBoundaryCacheRepository(db)
 
+	var vertexAI *llm.VertexAI
+	switch cfg.GeminiBackend {
+	case "gemini":
+	case "vertex":
+		if cfg.VertexProject == "" {
+			log.Fatal().Msg("VERTEX_PROJECT is required when GEMINI_BACKEND=vertex")
+		}
+		vertexAI = &llm.VertexAI{Project: cfg.VertexProject, Location: cfg.VertexLocation}
+	default:
+		log.Fatal().Str("backend", cfg.GeminiBackend).Msg("Invalid GEMINI_BACKEND (want gemini or vertex)")
+	}
+
 	// Initialize Gemini LLM client with boundary cache
 	llmClient := llm.NewClient(
 		cfg.GeminiAPIKey,
+		vertexAI,
 		cfg.GeminiModelFlash,
 		cfg.GeminiModelPro,
 		cfg.GeminiModelImage,
@@ -88,12 +133,88 @@ func main() {
 		cfg.GeminiModelSegmentPrimary,
 		cfg.GeminiModelSegmentFallback,
 		boundaryCacheRepo,
+		cfg.GeminiProxy,
 	)
 
-	// Initialize Kafka producer for webhook events
+	if err := ratelimit.CheckBackend(cfg.LimitsBackend, cfg.RedisURL); err != nil {
+		log.Fatal().Err(err).Msg("Invalid limits configuration")
+	}
+
+	// Optional Redis cache shared by all workers (boundaries, narration, image prompts)
+	var redisCache *cache.Redis
+	if cfg.RedisURL != "" {
+		redisCache, err = cache.NewRedis(cfg.RedisURL, cfg.MaxConcurrentSegments+1)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid REDIS_URL")
+		}
+		defer redisCache.Close()
+		if err := redisCache.Ping(context.Background()); err != nil {
+			log.Warn().Err(err).Msg("Redis not reachable; cache lookups will fail over to Postgres/Gemini")
+		}
+		llmClient.SetSharedCache(redisCache, cfg.RedisCacheTTL)
+	}
+
+	// Optional A/B experiments routing a share of jobs to alternative models or prompt variants
+	experiments, err := llm.ParseExperiments(cfg.LLMExperiments)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid LLM experiments configuration")
+	}
+	llmClient.SetExperiments(experiments)
+	inputTypes, err := inputtype.Parse(cfg.InputTypeProfiles)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid input type profiles")
+	}
+	llmClient.SetInputTypes(inputTypes)
+	llmClient.SetExtractionModel(cfg.GeminiModelExtract)
+	geminiLimits := map[string]int{
+		llm.ModelFamilyPro:   cfg.GeminiMaxConcurrentPro,
+		llm.ModelFamilyFlash: cfg.GeminiMaxConcurrentFlash,
+		llm.ModelFamilyImage: cfg.GeminiMaxConcurrentImage,
+		llm.ModelFamilyTTS:   cfg.GeminiMaxConcurrentTTS,
+	}
+	llmClient.SetConcurrencyLimits(geminiLimits)
+	// With LIMITS_BACKEND=redis the limits hold across all workers and agents instead of per process
+	if cfg.LimitsBackend == ratelimit.BackendRedis {
+		shared := map[string]llm.SharedLimiter{}
+		for family, limit := range geminiLimits {
+			if limit > 0 {
+				shared[family] = ratelimit.NewRedisSemaphore(redisCache, "gemini_"+family, int64(limit), ratelimit.DefaultLease)
+			}
+		}
+		llmClient.SetSharedConcurrencyLimits(shared)
+	}
+	llmClient.SetAdaptiveRateLimit(cfg.GeminiAdaptiveRateLimit)
+	llmClient.SetSlowCallThreshold(cfg.SlowLLMCallThreshold)
+	if err := llmClient.SetContentLogging(cfg.GeminiLogContent, cfg.GeminiLogSampleRate); err != nil {
+		log.Fatal().Err(err).Msg("Invalid GEMINI_LOG_CONTENT or GEMINI_LOG_SAMPLE_RATE")
+	}
+
+	// Rolling failure rates of Gemini calls and jobs, alerting on spikes (each replica its own traffic)
+	anomalyConfig := anomaly.Config{
+		Interval:      cfg.AnomalyCheckInterval,
+		Window:        cfg.AnomalyWindow,
+		Threshold:     cfg.AnomalyFailureRate,
+		MinSamples:    cfg.AnomalyMinSamples,
+		WebhookURL:    cfg.AnomalyWebhookURL,
+		WebhookSecret: cfg.AnomalyWebhookSecret,
+	}
+	if err := anomalyConfig.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("Invalid ANOMALY_WINDOW or ANOMALY_FAILURE_RATE")
+	}
+	// Alert webhooks and pipeline hook callbacks go to configured URLs, through the egress policy
+	egressPolicy := egress.NewPolicy(cfg.EgressAllowHosts, cfg.EgressDenyHosts, cfg.EgressAllowPrivate)
+	anomalies := anomaly.New(anomalyConfig)
+	if err := anomalies.SetEgress(egressPolicy); err != nil {
+		log.Fatal().Err(err).Msg("Invalid ANOMALY_WEBHOOK_URL")
+	}
+	llmClient.SetCallObserver(func(model, operation string, err error) {
+		anomalies.RecordCall(model, operation, err != nil)
+	})
+
+	// Initialize Kafka producer for job events (webhooks and notifications)
 	webhookProducer := kafka.NewProducer(
 		cfg.KafkaBrokers,
-		cfg.KafkaTopicWebhooks,
+		cfg.KafkaTopicEvents,
 	)
 	defer webhookProducer.Close()
 
@@ -102,6 +223,8 @@ func main() {
 	jobFileRepo := database.NewJobFileRepository(db)
 	factCheckRepo := database.NewFactCheckRepository(db)
 	multiFileProcessor := processor.NewMultiFileProcessor(llmClient, storageClient, fileRepo, jobFileRepo)
+	multiFileProcessor.SetPDFPagesPerBatch(cfg.PDFPagesPerBatch)
+	multiFileProcessor.SetLocalPDFText(cfg.PDFLocalText)
 	inputRegistry := processor.NewInputProcessorRegistry(
 		processor.NewTextProcessor(),
 		multiFileProcessor,
@@ -119,33 +242,104 @@ func main() {
 		fileRepo,
 		factCheckRepo,
 	)
+	jobProcessor.SetInputTypes(inputTypes)
+	pipelineHooks, err := hooks.Parse(cfg.PipelineHooks, egressPolicy)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid pipeline hooks")
+	}
+	jobProcessor.SetHooks(pipelineHooks)
+	rollouts, err := features.ParseRollouts(cfg.FeatureFlags)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid FEATURE_FLAGS")
+	}
+	jobProcessor.SetFeatures(features.New(rollouts, database.NewFeatureFlagRepository(db), cfg.FeatureFlagsRefresh))
+	jobProcessor.SetAnomalyMonitor(anomalies)
+	pricing, err := llm.ParsePricing(cfg.LLMPricing)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid LLM_PRICING")
+	}
+	if cfg.CostCapAction != models.CostCapReject && cfg.CostCapAction != models.CostCapQueue {
+		log.Fatal().Str("action", cfg.CostCapAction).Msg("Invalid COST_CAP_ACTION (want reject or queue)")
+	}
+	jobProcessor.SetPricing(pricing)
 
 	// Create job handler
 	handler := &JobHandler{
 		processor: jobProcessor,
 	}
 
+	// A worker serves either the shared pool or one enterprise tenant's pool (WORKER_TENANT), each
+	// with its own jobs topic, retry topics and consumer groups
+	tenantRouting, err := kafka.ParseTenantRouting(cfg.KafkaTenantRoutes, cfg.KafkaTenantTopicTemplate, cfg.KafkaTenantGroupTemplate)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid tenant routing")
+	}
+	if cfg.WorkerTenant != "" && !tenantRouting.HasTenant(cfg.WorkerTenant) {
+		log.Fatal().Str("tenant", cfg.WorkerTenant).Msg("WORKER_TENANT is not a tenant of KAFKA_TENANT_ROUTES")
+	}
+	jobsTopic := tenantRouting.Topic(cfg.KafkaTopicJobs, cfg.WorkerTenant)
+	consumerGroup := tenantRouting.Group(cfg.KafkaConsumerGroup, cfg.WorkerTenant)
+	log.Info().
+		Str("tenant", cfg.WorkerTenant).
+		Str("topic", jobsTopic).
+		Str("group", consumerGroup).
+		Msg("Worker pool")
+
 	// Initialize Kafka consumer for jobs
 	consumer := kafka.NewJobConsumer(
 		cfg.KafkaBrokers,
-		cfg.KafkaTopicJobs,
-		cfg.KafkaConsumerGroup,
+		jobsTopic,
+		consumerGroup,
 		handler,
 	)
 	defer consumer.Close()
 
+	// Jobs failing transiently move through delayed retry topics instead of failing right away
+	retryTiers := kafka.RetryTiers(jobsTopic, cfg.KafkaJobRetryDelays)
+	consumer.SetRetryTiers(cfg.KafkaBrokers, retryTiers)
+	consumers := []*kafka.JobConsumer{consumer}
+	for _, tier := range retryTiers {
+		// e.g. stories-worker-main.retry.5m
+		groupID := consumerGroup + strings.TrimPrefix(tier.Topic, jobsTopic)
+		retryConsumer := kafka.NewJobRetryConsumer(cfg.KafkaBrokers, tier, groupID, handler)
+		retryConsumer.SetRetryTiers(cfg.KafkaBrokers, retryTiers)
+		defer retryConsumer.Close()
+		consumers = append(consumers, retryConsumer)
+	}
+
+	// Maintenance mode (PUT /admin/maintenance): consumers finish the job in progress and hold the next
+	// one until it ends
+	maintenanceSwitch := maintenance.New(database.NewMaintenanceRepository(db), cfg.MaintenanceRefresh)
+	for _, c := range consumers {
+		c.SetPause(maintenanceSwitch)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Start Kafka consumer in goroutine
+	// Republish or fail jobs stuck in queued/running, and queue jobs whose dependencies finished (in the
+	// elected worker only)
+	jobsProducer := kafka.NewTenantProducer(cfg.KafkaBrokers, cfg.KafkaTopicJobs, tenantRouting, database.NewJobRepository(db).GetOwner)
+	defer jobsProducer.Close()
+	jobProcessor.SetJobPublisher(jobsProducer)
+	sweeper := processor.NewStuckJobSweeper(db, jobsProducer, webhookProducer, cfg)
+	sweeper.SetMaintenance(maintenanceSwitch)
+	leader.Start(ctx, db.SQLDB(), "stuck-job-sweeper", cfg.LeaderElectionInterval, sweeper.Start)
+	defer sweeper.Stop()
+	anomalies.Start(ctx)
+	defer anomalies.Stop()
+
+	// Start Kafka consumers in goroutines
 	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		if err := consumer.Start(ctx); err != nil && err != context.Canceled {
-			log.Error().Err(err).Msg("Kafka consumer error")
-		}
-	}()
+	for _, c := range consumers {
+		wg.Add(1)
+		go func(c *kafka.JobConsumer) {
+			defer wg.Done()
+			if err := c.Start(ctx); err != nil && err != context.Canceled {
+				log.Error().Err(err).Msg("Kafka consumer error")
+			}
+		}(c)
+	}
 
 	log.Info().Msg("Worker started, consuming job messages...")
 
@@ -261,6 +261,160 @@ func (x *GenerateAudioResponse) GetUrl() string {
 	return ""
 }
 
+// AudioChunk is a message of StreamAudio and ResumeAudio. The first message of a stream carries the audio's
+// metadata; each message carries the bytes at offset.
+type AudioChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AudioId       string                 `protobuf:"bytes,1,opt,name=audio_id,json=audioId,proto3" json:"audio_id,omitempty"` // pass to ResumeAudio with the number of bytes received
+	Offset        int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Size          int64                  `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"` // total bytes of the audio
+	Duration      float64                `protobuf:"fixed64,5,opt,name=duration,proto3" json:"duration,omitempty"`
+	MimeType      string                 `protobuf:"bytes,6,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	Model         string                 `protobuf:"bytes,7,opt,name=model,proto3" json:"model,omitempty"`
+	Url           string                 `protobuf:"bytes,8,opt,name=url,proto3" json:"url,omitempty"` // when set, audio is served from this URL and no data follows
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AudioChunk) Reset() {
+	*x = AudioChunk{}
+	mi := &file_proto_audio_v1_audio_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AudioChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AudioChunk) ProtoMessage() {}
+
+func (x *AudioChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_audio_v1_audio_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AudioChunk.ProtoReflect.Descriptor instead.
+func (*AudioChunk) Descriptor() ([]byte, []int) {
+	return file_proto_audio_v1_audio_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AudioChunk) GetAudioId() string {
+	if x != nil {
+		return x.AudioId
+	}
+	return ""
+}
+
+func (x *AudioChunk) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *AudioChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *AudioChunk) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *AudioChunk) GetDuration() float64 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+func (x *AudioChunk) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *AudioChunk) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *AudioChunk) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+type ResumeAudioRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AudioId       string                 `protobuf:"bytes,1,opt,name=audio_id,json=audioId,proto3" json:"audio_id,omitempty"`
+	Offset        int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResumeAudioRequest) Reset() {
+	*x = ResumeAudioRequest{}
+	mi := &file_proto_audio_v1_audio_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeAudioRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeAudioRequest) ProtoMessage() {}
+
+func (x *ResumeAudioRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_audio_v1_audio_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeAudioRequest.ProtoReflect.Descriptor instead.
+func (*ResumeAudioRequest) Descriptor() ([]byte, []int) {
+	return file_proto_audio_v1_audio_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ResumeAudioRequest) GetAudioId() string {
+	if x != nil {
+		return x.AudioId
+	}
+	return ""
+}
+
+func (x *ResumeAudioRequest) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
 var File_proto_audio_v1_audio_proto protoreflect.FileDescriptor
 
 const file_proto_audio_v1_audio_proto_rawDesc = "" +
@@ -284,10 +438,25 @@ const file_proto_audio_v1_audio_proto_rawDesc = "" +
 	"\bduration\x18\x03 \x01(\x01R\bduration\x12\x1b\n" +
 	"\tmime_type\x18\x04 \x01(\tR\bmimeType\x12\x14\n" +
 	"\x05model\x18\x05 \x01(\tR\x05model\x12\x10\n" +
-	"\x03url\x18\x06 \x01(\tR\x03url2\xbe\x01\n" +
+	"\x03url\x18\x06 \x01(\tR\x03url\"\xc8\x01\n" +
+	"\n" +
+	"AudioChunk\x12\x19\n" +
+	"\baudio_id\x18\x01 \x01(\tR\aaudioId\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x03R\x06offset\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x03R\x04size\x12\x1a\n" +
+	"\bduration\x18\x05 \x01(\x01R\bduration\x12\x1b\n" +
+	"\tmime_type\x18\x06 \x01(\tR\bmimeType\x12\x14\n" +
+	"\x05model\x18\a \x01(\tR\x05model\x12\x10\n" +
+	"\x03url\x18\b \x01(\tR\x03url\"G\n" +
+	"\x12ResumeAudioRequest\x12\x19\n" +
+	"\baudio_id\x18\x01 \x01(\tR\aaudioId\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x03R\x06offset2\xca\x02\n" +
 	"\fAudioService\x12\\\n" +
 	"\x11GenerateNarration\x12\".audio.v1.GenerateNarrationRequest\x1a#.audio.v1.GenerateNarrationResponse\x12P\n" +
-	"\rGenerateAudio\x12\x1e.audio.v1.GenerateAudioRequest\x1a\x1f.audio.v1.GenerateAudioResponseB5Z3github.com/snappy-loop/stories/gen/audio/v1;audiov1b\x06proto3"
+	"\rGenerateAudio\x12\x1e.audio.v1.GenerateAudioRequest\x1a\x1f.audio.v1.GenerateAudioResponse\x12E\n" +
+	"\vStreamAudio\x12\x1e.audio.v1.GenerateAudioRequest\x1a\x14.audio.v1.AudioChunk0\x01\x12C\n" +
+	"\vResumeAudio\x12\x1c.audio.v1.ResumeAudioRequest\x1a\x14.audio.v1.AudioChunk0\x01B5Z3github.com/snappy-loop/stories/gen/audio/v1;audiov1b\x06proto3"
 
 var (
 	file_proto_audio_v1_audio_proto_rawDescOnce sync.Once
@@ -301,20 +470,26 @@ func file_proto_audio_v1_audio_proto_rawDescGZIP() []byte {
 	return file_proto_audio_v1_audio_proto_rawDescData
 }
 
-var file_proto_audio_v1_audio_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_proto_audio_v1_audio_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_proto_audio_v1_audio_proto_goTypes = []any{
 	(*GenerateNarrationRequest)(nil),  // 0: audio.v1.GenerateNarrationRequest
 	(*GenerateNarrationResponse)(nil), // 1: audio.v1.GenerateNarrationResponse
 	(*GenerateAudioRequest)(nil),      // 2: audio.v1.GenerateAudioRequest
 	(*GenerateAudioResponse)(nil),     // 3: audio.v1.GenerateAudioResponse
+	(*AudioChunk)(nil),                // 4: audio.v1.AudioChunk
+	(*ResumeAudioRequest)(nil),        // 5: audio.v1.ResumeAudioRequest
 }
 var file_proto_audio_v1_audio_proto_depIdxs = []int32{
 	0, // 0: audio.v1.AudioService.GenerateNarration:input_type -> audio.v1.GenerateNarrationRequest
 	2, // 1: audio.v1.AudioService.GenerateAudio:input_type -> audio.v1.GenerateAudioRequest
-	1, // 2: audio.v1.AudioService.GenerateNarration:output_type -> audio.v1.GenerateNarrationResponse
-	3, // 3: audio.v1.AudioService.GenerateAudio:output_type -> audio.v1.GenerateAudioResponse
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
+	2, // 2: audio.v1.AudioService.StreamAudio:input_type -> audio.v1.GenerateAudioRequest
+	5, // 3: audio.v1.AudioService.ResumeAudio:input_type -> audio.v1.ResumeAudioRequest
+	1, // 4: audio.v1.AudioService.GenerateNarration:output_type -> audio.v1.GenerateNarrationResponse
+	3, // 5: audio.v1.AudioService.GenerateAudio:output_type -> audio.v1.GenerateAudioResponse
+	4, // 6: audio.v1.AudioService.StreamAudio:output_type -> audio.v1.AudioChunk
+	4, // 7: audio.v1.AudioService.ResumeAudio:output_type -> audio.v1.AudioChunk
+	4, // [4:8] is the sub-list for method output_type
+	0, // [0:4] is the sub-list for method input_type
 	0, // [0:0] is the sub-list for extension type_name
 	0, // [0:0] is the sub-list for extension extendee
 	0, // [0:0] is the sub-list for field type_name
@@ -331,7 +506,7 @@ func file_proto_audio_v1_audio_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_audio_v1_audio_proto_rawDesc), len(file_proto_audio_v1_audio_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
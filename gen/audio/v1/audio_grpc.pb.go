@@ -21,6 +21,8 @@ const _ = grpc.SupportPackageIsVersion9
 const (
 	AudioService_GenerateNarration_FullMethodName = "/audio.v1.AudioService/GenerateNarration"
 	AudioService_GenerateAudio_FullMethodName     = "/audio.v1.AudioService/GenerateAudio"
+	AudioService_StreamAudio_FullMethodName       = "/audio.v1.AudioService/StreamAudio"
+	AudioService_ResumeAudio_FullMethodName       = "/audio.v1.AudioService/ResumeAudio"
 )
 
 // AudioServiceClient is the client API for AudioService service.
@@ -29,6 +31,12 @@ const (
 type AudioServiceClient interface {
 	GenerateNarration(ctx context.Context, in *GenerateNarrationRequest, opts ...grpc.CallOption) (*GenerateNarrationResponse, error)
 	GenerateAudio(ctx context.Context, in *GenerateAudioRequest, opts ...grpc.CallOption) (*GenerateAudioResponse, error)
+	// StreamAudio generates audio like GenerateAudio and streams it in chunks, so large audio does not hit
+	// message size limits. With storage configured a single chunk carries the url instead of data.
+	StreamAudio(ctx context.Context, in *GenerateAudioRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AudioChunk], error)
+	// ResumeAudio streams the audio of an interrupted StreamAudio again from offset, without generating it
+	// again. Audio is kept for a limited time by the replica that generated it.
+	ResumeAudio(ctx context.Context, in *ResumeAudioRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AudioChunk], error)
 }
 
 type audioServiceClient struct {
@@ -59,12 +67,56 @@ func (c *audioServiceClient) GenerateAudio(ctx context.Context, in *GenerateAudi
 	return out, nil
 }
 
+func (c *audioServiceClient) StreamAudio(ctx context.Context, in *GenerateAudioRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AudioChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AudioService_ServiceDesc.Streams[0], AudioService_StreamAudio_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GenerateAudioRequest, AudioChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AudioService_StreamAudioClient = grpc.ServerStreamingClient[AudioChunk]
+
+func (c *audioServiceClient) ResumeAudio(ctx context.Context, in *ResumeAudioRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AudioChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AudioService_ServiceDesc.Streams[1], AudioService_ResumeAudio_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ResumeAudioRequest, AudioChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AudioService_ResumeAudioClient = grpc.ServerStreamingClient[AudioChunk]
+
 // AudioServiceServer is the server API for AudioService service.
 // All implementations must embed UnimplementedAudioServiceServer
 // for forward compatibility.
 type AudioServiceServer interface {
 	GenerateNarration(context.Context, *GenerateNarrationRequest) (*GenerateNarrationResponse, error)
 	GenerateAudio(context.Context, *GenerateAudioRequest) (*GenerateAudioResponse, error)
+	// StreamAudio generates audio like GenerateAudio and streams it in chunks, so large audio does not hit
+	// message size limits. With storage configured a single chunk carries the url instead of data.
+	StreamAudio(*GenerateAudioRequest, grpc.ServerStreamingServer[AudioChunk]) error
+	// ResumeAudio streams the audio of an interrupted StreamAudio again from offset, without generating it
+	// again. Audio is kept for a limited time by the replica that generated it.
+	ResumeAudio(*ResumeAudioRequest, grpc.ServerStreamingServer[AudioChunk]) error
 	mustEmbedUnimplementedAudioServiceServer()
 }
 
@@ -81,6 +133,12 @@ func (UnimplementedAudioServiceServer) GenerateNarration(context.Context, *Gener
 func (UnimplementedAudioServiceServer) GenerateAudio(context.Context, *GenerateAudioRequest) (*GenerateAudioResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method GenerateAudio not implemented")
 }
+func (UnimplementedAudioServiceServer) StreamAudio(*GenerateAudioRequest, grpc.ServerStreamingServer[AudioChunk]) error {
+	return status.Error(codes.Unimplemented, "method StreamAudio not implemented")
+}
+func (UnimplementedAudioServiceServer) ResumeAudio(*ResumeAudioRequest, grpc.ServerStreamingServer[AudioChunk]) error {
+	return status.Error(codes.Unimplemented, "method ResumeAudio not implemented")
+}
 func (UnimplementedAudioServiceServer) mustEmbedUnimplementedAudioServiceServer() {}
 func (UnimplementedAudioServiceServer) testEmbeddedByValue()                      {}
 
@@ -138,6 +196,28 @@ func _AudioService_GenerateAudio_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AudioService_StreamAudio_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GenerateAudioRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AudioServiceServer).StreamAudio(m, &grpc.GenericServerStream[GenerateAudioRequest, AudioChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AudioService_StreamAudioServer = grpc.ServerStreamingServer[AudioChunk]
+
+func _AudioService_ResumeAudio_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ResumeAudioRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AudioServiceServer).ResumeAudio(m, &grpc.GenericServerStream[ResumeAudioRequest, AudioChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AudioService_ResumeAudioServer = grpc.ServerStreamingServer[AudioChunk]
+
 // AudioService_ServiceDesc is the grpc.ServiceDesc for AudioService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -154,6 +234,17 @@ var AudioService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _AudioService_GenerateAudio_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamAudio",
+			Handler:       _AudioService_StreamAudio_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ResumeAudio",
+			Handler:       _AudioService_ResumeAudio_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "proto/audio/v1/audio.proto",
 }
@@ -21,6 +21,61 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type Verdict int32
+
+const (
+	Verdict_VERDICT_UNSPECIFIED  Verdict = 0
+	Verdict_VERDICT_SUPPORTED    Verdict = 1
+	Verdict_VERDICT_REFUTED      Verdict = 2
+	Verdict_VERDICT_MISLEADING   Verdict = 3
+	Verdict_VERDICT_UNVERIFIABLE Verdict = 4
+)
+
+// Enum value maps for Verdict.
+var (
+	Verdict_name = map[int32]string{
+		0: "VERDICT_UNSPECIFIED",
+		1: "VERDICT_SUPPORTED",
+		2: "VERDICT_REFUTED",
+		3: "VERDICT_MISLEADING",
+		4: "VERDICT_UNVERIFIABLE",
+	}
+	Verdict_value = map[string]int32{
+		"VERDICT_UNSPECIFIED":  0,
+		"VERDICT_SUPPORTED":    1,
+		"VERDICT_REFUTED":      2,
+		"VERDICT_MISLEADING":   3,
+		"VERDICT_UNVERIFIABLE": 4,
+	}
+)
+
+func (x Verdict) Enum() *Verdict {
+	p := new(Verdict)
+	*p = x
+	return p
+}
+
+func (x Verdict) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Verdict) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_factcheck_v1_factcheck_proto_enumTypes[0].Descriptor()
+}
+
+func (Verdict) Type() protoreflect.EnumType {
+	return &file_proto_factcheck_v1_factcheck_proto_enumTypes[0]
+}
+
+func (x Verdict) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Verdict.Descriptor instead.
+func (Verdict) EnumDescriptor() ([]byte, []int) {
+	return file_proto_factcheck_v1_factcheck_proto_rawDescGZIP(), []int{0}
+}
+
 type FactCheckSegmentRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
@@ -67,7 +122,9 @@ func (x *FactCheckSegmentRequest) GetText() string {
 
 type FactCheckSegmentResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	FactCheckText string                 `protobuf:"bytes,1,opt,name=fact_check_text,json=factCheckText,proto3" json:"fact_check_text,omitempty"` // empty if no issues found
+	FactCheckText string                 `protobuf:"bytes,1,opt,name=fact_check_text,json=factCheckText,proto3" json:"fact_check_text,omitempty"` // empty if no issues found; the issues of refuted and misleading claims
+	Claims        []*Claim               `protobuf:"bytes,2,rep,name=claims,proto3" json:"claims,omitempty"`                                      // the factual claims of the text, checked
+	Sources       []*Source              `protobuf:"bytes,3,rep,name=sources,proto3" json:"sources,omitempty"`                                    // web sources the check searched
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -109,15 +166,176 @@ func (x *FactCheckSegmentResponse) GetFactCheckText() string {
 	return ""
 }
 
+func (x *FactCheckSegmentResponse) GetClaims() []*Claim {
+	if x != nil {
+		return x.Claims
+	}
+	return nil
+}
+
+func (x *FactCheckSegmentResponse) GetSources() []*Source {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+type Claim struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"` // the claim as stated in the text
+	Verdict       Verdict                `protobuf:"varint,2,opt,name=verdict,proto3,enum=factcheck.v1.Verdict" json:"verdict,omitempty"`
+	Confidence    float32                `protobuf:"fixed32,3,opt,name=confidence,proto3" json:"confidence,omitempty"` // 0-1, the checker's confidence in the verdict
+	Explanation   string                 `protobuf:"bytes,4,opt,name=explanation,proto3" json:"explanation,omitempty"` // why, briefly; empty for supported claims
+	Sources       []*Source              `protobuf:"bytes,5,rep,name=sources,proto3" json:"sources,omitempty"`         // sources backing the verdict
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Claim) Reset() {
+	*x = Claim{}
+	mi := &file_proto_factcheck_v1_factcheck_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Claim) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Claim) ProtoMessage() {}
+
+func (x *Claim) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_factcheck_v1_factcheck_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Claim.ProtoReflect.Descriptor instead.
+func (*Claim) Descriptor() ([]byte, []int) {
+	return file_proto_factcheck_v1_factcheck_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Claim) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Claim) GetVerdict() Verdict {
+	if x != nil {
+		return x.Verdict
+	}
+	return Verdict_VERDICT_UNSPECIFIED
+}
+
+func (x *Claim) GetConfidence() float32 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *Claim) GetExplanation() string {
+	if x != nil {
+		return x.Explanation
+	}
+	return ""
+}
+
+func (x *Claim) GetSources() []*Source {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+type Source struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Title         string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Url           string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Source) Reset() {
+	*x = Source{}
+	mi := &file_proto_factcheck_v1_factcheck_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Source) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Source) ProtoMessage() {}
+
+func (x *Source) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_factcheck_v1_factcheck_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Source.ProtoReflect.Descriptor instead.
+func (*Source) Descriptor() ([]byte, []int) {
+	return file_proto_factcheck_v1_factcheck_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Source) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Source) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
 var File_proto_factcheck_v1_factcheck_proto protoreflect.FileDescriptor
 
 const file_proto_factcheck_v1_factcheck_proto_rawDesc = "" +
 	"\n" +
 	"\"proto/factcheck/v1/factcheck.proto\x12\ffactcheck.v1\"-\n" +
 	"\x17FactCheckSegmentRequest\x12\x12\n" +
-	"\x04text\x18\x01 \x01(\tR\x04text\"B\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\"\x9f\x01\n" +
 	"\x18FactCheckSegmentResponse\x12&\n" +
-	"\x0ffact_check_text\x18\x01 \x01(\tR\rfactCheckText2u\n" +
+	"\x0ffact_check_text\x18\x01 \x01(\tR\rfactCheckText\x12+\n" +
+	"\x06claims\x18\x02 \x03(\v2\x13.factcheck.v1.ClaimR\x06claims\x12.\n" +
+	"\asources\x18\x03 \x03(\v2\x14.factcheck.v1.SourceR\asources\"\xbe\x01\n" +
+	"\x05Claim\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\x12/\n" +
+	"\averdict\x18\x02 \x01(\x0e2\x15.factcheck.v1.VerdictR\averdict\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\x03 \x01(\x02R\n" +
+	"confidence\x12 \n" +
+	"\vexplanation\x18\x04 \x01(\tR\vexplanation\x12.\n" +
+	"\asources\x18\x05 \x03(\v2\x14.factcheck.v1.SourceR\asources\"0\n" +
+	"\x06Source\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url*\x80\x01\n" +
+	"\aVerdict\x12\x17\n" +
+	"\x13VERDICT_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11VERDICT_SUPPORTED\x10\x01\x12\x13\n" +
+	"\x0fVERDICT_REFUTED\x10\x02\x12\x16\n" +
+	"\x12VERDICT_MISLEADING\x10\x03\x12\x18\n" +
+	"\x14VERDICT_UNVERIFIABLE\x10\x042u\n" +
 	"\x10FactCheckService\x12a\n" +
 	"\x10FactCheckSegment\x12%.factcheck.v1.FactCheckSegmentRequest\x1a&.factcheck.v1.FactCheckSegmentResponseB=Z;github.com/snappy-loop/stories/gen/factcheck/v1;factcheckv1b\x06proto3"
 
@@ -133,19 +351,27 @@ func file_proto_factcheck_v1_factcheck_proto_rawDescGZIP() []byte {
 	return file_proto_factcheck_v1_factcheck_proto_rawDescData
 }
 
-var file_proto_factcheck_v1_factcheck_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_proto_factcheck_v1_factcheck_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_factcheck_v1_factcheck_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
 var file_proto_factcheck_v1_factcheck_proto_goTypes = []any{
-	(*FactCheckSegmentRequest)(nil),  // 0: factcheck.v1.FactCheckSegmentRequest
-	(*FactCheckSegmentResponse)(nil), // 1: factcheck.v1.FactCheckSegmentResponse
+	(Verdict)(0),                     // 0: factcheck.v1.Verdict
+	(*FactCheckSegmentRequest)(nil),  // 1: factcheck.v1.FactCheckSegmentRequest
+	(*FactCheckSegmentResponse)(nil), // 2: factcheck.v1.FactCheckSegmentResponse
+	(*Claim)(nil),                    // 3: factcheck.v1.Claim
+	(*Source)(nil),                   // 4: factcheck.v1.Source
 }
 var file_proto_factcheck_v1_factcheck_proto_depIdxs = []int32{
-	0, // 0: factcheck.v1.FactCheckService.FactCheckSegment:input_type -> factcheck.v1.FactCheckSegmentRequest
-	1, // 1: factcheck.v1.FactCheckService.FactCheckSegment:output_type -> factcheck.v1.FactCheckSegmentResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	3, // 0: factcheck.v1.FactCheckSegmentResponse.claims:type_name -> factcheck.v1.Claim
+	4, // 1: factcheck.v1.FactCheckSegmentResponse.sources:type_name -> factcheck.v1.Source
+	0, // 2: factcheck.v1.Claim.verdict:type_name -> factcheck.v1.Verdict
+	4, // 3: factcheck.v1.Claim.sources:type_name -> factcheck.v1.Source
+	1, // 4: factcheck.v1.FactCheckService.FactCheckSegment:input_type -> factcheck.v1.FactCheckSegmentRequest
+	2, // 5: factcheck.v1.FactCheckService.FactCheckSegment:output_type -> factcheck.v1.FactCheckSegmentResponse
+	5, // [5:6] is the sub-list for method output_type
+	4, // [4:5] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_proto_factcheck_v1_factcheck_proto_init() }
@@ -158,13 +384,14 @@ func file_proto_factcheck_v1_factcheck_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_factcheck_v1_factcheck_proto_rawDesc), len(file_proto_factcheck_v1_factcheck_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   2,
+			NumEnums:      1,
+			NumMessages:   4,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_proto_factcheck_v1_factcheck_proto_goTypes,
 		DependencyIndexes: file_proto_factcheck_v1_factcheck_proto_depIdxs,
+		EnumInfos:         file_proto_factcheck_v1_factcheck_proto_enumTypes,
 		MessageInfos:      file_proto_factcheck_v1_factcheck_proto_msgTypes,
 	}.Build()
 	File_proto_factcheck_v1_factcheck_proto = out.File
@@ -0,0 +1,5 @@
+// Package gen holds the Go code generated from proto/. It is committed; go generate ./gen (make proto)
+// regenerates it and make proto-verify checks it is up to date.
+package gen
+
+//go:generate make -C .. proto
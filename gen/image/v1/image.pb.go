@@ -245,6 +245,119 @@ func (x *GenerateImageResponse) GetUrl() string {
 	return ""
 }
 
+type GetOperationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOperationRequest) Reset() {
+	*x = GetOperationRequest{}
+	mi := &file_proto_image_v1_image_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOperationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOperationRequest) ProtoMessage() {}
+
+func (x *GetOperationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_v1_image_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOperationRequest.ProtoReflect.Descriptor instead.
+func (*GetOperationRequest) Descriptor() ([]byte, []int) {
+	return file_proto_image_v1_image_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetOperationRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// Operation is a GenerateImage call running in the background. Operations of other API keys are not found.
+type Operation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Done          bool                   `protobuf:"varint,2,opt,name=done,proto3" json:"done,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`   // set when the operation failed
+	Result        *GenerateImageResponse `protobuf:"bytes,4,opt,name=result,proto3" json:"result,omitempty"` // set when it succeeded
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Operation) Reset() {
+	*x = Operation{}
+	mi := &file_proto_image_v1_image_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Operation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Operation) ProtoMessage() {}
+
+func (x *Operation) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_image_v1_image_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Operation.ProtoReflect.Descriptor instead.
+func (*Operation) Descriptor() ([]byte, []int) {
+	return file_proto_image_v1_image_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Operation) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Operation) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+func (x *Operation) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Operation) GetResult() *GenerateImageResponse {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
 var File_proto_image_v1_image_proto protoreflect.FileDescriptor
 
 const file_proto_image_v1_image_proto_rawDesc = "" +
@@ -266,10 +379,19 @@ const file_proto_image_v1_image_proto_rawDesc = "" +
 	"resolution\x12\x1b\n" +
 	"\tmime_type\x18\x04 \x01(\tR\bmimeType\x12\x14\n" +
 	"\x05model\x18\x05 \x01(\tR\x05model\x12\x10\n" +
-	"\x03url\x18\x06 \x01(\tR\x03url2\xc4\x01\n" +
+	"\x03url\x18\x06 \x01(\tR\x03url\"%\n" +
+	"\x13GetOperationRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"~\n" +
+	"\tOperation\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04done\x18\x02 \x01(\bR\x04done\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x127\n" +
+	"\x06result\x18\x04 \x01(\v2\x1f.image.v1.GenerateImageResponseR\x06result2\xd3\x02\n" +
 	"\fImageService\x12b\n" +
 	"\x13GenerateImagePrompt\x12$.image.v1.GenerateImagePromptRequest\x1a%.image.v1.GenerateImagePromptResponse\x12P\n" +
-	"\rGenerateImage\x12\x1e.image.v1.GenerateImageRequest\x1a\x1f.image.v1.GenerateImageResponseB5Z3github.com/snappy-loop/stories/gen/image/v1;imagev1b\x06proto3"
+	"\rGenerateImage\x12\x1e.image.v1.GenerateImageRequest\x1a\x1f.image.v1.GenerateImageResponse\x12I\n" +
+	"\x12GenerateImageAsync\x12\x1e.image.v1.GenerateImageRequest\x1a\x13.image.v1.Operation\x12B\n" +
+	"\fGetOperation\x12\x1d.image.v1.GetOperationRequest\x1a\x13.image.v1.OperationB5Z3github.com/snappy-loop/stories/gen/image/v1;imagev1b\x06proto3"
 
 var (
 	file_proto_image_v1_image_proto_rawDescOnce sync.Once
@@ -283,23 +405,30 @@ func file_proto_image_v1_image_proto_rawDescGZIP() []byte {
 	return file_proto_image_v1_image_proto_rawDescData
 }
 
-var file_proto_image_v1_image_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_proto_image_v1_image_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_proto_image_v1_image_proto_goTypes = []any{
 	(*GenerateImagePromptRequest)(nil),  // 0: image.v1.GenerateImagePromptRequest
 	(*GenerateImagePromptResponse)(nil), // 1: image.v1.GenerateImagePromptResponse
 	(*GenerateImageRequest)(nil),        // 2: image.v1.GenerateImageRequest
 	(*GenerateImageResponse)(nil),       // 3: image.v1.GenerateImageResponse
+	(*GetOperationRequest)(nil),         // 4: image.v1.GetOperationRequest
+	(*Operation)(nil),                   // 5: image.v1.Operation
 }
 var file_proto_image_v1_image_proto_depIdxs = []int32{
-	0, // 0: image.v1.ImageService.GenerateImagePrompt:input_type -> image.v1.GenerateImagePromptRequest
-	2, // 1: image.v1.ImageService.GenerateImage:input_type -> image.v1.GenerateImageRequest
-	1, // 2: image.v1.ImageService.GenerateImagePrompt:output_type -> image.v1.GenerateImagePromptResponse
-	3, // 3: image.v1.ImageService.GenerateImage:output_type -> image.v1.GenerateImageResponse
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	3, // 0: image.v1.Operation.result:type_name -> image.v1.GenerateImageResponse
+	0, // 1: image.v1.ImageService.GenerateImagePrompt:input_type -> image.v1.GenerateImagePromptRequest
+	2, // 2: image.v1.ImageService.GenerateImage:input_type -> image.v1.GenerateImageRequest
+	2, // 3: image.v1.ImageService.GenerateImageAsync:input_type -> image.v1.GenerateImageRequest
+	4, // 4: image.v1.ImageService.GetOperation:input_type -> image.v1.GetOperationRequest
+	1, // 5: image.v1.ImageService.GenerateImagePrompt:output_type -> image.v1.GenerateImagePromptResponse
+	3, // 6: image.v1.ImageService.GenerateImage:output_type -> image.v1.GenerateImageResponse
+	5, // 7: image.v1.ImageService.GenerateImageAsync:output_type -> image.v1.Operation
+	5, // 8: image.v1.ImageService.GetOperation:output_type -> image.v1.Operation
+	5, // [5:9] is the sub-list for method output_type
+	1, // [1:5] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
 }
 
 func init() { file_proto_image_v1_image_proto_init() }
@@ -313,7 +442,7 @@ func file_proto_image_v1_image_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_image_v1_image_proto_rawDesc), len(file_proto_image_v1_image_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   6,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
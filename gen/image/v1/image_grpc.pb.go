@@ -21,6 +21,8 @@ const _ = grpc.SupportPackageIsVersion9
 const (
 	ImageService_GenerateImagePrompt_FullMethodName = "/image.v1.ImageService/GenerateImagePrompt"
 	ImageService_GenerateImage_FullMethodName       = "/image.v1.ImageService/GenerateImage"
+	ImageService_GenerateImageAsync_FullMethodName  = "/image.v1.ImageService/GenerateImageAsync"
+	ImageService_GetOperation_FullMethodName        = "/image.v1.ImageService/GetOperation"
 )
 
 // ImageServiceClient is the client API for ImageService service.
@@ -29,6 +31,10 @@ const (
 type ImageServiceClient interface {
 	GenerateImagePrompt(ctx context.Context, in *GenerateImagePromptRequest, opts ...grpc.CallOption) (*GenerateImagePromptResponse, error)
 	GenerateImage(ctx context.Context, in *GenerateImageRequest, opts ...grpc.CallOption) (*GenerateImageResponse, error)
+	// GenerateImageAsync starts GenerateImage in the background and returns its operation at once;
+	// poll GetOperation until it is done.
+	GenerateImageAsync(ctx context.Context, in *GenerateImageRequest, opts ...grpc.CallOption) (*Operation, error)
+	GetOperation(ctx context.Context, in *GetOperationRequest, opts ...grpc.CallOption) (*Operation, error)
 }
 
 type imageServiceClient struct {
@@ -59,12 +65,36 @@ func (c *imageServiceClient) GenerateImage(ctx context.Context, in *GenerateImag
 	return out, nil
 }
 
+func (c *imageServiceClient) GenerateImageAsync(ctx context.Context, in *GenerateImageRequest, opts ...grpc.CallOption) (*Operation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Operation)
+	err := c.cc.Invoke(ctx, ImageService_GenerateImageAsync_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *imageServiceClient) GetOperation(ctx context.Context, in *GetOperationRequest, opts ...grpc.CallOption) (*Operation, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Operation)
+	err := c.cc.Invoke(ctx, ImageService_GetOperation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ImageServiceServer is the server API for ImageService service.
 // All implementations must embed UnimplementedImageServiceServer
 // for forward compatibility.
 type ImageServiceServer interface {
 	GenerateImagePrompt(context.Context, *GenerateImagePromptRequest) (*GenerateImagePromptResponse, error)
 	GenerateImage(context.Context, *GenerateImageRequest) (*GenerateImageResponse, error)
+	// GenerateImageAsync starts GenerateImage in the background and returns its operation at once;
+	// poll GetOperation until it is done.
+	GenerateImageAsync(context.Context, *GenerateImageRequest) (*Operation, error)
+	GetOperation(context.Context, *GetOperationRequest) (*Operation, error)
 	mustEmbedUnimplementedImageServiceServer()
 }
 
@@ -81,6 +111,12 @@ func (UnimplementedImageServiceServer) GenerateImagePrompt(context.Context, *Gen
 func (UnimplementedImageServiceServer) GenerateImage(context.Context, *GenerateImageRequest) (*GenerateImageResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method GenerateImage not implemented")
 }
+func (UnimplementedImageServiceServer) GenerateImageAsync(context.Context, *GenerateImageRequest) (*Operation, error) {
+	return nil, status.Error(codes.Unimplemented, "method GenerateImageAsync not implemented")
+}
+func (UnimplementedImageServiceServer) GetOperation(context.Context, *GetOperationRequest) (*Operation, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetOperation not implemented")
+}
 func (UnimplementedImageServiceServer) mustEmbedUnimplementedImageServiceServer() {}
 func (UnimplementedImageServiceServer) testEmbeddedByValue()                      {}
 
@@ -138,6 +174,42 @@ func _ImageService_GenerateImage_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ImageService_GenerateImageAsync_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GenerateImageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageServiceServer).GenerateImageAsync(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImageService_GenerateImageAsync_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageServiceServer).GenerateImageAsync(ctx, req.(*GenerateImageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImageService_GetOperation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOperationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageServiceServer).GetOperation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImageService_GetOperation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageServiceServer).GetOperation(ctx, req.(*GetOperationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ImageService_ServiceDesc is the grpc.ServiceDesc for ImageService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -153,6 +225,14 @@ var ImageService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GenerateImage",
 			Handler:    _ImageService_GenerateImage_Handler,
 		},
+		{
+			MethodName: "GenerateImageAsync",
+			Handler:    _ImageService_GenerateImageAsync_Handler,
+		},
+		{
+			MethodName: "GetOperation",
+			Handler:    _ImageService_GetOperation_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/image/v1/image.proto",
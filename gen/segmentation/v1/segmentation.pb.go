@@ -193,6 +193,110 @@ func (x *SegmentTextResponse) GetSegments() []*Segment {
 	return nil
 }
 
+type ExtractContentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	MimeType      string                 `protobuf:"bytes,2,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	InputType     string                 `protobuf:"bytes,3,opt,name=input_type,json=inputType,proto3" json:"input_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExtractContentRequest) Reset() {
+	*x = ExtractContentRequest{}
+	mi := &file_proto_segmentation_v1_segmentation_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExtractContentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExtractContentRequest) ProtoMessage() {}
+
+func (x *ExtractContentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_segmentation_v1_segmentation_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExtractContentRequest.ProtoReflect.Descriptor instead.
+func (*ExtractContentRequest) Descriptor() ([]byte, []int) {
+	return file_proto_segmentation_v1_segmentation_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ExtractContentRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *ExtractContentRequest) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *ExtractContentRequest) GetInputType() string {
+	if x != nil {
+		return x.InputType
+	}
+	return ""
+}
+
+type ExtractContentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExtractContentResponse) Reset() {
+	*x = ExtractContentResponse{}
+	mi := &file_proto_segmentation_v1_segmentation_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExtractContentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExtractContentResponse) ProtoMessage() {}
+
+func (x *ExtractContentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_segmentation_v1_segmentation_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExtractContentResponse.ProtoReflect.Descriptor instead.
+func (*ExtractContentResponse) Descriptor() ([]byte, []int) {
+	return file_proto_segmentation_v1_segmentation_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ExtractContentResponse) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
 var File_proto_segmentation_v1_segmentation_proto protoreflect.FileDescriptor
 
 const file_proto_segmentation_v1_segmentation_proto_rawDesc = "" +
@@ -210,9 +314,17 @@ const file_proto_segmentation_v1_segmentation_proto_rawDesc = "" +
 	"\x05title\x18\x03 \x01(\tR\x05title\x12\x12\n" +
 	"\x04text\x18\x04 \x01(\tR\x04text\"K\n" +
 	"\x13SegmentTextResponse\x124\n" +
-	"\bsegments\x18\x01 \x03(\v2\x18.segmentation.v1.SegmentR\bsegments2o\n" +
+	"\bsegments\x18\x01 \x03(\v2\x18.segmentation.v1.SegmentR\bsegments\"g\n" +
+	"\x15ExtractContentRequest\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x12\x1b\n" +
+	"\tmime_type\x18\x02 \x01(\tR\bmimeType\x12\x1d\n" +
+	"\n" +
+	"input_type\x18\x03 \x01(\tR\tinputType\",\n" +
+	"\x16ExtractContentResponse\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text2\xd7\x01\n" +
 	"\x13SegmentationService\x12X\n" +
-	"\vSegmentText\x12#.segmentation.v1.SegmentTextRequest\x1a$.segmentation.v1.SegmentTextResponseBCZAgithub.com/snappy-loop/stories/gen/segmentation/v1;segmentationv1b\x06proto3"
+	"\vSegmentText\x12#.segmentation.v1.SegmentTextRequest\x1a$.segmentation.v1.SegmentTextResponse\x12a\n" +
+	"\x0eExtractContent\x12&.segmentation.v1.ExtractContentRequest\x1a'.segmentation.v1.ExtractContentResponse\x1a\x03\x88\x02\x01BCZAgithub.com/snappy-loop/stories/gen/segmentation/v1;segmentationv1b\x06proto3"
 
 var (
 	file_proto_segmentation_v1_segmentation_proto_rawDescOnce sync.Once
@@ -226,18 +338,22 @@ func file_proto_segmentation_v1_segmentation_proto_rawDescGZIP() []byte {
 	return file_proto_segmentation_v1_segmentation_proto_rawDescData
 }
 
-var file_proto_segmentation_v1_segmentation_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_proto_segmentation_v1_segmentation_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
 var file_proto_segmentation_v1_segmentation_proto_goTypes = []any{
-	(*SegmentTextRequest)(nil),  // 0: segmentation.v1.SegmentTextRequest
-	(*Segment)(nil),             // 1: segmentation.v1.Segment
-	(*SegmentTextResponse)(nil), // 2: segmentation.v1.SegmentTextResponse
+	(*SegmentTextRequest)(nil),     // 0: segmentation.v1.SegmentTextRequest
+	(*Segment)(nil),                // 1: segmentation.v1.Segment
+	(*SegmentTextResponse)(nil),    // 2: segmentation.v1.SegmentTextResponse
+	(*ExtractContentRequest)(nil),  // 3: segmentation.v1.ExtractContentRequest
+	(*ExtractContentResponse)(nil), // 4: segmentation.v1.ExtractContentResponse
 }
 var file_proto_segmentation_v1_segmentation_proto_depIdxs = []int32{
 	1, // 0: segmentation.v1.SegmentTextResponse.segments:type_name -> segmentation.v1.Segment
 	0, // 1: segmentation.v1.SegmentationService.SegmentText:input_type -> segmentation.v1.SegmentTextRequest
-	2, // 2: segmentation.v1.SegmentationService.SegmentText:output_type -> segmentation.v1.SegmentTextResponse
-	2, // [2:3] is the sub-list for method output_type
-	1, // [1:2] is the sub-list for method input_type
+	3, // 2: segmentation.v1.SegmentationService.ExtractContent:input_type -> segmentation.v1.ExtractContentRequest
+	2, // 3: segmentation.v1.SegmentationService.SegmentText:output_type -> segmentation.v1.SegmentTextResponse
+	4, // 4: segmentation.v1.SegmentationService.ExtractContent:output_type -> segmentation.v1.ExtractContentResponse
+	3, // [3:5] is the sub-list for method output_type
+	1, // [1:3] is the sub-list for method input_type
 	1, // [1:1] is the sub-list for extension type_name
 	1, // [1:1] is the sub-list for extension extendee
 	0, // [0:1] is the sub-list for field type_name
@@ -254,7 +370,7 @@ func file_proto_segmentation_v1_segmentation_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_segmentation_v1_segmentation_proto_rawDesc), len(file_proto_segmentation_v1_segmentation_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   3,
+			NumMessages:   5,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
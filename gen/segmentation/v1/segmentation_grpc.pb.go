@@ -19,20 +19,28 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	SegmentationService_SegmentText_FullMethodName = "/segmentation.v1.SegmentationService/SegmentText"
+	SegmentationService_SegmentText_FullMethodName    = "/segmentation.v1.SegmentationService/SegmentText"
+	SegmentationService_ExtractContent_FullMethodName = "/segmentation.v1.SegmentationService/ExtractContent"
 )
 
 // SegmentationServiceClient is the client API for SegmentationService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// SegmentationService is deprecated: use segmentation.v2.SegmentationService. Calls get the
+// x-stories-deprecated response header.
+//
+// Deprecated: Do not use.
 type SegmentationServiceClient interface {
 	SegmentText(ctx context.Context, in *SegmentTextRequest, opts ...grpc.CallOption) (*SegmentTextResponse, error)
+	ExtractContent(ctx context.Context, in *ExtractContentRequest, opts ...grpc.CallOption) (*ExtractContentResponse, error)
 }
 
 type segmentationServiceClient struct {
 	cc grpc.ClientConnInterface
 }
 
+// Deprecated: Do not use.
 func NewSegmentationServiceClient(cc grpc.ClientConnInterface) SegmentationServiceClient {
 	return &segmentationServiceClient{cc}
 }
@@ -47,11 +55,27 @@ func (c *segmentationServiceClient) SegmentText(ctx context.Context, in *Segment
 	return out, nil
 }
 
+func (c *segmentationServiceClient) ExtractContent(ctx context.Context, in *ExtractContentRequest, opts ...grpc.CallOption) (*ExtractContentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExtractContentResponse)
+	err := c.cc.Invoke(ctx, SegmentationService_ExtractContent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SegmentationServiceServer is the server API for SegmentationService service.
 // All implementations must embed UnimplementedSegmentationServiceServer
 // for forward compatibility.
+//
+// SegmentationService is deprecated: use segmentation.v2.SegmentationService. Calls get the
+// x-stories-deprecated response header.
+//
+// Deprecated: Do not use.
 type SegmentationServiceServer interface {
 	SegmentText(context.Context, *SegmentTextRequest) (*SegmentTextResponse, error)
+	ExtractContent(context.Context, *ExtractContentRequest) (*ExtractContentResponse, error)
 	mustEmbedUnimplementedSegmentationServiceServer()
 }
 
@@ -65,6 +89,9 @@ type UnimplementedSegmentationServiceServer struct{}
 func (UnimplementedSegmentationServiceServer) SegmentText(context.Context, *SegmentTextRequest) (*SegmentTextResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method SegmentText not implemented")
 }
+func (UnimplementedSegmentationServiceServer) ExtractContent(context.Context, *ExtractContentRequest) (*ExtractContentResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ExtractContent not implemented")
+}
 func (UnimplementedSegmentationServiceServer) mustEmbedUnimplementedSegmentationServiceServer() {}
 func (UnimplementedSegmentationServiceServer) testEmbeddedByValue()                             {}
 
@@ -75,6 +102,7 @@ type UnsafeSegmentationServiceServer interface {
 	mustEmbedUnimplementedSegmentationServiceServer()
 }
 
+// Deprecated: Do not use.
 func RegisterSegmentationServiceServer(s grpc.ServiceRegistrar, srv SegmentationServiceServer) {
 	// If the following call panics, it indicates UnimplementedSegmentationServiceServer was
 	// embedded by pointer and is nil.  This will cause panics if an
@@ -104,6 +132,24 @@ func _SegmentationService_SegmentText_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SegmentationService_ExtractContent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExtractContentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SegmentationServiceServer).ExtractContent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SegmentationService_ExtractContent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SegmentationServiceServer).ExtractContent(ctx, req.(*ExtractContentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // SegmentationService_ServiceDesc is the grpc.ServiceDesc for SegmentationService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -115,6 +161,10 @@ var SegmentationService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SegmentText",
 			Handler:    _SegmentationService_SegmentText_Handler,
 		},
+		{
+			MethodName: "ExtractContent",
+			Handler:    _SegmentationService_ExtractContent_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/segmentation/v1/segmentation.proto",
@@ -0,0 +1,449 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v6.33.4
+// source: proto/segmentation/v2/segmentation.proto
+
+package segmentationv2
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// InputType is the kind of text, which guides segmentation and extraction.
+type InputType int32
+
+const (
+	InputType_INPUT_TYPE_UNSPECIFIED InputType = 0 // educational
+	InputType_INPUT_TYPE_EDUCATIONAL InputType = 1
+	InputType_INPUT_TYPE_FINANCIAL   InputType = 2
+	InputType_INPUT_TYPE_FICTIONAL   InputType = 3
+)
+
+// Enum value maps for InputType.
+var (
+	InputType_name = map[int32]string{
+		0: "INPUT_TYPE_UNSPECIFIED",
+		1: "INPUT_TYPE_EDUCATIONAL",
+		2: "INPUT_TYPE_FINANCIAL",
+		3: "INPUT_TYPE_FICTIONAL",
+	}
+	InputType_value = map[string]int32{
+		"INPUT_TYPE_UNSPECIFIED": 0,
+		"INPUT_TYPE_EDUCATIONAL": 1,
+		"INPUT_TYPE_FINANCIAL":   2,
+		"INPUT_TYPE_FICTIONAL":   3,
+	}
+)
+
+func (x InputType) Enum() *InputType {
+	p := new(InputType)
+	*p = x
+	return p
+}
+
+func (x InputType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (InputType) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_segmentation_v2_segmentation_proto_enumTypes[0].Descriptor()
+}
+
+func (InputType) Type() protoreflect.EnumType {
+	return &file_proto_segmentation_v2_segmentation_proto_enumTypes[0]
+}
+
+func (x InputType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use InputType.Descriptor instead.
+func (InputType) EnumDescriptor() ([]byte, []int) {
+	return file_proto_segmentation_v2_segmentation_proto_rawDescGZIP(), []int{0}
+}
+
+type SegmentTextRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	SegmentsCount int32                  `protobuf:"varint,2,opt,name=segments_count,json=segmentsCount,proto3" json:"segments_count,omitempty"`
+	InputType     InputType              `protobuf:"varint,3,opt,name=input_type,json=inputType,proto3,enum=segmentation.v2.InputType" json:"input_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SegmentTextRequest) Reset() {
+	*x = SegmentTextRequest{}
+	mi := &file_proto_segmentation_v2_segmentation_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SegmentTextRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SegmentTextRequest) ProtoMessage() {}
+
+func (x *SegmentTextRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_segmentation_v2_segmentation_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SegmentTextRequest.ProtoReflect.Descriptor instead.
+func (*SegmentTextRequest) Descriptor() ([]byte, []int) {
+	return file_proto_segmentation_v2_segmentation_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SegmentTextRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *SegmentTextRequest) GetSegmentsCount() int32 {
+	if x != nil {
+		return x.SegmentsCount
+	}
+	return 0
+}
+
+func (x *SegmentTextRequest) GetInputType() InputType {
+	if x != nil {
+		return x.InputType
+	}
+	return InputType_INPUT_TYPE_UNSPECIFIED
+}
+
+type Segment struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartChar     int64                  `protobuf:"varint,1,opt,name=start_char,json=startChar,proto3" json:"start_char,omitempty"`
+	EndChar       int64                  `protobuf:"varint,2,opt,name=end_char,json=endChar,proto3" json:"end_char,omitempty"`
+	Title         *string                `protobuf:"bytes,3,opt,name=title,proto3,oneof" json:"title,omitempty"` // unset when the segment has no title
+	Text          string                 `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Segment) Reset() {
+	*x = Segment{}
+	mi := &file_proto_segmentation_v2_segmentation_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Segment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Segment) ProtoMessage() {}
+
+func (x *Segment) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_segmentation_v2_segmentation_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Segment.ProtoReflect.Descriptor instead.
+func (*Segment) Descriptor() ([]byte, []int) {
+	return file_proto_segmentation_v2_segmentation_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Segment) GetStartChar() int64 {
+	if x != nil {
+		return x.StartChar
+	}
+	return 0
+}
+
+func (x *Segment) GetEndChar() int64 {
+	if x != nil {
+		return x.EndChar
+	}
+	return 0
+}
+
+func (x *Segment) GetTitle() string {
+	if x != nil && x.Title != nil {
+		return *x.Title
+	}
+	return ""
+}
+
+func (x *Segment) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type SegmentTextResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Segments      []*Segment             `protobuf:"bytes,1,rep,name=segments,proto3" json:"segments,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SegmentTextResponse) Reset() {
+	*x = SegmentTextResponse{}
+	mi := &file_proto_segmentation_v2_segmentation_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SegmentTextResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SegmentTextResponse) ProtoMessage() {}
+
+func (x *SegmentTextResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_segmentation_v2_segmentation_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SegmentTextResponse.ProtoReflect.Descriptor instead.
+func (*SegmentTextResponse) Descriptor() ([]byte, []int) {
+	return file_proto_segmentation_v2_segmentation_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SegmentTextResponse) GetSegments() []*Segment {
+	if x != nil {
+		return x.Segments
+	}
+	return nil
+}
+
+type ExtractContentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	MimeType      string                 `protobuf:"bytes,2,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	InputType     InputType              `protobuf:"varint,3,opt,name=input_type,json=inputType,proto3,enum=segmentation.v2.InputType" json:"input_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExtractContentRequest) Reset() {
+	*x = ExtractContentRequest{}
+	mi := &file_proto_segmentation_v2_segmentation_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExtractContentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExtractContentRequest) ProtoMessage() {}
+
+func (x *ExtractContentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_segmentation_v2_segmentation_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExtractContentRequest.ProtoReflect.Descriptor instead.
+func (*ExtractContentRequest) Descriptor() ([]byte, []int) {
+	return file_proto_segmentation_v2_segmentation_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ExtractContentRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *ExtractContentRequest) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *ExtractContentRequest) GetInputType() InputType {
+	if x != nil {
+		return x.InputType
+	}
+	return InputType_INPUT_TYPE_UNSPECIFIED
+}
+
+type ExtractContentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExtractContentResponse) Reset() {
+	*x = ExtractContentResponse{}
+	mi := &file_proto_segmentation_v2_segmentation_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExtractContentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExtractContentResponse) ProtoMessage() {}
+
+func (x *ExtractContentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_segmentation_v2_segmentation_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExtractContentResponse.ProtoReflect.Descriptor instead.
+func (*ExtractContentResponse) Descriptor() ([]byte, []int) {
+	return file_proto_segmentation_v2_segmentation_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ExtractContentResponse) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+var File_proto_segmentation_v2_segmentation_proto protoreflect.FileDescriptor
+
+const file_proto_segmentation_v2_segmentation_proto_rawDesc = "" +
+	"\n" +
+	"(proto/segmentation/v2/segmentation.proto\x12\x0fsegmentation.v2\"\x8a\x01\n" +
+	"\x12SegmentTextRequest\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\x12%\n" +
+	"\x0esegments_count\x18\x02 \x01(\x05R\rsegmentsCount\x129\n" +
+	"\n" +
+	"input_type\x18\x03 \x01(\x0e2\x1a.segmentation.v2.InputTypeR\tinputType\"|\n" +
+	"\aSegment\x12\x1d\n" +
+	"\n" +
+	"start_char\x18\x01 \x01(\x03R\tstartChar\x12\x19\n" +
+	"\bend_char\x18\x02 \x01(\x03R\aendChar\x12\x19\n" +
+	"\x05title\x18\x03 \x01(\tH\x00R\x05title\x88\x01\x01\x12\x12\n" +
+	"\x04text\x18\x04 \x01(\tR\x04textB\b\n" +
+	"\x06_title\"K\n" +
+	"\x13SegmentTextResponse\x124\n" +
+	"\bsegments\x18\x01 \x03(\v2\x18.segmentation.v2.SegmentR\bsegments\"\x83\x01\n" +
+	"\x15ExtractContentRequest\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x12\x1b\n" +
+	"\tmime_type\x18\x02 \x01(\tR\bmimeType\x129\n" +
+	"\n" +
+	"input_type\x18\x03 \x01(\x0e2\x1a.segmentation.v2.InputTypeR\tinputType\",\n" +
+	"\x16ExtractContentResponse\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text*w\n" +
+	"\tInputType\x12\x1a\n" +
+	"\x16INPUT_TYPE_UNSPECIFIED\x10\x00\x12\x1a\n" +
+	"\x16INPUT_TYPE_EDUCATIONAL\x10\x01\x12\x18\n" +
+	"\x14INPUT_TYPE_FINANCIAL\x10\x02\x12\x18\n" +
+	"\x14INPUT_TYPE_FICTIONAL\x10\x032\xd2\x01\n" +
+	"\x13SegmentationService\x12X\n" +
+	"\vSegmentText\x12#.segmentation.v2.SegmentTextRequest\x1a$.segmentation.v2.SegmentTextResponse\x12a\n" +
+	"\x0eExtractContent\x12&.segmentation.v2.ExtractContentRequest\x1a'.segmentation.v2.ExtractContentResponseBCZAgithub.com/snappy-loop/stories/gen/segmentation/v2;segmentationv2b\x06proto3"
+
+var (
+	file_proto_segmentation_v2_segmentation_proto_rawDescOnce sync.Once
+	file_proto_segmentation_v2_segmentation_proto_rawDescData []byte
+)
+
+func file_proto_segmentation_v2_segmentation_proto_rawDescGZIP() []byte {
+	file_proto_segmentation_v2_segmentation_proto_rawDescOnce.Do(func() {
+		file_proto_segmentation_v2_segmentation_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_segmentation_v2_segmentation_proto_rawDesc), len(file_proto_segmentation_v2_segmentation_proto_rawDesc)))
+	})
+	return file_proto_segmentation_v2_segmentation_proto_rawDescData
+}
+
+var file_proto_segmentation_v2_segmentation_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_segmentation_v2_segmentation_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_proto_segmentation_v2_segmentation_proto_goTypes = []any{
+	(InputType)(0),                 // 0: segmentation.v2.InputType
+	(*SegmentTextRequest)(nil),     // 1: segmentation.v2.SegmentTextRequest
+	(*Segment)(nil),                // 2: segmentation.v2.Segment
+	(*SegmentTextResponse)(nil),    // 3: segmentation.v2.SegmentTextResponse
+	(*ExtractContentRequest)(nil),  // 4: segmentation.v2.ExtractContentRequest
+	(*ExtractContentResponse)(nil), // 5: segmentation.v2.ExtractContentResponse
+}
+var file_proto_segmentation_v2_segmentation_proto_depIdxs = []int32{
+	0, // 0: segmentation.v2.SegmentTextRequest.input_type:type_name -> segmentation.v2.InputType
+	2, // 1: segmentation.v2.SegmentTextResponse.segments:type_name -> segmentation.v2.Segment
+	0, // 2: segmentation.v2.ExtractContentRequest.input_type:type_name -> segmentation.v2.InputType
+	1, // 3: segmentation.v2.SegmentationService.SegmentText:input_type -> segmentation.v2.SegmentTextRequest
+	4, // 4: segmentation.v2.SegmentationService.ExtractContent:input_type -> segmentation.v2.ExtractContentRequest
+	3, // 5: segmentation.v2.SegmentationService.SegmentText:output_type -> segmentation.v2.SegmentTextResponse
+	5, // 6: segmentation.v2.SegmentationService.ExtractContent:output_type -> segmentation.v2.ExtractContentResponse
+	5, // [5:7] is the sub-list for method output_type
+	3, // [3:5] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_proto_segmentation_v2_segmentation_proto_init() }
+func file_proto_segmentation_v2_segmentation_proto_init() {
+	if File_proto_segmentation_v2_segmentation_proto != nil {
+		return
+	}
+	file_proto_segmentation_v2_segmentation_proto_msgTypes[1].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_segmentation_v2_segmentation_proto_rawDesc), len(file_proto_segmentation_v2_segmentation_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_segmentation_v2_segmentation_proto_goTypes,
+		DependencyIndexes: file_proto_segmentation_v2_segmentation_proto_depIdxs,
+		EnumInfos:         file_proto_segmentation_v2_segmentation_proto_enumTypes,
+		MessageInfos:      file_proto_segmentation_v2_segmentation_proto_msgTypes,
+	}.Build()
+	File_proto_segmentation_v2_segmentation_proto = out.File
+	file_proto_segmentation_v2_segmentation_proto_goTypes = nil
+	file_proto_segmentation_v2_segmentation_proto_depIdxs = nil
+}
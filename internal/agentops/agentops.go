@@ -0,0 +1,225 @@
+// Package agentops runs agent calls in the background for callers that cannot wait for them (image
+// generation can outlast HTTP timeouts): a call started in async mode returns an operation ID at once, and
+// the caller polls the operation until it is done. Operations live in Postgres so any agents replica can
+// answer the poll; large results go to S3.
+package agentops
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+const (
+	// inlineResultLimit is the largest result stored in Postgres when S3 is available
+	inlineResultLimit = 64 * 1024
+	pruneInterval     = time.Minute
+	finishTimeout     = 30 * time.Second
+)
+
+// ErrNotFound is returned for operations that do not exist, have expired or belong to another API key.
+var ErrNotFound = errors.New("operation not found")
+
+// ErrDisabled is returned by a nil Manager.
+var ErrDisabled = errors.New("async operations are not enabled")
+
+// repository is the subset of agent operation DB operations used by the manager.
+type repository interface {
+	Create(ctx context.Context, op *models.AgentOperation) error
+	Finish(ctx context.Context, op *models.AgentOperation) error
+	GetByID(ctx context.Context, id uuid.UUID, now time.Time) (*models.AgentOperation, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// objectStore stores large results in S3.
+type objectStore interface {
+	Upload(ctx context.Context, key string, data io.Reader, contentType string, contentLength int64) (string, error)
+	GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, error)
+}
+
+// RunFunc runs an operation's call and returns its serialized result.
+type RunFunc func(ctx context.Context) ([]byte, error)
+
+// Manager starts, tracks and prunes operations.
+type Manager struct {
+	repo    repository
+	storage objectStore
+	ttl     time.Duration
+	timeout time.Duration
+	now     func() time.Time
+	wg      sync.WaitGroup
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewManager creates a Manager. Calls are given timeout to finish; finished operations are kept for ttl.
+func NewManager(repo repository, ttl, timeout time.Duration) *Manager {
+	return &Manager{
+		repo:     repo,
+		ttl:      ttl,
+		timeout:  timeout,
+		now:      time.Now,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// SetStorage stores results larger than 64 KiB in S3. Without storage results are kept in Postgres.
+func (m *Manager) SetStorage(storage objectStore) {
+	m.storage = storage
+}
+
+// Start records a running operation of apiKeyID and runs it in the background with the values of ctx
+// (but not its cancellation), so the call outlives the request that started it.
+func (m *Manager) Start(ctx context.Context, apiKeyID uuid.UUID, tool, protocol string, run RunFunc) (*models.AgentOperation, error) {
+	if m == nil {
+		return nil, ErrDisabled
+	}
+	now := m.now()
+	op := &models.AgentOperation{
+		ID:        uuid.New(),
+		APIKeyID:  apiKeyID,
+		Tool:      tool,
+		Protocol:  protocol,
+		Status:    models.AgentOperationRunning,
+		CreatedAt: now,
+		ExpiresAt: now.Add(m.timeout + m.ttl), // a call lost with its replica expires like a finished one
+	}
+	if err := m.repo.Create(ctx, op); err != nil {
+		return nil, fmt.Errorf("failed to record operation: %w", err)
+	}
+	runCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.timeout)
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer cancel()
+		result, err := run(runCtx)
+		m.finish(context.WithoutCancel(ctx), op, result, err)
+	}()
+	return op, nil
+}
+
+// finish records a call's result, in S3 when it is large and S3 is available.
+func (m *Manager) finish(ctx context.Context, op *models.AgentOperation, result []byte, runErr error) {
+	ctx, cancel := context.WithTimeout(ctx, finishTimeout)
+	defer cancel()
+	now := m.now()
+	op.FinishedAt = &now
+	op.ExpiresAt = now.Add(m.ttl)
+	op.Status = models.AgentOperationSucceeded
+	if runErr != nil {
+		msg := runErr.Error()
+		op.Status = models.AgentOperationFailed
+		op.Error = &msg
+	} else if m.storage != nil && len(result) > inlineResultLimit {
+		key := "agents/operations/" + op.ID.String()
+		bucket, err := m.storage.Upload(ctx, key, bytes.NewReader(result), "application/octet-stream", int64(len(result)))
+		if err != nil {
+			log.Warn().Err(err).Str("operation_id", op.ID.String()).Msg("Failed to store operation result in S3; keeping it in Postgres")
+			op.Result = result
+		} else {
+			op.ResultS3Bucket = &bucket
+			op.ResultS3Key = &key
+		}
+	} else {
+		op.Result = result
+	}
+	if err := m.repo.Finish(ctx, op); err != nil {
+		log.Error().Err(err).Str("operation_id", op.ID.String()).Str("tool", op.Tool).Msg("Failed to record operation result")
+		return
+	}
+	log.Info().Str("operation_id", op.ID.String()).Str("tool", op.Tool).Str("status", op.Status).
+		Dur("duration", now.Sub(op.CreatedAt)).Msg("Agent operation finished")
+}
+
+// Get returns an operation of apiKeyID started over protocol, with its result loaded. A running operation
+// older than the call timeout (its replica stopped) is reported failed.
+func (m *Manager) Get(ctx context.Context, apiKeyID, id uuid.UUID, protocol string) (*models.AgentOperation, error) {
+	if m == nil {
+		return nil, ErrDisabled
+	}
+	now := m.now()
+	op, err := m.repo.GetByID(ctx, id, now)
+	if err != nil || op.APIKeyID != apiKeyID || op.Protocol != protocol {
+		return nil, ErrNotFound
+	}
+	if !op.Done() && now.Sub(op.CreatedAt) > m.timeout+finishTimeout {
+		msg := "operation interrupted"
+		op.Status = models.AgentOperationFailed
+		op.Error = &msg
+	}
+	if op.ResultS3Key != nil && m.storage != nil {
+		body, err := m.storage.GetObject(ctx, *op.ResultS3Bucket, *op.ResultS3Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load operation result: %w", err)
+		}
+		defer body.Close()
+		if op.Result, err = io.ReadAll(body); err != nil {
+			return nil, fmt.Errorf("failed to load operation result: %w", err)
+		}
+	}
+	return op, nil
+}
+
+// StartPruning deletes expired operations every minute until ctx is done or Stop is called. It runs in
+// every agents replica: each expired row is deleted once.
+func (m *Manager) StartPruning(ctx context.Context) {
+	if m == nil {
+		return
+	}
+	ticker := time.NewTicker(pruneInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-m.stopChan:
+				return
+			case <-ticker.C:
+				m.Prune(ctx)
+			}
+		}
+	}()
+}
+
+// Prune deletes expired operations (their S3 results are queued for the storage cleanup loop).
+func (m *Manager) Prune(ctx context.Context) {
+	deleted, err := m.repo.DeleteExpired(ctx, m.now())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to delete expired agent operations")
+		return
+	}
+	if deleted > 0 {
+		log.Info().Int64("deleted", deleted).Msg("Expired agent operations deleted")
+	}
+}
+
+// Stop stops pruning and waits for running operations to be recorded, up to ctx. Safe to call multiple
+// times.
+func (m *Manager) Stop(ctx context.Context) {
+	if m == nil {
+		return
+	}
+	m.stopOnce.Do(func() {
+		close(m.stopChan)
+	})
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Warn().Msg("Agent operations still running at shutdown; they will be reported interrupted")
+	}
+}
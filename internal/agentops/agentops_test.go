@@ -0,0 +1,140 @@
+package agentops
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+type fakeRepo struct {
+	mu  sync.Mutex
+	ops map[uuid.UUID]models.AgentOperation
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{ops: map[uuid.UUID]models.AgentOperation{}}
+}
+
+func (r *fakeRepo) Create(_ context.Context, op *models.AgentOperation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ops[op.ID] = *op
+	return nil
+}
+
+func (r *fakeRepo) Finish(ctx context.Context, op *models.AgentOperation) error {
+	return r.Create(ctx, op)
+}
+
+func (r *fakeRepo) GetByID(_ context.Context, id uuid.UUID, now time.Time) (*models.AgentOperation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	op, ok := r.ops[id]
+	if !ok || !op.ExpiresAt.After(now) {
+		return nil, errors.New("operation not found")
+	}
+	return &op, nil
+}
+
+func (r *fakeRepo) DeleteExpired(_ context.Context, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int64
+	for id, op := range r.ops {
+		if !op.ExpiresAt.After(now) {
+			delete(r.ops, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+type fakeStore struct {
+	objects map[string][]byte
+}
+
+func (s *fakeStore) Upload(_ context.Context, key string, data io.Reader, _ string, _ int64) (string, error) {
+	b, err := io.ReadAll(data)
+	s.objects[key] = b
+	return "bucket", err
+}
+
+func (s *fakeStore) GetObject(_ context.Context, _, key string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(s.objects[key])), nil
+}
+
+func TestManagerRunsOperations(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeStore{objects: map[string][]byte{}}
+	m := NewManager(newFakeRepo(), time.Hour, time.Minute)
+	m.SetStorage(store)
+	keyID := uuid.New()
+	large := bytes.Repeat([]byte("x"), inlineResultLimit+1)
+
+	small, _ := m.Start(ctx, keyID, "fact_check", models.AgentProtocolMCP, func(context.Context) ([]byte, error) { return []byte("ok"), nil })
+	big, _ := m.Start(ctx, keyID, "generate_image", models.AgentProtocolMCP, func(context.Context) ([]byte, error) { return large, nil })
+	failed, _ := m.Start(ctx, keyID, "generate_image", models.AgentProtocolMCP, func(context.Context) ([]byte, error) { return nil, errors.New("boom") })
+	m.wg.Wait()
+
+	op, err := m.Get(ctx, keyID, small.ID, models.AgentProtocolMCP)
+	if err != nil || op.Status != models.AgentOperationSucceeded || string(op.Result) != "ok" {
+		t.Errorf("small: got %+v, %v", op, err)
+	}
+	op, err = m.Get(ctx, keyID, big.ID, models.AgentProtocolMCP)
+	if err != nil || op.ResultS3Key == nil || !bytes.Equal(op.Result, large) {
+		t.Errorf("large result: want it stored in S3 and loaded back, got err %v", err)
+	}
+	op, err = m.Get(ctx, keyID, failed.ID, models.AgentProtocolMCP)
+	if err != nil || op.Status != models.AgentOperationFailed || op.Error == nil || *op.Error != "boom" {
+		t.Errorf("failed: got %+v, %v", op, err)
+	}
+	if _, err := m.Get(ctx, uuid.New(), small.ID, models.AgentProtocolMCP); !errors.Is(err, ErrNotFound) {
+		t.Errorf("other key: got %v, want ErrNotFound", err)
+	}
+	if _, err := m.Get(ctx, keyID, small.ID, models.AgentProtocolGRPC); !errors.Is(err, ErrNotFound) {
+		t.Errorf("other protocol: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestManagerExpiresOperations(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepo()
+	m := NewManager(repo, time.Hour, time.Minute)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	keyID := uuid.New()
+	release := make(chan struct{})
+	op, _ := m.Start(ctx, keyID, "generate_image", models.AgentProtocolGRPC, func(context.Context) ([]byte, error) {
+		<-release
+		return nil, nil
+	})
+
+	now = now.Add(2 * time.Minute)
+	got, err := m.Get(ctx, keyID, op.ID, models.AgentProtocolGRPC)
+	if err != nil || got.Status != models.AgentOperationFailed || !got.Done() {
+		t.Errorf("running past the timeout: got %+v, %v, want interrupted", got, err)
+	}
+
+	close(release)
+	m.wg.Wait()
+	now = now.Add(2 * time.Hour)
+	m.Prune(ctx)
+	if len(repo.ops) != 0 {
+		t.Errorf("after ttl: %d operations left, want 0", len(repo.ops))
+	}
+	if _, err := m.Get(ctx, keyID, op.ID, models.AgentProtocolGRPC); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expired: got %v, want ErrNotFound", err)
+	}
+
+	var disabled *Manager
+	if _, err := disabled.Start(ctx, keyID, "generate_image", models.AgentProtocolMCP, nil); !errors.Is(err, ErrDisabled) {
+		t.Errorf("nil manager: got %v, want ErrDisabled", err)
+	}
+}
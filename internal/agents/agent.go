@@ -7,9 +7,10 @@ import (
 	"github.com/snappy-loop/stories/internal/llm"
 )
 
-// SegmentationAgent segments text into logical parts.
+// SegmentationAgent segments text into logical parts and extracts text from documents and images.
 type SegmentationAgent interface {
 	SegmentText(ctx context.Context, text string, segmentsCount int, inputType string) ([]*llm.Segment, error)
+	ExtractContent(ctx context.Context, data []byte, mimeType, inputType string) (string, error)
 }
 
 // AudioAgent generates narration scripts and TTS audio.
@@ -27,6 +28,7 @@ type ImageAgent interface {
 // FactCheckAgent fact-checks segment text using search grounding.
 type FactCheckAgent interface {
 	FactCheckSegment(ctx context.Context, text string) (string, error)
+	FactCheckClaims(ctx context.Context, text string) (*llm.FactCheck, error)
 }
 
 // AudioData reads the full audio bytes from llm.Audio (for gRPC/MCP which need bytes).
@@ -20,3 +20,8 @@ func NewFactCheckAgent(client *llm.Client) FactCheckAgent {
 func (a *FactCheckAgentImpl) FactCheckSegment(ctx context.Context, text string) (string, error) {
 	return a.Client.FactCheckSegment(ctx, text)
 }
+
+// FactCheckClaims delegates to llm.Client.FactCheckClaims.
+func (a *FactCheckAgentImpl) FactCheckClaims(ctx context.Context, text string) (*llm.FactCheck, error) {
+	return a.Client.FactCheckClaims(ctx, text)
+}
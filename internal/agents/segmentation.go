@@ -2,21 +2,49 @@ package agents
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/snappy-loop/stories/internal/llm"
 )
 
-// SegmentationAgentImpl wraps llm.Client for segmentation.
+// ExtractMimeTypes are the MIME types ExtractContent accepts (the same as job file uploads).
+var ExtractMimeTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+// SegmentationAgentImpl wraps llm.Client for segmentation and content extraction.
 type SegmentationAgentImpl struct {
 	Client *llm.Client
+	// MaxExtractBytes caps ExtractContent input (0 = no limit)
+	MaxExtractBytes int64
 }
 
-// NewSegmentationAgent returns a SegmentationAgent that delegates to the LLM client.
-func NewSegmentationAgent(client *llm.Client) SegmentationAgent {
-	return &SegmentationAgentImpl{Client: client}
+// NewSegmentationAgent returns a SegmentationAgent that delegates to the LLM client. maxExtractBytes caps
+// the documents ExtractContent accepts (MAX_FILE_SIZE, like job file uploads).
+func NewSegmentationAgent(client *llm.Client, maxExtractBytes int64) SegmentationAgent {
+	return &SegmentationAgentImpl{Client: client, MaxExtractBytes: maxExtractBytes}
 }
 
 // SegmentText delegates to llm.Client.SegmentText.
 func (a *SegmentationAgentImpl) SegmentText(ctx context.Context, text string, segmentsCount int, inputType string) ([]*llm.Segment, error) {
 	return a.Client.SegmentText(ctx, text, segmentsCount, inputType)
 }
+
+// ExtractContent validates the document and delegates to llm.Client.ExtractContent (the vision extraction
+// the worker runs on job files).
+func (a *SegmentationAgentImpl) ExtractContent(ctx context.Context, data []byte, mimeType, inputType string) (string, error) {
+	if len(data) == 0 {
+		return "", fmt.Errorf("data is required")
+	}
+	if a.MaxExtractBytes > 0 && int64(len(data)) > a.MaxExtractBytes {
+		return "", fmt.Errorf("data exceeds maximum of %d bytes", a.MaxExtractBytes)
+	}
+	if !ExtractMimeTypes[mimeType] {
+		return "", fmt.Errorf("unsupported mime type: %s", mimeType)
+	}
+	return a.Client.ExtractContent(ctx, data, mimeType, inputType)
+}
@@ -3,15 +3,20 @@ package agentsclient
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	audiov1 "github.com/snappy-loop/stories/gen/audio/v1"
 	factcheckv1 "github.com/snappy-loop/stories/gen/factcheck/v1"
 	imagev1 "github.com/snappy-loop/stories/gen/image/v1"
-	segmentationv1 "github.com/snappy-loop/stories/gen/segmentation/v1"
+	segmentationv2 "github.com/snappy-loop/stories/gen/segmentation/v2"
+	"github.com/snappy-loop/stories/internal/auth"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
@@ -19,10 +24,13 @@ import (
 
 const apiKeyRedacted = "***"
 
+// maxAudioResumes is how many times an interrupted audio stream is resumed
+const maxAudioResumes = 3
+
 // Client calls the agents service via gRPC or MCP.
 type Client struct {
 	grpcConn    *grpc.ClientConn
-	segCli      segmentationv1.SegmentationServiceClient
+	segCli      segmentationv2.SegmentationServiceClient
 	audioCli    audiov1.AudioServiceClient
 	imageCli    imagev1.ImageServiceClient
 	factCheckCli factcheckv1.FactCheckServiceClient
@@ -39,7 +47,11 @@ func NewClient(grpcURL, mcpURL string) (*Client, error) {
 	var conn *grpc.ClientConn
 	if grpcURL != "" {
 		var err error
-		conn, err = grpc.NewClient(grpcURL, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		conn, err = grpc.NewClient(grpcURL,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithUnaryInterceptor(signUnary),
+			grpc.WithStreamInterceptor(signStream),
+		)
 		if err != nil {
 			return nil, fmt.Errorf("grpc dial: %w", err)
 		}
@@ -50,7 +62,7 @@ func NewClient(grpcURL, mcpURL string) (*Client, error) {
 		httpCli:  &http.Client{Timeout: 120 * time.Second},
 	}
 	if conn != nil {
-		c.segCli = segmentationv1.NewSegmentationServiceClient(conn)
+		c.segCli = segmentationv2.NewSegmentationServiceClient(conn)
 		c.audioCli = audiov1.NewAudioServiceClient(conn)
 		c.imageCli = imagev1.NewImageServiceClient(conn)
 		c.factCheckCli = factcheckv1.NewFactCheckServiceClient(conn)
@@ -80,9 +92,11 @@ func RedactRequest(req map[string]interface{}) map[string]interface{} {
 }
 
 // Call invokes the agents service. transport is "grpc" or "mcp". action is one of:
-// segment_text, generate_narration, generate_audio, generate_image_prompt, generate_image, fact_check.
+// segment_text, extract_content, generate_narration, generate_audio, generate_image_prompt, generate_image, fact_check.
+// extract_content takes the document as base64 in "data_base64" plus "mime_type".
 // params must contain the action-specific fields plus "api_key".
 // Returns (redacted request, response, error). Response is a map or struct for JSON encoding.
+// Audio and images the agents service did not store (no S3 there) come back as "data_base64".
 func (c *Client) Call(ctx context.Context, apiKey, transport, action string, params map[string]interface{}) (requestRedacted map[string]interface{}, response interface{}, err error) {
 	requestRedacted = RedactRequest(params)
 
@@ -107,6 +121,73 @@ func (c *Client) ctxWithAuth(ctx context.Context, apiKey string) context.Context
 	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+apiKey)
 }
 
+// signUnary signs each gRPC call with the API key of its "authorization" metadata, so agents requiring
+// signed requests (AGENTS_REQUEST_SIGNING) accept it.
+func signUnary(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(signedContext(ctx, method), method, req, reply, cc, opts...)
+}
+
+// signStream is signUnary for streaming calls.
+func signStream(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(signedContext(ctx, method), desc, cc, method, opts...)
+}
+
+func signedContext(ctx context.Context, method string) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if vals := md.Get("authorization"); len(vals) > 0 && strings.HasPrefix(vals[0], "Bearer ") {
+		sig := auth.NewRequestSignature(strings.TrimPrefix(vals[0], "Bearer "), method, nil)
+		ctx = metadata.AppendToOutgoingContext(ctx,
+			strings.ToLower(auth.HeaderSignatureTimestamp), sig.Timestamp,
+			strings.ToLower(auth.HeaderSignatureNonce), sig.Nonce,
+			strings.ToLower(auth.HeaderSignature), sig.Signature,
+		)
+	}
+	return ctx
+}
+
+// streamAudio generates audio with StreamAudio (GenerateAudio returns it in one message, which large audio
+// exceeds) and returns its first chunk (the metadata and url) and bytes. An interrupted stream is resumed
+// from the bytes received, up to maxAudioResumes times.
+func (c *Client) streamAudio(ctx context.Context, req *audiov1.GenerateAudioRequest) (*audiov1.AudioChunk, []byte, error) {
+	stream, err := c.audioCli.StreamAudio(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	var head *audiov1.AudioChunk
+	var data []byte
+	for resumes := 0; ; {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if head == nil || head.GetUrl() != "" || ctx.Err() != nil || resumes == maxAudioResumes {
+				return nil, nil, err
+			}
+			resumes++
+			stream, err = c.audioCli.ResumeAudio(ctx, &audiov1.ResumeAudioRequest{AudioId: head.GetAudioId(), Offset: int64(len(data))})
+			if err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		if head == nil {
+			head = chunk
+		}
+		if chunk.GetOffset() != int64(len(data)) {
+			return nil, nil, fmt.Errorf("audio chunk at offset %d, want %d", chunk.GetOffset(), len(data))
+		}
+		data = append(data, chunk.GetData()...)
+	}
+	if head == nil {
+		return nil, nil, fmt.Errorf("audio stream ended without audio")
+	}
+	if head.GetUrl() == "" && int64(len(data)) != head.GetSize() {
+		return nil, nil, fmt.Errorf("audio stream ended after %d of %d bytes", len(data), head.GetSize())
+	}
+	return head, data, nil
+}
+
 func getStr(m map[string]interface{}, key string) string {
 	if v, ok := m[key]; ok {
 		if s, ok := v.(string); ok {
@@ -132,13 +213,17 @@ func getInt(m map[string]interface{}, key string) int32 {
 
 func (c *Client) callGRPC(ctx context.Context, apiKey, action string, params map[string]interface{}) (interface{}, error) {
 	ctx = c.ctxWithAuth(ctx, apiKey)
+	// segment_text and generate_image_prompt honor a per-call cache control (default, no-cache, no-store)
+	if cc := getStr(params, "cache_control"); cc != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "cache-control", cc)
+	}
 	switch action {
 	case "segment_text":
-		it := getStr(params, "input_type")
-		if it == "" {
-			it = "educational"
+		it, err := inputType(getStr(params, "input_type"))
+		if err != nil {
+			return nil, err
 		}
-		req := &segmentationv1.SegmentTextRequest{
+		req := &segmentationv2.SegmentTextRequest{
 			Text:          getStr(params, "text"),
 			SegmentsCount: getInt(params, "segments_count"),
 			InputType:     it,
@@ -151,6 +236,25 @@ func (c *Client) callGRPC(ctx context.Context, apiKey, action string, params map
 			return nil, err
 		}
 		return segmentResponseToMap(resp), nil
+	case "extract_content":
+		data, err := base64.StdEncoding.DecodeString(getStr(params, "data_base64"))
+		if err != nil {
+			return nil, fmt.Errorf("data_base64 is not valid base64")
+		}
+		it, err := inputType(getStr(params, "input_type"))
+		if err != nil {
+			return nil, err
+		}
+		req := &segmentationv2.ExtractContentRequest{
+			Data:      data,
+			MimeType:  getStr(params, "mime_type"),
+			InputType: it,
+		}
+		resp, err := c.segCli.ExtractContent(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"text": resp.GetText()}, nil
 	case "generate_narration":
 		at := getStr(params, "audio_type")
 		if at == "" {
@@ -179,23 +283,25 @@ func (c *Client) callGRPC(ctx context.Context, apiKey, action string, params map
 			Script:    getStr(params, "script"),
 			AudioType: at,
 		}
-		resp, err := c.audioCli.GenerateAudio(ctx, req)
+		head, data, err := c.streamAudio(ctx, req)
 		if err != nil {
 			return nil, err
 		}
-		ct := resp.GetMimeType()
+		ct := head.GetMimeType()
 		if ct == "" {
 			ct = "audio/wav"
 		}
 		out := map[string]interface{}{
-			"size":         resp.GetSize(),
-			"duration":     resp.GetDuration(),
+			"size":         head.GetSize(),
+			"duration":     head.GetDuration(),
 			"mime_type":    ct,
 			"content_type": ct,
-			"model":        resp.GetModel(),
+			"model":        head.GetModel(),
 		}
-		if u := resp.GetUrl(); u != "" {
+		if u := head.GetUrl(); u != "" {
 			out["url"] = u
+		} else if len(data) > 0 {
+			out["data_base64"] = base64.StdEncoding.EncodeToString(data)
 		}
 		return out, nil
 	case "generate_image_prompt":
@@ -233,6 +339,8 @@ func (c *Client) callGRPC(ctx context.Context, apiKey, action string, params map
 		}
 		if u := resp.GetUrl(); u != "" {
 			out["url"] = u
+		} else if len(resp.GetData()) > 0 {
+			out["data_base64"] = base64.StdEncoding.EncodeToString(resp.GetData())
 		}
 		return out, nil
 	case "fact_check":
@@ -243,13 +351,47 @@ func (c *Client) callGRPC(ctx context.Context, apiKey, action string, params map
 		if err != nil {
 			return nil, err
 		}
-		return map[string]interface{}{"fact_check_text": resp.GetFactCheckText()}, nil
+		claims := make([]map[string]interface{}, len(resp.GetClaims()))
+		for i, cl := range resp.GetClaims() {
+			claims[i] = map[string]interface{}{
+				"claim":       cl.GetText(),
+				"verdict":     strings.ToLower(strings.TrimPrefix(cl.GetVerdict().String(), "VERDICT_")),
+				"confidence":  cl.GetConfidence(),
+				"explanation": cl.GetExplanation(),
+				"sources":     sourcesToMaps(cl.GetSources()),
+			}
+		}
+		return map[string]interface{}{
+			"fact_check_text": resp.GetFactCheckText(),
+			"claims":          claims,
+			"sources":         sourcesToMaps(resp.GetSources()),
+		}, nil
 	default:
 		return nil, fmt.Errorf("unknown action: %s", action)
 	}
 }
 
-func segmentResponseToMap(resp *segmentationv1.SegmentTextResponse) map[string]interface{} {
+func sourcesToMaps(sources []*factcheckv1.Source) []map[string]interface{} {
+	out := make([]map[string]interface{}, len(sources))
+	for i, src := range sources {
+		out[i] = map[string]interface{}{"title": src.GetTitle(), "url": src.GetUrl()}
+	}
+	return out
+}
+
+// inputType returns the segmentation.v2 input type named s (educational when empty).
+func inputType(s string) (segmentationv2.InputType, error) {
+	if s == "" {
+		return segmentationv2.InputType_INPUT_TYPE_EDUCATIONAL, nil
+	}
+	t, ok := segmentationv2.InputType_value["INPUT_TYPE_"+strings.ToUpper(s)]
+	if !ok || t == 0 {
+		return 0, fmt.Errorf("unknown input_type: %s", s)
+	}
+	return segmentationv2.InputType(t), nil
+}
+
+func segmentResponseToMap(resp *segmentationv2.SegmentTextResponse) map[string]interface{} {
 	segs := make([]map[string]interface{}, len(resp.GetSegments()))
 	for i, s := range resp.GetSegments() {
 		segs[i] = map[string]interface{}{
@@ -262,6 +404,15 @@ func segmentResponseToMap(resp *segmentationv1.SegmentTextResponse) map[string]i
 	return map[string]interface{}{"segments": segs}
 }
 
+// mcpPath returns the request path of the MCP URL, as the agents server sees it when verifying signatures.
+func mcpPath(mcpURL string) string {
+	u, err := url.Parse(mcpURL)
+	if err != nil || u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
+
 // MCP tools/call request and response
 type mcpCallParams struct {
 	Name      string                 `json:"name"`
@@ -284,6 +435,17 @@ func (c *Client) callMCP(ctx context.Context, apiKey, action string, params map[
 			it = "educational"
 		}
 		args["input_type"] = it
+		if cc := getStr(params, "cache_control"); cc != "" {
+			args["cache_control"] = cc
+		}
+	case "extract_content":
+		args["data_base64"] = getStr(params, "data_base64")
+		args["mime_type"] = getStr(params, "mime_type")
+		it := getStr(params, "input_type")
+		if it == "" {
+			it = "educational"
+		}
+		args["input_type"] = it
 	case "generate_narration", "generate_audio":
 		return nil, fmt.Errorf("MCP does not support action %s (use gRPC for audio)", action)
 	case "generate_image_prompt":
@@ -294,6 +456,9 @@ func (c *Client) callMCP(ctx context.Context, apiKey, action string, params map[
 			it = "educational"
 		}
 		args["input_type"] = it
+		if cc := getStr(params, "cache_control"); cc != "" {
+			args["cache_control"] = cc
+		}
 	case "generate_image":
 		mcpAction = "generate_image"
 		args["prompt"] = getStr(params, "prompt")
@@ -317,6 +482,10 @@ func (c *Client) callMCP(ctx context.Context, apiKey, action string, params map[
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+apiKey)
+	sig := auth.NewRequestSignature(apiKey, http.MethodPost+" "+mcpPath(c.mcpURL), bodyBytes)
+	req.Header.Set(auth.HeaderSignatureTimestamp, sig.Timestamp)
+	req.Header.Set(auth.HeaderSignatureNonce, sig.Nonce)
+	req.Header.Set(auth.HeaderSignature, sig.Signature)
 	resp, err := c.httpCli.Do(req)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,350 @@
+// Package anomaly watches rolling failure rates of the worker's Gemini calls (per model and client method)
+// and job outcomes (per error code) and raises an alert when one stays above a threshold over the window,
+// so incidents such as image generation failing for most segments surface before customers report them.
+package anomaly
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/egress"
+)
+
+// Kinds of failure rates.
+const (
+	KindModel     = "model"      // Gemini calls of a model and client method, e.g. gemini-2.5-flash-image/GenerateImage
+	KindErrorCode = "error_code" // finished jobs that failed with the error code
+)
+
+// Alert events.
+const (
+	EventFiring   = "alert.firing"
+	EventResolved = "alert.resolved"
+)
+
+// bucketsPerWindow is the resolution of the rolling window.
+const bucketsPerWindow = 10
+
+// Key identifies a failure rate.
+type Key struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// Alert is a failure rate crossing the threshold (firing) or falling back under it (resolved). It is
+// logged and posted to the alert webhook as JSON.
+type Alert struct {
+	Event         string    `json:"event"`
+	Key           Key       `json:"key"`
+	FailureRate   float64   `json:"failure_rate"`
+	Failures      int       `json:"failures"`
+	Total         int       `json:"total"`
+	Threshold     float64   `json:"threshold"`
+	WindowSeconds int       `json:"window_seconds"`
+	Replica       string    `json:"replica"` // host name; each worker replica watches its own traffic
+	At            time.Time `json:"at"`
+}
+
+// Config holds the monitor settings.
+type Config struct {
+	Interval      time.Duration // how often rates are checked; 0 disables the monitor
+	Window        time.Duration // rates cover the outcomes of this long
+	Threshold     float64       // failure rate (0-1) above which an alert fires
+	MinSamples    int           // rates over fewer outcomes never fire
+	WebhookURL    string        // optional; alerts are always logged
+	WebhookSecret string        // signs webhook bodies (X-GS-Signature, as job webhooks)
+}
+
+// Validate checks the window and threshold of an enabled monitor.
+func (c Config) Validate() error {
+	if c.Interval <= 0 {
+		return nil
+	}
+	if c.Window <= 0 {
+		return fmt.Errorf("window must be positive")
+	}
+	if c.Threshold < 0 || c.Threshold >= 1 {
+		return fmt.Errorf("failure rate threshold must be in [0, 1)")
+	}
+	return nil
+}
+
+type bucket struct {
+	start    time.Time
+	total    int
+	failures int
+}
+
+// Monitor counts outcomes in a rolling window per key and alerts on transitions. It is safe for
+// concurrent use; a nil *Monitor records nothing.
+type Monitor struct {
+	cfg     Config
+	client  *http.Client
+	replica string
+	now     func() time.Time
+
+	mu      sync.Mutex
+	buckets map[Key][]bucket
+	jobs    []bucket // finished jobs, the denominator of error code rates
+	firing  map[Key]bool
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// New creates a monitor.
+func New(cfg Config) *Monitor {
+	replica, _ := os.Hostname()
+	return &Monitor{
+		cfg:      cfg,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		replica:  replica,
+		now:      time.Now,
+		buckets:  make(map[Key][]bucket),
+		firing:   make(map[Key]bool),
+		stopChan: make(chan struct{}),
+	}
+}
+
+// SetEgress checks the alert webhook URL against policy and sends alerts with policy's client, which
+// refuses non-public addresses when dialing and does not follow redirects.
+func (m *Monitor) SetEgress(policy *egress.Policy) error {
+	if m.cfg.WebhookURL != "" {
+		if err := policy.CheckURL(m.cfg.WebhookURL, nil); err != nil {
+			return err
+		}
+	}
+	m.client = policy.Client(10 * time.Second)
+	return nil
+}
+
+// RecordCall counts a Gemini call of model made by operation (the llm.Client method).
+func (m *Monitor) RecordCall(model, operation string, failed bool) {
+	if m == nil {
+		return
+	}
+	name := model
+	if operation != "" {
+		name += "/" + operation
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	key := Key{Kind: KindModel, Name: name}
+	m.buckets[key] = m.add(m.buckets[key], now, failed)
+}
+
+// RecordJob counts a finished job; errorCode is empty for jobs that succeeded.
+func (m *Monitor) RecordJob(errorCode string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	m.jobs = m.add(m.jobs, now, errorCode != "")
+	if errorCode != "" {
+		key := Key{Kind: KindErrorCode, Name: errorCode}
+		m.buckets[key] = m.add(m.buckets[key], now, true)
+	}
+}
+
+// add counts an outcome in the current bucket of buckets, dropping buckets older than the window.
+func (m *Monitor) add(buckets []bucket, now time.Time, failed bool) []bucket {
+	buckets = m.prune(buckets, now)
+	width := m.cfg.Window / bucketsPerWindow
+	if n := len(buckets); n == 0 || now.Sub(buckets[n-1].start) >= width {
+		buckets = append(buckets, bucket{start: now})
+	}
+	b := &buckets[len(buckets)-1]
+	b.total++
+	if failed {
+		b.failures++
+	}
+	return buckets
+}
+
+func (m *Monitor) prune(buckets []bucket, now time.Time) []bucket {
+	i := 0
+	for i < len(buckets) && now.Sub(buckets[i].start) >= m.cfg.Window {
+		i++
+	}
+	return buckets[i:]
+}
+
+func sum(buckets []bucket) (total, failures int) {
+	for _, b := range buckets {
+		total += b.total
+		failures += b.failures
+	}
+	return total, failures
+}
+
+// Check computes every rate over the window and returns the alerts of the keys whose state changed:
+// firing when a rate with at least MinSamples outcomes exceeds the threshold, resolved when a firing
+// rate no longer does (or has no outcomes left).
+func (m *Monitor) Check() []Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+
+	m.jobs = m.prune(m.jobs, now)
+	jobsTotal, _ := sum(m.jobs)
+	keys := make([]Key, 0, len(m.buckets))
+	for key := range m.buckets {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Kind < keys[j].Kind || keys[i].Kind == keys[j].Kind && keys[i].Name < keys[j].Name
+	})
+
+	var alerts []Alert
+	for _, key := range keys {
+		buckets := m.prune(m.buckets[key], now)
+		if len(buckets) == 0 {
+			delete(m.buckets, key)
+		} else {
+			m.buckets[key] = buckets
+		}
+		total, failures := sum(buckets)
+		if key.Kind == KindErrorCode {
+			total = jobsTotal
+		}
+		rate := 0.0
+		if total > 0 {
+			rate = float64(failures) / float64(total)
+		}
+		above := total >= m.cfg.MinSamples && rate > m.cfg.Threshold
+		if above == m.firing[key] {
+			continue
+		}
+		event := EventFiring
+		if above {
+			m.firing[key] = true
+		} else {
+			event = EventResolved
+			delete(m.firing, key)
+		}
+		alerts = append(alerts, Alert{
+			Event:         event,
+			Key:           key,
+			FailureRate:   rate,
+			Failures:      failures,
+			Total:         total,
+			Threshold:     m.cfg.Threshold,
+			WindowSeconds: int(m.cfg.Window.Seconds()),
+			Replica:       m.replica,
+			At:            now,
+		})
+	}
+	return alerts
+}
+
+// Start checks the rates every Interval in the background until ctx is cancelled or Stop is called,
+// logging and posting each alert. It does nothing when the interval is zero.
+func (m *Monitor) Start(ctx context.Context) {
+	if m == nil || m.cfg.Interval <= 0 {
+		log.Info().Msg("Anomaly monitor disabled")
+		return
+	}
+	ticker := time.NewTicker(m.cfg.Interval)
+	go func() {
+		defer ticker.Stop()
+		log.Info().
+			Dur("interval", m.cfg.Interval).
+			Dur("window", m.cfg.Window).
+			Float64("threshold", m.cfg.Threshold).
+			Int("min_samples", m.cfg.MinSamples).
+			Msg("Anomaly monitor started")
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-m.stopChan:
+				log.Info().Msg("Anomaly monitor stopped")
+				return
+			case <-ticker.C:
+				for _, alert := range m.Check() {
+					m.notify(ctx, alert)
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops the monitor. Safe to call multiple times.
+func (m *Monitor) Stop() {
+	if m == nil {
+		return
+	}
+	m.stopOnce.Do(func() {
+		close(m.stopChan)
+	})
+}
+
+// notify logs alert and posts it to the alert webhook. A failed post is logged and not retried: the
+// next transition is posted regardless.
+func (m *Monitor) notify(ctx context.Context, alert Alert) {
+	ev := log.Info()
+	msg := "Failure rate back to normal"
+	if alert.Event == EventFiring {
+		ev = log.Error().Bool("alert", true)
+		msg = "Failure rate above threshold"
+	}
+	ev.Str("alert_event", alert.Event).
+		Str("alert_kind", alert.Key.Kind).
+		Str("alert_name", alert.Key.Name).
+		Float64("failure_rate", alert.FailureRate).
+		Int("failures", alert.Failures).
+		Int("total", alert.Total).
+		Float64("threshold", alert.Threshold).
+		Int("window_seconds", alert.WindowSeconds).
+		Msg(msg)
+
+	if m.cfg.WebhookURL == "" {
+		return
+	}
+	if err := m.post(ctx, alert); err != nil {
+		log.Warn().Err(err).Str("alert_name", alert.Key.Name).Msg("Failed to post alert webhook")
+	}
+}
+
+func (m *Monitor) post(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.cfg.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Stories-Alerts/1.0")
+	if m.cfg.WebhookSecret != "" {
+		h := hmac.New(sha256.New, []byte(m.cfg.WebhookSecret))
+		h.Write(body)
+		req.Header.Set("X-GS-Signature", hex.EncodeToString(h.Sum(nil)))
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("alert webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package anomaly
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/snappy-loop/stories/internal/egress"
+)
+
+func newTestMonitor(cfg Config) (*Monitor, *time.Time) {
+	m := New(cfg)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	return m, &now
+}
+
+func TestMonitor_ModelRate(t *testing.T) {
+	m, now := newTestMonitor(Config{Window: 10 * time.Minute, Threshold: 0.2, MinSamples: 5})
+
+	// 1 of 4 failing: above the threshold but too few samples
+	for i := range 4 {
+		m.RecordCall("image-model", "GenerateImage", i == 0)
+	}
+	if alerts := m.Check(); len(alerts) != 0 {
+		t.Fatalf("alerts = %+v, want none below MinSamples", alerts)
+	}
+	m.RecordCall("image-model", "GenerateImage", false)
+	m.RecordCall("text-model", "GenerateNarration", false)
+	*now = now.Add(time.Minute)
+	m.RecordCall("image-model", "GenerateImage", true)
+
+	alerts := m.Check()
+	if len(alerts) != 1 {
+		t.Fatalf("alerts = %+v, want one", alerts)
+	}
+	a := alerts[0]
+	if a.Event != EventFiring || a.Key != (Key{KindModel, "image-model/GenerateImage"}) || a.Failures != 2 || a.Total != 6 {
+		t.Errorf("alert = %+v", a)
+	}
+	// Still firing: no new alert
+	if alerts := m.Check(); len(alerts) != 0 {
+		t.Errorf("alerts = %+v, want none while still firing", alerts)
+	}
+
+	// The failures age out of the window while calls succeed
+	*now = now.Add(9*time.Minute + 30*time.Second)
+	for range 5 {
+		m.RecordCall("image-model", "GenerateImage", false)
+	}
+	alerts = m.Check()
+	if len(alerts) != 1 || alerts[0].Event != EventResolved || alerts[0].Failures != 1 || alerts[0].Total != 6 {
+		t.Errorf("alerts = %+v, want resolved at 1/6", alerts)
+	}
+
+	// A firing rate whose outcomes all age out resolves too
+	for range 5 {
+		m.RecordCall("tts-model", "GenerateAudio", true)
+	}
+	if alerts := m.Check(); len(alerts) != 1 || alerts[0].Event != EventFiring {
+		t.Fatalf("alerts = %+v, want tts firing", alerts)
+	}
+	*now = now.Add(11 * time.Minute)
+	if alerts := m.Check(); len(alerts) != 1 || alerts[0].Event != EventResolved || alerts[0].Key.Name != "tts-model/GenerateAudio" {
+		t.Errorf("alerts = %+v, want tts resolved", alerts)
+	}
+}
+
+func TestMonitor_ErrorCodeRate(t *testing.T) {
+	m, _ := newTestMonitor(Config{Window: 10 * time.Minute, Threshold: 0.2, MinSamples: 5})
+	for range 7 {
+		m.RecordJob("")
+	}
+	m.RecordJob("worker_timeout")
+	m.RecordJob("processing_error")
+	m.RecordJob("processing_error")
+	m.RecordJob("processing_error")
+
+	alerts := m.Check()
+	if len(alerts) != 1 {
+		t.Fatalf("alerts = %+v, want one", alerts)
+	}
+	// processing_error: 3 of 11 finished jobs; worker_timeout 1 of 11 stays quiet
+	if a := alerts[0]; a.Key != (Key{KindErrorCode, "processing_error"}) || a.Failures != 3 || a.Total != 11 {
+		t.Errorf("alert = %+v", a)
+	}
+}
+
+func TestMonitor_Webhook(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		received <- r
+	}))
+	defer srv.Close()
+
+	m, _ := newTestMonitor(Config{Window: time.Minute, Threshold: 0.5, MinSamples: 1, WebhookURL: srv.URL, WebhookSecret: "s3cret"})
+	if err := m.SetEgress(egress.NewPolicy(nil, nil, false)); !errors.Is(err, egress.ErrDenied) {
+		t.Errorf("loopback webhook: err = %v, want egress.ErrDenied", err)
+	}
+	if err := m.SetEgress(egress.NewPolicy(nil, nil, true)); err != nil {
+		t.Fatalf("SetEgress: %v", err)
+	}
+	m.notify(context.Background(), Alert{Event: EventFiring, Key: Key{KindModel, "m/GenerateImage"}, FailureRate: 1, Failures: 2, Total: 2})
+
+	r := <-received
+	var alert Alert
+	if err := json.Unmarshal(body, &alert); err != nil || alert.Event != EventFiring || alert.Key.Name != "m/GenerateImage" {
+		t.Errorf("body = %s (%v)", body, err)
+	}
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	if got := r.Header.Get("X-GS-Signature"); got != hex.EncodeToString(mac.Sum(nil)) {
+		t.Errorf("X-GS-Signature = %q", got)
+	}
+}
+
+func TestMonitor_Nil(t *testing.T) {
+	var m *Monitor
+	m.RecordCall("m", "GenerateImage", true)
+	m.RecordJob("processing_error")
+	m.Start(context.Background())
+	m.Stop()
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/i18n"
 	"github.com/snappy-loop/stories/internal/models"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -41,19 +42,19 @@ func (s *Service) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			writeJSONError(w, http.StatusUnauthorized, "missing authorization header")
+			writeJSONError(w, r, http.StatusUnauthorized, "missing authorization header")
 			return
 		}
 
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			writeJSONError(w, http.StatusUnauthorized, "invalid authorization header format")
+			writeJSONError(w, r, http.StatusUnauthorized, "invalid authorization header format")
 			return
 		}
 
 		apiKey := parts[1]
 		if apiKey == "" {
-			writeJSONError(w, http.StatusUnauthorized, "empty api key")
+			writeJSONError(w, r, http.StatusUnauthorized, "empty api key")
 			return
 		}
 
@@ -65,21 +66,27 @@ func (s *Service) Middleware(next http.Handler) http.Handler {
 		}
 		if err != nil {
 			log.Debug().Msg("API key not found")
-			writeJSONError(w, http.StatusUnauthorized, "invalid api key")
+			writeJSONError(w, r, http.StatusUnauthorized, "invalid api key")
 			return
 		}
 
 		// Check if key is active
 		if storedKey.Status != "active" {
 			log.Warn().Str("key_id", storedKey.ID.String()).Msg("API key is not active")
-			writeJSONError(w, http.StatusUnauthorized, "api key is disabled")
+			writeJSONError(w, r, http.StatusUnauthorized, "api key is disabled")
+			return
+		}
+
+		// Keys restricted to scopes without api (e.g. agent deployments) cannot use the REST API
+		if !storedKey.HasScope(models.ScopeAPI) {
+			writeJSONError(w, r, http.StatusForbidden, "api key is not allowed to use the REST API")
 			return
 		}
 
 		// Verify key: bcrypt for new keys; legacy keys store plain key in KeyHash
 		if err := bcrypt.CompareHashAndPassword([]byte(storedKey.KeyHash), []byte(apiKey)); err != nil {
 			if storedKey.KeyHash != apiKey {
-				writeJSONError(w, http.StatusUnauthorized, "invalid api key")
+				writeJSONError(w, r, http.StatusUnauthorized, "invalid api key")
 				return
 			}
 		}
@@ -142,8 +149,12 @@ func hashAPIKeySimple(apiKey string) string {
 	return apiKey
 }
 
-func writeJSONError(w http.ResponseWriter, status int, message string) {
+// writeJSONError writes message as {"error", "code"}, translated to the language of r's Accept-Language
+// when the message is in the i18n catalog.
+func writeJSONError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	msg := i18n.Localize(r, status, message)
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", msg.Language.String())
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
+	json.NewEncoder(w).Encode(map[string]string{"error": msg.Text, "code": msg.Code})
 }
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSignatureVerifier_Verify(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1792324800, 0)
+	nonces := NewMemoryNonceStore()
+	nonces.now = func() time.Time { return now }
+	v, err := NewSignatureVerifier(SigningOptional, 5*time.Minute, nonces)
+	if err != nil {
+		t.Fatalf("NewSignatureVerifier: %v", err)
+	}
+	v.now = func() time.Time { return now }
+
+	body := []byte(`{"method":"tools/call"}`)
+	signed := func(key, nonce string, at time.Time) RequestSignature {
+		ts := strconv.FormatInt(at.Unix(), 10)
+		return RequestSignature{Timestamp: ts, Nonce: nonce, Signature: Sign(key, ts, nonce, "POST /", body)}
+	}
+
+	if err := v.Verify(ctx, "key-a", RequestSignature{}, "POST /", body); err != nil {
+		t.Errorf("unsigned request in optional mode: %v", err)
+	}
+	if err := v.Verify(ctx, "key-a", signed("key-a", "n1", now.Add(-time.Minute)), "POST /", body); err != nil {
+		t.Errorf("valid signature: %v", err)
+	}
+	if err := v.Verify(ctx, "key-a", signed("key-a", "n1", now.Add(-time.Minute)), "POST /", body); !errors.Is(err, ErrNonceReused) {
+		t.Errorf("replayed request: err = %v, want ErrNonceReused", err)
+	}
+	// Nonces are per key
+	if err := v.Verify(ctx, "key-b", signed("key-b", "n1", now), "POST /", body); err != nil {
+		t.Errorf("same nonce with another key: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		sig    RequestSignature
+		target string
+		body   []byte
+		want   error
+	}{
+		{"stale", signed("key-a", "n2", now.Add(-6*time.Minute)), "POST /", body, ErrSignatureExpired},
+		{"future", signed("key-a", "n3", now.Add(6*time.Minute)), "POST /", body, ErrSignatureExpired},
+		{"wrong key", signed("key-b", "n4", now), "POST /", body, ErrSignatureInvalid},
+		{"other target", signed("key-a", "n5", now), "POST /other", body, ErrSignatureInvalid},
+		{"tampered body", signed("key-a", "n6", now), "POST /", []byte(`{}`), ErrSignatureInvalid},
+		{"partial", RequestSignature{Nonce: "n7"}, "POST /", body, ErrSignatureInvalid},
+		{"bad timestamp", RequestSignature{Timestamp: "x", Nonce: "n8", Signature: "00"}, "POST /", body, ErrSignatureInvalid},
+	}
+	for _, tt := range tests {
+		if err := v.Verify(ctx, "key-a", tt.sig, tt.target, tt.body); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	// Nonces expire after twice the window, when their timestamp is refused anyway
+	now = now.Add(11 * time.Minute)
+	if err := v.Verify(ctx, "key-a", signed("key-a", "n9", now), "POST /", body); err != nil {
+		t.Fatalf("valid signature: %v", err)
+	}
+	if len(nonces.expires) != 1 {
+		t.Errorf("%d nonces kept, want the last one only", len(nonces.expires))
+	}
+}
+
+func TestSignatureVerifier_Modes(t *testing.T) {
+	ctx := context.Background()
+	if _, err := NewSignatureVerifier("sometimes", time.Minute, NewMemoryNonceStore()); err == nil {
+		t.Error("invalid mode accepted")
+	}
+	required, err := NewSignatureVerifier(SigningRequired, time.Minute, NewMemoryNonceStore())
+	if err != nil {
+		t.Fatalf("NewSignatureVerifier: %v", err)
+	}
+	if err := required.Verify(ctx, "key", RequestSignature{}, "/pkg.Service/Method", nil); !errors.Is(err, ErrSignatureMissing) {
+		t.Errorf("unsigned request in required mode: err = %v", err)
+	}
+	if err := required.Verify(ctx, "key", NewRequestSignature("key", "/pkg.Service/Method", nil), "/pkg.Service/Method", nil); err != nil {
+		t.Errorf("signed request in required mode: %v", err)
+	}
+	var off *SignatureVerifier
+	if err := off.Verify(ctx, "key", RequestSignature{Nonce: "x"}, "/pkg.Service/Method", nil); err != nil {
+		t.Errorf("nil verifier: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+// Package buildinfo identifies the build of the running binary. Version, Commit and BuildTime are set at
+// link time:
+//
+//	go build -ldflags "-X github.com/snappy-loop/stories/internal/buildinfo.Version=v1.4.0 \
+//	  -X github.com/snappy-loop/stories/internal/buildinfo.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/snappy-loop/stories/internal/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Unset values fall back to the VCS stamp the go tool embeds when building from a git checkout.
+package buildinfo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+)
+
+// Set with -ldflags -X.
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info is the build of the running binary, as served by GET /version.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"` // built from a checkout with uncommitted changes
+	GoVersion string `json:"go_version"`
+
+	FeatureFlags map[string]int `json:"feature_flags,omitempty"` // rollout percentage by flag (API only)
+}
+
+// Get returns the build of the running binary.
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, BuildTime: BuildTime, GoVersion: runtime.Version()}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = s.Value
+				}
+			case "vcs.modified":
+				info.Modified = s.Value == "true"
+			}
+		}
+	}
+	return info
+}
+
+// Handler returns the handler of GET /version: the build as JSON, with the rollouts of the feature flags
+// when flags is not nil. It needs no authentication.
+func Handler(flags func(ctx context.Context) map[string]int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		info := Get()
+		if flags != nil {
+			info.FeatureFlags = flags(r.Context())
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(info)
+	}
+}
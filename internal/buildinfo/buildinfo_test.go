@@ -0,0 +1,34 @@
+package buildinfo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	defer func(v, c string) { Version, Commit = v, c }(Version, Commit)
+	Version, Commit = "v1.2.3", "0123abcd"
+
+	flags := func(context.Context) map[string]int { return map[string]int{"fact_check": 25} }
+	rec := httptest.NewRecorder()
+	Handler(flags)(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var got Info
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("body %q: %v", rec.Body, err)
+	}
+	if got.Version != "v1.2.3" || got.Commit != "0123abcd" || got.GoVersion == "" || got.FeatureFlags["fact_check"] != 25 {
+		t.Errorf("got %+v", got)
+	}
+
+	rec = httptest.NewRecorder()
+	Handler(nil)(rec, httptest.NewRequest(http.MethodPost, "/version", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", rec.Code)
+	}
+}
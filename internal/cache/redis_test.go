@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis serves GET, SET, PING and AUTH from memory over RESP.
+type fakeRedis struct {
+	mu   sync.Mutex
+	data map[string]string
+	cmds []string
+}
+
+func startFakeRedis(t *testing.T) (*fakeRedis, string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	f := &fakeRedis{data: make(map[string]string)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f, ln.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, a := range reply.([]any) {
+			args = append(args, string(a.([]byte)))
+		}
+		f.mu.Lock()
+		f.cmds = append(f.cmds, args[0])
+		var out string
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			out = "+PONG\r\n"
+		case "AUTH":
+			out = "+OK\r\n"
+			if args[len(args)-1] != "secret" {
+				out = "-WRONGPASS invalid password\r\n"
+			}
+		case "SET":
+			f.data[args[1]] = args[2]
+			out = "+OK\r\n"
+		case "GET":
+			if v, ok := f.data[args[1]]; ok {
+				out = "$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n"
+			} else {
+				out = "$-1\r\n"
+			}
+		default:
+			out = "-ERR unknown command\r\n"
+		}
+		f.mu.Unlock()
+		conn.Write([]byte(out))
+	}
+}
+
+func TestRedis_GetSet(t *testing.T) {
+	f, addr := startFakeRedis(t)
+	r, err := NewRedis("redis://:secret@"+addr+"/0", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := r.Ping(ctx); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if v, err := r.Get(ctx, "missing"); err != nil || v != nil {
+		t.Fatalf("Get(missing) = %q, %v; want nil, nil", v, err)
+	}
+	if err := r.Set(ctx, "k", []byte("line1\r\nline2"), time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if v, err := r.Get(ctx, "k"); err != nil || string(v) != "line1\r\nline2" {
+		t.Fatalf("Get(k) = %q, %v", v, err)
+	}
+
+	var redisErr RedisError
+	if _, err := r.Do(ctx, "FLUSHALL"); !errors.As(err, &redisErr) {
+		t.Fatalf("unknown command error = %v, want RedisError", err)
+	}
+	// The connection survives an error reply and is reused: AUTH ran once.
+	if err := r.Ping(ctx); err != nil {
+		t.Fatalf("Ping after error reply: %v", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	auths := 0
+	for _, c := range f.cmds {
+		if c == "AUTH" {
+			auths++
+		}
+	}
+	if auths != 1 {
+		t.Errorf("AUTH sent %d times, want 1 (pooled connection)", auths)
+	}
+}
+
+func TestNewRedis_InvalidURL(t *testing.T) {
+	for _, u := range []string{"http://localhost", "redis://localhost/abc"} {
+		if _, err := NewRedis(u, 1); err == nil {
+			t.Errorf("NewRedis(%q) succeeded, want error", u)
+		}
+	}
+}
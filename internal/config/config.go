@@ -3,19 +3,57 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // Config holds application configuration
 type Config struct {
 	// Server
-	HTTPAddr string
-	LogLevel string
-	Timezone string
+	HTTPAddr     string
+	Timezone     string
+	PublicAPIURL string // externally reachable API base URL, used for links such as webhook result_url
+
+	// Logging (see package logging)
+	LogLevel  string
+	LogLevels map[string]string // level per binary (api, worker, dispatcher, agents), overriding LogLevel
+	LogFormat string            // stderr format: console or json
+	LogFile   string            // optional file receiving JSON lines
+	LogSyslog string            // optional syslog sink: local, udp://host:port or tcp://host:port
+
+	// API request deadlines (per route; the server sets only a header read timeout)
+	HTTPRequestTimeout time.Duration // ordinary endpoints
+	HTTPUploadTimeout  time.Duration // file uploads and asset replacements
+	HTTPStreamTimeout  time.Duration // asset downloads (0: no limit)
+	HTTPIdleTimeout    time.Duration // keep-alive connections without requests are closed after this
+	HTTPH2C            bool          // also serve HTTP/2 without TLS (prior knowledge), e.g. behind a proxy speaking h2c
+
+	HTTPConnMetricsInterval time.Duration // how often the API logs connection counts (0 disables)
+
+	// Slow operation warnings (0 disables each): database queries, Gemini calls and S3 operations taking
+	// at least this long are logged as warnings with their identifiers
+	SlowDBQueryThreshold time.Duration
+	SlowLLMCallThreshold time.Duration
+	SlowS3OpThreshold    time.Duration
 
 	// Agents service (gRPC + MCP) — used by agents binary
 	GRPCAddr string
 	MCPAddr  string
+	// Request signing of agent calls (timestamp + nonce + HMAC with the API key): "off", "optional"
+	// (verify signed requests) or "required"; nonces are shared through Redis when REDIS_URL is set
+	AgentsRequestSigning  string
+	AgentsSignatureWindow time.Duration
+	// Agent calls are charged to the caller's character quota (input characters, CHARS_PER_FILE for
+	// extract_content) times a per-tool weight (tool=weight, default 1; 0 makes a tool free)
+	AgentsQuota        bool
+	AgentsQuotaWeights map[string]string
+	// Async agent calls (async tool argument, GenerateImageAsync) get AgentsOperationTimeout to run;
+	// their results are kept for AgentsOperationTTL after they finish
+	AgentsOperationTTL     time.Duration
+	AgentsOperationTimeout time.Duration
+	// MCP generate_image returns images up to this many bytes inline; larger ones are stored in S3 and
+	// linked (refused without S3)
+	AgentsMCPInlineImageLimit int
 
 	// Agents service URLs — used by API to call agents (e.g. localhost:9090 or agents:9090)
 	AgentsGRPCURL string
@@ -24,12 +62,31 @@ type Config struct {
 	// Database
 	DatabaseURL string
 
+	// Redis (optional shared cache for LLM results; empty disables)
+	RedisURL      string
+	RedisCacheTTL time.Duration
+
+	// Limits: per-key API rate limits and Gemini concurrency limits are kept in process memory ("memory")
+	// or shared through Redis by all replicas ("redis", requires REDIS_URL)
+	LimitsBackend      string
+	RateLimitPerMinute int // requests per API key and minute (0 disables)
+	RateLimitBurst     int // requests an idle key may send at once
+
 	// Kafka
 	KafkaBrokers       []string
 	KafkaConsumerGroup string
 	KafkaTopicJobs     string
-	KafkaTopicEvents   string
-	KafkaTopicWebhooks string
+	KafkaTopicEvents   string // job events routed by the dispatcher to webhooks and notification sinks
+	KafkaTopicWebhooks string // legacy webhook events topic, still drained by the dispatcher
+	// Delays of the jobs topic's retry tiers (<jobs topic>.retry.<delay>) for jobs failing transiently;
+	// empty disables retries
+	KafkaJobRetryDelays []time.Duration
+	// Tenant pools: JSON array of kafka.TenantRoute sending some users' or API keys' jobs to dedicated
+	// topics and workers (empty routes everything to the shared pool), and the pools' topic and group names
+	KafkaTenantRoutes        string
+	KafkaTenantTopicTemplate string // default {topic}.tenant.{tenant}
+	KafkaTenantGroupTemplate string // default {group}.tenant.{tenant}
+	WorkerTenant             string // tenant pool this worker serves; empty for the shared pool
 
 	// S3/Storage
 	S3Endpoint  string
@@ -40,36 +97,158 @@ type Config struct {
 	S3UseSSL    bool
 	S3PublicURL string
 
+	// Upload resilience (storage.Client.SetUploadRetries, SetFallbackBucket)
+	S3UploadRetries    int           // retries of a failed upload, with exponential backoff
+	S3UploadRetryDelay time.Duration // wait before the first retry
+	S3UploadVerify     bool          // HEAD each upload and compare size and ETag
+	S3FallbackBucket   string        // bucket uploads go to when S3Bucket keeps failing; empty disables it
+
 	// Gemini API
 	GeminiAPIKey               string
 	GeminiAPIEndpoint          string // if set, overrides default Gemini API base URL (e.g. http://host.docker.internal:31300/gemini)
+	GeminiBackend              string // "gemini" (API key) or "vertex" (Vertex AI with Application Default Credentials)
+	VertexProject              string // GCP project for the vertex backend
+	VertexLocation             string // Vertex AI region for the vertex backend, e.g. us-central1 or global
 	GeminiModelPro             string
 	GeminiModelFlash           string
 	GeminiModelImage           string // image generation, e.g. gemini-3-pro-image-preview
 	GeminiModelTTS             string // TTS model, e.g. gemini-2.5-pro-preview-tts
+	GeminiModelExtract         string // vision extraction of typed files; empty uses GeminiModelPro (handwriting and tables always do)
 	GeminiTTSVoice             string // TTS voice name, e.g. Zephyr, Puck, Aoede
 	GeminiModelSegmentPrimary  string // primary model for segmentation, e.g. gemini-3.0-flash
 	GeminiModelSegmentFallback string // fallback model for segmentation, e.g. gemini-2.5-flash-lite
+	LLMExperiments             string // JSON array of A/B experiments (see llm.ParseExperiments); empty disables them
+	InputTypeProfiles          string // JSON array of input type profiles (see inputtype.Parse); empty uses the built-in types
+	PipelineHooks              string // JSON array of pipeline hooks (see hooks.Parse); empty runs none
+	// Process-wide limits on concurrent Gemini calls per model family, across all jobs (0 = unlimited)
+	GeminiMaxConcurrentPro   int
+	GeminiMaxConcurrentFlash int
+	GeminiMaxConcurrentImage int
+	GeminiMaxConcurrentTTS   int
+	GeminiAdaptiveRateLimit  bool // throttle and retry on 429s instead of failing calls
+
+	// User content and model output in Gemini logs: full, redacted or none, in a sampled fraction (0-1) of
+	// the entries; metadata is always logged
+	GeminiLogContent    string
+	GeminiLogSampleRate float64
+
+	// LazyImages makes the API generate the images of jobs created with lazy_images on their first
+	// download, with the Gemini settings above; without it such jobs are refused
+	LazyImages bool
 
 	// Processing
 	MaxInputLength        int
 	MaxSegmentsCount      int
 	MaxConcurrentSegments int
+	JobDetailPageSize     int    // segments/assets embedded in GET /v1/jobs/{id} before paging (default 100)
+	SegmentationStrategy  string // default for jobs that don't set segmentation_strategy: llm or heuristic
+
+	// Stuck job sweeper (worker)
+	StuckSweepInterval time.Duration // how often to look for stuck jobs; 0 disables the sweeper
+	StuckQueuedAfter   time.Duration // queued jobs older than this are republished
+	StuckRunningAfter  time.Duration // running jobs with no heartbeat (see processor.claimRun) for this long are republished
+	StuckMaxRequeues   int           // after this many sweeper requeues a stuck job is failed
+
+	// Anomaly alerts (worker): rolling failure rates of Gemini calls per model and of jobs per error code
+	AnomalyCheckInterval time.Duration // how often rates are checked; 0 disables the monitor
+	AnomalyWindow        time.Duration // rates cover this long
+	AnomalyFailureRate   float64       // alert above this failure rate (0-1)
+	AnomalyMinSamples    int           // rates over fewer outcomes never alert
+	AnomalyWebhookURL    string        // optional; alerts are always logged
+	AnomalyWebhookSecret string        // signs alert webhook bodies
+
+	// Leader election of singleton background loops (webhook and notification retries, stuck job sweeper,
+	// S3 ingestion polling, storage cleanup, Stripe usage reporting): how often followers try to take over; 0
+	// runs them in every replica
+	LeaderElectionInterval time.Duration
+
+	// Quality evaluator (jobs with quality_check)
+	QualityMinScore         int // outputs scoring below this (1-5) are regenerated
+	QualityMaxRegenerations int // regeneration attempts per output; the best-scoring version is kept
+
+	ExternalToolTimeout time.Duration // timeout of a job's external MCP tool call per segment (worker)
 
 	// File upload (multi-modal input)
 	MaxFileSize       int64 // max size per file in bytes (default 10MB)
 	MaxFilesPerJob    int   // max files per job (default 10)
 	FileExpirationHrs int   // hours until unused file expires (default 24)
-	CharsPerFile      int   // quota cost in chars per file (default 1000)
+	// FileUploadURLExpiry is how long presigned direct uploads (POST /v1/files/presign) work (default 15m)
+	FileUploadURLExpiry time.Duration
+	CharsPerFile        int  // quota cost in chars per file (default 1000)
+	PDFPagesPerBatch    int  // PDF pages per vision extraction request (default 10); 0 extracts each PDF whole
+	PDFLocalText        bool // read PDF text layers locally and send only scanned pages to vision (default true)
+
+	// S3 drop-folder ingestion: objects under <IngestPrefix><folder>/ become a file and a job of the API
+	// key IngestRoutes maps the folder to
+	IngestPrefix        string        // key prefix in S3_BUCKET, e.g. "ingest/"; empty disables ingestion
+	IngestRoutes        string        // "folder=<api key id>,..."
+	IngestPollInterval  time.Duration // how often the API lists the prefix (default 30s); 0 relies on the event webhook
+	IngestWebhookToken  string        // bearer token of POST /ingest/s3-events; empty disables the webhook
+	IngestJobType       string        // type of ingested jobs (default educational)
+	IngestSegmentsCount int           // segments_count of ingested jobs (default 5)
+	IngestAudioType     string        // audio_type of ingested jobs (default free_speech)
+
+	// Job deletion: the S3 objects of deleted jobs are removed by the API's storage cleanup loop
+	StorageCleanupInterval time.Duration // how often it deletes the objects due (default 30s); failed deletes back off up to an hour
+
+	// Job retention: jobs created with expires_at or retention_days are expired by the API's job expiry loop
+	JobExpiryInterval time.Duration // how often it expires the jobs due (default 5m); 0 disables the loop
+
+	// Stripe metered billing (dispatcher): usage ledger entries of users linked to a Stripe customer are
+	// reported as meter events
+	StripeAPIKey            string        // Stripe secret key; empty disables reporting
+	StripeAPIURL            string        // default https://api.stripe.com
+	StripeReportInterval    time.Duration // how often unreported usage is sent (default 1m)
+	StripeMeterJobs         string        // meter event name of completed jobs; "none" does not report them
+	StripeMeterChars        string        // meter event name of characters charged
+	StripeMeterAudioSeconds string        // meter event name of audio seconds generated
+
+	// Admin API (/admin/...): requests carry "Authorization: Bearer <AdminAPIToken>"
+	AdminAPIToken string // empty disables the admin API
+
+	// Feature flags (package features): rollouts of pipeline steps, overridable through the admin API
+	FeatureFlags        map[string]string // flag=on, flag=off or flag=25% (see features.ParseRollouts)
+	FeatureFlagsRefresh time.Duration     // how often API and worker reload the admin-set flags
+
+	// Maintenance mode (PUT /admin/maintenance) pauses job intake and workers
+	MaintenanceRefresh time.Duration // how often API and worker check it
 
 	// Quota
 	DefaultQuotaChars  int64
 	DefaultQuotaPeriod string
 
+	// Cost guardrails: estimated LLM cost per API key and period, capped per key (storiesctl set-cost-cap)
+	LLMPricing    map[string]string // model=input:output USD per million tokens; unpriced models cost nothing
+	CostCapAction string            // reject (new jobs fail at creation) or queue (jobs wait for the next period)
+
 	// Webhook
 	WebhookMaxRetries     int
 	WebhookRetryBaseDelay time.Duration
 	WebhookRetryMaxDelay  time.Duration
+	WebhookRetryWorkers   int // retries in flight at once
+	WebhookRetryPerHost   int // retries in flight at once per destination host
+	WebhookRetryBatch     int // pending deliveries loaded per retry tick
+
+	// Egress: destinations of job webhooks, notification sinks, external MCP tools, pipeline hook callbacks and
+	// the alert webhook (see package egress)
+	EgressAllowHosts   []string // when set, the only hosts (and their subdomains) requests may go to
+	EgressDenyHosts    []string // hosts (and their subdomains) requests never go to
+	EgressAllowPrivate bool     // allow private, loopback and link-local addresses (local development)
+
+	// Outbound proxy per component (see package proxy): empty uses HTTP_PROXY, HTTPS_PROXY and NO_PROXY,
+	// "direct" connects without a proxy, and a URL (http, https or socks5) is that component's proxy
+	WebhookProxy string // job webhooks and chat notification sinks (dispatcher)
+	GeminiProxy  string // Gemini API and Vertex AI calls
+	S3Proxy      string // asset and file storage
+
+	// Notification sinks (email via SMTP; Slack, webhook and SNS sinks need no settings). SNS uses the
+	// default AWS credential chain; SNSEndpoint overrides the regional endpoint (e.g. LocalStack).
+	SMTPHost     string // empty disables email sinks
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+	SNSEndpoint  string
 
 	// Observability
 	SentryDSN             string
@@ -82,22 +261,64 @@ type Config struct {
 func Load() *Config {
 	return &Config{
 		HTTPAddr: getEnv("HTTP_ADDR", ":8080"),
-		LogLevel: getEnv("LOG_LEVEL", "info"),
 		Timezone: getEnv("TZ", "UTC"),
 
+		LogLevel:  getEnv("LOG_LEVEL", "info"),
+		LogLevels: getEnvMap("LOG_LEVELS"),
+		LogFormat: getEnv("LOG_FORMAT", "console"),
+		LogFile:   getEnv("LOG_FILE", ""),
+		LogSyslog: getEnv("LOG_SYSLOG", ""),
+
+		PublicAPIURL: getEnv("PUBLIC_API_URL", "http://localhost:8080"),
+
+		HTTPRequestTimeout: getEnvDuration("HTTP_REQUEST_TIMEOUT", 15*time.Second),
+		HTTPUploadTimeout:  getEnvDuration("HTTP_UPLOAD_TIMEOUT", 10*time.Minute),
+		HTTPStreamTimeout:  getEnvDuration("HTTP_STREAM_TIMEOUT", time.Hour),
+		HTTPIdleTimeout:    getEnvDuration("HTTP_IDLE_TIMEOUT", 2*time.Minute),
+		HTTPH2C:            getEnvBool("HTTP_H2C", false),
+
+		HTTPConnMetricsInterval: getEnvDuration("HTTP_CONN_METRICS_INTERVAL", time.Minute),
+
+		SlowDBQueryThreshold: getEnvDuration("SLOW_DB_QUERY_THRESHOLD", 500*time.Millisecond),
+		SlowLLMCallThreshold: getEnvDuration("SLOW_LLM_CALL_THRESHOLD", 60*time.Second),
+		SlowS3OpThreshold:    getEnvDuration("SLOW_S3_OP_THRESHOLD", 2*time.Second),
+
 		GRPCAddr: getEnv("GRPC_ADDR", ":9090"),
 		MCPAddr:  getEnv("MCP_ADDR", ":9091"),
 
+		AgentsRequestSigning:  getEnv("AGENTS_REQUEST_SIGNING", "off"),
+		AgentsSignatureWindow: getEnvDuration("AGENTS_SIGNATURE_WINDOW", 5*time.Minute),
+		AgentsQuota:           getEnvBool("AGENTS_QUOTA", true),
+		AgentsQuotaWeights:    getEnvMap("AGENTS_QUOTA_WEIGHTS"),
+
+		AgentsOperationTTL:     getEnvDuration("AGENTS_OPERATION_TTL", time.Hour),
+		AgentsOperationTimeout: getEnvDuration("AGENTS_OPERATION_TIMEOUT", 10*time.Minute),
+
+		AgentsMCPInlineImageLimit: getEnvInt("AGENTS_MCP_INLINE_IMAGE_LIMIT", 4<<20),
+
 		AgentsGRPCURL: getEnv("AGENTS_GRPC_URL", ""),
 		AgentsMCPURL:  getEnv("AGENTS_MCP_URL", ""),
 
 		DatabaseURL: getEnv("DATABASE_URL", ""),
 
+		RedisURL:      getEnv("REDIS_URL", ""),
+		RedisCacheTTL: getEnvDuration("REDIS_CACHE_TTL", 7*24*time.Hour),
+
+		LimitsBackend:      getEnv("LIMITS_BACKEND", "memory"),
+		RateLimitPerMinute: getEnvInt("RATE_LIMIT_PER_MINUTE", 0),
+		RateLimitBurst:     clampMin(getEnvInt("RATE_LIMIT_BURST", 20), 1),
+
 		KafkaBrokers:       []string{getEnv("KAFKA_BROKERS", "localhost:9092")},
 		KafkaConsumerGroup: getEnv("KAFKA_CONSUMER_GROUP", "stories-worker-main"),
 		KafkaTopicJobs:     getEnv("KAFKA_TOPIC_JOBS", "greatstories.jobs.v1"),
 		KafkaTopicEvents:   getEnv("KAFKA_TOPIC_EVENTS", "greatstories.events.v1"),
 		KafkaTopicWebhooks: getEnv("KAFKA_TOPIC_WEBHOOKS", "greatstories.webhooks.v1"),
+		KafkaJobRetryDelays: getEnvDurations("KAFKA_JOB_RETRY_DELAYS",
+			[]time.Duration{5 * time.Minute, 30 * time.Minute, 2 * time.Hour}),
+		KafkaTenantRoutes:        getEnv("KAFKA_TENANT_ROUTES", ""),
+		KafkaTenantTopicTemplate: getEnv("KAFKA_TENANT_TOPIC_TEMPLATE", "{topic}.tenant.{tenant}"),
+		KafkaTenantGroupTemplate: getEnv("KAFKA_TENANT_GROUP_TEMPLATE", "{group}.tenant.{tenant}"),
+		WorkerTenant:             getEnv("WORKER_TENANT", ""),
 
 		S3Endpoint:  getEnv("S3_ENDPOINT", "http://localhost:9000"),
 		S3Region:    getEnv("S3_REGION", "us-east-1"),
@@ -107,31 +328,124 @@ func Load() *Config {
 		S3UseSSL:    getEnvBool("S3_USE_SSL", false),
 		S3PublicURL: getEnv("S3_PUBLIC_URL", ""),
 
+		S3UploadRetries:    clampMin(getEnvInt("S3_UPLOAD_RETRIES", 3), 0),
+		S3UploadRetryDelay: getEnvDuration("S3_UPLOAD_RETRY_DELAY", 500*time.Millisecond),
+		S3UploadVerify:     getEnvBool("S3_UPLOAD_VERIFY", true),
+		S3FallbackBucket:   getEnv("S3_FALLBACK_BUCKET", ""),
+
 		GeminiAPIKey:               getEnv("GEMINI_API_KEY", ""),
 		GeminiAPIEndpoint:          getEnv("GEMINI_API_ENDPOINT", ""),
+		GeminiBackend:              getEnv("GEMINI_BACKEND", "gemini"),
+		VertexProject:              getEnv("VERTEX_PROJECT", ""),
+		VertexLocation:             getEnv("VERTEX_LOCATION", "us-central1"),
 		GeminiModelPro:             getEnv("GEMINI_MODEL_PRO", "gemini-3-pro-preview"),
 		GeminiModelFlash:           getEnv("GEMINI_MODEL_FLASH", "gemini-2.5-flash-lite"),
 		GeminiModelImage:           getEnv("GEMINI_MODEL_IMAGE", "gemini-3-pro-image-preview"),
 		GeminiModelTTS:             getEnv("GEMINI_MODEL_TTS", "gemini-2.5-pro-preview-tts"),
+		GeminiModelExtract:         getEnv("GEMINI_MODEL_EXTRACT", ""),
 		GeminiTTSVoice:             getEnv("GEMINI_TTS_VOICE", "Zephyr"),
 		GeminiModelSegmentPrimary:  getEnv("GEMINI_MODEL_SEGMENT_PRIMARY", "gemini-3-flash-preview"),
 		GeminiModelSegmentFallback: getEnv("GEMINI_MODEL_SEGMENT_FALLBACK", "gemini-2.5-flash-lite"),
+		LLMExperiments:             getEnv("LLM_EXPERIMENTS", ""),
+		InputTypeProfiles:          getEnv("INPUT_TYPE_PROFILES", ""),
+		PipelineHooks:              getEnv("PIPELINE_HOOKS", ""),
+		GeminiMaxConcurrentPro:     getEnvInt("GEMINI_MAX_CONCURRENT_PRO", 0),
+		GeminiMaxConcurrentFlash:   getEnvInt("GEMINI_MAX_CONCURRENT_FLASH", 0),
+		GeminiMaxConcurrentImage:   getEnvInt("GEMINI_MAX_CONCURRENT_IMAGE", 0),
+		GeminiMaxConcurrentTTS:     getEnvInt("GEMINI_MAX_CONCURRENT_TTS", 0),
+		GeminiAdaptiveRateLimit:    getEnvBool("GEMINI_ADAPTIVE_RATE_LIMIT", true),
+
+		GeminiLogContent:    getEnv("GEMINI_LOG_CONTENT", "full"),
+		GeminiLogSampleRate: getEnvFloat("GEMINI_LOG_SAMPLE_RATE", 1),
+
+		LazyImages: getEnvBool("LAZY_IMAGES", false),
 
 		MaxInputLength:        getEnvInt("MAX_INPUT_LENGTH", 50000),
 		MaxSegmentsCount:      getEnvInt("MAX_SEGMENTS_COUNT", 20),
 		MaxConcurrentSegments: clampMin(getEnvInt("MAX_CONCURRENT_SEGMENTS", 5), 1),
+		JobDetailPageSize:     clampMin(getEnvInt("JOB_DETAIL_PAGE_SIZE", 100), 1),
+		SegmentationStrategy:  getEnv("SEGMENTATION_STRATEGY", "llm"),
+
+		StuckSweepInterval: getEnvDuration("STUCK_SWEEP_INTERVAL", time.Minute),
+		StuckQueuedAfter:   getEnvDuration("STUCK_QUEUED_AFTER", 10*time.Minute),
+		StuckRunningAfter:  getEnvDuration("STUCK_RUNNING_AFTER", 30*time.Minute),
+		StuckMaxRequeues:   clampMin(getEnvInt("STUCK_MAX_REQUEUES", 3), 0),
 
-		MaxFileSize:       getEnvInt64("MAX_FILE_SIZE", 10*1024*1024), // 10MB
-		MaxFilesPerJob:    getEnvInt("MAX_FILES_PER_JOB", 10),
-		FileExpirationHrs: getEnvInt("FILE_EXPIRATION_HOURS", 24),
-		CharsPerFile:      getEnvInt("CHARS_PER_FILE", 1000),
+		AnomalyCheckInterval: getEnvDuration("ANOMALY_CHECK_INTERVAL", time.Minute),
+		AnomalyWindow:        getEnvDuration("ANOMALY_WINDOW", 10*time.Minute),
+		AnomalyFailureRate:   getEnvFloat("ANOMALY_FAILURE_RATE", 0.2),
+		AnomalyMinSamples:    clampMin(getEnvInt("ANOMALY_MIN_SAMPLES", 10), 1),
+		AnomalyWebhookURL:    getEnv("ANOMALY_WEBHOOK_URL", ""),
+		AnomalyWebhookSecret: getEnv("ANOMALY_WEBHOOK_SECRET", ""),
+
+		LeaderElectionInterval: getEnvDuration("LEADER_ELECTION_INTERVAL", 10*time.Second),
+
+		QualityMinScore:         clampMin(getEnvInt("QUALITY_MIN_SCORE", 3), 1),
+		QualityMaxRegenerations: clampMin(getEnvInt("QUALITY_MAX_REGENERATIONS", 1), 0),
+
+		ExternalToolTimeout: getEnvDuration("EXTERNAL_TOOL_TIMEOUT", 30*time.Second),
+
+		MaxFileSize:         getEnvInt64("MAX_FILE_SIZE", 10*1024*1024), // 10MB
+		MaxFilesPerJob:      getEnvInt("MAX_FILES_PER_JOB", 10),
+		FileExpirationHrs:   getEnvInt("FILE_EXPIRATION_HOURS", 24),
+		FileUploadURLExpiry: getEnvDuration("FILE_UPLOAD_URL_EXPIRY", 15*time.Minute),
+		CharsPerFile:        getEnvInt("CHARS_PER_FILE", 1000),
+		PDFPagesPerBatch:    clampMin(getEnvInt("PDF_PAGES_PER_BATCH", 10), 0),
+		PDFLocalText:        getEnvBool("PDF_LOCAL_TEXT", true),
+
+		IngestPrefix:        getEnv("INGEST_PREFIX", ""),
+		IngestRoutes:        getEnv("INGEST_ROUTES", ""),
+		IngestPollInterval:  getEnvDuration("INGEST_POLL_INTERVAL", 30*time.Second),
+		IngestWebhookToken:  getEnv("INGEST_WEBHOOK_TOKEN", ""),
+		IngestJobType:       getEnv("INGEST_JOB_TYPE", "educational"),
+		IngestSegmentsCount: getEnvInt("INGEST_SEGMENTS_COUNT", 5),
+		IngestAudioType:     getEnv("INGEST_AUDIO_TYPE", "free_speech"),
+
+		StorageCleanupInterval: getEnvDuration("STORAGE_CLEANUP_INTERVAL", 30*time.Second),
+
+		JobExpiryInterval: getEnvDuration("JOB_EXPIRY_INTERVAL", 5*time.Minute),
+
+		StripeAPIKey:            getEnv("STRIPE_API_KEY", ""),
+		StripeAPIURL:            getEnv("STRIPE_API_URL", "https://api.stripe.com"),
+		StripeReportInterval:    getEnvDuration("STRIPE_REPORT_INTERVAL", time.Minute),
+		StripeMeterJobs:         getEnvName("STRIPE_METER_JOBS", "stories_jobs_completed"),
+		StripeMeterChars:        getEnvName("STRIPE_METER_CHARS", "stories_characters"),
+		StripeMeterAudioSeconds: getEnvName("STRIPE_METER_AUDIO_SECONDS", "stories_audio_seconds"),
+
+		AdminAPIToken: getEnv("ADMIN_API_TOKEN", ""),
+
+		FeatureFlags:        getEnvMap("FEATURE_FLAGS"),
+		FeatureFlagsRefresh: getEnvDuration("FEATURE_FLAGS_REFRESH", 30*time.Second),
+
+		MaintenanceRefresh: getEnvDuration("MAINTENANCE_REFRESH", 10*time.Second),
 
 		DefaultQuotaChars:  int64(getEnvInt("DEFAULT_QUOTA_CHARS", 100000)),
 		DefaultQuotaPeriod: getEnv("DEFAULT_QUOTA_PERIOD", "monthly"),
 
+		LLMPricing:    getEnvMap("LLM_PRICING"),
+		CostCapAction: getEnv("COST_CAP_ACTION", "reject"),
+
 		WebhookMaxRetries:     getEnvInt("WEBHOOK_MAX_RETRIES", 10),
 		WebhookRetryBaseDelay: getEnvDuration("WEBHOOK_RETRY_BASE_DELAY", 30*time.Second),
 		WebhookRetryMaxDelay:  getEnvDuration("WEBHOOK_RETRY_MAX_DELAY", 24*time.Hour),
+		WebhookRetryWorkers:   clampMin(getEnvInt("WEBHOOK_RETRY_WORKERS", 8), 1),
+		WebhookRetryPerHost:   clampMin(getEnvInt("WEBHOOK_RETRY_PER_HOST", 2), 1),
+		WebhookRetryBatch:     clampMin(getEnvInt("WEBHOOK_RETRY_BATCH", 100), 1),
+
+		EgressAllowHosts:   getEnvList("EGRESS_ALLOW_HOSTS"),
+		EgressDenyHosts:    getEnvList("EGRESS_DENY_HOSTS"),
+		EgressAllowPrivate: getEnvBool("EGRESS_ALLOW_PRIVATE", false),
+
+		WebhookProxy: getEnv("WEBHOOK_PROXY", ""),
+		GeminiProxy:  getEnv("GEMINI_PROXY", ""),
+		S3Proxy:      getEnv("S3_PROXY", ""),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnvInt("SMTP_PORT", 587),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:     getEnv("SMTP_FROM", "stories@localhost"),
+		SNSEndpoint:  getEnv("SNS_ENDPOINT", ""),
 
 		SentryDSN:             getEnv("SENTRY_DSN", ""),
 		SentryEnvironment:     getEnv("SENTRY_ENVIRONMENT", "development"),
@@ -147,6 +461,15 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvName returns a name setting; "none" yields "" (the feature is off).
+func getEnvName(key, defaultValue string) string {
+	value := getEnv(key, defaultValue)
+	if value == "none" {
+		return ""
+	}
+	return value
+}
+
 func getEnvInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intVal, err := strconv.Atoi(value); err == nil {
@@ -165,6 +488,15 @@ func getEnvInt64(key string, defaultValue int64) int64 {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
+}
+
 // clampMin returns v if v >= min, otherwise min. Used to ensure config values are in valid range.
 func clampMin(v, min int) int {
 	if v < min {
@@ -190,3 +522,51 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+// getEnvList parses a comma-separated list, dropping blank entries (nil when unset).
+func getEnvList(key string) []string {
+	var list []string
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	return list
+}
+
+// getEnvMap parses a comma-separated list of key=value pairs, dropping entries without '=' (nil when unset).
+func getEnvMap(key string) map[string]string {
+	var m map[string]string
+	for _, entry := range getEnvList(key) {
+		k, v, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		if m == nil {
+			m = make(map[string]string)
+		}
+		m[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return m
+}
+
+// getEnvDurations parses a comma-separated list of durations ("5m,30m,2h"). "none" yields an empty list;
+// an invalid or non-positive entry yields defaultValue.
+func getEnvDurations(key string, defaultValue []time.Duration) []time.Duration {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return defaultValue
+	}
+	if value == "none" {
+		return nil
+	}
+	var durations []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil || d <= 0 {
+			return defaultValue
+		}
+		durations = append(durations, d)
+	}
+	return durations
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// AgentOperationRepository handles agent operations (async agent calls)
+type AgentOperationRepository struct {
+	db *DB
+}
+
+// NewAgentOperationRepository creates a new AgentOperationRepository
+func NewAgentOperationRepository(db *DB) *AgentOperationRepository {
+	return &AgentOperationRepository{db: db}
+}
+
+// Create inserts a running operation.
+func (r *AgentOperationRepository) Create(ctx context.Context, op *models.AgentOperation) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO agent_operations (id, api_key_id, tool, protocol, status, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, op.ID, op.APIKeyID, op.Tool, op.Protocol, op.Status, op.CreatedAt, op.ExpiresAt)
+	return err
+}
+
+// Finish records the outcome of an operation: its status, result (inline or S3 key) or error, and
+// when it finished and expires.
+func (r *AgentOperationRepository) Finish(ctx context.Context, op *models.AgentOperation) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE agent_operations
+		SET status = $2, result = $3, result_s3_bucket = $4, result_s3_key = $5, error = $6,
+			finished_at = $7, expires_at = $8
+		WHERE id = $1
+	`, op.ID, op.Status, op.Result, op.ResultS3Bucket, op.ResultS3Key, op.Error, op.FinishedAt, op.ExpiresAt)
+	return err
+}
+
+// GetByID returns an operation that has not expired at now.
+func (r *AgentOperationRepository) GetByID(ctx context.Context, id uuid.UUID, now time.Time) (*models.AgentOperation, error) {
+	op := &models.AgentOperation{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, api_key_id, tool, protocol, status, result, result_s3_bucket, result_s3_key, error,
+			created_at, finished_at, expires_at
+		FROM agent_operations
+		WHERE id = $1 AND expires_at > $2
+	`, id, now).Scan(
+		&op.ID, &op.APIKeyID, &op.Tool, &op.Protocol, &op.Status, &op.Result, &op.ResultS3Bucket, &op.ResultS3Key, &op.Error,
+		&op.CreatedAt, &op.FinishedAt, &op.ExpiresAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("operation not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+// DeleteExpired deletes the operations that expired before now, queueing their S3 results in
+// storage_deletions in the same statement, and returns how many were deleted. Replicas running it at
+// the same time each delete (and queue) different rows.
+func (r *AgentOperationRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	var deleted int64
+	err := r.db.QueryRowContext(ctx, `
+		WITH deleted AS (
+			DELETE FROM agent_operations WHERE expires_at <= $1
+			RETURNING result_s3_bucket, result_s3_key
+		), queued AS (
+			INSERT INTO storage_deletions (s3_bucket, s3_key)
+			SELECT result_s3_bucket, result_s3_key FROM deleted WHERE result_s3_key IS NOT NULL
+		)
+		SELECT COUNT(*) FROM deleted
+	`, now).Scan(&deleted)
+	return deleted, err
+}
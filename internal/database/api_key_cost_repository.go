@@ -0,0 +1,30 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// AddCost adds the estimated LLM cost (USD) of a finished job to its API key's current period.
+func (r *APIKeyRepository) AddCost(ctx context.Context, keyID uuid.UUID, costUSD float64) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE api_keys SET used_cost_in_period = used_cost_in_period + $1 WHERE id = $2`, costUSD, keyID)
+	return err
+}
+
+// SetCostCap sets the estimated LLM cost (USD) an API key may use per period; nil removes the cap.
+func (r *APIKeyRepository) SetCostCap(ctx context.Context, keyID uuid.UUID, capUSD *float64) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE api_keys SET cost_cap_usd = $1 WHERE id = $2`, capUSD, keyID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("api key not found")
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// SetScopes restricts an API key to scopes (models.HasScope); nil lifts the restriction.
+func (r *APIKeyRepository) SetScopes(ctx context.Context, keyID uuid.UUID, scopes []string) error {
+	var value any
+	if scopes != nil {
+		value = pq.Array(scopes)
+	}
+	result, err := r.db.ExecContext(ctx, `UPDATE api_keys SET scopes = $1 WHERE id = $2`, value, keyID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("api key not found")
+	}
+	return nil
+}
@@ -2,9 +2,13 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/snappy-loop/stories/internal/models"
 )
 
@@ -20,18 +24,240 @@ func (r *AssetRepository) Create(ctx context.Context, asset *models.Asset) error
 		}
 	}
 
+	if asset.Version == 0 {
+		asset.Version = 1
+	}
+
 	query := `
 		INSERT INTO assets (
 			id, job_id, segment_id, kind, mime_type, s3_bucket, s3_key,
-			size_bytes, checksum, meta, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			size_bytes, checksum, meta, created_at, version
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	`
 
 	_, err = r.db.ExecContext(ctx, query,
 		asset.ID, asset.JobID, asset.SegmentID, asset.Kind,
 		asset.MimeType, asset.S3Bucket, asset.S3Key, asset.SizeBytes,
-		asset.Checksum, metaJSON, asset.CreatedAt,
+		asset.Checksum, metaJSON, asset.CreatedAt, asset.Version,
 	)
 
 	return err
 }
+
+// NextVersion returns the version number for a new asset of kind for a segment (1 for the first one).
+func (r *AssetRepository) NextVersion(ctx context.Context, segmentID uuid.UUID, kind string) (int, error) {
+	var v int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COALESCE(MAX(version), 0) + 1 FROM assets WHERE segment_id = $1 AND kind = $2`,
+		segmentID, kind,
+	).Scan(&v)
+	return v, err
+}
+
+// ListByJobPage retrieves up to limit assets for a job ordered by (created_at, id).
+// When afterCreatedAt is non-nil, only assets after (afterCreatedAt, afterID) are returned.
+func (r *AssetRepository) ListByJobPage(ctx context.Context, jobID uuid.UUID, afterCreatedAt *time.Time, afterID uuid.UUID, limit int) ([]*models.Asset, error) {
+	query := `
+		SELECT id, job_id, segment_id, kind, mime_type, s3_bucket, s3_key,
+			size_bytes, checksum, meta, created_at, version, superseded_at
+		FROM assets
+		WHERE job_id = $1 AND superseded_at IS NULL AND ($2::timestamptz IS NULL OR (created_at, id) > ($2, $3))
+		ORDER BY created_at ASC, id ASC
+		LIMIT $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, jobID, afterCreatedAt, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assets []*models.Asset
+	for rows.Next() {
+		asset := &models.Asset{}
+		var metaJSON []byte
+
+		err := rows.Scan(
+			&asset.ID, &asset.JobID, &asset.SegmentID, &asset.Kind,
+			&asset.MimeType, &asset.S3Bucket, &asset.S3Key, &asset.SizeBytes,
+			&asset.Checksum, &metaJSON, &asset.CreatedAt, &asset.Version, &asset.SupersededAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(metaJSON) > 0 {
+			if err := json.Unmarshal(metaJSON, &asset.Meta); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal meta: %w", err)
+			}
+		}
+
+		assets = append(assets, asset)
+	}
+
+	return assets, rows.Err()
+}
+
+// CountByJob returns the number of current (not superseded) assets for a job
+func (r *AssetRepository) CountByJob(ctx context.Context, jobID uuid.UUID) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM assets WHERE job_id = $1 AND superseded_at IS NULL`, jobID).Scan(&n)
+	return n, err
+}
+
+// SupersedeSegmentAssets marks a segment's current assets of the given kind other than keepID as
+// superseded. Used after a segment edit has stored the regenerated asset; old versions are kept.
+func (r *AssetRepository) SupersedeSegmentAssets(ctx context.Context, segmentID uuid.UUID, kind string, keepID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE assets SET superseded_at = NOW()
+		WHERE segment_id = $1 AND kind = $2 AND id <> $3 AND superseded_at IS NULL
+	`, segmentID, kind, keepID)
+	return err
+}
+
+// Supersede marks an asset as superseded (e.g. a job's previous podcast feed)
+func (r *AssetRepository) Supersede(ctx context.Context, assetID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE assets SET superseded_at = NOW() WHERE id = $1 AND superseded_at IS NULL`, assetID)
+	return err
+}
+
+// Replace swaps an asset for its replacement (a user upload): inserts replacement, supersedes old, points
+// the output markup of old's job, and of jobs deduplicated against it, at the new asset and records an
+// output version, in one transaction.
+func (r *AssetRepository) Replace(ctx context.Context, old, replacement *models.Asset) error {
+	if replacement.Version == 0 {
+		replacement.Version = 1
+	}
+	var metaJSON []byte
+	if replacement.Meta != nil {
+		var err error
+		metaJSON, err = json.Marshal(replacement.Meta)
+		if err != nil {
+			return fmt.Errorf("failed to marshal meta: %w", err)
+		}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO assets (
+			id, job_id, segment_id, kind, mime_type, s3_bucket, s3_key,
+			size_bytes, checksum, meta, created_at, version
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`,
+		replacement.ID, replacement.JobID, replacement.SegmentID, replacement.Kind,
+		replacement.MimeType, replacement.S3Bucket, replacement.S3Key, replacement.SizeBytes,
+		replacement.Checksum, metaJSON, replacement.CreatedAt, replacement.Version,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE assets SET superseded_at = NOW() WHERE id = $1`, old.ID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE jobs SET output_markup = replace(output_markup, $1, $2)
+		WHERE (id = $3 OR duplicate_of = $3) AND output_markup IS NOT NULL
+	`, "asset_id="+old.ID.String(), "asset_id="+replacement.ID.String(), old.JobID); err != nil {
+		return err
+	}
+	if err := recordOutputVersion(ctx, tx, old.JobID, models.OutputVersionAssetReplaced); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ErrPendingImageClaimed is returned by ClaimPendingImage while another request generates the image.
+var ErrPendingImageClaimed = errors.New("pending image is being generated")
+
+// CreatePending creates a pending image asset (meta.pending, see models.Asset.Pending) and stores the
+// prompt it is generated from on its first download, in one transaction.
+func (r *AssetRepository) CreatePending(ctx context.Context, asset *models.Asset, prompt string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	metaJSON, err := json.Marshal(asset.Meta)
+	if err != nil {
+		return fmt.Errorf("failed to marshal meta: %w", err)
+	}
+	if asset.Version == 0 {
+		asset.Version = 1
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO assets (
+			id, job_id, segment_id, kind, mime_type, s3_bucket, s3_key,
+			size_bytes, checksum, meta, created_at, version
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`,
+		asset.ID, asset.JobID, asset.SegmentID, asset.Kind,
+		asset.MimeType, asset.S3Bucket, asset.S3Key, asset.SizeBytes,
+		asset.Checksum, metaJSON, asset.CreatedAt, asset.Version,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO pending_images (asset_id, prompt) VALUES ($1, $2)`, asset.ID, prompt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ClaimPendingImage leases the generation of a pending image for lease and returns its prompt. It returns
+// ErrPendingImageClaimed while another lease is current, and sql.ErrNoRows when the image is no longer
+// pending (generated in the meantime).
+func (r *AssetRepository) ClaimPendingImage(ctx context.Context, assetID uuid.UUID, lease time.Duration) (string, error) {
+	var prompt string
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE pending_images SET claimed_until = NOW() + make_interval(secs => $2)
+		WHERE asset_id = $1 AND (claimed_until IS NULL OR claimed_until <= NOW())
+		RETURNING prompt
+	`, assetID, lease.Seconds()).Scan(&prompt)
+	if err != sql.ErrNoRows {
+		return prompt, err
+	}
+	var pending bool
+	if err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM pending_images WHERE asset_id = $1)`, assetID).Scan(&pending); err != nil {
+		return "", err
+	}
+	if pending {
+		return "", ErrPendingImageClaimed
+	}
+	return "", sql.ErrNoRows
+}
+
+// ReleasePendingImage ends the lease of a pending image whose generation failed, so the next download
+// tries again.
+func (r *AssetRepository) ReleasePendingImage(ctx context.Context, assetID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE pending_images SET claimed_until = NULL WHERE asset_id = $1`, assetID)
+	return err
+}
+
+// CompletePendingImage records the generated image of a pending asset (MIME type, bucket, key, size and
+// meta of asset) and deletes its pending row, in one transaction.
+func (r *AssetRepository) CompletePendingImage(ctx context.Context, asset *models.Asset) error {
+	metaJSON, err := json.Marshal(asset.Meta)
+	if err != nil {
+		return fmt.Errorf("failed to marshal meta: %w", err)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE assets SET mime_type = $2, s3_bucket = $3, s3_key = $4, size_bytes = $5, meta = $6
+		WHERE id = $1
+	`, asset.ID, asset.MimeType, asset.S3Bucket, asset.S3Key, asset.SizeBytes, metaJSON); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM pending_images WHERE asset_id = $1`, asset.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
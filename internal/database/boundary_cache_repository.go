@@ -77,3 +77,38 @@ func (r *BoundaryCacheRepository) Set(ctx context.Context, textHash string, boun
 
 	return nil
 }
+
+// GetString retrieves a cached string (image prompts share the boundary cache table); ok is false on a miss.
+func (r *BoundaryCacheRepository) GetString(ctx context.Context, key string) (value string, ok bool, err error) {
+	var valueJSON []byte
+	err = r.db.QueryRowContext(ctx, `SELECT boundaries FROM segment_boundaries_cache WHERE text_hash = $1`, key).Scan(&valueJSON)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("query cache: %w", err)
+	}
+	if err := json.Unmarshal(valueJSON, &value); err != nil {
+		return "", false, fmt.Errorf("unmarshal cached value: %w", err)
+	}
+	return value, true, nil
+}
+
+// SetString stores a string in the cache under key.
+func (r *BoundaryCacheRepository) SetString(ctx context.Context, key, value string) error {
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal value: %w", err)
+	}
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO segment_boundaries_cache (text_hash, boundaries, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (text_hash) DO UPDATE
+		SET boundaries = EXCLUDED.boundaries,
+		    created_at = EXCLUDED.created_at
+	`, key, valueJSON, time.Now())
+	if err != nil {
+		return fmt.Errorf("insert cache: %w", err)
+	}
+	return nil
+}
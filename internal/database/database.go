@@ -4,15 +4,20 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"runtime"
+	"strings"
 	"time"
 
 	_ "github.com/lib/pq"
 	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/slowlog"
 )
 
-// DB wraps sql.DB with additional functionality
+// DB wraps sql.DB with additional functionality: queries run through ExecContext, QueryContext and
+// QueryRowContext taking at least the slow query threshold are logged (see SetSlowQueryThreshold).
 type DB struct {
 	*sql.DB
+	slowQuery time.Duration
 }
 
 // SQLDB returns the underlying *sql.DB for use with migrations etc.
@@ -55,3 +60,50 @@ func (db *DB) Health() error {
 	defer cancel()
 	return db.PingContext(ctx)
 }
+
+// SetSlowQueryThreshold makes queries taking at least d log a warning with the query text (never its
+// arguments) and the repository method that ran it; 0 disables it. Statements inside transactions are not
+// timed.
+func (db *DB) SetSlowQueryThreshold(d time.Duration) {
+	db.slowQuery = d
+}
+
+// ExecContext runs sql.DB.ExecContext, logging it when slow.
+func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	start := time.Now()
+	result, err := db.DB.ExecContext(ctx, query, args...)
+	db.logSlow(start, query)
+	return result, err
+}
+
+// QueryContext runs sql.DB.QueryContext, logging it when slow. Only the time to the first rows counts.
+func (db *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	db.logSlow(start, query)
+	return rows, err
+}
+
+// QueryRowContext runs sql.DB.QueryRowContext, logging it when slow.
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	start := time.Now()
+	row := db.DB.QueryRowContext(ctx, query, args...)
+	db.logSlow(start, query)
+	return row
+}
+
+// logSlow logs query, started at start, when it reached the slow query threshold. It must be called
+// directly by the query methods so that the caller two frames up is the repository method.
+func (db *DB) logSlow(start time.Time, query string) {
+	ev := slowlog.Event(slowlog.KindDB, db.slowQuery, time.Since(start))
+	if ev == nil {
+		return
+	}
+	caller := ""
+	if pc, _, _, ok := runtime.Caller(2); ok {
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			caller = fn.Name()[strings.LastIndex(fn.Name(), "/")+1:]
+		}
+	}
+	ev.Str("caller", caller).Str("query", slowlog.Query(query)).Msg("Slow database query")
+}
@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// ErrDisclaimerNotFound is returned when a jurisdiction has no (active) disclaimer or version
+var ErrDisclaimerNotFound = errors.New("disclaimer not found")
+
+// DisclaimerRepository handles the versioned compliance disclaimers of financial jobs
+type DisclaimerRepository struct {
+	db *DB
+}
+
+// NewDisclaimerRepository creates a new DisclaimerRepository
+func NewDisclaimerRepository(db *DB) *DisclaimerRepository {
+	return &DisclaimerRepository{db: db}
+}
+
+const disclaimerColumns = `id, jurisdiction, version, text, created_at, retired_at`
+
+func scanDisclaimer(row interface{ Scan(...any) error }) (*models.Disclaimer, error) {
+	d := &models.Disclaimer{}
+	if err := row.Scan(&d.ID, &d.Jurisdiction, &d.Version, &d.Text, &d.CreatedAt, &d.RetiredAt); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Create saves text as the next version of the jurisdiction's disclaimer (version 1 for a new one) and
+// returns it. The jurisdiction's retired versions stay retired; the new one is active.
+func (r *DisclaimerRepository) Create(ctx context.Context, jurisdiction, text string) (*models.Disclaimer, error) {
+	return scanDisclaimer(r.db.QueryRowContext(ctx, `
+		INSERT INTO disclaimers (id, jurisdiction, version, text)
+		SELECT $1, $2, COALESCE(MAX(version), 0) + 1, $3 FROM disclaimers WHERE jurisdiction = $2
+		RETURNING `+disclaimerColumns,
+		uuid.New(), jurisdiction, text))
+}
+
+// Latest returns the jurisdiction's newest active version, or ErrDisclaimerNotFound
+func (r *DisclaimerRepository) Latest(ctx context.Context, jurisdiction string) (*models.Disclaimer, error) {
+	d, err := scanDisclaimer(r.db.QueryRowContext(ctx, `
+		SELECT `+disclaimerColumns+` FROM disclaimers
+		WHERE jurisdiction = $1 AND retired_at IS NULL
+		ORDER BY version DESC LIMIT 1
+	`, jurisdiction))
+	if err == sql.ErrNoRows {
+		return nil, ErrDisclaimerNotFound
+	}
+	return d, err
+}
+
+// Get returns one version of a jurisdiction's disclaimer, retired or not, or ErrDisclaimerNotFound
+func (r *DisclaimerRepository) Get(ctx context.Context, jurisdiction string, version int) (*models.Disclaimer, error) {
+	d, err := scanDisclaimer(r.db.QueryRowContext(ctx, `
+		SELECT `+disclaimerColumns+` FROM disclaimers WHERE jurisdiction = $1 AND version = $2
+	`, jurisdiction, version))
+	if err == sql.ErrNoRows {
+		return nil, ErrDisclaimerNotFound
+	}
+	return d, err
+}
+
+// ListActive returns the newest active version of each jurisdiction, by jurisdiction
+func (r *DisclaimerRepository) ListActive(ctx context.Context) ([]*models.Disclaimer, error) {
+	return r.list(ctx, `
+		SELECT DISTINCT ON (jurisdiction) `+disclaimerColumns+` FROM disclaimers
+		WHERE retired_at IS NULL
+		ORDER BY jurisdiction, version DESC
+	`)
+}
+
+// ListVersions returns all versions of a jurisdiction's disclaimer, oldest first
+func (r *DisclaimerRepository) ListVersions(ctx context.Context, jurisdiction string) ([]*models.Disclaimer, error) {
+	return r.list(ctx, `SELECT `+disclaimerColumns+` FROM disclaimers WHERE jurisdiction = $1 ORDER BY version`, jurisdiction)
+}
+
+func (r *DisclaimerRepository) list(ctx context.Context, query string, args ...any) ([]*models.Disclaimer, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*models.Disclaimer
+	for rows.Next() {
+		d, err := scanDisclaimer(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// Retire stops applying a jurisdiction's disclaimer (its versions are kept). Returns
+// ErrDisclaimerNotFound when it has no active version.
+func (r *DisclaimerRepository) Retire(ctx context.Context, jurisdiction string) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE disclaimers SET retired_at = NOW() WHERE jurisdiction = $1 AND retired_at IS NULL
+	`, jurisdiction)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrDisclaimerNotFound
+	}
+	return nil
+}
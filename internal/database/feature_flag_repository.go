@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// ErrFeatureFlagNotFound is returned when a flag has no admin-set rollout or the user no override
+var ErrFeatureFlagNotFound = errors.New("feature flag not found")
+
+// ErrFeatureFlagUserNotFound is returned when an override names a user that does not exist
+var ErrFeatureFlagUserNotFound = errors.New("user not found")
+
+// FeatureFlagRepository handles the admin-managed feature flag rollouts and per-user overrides
+type FeatureFlagRepository struct {
+	db *DB
+}
+
+// NewFeatureFlagRepository creates a new FeatureFlagRepository
+func NewFeatureFlagRepository(db *DB) *FeatureFlagRepository {
+	return &FeatureFlagRepository{db: db}
+}
+
+// ListRollouts returns the admin-set rollouts, by name
+func (r *FeatureFlagRepository) ListRollouts(ctx context.Context) ([]*models.FeatureFlag, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT name, rollout_percent, updated_at FROM feature_flags ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*models.FeatureFlag
+	for rows.Next() {
+		f := &models.FeatureFlag{Source: "admin"}
+		if err := rows.Scan(&f.Name, &f.RolloutPercent, &f.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+// ListOverrides returns the per-user overrides of all flags
+func (r *FeatureFlagRepository) ListOverrides(ctx context.Context) ([]*models.FeatureFlagOverride, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT flag_name, user_id, enabled FROM feature_flag_overrides ORDER BY flag_name, user_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*models.FeatureFlagOverride
+	for rows.Next() {
+		o := &models.FeatureFlagOverride{}
+		if err := rows.Scan(&o.FlagName, &o.UserID, &o.Enabled); err != nil {
+			return nil, err
+		}
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
+
+// SetRollout sets the rollout of a flag, replacing its FEATURE_FLAGS one
+func (r *FeatureFlagRepository) SetRollout(ctx context.Context, name string, percent int) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO feature_flags (name, rollout_percent) VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET rollout_percent = EXCLUDED.rollout_percent, updated_at = NOW()
+	`, name, percent)
+	return err
+}
+
+// DeleteRollout removes the admin-set rollout of a flag (FEATURE_FLAGS applies again). Returns
+// ErrFeatureFlagNotFound when it has none.
+func (r *FeatureFlagRepository) DeleteRollout(ctx context.Context, name string) error {
+	return r.execOne(ctx, `DELETE FROM feature_flags WHERE name = $1`, name)
+}
+
+// SetOverride turns a flag on or off for one user. Returns ErrFeatureFlagUserNotFound when the user does
+// not exist.
+func (r *FeatureFlagRepository) SetOverride(ctx context.Context, name string, userID uuid.UUID, enabled bool) error {
+	err := r.execOne(ctx, `
+		INSERT INTO feature_flag_overrides (flag_name, user_id, enabled)
+		SELECT $1, $2, $3 WHERE EXISTS (SELECT 1 FROM users WHERE id = $2)
+		ON CONFLICT (flag_name, user_id) DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = NOW()
+	`, name, userID, enabled)
+	if errors.Is(err, ErrFeatureFlagNotFound) {
+		return ErrFeatureFlagUserNotFound
+	}
+	return err
+}
+
+// DeleteOverride removes a user's override of a flag. Returns ErrFeatureFlagNotFound when there is none.
+func (r *FeatureFlagRepository) DeleteOverride(ctx context.Context, name string, userID uuid.UUID) error {
+	return r.execOne(ctx, `DELETE FROM feature_flag_overrides WHERE flag_name = $1 AND user_id = $2`, name, userID)
+}
+
+// execOne runs a statement that must affect a row; ErrFeatureFlagNotFound when it affected none.
+func (r *FeatureFlagRepository) execOne(ctx context.Context, query string, args ...any) error {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrFeatureFlagNotFound
+	}
+	return nil
+}
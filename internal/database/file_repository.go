@@ -3,12 +3,18 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/snappy-loop/stories/internal/models"
 )
 
+// ErrFileInUse is returned by DeleteByIDAndUser for a file that a job which has not finished reads.
+var ErrFileInUse = errors.New("file is used by a job that has not finished")
+
 // FileRepository handles file-related database operations
 type FileRepository struct {
 	db *DB
@@ -21,15 +27,19 @@ func NewFileRepository(db *DB) *FileRepository {
 
 // Create creates a new file record
 func (r *FileRepository) Create(ctx context.Context, file *models.File) error {
+	optionsJSON, err := marshalExtractionOptions(file.ExtractionOptions)
+	if err != nil {
+		return err
+	}
 	query := `
 		INSERT INTO files (
 			id, user_id, filename, mime_type, size_bytes, s3_bucket, s3_key,
-			status, expires_at, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			status, expires_at, created_at, extraction_options
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	_, err = r.db.ExecContext(ctx, query,
 		file.ID, file.UserID, file.Filename, file.MimeType, file.SizeBytes,
-		file.S3Bucket, file.S3Key, file.Status, file.ExpiresAt, file.CreatedAt,
+		file.S3Bucket, file.S3Key, file.Status, file.ExpiresAt, file.CreatedAt, optionsJSON,
 	)
 	return err
 }
@@ -38,14 +48,15 @@ func (r *FileRepository) Create(ctx context.Context, file *models.File) error {
 func (r *FileRepository) GetByID(ctx context.Context, fileID uuid.UUID) (*models.File, error) {
 	query := `
 		SELECT id, user_id, filename, mime_type, size_bytes, s3_bucket, s3_key,
-			status, expires_at, created_at
+			status, expires_at, created_at, extraction_options
 		FROM files
 		WHERE id = $1
 	`
 	file := &models.File{}
+	var optionsJSON []byte
 	err := r.db.QueryRowContext(ctx, query, fileID).Scan(
 		&file.ID, &file.UserID, &file.Filename, &file.MimeType, &file.SizeBytes,
-		&file.S3Bucket, &file.S3Key, &file.Status, &file.ExpiresAt, &file.CreatedAt,
+		&file.S3Bucket, &file.S3Key, &file.Status, &file.ExpiresAt, &file.CreatedAt, &optionsJSON,
 	)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("file not found")
@@ -53,6 +64,9 @@ func (r *FileRepository) GetByID(ctx context.Context, fileID uuid.UUID) (*models
 	if err != nil {
 		return nil, err
 	}
+	if file.ExtractionOptions, err = unmarshalExtractionOptions(optionsJSON); err != nil {
+		return nil, err
+	}
 	return file, nil
 }
 
@@ -60,14 +74,15 @@ func (r *FileRepository) GetByID(ctx context.Context, fileID uuid.UUID) (*models
 func (r *FileRepository) GetByIDAndUser(ctx context.Context, fileID, userID uuid.UUID) (*models.File, error) {
 	query := `
 		SELECT id, user_id, filename, mime_type, size_bytes, s3_bucket, s3_key,
-			status, expires_at, created_at
+			status, expires_at, created_at, extraction_options
 		FROM files
 		WHERE id = $1 AND user_id = $2
 	`
 	file := &models.File{}
+	var optionsJSON []byte
 	err := r.db.QueryRowContext(ctx, query, fileID, userID).Scan(
 		&file.ID, &file.UserID, &file.Filename, &file.MimeType, &file.SizeBytes,
-		&file.S3Bucket, &file.S3Key, &file.Status, &file.ExpiresAt, &file.CreatedAt,
+		&file.S3Bucket, &file.S3Key, &file.Status, &file.ExpiresAt, &file.CreatedAt, &optionsJSON,
 	)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("file not found")
@@ -75,19 +90,25 @@ func (r *FileRepository) GetByIDAndUser(ctx context.Context, fileID, userID uuid
 	if err != nil {
 		return nil, err
 	}
+	if file.ExtractionOptions, err = unmarshalExtractionOptions(optionsJSON); err != nil {
+		return nil, err
+	}
 	return file, nil
 }
 
-// ListByUser retrieves files for a user, optionally filtered by status
-func (r *FileRepository) ListByUser(ctx context.Context, userID uuid.UUID, status string) ([]*models.File, error) {
+// ListByUser retrieves up to limit files of a user, newest first, optionally filtered by status. When
+// afterCreatedAt is set only files before the (afterCreatedAt, afterID) position are returned.
+func (r *FileRepository) ListByUser(ctx context.Context, userID uuid.UUID, status string, limit int, afterCreatedAt *time.Time, afterID uuid.UUID) ([]*models.File, error) {
 	query := `
 		SELECT id, user_id, filename, mime_type, size_bytes, s3_bucket, s3_key,
-			status, expires_at, created_at
+			status, expires_at, created_at, extraction_options
 		FROM files
 		WHERE user_id = $1 AND ($2 = '' OR status = $2)
-		ORDER BY created_at DESC
+			AND ($3::timestamptz IS NULL OR (created_at, id) < ($3, $4))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $5
 	`
-	rows, err := r.db.QueryContext(ctx, query, userID, status)
+	rows, err := r.db.QueryContext(ctx, query, userID, status, afterCreatedAt, afterID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -96,18 +117,36 @@ func (r *FileRepository) ListByUser(ctx context.Context, userID uuid.UUID, statu
 	var files []*models.File
 	for rows.Next() {
 		file := &models.File{}
+		var optionsJSON []byte
 		err := rows.Scan(
 			&file.ID, &file.UserID, &file.Filename, &file.MimeType, &file.SizeBytes,
-			&file.S3Bucket, &file.S3Key, &file.Status, &file.ExpiresAt, &file.CreatedAt,
+			&file.S3Bucket, &file.S3Key, &file.Status, &file.ExpiresAt, &file.CreatedAt, &optionsJSON,
 		)
 		if err != nil {
 			return nil, err
 		}
+		if file.ExtractionOptions, err = unmarshalExtractionOptions(optionsJSON); err != nil {
+			return nil, err
+		}
 		files = append(files, file)
 	}
 	return files, rows.Err()
 }
 
+// MarkUploaded moves a pending file (presigned upload) to status ("ready" once its object is verified, or
+// "failed"). Files no longer pending are left alone, so repeated confirmations are harmless.
+func (r *FileRepository) MarkUploaded(ctx context.Context, fileID uuid.UUID, status string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE files SET status = $2 WHERE id = $1 AND status = 'pending'`, fileID, status)
+	return err
+}
+
+// MarkUploadedAt marks a pending file (presigned upload) ready with its object at key, where ConfirmUpload
+// copied it. Files no longer pending are left alone.
+func (r *FileRepository) MarkUploadedAt(ctx context.Context, fileID uuid.UUID, key string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE files SET status = 'ready', s3_key = $2 WHERE id = $1 AND status = 'pending'`, fileID, key)
+	return err
+}
+
 // Delete deletes a file by ID
 func (r *FileRepository) Delete(ctx context.Context, fileID uuid.UUID) error {
 	result, err := r.db.ExecContext(ctx, `DELETE FROM files WHERE id = $1`, fileID)
@@ -121,15 +160,65 @@ func (r *FileRepository) Delete(ctx context.Context, fileID uuid.UUID) error {
 	return nil
 }
 
-// DeleteByIDAndUser deletes a file by ID and user ID (for ownership check)
+// DeleteByIDAndUser deletes a file by ID and user ID (for ownership check). A file that a queued, running
+// or awaiting review job reads is left alone (ErrFileInUse); the links of finished jobs cascade. The file
+// row is locked first, so a job linking the file at the same time (its job_files insert waits for the
+// row's key share lock) is either seen here or fails on the deleted file.
 func (r *FileRepository) DeleteByIDAndUser(ctx context.Context, fileID, userID uuid.UUID) error {
-	result, err := r.db.ExecContext(ctx, `DELETE FROM files WHERE id = $1 AND user_id = $2`, fileID, userID)
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	n, _ := result.RowsAffected()
-	if n == 0 {
+	defer tx.Rollback()
+
+	var id uuid.UUID
+	err = tx.QueryRowContext(ctx, `SELECT id FROM files WHERE id = $1 AND user_id = $2 FOR UPDATE`, fileID, userID).Scan(&id)
+	if err == sql.ErrNoRows {
 		return fmt.Errorf("file not found")
 	}
-	return nil
+	if err != nil {
+		return err
+	}
+	// A separate statement, so links committed while waiting for the lock are seen
+	var inUse bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM job_files
+			JOIN jobs ON jobs.id = job_files.job_id
+			WHERE job_files.file_id = $1 AND jobs.status NOT IN ('succeeded', 'failed', 'canceled')
+		)
+	`, fileID).Scan(&inUse)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return ErrFileInUse
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM files WHERE id = $1`, fileID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// marshalExtractionOptions encodes extraction options for a JSONB column; unset options are stored as NULL.
+func marshalExtractionOptions(o *models.ExtractionOptions) ([]byte, error) {
+	if o.IsZero() {
+		return nil, nil
+	}
+	b, err := json.Marshal(o)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal extraction_options: %w", err)
+	}
+	return b, nil
+}
+
+func unmarshalExtractionOptions(b []byte) (*models.ExtractionOptions, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	o := &models.ExtractionOptions{}
+	if err := json.Unmarshal(b, o); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal extraction_options: %w", err)
+	}
+	return o, nil
 }
@@ -0,0 +1,86 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/testutil"
+)
+
+func TestFileRepository_ListAndDelete(t *testing.T) {
+	db := testutil.Postgres(t)
+	ctx := context.Background()
+	repo := database.NewFileRepository(db)
+	user := testutil.CreateUser(t, db)
+	_, key := testutil.CreateAPIKey(t, db, user.ID)
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	var files []*models.File
+	for i := 0; i < 3; i++ {
+		f := &models.File{
+			ID: uuid.New(), UserID: user.ID, Filename: "doc.pdf", MimeType: "application/pdf", SizeBytes: 10,
+			S3Bucket: "bucket", S3Key: "files/doc.pdf", Status: "ready",
+			ExpiresAt: now.Add(time.Hour), CreatedAt: now.Add(time.Duration(i) * time.Second),
+		}
+		if err := repo.Create(ctx, f); err != nil {
+			t.Fatalf("create file: %v", err)
+		}
+		files = append(files, f)
+	}
+
+	page, err := repo.ListByUser(ctx, user.ID, "", 2, nil, uuid.Nil)
+	if err != nil || len(page) != 2 || page[0].ID != files[2].ID {
+		t.Fatalf("first page = %v, %v; want the two newest files", page, err)
+	}
+	last := page[1]
+	page, err = repo.ListByUser(ctx, user.ID, "", 2, &last.CreatedAt, last.ID)
+	if err != nil || len(page) != 1 || page[0].ID != files[0].ID {
+		t.Fatalf("second page = %v, %v; want the oldest file", page, err)
+	}
+
+	// Only pending files (presigned uploads) change status on confirmation
+	if err := repo.MarkUploaded(ctx, files[1].ID, "failed"); err != nil {
+		t.Fatalf("MarkUploaded: %v", err)
+	}
+	if got, err := repo.GetByID(ctx, files[1].ID); err != nil || got.Status != "ready" {
+		t.Errorf("ready file after MarkUploaded = %v, %v; want it left ready", got, err)
+	}
+	pending := &models.File{
+		ID: uuid.New(), UserID: user.ID, Filename: "big.pdf", MimeType: "application/pdf", SizeBytes: 10,
+		S3Bucket: "bucket", S3Key: "uploads/big.pdf", Status: "pending", ExpiresAt: now.Add(time.Hour), CreatedAt: now,
+	}
+	if err := repo.Create(ctx, pending); err != nil {
+		t.Fatalf("create pending file: %v", err)
+	}
+	if err := repo.MarkUploadedAt(ctx, pending.ID, "files/big.pdf"); err != nil {
+		t.Fatalf("MarkUploadedAt: %v", err)
+	}
+	if got, err := repo.GetByID(ctx, pending.ID); err != nil || got.Status != "ready" || got.S3Key != "files/big.pdf" {
+		t.Errorf("confirmed file = %v, %v; want it ready at files/big.pdf", got, err)
+	}
+
+	// A queued job reads files[0]: it cannot be deleted until the job finishes
+	job := testutil.NewJob(key)
+	testutil.InsertJob(t, db, job)
+	link := &models.JobFile{ID: uuid.New(), JobID: job.ID, FileID: files[0].ID, Status: "pending", CreatedAt: now}
+	if err := database.NewJobFileRepository(db).Create(ctx, link); err != nil {
+		t.Fatalf("link file: %v", err)
+	}
+	if err := repo.DeleteByIDAndUser(ctx, files[0].ID, user.ID); !errors.Is(err, database.ErrFileInUse) {
+		t.Errorf("delete file of a queued job: err = %v, want ErrFileInUse", err)
+	}
+	if err := database.NewJobRepository(db).UpdateStatus(ctx, job.ID, models.JobStatusCanceled, nil, nil); err != nil {
+		t.Fatalf("cancel job: %v", err)
+	}
+	if err := repo.DeleteByIDAndUser(ctx, files[0].ID, user.ID); err != nil {
+		t.Errorf("delete file of a canceled job: %v", err)
+	}
+	if err := repo.DeleteByIDAndUser(ctx, files[1].ID, uuid.New()); err == nil {
+		t.Error("delete another user's file: want an error")
+	}
+}
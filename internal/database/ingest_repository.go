@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// IngestRepository records objects ingested from the S3 ingestion prefix
+type IngestRepository struct {
+	db *DB
+}
+
+// NewIngestRepository creates a new IngestRepository
+func NewIngestRepository(db *DB) *IngestRepository {
+	return &IngestRepository{db: db}
+}
+
+// Claim records obj as being ingested. It returns false without error when the object (bucket, key and
+// etag) was already claimed, by this or another API instance.
+func (r *IngestRepository) Claim(ctx context.Context, obj *models.IngestedObject) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO ingested_objects (id, s3_bucket, s3_key, etag, api_key_id, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		ON CONFLICT (s3_bucket, s3_key, etag) DO NOTHING
+	`, obj.ID, obj.S3Bucket, obj.S3Key, obj.ETag, obj.APIKeyID, obj.Status, obj.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// Update records the outcome of an ingestion
+func (r *IngestRepository) Update(ctx context.Context, obj *models.IngestedObject) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE ingested_objects
+		SET file_id = $1, job_id = $2, status = $3, error = $4, updated_at = NOW()
+		WHERE id = $5
+	`, obj.FileID, obj.JobID, obj.Status, obj.Error, obj.ID)
+	return err
+}
+
+// Release deletes a claim so the object is ingested again (after a transient failure)
+func (r *IngestRepository) Release(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM ingested_objects WHERE id = $1`, id)
+	return err
+}
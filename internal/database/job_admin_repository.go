@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// Administrative job queries used by cmd/storiesctl. They span all users and return
+// jobs without the large text columns.
+
+const adminJobColumns = `id, user_id, api_key_id, status, input_type, segments_count, audio_type,
+	input_source, error_code, error_message, created_at, started_at, finished_at, expires_at, expired_at`
+
+// ListStuck returns up to limit jobs in status that have not progressed since before.
+// Queued jobs are compared by created_at, running jobs by the heartbeat of the worker processing them
+// (heartbeat_at, refreshed while it runs), else started_at (falling back to created_at);
+// jobs regenerating segments for a reviewer by review_requested_at, jobs parked for a delayed retry by
+// retry_at, and failed jobs retried through the API by retry_requested_at.
+// Duplicates waiting for a source job, and jobs waiting for a depends_on job, that is still queued, running
+// or awaiting review are not stuck.
+func (r *JobRepository) ListStuck(ctx context.Context, status string, before time.Time, limit int) ([]*models.Job, error) {
+	query := `
+		SELECT ` + adminJobColumns + `
+		FROM jobs
+		WHERE status = $1::job_status AND GREATEST(retry_at, retry_requested_at, heartbeat_at, COALESCE(review_requested_at, started_at, created_at)) < $2
+			AND NOT EXISTS (
+				SELECT 1 FROM jobs AS src
+				WHERE src.id = jobs.duplicate_of AND src.status IN ('queued', 'running', 'awaiting_review')
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM jsonb_array_elements_text(COALESCE(jobs.depends_on, '[]'::jsonb)) AS dep(id)
+				JOIN jobs AS parent ON parent.id = dep.id::uuid
+				WHERE parent.status IN ('queued', 'running', 'awaiting_review')
+			)
+		ORDER BY created_at, id
+		LIMIT $3
+	`
+	return r.listAdmin(ctx, query, status, before, limit)
+}
+
+// ListCreatedBetween returns up to limit jobs created in [from, to), optionally restricted to status
+// (empty means any status), oldest first. Jobs whose retention has ended are left out.
+func (r *JobRepository) ListCreatedBetween(ctx context.Context, from, to time.Time, status string, limit int) ([]*models.Job, error) {
+	query := `
+		SELECT ` + adminJobColumns + `
+		FROM jobs
+		WHERE created_at >= $1 AND created_at < $2
+			AND ($3 = '' OR status = $3::job_status)
+			AND ` + unexpiredJob + `
+		ORDER BY created_at, id
+		LIMIT $4
+	`
+	return r.listAdmin(ctx, query, from, to, status, limit)
+}
+
+func (r *JobRepository) listAdmin(ctx context.Context, query string, args ...interface{}) ([]*models.Job, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*models.Job
+	for rows.Next() {
+		job := &models.Job{}
+		if err := rows.Scan(
+			&job.ID, &job.UserID, &job.APIKeyID, &job.Status, &job.InputType, &job.SegmentsCount, &job.AudioType,
+			&job.InputSource, &job.ErrorCode, &job.ErrorMessage, &job.CreatedAt, &job.StartedAt, &job.FinishedAt,
+			&job.ExpiresAt, &job.ExpiredAt,
+		); err != nil {
+			return nil, err
+		}
+		job.SetDurationMs()
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// ResetForReprocess puts a finished job back into queued with no output, error or timestamps so the
+// worker runs it from scratch. Segments (and their assets, by cascade) are removed in the same transaction,
+// with the assets' S3 objects queued in storage_deletions, and so is the webhook delivery so the webhook
+// is called again when the job finishes.
+// This is the only way out of a terminal status and is reserved for operators (storiesctl reprocess);
+// jobs that are still queued or running are rejected with ErrInvalidJobTransition, and jobs whose retention
+// has ended with ErrJobExpired.
+func (r *JobRepository) ResetForReprocess(ctx context.Context, jobID uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE jobs
+		SET status = 'queued', output_markup = NULL, error_code = NULL, error_message = NULL,
+		    started_at = NULL, finished_at = NULL, heartbeat_at = NULL,
+		    progress_step = NULL, segments_total = 0, segments_completed = 0, segments_failed = 0
+		WHERE id = $1 AND status IN ('succeeded', 'failed', 'canceled') AND `+unexpiredJob+`
+	`, jobID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		var unexpired bool
+		err := r.db.QueryRowContext(ctx, `SELECT `+unexpiredJob+` FROM jobs WHERE id = $1`, jobID).Scan(&unexpired)
+		if err == nil && !unexpired {
+			return fmt.Errorf("%w: job %s", ErrJobExpired, jobID)
+		}
+		return r.transitionError(ctx, jobID, models.JobStatusQueued)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO storage_deletions (s3_bucket, s3_key, job_id)
+		SELECT s3_bucket, s3_key, job_id FROM assets WHERE job_id = $1 AND segment_id IS NOT NULL AND s3_key <> ''
+	`, jobID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM segments WHERE job_id = $1`, jobID); err != nil {
+		return err
+	}
+	if err := deleteJobDelivery(ctx, tx, jobID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
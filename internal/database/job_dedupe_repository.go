@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// FindDedupeSource returns the newest job of the user with the given content hash that a new job can
+// reuse (queued, running, awaiting review or succeeded, not itself a duplicate, and not expired), or nil if
+// there is none.
+func (r *JobRepository) FindDedupeSource(ctx context.Context, userID uuid.UUID, contentHash string) (*models.Job, error) {
+	query := `
+		SELECT id
+		FROM jobs
+		WHERE user_id = $1 AND content_hash = $2 AND duplicate_of IS NULL
+			AND status IN ('queued', 'running', 'awaiting_review', 'succeeded')
+			AND expired_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+	var id uuid.UUID
+	err := r.db.QueryRowContext(ctx, query, userID, contentHash).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// ListQueuedDuplicates returns the IDs of queued jobs waiting for sourceID's results.
+func (r *JobRepository) ListQueuedDuplicates(ctx context.Context, sourceID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id FROM jobs WHERE duplicate_of = $1 AND status = 'queued' ORDER BY created_at
+	`, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// CopyProgress copies the progress step and segment counters of sourceID to jobID.
+func (r *JobRepository) CopyProgress(ctx context.Context, jobID, sourceID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE jobs AS j
+		SET progress_step = s.progress_step, segments_total = s.segments_total,
+		    segments_completed = s.segments_completed, segments_failed = s.segments_failed
+		FROM jobs AS s
+		WHERE j.id = $1 AND s.id = $2
+	`, jobID, sourceID)
+	return err
+}
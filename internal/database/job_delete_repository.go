@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// ErrJobHasDuplicates is returned by Delete for a job whose results other jobs (dedupe duplicates) use.
+var ErrJobHasDuplicates = errors.New("job has duplicates")
+
+// Delete deletes a finished job with its segments, assets, events, versions and webhook deliveries (the
+// foreign keys cascade), queueing the S3 objects of its assets in storage_deletions in the same
+// transaction. It returns how many objects were queued. A job that is not finished is left alone
+// (ErrInvalidJobTransition, wrapped), as is one with duplicates, whose markup points at its assets
+// (ErrJobHasDuplicates).
+func (r *JobRepository) Delete(ctx context.Context, jobID uuid.UUID) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var status string
+	var hasDuplicates bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT status, EXISTS (SELECT 1 FROM jobs AS dup WHERE dup.duplicate_of = jobs.id)
+		FROM jobs
+		WHERE id = $1
+		FOR UPDATE
+	`, jobID).Scan(&status, &hasDuplicates)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("job not found: %s", jobID)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if status != "succeeded" && status != "failed" && status != "canceled" {
+		return 0, fmt.Errorf("%w: job %s is %s, cannot be deleted", ErrInvalidJobTransition, jobID, status)
+	}
+	if hasDuplicates {
+		return 0, ErrJobHasDuplicates
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO storage_deletions (s3_bucket, s3_key, job_id)
+		SELECT s3_bucket, s3_key, job_id FROM assets WHERE job_id = $1 AND s3_key <> ''
+	`, jobID)
+	if err != nil {
+		return 0, err
+	}
+	objects, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM jobs WHERE id = $1`, jobID); err != nil {
+		return 0, err
+	}
+	return objects, tx.Commit()
+}
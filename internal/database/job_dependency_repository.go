@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// encodeDependsOn marshals a job's dependencies for the depends_on JSONB column (nil when empty).
+func encodeDependsOn(ids []uuid.UUID) ([]byte, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal depends_on: %w", err)
+	}
+	return b, nil
+}
+
+// decodeDependsOn unmarshals the depends_on JSONB column (nil when NULL).
+func decodeDependsOn(b []byte) ([]uuid.UUID, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var ids []uuid.UUID
+	if err := json.Unmarshal(b, &ids); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal depends_on: %w", err)
+	}
+	return ids, nil
+}
+
+// ListQueuedDependents returns the IDs of queued jobs that depend on jobID, oldest first.
+func (r *JobRepository) ListQueuedDependents(ctx context.Context, jobID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id FROM jobs WHERE depends_on @> jsonb_build_array($1::text) AND status = 'queued' ORDER BY created_at
+	`, jobID.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// JobEventRepository handles job event log database operations
+type JobEventRepository struct {
+	db *DB
+}
+
+// NewJobEventRepository creates a new JobEventRepository
+func NewJobEventRepository(db *DB) *JobEventRepository {
+	return &JobEventRepository{db: db}
+}
+
+// Create inserts a job event
+func (r *JobEventRepository) Create(ctx context.Context, ev *models.JobEvent) error {
+	var dataJSON []byte
+	if ev.Data != nil {
+		var err error
+		dataJSON, err = json.Marshal(ev.Data)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event data: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO job_events (id, job_id, event_type, message, data, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		ev.ID, ev.JobID, ev.Type, ev.Message, dataJSON, ev.CreatedAt,
+	)
+	return err
+}
+
+// ListByJob returns all events for a job in chronological order
+func (r *JobEventRepository) ListByJob(ctx context.Context, jobID uuid.UUID) ([]*models.JobEvent, error) {
+	query := `
+		SELECT id, job_id, event_type, message, data, created_at
+		FROM job_events
+		WHERE job_id = $1
+		ORDER BY created_at ASC, id ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("list job events: %w", err)
+	}
+	defer rows.Close()
+
+	var list []*models.JobEvent
+	for rows.Next() {
+		ev := &models.JobEvent{}
+		var dataJSON []byte
+		if err := rows.Scan(&ev.ID, &ev.JobID, &ev.Type, &ev.Message, &dataJSON, &ev.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan job event: %w", err)
+		}
+		if len(dataJSON) > 0 {
+			if err := json.Unmarshal(dataJSON, &ev.Data); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal event data: %w", err)
+			}
+		}
+		list = append(list, ev)
+	}
+	return list, rows.Err()
+}
+
+// CountByType returns how many events of eventType a job has and when the latest one was recorded (nil if none).
+func (r *JobEventRepository) CountByType(ctx context.Context, jobID uuid.UUID, eventType string) (int, *time.Time, error) {
+	query := `
+		SELECT COUNT(*), MAX(created_at)
+		FROM job_events
+		WHERE job_id = $1 AND event_type = $2
+	`
+	var count int
+	var last *time.Time
+	if err := r.db.QueryRowContext(ctx, query, jobID, eventType).Scan(&count, &last); err != nil {
+		return 0, nil, fmt.Errorf("count job events: %w", err)
+	}
+	return count, last, nil
+}
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// UpdateExperiments stores the experiment variants a job runs with (experiment name -> variant).
+func (r *JobRepository) UpdateExperiments(ctx context.Context, jobID uuid.UUID, variants map[string]string) error {
+	var variantsJSON []byte
+	if len(variants) > 0 {
+		var err error
+		if variantsJSON, err = json.Marshal(variants); err != nil {
+			return fmt.Errorf("failed to marshal experiments: %w", err)
+		}
+	}
+	_, err := r.db.ExecContext(ctx, `UPDATE jobs SET experiments = $1 WHERE id = $2`, variantsJSON, jobID)
+	return err
+}
+
+// decodeJobExperiments unmarshals the experiments JSONB column into job.
+func decodeJobExperiments(job *models.Job, experimentsJSON []byte) error {
+	if len(experimentsJSON) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(experimentsJSON, &job.Experiments); err != nil {
+		return fmt.Errorf("failed to unmarshal experiments: %w", err)
+	}
+	return nil
+}
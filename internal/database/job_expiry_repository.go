@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrJobExpired is returned by ResetForReprocess for jobs whose retention has ended: their results could
+// not be read (the API answers 410) and the expiry loop would not delete them again.
+var ErrJobExpired = errors.New("job retention has ended")
+
+// unexpiredJob is the condition on jobs whose retention has not ended, whether or not the expiry loop
+// has reached them.
+const unexpiredJob = `expired_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())`
+
+// expirableJob is the condition on jobs (and their duplicates, aliased dup) for the expiry loop: the
+// retention has ended, the job has finished and no unexpired duplicate shows its results.
+const expirableJob = `expired_at IS NULL AND expires_at <= $1
+	AND status IN ('succeeded', 'failed', 'canceled')
+	AND NOT EXISTS (SELECT 1 FROM jobs AS dup WHERE dup.duplicate_of = jobs.id AND dup.expired_at IS NULL)`
+
+// ListExpired returns up to limit jobs whose retention ended at or before now and that can expire, oldest
+// expiry first (served by idx_jobs_expires_at). Unfinished jobs wait until they finish, and jobs other jobs
+// duplicate until their duplicates have expired.
+func (r *JobRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id FROM jobs
+		WHERE `+expirableJob+`
+		ORDER BY expires_at, id
+		LIMIT $2
+	`, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Expire marks a job returned by ListExpired as expired and deletes its assets, queueing their S3 objects
+// in storage_deletions in the same transaction; segments, events and the job itself stay. It returns how
+// many objects were queued, and false when the job can no longer expire (it changed since ListExpired).
+func (r *JobRepository) Expire(ctx context.Context, jobID uuid.UUID, now time.Time) (int64, bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback()
+
+	var id uuid.UUID
+	err = tx.QueryRowContext(ctx, `
+		SELECT id FROM jobs
+		WHERE id = $2 AND `+expirableJob+`
+		FOR UPDATE
+	`, now, jobID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO storage_deletions (s3_bucket, s3_key, job_id)
+		SELECT s3_bucket, s3_key, job_id FROM assets WHERE job_id = $1 AND s3_key <> ''
+	`, jobID)
+	if err != nil {
+		return 0, false, err
+	}
+	objects, err := result.RowsAffected()
+	if err != nil {
+		return 0, false, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM assets WHERE job_id = $1`, jobID); err != nil {
+		return 0, false, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE jobs SET expired_at = $2 WHERE id = $1`, jobID, now); err != nil {
+		return 0, false, fmt.Errorf("failed to mark job expired: %w", err)
+	}
+	return objects, true, tx.Commit()
+}
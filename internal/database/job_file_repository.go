@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 
 	"github.com/google/uuid"
@@ -21,13 +22,21 @@ func NewJobFileRepository(db *DB) *JobFileRepository {
 
 // Create creates a new job_file link
 func (r *JobFileRepository) Create(ctx context.Context, jf *models.JobFile) error {
+	metaJSON, err := marshalJobFileMeta(jf.Meta)
+	if err != nil {
+		return err
+	}
+	optionsJSON, err := marshalExtractionOptions(jf.ExtractionOptions)
+	if err != nil {
+		return err
+	}
 	query := `
 		INSERT INTO job_files (
-			id, job_id, file_id, processing_order, status, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6)
+			id, job_id, file_id, processing_order, extracted_text, status, created_at, meta, extraction_options
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
-	_, err := r.db.ExecContext(ctx, query,
-		jf.ID, jf.JobID, jf.FileID, jf.ProcessingOrder, jf.Status, jf.CreatedAt,
+	_, err = r.db.ExecContext(ctx, query,
+		jf.ID, jf.JobID, jf.FileID, jf.ProcessingOrder, jf.ExtractedText, jf.Status, jf.CreatedAt, metaJSON, optionsJSON,
 	)
 	return err
 }
@@ -35,7 +44,7 @@ func (r *JobFileRepository) Create(ctx context.Context, jf *models.JobFile) erro
 // ListByJob retrieves all job_file links for a job, ordered by processing_order
 func (r *JobFileRepository) ListByJob(ctx context.Context, jobID uuid.UUID) ([]*models.JobFile, error) {
 	query := `
-		SELECT id, job_id, file_id, processing_order, extracted_text, status, created_at
+		SELECT id, job_id, file_id, processing_order, extracted_text, status, created_at, meta, extraction_options
 		FROM job_files
 		WHERE job_id = $1
 		ORDER BY processing_order ASC
@@ -50,16 +59,25 @@ func (r *JobFileRepository) ListByJob(ctx context.Context, jobID uuid.UUID) ([]*
 	for rows.Next() {
 		jf := &models.JobFile{}
 		var extractedText sql.NullString
+		var metaJSON, optionsJSON []byte
 		err := rows.Scan(
 			&jf.ID, &jf.JobID, &jf.FileID, &jf.ProcessingOrder,
-			&extractedText, &jf.Status, &jf.CreatedAt,
+			&extractedText, &jf.Status, &jf.CreatedAt, &metaJSON, &optionsJSON,
 		)
 		if err != nil {
 			return nil, err
 		}
+		if jf.ExtractionOptions, err = unmarshalExtractionOptions(optionsJSON); err != nil {
+			return nil, err
+		}
 		if extractedText.Valid {
 			jf.ExtractedText = &extractedText.String
 		}
+		if len(metaJSON) > 0 {
+			if err := json.Unmarshal(metaJSON, &jf.Meta); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal meta: %w", err)
+			}
+		}
 		list = append(list, jf)
 	}
 	return list, rows.Err()
@@ -82,3 +100,31 @@ func (r *JobFileRepository) UpdateExtraction(ctx context.Context, id uuid.UUID,
 	}
 	return nil
 }
+
+// UpdateMeta stores extraction metadata (e.g. PDF page offsets) for a job_file
+func (r *JobFileRepository) UpdateMeta(ctx context.Context, id uuid.UUID, meta *models.JobFileMeta) error {
+	metaJSON, err := marshalJobFileMeta(meta)
+	if err != nil {
+		return err
+	}
+	result, err := r.db.ExecContext(ctx, `UPDATE job_files SET meta = $1 WHERE id = $2`, metaJSON, id)
+	if err != nil {
+		return err
+	}
+	n, _ := result.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("job_file not found")
+	}
+	return nil
+}
+
+func marshalJobFileMeta(meta *models.JobFileMeta) ([]byte, error) {
+	if meta == nil {
+		return nil, nil
+	}
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal meta: %w", err)
+	}
+	return metaJSON, nil
+}
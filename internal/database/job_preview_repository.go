@@ -0,0 +1,32 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// UpdatePreview stores a job's card preview (see models.JobPreview), replacing the previous one.
+func (r *JobRepository) UpdatePreview(ctx context.Context, jobID uuid.UUID, preview *models.JobPreview) error {
+	b, err := json.Marshal(preview)
+	if err != nil {
+		return fmt.Errorf("failed to marshal preview: %w", err)
+	}
+	_, err = r.db.ExecContext(ctx, `UPDATE jobs SET preview = $1 WHERE id = $2`, b, jobID)
+	return err
+}
+
+// decodePreview unmarshals the preview JSONB column (nil when NULL).
+func decodePreview(b []byte) (*models.JobPreview, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var preview models.JobPreview
+	if err := json.Unmarshal(b, &preview); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal preview: %w", err)
+	}
+	return &preview, nil
+}
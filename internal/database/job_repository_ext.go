@@ -2,12 +2,29 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/snappy-loop/stories/internal/models"
 )
 
-// UpdateStatus updates a job's status and error information
+// ErrInvalidJobTransition is returned when a status update is not allowed from the job's current status
+// (see models.CanTransitionJob), e.g. a replayed message trying to move a succeeded job back to running.
+var ErrInvalidJobTransition = errors.New("invalid job status transition")
+
+// UpdateStatus moves a job to status and sets its error information. The UPDATE only matches rows whose
+// current status may transition to status, so concurrent workers and replayed messages cannot undo a
+// terminal state; in that case the returned error wraps ErrInvalidJobTransition.
 func (r *JobRepository) UpdateStatus(ctx context.Context, jobID uuid.UUID, status string, errorCode, errorMessage *string) error {
+	from := models.JobStatusSources(status)
+	if len(from) == 0 {
+		return fmt.Errorf("%w: no transition leads to %s", ErrInvalidJobTransition, status)
+	}
+
 	query := `
 		UPDATE jobs
 		SET status = $1::job_status,
@@ -15,11 +32,44 @@ func (r *JobRepository) UpdateStatus(ctx context.Context, jobID uuid.UUID, statu
 		    error_message = $3,
 		    started_at = CASE WHEN status = 'queued' AND ($1::job_status = 'running') THEN NOW() ELSE started_at END,
 		    finished_at = CASE WHEN $1::job_status IN ('succeeded', 'failed', 'canceled') THEN NOW() ELSE finished_at END
-		WHERE id = $4
+		WHERE id = $4 AND status = ANY($5::job_status[])
 	`
 
-	_, err := r.db.ExecContext(ctx, query, status, errorCode, errorMessage, jobID)
-	return err
+	result, err := r.db.ExecContext(ctx, query, status, errorCode, errorMessage, jobID, pq.Array(from))
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return r.transitionError(ctx, jobID, status)
+	}
+	return nil
+}
+
+// IsCanceled reports whether a job has been canceled; the worker checks it between pipeline steps.
+func (r *JobRepository) IsCanceled(ctx context.Context, jobID uuid.UUID) (bool, error) {
+	var canceled bool
+	err := r.db.QueryRowContext(ctx, `SELECT status = 'canceled' FROM jobs WHERE id = $1`, jobID).Scan(&canceled)
+	if err == sql.ErrNoRows {
+		return false, fmt.Errorf("job not found: %s", jobID)
+	}
+	return canceled, err
+}
+
+// transitionError explains why a conditional status update matched no row.
+func (r *JobRepository) transitionError(ctx context.Context, jobID uuid.UUID, to string) error {
+	var current string
+	err := r.db.QueryRowContext(ctx, `SELECT status FROM jobs WHERE id = $1`, jobID).Scan(&current)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("job not found: %s", jobID)
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: job %s is %s, cannot move to %s", ErrInvalidJobTransition, jobID, current, to)
 }
 
 // UpdateMarkup updates a job's output markup
@@ -44,3 +94,167 @@ func (r *JobRepository) UpdateExtractedText(ctx context.Context, jobID uuid.UUID
 	_, err := r.db.ExecContext(ctx, query, extractedText, jobID)
 	return err
 }
+
+// FailIfStatus marks a job failed only if it is still in expectedStatus, so a job that finished
+// concurrently is left untouched. Reports whether the job was updated.
+func (r *JobRepository) FailIfStatus(ctx context.Context, jobID uuid.UUID, expectedStatus, errorCode, errorMessage string) (bool, error) {
+	if !models.CanTransitionJob(expectedStatus, models.JobStatusFailed) {
+		return false, fmt.Errorf("%w: cannot fail a %s job", ErrInvalidJobTransition, expectedStatus)
+	}
+	query := `
+		UPDATE jobs
+		SET status = 'failed',
+		    error_code = $1,
+		    error_message = $2,
+		    finished_at = NOW()
+		WHERE id = $3 AND status = $4::job_status
+	`
+	result, err := r.db.ExecContext(ctx, query, errorCode, errorMessage, jobID, expectedStatus)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// jobProgressColumns holds the scanned progress columns of a job row.
+type jobProgressColumns struct {
+	step                     sql.NullString
+	total, completed, failed int
+}
+
+// apply sets job.Progress from the scanned columns; job.Status must already be set.
+func (c jobProgressColumns) apply(job *models.Job) {
+	job.Progress = models.NewJobProgress(job.Status, c.step.String, c.total, c.completed, c.failed)
+}
+
+// ResetProgress sets the job's current step and zeroes its segment counters (used when a worker
+// picks up or restarts a job).
+func (r *JobRepository) ResetProgress(ctx context.Context, jobID uuid.UUID, step string) error {
+	query := `
+		UPDATE jobs
+		SET progress_step = $1, segments_total = 0, segments_completed = 0, segments_failed = 0, heartbeat_at = NOW()
+		WHERE id = $2
+	`
+	_, err := r.db.ExecContext(ctx, query, step, jobID)
+	return err
+}
+
+// GetOwner returns the user and API key that created a job.
+func (r *JobRepository) GetOwner(ctx context.Context, jobID uuid.UUID) (userID, apiKeyID uuid.UUID, err error) {
+	err = r.db.QueryRowContext(ctx, `SELECT user_id, api_key_id FROM jobs WHERE id = $1`, jobID).Scan(&userID, &apiKeyID)
+	if err == sql.ErrNoRows {
+		err = fmt.Errorf("job not found: %s", jobID)
+	}
+	return userID, apiKeyID, err
+}
+
+// ScheduleRetry parks a running job until retryAt, when a delayed retry picks it up again; the stuck job
+// sweeper leaves it alone until then. It returns false if the job is no longer running.
+func (r *JobRepository) ScheduleRetry(ctx context.Context, jobID uuid.UUID, retryAt time.Time) (bool, error) {
+	query := `
+		UPDATE jobs
+		SET retry_at = $1, progress_step = $2
+		WHERE id = $3 AND status = 'running'
+	`
+	result, err := r.db.ExecContext(ctx, query, retryAt, models.JobStepRetrying, jobID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// HoldQueued parks a queued job until until, at the given progress step: the stuck job sweeper leaves it
+// alone until then and requeues it afterwards. It returns false if the job is no longer queued.
+func (r *JobRepository) HoldQueued(ctx context.Context, jobID uuid.UUID, until time.Time, step string) (bool, error) {
+	query := `
+		UPDATE jobs
+		SET retry_at = $1, progress_step = $2
+		WHERE id = $3 AND status = 'queued'
+	`
+	result, err := r.db.ExecContext(ctx, query, until, step, jobID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// UpdateProgressStep sets the job's current step, leaving segment counters unchanged.
+func (r *JobRepository) UpdateProgressStep(ctx context.Context, jobID uuid.UUID, step string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE jobs SET progress_step = $1, heartbeat_at = NOW() WHERE id = $2`, step, jobID)
+	return err
+}
+
+// StartSegmentProgress records the number of segments of the job and how many of them are completed
+// already (kept from a failed run when the job is retried), and moves the job to the generating step.
+func (r *JobRepository) StartSegmentProgress(ctx context.Context, jobID uuid.UUID, total, completed int) error {
+	query := `
+		UPDATE jobs
+		SET progress_step = $1, segments_total = $2, segments_completed = $3, segments_failed = 0, heartbeat_at = NOW()
+		WHERE id = $4
+	`
+	_, err := r.db.ExecContext(ctx, query, models.JobStepGenerating, total, completed, jobID)
+	return err
+}
+
+// IncrementSegmentProgress atomically counts one finished segment as completed or failed.
+func (r *JobRepository) IncrementSegmentProgress(ctx context.Context, jobID uuid.UUID, failed bool) error {
+	query := `
+		UPDATE jobs
+		SET segments_completed = segments_completed + CASE WHEN $1 THEN 0 ELSE 1 END,
+		    segments_failed = segments_failed + CASE WHEN $1 THEN 1 ELSE 0 END,
+		    heartbeat_at = NOW()
+		WHERE id = $2
+	`
+	_, err := r.db.ExecContext(ctx, query, failed, jobID)
+	return err
+}
+
+// ClaimRun marks a job as processed by the calling worker by setting its heartbeat, unless another
+// worker's heartbeat is more recent than staleBefore. It reports whether the job was claimed. The
+// progress updates above refresh the heartbeat too.
+func (r *JobRepository) ClaimRun(ctx context.Context, jobID uuid.UUID, staleBefore time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE jobs SET heartbeat_at = NOW()
+		WHERE id = $1 AND (heartbeat_at IS NULL OR heartbeat_at < $2)
+	`, jobID, staleBefore)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// Heartbeat refreshes the heartbeat of a job the calling worker has claimed.
+func (r *JobRepository) Heartbeat(ctx context.Context, jobID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE jobs SET heartbeat_at = NOW() WHERE id = $1`, jobID)
+	return err
+}
+
+// ReleaseRun clears the heartbeat of a job the calling worker stopped processing.
+func (r *JobRepository) ReleaseRun(ctx context.Context, jobID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE jobs SET heartbeat_at = NULL WHERE id = $1`, jobID)
+	return err
+}
+
+// SetDisclaimer records the version of the compliance disclaimer applied to a job
+func (r *JobRepository) SetDisclaimer(ctx context.Context, jobID uuid.UUID, jurisdiction string, version int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE jobs SET disclaimer_jurisdiction = $1, disclaimer_version = $2 WHERE id = $3
+	`, jurisdiction, version, jobID)
+	return err
+}
@@ -0,0 +1,228 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/testutil"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	testutil.Cleanup()
+	os.Exit(code)
+}
+
+func TestJobRepository_CreateAndGet(t *testing.T) {
+	db := testutil.Postgres(t)
+	user := testutil.CreateUser(t, db)
+	_, key := testutil.CreateAPIKey(t, db, user.ID)
+	job := testutil.NewJob(key)
+	job.Metadata = map[string]string{"document_id": "doc-42"}
+	job.Tags = []string{"biology", "grade-7"}
+	testutil.InsertJob(t, db, job)
+
+	got, err := database.NewJobRepository(db).GetByID(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.InputText != job.InputText || got.SegmentsCount != 2 || got.Status != models.JobStatusQueued {
+		t.Errorf("job = %+v, want the inserted queued job", got)
+	}
+	if got.Metadata["document_id"] != "doc-42" || len(got.Tags) != 2 || got.Tags[1] != "grade-7" {
+		t.Errorf("labels = %v %v, want metadata and tags round-tripped", got.Metadata, got.Tags)
+	}
+}
+
+func TestJobRepository_UpdateStatusTransitions(t *testing.T) {
+	db := testutil.Postgres(t)
+	repo := database.NewJobRepository(db)
+	ctx := context.Background()
+	job := testutil.CreateJob(t, db)
+
+	if err := repo.UpdateStatus(ctx, job.ID, models.JobStatusRunning, nil, nil); err != nil {
+		t.Fatalf("queued -> running: %v", err)
+	}
+	if err := repo.UpdateStatus(ctx, job.ID, models.JobStatusSucceeded, nil, nil); err != nil {
+		t.Fatalf("running -> succeeded: %v", err)
+	}
+	// A late duplicate delivery must not reopen or fail a finished job
+	for _, to := range []string{models.JobStatusRunning, models.JobStatusFailed} {
+		if err := repo.UpdateStatus(ctx, job.ID, to, nil, nil); !errors.Is(err, database.ErrInvalidJobTransition) {
+			t.Errorf("succeeded -> %s: err = %v, want ErrInvalidJobTransition", to, err)
+		}
+	}
+
+	got, err := repo.GetByID(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.Status != models.JobStatusSucceeded || got.StartedAt == nil || got.FinishedAt == nil {
+		t.Errorf("status = %s, started = %v, finished = %v; want succeeded with both times", got.Status, got.StartedAt, got.FinishedAt)
+	}
+}
+
+func TestJobRepository_Heartbeat(t *testing.T) {
+	db := testutil.Postgres(t)
+	repo := database.NewJobRepository(db)
+	ctx := context.Background()
+	job := testutil.CreateJob(t, db)
+	if err := repo.UpdateStatus(ctx, job.ID, models.JobStatusRunning, nil, nil); err != nil {
+		t.Fatalf("queued -> running: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE jobs SET started_at = NOW() - INTERVAL '2 hours' WHERE id = $1`, job.ID); err != nil {
+		t.Fatalf("backdate started_at: %v", err)
+	}
+	stuck := func() bool {
+		t.Helper()
+		jobs, err := repo.ListStuck(ctx, models.JobStatusRunning, time.Now().Add(-30*time.Minute), 10)
+		if err != nil {
+			t.Fatalf("ListStuck: %v", err)
+		}
+		return len(jobs) == 1
+	}
+
+	staleBefore := time.Now().Add(-90 * time.Second)
+	if ok, err := repo.ClaimRun(ctx, job.ID, staleBefore); err != nil || !ok {
+		t.Fatalf("first claim = %v, %v; want claimed", ok, err)
+	}
+	if ok, err := repo.ClaimRun(ctx, job.ID, staleBefore); err != nil || ok {
+		t.Errorf("claim while the heartbeat is fresh = %v, %v; want refused", ok, err)
+	}
+	if stuck() {
+		t.Error("job with a fresh heartbeat is listed as stuck though it started 2 hours ago")
+	}
+
+	if err := repo.ReleaseRun(ctx, job.ID); err != nil {
+		t.Fatalf("ReleaseRun: %v", err)
+	}
+	if !stuck() {
+		t.Error("released job started 2 hours ago is not listed as stuck")
+	}
+	if ok, err := repo.ClaimRun(ctx, job.ID, staleBefore); err != nil || !ok {
+		t.Errorf("claim after release = %v, %v; want claimed", ok, err)
+	}
+}
+
+func TestJobRepository_ResetForReprocess(t *testing.T) {
+	db := testutil.Postgres(t)
+	repo := database.NewJobRepository(db)
+	ctx := context.Background()
+	job := testutil.CreateJob(t, db)
+	for _, status := range []string{models.JobStatusRunning, models.JobStatusSucceeded} {
+		if err := repo.UpdateStatus(ctx, job.ID, status, nil, nil); err != nil {
+			t.Fatalf("move to %s: %v", status, err)
+		}
+	}
+	now := time.Now()
+	segment := &models.Segment{ID: uuid.New(), JobID: job.ID, Idx: 0, SegmentText: "The Sun is a star.", Status: "succeeded",
+		CreatedAt: now, UpdatedAt: now}
+	if err := database.NewSegmentRepository(db).Create(ctx, segment); err != nil {
+		t.Fatalf("create segment: %v", err)
+	}
+	asset := &models.Asset{ID: uuid.New(), JobID: job.ID, SegmentID: &segment.ID, Kind: "audio", MimeType: "audio/mpeg",
+		S3Bucket: "stories", S3Key: "jobs/" + job.ID.String() + "/0.mp3", SizeBytes: 1, CreatedAt: now}
+	if err := database.NewAssetRepository(db).Create(ctx, asset); err != nil {
+		t.Fatalf("create asset: %v", err)
+	}
+	deliveries := database.NewWebhookDeliveryRepository(db)
+	delivery := &models.WebhookDelivery{ID: uuid.New(), JobID: job.ID, URL: "https://example.com/hook", Status: "sent",
+		Attempts: 1, CreatedAt: now}
+	if err := deliveries.Create(ctx, delivery); err != nil {
+		t.Fatalf("create delivery: %v", err)
+	}
+
+	if err := repo.ResetForReprocess(ctx, job.ID); err != nil {
+		t.Fatalf("ResetForReprocess: %v", err)
+	}
+
+	var queued string
+	if err := db.QueryRowContext(ctx, `SELECT s3_key FROM storage_deletions WHERE job_id = $1`, job.ID).Scan(&queued); err != nil || queued != asset.S3Key {
+		t.Errorf("queued deletion = %q, %v; want %q", queued, err, asset.S3Key)
+	}
+	if got, err := deliveries.GetByJobID(ctx, job.ID); err != nil || len(got) != 0 {
+		t.Errorf("deliveries after reprocess = %v, %v; want none so the webhook is delivered again", got, err)
+	}
+	if got, err := repo.GetByID(ctx, job.ID); err != nil || got.Status != models.JobStatusQueued {
+		t.Errorf("job after reprocess = %+v, %v; want queued", got, err)
+	}
+
+	// An expired job is neither listed for reprocessing nor reset
+	expired := testutil.CreateJob(t, db)
+	if _, err := db.ExecContext(ctx, `UPDATE jobs SET status = 'succeeded', finished_at = NOW(), expired_at = NOW() WHERE id = $1`, expired.ID); err != nil {
+		t.Fatalf("expire job: %v", err)
+	}
+	listed, err := repo.ListCreatedBetween(ctx, now.Add(-time.Hour), time.Now().Add(time.Hour), models.JobStatusSucceeded, 100)
+	if err != nil {
+		t.Fatalf("ListCreatedBetween: %v", err)
+	}
+	for _, j := range listed {
+		if j.ID == expired.ID {
+			t.Error("expired job listed for reprocessing")
+		}
+	}
+	if err := repo.ResetForReprocess(ctx, expired.ID); !errors.Is(err, database.ErrJobExpired) {
+		t.Errorf("reprocess expired job: err = %v, want ErrJobExpired", err)
+	}
+}
+
+func TestJobRepository_Stats(t *testing.T) {
+	db := testutil.Postgres(t)
+	repo := database.NewJobRepository(db)
+	ctx := context.Background()
+	user := testutil.CreateUser(t, db)
+	_, key := testutil.CreateAPIKey(t, db, user.ID)
+	from := time.Now().Add(-time.Hour)
+
+	succeeded := testutil.NewJob(key)
+	succeeded.CharsCharged = 120
+	failed := testutil.NewJob(key)
+	failed.CharsCharged = 80
+	testutil.InsertJob(t, db, succeeded)
+	testutil.InsertJob(t, db, failed)
+	testutil.InsertJob(t, db, testutil.NewJob(key))
+	testutil.CreateJob(t, db) // another user
+	code := "segmentation_failed"
+	for _, step := range []struct {
+		job    *models.Job
+		status string
+		code   *string
+	}{
+		{succeeded, models.JobStatusRunning, nil}, {succeeded, models.JobStatusSucceeded, nil},
+		{failed, models.JobStatusRunning, nil}, {failed, models.JobStatusFailed, &code},
+	} {
+		if err := repo.UpdateStatus(ctx, step.job.ID, step.status, step.code, nil); err != nil {
+			t.Fatalf("UpdateStatus: %v", err)
+		}
+	}
+
+	days, totals, err := repo.Stats(ctx, &user.ID, from)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if totals.Jobs != 3 || totals.Succeeded != 1 || totals.Failed != 1 || totals.CharsCharged != 200 || totals.AvgDurationMs == nil {
+		t.Errorf("totals = %+v", totals)
+	}
+	if len(days) == 0 || days[len(days)-1].Date != time.Now().UTC().Format("2006-01-02") {
+		t.Errorf("days = %+v, want today last", days)
+	}
+
+	codes, err := repo.TopFailureCodes(ctx, &user.ID, from, 10)
+	if err != nil {
+		t.Fatalf("TopFailureCodes: %v", err)
+	}
+	if len(codes) != 1 || codes[0].Code != code || codes[0].Count != 1 {
+		t.Errorf("codes = %+v", codes)
+	}
+
+	// All users
+	if _, all, err := repo.Stats(ctx, nil, from); err != nil || all.Jobs < 4 {
+		t.Errorf("global totals = %+v, %v", all, err)
+	}
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// ResetForRetry queues a failed job again (POST /v1/jobs/{id}/retry), clearing its error, finish time and
+// progress. Unlike ResetForReprocess it keeps the segments, assets, markup and extracted text, so the
+// worker only regenerates the segments that did not succeed. The failed run's webhook delivery is removed
+// in the same transaction so the retried run's outcome is delivered. Returns ErrInvalidJobTransition
+// (wrapped) when the job is not failed.
+func (r *JobRepository) ResetForRetry(ctx context.Context, jobID uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE jobs
+		SET status = 'queued', error_code = NULL, error_message = NULL, finished_at = NULL, retry_at = NULL,
+		    retry_requested_at = NOW(), progress_step = NULL, segments_total = 0, segments_completed = 0, segments_failed = 0
+		WHERE id = $1 AND status = 'failed'
+	`, jobID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return r.transitionError(ctx, jobID, models.JobStatusQueued)
+	}
+
+	if err := deleteJobDelivery(ctx, tx, jobID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// ApproveReview moves a job awaiting review to succeeded. Returns an error wrapping ErrInvalidJobTransition
+// when the job is not awaiting review (e.g. it is regenerating segments).
+func (r *JobRepository) ApproveReview(ctx context.Context, jobID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE jobs SET status = 'succeeded', finished_at = NOW()
+		WHERE id = $1 AND status = 'awaiting_review'
+	`, jobID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return r.transitionError(ctx, jobID, models.JobStatusSucceeded)
+	}
+	return nil
+}
+
+// RequestRegeneration moves a job awaiting review back to queued with the reviewer's request stored in
+// review_regeneration, and queues the requested segments. Returns an error wrapping ErrInvalidJobTransition
+// when the job is not awaiting review.
+func (r *JobRepository) RequestRegeneration(ctx context.Context, jobID uuid.UUID, req *models.ReviewRegeneration) error {
+	reqJSON, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal review regeneration: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE jobs
+		SET status = 'queued', review_regeneration = $1, review_requested_at = NOW()
+		WHERE id = $2 AND status = 'awaiting_review'
+	`, reqJSON, jobID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return r.transitionError(ctx, jobID, models.JobStatusQueued)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE segments SET status = 'queued', updated_at = NOW()
+		WHERE job_id = $1 AND idx = ANY($2)
+	`, jobID, pq.Array(req.Segments)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ClearReviewRegeneration removes a job's pending regeneration request once the worker has handled it.
+func (r *JobRepository) ClearReviewRegeneration(ctx context.Context, jobID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE jobs SET review_regeneration = NULL WHERE id = $1`, jobID)
+	return err
+}
+
+// decodeJobReview unmarshals the review_regeneration JSONB column into job.
+func decodeJobReview(job *models.Job, reviewJSON []byte) error {
+	if len(reviewJSON) == 0 {
+		return nil
+	}
+	job.ReviewRegeneration = &models.ReviewRegeneration{}
+	if err := json.Unmarshal(reviewJSON, job.ReviewRegeneration); err != nil {
+		return fmt.Errorf("failed to unmarshal review regeneration: %w", err)
+	}
+	return nil
+}
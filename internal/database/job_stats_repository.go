@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// Stats aggregates the jobs created since from, of one user or all users when userID is nil: one bucket
+// per UTC day with jobs, oldest first, and the totals. Success rates are left to the caller.
+func (r *JobRepository) Stats(ctx context.Context, userID *uuid.UUID, from time.Time) ([]models.JobStatsBucket, models.JobStatsBucket, error) {
+	query := `
+		SELECT day, COUNT(*),
+			COUNT(*) FILTER (WHERE status = 'succeeded'),
+			COUNT(*) FILTER (WHERE status = 'failed'),
+			COUNT(*) FILTER (WHERE status = 'canceled'),
+			AVG(duration_ms) FILTER (WHERE status IN ('succeeded', 'failed')),
+			COALESCE(SUM(chars_charged), 0)
+		FROM (
+			SELECT to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day, status, chars_charged,
+				EXTRACT(EPOCH FROM finished_at - started_at) * 1000 AS duration_ms
+			FROM jobs
+			WHERE created_at >= $1 AND ($2::uuid IS NULL OR user_id = $2)
+		) j
+		GROUP BY GROUPING SETS ((day), ())
+		ORDER BY day NULLS FIRST
+	`
+	rows, err := r.db.QueryContext(ctx, query, from, userID)
+	if err != nil {
+		return nil, models.JobStatsBucket{}, err
+	}
+	defer rows.Close()
+
+	var days []models.JobStatsBucket
+	var totals models.JobStatsBucket
+	for rows.Next() {
+		var b models.JobStatsBucket
+		var day sql.NullString
+		var avgDuration sql.NullFloat64
+		if err := rows.Scan(&day, &b.Jobs, &b.Succeeded, &b.Failed, &b.Canceled, &avgDuration, &b.CharsCharged); err != nil {
+			return nil, models.JobStatsBucket{}, err
+		}
+		if avgDuration.Valid {
+			ms := int64(avgDuration.Float64)
+			b.AvgDurationMs = &ms
+		}
+		if !day.Valid {
+			totals = b
+			continue
+		}
+		b.Date = day.String
+		days = append(days, b)
+	}
+	return days, totals, rows.Err()
+}
+
+// TopFailureCodes returns the most frequent error codes of the failed jobs created since from, of one
+// user or all users when userID is nil, most frequent first (at most limit).
+func (r *JobRepository) TopFailureCodes(ctx context.Context, userID *uuid.UUID, from time.Time, limit int) ([]models.FailureCodeCount, error) {
+	query := `
+		SELECT COALESCE(error_code, 'unknown') AS code, COUNT(*)
+		FROM jobs
+		WHERE status = 'failed' AND created_at >= $1 AND ($2::uuid IS NULL OR user_id = $2)
+		GROUP BY code
+		ORDER BY COUNT(*) DESC, code
+		LIMIT $3
+	`
+	rows, err := r.db.QueryContext(ctx, query, from, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	codes := []models.FailureCodeCount{}
+	for rows.Next() {
+		var c models.FailureCodeCount
+		if err := rows.Scan(&c.Code, &c.Count); err != nil {
+			return nil, err
+		}
+		codes = append(codes, c)
+	}
+	return codes, rows.Err()
+}
@@ -0,0 +1,202 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// JobVersionRepository handles job output version (snapshot) database operations
+type JobVersionRepository struct {
+	db *DB
+}
+
+// NewJobVersionRepository creates a new JobVersionRepository
+func NewJobVersionRepository(db *DB) *JobVersionRepository {
+	return &JobVersionRepository{db: db}
+}
+
+// execer is implemented by *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// recordOutputVersion snapshots a job's current markup, segment texts and current assets as its next output version.
+func recordOutputVersion(ctx context.Context, db execer, jobID uuid.UUID, reason string) error {
+	query := `
+		INSERT INTO job_output_versions (job_id, version, reason, output_markup, segments)
+		SELECT j.id,
+			COALESCE((SELECT MAX(v.version) FROM job_output_versions v WHERE v.job_id = j.id), 0) + 1,
+			$2, j.output_markup,
+			(SELECT COALESCE(jsonb_agg(jsonb_build_object(
+				'idx', s.idx,
+				'segment_text', s.segment_text,
+				'narration_text', s.narration_text,
+				'asset_ids', (SELECT COALESCE(jsonb_agg(a.id ORDER BY a.kind), '[]'::jsonb)
+					FROM assets a WHERE a.segment_id = s.id AND a.superseded_at IS NULL)
+			) ORDER BY s.idx), '[]'::jsonb)
+			FROM segments s WHERE s.job_id = j.id)
+		FROM jobs j
+		WHERE j.id = $1
+	`
+	if _, err := db.ExecContext(ctx, query, jobID, reason); err != nil {
+		return fmt.Errorf("record output version: %w", err)
+	}
+	return nil
+}
+
+// Record snapshots a job's current output as its next version
+func (r *JobVersionRepository) Record(ctx context.Context, jobID uuid.UUID, reason string) error {
+	return recordOutputVersion(ctx, r.db, jobID, reason)
+}
+
+// List returns a job's output versions, oldest first, without markup and segments. The latest one is marked current.
+func (r *JobVersionRepository) List(ctx context.Context, jobID uuid.UUID) ([]*models.OutputVersion, error) {
+	query := `
+		SELECT id, job_id, version, reason, created_at
+		FROM job_output_versions
+		WHERE job_id = $1
+		ORDER BY version ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("list output versions: %w", err)
+	}
+	defer rows.Close()
+
+	var list []*models.OutputVersion
+	for rows.Next() {
+		v := &models.OutputVersion{}
+		if err := rows.Scan(&v.ID, &v.JobID, &v.Version, &v.Reason, &v.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan output version: %w", err)
+		}
+		list = append(list, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(list) > 0 {
+		list[len(list)-1].Current = true
+	}
+	return list, nil
+}
+
+// Get retrieves one output version of a job with its markup and segments
+func (r *JobVersionRepository) Get(ctx context.Context, jobID uuid.UUID, version int) (*models.OutputVersion, error) {
+	query := `
+		SELECT id, job_id, version, reason, output_markup, segments, created_at,
+			version = (SELECT MAX(version) FROM job_output_versions WHERE job_id = $1)
+		FROM job_output_versions
+		WHERE job_id = $1 AND version = $2
+	`
+	v := &models.OutputVersion{}
+	var segmentsJSON []byte
+	err := r.db.QueryRowContext(ctx, query, jobID, version).Scan(
+		&v.ID, &v.JobID, &v.Version, &v.Reason, &v.OutputMarkup, &segmentsJSON, &v.CreatedAt, &v.Current,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("output version not found: job_id=%s, version=%d", jobID, version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(segmentsJSON) > 0 {
+		if err := json.Unmarshal(segmentsJSON, &v.Segments); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal version segments: %w", err)
+		}
+	}
+	return v, nil
+}
+
+// Restore makes an output version current again: the job's markup (and its duplicates'), segment texts and
+// assets are set back to the snapshot, and the result is recorded as a new "restored" version. Returns an
+// error wrapping ErrInvalidJobTransition when the job has not finished, and ErrSegmentBusy when a segment
+// is being regenerated.
+func (r *JobVersionRepository) Restore(ctx context.Context, jobID uuid.UUID, version int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRowContext(ctx, `SELECT status FROM jobs WHERE id = $1 FOR UPDATE`, jobID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("job not found: %s", jobID)
+	}
+	if err != nil {
+		return err
+	}
+	if status != models.JobStatusSucceeded && status != models.JobStatusAwaitingReview {
+		return fmt.Errorf("%w: job %s is %s, cannot restore an output version", ErrInvalidJobTransition, jobID, status)
+	}
+
+	var busy bool
+	if err := tx.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM segments WHERE job_id = $1 AND status IN ('queued', 'running'))`, jobID,
+	).Scan(&busy); err != nil {
+		return err
+	}
+	if busy {
+		return ErrSegmentBusy
+	}
+
+	var markup *string
+	var segmentsJSON []byte
+	err = tx.QueryRowContext(ctx,
+		`SELECT output_markup, segments FROM job_output_versions WHERE job_id = $1 AND version = $2`, jobID, version,
+	).Scan(&markup, &segmentsJSON)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("output version not found: job_id=%s, version=%d", jobID, version)
+	}
+	if err != nil {
+		return err
+	}
+	var segments []models.OutputVersionSegment
+	if len(segmentsJSON) > 0 {
+		if err := json.Unmarshal(segmentsJSON, &segments); err != nil {
+			return fmt.Errorf("failed to unmarshal version segments: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE jobs SET output_markup = $1 WHERE id = $2 OR duplicate_of = $2`, markup, jobID,
+	); err != nil {
+		return err
+	}
+
+	var assetIDs []string
+	for _, s := range segments {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE segments SET segment_text = $1, narration_text = $2, updated_at = NOW()
+			WHERE job_id = $3 AND idx = $4
+		`, s.SegmentText, s.NarrationText, jobID, s.Idx); err != nil {
+			return err
+		}
+		for _, id := range s.AssetIDs {
+			assetIDs = append(assetIDs, id.String())
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE assets SET superseded_at = NOW()
+		WHERE job_id = $1 AND segment_id IS NOT NULL AND superseded_at IS NULL AND NOT (id = ANY($2::uuid[]))
+	`, jobID, pq.Array(assetIDs)); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE assets SET superseded_at = NULL WHERE job_id = $1 AND id = ANY($2::uuid[])
+	`, jobID, pq.Array(assetIDs)); err != nil {
+		return err
+	}
+
+	if err := recordOutputVersion(ctx, tx, jobID, models.OutputVersionRestored); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,30 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// UpdateWebhook replaces the webhook configuration of a job that has not finished (queued, running or
+// awaiting review). The dispatcher reads it when the job finishes, so the change applies to the job's
+// webhook. A finished job is left alone (ErrInvalidJobTransition, wrapped): its webhook may already be sent.
+func (r *JobRepository) UpdateWebhook(ctx context.Context, job *models.Job) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE jobs
+		SET webhook_url = $2, webhook_secret = $3, webhook_payload = $4, webhook_encryption_key = $5
+		WHERE id = $1 AND status NOT IN ('succeeded', 'failed', 'canceled')
+	`, job.ID, job.WebhookURL, job.WebhookSecret, job.WebhookPayload, job.WebhookEncryptionKey)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: job %s is finished, its webhook cannot change", ErrInvalidJobTransition, job.ID)
+	}
+	return nil
+}
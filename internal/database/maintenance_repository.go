@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// MaintenanceRepository handles the maintenance mode switch (a single row)
+type MaintenanceRepository struct {
+	db *DB
+}
+
+// NewMaintenanceRepository creates a new MaintenanceRepository
+func NewMaintenanceRepository(db *DB) *MaintenanceRepository {
+	return &MaintenanceRepository{db: db}
+}
+
+// Get returns the maintenance mode state
+func (r *MaintenanceRepository) Get(ctx context.Context) (*models.Maintenance, error) {
+	m := &models.Maintenance{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT enabled, COALESCE(reason, ''), retry_after_seconds, updated_at FROM maintenance
+	`).Scan(&m.Enabled, &m.Reason, &m.RetryAfterSeconds, &m.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Set turns maintenance mode on or off and returns the new state
+func (r *MaintenanceRepository) Set(ctx context.Context, enabled bool, reason string, retryAfterSeconds int) (*models.Maintenance, error) {
+	m := &models.Maintenance{}
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE maintenance SET enabled = $1, reason = NULLIF($2, ''), retry_after_seconds = $3, updated_at = NOW()
+		RETURNING enabled, COALESCE(reason, ''), retry_after_seconds, updated_at
+	`, enabled, reason, retryAfterSeconds).Scan(&m.Enabled, &m.Reason, &m.RetryAfterSeconds, &m.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// ErrNotificationSinkNotFound is returned when a sink does not exist or belongs to another user
+var ErrNotificationSinkNotFound = errors.New("notification sink not found")
+
+// NotificationSinkRepository handles users' notification sinks
+type NotificationSinkRepository struct {
+	db *DB
+}
+
+// NewNotificationSinkRepository creates a new NotificationSinkRepository
+func NewNotificationSinkRepository(db *DB) *NotificationSinkRepository {
+	return &NotificationSinkRepository{db: db}
+}
+
+const notificationSinkColumns = `id, user_id, type, target, secret, events, template, enabled, created_at`
+
+func scanNotificationSink(row interface{ Scan(...any) error }) (*models.NotificationSink, error) {
+	s := &models.NotificationSink{}
+	if err := row.Scan(&s.ID, &s.UserID, &s.Type, &s.Target, &s.Secret, pq.Array(&s.Events), &s.Template, &s.Enabled, &s.CreatedAt); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Create creates a notification sink
+func (r *NotificationSinkRepository) Create(ctx context.Context, sink *models.NotificationSink) error {
+	events := sink.Events
+	if events == nil {
+		events = []string{}
+	}
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO notification_sinks (id, user_id, type, target, secret, events, template, enabled, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, sink.ID, sink.UserID, sink.Type, sink.Target, sink.Secret, pq.Array(events), sink.Template, sink.Enabled, sink.CreatedAt)
+	return err
+}
+
+// GetByID returns a sink by ID, or ErrNotificationSinkNotFound
+func (r *NotificationSinkRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.NotificationSink, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+notificationSinkColumns+` FROM notification_sinks WHERE id = $1`, id)
+	sink, err := scanNotificationSink(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotificationSinkNotFound
+	}
+	return sink, err
+}
+
+// ListByUser returns a user's sinks, oldest first
+func (r *NotificationSinkRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.NotificationSink, error) {
+	return r.list(ctx, `SELECT `+notificationSinkColumns+` FROM notification_sinks WHERE user_id = $1 ORDER BY created_at`, userID)
+}
+
+// ListForEvent returns a user's enabled sinks subscribed to event
+func (r *NotificationSinkRepository) ListForEvent(ctx context.Context, userID uuid.UUID, event string) ([]*models.NotificationSink, error) {
+	return r.list(ctx, `
+		SELECT `+notificationSinkColumns+` FROM notification_sinks
+		WHERE user_id = $1 AND enabled AND (cardinality(events) = 0 OR $2 = ANY(events))
+		ORDER BY created_at
+	`, userID, event)
+}
+
+func (r *NotificationSinkRepository) list(ctx context.Context, query string, args ...any) ([]*models.NotificationSink, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sinks []*models.NotificationSink
+	for rows.Next() {
+		sink, err := scanNotificationSink(rows)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, rows.Err()
+}
+
+// Delete deletes a user's sink (and its deliveries), or returns ErrNotificationSinkNotFound
+func (r *NotificationSinkRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM notification_sinks WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotificationSinkNotFound
+	}
+	return nil
+}
+
+// NotificationDeliveryRepository handles deliveries of job events to notification sinks
+type NotificationDeliveryRepository struct {
+	db *DB
+}
+
+// NewNotificationDeliveryRepository creates a new NotificationDeliveryRepository
+func NewNotificationDeliveryRepository(db *DB) *NotificationDeliveryRepository {
+	return &NotificationDeliveryRepository{db: db}
+}
+
+// Create records a delivery. It returns false without error when the sink (or the job creator, for
+// deliveries without a sink) already has a delivery of the job's event (Kafka redelivery), so each
+// event is delivered at most once per recipient.
+func (r *NotificationDeliveryRepository) Create(ctx context.Context, d *models.NotificationDelivery) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO notification_deliveries (id, sink_id, job_id, event, status, attempts, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT DO NOTHING
+	`, d.ID, d.SinkID, d.JobID, d.Event, d.Status, d.Attempts, d.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// Update updates a delivery's status and attempts
+func (r *NotificationDeliveryRepository) Update(ctx context.Context, d *models.NotificationDelivery) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE notification_deliveries
+		SET status = $1, attempts = $2, last_attempt_at = $3, last_error = $4
+		WHERE id = $5
+	`, d.Status, d.Attempts, d.LastAttemptAt, d.LastError, d.ID)
+	return err
+}
+
+// GetPending returns pending deliveries, oldest first
+func (r *NotificationDeliveryRepository) GetPending(ctx context.Context, limit int) ([]*models.NotificationDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, sink_id, job_id, event, status, attempts, last_attempt_at, last_error, created_at
+		FROM notification_deliveries
+		WHERE status = 'pending'
+		ORDER BY created_at ASC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*models.NotificationDelivery
+	for rows.Next() {
+		d := &models.NotificationDelivery{}
+		if err := rows.Scan(&d.ID, &d.SinkID, &d.JobID, &d.Event, &d.Status, &d.Attempts, &d.LastAttemptAt, &d.LastError, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/snappy-loop/stories/internal/models"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -27,59 +28,207 @@ func NewJobRepository(db *DB) *JobRepository {
 
 // Create creates a new job
 func (r *JobRepository) Create(ctx context.Context, job *models.Job) error {
+	metadataJSON, tagsJSON, err := encodeJobLabels(job)
+	if err != nil {
+		return err
+	}
+	lexiconJSON, err := encodeLexicon(job.Lexicon)
+	if err != nil {
+		return err
+	}
+	externalToolJSON, err := encodeExternalTool(job.ExternalTool)
+	if err != nil {
+		return err
+	}
+	dependsOnJSON, err := encodeDependsOn(job.DependsOn)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO jobs (
 			id, user_id, api_key_id, status, input_type, segments_count, 
-			audio_type, input_text, input_source, extracted_text, webhook_url, webhook_secret, fact_check_needed, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+			audio_type, input_text, input_source, extracted_text, webhook_url, webhook_secret, fact_check_needed, created_at,
+			metadata, tags, webhook_payload, segmentation_strategy, content_hash, duplicate_of, quality_check,
+			require_review, notify_email, lexicon, voice_id, jurisdiction, external_tool, depends_on,
+			webhook_encryption_key, lazy_images, chars_charged, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err = r.db.ExecContext(ctx, query,
 		job.ID, job.UserID, job.APIKeyID, job.Status, job.InputType,
 		job.SegmentsCount, job.AudioType, job.InputText, job.InputSource, job.ExtractedText,
 		job.WebhookURL, job.WebhookSecret, job.FactCheckNeeded, job.CreatedAt,
+		metadataJSON, tagsJSON, job.WebhookPayload, job.SegmentationStrategy, job.ContentHash, job.DuplicateOf,
+		job.QualityCheck, job.RequireReview, job.NotifyEmail, lexiconJSON, job.VoiceID, job.Jurisdiction,
+		externalToolJSON, dependsOnJSON, job.WebhookEncryptionKey, job.LazyImages, job.CharsCharged, job.ExpiresAt,
 	)
 
 	return err
 }
 
+// encodeJobLabels marshals job metadata and tags for the JSONB columns (nil when empty).
+func encodeJobLabels(job *models.Job) (metadataJSON, tagsJSON []byte, err error) {
+	if len(job.Metadata) > 0 {
+		if metadataJSON, err = json.Marshal(job.Metadata); err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+	}
+	if len(job.Tags) > 0 {
+		if tagsJSON, err = json.Marshal(job.Tags); err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal tags: %w", err)
+		}
+	}
+	return metadataJSON, tagsJSON, nil
+}
+
+// decodeJobLabels unmarshals the metadata and tags JSONB columns into job.
+func decodeJobLabels(job *models.Job, metadataJSON, tagsJSON []byte) error {
+	if len(metadataJSON) > 0 {
+		if err := json.Unmarshal(metadataJSON, &job.Metadata); err != nil {
+			return fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+	}
+	if len(tagsJSON) > 0 {
+		if err := json.Unmarshal(tagsJSON, &job.Tags); err != nil {
+			return fmt.Errorf("failed to unmarshal tags: %w", err)
+		}
+	}
+	return nil
+}
+
+// encodeExternalTool marshals a job's external tool for the JSONB column (nil when unset).
+func encodeExternalTool(tool *models.ExternalTool) ([]byte, error) {
+	if tool == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(tool)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal external_tool: %w", err)
+	}
+	return b, nil
+}
+
+// decodeExternalTool unmarshals the external_tool JSONB column (nil when NULL).
+func decodeExternalTool(b []byte) (*models.ExternalTool, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	tool := &models.ExternalTool{}
+	if err := json.Unmarshal(b, tool); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal external_tool: %w", err)
+	}
+	return tool, nil
+}
+
 // GetByID retrieves a job by ID
 func (r *JobRepository) GetByID(ctx context.Context, jobID uuid.UUID) (*models.Job, error) {
 	query := `
 		SELECT id, user_id, api_key_id, status, input_type, segments_count,
 			audio_type, input_text, input_source, extracted_text, output_markup, webhook_url, webhook_secret,
-			fact_check_needed, error_code, error_message, created_at, started_at, finished_at
+			fact_check_needed, error_code, error_message, created_at, started_at, finished_at,
+			metadata, tags, webhook_payload, webhook_encryption_key,
+			progress_step, segments_total, segments_completed, segments_failed, segmentation_strategy,
+			duplicate_of, experiments, quality_check, require_review, review_regeneration, notify_email, lexicon, voice_id,
+			jurisdiction, disclaimer_jurisdiction, disclaimer_version, external_tool, depends_on, lazy_images, preview,
+			expires_at, expired_at
 		FROM jobs WHERE id = $1
 	`
 
 	job := &models.Job{}
+	var metadataJSON, tagsJSON, experimentsJSON, reviewJSON, lexiconJSON, externalToolJSON, dependsOnJSON, previewJSON []byte
+	var progress jobProgressColumns
 	err := r.db.QueryRowContext(ctx, query, jobID).Scan(
 		&job.ID, &job.UserID, &job.APIKeyID, &job.Status, &job.InputType,
 		&job.SegmentsCount, &job.AudioType, &job.InputText, &job.InputSource, &job.ExtractedText,
 		&job.OutputMarkup, &job.WebhookURL, &job.WebhookSecret, &job.FactCheckNeeded,
 		&job.ErrorCode, &job.ErrorMessage, &job.CreatedAt, &job.StartedAt, &job.FinishedAt,
+		&metadataJSON, &tagsJSON, &job.WebhookPayload, &job.WebhookEncryptionKey,
+		&progress.step, &progress.total, &progress.completed, &progress.failed, &job.SegmentationStrategy,
+		&job.DuplicateOf, &experimentsJSON, &job.QualityCheck, &job.RequireReview, &reviewJSON, &job.NotifyEmail, &lexiconJSON, &job.VoiceID,
+		&job.Jurisdiction, &job.DisclaimerJurisdiction, &job.DisclaimerVersion, &externalToolJSON, &dependsOnJSON, &job.LazyImages, &previewJSON,
+		&job.ExpiresAt, &job.ExpiredAt,
 	)
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("job not found")
 	}
+	if err != nil {
+		return nil, err
+	}
 
-	return job, err
+	if err := decodeJobLabels(job, metadataJSON, tagsJSON); err != nil {
+		return nil, err
+	}
+	if err := decodeJobExperiments(job, experimentsJSON); err != nil {
+		return nil, err
+	}
+	if err := decodeJobReview(job, reviewJSON); err != nil {
+		return nil, err
+	}
+	if job.Lexicon, err = decodeLexicon(lexiconJSON); err != nil {
+		return nil, err
+	}
+	if job.ExternalTool, err = decodeExternalTool(externalToolJSON); err != nil {
+		return nil, err
+	}
+	if job.DependsOn, err = decodeDependsOn(dependsOnJSON); err != nil {
+		return nil, err
+	}
+	if job.Preview, err = decodePreview(previewJSON); err != nil {
+		return nil, err
+	}
+	job.SetDurationMs()
+	progress.apply(job)
+	return job, nil
 }
 
-// ListByUser retrieves jobs for a user with pagination
-func (r *JobRepository) ListByUser(ctx context.Context, userID uuid.UUID, limit int, cursor *time.Time) ([]*models.Job, error) {
+// ListByUser retrieves jobs for a user, newest first, ordered by (created_at, id).
+// When afterCreatedAt is non-nil, only jobs strictly after (afterCreatedAt, afterID) in that order are returned,
+// so jobs sharing a timestamp are neither skipped nor repeated across pages.
+// filter narrows the result to jobs carrying all given tags and metadata key/value pairs, with one of the
+// given statuses, types and audio types, created in the given range, and whose input_text matches the
+// full-text query (websearch_to_tsquery with the simple configuration, served by idx_jobs_input_text_search).
+func (r *JobRepository) ListByUser(ctx context.Context, userID uuid.UUID, limit int, afterCreatedAt *time.Time, afterID uuid.UUID, filter models.JobListFilter) ([]*models.Job, error) {
+	var tagsFilter, metadataFilter []byte
+	var err error
+	if len(filter.Tags) > 0 {
+		if tagsFilter, err = json.Marshal(filter.Tags); err != nil {
+			return nil, fmt.Errorf("failed to marshal tags filter: %w", err)
+		}
+	}
+	if len(filter.Metadata) > 0 {
+		if metadataFilter, err = json.Marshal(filter.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to marshal metadata filter: %w", err)
+		}
+	}
+
 	query := `
 		SELECT id, user_id, api_key_id, status, input_type, segments_count,
 			audio_type, input_text, input_source, extracted_text, output_markup, webhook_url, webhook_secret,
-			fact_check_needed, error_code, error_message, created_at, started_at, finished_at
+			fact_check_needed, error_code, error_message, created_at, started_at, finished_at,
+			metadata, tags, webhook_payload, webhook_encryption_key,
+			progress_step, segments_total, segments_completed, segments_failed, segmentation_strategy,
+			duplicate_of, experiments, quality_check, require_review, review_regeneration, notify_email, lexicon, voice_id,
+			jurisdiction, disclaimer_jurisdiction, disclaimer_version, external_tool, depends_on, lazy_images, preview,
+			expires_at, expired_at
 		FROM jobs 
-		WHERE user_id = $1 AND ($2::timestamptz IS NULL OR created_at < $2)
-		ORDER BY created_at DESC
-		LIMIT $3
+		WHERE user_id = $1 AND ($2::timestamptz IS NULL OR (created_at, id) < ($2, $3))
+			AND ($5::jsonb IS NULL OR tags @> $5::jsonb)
+			AND ($6::jsonb IS NULL OR metadata @> $6::jsonb)
+			AND ($7::job_status[] IS NULL OR status = ANY($7::job_status[]))
+			AND ($8::text[] IS NULL OR input_type::text = ANY($8::text[]))
+			AND ($9::text[] IS NULL OR audio_type::text = ANY($9::text[]))
+			AND ($10::timestamptz IS NULL OR created_at >= $10)
+			AND ($11::timestamptz IS NULL OR created_at < $11)
+			AND ($12 = '' OR to_tsvector('simple', input_text) @@ websearch_to_tsquery('simple', $12))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, userID, cursor, limit)
+	rows, err := r.db.QueryContext(ctx, query, userID, afterCreatedAt, afterID, limit, tagsFilter, metadataFilter,
+		nullableTextArray(filter.Statuses), nullableTextArray(filter.Types), nullableTextArray(filter.AudioTypes),
+		filter.CreatedAfter, filter.CreatedBefore, filter.Query)
 	if err != nil {
 		return nil, err
 	}
@@ -88,21 +237,59 @@ func (r *JobRepository) ListByUser(ctx context.Context, userID uuid.UUID, limit
 	var jobs []*models.Job
 	for rows.Next() {
 		job := &models.Job{}
+		var metadataJSON, tagsJSON, experimentsJSON, reviewJSON, lexiconJSON, externalToolJSON, dependsOnJSON, previewJSON []byte
+		var progress jobProgressColumns
 		err := rows.Scan(
 			&job.ID, &job.UserID, &job.APIKeyID, &job.Status, &job.InputType,
 			&job.SegmentsCount, &job.AudioType, &job.InputText, &job.InputSource, &job.ExtractedText,
 			&job.OutputMarkup, &job.WebhookURL, &job.WebhookSecret, &job.FactCheckNeeded,
 			&job.ErrorCode, &job.ErrorMessage, &job.CreatedAt, &job.StartedAt, &job.FinishedAt,
+			&metadataJSON, &tagsJSON, &job.WebhookPayload, &job.WebhookEncryptionKey,
+			&progress.step, &progress.total, &progress.completed, &progress.failed, &job.SegmentationStrategy,
+			&job.DuplicateOf, &experimentsJSON, &job.QualityCheck, &job.RequireReview, &reviewJSON, &job.NotifyEmail, &lexiconJSON, &job.VoiceID,
+			&job.Jurisdiction, &job.DisclaimerJurisdiction, &job.DisclaimerVersion, &externalToolJSON, &dependsOnJSON, &job.LazyImages, &previewJSON,
+			&job.ExpiresAt, &job.ExpiredAt,
 		)
 		if err != nil {
 			return nil, err
 		}
+		if err := decodeJobLabels(job, metadataJSON, tagsJSON); err != nil {
+			return nil, err
+		}
+		if err := decodeJobExperiments(job, experimentsJSON); err != nil {
+			return nil, err
+		}
+		if err := decodeJobReview(job, reviewJSON); err != nil {
+			return nil, err
+		}
+		if job.Lexicon, err = decodeLexicon(lexiconJSON); err != nil {
+			return nil, err
+		}
+		if job.ExternalTool, err = decodeExternalTool(externalToolJSON); err != nil {
+			return nil, err
+		}
+		if job.DependsOn, err = decodeDependsOn(dependsOnJSON); err != nil {
+			return nil, err
+		}
+		if job.Preview, err = decodePreview(previewJSON); err != nil {
+			return nil, err
+		}
+		job.SetDurationMs()
+		progress.apply(job)
 		jobs = append(jobs, job)
 	}
 
 	return jobs, rows.Err()
 }
 
+// nullableTextArray passes values as a text[] parameter, or NULL when empty (the filter is off).
+func nullableTextArray(values []string) any {
+	if len(values) == 0 {
+		return nil
+	}
+	return pq.Array(values)
+}
+
 // SegmentRepository handles segment-related database operations
 type SegmentRepository struct {
 	db *DB
@@ -117,7 +304,9 @@ func NewSegmentRepository(db *DB) *SegmentRepository {
 func (r *SegmentRepository) ListByJob(ctx context.Context, jobID uuid.UUID) ([]*models.Segment, error) {
 	query := `
 		SELECT id, job_id, idx, start_char, end_char, title, segment_text,
-			status, created_at, updated_at
+			status, created_at, updated_at,
+			narration_score, image_prompt_score, quality_notes, quality_regenerations,
+			narration_text, edited_at, source_pages, metadata
 		FROM segments
 		WHERE job_id = $1
 		ORDER BY idx ASC
@@ -132,14 +321,23 @@ func (r *SegmentRepository) ListByJob(ctx context.Context, jobID uuid.UUID) ([]*
 	var segments []*models.Segment
 	for rows.Next() {
 		segment := &models.Segment{}
+		var sourcePages, metadata []byte
 		err := rows.Scan(
 			&segment.ID, &segment.JobID, &segment.Idx, &segment.StartChar,
 			&segment.EndChar, &segment.Title, &segment.SegmentText,
 			&segment.Status, &segment.CreatedAt, &segment.UpdatedAt,
+			&segment.NarrationScore, &segment.ImagePromptScore, &segment.QualityNotes, &segment.QualityRegenerations,
+			&segment.NarrationText, &segment.EditedAt, &sourcePages, &metadata,
 		)
 		if err != nil {
 			return nil, err
 		}
+		if err := scanSourcePages(segment, sourcePages); err != nil {
+			return nil, err
+		}
+		if err := scanSegmentMetadata(segment, metadata); err != nil {
+			return nil, err
+		}
 		segments = append(segments, segment)
 	}
 
@@ -160,7 +358,7 @@ func NewAssetRepository(db *DB) *AssetRepository {
 func (r *AssetRepository) GetByID(ctx context.Context, assetID uuid.UUID) (*models.Asset, error) {
 	query := `
 		SELECT id, job_id, segment_id, kind, mime_type, s3_bucket, s3_key,
-			size_bytes, checksum, meta, created_at
+			size_bytes, checksum, meta, created_at, version, superseded_at
 		FROM assets
 		WHERE id = $1
 	`
@@ -171,7 +369,7 @@ func (r *AssetRepository) GetByID(ctx context.Context, assetID uuid.UUID) (*mode
 	err := r.db.QueryRowContext(ctx, query, assetID).Scan(
 		&asset.ID, &asset.JobID, &asset.SegmentID, &asset.Kind,
 		&asset.MimeType, &asset.S3Bucket, &asset.S3Key, &asset.SizeBytes,
-		&asset.Checksum, &metaJSON, &asset.CreatedAt,
+		&asset.Checksum, &metaJSON, &asset.CreatedAt, &asset.Version, &asset.SupersededAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -195,9 +393,9 @@ func (r *AssetRepository) GetByID(ctx context.Context, assetID uuid.UUID) (*mode
 func (r *AssetRepository) ListByJob(ctx context.Context, jobID uuid.UUID) ([]*models.Asset, error) {
 	query := `
 		SELECT id, job_id, segment_id, kind, mime_type, s3_bucket, s3_key,
-			size_bytes, checksum, meta, created_at
+			size_bytes, checksum, meta, created_at, version, superseded_at
 		FROM assets
-		WHERE job_id = $1
+		WHERE job_id = $1 AND superseded_at IS NULL
 		ORDER BY created_at ASC
 	`
 
@@ -215,7 +413,7 @@ func (r *AssetRepository) ListByJob(ctx context.Context, jobID uuid.UUID) ([]*mo
 		err := rows.Scan(
 			&asset.ID, &asset.JobID, &asset.SegmentID, &asset.Kind,
 			&asset.MimeType, &asset.S3Bucket, &asset.S3Key, &asset.SizeBytes,
-			&asset.Checksum, &metaJSON, &asset.CreatedAt,
+			&asset.Checksum, &metaJSON, &asset.CreatedAt, &asset.Version, &asset.SupersededAt,
 		)
 		if err != nil {
 			return nil, err
@@ -254,15 +452,15 @@ func KeyLookupHash(apiKey string) string {
 func (r *APIKeyRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
 	query := `
 		SELECT id, user_id, key_hash, status, quota_period, quota_chars,
-			used_chars_in_period, period_started_at, created_at
+			used_chars_in_period, cost_cap_usd, used_cost_in_period, period_started_at, created_at, scopes
 		FROM api_keys
 		WHERE id = $1
 	`
 	key := &models.APIKey{}
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&key.ID, &key.UserID, &key.KeyHash, &key.Status, &key.QuotaPeriod,
-		&key.QuotaChars, &key.UsedCharsInPeriod, &key.PeriodStartedAt,
-		&key.CreatedAt,
+		&key.QuotaChars, &key.UsedCharsInPeriod, &key.CostCapUSD, &key.UsedCostInPeriod, &key.PeriodStartedAt,
+		&key.CreatedAt, pq.Array(&key.Scopes),
 	)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("api key not found")
@@ -274,7 +472,7 @@ func (r *APIKeyRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.A
 func (r *APIKeyRepository) GetByKeyHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
 	query := `
 		SELECT id, user_id, key_hash, status, quota_period, quota_chars,
-			used_chars_in_period, period_started_at, created_at
+			used_chars_in_period, cost_cap_usd, used_cost_in_period, period_started_at, created_at, scopes
 		FROM api_keys
 		WHERE key_hash = $1
 	`
@@ -282,8 +480,8 @@ func (r *APIKeyRepository) GetByKeyHash(ctx context.Context, keyHash string) (*m
 	key := &models.APIKey{}
 	err := r.db.QueryRowContext(ctx, query, keyHash).Scan(
 		&key.ID, &key.UserID, &key.KeyHash, &key.Status, &key.QuotaPeriod,
-		&key.QuotaChars, &key.UsedCharsInPeriod, &key.PeriodStartedAt,
-		&key.CreatedAt,
+		&key.QuotaChars, &key.UsedCharsInPeriod, &key.CostCapUSD, &key.UsedCostInPeriod, &key.PeriodStartedAt,
+		&key.CreatedAt, pq.Array(&key.Scopes),
 	)
 
 	if err == sql.ErrNoRows {
@@ -297,7 +495,7 @@ func (r *APIKeyRepository) GetByKeyHash(ctx context.Context, keyHash string) (*m
 func (r *APIKeyRepository) GetByKeyLookup(ctx context.Context, lookup string) (*models.APIKey, error) {
 	query := `
 		SELECT id, user_id, key_hash, status, quota_period, quota_chars,
-			used_chars_in_period, period_started_at, created_at
+			used_chars_in_period, cost_cap_usd, used_cost_in_period, period_started_at, created_at, scopes
 		FROM api_keys
 		WHERE key_lookup = $1
 	`
@@ -305,8 +503,8 @@ func (r *APIKeyRepository) GetByKeyLookup(ctx context.Context, lookup string) (*
 	key := &models.APIKey{}
 	err := r.db.QueryRowContext(ctx, query, lookup).Scan(
 		&key.ID, &key.UserID, &key.KeyHash, &key.Status, &key.QuotaPeriod,
-		&key.QuotaChars, &key.UsedCharsInPeriod, &key.PeriodStartedAt,
-		&key.CreatedAt,
+		&key.QuotaChars, &key.UsedCharsInPeriod, &key.CostCapUSD, &key.UsedCostInPeriod, &key.PeriodStartedAt,
+		&key.CreatedAt, pq.Array(&key.Scopes),
 	)
 
 	if err == sql.ErrNoRows {
@@ -358,11 +556,13 @@ func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, userID uuid.UUID, q
 	return plainKey, key, nil
 }
 
-// UpdateUsage updates the usage for an API key
+// UpdateUsage updates the usage for an API key. A new periodStartedAt starts a new period: the usage
+// (characters and cost) of the previous one is dropped.
 func (r *APIKeyRepository) UpdateUsage(ctx context.Context, keyID uuid.UUID, chars int64, periodStartedAt time.Time) error {
 	query := `
 		UPDATE api_keys
-		SET used_chars_in_period = used_chars_in_period + $1,
+		SET used_chars_in_period = CASE WHEN period_started_at = $2 THEN used_chars_in_period ELSE 0 END + $1,
+			used_cost_in_period = CASE WHEN period_started_at = $2 THEN used_cost_in_period ELSE 0 END,
 			period_started_at = $2
 		WHERE id = $3
 	`
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// ErrSegmentBusy is returned by ApplyEdit when the segment is still being generated or regenerated.
+var ErrSegmentBusy = errors.New("segment is being generated")
+
+// GetByJobIdx retrieves the segment with index idx of a job
+func (r *SegmentRepository) GetByJobIdx(ctx context.Context, jobID uuid.UUID, idx int) (*models.Segment, error) {
+	query := `
+		SELECT id, job_id, idx, start_char, end_char, title, segment_text,
+			status, created_at, updated_at,
+			narration_score, image_prompt_score, quality_notes, quality_regenerations,
+			narration_text, edited_at, source_pages, metadata
+		FROM segments
+		WHERE job_id = $1 AND idx = $2
+	`
+
+	segment := &models.Segment{}
+	var sourcePages, metadata []byte
+	err := r.db.QueryRowContext(ctx, query, jobID, idx).Scan(
+		&segment.ID, &segment.JobID, &segment.Idx, &segment.StartChar,
+		&segment.EndChar, &segment.Title, &segment.SegmentText,
+		&segment.Status, &segment.CreatedAt, &segment.UpdatedAt,
+		&segment.NarrationScore, &segment.ImagePromptScore, &segment.QualityNotes, &segment.QualityRegenerations,
+		&segment.NarrationText, &segment.EditedAt, &sourcePages, &metadata,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("segment not found: job_id=%s, idx=%d", jobID, idx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := scanSourcePages(segment, sourcePages); err != nil {
+		return nil, err
+	}
+	if err := scanSegmentMetadata(segment, metadata); err != nil {
+		return nil, err
+	}
+	return segment, nil
+}
+
+// ApplyEdit stores a user's edit of a finished segment and queues it for regeneration. A new segment_text
+// without narration_text clears the stored narration so the worker writes a new script from the text.
+// Returns ErrSegmentBusy when the segment is queued or running.
+func (r *SegmentRepository) ApplyEdit(ctx context.Context, segmentID uuid.UUID, req *models.UpdateSegmentRequest) error {
+	query := `
+		UPDATE segments
+		SET segment_text = COALESCE($1, segment_text),
+			narration_text = CASE WHEN $2::text IS NOT NULL THEN $2 WHEN $1::text IS NOT NULL THEN NULL ELSE narration_text END,
+			status = 'queued', edited_at = NOW(), updated_at = NOW()
+		WHERE id = $3 AND status IN ('succeeded', 'failed')
+	`
+
+	result, err := r.db.ExecContext(ctx, query, req.SegmentText, req.NarrationText, segmentID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrSegmentBusy
+	}
+	return nil
+}
+
+// UpdateNarration stores the script a segment's audio was generated from
+func (r *SegmentRepository) UpdateNarration(ctx context.Context, segmentID uuid.UUID, script string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE segments SET narration_text = $1, updated_at = NOW() WHERE id = $2`, script, segmentID)
+	return err
+}
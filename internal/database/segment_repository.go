@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/google/uuid"
@@ -10,22 +11,70 @@ import (
 
 // Create creates a new segment
 func (r *SegmentRepository) Create(ctx context.Context, segment *models.Segment) error {
+	var sourcePagesJSON, metadataJSON []byte
+	var err error
+	if len(segment.SourcePages) > 0 {
+		if sourcePagesJSON, err = json.Marshal(segment.SourcePages); err != nil {
+			return fmt.Errorf("failed to marshal source_pages: %w", err)
+		}
+	}
+	if len(segment.Metadata) > 0 {
+		if metadataJSON, err = json.Marshal(segment.Metadata); err != nil {
+			return fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+	}
+
 	query := `
 		INSERT INTO segments (
 			id, job_id, idx, start_char, end_char, title, segment_text,
-			status, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			status, created_at, updated_at, source_pages, metadata
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err = r.db.ExecContext(ctx, query,
 		segment.ID, segment.JobID, segment.Idx, segment.StartChar,
 		segment.EndChar, segment.Title, segment.SegmentText,
-		segment.Status, segment.CreatedAt, segment.UpdatedAt,
+		segment.Status, segment.CreatedAt, segment.UpdatedAt, sourcePagesJSON, metadataJSON,
 	)
 
 	return err
 }
 
+// scanSourcePages decodes a scanned segments.source_pages column into segment.SourcePages
+func scanSourcePages(segment *models.Segment, sourcePagesJSON []byte) error {
+	if len(sourcePagesJSON) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(sourcePagesJSON, &segment.SourcePages); err != nil {
+		return fmt.Errorf("failed to unmarshal source_pages: %w", err)
+	}
+	return nil
+}
+
+// scanSegmentMetadata decodes a scanned segments.metadata column into segment.Metadata
+func scanSegmentMetadata(segment *models.Segment, metadataJSON []byte) error {
+	if len(metadataJSON) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(metadataJSON, &segment.Metadata); err != nil {
+		return fmt.Errorf("failed to unmarshal segment metadata: %w", err)
+	}
+	return nil
+}
+
+// MergeMetadata sets the given keys of a segment's metadata, keeping its other keys
+func (r *SegmentRepository) MergeMetadata(ctx context.Context, segmentID uuid.UUID, metadata map[string]any) error {
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE segments SET metadata = COALESCE(metadata, '{}'::jsonb) || $1::jsonb, updated_at = NOW()
+		WHERE id = $2
+	`, metadataJSON, segmentID)
+	return err
+}
+
 // UpdateStatus updates a segment's status
 func (r *SegmentRepository) UpdateStatus(ctx context.Context, jobID uuid.UUID, idx int, status string) error {
 	query := `
@@ -51,6 +100,28 @@ func (r *SegmentRepository) UpdateStatus(ctx context.Context, jobID uuid.UUID, i
 	return nil
 }
 
+// CancelUnfinished marks the queued and running segments of a canceled job canceled, and the assets they
+// already have (e.g. an image generated before the audio) partial (meta.partial = true). It returns how
+// many segments it marked; finished segments keep their status and assets.
+func (r *SegmentRepository) CancelUnfinished(ctx context.Context, jobID uuid.UUID) (int64, error) {
+	query := `
+		WITH canceled AS (
+			UPDATE segments
+			SET status = 'canceled', updated_at = NOW()
+			WHERE job_id = $1 AND status IN ('queued', 'running')
+			RETURNING id
+		), partial AS (
+			UPDATE assets
+			SET meta = COALESCE(meta, '{}'::jsonb) || '{"partial": true}'::jsonb
+			WHERE segment_id IN (SELECT id FROM canceled)
+		)
+		SELECT COUNT(*) FROM canceled
+	`
+	var n int64
+	err := r.db.QueryRowContext(ctx, query, jobID).Scan(&n)
+	return n, err
+}
+
 // DeleteByJobID deletes all segments for a job. Assets are cascade-deleted by the DB.
 // Used for idempotent restart when a job was left in "running" after a worker crash.
 func (r *SegmentRepository) DeleteByJobID(ctx context.Context, jobID uuid.UUID) error {
@@ -58,3 +129,97 @@ func (r *SegmentRepository) DeleteByJobID(ctx context.Context, jobID uuid.UUID)
 	_, err := r.db.ExecContext(ctx, query, jobID)
 	return err
 }
+
+// ResetForRegeneration supersedes a segment's assets, removes its fact-checks and quality scores and queues
+// it, so the worker can generate it again (reviewer regeneration requests). Old asset versions are kept.
+func (r *SegmentRepository) ResetForRegeneration(ctx context.Context, segmentID uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		`UPDATE assets SET superseded_at = NOW() WHERE segment_id = $1 AND superseded_at IS NULL`,
+		`DELETE FROM segment_fact_checks WHERE segment_id = $1`,
+		`UPDATE segments
+		SET status = 'queued', narration_score = NULL, image_prompt_score = NULL, quality_notes = NULL,
+			quality_regenerations = 0, updated_at = NOW()
+		WHERE id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, segmentID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListByJobPage retrieves up to limit segments for a job with idx greater than afterIdx, ordered by idx.
+// Pass afterIdx = -1 for the first page.
+func (r *SegmentRepository) ListByJobPage(ctx context.Context, jobID uuid.UUID, afterIdx, limit int) ([]*models.Segment, error) {
+	query := `
+		SELECT id, job_id, idx, start_char, end_char, title, segment_text,
+			status, created_at, updated_at,
+			narration_score, image_prompt_score, quality_notes, quality_regenerations,
+			narration_text, edited_at, source_pages, metadata
+		FROM segments
+		WHERE job_id = $1 AND idx > $2
+		ORDER BY idx ASC
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, jobID, afterIdx, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var segments []*models.Segment
+	for rows.Next() {
+		segment := &models.Segment{}
+		var sourcePages, metadata []byte
+		err := rows.Scan(
+			&segment.ID, &segment.JobID, &segment.Idx, &segment.StartChar,
+			&segment.EndChar, &segment.Title, &segment.SegmentText,
+			&segment.Status, &segment.CreatedAt, &segment.UpdatedAt,
+			&segment.NarrationScore, &segment.ImagePromptScore, &segment.QualityNotes, &segment.QualityRegenerations,
+			&segment.NarrationText, &segment.EditedAt, &sourcePages, &metadata,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := scanSourcePages(segment, sourcePages); err != nil {
+			return nil, err
+		}
+		if err := scanSegmentMetadata(segment, metadata); err != nil {
+			return nil, err
+		}
+		segments = append(segments, segment)
+	}
+
+	return segments, rows.Err()
+}
+
+// UpdateQuality stores the quality evaluator's scores for a segment
+func (r *SegmentRepository) UpdateQuality(ctx context.Context, segmentID uuid.UUID, q *models.SegmentQuality) error {
+	query := `
+		UPDATE segments
+		SET narration_score = $1, image_prompt_score = $2, quality_notes = $3,
+			quality_regenerations = $4, updated_at = NOW()
+		WHERE id = $5
+	`
+
+	var notes *string
+	if q.Notes != "" {
+		notes = &q.Notes
+	}
+	_, err := r.db.ExecContext(ctx, query, q.NarrationScore, q.ImagePromptScore, notes, q.Regenerations, segmentID)
+	return err
+}
+
+// CountByJob returns the number of segments for a job
+func (r *SegmentRepository) CountByJob(ctx context.Context, jobID uuid.UUID) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM segments WHERE job_id = $1`, jobID).Scan(&n)
+	return n, err
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// StorageDeletionRepository handles the S3 objects queued for deletion (see JobRepository.Delete)
+type StorageDeletionRepository struct {
+	db *DB
+}
+
+// NewStorageDeletionRepository creates a new StorageDeletionRepository
+func NewStorageDeletionRepository(db *DB) *StorageDeletionRepository {
+	return &StorageDeletionRepository{db: db}
+}
+
+// ListDue returns up to limit objects whose next delete attempt is due, oldest first.
+func (r *StorageDeletionRepository) ListDue(ctx context.Context, limit int) ([]*models.StorageDeletion, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, s3_bucket, s3_key, job_id, attempts, last_error, created_at
+		FROM storage_deletions
+		WHERE next_attempt_at <= NOW()
+		ORDER BY next_attempt_at, id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deletions []*models.StorageDeletion
+	for rows.Next() {
+		d := &models.StorageDeletion{}
+		if err := rows.Scan(&d.ID, &d.S3Bucket, &d.S3Key, &d.JobID, &d.Attempts, &d.LastError, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		deletions = append(deletions, d)
+	}
+	return deletions, rows.Err()
+}
+
+// Done removes an object that was deleted from the queue.
+func (r *StorageDeletionRepository) Done(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM storage_deletions WHERE id = $1`, id)
+	return err
+}
+
+// Failed records a failed delete attempt and when to try again.
+func (r *StorageDeletionRepository) Failed(ctx context.Context, id int64, lastError string, next time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE storage_deletions
+		SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2
+		WHERE id = $3
+	`, lastError, next, id)
+	return err
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// UsageLedgerRepository records billable usage and sums it per user for billing exports
+type UsageLedgerRepository struct {
+	db *DB
+}
+
+// NewUsageLedgerRepository creates a new UsageLedgerRepository
+func NewUsageLedgerRepository(db *DB) *UsageLedgerRepository {
+	return &UsageLedgerRepository{db: db}
+}
+
+// Record adds an entry to the ledger.
+func (r *UsageLedgerRepository) Record(ctx context.Context, e *models.UsageEntry) error {
+	query := `
+		INSERT INTO usage_ledger (user_id, api_key_id, job_id, kind, chars, input_tokens, output_tokens, cost_usd)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.db.ExecContext(ctx, query, e.UserID, e.APIKeyID, e.JobID, e.Kind, e.Chars, e.InputTokens, e.OutputTokens, e.CostUSD)
+	return err
+}
+
+// RecordRun adds the entry of a job run that started at since, counting the audio seconds (meta.duration)
+// and images of the segment assets the job created since then. Lazy images not generated yet (meta.pending)
+// are not counted, nor the podcast audio (it joins the segments' audio). Runs that used nothing (a job
+// deferred for its dependencies, a duplicate) are not recorded.
+func (r *UsageLedgerRepository) RecordRun(ctx context.Context, e *models.UsageEntry, since time.Time) error {
+	query := `
+		INSERT INTO usage_ledger (user_id, api_key_id, job_id, kind, input_tokens, output_tokens, cost_usd, audio_seconds, images)
+		SELECT $1, $2, $3, $4, $5::bigint, $6::bigint, $7, audio_seconds, images
+		FROM (
+			SELECT COALESCE(SUM((meta->>'duration')::numeric) FILTER (WHERE kind = 'audio'), 0) AS audio_seconds,
+				COUNT(*) FILTER (WHERE kind = 'image' AND COALESCE((meta->>'pending')::boolean, false) = false) AS images
+			FROM assets
+			WHERE job_id = $3 AND segment_id IS NOT NULL AND created_at >= $8
+		) a
+		WHERE $5::bigint > 0 OR $6::bigint > 0 OR audio_seconds > 0 OR images > 0
+	`
+	_, err := r.db.ExecContext(ctx, query, e.UserID, e.APIKeyID, e.JobID, e.Kind, e.InputTokens, e.OutputTokens, e.CostUSD, since)
+	return err
+}
+
+// RecordCompleted adds a job_completed entry for a job that succeeded; duplicates are not recorded.
+func (r *UsageLedgerRepository) RecordCompleted(ctx context.Context, jobID uuid.UUID) error {
+	query := `
+		INSERT INTO usage_ledger (user_id, api_key_id, job_id, kind)
+		SELECT user_id, api_key_id, id, $2
+		FROM jobs
+		WHERE id = $1 AND duplicate_of IS NULL
+	`
+	_, err := r.db.ExecContext(ctx, query, jobID, models.UsageKindJobCompleted)
+	return err
+}
+
+// TotalsByUser sums the ledger entries created in [from, to) per user, by user ID. Jobs counts the jobs
+// created in the range.
+func (r *UsageLedgerRepository) TotalsByUser(ctx context.Context, from, to time.Time) ([]models.UserUsageTotal, error) {
+	query := `
+		SELECT l.user_id, COALESCE(u.email, ''),
+			COUNT(*) FILTER (WHERE l.kind = 'job_created'),
+			SUM(l.chars), SUM(l.input_tokens), SUM(l.output_tokens),
+			SUM(l.audio_seconds), SUM(l.images), SUM(l.cost_usd)
+		FROM usage_ledger l
+		LEFT JOIN users u ON u.id = l.user_id
+		WHERE l.created_at >= $1 AND l.created_at < $2
+		GROUP BY l.user_id, u.email
+		ORDER BY l.user_id
+	`
+	rows, err := r.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []models.UserUsageTotal{}
+	for rows.Next() {
+		var t models.UserUsageTotal
+		if err := rows.Scan(&t.UserID, &t.Email, &t.Jobs, &t.Chars, &t.InputTokens, &t.OutputTokens, &t.AudioSeconds, &t.Images, &t.CostUSD); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+	return totals, rows.Err()
+}
+
+// ListUnreported returns up to limit entries not reported to Stripe yet, oldest first, with the Stripe
+// customer of their user.
+func (r *UsageLedgerRepository) ListUnreported(ctx context.Context, limit int) ([]*models.UnreportedUsage, error) {
+	query := `
+		SELECT l.id, l.kind, l.chars, l.audio_seconds, u.stripe_customer_id, l.created_at
+		FROM usage_ledger l
+		LEFT JOIN users u ON u.id = l.user_id
+		WHERE l.stripe_reported_at IS NULL
+		ORDER BY l.id
+		LIMIT $1
+	`
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.UnreportedUsage
+	for rows.Next() {
+		e := &models.UnreportedUsage{}
+		if err := rows.Scan(&e.ID, &e.Kind, &e.Chars, &e.AudioSeconds, &e.StripeCustomerID, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// MarkReported marks an entry as handled by the Stripe reporter. A non-empty reason records why it was not
+// reported (no Stripe customer, rejected by Stripe).
+func (r *UsageLedgerRepository) MarkReported(ctx context.Context, id int64, reason string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE usage_ledger SET stripe_reported_at = NOW(), stripe_error = NULLIF($2, '') WHERE id = $1`, id, reason)
+	return err
+}
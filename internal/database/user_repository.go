@@ -2,7 +2,12 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
 
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/snappy-loop/stories/internal/models"
 )
 
@@ -25,3 +30,148 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 	_, err := r.db.ExecContext(ctx, query, user.ID, user.Email, user.CreatedAt)
 	return err
 }
+
+// GetByID returns a user, or an error when the user does not exist
+func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	user := &models.User{}
+	var lexiconJSON, settingsJSON []byte
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, email, email_notifications, lexicon, settings, egress_allowlist, created_at FROM users WHERE id = $1
+	`, id).Scan(&user.ID, &user.Email, &user.EmailNotifications, &lexiconJSON, &settingsJSON,
+		pq.Array(&user.EgressAllowlist), &user.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Lexicon, err = decodeLexicon(lexiconJSON); err != nil {
+		return nil, err
+	}
+	if user.Settings, err = decodeUserSettings(settingsJSON); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetSettings returns the user's defaults for new jobs (nil when none are set)
+func (r *UserRepository) GetSettings(ctx context.Context, id uuid.UUID) (*models.UserSettings, error) {
+	var settingsJSON []byte
+	err := r.db.QueryRowContext(ctx, `SELECT settings FROM users WHERE id = $1`, id).Scan(&settingsJSON)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeUserSettings(settingsJSON)
+}
+
+// SetSettings replaces the user's defaults for new jobs (nil clears them)
+func (r *UserRepository) SetSettings(ctx context.Context, id uuid.UUID, settings *models.UserSettings) error {
+	settingsJSON, err := encodeUserSettings(settings)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `UPDATE users SET settings = $1 WHERE id = $2`, settingsJSON, id)
+	return err
+}
+
+// SetEmailNotifications turns email notifications of the user's jobs on or off
+func (r *UserRepository) SetEmailNotifications(ctx context.Context, id uuid.UUID, enabled bool) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET email_notifications = $1 WHERE id = $2`, enabled, id)
+	return err
+}
+
+// SetLexicon replaces the user's pronunciation lexicon (nil or empty clears it)
+func (r *UserRepository) SetLexicon(ctx context.Context, id uuid.UUID, entries []models.LexiconEntry) error {
+	lexiconJSON, err := encodeLexicon(entries)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `UPDATE users SET lexicon = $1 WHERE id = $2`, lexiconJSON, id)
+	return err
+}
+
+// GetEgressAllowlist returns the hosts the user's webhooks and sinks may target (nil when unrestricted)
+func (r *UserRepository) GetEgressAllowlist(ctx context.Context, id uuid.UUID) ([]string, error) {
+	var hosts []string
+	err := r.db.QueryRowContext(ctx, `SELECT egress_allowlist FROM users WHERE id = $1`, id).Scan(pq.Array(&hosts))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return hosts, nil
+}
+
+// SetEgressAllowlist replaces the user's egress allow list (nil or empty lifts the restriction)
+func (r *UserRepository) SetEgressAllowlist(ctx context.Context, id uuid.UUID, hosts []string) error {
+	var value any
+	if len(hosts) > 0 {
+		value = pq.Array(hosts)
+	}
+	_, err := r.db.ExecContext(ctx, `UPDATE users SET egress_allowlist = $1 WHERE id = $2`, value, id)
+	return err
+}
+
+// SetStripeCustomer links the user to a Stripe customer for metered billing (empty unlinks)
+func (r *UserRepository) SetStripeCustomer(ctx context.Context, id uuid.UUID, customerID string) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET stripe_customer_id = NULLIF($1, '') WHERE id = $2`, customerID, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
+// encodeLexicon marshals a pronunciation lexicon for a JSONB column (nil when empty).
+func encodeLexicon(entries []models.LexiconEntry) ([]byte, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal lexicon: %w", err)
+	}
+	return b, nil
+}
+
+// decodeLexicon unmarshals a lexicon JSONB column (nil when NULL).
+func decodeLexicon(b []byte) ([]models.LexiconEntry, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var entries []models.LexiconEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal lexicon: %w", err)
+	}
+	return entries, nil
+}
+
+// encodeUserSettings marshals user settings for a JSONB column (nil when unset).
+func encodeUserSettings(settings *models.UserSettings) ([]byte, error) {
+	if settings == nil || *settings == (models.UserSettings{}) {
+		return nil, nil
+	}
+	b, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal settings: %w", err)
+	}
+	return b, nil
+}
+
+// decodeUserSettings unmarshals a settings JSONB column (nil when NULL).
+func decodeUserSettings(b []byte) (*models.UserSettings, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var settings models.UserSettings
+	if err := json.Unmarshal(b, &settings); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal settings: %w", err)
+	}
+	return &settings, nil
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// ErrVoiceNotFound is returned when a voice does not exist or belongs to another user
+var ErrVoiceNotFound = errors.New("voice not found")
+
+// VoiceRepository handles users' custom voices
+type VoiceRepository struct {
+	db *DB
+}
+
+// NewVoiceRepository creates a new VoiceRepository
+func NewVoiceRepository(db *DB) *VoiceRepository {
+	return &VoiceRepository{db: db}
+}
+
+const voiceColumns = `id, user_id, name, provider, provider_voice_id, style, reference_url, meta, created_at`
+
+func scanVoice(row interface{ Scan(...any) error }) (*models.Voice, error) {
+	v := &models.Voice{}
+	var metaJSON []byte
+	if err := row.Scan(&v.ID, &v.UserID, &v.Name, &v.Provider, &v.ProviderVoiceID, &v.Style, &v.ReferenceURL, &metaJSON, &v.CreatedAt); err != nil {
+		return nil, err
+	}
+	if len(metaJSON) > 0 {
+		if err := json.Unmarshal(metaJSON, &v.Meta); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal voice meta: %w", err)
+		}
+	}
+	return v, nil
+}
+
+// Create creates a voice
+func (r *VoiceRepository) Create(ctx context.Context, v *models.Voice) error {
+	var metaJSON []byte
+	if len(v.Meta) > 0 {
+		var err error
+		if metaJSON, err = json.Marshal(v.Meta); err != nil {
+			return fmt.Errorf("failed to marshal voice meta: %w", err)
+		}
+	}
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO voices (id, user_id, name, provider, provider_voice_id, style, reference_url, meta, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, v.ID, v.UserID, v.Name, v.Provider, v.ProviderVoiceID, v.Style, v.ReferenceURL, metaJSON, v.CreatedAt)
+	return err
+}
+
+// GetByID returns a voice by ID, or ErrVoiceNotFound
+func (r *VoiceRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Voice, error) {
+	v, err := scanVoice(r.db.QueryRowContext(ctx, `SELECT `+voiceColumns+` FROM voices WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrVoiceNotFound
+	}
+	return v, err
+}
+
+// GetByIDAndUser returns one of the user's voices, or ErrVoiceNotFound
+func (r *VoiceRepository) GetByIDAndUser(ctx context.Context, id, userID uuid.UUID) (*models.Voice, error) {
+	v, err := scanVoice(r.db.QueryRowContext(ctx, `SELECT `+voiceColumns+` FROM voices WHERE id = $1 AND user_id = $2`, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrVoiceNotFound
+	}
+	return v, err
+}
+
+// ListByUser returns a user's voices, oldest first
+func (r *VoiceRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.Voice, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+voiceColumns+` FROM voices WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var voices []*models.Voice
+	for rows.Next() {
+		v, err := scanVoice(rows)
+		if err != nil {
+			return nil, err
+		}
+		voices = append(voices, v)
+	}
+	return voices, rows.Err()
+}
+
+// Delete deletes one of the user's voices; jobs using it fall back to the default voice. Returns
+// ErrVoiceNotFound when there is none.
+func (r *VoiceRepository) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM voices WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrVoiceNotFound
+	}
+	return nil
+}
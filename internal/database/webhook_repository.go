@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
@@ -64,10 +65,18 @@ func (r *WebhookDeliveryRepository) Update(ctx context.Context, delivery *models
 	return nil
 }
 
+// deleteJobDelivery removes a job's webhook delivery and, by cascade, its attempts. Jobs that run again
+// (retry, reprocess) call it in the reset transaction: deliveries are unique per job and DeliverWebhook
+// skips jobs that already have one, so the next terminal status would otherwise never be delivered.
+func deleteJobDelivery(ctx context.Context, db execer, jobID uuid.UUID) error {
+	_, err := db.ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE job_id = $1`, jobID)
+	return err
+}
+
 // GetByJobID retrieves webhook deliveries for a job
 func (r *WebhookDeliveryRepository) GetByJobID(ctx context.Context, jobID uuid.UUID) ([]*models.WebhookDelivery, error) {
 	query := `
-		SELECT id, job_id, url, status, attempts, last_attempt_at, last_error, created_at
+		SELECT id, job_id, url, status, attempts, last_attempt_at, last_error, redeliveries, created_at
 		FROM webhook_deliveries
 		WHERE job_id = $1
 		ORDER BY created_at DESC
@@ -85,7 +94,7 @@ func (r *WebhookDeliveryRepository) GetByJobID(ctx context.Context, jobID uuid.U
 		err := rows.Scan(
 			&delivery.ID, &delivery.JobID, &delivery.URL, &delivery.Status,
 			&delivery.Attempts, &delivery.LastAttemptAt, &delivery.LastError,
-			&delivery.CreatedAt,
+			&delivery.Redeliveries, &delivery.CreatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -96,17 +105,25 @@ func (r *WebhookDeliveryRepository) GetByJobID(ctx context.Context, jobID uuid.U
 	return deliveries, rows.Err()
 }
 
-// GetPendingDeliveries retrieves pending webhook deliveries
-func (r *WebhookDeliveryRepository) GetPendingDeliveries(ctx context.Context, limit int) ([]*models.WebhookDelivery, error) {
+// GetPendingDeliveries retrieves up to limit pending webhook deliveries, least recently attempted first,
+// with at most perHost of them per destination host so one host's backlog cannot fill the batch.
+func (r *WebhookDeliveryRepository) GetPendingDeliveries(ctx context.Context, limit, perHost int) ([]*models.WebhookDelivery, error) {
 	query := `
-		SELECT id, job_id, url, status, attempts, last_attempt_at, last_error, created_at
-		FROM webhook_deliveries
-		WHERE status = 'pending'
-		ORDER BY created_at ASC
+		SELECT id, job_id, url, status, attempts, last_attempt_at, last_error, redeliveries, created_at
+		FROM (
+			SELECT *, ROW_NUMBER() OVER (
+				PARTITION BY lower(substring(url from '^[^:]+://([^/?#]+)'))
+				ORDER BY COALESCE(last_attempt_at, created_at), created_at
+			) AS host_rank
+			FROM webhook_deliveries
+			WHERE status = 'pending'
+		) d
+		WHERE host_rank <= $2
+		ORDER BY COALESCE(last_attempt_at, created_at), created_at
 		LIMIT $1
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, limit)
+	rows, err := r.db.QueryContext(ctx, query, limit, perHost)
 	if err != nil {
 		return nil, err
 	}
@@ -118,7 +135,7 @@ func (r *WebhookDeliveryRepository) GetPendingDeliveries(ctx context.Context, li
 		err := rows.Scan(
 			&delivery.ID, &delivery.JobID, &delivery.URL, &delivery.Status,
 			&delivery.Attempts, &delivery.LastAttemptAt, &delivery.LastError,
-			&delivery.CreatedAt,
+			&delivery.Redeliveries, &delivery.CreatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -132,7 +149,7 @@ func (r *WebhookDeliveryRepository) GetPendingDeliveries(ctx context.Context, li
 // GetByID retrieves a webhook delivery by ID
 func (r *WebhookDeliveryRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error) {
 	query := `
-		SELECT id, job_id, url, status, attempts, last_attempt_at, last_error, created_at
+		SELECT id, job_id, url, status, attempts, last_attempt_at, last_error, redeliveries, created_at
 		FROM webhook_deliveries
 		WHERE id = $1
 	`
@@ -141,12 +158,106 @@ func (r *WebhookDeliveryRepository) GetByID(ctx context.Context, id uuid.UUID) (
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&delivery.ID, &delivery.JobID, &delivery.URL, &delivery.Status,
 		&delivery.Attempts, &delivery.LastAttemptAt, &delivery.LastError,
-		&delivery.CreatedAt,
+		&delivery.Redeliveries, &delivery.CreatedAt,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("webhook delivery not found")
+		return nil, ErrWebhookDeliveryNotFound
 	}
 
 	return delivery, err
 }
+
+// ErrWebhookDeliveryNotFound is returned by GetByID and Redeliver for unknown deliveries.
+var ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+
+// ErrWebhookDeliveryPending is returned by Redeliver while the delivery is still being attempted.
+var ErrWebhookDeliveryPending = errors.New("webhook delivery is still pending")
+
+// CreateAttempt records one POST of a delivery.
+func (r *WebhookDeliveryRepository) CreateAttempt(ctx context.Context, a *models.WebhookDeliveryAttempt) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO webhook_delivery_attempts (id, delivery_id, redelivery, attempt, payload, status_code, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at
+	`, a.ID, a.DeliveryID, a.Redelivery, a.Attempt, string(a.Payload), a.StatusCode, a.Error).Scan(&a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create webhook delivery attempt: %w", err)
+	}
+	return nil
+}
+
+// ListAttempts returns the attempts of a delivery, oldest first.
+func (r *WebhookDeliveryRepository) ListAttempts(ctx context.Context, deliveryID uuid.UUID) ([]*models.WebhookDeliveryAttempt, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, delivery_id, redelivery, attempt, payload, status_code, error, created_at
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = $1
+		ORDER BY created_at, redelivery, attempt
+	`, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook delivery attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := []*models.WebhookDeliveryAttempt{}
+	for rows.Next() {
+		a := &models.WebhookDeliveryAttempt{}
+		var payload string
+		if err := rows.Scan(&a.ID, &a.DeliveryID, &a.Redelivery, &a.Attempt, &payload, &a.StatusCode, &a.Error, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan webhook delivery attempt: %w", err)
+		}
+		a.Payload = []byte(payload)
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
+
+// FirstPayload returns the body of the delivery's first attempt, which retries and redeliveries resend;
+// nil when it has none (deliveries made before attempts were recorded).
+func (r *WebhookDeliveryRepository) FirstPayload(ctx context.Context, deliveryID uuid.UUID) ([]byte, error) {
+	var payload string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT payload FROM webhook_delivery_attempts
+		WHERE delivery_id = $1
+		ORDER BY created_at, redelivery, attempt
+		LIMIT 1
+	`, deliveryID).Scan(&payload)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get webhook delivery payload: %w", err)
+	}
+	return []byte(payload), nil
+}
+
+// Redeliver starts a new round of attempts of a sent or failed delivery: it becomes pending with no
+// attempts, so the dispatcher's retry worker sends it at its next tick.
+func (r *WebhookDeliveryRepository) Redeliver(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{}
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, last_attempt_at = NULL, last_error = NULL,
+		    redeliveries = redeliveries + 1
+		WHERE id = $1 AND status <> 'pending'
+		RETURNING id, job_id, url, status, attempts, last_attempt_at, last_error, redeliveries, created_at
+	`, id).Scan(
+		&delivery.ID, &delivery.JobID, &delivery.URL, &delivery.Status,
+		&delivery.Attempts, &delivery.LastAttemptAt, &delivery.LastError,
+		&delivery.Redeliveries, &delivery.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrWebhookDeliveryPending
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redeliver webhook delivery: %w", err)
+	}
+	return delivery, nil
+}
@@ -0,0 +1,266 @@
+// Package egress guards the requests the services send to URLs users or the configuration supply
+// against server-side request forgery: job webhooks, webhook and chat notification sinks, jobs' external
+// MCP tools (also checked against the owner's allow list), and the worker's pipeline hook callbacks and
+// alert webhook. Fixed service endpoints (Gemini, S3, SNS, the agents) do not go through it. A Policy rejects URLs that are not http(s),
+// hosts outside the allow lists or on the deny list, and addresses in private, loopback, link-local and
+// other non-public ranges. Addresses are checked when connecting, on the IP actually dialed, so a
+// hostname resolving to a public address when validated and to an internal one later (DNS rebinding)
+// is still refused. Through a proxy (SetProxy) the destination's addresses are checked by resolving its
+// host just before the request; the proxy resolves it again, so only the proxy's own rules close that
+// window.
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrDenied is wrapped by the errors of destinations the policy refuses.
+var ErrDenied = errors.New("destination not allowed")
+
+// MaxHosts is the maximum number of entries of a user's allow list.
+const MaxHosts = 50
+
+// Policy is the egress policy of the server (EGRESS_ALLOW_HOSTS, EGRESS_DENY_HOSTS,
+// EGRESS_ALLOW_PRIVATE). Host patterns match the host and its subdomains: "example.com" matches
+// example.com and hooks.example.com.
+type Policy struct {
+	allow        []string
+	deny         []string
+	allowPrivate bool
+	proxy        func(*http.Request) (*url.URL, error)
+	proxies      sync.Map // host:port of the proxies requests went through, dialed without checks
+}
+
+// NewPolicy creates a policy. An empty allow list allows every host not denied; allowPrivate lifts the
+// address checks (for local development against receivers on the same machine or network).
+func NewPolicy(allow, deny []string, allowPrivate bool) *Policy {
+	return &Policy{allow: NormalizeHosts(allow), deny: NormalizeHosts(deny), allowPrivate: allowPrivate}
+}
+
+// SetProxy makes the clients of Transport and Client send requests through proxy (an
+// http.Transport.Proxy function, such as proxy.Func; nil connects directly, the default).
+func (p *Policy) SetProxy(proxy func(*http.Request) (*url.URL, error)) {
+	p.proxy = proxy
+}
+
+// CheckURL validates a destination before it is stored or requested: an http(s) URL whose host passes
+// the server's lists and, when userAllow is not empty, matches one of its patterns. IP literals are
+// checked here; hostnames are only resolved when connecting.
+func (p *Policy) CheckURL(rawURL string, userAllow []string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Hostname() == "" {
+		return fmt.Errorf("%w: %q is not an http(s) URL", ErrDenied, rawURL)
+	}
+	host := normalizeHost(u.Hostname())
+	if err := p.checkHost(host); err != nil {
+		return err
+	}
+	if len(userAllow) > 0 && !MatchHost(host, NormalizeHosts(userAllow)) {
+		return fmt.Errorf("%w: %s is not in your egress allow list", ErrDenied, host)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return p.CheckAddr(addr)
+	}
+	return nil
+}
+
+// CheckAddr refuses non-public addresses unless the policy allows private ones.
+func (p *Policy) CheckAddr(addr netip.Addr) error {
+	if p.allowPrivate || Public(addr) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is not a public address", ErrDenied, addr)
+}
+
+// checkHost applies the server's deny and allow lists to a normalized host.
+func (p *Policy) checkHost(host string) error {
+	if MatchHost(host, p.deny) {
+		return fmt.Errorf("%w: %s is denied", ErrDenied, host)
+	}
+	if len(p.allow) > 0 && !MatchHost(host, p.allow) {
+		return fmt.Errorf("%w: %s is not in the allowed hosts", ErrDenied, host)
+	}
+	return nil
+}
+
+// Transport returns an HTTP transport enforcing the policy on every connection: the host is checked
+// against the lists and each address dialed against the private ranges. Requests sent through the proxy
+// (SetProxy) have their host resolved and checked first, as the transport only dials the proxy.
+func (p *Policy) Transport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: cannot parse %q", ErrDenied, address)
+			}
+			return p.CheckAddr(addrPort.Addr().Unmap())
+		},
+	}
+	direct := &net.Dialer{Timeout: dialer.Timeout, KeepAlive: dialer.KeepAlive}
+	return &http.Transport{
+		Proxy: p.proxyFor,
+		DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			if _, ok := p.proxies.Load(address); ok {
+				return direct.DialContext(ctx, network, address)
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.checkHost(normalizeHost(host)); err != nil {
+				return nil, err
+			}
+			return dialer.DialContext(ctx, network, address)
+		},
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// proxyFor is the transport's Proxy function: it returns the proxy of req, if any, after checking the
+// addresses req's host resolves to.
+func (p *Policy) proxyFor(req *http.Request) (*url.URL, error) {
+	if p.proxy == nil {
+		return nil, nil
+	}
+	u, err := p.proxy(req)
+	if err != nil || u == nil {
+		return u, err
+	}
+	host := normalizeHost(req.URL.Hostname())
+	if err := p.checkHost(host); err != nil {
+		return nil, err
+	}
+	if !p.allowPrivate {
+		addrs, err := net.DefaultResolver.LookupNetIP(req.Context(), "ip", host)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", host, err)
+		}
+		for _, addr := range addrs {
+			if err := p.CheckAddr(addr); err != nil {
+				return nil, err
+			}
+		}
+	}
+	p.proxies.Store(proxyAddr(u), struct{}{})
+	return u, nil
+}
+
+// proxyAddr returns the host:port the transport dials to reach proxy u.
+func proxyAddr(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	port := map[string]string{"http": "80", "https": "443", "socks5": "1080"}[u.Scheme]
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// Client returns an HTTP client using Transport that does not follow redirects: a 3xx is returned as
+// the response, so a receiver cannot bounce requests to a destination the lists would refuse.
+func (p *Policy) Client(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: p.Transport(),
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// nonPublic are the ranges not covered by the netip.Addr predicates used in Public
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved, and broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64, which can reach any IPv4 address
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local-use NAT64
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+}
+
+// Public reports whether addr is a public unicast address: not private (RFC 1918, fc00::/7), loopback,
+// link-local (including cloud metadata endpoints at 169.254.169.254), multicast, unspecified or in
+// another special-purpose range.
+func Public(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublic {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchHost reports whether host matches one of the normalized patterns, either exactly or as a
+// subdomain.
+func MatchHost(host string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if host == pattern || strings.HasSuffix(host, "."+pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostRe is a DNS name or an IPv4 address; IPv6 literals are checked with netip
+var hostRe = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)*[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// ValidateHosts checks a user's allow list: at most MaxHosts host names or IP addresses, without
+// schemes, ports or wildcards.
+func ValidateHosts(hosts []string) error {
+	if len(hosts) > MaxHosts {
+		return fmt.Errorf("at most %d hosts are allowed", MaxHosts)
+	}
+	for _, h := range hosts {
+		host := normalizeHost(h)
+		if _, err := netip.ParseAddr(host); err == nil {
+			continue
+		}
+		if len(host) > 253 || !hostRe.MatchString(host) {
+			return fmt.Errorf("invalid host %q: must be a host name such as hooks.example.com", h)
+		}
+	}
+	return nil
+}
+
+// NormalizeHosts lowercases the hosts and drops trailing dots, blanks and duplicates.
+func NormalizeHosts(hosts []string) []string {
+	var out []string
+	for _, h := range hosts {
+		host := normalizeHost(h)
+		if host != "" && !slices.Contains(out, host) {
+			out = append(out, host)
+		}
+	}
+	return out
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
@@ -0,0 +1,147 @@
+package egress
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestPublic(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"127.0.0.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"255.255.255.255", false},
+		{"::1", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+	}
+	for _, tt := range tests {
+		if got := Public(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("Public(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	p := NewPolicy(nil, []string{"Internal.Example.com"}, false)
+	tests := []struct {
+		url       string
+		userAllow []string
+		wantErr   bool
+	}{
+		{"https://hooks.example.com/x", nil, false},
+		{"http://93.184.216.34:8080/x", nil, false},
+		{"ftp://hooks.example.com/x", nil, true},
+		{"https://", nil, true},
+		{"http://127.0.0.1/x", nil, true},
+		{"http://[::1]:8080/x", nil, true},
+		{"http://169.254.169.254/latest/meta-data", nil, true},
+		{"https://internal.example.com/x", nil, true},
+		{"https://a.internal.example.com./x", nil, true},
+		{"https://hooks.example.com/x", []string{"example.com"}, false},
+		{"https://hooks.example.org/x", []string{"example.com"}, true},
+		{"https://badexample.com/x", []string{"example.com"}, true},
+	}
+	for _, tt := range tests {
+		err := p.CheckURL(tt.url, tt.userAllow)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckURL(%q, %v) = %v, want error %v", tt.url, tt.userAllow, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrDenied) {
+			t.Errorf("CheckURL(%q) error %v does not wrap ErrDenied", tt.url, err)
+		}
+	}
+
+	allowOnly := NewPolicy([]string{"hooks.slack.com"}, nil, false)
+	if err := allowOnly.CheckURL("https://hooks.example.com/x", nil); err == nil {
+		t.Errorf("host outside the server's allow list accepted")
+	}
+	if err := NewPolicy(nil, nil, true).CheckURL("http://127.0.0.1:9000/x", nil); err != nil {
+		t.Errorf("loopback with private addresses allowed: %v", err)
+	}
+}
+
+// TestClient asserts the client refuses to connect to a loopback receiver and does not follow redirects.
+func TestClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	_, err := NewPolicy(nil, nil, false).Client(time.Second).Get(srv.URL)
+	if !errors.Is(err, ErrDenied) {
+		t.Errorf("GET loopback err = %v, want ErrDenied", err)
+	}
+	_, err = NewPolicy(nil, []string{"localhost"}, true).Client(time.Second).Get("http://localhost:1/")
+	if !errors.Is(err, ErrDenied) {
+		t.Errorf("GET denied host err = %v, want ErrDenied", err)
+	}
+
+	resp, err := NewPolicy(nil, nil, true).Client(time.Second).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET with private addresses allowed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("status = %d, want the redirect itself", resp.StatusCode)
+	}
+}
+
+// TestClient_Proxy asserts requests go through the proxy, which is dialed without checks, while their
+// destinations are still checked.
+func TestClient_Proxy(t *testing.T) {
+	var proxied string
+	proxySrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxySrv.Close()
+	proxyURL, _ := url.Parse(proxySrv.URL)
+
+	p := NewPolicy(nil, []string{"denied.example.com"}, false)
+	p.SetProxy(http.ProxyURL(proxyURL))
+	client := p.Client(time.Second)
+
+	resp, err := client.Get("http://93.184.216.34/hook")
+	if err != nil {
+		t.Fatalf("GET through the proxy: %v", err)
+	}
+	resp.Body.Close()
+	if proxied != "http://93.184.216.34/hook" {
+		t.Errorf("proxy got %q, want the request for the destination", proxied)
+	}
+	for _, dest := range []string{"http://127.0.0.1:9000/hook", "http://denied.example.com/hook"} {
+		if _, err := client.Get(dest); !errors.Is(err, ErrDenied) {
+			t.Errorf("GET %s through the proxy err = %v, want ErrDenied", dest, err)
+		}
+	}
+}
+
+func TestValidateHosts(t *testing.T) {
+	if err := ValidateHosts([]string{"hooks.example.com", "Example.ORG.", "203.0.113.7", "::1"}); err != nil {
+		t.Errorf("valid hosts rejected: %v", err)
+	}
+	for _, host := range []string{"https://example.com", "example.com:443", "*.example.com", "exa mple.com", "-bad.com"} {
+		if err := ValidateHosts([]string{host}); err == nil {
+			t.Errorf("ValidateHosts(%q) accepted", host)
+		}
+	}
+	if got := NormalizeHosts([]string{" A.com.", "a.com", "", "b.com"}); len(got) != 2 || got[0] != "a.com" || got[1] != "b.com" {
+		t.Errorf("NormalizeHosts = %v, want [a.com b.com]", got)
+	}
+}
@@ -0,0 +1,202 @@
+// Package features decides which users get the pipeline steps being rolled out gradually. Each flag has a
+// rollout: the percentage of users it is enabled for, picked by a stable hash of flag and user so a user
+// keeps their answer as the percentage grows. The rollout comes from the admin API (feature_flags table),
+// else FEATURE_FLAGS, else the built-in default of the flag; a per-user override (feature_flag_overrides)
+// turns a flag on or off for one user whatever the rollout.
+package features
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// Flags consulted by the API and the worker.
+const (
+	FactCheck = "fact_check" // per-segment fact-check of jobs with fact_check_needed
+)
+
+// defaults are the built-in rollouts of the known flags. Steps released before flags existed are on for
+// everyone; flags not listed are off until configured.
+var defaults = map[string]int{
+	FactCheck: 100,
+}
+
+// nameRe matches flag names: lower-case letters, digits and underscores.
+var nameRe = regexp.MustCompile(`^[a-z][a-z0-9_]{1,63}$`)
+
+// ValidName reports whether name can be a flag name.
+func ValidName(name string) bool {
+	return nameRe.MatchString(name)
+}
+
+// ParseRollouts parses FEATURE_FLAGS entries (flag=on, flag=off or flag=25%) into rollout percentages.
+func ParseRollouts(entries map[string]string) (map[string]int, error) {
+	rollouts := make(map[string]int, len(entries))
+	for name, value := range entries {
+		if !ValidName(name) {
+			return nil, fmt.Errorf("invalid feature flag name %q: must be lower-case letters, digits and underscores", name)
+		}
+		switch value {
+		case "on":
+			rollouts[name] = 100
+		case "off":
+			rollouts[name] = 0
+		default:
+			percent, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+			if err != nil || percent < 0 || percent > 100 {
+				return nil, fmt.Errorf("invalid rollout %q for feature flag %s: must be on, off or 0-100%%", value, name)
+			}
+			rollouts[name] = percent
+		}
+	}
+	return rollouts, nil
+}
+
+// Store is the admin-managed state of the flags (database.FeatureFlagRepository).
+type Store interface {
+	ListRollouts(ctx context.Context) ([]*models.FeatureFlag, error)
+	ListOverrides(ctx context.Context) ([]*models.FeatureFlagOverride, error)
+}
+
+// Flags answers whether a flag is enabled for a user. The admin-managed state is loaded from the store at
+// most once per refresh interval, so changes reach every replica within it. A nil *Flags uses the
+// built-in defaults.
+type Flags struct {
+	config  map[string]int
+	store   Store // nil: FEATURE_FLAGS and defaults only
+	refresh time.Duration
+
+	mu        sync.Mutex
+	loadedAt  time.Time
+	rollouts  map[string]int
+	overrides map[string]map[uuid.UUID]bool
+}
+
+// New creates Flags from the FEATURE_FLAGS rollouts (ParseRollouts) and the admin-managed store.
+func New(config map[string]int, store Store, refresh time.Duration) *Flags {
+	return &Flags{config: config, store: store, refresh: refresh}
+}
+
+// Enabled reports whether the flag is enabled for the user.
+func (f *Flags) Enabled(ctx context.Context, name string, userID uuid.UUID) bool {
+	if f == nil {
+		return bucket(name, userID) < defaults[name]
+	}
+	rollouts, overrides := f.state(ctx)
+	if enabled, ok := overrides[name][userID]; ok {
+		return enabled
+	}
+	return bucket(name, userID) < f.rollout(name, rollouts)
+}
+
+// Rollouts returns the effective rollout percentage of every known or configured flag.
+func (f *Flags) Rollouts(ctx context.Context) map[string]int {
+	if f == nil {
+		f = &Flags{}
+	}
+	rollouts, _ := f.state(ctx)
+	out := make(map[string]int, len(defaults))
+	for _, names := range []map[string]int{defaults, f.config, rollouts} {
+		for name := range names {
+			out[name] = f.rollout(name, rollouts)
+		}
+	}
+	return out
+}
+
+// Source returns where the rollout of a flag comes from: "admin", "config" (FEATURE_FLAGS) or "default".
+func (f *Flags) Source(ctx context.Context, name string) string {
+	if f == nil {
+		return "default"
+	}
+	rollouts, _ := f.state(ctx)
+	if _, ok := rollouts[name]; ok {
+		return "admin"
+	}
+	if _, ok := f.config[name]; ok {
+		return "config"
+	}
+	return "default"
+}
+
+// Overrides returns the per-user overrides of a flag.
+func (f *Flags) Overrides(ctx context.Context, name string) map[uuid.UUID]bool {
+	_, overrides := f.state(ctx)
+	return overrides[name]
+}
+
+// Invalidate makes the next check reload the store, so admin changes apply at once in this process.
+func (f *Flags) Invalidate() {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	f.loadedAt = time.Time{}
+	f.mu.Unlock()
+}
+
+func (f *Flags) rollout(name string, rollouts map[string]int) int {
+	if percent, ok := rollouts[name]; ok {
+		return percent
+	}
+	if percent, ok := f.config[name]; ok {
+		return percent
+	}
+	return defaults[name]
+}
+
+// state returns the admin-managed rollouts and overrides, reloading them when older than the refresh
+// interval. A failed reload keeps the previous state and is retried at the next interval.
+func (f *Flags) state(ctx context.Context) (map[string]int, map[string]map[uuid.UUID]bool) {
+	if f == nil || f.store == nil {
+		return nil, nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.loadedAt.IsZero() && time.Since(f.loadedAt) < f.refresh {
+		return f.rollouts, f.overrides
+	}
+	f.loadedAt = time.Now()
+
+	flags, err := f.store.ListRollouts(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to load feature flags; keeping the previous ones")
+		return f.rollouts, f.overrides
+	}
+	userOverrides, err := f.store.ListOverrides(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to load feature flag overrides; keeping the previous ones")
+		return f.rollouts, f.overrides
+	}
+	f.rollouts = make(map[string]int, len(flags))
+	for _, flag := range flags {
+		f.rollouts[flag.Name] = flag.RolloutPercent
+	}
+	f.overrides = make(map[string]map[uuid.UUID]bool)
+	for _, o := range userOverrides {
+		if f.overrides[o.FlagName] == nil {
+			f.overrides[o.FlagName] = make(map[uuid.UUID]bool)
+		}
+		f.overrides[o.FlagName][o.UserID] = o.Enabled
+	}
+	return f.rollouts, f.overrides
+}
+
+// bucket places a user in 0-99 for a flag. Flags hash independently, so the first users of one rollout
+// are not always the first of the next.
+func bucket(name string, userID uuid.UUID) int {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write(userID[:])
+	return int(h.Sum32() % 100)
+}
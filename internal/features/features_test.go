@@ -0,0 +1,106 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+type fakeStore struct {
+	rollouts  []*models.FeatureFlag
+	overrides []*models.FeatureFlagOverride
+	err       error
+	loads     int
+}
+
+func (s *fakeStore) ListRollouts(context.Context) ([]*models.FeatureFlag, error) {
+	s.loads++
+	return s.rollouts, s.err
+}
+
+func (s *fakeStore) ListOverrides(context.Context) ([]*models.FeatureFlagOverride, error) {
+	return s.overrides, s.err
+}
+
+func TestParseRollouts(t *testing.T) {
+	got, err := ParseRollouts(map[string]string{"fact_check": "off", "video": "25%", "moderation": "on", "beta": "5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"fact_check": 0, "video": 25, "moderation": 100, "beta": 5}
+	for name, percent := range want {
+		if got[name] != percent {
+			t.Errorf("%s = %d, want %d", name, got[name], percent)
+		}
+	}
+
+	for _, bad := range []map[string]string{{"video": "half"}, {"video": "120%"}, {"Video": "on"}} {
+		if _, err := ParseRollouts(bad); err == nil {
+			t.Errorf("ParseRollouts(%v) succeeded", bad)
+		}
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	ctx := context.Background()
+	user := uuid.New()
+
+	// Built-in defaults: fact_check on, unknown flags off
+	var none *Flags
+	if !none.Enabled(ctx, FactCheck, user) || none.Enabled(ctx, "video", user) {
+		t.Error("nil Flags do not follow the defaults")
+	}
+
+	store := &fakeStore{
+		rollouts:  []*models.FeatureFlag{{Name: "video", RolloutPercent: 100}},
+		overrides: []*models.FeatureFlagOverride{{FlagName: "moderation", UserID: user, Enabled: true}},
+	}
+	flags := New(map[string]int{FactCheck: 0, "video": 0}, store, time.Minute)
+	if flags.Enabled(ctx, FactCheck, user) {
+		t.Error("fact_check enabled although FEATURE_FLAGS turns it off")
+	}
+	if !flags.Enabled(ctx, "video", user) {
+		t.Error("video disabled although the admin rollout is 100%")
+	}
+	if !flags.Enabled(ctx, "moderation", user) || flags.Enabled(ctx, "moderation", uuid.New()) {
+		t.Error("moderation does not follow the user override")
+	}
+	if store.loads != 1 {
+		t.Errorf("store loaded %d times, want once per refresh interval", store.loads)
+	}
+
+	// A failed reload keeps the previous state
+	flags.Invalidate()
+	store.err = errors.New("db down")
+	if !flags.Enabled(ctx, "video", user) {
+		t.Error("video disabled after a failed reload")
+	}
+	if got := flags.Source(ctx, "video"); got != "admin" {
+		t.Errorf("Source(video) = %q, want admin", got)
+	}
+}
+
+func TestEnabled_Rollout(t *testing.T) {
+	ctx := context.Background()
+	flags := New(map[string]int{"video": 30}, nil, time.Minute)
+	enabled := 0
+	for i := 0; i < 2000; i++ {
+		user := uuid.New()
+		if flags.Enabled(ctx, "video", user) {
+			enabled++
+			if !flags.Enabled(ctx, "video", user) {
+				t.Fatal("a user's answer changed between checks")
+			}
+		}
+	}
+	if enabled < 500 || enabled > 700 {
+		t.Errorf("%d of 2000 users enabled, want about 30%%", enabled)
+	}
+	if got := flags.Rollouts(ctx); got["video"] != 30 || got[FactCheck] != 100 {
+		t.Errorf("Rollouts = %v", got)
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/snappy-loop/stories/internal/agents"
+	"github.com/snappy-loop/stories/internal/llm"
 	"github.com/snappy-loop/stories/internal/storage"
 	audiov1 "github.com/snappy-loop/stories/gen/audio/v1"
 )
@@ -17,11 +18,13 @@ type AudioServer struct {
 	audiov1.UnimplementedAudioServiceServer
 	agent   agents.AudioAgent
 	storage *storage.Client
+	// streams keeps streamed audio for ResumeAudio when it is not stored in S3
+	streams *audioCache
 }
 
 // NewAudioServer returns a new AudioServer. storageClient may be nil; then audio is returned inline (may hit gRPC size limits).
 func NewAudioServer(agent agents.AudioAgent, storageClient *storage.Client) *AudioServer {
-	return &AudioServer{agent: agent, storage: storageClient}
+	return &AudioServer{agent: agent, storage: storageClient, streams: newAudioCache()}
 }
 
 // GenerateNarration delegates to the audio agent.
@@ -35,41 +38,19 @@ func (s *AudioServer) GenerateNarration(ctx context.Context, req *audiov1.Genera
 
 // GenerateAudio delegates to the audio agent. If storage is configured, uploads to S3 and returns URL to avoid gRPC message size limits.
 func (s *AudioServer) GenerateAudio(ctx context.Context, req *audiov1.GenerateAudioRequest) (*audiov1.GenerateAudioResponse, error) {
-	audio, err := s.agent.GenerateAudio(ctx, req.GetScript(), req.GetAudioType())
-	if err != nil {
-		return nil, err
-	}
-	if audio == nil {
-		return nil, fmt.Errorf("audio agent returned nil result")
-	}
-	data, err := agents.AudioData(audio)
+	audio, data, err := s.generate(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	mimeType := audio.MimeType
-	if mimeType == "" {
-		mimeType = "audio/wav"
-	}
 	resp := &audiov1.GenerateAudioResponse{
 		Size:     audio.Size,
 		Duration: audio.Duration,
-		MimeType: mimeType,
+		MimeType: audio.MimeType,
 		Model:    audio.Model,
 	}
 	if s.storage != nil && len(data) > 0 {
-		userID := userIDFromContext(ctx)
-		key := "agents/" + userID + "/audio/" + uuid.New().String() + extensionForMime(mimeType)
-		if err := s.storage.Upload(ctx, key, bytes.NewReader(data), mimeType, int64(len(data))); err != nil {
-			return nil, fmt.Errorf("upload audio to S3: %w", err)
-		}
-		if url := s.storage.PublicURL(key); url != "" {
-			resp.Url = url
-		} else {
-			url, err := s.storage.GeneratePresignedURL(key, 24*time.Hour)
-			if err != nil {
-				return nil, fmt.Errorf("presign audio URL: %w", err)
-			}
-			resp.Url = url
+		if resp.Url, err = s.store(ctx, data, audio.MimeType); err != nil {
+			return nil, err
 		}
 	} else {
 		resp.Data = data
@@ -77,6 +58,43 @@ func (s *AudioServer) GenerateAudio(ctx context.Context, req *audiov1.GenerateAu
 	return resp, nil
 }
 
+// generate runs the audio agent and returns the audio (with its MIME type defaulted) and its bytes.
+func (s *AudioServer) generate(ctx context.Context, req *audiov1.GenerateAudioRequest) (*llm.Audio, []byte, error) {
+	audio, err := s.agent.GenerateAudio(ctx, req.GetScript(), req.GetAudioType())
+	if err != nil {
+		return nil, nil, err
+	}
+	if audio == nil {
+		return nil, nil, fmt.Errorf("audio agent returned nil result")
+	}
+	data, err := agents.AudioData(audio)
+	if err != nil {
+		return nil, nil, err
+	}
+	if audio.MimeType == "" {
+		audio.MimeType = "audio/wav"
+	}
+	return audio, data, nil
+}
+
+// store uploads audio to S3 under the caller's agents prefix and returns its public URL, else a presigned one.
+func (s *AudioServer) store(ctx context.Context, data []byte, mimeType string) (string, error) {
+	userID := userIDFromContext(ctx)
+	key := "agents/" + userID + "/audio/" + uuid.New().String() + extensionForMime(mimeType)
+	bucket, err := s.storage.Upload(ctx, key, bytes.NewReader(data), mimeType, int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("upload audio to S3: %w", err)
+	}
+	if url := s.storage.PublicURL(bucket, key); url != "" {
+		return url, nil
+	}
+	url, err := s.storage.GeneratePresignedURL(bucket, key, 24*time.Hour)
+	if err != nil {
+		return "", fmt.Errorf("presign audio URL: %w", err)
+	}
+	return url, nil
+}
+
 func extensionForMime(mime string) string {
 	switch mime {
 	case "audio/mpeg", "audio/mp3":
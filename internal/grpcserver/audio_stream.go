@@ -0,0 +1,153 @@
+package grpcserver
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	audiov1 "github.com/snappy-loop/stories/gen/audio/v1"
+	"github.com/snappy-loop/stories/internal/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// audioChunkSize is the data of each streamed message, well below the default 4 MiB message limit
+	audioChunkSize = 256 << 10
+	// audioResumeTTL is how long streamed audio can be resumed
+	audioResumeTTL = 15 * time.Minute
+	// audioResumeMaxBytes bounds the audio kept for resumption; the oldest goes first
+	audioResumeMaxBytes = 256 << 20
+)
+
+// StreamAudio generates audio and streams it: one chunk with the url when storage is configured (clients
+// resume downloads with HTTP range requests), else the bytes in chunks, kept for ResumeAudio.
+func (s *AudioServer) StreamAudio(req *audiov1.GenerateAudioRequest, stream grpc.ServerStreamingServer[audiov1.AudioChunk]) error {
+	ctx := stream.Context()
+	audio, data, err := s.generate(ctx, req)
+	if err != nil {
+		return err
+	}
+	a := &streamedAudio{
+		id:       uuid.New().String(),
+		data:     data,
+		duration: audio.Duration,
+		mimeType: audio.MimeType,
+		model:    audio.Model,
+	}
+	if s.storage != nil && len(data) > 0 {
+		url, err := s.store(ctx, data, audio.MimeType)
+		if err != nil {
+			return err
+		}
+		header := a.header()
+		header.Url = url
+		return stream.Send(header)
+	}
+	a.apiKeyID, _ = auth.GetAPIKeyID(ctx)
+	s.streams.put(a)
+	return sendAudio(stream, a, 0)
+}
+
+// ResumeAudio streams audio of an earlier StreamAudio of the caller's key from offset.
+func (s *AudioServer) ResumeAudio(req *audiov1.ResumeAudioRequest, stream grpc.ServerStreamingServer[audiov1.AudioChunk]) error {
+	apiKeyID, _ := auth.GetAPIKeyID(stream.Context())
+	a := s.streams.get(req.GetAudioId(), apiKeyID)
+	if a == nil {
+		return status.Error(codes.NotFound, "audio not found or expired")
+	}
+	if req.GetOffset() < 0 || req.GetOffset() > int64(len(a.data)) {
+		return status.Errorf(codes.OutOfRange, "offset must be between 0 and %d", len(a.data))
+	}
+	return sendAudio(stream, a, req.GetOffset())
+}
+
+// sendAudio streams a's bytes from offset, the first message with a's metadata.
+func sendAudio(stream grpc.ServerStreamingServer[audiov1.AudioChunk], a *streamedAudio, offset int64) error {
+	chunk := a.header()
+	for {
+		end := min(offset+audioChunkSize, int64(len(a.data)))
+		chunk.Offset = offset
+		chunk.Data = a.data[offset:end]
+		if err := stream.Send(chunk); err != nil {
+			return err
+		}
+		if end == int64(len(a.data)) {
+			return nil
+		}
+		offset = end
+		chunk = &audiov1.AudioChunk{AudioId: a.id}
+	}
+}
+
+// streamedAudio is audio streamed by StreamAudio.
+type streamedAudio struct {
+	id        string
+	apiKeyID  uuid.UUID
+	data      []byte
+	duration  float64
+	mimeType  string
+	model     string
+	expiresAt time.Time
+}
+
+func (a *streamedAudio) header() *audiov1.AudioChunk {
+	return &audiov1.AudioChunk{
+		AudioId:  a.id,
+		Size:     int64(len(a.data)),
+		Duration: a.duration,
+		MimeType: a.mimeType,
+		Model:    a.model,
+	}
+}
+
+// audioCache keeps streamed audio in memory for audioResumeTTL, up to maxBytes.
+type audioCache struct {
+	mu       sync.Mutex
+	entries  map[string]*streamedAudio
+	order    []*streamedAudio // oldest first
+	bytes    int
+	maxBytes int
+	now      func() time.Time
+}
+
+func newAudioCache() *audioCache {
+	return &audioCache{entries: make(map[string]*streamedAudio), maxBytes: audioResumeMaxBytes, now: time.Now}
+}
+
+// put adds a, evicting expired and then the oldest audio to make room. Audio larger than the cache is not
+// kept (it cannot be resumed).
+func (c *audioCache) put(a *streamedAudio) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	a.expiresAt = now.Add(audioResumeTTL)
+	for len(c.order) > 0 && (!c.order[0].expiresAt.After(now) || c.bytes+len(a.data) > c.maxBytes) {
+		c.evictOldest()
+	}
+	if len(a.data) > c.maxBytes {
+		return
+	}
+	c.entries[a.id] = a
+	c.order = append(c.order, a)
+	c.bytes += len(a.data)
+}
+
+func (c *audioCache) evictOldest() {
+	oldest := c.order[0]
+	c.order = c.order[1:]
+	delete(c.entries, oldest.id)
+	c.bytes -= len(oldest.data)
+}
+
+// get returns the unexpired audio id of apiKeyID, or nil.
+func (c *audioCache) get(id string, apiKeyID uuid.UUID) *streamedAudio {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	a := c.entries[id]
+	if a == nil || a.apiKeyID != apiKeyID || !a.expiresAt.After(c.now()) {
+		return nil
+	}
+	return a
+}
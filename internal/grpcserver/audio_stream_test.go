@@ -0,0 +1,106 @@
+package grpcserver
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	audiov1 "github.com/snappy-loop/stories/gen/audio/v1"
+	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/llm"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeAudioAgent struct {
+	data []byte
+}
+
+func (a *fakeAudioAgent) GenerateNarration(context.Context, string, string, string) (string, error) {
+	return "", nil
+}
+
+func (a *fakeAudioAgent) GenerateAudio(context.Context, string, string) (*llm.Audio, error) {
+	return &llm.Audio{Data: bytes.NewReader(a.data), Size: int64(len(a.data)), Duration: 1.5, Model: "tts"}, nil
+}
+
+type fakeAudioStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	chunks []*audiov1.AudioChunk
+}
+
+func (s *fakeAudioStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeAudioStream) Send(c *audiov1.AudioChunk) error {
+	s.chunks = append(s.chunks, c)
+	return nil
+}
+
+func TestStreamAudio(t *testing.T) {
+	data := bytes.Repeat([]byte("a"), audioChunkSize*2+10)
+	s := NewAudioServer(&fakeAudioAgent{data: data}, nil)
+	keyID := uuid.New()
+	ctx := context.WithValue(context.Background(), auth.APIKeyIDKey, keyID)
+
+	stream := &fakeAudioStream{ctx: ctx}
+	if err := s.StreamAudio(&audiov1.GenerateAudioRequest{Script: "hi"}, stream); err != nil {
+		t.Fatalf("StreamAudio: %v", err)
+	}
+	if len(stream.chunks) != 3 {
+		t.Fatalf("got %d chunks, want 3", len(stream.chunks))
+	}
+	head := stream.chunks[0]
+	if head.GetSize() != int64(len(data)) || head.GetMimeType() != "audio/wav" || head.GetModel() != "tts" || head.GetDuration() != 1.5 {
+		t.Errorf("first chunk = %+v, want the audio metadata", head)
+	}
+	if c := stream.chunks[2]; c.GetOffset() != audioChunkSize*2 || len(c.GetData()) != 10 || c.GetMimeType() != "" {
+		t.Errorf("last chunk at %d with %d bytes (mime %q), want 10 bytes at %d without metadata", c.GetOffset(), len(c.GetData()), c.GetMimeType(), audioChunkSize*2)
+	}
+
+	resumed := &fakeAudioStream{ctx: ctx}
+	if err := s.ResumeAudio(&audiov1.ResumeAudioRequest{AudioId: head.GetAudioId(), Offset: audioChunkSize + 5}, resumed); err != nil {
+		t.Fatalf("ResumeAudio: %v", err)
+	}
+	if len(resumed.chunks) != 2 || resumed.chunks[0].GetOffset() != audioChunkSize+5 || resumed.chunks[0].GetSize() != int64(len(data)) {
+		t.Errorf("resumed chunks = %d starting at %d, want 2 from %d with the metadata", len(resumed.chunks), resumed.chunks[0].GetOffset(), audioChunkSize+5)
+	}
+
+	other := &fakeAudioStream{ctx: context.WithValue(context.Background(), auth.APIKeyIDKey, uuid.New())}
+	if err := s.ResumeAudio(&audiov1.ResumeAudioRequest{AudioId: head.GetAudioId()}, other); status.Code(err) != codes.NotFound {
+		t.Errorf("resume another key's audio: err = %v, want NotFound", err)
+	}
+	if err := s.ResumeAudio(&audiov1.ResumeAudioRequest{AudioId: head.GetAudioId(), Offset: int64(len(data)) + 1}, resumed); status.Code(err) != codes.OutOfRange {
+		t.Errorf("resume past the end: err = %v, want OutOfRange", err)
+	}
+}
+
+func TestAudioCache(t *testing.T) {
+	c := newAudioCache()
+	c.maxBytes = 100
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	keyID := uuid.New()
+
+	first := &streamedAudio{id: "first", apiKeyID: keyID, data: make([]byte, 50)}
+	c.put(first)
+	now = now.Add(audioResumeTTL)
+	if c.get("first", keyID) != nil {
+		t.Error("expired audio was returned")
+	}
+
+	c.put(&streamedAudio{id: "second", apiKeyID: keyID, data: make([]byte, 50)})
+	c.put(&streamedAudio{id: "third", apiKeyID: keyID, data: make([]byte, 50)})
+	if c.entries["first"] != nil || c.get("second", keyID) == nil || c.get("third", keyID) == nil {
+		t.Errorf("cache has %d entries, want the expired one evicted and the others kept", len(c.entries))
+	}
+	c.put(&streamedAudio{id: "fourth", apiKeyID: keyID, data: make([]byte, 1)})
+	if c.get("second", keyID) != nil || c.get("fourth", keyID) == nil || c.bytes != 50+1 {
+		t.Errorf("full cache holds %d bytes, want the oldest evicted for the new audio", c.bytes)
+	}
+}
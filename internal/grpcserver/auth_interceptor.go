@@ -2,10 +2,19 @@ package grpcserver
 
 import (
 	"context"
+	"errors"
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	audiov1 "github.com/snappy-loop/stories/gen/audio/v1"
+	factcheckv1 "github.com/snappy-loop/stories/gen/factcheck/v1"
+	imagev1 "github.com/snappy-loop/stories/gen/image/v1"
+	segmentationv1 "github.com/snappy-loop/stories/gen/segmentation/v1"
+	segmentationv2 "github.com/snappy-loop/stories/gen/segmentation/v2"
 	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/quota"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -14,34 +23,161 @@ import (
 
 const metadataKeyAuthorization = "authorization"
 
+// methodTools maps gRPC methods to the agent tools API key scopes grant (models.AgentToolScope).
+var methodTools = map[string]string{
+	segmentationv1.SegmentationService_SegmentText_FullMethodName:    "segment_text",
+	segmentationv1.SegmentationService_ExtractContent_FullMethodName: "extract_content",
+	segmentationv2.SegmentationService_SegmentText_FullMethodName:    "segment_text",
+	segmentationv2.SegmentationService_ExtractContent_FullMethodName: "extract_content",
+	audiov1.AudioService_GenerateNarration_FullMethodName:            "generate_narration",
+	audiov1.AudioService_GenerateAudio_FullMethodName:                "generate_audio",
+	audiov1.AudioService_StreamAudio_FullMethodName:                  "generate_audio",
+	imagev1.ImageService_GenerateImagePrompt_FullMethodName:          "generate_image_prompt",
+	imagev1.ImageService_GenerateImage_FullMethodName:                "generate_image",
+	imagev1.ImageService_GenerateImageAsync_FullMethodName:           "generate_image",
+	factcheckv1.FactCheckService_FactCheckSegment_FullMethodName:     "fact_check",
+}
+
 // AuthUnaryInterceptor returns a gRPC unary interceptor that validates the API key
-// from the "authorization" metadata (Bearer <key>) using auth.Service.
-func AuthUnaryInterceptor(authService *auth.Service) func(context.Context, interface{}, *grpc.UnaryServerInfo, grpc.UnaryHandler) (interface{}, error) {
-	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			return nil, status.Error(codes.Unauthenticated, "missing metadata")
-		}
-		vals := md.Get(metadataKeyAuthorization)
-		if len(vals) == 0 {
-			return nil, status.Error(codes.Unauthenticated, "missing authorization")
-		}
-		authHeader := vals[0]
-		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			return nil, status.Error(codes.Unauthenticated, "invalid authorization format")
+// from the "authorization" metadata (Bearer <key>) using auth.Service, then the request
+// signature (see auth.Sign; target is the full method name, body empty) with verifier. The
+// signature does not cover the request message, so it prevents replays but not tampering.
+// A nil verifier does not check signatures. Methods of tools the key's scopes do not
+// grant fail with PermissionDenied. Calls are charged to the key's quota with quotaService
+// (nil charges nothing) before they run: ResourceExhausted when it is used up.
+func AuthUnaryInterceptor(authService *auth.Service, verifier *auth.SignatureVerifier, quotaService *quota.Service) func(context.Context, interface{}, *grpc.UnaryServerInfo, grpc.UnaryHandler) (interface{}, error) {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, storedKey, err := authenticate(ctx, authService, verifier, info.FullMethod)
+		if err != nil {
+			return nil, err
 		}
-		apiKey := strings.TrimSpace(parts[1])
-		if apiKey == "" {
-			return nil, status.Error(codes.Unauthenticated, "empty api key")
+		if err := chargeCall(ctx, quotaService, storedKey, info.FullMethod, req); err != nil {
+			return nil, err
 		}
-		storedKey, err := authService.ValidateAPIKey(ctx, apiKey)
+		return handler(ctx, req)
+	}
+}
+
+// AuthStreamInterceptor is AuthUnaryInterceptor for streaming methods: the key, signature and scopes are
+// checked when the stream opens, and the call is charged when its request is received.
+func AuthStreamInterceptor(authService *auth.Service, verifier *auth.SignatureVerifier, quotaService *quota.Service) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, storedKey, err := authenticate(ss.Context(), authService, verifier, info.FullMethod)
 		if err != nil {
-			return nil, status.Error(codes.Unauthenticated, "invalid api key")
+			return err
 		}
-		ctx = context.WithValue(ctx, auth.UserIDKey, storedKey.UserID)
-		return handler(ctx, req)
+		return handler(srv, &authStream{ServerStream: ss, ctx: ctx, quota: quotaService, key: storedKey, method: info.FullMethod})
+	}
+}
+
+// authStream gives handlers the authenticated context and charges the first request received.
+type authStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	quota   *quota.Service
+	key     *models.APIKey
+	method  string
+	charged bool
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *authStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if s.charged {
+		return nil
+	}
+	s.charged = true
+	return chargeCall(s.ctx, s.quota, s.key, s.method, m)
+}
+
+// authenticate validates the API key and signature of a call to fullMethod and the key's scope for its
+// tool, and returns ctx with the key's user and ID.
+func authenticate(ctx context.Context, authService *auth.Service, verifier *auth.SignatureVerifier, fullMethod string) (context.Context, *models.APIKey, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, nil, status.Error(codes.Unauthenticated, "missing metadata")
+	}
+	vals := md.Get(metadataKeyAuthorization)
+	if len(vals) == 0 {
+		return nil, nil, status.Error(codes.Unauthenticated, "missing authorization")
+	}
+	authHeader := vals[0]
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		return nil, nil, status.Error(codes.Unauthenticated, "invalid authorization format")
+	}
+	apiKey := strings.TrimSpace(parts[1])
+	if apiKey == "" {
+		return nil, nil, status.Error(codes.Unauthenticated, "empty api key")
+	}
+	storedKey, err := authService.ValidateAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, nil, status.Error(codes.Unauthenticated, "invalid api key")
+	}
+	sig := auth.RequestSignature{
+		Timestamp: firstMetadata(md, strings.ToLower(auth.HeaderSignatureTimestamp)),
+		Nonce:     firstMetadata(md, strings.ToLower(auth.HeaderSignatureNonce)),
+		Signature: firstMetadata(md, strings.ToLower(auth.HeaderSignature)),
+	}
+	if err := verifier.Verify(ctx, apiKey, sig, fullMethod, nil); err != nil {
+		return nil, nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if tool, ok := methodTools[fullMethod]; ok && !storedKey.HasScope(models.AgentToolScope(tool)) {
+		return nil, nil, status.Errorf(codes.PermissionDenied, "api key is not allowed to call %s", tool)
+	}
+	ctx = context.WithValue(ctx, auth.UserIDKey, storedKey.UserID)
+	ctx = context.WithValue(ctx, auth.APIKeyIDKey, storedKey.ID)
+	return ctx, storedKey, nil
+}
+
+// chargeCall charges a call of a tool method to the key's quota; other methods are free.
+func chargeCall(ctx context.Context, quotaService *quota.Service, storedKey *models.APIKey, fullMethod string, req interface{}) error {
+	tool, ok := methodTools[fullMethod]
+	if !ok {
+		return nil
+	}
+	if err := quotaService.ChargeAgentCall(ctx, storedKey.ID, tool, requestChars(req)); err != nil {
+		if errors.Is(err, quota.ErrQuotaExceeded) {
+			return status.Error(codes.ResourceExhausted, err.Error())
+		}
+		log.Error().Err(err).Str("tool", tool).Msg("Failed to charge agent call")
+		return status.Error(codes.Internal, "failed to check quota")
+	}
+	return nil
+}
+
+// requestChars returns the size of a request's text, charged to the caller's quota.
+func requestChars(req interface{}) int64 {
+	switch r := req.(type) {
+	case *segmentationv1.SegmentTextRequest:
+		return int64(len(r.GetText()))
+	case *segmentationv2.SegmentTextRequest:
+		return int64(len(r.GetText()))
+	case *audiov1.GenerateNarrationRequest:
+		return int64(len(r.GetText()))
+	case *audiov1.GenerateAudioRequest: // GenerateAudio and StreamAudio
+		return int64(len(r.GetScript()))
+	case *imagev1.GenerateImagePromptRequest:
+		return int64(len(r.GetText()))
+	case *imagev1.GenerateImageRequest: // GenerateImage and GenerateImageAsync
+		return int64(len(r.GetPrompt()))
+	case *factcheckv1.FactCheckSegmentRequest:
+		return int64(len(r.GetText()))
+	}
+	return 0
+}
+
+// firstMetadata returns the first value of key in md, or "".
+func firstMetadata(md metadata.MD, key string) string {
+	if vals := md.Get(key); len(vals) > 0 {
+		return vals[0]
 	}
+	return ""
 }
 
 // userIDFromContext returns the authenticated user ID from context for artifact paths, or "anonymous" if missing.
@@ -0,0 +1,24 @@
+package grpcserver
+
+import (
+	"context"
+
+	"github.com/snappy-loop/stories/internal/llm"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// metadataKeyCacheControl is the per-call cache control of SegmentText and GenerateImagePrompt:
+// default, no-cache or no-store (see llm.CacheControl).
+const metadataKeyCacheControl = "cache-control"
+
+// withCacheControl applies the call's cache-control metadata to ctx.
+func withCacheControl(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	cc, err := llm.ParseCacheControl(firstMetadata(md, metadataKeyCacheControl))
+	if err != nil {
+		return ctx, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return llm.WithCacheControl(ctx, cc), nil
+}
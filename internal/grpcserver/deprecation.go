@@ -0,0 +1,54 @@
+package grpcserver
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// metadataKeyDeprecated is the response header of calls to deprecated services, naming their successor.
+const metadataKeyDeprecated = "x-stories-deprecated"
+
+// deprecatedServices maps services still served while clients migrate to their successors.
+var deprecatedServices = map[string]string{
+	"segmentation.v1.SegmentationService": "segmentation.v2.SegmentationService",
+}
+
+// deprecationLogged records the API keys and services already logged, so each is logged once per process.
+var deprecationLogged sync.Map
+
+// DeprecationUnaryInterceptor marks calls to deprecated services with the x-stories-deprecated header and
+// logs each API key still calling one (once per key and service), to see who has yet to migrate before
+// the old version is removed. Chain it after AuthUnaryInterceptor.
+func DeprecationUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		service := serviceName(info.FullMethod)
+		successor, ok := deprecatedServices[service]
+		if !ok {
+			return handler(ctx, req)
+		}
+		if err := grpc.SetHeader(ctx, metadata.Pairs(metadataKeyDeprecated, "use "+successor)); err != nil {
+			log.Debug().Err(err).Str("method", info.FullMethod).Msg("Failed to set deprecation header")
+		}
+		keyID, _ := auth.GetAPIKeyID(ctx)
+		if _, logged := deprecationLogged.LoadOrStore(keyID.String()+" "+service, true); !logged {
+			log.Warn().
+				Str("method", info.FullMethod).
+				Str("api_key_id", keyID.String()).
+				Str("successor", successor).
+				Msg("Deprecated gRPC service called")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// serviceName returns the service of a full method name (/segmentation.v1.SegmentationService/SegmentText).
+func serviceName(fullMethod string) string {
+	service, _, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	return service
+}
@@ -2,8 +2,11 @@ package grpcserver
 
 import (
 	"context"
+	"errors"
 
+	"github.com/rs/zerolog/log"
 	"github.com/snappy-loop/stories/internal/agents"
+	"github.com/snappy-loop/stories/internal/llm"
 	factcheckv1 "github.com/snappy-loop/stories/gen/factcheck/v1"
 )
 
@@ -18,11 +21,57 @@ func NewFactCheckServer(agent agents.FactCheckAgent) *FactCheckServer {
 	return &FactCheckServer{agent: agent}
 }
 
-// FactCheckSegment delegates to the fact-check agent.
+// FactCheckSegment delegates to the fact-check agent, returning the checked claims with fact_check_text.
+// When the model's claims cannot be parsed it answers like before claims existed: the text of a plain
+// fact-check and no claims.
 func (s *FactCheckServer) FactCheckSegment(ctx context.Context, req *factcheckv1.FactCheckSegmentRequest) (*factcheckv1.FactCheckSegmentResponse, error) {
-	text, err := s.agent.FactCheckSegment(ctx, req.GetText())
+	fc, err := s.agent.FactCheckClaims(ctx, req.GetText())
+	if errors.Is(err, llm.ErrFactCheckUnparseable) {
+		log.Warn().Err(err).Msg("Fact-check claims unparseable, falling back to the plain fact-check")
+		text, err := s.agent.FactCheckSegment(ctx, req.GetText())
+		if err != nil {
+			return nil, err
+		}
+		return &factcheckv1.FactCheckSegmentResponse{FactCheckText: text}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
-	return &factcheckv1.FactCheckSegmentResponse{FactCheckText: text}, nil
+	resp := &factcheckv1.FactCheckSegmentResponse{
+		FactCheckText: fc.Text,
+		Sources:       sourcesToProto(fc.Sources),
+	}
+	for _, c := range fc.Claims {
+		resp.Claims = append(resp.Claims, &factcheckv1.Claim{
+			Text:        c.Claim,
+			Verdict:     verdictToProto(c.Verdict),
+			Confidence:  float32(c.Confidence),
+			Explanation: c.Explanation,
+			Sources:     sourcesToProto(c.Sources),
+		})
+	}
+	return resp, nil
+}
+
+func verdictToProto(verdict string) factcheckv1.Verdict {
+	switch verdict {
+	case llm.VerdictSupported:
+		return factcheckv1.Verdict_VERDICT_SUPPORTED
+	case llm.VerdictRefuted:
+		return factcheckv1.Verdict_VERDICT_REFUTED
+	case llm.VerdictMisleading:
+		return factcheckv1.Verdict_VERDICT_MISLEADING
+	case llm.VerdictUnverifiable:
+		return factcheckv1.Verdict_VERDICT_UNVERIFIABLE
+	default:
+		return factcheckv1.Verdict_VERDICT_UNSPECIFIED
+	}
+}
+
+func sourcesToProto(sources []llm.FactCheckSource) []*factcheckv1.Source {
+	out := make([]*factcheckv1.Source, len(sources))
+	for i, src := range sources {
+		out[i] = &factcheckv1.Source{Title: src.Title, Url: src.URL}
+	}
+	return out
 }
@@ -0,0 +1,57 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	factcheckv1 "github.com/snappy-loop/stories/gen/factcheck/v1"
+	"github.com/snappy-loop/stories/internal/llm"
+)
+
+type fakeFactCheckAgent struct {
+	claims      *llm.FactCheck
+	claimsErr   error
+	plainCalled bool
+}
+
+func (a *fakeFactCheckAgent) FactCheckSegment(context.Context, string) (string, error) {
+	a.plainCalled = true
+	return "The Moon is not made of cheese.", nil
+}
+
+func (a *fakeFactCheckAgent) FactCheckClaims(context.Context, string) (*llm.FactCheck, error) {
+	return a.claims, a.claimsErr
+}
+
+// TestFactCheckServer_UnparseableClaims asserts v1 clients still get the fact-check text when the grounded
+// claims cannot be parsed.
+func TestFactCheckServer_UnparseableClaims(t *testing.T) {
+	req := &factcheckv1.FactCheckSegmentRequest{Text: "The Moon is made of cheese."}
+
+	agent := &fakeFactCheckAgent{claimsErr: fmt.Errorf("parse fact check: %w: no JSON object in response", llm.ErrFactCheckUnparseable)}
+	resp, err := NewFactCheckServer(agent).FactCheckSegment(context.Background(), req)
+	if err != nil {
+		t.Fatalf("FactCheckSegment: %v", err)
+	}
+	if !agent.plainCalled || resp.GetFactCheckText() != "The Moon is not made of cheese." || len(resp.GetClaims()) != 0 {
+		t.Errorf("response = %v, want the plain fact-check text and no claims", resp)
+	}
+
+	agent = &fakeFactCheckAgent{claims: &llm.FactCheck{Text: "The Moon is rock.", Claims: []llm.FactCheckClaim{
+		{Claim: "The Moon is made of cheese.", Verdict: llm.VerdictRefuted, Confidence: 1, Explanation: "The Moon is rock."},
+	}}}
+	resp, err = NewFactCheckServer(agent).FactCheckSegment(context.Background(), req)
+	if err != nil {
+		t.Fatalf("FactCheckSegment: %v", err)
+	}
+	if agent.plainCalled || resp.GetFactCheckText() != "The Moon is rock." || len(resp.GetClaims()) != 1 ||
+		resp.GetClaims()[0].GetVerdict() != factcheckv1.Verdict_VERDICT_REFUTED {
+		t.Errorf("response = %v, want the parsed claim", resp)
+	}
+
+	agent = &fakeFactCheckAgent{claimsErr: llm.ErrFactCheckNotConfigured}
+	if _, err := NewFactCheckServer(agent).FactCheckSegment(context.Background(), req); err == nil || agent.plainCalled {
+		t.Errorf("other errors: err = %v, fallback = %v; want the error without a fallback", err, agent.plainCalled)
+	}
+}
@@ -3,14 +3,21 @@ package grpcserver
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/agentops"
 	"github.com/snappy-loop/stories/internal/agents"
+	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/models"
 	"github.com/snappy-loop/stories/internal/storage"
 	imagev1 "github.com/snappy-loop/stories/gen/image/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 // ImageServer implements image.v1.ImageServiceServer.
@@ -18,6 +25,7 @@ type ImageServer struct {
 	imagev1.UnimplementedImageServiceServer
 	agent   agents.ImageAgent
 	storage *storage.Client
+	ops     *agentops.Manager
 }
 
 // NewImageServer returns a new ImageServer. storageClient may be nil; then image is returned inline (may hit gRPC size limits).
@@ -25,8 +33,13 @@ func NewImageServer(agent agents.ImageAgent, storageClient *storage.Client) *Ima
 	return &ImageServer{agent: agent, storage: storageClient}
 }
 
-// GenerateImagePrompt delegates to the image agent.
+// GenerateImagePrompt delegates to the image agent. The cache-control metadata sets how the image prompt
+// cache is used.
 func (s *ImageServer) GenerateImagePrompt(ctx context.Context, req *imagev1.GenerateImagePromptRequest) (*imagev1.GenerateImagePromptResponse, error) {
+	ctx, err := withCacheControl(ctx)
+	if err != nil {
+		return nil, err
+	}
 	prompt, err := s.agent.GenerateImagePrompt(ctx, req.GetText(), req.GetInputType())
 	if err != nil {
 		return nil, err
@@ -63,13 +76,14 @@ func (s *ImageServer) GenerateImage(ctx context.Context, req *imagev1.GenerateIm
 	if s.storage != nil && len(data) > 0 {
 		userID := userIDFromContext(ctx)
 		key := "agents/" + userID + "/image/" + uuid.New().String() + imageExtensionForMime(mimeType)
-		if err := s.storage.Upload(ctx, key, bytes.NewReader(data), mimeType, int64(len(data))); err != nil {
+		bucket, err := s.storage.Upload(ctx, key, bytes.NewReader(data), mimeType, int64(len(data)))
+		if err != nil {
 			return nil, fmt.Errorf("upload image to S3: %w", err)
 		}
-		if url := s.storage.PublicURL(key); url != "" {
+		if url := s.storage.PublicURL(bucket, key); url != "" {
 			resp.Url = url
 		} else {
-			url, err := s.storage.GeneratePresignedURL(key, 24*time.Hour)
+			url, err := s.storage.GeneratePresignedURL(bucket, key, 24*time.Hour)
 			if err != nil {
 				return nil, fmt.Errorf("presign image URL: %w", err)
 			}
@@ -81,6 +95,66 @@ func (s *ImageServer) GenerateImage(ctx context.Context, req *imagev1.GenerateIm
 	return resp, nil
 }
 
+// SetOperations enables GenerateImageAsync and GetOperation. nil disables them (Unimplemented).
+func (s *ImageServer) SetOperations(ops *agentops.Manager) {
+	s.ops = ops
+}
+
+// GenerateImageAsync runs GenerateImage in the background and returns its running operation.
+func (s *ImageServer) GenerateImageAsync(ctx context.Context, req *imagev1.GenerateImageRequest) (*imagev1.Operation, error) {
+	if s.ops == nil {
+		return nil, status.Error(codes.Unimplemented, agentops.ErrDisabled.Error())
+	}
+	apiKeyID, err := auth.GetAPIKeyID(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	op, err := s.ops.Start(ctx, apiKeyID, "generate_image", models.AgentProtocolGRPC, func(ctx context.Context) ([]byte, error) {
+		resp, err := s.GenerateImage(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return proto.Marshal(resp)
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &imagev1.Operation{Id: op.ID.String()}, nil
+}
+
+// GetOperation returns an operation of the caller's key started with GenerateImageAsync.
+func (s *ImageServer) GetOperation(ctx context.Context, req *imagev1.GetOperationRequest) (*imagev1.Operation, error) {
+	if s.ops == nil {
+		return nil, status.Error(codes.Unimplemented, agentops.ErrDisabled.Error())
+	}
+	id, err := uuid.Parse(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid operation id")
+	}
+	apiKeyID, err := auth.GetAPIKeyID(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	op, err := s.ops.Get(ctx, apiKeyID, id, models.AgentProtocolGRPC)
+	if errors.Is(err, agentops.ErrNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	out := &imagev1.Operation{Id: op.ID.String(), Done: op.Done()}
+	if op.Error != nil {
+		out.Error = *op.Error
+	}
+	if op.Status == models.AgentOperationSucceeded {
+		out.Result = &imagev1.GenerateImageResponse{}
+		if err := proto.Unmarshal(op.Result, out.Result); err != nil {
+			return nil, status.Error(codes.Internal, "failed to decode operation result")
+		}
+	}
+	return out, nil
+}
+
 func imageExtensionForMime(mime string) string {
 	switch mime {
 	case "image/png":
@@ -18,8 +18,13 @@ func NewSegmentationServer(agent agents.SegmentationAgent) *SegmentationServer {
 	return &SegmentationServer{agent: agent}
 }
 
-// SegmentText delegates to the segmentation agent and maps the response to proto.
+// SegmentText delegates to the segmentation agent and maps the response to proto. The cache-control
+// metadata sets how the boundary cache is used.
 func (s *SegmentationServer) SegmentText(ctx context.Context, req *segmentationv1.SegmentTextRequest) (*segmentationv1.SegmentTextResponse, error) {
+	ctx, err := withCacheControl(ctx)
+	if err != nil {
+		return nil, err
+	}
 	segments, err := s.agent.SegmentText(ctx, req.GetText(), int(req.GetSegmentsCount()), req.GetInputType())
 	if err != nil {
 		return nil, err
@@ -39,3 +44,16 @@ func (s *SegmentationServer) SegmentText(ctx context.Context, req *segmentationv
 	}
 	return &segmentationv1.SegmentTextResponse{Segments: out}, nil
 }
+
+// ExtractContent delegates to the segmentation agent's vision extraction (images and PDFs).
+func (s *SegmentationServer) ExtractContent(ctx context.Context, req *segmentationv1.ExtractContentRequest) (*segmentationv1.ExtractContentResponse, error) {
+	inputType := req.GetInputType()
+	if inputType == "" {
+		inputType = "educational"
+	}
+	text, err := s.agent.ExtractContent(ctx, req.GetData(), req.GetMimeType(), inputType)
+	if err != nil {
+		return nil, err
+	}
+	return &segmentationv1.ExtractContentResponse{Text: text}, nil
+}
@@ -0,0 +1,63 @@
+package grpcserver
+
+import (
+	"context"
+
+	segmentationv2 "github.com/snappy-loop/stories/gen/segmentation/v2"
+	"github.com/snappy-loop/stories/internal/agents"
+)
+
+// SegmentationServerV2 implements segmentation.v2.SegmentationServiceServer, served next to the
+// deprecated v1 while clients migrate.
+type SegmentationServerV2 struct {
+	segmentationv2.UnimplementedSegmentationServiceServer
+	agent agents.SegmentationAgent
+}
+
+// NewSegmentationServerV2 returns a new SegmentationServerV2.
+func NewSegmentationServerV2(agent agents.SegmentationAgent) *SegmentationServerV2 {
+	return &SegmentationServerV2{agent: agent}
+}
+
+// SegmentText delegates to the segmentation agent like v1; segments without a title leave it unset.
+func (s *SegmentationServerV2) SegmentText(ctx context.Context, req *segmentationv2.SegmentTextRequest) (*segmentationv2.SegmentTextResponse, error) {
+	ctx, err := withCacheControl(ctx)
+	if err != nil {
+		return nil, err
+	}
+	segments, err := s.agent.SegmentText(ctx, req.GetText(), int(req.GetSegmentsCount()), inputTypeV2(req.GetInputType()))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*segmentationv2.Segment, len(segments))
+	for i, seg := range segments {
+		out[i] = &segmentationv2.Segment{
+			StartChar: int64(seg.StartChar),
+			EndChar:   int64(seg.EndChar),
+			Title:     seg.Title,
+			Text:      seg.Text,
+		}
+	}
+	return &segmentationv2.SegmentTextResponse{Segments: out}, nil
+}
+
+// ExtractContent delegates to the segmentation agent's vision extraction (images and PDFs).
+func (s *SegmentationServerV2) ExtractContent(ctx context.Context, req *segmentationv2.ExtractContentRequest) (*segmentationv2.ExtractContentResponse, error) {
+	text, err := s.agent.ExtractContent(ctx, req.GetData(), req.GetMimeType(), inputTypeV2(req.GetInputType()))
+	if err != nil {
+		return nil, err
+	}
+	return &segmentationv2.ExtractContentResponse{Text: text}, nil
+}
+
+// inputTypeV2 returns the agents' input type of a v2 InputType (educational when unspecified).
+func inputTypeV2(t segmentationv2.InputType) string {
+	switch t {
+	case segmentationv2.InputType_INPUT_TYPE_FINANCIAL:
+		return "financial"
+	case segmentationv2.InputType_INPUT_TYPE_FICTIONAL:
+		return "fictional"
+	default:
+		return "educational"
+	}
+}
@@ -0,0 +1,83 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+
+	segmentationv1 "github.com/snappy-loop/stories/gen/segmentation/v1"
+	segmentationv2 "github.com/snappy-loop/stories/gen/segmentation/v2"
+	"github.com/snappy-loop/stories/internal/llm"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type fakeSegmentationAgent struct {
+	inputType string
+}
+
+func (a *fakeSegmentationAgent) SegmentText(_ context.Context, text string, _ int, inputType string) ([]*llm.Segment, error) {
+	a.inputType = inputType
+	title := "Intro"
+	return []*llm.Segment{
+		{StartChar: 0, EndChar: 5, Title: &title, Text: text[:5]},
+		{StartChar: 5, EndChar: len(text), Text: text[5:]},
+	}, nil
+}
+
+func (a *fakeSegmentationAgent) ExtractContent(_ context.Context, _ []byte, _, inputType string) (string, error) {
+	a.inputType = inputType
+	return "text", nil
+}
+
+func TestSegmentationServerV2(t *testing.T) {
+	agent := &fakeSegmentationAgent{}
+	s := NewSegmentationServerV2(agent)
+	ctx := context.Background()
+
+	resp, err := s.SegmentText(ctx, &segmentationv2.SegmentTextRequest{Text: "Hello world", SegmentsCount: 2})
+	if err != nil {
+		t.Fatalf("SegmentText: %v", err)
+	}
+	segs := resp.GetSegments()
+	if len(segs) != 2 || segs[0].GetTitle() != "Intro" || segs[1].Title != nil || segs[1].GetEndChar() != 11 {
+		t.Errorf("segments = %v, want the second without a title", segs)
+	}
+	if agent.inputType != "educational" {
+		t.Errorf("unspecified input type = %q, want educational", agent.inputType)
+	}
+
+	if _, err := s.ExtractContent(ctx, &segmentationv2.ExtractContentRequest{InputType: segmentationv2.InputType_INPUT_TYPE_FICTIONAL}); err != nil || agent.inputType != "fictional" {
+		t.Errorf("ExtractContent input type = %q, %v; want fictional", agent.inputType, err)
+	}
+}
+
+type fakeTransportStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (s *fakeTransportStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestDeprecationUnaryInterceptor(t *testing.T) {
+	interceptor := DeprecationUnaryInterceptor()
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+	for _, tc := range []struct {
+		method string
+		want   string
+	}{
+		{segmentationv1.SegmentationService_SegmentText_FullMethodName, "use segmentation.v2.SegmentationService"},
+		{segmentationv2.SegmentationService_SegmentText_FullMethodName, ""},
+	} {
+		stream := &fakeTransportStream{}
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+		if _, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tc.method}, handler); err != nil {
+			t.Fatalf("%s: %v", tc.method, err)
+		}
+		if got := firstMetadata(stream.header, metadataKeyDeprecated); got != tc.want {
+			t.Errorf("%s: %s = %q, want %q", tc.method, metadataKeyDeprecated, got, tc.want)
+		}
+	}
+}
@@ -41,7 +41,7 @@ func (h *Handler) AgentsCall(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if r.Method != http.MethodPost {
-		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		writeJSONError(w, r, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 	var body struct {
@@ -51,15 +51,15 @@ func (h *Handler) AgentsCall(w http.ResponseWriter, r *http.Request) {
 		Params    map[string]interface{} `json:"params"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		writeJSONError(w, http.StatusBadRequest, "invalid JSON")
+		writeJSONError(w, r, http.StatusBadRequest, "invalid JSON")
 		return
 	}
 	if body.APIKey == "" {
-		writeJSONError(w, http.StatusBadRequest, "api_key required")
+		writeJSONError(w, r, http.StatusBadRequest, "api_key required")
 		return
 	}
 	if body.Transport != "grpc" && body.Transport != "mcp" {
-		writeJSONError(w, http.StatusBadRequest, "transport must be grpc or mcp")
+		writeJSONError(w, r, http.StatusBadRequest, "transport must be grpc or mcp")
 		return
 	}
 	if body.Transport == "grpc" && h.agentsGRPCURL == "" {
@@ -75,7 +75,7 @@ func (h *Handler) AgentsCall(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if body.Action == "" {
-		writeJSONError(w, http.StatusBadRequest, "action required")
+		writeJSONError(w, r, http.StatusBadRequest, "action required")
 		return
 	}
 	if body.Params == nil {
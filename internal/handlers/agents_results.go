@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// agentResultURLExpiry is how long presigned URLs of stored agent results work.
+const agentResultURLExpiry = 24 * time.Hour
+
+// agentResultStore stores binary agent results for the agents WebSocket (storage.Client).
+type agentResultStore interface {
+	Upload(ctx context.Context, key string, data io.Reader, contentType string, contentLength int64) (string, error)
+	PublicURL(bucket, key string) string
+	GeneratePresignedURL(bucket, key string, expiration time.Duration) (string, error)
+}
+
+// storeAgentBinaries uploads the inline binary data of an agents response and replaces it with a "url":
+// data_base64 of gRPC responses, and the data of MCP image/audio content blocks or the data_base64 of
+// MCP text blocks holding JSON. The URL of the first stored object (or of an MCP resource_link the agents
+// service stored itself) is also set on the response with its mime_type. Other responses are returned
+// unchanged.
+func storeAgentBinaries(ctx context.Context, store agentResultStore, response interface{}) (interface{}, error) {
+	m, ok := response.(map[string]interface{})
+	if !ok {
+		return response, nil
+	}
+	if _, err := storeInlineData(ctx, store, m, "data_base64", "mime_type"); err != nil {
+		return nil, err
+	}
+	content, _ := m["content"].([]interface{})
+	for _, item := range content {
+		block, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var url, mimeType string
+		var err error
+		switch block["type"] {
+		case "image", "audio":
+			url, err = storeInlineData(ctx, store, block, "data", "mimeType")
+			mimeType, _ = block["mimeType"].(string)
+		case "text":
+			url, mimeType, err = storeTextBlockData(ctx, store, block)
+		case "resource_link":
+			url, _ = block["uri"].(string)
+			mimeType, _ = block["mimeType"].(string)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if url != "" && m["url"] == nil {
+			m["url"] = url
+			m["mime_type"] = mimeType
+		}
+	}
+	return m, nil
+}
+
+// storeTextBlockData stores the data_base64 of a text block holding a JSON object (MCP generate_image
+// results) and rewrites the text with its URL.
+func storeTextBlockData(ctx context.Context, store agentResultStore, block map[string]interface{}) (url, mimeType string, err error) {
+	text, _ := block["text"].(string)
+	var obj map[string]interface{}
+	if json.Unmarshal([]byte(text), &obj) != nil {
+		return "", "", nil
+	}
+	if url, err = storeInlineData(ctx, store, obj, "data_base64", "mime_type"); err != nil || url == "" {
+		return "", "", err
+	}
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return "", "", err
+	}
+	block["text"] = string(b)
+	mimeType, _ = obj["mime_type"].(string)
+	return url, mimeType, nil
+}
+
+// storeInlineData uploads the base64 data of obj[dataKey] with the content type in obj[mimeKey], removes
+// it and sets obj["url"]. It returns "" when obj has no data.
+func storeInlineData(ctx context.Context, store agentResultStore, obj map[string]interface{}, dataKey, mimeKey string) (string, error) {
+	encoded, _ := obj[dataKey].(string)
+	if encoded == "" {
+		return "", nil
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("%s is not valid base64", dataKey)
+	}
+	mimeType, _ := obj[mimeKey].(string)
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	key := "agents/ws/" + uuid.New().String() + agentResultExtension(mimeType)
+	bucket, err := store.Upload(ctx, key, bytes.NewReader(data), mimeType, int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("upload %s: %w", key, err)
+	}
+	url := store.PublicURL(bucket, key)
+	if url == "" {
+		if url, err = store.GeneratePresignedURL(bucket, key, agentResultURLExpiry); err != nil {
+			return "", fmt.Errorf("presign %s: %w", key, err)
+		}
+	}
+	delete(obj, dataKey)
+	obj["url"] = url
+	return url, nil
+}
+
+func agentResultExtension(mimeType string) string {
+	switch mimeType {
+	case "image/png":
+		return ".png"
+	case "image/jpeg", "image/jpg":
+		return ".jpg"
+	case "image/webp":
+		return ".webp"
+	case "audio/mpeg", "audio/mp3":
+		return ".mp3"
+	case "audio/wav":
+		return ".wav"
+	case "audio/webm":
+		return ".webm"
+	default:
+		return ".bin"
+	}
+}
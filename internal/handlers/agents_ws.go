@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -12,6 +13,11 @@ import (
 
 const (
 	agentsWSReadLimit = 64 << 10
+	// agentsWSMaxInFlight bounds the calls a connection runs at once; more are refused until one finishes
+	agentsWSMaxInFlight = 4
+	agentsWSIdleTimeout = 60 * time.Minute
+	agentsWSPingPeriod  = 30 * time.Second
+	agentsWSCallTimeout = 10 * time.Minute
 )
 
 var agentsWSUpgrader = websocket.Upgrader{
@@ -20,90 +26,171 @@ var agentsWSUpgrader = websocket.Upgrader{
 	CheckOrigin:     func(r *http.Request) bool { return true },
 }
 
-// agentsWSInMessage is the JSON shape sent from the client.
+// agentsWSInMessage is the JSON shape sent from the client. ID is optional and echoed in the replies, so
+// clients running several calls at once can match results to calls.
 type agentsWSInMessage struct {
 	Type      string                 `json:"type"`
+	ID        string                 `json:"id,omitempty"`
 	APIKey    string                 `json:"api_key"`
 	Transport string                 `json:"transport"`
 	Action    string                 `json:"action"`
 	Params    map[string]interface{} `json:"params"`
 }
 
-// agentsWSOutMessage is the JSON shape sent to the client.
+// agentsWSOutMessage is the JSON shape sent to the client: "started" when a call is forwarded, then its
+// "result".
 type agentsWSOutMessage struct {
 	Type     string      `json:"type"`
+	ID       string      `json:"id,omitempty"`
 	Request  interface{} `json:"request,omitempty"`
 	Response interface{} `json:"response,omitempty"`
 	Error    string      `json:"error,omitempty"`
 }
 
-// AgentsWS handles GET /agents/ws — WebSocket endpoint for long-running agent calls.
+// agentsWSConn serializes writes to a WebSocket (gorilla allows one writer at a time).
+type agentsWSConn struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+}
+
+func (c *agentsWSConn) write(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return writeWSJSON(c.conn, v)
+}
+
+func (c *agentsWSConn) ping() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second))
+}
+
+// AgentsWS handles GET /agents/ws — WebSocket endpoint for long-running agent calls. Each call message is
+// forwarded to the agents service (agentsclient) in the background, up to agentsWSMaxInFlight at once, and
+// answered with "started" and then "result". Binary results (images, audio returned inline) are uploaded
+// to storage when it is configured and returned as URLs. Calls still running when the connection closes
+// are canceled.
 func (h *Handler) AgentsWS(w http.ResponseWriter, r *http.Request) {
 	if h.agentsClient == nil {
 		http.Error(w, "Agents service not configured", http.StatusServiceUnavailable)
 		return
 	}
-	conn, err := agentsWSUpgrader.Upgrade(w, r, nil)
+	ws, err := agentsWSUpgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Warn().Err(err).Msg("agents ws upgrade failed")
 		return
 	}
-	defer conn.Close()
+	defer ws.Close()
+	conn := &agentsWSConn{conn: ws}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls sync.WaitGroup
+	defer calls.Wait()
+	defer cancel()
 
-	conn.SetReadLimit(agentsWSReadLimit)
-	conn.SetReadDeadline(time.Now().Add(60 * time.Minute))
-	conn.SetPongHandler(func(string) error {
-		conn.SetReadDeadline(time.Now().Add(60 * time.Minute))
+	ws.SetReadLimit(agentsWSReadLimit)
+	ws.SetReadDeadline(time.Now().Add(agentsWSIdleTimeout))
+	ws.SetPongHandler(func(string) error {
+		ws.SetReadDeadline(time.Now().Add(agentsWSIdleTimeout))
 		return nil
 	})
+	go func() {
+		ticker := time.NewTicker(agentsWSPingPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := conn.ping(); err != nil {
+					return
+				}
+			}
+		}
+	}()
 
+	slots := make(chan struct{}, agentsWSMaxInFlight)
 	for {
-		_, raw, err := conn.ReadMessage()
+		_, raw, err := ws.ReadMessage()
 		if err != nil {
-			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
-				return
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Debug().Err(err).Msg("agents ws read")
 			}
-			log.Debug().Err(err).Msg("agents ws read")
 			return
 		}
-		conn.SetReadDeadline(time.Now().Add(60 * time.Minute))
+		ws.SetReadDeadline(time.Now().Add(agentsWSIdleTimeout))
 
 		var in agentsWSInMessage
 		if err := json.Unmarshal(raw, &in); err != nil {
-			_ = writeWSJSON(conn, agentsWSOutMessage{Type: "result", Error: "invalid JSON: " + err.Error()})
-			continue
-		}
-		if in.Type != "call" {
-			_ = writeWSJSON(conn, agentsWSOutMessage{Type: "result", Error: "expected type: call"})
-			continue
-		}
-		if in.APIKey == "" {
-			_ = writeWSJSON(conn, agentsWSOutMessage{Type: "result", Error: "api_key required"})
+			_ = conn.write(agentsWSOutMessage{Type: "result", Error: "invalid JSON: " + err.Error()})
 			continue
 		}
-		if in.Transport != "grpc" && in.Transport != "mcp" {
-			_ = writeWSJSON(conn, agentsWSOutMessage{Type: "result", Error: "transport must be grpc or mcp"})
+		if msg := h.checkAgentsWSCall(&in); msg != "" {
+			_ = conn.write(agentsWSOutMessage{Type: "result", ID: in.ID, Error: msg})
 			continue
 		}
-		if in.Action == "" {
-			_ = writeWSJSON(conn, agentsWSOutMessage{Type: "result", Error: "action required"})
+		select {
+		case slots <- struct{}{}:
+		default:
+			_ = conn.write(agentsWSOutMessage{Type: "result", ID: in.ID, Error: "too many calls in progress"})
 			continue
 		}
-		if in.Params == nil {
-			in.Params = make(map[string]interface{})
+		if err := conn.write(agentsWSOutMessage{Type: "started", ID: in.ID}); err != nil {
+			<-slots
+			return
 		}
-		in.Params["api_key"] = in.APIKey
+		calls.Add(1)
+		go func() {
+			defer calls.Done()
+			defer func() { <-slots }()
+			if err := conn.write(h.agentsWSCall(ctx, in)); err != nil {
+				log.Debug().Err(err).Msg("agents ws write")
+			}
+		}()
+	}
+}
 
-		reqRedacted, response, callErr := h.agentsClient.Call(context.Background(), in.APIKey, in.Transport, in.Action, in.Params)
-		out := agentsWSOutMessage{Type: "result", Request: reqRedacted, Response: response}
-		if callErr != nil {
-			out.Error = callErr.Error()
-		}
-		if err := writeWSJSON(conn, out); err != nil {
-			log.Debug().Err(err).Msg("agents ws write")
-			return
+// checkAgentsWSCall validates a call message and returns why it is refused, or "".
+func (h *Handler) checkAgentsWSCall(in *agentsWSInMessage) string {
+	switch {
+	case in.Type != "call":
+		return "expected type: call"
+	case in.APIKey == "":
+		return "api_key required"
+	case in.Transport != "grpc" && in.Transport != "mcp":
+		return "transport must be grpc or mcp"
+	case in.Transport == "grpc" && h.agentsGRPCURL == "":
+		return "gRPC not configured (AGENTS_GRPC_URL empty)"
+	case in.Transport == "mcp" && h.agentsMCPURL == "":
+		return "MCP not configured (AGENTS_MCP_URL empty)"
+	case in.Action == "":
+		return "action required"
+	}
+	return ""
+}
+
+// agentsWSCall forwards one call to the agents service and builds its result message.
+func (h *Handler) agentsWSCall(ctx context.Context, in agentsWSInMessage) agentsWSOutMessage {
+	ctx, cancel := context.WithTimeout(ctx, agentsWSCallTimeout)
+	defer cancel()
+	if in.Params == nil {
+		in.Params = make(map[string]interface{})
+	}
+	in.Params["api_key"] = in.APIKey
+
+	reqRedacted, response, err := h.agentsClient.Call(ctx, in.APIKey, in.Transport, in.Action, in.Params)
+	out := agentsWSOutMessage{Type: "result", ID: in.ID, Request: reqRedacted, Response: response}
+	if err != nil {
+		out.Error = err.Error()
+		return out
+	}
+	if h.storage != nil {
+		if out.Response, err = storeAgentBinaries(ctx, h.storage, response); err != nil {
+			log.Error().Err(err).Str("action", in.Action).Msg("Failed to store agent result")
+			out.Error = "failed to store result: " + err.Error()
 		}
 	}
+	return out
 }
 
 func writeWSJSON(conn *websocket.Conn, v interface{}) error {
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/snappy-loop/stories/internal/agentsclient"
+)
+
+type fakeAgentResultStore struct {
+	uploads map[string][]byte
+}
+
+func (s *fakeAgentResultStore) Upload(_ context.Context, key string, data io.Reader, _ string, _ int64) (string, error) {
+	b, err := io.ReadAll(data)
+	s.uploads[key] = b
+	return "bucket", err
+}
+
+func (s *fakeAgentResultStore) PublicURL(bucket, key string) string {
+	return "https://cdn.example.com/" + key
+}
+
+func (s *fakeAgentResultStore) GeneratePresignedURL(bucket, key string, _ time.Duration) (string, error) {
+	return "", nil
+}
+
+func TestStoreAgentBinaries(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeAgentResultStore{uploads: map[string][]byte{}}
+	png := base64.StdEncoding.EncodeToString([]byte("png bytes"))
+
+	grpcResp := map[string]interface{}{"mime_type": "image/png", "data_base64": png}
+	got, err := storeAgentBinaries(ctx, store, grpcResp)
+	m := got.(map[string]interface{})
+	if err != nil || m["data_base64"] != nil || !strings.HasSuffix(m["url"].(string), ".png") {
+		t.Fatalf("gRPC response = %v, %v; want the data replaced by a URL", got, err)
+	}
+
+	text, _ := json.Marshal(map[string]interface{}{"mime_type": "image/png", "data_base64": png})
+	mcpResp := map[string]interface{}{"content": []interface{}{
+		map[string]interface{}{"type": "text", "text": string(text)},
+		map[string]interface{}{"type": "audio", "mimeType": "audio/wav", "data": png},
+	}}
+	got, err = storeAgentBinaries(ctx, store, mcpResp)
+	m = got.(map[string]interface{})
+	if err != nil || m["url"] == nil || m["mime_type"] != "image/png" || strings.Contains(m["content"].([]interface{})[0].(map[string]interface{})["text"].(string), "data_base64") {
+		t.Fatalf("MCP response = %v, %v; want the text block's data replaced by a URL", got, err)
+	}
+	if block := m["content"].([]interface{})[1].(map[string]interface{}); block["data"] != nil || block["url"] == nil {
+		t.Errorf("audio block = %v, want its data replaced by a URL", block)
+	}
+	if len(store.uploads) != 3 {
+		t.Errorf("uploads = %d, want 3", len(store.uploads))
+	}
+
+	linked := map[string]interface{}{"content": []interface{}{
+		map[string]interface{}{"type": "resource_link", "uri": "https://s3.example.com/a.png", "mimeType": "image/png"},
+	}}
+	if got, _ := storeAgentBinaries(ctx, store, linked); got.(map[string]interface{})["url"] != "https://s3.example.com/a.png" {
+		t.Errorf("resource_link response = %v, want its uri as url", got)
+	}
+
+	plain := map[string]interface{}{"prompt": "a cat"}
+	if got, err := storeAgentBinaries(ctx, store, plain); err != nil || len(got.(map[string]interface{})) != 1 {
+		t.Errorf("response without binaries = %v, %v; want it unchanged", got, err)
+	}
+}
+
+// TestAgentsWS asserts calls are forwarded to the agents service and answered with started and result
+// messages carrying the call's id, and that invalid calls are refused.
+func TestAgentsWS(t *testing.T) {
+	mcp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"a cat"}],"isError":false}}`)
+	}))
+	defer mcp.Close()
+	client, err := agentsclient.NewClient("", mcp.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	h := NewHandler(&fakeJobService{}, nil, nil, nil, nil, 100000, "monthly", 20, client, "", mcp.URL)
+	srv := httptest.NewServer(http.HandlerFunc(h.AgentsWS))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+
+	read := func() agentsWSOutMessage {
+		t.Helper()
+		var out agentsWSOutMessage
+		if err := conn.ReadJSON(&out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	conn.WriteJSON(agentsWSInMessage{Type: "call", ID: "1", APIKey: "key", Transport: "grpc", Action: "segment_text"})
+	if out := read(); out.Type != "result" || out.ID != "1" || !strings.Contains(out.Error, "gRPC not configured") {
+		t.Errorf("gRPC call without gRPC = %+v, want refused", out)
+	}
+
+	conn.WriteJSON(agentsWSInMessage{Type: "call", ID: "2", APIKey: "key", Transport: "mcp", Action: "generate_image_prompt", Params: map[string]interface{}{"text": "cats"}})
+	if out := read(); out.Type != "started" || out.ID != "2" {
+		t.Errorf("first message = %+v, want started", out)
+	}
+	out := read()
+	if out.Type != "result" || out.ID != "2" || out.Error != "" || out.Response == nil {
+		t.Errorf("result = %+v, want the agents response", out)
+	}
+	if b, _ := json.Marshal(out.Request); strings.Contains(string(b), `"key"`) {
+		t.Errorf("request %s leaks the API key", b)
+	}
+}
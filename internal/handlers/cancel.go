@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/services"
+)
+
+// CancelJob handles POST /v1/jobs/{id}/cancel: a queued, running or awaiting review job becomes canceled
+// (200 with the job); finished jobs get 409.
+func (h *Handler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	jobID, userID, ok := parseReviewRequest(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.jobService.CancelJob(r.Context(), jobID, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrJobNotCancelable) {
+			writeJSONError(w, r, http.StatusConflict, err.Error())
+			return
+		}
+		log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to cancel job")
+		writeJSONError(w, r, http.StatusNotFound, "job not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
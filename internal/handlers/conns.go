@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ConnMetrics tracks the API server's client connections through http.Server.ConnState. Like the other
+// metrics they are reported as structured log fields: http_conns_{open,active,idle} now and
+// http_conns_{accepted,hijacked,closed}_total since start.
+type ConnMetrics struct {
+	mu     sync.Mutex
+	states map[net.Conn]http.ConnState
+	stats  ConnStats
+}
+
+// ConnStats is a snapshot of ConnMetrics. Open connections are active (serving a request; for HTTP/2,
+// at least one stream) or idle (kept alive between requests, or new and not yet read from).
+type ConnStats struct {
+	Active        int
+	Idle          int
+	AcceptedTotal int64
+	HijackedTotal int64 // taken over by the handler, e.g. WebSockets
+	ClosedTotal   int64
+}
+
+// NewConnMetrics creates an empty ConnMetrics.
+func NewConnMetrics() *ConnMetrics {
+	return &ConnMetrics{states: make(map[net.Conn]http.ConnState)}
+}
+
+// ConnState is the http.Server.ConnState hook.
+func (m *ConnMetrics) ConnState(conn net.Conn, state http.ConnState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if prev, ok := m.states[conn]; ok {
+		m.count(prev, -1)
+	}
+	switch state {
+	case http.StateHijacked, http.StateClosed:
+		delete(m.states, conn)
+		if state == http.StateHijacked {
+			m.stats.HijackedTotal++
+		} else {
+			m.stats.ClosedTotal++
+		}
+		return
+	case http.StateNew:
+		m.stats.AcceptedTotal++
+	}
+	m.states[conn] = state
+	m.count(state, 1)
+}
+
+// count adds n to the gauge of state (new connections count as idle).
+func (m *ConnMetrics) count(state http.ConnState, n int) {
+	switch state {
+	case http.StateActive:
+		m.stats.Active += n
+	case http.StateNew, http.StateIdle:
+		m.stats.Idle += n
+	}
+}
+
+// Stats returns the current counts.
+func (m *ConnMetrics) Stats() ConnStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+// Run logs the counts every interval until ctx is done.
+func (m *ConnMetrics) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s := m.Stats()
+			log.Info().
+				Int("http_conns_open", s.Active+s.Idle).
+				Int("http_conns_active", s.Active).
+				Int("http_conns_idle", s.Idle).
+				Int64("http_conns_accepted_total", s.AcceptedTotal).
+				Int64("http_conns_hijacked_total", s.HijackedTotal).
+				Int64("http_conns_closed_total", s.ClosedTotal).
+				Msg("HTTP connections")
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestConnMetrics(t *testing.T) {
+	m := NewConnMetrics()
+	a, _ := net.Pipe()
+	b, _ := net.Pipe()
+
+	m.ConnState(a, http.StateNew)
+	m.ConnState(b, http.StateNew)
+	m.ConnState(a, http.StateActive)
+	m.ConnState(b, http.StateActive)
+	m.ConnState(a, http.StateIdle)
+	if got, want := m.Stats(), (ConnStats{Active: 1, Idle: 1, AcceptedTotal: 2}); got != want {
+		t.Errorf("stats = %+v, want %+v", got, want)
+	}
+
+	m.ConnState(b, http.StateHijacked)
+	m.ConnState(a, http.StateClosed)
+	if got, want := m.Stats(), (ConnStats{AcceptedTotal: 2, HijackedTotal: 1, ClosedTotal: 1}); got != want {
+		t.Errorf("stats = %+v, want %+v", got, want)
+	}
+}
+
+// TestConnMetrics_H2C asserts an h2c server serves HTTP/2 clients and tracks their connection.
+func TestConnMetrics_H2C(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	m := NewConnMetrics()
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+	var active int
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			active = m.Stats().Active
+		}),
+		Protocols: protocols,
+		ConnState: m.ConnState,
+	}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	clientProtocols := new(http.Protocols)
+	clientProtocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: clientProtocols}}
+	resp, err := client.Get("http://" + ln.Addr().String())
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		t.Errorf("protocol = %s, want HTTP/2", resp.Proto)
+	}
+	if active != 1 {
+		t.Errorf("active connections during the request = %d, want 1", active)
+	}
+	if got := m.Stats().AcceptedTotal; got != 1 {
+		t.Errorf("accepted = %d, want 1", got)
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/services"
+)
+
+// SetDisclaimerService sets the service behind the /admin/disclaimers endpoints, authenticated with the
+// admin API token.
+func (h *Handler) SetDisclaimerService(s *services.DisclaimerService, adminToken string) {
+	h.disclaimerService = s
+	h.adminAPIToken = adminToken
+}
+
+// adminAuthorized checks the admin API token of r, writing the error response when it is missing or
+// wrong. The admin API is not found when no token is configured.
+func (h *Handler) adminAuthorized(w http.ResponseWriter, r *http.Request) bool {
+	if h.disclaimerService == nil || h.adminAPIToken == "" {
+		writeJSONError(w, r, http.StatusNotFound, "admin API not enabled")
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.adminAPIToken)) != 1 {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return false
+	}
+	return true
+}
+
+// ListDisclaimers handles GET /admin/disclaimers: the active disclaimer of each jurisdiction.
+func (h *Handler) ListDisclaimers(w http.ResponseWriter, r *http.Request) {
+	if !h.adminAuthorized(w, r) {
+		return
+	}
+	disclaimers, err := h.disclaimerService.ListDisclaimers(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list disclaimers")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to list disclaimers")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"disclaimers": disclaimers})
+}
+
+// ListDisclaimerVersions handles GET /admin/disclaimers/{jurisdiction}: every version, oldest first.
+func (h *Handler) ListDisclaimerVersions(w http.ResponseWriter, r *http.Request) {
+	if !h.adminAuthorized(w, r) {
+		return
+	}
+	versions, err := h.disclaimerService.DisclaimerVersions(r.Context(), mux.Vars(r)["jurisdiction"])
+	if err != nil {
+		if errors.Is(err, database.ErrDisclaimerNotFound) {
+			writeJSONError(w, r, http.StatusNotFound, "disclaimer not found")
+			return
+		}
+		log.Error().Err(err).Msg("Failed to list disclaimer versions")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to list disclaimer versions")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"versions": versions})
+}
+
+// PutDisclaimer handles PUT /admin/disclaimers/{jurisdiction}: saves the text as the jurisdiction's next
+// disclaimer version, applied to financial jobs processed from now on.
+func (h *Handler) PutDisclaimer(w http.ResponseWriter, r *http.Request) {
+	if !h.adminAuthorized(w, r) {
+		return
+	}
+	var req models.DisclaimerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	disclaimer, err := h.disclaimerService.SaveDisclaimer(r.Context(), mux.Vars(r)["jurisdiction"], &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidDisclaimer) {
+			writeJSONError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Error().Err(err).Msg("Failed to save disclaimer")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to save disclaimer")
+		return
+	}
+	writeJSON(w, http.StatusCreated, disclaimer)
+}
+
+// RetireDisclaimer handles DELETE /admin/disclaimers/{jurisdiction}: the jurisdiction's jobs get the
+// DEFAULT disclaimer from now on; jobs already processed keep theirs.
+func (h *Handler) RetireDisclaimer(w http.ResponseWriter, r *http.Request) {
+	if !h.adminAuthorized(w, r) {
+		return
+	}
+	if err := h.disclaimerService.RetireDisclaimer(r.Context(), mux.Vars(r)["jurisdiction"]); err != nil {
+		if errors.Is(err, database.ErrDisclaimerNotFound) {
+			writeJSONError(w, r, http.StatusNotFound, "disclaimer not found")
+			return
+		}
+		log.Error().Err(err).Msg("Failed to retire disclaimer")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to retire disclaimer")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
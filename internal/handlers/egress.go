@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/services"
+)
+
+// egressAllowlistBody is the request and response body of /v1/egress-allowlist
+type egressAllowlistBody struct {
+	Hosts []string `json:"hosts"`
+}
+
+// GetEgressAllowlist handles GET /v1/egress-allowlist: the hosts the user's job webhooks and notification
+// sinks may target (empty: any host the server allows).
+func (h *Handler) GetEgressAllowlist(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	hosts, err := h.jobService.GetEgressAllowlist(r.Context(), userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get egress allow list")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to get egress allow list")
+		return
+	}
+	writeJSON(w, http.StatusOK, egressAllowlistBody{Hosts: hosts})
+}
+
+// UpdateEgressAllowlist handles PUT /v1/egress-allowlist with {"hosts": [...]}, replacing the user's allow
+// list. Each host also allows its subdomains. Pending webhook and sink deliveries to hosts no longer
+// allowed fail on their next attempt.
+func (h *Handler) UpdateEgressAllowlist(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req egressAllowlistBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	hosts, err := h.jobService.UpdateEgressAllowlist(r.Context(), userID, req.Hosts)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidEgressAllowlist) {
+			writeJSONError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Error().Err(err).Msg("Failed to update egress allow list")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to update egress allow list")
+		return
+	}
+	writeJSON(w, http.StatusOK, egressAllowlistBody{Hosts: hosts})
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/features"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/services"
+)
+
+// SetFeatureFlags sets the feature flags consulted when jobs are created and the service behind the
+// /admin/feature-flags endpoints (which also need the admin API token, see SetDisclaimerService).
+func (h *Handler) SetFeatureFlags(flags *features.Flags, s *services.FeatureFlagService) {
+	h.features = flags
+	h.featureFlagService = s
+}
+
+// featuresAvailable refuses requests asking for a pipeline step whose flag is off for the user, writing
+// the error response.
+func (h *Handler) featuresAvailable(w http.ResponseWriter, r *http.Request, userID uuid.UUID, factCheckNeeded *bool) bool {
+	if factCheckNeeded != nil && *factCheckNeeded && !h.features.Enabled(r.Context(), features.FactCheck, userID) {
+		writeJSONError(w, r, http.StatusBadRequest, "fact_check_needed is not available for this account")
+		return false
+	}
+	return true
+}
+
+// ListFeatureFlags handles GET /admin/feature-flags: every flag with its rollout, where the rollout comes
+// from and its per-user overrides.
+func (h *Handler) ListFeatureFlags(w http.ResponseWriter, r *http.Request) {
+	if !h.adminAuthorized(w, r) || !h.featureFlagsEnabled(w, r) {
+		return
+	}
+	flags, err := h.featureFlagService.ListFeatureFlags(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list feature flags")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to list feature flags")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"feature_flags": flags})
+}
+
+// PutFeatureFlag handles PUT /admin/feature-flags/{name}: sets the share of users the flag is enabled for.
+func (h *Handler) PutFeatureFlag(w http.ResponseWriter, r *http.Request) {
+	if !h.adminAuthorized(w, r) || !h.featureFlagsEnabled(w, r) {
+		return
+	}
+	var req models.FeatureFlagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := h.featureFlagService.SetRollout(r.Context(), mux.Vars(r)["name"], &req); err != nil {
+		if errors.Is(err, services.ErrInvalidFeatureFlag) {
+			writeJSONError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Error().Err(err).Msg("Failed to save feature flag")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to save feature flag")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteFeatureFlag handles DELETE /admin/feature-flags/{name}: the flag's FEATURE_FLAGS rollout (or
+// default) applies again. Per-user overrides are kept.
+func (h *Handler) DeleteFeatureFlag(w http.ResponseWriter, r *http.Request) {
+	if !h.adminAuthorized(w, r) || !h.featureFlagsEnabled(w, r) {
+		return
+	}
+	if err := h.featureFlagService.ResetRollout(r.Context(), mux.Vars(r)["name"]); err != nil {
+		h.writeFeatureFlagError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PutFeatureFlagOverride handles PUT /admin/feature-flags/{name}/users/{user_id}: turns the flag on or
+// off for the user whatever its rollout.
+func (h *Handler) PutFeatureFlagOverride(w http.ResponseWriter, r *http.Request) {
+	if !h.adminAuthorized(w, r) || !h.featureFlagsEnabled(w, r) {
+		return
+	}
+	userID, err := uuid.Parse(mux.Vars(r)["user_id"])
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid user id")
+		return
+	}
+	var req models.FeatureFlagOverrideRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := h.featureFlagService.SetOverride(r.Context(), mux.Vars(r)["name"], userID, &req); err != nil {
+		h.writeFeatureFlagError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteFeatureFlagOverride handles DELETE /admin/feature-flags/{name}/users/{user_id}: the user follows
+// the flag's rollout again.
+func (h *Handler) DeleteFeatureFlagOverride(w http.ResponseWriter, r *http.Request) {
+	if !h.adminAuthorized(w, r) || !h.featureFlagsEnabled(w, r) {
+		return
+	}
+	userID, err := uuid.Parse(mux.Vars(r)["user_id"])
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid user id")
+		return
+	}
+	if err := h.featureFlagService.DeleteOverride(r.Context(), mux.Vars(r)["name"], userID); err != nil {
+		h.writeFeatureFlagError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) featureFlagsEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.featureFlagService == nil {
+		writeJSONError(w, r, http.StatusNotFound, "admin API not enabled")
+		return false
+	}
+	return true
+}
+
+func (h *Handler) writeFeatureFlagError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidFeatureFlag):
+		writeJSONError(w, r, http.StatusBadRequest, err.Error())
+	case errors.Is(err, database.ErrFeatureFlagNotFound):
+		writeJSONError(w, r, http.StatusNotFound, "feature flag not found")
+	case errors.Is(err, database.ErrFeatureFlagUserNotFound):
+		writeJSONError(w, r, http.StatusNotFound, "user not found")
+	default:
+		log.Error().Err(err).Msg("Failed to update feature flag")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to update feature flag")
+	}
+}
@@ -1,32 +1,38 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/rs/zerolog/log"
 	"github.com/snappy-loop/stories/internal/auth"
 	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/services"
 )
 
-// UploadFile handles POST /v1/files (multipart/form-data, field name: file)
+// UploadFile handles POST /v1/files (multipart/form-data, field name: file). Optional form fields language,
+// handwriting and preserve_tables set the file's default extraction options.
 func (h *Handler) UploadFile(w http.ResponseWriter, r *http.Request) {
 	userID, err := auth.GetUserID(r.Context())
 	if err != nil {
-		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	const maxMemory = 32 << 20 // 32MB
 	if err := r.ParseMultipartForm(maxMemory); err != nil {
-		writeJSONError(w, http.StatusBadRequest, "failed to parse multipart form")
+		writeJSONError(w, r, http.StatusBadRequest, "failed to parse multipart form")
 		return
 	}
 
 	file, header, err := r.FormFile("file")
 	if err != nil {
-		writeJSONError(w, http.StatusBadRequest, "missing or invalid file field (use form field name: file)")
+		writeJSONError(w, r, http.StatusBadRequest, "missing or invalid file field (use form field name: file)")
 		return
 	}
 	defer file.Close()
@@ -40,67 +46,148 @@ func (h *Handler) UploadFile(w http.ResponseWriter, r *http.Request) {
 		mimeType = "application/octet-stream"
 	}
 
+	opts := &models.ExtractionOptions{Language: r.FormValue("language")}
+	for field, dst := range map[string]*bool{"handwriting": &opts.Handwriting, "preserve_tables": &opts.PreserveTables} {
+		if v := r.FormValue(field); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				writeJSONError(w, r, http.StatusBadRequest, field+" must be true or false")
+				return
+			}
+			*dst = b
+		}
+	}
+
 	// Size is enforced by the service from the stream (client-reported size is not trusted)
-	resp, err := h.fileService.UploadFile(r.Context(), userID, filename, mimeType, file)
+	resp, err := h.fileService.UploadFile(r.Context(), userID, filename, mimeType, file, opts)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to upload file")
-		writeJSONError(w, http.StatusBadRequest, err.Error())
+		writeJSONError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	writeJSON(w, http.StatusCreated, resp)
 }
 
-// ListFiles handles GET /v1/files?status=ready
+// ListFiles handles GET /v1/files?status=ready&limit=20&cursor=... (newest first; pass next_cursor as
+// cursor while has_more is true)
 func (h *Handler) ListFiles(w http.ResponseWriter, r *http.Request) {
 	userID, err := auth.GetUserID(r.Context())
 	if err != nil {
-		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
-	status := r.URL.Query().Get("status")
+	limit := 20
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil {
+			limit = parsed
+		}
+	}
 
-	files, err := h.fileService.ListFiles(r.Context(), userID, status)
+	page, err := h.fileService.ListFiles(r.Context(), userID, r.URL.Query().Get("status"), limit, r.URL.Query().Get("cursor"))
 	if err != nil {
+		if strings.HasPrefix(err.Error(), "validation error") {
+			writeJSONError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
 		log.Error().Err(err).Msg("Failed to list files")
-		writeJSONError(w, http.StatusInternalServerError, "failed to list files")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to list files")
 		return
 	}
 
-	out := make([]models.FileInResponse, len(files))
-	for i, f := range files {
-		out[i] = f.ToInResponse()
-	}
-	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"files": out,
-	})
+	writeJSON(w, http.StatusOK, page)
 }
 
 // DeleteFile handles DELETE /v1/files/{id}
 func (h *Handler) DeleteFile(w http.ResponseWriter, r *http.Request) {
 	userID, err := auth.GetUserID(r.Context())
 	if err != nil {
-		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	vars := mux.Vars(r)
 	fileID, err := uuid.Parse(vars["id"])
 	if err != nil {
-		writeJSONError(w, http.StatusBadRequest, "invalid file id")
+		writeJSONError(w, r, http.StatusBadRequest, "invalid file id")
 		return
 	}
 
 	if err := h.fileService.DeleteFile(r.Context(), fileID, userID); err != nil {
 		if err.Error() == "file not found" {
-			writeJSONError(w, http.StatusNotFound, "file not found")
+			writeJSONError(w, r, http.StatusNotFound, "file not found")
+			return
+		}
+		if errors.Is(err, services.ErrFileInUse) {
+			writeJSONError(w, r, http.StatusConflict, err.Error())
 			return
 		}
 		log.Error().Err(err).Msg("Failed to delete file")
-		writeJSONError(w, http.StatusInternalServerError, "failed to delete file")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to delete file")
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// PresignFile handles POST /v1/files/presign: creates a pending file and returns a presigned PUT that
+// uploads it straight to S3 (201). Confirm it with POST /v1/files/{id}/confirm once uploaded.
+func (h *Handler) PresignFile(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.PresignFileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	resp, err := h.fileService.PresignUpload(r.Context(), userID, &req)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "validation error") {
+			writeJSONError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Error().Err(err).Msg("Failed to presign file upload")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to presign upload")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// ConfirmFile handles POST /v1/files/{id}/confirm: verifies the object of a presigned upload and marks the
+// file ready (200 with the file). 409 while the object is missing or when it failed verification.
+func (h *Handler) ConfirmFile(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	fileID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid file id")
+		return
+	}
+
+	resp, err := h.fileService.ConfirmUpload(r.Context(), fileID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrFileNotUploaded), errors.Is(err, services.ErrFileUploadFailed):
+			writeJSONError(w, r, http.StatusConflict, err.Error())
+		case err.Error() == "file not found":
+			writeJSONError(w, r, http.StatusNotFound, "file not found")
+		default:
+			log.Error().Err(err).Str("file_id", fileID.String()).Msg("Failed to confirm file upload")
+			writeJSONError(w, r, http.StatusInternalServerError, "failed to confirm upload")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/services"
+)
+
+// maxS3EventBody limits the body of S3 event notifications
+const maxS3EventBody = 1 << 20
+
+// SetIngestService sets the service behind POST /ingest/s3-events, authenticated with token.
+func (h *Handler) SetIngestService(s *services.IngestService, token string) {
+	h.ingestService = s
+	h.ingestWebhookToken = token
+}
+
+// IngestS3Events handles POST /ingest/s3-events: S3 (or MinIO) bucket event notifications for objects
+// created under the ingestion prefix. Requests carry "Authorization: Bearer <INGEST_WEBHOOK_TOKEN>".
+// Transient failures return 500 so the sender redelivers the event.
+func (h *Handler) IngestS3Events(w http.ResponseWriter, r *http.Request) {
+	if h.ingestService == nil || h.ingestWebhookToken == "" {
+		writeJSONError(w, r, http.StatusNotFound, "ingestion not enabled")
+		return
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.ingestWebhookToken)) != 1 {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	// The sender redelivers the event after Retry-After
+	if !h.intakeOpen(w, r) {
+		return
+	}
+
+	var ev models.S3EventNotification
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxS3EventBody)).Decode(&ev); err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid S3 event notification")
+		return
+	}
+	ingested, err := h.ingestService.HandleS3Event(r.Context(), &ev)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to ingest objects from S3 event")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to ingest objects")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"ingested": ingested})
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/services"
+)
+
+// DeleteJob handles DELETE /v1/jobs/{id}: a finished job is deleted with its segments, assets and webhook
+// deliveries (204); its S3 objects are deleted in the background. Unfinished jobs and jobs other jobs
+// duplicate get 409.
+func (h *Handler) DeleteJob(w http.ResponseWriter, r *http.Request) {
+	jobID, userID, ok := parseReviewRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.jobService.DeleteJob(r.Context(), jobID, userID); err != nil {
+		if errors.Is(err, services.ErrJobNotDeletable) || errors.Is(err, services.ErrJobHasDuplicates) {
+			writeJSONError(w, r, http.StatusConflict, err.Error())
+			return
+		}
+		log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to delete job")
+		writeJSONError(w, r, http.StatusNotFound, "job not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/services"
+)
+
+// UpdateJob handles PATCH /v1/jobs/{id}: sets or changes the webhook of a job that has not finished (200
+// with the job). Finished jobs get 409, expired ones 410.
+func (h *Handler) UpdateJob(w http.ResponseWriter, r *http.Request) {
+	jobID, userID, ok := parseReviewRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	resp, err := h.jobService.UpdateJob(r.Context(), jobID, userID, &req)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "validation error"):
+			writeJSONError(w, r, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrJobFinished):
+			writeJSONError(w, r, http.StatusConflict, err.Error())
+		case errors.Is(err, services.ErrJobExpired):
+			writeJSONError(w, r, http.StatusGone, "job expired")
+		default:
+			log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to update job")
+			writeJSONError(w, r, http.StatusNotFound, "job not found")
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
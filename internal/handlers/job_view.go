@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// jobResponseSections are the top-level keys of a GetJob response that can be selected with ?fields=.
+var jobResponseSections = map[string]bool{
+	"job":         true,
+	"segments":    true,
+	"assets":      true,
+	"files":       true,
+	"fact_checks": true,
+	"stats":       true,
+}
+
+// jobResponseHeavyFields are the large text fields that can be dropped with ?exclude=.
+// ?view=summary excludes all of them.
+var jobResponseHeavyFields = map[string]bool{
+	"input_text":     true,
+	"extracted_text": true,
+	"output_markup":  true,
+	"segment_text":   true,
+}
+
+// jobViewOptions controls which parts of a GetJob response are returned.
+type jobViewOptions struct {
+	fields  map[string]bool // selected sections; nil means all
+	exclude map[string]bool // heavy text fields to drop
+}
+
+// parseJobViewOptions reads view, fields and exclude query parameters.
+// Unknown names are rejected so that typos don't silently return the full payload.
+func parseJobViewOptions(q url.Values) (*jobViewOptions, error) {
+	opts := &jobViewOptions{exclude: make(map[string]bool)}
+
+	switch view := q.Get("view"); view {
+	case "", "full":
+	case "summary":
+		for f := range jobResponseHeavyFields {
+			opts.exclude[f] = true
+		}
+	default:
+		return nil, fmt.Errorf("invalid view: must be full or summary")
+	}
+
+	if raw := q.Get("fields"); raw != "" {
+		opts.fields = make(map[string]bool)
+		for _, f := range splitCommaList(raw) {
+			if !jobResponseSections[f] {
+				return nil, fmt.Errorf("invalid fields value: %s", f)
+			}
+			opts.fields[f] = true
+		}
+	}
+
+	if raw := q.Get("exclude"); raw != "" {
+		for _, f := range splitCommaList(raw) {
+			if !jobResponseHeavyFields[f] {
+				return nil, fmt.Errorf("invalid exclude value: %s", f)
+			}
+			opts.exclude[f] = true
+		}
+	}
+
+	return opts, nil
+}
+
+// splitCommaList splits a comma-separated query value, trimming spaces and dropping empty items.
+func splitCommaList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// isFull reports whether the options leave the response unchanged.
+func (o *jobViewOptions) isFull() bool {
+	return o.fields == nil && len(o.exclude) == 0
+}
+
+// apply returns the response body to encode: resp itself for the full view, otherwise a map with
+// only the selected sections and the excluded text fields removed. resp is not modified.
+func (o *jobViewOptions) apply(resp *models.JobStatusResponse) interface{} {
+	if o.isFull() {
+		return resp
+	}
+
+	job := resp.Job
+	if o.exclude["input_text"] {
+		job.InputText = ""
+	}
+	if o.exclude["extracted_text"] {
+		job.ExtractedText = nil
+	}
+	if o.exclude["output_markup"] {
+		job.OutputMarkup = nil
+	}
+
+	segments := resp.Segments
+	if o.exclude["segment_text"] {
+		segments = make([]*models.Segment, len(resp.Segments))
+		for i, seg := range resp.Segments {
+			s := *seg
+			s.SegmentText = ""
+			segments[i] = &s
+		}
+	}
+
+	files := resp.Files
+	if o.exclude["extracted_text"] {
+		files = make([]*models.JobFileResponse, len(resp.Files))
+		for i, f := range resp.Files {
+			c := *f
+			c.ExtractedText = nil
+			files[i] = &c
+		}
+	}
+
+	out := make(map[string]interface{})
+	if o.wants("job") {
+		out["job"] = job
+	}
+	if o.wants("segments") {
+		out["segments"] = segments
+		out["segments_total"] = resp.SegmentsTotal
+		if resp.SegmentsNextCursor != "" {
+			out["segments_next_cursor"] = resp.SegmentsNextCursor
+		}
+	}
+	if o.wants("assets") {
+		out["assets"] = resp.Assets
+		out["assets_total"] = resp.AssetsTotal
+		if resp.AssetsNextCursor != "" {
+			out["assets_next_cursor"] = resp.AssetsNextCursor
+		}
+	}
+	if o.wants("files") {
+		out["files"] = files
+	}
+	if o.wants("fact_checks") && len(resp.FactChecks) > 0 {
+		out["fact_checks"] = resp.FactChecks
+	}
+	if o.wants("stats") && resp.Stats != nil {
+		out["stats"] = resp.Stats
+	}
+	return out
+}
+
+func (o *jobViewOptions) wants(section string) bool {
+	return o.fields == nil || o.fields[section]
+}
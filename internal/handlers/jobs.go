@@ -3,11 +3,13 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html"
 	"html/template"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -18,20 +20,48 @@ import (
 	"github.com/snappy-loop/stories/internal/agentsclient"
 	"github.com/snappy-loop/stories/internal/auth"
 	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/features"
+	"github.com/snappy-loop/stories/internal/i18n"
+	"github.com/snappy-loop/stories/internal/maintenance"
 	"github.com/snappy-loop/stories/internal/markup"
 	"github.com/snappy-loop/stories/internal/models"
 	"github.com/snappy-loop/stories/internal/services"
 	"github.com/snappy-loop/stories/internal/storage"
+	"github.com/snappy-loop/stories/internal/teleprompter"
 )
 
 // jobService is the subset of JobService used by job handlers (for testability).
 type jobService interface {
 	CreateJob(ctx context.Context, req *models.CreateJobRequest, userID, apiKeyID uuid.UUID) (*models.CreateJobResponse, error)
+	CloneJob(ctx context.Context, sourceID, userID, apiKeyID uuid.UUID, req *models.CloneJobRequest) (*models.CreateJobResponse, error)
 	GetJob(ctx context.Context, jobID, userID uuid.UUID) (*models.JobStatusResponse, error)
 	GetJobByID(ctx context.Context, jobID uuid.UUID) (*models.JobStatusResponse, error)
-	ListJobs(ctx context.Context, userID uuid.UUID, limit int, cursor *time.Time) ([]*models.Job, error)
+	ListSegments(ctx context.Context, jobID, userID uuid.UUID, limit int, cursor string) (*models.SegmentPage, error)
+	ListAssets(ctx context.Context, jobID, userID uuid.UUID, limit int, cursor string) (*models.AssetPage, error)
+	ListJobEvents(ctx context.Context, jobID, userID uuid.UUID) ([]*models.JobEvent, error)
+	ListJobs(ctx context.Context, userID uuid.UUID, limit int, cursor string, filter models.JobListFilter) (*models.JobPage, error)
 	GetAsset(ctx context.Context, assetID, userID uuid.UUID) (*models.Asset, error)
 	GetAssetByJobID(ctx context.Context, assetID, jobID uuid.UUID) (*models.Asset, error)
+	GetPodcastFeed(ctx context.Context, jobID uuid.UUID) (*models.Asset, error)
+	CancelJob(ctx context.Context, jobID, userID uuid.UUID) (*models.JobStatusResponse, error)
+	RetryJob(ctx context.Context, jobID, userID uuid.UUID) (*models.JobStatusResponse, error)
+	UpdateJob(ctx context.Context, jobID, userID uuid.UUID, req *models.UpdateJobRequest) (*models.JobStatusResponse, error)
+	DeleteJob(ctx context.Context, jobID, userID uuid.UUID) error
+	ApproveJob(ctx context.Context, jobID, userID uuid.UUID, req *models.ReviewApproval) (*models.JobStatusResponse, error)
+	RequestSegmentRegeneration(ctx context.Context, jobID, userID uuid.UUID, req *models.ReviewRegeneration) (*models.JobStatusResponse, error)
+	UpdateSegment(ctx context.Context, jobID, userID uuid.UUID, idx int, req *models.UpdateSegmentRequest) (*models.Segment, error)
+	ReplaceAssetContent(ctx context.Context, assetID, userID uuid.UUID, mimeType string, data io.Reader) (*models.Asset, error)
+	ListOutputVersions(ctx context.Context, jobID, userID uuid.UUID) ([]*models.OutputVersion, error)
+	GetOutputVersion(ctx context.Context, jobID, userID uuid.UUID, version int) (*models.OutputVersion, error)
+	RestoreOutputVersion(ctx context.Context, jobID, userID uuid.UUID, version int) (*models.OutputVersion, error)
+	GetScript(ctx context.Context, jobID, userID uuid.UUID) (*teleprompter.Script, error)
+	NarrationDiff(ctx context.Context, jobID, userID uuid.UUID) ([]*models.SegmentNarrationDiff, error)
+	GetSettings(ctx context.Context, userID uuid.UUID) (*models.UserSettings, error)
+	UpdateSettings(ctx context.Context, userID uuid.UUID, settings *models.UserSettings) (*models.UserSettings, error)
+	GetWebhookDelivery(ctx context.Context, deliveryID, userID uuid.UUID) (*models.WebhookDeliveryDetail, error)
+	RedeliverWebhook(ctx context.Context, deliveryID, userID uuid.UUID) (*models.WebhookDelivery, error)
+	GetEgressAllowlist(ctx context.Context, userID uuid.UUID) ([]string, error)
+	UpdateEgressAllowlist(ctx context.Context, userID uuid.UUID, hosts []string) ([]string, error)
 }
 
 // Handler contains all HTTP handlers
@@ -47,6 +77,20 @@ type Handler struct {
 	agentsClient       *agentsclient.Client
 	agentsGRPCURL      string
 	agentsMCPURL       string
+
+	notificationService *services.NotificationService
+	voiceService        *services.VoiceService
+	ingestService       *services.IngestService
+	ingestWebhookToken  string
+	disclaimerService   *services.DisclaimerService
+	adminAPIToken       string
+	features            *features.Flags // nil uses the built-in flag defaults
+	featureFlagService  *services.FeatureFlagService
+	maintenance         *maintenance.Switch // nil never pauses job intake
+	maintenanceService  *services.MaintenanceService
+	lazyImages          *services.LazyImageService // nil: jobs cannot use lazy_images
+	statsService        *services.StatsService
+	usageExportService  *services.UsageExportService
 }
 
 // NewHandler creates a new handler. agentsClient may be nil if the agents service is not configured.
@@ -123,7 +167,7 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		Email *string `json:"email"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSONError(w, http.StatusBadRequest, "invalid request body")
+		writeJSONError(w, r, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
@@ -134,14 +178,14 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	}
 	if err := h.userRepo.Create(r.Context(), user); err != nil {
 		log.Error().Err(err).Msg("Failed to create user")
-		writeJSONError(w, http.StatusInternalServerError, "failed to create user")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to create user")
 		return
 	}
 
 	plainKey, _, err := h.apiKeyRepo.CreateAPIKey(r.Context(), user.ID, h.defaultQuotaChars, h.defaultQuotaPeriod)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to create API key")
-		writeJSONError(w, http.StatusInternalServerError, "failed to create API key")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to create API key")
 		return
 	}
 
@@ -157,20 +201,23 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) CreateJob(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateJobRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSONError(w, http.StatusBadRequest, "invalid request body")
+		writeJSONError(w, r, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	// Get user ID from context
 	userID, err := auth.GetUserID(r.Context())
 	if err != nil {
-		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	apiKeyID, err := auth.GetAPIKeyID(r.Context())
 	if err != nil {
-		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !h.intakeOpen(w, r) || !h.featuresAvailable(w, r, userID, req.FactCheckNeeded) || !h.lazyImagesAvailable(w, r, req.LazyImages) {
 		return
 	}
 
@@ -178,7 +225,51 @@ func (h *Handler) CreateJob(w http.ResponseWriter, r *http.Request) {
 	resp, err := h.jobService.CreateJob(r.Context(), &req, userID, apiKeyID)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to create job")
-		writeJSONError(w, http.StatusBadRequest, err.Error())
+		writeJSONError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, resp)
+}
+
+// CloneJob handles POST /v1/jobs/{id}/clone: creates a new job with the job's input and options, with
+// options in the (optional) body overriding them.
+func (h *Handler) CloneJob(w http.ResponseWriter, r *http.Request) {
+	sourceID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid job id")
+		return
+	}
+
+	var req models.CloneJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	apiKeyID, err := auth.GetAPIKeyID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !h.intakeOpen(w, r) || !h.featuresAvailable(w, r, userID, req.FactCheckNeeded) ||
+		!h.lazyImagesAvailable(w, r, req.LazyImages != nil && *req.LazyImages) {
+		return
+	}
+
+	resp, err := h.jobService.CloneJob(r.Context(), sourceID, userID, apiKeyID, &req)
+	if err != nil {
+		log.Error().Err(err).Str("job_id", sourceID.String()).Msg("Failed to clone job")
+		if strings.HasPrefix(err.Error(), "job not found") || err.Error() == "access denied" {
+			writeJSONError(w, r, http.StatusNotFound, "job not found")
+			return
+		}
+		writeJSONError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -186,35 +277,154 @@ func (h *Handler) CreateJob(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetJob handles GET /v1/jobs/{id}
+// Optional query: view=summary, fields=job,segments,... and exclude=output_markup,... to shrink the payload.
 func (h *Handler) GetJob(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	jobID, err := uuid.Parse(vars["id"])
 	if err != nil {
-		writeJSONError(w, http.StatusBadRequest, "invalid job id")
+		writeJSONError(w, r, http.StatusBadRequest, "invalid job id")
+		return
+	}
+
+	viewOpts, err := parseJobViewOptions(r.URL.Query())
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	userID, err := auth.GetUserID(r.Context())
 	if err != nil {
-		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	resp, err := h.jobService.GetJob(r.Context(), jobID, userID)
+	if errors.Is(err, services.ErrJobExpired) {
+		writeJSONError(w, r, http.StatusGone, "job expired")
+		return
+	}
 	if err != nil {
 		log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to get job")
-		writeJSONError(w, http.StatusNotFound, "job not found")
+		writeJSONError(w, r, http.StatusNotFound, "job not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, viewOpts.apply(resp))
+}
+
+// ListJobSegments handles GET /v1/jobs/{id}/segments?limit=&cursor=
+func (h *Handler) ListJobSegments(w http.ResponseWriter, r *http.Request) {
+	jobID, userID, limit, cursor, ok := parseJobPageRequest(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.jobService.ListSegments(r.Context(), jobID, userID, limit, cursor)
+	if err != nil {
+		writeJobPageError(w, r, err, jobID, "segments")
 		return
 	}
 
-	writeJSON(w, http.StatusOK, resp)
+	writeJSON(w, http.StatusOK, page)
+}
+
+// ListJobAssets handles GET /v1/jobs/{id}/assets?limit=&cursor=
+func (h *Handler) ListJobAssets(w http.ResponseWriter, r *http.Request) {
+	jobID, userID, limit, cursor, ok := parseJobPageRequest(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.jobService.ListAssets(r.Context(), jobID, userID, limit, cursor)
+	if err != nil {
+		writeJobPageError(w, r, err, jobID, "assets")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+// ListJobEvents handles GET /v1/jobs/{id}/events
+func (h *Handler) ListJobEvents(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID, err := uuid.Parse(vars["id"])
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid job id")
+		return
+	}
+
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	events, err := h.jobService.ListJobEvents(r.Context(), jobID, userID)
+	if err != nil {
+		log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to list job events")
+		writeJSONError(w, r, http.StatusNotFound, "job not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"events": events,
+	})
+}
+
+// parseJobPageRequest reads the job id, caller and paging params shared by the job sub-resource lists.
+// It writes the error response and returns ok=false when the request is invalid.
+func parseJobPageRequest(w http.ResponseWriter, r *http.Request) (jobID, userID uuid.UUID, limit int, cursor string, ok bool) {
+	jobID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid job id")
+		return
+	}
+
+	userID, err = auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			writeJSONError(w, r, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+
+	return jobID, userID, limit, r.URL.Query().Get("cursor"), true
+}
+
+// isNotFoundError reports whether err is a service's missing job or asset error. Resources of other users
+// ("access denied") are reported as missing too.
+func isNotFoundError(err error) bool {
+	msg := err.Error()
+	return strings.HasPrefix(msg, "job not found") || strings.HasPrefix(msg, "asset not found") || msg == "access denied"
+}
+
+// writeJobPageError maps job sub-resource list errors: bad cursor → 400, expired job → 410, missing job → 404,
+// anything else → 500.
+func writeJobPageError(w http.ResponseWriter, r *http.Request, err error, jobID uuid.UUID, resource string) {
+	switch {
+	case strings.HasPrefix(err.Error(), "validation error"):
+		writeJSONError(w, r, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrJobExpired):
+		writeJSONError(w, r, http.StatusGone, "job expired")
+	case isNotFoundError(err):
+		writeJSONError(w, r, http.StatusNotFound, "job not found")
+	default:
+		log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to list " + resource)
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to list "+resource)
+	}
 }
 
 // ListJobs handles GET /v1/jobs
 func (h *Handler) ListJobs(w http.ResponseWriter, r *http.Request) {
 	userID, err := auth.GetUserID(r.Context())
 	if err != nil {
-		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
@@ -227,24 +437,71 @@ func (h *Handler) ListJobs(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	var cursor *time.Time
-	cursorStr := r.URL.Query().Get("cursor")
-	if cursorStr != "" {
-		if parsedCursor, err := time.Parse(time.RFC3339, cursorStr); err == nil {
-			cursor = &parsedCursor
-		}
+	filter, err := parseJobListFilter(r.URL.Query())
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "validation error: "+err.Error())
+		return
 	}
 
-	jobs, err := h.jobService.ListJobs(r.Context(), userID, limit, cursor)
+	page, err := h.jobService.ListJobs(r.Context(), userID, limit, r.URL.Query().Get("cursor"), filter)
 	if err != nil {
+		if strings.HasPrefix(err.Error(), "validation error") {
+			writeJSONError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
 		log.Error().Err(err).Msg("Failed to list jobs")
-		writeJSONError(w, http.StatusInternalServerError, "failed to list jobs")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to list jobs")
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"jobs": jobs,
-	})
+	writeJSON(w, http.StatusOK, page)
+}
+
+// parseJobListFilter reads ListJobs filters: tag=a&tag=b (or tag=a,b), metadata.<key>=<value>, status,
+// type and audio_type (repeated or comma-separated, any of them), created_after and created_before
+// (RFC3339 or YYYY-MM-DD, UTC) and q (free text over input_text). Values are validated by the service.
+func parseJobListFilter(q url.Values) (models.JobListFilter, error) {
+	var filter models.JobListFilter
+	for _, raw := range q["tag"] {
+		filter.Tags = append(filter.Tags, splitCommaList(raw)...)
+	}
+	for _, raw := range q["status"] {
+		filter.Statuses = append(filter.Statuses, splitCommaList(raw)...)
+	}
+	for _, raw := range q["type"] {
+		filter.Types = append(filter.Types, splitCommaList(raw)...)
+	}
+	for _, raw := range q["audio_type"] {
+		filter.AudioTypes = append(filter.AudioTypes, splitCommaList(raw)...)
+	}
+	for _, bound := range []struct {
+		name string
+		dst  **time.Time
+	}{{"created_after", &filter.CreatedAfter}, {"created_before", &filter.CreatedBefore}} {
+		raw := q.Get(bound.name)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			if t, err = time.Parse(time.DateOnly, raw); err != nil {
+				return filter, fmt.Errorf("%s must be an RFC3339 timestamp or a date (YYYY-MM-DD)", bound.name)
+			}
+		}
+		*bound.dst = &t
+	}
+	filter.Query = strings.TrimSpace(q.Get("q"))
+	for key, values := range q {
+		name, ok := strings.CutPrefix(key, "metadata.")
+		if !ok || name == "" || len(values) == 0 {
+			continue
+		}
+		if filter.Metadata == nil {
+			filter.Metadata = make(map[string]string)
+		}
+		filter.Metadata[name] = values[0]
+	}
+	return filter, nil
 }
 
 // GetAsset handles GET /v1/assets/{id}
@@ -252,20 +509,20 @@ func (h *Handler) GetAsset(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	assetID, err := uuid.Parse(vars["id"])
 	if err != nil {
-		writeJSONError(w, http.StatusBadRequest, "invalid asset id")
+		writeJSONError(w, r, http.StatusBadRequest, "invalid asset id")
 		return
 	}
 
 	userID, err := auth.GetUserID(r.Context())
 	if err != nil {
-		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	asset, err := h.jobService.GetAsset(r.Context(), assetID, userID)
 	if err != nil {
 		log.Error().Err(err).Str("asset_id", assetID.String()).Msg("Failed to get asset")
-		writeJSONError(w, http.StatusNotFound, "asset not found")
+		writeJSONError(w, r, http.StatusNotFound, "asset not found")
 		return
 	}
 
@@ -280,32 +537,37 @@ func (h *Handler) GetAssetContent(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	assetID, err := uuid.Parse(vars["id"])
 	if err != nil {
-		writeJSONError(w, http.StatusBadRequest, "invalid asset id")
+		writeJSONError(w, r, http.StatusBadRequest, "invalid asset id")
 		return
 	}
 
 	userID, err := auth.GetUserID(r.Context())
 	if err != nil {
-		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	asset, err := h.jobService.GetAsset(r.Context(), assetID, userID)
 	if err != nil {
 		log.Error().Err(err).Str("asset_id", assetID.String()).Msg("Failed to get asset")
-		writeJSONError(w, http.StatusNotFound, "asset not found")
+		writeJSONError(w, r, http.StatusNotFound, "asset not found")
 		return
 	}
 
 	if h.storage == nil {
-		writeJSONError(w, http.StatusServiceUnavailable, "storage not configured")
+		writeJSONError(w, r, http.StatusServiceUnavailable, "storage not configured")
+		return
+	}
+	asset, status, msg := h.generatePendingImage(w, r, asset)
+	if status != 0 {
+		writeJSONError(w, r, status, msg)
 		return
 	}
 
-	body, err := h.storage.GetObject(r.Context(), asset.S3Key)
+	body, err := h.storage.GetObject(r.Context(), asset.S3Bucket, asset.S3Key)
 	if err != nil {
 		log.Error().Err(err).Str("asset_id", assetID.String()).Str("s3_key", asset.S3Key).Msg("Failed to get object from storage")
-		writeJSONError(w, http.StatusInternalServerError, "failed to load asset")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to load asset")
 		return
 	}
 	defer body.Close()
@@ -320,6 +582,51 @@ func (h *Handler) GetAssetContent(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ReplaceAssetContent handles PUT /v1/assets/{id}/content — replaces a generated image or audio with the
+// raw request body (Content-Type: the upload's MIME type).
+func (h *Handler) ReplaceAssetContent(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	assetID, err := uuid.Parse(vars["id"])
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid asset id")
+		return
+	}
+
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if h.storage == nil {
+		writeJSONError(w, r, http.StatusServiceUnavailable, "storage not configured")
+		return
+	}
+
+	asset, err := h.jobService.ReplaceAssetContent(r.Context(), assetID, userID, r.Header.Get("Content-Type"), r.Body)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "validation error"):
+			writeJSONError(w, r, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrAssetNotReplaceable):
+			writeJSONError(w, r, http.StatusConflict, err.Error())
+		case errors.Is(err, services.ErrJobExpired):
+			writeJSONError(w, r, http.StatusGone, "job expired")
+		case isNotFoundError(err):
+			writeJSONError(w, r, http.StatusNotFound, "asset not found")
+		default:
+			log.Error().Err(err).Str("asset_id", assetID.String()).Msg("Failed to replace asset")
+			writeJSONError(w, r, http.StatusInternalServerError, "failed to replace asset")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.AssetResponse{
+		Asset:       asset.ToInResponse(),
+		DownloadURL: "/v1/assets/" + asset.ID.String() + "/content",
+	})
+}
+
 // injectFactChecksIntoHTML inserts fact-check divs into segment divs. For each non-empty fact-check,
 // finds the segment div with matching data-segment-id and appends a .fact-check div before its
 // outermost closing </div>. Uses string search instead of regex so that nested divs inside the
@@ -373,6 +680,16 @@ func injectFactChecksIntoHTML(bodyHTML string, factChecks []*models.SegmentFactC
 	return bodyHTML
 }
 
+// viewStatsHTML renders the view page header line with the job's word count and reading/listening times.
+func viewStatsHTML(stats *models.JobTextStats) string {
+	if stats == nil || stats.Words == 0 {
+		return ""
+	}
+	minutes := func(seconds int) int { return max(1, (seconds+59)/60) }
+	return fmt.Sprintf(`<p class="job-stats">%d words · %d min read · %d min listen</p>`,
+		stats.Words, minutes(stats.ReadSeconds), minutes(stats.ListenSeconds))
+}
+
 // viewJobFallbackHTML builds HTML from segments and assets when job has no output_markup (e.g. legacy jobs).
 func viewJobFallbackHTML(resp *models.JobStatusResponse, jobIDStr string) string {
 	type segmentAssets struct {
@@ -445,6 +762,7 @@ func (h *Handler) ViewJob(w http.ResponseWriter, r *http.Request) {
 		bodyHTML = viewJobFallbackHTML(resp, jobIDStr)
 	}
 	bodyHTML = injectFactChecksIntoHTML(bodyHTML, resp.FactChecks)
+	bodyHTML = viewStatsHTML(resp.Stats) + bodyHTML
 
 	var b []byte
 	b = append(b, viewHeadBytes...)
@@ -486,8 +804,13 @@ func (h *Handler) ViewAsset(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "storage not configured", http.StatusServiceUnavailable)
 		return
 	}
+	asset, status, msg := h.generatePendingImage(w, r, asset)
+	if status != 0 {
+		http.Error(w, msg, status)
+		return
+	}
 
-	body, err := h.storage.GetObject(r.Context(), asset.S3Key)
+	body, err := h.storage.GetObject(r.Context(), asset.S3Bucket, asset.S3Key)
 	if err != nil {
 		log.Error().Err(err).Str("asset_id", assetID.String()).Msg("ViewAsset: failed to get object")
 		http.Error(w, "failed to load asset", http.StatusInternalServerError)
@@ -503,6 +826,65 @@ func (h *Handler) ViewAsset(w http.ResponseWriter, r *http.Request) {
 	io.Copy(w, body)
 }
 
+// ViewAssetPeaks handles GET /view/asset/{id}/peaks?job_id=xxx — waveform peaks of an audio asset for
+// players on the view page and embeds (no auth)
+func (h *Handler) ViewAssetPeaks(w http.ResponseWriter, r *http.Request) {
+	assetID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid asset id", http.StatusBadRequest)
+		return
+	}
+	jobID, err := uuid.Parse(r.URL.Query().Get("job_id"))
+	if err != nil {
+		http.Error(w, "invalid job_id", http.StatusBadRequest)
+		return
+	}
+	asset, err := h.jobService.GetAssetByJobID(r.Context(), assetID, jobID)
+	if err != nil {
+		http.Error(w, "asset not found", http.StatusNotFound)
+		return
+	}
+	peaks, ok := asset.Meta["peaks"]
+	if !ok {
+		http.Error(w, "no waveform for asset", http.StatusNotFound)
+		return
+	}
+	// Assets are immutable (replacements are new assets), so their peaks can be cached
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	writeJSON(w, http.StatusOK, map[string]any{"peaks": peaks, "duration": asset.Meta["duration"]})
+}
+
+// ViewPodcastFeed handles GET /view/{id}/podcast.rss — the podcast job's RSS feed, a stable public URL for
+// podcast directories (no auth)
+func (h *Handler) ViewPodcastFeed(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid job id", http.StatusBadRequest)
+		return
+	}
+	asset, err := h.jobService.GetPodcastFeed(r.Context(), jobID)
+	if err != nil {
+		log.Debug().Err(err).Str("job_id", jobID.String()).Msg("ViewPodcastFeed: feed not found")
+		http.Error(w, "podcast feed not found", http.StatusNotFound)
+		return
+	}
+	if h.storage == nil {
+		http.Error(w, "storage not configured", http.StatusServiceUnavailable)
+		return
+	}
+	body, err := h.storage.GetObject(r.Context(), asset.S3Bucket, asset.S3Key)
+	if err != nil {
+		log.Error().Err(err).Str("asset_id", asset.ID.String()).Msg("ViewPodcastFeed: failed to get object")
+		http.Error(w, "failed to load podcast feed", http.StatusInternalServerError)
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, body)
+}
+
 func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -511,8 +893,12 @@ func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	}
 }
 
-func writeJSONError(w http.ResponseWriter, status int, message string) {
+// writeJSONError writes message as {"error", "code"}, translated to the language of r's Accept-Language
+// when the message is in the i18n catalog.
+func writeJSONError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	msg := i18n.Localize(r, status, message)
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", msg.Language.String())
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
+	json.NewEncoder(w).Encode(map[string]string{"error": msg.Text, "code": msg.Code})
 }
@@ -4,22 +4,45 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/features"
+	"github.com/snappy-loop/stories/internal/maintenance"
 	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/services"
+	"github.com/snappy-loop/stories/internal/storage"
+	"github.com/snappy-loop/stories/internal/teleprompter"
 )
 
 // fakeJobService is a minimal jobService for tests.
 type fakeJobService struct {
 	createJob func(context.Context, *models.CreateJobRequest, uuid.UUID, uuid.UUID) (*models.CreateJobResponse, error)
 	getJob    func(context.Context, uuid.UUID, uuid.UUID) (*models.JobStatusResponse, error)
+	approve   func(context.Context, uuid.UUID, uuid.UUID, *models.ReviewApproval) (*models.JobStatusResponse, error)
+	cancel    func(context.Context, uuid.UUID, uuid.UUID) (*models.JobStatusResponse, error)
+	retry     func(context.Context, uuid.UUID, uuid.UUID) (*models.JobStatusResponse, error)
+	deleteJob func(context.Context, uuid.UUID, uuid.UUID) error
+	updateJob func(context.Context, uuid.UUID, uuid.UUID, *models.UpdateJobRequest) (*models.JobStatusResponse, error)
+
+	updateSegment func(context.Context, uuid.UUID, uuid.UUID, int, *models.UpdateSegmentRequest) (*models.Segment, error)
+	restore       func(context.Context, uuid.UUID, uuid.UUID, int) (*models.OutputVersion, error)
+	cloneJob      func(context.Context, uuid.UUID, uuid.UUID, uuid.UUID, *models.CloneJobRequest) (*models.CreateJobResponse, error)
+	getScript     func(context.Context, uuid.UUID, uuid.UUID) (*teleprompter.Script, error)
+	redeliver     func(context.Context, uuid.UUID, uuid.UUID) (*models.WebhookDelivery, error)
+	replaceAsset  func(context.Context, uuid.UUID, uuid.UUID, string, io.Reader) (*models.Asset, error)
+	listErr       error
 }
 
 func (f *fakeJobService) CreateJob(ctx context.Context, req *models.CreateJobRequest, userID, apiKeyID uuid.UUID) (*models.CreateJobResponse, error) {
@@ -29,6 +52,13 @@ func (f *fakeJobService) CreateJob(ctx context.Context, req *models.CreateJobReq
 	return &models.CreateJobResponse{JobID: uuid.New(), Status: "queued", CreatedAt: time.Now()}, nil
 }
 
+func (f *fakeJobService) CloneJob(ctx context.Context, sourceID, userID, apiKeyID uuid.UUID, req *models.CloneJobRequest) (*models.CreateJobResponse, error) {
+	if f.cloneJob != nil {
+		return f.cloneJob(ctx, sourceID, userID, apiKeyID, req)
+	}
+	return &models.CreateJobResponse{JobID: uuid.New(), Status: "queued", CreatedAt: time.Now()}, nil
+}
+
 func (f *fakeJobService) GetJob(ctx context.Context, jobID, userID uuid.UUID) (*models.JobStatusResponse, error) {
 	if f.getJob != nil {
 		return f.getJob(ctx, jobID, userID)
@@ -40,8 +70,26 @@ func (f *fakeJobService) GetJobByID(ctx context.Context, jobID uuid.UUID) (*mode
 	return nil, nil
 }
 
-func (f *fakeJobService) ListJobs(ctx context.Context, userID uuid.UUID, limit int, cursor *time.Time) ([]*models.Job, error) {
-	return nil, nil
+func (f *fakeJobService) ListSegments(ctx context.Context, jobID, userID uuid.UUID, limit int, cursor string) (*models.SegmentPage, error) {
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	return &models.SegmentPage{}, nil
+}
+
+func (f *fakeJobService) ListAssets(ctx context.Context, jobID, userID uuid.UUID, limit int, cursor string) (*models.AssetPage, error) {
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	return &models.AssetPage{}, nil
+}
+
+func (f *fakeJobService) ListJobEvents(ctx context.Context, jobID, userID uuid.UUID) ([]*models.JobEvent, error) {
+	return []*models.JobEvent{}, nil
+}
+
+func (f *fakeJobService) ListJobs(ctx context.Context, userID uuid.UUID, limit int, cursor string, filter models.JobListFilter) (*models.JobPage, error) {
+	return &models.JobPage{Jobs: []*models.Job{}}, nil
 }
 
 func (f *fakeJobService) GetAsset(ctx context.Context, assetID, userID uuid.UUID) (*models.Asset, error) {
@@ -52,6 +100,165 @@ func (f *fakeJobService) GetAssetByJobID(ctx context.Context, assetID, jobID uui
 	return nil, nil
 }
 
+func (f *fakeJobService) GetPodcastFeed(ctx context.Context, jobID uuid.UUID) (*models.Asset, error) {
+	return nil, nil
+}
+
+func (f *fakeJobService) CancelJob(ctx context.Context, jobID, userID uuid.UUID) (*models.JobStatusResponse, error) {
+	if f.cancel != nil {
+		return f.cancel(ctx, jobID, userID)
+	}
+	return nil, nil
+}
+
+func (f *fakeJobService) UpdateJob(ctx context.Context, jobID, userID uuid.UUID, req *models.UpdateJobRequest) (*models.JobStatusResponse, error) {
+	if f.updateJob != nil {
+		return f.updateJob(ctx, jobID, userID, req)
+	}
+	return nil, nil
+}
+
+func (f *fakeJobService) RetryJob(ctx context.Context, jobID, userID uuid.UUID) (*models.JobStatusResponse, error) {
+	if f.retry != nil {
+		return f.retry(ctx, jobID, userID)
+	}
+	return nil, nil
+}
+
+func (f *fakeJobService) DeleteJob(ctx context.Context, jobID, userID uuid.UUID) error {
+	if f.deleteJob != nil {
+		return f.deleteJob(ctx, jobID, userID)
+	}
+	return nil
+}
+
+func (f *fakeJobService) ApproveJob(ctx context.Context, jobID, userID uuid.UUID, req *models.ReviewApproval) (*models.JobStatusResponse, error) {
+	if f.approve != nil {
+		return f.approve(ctx, jobID, userID, req)
+	}
+	return nil, nil
+}
+
+func (f *fakeJobService) RequestSegmentRegeneration(ctx context.Context, jobID, userID uuid.UUID, req *models.ReviewRegeneration) (*models.JobStatusResponse, error) {
+	return nil, nil
+}
+
+func (f *fakeJobService) ReplaceAssetContent(ctx context.Context, assetID, userID uuid.UUID, mimeType string, data io.Reader) (*models.Asset, error) {
+	if f.replaceAsset != nil {
+		return f.replaceAsset(ctx, assetID, userID, mimeType, data)
+	}
+	return nil, nil
+}
+
+func (f *fakeJobService) UpdateSegment(ctx context.Context, jobID, userID uuid.UUID, idx int, req *models.UpdateSegmentRequest) (*models.Segment, error) {
+	if f.updateSegment != nil {
+		return f.updateSegment(ctx, jobID, userID, idx, req)
+	}
+	return &models.Segment{JobID: jobID, Idx: idx, Status: "queued"}, nil
+}
+
+func (f *fakeJobService) ListOutputVersions(ctx context.Context, jobID, userID uuid.UUID) ([]*models.OutputVersion, error) {
+	return []*models.OutputVersion{}, nil
+}
+
+func (f *fakeJobService) GetOutputVersion(ctx context.Context, jobID, userID uuid.UUID, version int) (*models.OutputVersion, error) {
+	return nil, nil
+}
+
+func (f *fakeJobService) RestoreOutputVersion(ctx context.Context, jobID, userID uuid.UUID, version int) (*models.OutputVersion, error) {
+	if f.restore != nil {
+		return f.restore(ctx, jobID, userID, version)
+	}
+	return &models.OutputVersion{JobID: jobID, Version: version + 1, Reason: models.OutputVersionRestored, Current: true}, nil
+}
+
+func (f *fakeJobService) GetScript(ctx context.Context, jobID, userID uuid.UUID) (*teleprompter.Script, error) {
+	if f.getScript != nil {
+		return f.getScript(ctx, jobID, userID)
+	}
+	return &teleprompter.Script{Title: "Story", Sections: []teleprompter.Section{{Number: 1, Title: "Intro", Narration: "Hello."}}}, nil
+}
+
+func (f *fakeJobService) NarrationDiff(ctx context.Context, jobID, userID uuid.UUID) ([]*models.SegmentNarrationDiff, error) {
+	title := "Intro <1>"
+	return []*models.SegmentNarrationDiff{
+		{Idx: 0, Title: &title, HasNarration: true, HTML: "Revenue <del>5%</del><ins>sharply</ins>", RemovedWords: 1, AddedWords: 1},
+		{Idx: 1},
+	}, nil
+}
+
+func (f *fakeJobService) GetSettings(ctx context.Context, userID uuid.UUID) (*models.UserSettings, error) {
+	return &models.UserSettings{}, nil
+}
+
+func (f *fakeJobService) UpdateSettings(ctx context.Context, userID uuid.UUID, settings *models.UserSettings) (*models.UserSettings, error) {
+	return settings, nil
+}
+
+func (f *fakeJobService) GetWebhookDelivery(ctx context.Context, deliveryID, userID uuid.UUID) (*models.WebhookDeliveryDetail, error) {
+	return nil, services.ErrWebhookDeliveryNotFound
+}
+
+func (f *fakeJobService) RedeliverWebhook(ctx context.Context, deliveryID, userID uuid.UUID) (*models.WebhookDelivery, error) {
+	if f.redeliver != nil {
+		return f.redeliver(ctx, deliveryID, userID)
+	}
+	return &models.WebhookDelivery{ID: deliveryID, Status: "pending", Redeliveries: 1}, nil
+}
+
+func (f *fakeJobService) GetEgressAllowlist(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	return []string{}, nil
+}
+
+func (f *fakeJobService) UpdateEgressAllowlist(ctx context.Context, userID uuid.UUID, hosts []string) ([]string, error) {
+	if len(hosts) > 1 {
+		return nil, fmt.Errorf("%w: at most 1 hosts are allowed", services.ErrInvalidEgressAllowlist)
+	}
+	return hosts, nil
+}
+
+// newTestHandler returns a Handler over svc with no optional dependencies.
+func newTestHandler(svc jobService) *Handler {
+	return NewHandler(svc, nil, nil, nil, nil, 100000, "monthly", 20, nil, "", "")
+}
+
+// serveAsUser calls handler with a method request for path from a new user and API key, with the mux route
+// variables vars (may be nil) and body, and returns the recorded response.
+func serveAsUser(t *testing.T, handler http.HandlerFunc, method, path string, vars map[string]string, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if vars != nil {
+		req = mux.SetURLVars(req, vars)
+	}
+	ctx := context.WithValue(req.Context(), auth.UserIDKey, uuid.New())
+	req = req.WithContext(context.WithValue(ctx, auth.APIKeyIDKey, uuid.New()))
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+// checkResponse fails t unless rec has status want. Errors (code set) must have a JSON body with that error
+// code; successes must contain success in their body.
+func checkResponse(t *testing.T, rec *httptest.ResponseRecorder, want int, code, success string) {
+	t.Helper()
+	if rec.Code != want {
+		t.Fatalf("expected %d, got %d: %s", want, rec.Code, rec.Body.String())
+	}
+	if code == "" {
+		if !strings.Contains(rec.Body.String(), success) {
+			t.Errorf("body = %s, want it to contain %s", rec.Body.String(), success)
+		}
+		return
+	}
+	var body struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Code != code || body.Error == "" {
+		t.Errorf("error body = %s, want code %q", rec.Body.String(), code)
+	}
+}
+
 // TestCreateJob_Unauthorized asserts 401 when request context has no user/key.
 func TestCreateJob_Unauthorized(t *testing.T) {
 	h := NewHandler(
@@ -117,6 +324,7 @@ func TestCreateJob_ValidationErrorFromService(t *testing.T) {
 	body := bytes.NewBufferString(`{"type":"educational","segments_count":2,"audio_type":"free_speech"}`)
 	req := httptest.NewRequest(http.MethodPost, "/v1/jobs", body)
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "de-DE, en;q=0.5")
 	ctx := context.WithValue(req.Context(), auth.UserIDKey, userID)
 	ctx = context.WithValue(ctx, auth.APIKeyIDKey, apiKeyID)
 	req = req.WithContext(ctx)
@@ -127,6 +335,16 @@ func TestCreateJob_ValidationErrorFromService(t *testing.T) {
 	if rec.Code != http.StatusBadRequest {
 		t.Errorf("expected 400, got %d: %s", rec.Code, rec.Body.String())
 	}
+	var resp map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp["code"] != "text_or_files_required" || resp["error"] != "Validierungsfehler: text oder file_ids ist erforderlich" {
+		t.Errorf("body = %v, want the German text_or_files_required error", resp)
+	}
+	if got := rec.Header().Get("Content-Language"); got != "de" {
+		t.Errorf("Content-Language = %q, want de", got)
+	}
 }
 
 // TestCreateJob_Success asserts 202 and job_id when service succeeds.
@@ -188,3 +406,747 @@ func TestGetJob_InvalidID(t *testing.T) {
 		t.Errorf("expected 400, got %d: %s", rec.Code, rec.Body.String())
 	}
 }
+
+// TestParseJobListFilter asserts the ListJobs query filters and the 400 for malformed dates.
+func TestParseJobListFilter(t *testing.T) {
+	q, _ := url.ParseQuery("status=failed,canceled&type=educational&audio_type=podcast&created_after=2026-10-01" +
+		"&created_before=2026-10-18T12:00:00Z&q=+water+cycle+&tag=a&metadata.doc=D-1")
+	filter, err := parseJobListFilter(q)
+	if err != nil {
+		t.Fatalf("parseJobListFilter: %v", err)
+	}
+	after := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	if !slices.Equal(filter.Statuses, []string{"failed", "canceled"}) || !slices.Equal(filter.Types, []string{"educational"}) ||
+		!slices.Equal(filter.AudioTypes, []string{"podcast"}) || filter.Query != "water cycle" ||
+		filter.CreatedAfter == nil || !filter.CreatedAfter.Equal(after) || filter.CreatedBefore == nil || !filter.CreatedBefore.Equal(before) ||
+		!slices.Equal(filter.Tags, []string{"a"}) || filter.Metadata["doc"] != "D-1" {
+		t.Errorf("filter = %+v", filter)
+	}
+
+	h := NewHandler(&fakeJobService{}, nil, nil, nil, nil, 100000, "monthly", 20, nil, "", "")
+	req := httptest.NewRequest(http.MethodGet, "/v1/jobs?created_after=yesterday", nil)
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserIDKey, uuid.New()))
+	rec := httptest.NewRecorder()
+	h.ListJobs(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a malformed created_after, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestGetJob_SummaryView asserts view=summary drops markup and large text fields but keeps status.
+func TestGetJob_SummaryView(t *testing.T) {
+	userID := uuid.New()
+	jobID := uuid.New()
+	markupStr := "[[SEGMENT id=x]]long text[[/SEGMENT]]"
+	extracted := "extracted"
+
+	h := NewHandler(
+		&fakeJobService{
+			getJob: func(context.Context, uuid.UUID, uuid.UUID) (*models.JobStatusResponse, error) {
+				return &models.JobStatusResponse{
+					Job: models.Job{
+						ID:            jobID,
+						Status:        "succeeded",
+						InputText:     "input",
+						ExtractedText: &extracted,
+						OutputMarkup:  &markupStr,
+					},
+					Segments: []*models.Segment{{ID: uuid.New(), Idx: 0, SegmentText: "segment text", Status: "succeeded"}},
+					Files:    []*models.JobFileResponse{{FileID: uuid.New(), ExtractedText: &extracted, Status: "succeeded"}},
+				}, nil
+			},
+		},
+		nil, nil, nil, nil,
+		100000, "monthly", 20, nil, "", "",
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/jobs/"+jobID.String()+"?view=summary", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": jobID.String()})
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserIDKey, userID))
+	rec := httptest.NewRecorder()
+
+	h.GetJob(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	body := rec.Body.String()
+	for _, unwanted := range []string{"input_text", "extracted_text", "output_markup", "segment_text"} {
+		if bytes.Contains([]byte(body), []byte(`"`+unwanted+`"`)) {
+			t.Errorf("summary view should not contain %q: %s", unwanted, body)
+		}
+	}
+	if !bytes.Contains([]byte(body), []byte(`"status":"succeeded"`)) {
+		t.Errorf("summary view should keep status: %s", body)
+	}
+}
+
+// TestGetJob_FieldsSelection asserts fields=job returns only the job section.
+func TestGetJob_FieldsSelection(t *testing.T) {
+	jobID := uuid.New()
+	h := NewHandler(
+		&fakeJobService{
+			getJob: func(context.Context, uuid.UUID, uuid.UUID) (*models.JobStatusResponse, error) {
+				return &models.JobStatusResponse{Job: models.Job{ID: jobID, Status: "running"}}, nil
+			},
+		},
+		nil, nil, nil, nil,
+		100000, "monthly", 20, nil, "", "",
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/jobs/"+jobID.String()+"?fields=job", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": jobID.String()})
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserIDKey, uuid.New()))
+	rec := httptest.NewRecorder()
+
+	h.GetJob(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp map[string]json.RawMessage
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if _, ok := resp["job"]; !ok {
+		t.Errorf("expected job key, got %v", resp)
+	}
+	if len(resp) != 1 {
+		t.Errorf("expected only job key, got %d keys", len(resp))
+	}
+}
+
+// TestGetJob_Expired asserts 410 for jobs whose retention has ended.
+func TestGetJob_Expired(t *testing.T) {
+	jobID := uuid.New()
+	h := NewHandler(
+		&fakeJobService{
+			getJob: func(context.Context, uuid.UUID, uuid.UUID) (*models.JobStatusResponse, error) {
+				return nil, services.ErrJobExpired
+			},
+		},
+		nil, nil, nil, nil,
+		100000, "monthly", 20, nil, "", "",
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/jobs/"+jobID.String(), nil)
+	req = mux.SetURLVars(req, map[string]string{"id": jobID.String()})
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserIDKey, uuid.New()))
+	rec := httptest.NewRecorder()
+
+	h.GetJob(rec, req)
+
+	if rec.Code != http.StatusGone {
+		t.Errorf("expected 410, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestGetJob_InvalidExclude asserts 400 for unknown exclude names.
+func TestGetJob_InvalidExclude(t *testing.T) {
+	jobID := uuid.New()
+	h := NewHandler(&fakeJobService{}, nil, nil, nil, nil, 100000, "monthly", 20, nil, "", "")
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/jobs/"+jobID.String()+"?exclude=status", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": jobID.String()})
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserIDKey, uuid.New()))
+	rec := httptest.NewRecorder()
+
+	h.GetJob(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestApproveJob_StatusCodes asserts the review error mapping: not awaiting review → 409, validation → 400.
+func TestApproveJob_StatusCodes(t *testing.T) {
+	jobID := uuid.New()
+	for _, tc := range []struct {
+		name string
+		err  error
+		want int
+		code string
+	}{
+		{"approved", nil, http.StatusOK, ""},
+		{"not awaiting review", services.ErrJobNotAwaitingReview, http.StatusConflict, "job_not_awaiting_review"},
+		{"validation", fmt.Errorf("validation error: comment exceeds maximum length of 2000"), http.StatusBadRequest, "validation_error"},
+		{"not found", fmt.Errorf("job not found: missing"), http.StatusNotFound, "job_not_found"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newTestHandler(&fakeJobService{
+				approve: func(context.Context, uuid.UUID, uuid.UUID, *models.ReviewApproval) (*models.JobStatusResponse, error) {
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					return &models.JobStatusResponse{Job: models.Job{ID: jobID, Status: models.JobStatusSucceeded}}, nil
+				},
+			})
+
+			rec := serveAsUser(t, h.ApproveJob, http.MethodPost, "/v1/jobs/"+jobID.String()+"/review/approve", map[string]string{"id": jobID.String()}, "")
+
+			checkResponse(t, rec, tc.want, tc.code, `"status":"succeeded"`)
+		})
+	}
+}
+
+func TestCancelJob_StatusCodes(t *testing.T) {
+	jobID := uuid.New()
+	for _, tc := range []struct {
+		name string
+		err  error
+		want int
+		code string
+	}{
+		{"canceled", nil, http.StatusOK, ""},
+		{"finished", services.ErrJobNotCancelable, http.StatusConflict, "job_not_cancelable"},
+		{"not found", fmt.Errorf("job not found: missing"), http.StatusNotFound, "job_not_found"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newTestHandler(&fakeJobService{
+				cancel: func(context.Context, uuid.UUID, uuid.UUID) (*models.JobStatusResponse, error) {
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					return &models.JobStatusResponse{Job: models.Job{ID: jobID, Status: models.JobStatusCanceled}}, nil
+				},
+			})
+
+			rec := serveAsUser(t, h.CancelJob, http.MethodPost, "/v1/jobs/"+jobID.String()+"/cancel", map[string]string{"id": jobID.String()}, "")
+
+			checkResponse(t, rec, tc.want, tc.code, `"status":"canceled"`)
+		})
+	}
+}
+
+func TestRetryJob_StatusCodes(t *testing.T) {
+	jobID := uuid.New()
+	for _, tc := range []struct {
+		name string
+		err  error
+		want int
+		code string
+	}{
+		{"retried", nil, http.StatusAccepted, ""},
+		{"not failed", services.ErrJobNotRetryable, http.StatusConflict, "job_not_retryable"},
+		{"duplicate", services.ErrDuplicateNotRetryable, http.StatusConflict, "duplicate_not_retryable"},
+		{"not found", fmt.Errorf("job not found: missing"), http.StatusNotFound, "job_not_found"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newTestHandler(&fakeJobService{
+				retry: func(context.Context, uuid.UUID, uuid.UUID) (*models.JobStatusResponse, error) {
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					return &models.JobStatusResponse{Job: models.Job{ID: jobID, Status: models.JobStatusQueued}}, nil
+				},
+			})
+
+			rec := serveAsUser(t, h.RetryJob, http.MethodPost, "/v1/jobs/"+jobID.String()+"/retry", map[string]string{"id": jobID.String()}, "")
+
+			checkResponse(t, rec, tc.want, tc.code, `"status":"queued"`)
+		})
+	}
+}
+
+func TestUpdateJob_StatusCodes(t *testing.T) {
+	jobID := uuid.New()
+	for _, tc := range []struct {
+		name string
+		body string
+		err  error
+		want int
+		code string
+	}{
+		{"updated", `{"webhook":{"url":"https://example.com/hook"}}`, nil, http.StatusOK, ""},
+		{"bad body", `{`, nil, http.StatusBadRequest, "invalid_request_body"},
+		{"validation", `{}`, fmt.Errorf("validation error: webhook is required"), http.StatusBadRequest, "webhook_required"},
+		{"finished", `{"webhook":{"url":"https://example.com/hook"}}`, services.ErrJobFinished, http.StatusConflict, "job_not_cancelable"},
+		{"expired", `{"webhook":{"url":"https://example.com/hook"}}`, services.ErrJobExpired, http.StatusGone, "job_expired"},
+		{"not found", `{"webhook":{"url":"https://example.com/hook"}}`, fmt.Errorf("job not found: missing"), http.StatusNotFound, "job_not_found"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newTestHandler(&fakeJobService{
+				updateJob: func(_ context.Context, _, _ uuid.UUID, req *models.UpdateJobRequest) (*models.JobStatusResponse, error) {
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					return &models.JobStatusResponse{Job: models.Job{ID: jobID, Status: models.JobStatusRunning, WebhookURL: &req.Webhook.URL}}, nil
+				},
+			})
+
+			rec := serveAsUser(t, h.UpdateJob, http.MethodPatch, "/v1/jobs/"+jobID.String(), map[string]string{"id": jobID.String()}, tc.body)
+
+			checkResponse(t, rec, tc.want, tc.code, `"webhook_url":"https://example.com/hook"`)
+		})
+	}
+}
+
+func TestDeleteJob_StatusCodes(t *testing.T) {
+	jobID := uuid.New()
+	for _, tc := range []struct {
+		name string
+		err  error
+		want int
+		code string
+	}{
+		{"deleted", nil, http.StatusNoContent, ""},
+		{"not finished", services.ErrJobNotDeletable, http.StatusConflict, "job_not_deletable"},
+		{"has duplicates", services.ErrJobHasDuplicates, http.StatusConflict, "job_has_duplicates"},
+		{"not found", fmt.Errorf("job not found: missing"), http.StatusNotFound, "job_not_found"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newTestHandler(&fakeJobService{
+				deleteJob: func(context.Context, uuid.UUID, uuid.UUID) error { return tc.err },
+			})
+
+			rec := serveAsUser(t, h.DeleteJob, http.MethodDelete, "/v1/jobs/"+jobID.String(), map[string]string{"id": jobID.String()}, "")
+
+			checkResponse(t, rec, tc.want, tc.code, "")
+		})
+	}
+}
+
+// TestUpdateSegment_StatusCodes asserts PATCH segment parsing and the 409 for segments that cannot be edited.
+func TestUpdateSegment_StatusCodes(t *testing.T) {
+	jobID := uuid.New()
+	for _, tc := range []struct {
+		name string
+		idx  string
+		body string
+		err  error
+		want int
+		code string
+	}{
+		{"accepted", "1", `{"narration_text":"Hello there."}`, nil, http.StatusAccepted, ""},
+		{"bad index", "x", `{"narration_text":"Hello there."}`, nil, http.StatusBadRequest, "invalid_segment_index"},
+		{"bad body", "1", `{`, nil, http.StatusBadRequest, "invalid_request_body"},
+		{"busy", "1", `{"narration_text":"Hello there."}`, services.ErrSegmentNotEditable, http.StatusConflict, "segment_not_editable"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newTestHandler(&fakeJobService{
+				updateSegment: func(_ context.Context, _, _ uuid.UUID, idx int, _ *models.UpdateSegmentRequest) (*models.Segment, error) {
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					return &models.Segment{JobID: jobID, Idx: idx, Status: "queued"}, nil
+				},
+			})
+
+			rec := serveAsUser(t, h.UpdateSegment, http.MethodPatch, "/v1/jobs/"+jobID.String()+"/segments/"+tc.idx,
+				map[string]string{"id": jobID.String(), "idx": tc.idx}, tc.body)
+
+			checkResponse(t, rec, tc.want, tc.code, `"idx":1`)
+		})
+	}
+}
+
+// TestRestoreOutputVersion_StatusCodes asserts the restore endpoint's status mapping.
+func TestRestoreOutputVersion_StatusCodes(t *testing.T) {
+	jobID := uuid.New()
+	for _, tc := range []struct {
+		name    string
+		version string
+		err     error
+		want    int
+		code    string
+	}{
+		{"restored", "2", nil, http.StatusOK, ""},
+		{"bad version", "0", nil, http.StatusBadRequest, "invalid_version"},
+		{"duplicate", "2", fmt.Errorf("validation error: job reuses the output of job %s", uuid.New()), http.StatusBadRequest, "validation_error"},
+		{"busy", "2", services.ErrOutputNotRestorable, http.StatusConflict, "version_not_restorable"},
+		{"not found", "9", fmt.Errorf("output version not found"), http.StatusNotFound, "version_not_found"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newTestHandler(&fakeJobService{
+				restore: func(_ context.Context, _, _ uuid.UUID, version int) (*models.OutputVersion, error) {
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					return &models.OutputVersion{JobID: jobID, Version: version + 1, Current: true}, nil
+				},
+			})
+
+			rec := serveAsUser(t, h.RestoreOutputVersion, http.MethodPost, "/v1/jobs/"+jobID.String()+"/versions/"+tc.version+"/restore",
+				map[string]string{"id": jobID.String(), "version": tc.version}, "")
+
+			checkResponse(t, rec, tc.want, tc.code, `"version":3`)
+		})
+	}
+}
+
+// TestListJobPages_StatusCodes asserts only missing jobs are reported as 404 by the segment and asset listings.
+func TestListJobPages_StatusCodes(t *testing.T) {
+	jobID := uuid.New()
+	for _, tc := range []struct {
+		name string
+		err  error
+		want int
+		code string
+	}{
+		{"listed", nil, http.StatusOK, ""},
+		{"bad cursor", fmt.Errorf("validation error: invalid cursor"), http.StatusBadRequest, "invalid_cursor"},
+		{"expired", services.ErrJobExpired, http.StatusGone, "job_expired"},
+		{"not found", fmt.Errorf("job not found: %w", errors.New("no rows")), http.StatusNotFound, "job_not_found"},
+		{"other user", fmt.Errorf("access denied"), http.StatusNotFound, "job_not_found"},
+		{"database", errors.New("failed to list segments: connection refused"), http.StatusInternalServerError, "internal_server_error"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newTestHandler(&fakeJobService{listErr: tc.err})
+			for _, list := range []struct {
+				handler http.HandlerFunc
+				path    string
+			}{
+				{h.ListJobSegments, "/v1/jobs/" + jobID.String() + "/segments"},
+				{h.ListJobAssets, "/v1/jobs/" + jobID.String() + "/assets"},
+			} {
+				rec := serveAsUser(t, list.handler, http.MethodGet, list.path, map[string]string{"id": jobID.String()}, "")
+
+				checkResponse(t, rec, tc.want, tc.code, "{")
+			}
+		})
+	}
+}
+
+// TestReplaceAssetContent_StatusCodes asserts missing assets get 404, expired jobs 410 and failed uploads 500.
+func TestReplaceAssetContent_StatusCodes(t *testing.T) {
+	assetID := uuid.New()
+	for _, tc := range []struct {
+		name string
+		err  error
+		want int
+		code string
+	}{
+		{"replaced", nil, http.StatusOK, ""},
+		{"validation", fmt.Errorf("validation error: empty upload"), http.StatusBadRequest, "validation_error"},
+		{"generating", services.ErrAssetNotReplaceable, http.StatusConflict, "asset_not_replaceable"},
+		{"expired", services.ErrJobExpired, http.StatusGone, "job_expired"},
+		{"not found", fmt.Errorf("asset not found: %w", errors.New("no rows")), http.StatusNotFound, "asset_not_found"},
+		{"other user", fmt.Errorf("access denied"), http.StatusNotFound, "asset_not_found"},
+		{"upload failed", fmt.Errorf("failed to upload to storage: %w", errors.New("connection reset")), http.StatusInternalServerError, "internal_server_error"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newTestHandler(&fakeJobService{
+				replaceAsset: func(_ context.Context, id, _ uuid.UUID, mimeType string, _ io.Reader) (*models.Asset, error) {
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					return &models.Asset{ID: uuid.New(), Kind: "image", MimeType: mimeType, Version: 2}, nil
+				},
+			})
+			h.storage = &storage.Client{}
+
+			rec := serveAsUser(t, h.ReplaceAssetContent, http.MethodPut, "/v1/assets/"+assetID.String()+"/content", map[string]string{"id": assetID.String()}, "png")
+
+			checkResponse(t, rec, tc.want, tc.code, `"version":2`)
+		})
+	}
+}
+
+// TestCloneJob_StatusCodes asserts the clone endpoint accepts an empty body and maps service errors.
+func TestCloneJob_StatusCodes(t *testing.T) {
+	jobID := uuid.New()
+	for _, tc := range []struct {
+		name string
+		body string
+		err  error
+		want int
+		code string
+	}{
+		{"empty body", "", nil, http.StatusAccepted, ""},
+		{"overrides", `{"segments_count":3,"audio_type":"podcast"}`, nil, http.StatusAccepted, ""},
+		{"bad body", `{`, nil, http.StatusBadRequest, "invalid_request_body"},
+		{"invalid override", `{"segments_count":0}`, fmt.Errorf("validation error: segments_count must be between 1 and 20"), http.StatusBadRequest, "segments_count_out_of_range"},
+		{"not found", "", fmt.Errorf("job not found: %w", errors.New("no rows")), http.StatusNotFound, "job_not_found"},
+		{"other user", "", fmt.Errorf("access denied"), http.StatusNotFound, "job_not_found"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newTestHandler(&fakeJobService{
+				cloneJob: func(_ context.Context, _, _, _ uuid.UUID, _ *models.CloneJobRequest) (*models.CreateJobResponse, error) {
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					return &models.CreateJobResponse{JobID: uuid.New(), Status: "queued", CreatedAt: time.Now()}, nil
+				},
+			})
+
+			rec := serveAsUser(t, h.CloneJob, http.MethodPost, "/v1/jobs/"+jobID.String()+"/clone", map[string]string{"id": jobID.String()}, tc.body)
+
+			checkResponse(t, rec, tc.want, tc.code, `"status":"queued"`)
+		})
+	}
+}
+
+// TestGetJobScript asserts the script download: format validation, content type and attachment name,
+// and 409 while the job has no segments.
+func TestGetJobScript(t *testing.T) {
+	jobID := uuid.New()
+	for _, tc := range []struct {
+		name, format string
+		err          error
+		want         int
+		code         string
+		contentType  string
+	}{
+		{"default text", "", nil, http.StatusOK, "", "text/plain; charset=utf-8"},
+		{"docx", "docx", nil, http.StatusOK, "", "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{"bad format", "pdf", nil, http.StatusBadRequest, "bad_request", ""},
+		{"no segments", "html", services.ErrNoScript, http.StatusConflict, "script_not_ready", ""},
+		{"not found", "html", fmt.Errorf("job not found: missing"), http.StatusNotFound, "job_not_found", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &fakeJobService{}
+			if tc.err != nil {
+				svc.getScript = func(context.Context, uuid.UUID, uuid.UUID) (*teleprompter.Script, error) { return nil, tc.err }
+			}
+			h := newTestHandler(svc)
+
+			rec := serveAsUser(t, h.GetJobScript, http.MethodGet, "/v1/jobs/"+jobID.String()+"/script?format="+tc.format, map[string]string{"id": jobID.String()}, "")
+
+			checkResponse(t, rec, tc.want, tc.code, "")
+			if tc.contentType == "" {
+				return
+			}
+			if got := rec.Header().Get("Content-Type"); got != tc.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tc.contentType)
+			}
+			if got := rec.Header().Get("Content-Disposition"); got == "" || !strings.Contains(got, "script-"+jobID.String()) {
+				t.Errorf("Content-Disposition = %q", got)
+			}
+		})
+	}
+}
+
+// TestGetNarrationDiff asserts the JSON and HTML forms of the narration diff and format validation.
+func TestGetNarrationDiff(t *testing.T) {
+	jobID := uuid.New()
+	h := newTestHandler(&fakeJobService{})
+	get := func(format string) *httptest.ResponseRecorder {
+		return serveAsUser(t, h.GetNarrationDiff, http.MethodGet, "/v1/jobs/"+jobID.String()+"/narration-diff?format="+format,
+			map[string]string{"id": jobID.String()}, "")
+	}
+
+	rec := get("")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"removed_words":1`) {
+		t.Errorf("json: %d %s", rec.Code, rec.Body.String())
+	}
+	rec = get("html")
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "<del>5%</del><ins>sharply</ins>") ||
+		!strings.Contains(body, "1. Intro &lt;1&gt;") || !strings.Contains(body, "No narration yet") {
+		t.Errorf("html: %d %s", rec.Code, body)
+	}
+	checkResponse(t, get("pdf"), http.StatusBadRequest, "bad_request", "")
+}
+
+func TestDisclaimerAdminAuth(t *testing.T) {
+	put := func(h *Handler, auth, jurisdiction string) int {
+		req := httptest.NewRequest(http.MethodPut, "/admin/disclaimers/"+jurisdiction, strings.NewReader(`{"text":"Not investment advice."}`))
+		req = mux.SetURLVars(req, map[string]string{"jurisdiction": jurisdiction})
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		h.PutDisclaimer(rec, req)
+		return rec.Code
+	}
+
+	h := NewHandler(&fakeJobService{}, nil, nil, nil, nil, 100000, "monthly", 20, nil, "", "")
+	if code := put(h, "Bearer secret", "US"); code != http.StatusNotFound {
+		t.Errorf("not configured: expected 404, got %d", code)
+	}
+	h.SetDisclaimerService(services.NewDisclaimerService(nil), "secret")
+	if code := put(h, "", "US"); code != http.StatusUnauthorized {
+		t.Errorf("no token: expected 401, got %d", code)
+	}
+	if code := put(h, "Bearer wrong", "US"); code != http.StatusUnauthorized {
+		t.Errorf("wrong token: expected 401, got %d", code)
+	}
+	// Validation happens before the repository is used
+	if code := put(h, "Bearer secret", "U.S."); code != http.StatusBadRequest {
+		t.Errorf("invalid jurisdiction: expected 400, got %d", code)
+	}
+}
+
+// TestRedeliverWebhook_StatusCodes asserts redelivery is accepted and maps service errors.
+func TestRedeliverWebhook_StatusCodes(t *testing.T) {
+	deliveryID := uuid.New()
+	for _, tc := range []struct {
+		name string
+		id   string
+		err  error
+		want int
+		code string
+	}{
+		{"accepted", deliveryID.String(), nil, http.StatusAccepted, ""},
+		{"invalid id", "nope", nil, http.StatusBadRequest, "invalid_webhook_delivery_id"},
+		{"not found", deliveryID.String(), services.ErrWebhookDeliveryNotFound, http.StatusNotFound, "webhook_delivery_not_found"},
+		{"pending", deliveryID.String(), services.ErrWebhookDeliveryPending, http.StatusConflict, "webhook_delivery_pending"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newTestHandler(&fakeJobService{
+				redeliver: func(_ context.Context, id, _ uuid.UUID) (*models.WebhookDelivery, error) {
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					return &models.WebhookDelivery{ID: id, Status: "pending", Redeliveries: 1}, nil
+				},
+			})
+
+			rec := serveAsUser(t, h.RedeliverWebhook, http.MethodPost, "/v1/webhook-deliveries/"+tc.id+"/redeliver", map[string]string{"id": tc.id}, "")
+
+			checkResponse(t, rec, tc.want, tc.code, `"redeliveries":1`)
+		})
+	}
+}
+
+// TestUpdateEgressAllowlist_StatusCodes asserts invalid allow lists are rejected with 400.
+func TestUpdateEgressAllowlist_StatusCodes(t *testing.T) {
+	h := newTestHandler(&fakeJobService{})
+	for _, tc := range []struct {
+		name string
+		body string
+		want int
+		code string
+	}{
+		{"ok", `{"hosts":["hooks.example.com"]}`, http.StatusOK, ""},
+		{"invalid body", `{"hosts":`, http.StatusBadRequest, "invalid_request_body"},
+		{"invalid list", `{"hosts":["a.example.com","b.example.com"]}`, http.StatusBadRequest, "too_many_egress_hosts"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serveAsUser(t, h.UpdateEgressAllowlist, http.MethodPut, "/v1/egress-allowlist", nil, tc.body)
+
+			checkResponse(t, rec, tc.want, tc.code, `"hooks.example.com"`)
+		})
+	}
+}
+
+// TestCreateJob_FeatureFlagOff asserts 400 when fact_check_needed is asked for while the flag is off for
+// the user.
+func TestCreateJob_FeatureFlagOff(t *testing.T) {
+	h := NewHandler(
+		&fakeJobService{
+			createJob: func(context.Context, *models.CreateJobRequest, uuid.UUID, uuid.UUID) (*models.CreateJobResponse, error) {
+				t.Error("job created although fact_check is off")
+				return nil, nil
+			},
+		},
+		nil, nil, nil, nil,
+		100000, "monthly", 20, nil, "", "",
+	)
+	h.SetFeatureFlags(features.New(map[string]int{features.FactCheck: 0}, nil, time.Minute), nil)
+
+	body := bytes.NewBufferString(`{"text":"Hi","type":"educational","segments_count":2,"audio_type":"free_speech","fact_check_needed":true}`)
+	req := httptest.NewRequest(http.MethodPost, "/v1/jobs", body)
+	ctx := context.WithValue(req.Context(), auth.UserIDKey, uuid.New())
+	ctx = context.WithValue(ctx, auth.APIKeyIDKey, uuid.New())
+	rec := httptest.NewRecorder()
+
+	h.CreateJob(rec, req.WithContext(ctx))
+
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "feature_not_available") {
+		t.Errorf("expected 400 feature_not_available, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+type fakeMaintenanceStore struct{ state models.Maintenance }
+
+func (s fakeMaintenanceStore) Get(context.Context) (*models.Maintenance, error) { return &s.state, nil }
+
+// TestCreateJob_Maintenance asserts 503 with Retry-After while maintenance mode is on.
+func TestCreateJob_Maintenance(t *testing.T) {
+	h := NewHandler(
+		&fakeJobService{
+			createJob: func(context.Context, *models.CreateJobRequest, uuid.UUID, uuid.UUID) (*models.CreateJobResponse, error) {
+				t.Error("job created during maintenance")
+				return nil, nil
+			},
+		},
+		nil, nil, nil, nil,
+		100000, "monthly", 20, nil, "", "",
+	)
+	store := fakeMaintenanceStore{state: models.Maintenance{Enabled: true, RetryAfterSeconds: 120}}
+	h.SetMaintenance(maintenance.New(store, time.Minute), nil)
+
+	body := bytes.NewBufferString(`{"text":"Hi","type":"educational","segments_count":2,"audio_type":"free_speech"}`)
+	req := httptest.NewRequest(http.MethodPost, "/v1/jobs", body)
+	ctx := context.WithValue(req.Context(), auth.UserIDKey, uuid.New())
+	ctx = context.WithValue(ctx, auth.APIKeyIDKey, uuid.New())
+	rec := httptest.NewRecorder()
+
+	h.CreateJob(rec, req.WithContext(ctx))
+
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), `"code":"maintenance"`) {
+		t.Errorf("expected 503 maintenance, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Retry-After"); got != "120" {
+		t.Errorf("Retry-After = %q, want 120", got)
+	}
+}
+
+func TestCreateJob_LazyImagesNotEnabled(t *testing.T) {
+	h := NewHandler(
+		&fakeJobService{
+			createJob: func(context.Context, *models.CreateJobRequest, uuid.UUID, uuid.UUID) (*models.CreateJobResponse, error) {
+				t.Error("lazy_images job created without LAZY_IMAGES")
+				return nil, nil
+			},
+		},
+		nil, nil, nil, nil,
+		100000, "monthly", 20, nil, "", "",
+	)
+
+	body := bytes.NewBufferString(`{"text":"Hi","type":"educational","segments_count":2,"audio_type":"free_speech","lazy_images":true}`)
+	req := httptest.NewRequest(http.MethodPost, "/v1/jobs", body)
+	ctx := context.WithValue(req.Context(), auth.UserIDKey, uuid.New())
+	ctx = context.WithValue(ctx, auth.APIKeyIDKey, uuid.New())
+	rec := httptest.NewRecorder()
+
+	h.CreateJob(rec, req.WithContext(ctx))
+
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"code":"lazy_images_not_enabled"`) {
+		t.Errorf("expected 400 lazy_images_not_enabled, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// fakeJobStats scopes the aggregates to the user it is asked about.
+type fakeJobStats struct{ userID *uuid.UUID }
+
+func (f *fakeJobStats) Stats(ctx context.Context, userID *uuid.UUID, from time.Time) ([]models.JobStatsBucket, models.JobStatsBucket, error) {
+	f.userID = userID
+	return nil, models.JobStatsBucket{Jobs: 2, Succeeded: 2, CharsCharged: 300}, nil
+}
+
+func (f *fakeJobStats) TopFailureCodes(ctx context.Context, userID *uuid.UUID, from time.Time, limit int) ([]models.FailureCodeCount, error) {
+	return []models.FailureCodeCount{}, nil
+}
+
+func TestGetStats(t *testing.T) {
+	h := NewHandler(&fakeJobService{}, nil, nil, nil, nil, 100000, "monthly", 20, nil, "", "")
+	repo := &fakeJobStats{}
+	h.SetStatsService(services.NewStatsService(repo))
+	userID := uuid.New()
+
+	get := func(url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		rec := httptest.NewRecorder()
+		h.GetStats(rec, req.WithContext(context.WithValue(req.Context(), auth.UserIDKey, userID)))
+		return rec
+	}
+
+	rec := get("/v1/stats?window=7d")
+	var stats models.JobStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); rec.Code != http.StatusOK || err != nil {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if repo.userID == nil || *repo.userID != userID {
+		t.Errorf("stats of user %v, want %v", repo.userID, userID)
+	}
+	if len(stats.Days) != 7 || stats.Totals.Jobs != 2 || stats.Totals.SuccessRate == nil || *stats.Totals.SuccessRate != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	if rec := get("/v1/stats?window=2y"); rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"code":"invalid_stats_window"`) {
+		t.Errorf("expected 400 invalid_stats_window, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
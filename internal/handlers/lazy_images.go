@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/services"
+)
+
+// lazyImageRetryAfter is the Retry-After (seconds) of downloads of an image another replica is generating.
+const lazyImageRetryAfter = "5"
+
+// SetLazyImages sets the service generating the images of lazy_images jobs on their first download. Without
+// it jobs cannot be created with lazy_images.
+func (h *Handler) SetLazyImages(s *services.LazyImageService) {
+	h.lazyImages = s
+}
+
+// lazyImagesAvailable refuses jobs asking for lazy_images when this API cannot generate images, writing
+// the error response.
+func (h *Handler) lazyImagesAvailable(w http.ResponseWriter, r *http.Request, lazyImages bool) bool {
+	if lazyImages && h.lazyImages == nil {
+		writeJSONError(w, r, http.StatusBadRequest, "lazy_images is not enabled")
+		return false
+	}
+	return true
+}
+
+// generatePendingImage returns asset with its image generated when it is pending (lazy_images jobs), so its
+// content can be served. On failure it returns the status and message of the error response, with
+// Retry-After set when another replica is generating the image.
+func (h *Handler) generatePendingImage(w http.ResponseWriter, r *http.Request, asset *models.Asset) (*models.Asset, int, string) {
+	if !asset.Pending() {
+		return asset, 0, ""
+	}
+	if h.lazyImages == nil {
+		return nil, http.StatusServiceUnavailable, "image generation not available"
+	}
+	generated, err := h.lazyImages.Generate(r.Context(), asset)
+	if errors.Is(err, services.ErrImageGenerating) {
+		w.Header().Set("Retry-After", lazyImageRetryAfter)
+		return nil, http.StatusServiceUnavailable, "image is being generated"
+	}
+	if err != nil {
+		log.Error().Err(err).Str("asset_id", asset.ID.String()).Msg("Failed to generate lazy image")
+		return nil, http.StatusInternalServerError, "failed to generate image"
+	}
+	return generated, 0, ""
+}
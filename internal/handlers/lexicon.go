@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/lexicon"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// lexiconBody is the request and response body of /v1/lexicon
+type lexiconBody struct {
+	Entries []models.LexiconEntry `json:"entries"`
+}
+
+// GetLexicon handles GET /v1/lexicon: the user's pronunciation lexicon, applied to the narration of all
+// their jobs (jobs can add or override entries with lexicon).
+func (h *Handler) GetLexicon(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	user, err := h.userRepo.GetByID(r.Context(), userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get user")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to get lexicon")
+		return
+	}
+	entries := user.Lexicon
+	if entries == nil {
+		entries = []models.LexiconEntry{}
+	}
+	writeJSON(w, http.StatusOK, lexiconBody{Entries: entries})
+}
+
+// UpdateLexicon handles PUT /v1/lexicon with {"entries": [...]}, replacing the user's lexicon. Jobs
+// already generating audio pick up the change for segments not yet narrated.
+func (h *Handler) UpdateLexicon(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req lexiconBody
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := lexicon.Validate(req.Entries); err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.userRepo.SetLexicon(r.Context(), userID, req.Entries); err != nil {
+		log.Error().Err(err).Msg("Failed to update lexicon")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to update lexicon")
+		return
+	}
+	if req.Entries == nil {
+		req.Entries = []models.LexiconEntry{}
+	}
+	writeJSON(w, http.StatusOK, req)
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/maintenance"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/services"
+)
+
+// SetMaintenance sets the maintenance mode switch checked before jobs are created and the service behind
+// the /admin/maintenance endpoints (which also need the admin API token, see SetDisclaimerService).
+func (h *Handler) SetMaintenance(sw *maintenance.Switch, s *services.MaintenanceService) {
+	h.maintenance = sw
+	h.maintenanceService = s
+}
+
+// intakeOpen refuses requests that would create jobs while maintenance mode is on (503 with
+// Retry-After), writing the error response.
+func (h *Handler) intakeOpen(w http.ResponseWriter, r *http.Request) bool {
+	state := h.maintenance.State(r.Context())
+	if !state.Enabled {
+		return true
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(state.RetryAfterSeconds))
+	writeJSONError(w, r, http.StatusServiceUnavailable, "job intake is paused for maintenance")
+	return false
+}
+
+// GetMaintenance handles GET /admin/maintenance: whether maintenance mode is on.
+func (h *Handler) GetMaintenance(w http.ResponseWriter, r *http.Request) {
+	if !h.adminAuthorized(w, r) || !h.maintenanceEnabled(w, r) {
+		return
+	}
+	m, err := h.maintenanceService.GetMaintenance(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get maintenance mode")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to get maintenance mode")
+		return
+	}
+	writeJSON(w, http.StatusOK, m)
+}
+
+// PutMaintenance handles PUT /admin/maintenance: turns maintenance mode on (new jobs are refused, workers
+// pause after their in-flight jobs) or off.
+func (h *Handler) PutMaintenance(w http.ResponseWriter, r *http.Request) {
+	if !h.adminAuthorized(w, r) || !h.maintenanceEnabled(w, r) {
+		return
+	}
+	var req models.MaintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	m, err := h.maintenanceService.SetMaintenance(r.Context(), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidMaintenance) {
+			writeJSONError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Error().Err(err).Msg("Failed to set maintenance mode")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to set maintenance mode")
+		return
+	}
+	log.Warn().Bool("maintenance", m.Enabled).Str("reason", m.Reason).Msg("Maintenance mode set through the admin API")
+	writeJSON(w, http.StatusOK, m)
+}
+
+func (h *Handler) maintenanceEnabled(w http.ResponseWriter, r *http.Request) bool {
+	if h.maintenanceService == nil {
+		writeJSONError(w, r, http.StatusNotFound, "admin API not enabled")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// narrationDiffPage is the review page of GET /v1/jobs/{id}/narration-diff?format=html. Segment diffs
+// are already escaped HTML with <del> and <ins>.
+var narrationDiffPage = template.Must(template.New("narration_diff").Funcs(template.FuncMap{
+	"trusted": func(s string) template.HTML { return template.HTML(s) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Narration changes</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 720px; margin: 2rem auto; padding: 0 1rem; line-height: 1.5; }
+.segment { margin-bottom: 2rem; padding-bottom: 1.5rem; border-bottom: 1px solid #eee; }
+.counts { font-size: 0.85rem; color: #666; }
+.diff { white-space: pre-wrap; }
+del { background: #fde2e2; color: #9b1c1c; }
+ins { background: #def7ec; color: #03543f; text-decoration: none; }
+</style>
+</head>
+<body>
+<h1>Narration changes</h1>
+{{range .}}<div class="segment">
+<h2>{{.Idx}}. {{with .Title}}{{.}}{{end}}</h2>
+{{if .HasNarration}}<p class="counts">{{.RemovedWords}} words removed, {{.AddedWords}} added</p>
+<p class="diff">{{trusted .HTML}}</p>{{else}}<p class="counts">No narration yet</p>{{end}}
+</div>
+{{end}}</body>
+</html>
+`))
+
+// GetNarrationDiff handles GET /v1/jobs/{id}/narration-diff: per segment, what the narration script
+// changed relative to the source text, as JSON or, with ?format=html, a review page.
+func (h *Handler) GetNarrationDiff(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid job id")
+		return
+	}
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "html" {
+		writeJSONError(w, r, http.StatusBadRequest, "format must be json or html")
+		return
+	}
+
+	diffs, err := h.jobService.NarrationDiff(r.Context(), jobID, userID)
+	if err != nil {
+		log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to diff narration")
+		writeJSONError(w, r, http.StatusNotFound, "job not found")
+		return
+	}
+	if format != "html" {
+		writeJSON(w, http.StatusOK, map[string]any{"segments": diffs})
+		return
+	}
+
+	var b bytes.Buffer
+	if err := narrationDiffPage.Execute(&b, numberedDiffs(diffs)); err != nil {
+		log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to render narration diff")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to render diff")
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(b.Bytes())
+}
+
+// numberedDiffs returns copies of diffs with 1-based Idx for display.
+func numberedDiffs(diffs []*models.SegmentNarrationDiff) []models.SegmentNarrationDiff {
+	out := make([]models.SegmentNarrationDiff, len(diffs))
+	for i, d := range diffs {
+		out[i] = *d
+		out[i].Idx++
+	}
+	return out
+}
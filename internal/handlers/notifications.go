@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/services"
+)
+
+// SetNotificationService sets the service behind the /v1/notification-sinks endpoints.
+func (h *Handler) SetNotificationService(s *services.NotificationService) {
+	h.notificationService = s
+}
+
+// CreateNotificationSink handles POST /v1/notification-sinks: adds a sink receiving the job events of
+// all of the user's jobs.
+func (h *Handler) CreateNotificationSink(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.CreateNotificationSinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	sink, err := h.notificationService.CreateSink(r.Context(), userID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidNotificationSink) {
+			writeJSONError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Error().Err(err).Msg("Failed to create notification sink")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to create notification sink")
+		return
+	}
+	writeJSON(w, http.StatusCreated, sink)
+}
+
+// ListNotificationSinks handles GET /v1/notification-sinks
+func (h *Handler) ListNotificationSinks(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	sinks, err := h.notificationService.ListSinks(r.Context(), userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list notification sinks")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to list notification sinks")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"sinks": sinks})
+}
+
+// DeleteNotificationSink handles DELETE /v1/notification-sinks/{id}
+func (h *Handler) DeleteNotificationSink(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	sinkID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid notification sink id")
+		return
+	}
+
+	if err := h.notificationService.DeleteSink(r.Context(), userID, sinkID); err != nil {
+		if errors.Is(err, database.ErrNotificationSinkNotFound) {
+			writeJSONError(w, r, http.StatusNotFound, "notification sink not found")
+			return
+		}
+		log.Error().Err(err).Msg("Failed to delete notification sink")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to delete notification sink")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// emailNotificationsResponse is the body of GET and PUT /v1/email-notifications
+type emailNotificationsResponse struct {
+	Email   *string `json:"email"`
+	Enabled bool    `json:"enabled"`
+}
+
+// GetEmailNotifications handles GET /v1/email-notifications: whether the user is emailed when their
+// jobs complete or fail (jobs can override it with notify_email).
+func (h *Handler) GetEmailNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	user, err := h.userRepo.GetByID(r.Context(), userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get user")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to get email notifications")
+		return
+	}
+	writeJSON(w, http.StatusOK, emailNotificationsResponse{Email: user.Email, Enabled: user.EmailNotifications})
+}
+
+// UpdateEmailNotifications handles PUT /v1/email-notifications with {"enabled": true|false}
+func (h *Handler) UpdateEmailNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Enabled == nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid request body: enabled is required")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(r.Context(), userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get user")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to update email notifications")
+		return
+	}
+	if *req.Enabled && (user.Email == nil || *user.Email == "") {
+		writeJSONError(w, r, http.StatusBadRequest, "user has no email address")
+		return
+	}
+	if err := h.userRepo.SetEmailNotifications(r.Context(), userID, *req.Enabled); err != nil {
+		log.Error().Err(err).Msg("Failed to update email notifications")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to update email notifications")
+		return
+	}
+	writeJSON(w, http.StatusOK, emailNotificationsResponse{Email: user.Email, Enabled: *req.Enabled})
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/ratelimit"
+)
+
+// RateLimit limits the requests of each API key. It runs after auth.Middleware, which sets the key.
+type RateLimit struct {
+	Limiter   ratelimit.Limiter
+	PerMinute int // reported in X-RateLimit-Limit
+}
+
+// Middleware answers 429 with Retry-After once the key's bucket is empty. Responses carry
+// X-RateLimit-Limit and X-RateLimit-Remaining. When the limiter fails (Redis down) requests go through.
+func (l RateLimit) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keyID, err := auth.GetAPIKeyID(r.Context())
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		res, err := l.Limiter.Allow(r.Context(), keyID.String())
+		if err != nil {
+			log.Warn().Err(err).Str("key_id", keyID.String()).Msg("Rate limiter failed; allowing request")
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(l.PerMinute))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		if !res.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds()))))
+			writeJSONError(w, r, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/ratelimit"
+)
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(context.Context, string) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("redis down")
+}
+
+func TestRateLimit(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := RateLimit{Limiter: ratelimit.NewMemoryLimiter(60, 1), PerMinute: 60}.Middleware(ok)
+	request := func(keyID uuid.UUID) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/v1/jobs", nil)
+		r.Header.Set("Accept-Language", "de")
+		r = r.WithContext(context.WithValue(r.Context(), auth.APIKeyIDKey, keyID))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	key := uuid.New()
+	if w := request(key); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("first request = %d remaining %q, want 200 remaining 0", w.Code, w.Header().Get("X-RateLimit-Remaining"))
+	}
+	w := request(key)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" ||
+		!strings.Contains(w.Body.String(), `"code":"rate_limited"`) || !strings.Contains(w.Body.String(), "Anfragelimit") {
+		t.Errorf("second request = %d Retry-After %q %s, want 429 Retry-After 1 rate_limited",
+			w.Code, w.Header().Get("Retry-After"), w.Body)
+	}
+	if w := request(uuid.New()); w.Code != http.StatusOK {
+		t.Errorf("other key = %d, want 200", w.Code)
+	}
+
+	h = RateLimit{Limiter: failingLimiter{}, PerMinute: 60}.Middleware(ok)
+	if w := request(key); w.Code != http.StatusOK {
+		t.Errorf("failing limiter = %d, want 200", w.Code)
+	}
+}
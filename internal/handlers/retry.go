@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/services"
+)
+
+// RetryJob handles POST /v1/jobs/{id}/retry: a failed job is queued again (202 with the job) and the
+// worker regenerates only what did not succeed; other jobs and duplicates get 409.
+func (h *Handler) RetryJob(w http.ResponseWriter, r *http.Request) {
+	jobID, userID, ok := parseReviewRequest(w, r)
+	if !ok {
+		return
+	}
+
+	resp, err := h.jobService.RetryJob(r.Context(), jobID, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrJobNotRetryable) || errors.Is(err, services.ErrDuplicateNotRetryable) {
+			writeJSONError(w, r, http.StatusConflict, err.Error())
+			return
+		}
+		log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to retry job")
+		writeJSONError(w, r, http.StatusNotFound, "job not found")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, resp)
+}
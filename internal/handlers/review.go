@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/services"
+)
+
+// ApproveJob handles POST /v1/jobs/{id}/review/approve (jobs created with require_review).
+// The body ({"comment": "..."}) is optional.
+func (h *Handler) ApproveJob(w http.ResponseWriter, r *http.Request) {
+	jobID, userID, ok := parseReviewRequest(w, r)
+	if !ok {
+		return
+	}
+	var req models.ReviewApproval
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	resp, err := h.jobService.ApproveJob(r.Context(), jobID, userID, &req)
+	if err != nil {
+		writeReviewError(w, r, err, jobID, "Failed to approve job")
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// RegenerateJobSegments handles POST /v1/jobs/{id}/review/regenerate: the job goes back to the worker to
+// regenerate the listed segments and returns to awaiting_review.
+func (h *Handler) RegenerateJobSegments(w http.ResponseWriter, r *http.Request) {
+	jobID, userID, ok := parseReviewRequest(w, r)
+	if !ok {
+		return
+	}
+	var req models.ReviewRegeneration
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	resp, err := h.jobService.RequestSegmentRegeneration(r.Context(), jobID, userID, &req)
+	if err != nil {
+		writeReviewError(w, r, err, jobID, "Failed to request segment regeneration")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, resp)
+}
+
+// parseReviewRequest reads the job id and caller of a review action (and of a cancellation, retry or deletion).
+// It writes the error response and returns ok=false when the request is invalid.
+func parseReviewRequest(w http.ResponseWriter, r *http.Request) (jobID, userID uuid.UUID, ok bool) {
+	jobID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid job id")
+		return
+	}
+	userID, err = auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	return jobID, userID, true
+}
+
+// writeReviewError maps review action errors: validation → 400, job not awaiting review → 409, expired job → 410,
+// anything else → 404.
+func writeReviewError(w http.ResponseWriter, r *http.Request, err error, jobID uuid.UUID, msg string) {
+	switch {
+	case strings.HasPrefix(err.Error(), "validation error"):
+		writeJSONError(w, r, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrJobNotAwaitingReview):
+		writeJSONError(w, r, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrJobExpired):
+		writeJSONError(w, r, http.StatusGone, "job expired")
+	default:
+		log.Error().Err(err).Str("job_id", jobID.String()).Msg(msg)
+		writeJSONError(w, r, http.StatusNotFound, "job not found")
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/services"
+	"github.com/snappy-loop/stories/internal/teleprompter"
+)
+
+// GetJobScript handles GET /v1/jobs/{id}/script?format=txt|html|docx: the job's narration scripts as a
+// teleprompter document download (default txt).
+func (h *Handler) GetJobScript(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid job id")
+		return
+	}
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = teleprompter.FormatText
+	}
+	contentType := teleprompter.ContentType(format)
+	if contentType == "" {
+		writeJSONError(w, r, http.StatusBadRequest, "format must be txt, html or docx")
+		return
+	}
+
+	script, err := h.jobService.GetScript(r.Context(), jobID, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrNoScript) {
+			writeJSONError(w, r, http.StatusConflict, err.Error())
+			return
+		}
+		log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to get job script")
+		writeJSONError(w, r, http.StatusNotFound, "job not found")
+		return
+	}
+	body, err := teleprompter.Render(script, format)
+	if err != nil {
+		log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to render job script")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to render script")
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="script-%s.%s"`, jobID, format))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
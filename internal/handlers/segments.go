@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/services"
+)
+
+// UpdateSegment handles PATCH /v1/jobs/{id}/segments/{idx}: edits a segment's text or narration and queues
+// regeneration of only that segment's audio (and image with regenerate_image).
+func (h *Handler) UpdateSegment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jobID, err := uuid.Parse(vars["id"])
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid job id")
+		return
+	}
+	idx, err := strconv.Atoi(vars["idx"])
+	if err != nil || idx < 0 {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid segment index")
+		return
+	}
+
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.UpdateSegmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	segment, err := h.jobService.UpdateSegment(r.Context(), jobID, userID, idx, &req)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "validation error"):
+			writeJSONError(w, r, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrSegmentNotEditable):
+			writeJSONError(w, r, http.StatusConflict, err.Error())
+		default:
+			log.Error().Err(err).Str("job_id", jobID.String()).Int("segment", idx).Msg("Failed to update segment")
+			writeJSONError(w, r, http.StatusNotFound, "segment not found")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, segment)
+}
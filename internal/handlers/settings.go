@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/services"
+)
+
+// GetSettings handles GET /v1/settings: the user's defaults for fields omitted from new job requests.
+func (h *Handler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	settings, err := h.jobService.GetSettings(r.Context(), userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get settings")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to get settings")
+		return
+	}
+	writeJSON(w, http.StatusOK, settings)
+}
+
+// UpdateSettings handles PUT /v1/settings, replacing the user's job defaults (an empty object clears
+// them). Jobs already created keep their options.
+func (h *Handler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req models.UserSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	settings, err := h.jobService.UpdateSettings(r.Context(), userID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSettings) {
+			writeJSONError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Error().Err(err).Msg("Failed to update settings")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to update settings")
+		return
+	}
+	writeJSON(w, http.StatusOK, settings)
+}
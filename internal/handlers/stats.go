@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/services"
+)
+
+// SetStatsService sets the service behind GET /v1/stats and GET /admin/stats (which also needs the admin
+// API token, see SetDisclaimerService).
+func (h *Handler) SetStatsService(s *services.StatsService) {
+	h.statsService = s
+}
+
+// GetStats handles GET /v1/stats?window=30d: the user's jobs per day, success rates, average durations,
+// characters charged and most frequent failure codes.
+func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if h.statsService == nil {
+		writeJSONError(w, r, http.StatusNotFound, "not found")
+		return
+	}
+	h.writeStats(w, r, &userID)
+}
+
+// GetAdminStats handles GET /admin/stats?window=30d[&user_id=...]: GetStats over all users, or one.
+func (h *Handler) GetAdminStats(w http.ResponseWriter, r *http.Request) {
+	if !h.adminAuthorized(w, r) {
+		return
+	}
+	if h.statsService == nil {
+		writeJSONError(w, r, http.StatusNotFound, "admin API not enabled")
+		return
+	}
+	var userID *uuid.UUID
+	if raw := r.URL.Query().Get("user_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			writeJSONError(w, r, http.StatusBadRequest, "invalid user id")
+			return
+		}
+		userID = &id
+	}
+	h.writeStats(w, r, userID)
+}
+
+func (h *Handler) writeStats(w http.ResponseWriter, r *http.Request, userID *uuid.UUID) {
+	stats, err := h.statsService.Stats(r.Context(), userID, r.URL.Query().Get("window"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidStatsWindow) {
+			writeJSONError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Error().Err(err).Msg("Failed to get stats")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to get stats")
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
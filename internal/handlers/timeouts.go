@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// Timeouts bounds the time the API spends on a request. The server itself sets no read or write timeout
+// (only ReadHeaderTimeout): Middleware applies Request to every route except those registered with
+// Upload, Stream or Unbounded, whose deadlines fit large bodies, long downloads and WebSockets.
+type Timeouts struct {
+	Request time.Duration // reading the body and producing the response of ordinary endpoints (0: no limit)
+	Upload  time.Duration // reading the body and responding for file and asset uploads (0: no limit)
+	Stream  time.Duration // writing asset downloads (0: no limit)
+}
+
+// timeoutGrace keeps the connection open after Timeouts.Request so the 503 of TimeoutHandler gets out
+const timeoutGrace = 5 * time.Second
+
+// routeKind is how long a route's requests may take
+type routeKind int
+
+const (
+	routeUpload routeKind = iota + 1
+	routeStream
+	routeUnbounded
+)
+
+// longRoute is a handler registered with Upload, Stream or Unbounded
+type longRoute struct {
+	http.HandlerFunc
+	kind routeKind
+}
+
+// Upload marks a route receiving large bodies (Timeouts.Upload applies).
+func Upload(h http.HandlerFunc) http.Handler { return longRoute{h, routeUpload} }
+
+// Stream marks a route streaming large responses (Timeouts.Stream applies).
+func Stream(h http.HandlerFunc) http.Handler { return longRoute{h, routeStream} }
+
+// Unbounded marks a route managing its own deadlines, such as a WebSocket.
+func Unbounded(h http.HandlerFunc) http.Handler { return longRoute{h, routeUnbounded} }
+
+// Middleware applies the route's deadlines. Ordinary routes run under http.TimeoutHandler: past Request
+// the client gets a 503 {"error", "code": "timeout"} and the handler's context is canceled; connection
+// deadlines shortly after stop slow request bodies. Long routes only get connection deadlines (through
+// http.ResponseController), as TimeoutHandler buffers the response and cannot flush or hijack.
+func (t Timeouts) Middleware(next http.Handler) http.Handler {
+	bounded := next
+	if t.Request > 0 {
+		bounded = http.TimeoutHandler(next, t.Request, timeoutBody())
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		kind := routeKindOf(r)
+		if kind == 0 {
+			setDeadline(w, r, t.Request+timeoutGrace)
+			bounded.ServeHTTP(w, r)
+			return
+		}
+		switch kind {
+		case routeUpload:
+			setDeadline(w, r, t.Upload)
+		case routeStream:
+			setDeadline(w, r, t.Stream)
+		case routeUnbounded:
+			setDeadline(w, r, 0)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// routeKindOf returns the kind of the route r matched (0 for ordinary routes).
+func routeKindOf(r *http.Request) routeKind {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return 0
+	}
+	if lr, ok := route.GetHandler().(longRoute); ok {
+		return lr.kind
+	}
+	return 0
+}
+
+// setDeadline sets the connection's read and write deadlines to d from now; 0 removes them. The read
+// deadline must not pass before the response is done: the server's background read then fails and
+// cancels the request's context.
+func setDeadline(w http.ResponseWriter, r *http.Request, d time.Duration) {
+	var deadline time.Time
+	if d > 0 {
+		deadline = time.Now().Add(d)
+	}
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Warn().Err(err).Str("path", r.URL.Path).Msg("Failed to set read deadline")
+	}
+	if err := rc.SetWriteDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Warn().Err(err).Str("path", r.URL.Path).Msg("Failed to set write deadline")
+	}
+}
+
+// timeoutBody is the error TimeoutHandler writes, shaped like writeJSONError's (TimeoutHandler sends it as
+// text/plain).
+func timeoutBody() string {
+	b, _ := json.Marshal(map[string]string{"error": "request timed out", "code": "timeout"})
+	return string(b)
+}
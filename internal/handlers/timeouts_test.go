@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// TestTimeouts asserts ordinary routes are cut at Request while streaming routes outlive it.
+func TestTimeouts(t *testing.T) {
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+			w.Write([]byte("done"))
+		case <-r.Context().Done():
+		}
+	}
+	r := mux.NewRouter()
+	r.Use(Timeouts{Request: 50 * time.Millisecond, Upload: time.Second, Stream: time.Second}.Middleware)
+	r.HandleFunc("/slow", slow)
+	r.Handle("/stream", Stream(slow))
+	r.Handle("/upload", Upload(slow))
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"/upload", http.StatusOK, "done"},
+		{"/stream", http.StatusOK, "done"},
+		{"/slow", http.StatusServiceUnavailable, `"code":"timeout"`},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(srv.URL + tt.path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", tt.path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.wantStatus || !strings.Contains(string(body), tt.wantBody) {
+			t.Errorf("GET %s = %d %s, want %d %s", tt.path, resp.StatusCode, body, tt.wantStatus, tt.wantBody)
+		}
+	}
+}
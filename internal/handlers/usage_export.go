@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/services"
+)
+
+// usageCSVHeader is the header row of the CSV usage export, one column per models.UserUsageTotal field.
+var usageCSVHeader = []string{"period", "user_id", "email", "jobs", "chars", "input_tokens", "output_tokens", "audio_seconds", "images", "cost_usd"}
+
+// SetUsageExportService sets the service behind GET /admin/usage/export (which also needs the admin API
+// token, see SetDisclaimerService).
+func (h *Handler) SetUsageExportService(s *services.UsageExportService) {
+	h.usageExportService = s
+}
+
+// ExportUsage handles GET /admin/usage/export?period=2026-09&format=json|csv: each user's characters,
+// tokens, audio seconds, images and estimated cost in a month, from the usage ledger (default the previous
+// month, as JSON).
+func (h *Handler) ExportUsage(w http.ResponseWriter, r *http.Request) {
+	if !h.adminAuthorized(w, r) {
+		return
+	}
+	if h.usageExportService == nil {
+		writeJSONError(w, r, http.StatusNotFound, "admin API not enabled")
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		writeJSONError(w, r, http.StatusBadRequest, "format must be json or csv")
+		return
+	}
+
+	export, err := h.usageExportService.Export(r.Context(), r.URL.Query().Get("period"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidUsagePeriod) {
+			writeJSONError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Error().Err(err).Msg("Failed to export usage")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to export usage")
+		return
+	}
+	if format != "csv" {
+		writeJSON(w, http.StatusOK, export)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="usage-%s.csv"`, export.Period))
+	w.WriteHeader(http.StatusOK)
+	cw := csv.NewWriter(w)
+	_ = cw.Write(usageCSVHeader)
+	for _, u := range export.Users {
+		_ = cw.Write(usageCSVRow(export.Period, u))
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		log.Warn().Err(err).Msg("Failed to write usage export")
+	}
+}
+
+func usageCSVRow(period string, u models.UserUsageTotal) []string {
+	return []string{
+		period,
+		u.UserID.String(),
+		u.Email,
+		strconv.Itoa(u.Jobs),
+		strconv.FormatInt(u.Chars, 10),
+		strconv.FormatInt(u.InputTokens, 10),
+		strconv.FormatInt(u.OutputTokens, 10),
+		strconv.FormatFloat(u.AudioSeconds, 'f', 3, 64),
+		strconv.FormatInt(u.Images, 10),
+		strconv.FormatFloat(u.CostUSD, 'f', 6, 64),
+	}
+}
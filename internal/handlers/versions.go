@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/services"
+)
+
+// ListOutputVersions handles GET /v1/jobs/{id}/versions: the job's output versions, oldest first.
+func (h *Handler) ListOutputVersions(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid job id")
+		return
+	}
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	versions, err := h.jobService.ListOutputVersions(r.Context(), jobID, userID)
+	if err != nil {
+		log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to list output versions")
+		writeJSONError(w, r, http.StatusNotFound, "job not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"versions": versions})
+}
+
+// GetOutputVersion handles GET /v1/jobs/{id}/versions/{version}: one version with its markup and segment texts.
+func (h *Handler) GetOutputVersion(w http.ResponseWriter, r *http.Request) {
+	jobID, version, ok := parseVersionVars(w, r)
+	if !ok {
+		return
+	}
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	v, err := h.jobService.GetOutputVersion(r.Context(), jobID, userID, version)
+	if err != nil {
+		log.Error().Err(err).Str("job_id", jobID.String()).Int("version", version).Msg("Failed to get output version")
+		writeJSONError(w, r, http.StatusNotFound, "version not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+// RestoreOutputVersion handles POST /v1/jobs/{id}/versions/{version}/restore: makes the version's markup,
+// segment texts and assets current again, recorded as a new version.
+func (h *Handler) RestoreOutputVersion(w http.ResponseWriter, r *http.Request) {
+	jobID, version, ok := parseVersionVars(w, r)
+	if !ok {
+		return
+	}
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	v, err := h.jobService.RestoreOutputVersion(r.Context(), jobID, userID, version)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "validation error"):
+			writeJSONError(w, r, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrOutputNotRestorable):
+			writeJSONError(w, r, http.StatusConflict, err.Error())
+		default:
+			log.Error().Err(err).Str("job_id", jobID.String()).Int("version", version).Msg("Failed to restore output version")
+			writeJSONError(w, r, http.StatusNotFound, "version not found")
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+// parseVersionVars reads the job ID and version number from the route, writing 400 when either is invalid.
+func parseVersionVars(w http.ResponseWriter, r *http.Request) (uuid.UUID, int, bool) {
+	vars := mux.Vars(r)
+	jobID, err := uuid.Parse(vars["id"])
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid job id")
+		return uuid.Nil, 0, false
+	}
+	version, err := strconv.Atoi(vars["version"])
+	if err != nil || version < 1 {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid version")
+		return uuid.Nil, 0, false
+	}
+	return jobID, version, true
+}
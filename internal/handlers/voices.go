@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/services"
+)
+
+// SetVoiceService sets the service behind the /v1/voices endpoints.
+func (h *Handler) SetVoiceService(s *services.VoiceService) {
+	h.voiceService = s
+}
+
+// CreateVoice handles POST /v1/voices: registers a custom voice with its TTS provider; jobs select it
+// with voice_id.
+func (h *Handler) CreateVoice(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req models.CreateVoiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	voice, err := h.voiceService.CreateVoice(r.Context(), userID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidVoice) {
+			writeJSONError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Error().Err(err).Msg("Failed to create voice")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to create voice")
+		return
+	}
+	writeJSON(w, http.StatusCreated, voice)
+}
+
+// ListVoices handles GET /v1/voices
+func (h *Handler) ListVoices(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	voices, err := h.voiceService.ListVoices(r.Context(), userID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list voices")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to list voices")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"voices": voices})
+}
+
+// DeleteVoice handles DELETE /v1/voices/{id}; jobs using the voice are narrated with the default voice.
+func (h *Handler) DeleteVoice(w http.ResponseWriter, r *http.Request) {
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	voiceID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid voice id")
+		return
+	}
+
+	if err := h.voiceService.DeleteVoice(r.Context(), userID, voiceID); err != nil {
+		if errors.Is(err, database.ErrVoiceNotFound) {
+			writeJSONError(w, r, http.StatusNotFound, "voice not found")
+			return
+		}
+		log.Error().Err(err).Msg("Failed to delete voice")
+		writeJSONError(w, r, http.StatusInternalServerError, "failed to delete voice")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/services"
+)
+
+// GetWebhookDelivery handles GET /v1/webhook-deliveries/{id}: a job's webhook delivery with every
+// attempt and the payload it sent. Delivery IDs are in the job's webhook_* events.
+func (h *Handler) GetWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	deliveryID, userID, ok := parseDeliveryRequest(w, r)
+	if !ok {
+		return
+	}
+	delivery, err := h.jobService.GetWebhookDelivery(r.Context(), deliveryID, userID)
+	if err != nil {
+		writeDeliveryError(w, r, err, "failed to get webhook delivery")
+		return
+	}
+	writeJSON(w, http.StatusOK, delivery)
+}
+
+// RedeliverWebhook handles POST /v1/webhook-deliveries/{id}/redeliver: sends a sent or failed delivery
+// again with its original payload, without re-running the job. The dispatcher sends it shortly (202).
+func (h *Handler) RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	deliveryID, userID, ok := parseDeliveryRequest(w, r)
+	if !ok {
+		return
+	}
+	delivery, err := h.jobService.RedeliverWebhook(r.Context(), deliveryID, userID)
+	if err != nil {
+		writeDeliveryError(w, r, err, "failed to redeliver webhook")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, delivery)
+}
+
+// parseDeliveryRequest reads the delivery id and caller. It writes the error response and returns
+// ok=false when the request is invalid.
+func parseDeliveryRequest(w http.ResponseWriter, r *http.Request) (deliveryID, userID uuid.UUID, ok bool) {
+	userID, err := auth.GetUserID(r.Context())
+	if err != nil {
+		writeJSONError(w, r, http.StatusUnauthorized, "unauthorized")
+		return uuid.Nil, uuid.Nil, false
+	}
+	deliveryID, err = uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "invalid webhook delivery id")
+		return uuid.Nil, uuid.Nil, false
+	}
+	return deliveryID, userID, true
+}
+
+// writeDeliveryError maps webhook delivery service errors to responses.
+func writeDeliveryError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	switch {
+	case errors.Is(err, services.ErrWebhookDeliveryNotFound):
+		writeJSONError(w, r, http.StatusNotFound, "webhook delivery not found")
+	case errors.Is(err, services.ErrWebhookDeliveryPending):
+		writeJSONError(w, r, http.StatusConflict, "webhook delivery is still pending")
+	default:
+		log.Error().Err(err).Msg(msg)
+		writeJSONError(w, r, http.StatusInternalServerError, msg)
+	}
+}
@@ -0,0 +1,275 @@
+// Package hooks runs customer hooks around pipeline stages: in-process Go plugins registered with
+// RegisterPlugin and external HTTP callbacks, configured with PIPELINE_HOOKS. Hooks at
+// after_segmentation may rewrite segment titles and texts, hooks at before_tts the script spoken for a
+// segment; after_asset_upload hooks are notified of each stored asset.
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/egress"
+)
+
+// Pipeline stages hooks run at
+const (
+	StageAfterSegmentation = "after_segmentation" // segments are known, before they are saved
+	StageBeforeTTS         = "before_tts"         // a segment's script is final, before audio is generated
+	StageAfterAssetUpload  = "after_asset_upload" // an audio or image asset was stored
+)
+
+// Limits of configured hooks
+const (
+	maxHooks       = 20
+	DefaultTimeout = 10 * time.Second
+	MaxTimeout     = 60 * time.Second
+)
+
+// Segment is a segment as hooks see it; at after_segmentation Title and Text may be changed.
+type Segment struct {
+	ID    uuid.UUID `json:"id"`
+	Idx   int       `json:"idx"`
+	Title string    `json:"title"`
+	Text  string    `json:"text"`
+}
+
+// Asset is a stored asset as after_asset_upload hooks see it.
+type Asset struct {
+	ID        uuid.UUID `json:"id"`
+	SegmentID uuid.UUID `json:"segment_id"`
+	Kind      string    `json:"kind"`
+	MimeType  string    `json:"mime_type"`
+	S3Key     string    `json:"s3_key"`
+	SizeBytes int64     `json:"size_bytes"`
+}
+
+// Event is what a hook receives. Hooks change it in place (plugins) or return it changed (HTTP); only the
+// fields of the stage's payload are read back: Segments at after_segmentation, Script at before_tts.
+type Event struct {
+	Stage     string            `json:"stage"`
+	JobID     uuid.UUID         `json:"job_id"`
+	InputType string            `json:"input_type"`
+	AudioType string            `json:"audio_type"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Segments  []Segment         `json:"segments,omitempty"` // after_segmentation
+	Segment   *Segment          `json:"segment,omitempty"`  // before_tts and after_asset_upload
+	Script    string            `json:"script,omitempty"`   // before_tts
+	Asset     *Asset            `json:"asset,omitempty"`    // after_asset_upload
+}
+
+// Hook is a transform or listener run at a stage.
+type Hook interface {
+	Run(ctx context.Context, ev *Event) error
+}
+
+// HookFunc adapts a function to Hook.
+type HookFunc func(ctx context.Context, ev *Event) error
+
+// Run implements Hook.
+func (f HookFunc) Run(ctx context.Context, ev *Event) error { return f(ctx, ev) }
+
+var (
+	pluginsMu sync.RWMutex
+	plugins   = make(map[string]Hook)
+)
+
+// RegisterPlugin makes a Go hook available to PIPELINE_HOOKS under name. Call it from an init function
+// of a package imported by the worker; registering a name twice panics.
+func RegisterPlugin(name string, h Hook) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	if _, ok := plugins[name]; ok {
+		panic("hooks: plugin " + name + " registered twice")
+	}
+	plugins[name] = h
+}
+
+func plugin(name string) (Hook, bool) {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	h, ok := plugins[name]
+	return h, ok
+}
+
+// Spec configures one hook: exactly one of Plugin (a registered plugin) and URL (an HTTP callback).
+// Failures of a Required hook fail the step (the segment, or the job at after_segmentation); other
+// failures are logged and the stage continues with the event as it was before the hook.
+type Spec struct {
+	Stage    string `json:"stage"`
+	Plugin   string `json:"plugin,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Secret   string `json:"secret,omitempty"`  // signs HTTP requests like job webhooks (X-GS-Signature)
+	Timeout  string `json:"timeout,omitempty"` // e.g. "5s"; default 10s, at most 60s
+	Required bool   `json:"required,omitempty"`
+}
+
+// name identifies a hook in logs and errors.
+func (s Spec) name() string {
+	if s.Plugin != "" {
+		return "plugin " + s.Plugin
+	}
+	return "callback " + s.URL
+}
+
+type entry struct {
+	spec    Spec
+	hook    Hook
+	timeout time.Duration
+}
+
+// Registry holds the configured hooks by stage, in configuration order. A nil *Registry has no hooks.
+type Registry struct {
+	byStage map[string][]entry
+}
+
+// Parse parses the PIPELINE_HOOKS setting, a JSON array of hook specs. Plugins must be registered before.
+// Empty means no hooks. Callback URLs must pass policy, whose client sends the callbacks.
+func Parse(spec string, policy *egress.Policy) (*Registry, error) {
+	r := &Registry{byStage: make(map[string][]entry)}
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return r, nil
+	}
+	var specs []Spec
+	if err := json.Unmarshal([]byte(spec), &specs); err != nil {
+		return nil, fmt.Errorf("invalid PIPELINE_HOOKS: %w", err)
+	}
+	if len(specs) > maxHooks {
+		return nil, fmt.Errorf("invalid PIPELINE_HOOKS: more than %d hooks", maxHooks)
+	}
+	for i, s := range specs {
+		e, err := newEntry(s, policy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PIPELINE_HOOKS: hook %d: %w", i, err)
+		}
+		r.byStage[s.Stage] = append(r.byStage[s.Stage], e)
+	}
+	return r, nil
+}
+
+// newEntry validates a spec and resolves its hook.
+func newEntry(s Spec, policy *egress.Policy) (entry, error) {
+	switch s.Stage {
+	case StageAfterSegmentation, StageBeforeTTS, StageAfterAssetUpload:
+	default:
+		return entry{}, fmt.Errorf("unknown stage %q", s.Stage)
+	}
+	e := entry{spec: s, timeout: DefaultTimeout}
+	if s.Timeout != "" {
+		d, err := time.ParseDuration(s.Timeout)
+		if err != nil || d <= 0 || d > MaxTimeout {
+			return entry{}, fmt.Errorf("timeout must be a duration up to %s, got %q", MaxTimeout, s.Timeout)
+		}
+		e.timeout = d
+	}
+	switch {
+	case (s.Plugin == "") == (s.URL == ""):
+		return entry{}, fmt.Errorf("exactly one of plugin and url is required")
+	case s.Plugin != "":
+		h, ok := plugin(s.Plugin)
+		if !ok {
+			return entry{}, fmt.Errorf("unknown plugin %q", s.Plugin)
+		}
+		e.hook = h
+	default:
+		h, err := newHTTPHook(s.URL, s.Secret, policy)
+		if err != nil {
+			return entry{}, err
+		}
+		e.hook = h
+	}
+	return e, nil
+}
+
+// Has reports whether any hook runs at stage, so callers can skip building its event.
+func (r *Registry) Has(stage string) bool {
+	return r != nil && len(r.byStage[stage]) > 0
+}
+
+// Run runs the hooks of ev.Stage in order, each on the event the previous one returned. A failing
+// optional hook is logged and its changes dropped; a failing required hook stops the run with its error.
+func (r *Registry) Run(ctx context.Context, ev *Event) error {
+	if !r.Has(ev.Stage) {
+		return nil
+	}
+	for _, e := range r.byStage[ev.Stage] {
+		candidate := ev.clone()
+		started := time.Now()
+		hookCtx, cancel := context.WithTimeout(ctx, e.timeout)
+		err := e.hook.Run(hookCtx, candidate)
+		cancel()
+		if err == nil {
+			err = candidate.check(ev)
+		}
+		logger := log.With().
+			Str("job_id", ev.JobID.String()).
+			Str("stage", ev.Stage).
+			Str("hook", e.spec.name()).
+			Int64("duration_ms", time.Since(started).Milliseconds()).
+			Logger()
+		if err != nil {
+			if e.spec.Required {
+				return fmt.Errorf("%s hook %s failed: %w", ev.Stage, e.spec.name(), err)
+			}
+			logger.Warn().Err(err).Msg("Pipeline hook failed, continuing without its changes")
+			continue
+		}
+		ev.apply(candidate)
+		logger.Debug().Msg("Pipeline hook ran")
+	}
+	return nil
+}
+
+// clone copies the event so a failing hook cannot leave partial changes.
+func (ev *Event) clone() *Event {
+	c := *ev
+	c.Segments = append([]Segment(nil), ev.Segments...)
+	if ev.Segment != nil {
+		s := *ev.Segment
+		c.Segment = &s
+	}
+	if ev.Asset != nil {
+		a := *ev.Asset
+		c.Asset = &a
+	}
+	return &c
+}
+
+// check rejects hook results that change more than the stage allows.
+func (ev *Event) check(before *Event) error {
+	switch before.Stage {
+	case StageAfterSegmentation:
+		if len(ev.Segments) != len(before.Segments) {
+			return fmt.Errorf("hook returned %d segments, want %d", len(ev.Segments), len(before.Segments))
+		}
+		for i, s := range ev.Segments {
+			if strings.TrimSpace(s.Text) == "" {
+				return fmt.Errorf("hook returned empty text for segment %d", i)
+			}
+		}
+	case StageBeforeTTS:
+		if strings.TrimSpace(ev.Script) == "" {
+			return fmt.Errorf("hook returned an empty script")
+		}
+	}
+	return nil
+}
+
+// apply copies the stage's payload from a hook result into ev.
+func (ev *Event) apply(result *Event) {
+	switch ev.Stage {
+	case StageAfterSegmentation:
+		for i := range ev.Segments {
+			ev.Segments[i].Title = result.Segments[i].Title
+			ev.Segments[i].Text = result.Segments[i].Text
+		}
+	case StageBeforeTTS:
+		ev.Script = result.Script
+	}
+}
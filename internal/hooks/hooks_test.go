@@ -0,0 +1,154 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/snappy-loop/stories/internal/egress"
+)
+
+// localPolicy lets hooks call the test servers on the loopback address
+var localPolicy = egress.NewPolicy(nil, nil, true)
+
+func init() {
+	RegisterPlugin("upper_titles", HookFunc(func(ctx context.Context, ev *Event) error {
+		for i := range ev.Segments {
+			ev.Segments[i].Title = strings.ToUpper(ev.Segments[i].Title)
+		}
+		return nil
+	}))
+	RegisterPlugin("drop_segment", HookFunc(func(ctx context.Context, ev *Event) error {
+		ev.Segments = ev.Segments[:1]
+		return nil
+	}))
+	RegisterPlugin("broken", HookFunc(func(ctx context.Context, ev *Event) error {
+		ev.Script = "partial"
+		return errors.New("boom")
+	}))
+}
+
+func TestRun_Plugins(t *testing.T) {
+	r, err := Parse(`[
+		{"stage": "after_segmentation", "plugin": "upper_titles"},
+		{"stage": "after_segmentation", "plugin": "drop_segment"}
+	]`, localPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := &Event{Stage: StageAfterSegmentation, Segments: []Segment{{Title: "one", Text: "a"}, {Title: "two", Text: "b"}}}
+	if err := r.Run(context.Background(), ev); err != nil {
+		t.Fatal(err)
+	}
+	// drop_segment changes the segment count, so only upper_titles applies
+	if len(ev.Segments) != 2 || ev.Segments[0].Title != "ONE" || ev.Segments[1].Title != "TWO" {
+		t.Errorf("segments = %+v", ev.Segments)
+	}
+}
+
+func TestRun_Required(t *testing.T) {
+	optional, err := Parse(`[{"stage": "before_tts", "plugin": "broken"}]`, localPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := &Event{Stage: StageBeforeTTS, Script: "hello"}
+	if err := optional.Run(context.Background(), ev); err != nil {
+		t.Fatalf("optional hook failure should be skipped: %v", err)
+	}
+	if ev.Script != "hello" {
+		t.Errorf("failed hook changes should be dropped, script = %q", ev.Script)
+	}
+
+	required, err := Parse(`[{"stage": "before_tts", "plugin": "broken", "required": true}]`, localPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := required.Run(context.Background(), ev); err == nil {
+		t.Error("required hook failure should fail the run")
+	}
+}
+
+func TestRun_HTTP(t *testing.T) {
+	var gotSignature, gotStage string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSignature = r.Header.Get("X-GS-Signature")
+		gotStage = r.Header.Get("X-GS-Hook-Stage")
+		var ev Event
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ev.Script = strings.ReplaceAll(ev.Script, "ACME", "Acme Corporation")
+		ev.Stage = "ignored"
+		json.NewEncoder(w).Encode(ev)
+	}))
+	defer srv.Close()
+
+	r, err := Parse(`[{"stage": "before_tts", "url": "`+srv.URL+`", "secret": "s3cret", "timeout": "2s"}]`, localPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := &Event{Stage: StageBeforeTTS, Script: "Welcome to ACME."}
+	if err := r.Run(context.Background(), ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.Script != "Welcome to Acme Corporation." || ev.Stage != StageBeforeTTS {
+		t.Errorf("event = %+v", ev)
+	}
+	if gotSignature == "" || gotStage != StageBeforeTTS {
+		t.Errorf("signature = %q, stage header = %q", gotSignature, gotStage)
+	}
+}
+
+func TestRun_HTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	r, err := Parse(`[{"stage": "after_asset_upload", "url": "`+srv.URL+`", "required": true}]`, localPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.Run(context.Background(), &Event{Stage: StageAfterAssetUpload, Asset: &Asset{Kind: "audio"}})
+	if err == nil || !strings.Contains(err.Error(), "HTTP 502") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{
+		`not json`,
+		`[{"stage": "before_upload", "plugin": "upper_titles"}]`,
+		`[{"stage": "before_tts"}]`,
+		`[{"stage": "before_tts", "plugin": "upper_titles", "url": "https://example.com"}]`,
+		`[{"stage": "before_tts", "plugin": "missing"}]`,
+		`[{"stage": "before_tts", "url": "ftp://example.com"}]`,
+		`[{"stage": "before_tts", "plugin": "upper_titles", "timeout": "5m"}]`,
+	} {
+		if _, err := Parse(spec, localPolicy); err == nil {
+			t.Errorf("Parse(%s) should fail", spec)
+		}
+	}
+
+	policy := egress.NewPolicy(nil, []string{"internal.example.com"}, false)
+	for _, url := range []string{"http://169.254.169.254/latest", "http://127.0.0.1:8080/hook", "https://hooks.internal.example.com"} {
+		if _, err := Parse(`[{"stage": "before_tts", "url": "`+url+`"}]`, policy); !errors.Is(err, egress.ErrDenied) {
+			t.Errorf("hook at %s: err = %v, want egress.ErrDenied", url, err)
+		}
+	}
+}
+
+func TestRegistry_Nil(t *testing.T) {
+	var r *Registry
+	if r.Has(StageBeforeTTS) {
+		t.Error("nil registry should have no hooks")
+	}
+	if err := r.Run(context.Background(), &Event{Stage: StageBeforeTTS, Script: "x"}); err != nil {
+		t.Error(err)
+	}
+}
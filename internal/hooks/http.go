@@ -0,0 +1,94 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/snappy-loop/stories/internal/egress"
+)
+
+// maxResponseBodyBytes limits how much of a callback's response is read.
+const maxResponseBodyBytes = 1 << 20
+
+// httpHook posts the event as JSON to a customer endpoint and reads the (possibly changed) event back.
+// An empty 2xx response (e.g. 204) leaves the event unchanged.
+type httpHook struct {
+	url        string
+	secret     string
+	httpClient *http.Client
+}
+
+// newHTTPHook checks rawURL against policy and returns a hook posting to it with policy's client, which
+// refuses non-public addresses when dialing and does not follow redirects.
+func newHTTPHook(rawURL, secret string, policy *egress.Policy) (*httpHook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("url must be an absolute http(s) URL, got %q", rawURL)
+	}
+	if err := policy.CheckURL(rawURL, nil); err != nil {
+		return nil, err
+	}
+	// Per-hook timeouts come from the context; the client timeout is only a backstop.
+	return &httpHook{url: rawURL, secret: secret, httpClient: policy.Client(MaxTimeout)}, nil
+}
+
+// Run implements Hook.
+func (h *httpHook) Run(ctx context.Context, ev *Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Stories-Hook/1.0")
+	req.Header.Set("X-GS-Timestamp", fmt.Sprintf("%d", time.Now().Unix()))
+	req.Header.Set("X-GS-Hook-Stage", ev.Stage)
+	if h.secret != "" {
+		req.Header.Set("X-GS-Signature", sign(body, h.secret))
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodyBytes+1))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("callback returned HTTP %d", resp.StatusCode)
+	}
+	if len(respBody) > maxResponseBodyBytes {
+		return fmt.Errorf("callback response exceeds %d bytes", maxResponseBodyBytes)
+	}
+	if len(bytes.TrimSpace(respBody)) == 0 {
+		return nil
+	}
+	stage := ev.Stage
+	if err := json.Unmarshal(respBody, ev); err != nil {
+		return fmt.Errorf("invalid callback response: %w", err)
+	}
+	ev.Stage = stage
+	return nil
+}
+
+// sign returns the hex HMAC-SHA256 of the body, the same signature job webhooks carry.
+func sign(body []byte, secret string) string {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write(body)
+	return hex.EncodeToString(m.Sum(nil))
+}
@@ -0,0 +1,170 @@
+// Package i18n localizes the API's user-facing error messages. Handlers keep producing English messages;
+// Localize matches them against the English catalog to find their stable code and renders the entry of
+// that code in the language negotiated from Accept-Language. Catalogs are embedded from catalogs/<tag>.json
+// (code -> message, %s for each value taken from the English message).
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+//go:embed catalogs/*.json
+var catalogFS embed.FS
+
+// Message is a localized error message. Code is stable across languages and releases; Text is for people.
+type Message struct {
+	Code     string
+	Text     string
+	Language language.Tag
+}
+
+// pattern matches an English message of the catalog.
+type pattern struct {
+	code    string
+	re      *regexp.Regexp
+	literal int // length without placeholders; longer patterns are more specific
+	wrapper bool
+}
+
+var (
+	catalogs map[language.Tag]map[string]string
+	tags     []language.Tag
+	matcher  language.Matcher
+	patterns []pattern
+)
+
+func init() {
+	if err := load(); err != nil {
+		panic(err)
+	}
+}
+
+// load reads the embedded catalogs; English first, as it is the fallback and the source of the patterns.
+func load() error {
+	files, err := catalogFS.ReadDir("catalogs")
+	if err != nil {
+		return err
+	}
+	catalogs = make(map[language.Tag]map[string]string, len(files))
+	tags = []language.Tag{language.English}
+	for _, f := range files {
+		tag, err := language.Parse(strings.TrimSuffix(f.Name(), ".json"))
+		if err != nil {
+			return fmt.Errorf("i18n: catalog %s: %w", f.Name(), err)
+		}
+		b, err := catalogFS.ReadFile(path.Join("catalogs", f.Name()))
+		if err != nil {
+			return err
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(b, &messages); err != nil {
+			return fmt.Errorf("i18n: catalog %s: %w", f.Name(), err)
+		}
+		catalogs[tag] = messages
+		if tag != language.English {
+			tags = append(tags, tag)
+		}
+	}
+	english, ok := catalogs[language.English]
+	if !ok {
+		return fmt.Errorf("i18n: no English catalog")
+	}
+	matcher = language.NewMatcher(tags)
+
+	patterns = patterns[:0]
+	for code, msg := range english {
+		parts := strings.Split(msg, "%s")
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+		patterns = append(patterns, pattern{
+			code:    code,
+			re:      regexp.MustCompile("^" + strings.Join(parts, "(.+)") + "$"),
+			literal: len(msg) - 2*(len(parts)-1),
+			wrapper: len(parts) == 2 && parts[1] == "",
+		})
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if patterns[i].literal != patterns[j].literal {
+			return patterns[i].literal > patterns[j].literal
+		}
+		return patterns[i].code < patterns[j].code
+	})
+	return nil
+}
+
+// Language returns the catalog language that best matches r's Accept-Language header (English when none
+// does or r is nil).
+func Language(r *http.Request) language.Tag {
+	if r == nil {
+		return language.English
+	}
+	prefs, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	if err != nil || len(prefs) == 0 {
+		return language.English
+	}
+	_, idx, confidence := matcher.Match(prefs...)
+	if confidence == language.No {
+		return language.English
+	}
+	return tags[idx]
+}
+
+// Localize returns message in r's language with its code. Messages missing from the catalog keep their
+// English text and get the code of the HTTP status.
+func Localize(r *http.Request, status int, message string) Message {
+	tag := Language(r)
+	if code, text, ok := localize(tag, message); ok {
+		return Message{Code: code, Text: text, Language: tag}
+	}
+	return Message{Code: StatusCode(status), Text: message, Language: language.English}
+}
+
+// localize translates message and the catalog messages it embeds. A message that only prefixes another
+// one (e.g. "validation error: %s") takes that message's code, the more specific one.
+func localize(tag language.Tag, message string) (code, text string, ok bool) {
+	for _, p := range patterns {
+		m := p.re.FindStringSubmatch(message)
+		if m == nil {
+			continue
+		}
+		code = p.code
+		args := make([]any, len(m)-1)
+		for i, arg := range m[1:] {
+			argCode, argText, argOK := localize(tag, arg)
+			if !argOK {
+				args[i] = arg
+				continue
+			}
+			args[i] = argText
+			if p.wrapper {
+				code = argCode
+			}
+		}
+		format, found := catalogs[tag][p.code]
+		if !found {
+			format = catalogs[language.English][p.code]
+		}
+		return code, fmt.Sprintf(format, args...), true
+	}
+	return "", message, false
+}
+
+// StatusCode is the code of messages missing from the catalog: a snake_case name of the HTTP status
+// (e.g. bad_request, not_found).
+func StatusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(strings.ReplaceAll(text, "-", " ")), " ", "_")
+}
@@ -0,0 +1,86 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestCatalogsMatchEnglish(t *testing.T) {
+	english := catalogs[language.English]
+	for tag, messages := range catalogs {
+		for code, msg := range english {
+			translated, ok := messages[code]
+			if !ok {
+				t.Errorf("%s: missing %s", tag, code)
+				continue
+			}
+			if strings.Count(translated, "%s") != strings.Count(msg, "%s") {
+				t.Errorf("%s: %s has %q, want the placeholders of %q", tag, code, translated, msg)
+			}
+		}
+		for code := range messages {
+			if _, ok := english[code]; !ok {
+				t.Errorf("%s: %s is not in the English catalog", tag, code)
+			}
+		}
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   language.Tag
+	}{
+		{"", language.English},
+		{"de-DE,de;q=0.9,en;q=0.8", language.German},
+		{"fr-CA", language.French},
+		{"ja, es;q=0.5", language.Spanish},
+		{"ja", language.English},
+		{"not a language;;", language.English},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Language", tt.header)
+		if got := Language(r); got != tt.want {
+			t.Errorf("Language(%q) = %s, want %s", tt.header, got, tt.want)
+		}
+	}
+	if got := Language(nil); got != language.English {
+		t.Errorf("Language(nil) = %s, want en", got)
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	tests := []struct {
+		lang     string
+		status   int
+		message  string
+		wantCode string
+		wantText string
+	}{
+		{"de", http.StatusNotFound, "job not found", "job_not_found", "Job nicht gefunden"},
+		{"es", http.StatusBadRequest, "quota exceeded: 120/100 chars used", "quota_exceeded", "cuota superada: 120/100 caracteres usados"},
+		{"fr", http.StatusBadRequest, "validation error: segments_count must be between 1 and 20",
+			"segments_count_out_of_range", "erreur de validation : segments_count doit être compris entre 1 et 20"},
+		{"en", http.StatusBadRequest, "validation error: either text or file_ids is required",
+			"text_or_files_required", "validation error: either text or file_ids is required"},
+		{"de", http.StatusBadRequest, "file 3f1c is not available (status: processing)", "file_unavailable",
+			"Datei 3f1c ist nicht verfügbar (Status: processing)"},
+		{"de", http.StatusInternalServerError, "failed to list jobs", "internal_server_error", "failed to list jobs"},
+		{"es", http.StatusConflict, "something new", "conflict", "something new"},
+		{"de", http.StatusBadRequest, "validation error: destination not allowed: 10.0.0.1 is not a public address",
+			"destination_not_allowed", "Validierungsfehler: Ziel nicht erlaubt: 10.0.0.1 is not a public address"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept-Language", tt.lang)
+		got := Localize(r, tt.status, tt.message)
+		if got.Code != tt.wantCode || got.Text != tt.wantText {
+			t.Errorf("Localize(%s, %q) = %s %q, want %s %q", tt.lang, tt.message, got.Code, got.Text, tt.wantCode, tt.wantText)
+		}
+	}
+}
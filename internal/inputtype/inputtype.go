@@ -0,0 +1,219 @@
+// Package inputtype holds the profiles of job input types (educational, financial, fictional, ...): the
+// prompt guidance of each pipeline step and the type's other settings. The built-in types can be tuned
+// and new types added with the INPUT_TYPE_PROFILES setting, without code changes.
+package inputtype
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Profile configures the pipeline for one input type. Empty prompt fields fall back to the generic
+// guidance used for unknown types.
+type Profile struct {
+	Name                 string `json:"name"`
+	SegmentationGuidance string `json:"segmentation_guidance,omitempty"` // where segment boundaries go
+	ExtractionFocus      string `json:"extraction_focus,omitempty"`      // what file summaries keep
+	NarrationStyle       string `json:"narration_style,omitempty"`       // style of narration scripts
+	ImageStyle           string `json:"image_style,omitempty"`           // style guidance of image prompts
+	ImagePromptPrefix    string `json:"image_prompt_prefix,omitempty"`   // fallback image prompt, before the text
+	PodcastCategory      string `json:"podcast_category,omitempty"`      // iTunes category of podcast feeds
+	Disclaimer           bool   `json:"disclaimer,omitempty"`            // append the compliance disclaimer (see processor)
+}
+
+// generic is the guidance of types without a profile field (and of unknown types)
+var generic = Profile{
+	SegmentationGuidance: "Identify boundaries at paragraph breaks or major topic changes.",
+	ExtractionFocus:      "Keep the overall structure and meaning clear in your summary.",
+	NarrationStyle:       "Create clear, engaging narration.",
+	ImagePromptPrefix:    "Illustration: ",
+}
+
+// builtin are the types available without configuration
+var builtin = []Profile{
+	{
+		Name:                 "educational",
+		SegmentationGuidance: "Identify boundaries between concepts, subtopics, or learning units.",
+		ExtractionFocus:      "Focus on the main concepts, facts, and how they are organized. Keep the logical flow clear.",
+		NarrationStyle:       "Create clear, engaging educational narration suitable for learning. Use conversational tone. Pause briefly after key definitions and before new ideas, and spell out acronyms that are read letter by letter.",
+		ImageStyle:           "Create a clear, easy-to-read illustration or reference table suitable for learning. Prefer diagrams, simple charts, step-by-step visuals, or tables that are easy to understand. Focus on clarity and accuracy.",
+		ImagePromptPrefix:    "Clear, easy-to-read educational illustration or reference table: ",
+		PodcastCategory:      "Education",
+	},
+	{
+		Name:                 "financial",
+		SegmentationGuidance: "Identify boundaries between financial topics, time periods, or categories.",
+		ExtractionFocus:      "Summarize the main points, figures, and conclusions. Note the presence of any disclaimers or risk warnings without quoting them in full.",
+		NarrationStyle:       "Create professional, measured narration for financial content. Include appropriate disclaimers. Avoid hype or promises.",
+		ImageStyle:           "Create a professional, restrained visual suitable for financial content. Avoid flashy or misleading imagery.",
+		ImagePromptPrefix:    "Professional financial chart: ",
+		PodcastCategory:      "Business",
+		Disclaimer:           true,
+	},
+	{
+		Name:                 "fictional",
+		SegmentationGuidance: "Identify boundaries between scenes, plot points, or narrative beats.",
+		ExtractionFocus:      "Summarize the plot, key characters, and story beats in your own words. Capture the tone and main events without copying dialogue or text verbatim.",
+		NarrationStyle:       "Create immersive, dramatic narration suitable for storytelling.",
+		ImageStyle:           "Create a cinematic, atmospheric scene that captures the mood and setting of the story.",
+		ImagePromptPrefix:    "Cinematic scene: ",
+		PodcastCategory:      "Fiction",
+	},
+}
+
+// Limits of configured profiles
+const (
+	maxProfiles    = 50
+	maxGuidanceLen = 2000
+	maxCategoryLen = 64
+)
+
+// settingName is the configuration setting parsed by Parse
+const settingName = "INPUT_TYPE_PROFILES"
+
+// nameRe matches input type names: lower-case letters, digits and underscores
+var nameRe = regexp.MustCompile(`^[a-z][a-z0-9_]{1,31}$`)
+
+// Registry is the set of input types jobs may use. A nil *Registry has the built-in types only.
+type Registry struct {
+	profiles map[string]Profile
+}
+
+// Default returns a registry of the built-in types.
+func Default() *Registry {
+	r := &Registry{profiles: make(map[string]Profile, len(builtin))}
+	for _, p := range builtin {
+		r.profiles[p.Name] = p
+	}
+	return r
+}
+
+// profileSpec is a configured profile; Disclaimer is a pointer so omitting it keeps a built-in's value.
+type profileSpec struct {
+	Profile
+	Disclaimer *bool `json:"disclaimer,omitempty"`
+}
+
+// Parse parses the INPUT_TYPE_PROFILES setting, a JSON array of profiles, into a registry of the built-in
+// types and the configured ones. A profile named like a built-in type overrides the fields it sets; other
+// names add types. Empty means the built-in types only.
+func Parse(spec string) (*Registry, error) {
+	r := Default()
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return r, nil
+	}
+	var specs []profileSpec
+	if err := json.Unmarshal([]byte(spec), &specs); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", settingName, err)
+	}
+	if len(specs) > maxProfiles {
+		return nil, fmt.Errorf("invalid %s: more than %d profiles", settingName, maxProfiles)
+	}
+	seen := make(map[string]bool, len(specs))
+	for _, s := range specs {
+		if err := s.validate(); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", settingName, err)
+		}
+		if seen[s.Name] {
+			return nil, fmt.Errorf("invalid %s: duplicate profile %q", settingName, s.Name)
+		}
+		seen[s.Name] = true
+		r.profiles[s.Name] = merge(r.profiles[s.Name], s)
+	}
+	return r, nil
+}
+
+// validate checks a configured profile's name and field lengths.
+func (s profileSpec) validate() error {
+	if !nameRe.MatchString(s.Name) {
+		return fmt.Errorf("profile name %q must be 2-32 lower-case letters, digits or underscores", s.Name)
+	}
+	for field, v := range map[string]string{
+		"segmentation_guidance": s.SegmentationGuidance,
+		"extraction_focus":      s.ExtractionFocus,
+		"narration_style":       s.NarrationStyle,
+		"image_style":           s.ImageStyle,
+		"image_prompt_prefix":   s.ImagePromptPrefix,
+	} {
+		if len(v) > maxGuidanceLen {
+			return fmt.Errorf("profile %q %s exceeds %d characters", s.Name, field, maxGuidanceLen)
+		}
+	}
+	if len(s.PodcastCategory) > maxCategoryLen {
+		return fmt.Errorf("profile %q podcast_category exceeds %d characters", s.Name, maxCategoryLen)
+	}
+	return nil
+}
+
+// merge returns base with the fields s sets.
+func merge(base Profile, s profileSpec) Profile {
+	base.Name = s.Name
+	for _, f := range []struct {
+		dst *string
+		src string
+	}{
+		{&base.SegmentationGuidance, s.SegmentationGuidance},
+		{&base.ExtractionFocus, s.ExtractionFocus},
+		{&base.NarrationStyle, s.NarrationStyle},
+		{&base.ImageStyle, s.ImageStyle},
+		{&base.ImagePromptPrefix, s.ImagePromptPrefix},
+		{&base.PodcastCategory, s.PodcastCategory},
+	} {
+		if f.src != "" {
+			*f.dst = f.src
+		}
+	}
+	if s.Disclaimer != nil {
+		base.Disclaimer = *s.Disclaimer
+	}
+	return base
+}
+
+// Has reports whether name is an input type jobs may use.
+func (r *Registry) Has(name string) bool {
+	if r == nil {
+		r = Default()
+	}
+	_, ok := r.profiles[name]
+	return ok
+}
+
+// Get returns the profile of an input type with the generic guidance in the fields it does not set.
+// Unknown types get the generic profile.
+func (r *Registry) Get(name string) Profile {
+	if r == nil {
+		r = Default()
+	}
+	p := r.profiles[name]
+	p.Name = name
+	if p.SegmentationGuidance == "" {
+		p.SegmentationGuidance = generic.SegmentationGuidance
+	}
+	if p.ExtractionFocus == "" {
+		p.ExtractionFocus = generic.ExtractionFocus
+	}
+	if p.NarrationStyle == "" {
+		p.NarrationStyle = generic.NarrationStyle
+	}
+	if p.ImagePromptPrefix == "" {
+		p.ImagePromptPrefix = generic.ImagePromptPrefix
+	}
+	return p
+}
+
+// Names returns the input types, sorted.
+func (r *Registry) Names() []string {
+	if r == nil {
+		r = Default()
+	}
+	names := make([]string, 0, len(r.profiles))
+	for name := range r.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,77 @@
+package inputtype
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	r, err := Parse(`[
+		{"name": "recipe", "narration_style": "Warm, step-by-step cooking narration.", "podcast_category": "Food"},
+		{"name": "financial", "narration_style": "Plain, cautious narration.", "disclaimer": false},
+		{"name": "legal", "disclaimer": true}
+	]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(r.Names(), ","); got != "educational,fictional,financial,legal,recipe" {
+		t.Errorf("Names = %s", got)
+	}
+
+	recipe := r.Get("recipe")
+	if recipe.NarrationStyle != "Warm, step-by-step cooking narration." || recipe.PodcastCategory != "Food" {
+		t.Errorf("recipe = %+v", recipe)
+	}
+	if recipe.SegmentationGuidance != generic.SegmentationGuidance || recipe.ImagePromptPrefix != generic.ImagePromptPrefix {
+		t.Errorf("recipe should use the generic guidance it does not set: %+v", recipe)
+	}
+
+	// Overrides keep the built-in fields they do not set
+	financial := r.Get("financial")
+	if financial.NarrationStyle != "Plain, cautious narration." || financial.Disclaimer || financial.PodcastCategory != "Business" {
+		t.Errorf("financial = %+v", financial)
+	}
+	if !r.Get("legal").Disclaimer {
+		t.Error("legal should append disclaimers")
+	}
+}
+
+func TestParseEmptyAndNil(t *testing.T) {
+	r, err := Parse("  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nilRegistry *Registry
+	for _, reg := range []*Registry{r, nilRegistry} {
+		if !reg.Has("educational") || reg.Has("recipe") || len(reg.Names()) != 3 {
+			t.Errorf("registry %v should have the built-in types only", reg)
+		}
+		if !reg.Get("financial").Disclaimer || reg.Get("fictional").PodcastCategory != "Fiction" {
+			t.Errorf("built-in profiles changed: %+v", reg.Get("financial"))
+		}
+	}
+	unknown := nilRegistry.Get("poetry")
+	if unknown.Name != "poetry" || unknown.NarrationStyle != generic.NarrationStyle || unknown.ImageStyle != "" {
+		t.Errorf("unknown type = %+v, want the generic profile", unknown)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"not json":       `recipe`,
+		"not an array":   `{"name": "recipe"}`,
+		"no name":        `[{"narration_style": "x"}]`,
+		"bad name":       `[{"name": "Recipe Type"}]`,
+		"duplicate":      `[{"name": "recipe"}, {"name": "recipe"}]`,
+		"long guidance":  `[{"name": "recipe", "image_style": "` + strings.Repeat("x", maxGuidanceLen+1) + `"}]`,
+		"long category":  `[{"name": "recipe", "podcast_category": "` + strings.Repeat("x", maxCategoryLen+1) + `"}]`,
+		"wrong disclaim": `[{"name": "recipe", "disclaimer": "yes"}]`,
+	}
+	for name, spec := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Parse(spec); err == nil || !strings.Contains(err.Error(), settingName) {
+				t.Errorf("Parse = %v, want an %s error", err, settingName)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package kafka
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -17,7 +18,7 @@ type Consumer struct {
 	handler MessageHandler
 }
 
-// MessageHandler processes Kafka webhook messages
+// MessageHandler processes Kafka job event messages
 type MessageHandler interface {
 	HandleMessage(ctx context.Context, msg *WebhookMessage) error
 }
@@ -29,14 +30,21 @@ type JobMessageHandler interface {
 
 // JobMessage represents an incoming job creation message
 type JobMessage struct {
-	JobID   uuid.UUID `json:"job_id"`
-	TraceID string    `json:"trace_id,omitempty"`
+	JobID        uuid.UUID `json:"job_id"`
+	TraceID      string    `json:"trace_id,omitempty"`
+	SegmentModel string    `json:"segment_model,omitempty"` // optional segmentation model override (set by storiesctl reprocess)
+	// Segment edit (PATCH /v1/jobs/{id}/segments/{idx}): regenerate only this segment's audio (and image)
+	SegmentIdx      *int `json:"segment_idx,omitempty"`
+	RegenerateImage bool `json:"regenerate_image,omitempty"`
+	// Set on messages in the retry tiers: retries so far and when the message is due
+	Attempt int        `json:"attempt,omitempty"`
+	RetryAt *time.Time `json:"retry_at,omitempty"`
 }
 
-// WebhookMessage represents a webhook event message
+// WebhookMessage represents a job event message on the events topic (or the legacy webhooks topic)
 type WebhookMessage struct {
 	JobID   uuid.UUID `json:"job_id"`
-	Event   string    `json:"event"` // "job_completed", "job_failed"
+	Event   string    `json:"event"` // models.EventJob*: job_completed, job_failed, job_awaiting_review, job_retry_scheduled
 	TraceID string    `json:"trace_id,omitempty"`
 }
 
@@ -191,10 +199,33 @@ func (c *Consumer) Close() error {
 type JobConsumer struct {
 	reader  *kafka.Reader
 	handler JobMessageHandler
+
+	// Retry tiers and a producer per tier (SetRetryTiers)
+	retryTiers     []RetryTier
+	retryProducers []*Producer
+
+	pause Pauser // nil never pauses
+}
+
+// Pauser pauses job consumption (maintenance.Switch).
+type Pauser interface {
+	Enabled(ctx context.Context) bool
+	Wait(ctx context.Context) error
 }
 
 // NewJobConsumer creates a new Kafka consumer for job messages
 func NewJobConsumer(brokers []string, topic, groupID string, handler JobMessageHandler) *JobConsumer {
+	return newJobConsumer(brokers, topic, groupID, handler, kafka.LastOffset)
+}
+
+// NewJobRetryConsumer creates a consumer for a retry tier topic. It holds each message until its retry
+// is due. Unlike the jobs topic, a new consumer group starts from the earliest message, so retries
+// published before the group first joined are not lost.
+func NewJobRetryConsumer(brokers []string, tier RetryTier, groupID string, handler JobMessageHandler) *JobConsumer {
+	return newJobConsumer(brokers, tier.Topic, groupID, handler, kafka.FirstOffset)
+}
+
+func newJobConsumer(brokers []string, topic, groupID string, handler JobMessageHandler, startOffset int64) *JobConsumer {
 	reader := kafka.NewReader(kafka.ReaderConfig{
 		Brokers:        brokers,
 		Topic:          topic,
@@ -202,7 +233,7 @@ func NewJobConsumer(brokers []string, topic, groupID string, handler JobMessageH
 		MinBytes:       1,
 		MaxBytes:       10e6, // 10MB
 		CommitInterval: 0,    // Disable auto-commit, using manual commits
-		StartOffset:    kafka.LastOffset,
+		StartOffset:    startOffset,
 	})
 
 	log.Info().
@@ -217,6 +248,23 @@ func NewJobConsumer(brokers []string, topic, groupID string, handler JobMessageH
 	}
 }
 
+// SetRetryTiers makes the consumer publish a message whose handler returns a RetryError to the next
+// tier (tiers[attempt]) instead of dropping it; see NextRetry. Set the same tiers on the consumers of
+// the jobs topic and of every tier topic.
+func (c *JobConsumer) SetRetryTiers(brokers []string, tiers []RetryTier) {
+	c.retryTiers = tiers
+	c.retryProducers = make([]*Producer, len(tiers))
+	for i, tier := range tiers {
+		c.retryProducers[i] = NewProducer(brokers, tier.Topic)
+	}
+}
+
+// SetPause makes the consumer hold each fetched message, uncommitted, while p is enabled. Messages are
+// processed one at a time, so the job in progress finishes first.
+func (c *JobConsumer) SetPause(p Pauser) {
+	c.pause = p
+}
+
 // Start starts consuming job messages
 func (c *JobConsumer) Start(ctx context.Context) error {
 	log.Info().Msg("Starting Kafka job consumer")
@@ -236,8 +284,20 @@ func (c *JobConsumer) Start(ctx context.Context) error {
 				continue
 			}
 
+			if c.pause != nil && c.pause.Enabled(ctx) {
+				log.Info().Str("topic", msg.Topic).Msg("Maintenance mode: job consumption paused")
+				if err := c.pause.Wait(ctx); err != nil {
+					return err
+				}
+				log.Info().Str("topic", msg.Topic).Msg("Maintenance mode ended: job consumption resumed")
+			}
+
 			// Process message
 			if err := c.processMessage(ctx, msg); err != nil {
+				if ctx.Err() != nil {
+					// Shutting down; the uncommitted message is fetched again on restart
+					return ctx.Err()
+				}
 				log.Error().
 					Err(err).
 					Str("topic", msg.Topic).
@@ -270,8 +330,33 @@ func (c *JobConsumer) processMessage(ctx context.Context, msg kafka.Message) err
 		return fmt.Errorf("failed to unmarshal message: %w", err)
 	}
 
+	// Messages of a retry tier are in publish order, so holding the partition until this one is due
+	// delays nothing that is due earlier
+	if jobMsg.RetryAt != nil {
+		if wait := time.Until(*jobMsg.RetryAt); wait > 0 {
+			log.Info().
+				Str("job_id", jobMsg.JobID.String()).
+				Int("attempt", jobMsg.Attempt).
+				Time("retry_at", *jobMsg.RetryAt).
+				Msg("Waiting until job retry is due")
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+	}
+
+	if jobMsg.Attempt < len(c.retryTiers) {
+		ctx = WithNextRetry(ctx, RetryInfo{Attempt: jobMsg.Attempt, Delay: c.retryTiers[jobMsg.Attempt].Delay})
+	}
+
 	// Handle message
 	if err := c.handler.HandleMessage(ctx, &jobMsg); err != nil {
+		var retryErr *RetryError
+		if errors.As(err, &retryErr) && jobMsg.Attempt < len(c.retryTiers) {
+			return c.publishRetry(ctx, jobMsg, retryErr.Err)
+		}
 		return fmt.Errorf("handler error: %w", err)
 	}
 
@@ -282,8 +367,31 @@ func (c *JobConsumer) processMessage(ctx context.Context, msg kafka.Message) err
 	return nil
 }
 
+// publishRetry publishes msg to the retry tier of its attempt.
+func (c *JobConsumer) publishRetry(ctx context.Context, msg JobMessage, cause error) error {
+	tier := c.retryTiers[msg.Attempt]
+	retryAt := time.Now().Add(tier.Delay)
+	msg.Attempt++
+	msg.RetryAt = &retryAt
+	if err := c.retryProducers[msg.Attempt-1].PublishJobMessage(ctx, msg); err != nil {
+		return fmt.Errorf("failed to publish retry (after %v): %w", cause, err)
+	}
+
+	log.Warn().
+		Err(cause).
+		Str("job_id", msg.JobID.String()).
+		Int("attempt", msg.Attempt).
+		Str("retry_topic", tier.Topic).
+		Time("retry_at", retryAt).
+		Msg("Job failed transiently, retry scheduled")
+	return nil
+}
+
 // Close closes the job consumer
 func (c *JobConsumer) Close() error {
 	log.Info().Msg("Closing Kafka job consumer")
+	for _, p := range c.retryProducers {
+		p.Close()
+	}
 	return c.reader.Close()
 }
@@ -40,11 +40,25 @@ func NewProducer(brokers []string, topic string) *Producer {
 
 // PublishJob publishes a job message to Kafka
 func (p *Producer) PublishJob(ctx context.Context, jobID uuid.UUID, traceID string) error {
-	msg := JobMessage{
+	return p.PublishJobMessage(ctx, JobMessage{
 		JobID:   jobID,
 		TraceID: traceID,
-	}
+	})
+}
+
+// PublishSegmentEdit publishes a message asking the worker to regenerate one edited segment of a job
+func (p *Producer) PublishSegmentEdit(ctx context.Context, jobID uuid.UUID, idx int, regenerateImage bool, traceID string) error {
+	return p.PublishJobMessage(ctx, JobMessage{
+		JobID:           jobID,
+		TraceID:         traceID,
+		SegmentIdx:      &idx,
+		RegenerateImage: regenerateImage,
+	})
+}
 
+// PublishJobMessage publishes a fully populated job message (e.g. with a segmentation model override) to Kafka
+func (p *Producer) PublishJobMessage(ctx context.Context, msg JobMessage) error {
+	jobID := msg.JobID
 	data, err := json.Marshal(msg)
 	if err != nil {
 		return fmt.Errorf("failed to marshal job message: %w", err)
@@ -67,7 +81,8 @@ func (p *Producer) PublishJob(ctx context.Context, jobID uuid.UUID, traceID stri
 	return nil
 }
 
-// PublishWebhook publishes a webhook event message to Kafka (webhooks topic)
+// PublishWebhook publishes a job event message (models.EventJob*) to Kafka (events topic), routed by the
+// dispatcher to the job's webhook and the user's notification sinks
 func (p *Producer) PublishWebhook(ctx context.Context, jobID uuid.UUID, event, traceID string) error {
 	msg := WebhookMessage{
 		JobID:   jobID,
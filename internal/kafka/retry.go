@@ -0,0 +1,76 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RetryTier is a delayed retry topic of the jobs topic: a job message published to it is handled no
+// earlier than Delay after it was published.
+type RetryTier struct {
+	Topic string
+	Delay time.Duration
+}
+
+// RetryTiers returns one retry tier of topic per delay, in order, named <topic>.retry.<delay>
+// (e.g. greatstories.jobs.v1.retry.30m).
+func RetryTiers(topic string, delays []time.Duration) []RetryTier {
+	tiers := make([]RetryTier, len(delays))
+	for i, d := range delays {
+		tiers[i] = RetryTier{Topic: topic + ".retry." + formatDelay(d), Delay: d}
+	}
+	return tiers
+}
+
+// formatDelay renders d in its largest whole unit: 2h, 30m, 45s.
+func formatDelay(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d >= time.Minute && d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+}
+
+// RetryError is returned by a JobMessageHandler to have the consumer publish the message to the next
+// retry tier (see NextRetry) instead of dropping it.
+type RetryError struct {
+	Err error
+}
+
+func (e *RetryError) Error() string {
+	return "retry scheduled: " + e.Err.Error()
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// Retry wraps err in a RetryError.
+func Retry(err error) error {
+	return &RetryError{Err: err}
+}
+
+// RetryInfo describes the retry a job message handler gets by returning a RetryError.
+type RetryInfo struct {
+	Attempt int           // retries of the message so far; 0 on its first delivery
+	Delay   time.Duration // how long after the failure the retry is handled
+}
+
+type retryInfoKey struct{}
+
+// NextRetry returns the retry available to the job message being handled in ctx. It returns false when
+// the message already went through every retry tier or the consumer has none, in which case a
+// RetryError is treated like any other error.
+func NextRetry(ctx context.Context) (RetryInfo, bool) {
+	info, ok := ctx.Value(retryInfoKey{}).(RetryInfo)
+	return info, ok
+}
+
+// WithNextRetry returns ctx carrying the retry available to the message being handled (see NextRetry).
+func WithNextRetry(ctx context.Context, info RetryInfo) context.Context {
+	return context.WithValue(ctx, retryInfoKey{}, info)
+}
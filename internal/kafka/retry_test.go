@@ -0,0 +1,101 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"github.com/snappy-loop/stories/internal/testutil"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	testutil.Cleanup()
+	os.Exit(code)
+}
+
+func TestRetryTiers(t *testing.T) {
+	tiers := RetryTiers("greatstories.jobs.v1", []time.Duration{90 * time.Second, 5 * time.Minute, 90 * time.Minute, 2 * time.Hour})
+	want := []string{
+		"greatstories.jobs.v1.retry.90s",
+		"greatstories.jobs.v1.retry.5m",
+		"greatstories.jobs.v1.retry.90m",
+		"greatstories.jobs.v1.retry.2h",
+	}
+	for i, tier := range tiers {
+		if tier.Topic != want[i] {
+			t.Errorf("tier %d topic = %q, want %q", i, tier.Topic, want[i])
+		}
+	}
+	if tiers[2].Delay != 90*time.Minute {
+		t.Errorf("tier 2 delay = %s, want 1h30m", tiers[2].Delay)
+	}
+}
+
+// retryRecorder fails a message's first delivery with a RetryError and records every delivery.
+type retryRecorder struct {
+	calls chan retryCall
+}
+
+type retryCall struct {
+	msg       JobMessage
+	canRetry  bool
+	delivered time.Time
+}
+
+func (h *retryRecorder) HandleMessage(ctx context.Context, msg *JobMessage) error {
+	_, canRetry := NextRetry(ctx)
+	h.calls <- retryCall{msg: *msg, canRetry: canRetry, delivered: time.Now()}
+	if msg.Attempt == 0 {
+		return Retry(errors.New("gemini: Error 503"))
+	}
+	return nil
+}
+
+func TestJobConsumer_PublishesToRetryTier(t *testing.T) {
+	brokers := testutil.KafkaBrokers(t)
+	topic := testutil.KafkaTopic(t, brokers)
+	tiers := RetryTiers(topic, []time.Duration{2 * time.Second})
+	handler := &retryRecorder{calls: make(chan retryCall, 2)}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// First delivery on the jobs topic
+	consumer := &JobConsumer{handler: handler}
+	consumer.SetRetryTiers(brokers, tiers)
+	defer consumer.retryProducers[0].Close()
+	jobID := uuid.New()
+	value, _ := json.Marshal(JobMessage{JobID: jobID})
+	if err := consumer.processMessage(ctx, kafka.Message{Topic: topic, Value: value}); err != nil {
+		t.Fatalf("processMessage: %v", err)
+	}
+	first := <-handler.calls
+	if !first.canRetry {
+		t.Errorf("first delivery has no retry available")
+	}
+
+	retryConsumer := NewJobRetryConsumer(brokers, tiers[0], "test-"+uuid.NewString(), handler)
+	retryConsumer.SetRetryTiers(brokers, tiers)
+	defer retryConsumer.Close()
+	go retryConsumer.Start(ctx)
+
+	select {
+	case retry := <-handler.calls:
+		if retry.msg.JobID != jobID || retry.msg.Attempt != 1 || retry.msg.RetryAt == nil {
+			t.Errorf("retry message = %+v, want attempt 1 of job %s with retry_at", retry.msg, jobID)
+		}
+		if retry.canRetry {
+			t.Errorf("retry on the last tier has a retry available")
+		}
+		if wait := retry.delivered.Sub(first.delivered); wait < 2*time.Second {
+			t.Errorf("retry delivered after %s, want at least the tier delay", wait)
+		}
+	case <-ctx.Done():
+		t.Fatal("retry not delivered")
+	}
+}
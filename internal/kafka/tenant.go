@@ -0,0 +1,240 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// Default topic and consumer group name templates of tenant pools. {topic} and {group} are the shared
+// jobs topic and worker consumer group, {tenant} the tenant name.
+const (
+	DefaultTenantTopicTemplate = "{topic}.tenant.{tenant}"
+	DefaultTenantGroupTemplate = "{group}.tenant.{tenant}"
+)
+
+// tenantNameRe restricts tenant names to characters valid in Kafka topic names.
+var tenantNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// TenantRoute sends the jobs of some users or API keys to the dedicated topic and worker pool of a tenant.
+type TenantRoute struct {
+	Tenant    string      `json:"tenant"`
+	UserIDs   []uuid.UUID `json:"user_ids,omitempty"`
+	APIKeyIDs []uuid.UUID `json:"api_key_ids,omitempty"` // take precedence over user_ids
+}
+
+// parseTenantRoutes parses the KAFKA_TENANT_ROUTES setting, a JSON array of routes. Empty means none.
+func parseTenantRoutes(spec string) ([]TenantRoute, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+	var routes []TenantRoute
+	if err := json.Unmarshal([]byte(spec), &routes); err != nil {
+		return nil, fmt.Errorf("invalid KAFKA_TENANT_ROUTES: %w", err)
+	}
+	tenants := make(map[string]bool)
+	owners := make(map[uuid.UUID]string)
+	for _, r := range routes {
+		switch {
+		case !tenantNameRe.MatchString(r.Tenant):
+			return nil, fmt.Errorf("invalid KAFKA_TENANT_ROUTES: tenant %q must be lowercase letters, digits, - or _", r.Tenant)
+		case tenants[r.Tenant]:
+			return nil, fmt.Errorf("invalid KAFKA_TENANT_ROUTES: duplicate tenant %q", r.Tenant)
+		case len(r.UserIDs) == 0 && len(r.APIKeyIDs) == 0:
+			return nil, fmt.Errorf("invalid KAFKA_TENANT_ROUTES: tenant %q needs user_ids or api_key_ids", r.Tenant)
+		}
+		tenants[r.Tenant] = true
+		for _, id := range append(append([]uuid.UUID{}, r.UserIDs...), r.APIKeyIDs...) {
+			if other, ok := owners[id]; ok {
+				return nil, fmt.Errorf("invalid KAFKA_TENANT_ROUTES: %s is routed to both %q and %q", id, other, r.Tenant)
+			}
+			owners[id] = r.Tenant
+		}
+	}
+	return routes, nil
+}
+
+// TenantRouting decides which tenant pool a job belongs to and names the pools' topics and consumer
+// groups. A nil or empty TenantRouting routes every job to the shared pool.
+type TenantRouting struct {
+	byUser        map[uuid.UUID]string
+	byAPIKey      map[uuid.UUID]string
+	tenants       []string
+	topicTemplate string
+	groupTemplate string
+}
+
+// ParseTenantRouting creates a routing from the KAFKA_TENANT_ROUTES setting, a JSON array of
+// TenantRoute (empty means none), and the topic and group name templates (empty uses the defaults).
+func ParseTenantRouting(spec, topicTemplate, groupTemplate string) (*TenantRouting, error) {
+	routes, err := parseTenantRoutes(spec)
+	if err != nil {
+		return nil, err
+	}
+	if topicTemplate == "" {
+		topicTemplate = DefaultTenantTopicTemplate
+	}
+	if groupTemplate == "" {
+		groupTemplate = DefaultTenantGroupTemplate
+	}
+	if !strings.Contains(topicTemplate, "{tenant}") {
+		return nil, fmt.Errorf("tenant topic template %q must contain {tenant}", topicTemplate)
+	}
+	if !strings.Contains(groupTemplate, "{tenant}") {
+		return nil, fmt.Errorf("tenant group template %q must contain {tenant}", groupTemplate)
+	}
+	r := &TenantRouting{
+		byUser:        make(map[uuid.UUID]string),
+		byAPIKey:      make(map[uuid.UUID]string),
+		topicTemplate: topicTemplate,
+		groupTemplate: groupTemplate,
+	}
+	for _, route := range routes {
+		r.tenants = append(r.tenants, route.Tenant)
+		for _, id := range route.UserIDs {
+			r.byUser[id] = route.Tenant
+		}
+		for _, id := range route.APIKeyIDs {
+			r.byAPIKey[id] = route.Tenant
+		}
+	}
+	sort.Strings(r.tenants)
+	return r, nil
+}
+
+// Enabled reports whether any tenant has a dedicated pool.
+func (r *TenantRouting) Enabled() bool {
+	return r != nil && len(r.tenants) > 0
+}
+
+// Tenants returns the configured tenant names, sorted.
+func (r *TenantRouting) Tenants() []string {
+	if r == nil {
+		return nil
+	}
+	return r.tenants
+}
+
+// HasTenant reports whether tenant is configured.
+func (r *TenantRouting) HasTenant(tenant string) bool {
+	for _, t := range r.Tenants() {
+		if t == tenant {
+			return true
+		}
+	}
+	return false
+}
+
+// Tenant returns the tenant of a job created by userID with apiKeyID, or "" for the shared pool.
+func (r *TenantRouting) Tenant(userID, apiKeyID uuid.UUID) string {
+	if !r.Enabled() {
+		return ""
+	}
+	if tenant, ok := r.byAPIKey[apiKeyID]; ok {
+		return tenant
+	}
+	return r.byUser[userID]
+}
+
+// Topic returns the jobs topic of tenant's pool; topic itself for the shared pool ("").
+func (r *TenantRouting) Topic(topic, tenant string) string {
+	if tenant == "" || r == nil {
+		return topic
+	}
+	return strings.NewReplacer("{topic}", topic, "{tenant}", tenant).Replace(r.topicTemplate)
+}
+
+// Group returns the worker consumer group of tenant's pool; group itself for the shared pool ("").
+func (r *TenantRouting) Group(group, tenant string) string {
+	if tenant == "" || r == nil {
+		return group
+	}
+	return strings.NewReplacer("{group}", group, "{tenant}", tenant).Replace(r.groupTemplate)
+}
+
+// JobOwnerFunc returns the user and API key that created a job.
+type JobOwnerFunc func(ctx context.Context, jobID uuid.UUID) (userID, apiKeyID uuid.UUID, err error)
+
+// TenantProducer publishes job messages to the jobs topic of the job's tenant pool (see TenantRouting),
+// looking up the job's owner with owner. Without routes it publishes everything to the shared topic.
+type TenantProducer struct {
+	brokers []string
+	topic   string
+	routing *TenantRouting
+	owner   JobOwnerFunc
+
+	mu        sync.Mutex
+	producers map[string]*Producer // by topic
+}
+
+// NewTenantProducer creates a producer for the shared jobs topic and the tenant topics derived from it.
+func NewTenantProducer(brokers []string, topic string, routing *TenantRouting, owner JobOwnerFunc) *TenantProducer {
+	return &TenantProducer{
+		brokers:   brokers,
+		topic:     topic,
+		routing:   routing,
+		owner:     owner,
+		producers: make(map[string]*Producer),
+	}
+}
+
+// PublishJob publishes a job message to the job's tenant topic
+func (p *TenantProducer) PublishJob(ctx context.Context, jobID uuid.UUID, traceID string) error {
+	return p.PublishJobMessage(ctx, JobMessage{JobID: jobID, TraceID: traceID})
+}
+
+// PublishSegmentEdit publishes a segment edit message to the job's tenant topic
+func (p *TenantProducer) PublishSegmentEdit(ctx context.Context, jobID uuid.UUID, idx int, regenerateImage bool, traceID string) error {
+	return p.PublishJobMessage(ctx, JobMessage{
+		JobID:           jobID,
+		TraceID:         traceID,
+		SegmentIdx:      &idx,
+		RegenerateImage: regenerateImage,
+	})
+}
+
+// PublishJobMessage publishes msg to the job's tenant topic
+func (p *TenantProducer) PublishJobMessage(ctx context.Context, msg JobMessage) error {
+	topic := p.topic
+	if p.routing.Enabled() {
+		userID, apiKeyID, err := p.owner(ctx, msg.JobID)
+		if err != nil {
+			return fmt.Errorf("failed to look up job owner for tenant routing: %w", err)
+		}
+		topic = p.routing.Topic(p.topic, p.routing.Tenant(userID, apiKeyID))
+	}
+	return p.producer(topic).PublishJobMessage(ctx, msg)
+}
+
+func (p *TenantProducer) producer(topic string) *Producer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	producer, ok := p.producers[topic]
+	if !ok {
+		producer = NewProducer(p.brokers, topic)
+		p.producers[topic] = producer
+	}
+	return producer
+}
+
+// Close closes the producers of all topics published to
+func (p *TenantProducer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var firstErr error
+	for topic, producer := range p.producers {
+		if err := producer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+			log.Error().Err(err).Str("topic", topic).Msg("Failed to close producer")
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,89 @@
+package kafka
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestParseTenantRouting(t *testing.T) {
+	acmeUser, acmeKey, globexKey := uuid.New(), uuid.New(), uuid.New()
+	spec := `[{"tenant":"acme","user_ids":["` + acmeUser.String() + `"],"api_key_ids":["` + acmeKey.String() + `"]},` +
+		`{"tenant":"globex","api_key_ids":["` + globexKey.String() + `"]}]`
+	routing, err := ParseTenantRouting(spec, "", "")
+	if err != nil {
+		t.Fatalf("ParseTenantRouting: %v", err)
+	}
+	if got := routing.Tenants(); len(got) != 2 || got[0] != "acme" || got[1] != "globex" {
+		t.Errorf("Tenants() = %v, want [acme globex]", got)
+	}
+
+	tests := []struct {
+		name     string
+		userID   uuid.UUID
+		apiKeyID uuid.UUID
+		want     string
+	}{
+		{"user", acmeUser, uuid.New(), "acme"},
+		{"api key", uuid.New(), acmeKey, "acme"},
+		{"api key wins over user", acmeUser, globexKey, "globex"},
+		{"shared pool", uuid.New(), uuid.New(), ""},
+	}
+	for _, tt := range tests {
+		if got := routing.Tenant(tt.userID, tt.apiKeyID); got != tt.want {
+			t.Errorf("%s: Tenant() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if got := routing.Topic("greatstories.jobs.v1", "acme"); got != "greatstories.jobs.v1.tenant.acme" {
+		t.Errorf("Topic() = %q", got)
+	}
+	if got := routing.Topic("greatstories.jobs.v1", ""); got != "greatstories.jobs.v1" {
+		t.Errorf("shared Topic() = %q", got)
+	}
+	if got := routing.Group("stories-worker-main", "acme"); got != "stories-worker-main.tenant.acme" {
+		t.Errorf("Group() = %q", got)
+	}
+
+	custom, err := ParseTenantRouting(spec, "tenant-{tenant}.jobs", "workers-{tenant}")
+	if err != nil {
+		t.Fatalf("ParseTenantRouting with templates: %v", err)
+	}
+	if got := custom.Topic("greatstories.jobs.v1", "globex"); got != "tenant-globex.jobs" {
+		t.Errorf("templated Topic() = %q", got)
+	}
+	if got := custom.Group("stories-worker-main", "globex"); got != "workers-globex" {
+		t.Errorf("templated Group() = %q", got)
+	}
+}
+
+func TestParseTenantRouting_Empty(t *testing.T) {
+	routing, err := ParseTenantRouting("", "", "")
+	if err != nil {
+		t.Fatalf("ParseTenantRouting: %v", err)
+	}
+	if routing.Enabled() || routing.Tenant(uuid.New(), uuid.New()) != "" {
+		t.Errorf("empty routing is enabled")
+	}
+}
+
+func TestParseTenantRouting_Invalid(t *testing.T) {
+	id := uuid.New().String()
+	tests := []struct {
+		name, spec, topicTemplate, want string
+	}{
+		{"not json", `{`, "", "invalid KAFKA_TENANT_ROUTES"},
+		{"bad name", `[{"tenant":"Acme Corp","user_ids":["` + id + `"]}]`, "", "must be lowercase"},
+		{"duplicate tenant", `[{"tenant":"acme","user_ids":["` + id + `"]},{"tenant":"acme","api_key_ids":["` + uuid.New().String() + `"]}]`, "", "duplicate tenant"},
+		{"no ids", `[{"tenant":"acme"}]`, "", "needs user_ids or api_key_ids"},
+		{"id routed twice", `[{"tenant":"acme","user_ids":["` + id + `"]},{"tenant":"globex","user_ids":["` + id + `"]}]`, "", "routed to both"},
+		{"template without tenant", `[]`, "{topic}.dedicated", "must contain {tenant}"},
+	}
+	for _, tt := range tests {
+		_, err := ParseTenantRouting(tt.spec, tt.topicTemplate, "")
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
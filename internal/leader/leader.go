@@ -0,0 +1,135 @@
+// Package leader elects one replica to run each singleton background loop (webhook retries, the stuck
+// job sweeper, S3 ingestion polling) so replicas do not duplicate their work.
+//
+// Election uses Postgres session advisory locks: the leader holds pg_try_advisory_lock(key) on a
+// dedicated connection. If the leader's process dies or its connection drops, Postgres releases the lock
+// and another replica takes over at its next attempt.
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"hash/fnv"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Elector campaigns for one named loop.
+type Elector struct {
+	db       *sql.DB
+	name     string
+	key      int64
+	interval time.Duration
+	leading  atomic.Bool
+}
+
+// New creates an elector for the loop name. Followers try to take the lock, and the leader checks its
+// connection, every interval.
+func New(db *sql.DB, name string, interval time.Duration) *Elector {
+	return &Elector{db: db, name: name, key: lockKey(name), interval: interval}
+}
+
+// lockKey is the advisory lock of a loop name.
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("stories.leader." + name))
+	return int64(h.Sum64())
+}
+
+// IsLeader reports whether this process currently runs the loop.
+func (e *Elector) IsLeader() bool {
+	return e.leading.Load()
+}
+
+// Run campaigns until ctx ends. Once elected it calls start with a context canceled when leadership is
+// lost; start must start the loop in the background bound to that context (like the loops' Start
+// methods). A loop iteration in flight when leadership is lost may overlap the new leader's first one.
+// Run blocks; on return the lock is released.
+func (e *Elector) Run(ctx context.Context, start func(ctx context.Context)) {
+	for {
+		if conn := e.tryAcquire(ctx); conn != nil {
+			e.lead(ctx, conn, start)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.interval):
+		}
+	}
+}
+
+// tryAcquire returns the connection holding the lock, or nil when another replica leads.
+func (e *Elector) tryAcquire(ctx context.Context) *sql.Conn {
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Warn().Err(err).Str("loop", e.name).Msg("Leader election: failed to get a connection")
+		}
+		return nil
+	}
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, e.key).Scan(&acquired); err != nil || !acquired {
+		if err != nil && ctx.Err() == nil {
+			log.Warn().Err(err).Str("loop", e.name).Msg("Leader election: failed to try the lock")
+		}
+		conn.Close()
+		return nil
+	}
+	return conn
+}
+
+// lead runs the loop while conn keeps the lock, then releases it.
+func (e *Elector) lead(ctx context.Context, conn *sql.Conn, start func(ctx context.Context)) {
+	leadCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	e.leading.Store(true)
+	defer e.leading.Store(false)
+	log.Info().Str("loop", e.name).Msg("Elected leader")
+	start(leadCtx)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			e.release(conn)
+			log.Info().Str("loop", e.name).Msg("Stepped down as leader")
+			return
+		case <-ticker.C:
+			pingCtx, cancelPing := context.WithTimeout(ctx, e.interval)
+			err := conn.PingContext(pingCtx)
+			cancelPing()
+			if err != nil && ctx.Err() == nil {
+				// The session (and with it the lock) may be gone: stop before another replica takes over
+				cancel()
+				e.release(conn)
+				log.Warn().Err(err).Str("loop", e.name).Msg("Lost leadership")
+				return
+			}
+		}
+	}
+}
+
+// release unlocks and closes conn. A connection that cannot unlock is discarded rather than returned to
+// the pool, where its session would keep holding the lock.
+func (e *Elector) release(conn *sql.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, e.key); err != nil {
+		conn.Raw(func(any) error { return driver.ErrBadConn })
+	}
+	conn.Close()
+}
+
+// Start runs the loop name: under election in the background, or in every replica (start called right
+// away) when interval is 0.
+func Start(ctx context.Context, db *sql.DB, name string, interval time.Duration, start func(ctx context.Context)) {
+	if interval <= 0 {
+		start(ctx)
+		return
+	}
+	go New(db, name, interval).Run(ctx, start)
+}
@@ -0,0 +1,66 @@
+package leader
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/snappy-loop/stories/internal/testutil"
+)
+
+// TestElector_Failover asserts one of two electors leads and the other takes over once it steps down.
+func TestElector_Failover(t *testing.T) {
+	db := testutil.Postgres(t).SQLDB()
+	a := New(db, "sweeper", 20*time.Millisecond)
+	b := New(db, "sweeper", 20*time.Millisecond)
+	var startsA, startsB atomic.Int32
+
+	ctxA, stopA := context.WithCancel(context.Background())
+	defer stopA()
+	ctxB, stopB := context.WithCancel(context.Background())
+	defer stopB()
+	doneA := make(chan struct{})
+	go func() {
+		a.Run(ctxA, func(context.Context) { startsA.Add(1) })
+		close(doneA)
+	}()
+	waitUntil(t, a.IsLeader)
+	go b.Run(ctxB, func(context.Context) { startsB.Add(1) })
+
+	time.Sleep(100 * time.Millisecond)
+	if b.IsLeader() || startsB.Load() != 0 {
+		t.Fatal("second elector leads while the first holds the lock")
+	}
+
+	stopA()
+	<-doneA
+	waitUntil(t, b.IsLeader)
+	if startsA.Load() != 1 || startsB.Load() != 1 {
+		t.Errorf("starts = %d, %d; want 1, 1", startsA.Load(), startsB.Load())
+	}
+
+	// Other loops are elected independently
+	other := New(db, "ingest", 20*time.Millisecond)
+	go other.Run(ctxB, func(context.Context) {})
+	waitUntil(t, other.IsLeader)
+}
+
+func TestStart_Disabled(t *testing.T) {
+	started := false
+	Start(context.Background(), nil, "sweeper", 0, func(context.Context) { started = true })
+	if !started {
+		t.Error("start not called with election disabled")
+	}
+}
+
+func waitUntil(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
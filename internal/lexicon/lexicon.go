@@ -0,0 +1,158 @@
+// Package lexicon applies pronunciation dictionaries to narration scripts before TTS, so product names
+// and technical terms are spoken as intended: respellings replace the term in the script, IPA
+// transcriptions are given to the TTS model as instructions.
+package lexicon
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// Limits of a lexicon (the user's or a job's)
+const (
+	MaxEntries          = 200
+	maxTermLen          = 100
+	maxPronunciationLen = 200
+)
+
+// Validate checks a lexicon's size and entries: each needs a term and exactly one of replacement and
+// ipa, and terms must be unique ignoring case.
+func Validate(entries []models.LexiconEntry) error {
+	if len(entries) > MaxEntries {
+		return fmt.Errorf("lexicon exceeds maximum of %d entries", MaxEntries)
+	}
+	seen := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		term := strings.TrimSpace(e.Term)
+		if term == "" || len(term) > maxTermLen {
+			return fmt.Errorf("lexicon terms must be 1-%d characters", maxTermLen)
+		}
+		if (e.Replacement == "") == (e.IPA == "") {
+			return fmt.Errorf("lexicon entry %q needs exactly one of replacement and ipa", term)
+		}
+		if len(e.Replacement) > maxPronunciationLen || len(e.IPA) > maxPronunciationLen {
+			return fmt.Errorf("lexicon entry %q exceeds %d characters", term, maxPronunciationLen)
+		}
+		key := strings.ToLower(term)
+		if seen[key] {
+			return fmt.Errorf("duplicate lexicon term %q", term)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// Merge returns base with overrides added; an override replaces the base entry with the same term
+// (ignoring case).
+func Merge(base, overrides []models.LexiconEntry) []models.LexiconEntry {
+	if len(overrides) == 0 {
+		return base
+	}
+	overridden := make(map[string]bool, len(overrides))
+	for _, e := range overrides {
+		overridden[strings.ToLower(strings.TrimSpace(e.Term))] = true
+	}
+	merged := make([]models.LexiconEntry, 0, len(base)+len(overrides))
+	for _, e := range base {
+		if !overridden[strings.ToLower(strings.TrimSpace(e.Term))] {
+			merged = append(merged, e)
+		}
+	}
+	return append(merged, overrides...)
+}
+
+// Apply substitutes the replacement entries in script and returns it with the IPA entries whose term
+// occurs in it. Terms match whole words (a term starting or ending with a letter or digit does not match
+// inside a longer word); overlapping terms prefer the longest.
+func Apply(script string, entries []models.LexiconEntry) (string, []models.LexiconEntry) {
+	if len(entries) == 0 || script == "" {
+		return script, nil
+	}
+	sorted := make([]models.LexiconEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.Term = strings.TrimSpace(e.Term); e.Term != "" {
+			sorted = append(sorted, e)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i].Term) > len(sorted[j].Term) })
+
+	alternatives := make([]string, len(sorted))
+	for i, e := range sorted {
+		alternatives[i] = "(" + termPattern(e) + ")"
+	}
+	re, err := regexp.Compile(strings.Join(alternatives, "|"))
+	if err != nil {
+		return script, nil
+	}
+
+	var b strings.Builder
+	var spoken []models.LexiconEntry
+	used := make(map[int]bool)
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(script, -1) {
+		i := matchedEntry(m)
+		e := sorted[i]
+		if e.Replacement == "" {
+			if !used[i] {
+				used[i] = true
+				spoken = append(spoken, e)
+			}
+			continue
+		}
+		b.WriteString(script[last:m[0]])
+		b.WriteString(e.Replacement)
+		last = m[1]
+	}
+	b.WriteString(script[last:])
+	return b.String(), spoken
+}
+
+// Instructions returns the TTS instructions for speaking the IPA entries, or "" when there are none.
+func Instructions(ipa []models.LexiconEntry) string {
+	if len(ipa) == 0 {
+		return ""
+	}
+	parts := make([]string, len(ipa))
+	for i, e := range ipa {
+		parts[i] = fmt.Sprintf("%q as /%s/", e.Term, strings.Trim(e.IPA, "/[] "))
+	}
+	return "Pronounce these terms as given by their IPA transcriptions: " + strings.Join(parts, "; ") + "."
+}
+
+// termPattern matches e's term as a whole word.
+func termPattern(e models.LexiconEntry) string {
+	p := regexp.QuoteMeta(e.Term)
+	if !e.CaseSensitive {
+		p = "(?i:" + p + ")"
+	}
+	first, _ := utf8.DecodeRuneInString(e.Term)
+	lastRune, _ := utf8.DecodeLastRuneInString(e.Term)
+	if isWordRune(first) {
+		p = `\b` + p
+	}
+	if isWordRune(lastRune) {
+		p += `\b`
+	}
+	return p
+}
+
+// isWordRune reports whether \b treats r as a word character (ASCII letters, digits and underscore).
+func isWordRune(r rune) bool {
+	return r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+// matchedEntry returns the index of the alternative (entry) that produced submatch indices m.
+func matchedEntry(m []int) int {
+	for g := 1; 2*g < len(m); g++ {
+		if m[2*g] >= 0 {
+			return g - 1
+		}
+	}
+	return 0
+}
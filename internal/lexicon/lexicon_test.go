@@ -0,0 +1,100 @@
+package lexicon
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+func TestApply(t *testing.T) {
+	entries := []models.LexiconEntry{
+		{Term: "Kubernetes", Replacement: "koo-ber-NET-eez"},
+		{Term: "AWS", Replacement: "A W S", CaseSensitive: true},
+		{Term: "AWS Lambda", Replacement: "A W S lamb-duh", CaseSensitive: true},
+		{Term: "C++", Replacement: "C plus plus"},
+		{Term: "nginx", IPA: "ˈɛndʒɪnˈɛks"},
+		{Term: "Quarkus", IPA: "/ˈkwɑːrkəs/"},
+	}
+	tests := []struct {
+		name, script, want string
+		ipa                []string
+	}{
+		{"ignores case by default", "Deploy to kubernetes, then KUBERNETES.", "Deploy to koo-ber-NET-eez, then koo-ber-NET-eez.", nil},
+		{"longest term first", "AWS Lambda runs on AWS.", "A W S lamb-duh runs on A W S.", nil},
+		{"case sensitive", "aws and AWS", "aws and A W S", nil},
+		{"whole words only", "AWSome Kubernetesless", "AWSome Kubernetesless", nil},
+		{"non-word edges", "Written in C++.", "Written in C plus plus.", nil},
+		{"ipa keeps term", "nginx serves NGINX traffic", "nginx serves NGINX traffic", []string{"nginx"}},
+		{"no matches", "Plain text.", "Plain text.", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ipa := Apply(tt.script, entries)
+			if got != tt.want {
+				t.Errorf("Apply = %q, want %q", got, tt.want)
+			}
+			var terms []string
+			for _, e := range ipa {
+				terms = append(terms, e.Term)
+			}
+			if strings.Join(terms, ",") != strings.Join(tt.ipa, ",") {
+				t.Errorf("ipa = %v, want %v", terms, tt.ipa)
+			}
+		})
+	}
+}
+
+func TestInstructions(t *testing.T) {
+	if got := Instructions(nil); got != "" {
+		t.Errorf("Instructions(nil) = %q, want empty", got)
+	}
+	got := Instructions([]models.LexiconEntry{{Term: "Quarkus", IPA: "/ˈkwɑːrkəs/"}, {Term: "nginx", IPA: "ˈɛndʒɪnˈɛks"}})
+	for _, want := range []string{`"Quarkus" as /ˈkwɑːrkəs/`, `"nginx" as /ˈɛndʒɪnˈɛks/`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Instructions = %q, missing %q", got, want)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := []models.LexiconEntry{{Term: "SQL", Replacement: "sequel"}, {Term: "GIF", Replacement: "jif"}}
+	merged := Merge(base, []models.LexiconEntry{{Term: "gif", Replacement: "ghif"}})
+	if len(merged) != 2 || merged[0].Term != "SQL" || merged[1].Replacement != "ghif" {
+		t.Errorf("Merge = %+v, want SQL and the job's gif", merged)
+	}
+	if got := Merge(base, nil); len(got) != 2 {
+		t.Errorf("Merge without overrides = %+v, want base", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []models.LexiconEntry
+		wantErr string
+	}{
+		{"valid", []models.LexiconEntry{{Term: "SQL", Replacement: "sequel"}, {Term: "nginx", IPA: "ˈɛndʒɪnˈɛks"}}, ""},
+		{"empty", nil, ""},
+		{"missing term", []models.LexiconEntry{{Term: " ", Replacement: "x"}}, "terms must be"},
+		{"no pronunciation", []models.LexiconEntry{{Term: "SQL"}}, "exactly one of"},
+		{"both pronunciations", []models.LexiconEntry{{Term: "SQL", Replacement: "sequel", IPA: "ˈsiːkwəl"}}, "exactly one of"},
+		{"duplicate", []models.LexiconEntry{{Term: "SQL", Replacement: "sequel"}, {Term: "sql", Replacement: "S Q L"}}, "duplicate"},
+		{"too long", []models.LexiconEntry{{Term: "SQL", Replacement: strings.Repeat("x", 201)}}, "exceeds"},
+		{"too many", make([]models.LexiconEntry, MaxEntries+1), "maximum"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.entries)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
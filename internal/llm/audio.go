@@ -10,12 +10,17 @@ import (
 	"strings"
 
 	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/lexicon"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/ssml"
 	unifiedgenai "google.golang.org/genai"
 )
 
 // GenerateAudio generates audio from narration script using the unified genai SDK.
 // Uses gemini-2.5-pro-preview-tts with response_modalities: ["audio"] and SpeechConfig.
 // If script is empty, skips TTS and returns placeholder (avoids unnecessary API call and zero-length audio).
+// Control tags (package ssml) are translated: each <break/> splits the script and becomes silence between
+// the chunks' audio; scripts with invalid tags are spoken without them.
 func (c *Client) GenerateAudio(ctx context.Context, script, audioType string) (*Audio, error) {
 	log.Debug().
 		Str("audio_type", audioType).
@@ -28,7 +33,13 @@ func (c *Client) GenerateAudio(ctx context.Context, script, audioType string) (*
 	}
 
 	if c.unifiedClient != nil {
-		audio, err := c.generateAudioUnified(ctx, script, audioType)
+		parsed, err := ssml.Parse(script)
+		if err != nil {
+			log.Warn().Err(err).Msg("Invalid control tags in narration script, speaking it without them")
+			parsed = &ssml.Script{Chunks: []ssml.Chunk{{Text: ssml.Strip(script)}}}
+		}
+		pronunciation := applyLexicon(parsed, lexiconFromContext(ctx))
+		audio, err := c.generateAudioUnified(ctx, parsed, audioType, pronunciation)
 		if err != nil {
 			log.Warn().Err(err).
 				Str("model", c.modelTTS).
@@ -44,17 +55,48 @@ func (c *Client) GenerateAudio(ctx context.Context, script, audioType string) (*
 	return c.placeholderAudio(script)
 }
 
+// applyLexicon applies the pronunciation lexicon to each chunk of the script and returns the TTS
+// instructions for the IPA entries spoken in it.
+func applyLexicon(script *ssml.Script, entries []models.LexiconEntry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	var ipa []models.LexiconEntry
+	seen := make(map[string]bool)
+	for i := range script.Chunks {
+		text, spoken := lexicon.Apply(script.Chunks[i].Text, entries)
+		script.Chunks[i].Text = text
+		for _, e := range spoken {
+			if !seen[e.Term] {
+				seen[e.Term] = true
+				ipa = append(ipa, e)
+			}
+		}
+	}
+	return lexicon.Instructions(ipa)
+}
+
 // generateAudioUnified uses the unified genai SDK with response_modalities: ["audio"] for TTS.
-// System prompt holds voice/tone instructions; user message is the script, sent as-is.
-func (c *Client) generateAudioUnified(ctx context.Context, script, audioType string) (*Audio, error) {
+// System prompt holds voice/tone, emphasis and pronunciation instructions; user messages are the script's
+// chunks, one TTS call each, whose raw PCM is joined with the breaks' silence.
+func (c *Client) generateAudioUnified(ctx context.Context, script *ssml.Script, audioType, pronunciation string) (*Audio, error) {
 	toneHint := ttsToneHint(audioType)
 	systemPrompt := "You are a TTS model. Speak the text provided by the user."
 	if toneHint != "" {
 		systemPrompt = "You are a TTS model. Use this tone for the narration: " + toneHint + ". Speak the text provided by the user."
 	}
-
-	contents := []*unifiedgenai.Content{
-		unifiedgenai.NewContentFromText(script, unifiedgenai.RoleUser),
+	if script.Emphasis {
+		systemPrompt += " Stress the words between asterisks and never read the asterisks aloud."
+	}
+	if pronunciation != "" {
+		systemPrompt += " " + pronunciation
+	}
+	voiceName := c.ttsVoice
+	if v, ok := voiceFromContext(ctx); ok {
+		voiceName = v.name
+		if v.style != "" {
+			systemPrompt += " Speaking style: " + v.style
+		}
 	}
 
 	temp := float32(1.0)
@@ -65,7 +107,7 @@ func (c *Client) generateAudioUnified(ctx context.Context, script, audioType str
 		SpeechConfig: &unifiedgenai.SpeechConfig{
 			VoiceConfig: &unifiedgenai.VoiceConfig{
 				PrebuiltVoiceConfig: &unifiedgenai.PrebuiltVoiceConfig{
-					VoiceName: c.ttsVoice,
+					VoiceName: voiceName,
 				},
 			},
 		},
@@ -73,59 +115,70 @@ func (c *Client) generateAudioUnified(ctx context.Context, script, audioType str
 
 	log.Debug().
 		Str("model", c.modelTTS).
-		Str("voice", c.ttsVoice).
+		Str("voice", voiceName).
 		Str("audio_type", audioType).
+		Int("chunks", len(script.Chunks)).
 		Msg("Calling unified genai TTS GenerateContentStream")
 
-	// Collect audio data from streaming response
-	var audioBuffer bytes.Buffer
+	// Synthesize each chunk with text; breaks need raw PCM to splice silence in
+	chunks := make([][]byte, len(script.Chunks))
 	var lastMimeType string
-
-	for resp, err := range c.unifiedClient.Models.GenerateContentStream(ctx, c.modelTTS, contents, config) {
-		if err != nil {
-			return nil, fmt.Errorf("TTS stream error: %w", err)
-		}
-		if resp.Candidates == nil || len(resp.Candidates) == 0 {
+	spliced := true
+	for i, chunk := range script.Chunks {
+		if chunk.Text == "" {
 			continue
 		}
-		cand := resp.Candidates[0]
-		if cand.Content == nil || cand.Content.Parts == nil {
-			continue
+		data, mimeType, err := c.synthesize(ctx, chunk.Text, config)
+		if err != nil {
+			return nil, err
 		}
-		for _, part := range cand.Content.Parts {
-			if part.InlineData != nil && len(part.InlineData.Data) > 0 {
-				audioBuffer.Write(part.InlineData.Data)
-				if part.InlineData.MIMEType != "" {
-					lastMimeType = part.InlineData.MIMEType
-				}
+		if !strings.HasPrefix(mimeType, "audio/L") && len(script.Chunks) > 1 {
+			log.Warn().Str("mime_type", mimeType).Msg("TTS audio is not raw PCM, speaking the script without breaks")
+			if data, mimeType, err = c.synthesize(ctx, script.Text(), config); err != nil {
+				return nil, err
 			}
+			chunks = [][]byte{data}
+			lastMimeType = mimeType
+			spliced = false
+			break
 		}
+		chunks[i] = data
+		lastMimeType = mimeType
 	}
-
-	if audioBuffer.Len() == 0 {
+	if lastMimeType == "" {
 		return nil, fmt.Errorf("TTS returned no audio data")
 	}
 
+	var audioBuffer bytes.Buffer
+	params := parseAudioMimeType(lastMimeType)
+	for i, data := range chunks {
+		audioBuffer.Write(data)
+		if spliced && script.Chunks[i].Pause > 0 {
+			frames := int(script.Chunks[i].Pause.Seconds() * float64(params.rate))
+			audioBuffer.Write(make([]byte, frames*params.bitsPerSample/8)) // zero samples are silence
+		}
+	}
+
 	// Convert to WAV if raw PCM (per GEMINI_INTEGRATION.md: "Output: WAV format (converted from raw PCM)")
 	audioBytes := audioBuffer.Bytes()
 	outMime := lastMimeType
-	if lastMimeType != "" && strings.HasPrefix(lastMimeType, "audio/L") {
+	if strings.HasPrefix(lastMimeType, "audio/L") {
 		log.Debug().Str("mime_type", lastMimeType).Msg("Converting raw PCM to WAV")
 		audioBytes = convertToWAV(audioBytes, lastMimeType)
 		outMime = "audio/wav"
 	}
-	if outMime == "" {
-		outMime = "audio/wav"
-	}
 
 	size := int64(len(audioBytes))
-	words := len(script) / 5
+	words := len(script.Text()) / 5
 	duration := float64(words) / 150.0 * 60.0
+	if spliced {
+		duration += script.Pause().Seconds()
+	}
 
 	log.Info().
 		Str("caller", "GenerateAudio").
 		Int64("audio_size_bytes", size).
-		Str("voice", c.ttsVoice).
+		Str("voice", voiceName).
 		Str("mime_type", outMime).
 		Msg("TTS audio generated")
 
@@ -145,6 +198,63 @@ func (c *Client) generateAudioUnified(ctx context.Context, script, audioType str
 	return audio, nil
 }
 
+// synthesize streams the TTS audio of text and returns it with its MIME type (audio/wav when the
+// model does not say).
+func (c *Client) synthesize(ctx context.Context, text string, config *unifiedgenai.GenerateContentConfig) ([]byte, string, error) {
+	contents := []*unifiedgenai.Content{
+		unifiedgenai.NewContentFromText(text, unifiedgenai.RoleUser),
+	}
+
+	// Collect audio data from streaming response
+	var audioBuffer bytes.Buffer
+	var lastMimeType string
+	var usage *unifiedgenai.GenerateContentResponseUsageMetadata
+
+	err := c.call(ctx, c.modelTTS, callWeightText, func() error {
+		// A retried stream starts over
+		audioBuffer.Reset()
+		for resp, err := range c.unifiedClient.Models.GenerateContentStream(ctx, c.modelTTS, contents, config) {
+			if err != nil {
+				return fmt.Errorf("TTS stream error: %w", err)
+			}
+			// Each chunk reports the usage of the stream so far
+			if resp.UsageMetadata != nil {
+				usage = resp.UsageMetadata
+			}
+			if resp.Candidates == nil || len(resp.Candidates) == 0 {
+				continue
+			}
+			cand := resp.Candidates[0]
+			if cand.Content == nil || cand.Content.Parts == nil {
+				continue
+			}
+			for _, part := range cand.Content.Parts {
+				if part.InlineData != nil && len(part.InlineData.Data) > 0 {
+					audioBuffer.Write(part.InlineData.Data)
+					if part.InlineData.MIMEType != "" {
+						lastMimeType = part.InlineData.MIMEType
+					}
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if usage != nil {
+		recordUsage(ctx, c.modelTTS, usage.PromptTokenCount, usage.CandidatesTokenCount)
+	}
+
+	if audioBuffer.Len() == 0 {
+		return nil, "", fmt.Errorf("TTS returned no audio data")
+	}
+	if lastMimeType == "" {
+		lastMimeType = "audio/wav"
+	}
+	return audioBuffer.Bytes(), lastMimeType, nil
+}
+
 // ttsToneHint returns a tone hint for TTS based on audio type.
 func ttsToneHint(audioType string) string {
 	switch audioType {
@@ -0,0 +1,219 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/database"
+)
+
+// Prompt versions are part of every cache key. Bump one whenever its prompt (or, for segmentation,
+// the way LLM boundaries are validated) changes, so results computed under the old rules are not reused.
+const (
+	segmentPromptVersion     = 2
+	narrationPromptVersion   = 2
+	imagePromptPromptVersion = 1
+)
+
+// SharedCache is a cache shared by all workers, such as cache.Redis. Get returns nil on a miss.
+type SharedCache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// SetSharedCache puts cache in front of the Postgres boundary cache and enables caching of narration
+// scripts and image prompts. Entries expire after ttl (0 keeps them until evicted).
+func (c *Client) SetSharedCache(cache SharedCache, ttl time.Duration) {
+	c.sharedCache = cache
+	c.sharedCacheTTL = ttl
+}
+
+// cacheKey returns "v<version>:<options fingerprint>:<text hash>".
+func cacheKey(version int, options, textHash string) string {
+	fingerprint := sha256.Sum256([]byte(options))
+	return fmt.Sprintf("v%d:%s:%s", version, hex.EncodeToString(fingerprint[:6]), textHash)
+}
+
+// boundaryCacheKey returns the boundary cache key for text segmented with the given options.
+// Boundaries depend on the requested segment count (the prompt asks for at least segmentsCount-1
+// breakpoints) and the input type (style guidance); the text hash is case-insensitive.
+func boundaryCacheKey(text string, segmentsCount int, inputType string) string {
+	return cacheKey(segmentPromptVersion, fmt.Sprintf("segments_count=%d&input_type=%s", segmentsCount, inputType), database.TextHash(text))
+}
+
+// narrationCacheKey returns the shared cache key for a narration script.
+func narrationCacheKey(text, audioType, inputType string) string {
+	return "narration:" + cacheKey(narrationPromptVersion, fmt.Sprintf("audio_type=%s&input_type=%s", audioType, inputType), exactTextHash(text))
+}
+
+// imagePromptCacheKey returns the shared cache key for an image prompt.
+func imagePromptCacheKey(text, inputType string) string {
+	return "image_prompt:" + cacheKey(imagePromptPromptVersion, "input_type="+inputType, exactTextHash(text))
+}
+
+// exactTextHash is a case-sensitive SHA-256 of text, for outputs that quote the text.
+func exactTextHash(text string) string {
+	h := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(h[:])
+}
+
+// cachedBoundaries looks up boundaries in the shared cache, then Postgres (copying Postgres hits into
+// the shared cache). Returns nil on a miss or when ctx's cache control skips lookups.
+func (c *Client) cachedBoundaries(ctx context.Context, key string) []int {
+	if (c.sharedCache == nil && c.boundaryCache == nil) || !cacheReadable(ctx) {
+		return nil
+	}
+	if c.sharedCache != nil {
+		data, err := c.sharedCache.Get(ctx, "boundaries:"+key)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to get from shared boundary cache")
+		} else if data != nil {
+			var boundaries []int
+			if err := json.Unmarshal(data, &boundaries); err == nil {
+				c.boundaryStats.record("boundary", key, "redis", nil)
+				return boundaries
+			}
+		}
+	}
+	if c.boundaryCache == nil {
+		c.boundaryStats.record("boundary", key, "", nil)
+		return nil
+	}
+	boundaries, err := c.boundaryCache.Get(ctx, key)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get from boundary cache, proceeding with LLM")
+		c.boundaryStats.record("boundary", key, "", err)
+		return nil
+	}
+	if boundaries == nil {
+		c.boundaryStats.record("boundary", key, "", nil)
+		return nil
+	}
+	c.boundaryStats.record("boundary", key, "postgres", nil)
+	c.setShared(ctx, "boundaries:"+key, boundaries)
+	return boundaries
+}
+
+// storeBoundaries writes validated boundaries to Postgres and the shared cache, unless ctx's cache control
+// is no-store.
+func (c *Client) storeBoundaries(ctx context.Context, key string, boundaries []int) {
+	c.setShared(ctx, "boundaries:"+key, boundaries)
+	if c.boundaryCache == nil || !cacheWritable(ctx) {
+		return
+	}
+	if err := c.boundaryCache.Set(ctx, key, boundaries); err != nil {
+		log.Warn().Err(err).Msg("Failed to cache boundaries")
+		return
+	}
+	log.Info().
+		Str("cache_key", key).
+		Int("boundaries_cached", len(boundaries)).
+		Msg("Cached boundaries for future use")
+}
+
+// cachedString returns a cached string (narration script, image prompt) and counts the lookup. persistent
+// strings are also looked up in Postgres (the boundary cache table), and hits there are copied into the
+// shared cache. Lookups are skipped when ctx's cache control says so.
+func (c *Client) cachedString(ctx context.Context, kind string, stats *cacheMetrics, key string, persistent bool) (string, bool) {
+	if (c.sharedCache == nil && (!persistent || c.boundaryCache == nil)) || !cacheReadable(ctx) {
+		return "", false
+	}
+	if c.sharedCache != nil {
+		data, err := c.sharedCache.Get(ctx, key)
+		if err != nil {
+			log.Warn().Err(err).Str("cache", kind).Msg("Failed to get from shared cache")
+		} else if data != nil {
+			stats.record(kind, key, "redis", nil)
+			return string(data), true
+		}
+	}
+	if !persistent || c.boundaryCache == nil {
+		stats.record(kind, key, "", nil)
+		return "", false
+	}
+	value, ok, err := c.boundaryCache.GetString(ctx, key)
+	if err != nil {
+		log.Warn().Err(err).Str("cache", kind).Msg("Failed to get from Postgres cache")
+		stats.record(kind, key, "", err)
+		return "", false
+	}
+	if !ok {
+		stats.record(kind, key, "", nil)
+		return "", false
+	}
+	stats.record(kind, key, "postgres", nil)
+	c.setShared(ctx, key, value)
+	return value, true
+}
+
+// storeString caches a string in the shared cache and, when persistent, in Postgres.
+func (c *Client) storeString(ctx context.Context, key, value string, persistent bool) {
+	c.setShared(ctx, key, value)
+	if !persistent || c.boundaryCache == nil || !cacheWritable(ctx) {
+		return
+	}
+	if err := c.boundaryCache.SetString(ctx, key, value); err != nil {
+		log.Warn().Err(err).Str("cache_key", key).Msg("Failed to write Postgres cache")
+	}
+}
+
+// setShared JSON-encodes value (strings are stored raw) into the shared cache, if any, unless ctx's cache
+// control is no-store.
+func (c *Client) setShared(ctx context.Context, key string, value any) {
+	if c.sharedCache == nil || !cacheWritable(ctx) {
+		return
+	}
+	var data []byte
+	if s, ok := value.(string); ok {
+		data = []byte(s)
+	} else {
+		var err error
+		if data, err = json.Marshal(value); err != nil {
+			return
+		}
+	}
+	if err := c.sharedCache.Set(ctx, key, data, c.sharedCacheTTL); err != nil {
+		log.Warn().Err(err).Str("cache_key", key).Msg("Failed to write shared cache")
+	}
+}
+
+// cacheMetrics counts cache lookups since start. Like the other worker metrics they are reported as
+// structured log fields: <kind>_cache_{hits,misses,errors}_total and <kind>_cache_hit_rate.
+type cacheMetrics struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+	errors atomic.Int64
+}
+
+// record counts one lookup and logs the running totals. layer is where the hit came from
+// ("redis" or "postgres"), empty on a miss or error.
+func (m *cacheMetrics) record(kind, key, layer string, err error) {
+	switch {
+	case err != nil:
+		m.errors.Add(1)
+	case layer != "":
+		m.hits.Add(1)
+	default:
+		m.misses.Add(1)
+	}
+	hits, misses := m.hits.Load(), m.misses.Load()
+	var hitRate float64
+	if hits+misses > 0 {
+		hitRate = float64(hits) / float64(hits+misses)
+	}
+	log.Info().
+		Str("cache_key", key).
+		Str("cache_layer", layer).
+		Bool(kind+"_cache_hit", layer != "").
+		Int64(kind+"_cache_hits_total", hits).
+		Int64(kind+"_cache_misses_total", misses).
+		Int64(kind+"_cache_errors_total", m.errors.Load()).
+		Float64(kind+"_cache_hit_rate", hitRate).
+		Msg("Cache lookup")
+}
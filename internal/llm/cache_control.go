@@ -0,0 +1,50 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+)
+
+// CacheControl is how a call uses the result caches (segmentation boundaries, narration scripts, image
+// prompts). Agent clients set it per call; jobs always use the default.
+type CacheControl string
+
+// Cache control values, named after their HTTP Cache-Control counterparts.
+const (
+	CacheDefault CacheControl = ""         // use cached results and cache fresh ones
+	CacheNoCache CacheControl = "no-cache" // skip lookups; the fresh result replaces the cached one
+	CacheNoStore CacheControl = "no-store" // neither read nor write the caches
+)
+
+// ParseCacheControl parses "", "default", "no-cache" or "no-store".
+func ParseCacheControl(s string) (CacheControl, error) {
+	switch CacheControl(s) {
+	case CacheDefault, "default":
+		return CacheDefault, nil
+	case CacheNoCache, CacheNoStore:
+		return CacheControl(s), nil
+	}
+	return "", fmt.Errorf("invalid cache control %q (want default, no-cache or no-store)", s)
+}
+
+type cacheControlKey struct{}
+
+// WithCacheControl returns a context whose calls use the caches as cc says.
+func WithCacheControl(ctx context.Context, cc CacheControl) context.Context {
+	if cc == CacheDefault {
+		return ctx
+	}
+	return context.WithValue(ctx, cacheControlKey{}, cc)
+}
+
+// cacheReadable reports whether ctx's calls may use cached results.
+func cacheReadable(ctx context.Context) bool {
+	cc, _ := ctx.Value(cacheControlKey{}).(CacheControl)
+	return cc == CacheDefault
+}
+
+// cacheWritable reports whether ctx's calls may cache their results.
+func cacheWritable(ctx context.Context) bool {
+	cc, _ := ctx.Value(cacheControlKey{}).(CacheControl)
+	return cc != CacheNoStore
+}
@@ -0,0 +1,125 @@
+package llm
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBoundaryCacheKey(t *testing.T) {
+	text := "Some text. More text."
+	key := boundaryCacheKey(text, 3, "educational")
+	if !strings.HasPrefix(key, "v2:") {
+		t.Errorf("key %q does not start with the prompt version", key)
+	}
+	if key != boundaryCacheKey(strings.ToUpper(text), 3, "educational") {
+		t.Error("key should be case-insensitive in the text")
+	}
+	for _, other := range []string{
+		boundaryCacheKey(text, 4, "educational"),
+		boundaryCacheKey(text, 3, "fictional"),
+		boundaryCacheKey(text+" Extra.", 3, "educational"),
+	} {
+		if other == key {
+			t.Errorf("different options or text produced the same key %q", key)
+		}
+	}
+}
+
+func TestCacheMetrics(t *testing.T) {
+	var m cacheMetrics
+	m.record("boundary", "k", "redis", nil)
+	m.record("boundary", "k", "", nil)
+	m.record("boundary", "k", "", nil)
+	if m.hits.Load() != 1 || m.misses.Load() != 2 || m.errors.Load() != 0 {
+		t.Errorf("hits=%d misses=%d errors=%d, want 1/2/0", m.hits.Load(), m.misses.Load(), m.errors.Load())
+	}
+}
+
+// memoryCache is an in-memory SharedCache.
+type memoryCache map[string][]byte
+
+func (m memoryCache) Get(_ context.Context, key string) ([]byte, error) { return m[key], nil }
+
+func (m memoryCache) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	m[key] = value
+	return nil
+}
+
+func TestSharedCache(t *testing.T) {
+	ctx := context.Background()
+	shared := memoryCache{}
+	c := &Client{}
+	c.SetSharedCache(shared, time.Hour)
+
+	key := boundaryCacheKey("One. Two.", 2, "educational")
+	if got := c.cachedBoundaries(ctx, key); got != nil {
+		t.Fatalf("empty cache returned %v", got)
+	}
+	c.storeBoundaries(ctx, key, []int{4, 9})
+	if got := c.cachedBoundaries(ctx, key); !reflect.DeepEqual(got, []int{4, 9}) {
+		t.Errorf("cachedBoundaries = %v, want [4 9]", got)
+	}
+
+	// Without models narration is empty and must not be cached.
+	if n, _ := c.GenerateNarration(ctx, "Some text.", "free_speech", "educational"); n != "" || len(shared) != 1 {
+		t.Fatalf("narration %q cached entries %d", n, len(shared))
+	}
+	shared[narrationCacheKey("Some text.", "free_speech", "educational")] = []byte("Cached script.")
+	if n, _ := c.GenerateNarration(ctx, "Some text.", "free_speech", "educational"); n != "Cached script." {
+		t.Errorf("narration = %q, want cached script", n)
+	}
+	if n, _ := c.GenerateNarration(ctx, "Some text.", "podcast", "educational"); n != "" {
+		t.Errorf("narration for another audio type = %q, want a miss", n)
+	}
+
+	// Fallback image prompts are not cached.
+	c.GenerateImagePrompt(ctx, "A castle.", "fictional")
+	if _, ok := shared[imagePromptCacheKey("A castle.", "fictional")]; ok {
+		t.Error("fallback image prompt was cached")
+	}
+}
+
+func TestCacheControl(t *testing.T) {
+	shared := memoryCache{}
+	c := &Client{}
+	c.SetSharedCache(shared, time.Hour)
+	key := boundaryCacheKey("One. Two.", 2, "educational")
+
+	noStore := WithCacheControl(context.Background(), CacheNoStore)
+	c.storeBoundaries(noStore, key, []int{4, 9})
+	if len(shared) != 0 {
+		t.Fatalf("no-store wrote %d entries", len(shared))
+	}
+
+	noCache := WithCacheControl(context.Background(), CacheNoCache)
+	c.storeBoundaries(noCache, key, []int{4, 9})
+	if got := c.cachedBoundaries(noCache, key); got != nil {
+		t.Errorf("no-cache read %v", got)
+	}
+	if got := c.cachedBoundaries(noStore, key); got != nil {
+		t.Errorf("no-store read %v", got)
+	}
+	if got := c.cachedBoundaries(context.Background(), key); !reflect.DeepEqual(got, []int{4, 9}) {
+		t.Errorf("no-cache result not cached: %v", got)
+	}
+
+	shared[imagePromptCacheKey("A castle.", "fictional")] = []byte("Cached prompt.")
+	if _, ok := c.cachedString(noCache, "image_prompt", &c.imagePromptStats, imagePromptCacheKey("A castle.", "fictional"), true); ok {
+		t.Error("no-cache read a cached image prompt")
+	}
+	if p, _ := c.GenerateImagePrompt(context.Background(), "A castle.", "fictional"); p != "Cached prompt." {
+		t.Errorf("image prompt = %q, want the cached one", p)
+	}
+
+	for in, want := range map[string]CacheControl{"": CacheDefault, "default": CacheDefault, "no-cache": CacheNoCache, "no-store": CacheNoStore} {
+		if got, err := ParseCacheControl(in); err != nil || got != want {
+			t.Errorf("ParseCacheControl(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseCacheControl("max-age=60"); err == nil {
+		t.Error("unsupported cache control accepted")
+	}
+}
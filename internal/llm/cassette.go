@@ -0,0 +1,205 @@
+package llm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CassetteMode selects whether a Cassette calls Gemini or answers from its file.
+type CassetteMode int
+
+const (
+	// CassetteReplay answers every request from the cassette file and never calls Gemini.
+	CassetteReplay CassetteMode = iota
+	// CassetteRecord forwards requests to Gemini and records the responses; Save writes them out.
+	CassetteRecord
+)
+
+// defaultCassetteUpstream is the Gemini API base URL requests are recorded from.
+const defaultCassetteUpstream = "https://generativelanguage.googleapis.com"
+
+// Cassette records Gemini REST responses once and replays them, so tests that exercise real model
+// output run in CI without network calls or an API key. Like FakeGemini it is an http.Handler: serve
+// it with httptest.NewServer and pass the server URL as NewClient's apiEndpoint.
+//
+// Requests are matched on method, path (model and method) and a hash of the JSON body, so a prompt
+// change is a replay miss: re-record the cassette. Repeated identical requests replay their recordings
+// in order, the last one repeating. API keys are never written to the file.
+type Cassette struct {
+	// Upstream is the base URL recorded from (default the Gemini API).
+	Upstream string
+	// APIKey is sent upstream in record mode as x-goog-api-key.
+	APIKey string
+	// HTTPClient makes the upstream calls (default http.DefaultClient).
+	HTTPClient *http.Client
+
+	path string
+	mode CassetteMode
+
+	mu           sync.Mutex
+	interactions []*CassetteInteraction
+	replayed     map[string]int // key -> interactions replayed
+	misses       []string
+}
+
+// CassetteInteraction is one recorded request and its response.
+type CassetteInteraction struct {
+	Key         string `json:"key"` // method, path and body hash
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        string `json:"body"`
+}
+
+type cassetteFile struct {
+	Interactions []*CassetteInteraction `json:"interactions"`
+}
+
+// NewCassette returns a cassette backed by the file at path. In replay mode the file must exist.
+func NewCassette(path string, mode CassetteMode) (*Cassette, error) {
+	c := &Cassette{path: path, mode: mode, replayed: map[string]int{}}
+	if mode == CassetteRecord {
+		return c, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read cassette: %w", err)
+	}
+	var file cassetteFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse cassette %s: %w", path, err)
+	}
+	c.interactions = file.Interactions
+	return c, nil
+}
+
+// Misses returns the keys of replayed requests that had no recording.
+func (c *Cassette) Misses() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.misses...)
+}
+
+// Save writes the recorded interactions to the cassette file, sorted by key so re-recording the same
+// calls gives a small diff. It is a no-op in replay mode.
+func (c *Cassette) Save() error {
+	if c.mode != CassetteRecord {
+		return nil
+	}
+	c.mu.Lock()
+	interactions := append([]*CassetteInteraction(nil), c.interactions...)
+	c.mu.Unlock()
+	// Stable, so repeated requests keep their recording order
+	sort.SliceStable(interactions, func(i, j int) bool { return interactions[i].Key < interactions[j].Key })
+	data, err := json.MarshalIndent(cassetteFile{Interactions: interactions}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, append(data, '\n'), 0o644)
+}
+
+func (c *Cassette) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeFakeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", err.Error())
+		return
+	}
+	key := cassetteKey(r.Method, r.URL.Path, body)
+
+	var rec *CassetteInteraction
+	if c.mode == CassetteRecord {
+		rec, err = c.record(r, key, body)
+		if err != nil {
+			writeFakeError(w, http.StatusBadGateway, "UNAVAILABLE", err.Error())
+			return
+		}
+	} else if rec = c.replay(key); rec == nil {
+		writeFakeError(w, http.StatusNotFound, "NOT_FOUND", "no recorded interaction for "+key+"; re-record the cassette")
+		return
+	}
+	if rec.ContentType != "" {
+		w.Header().Set("Content-Type", rec.ContentType)
+	}
+	w.WriteHeader(rec.Status)
+	io.WriteString(w, rec.Body)
+}
+
+// replay returns the next recording of key, or nil on a miss.
+func (c *Cassette) replay(key string) *CassetteInteraction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var matches []*CassetteInteraction
+	for _, rec := range c.interactions {
+		if rec.Key == key {
+			matches = append(matches, rec)
+		}
+	}
+	if len(matches) == 0 {
+		c.misses = append(c.misses, key)
+		return nil
+	}
+	n := c.replayed[key]
+	c.replayed[key] = n + 1
+	return matches[min(n, len(matches)-1)]
+}
+
+// record forwards the request upstream and keeps the response.
+func (c *Cassette) record(r *http.Request, key string, body []byte) (*CassetteInteraction, error) {
+	upstream := c.Upstream
+	if upstream == "" {
+		upstream = defaultCassetteUpstream
+	}
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, strings.TrimSuffix(upstream, "/")+r.URL.RequestURI(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", r.Header.Get("Content-Type"))
+	// The client's key (if any) is replaced by the recorder's
+	q := req.URL.Query()
+	q.Del("key")
+	req.URL.RawQuery = q.Encode()
+	if c.APIKey != "" {
+		req.Header.Set("x-goog-api-key", c.APIKey)
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("record %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("record %s: %w", key, err)
+	}
+	rec := &CassetteInteraction{Key: key, Status: resp.StatusCode, ContentType: resp.Header.Get("Content-Type"), Body: string(respBody)}
+	c.mu.Lock()
+	c.interactions = append(c.interactions, rec)
+	c.mu.Unlock()
+	return rec, nil
+}
+
+// cassetteKey identifies a request by method, path and a hash of its body. JSON bodies are compacted
+// first so whitespace differences between SDK versions do not break replay.
+func cassetteKey(method, path string, body []byte) string {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, body); err == nil {
+		body = compact.Bytes()
+	}
+	sum := sha256.Sum256(body)
+	return method + " " + path + " " + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,206 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const cassetteTestText = "The sun is a star. It is very hot! Planets orbit around it."
+
+func newTestClient(endpoint string) *Client {
+	return NewClient("test-key", nil, "gemini-2.5-flash-lite", "gemini-3-pro-preview", "", "", "", endpoint, "", "", nil, "")
+}
+
+// runPipelineCalls makes the calls of one segment's processing and returns their text outputs.
+func runPipelineCalls(t *testing.T, c *Client) []string {
+	t.Helper()
+	ctx := context.Background()
+	segments, err := c.SegmentText(ctx, cassetteTestText, 2, "educational")
+	if err != nil {
+		t.Fatalf("SegmentText: %v", err)
+	}
+	out := []string{}
+	for _, seg := range segments {
+		out = append(out, seg.Text)
+	}
+	script, err := c.GenerateNarration(ctx, segments[0].Text, "free_speech", "educational")
+	if err != nil {
+		t.Fatalf("GenerateNarration: %v", err)
+	}
+	audio, err := c.GenerateAudio(ctx, "Welcome to the solar system.", "free_speech")
+	if err != nil {
+		t.Fatalf("GenerateAudio: %v", err)
+	}
+	prompt, err := c.GenerateImagePrompt(ctx, segments[0].Text, "educational")
+	if err != nil {
+		t.Fatalf("GenerateImagePrompt: %v", err)
+	}
+	img, err := c.GenerateImage(ctx, prompt)
+	if err != nil {
+		t.Fatalf("GenerateImage: %v", err)
+	}
+	factCheck, err := c.FactCheckSegment(ctx, segments[0].Text)
+	if err != nil {
+		t.Fatalf("FactCheckSegment: %v", err)
+	}
+	audioData, _ := io.ReadAll(audio.Data)
+	imgData, _ := io.ReadAll(img.Data)
+	return append(out, script, audio.MimeType, string(audioData), prompt, img.MimeType, string(imgData), factCheck)
+}
+
+func TestFakeGemini_ServesAllSDKs(t *testing.T) {
+	fake := NewFakeGemini()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	out := runPipelineCalls(t, newTestClient(srv.URL))
+	if len(out) != 9 {
+		t.Fatalf("got %d segments, want 2 (outputs: %q)", len(out)-7, out)
+	}
+	if strings.TrimSpace(out[0]) != "The sun is a star. It is very hot!" || strings.TrimSpace(out[1]) != "Planets orbit around it." {
+		t.Errorf("segments = %q, %q; want split at the fake's sentence boundaries", out[0], out[1])
+	}
+	if !strings.HasPrefix(out[8], "Fake gemini-2.5-flash-lite response") {
+		t.Errorf("fact check = %q, want fake flash response", out[8])
+	}
+	if out[3] != "audio/wav" || out[6] != "image/png" {
+		t.Errorf("audio mime = %q, image mime = %q", out[3], out[6])
+	}
+
+	var models []string
+	for _, call := range fake.Calls() {
+		models = append(models, call.Model+":"+call.Method)
+	}
+	for _, want := range []string{"gemini-3-flash-preview:generateContent", "gemini-2.5-pro-preview-tts:streamGenerateContent", "gemini-3-pro-image-preview:generateContent"} {
+		if !strings.Contains(strings.Join(models, " "), want) {
+			t.Errorf("calls %v missing %s", models, want)
+		}
+	}
+}
+
+// TestNewClient_Proxy asserts every SDK sends its calls through the configured proxy (here a forward
+// proxy answering with the fake for an endpoint that does not resolve).
+func TestNewClient_Proxy(t *testing.T) {
+	fake := NewFakeGemini()
+	var proxied, direct int
+	proxySrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Host != "gemini.invalid" {
+			direct++
+			http.Error(w, "not a proxy request", http.StatusBadGateway)
+			return
+		}
+		proxied++
+		fake.ServeHTTP(w, r)
+	}))
+	defer proxySrv.Close()
+
+	c := NewClient("test-key", nil, "gemini-2.5-flash-lite", "gemini-3-pro-preview", "", "", "", "http://gemini.invalid/gemini", "", "", nil, proxySrv.URL)
+	runPipelineCalls(t, c)
+	if proxied == 0 || direct != 0 {
+		t.Errorf("proxied %d requests, %d not for the endpoint; want all through the proxy", proxied, direct)
+	}
+}
+
+func TestFakeGemini_ScriptedText(t *testing.T) {
+	fake := NewFakeGemini()
+	fake.Text = func(req FakeRequest) (string, bool) {
+		if strings.Contains(req.System, "fact") {
+			return "All claims check out.", true
+		}
+		return "", false
+	}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	got, err := newTestClient(srv.URL).FactCheckSegment(context.Background(), cassetteTestText)
+	if err != nil {
+		t.Fatalf("FactCheckSegment: %v", err)
+	}
+	if got != "All claims check out." {
+		t.Errorf("fact check = %q, want scripted text", got)
+	}
+}
+
+func TestCassette_RecordThenReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pipeline.json")
+
+	// Record from the fake, standing in for Gemini
+	upstream := httptest.NewServer(NewFakeGemini())
+	recorder, err := NewCassette(path, CassetteRecord)
+	if err != nil {
+		t.Fatalf("NewCassette: %v", err)
+	}
+	recorder.Upstream = upstream.URL
+	recorder.APIKey = "secret-key"
+	recSrv := httptest.NewServer(recorder)
+	recorded := runPipelineCalls(t, newTestClient(recSrv.URL))
+	recSrv.Close()
+	upstream.Close()
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// Replay with the upstream gone
+	player, err := NewCassette(path, CassetteReplay)
+	if err != nil {
+		t.Fatalf("NewCassette replay: %v", err)
+	}
+	playSrv := httptest.NewServer(player)
+	defer playSrv.Close()
+	replayed := runPipelineCalls(t, newTestClient(playSrv.URL))
+	if misses := player.Misses(); len(misses) > 0 {
+		t.Fatalf("replay misses: %v", misses)
+	}
+	if strings.Join(replayed, "\x00") != strings.Join(recorded, "\x00") {
+		t.Errorf("replayed outputs differ from recorded:\n%q\n%q", replayed, recorded)
+	}
+
+	// A changed prompt is a miss, not a silent fallback to another recording
+	if _, err := newTestClient(playSrv.URL).FactCheckSegment(context.Background(), "Something else entirely."); err == nil || len(player.Misses()) == 0 {
+		t.Error("expected a replay miss for an unrecorded prompt")
+	}
+}
+
+func TestCassette_NeverStoresAPIKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "c.json")
+	upstream := httptest.NewServer(NewFakeGemini())
+	defer upstream.Close()
+	recorder, _ := NewCassette(path, CassetteRecord)
+	recorder.Upstream = upstream.URL
+	recorder.APIKey = "secret-key"
+	srv := httptest.NewServer(recorder)
+	defer srv.Close()
+	if _, err := newTestClient(srv.URL).FactCheckSegment(context.Background(), cassetteTestText); err != nil {
+		t.Fatalf("FactCheckSegment: %v", err)
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	c, err := NewCassette(path, CassetteReplay)
+	if err != nil {
+		t.Fatalf("NewCassette: %v", err)
+	}
+	for _, rec := range c.interactions {
+		if strings.Contains(rec.Key+rec.Body, "secret-key") || strings.Contains(rec.Key+rec.Body, "test-key") {
+			t.Errorf("cassette contains the API key: %+v", rec)
+		}
+	}
+}
+
+func TestFakeBoundaries(t *testing.T) {
+	got := fakeBoundaries("Hi. Dr.Who is here! 🙋‍♂️ Yes")
+	want := []int{3, 19, 25}
+	if len(got) != len(want) {
+		t.Fatalf("fakeBoundaries = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("fakeBoundaries = %v, want %v", got, want)
+		}
+	}
+}
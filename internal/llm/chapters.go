@@ -0,0 +1,190 @@
+package llm
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// chapter is a heading that starts a new part of a structured document.
+type chapter struct {
+	start int    // byte offset of the heading's first character
+	title string // empty for the preamble before the first heading
+}
+
+var (
+	reMarkdownHeading = regexp.MustCompile(`^(#{1,6})[ \t]+(.+?)(?:[ \t]+#+)?$`)
+	reChapterMarker   = regexp.MustCompile(`(?i)^(?:(?:chapter|chap\.|part|book|kapitel|teil|chapitre|partie|cap[ií]tulo|capitolo|hoofdstuk)[ \t]+(?:\d+|[ivxlcdm]+|one|two|three|four|five|six|seven|eight|nine|ten|eleven|twelve)\b.{0,80}|(?:prologue|epilogue|prolog|epilog|preface)(?:[ \t]*:.{0,80})?|第[0-9〇一二三四五六七八九十百千]+[章回節节部].{0,40})$`)
+)
+
+// detectChapters returns the chapter headings of text, or nil when it does not look structured.
+// Markdown ATX headings win over chapter markers ("Chapter 3", "Kapitel IV", "Prologue", "第三章").
+// Only the shallowest heading level that occurs at least twice (and any shallower headings) counts,
+// so a document title over "##" chapters does not also split at every "###" subsection.
+// Headings inside fenced code blocks are ignored. At least two headings are required.
+func detectChapters(text string) []chapter {
+	type heading struct {
+		chapter
+		level int
+	}
+	var headings []heading
+	var markers []chapter
+	inFence := false
+	for offset := 0; offset < len(text); {
+		line := text[offset:]
+		next := len(text)
+		if i := strings.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i]
+			next = offset + i + 1
+		}
+		trimmed := strings.TrimSpace(line)
+		start := offset + strings.Index(line, trimmed)
+		offset = next
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence || trimmed == "" {
+			continue
+		}
+		if m := reMarkdownHeading.FindStringSubmatch(trimmed); m != nil {
+			headings = append(headings, heading{chapter{start: start, title: strings.TrimSpace(m[2])}, len(m[1])})
+		} else if reChapterMarker.MatchString(trimmed) {
+			markers = append(markers, chapter{start: start, title: strings.TrimRight(trimmed, " \t:")})
+		}
+	}
+
+	if len(headings) > 0 {
+		var perLevel [7]int
+		for _, h := range headings {
+			perLevel[h.level]++
+		}
+		level := 0
+		for l := 1; l <= 6 && level == 0; l++ {
+			if perLevel[l] >= 2 {
+				level = l
+			}
+		}
+		var chapters []chapter
+		for _, h := range headings {
+			if level > 0 && h.level <= level {
+				chapters = append(chapters, h.chapter)
+			}
+		}
+		if len(chapters) >= 2 {
+			return chapters
+		}
+	}
+	if len(markers) >= 2 {
+		return markers
+	}
+	return nil
+}
+
+// segmentByChapters uses chapter starts as hard segment boundaries. When there are at least
+// segmentsCount sections (chapters plus any preamble), whole sections are merged into segments
+// without calling segment; otherwise each section is split further with segment, longer sections
+// getting more segments. Segments are titled after the chapter they start in.
+func segmentByChapters(text string, chapters []chapter, segmentsCount int, segment func(text string, count int) ([]*Segment, error)) ([]*Segment, error) {
+	sections := chapters
+	if chapters[0].start > 0 {
+		sections = append([]chapter{{start: 0}}, chapters...)
+	}
+	ends := make([]int, len(sections))
+	lengths := make([]int, len(sections))
+	for i := range sections {
+		ends[i] = len(text)
+		if i+1 < len(sections) {
+			ends[i] = sections[i+1].start
+		}
+		lengths[i] = ends[i] - sections[i].start
+	}
+
+	var segments []*Segment
+	if len(sections) >= segmentsCount {
+		byteOffsets := runeToByteOffsets(text)
+		boundaries := make([]int, len(ends))
+		for i, end := range ends {
+			boundaries[i] = findGraphemeForBytePos(byteOffsets, end)
+		}
+		segments = mergeBoundariesIntoSegments(boundaries, byteOffsets, text, segmentsCount)
+		for _, seg := range segments {
+			seg.Title = nil
+			for _, s := range sections {
+				if s.start == seg.StartChar {
+					seg.Title = sectionTitle(s.title, 0)
+				}
+			}
+		}
+	} else {
+		counts := allocateSegments(lengths, segmentsCount)
+		for i, s := range sections {
+			section := strings.TrimRightFunc(text[s.start:ends[i]], unicode.IsSpace)
+			segs, err := segment(section, counts[i])
+			if err != nil {
+				return nil, err
+			}
+			for k, seg := range segs {
+				seg.StartChar += s.start
+				seg.EndChar += s.start
+				seg.Title = sectionTitle(s.title, k)
+			}
+			segments = append(segments, segs...)
+		}
+	}
+
+	// Preamble segments have no chapter title; number them like any other untitled segment
+	for i, seg := range segments {
+		if seg.Title == nil {
+			title := fmt.Sprintf("Part %d", i+1)
+			seg.Title = &title
+		}
+	}
+	return segments, nil
+}
+
+// sectionTitle returns the title of the k-th segment (0-based) of a chapter: the chapter title for
+// the first one, "Title (2)", "Title (3)", ... for the rest, and nil for the preamble.
+func sectionTitle(title string, k int) *string {
+	if title == "" {
+		return nil
+	}
+	if k > 0 {
+		title = fmt.Sprintf("%s (%d)", title, k+1)
+	}
+	return &title
+}
+
+// allocateSegments gives each section one segment and distributes the remaining segmentsCount
+// by section length (largest remainder first).
+func allocateSegments(lengths []int, segmentsCount int) []int {
+	counts := make([]int, len(lengths))
+	total := 0
+	for i, l := range lengths {
+		counts[i] = 1
+		total += l
+	}
+	extra := segmentsCount - len(lengths)
+	if extra <= 0 || total == 0 {
+		return counts
+	}
+
+	order := make([]int, len(lengths))
+	remainders := make([]int, len(lengths))
+	assigned := 0
+	for i, l := range lengths {
+		share := extra * l
+		counts[i] += share / total
+		assigned += share / total
+		remainders[i] = share % total
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]] > remainders[order[b]] })
+	for k := 0; k < extra-assigned; k++ {
+		counts[order[k]]++
+	}
+	return counts
+}
@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectChapters(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "markdown levels",
+			text: "# Book\n\nIntro.\n\n## One\n\nText.\n\n### Detail\n\nMore.\n\n## Two\n\nEnd.",
+			want: []string{"Book", "One", "Two"},
+		},
+		{
+			name: "fenced code ignored",
+			text: "# A\n\n```\n# not a heading\n```\n\n# B\n\nText.",
+			want: []string{"A", "B"},
+		},
+		{
+			name: "chapter markers",
+			text: "Prologue\n\nIt began.\n\nChapter 1: The Road\n\nThey walked.\n\nCHAPTER II\n\nThey stopped.",
+			want: []string{"Prologue", "Chapter 1: The Road", "CHAPTER II"},
+		},
+		{name: "cjk chapters", text: "第一章 开始\n\n故事。\n\n第二章 结束\n\n完。", want: []string{"第一章 开始", "第二章 结束"}},
+		{name: "single heading", text: "# Title\n\nJust one section.", want: nil},
+		{name: "prose", text: "Part of the story is here. Part two follows.", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, ch := range detectChapters(tt.text) {
+				got = append(got, ch.title)
+				if !strings.HasPrefix(tt.text[ch.start:], strings.SplitN(ch.title, " ", 2)[0]) &&
+					!strings.HasPrefix(tt.text[ch.start:], "#") {
+					t.Errorf("chapter %q starts at %q", ch.title, tt.text[ch.start:])
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("titles = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSegmentTextHeuristic_Chapters(t *testing.T) {
+	c := &Client{}
+	text := "Preface text before any chapter.\n\n" +
+		"# Arrival\n\nThe train was late and nobody was waiting on the cold platform.\n\n" +
+		"A porter finally came over and offered to carry the heavy bags.\n\n" +
+		"# Departure\n\nShe left at dawn while the whole town still slept behind her.\n\n" +
+		"The road north was empty and she did not look back once."
+
+	// Fewer segments than sections: whole sections are merged, titled after their first chapter.
+	segs := c.SegmentTextHeuristic(text, 2)
+	if len(segs) != 2 {
+		t.Fatalf("got %d segments, want 2", len(segs))
+	}
+	if *segs[0].Title != "Part 1" || *segs[1].Title != "Departure" {
+		t.Errorf("titles = %q, %q", *segs[0].Title, *segs[1].Title)
+	}
+	if !strings.HasPrefix(segs[1].Text, "# Departure") {
+		t.Errorf("segment 2 does not start at its heading: %q", segs[1].Text)
+	}
+
+	// More segments than sections: chapters are split further, never across a heading.
+	segs = c.SegmentTextHeuristic(text, 5)
+	var titles []string
+	for _, seg := range segs {
+		titles = append(titles, *seg.Title)
+		if seg.Text != text[seg.StartChar:seg.EndChar] {
+			t.Errorf("segment %q has offsets [%d,%d) that do not match its text", *seg.Title, seg.StartChar, seg.EndChar)
+		}
+		if strings.TrimSpace(seg.Text) == "# "+strings.TrimSuffix(*seg.Title, " (2)") {
+			t.Errorf("segment %q is only its heading", *seg.Title)
+		}
+		if i := strings.Index(seg.Text, "# "); i > 0 {
+			t.Errorf("segment %q crosses a heading: %q", *seg.Title, seg.Text)
+		}
+	}
+	want := []string{"Part 1", "Arrival", "Arrival (2)", "Departure", "Departure (2)"}
+	if !reflect.DeepEqual(titles, want) {
+		t.Errorf("titles = %q, want %q", titles, want)
+	}
+}
+
+func TestAllocateSegments(t *testing.T) {
+	if got := allocateSegments([]int{100, 300, 100}, 7); !reflect.DeepEqual(got, []int{2, 3, 2}) {
+		t.Errorf("allocateSegments = %v", got)
+	}
+	if got := allocateSegments([]int{10, 10}, 1); !reflect.DeepEqual(got, []int{1, 1}) {
+		t.Errorf("allocateSegments with fewer segments than sections = %v", got)
+	}
+}
@@ -7,13 +7,18 @@ import (
 	"net/url"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/google/generative-ai-go/genai"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/inputtype"
+	"github.com/snappy-loop/stories/internal/proxy"
 	"github.com/tmc/langchaingo/llms"
 	"github.com/tmc/langchaingo/llms/googleai"
+	"github.com/tmc/langchaingo/llms/googleai/vertex"
+	"golang.org/x/oauth2"
 	"google.golang.org/api/option"
 	unifiedgenai "google.golang.org/genai"
 )
@@ -25,18 +30,35 @@ const maxGeminiResponseLogBytes = 8192
 const maxSegmentInputLogBytes = 4096
 
 // httpClientForEndpoint returns an http.Client that rewrites request URLs to the given base endpoint (e.g. http://host.docker.internal:31300/gemini).
-func httpClientForEndpoint(baseEndpoint string) *http.Client {
+// next sends the rewritten requests (nil: http.DefaultTransport).
+func httpClientForEndpoint(baseEndpoint string, next http.RoundTripper) *http.Client {
 	base, err := url.Parse(baseEndpoint)
 	if err != nil {
 		log.Warn().Err(err).Str("endpoint", baseEndpoint).Msg("Invalid GEMINI_API_ENDPOINT, using default")
 		return nil
 	}
 	base.Path = strings.TrimSuffix(base.Path, "/")
+	if next == nil {
+		next = http.DefaultTransport
+	}
 	return &http.Client{
-		Transport: &endpointRoundTripper{base: base, next: http.DefaultTransport},
+		Transport: &endpointRoundTripper{base: base, next: next},
 	}
 }
 
+// apiKeyRoundTripper authenticates Gemini API requests with an API key, for clients given their own
+// http.Client (which the SDKs do not add the key to).
+type apiKeyRoundTripper struct {
+	key  string
+	next http.RoundTripper
+}
+
+func (a *apiKeyRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req2 := req.Clone(req.Context())
+	req2.Header.Set("x-goog-api-key", a.key)
+	return a.next.RoundTrip(req2)
+}
+
 // endpointRoundTripper rewrites request URLs to a custom base (scheme, host, path prefix).
 type endpointRoundTripper struct {
 	base *url.URL
@@ -47,31 +69,21 @@ func (e *endpointRoundTripper) RoundTrip(req *http.Request) (*http.Response, err
 	req2 := req.Clone(req.Context())
 	req2.URL.Scheme = e.base.Scheme
 	req2.URL.Host = e.base.Host
-	req2.URL.Path = path.Join(e.base.Path, strings.TrimPrefix(req.URL.Path, "/"))
+	req2.Host = "" // the endpoint's, also in the request line sent to a proxy
+	// Rooted, so an endpoint without a path prefix (http://localhost:8080) still gets /v1beta/...
+	req2.URL.Path = path.Join("/", e.base.Path, req.URL.Path)
 	if req.URL.RawQuery != "" {
 		req2.URL.RawQuery = req.URL.RawQuery
 	}
 	return e.next.RoundTrip(req2)
 }
 
-// logGeminiResponse logs Gemini response text, truncating if over maxGeminiResponseLogBytes.
-func logGeminiResponse(caller, raw string) {
-	if len(raw) <= maxGeminiResponseLogBytes {
-		log.Info().Str("caller", caller).Str("gemini_response", raw).Msg("Gemini response")
-		return
-	}
-	log.Info().
-		Str("caller", caller).
-		Str("gemini_response", raw[:maxGeminiResponseLogBytes]+"... [truncated]").
-		Int("gemini_response_len", len(raw)).
-		Msg("Gemini response")
-}
-
 // Client wraps Gemini API client
 type Client struct {
 	apiKey               string
 	modelFlash           string
 	modelPro             string
+	modelExtract         string // vision extraction of typed documents; empty uses modelPro (see SetExtractionModel)
 	modelImage           string // image generation, e.g. gemini-3-pro-image-preview
 	modelTTS             string // TTS model, e.g. gemini-2.5-pro-preview-tts
 	ttsVoice             string // TTS voice name, e.g. Zephyr, Puck, Aoede
@@ -84,6 +96,19 @@ type Client struct {
 	genaiClient          *genai.Client                     // for image modality and segment schema
 	unifiedClient        *unifiedgenai.Client              // unified genai SDK for TTS
 	boundaryCache        *database.BoundaryCacheRepository // cache for segmentation boundaries
+	sharedCache          SharedCache                       // optional Redis cache in front of boundaryCache; also narration and image prompts
+	sharedCacheTTL       time.Duration
+	boundaryStats        cacheMetrics
+	narrationStats       cacheMetrics
+	imagePromptStats     cacheMetrics
+	experiments          []Experiment               // A/B experiments, see AssignVariants
+	inputTypes           *inputtype.Registry        // prompt guidance per input type; nil uses the built-in types
+	limiters             map[string]*callSemaphore  // process-wide concurrency limits by model family, see SetConcurrencyLimits
+	sharedLimiters       map[string]SharedLimiter   // cross-process concurrency limits by model family, see SetSharedConcurrencyLimits
+	throttles            map[string]*rateController // adaptive rate limits by model family, see SetAdaptiveRateLimit
+	slowCall             time.Duration              // calls taking longer are logged, see SetSlowCallThreshold
+	observeCall          CallObserver               // see SetCallObserver
+	contentLog           contentLogging             // user content and model output in logs, see SetContentLogging
 }
 
 // Segment represents a text segment
@@ -126,10 +151,13 @@ type Image struct {
 }
 
 // NewClient creates a new LLM client.
+// vertexAI: when set, Gemini is called on Vertex AI with Application Default Credentials and apiKey and apiEndpoint are ignored.
 // apiEndpoint: optional Gemini API base URL (e.g. http://host.docker.internal:31300/gemini); when set, all Gemini calls use this endpoint.
 // modelSegmentPrimary/modelSegmentFallback: models for segmentation (e.g. gemini-3.0-flash, gemini-2.5-flash-lite).
 // boundaryCache: optional repository for caching segmentation boundaries; if nil, caching is disabled.
-func NewClient(apiKey, modelFlash, modelPro, modelImage, modelTTS, ttsVoice, apiEndpoint, modelSegmentPrimary, modelSegmentFallback string, boundaryCache *database.BoundaryCacheRepository) *Client {
+// proxySetting: outbound proxy of Gemini calls (see package proxy); empty leaves the SDKs' defaults, which use the environment's.
+// On Vertex AI the text models are called over gRPC, which only follows HTTPS_PROXY from the environment.
+func NewClient(apiKey string, vertexAI *VertexAI, modelFlash, modelPro, modelImage, modelTTS, ttsVoice, apiEndpoint, modelSegmentPrimary, modelSegmentFallback string, boundaryCache *database.BoundaryCacheRepository, proxySetting string) *Client {
 	if modelImage == "" {
 		modelImage = "gemini-3-pro-image-preview"
 	}
@@ -145,61 +173,79 @@ func NewClient(apiKey, modelFlash, modelPro, modelImage, modelTTS, ttsVoice, api
 	if modelSegmentFallback == "" {
 		modelSegmentFallback = "gemini-2.5-flash-lite"
 	}
+	ctx := context.Background()
+
+	// Base transport of all Gemini HTTP calls; nil keeps each SDK's default
+	var transport http.RoundTripper
+	if proxySetting != "" {
+		transport = proxy.Transport(proxySetting)
+		// Vertex AI's OAuth client (vertexHTTPClient) is built on the context's client
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: transport})
+	}
 
-	// Optional custom HTTP client for langchaingo when using a custom endpoint
+	// Optional custom HTTP client for langchaingo when using a custom endpoint or proxy
 	var langchaingoHTTPClient *http.Client
-	if apiEndpoint != "" {
-		langchaingoHTTPClient = httpClientForEndpoint(apiEndpoint)
+	if apiEndpoint != "" && vertexAI == nil {
+		langchaingoHTTPClient = httpClientForEndpoint(apiEndpoint, transport)
+	} else if transport != nil && vertexAI == nil {
+		langchaingoHTTPClient = &http.Client{Transport: &apiKeyRoundTripper{key: apiKey, next: transport}}
+	}
+	newLangModel := func(model string) (llms.Model, error) {
+		if vertexAI != nil {
+			return vertex.New(ctx, googleai.WithCloudProject(vertexAI.Project), googleai.WithCloudLocation(vertexAI.location()), googleai.WithDefaultModel(model))
+		}
+		opts := []googleai.Option{googleai.WithAPIKey(apiKey), googleai.WithDefaultModel(model)}
+		if langchaingoHTTPClient != nil {
+			opts = append(opts, googleai.WithHTTPClient(langchaingoHTTPClient))
+		}
+		return googleai.New(ctx, opts...)
 	}
 
 	// Initialize Google AI LLM for flash model
-	flashOpts := []googleai.Option{googleai.WithAPIKey(apiKey), googleai.WithDefaultModel(modelFlash)}
-	if langchaingoHTTPClient != nil {
-		flashOpts = append(flashOpts, googleai.WithHTTPClient(langchaingoHTTPClient))
-	}
-	llmFlash, err := googleai.New(context.Background(), flashOpts...)
+	llmFlash, err := newLangModel(modelFlash)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to initialize flash model, using fallback")
 	}
 
 	// Initialize Google AI LLM for pro model
-	proOpts := []googleai.Option{googleai.WithAPIKey(apiKey), googleai.WithDefaultModel(modelPro)}
-	if langchaingoHTTPClient != nil {
-		proOpts = append(proOpts, googleai.WithHTTPClient(langchaingoHTTPClient))
-	}
-	llmPro, err := googleai.New(context.Background(), proOpts...)
+	llmPro, err := newLangModel(modelPro)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to initialize pro model, using fallback")
 	}
 
 	// Segment primary (e.g. 3.0 flash)
-	segPrimaryOpts := []googleai.Option{googleai.WithAPIKey(apiKey), googleai.WithDefaultModel(modelSegmentPrimary)}
-	if langchaingoHTTPClient != nil {
-		segPrimaryOpts = append(segPrimaryOpts, googleai.WithHTTPClient(langchaingoHTTPClient))
-	}
-	llmSegmentPrimary, err := googleai.New(context.Background(), segPrimaryOpts...)
+	llmSegmentPrimary, err := newLangModel(modelSegmentPrimary)
 	if err != nil {
 		log.Error().Err(err).Str("model", modelSegmentPrimary).Msg("Failed to initialize segment primary model")
 	}
 
 	// Segment fallback (e.g. 2.5 flash)
-	segFallbackOpts := []googleai.Option{googleai.WithAPIKey(apiKey), googleai.WithDefaultModel(modelSegmentFallback)}
-	if langchaingoHTTPClient != nil {
-		segFallbackOpts = append(segFallbackOpts, googleai.WithHTTPClient(langchaingoHTTPClient))
-	}
-	llmSegmentFallback, err := googleai.New(context.Background(), segFallbackOpts...)
+	llmSegmentFallback, err := newLangModel(modelSegmentFallback)
 	if err != nil {
 		log.Error().Err(err).Str("model", modelSegmentFallback).Msg("Failed to initialize segment fallback model")
 	}
 
-	// genai client for strict modality (IMAGE); requires API key
+	// genai client for strict modality (IMAGE); requires API key, or Vertex AI through a rewriting HTTP client
 	var genaiClient *genai.Client
-	if apiKey != "" {
+	switch {
+	case vertexAI != nil:
+		httpClient, err := vertexHTTPClient(ctx, *vertexAI)
+		if err == nil {
+			genaiClient, err = genai.NewClient(ctx, option.WithHTTPClient(httpClient))
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to initialize genai client for Vertex AI")
+		}
+	case apiKey != "":
 		genaiOpts := []option.ClientOption{option.WithAPIKey(apiKey)}
 		if apiEndpoint != "" {
 			genaiOpts = append(genaiOpts, option.WithEndpoint(apiEndpoint))
 		}
-		genaiClient, err = genai.NewClient(context.Background(), genaiOpts...)
+		if transport != nil {
+			// The API key option is ignored with a custom client, which sends the key itself
+			genaiOpts = append(genaiOpts, option.WithHTTPClient(&http.Client{Transport: &apiKeyRoundTripper{key: apiKey, next: transport}}))
+		}
+		genaiClient, err = genai.NewClient(ctx, genaiOpts...)
 		if err != nil {
 			log.Error().Err(err).Msg("Failed to initialize genai client for image generation")
 		}
@@ -207,17 +253,42 @@ func NewClient(apiKey, modelFlash, modelPro, modelImage, modelTTS, ttsVoice, api
 
 	// Unified genai client for TTS with response_modalities: audio
 	var unifiedClient *unifiedgenai.Client
-	if apiKey != "" {
+	switch {
+	case vertexAI != nil:
+		unifiedCfg := &unifiedgenai.ClientConfig{
+			Backend:  unifiedgenai.BackendVertexAI,
+			Project:  vertexAI.Project,
+			Location: vertexAI.location(),
+		}
+		err = nil
+		if transport != nil {
+			unifiedCfg.HTTPClient = &http.Client{Transport: transport}
+			err = unifiedCfg.UseDefaultCredentials()
+		}
+		if err == nil {
+			unifiedClient, err = unifiedgenai.NewClient(ctx, unifiedCfg)
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to initialize unified genai client for Vertex AI")
+		}
+	case apiKey != "":
 		unifiedCfg := &unifiedgenai.ClientConfig{APIKey: apiKey}
 		if apiEndpoint != "" {
 			unifiedCfg.HTTPOptions = unifiedgenai.HTTPOptions{BaseURL: apiEndpoint}
 		}
-		unifiedClient, err = unifiedgenai.NewClient(context.Background(), unifiedCfg)
+		if transport != nil {
+			unifiedCfg.HTTPClient = &http.Client{Transport: transport}
+		}
+		unifiedClient, err = unifiedgenai.NewClient(ctx, unifiedCfg)
 		if err != nil {
 			log.Error().Err(err).Msg("Failed to initialize unified genai client for TTS")
 		}
 	}
 
+	backend := "gemini_api"
+	if vertexAI != nil {
+		backend = "vertex_ai"
+	}
 	log.Info().
 		Str("model_flash", modelFlash).
 		Str("model_pro", modelPro).
@@ -226,7 +297,9 @@ func NewClient(apiKey, modelFlash, modelPro, modelImage, modelTTS, ttsVoice, api
 		Str("model_image", modelImage).
 		Str("model_tts", modelTTS).
 		Str("tts_voice", ttsVoice).
+		Str("backend", backend).
 		Str("api_endpoint", apiEndpoint).
+		Str("proxy", proxy.Redact(proxySetting)).
 		Bool("genai_client", genaiClient != nil).
 		Bool("unified_tts", unifiedClient != nil).
 		Msg("LLM client initialized")
@@ -249,3 +322,9 @@ func NewClient(apiKey, modelFlash, modelPro, modelImage, modelTTS, ttsVoice, api
 		boundaryCache:        boundaryCache,
 	}
 }
+
+// SetInputTypes sets the profiles whose guidance the prompts of each input type use (see
+// inputtype.Parse); without it the built-in types are used.
+func (c *Client) SetInputTypes(r *inputtype.Registry) {
+	c.inputTypes = r
+}
@@ -0,0 +1,208 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// Steps an experiment can target.
+const (
+	ExperimentStepSegmentation = "segmentation"
+	ExperimentStepNarration    = "narration"
+	ExperimentStepImagePrompt  = "image_prompt"
+)
+
+// Experiment variants. Jobs outside an experiment's percentage run the configured models and prompts (control).
+const (
+	VariantControl   = "control"
+	VariantTreatment = "treatment"
+)
+
+// Experiment routes Percent of jobs to an alternative model and/or prompt variant for one LLM step.
+// The treatment model is tried first, before the step's configured models; PromptSuffix is appended
+// to the step's system prompt. Treatment calls bypass the boundary and shared caches.
+type Experiment struct {
+	Name         string `json:"name"`
+	Step         string `json:"step"`    // segmentation, narration or image_prompt
+	Percent      int    `json:"percent"` // share of jobs in the treatment, 0-100
+	Model        string `json:"model,omitempty"`
+	PromptSuffix string `json:"prompt_suffix,omitempty"`
+}
+
+// ParseExperiments parses the LLM_EXPERIMENTS setting, a JSON array of experiments. Empty means none.
+func ParseExperiments(spec string) ([]Experiment, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+	var experiments []Experiment
+	if err := json.Unmarshal([]byte(spec), &experiments); err != nil {
+		return nil, fmt.Errorf("invalid LLM_EXPERIMENTS: %w", err)
+	}
+	seen := make(map[string]bool)
+	for _, e := range experiments {
+		switch {
+		case e.Name == "":
+			return nil, fmt.Errorf("invalid LLM_EXPERIMENTS: experiment without name")
+		case seen[e.Name]:
+			return nil, fmt.Errorf("invalid LLM_EXPERIMENTS: duplicate experiment %q", e.Name)
+		case e.Step != ExperimentStepSegmentation && e.Step != ExperimentStepNarration && e.Step != ExperimentStepImagePrompt:
+			return nil, fmt.Errorf("invalid LLM_EXPERIMENTS: experiment %q has unknown step %q", e.Name, e.Step)
+		case e.Percent < 0 || e.Percent > 100:
+			return nil, fmt.Errorf("invalid LLM_EXPERIMENTS: experiment %q percent must be between 0 and 100", e.Name)
+		case e.Model == "" && e.PromptSuffix == "":
+			return nil, fmt.Errorf("invalid LLM_EXPERIMENTS: experiment %q needs a model or prompt_suffix", e.Name)
+		}
+		seen[e.Name] = true
+	}
+	return experiments, nil
+}
+
+// SetExperiments configures the experiments jobs are assigned to (see AssignVariants).
+func (c *Client) SetExperiments(experiments []Experiment) {
+	c.experiments = experiments
+	for _, e := range experiments {
+		log.Info().
+			Str("experiment", e.Name).
+			Str("step", e.Step).
+			Int("percent", e.Percent).
+			Str("model", e.Model).
+			Bool("prompt_variant", e.PromptSuffix != "").
+			Msg("LLM experiment enabled")
+	}
+}
+
+// AssignVariants returns the variant of every configured experiment for a job, keyed by experiment name.
+// Assignment hashes the experiment name with the job ID, so it is stable across retries and
+// reprocessing and independent between experiments. Returns nil when no experiments are configured.
+func (c *Client) AssignVariants(jobID uuid.UUID) map[string]string {
+	if len(c.experiments) == 0 {
+		return nil
+	}
+	variants := make(map[string]string, len(c.experiments))
+	for _, e := range c.experiments {
+		sum := sha256.Sum256([]byte(e.Name + ":" + jobID.String()))
+		variant := VariantControl
+		if int(binary.BigEndian.Uint64(sum[:8])%100) < e.Percent {
+			variant = VariantTreatment
+		}
+		variants[e.Name] = variant
+	}
+	return variants
+}
+
+type variantsKey struct{}
+
+type jobVariants struct {
+	jobID    uuid.UUID
+	variants map[string]string
+}
+
+// WithVariants returns a context that makes LLM calls for jobID follow its experiment variants
+// (from AssignVariants) and report per-variant metrics.
+func WithVariants(ctx context.Context, jobID uuid.UUID, variants map[string]string) context.Context {
+	if len(variants) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, variantsKey{}, jobVariants{jobID: jobID, variants: variants})
+}
+
+// treatment returns the experiment whose treatment applies to step for the job in ctx, or nil.
+func (c *Client) treatment(ctx context.Context, step string) *Experiment {
+	jv, _ := ctx.Value(variantsKey{}).(jobVariants)
+	for i, e := range c.experiments {
+		if e.Step == step && jv.variants[e.Name] == VariantTreatment {
+			return &c.experiments[i]
+		}
+	}
+	return nil
+}
+
+// experimentCall measures one LLM step for the experiments covering it. A nil *experimentCall
+// (no experiment covers the step, or the job has no variants) records nothing.
+type experimentCall struct {
+	jobID      uuid.UUID
+	step       string
+	variants   map[string]string // experiment name -> variant, only experiments on step
+	started    time.Time
+	inputChars int
+}
+
+func (c *Client) startExperimentCall(ctx context.Context, step string, inputChars int) *experimentCall {
+	jv, ok := ctx.Value(variantsKey{}).(jobVariants)
+	if !ok {
+		return nil
+	}
+	var call *experimentCall
+	for _, e := range c.experiments {
+		variant, ok := jv.variants[e.Name]
+		if e.Step != step || !ok {
+			continue
+		}
+		if call == nil {
+			call = &experimentCall{jobID: jv.jobID, step: step, variants: map[string]string{}, started: time.Now(), inputChars: inputChars}
+		}
+		call.variants[e.Name] = variant
+	}
+	return call
+}
+
+// done logs the call's metrics once per experiment. model is the model that produced the output
+// ("cache" or "rule_based" when none did); outcome is ok, cache, fallback or error; outputSize is
+// characters for text steps and segments for segmentation.
+func (e *experimentCall) done(model, outcome string, outputSize int) {
+	if e == nil {
+		return
+	}
+	latency := time.Since(e.started).Milliseconds()
+	for name, variant := range e.variants {
+		log.Info().
+			Str("job_id", e.jobID.String()).
+			Str("experiment", name).
+			Str("variant", variant).
+			Str("step", e.step).
+			Str("model", model).
+			Str("outcome", outcome).
+			Int64("latency_ms", latency).
+			Int("input_chars", e.inputChars).
+			Int("output_size", outputSize).
+			Msg("llm_experiment_call")
+	}
+}
+
+// generateWithModel runs a single text generation with an arbitrary Gemini model (used for experiment
+// models, which have no preconfigured langchaingo client).
+func (c *Client) generateWithModel(ctx context.Context, modelName, systemPrompt, userText string, temperature float32, maxTokens int32) (string, error) {
+	if c.genaiClient == nil {
+		return "", fmt.Errorf("genai client not available for model %s", modelName)
+	}
+	model := c.genaiClient.GenerativeModel(modelName)
+	model.SetTemperature(temperature)
+	model.SetMaxOutputTokens(maxTokens)
+	model.SystemInstruction = &genai.Content{
+		Parts: []genai.Part{genai.Text(systemPrompt)},
+		Role:  "system",
+	}
+	resp, err := c.generateGenai(ctx, model, modelName, callWeightText, genai.Text(userText))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(c.extractTextFromGenaiResponse(resp)), nil
+}
+
+// withPromptSuffix appends an experiment's prompt variant to a system prompt.
+func withPromptSuffix(systemPrompt string, exp *Experiment) string {
+	if exp == nil || exp.PromptSuffix == "" {
+		return systemPrompt
+	}
+	return systemPrompt + "\n\n" + exp.PromptSuffix
+}
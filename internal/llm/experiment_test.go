@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParseExperiments(t *testing.T) {
+	if got, err := ParseExperiments(""); err != nil || got != nil {
+		t.Fatalf("empty spec = %v, %v", got, err)
+	}
+	got, err := ParseExperiments(`[{"name":"seg-lite","step":"segmentation","percent":20,"model":"gemini-2.5-flash-lite"}]`)
+	if err != nil || len(got) != 1 || got[0].Percent != 20 {
+		t.Fatalf("ParseExperiments = %+v, %v", got, err)
+	}
+
+	for spec, want := range map[string]string{
+		`{"name":"x"}`: "invalid LLM_EXPERIMENTS",
+		`[{"step":"narration","percent":5,"model":"m"}]`:                                                        "without name",
+		`[{"name":"a","step":"narration","percent":5,"model":"m"},{"name":"a","step":"narration","model":"m"}]`: "duplicate",
+		`[{"name":"a","step":"audio","percent":5,"model":"m"}]`:                                                 "unknown step",
+		`[{"name":"a","step":"narration","percent":101,"model":"m"}]`:                                           "between 0 and 100",
+		`[{"name":"a","step":"narration","percent":5}]`:                                                         "model or prompt_suffix",
+	} {
+		if _, err := ParseExperiments(spec); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseExperiments(%s) error = %v, want %q", spec, err, want)
+		}
+	}
+}
+
+func TestAssignVariants(t *testing.T) {
+	c := &Client{}
+	if v := c.AssignVariants(uuid.New()); v != nil {
+		t.Fatalf("no experiments assigned %v", v)
+	}
+	c.SetExperiments([]Experiment{
+		{Name: "half", Step: ExperimentStepNarration, Percent: 50, PromptSuffix: "Be brief."},
+		{Name: "none", Step: ExperimentStepImagePrompt, Percent: 0, Model: "m"},
+		{Name: "all", Step: ExperimentStepSegmentation, Percent: 100, Model: "m"},
+	})
+
+	treated := 0
+	for i := 0; i < 1000; i++ {
+		jobID := uuid.New()
+		v := c.AssignVariants(jobID)
+		if v["none"] != VariantControl || v["all"] != VariantTreatment {
+			t.Fatalf("variants %v ignore 0%%/100%% splits", v)
+		}
+		if v["half"] != c.AssignVariants(jobID)["half"] {
+			t.Fatal("assignment is not stable for a job")
+		}
+		if v["half"] == VariantTreatment {
+			treated++
+		}
+	}
+	if treated < 400 || treated > 600 {
+		t.Errorf("50%% experiment treated %d of 1000 jobs", treated)
+	}
+}
+
+func TestExperimentTreatmentBypassesCache(t *testing.T) {
+	shared := memoryCache{}
+	c := &Client{}
+	c.SetSharedCache(shared, time.Hour)
+	c.SetExperiments([]Experiment{{Name: "short", Step: ExperimentStepNarration, Percent: 100, PromptSuffix: "Be brief."}})
+	shared[narrationCacheKey("Some text.", "free_speech", "educational")] = []byte("Cached script.")
+
+	jobID := uuid.New()
+	control := WithVariants(context.Background(), jobID, map[string]string{"short": VariantControl})
+	if n, _ := c.GenerateNarration(control, "Some text.", "free_speech", "educational"); n != "Cached script." {
+		t.Errorf("control narration = %q, want cached script", n)
+	}
+	if c.treatment(control, ExperimentStepNarration) != nil {
+		t.Error("control job got a treatment")
+	}
+
+	// Treatment jobs must not see control output from the cache (no models here, so the script is empty).
+	treatment := WithVariants(context.Background(), jobID, c.AssignVariants(jobID))
+	if exp := c.treatment(treatment, ExperimentStepNarration); exp == nil || exp.Name != "short" {
+		t.Fatalf("treatment = %+v, want experiment short", exp)
+	}
+	if n, _ := c.GenerateNarration(treatment, "Some text.", "free_speech", "educational"); n != "" {
+		t.Errorf("treatment narration = %q, want a cache bypass", n)
+	}
+}
+
+func TestWithPromptSuffix(t *testing.T) {
+	if got := withPromptSuffix("Prompt.", nil); got != "Prompt." {
+		t.Errorf("no experiment changed the prompt: %q", got)
+	}
+	if got := withPromptSuffix("Prompt.", &Experiment{PromptSuffix: "Use short sentences."}); got != "Prompt.\n\nUse short sentences." {
+		t.Errorf("withPromptSuffix = %q", got)
+	}
+}
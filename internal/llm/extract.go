@@ -2,26 +2,96 @@ package llm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/google/generative-ai-go/genai"
 )
 
+// ExtractOptions adjusts the extraction prompt and model.
+type ExtractOptions struct {
+	// StrictParaphrase asks for a looser, higher-level summary; used after Gemini blocked a response for recitation
+	StrictParaphrase bool
+	// Language is a BCP 47 hint for the language of the content (empty: detect)
+	Language string
+	// Handwriting tells the model the content is handwritten; it is always read with the Pro model
+	Handwriting bool
+	// PreserveTables keeps tables as Markdown tables instead of describing them; always read with the Pro model
+	PreserveTables bool
+}
+
+// SetExtractionModel sets the model for vision extraction of files without handwriting or tables to
+// preserve (e.g. a cheaper Flash model for typed documents). Empty keeps the Pro model for everything.
+func (c *Client) SetExtractionModel(model string) {
+	c.modelExtract = model
+}
+
+// extractionModel returns the model extraction with opts uses: the Pro model for handwriting and table
+// preservation, which need its stronger layout and script recognition, else the extraction model if set.
+func (c *Client) extractionModel(opts ExtractOptions) string {
+	if c.modelExtract == "" || opts.Handwriting || opts.PreserveTables {
+		return c.modelPro
+	}
+	return c.modelExtract
+}
+
+// IsRecitationError reports whether err is Gemini blocking a response because it reproduced source material
+// (FinishReasonRecitation). Retrying with a stricter paraphrase prompt or smaller chunks often succeeds.
+func IsRecitationError(err error) bool {
+	var blocked *genai.BlockedError
+	return errors.As(err, &blocked) && blocked.Candidate != nil && blocked.Candidate.FinishReason == genai.FinishReasonRecitation
+}
+
 // ExtractContent uses Gemini 3 Pro vision to extract text from images/PDFs.
 // System prompt holds instructions; user message is the document/image, sent as-is.
 func (c *Client) ExtractContent(ctx context.Context, data []byte, mimeType, inputType string) (string, error) {
+	return c.ExtractContentWithOptions(ctx, data, mimeType, inputType, ExtractOptions{})
+}
+
+// ExtractContentWithOptions is ExtractContent with prompt options.
+func (c *Client) ExtractContentWithOptions(ctx context.Context, data []byte, mimeType, inputType string, opts ExtractOptions) (string, error) {
 	if c.genaiClient == nil {
 		return "", fmt.Errorf("genai client not initialized")
 	}
 
-	model := c.genaiClient.GenerativeModel(c.modelPro)
+	return c.generateExtraction(ctx, c.extractionModel(opts), c.buildExtractionSystemPrompt(inputType, mimeType, opts), data, mimeType)
+}
+
+// ExtractPDFPages extracts pages first..last (1-based, inclusive) of a PDF. The whole blob is sent, but the
+// model only covers the requested pages and starts each one with a [[PAGE n]] marker, so page structure
+// survives and each call's output stays bounded on large documents. Pages the model returns nothing for
+// are omitted from the result.
+func (c *Client) ExtractPDFPages(ctx context.Context, data []byte, inputType string, first, last int, opts ExtractOptions) ([]ExtractedPage, error) {
+	if c.genaiClient == nil {
+		return nil, fmt.Errorf("genai client not initialized")
+	}
+	if first < 1 || last < first {
+		return nil, fmt.Errorf("invalid page range %d-%d", first, last)
+	}
+
+	prompt := c.buildExtractionSystemPrompt(inputType, PDFMimeType, opts) + fmt.Sprintf(
+		" Only cover pages %d to %d of the document and ignore all other pages. Summarize each page on its own:"+
+			" start it with a line containing only [[PAGE n]], where n is the page number, followed by that page's summary."+
+			" Write a marker for every page in the range, even if the page has little content.",
+		first, last)
+
+	output, err := c.generateExtraction(ctx, c.extractionModel(opts), prompt, data, PDFMimeType)
+	if err != nil {
+		return nil, err
+	}
+	return splitPageMarkers(output, first, last), nil
+}
+
+// generateExtraction sends data to modelName with the given system prompt and returns the text parts of the response.
+func (c *Client) generateExtraction(ctx context.Context, modelName, systemPrompt string, data []byte, mimeType string) (string, error) {
+	model := c.genaiClient.GenerativeModel(modelName)
 	model.SystemInstruction = &genai.Content{
-		Parts: []genai.Part{genai.Text(c.buildExtractionSystemPrompt(inputType, mimeType))},
+		Parts: []genai.Part{genai.Text(systemPrompt)},
 		Role:  "system",
 	}
 
-	resp, err := model.GenerateContent(ctx, genai.Blob{MIMEType: mimeType, Data: data})
+	resp, err := c.generateGenai(ctx, model, modelName, callWeightDocument, genai.Blob{MIMEType: mimeType, Data: data})
 	if err != nil {
 		return "", fmt.Errorf("gemini vision failed: %w", err)
 	}
@@ -43,22 +113,25 @@ func (c *Client) ExtractContent(ctx context.Context, data []byte, mimeType, inpu
 
 // buildExtractionSystemPrompt returns the system prompt for extraction (instructions only).
 // The document or image to summarize is sent by the user as a separate message, as-is.
-func (c *Client) buildExtractionSystemPrompt(inputType, mimeType string) string {
+func (c *Client) buildExtractionSystemPrompt(inputType, mimeType string, opts ExtractOptions) string {
 	fileType := "document"
 	if strings.HasPrefix(mimeType, "image/") {
 		fileType = "image"
 	}
 
 	base := fmt.Sprintf("Summarize the %s provided by the user in your own words. Describe the main content, ideas, and structure. Do not quote or transcribe long passages verbatim; paraphrase and condense so the summary is useful for creating an enriched story version.", fileType)
-
-	switch inputType {
-	case "educational":
-		return base + " Focus on the main concepts, facts, and how they are organized. Keep the logical flow clear."
-	case "financial":
-		return base + " Summarize the main points, figures, and conclusions. Note the presence of any disclaimers or risk warnings without quoting them in full."
-	case "fictional":
-		return base + " Summarize the plot, key characters, and story beats in your own words. Capture the tone and main events without copying dialogue or text verbatim."
-	default:
-		return base + " Keep the overall structure and meaning clear in your summary."
+	if opts.StrictParaphrase {
+		base += " Write only a high-level description: never copy more than five consecutive words from the " + fileType + ", do not quote, list or transcribe any passage, and restate names of sections instead of reproducing headings."
+	}
+	if opts.Language != "" {
+		base += fmt.Sprintf(" The %s is written in the language with BCP 47 tag %q; use it to read the text and its script correctly.", fileType, opts.Language)
 	}
+	if opts.Handwriting {
+		base += " The content is handwritten: read it carefully, use the surrounding context to resolve unclear letters, and write [illegible] for words you cannot read instead of guessing."
+	}
+	if opts.PreserveTables {
+		base += " Keep tables as Markdown tables with their headers, rows and figures intact instead of describing them in prose; summarize only the text around them."
+	}
+
+	return base + " " + c.inputTypes.Get(inputType).ExtractionFocus
 }
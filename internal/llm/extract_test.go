@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+func TestIsRecitationError(t *testing.T) {
+	recitation := &genai.BlockedError{Candidate: &genai.Candidate{FinishReason: genai.FinishReasonRecitation}}
+	safety := &genai.BlockedError{Candidate: &genai.Candidate{FinishReason: genai.FinishReasonSafety}}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"recitation", recitation, true},
+		{"wrapped recitation", fmt.Errorf("gemini vision failed: %w", recitation), true},
+		{"safety", safety, false},
+		{"prompt blocked", &genai.BlockedError{PromptFeedback: &genai.PromptFeedback{BlockReason: genai.BlockReasonOther}}, false},
+		{"other", errors.New("timeout"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRecitationError(tt.err); got != tt.want {
+				t.Errorf("IsRecitationError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildExtractionSystemPrompt_StrictParaphrase(t *testing.T) {
+	c := &Client{}
+	normal := c.buildExtractionSystemPrompt("educational", PDFMimeType, ExtractOptions{})
+	strict := c.buildExtractionSystemPrompt("educational", PDFMimeType, ExtractOptions{StrictParaphrase: true})
+	if strings.Contains(normal, "five consecutive words") {
+		t.Errorf("default prompt should not carry the strict instruction: %q", normal)
+	}
+	if !strings.Contains(strict, "five consecutive words") || !strings.HasSuffix(strict, "Keep the logical flow clear.") {
+		t.Errorf("strict prompt should add the paraphrase rule and keep the type instructions: %q", strict)
+	}
+}
+
+func TestBuildExtractionSystemPrompt_FileOptions(t *testing.T) {
+	c := &Client{}
+	prompt := c.buildExtractionSystemPrompt("financial", "image/png", ExtractOptions{Language: "pt-BR", Handwriting: true, PreserveTables: true})
+	for _, want := range []string{`BCP 47 tag "pt-BR"`, "handwritten", "[illegible]", "Markdown tables"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q: %q", want, prompt)
+		}
+	}
+	if plain := c.buildExtractionSystemPrompt("financial", "image/png", ExtractOptions{}); strings.Contains(plain, "handwritten") || strings.Contains(plain, "Markdown tables") {
+		t.Errorf("default prompt should not carry file option instructions: %q", plain)
+	}
+}
+
+func TestExtractionModel(t *testing.T) {
+	c := &Client{modelPro: "pro"}
+	if got := c.extractionModel(ExtractOptions{}); got != "pro" {
+		t.Errorf("without extraction model = %q, want pro", got)
+	}
+	c.SetExtractionModel("flash")
+	tests := []struct {
+		name string
+		opts ExtractOptions
+		want string
+	}{
+		{"typed", ExtractOptions{}, "flash"},
+		{"language hint", ExtractOptions{Language: "de"}, "flash"},
+		{"handwriting", ExtractOptions{Handwriting: true}, "pro"},
+		{"tables", ExtractOptions{PreserveTables: true}, "pro"},
+	}
+	for _, tt := range tests {
+		if got := c.extractionModel(tt.opts); got != tt.want {
+			t.Errorf("%s: extractionModel() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
 	"unicode/utf8"
 
@@ -15,6 +17,10 @@ import (
 // non-fatal error and avoid persisting a misleading empty result.
 var ErrFactCheckNotConfigured = errors.New("fact-check unavailable: unified AI client not configured")
 
+// ErrFactCheckUnparseable is returned by FactCheckClaims when the model's answer holds no valid claims JSON.
+// Search grounding cannot be combined with a response schema, so callers should expect it.
+var ErrFactCheckUnparseable = errors.New("fact check response is not valid JSON")
+
 const maxFactCheckLen = 1024 // made larger than requested in case LLM returns more than requested
 
 const factCheckSystemPrompt = `You are a fact-checker. Analyze the text and check all factual claims against well-known and trusted sources using web search.
@@ -45,10 +51,17 @@ func (c *Client) FactCheckSegment(ctx context.Context, text string) (string, err
 	}
 
 	log.Debug().Str("model", c.modelFlash).Int("text_len", len(text)).Msg("Fact-checking segment with Google Search grounding")
-	result, err := c.unifiedClient.Models.GenerateContent(ctx, c.modelFlash, contents, config)
+	var result *unifiedgenai.GenerateContentResponse
+	err := c.call(ctx, c.modelFlash, callWeightText, func() (err error) {
+		result, err = c.unifiedClient.Models.GenerateContent(ctx, c.modelFlash, contents, config)
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
+	if result.UsageMetadata != nil {
+		recordUsage(ctx, c.modelFlash, result.UsageMetadata.PromptTokenCount, result.UsageMetadata.CandidatesTokenCount)
+	}
 
 	out := strings.TrimSpace(result.Text())
 	// Treat empty, "0", or responses that only confirm no issues (e.g. end with "0" or say no inaccuracies) as no issue
@@ -63,3 +76,157 @@ func (c *Client) FactCheckSegment(ctx context.Context, text string) (string, err
 	}
 	return out, nil
 }
+
+// Fact-check verdicts of a claim.
+const (
+	VerdictSupported    = "supported"
+	VerdictRefuted      = "refuted"
+	VerdictMisleading   = "misleading"
+	VerdictUnverifiable = "unverifiable"
+)
+
+const factCheckClaimsSystemPrompt = `You are a fact-checker. List the factual claims of the text and check each against well-known and trusted sources using web search.
+
+Respond with JSON only, no other text:
+{"claims": [{"claim": "the claim as stated", "verdict": "supported", "confidence": 0.9, "explanation": "", "sources": [{"title": "...", "url": "..."}]}]}
+
+verdict is one of supported, refuted, misleading or unverifiable; confidence (0 to 1) is how sure you are of the verdict; explanation briefly describes the issue of refuted and misleading claims (max 256 characters) and is empty otherwise; sources are the pages backing the verdict.
+If the text makes no factual claims respond with {"claims": []}.
+
+A text to check will be provided by the user.`
+
+// FactCheck is the structured result of FactCheckClaims.
+type FactCheck struct {
+	Text    string            // the issues found, as FactCheckSegment returns them; empty if none
+	Claims  []FactCheckClaim  // the text's factual claims
+	Sources []FactCheckSource // web sources the check searched (grounding metadata)
+}
+
+// FactCheckClaim is a checked factual claim.
+type FactCheckClaim struct {
+	Claim       string            `json:"claim"`
+	Verdict     string            `json:"verdict"`    // Verdict*
+	Confidence  float64           `json:"confidence"` // 0-1
+	Explanation string            `json:"explanation"`
+	Sources     []FactCheckSource `json:"sources"`
+}
+
+// FactCheckSource is a web page consulted by a fact-check.
+type FactCheckSource struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// FactCheckClaims checks the given text like FactCheckSegment, returning each factual claim with a verdict,
+// confidence and sources. Text is the issues of refuted and misleading claims (empty if none). Answers
+// that cannot be parsed fail with ErrFactCheckUnparseable.
+func (c *Client) FactCheckClaims(ctx context.Context, text string) (*FactCheck, error) {
+	if strings.TrimSpace(text) == "" {
+		return &FactCheck{}, nil
+	}
+	if c.unifiedClient == nil {
+		log.Warn().Msg("FactCheckClaims: unified client not configured")
+		return nil, ErrFactCheckNotConfigured
+	}
+
+	// Search grounding does not combine with a JSON response schema; the prompt asks for JSON instead
+	contents := unifiedgenai.Text(text)
+	config := &unifiedgenai.GenerateContentConfig{
+		SystemInstruction: unifiedgenai.NewContentFromText(factCheckClaimsSystemPrompt, unifiedgenai.Role("system")),
+		Tools: []*unifiedgenai.Tool{
+			{GoogleSearch: &unifiedgenai.GoogleSearch{}},
+		},
+	}
+
+	log.Debug().Str("model", c.modelFlash).Int("text_len", len(text)).Msg("Fact-checking claims with Google Search grounding")
+	var result *unifiedgenai.GenerateContentResponse
+	err := c.call(ctx, c.modelFlash, callWeightText, func() (err error) {
+		result, err = c.unifiedClient.Models.GenerateContent(ctx, c.modelFlash, contents, config)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.UsageMetadata != nil {
+		recordUsage(ctx, c.modelFlash, result.UsageMetadata.PromptTokenCount, result.UsageMetadata.CandidatesTokenCount)
+	}
+
+	fc, err := parseFactCheck(result.Text())
+	if err != nil {
+		return nil, err
+	}
+	fc.Sources = groundingSources(result)
+	return fc, nil
+}
+
+// parseFactCheck parses the claims of a FactCheckClaims response, normalizing verdicts and confidences,
+// and sets Text from the refuted and misleading ones.
+func parseFactCheck(response string) (*FactCheck, error) {
+	response = strings.TrimSpace(response)
+	// Without a response schema the model may wrap the JSON in prose or a code fence
+	start, end := strings.Index(response, "{"), strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("parse fact check: %w: no JSON object in response", ErrFactCheckUnparseable)
+	}
+	var parsed struct {
+		Claims []FactCheckClaim `json:"claims"`
+	}
+	if err := json.Unmarshal([]byte(response[start:end+1]), &parsed); err != nil {
+		return nil, fmt.Errorf("parse fact check: %w: %w", ErrFactCheckUnparseable, err)
+	}
+	fc := &FactCheck{Claims: make([]FactCheckClaim, 0, len(parsed.Claims))}
+	var issues []string
+	for _, claim := range parsed.Claims {
+		claim.Claim = strings.TrimSpace(claim.Claim)
+		if claim.Claim == "" {
+			continue
+		}
+		claim.Verdict = strings.ToLower(strings.TrimSpace(claim.Verdict))
+		switch claim.Verdict {
+		case VerdictSupported, VerdictRefuted, VerdictMisleading, VerdictUnverifiable:
+		default:
+			claim.Verdict = VerdictUnverifiable
+		}
+		claim.Confidence = min(max(claim.Confidence, 0), 1)
+		claim.Explanation = strings.TrimSpace(claim.Explanation)
+		sources := claim.Sources[:0]
+		for _, s := range claim.Sources {
+			if s.URL = strings.TrimSpace(s.URL); s.URL != "" {
+				sources = append(sources, s)
+			}
+		}
+		claim.Sources = sources
+		if claim.Verdict == VerdictRefuted || claim.Verdict == VerdictMisleading {
+			issue := claim.Explanation
+			if issue == "" {
+				issue = claim.Verdict + ": " + claim.Claim
+			}
+			issues = append(issues, issue)
+		}
+		fc.Claims = append(fc.Claims, claim)
+	}
+	fc.Text = strings.Join(issues, " ")
+	if utf8.RuneCountInString(fc.Text) > maxFactCheckLen {
+		fc.Text = string([]rune(fc.Text)[:maxFactCheckLen])
+	}
+	return fc, nil
+}
+
+// groundingSources returns the web pages of the response's grounding metadata, once each.
+func groundingSources(result *unifiedgenai.GenerateContentResponse) []FactCheckSource {
+	var sources []FactCheckSource
+	seen := make(map[string]bool)
+	for _, cand := range result.Candidates {
+		if cand.GroundingMetadata == nil {
+			continue
+		}
+		for _, chunk := range cand.GroundingMetadata.GroundingChunks {
+			if chunk.Web == nil || chunk.Web.URI == "" || seen[chunk.Web.URI] {
+				continue
+			}
+			seen[chunk.Web.URI] = true
+			sources = append(sources, FactCheckSource{Title: chunk.Web.Title, URL: chunk.Web.URI})
+		}
+	}
+	return sources
+}
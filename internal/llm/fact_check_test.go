@@ -0,0 +1,66 @@
+package llm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	unifiedgenai "google.golang.org/genai"
+)
+
+func TestParseFactCheck(t *testing.T) {
+	response := "Here is the check:\n```json\n" + `{"claims": [
+		{"claim": "Water boils at 100 °C at sea level.", "verdict": "supported", "confidence": 0.95, "sources": [{"title": "Boiling", "url": "https://example.com/boiling"}, {"title": "no url"}]},
+		{"claim": "The Moon is made of cheese.", "verdict": "Refuted", "confidence": 1.4, "explanation": "The Moon is rock."},
+		{"claim": "Most people like tea.", "verdict": "probably"},
+		{"claim": "  "}
+	]}` + "\n```"
+	fc, err := parseFactCheck(response)
+	if err != nil {
+		t.Fatalf("parseFactCheck: %v", err)
+	}
+	if len(fc.Claims) != 3 {
+		t.Fatalf("got %d claims, want 3 (the empty one dropped)", len(fc.Claims))
+	}
+	if c := fc.Claims[0]; c.Verdict != VerdictSupported || len(c.Sources) != 1 || c.Sources[0].URL != "https://example.com/boiling" {
+		t.Errorf("first claim = %+v, want supported with one source", c)
+	}
+	if c := fc.Claims[1]; c.Verdict != VerdictRefuted || c.Confidence != 1 {
+		t.Errorf("second claim = %+v, want refuted with confidence clamped to 1", c)
+	}
+	if c := fc.Claims[2]; c.Verdict != VerdictUnverifiable {
+		t.Errorf("unknown verdict = %q, want unverifiable", c.Verdict)
+	}
+	if fc.Text != "The Moon is rock." {
+		t.Errorf("Text = %q, want the refuted claim's explanation", fc.Text)
+	}
+
+	if fc, err := parseFactCheck(`{"claims": []}`); err != nil || fc.Text != "" || len(fc.Claims) != 0 {
+		t.Errorf("no claims = %+v, %v; want an empty check", fc, err)
+	}
+	for _, bad := range []string{"", "0", `{"claims": "none"}`} {
+		if _, err := parseFactCheck(bad); !errors.Is(err, ErrFactCheckUnparseable) {
+			t.Errorf("parseFactCheck(%q): err = %v, want ErrFactCheckUnparseable", bad, err)
+		}
+	}
+
+	long := `{"claims": [{"claim": "x", "verdict": "misleading", "explanation": "` + strings.Repeat("a", maxFactCheckLen+10) + `"}]}`
+	if fc, err := parseFactCheck(long); err != nil || len(fc.Text) != maxFactCheckLen {
+		t.Errorf("long explanation: Text has %d characters, %v; want %d", len(fc.Text), err, maxFactCheckLen)
+	}
+}
+
+func TestGroundingSources(t *testing.T) {
+	web := func(title, uri string) *unifiedgenai.GroundingChunk {
+		return &unifiedgenai.GroundingChunk{Web: &unifiedgenai.GroundingChunkWeb{Title: title, URI: uri}}
+	}
+	result := &unifiedgenai.GenerateContentResponse{Candidates: []*unifiedgenai.Candidate{{
+		GroundingMetadata: &unifiedgenai.GroundingMetadata{GroundingChunks: []*unifiedgenai.GroundingChunk{
+			web("A", "https://a.example"), web("B", "https://b.example"), web("A again", "https://a.example"), {},
+		}},
+	}}}
+	got := groundingSources(result)
+	if len(got) != 2 || got[0].Title != "A" || got[1].URL != "https://b.example" {
+		t.Errorf("groundingSources = %+v, want A and B once each", got)
+	}
+}
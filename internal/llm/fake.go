@@ -0,0 +1,196 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// FakeGemini is a deterministic stand-in for the Gemini REST API, for tests that run the whole client
+// (and the pipeline around it) without network calls. Serve it with httptest.NewServer and pass the
+// server URL as NewClient's apiEndpoint; every SDK the client uses then talks to it.
+//
+// Responses depend only on the request: image models return a 1x1 PNG, TTS models stream silent PCM,
+// segmentation requests get a boundary at every sentence end, quality judges score 4, and other text
+// calls echo the start of the user text. Set Text to script text responses.
+type FakeGemini struct {
+	// Text, if set, answers text calls; returning ok=false falls back to the default response.
+	Text func(req FakeRequest) (text string, ok bool)
+
+	mu    sync.Mutex
+	calls []FakeRequest
+}
+
+// FakeRequest is a generateContent call received by FakeGemini.
+type FakeRequest struct {
+	Model  string
+	Method string // generateContent or streamGenerateContent
+	System string // system instruction text
+	Text   string // user text parts, joined by newlines
+	JSON   bool   // responseMimeType application/json
+}
+
+// NewFakeGemini returns a FakeGemini with the default responses.
+func NewFakeGemini() *FakeGemini {
+	return &FakeGemini{}
+}
+
+// Calls returns the requests served so far, in arrival order.
+func (f *FakeGemini) Calls() []FakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeRequest(nil), f.calls...)
+}
+
+// fakeGenerateRequest is the part of a GenerateContentRequest the fake reads; both SDKs send the
+// same camelCase JSON.
+type fakeGenerateRequest struct {
+	Contents []struct {
+		Parts []struct {
+			Text string `json:"text"`
+		} `json:"parts"`
+	} `json:"contents"`
+	SystemInstruction *struct {
+		Parts []struct {
+			Text string `json:"text"`
+		} `json:"parts"`
+	} `json:"systemInstruction"`
+	GenerationConfig *struct {
+		ResponseMimeType string `json:"responseMimeType"`
+	} `json:"generationConfig"`
+}
+
+func (f *FakeGemini) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Paths look like /v1beta/models/{model}:{method}
+	_, target, ok := strings.Cut(r.URL.Path, "/models/")
+	model, method, ok2 := strings.Cut(target, ":")
+	if !ok || !ok2 || r.Method != http.MethodPost {
+		writeFakeError(w, http.StatusNotFound, "NOT_FOUND", "fake Gemini only serves generateContent")
+		return
+	}
+	var body fakeGenerateRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeFakeError(w, http.StatusBadRequest, "INVALID_ARGUMENT", err.Error())
+		return
+	}
+	req := FakeRequest{Model: model, Method: method}
+	if body.SystemInstruction != nil {
+		for _, p := range body.SystemInstruction.Parts {
+			req.System += p.Text
+		}
+	}
+	var texts []string
+	for _, c := range body.Contents {
+		for _, p := range c.Parts {
+			if p.Text != "" {
+				texts = append(texts, p.Text)
+			}
+		}
+	}
+	req.Text = strings.Join(texts, "\n")
+	req.JSON = body.GenerationConfig != nil && body.GenerationConfig.ResponseMimeType == "application/json"
+	f.mu.Lock()
+	f.calls = append(f.calls, req)
+	f.mu.Unlock()
+
+	var part map[string]any
+	switch modelFamily(model) {
+	case ModelFamilyImage:
+		part = map[string]any{"inlineData": map[string]any{"mimeType": "image/png", "data": fakePNG}}
+	case ModelFamilyTTS:
+		// 0.1s of silence as 16-bit mono PCM at 24kHz, like Gemini TTS
+		part = map[string]any{"inlineData": map[string]any{
+			"mimeType": "audio/L16;codec=pcm;rate=24000",
+			"data":     base64.StdEncoding.EncodeToString(make([]byte, 4800)),
+		}}
+	default:
+		part = map[string]any{"text": f.text(req)}
+	}
+	resp := map[string]any{
+		"candidates": []any{map[string]any{
+			"content":      map[string]any{"role": "model", "parts": []any{part}},
+			"finishReason": "STOP",
+			"index":        0,
+		}},
+		"usageMetadata": map[string]any{"promptTokenCount": len(req.Text) / 4, "candidatesTokenCount": 1},
+	}
+	data, _ := json.Marshal(resp)
+	if method == "streamGenerateContent" {
+		// The unified SDK streams server-sent events; generative-ai-go streams a JSON array
+		if r.URL.Query().Get("alt") == "sse" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: %s\r\n\r\n", data)
+			return
+		}
+		data = append(append([]byte("["), data...), ']')
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// text returns the response text of a text call.
+func (f *FakeGemini) text(req FakeRequest) string {
+	if f.Text != nil {
+		if text, ok := f.Text(req); ok {
+			return text
+		}
+	}
+	switch {
+	case req.JSON && strings.Contains(req.System, `"boundaries"`):
+		data, _ := json.Marshal(map[string][]int{"boundaries": fakeBoundaries(req.Text)})
+		return string(data)
+	case req.JSON && strings.Contains(req.System, `"score"`):
+		return `{"score": 4, "reason": "fake judge"}`
+	case req.JSON:
+		return "{}"
+	}
+	h := fnv.New32a()
+	h.Write([]byte(req.System))
+	h.Write([]byte(req.Text))
+	preview := req.Text
+	if utf8.RuneCountInString(preview) > 200 {
+		preview = string([]rune(preview)[:200])
+	}
+	return fmt.Sprintf("Fake %s response %08x: %s", req.Model, h.Sum32(), preview)
+}
+
+// fakeBoundaries returns the grapheme position after every sentence end of text, ending with its length.
+func fakeBoundaries(text string) []int {
+	offsets := runeToByteOffsets(text)
+	n := len(offsets) - 1
+	var boundaries []int
+	for i := 0; i < n; i++ {
+		g := text[offsets[i]:offsets[i+1]]
+		if (g == "." || g == "!" || g == "?") && (i+1 == n || strings.TrimSpace(text[offsets[i+1]:offsets[i+2]]) == "") {
+			boundaries = append(boundaries, i+1)
+		}
+	}
+	if len(boundaries) == 0 || boundaries[len(boundaries)-1] != n {
+		boundaries = append(boundaries, n)
+	}
+	return boundaries
+}
+
+func writeFakeError(w http.ResponseWriter, code int, status, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"code": code, "message": message, "status": status}})
+}
+
+// fakePNG is a base64 1x1 PNG.
+var fakePNG = func() string {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	img.Set(0, 0, color.RGBA{R: 0x33, G: 0x66, B: 0x99, A: 0xff})
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}()
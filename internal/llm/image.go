@@ -14,7 +14,7 @@ import (
 // Uses genai client and GenerateContent; when the SDK supports it, set model.ResponseModality = []string{"IMAGE"}.
 func (c *Client) GenerateImage(ctx context.Context, prompt string) (*Image, error) {
 	log.Debug().
-		Str("prompt", prompt[:min(50, len(prompt))]+"...").
+		Str("prompt", c.preview(prompt, 50)+"...").
 		Msg("Generating image")
 
 	if c.genaiClient != nil {
@@ -22,7 +22,7 @@ func (c *Client) GenerateImage(ctx context.Context, prompt string) (*Image, erro
 		if err != nil {
 			log.Error().Err(err).
 				Str("model", c.modelPro).
-				Str("prompt_preview", prompt[:min(80, len(prompt))]).
+				Str("prompt_preview", c.preview(prompt, 80)).
 				Msg("Genai image generation failed (strict modality: no fallback)")
 			return nil, err
 		}
@@ -42,12 +42,12 @@ func (c *Client) generateImageGenai(ctx context.Context, prompt string) (*Image,
 	setResponseModality(model, []string{"IMAGE"})
 
 	reqPrompt := genai.Text(prompt)
-	resp, err := model.GenerateContent(ctx, reqPrompt)
+	resp, err := c.generateGenai(ctx, model, c.modelImage, callWeightText, reqPrompt)
 	if err != nil {
 		return nil, err
 	}
 
-	logGeminiResponse("GenerateImage", fmt.Sprintf("candidates=%d", len(resp.Candidates)))
+	c.logGeminiResponse("GenerateImage", fmt.Sprintf("candidates=%d", len(resp.Candidates)))
 	for i, cand := range resp.Candidates {
 		if cand.Content == nil {
 			continue
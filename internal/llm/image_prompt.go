@@ -10,27 +10,52 @@ import (
 )
 
 // GenerateImagePrompt generates an image generation prompt using Gemini (Flash; Pro can return empty with langchaingo).
+// Gemini prompts (not fallbacks) are cached by text and input type in the shared cache, if configured, and
+// in Postgres next to the segmentation boundaries, except for jobs in an image prompt experiment's treatment.
 func (c *Client) GenerateImagePrompt(ctx context.Context, text, inputType string) (string, error) {
 	log.Debug().
 		Str("input_type", inputType).
 		Msg("Generating image prompt")
 
-	// Use Flash for image prompt generation (same as SegmentText/GenerateNarration); Pro often returns empty via langchaingo.
-	model := c.llmFlash
-	if model == nil {
+	exp := c.treatment(ctx, ExperimentStepImagePrompt)
+	call := c.startExperimentCall(ctx, ExperimentStepImagePrompt, len(text))
+	key := imagePromptCacheKey(text, inputType)
+	if exp == nil {
+		if prompt, ok := c.cachedString(ctx, "image_prompt", &c.imagePromptStats, key, true); ok {
+			call.done("cache", "cache", len(prompt))
+			return prompt, nil
+		}
+	}
+	imagePrompt, model := c.generateImagePromptGemini(ctx, text, inputType, exp, "")
+	if imagePrompt == "" {
+		imagePrompt = c.fallbackImagePrompt(text, inputType)
+		call.done("rule_based", "fallback", len(imagePrompt))
+		return imagePrompt, nil
+	}
+	call.done(model, "ok", len(imagePrompt))
+	if exp == nil {
+		c.storeString(ctx, key, imagePrompt, true)
+	}
+	return imagePrompt, nil
+}
+
+// RegenerateImagePrompt writes a new image prompt for one the quality evaluator rejected, passing the
+// reviewer's feedback to the model. Falls back to the rule-based prompt; never uses the shared cache.
+func (c *Client) RegenerateImagePrompt(ctx context.Context, text, inputType, feedback string) (string, error) {
+	imagePrompt, _ := c.generateImagePromptGemini(ctx, text, inputType, c.treatment(ctx, ExperimentStepImagePrompt), feedback)
+	if imagePrompt == "" {
 		return c.fallbackImagePrompt(text, inputType), nil
 	}
+	return imagePrompt, nil
+}
+
+// generateImagePromptGemini asks Gemini for an image prompt and returns it with the model that wrote it;
+// returns "" when no model is available or it fails. exp, when set, adds its prompt variant and tries
+// its model first; feedback, when set, explains why a previous version was rejected.
+func (c *Client) generateImagePromptGemini(ctx context.Context, text, inputType string, exp *Experiment, feedback string) (string, string) {
 
 	// Build style guidance and system prompt
-	var styleGuidance string
-	switch inputType {
-	case "educational":
-		styleGuidance = "Create a clear, easy-to-read illustration or reference table suitable for learning. Prefer diagrams, simple charts, step-by-step visuals, or tables that are easy to understand. Focus on clarity and accuracy."
-	case "financial":
-		styleGuidance = "Create a professional, restrained visual suitable for financial content. Avoid flashy or misleading imagery."
-	case "fictional":
-		styleGuidance = "Create a cinematic, atmospheric scene that captures the mood and setting of the story."
-	}
+	styleGuidance := c.inputTypes.Get(inputType).ImageStyle
 
 	systemPrompt := fmt.Sprintf(`You are an expert at creating image generation prompts for AI models like Midjourney or DALL-E.
 
@@ -46,6 +71,23 @@ Focus on:
 - Specific details that would create an effective image
 
 Return ONLY the image prompt, no explanations.`, inputType, styleGuidance)
+	systemPrompt = withReviewerFeedback(withPromptSuffix(systemPrompt, exp), feedback)
+
+	if exp != nil && exp.Model != "" {
+		imagePrompt, err := c.generateWithModel(ctx, exp.Model, systemPrompt, text, 0.8, 300)
+		if err != nil {
+			log.Warn().Err(err).Str("model", exp.Model).Str("experiment", exp.Name).Msg("Experiment image prompt model failed, trying Flash")
+		} else if imagePrompt != "" {
+			c.logGeminiResponse("GenerateImagePrompt", imagePrompt)
+			return imagePrompt, exp.Model
+		}
+	}
+
+	// Use Flash for image prompt generation (same as SegmentText/GenerateNarration); Pro often returns empty via langchaingo.
+	model := c.llmFlash
+	if model == nil {
+		return "", ""
+	}
 
 	messages := []llms.MessageContent{
 		{Role: llms.ChatMessageTypeSystem, Parts: []llms.ContentPart{llms.TextContent{Text: systemPrompt}}},
@@ -53,49 +95,39 @@ Return ONLY the image prompt, no explanations.`, inputType, styleGuidance)
 	}
 
 	// Call Gemini (Flash)
-	resp, err := model.GenerateContent(ctx, messages,
+	resp, err := c.generateLimited(ctx, model, c.modelFlash, messages,
 		llms.WithTemperature(0.8),
 		llms.WithMaxTokens(300),
 	)
 	if err != nil {
 		log.Error().Err(err).Msg("Gemini image prompt generation failed, using fallback")
-		return c.fallbackImagePrompt(text, inputType), nil
+		return "", ""
 	}
 
 	if len(resp.Choices) == 0 {
 		log.Warn().Msg("Gemini returned no choices, using fallback")
-		return c.fallbackImagePrompt(text, inputType), nil
+		return "", ""
 	}
 
 	response := resp.Choices[0].Content
-	logGeminiResponse("GenerateImagePrompt", response)
+	c.logGeminiResponse("GenerateImagePrompt", response)
 
 	imagePrompt := strings.TrimSpace(response)
 	if imagePrompt == "" {
 		log.Warn().Msg("Gemini returned empty image prompt, using fallback")
-		imagePrompt = c.fallbackImagePrompt(text, inputType)
+		return "", ""
 	}
 
 	log.Info().
 		Int("prompt_length", len(imagePrompt)).
 		Msg("Image prompt generation complete (Gemini)")
 
-	return imagePrompt, nil
+	return imagePrompt, c.modelFlash
 }
 
 // fallbackImagePrompt provides simple image prompt fallback (used when Gemini returns empty or model is unavailable).
 func (c *Client) fallbackImagePrompt(text, inputType string) string {
-	var stylePrefix string
-	switch inputType {
-	case "educational":
-		stylePrefix = "Clear, easy-to-read educational illustration or reference table: "
-	case "financial":
-		stylePrefix = "Professional financial chart: "
-	case "fictional":
-		stylePrefix = "Cinematic scene: "
-	default:
-		stylePrefix = "Illustration: "
-	}
+	stylePrefix := c.inputTypes.Get(inputType).ImagePromptPrefix
 
 	// Take first 200 chars of text as base so the prompt is substantive
 	textSample := strings.TrimSpace(text)
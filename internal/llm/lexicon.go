@@ -0,0 +1,24 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+type lexiconKey struct{}
+
+// WithLexicon returns a context that makes GenerateAudio apply the pronunciation lexicon to scripts:
+// replacements are substituted and IPA transcriptions added to the TTS instructions.
+func WithLexicon(ctx context.Context, entries []models.LexiconEntry) context.Context {
+	if len(entries) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, lexiconKey{}, entries)
+}
+
+// lexiconFromContext returns the lexicon set by WithLexicon, or nil.
+func lexiconFromContext(ctx context.Context) []models.LexiconEntry {
+	entries, _ := ctx.Value(lexiconKey{}).([]models.LexiconEntry)
+	return entries
+}
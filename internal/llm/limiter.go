@@ -0,0 +1,184 @@
+package llm
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Model families share Gemini rate limits; each can have its own process-wide concurrency limit.
+const (
+	ModelFamilyPro   = "pro"
+	ModelFamilyFlash = "flash"
+	ModelFamilyImage = "image"
+	ModelFamilyTTS   = "tts"
+)
+
+// Call weights: a call holds this many slots of its family's limit while it runs. Vision extraction
+// sends whole documents, so it counts double against the limit.
+const (
+	callWeightText     = 1
+	callWeightDocument = 2
+)
+
+// modelFamily returns the family of a Gemini model name (e.g. gemini-2.5-flash-lite is flash).
+func modelFamily(model string) string {
+	m := strings.ToLower(model)
+	switch {
+	case strings.Contains(m, "tts"):
+		return ModelFamilyTTS
+	case strings.Contains(m, "image"):
+		return ModelFamilyImage
+	case strings.Contains(m, "flash"):
+		return ModelFamilyFlash
+	default:
+		return ModelFamilyPro
+	}
+}
+
+// SetConcurrencyLimits bounds the number of concurrent Gemini calls per model family (ModelFamily*) across
+// every job using this client, on top of the per-job MAX_CONCURRENT_SEGMENTS. Calls over the limit queue in
+// FIFO order until a slot frees up or their context ends. Families with a limit <= 0 are unlimited.
+func (c *Client) SetConcurrencyLimits(limits map[string]int) {
+	c.limiters = map[string]*callSemaphore{}
+	for family, limit := range limits {
+		if limit > 0 {
+			c.limiters[family] = newCallSemaphore(family, int64(limit))
+		}
+	}
+}
+
+// SharedLimiter is a concurrency limit shared with other processes, such as ratelimit.RedisSemaphore.
+// Acquire takes weight slots (clamped to its size) and returns their release.
+type SharedLimiter interface {
+	Acquire(ctx context.Context, weight int64) (release func(), err error)
+}
+
+// SetSharedConcurrencyLimits bounds the concurrent Gemini calls per model family across every process
+// sharing the limiters (LIMITS_BACKEND=redis). They replace the process-wide limits of the same families.
+func (c *Client) SetSharedConcurrencyLimits(limiters map[string]SharedLimiter) {
+	c.sharedLimiters = limiters
+}
+
+// acquire takes weight slots of model's family limit, waiting in line if needed. The returned release
+// must be called once the call finishes; it is a no-op when the family is unlimited.
+func (c *Client) acquire(ctx context.Context, model string, weight int64) (release func(), err error) {
+	if shared := c.sharedLimiters[modelFamily(model)]; shared != nil {
+		return shared.Acquire(ctx, weight)
+	}
+	sem := c.limiters[modelFamily(model)]
+	if sem == nil {
+		return func() {}, nil
+	}
+	weight = min(weight, sem.size)
+	if err := sem.acquire(ctx, weight); err != nil {
+		return nil, err
+	}
+	return func() { sem.release(weight) }, nil
+}
+
+// callSemaphore is a weighted semaphore whose waiters are served in arrival order, so a heavy call
+// is not starved by a stream of light ones.
+type callSemaphore struct {
+	family  string
+	size    int64
+	mu      sync.Mutex
+	used    int64
+	waiters list.List // of *semWaiter
+
+	waits     atomic.Int64 // calls that had to queue
+	waitNanos atomic.Int64 // total time spent queued
+	timeouts  atomic.Int64 // calls whose context ended while queued
+}
+
+type semWaiter struct {
+	weight int64
+	ready  chan struct{}
+}
+
+func newCallSemaphore(family string, size int64) *callSemaphore {
+	return &callSemaphore{family: family, size: size}
+}
+
+func (s *callSemaphore) acquire(ctx context.Context, weight int64) error {
+	s.mu.Lock()
+	if s.used+weight <= s.size && s.waiters.Len() == 0 {
+		s.used += weight
+		s.mu.Unlock()
+		return nil
+	}
+	w := &semWaiter{weight: weight, ready: make(chan struct{})}
+	elem := s.waiters.PushBack(w)
+	queued := s.waiters.Len()
+	s.mu.Unlock()
+
+	start := time.Now()
+	select {
+	case <-w.ready:
+		s.recordWait(time.Since(start), queued)
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-w.ready:
+			// Granted while the context ended: give the slots back
+			s.used -= weight
+			s.notifyWaiters()
+		default:
+			isFront := s.waiters.Front() == elem
+			s.waiters.Remove(elem)
+			// Removing the head may let the calls behind it run
+			if isFront && s.used < s.size {
+				s.notifyWaiters()
+			}
+		}
+		s.mu.Unlock()
+		s.timeouts.Add(1)
+		return ctx.Err()
+	}
+}
+
+func (s *callSemaphore) release(weight int64) {
+	s.mu.Lock()
+	s.used -= weight
+	s.notifyWaiters()
+	s.mu.Unlock()
+}
+
+// notifyWaiters grants slots to queued calls in order while they fit. Callers hold s.mu.
+func (s *callSemaphore) notifyWaiters() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+		w := front.Value.(*semWaiter)
+		if s.used+w.weight > s.size {
+			return
+		}
+		s.used += w.weight
+		s.waiters.Remove(front)
+		close(w.ready)
+	}
+}
+
+// recordWait logs a queued call with the running totals. Like the cache metrics they are reported as
+// structured log fields: gemini_<family>_{waits_total,wait_ms_total,timeouts_total}.
+func (s *callSemaphore) recordWait(wait time.Duration, queued int) {
+	waits := s.waits.Add(1)
+	total := s.waitNanos.Add(int64(wait))
+	log.Info().
+		Str("model_family", s.family).
+		Int64("limit", s.size).
+		Int("queued", queued).
+		Int64("wait_ms", wait.Milliseconds()).
+		Int64("gemini_"+s.family+"_waits_total", waits).
+		Int64("gemini_"+s.family+"_wait_ms_total", time.Duration(total).Milliseconds()).
+		Int64("gemini_"+s.family+"_timeouts_total", s.timeouts.Load()).
+		Msg("Gemini call waited for a concurrency slot")
+}
@@ -0,0 +1,138 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestModelFamily(t *testing.T) {
+	tests := map[string]string{
+		"gemini-3-pro-preview":       ModelFamilyPro,
+		"gemini-2.5-flash-lite":      ModelFamilyFlash,
+		"gemini-3-flash-preview":     ModelFamilyFlash,
+		"gemini-3-pro-image-preview": ModelFamilyImage,
+		"gemini-2.5-pro-preview-tts": ModelFamilyTTS,
+		"":                           ModelFamilyPro,
+	}
+	for model, want := range tests {
+		if got := modelFamily(model); got != want {
+			t.Errorf("modelFamily(%q) = %q, want %q", model, got, want)
+		}
+	}
+}
+
+func TestAcquire_Unlimited(t *testing.T) {
+	c := &Client{}
+	c.SetConcurrencyLimits(map[string]int{ModelFamilyPro: 0})
+	for i := 0; i < 3; i++ {
+		if _, err := c.acquire(context.Background(), "gemini-3-pro-preview", callWeightDocument); err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+	}
+}
+
+type fakeSharedLimiter struct{ weights []int64 }
+
+func (f *fakeSharedLimiter) Acquire(ctx context.Context, weight int64) (func(), error) {
+	f.weights = append(f.weights, weight)
+	return func() {}, nil
+}
+
+// TestAcquire_Shared asserts a shared limiter replaces its family's process-wide limit only.
+func TestAcquire_Shared(t *testing.T) {
+	c := &Client{}
+	c.SetConcurrencyLimits(map[string]int{ModelFamilyPro: 1, ModelFamilyFlash: 1})
+	shared := &fakeSharedLimiter{}
+	c.SetSharedConcurrencyLimits(map[string]SharedLimiter{ModelFamilyPro: shared})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.acquire(ctx, "gemini-3-pro-preview", callWeightDocument); err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+	}
+	if len(shared.weights) != 2 || shared.weights[0] != callWeightDocument {
+		t.Errorf("shared acquisitions = %v, want two of weight %d", shared.weights, callWeightDocument)
+	}
+	if _, err := c.acquire(ctx, "gemini-2.5-flash-lite", callWeightText); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if used := c.limiters[ModelFamilyFlash].used; used != 1 {
+		t.Errorf("flash slots used = %d, want 1", used)
+	}
+}
+
+func TestCallSemaphore_FIFOAndWeights(t *testing.T) {
+	c := &Client{}
+	c.SetConcurrencyLimits(map[string]int{ModelFamilyFlash: 2})
+	ctx := context.Background()
+
+	first, err := c.acquire(ctx, "gemini-2.5-flash", callWeightText)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	// A document call needs both slots and queues; a later text call must not overtake it
+	order := make(chan string, 2)
+	go func() {
+		release, _ := c.acquire(ctx, "gemini-2.5-flash", callWeightDocument)
+		order <- "document"
+		release()
+	}()
+	waitForQueue(t, c.limiters[ModelFamilyFlash], 1)
+	go func() {
+		release, _ := c.acquire(ctx, "gemini-2.5-flash", callWeightText)
+		order <- "text"
+		release()
+	}()
+	waitForQueue(t, c.limiters[ModelFamilyFlash], 2)
+
+	first()
+	if got := <-order; got != "document" {
+		t.Errorf("first call to run after release = %q, want document", got)
+	}
+	if got := <-order; got != "text" {
+		t.Errorf("second call = %q, want text", got)
+	}
+}
+
+func TestCallSemaphore_ContextCancel(t *testing.T) {
+	c := &Client{}
+	c.SetConcurrencyLimits(map[string]int{ModelFamilyPro: 1})
+	held, err := c.acquire(context.Background(), "gemini-3-pro-preview", callWeightText)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.acquire(ctx, "gemini-3-pro-preview", callWeightText); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire with expired context: err = %v, want DeadlineExceeded", err)
+	}
+	sem := c.limiters[ModelFamilyPro]
+	if sem.waiters.Len() != 0 || sem.timeouts.Load() != 1 {
+		t.Errorf("after timeout: queued = %d, timeouts = %d; want 0 and 1", sem.waiters.Len(), sem.timeouts.Load())
+	}
+
+	held()
+	release, err := c.acquire(context.Background(), "gemini-3-pro-preview", callWeightText)
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	release()
+}
+
+func waitForQueue(t *testing.T, s *callSemaphore, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		queued := s.waiters.Len()
+		s.mu.Unlock()
+		if queued == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("queue length did not reach %d", n)
+}
@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Content logging modes (GEMINI_LOG_CONTENT): how much user content and model output the client's logs
+// carry. Metadata (caller, lengths, models) is logged in every mode.
+const (
+	LogContentFull     = "full"     // responses up to maxGeminiResponseLogBytes and short text previews
+	LogContentRedacted = "redacted" // the same with e-mail addresses, URLs and numbers masked
+	LogContentNone     = "none"     // no content
+)
+
+// contentLogging is the content logging policy of a Client. The zero value logs all content.
+type contentLogging struct {
+	mode       string
+	sampleRate float64
+}
+
+// SetContentLogging sets the content logging mode (LogContent*) and the fraction of log entries, from 0 to
+// 1, that carry content; the others only carry metadata.
+func (c *Client) SetContentLogging(mode string, sampleRate float64) error {
+	switch mode {
+	case LogContentFull, LogContentRedacted, LogContentNone:
+	default:
+		return fmt.Errorf("invalid content logging mode %q: must be full, redacted or none", mode)
+	}
+	if sampleRate < 0 || sampleRate > 1 {
+		return fmt.Errorf("invalid content logging sample rate %v: must be between 0 and 1", sampleRate)
+	}
+	c.contentLog = contentLogging{mode: mode, sampleRate: sampleRate}
+	return nil
+}
+
+// loggable returns text as the policy lets it appear in a log entry, or false when the entry must not
+// carry it (mode none, or not sampled).
+func (c *Client) loggable(text string) (string, bool) {
+	switch c.contentLog.mode {
+	case "", LogContentFull:
+	case LogContentRedacted:
+		text = redact(text)
+	default:
+		return "", false
+	}
+	if c.contentLog.mode != "" && c.contentLog.sampleRate < 1 && rand.Float64() >= c.contentLog.sampleRate {
+		return "", false
+	}
+	return text, true
+}
+
+// preview returns up to n bytes of text for a log entry, or "[omitted]" when the policy keeps it out.
+func (c *Client) preview(text string, n int) string {
+	text, ok := c.loggable(text[:min(n, len(text))])
+	if !ok {
+		return "[omitted]"
+	}
+	return text
+}
+
+// logGeminiResponse logs a Gemini response: its length always and, when the policy allows, its text
+// truncated to maxGeminiResponseLogBytes.
+func (c *Client) logGeminiResponse(caller, raw string) {
+	ev := log.Info().Str("caller", caller).Int("gemini_response_len", len(raw))
+	if text, ok := c.loggable(raw); ok {
+		if len(text) > maxGeminiResponseLogBytes {
+			text = text[:maxGeminiResponseLogBytes] + "... [truncated]"
+		}
+		ev.Str("gemini_response", text)
+	}
+	ev.Msg("Gemini response")
+}
+
+// Patterns masked in redacted mode: personal data commonly found in documents. Redaction is best effort;
+// use LogContentNone when no user content may be logged.
+var (
+	emailRe  = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	urlRe    = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s"'<>]+`)
+	numberRe = regexp.MustCompile(`\+?\d(?:[\d .()/-]*\d){5,}`) // phone, account and card numbers, IDs (6+ digits)
+)
+
+// redact masks e-mail addresses, URLs and numbers of six digits or more in text.
+func redact(text string) string {
+	text = emailRe.ReplaceAllString(text, "[email]")
+	text = urlRe.ReplaceAllString(text, "[url]")
+	return numberRe.ReplaceAllString(text, "[number]")
+}
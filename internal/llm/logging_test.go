@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func TestRedact(t *testing.T) {
+	in := "Contact jane.doe@example.com or +1 (555) 123-4567, see https://example.com/a?b=1. Account 12345678, year 2024."
+	want := "Contact [email] or [number], see [url] Account [number], year 2024."
+	if got := redact(in); got != want {
+		t.Errorf("redact = %q, want %q", got, want)
+	}
+}
+
+func TestLogGeminiResponse(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(l zerolog.Logger) { log.Logger = l }(log.Logger)
+	log.Logger = zerolog.New(&buf)
+
+	response := "Write to jane@example.com"
+	for _, tt := range []struct {
+		mode string
+		rate float64
+		want any // gemini_response field, nil when absent
+	}{
+		{"", 0, response}, // zero Client: everything, as before
+		{LogContentFull, 1, response},
+		{LogContentRedacted, 1, "Write to [email]"},
+		{LogContentNone, 1, nil},
+		{LogContentFull, 0, nil},
+	} {
+		c := &Client{}
+		if tt.mode != "" {
+			if err := c.SetContentLogging(tt.mode, tt.rate); err != nil {
+				t.Fatal(err)
+			}
+		}
+		buf.Reset()
+		c.logGeminiResponse("Test", response)
+		var entry map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatalf("log = %q: %v", buf.String(), err)
+		}
+		if entry["gemini_response"] != tt.want || entry["gemini_response_len"] != float64(len(response)) {
+			t.Errorf("mode %q rate %v: entry = %v, want gemini_response %v and the length", tt.mode, tt.rate, entry, tt.want)
+		}
+	}
+
+	if err := (&Client{}).SetContentLogging("verbose", 1); err == nil {
+		t.Error("SetContentLogging accepted an invalid mode")
+	}
+	if err := (&Client{}).SetContentLogging(LogContentFull, 1.5); err == nil {
+		t.Error("SetContentLogging accepted a sample rate above 1")
+	}
+}
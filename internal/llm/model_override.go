@@ -0,0 +1,20 @@
+package llm
+
+import "context"
+
+type segmentModelKey struct{}
+
+// WithSegmentModel returns a context that makes SegmentText try model before the configured
+// primary/fallback tiers and bypass the boundary cache. Used when reprocessing jobs with a new model.
+func WithSegmentModel(ctx context.Context, model string) context.Context {
+	if model == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, segmentModelKey{}, model)
+}
+
+// segmentModelFromContext returns the segmentation model override set by WithSegmentModel, or "".
+func segmentModelFromContext(ctx context.Context) string {
+	model, _ := ctx.Value(segmentModelKey{}).(string)
+	return model
+}
@@ -11,24 +11,48 @@ import (
 
 // GenerateNarration generates narration script for a segment.
 // Tries Gemini 3 Pro first; if it returns empty, falls back to 2.5 Flash.
+// Scripts are cached in the shared cache (if configured) by text, audio type and input type,
+// except for jobs in a narration experiment's treatment.
 func (c *Client) GenerateNarration(ctx context.Context, text, audioType, inputType string) (string, error) {
+	exp := c.treatment(ctx, ExperimentStepNarration)
+	call := c.startExperimentCall(ctx, ExperimentStepNarration, len(text))
+	key := narrationCacheKey(text, audioType, inputType)
+	if exp == nil {
+		if narration, ok := c.cachedString(ctx, "narration", &c.narrationStats, key, false); ok {
+			log.Info().Msg("Narration generation complete (cache)")
+			call.done("cache", "cache", len(narration))
+			return narration, nil
+		}
+	}
+	narration, model, err := c.generateNarration(ctx, text, audioType, inputType, exp, "")
+	if narration == "" {
+		call.done(model, "error", 0)
+	} else {
+		call.done(model, "ok", len(narration))
+	}
+	if err == nil && narration != "" && exp == nil {
+		c.storeString(ctx, key, narration, false)
+	}
+	return narration, err
+}
+
+// RegenerateNarration writes a new narration script for a script the quality evaluator rejected,
+// passing the reviewer's feedback to the model. It never reads or writes the shared cache.
+func (c *Client) RegenerateNarration(ctx context.Context, text, audioType, inputType, feedback string) (string, error) {
+	narration, _, err := c.generateNarration(ctx, text, audioType, inputType, c.treatment(ctx, ExperimentStepNarration), feedback)
+	return narration, err
+}
+
+// generateNarration returns the script and the model that wrote it. exp, when set, adds its prompt
+// variant and tries its model first; feedback, when set, explains why a previous version was rejected.
+func (c *Client) generateNarration(ctx context.Context, text, audioType, inputType string, exp *Experiment, feedback string) (string, string, error) {
 	log.Debug().
 		Str("audio_type", audioType).
 		Str("input_type", inputType).
 		Msg("Generating narration")
 
 	// Build style guidance and system prompt once (shared by Pro and Flash)
-	var styleGuidance string
-	switch inputType {
-	case "educational":
-		styleGuidance = "Create clear, engaging educational narration suitable for learning. Use conversational tone."
-	case "financial":
-		styleGuidance = "Create professional, measured narration for financial content. Include appropriate disclaimers. Avoid hype or promises."
-	case "fictional":
-		styleGuidance = "Create immersive, dramatic narration suitable for storytelling."
-	default:
-		styleGuidance = "Create clear, engaging narration."
-	}
+	styleGuidance := c.inputTypes.Get(inputType).NarrationStyle
 
 	var audioStyle string
 	switch audioType {
@@ -47,7 +71,12 @@ Audio format: %s
 
 Generate a natural narration script that would sound good when read aloud.
 Make it engaging and appropriate for the content type.
-Return ONLY the narration text, no explanations or formatting.`, styleGuidance, audioStyle)
+You may use these control tags, sparingly, to improve pacing:
+- <break time="1s"/> for a pause (up to 5s)
+- <emphasis>words</emphasis> to stress a few words
+- <say-as interpret-as="characters">API</say-as> to spell out letters (or interpret-as="digits" for numbers read digit by digit)
+Return ONLY the narration text, no explanations or formatting other than these tags.`, styleGuidance, audioStyle)
+	systemPrompt = withReviewerFeedback(withPromptSuffix(systemPrompt, exp), feedback)
 
 	messages := []llms.MessageContent{
 		{Role: llms.ChatMessageTypeSystem, Parts: []llms.ContentPart{llms.TextContent{Text: systemPrompt}}},
@@ -58,18 +87,30 @@ Return ONLY the narration text, no explanations or formatting.`, styleGuidance,
 		llms.WithMaxTokens(3000),
 	}
 
+	// Experiment model, if any
+	if exp != nil && exp.Model != "" {
+		narration, err := c.generateWithModel(ctx, exp.Model, systemPrompt, text, 0.7, 3000)
+		if err != nil {
+			log.Warn().Err(err).Str("model", exp.Model).Str("experiment", exp.Name).Msg("Experiment narration model failed, trying Gemini Pro")
+		} else if narration != "" {
+			c.logGeminiResponse("GenerateNarration", narration)
+			log.Info().Str("model", exp.Model).Msg("Narration generation complete (experiment model)")
+			return narration, exp.Model, nil
+		}
+	}
+
 	// Try Gemini 3 Pro first
 	if c.llmPro != nil {
-		resp, err := c.llmPro.GenerateContent(ctx, messages, opts...)
+		resp, err := c.generateLimited(ctx, c.llmPro, c.modelPro, messages, opts...)
 		if err != nil {
 			log.Warn().Err(err).Msg("Gemini Pro narration failed, trying 2.5 Flash")
 		} else if len(resp.Choices) > 0 {
 			response := resp.Choices[0].Content
-			logGeminiResponse("GenerateNarration", response)
+			c.logGeminiResponse("GenerateNarration", response)
 			narration := strings.TrimSpace(response)
 			if narration != "" {
 				log.Info().Msg("Narration generation complete (Gemini Pro)")
-				return narration, nil
+				return narration, c.modelPro, nil
 			}
 			log.Warn().Msg("Gemini Pro returned empty narration, trying 2.5 Flash")
 		}
@@ -77,21 +118,21 @@ Return ONLY the narration text, no explanations or formatting.`, styleGuidance,
 
 	// Fallback: 2.5 Flash
 	if c.llmFlash != nil {
-		resp, err := c.llmFlash.GenerateContent(ctx, messages, opts...)
+		resp, err := c.generateLimited(ctx, c.llmFlash, c.modelFlash, messages, opts...)
 		if err != nil {
 			log.Warn().Err(err).Msg("Gemini 2.5 Flash narration failed")
 		} else if len(resp.Choices) > 0 {
 			response := resp.Choices[0].Content
-			logGeminiResponse("GenerateNarration", response)
+			c.logGeminiResponse("GenerateNarration", response)
 			narration := strings.TrimSpace(response)
 			if narration != "" {
 				log.Info().Msg("Narration generation complete (Gemini 2.5 Flash)")
-				return narration, nil
+				return narration, c.modelFlash, nil
 			}
 		}
 	}
 
 	// No narration from either model: return empty so caller skips TTS
 	log.Info().Msg("Narration not generated, returning empty (TTS will be skipped)")
-	return "", nil
+	return "", "", nil
 }
@@ -0,0 +1,114 @@
+package llm
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PDFMimeType is the MIME type extracted page by page (see ExtractPDFPages).
+const PDFMimeType = "application/pdf"
+
+// pageObjectRe matches page objects (/Type /Page) but not the page tree (/Type /Pages).
+var pageObjectRe = regexp.MustCompile(`/Type\s*/Page(?:[^A-Za-z0-9]|$)`)
+
+// objectStreamRe matches compressed object streams, which is where PDF 1.5+ writers usually put page objects.
+var objectStreamRe = regexp.MustCompile(`(?s)<<([^>]*?/Type\s*/ObjStm[^>]*?)>>\s*stream\r?\n`)
+
+// maxObjStmBytes bounds the decompressed size of one object stream when counting pages.
+const maxObjStmBytes = 16 << 20
+
+// PDFPageCount returns a best-effort count of the pages in a PDF by counting its page objects, including
+// those inside Flate-compressed object streams. It returns 0 when no pages are found (not a PDF, encrypted,
+// or an unsupported stream filter); callers then extract the whole document in one request.
+func PDFPageCount(data []byte) int {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\r\n "), []byte("%PDF")) {
+		return 0
+	}
+	count := 0
+	var streams [][2]int // object stream bodies, excluded from the raw count
+	for _, m := range objectStreamRe.FindAllSubmatchIndex(data, -1) {
+		end := bytes.Index(data[m[1]:], []byte("endstream"))
+		if end < 0 {
+			continue
+		}
+		body := data[m[1] : m[1]+end]
+		streams = append(streams, [2]int{m[1], m[1] + end})
+		if !bytes.Contains(data[m[2]:m[3]], []byte("/FlateDecode")) {
+			continue
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			continue
+		}
+		// Truncated or trailing-garbage streams still yield the objects decoded so far
+		inflated, _ := io.ReadAll(io.LimitReader(zr, maxObjStmBytes))
+		_ = zr.Close()
+		count += len(pageObjectRe.FindAllIndex(inflated, -1))
+	}
+	for _, m := range pageObjectRe.FindAllIndex(data, -1) {
+		inStream := false
+		for _, s := range streams {
+			if m[0] >= s[0] && m[0] < s[1] {
+				inStream = true
+				break
+			}
+		}
+		if !inStream {
+			count++
+		}
+	}
+	return count
+}
+
+// ExtractedPage is the text extracted from one page of a PDF.
+type ExtractedPage struct {
+	Page int // 1-based page number
+	Text string
+}
+
+// pageMarkerRe matches the [[PAGE n]] line the model writes before each page's text.
+var pageMarkerRe = regexp.MustCompile(`(?m)^[ \t]*\[\[PAGE (\d+)\]\][ \t]*$`)
+
+// splitPageMarkers splits model output on [[PAGE n]] markers into pages first..last. Text before the first
+// marker belongs to the first page, markers outside the range are folded into the preceding page, and pages
+// the model skipped or left empty are omitted.
+func splitPageMarkers(output string, first, last int) []ExtractedPage {
+	texts := make(map[int]*strings.Builder)
+	appendText := func(page int, s string) {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return
+		}
+		b, ok := texts[page]
+		if !ok {
+			b = &strings.Builder{}
+			texts[page] = b
+		} else {
+			b.WriteString("\n\n")
+		}
+		b.WriteString(s)
+	}
+
+	current := first
+	pos := 0
+	for _, m := range pageMarkerRe.FindAllStringSubmatchIndex(output, -1) {
+		appendText(current, output[pos:m[0]])
+		pos = m[1]
+		if n, err := strconv.Atoi(output[m[2]:m[3]]); err == nil && n >= first && n <= last {
+			current = n
+		}
+	}
+	appendText(current, output[pos:])
+
+	var pages []ExtractedPage
+	for page := first; page <= last; page++ {
+		if b, ok := texts[page]; ok {
+			pages = append(pages, ExtractedPage{Page: page, Text: b.String()})
+		}
+	}
+	return pages
+}
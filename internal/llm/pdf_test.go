@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestPDFPageCount(t *testing.T) {
+	plain := []byte("%PDF-1.4\n1 0 obj << /Type /Pages /Kids [2 0 R 3 0 R] /Count 2 >> endobj\n" +
+		"2 0 obj << /Type /Page /Parent 1 0 R >> endobj\n3 0 obj <</Type/Page/Parent 1 0 R>> endobj\n%%EOF")
+
+	var objs bytes.Buffer
+	zw := zlib.NewWriter(&objs)
+	for i := 0; i < 3; i++ {
+		fmt.Fprintf(zw, "<< /Type /Page /Parent 1 0 R >>\n")
+	}
+	fmt.Fprintf(zw, "<< /Type /Pages /Count 3 >>\n")
+	_ = zw.Close()
+	compressed := append([]byte("%PDF-1.7\n5 0 obj\n<< /Type /ObjStm /N 4 /First 20 /Filter /FlateDecode >>\nstream\n"),
+		objs.Bytes()...)
+	compressed = append(compressed, []byte("\nendstream\nendobj\n%%EOF")...)
+
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"plain objects", plain, 2},
+		{"object stream", compressed, 3},
+		{"not a pdf", []byte("/Type /Page"), 0},
+		{"no pages", []byte("%PDF-1.4\n%%EOF"), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PDFPageCount(tt.data); got != tt.want {
+				t.Errorf("PDFPageCount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitPageMarkers(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      string
+		first, last int
+		want        []ExtractedPage
+	}{
+		{
+			name:   "in order",
+			output: "[[PAGE 3]]\nIntro.\n\n[[PAGE 4]]\nBody.\n",
+			first:  3, last: 4,
+			want: []ExtractedPage{{Page: 3, Text: "Intro."}, {Page: 4, Text: "Body."}},
+		},
+		{
+			name:   "preamble and skipped page",
+			output: "Here is the summary.\n[[PAGE 1]]\nOne.\n[[PAGE 3]]\nThree.",
+			first:  1, last: 3,
+			want: []ExtractedPage{{Page: 1, Text: "Here is the summary.\n\nOne."}, {Page: 3, Text: "Three."}},
+		},
+		{
+			name:   "out of range marker folds into previous page",
+			output: "[[PAGE 2]]\nTwo.\n[[PAGE 9]]\nMore of two.",
+			first:  1, last: 2,
+			want: []ExtractedPage{{Page: 2, Text: "Two.\n\nMore of two."}},
+		},
+		{
+			name:   "no markers",
+			output: "Whole batch.",
+			first:  5, last: 6,
+			want: []ExtractedPage{{Page: 5, Text: "Whole batch."}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitPageMarkers(tt.output, tt.first, tt.last); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitPageMarkers() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/rs/zerolog/log"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// ErrQualityNotConfigured is returned when quality scoring is requested but no Gemini model is available.
+// Callers should keep the output unscored rather than treat it as low quality.
+var ErrQualityNotConfigured = errors.New("quality scoring unavailable: no Gemini model configured")
+
+// QualityScore is an LLM-as-judge rating on a 1 (unusable) to 5 (excellent) rubric with the judge's reason.
+type QualityScore struct {
+	Score  int    `json:"score"`
+	Reason string `json:"reason"`
+}
+
+const narrationRubric = `You are a strict reviewer of narration scripts. The user message contains a SOURCE TEXT and a
+NARRATION SCRIPT written to be read aloud in its place. Rate how faithful the script is to the source:
+
+5: every statement is supported by the source and nothing important is missing
+4: minor omissions or harmless stylistic additions
+3: noticeable omissions, or one claim that is exaggerated or not in the source
+2: several claims not supported by the source, or key points missing
+1: unrelated to the source or contradicts it
+
+Speaker labels, greetings and transitions are fine. Respond with JSON: {"score": <1-5>, "reason": "<one sentence naming the main problem, or what is good>"}.`
+
+const imagePromptRubric = `You are a strict reviewer of image generation prompts. The user message contains a SOURCE TEXT
+(content type given) and an IMAGE PROMPT meant to illustrate it. Rate how relevant the prompt is:
+
+5: depicts the central subject of the text in a style suited to the content type
+4: relevant with minor issues (generic details, slightly off style)
+3: loosely related, or focused on a side detail
+2: mostly unrelated, or a style unsuited to the content type (e.g. flashy imagery for financial content)
+1: unrelated to the text
+
+Respond with JSON: {"score": <1-5>, "reason": "<one sentence naming the main problem, or what is good>"}.`
+
+// ScoreNarration rates how faithful a narration script is to the segment text it was written for.
+func (c *Client) ScoreNarration(ctx context.Context, text, script string) (*QualityScore, error) {
+	return c.judge(ctx, "narration", narrationRubric, "SOURCE TEXT:\n"+text+"\n\nNARRATION SCRIPT:\n"+script)
+}
+
+// ScoreImagePrompt rates how relevant an image prompt is to the segment text it illustrates.
+func (c *Client) ScoreImagePrompt(ctx context.Context, text, inputType, imagePrompt string) (*QualityScore, error) {
+	return c.judge(ctx, "image_prompt", imagePromptRubric,
+		"SOURCE TEXT ("+inputType+"):\n"+text+"\n\nIMAGE PROMPT:\n"+imagePrompt)
+}
+
+// judge asks the Flash model to rate userText against rubric. Uses genai with a response schema when
+// available, otherwise langchaingo with JSON MIME type (same split as segmentation).
+func (c *Client) judge(ctx context.Context, kind, rubric, userText string) (*QualityScore, error) {
+	var response string
+	switch {
+	case c.genaiClient != nil && c.modelFlash != "":
+		model := c.genaiClient.GenerativeModel(c.modelFlash)
+		model.SetTemperature(0)
+		model.SetMaxOutputTokens(300)
+		model.ResponseMIMEType = "application/json"
+		model.ResponseSchema = qualityResponseSchema()
+		model.SystemInstruction = &genai.Content{
+			Parts: []genai.Part{genai.Text(rubric)},
+			Role:  "system",
+		}
+		resp, err := c.generateGenai(ctx, model, c.modelFlash, callWeightText, genai.Text(userText))
+		if err != nil {
+			return nil, err
+		}
+		response = c.extractTextFromGenaiResponse(resp)
+	case c.llmFlash != nil:
+		messages := []llms.MessageContent{
+			{Role: llms.ChatMessageTypeSystem, Parts: []llms.ContentPart{llms.TextContent{Text: rubric}}},
+			{Role: llms.ChatMessageTypeHuman, Parts: []llms.ContentPart{llms.TextContent{Text: userText}}},
+		}
+		resp, err := c.generateLimited(ctx, c.llmFlash, c.modelFlash, messages,
+			llms.WithTemperature(0),
+			llms.WithMaxTokens(300),
+			llms.WithResponseMIMEType("application/json"),
+		)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Choices) == 0 {
+			return nil, fmt.Errorf("empty response from model")
+		}
+		response = resp.Choices[0].Content
+	default:
+		return nil, ErrQualityNotConfigured
+	}
+
+	score, err := parseQualityScore(response)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug().
+		Str("kind", kind).
+		Int("score", score.Score).
+		Str("reason", score.Reason).
+		Msg("Quality score")
+	return score, nil
+}
+
+// parseQualityScore parses the judge's JSON response. Scores outside 1-5 are rejected.
+func parseQualityScore(response string) (*QualityScore, error) {
+	response = strings.TrimSpace(response)
+	response = strings.TrimPrefix(response, "```json")
+	response = strings.TrimPrefix(response, "```")
+	response = strings.TrimSuffix(response, "```")
+	var score QualityScore
+	if err := json.Unmarshal([]byte(strings.TrimSpace(response)), &score); err != nil {
+		return nil, fmt.Errorf("parse quality score: %w", err)
+	}
+	if score.Score < 1 || score.Score > 5 {
+		return nil, fmt.Errorf("quality score %d out of range 1-5", score.Score)
+	}
+	score.Reason = strings.TrimSpace(score.Reason)
+	return &score, nil
+}
+
+// qualityResponseSchema returns the genai schema for judge responses.
+func qualityResponseSchema() *genai.Schema {
+	return &genai.Schema{
+		Type: genai.TypeObject,
+		Properties: map[string]*genai.Schema{
+			"score":  {Type: genai.TypeInteger, Description: "Rubric score from 1 (unusable) to 5 (excellent)"},
+			"reason": {Type: genai.TypeString, Description: "One sentence justifying the score"},
+		},
+		Required: []string{"score", "reason"},
+	}
+}
+
+// withReviewerFeedback asks for a new version of an output the quality evaluator rejected.
+func withReviewerFeedback(systemPrompt, feedback string) string {
+	if feedback == "" {
+		return systemPrompt
+	}
+	return systemPrompt + "\n\nA previous version was rejected by a reviewer: " + feedback + "\nWrite a new version that fixes this."
+}
@@ -0,0 +1,26 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseQualityScore(t *testing.T) {
+	got, err := parseQualityScore("```json\n{\"score\": 4, \"reason\": \" Faithful, omits one date. \"}\n```")
+	if err != nil || got.Score != 4 || got.Reason != "Faithful, omits one date." {
+		t.Fatalf("parseQualityScore = %+v, %v", got, err)
+	}
+	for _, bad := range []string{"", "four", `{"score": 0, "reason": "x"}`, `{"score": 6, "reason": "x"}`} {
+		if _, err := parseQualityScore(bad); err == nil {
+			t.Errorf("parseQualityScore(%q) accepted", bad)
+		}
+	}
+}
+
+func TestScoreWithoutModels(t *testing.T) {
+	c := &Client{}
+	if _, err := c.ScoreNarration(context.Background(), "Text.", "Script."); !errors.Is(err, ErrQualityNotConfigured) {
+		t.Errorf("ScoreNarration without models = %v, want ErrQualityNotConfigured", err)
+	}
+}
@@ -10,11 +10,11 @@ import (
 	"github.com/google/uuid"
 	"github.com/rivo/uniseg"
 	"github.com/rs/zerolog/log"
-	"github.com/snappy-loop/stories/internal/database"
 	"github.com/tmc/langchaingo/llms"
 )
 
 // SegmentText segments text into logical parts.
+// Markdown headings and chapter markers are hard boundaries and become segment titles (see detectChapters).
 // Uses 3.0 flash first, then 2.5 flash; if both fail or return no valid response, returns one segment (whole text).
 // segmentsCount is normalized to at least 1 to avoid division-by-zero in merge logic; callers may pass 0 from gRPC/jobs.
 func (c *Client) SegmentText(ctx context.Context, text string, segmentsCount int, inputType string) ([]*Segment, error) {
@@ -28,16 +28,28 @@ func (c *Client) SegmentText(ctx context.Context, text string, segmentsCount int
 		Int("text_length", len(text)).
 		Msg("Segmenting text")
 
-	// Check cache first
+	if chapters := detectChapters(text); chapters != nil {
+		log.Info().Int("chapters", len(chapters)).Msg("Using chapter headings as hard segment boundaries")
+		return segmentByChapters(text, chapters, segmentsCount, func(section string, count int) ([]*Segment, error) {
+			return c.segmentSection(ctx, section, count, inputType)
+		})
+	}
+	return c.segmentSection(ctx, text, segmentsCount, inputType)
+}
+
+// segmentSection segments text (already trimmed, without chapter headings to respect) from the boundary
+// cache or Gemini, falling back to rule-based boundaries.
+func (c *Client) segmentSection(ctx context.Context, text string, segmentsCount int, inputType string) ([]*Segment, error) {
+	// Check cache first (unless a model override or experiment treatment asks for fresh boundaries)
 	var cachedBoundaries []int
-	textHash := database.TextHash(text)
-	if c.boundaryCache != nil {
-		cached, err := c.boundaryCache.Get(ctx, textHash)
-		if err != nil {
-			log.Warn().Err(err).Msg("Failed to get from boundary cache, proceeding with LLM")
-		} else if cached != nil {
+	cacheKey := boundaryCacheKey(text, segmentsCount, inputType)
+	overrideModel := segmentModelFromContext(ctx)
+	exp := c.treatment(ctx, ExperimentStepSegmentation)
+	call := c.startExperimentCall(ctx, ExperimentStepSegmentation, len(text))
+	if overrideModel == "" && exp == nil {
+		if cached := c.cachedBoundaries(ctx, cacheKey); cached != nil {
 			log.Info().
-				Str("text_hash", textHash).
+				Str("cache_key", cacheKey).
 				Int("cached_boundaries", len(cached)).
 				Msg("Using cached boundaries")
 			cachedBoundaries = cached
@@ -57,11 +69,12 @@ func (c *Client) SegmentText(ctx context.Context, text string, segmentsCount int
 			Str("caller", "SegmentText").
 			Int("final_segments", len(segments)).
 			Msg("Text segmentation complete (from cache)")
+		call.done("cache", "cache", len(segments))
 
 		return segments, nil
 	}
 
-	systemPrompt := c.buildSegmentSystemPrompt(segmentsCount, inputType)
+	systemPrompt := withPromptSuffix(c.buildSegmentSystemPrompt(segmentsCount, inputType), exp)
 
 	// Log segmentation request (system prompt + user message length)
 	log.Info().
@@ -71,15 +84,23 @@ func (c *Client) SegmentText(ctx context.Context, text string, segmentsCount int
 		Int("user_text_len", len(text)).
 		Msg("SegmentText LLM request (system + user message)")
 
-	// Try primary (3.0 flash), then fallback (2.5 flash)
-	for _, tier := range []struct {
+	// Try the override model (if any), then the experiment model (if any), then primary (3.0 flash), then fallback (2.5 flash)
+	type segmentTier struct {
 		name      string
 		modelName string
 		langModel llms.Model
-	}{
+	}
+	tiers := []segmentTier{
 		{"primary", c.modelSegmentPrimary, c.llmSegmentPrimary},
 		{"fallback", c.modelSegmentFallback, c.llmSegmentFallback},
-	} {
+	}
+	if exp != nil && exp.Model != "" {
+		tiers = append([]segmentTier{{"experiment", exp.Model, nil}}, tiers...)
+	}
+	if overrideModel != "" {
+		tiers = append([]segmentTier{{"override", overrideModel, nil}}, tiers...)
+	}
+	for _, tier := range tiers {
 		if tier.modelName == "" && tier.langModel == nil {
 			continue
 		}
@@ -89,12 +110,41 @@ func (c *Client) SegmentText(ctx context.Context, text string, segmentsCount int
 			continue
 		}
 		if segments != nil {
+			call.done(tier.modelName, "ok", len(segments))
 			return segments, nil
 		}
 	}
 
 	// No response from both: use rule-based fallback (do not cache)
 	log.Info().Msg("No valid response from segment models, using rule-based fallback")
+	segments := c.heuristicSegments(text, segmentsCount)
+	call.done("rule_based", "fallback", len(segments))
+	return segments, nil
+}
+
+// SegmentTextHeuristic segments text with the rule-based boundaries only (paragraphs, then sentences),
+// without calling Gemini or the boundary cache. Used for segmentation_strategy=heuristic.
+func (c *Client) SegmentTextHeuristic(text string, segmentsCount int) []*Segment {
+	if segmentsCount < 1 {
+		segmentsCount = 1
+	}
+	text = strings.TrimSpace(text)
+	log.Info().
+		Int("segments_count", segmentsCount).
+		Int("text_length", len(text)).
+		Msg("Segmenting text (heuristic)")
+	if chapters := detectChapters(text); chapters != nil {
+		segments, _ := segmentByChapters(text, chapters, segmentsCount, func(section string, count int) ([]*Segment, error) {
+			return c.heuristicSegments(section, count), nil
+		})
+		return segments
+	}
+	return c.heuristicSegments(text, segmentsCount)
+}
+
+// heuristicSegments merges rule-based boundaries into segmentsCount segments, or returns the whole
+// text as one segment when no boundaries are found. Results are never cached.
+func (c *Client) heuristicSegments(text string, segmentsCount int) []*Segment {
 	fallbackBoundaries := fallbackSegmentBoundaries(text)
 	if len(fallbackBoundaries) > 0 {
 		byteOffsets := runeToByteOffsets(text)
@@ -104,25 +154,15 @@ func (c *Client) SegmentText(ctx context.Context, text string, segmentsCount int
 			Int("fallback_boundaries", len(validatedBoundaries)).
 			Int("final_segments", len(segments)).
 			Msg("Rule-based segmentation complete (not cached)")
-		return segments, nil
+		return segments
 	}
-	return c.oneSegmentFallback(text), nil
+	return c.oneSegmentFallback(text)
 }
 
 // buildSegmentSystemPrompt returns the system prompt for segmentation (instructions only).
 // The text to analyze is sent separately as a user message, as-is.
 func (c *Client) buildSegmentSystemPrompt(segmentsCount int, inputType string) string {
-	var styleGuidance string
-	switch inputType {
-	case "educational":
-		styleGuidance = "Identify boundaries between concepts, subtopics, or learning units."
-	case "financial":
-		styleGuidance = "Identify boundaries between financial topics, time periods, or categories."
-	case "fictional":
-		styleGuidance = "Identify boundaries between scenes, plot points, or narrative beats."
-	default:
-		styleGuidance = "Identify boundaries at paragraph breaks or major topic changes."
-	}
+	styleGuidance := c.inputTypes.Get(inputType).SegmentationGuidance
 
 	return fmt.Sprintf(`You are an expert at analyzing text structure and identifying logical segment boundaries.
 
@@ -169,6 +209,7 @@ func runeToByteOffsets(s string) []int {
 func validateAndAdjustBoundaries(boundaries []int, text string, byteOffsets []int) []int {
 	adjusted := make([]int, 0, len(boundaries))
 	numGraphemes := len(byteOffsets) - 1
+	sentenceEnds := findSentenceEnds(text)
 
 	for _, graphemeBoundary := range boundaries {
 		if graphemeBoundary >= numGraphemes {
@@ -180,8 +221,8 @@ func validateAndAdjustBoundaries(boundaries []int, text string, byteOffsets []in
 
 		bytePos := byteOffsets[graphemeBoundary]
 
-		// Check if we're at a sentence boundary (looking back for . ! ?)
-		if isSentenceBoundary(text, bytePos) {
+		// Check if we're at a sentence boundary
+		if isSentenceBoundary(sentenceEnds, bytePos) {
 			if len(adjusted) == 0 || graphemeBoundary > adjusted[len(adjusted)-1] {
 				adjusted = append(adjusted, graphemeBoundary)
 			}
@@ -189,7 +230,7 @@ func validateAndAdjustBoundaries(boundaries []int, text string, byteOffsets []in
 		}
 
 		// Not at sentence boundary - search backward for nearest sentence ending
-		newBytePos := findPreviousSentenceBoundary(text, bytePos)
+		newBytePos := findPreviousSentenceBoundary(sentenceEnds, bytePos)
 		if newBytePos < 0 {
 			// No sentence boundary found, use original
 			log.Warn().
@@ -223,46 +264,6 @@ func validateAndAdjustBoundaries(boundaries []int, text string, byteOffsets []in
 	return adjusted
 }
 
-// isSentenceBoundary checks if the position is right after sentence-ending punctuation
-func isSentenceBoundary(text string, bytePos int) bool {
-	if bytePos <= 0 || bytePos > len(text) {
-		return false
-	}
-
-	// Look back for sentence-ending punctuation: . ! ?
-	// Allow for closing quotes, parentheses, etc.
-	checkPos := bytePos - 1
-	for checkPos >= 0 && (text[checkPos] == ' ' || text[checkPos] == '\n' || text[checkPos] == ')' || text[checkPos] == '"' || text[checkPos] == '*') {
-		checkPos--
-	}
-
-	if checkPos < 0 {
-		return false
-	}
-
-	return text[checkPos] == '.' || text[checkPos] == '!' || text[checkPos] == '?'
-}
-
-// findPreviousSentenceBoundary searches backward from bytePos for a sentence-ending punctuation
-func findPreviousSentenceBoundary(text string, bytePos int) int {
-	if bytePos <= 0 {
-		return -1
-	}
-
-	for i := bytePos - 1; i >= 0; i-- {
-		if text[i] == '.' || text[i] == '!' || text[i] == '?' {
-			// Found sentence ending - return position after it (and any trailing whitespace/punctuation)
-			j := i + 1
-			for j < len(text) && (text[j] == ' ' || text[j] == '\n' || text[j] == ')' || text[j] == '"' || text[j] == '*') {
-				j++
-			}
-			return j
-		}
-	}
-
-	return -1
-}
-
 // findGraphemeForBytePos finds the grapheme index for a given byte position
 func findGraphemeForBytePos(byteOffsets []int, targetByte int) int {
 	// byteOffsets[i] = byte position of grapheme i
@@ -344,7 +345,6 @@ func mergeBoundariesIntoSegments(boundaries []int, byteOffsets []int, text strin
 			Int("end_grapheme", endGrapheme).
 			Int("start_byte", startByte).
 			Int("end_byte", endByte).
-			Str("text_preview", text[startByte:min(startByte+50, endByte)]+"..."+text[max(startByte, endByte-50):endByte]).
 			Msg("Creating segment")
 
 		title := fmt.Sprintf("Part %d", i+1)
@@ -413,7 +413,7 @@ func (c *Client) trySegmentWithModel(ctx context.Context, modelTier string, mode
 			Role:  "system",
 		}
 
-		resp, err := model.GenerateContent(ctx, genai.Text(userText))
+		resp, err := c.generateGenai(ctx, model, modelName, callWeightText, genai.Text(userText))
 		if err != nil {
 			return nil, err
 		}
@@ -424,7 +424,7 @@ func (c *Client) trySegmentWithModel(ctx context.Context, modelTier string, mode
 			{Role: llms.ChatMessageTypeSystem, Parts: []llms.ContentPart{llms.TextContent{Text: systemPrompt}}},
 			{Role: llms.ChatMessageTypeHuman, Parts: []llms.ContentPart{llms.TextContent{Text: userText}}},
 		}
-		resp, err := langModel.GenerateContent(ctx, messages,
+		resp, err := c.generateLimited(ctx, langModel, modelName, messages,
 			llms.WithTemperature(0.3),
 			llms.WithMaxTokens(2000),
 			llms.WithResponseMIMEType("application/json"),
@@ -447,7 +447,7 @@ func (c *Client) trySegmentWithModel(ctx context.Context, modelTier string, mode
 		Str("input_type", inputType).
 		Int("response_len", len(response)).
 		Msg("SegmentText LLM response output")
-	logGeminiResponse("SegmentText", response)
+	c.logGeminiResponse("SegmentText", response)
 
 	response = strings.TrimSpace(response)
 	response = strings.TrimPrefix(response, "```json")
@@ -507,17 +507,9 @@ func (c *Client) trySegmentWithModel(ctx context.Context, modelTier string, mode
 		Interface("validated_boundaries", validatedBoundaries).
 		Msg("Boundaries after validation")
 
-	// Cache the validated boundaries for future use
-	if c.boundaryCache != nil {
-		textHash := database.TextHash(userText)
-		if err := c.boundaryCache.Set(ctx, textHash, validatedBoundaries); err != nil {
-			log.Warn().Err(err).Msg("Failed to cache boundaries")
-		} else {
-			log.Info().
-				Str("text_hash", textHash).
-				Int("boundaries_cached", len(validatedBoundaries)).
-				Msg("Cached boundaries for future use")
-		}
+	// Cache the validated boundaries for future use (not experiment treatments: they would serve control jobs)
+	if c.treatment(ctx, ExperimentStepSegmentation) == nil {
+		c.storeBoundaries(ctx, boundaryCacheKey(userText, requestedCount, inputType), validatedBoundaries)
 	}
 
 	// Merge boundaries into requested number of segments
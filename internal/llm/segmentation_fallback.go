@@ -12,8 +12,8 @@ import (
 // Rules:
 // - If text has newlines: segment by newline(s), but do not split if the block after a newline
 //   has < 10 words (count spaces), or if it looks like a list item (starts with number+dot,
-//   indent, bullet), or if the line before the newline ends with a colon.
-// - If text has no newlines: segment by sentence (.). Return boundaries as grapheme indices.
+//   indent, bullet), or if the line before the newline ends with a colon or is a heading.
+// - If text has no newlines: segment by sentence (see findSentenceEnds). Return boundaries as grapheme indices.
 func fallbackSegmentBoundaries(text string) []int {
 	text = strings.TrimSpace(text)
 	if text == "" {
@@ -28,7 +28,7 @@ func fallbackSegmentBoundaries(text string) []int {
 			return boundaries
 		}
 	}
-	// No newlines or no boundaries from newline logic: segment by sentences (dots)
+	// No newlines or no boundaries from newline logic: segment by sentences
 	boundaries := fallbackBoundariesBySentences(text, byteOffsets)
 	if len(boundaries) == 0 {
 		return []int{numGraphemes}
@@ -94,6 +94,13 @@ func lineEndsWithColon(paragraph string) bool {
 	return paragraph[len(paragraph)-1] == ':'
 }
 
+// isHeadingLine reports whether line is a markdown heading or chapter marker (see detectChapters),
+// which belongs with the text below it.
+func isHeadingLine(line string) bool {
+	line = strings.TrimSpace(line)
+	return reMarkdownHeading.MatchString(line) || reChapterMarker.MatchString(line)
+}
+
 func fallbackBoundariesByNewlines(text string, byteOffsets []int) []int {
 	// Split by lines (paragraphs separated by one or more newlines)
 	type block struct{ start, end int }
@@ -116,7 +123,7 @@ func fallbackBoundariesByNewlines(text string, byteOffsets []int) []int {
 		return nil
 	}
 
-	// Merge blocks that should not be split: < 10 words, list line, or previous line ends with colon
+	// Merge blocks that should not be split: < 10 words, list line, or previous line ends with colon or is a heading
 	var segmentEnds []int
 	segmentEndByte := blocks[0].end
 	for i := 1; i < len(blocks); i++ {
@@ -125,7 +132,8 @@ func fallbackBoundariesByNewlines(text string, byteOffsets []int) []int {
 		prevContent := text[blocks[i-1].start:blocks[i-1].end]
 		merge := wordCount(content) < 10 ||
 			isListLine(content) ||
-			lineEndsWithColon(prevContent)
+			lineEndsWithColon(prevContent) ||
+			isHeadingLine(prevContent)
 		if merge {
 			segmentEndByte = b.end
 			continue
@@ -149,23 +157,12 @@ func fallbackBoundariesByNewlines(text string, byteOffsets []int) []int {
 	return boundaries
 }
 
+// fallbackBoundariesBySentences returns a boundary after each sentence; the last one is the end of the text.
 func fallbackBoundariesBySentences(text string, byteOffsets []int) []int {
 	numGraphemes := len(byteOffsets) - 1
 	var boundaries []int
-	i := 0
-	for i < len(text) {
-		// Find next sentence end (. ! ?)
-		j := strings.IndexAny(text[i:], ".!?")
-		if j < 0 {
-			break
-		}
-		i += j
-		// Skip trailing quote/paren/space after the punctuation
-		i++
-		for i < len(text) && (text[i] == ' ' || text[i] == '\t' || text[i] == '"' || text[i] == ')' || text[i] == '\'') {
-			i++
-		}
-		boundaries = append(boundaries, findGraphemeForBytePos(byteOffsets, i))
+	for _, e := range findSentenceEnds(text) {
+		boundaries = append(boundaries, findGraphemeForBytePos(byteOffsets, e.end))
 	}
 	if len(boundaries) > 0 && boundaries[len(boundaries)-1] != numGraphemes {
 		boundaries = append(boundaries, numGraphemes)
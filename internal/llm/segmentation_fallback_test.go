@@ -367,3 +367,26 @@ func TestFallbackSegmentBoundaries_TrimSpace(t *testing.T) {
 		t.Errorf("boundaries differ for trimmed vs untrimmed input: %v vs %v", b1, b2)
 	}
 }
+
+func TestSegmentTextHeuristic(t *testing.T) {
+	c := &Client{}
+	text := "First paragraph has enough words to stand on its own as a segment here.\n\n" +
+		"Second paragraph also has enough words to stand on its own as a segment.\n\n" +
+		"Third paragraph closes the text with enough words to count as a segment."
+
+	segs := c.SegmentTextHeuristic(text, 2)
+	if len(segs) != 2 {
+		t.Fatalf("got %d segments, want 2", len(segs))
+	}
+	var joined string
+	for _, s := range segs {
+		joined += s.Text
+	}
+	if strings.Join(strings.Fields(joined), " ") != strings.Join(strings.Fields(text), " ") {
+		t.Errorf("segments do not cover the text:\n%q", joined)
+	}
+
+	if segs := c.SegmentTextHeuristic("Single sentence", 0); len(segs) != 1 {
+		t.Errorf("short text: got %d segments, want 1", len(segs))
+	}
+}
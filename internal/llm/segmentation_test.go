@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// benchmarkText returns a document of n paragraphs of a few sentences each, with some multi-byte text.
+func benchmarkText(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "Paragraph %d begins here. The planets orbit the Sun at different speeds — Mercury fastest of all! "+
+			"Does Neptune take 165 years? Yes, and its moons are cold. Ünïcödé text 🙋‍♂️ counts by grapheme.\n\n", i+1)
+	}
+	return b.String()
+}
+
+// quietLogs disables the segmenter's debug logging for the rest of b, so benchmarks measure the work only.
+func quietLogs(b *testing.B) {
+	level := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	b.Cleanup(func() { zerolog.SetGlobalLevel(level) })
+}
+
+func TestMergeBoundariesIntoSegments(t *testing.T) {
+	text := benchmarkText(11)
+	offsets := runeToByteOffsets(text)
+	boundaries := validateAndAdjustBoundaries(fakeBoundaries(text), text, offsets)
+
+	segments := mergeBoundariesIntoSegments(boundaries, offsets, text, 3)
+	if len(segments) != 3 {
+		t.Fatalf("segments = %d, want 3", len(segments))
+	}
+	var joined strings.Builder
+	for i, seg := range segments {
+		if i > 0 && seg.StartChar != segments[i-1].EndChar {
+			t.Errorf("segment %d starts at %d, previous ends at %d", i, seg.StartChar, segments[i-1].EndChar)
+		}
+		joined.WriteString(seg.Text)
+	}
+	if joined.String() != text {
+		t.Errorf("segments do not cover the text")
+	}
+}
+
+func BenchmarkMergeBoundariesIntoSegments(b *testing.B) {
+	quietLogs(b)
+	for _, paragraphs := range []int{10, 100, 1000} {
+		text := benchmarkText(paragraphs)
+		offsets := runeToByteOffsets(text)
+		boundaries := validateAndAdjustBoundaries(fakeBoundaries(text), text, offsets)
+		b.Run(fmt.Sprintf("paragraphs=%d", paragraphs), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				mergeBoundariesIntoSegments(boundaries, offsets, text, 10)
+			}
+		})
+	}
+}
+
+// BenchmarkSegmentBoundaries covers the post-processing of an LLM segmentation response: grapheme
+// offsets, snapping boundaries to sentence ends and merging to the requested count.
+func BenchmarkSegmentBoundaries(b *testing.B) {
+	quietLogs(b)
+	for _, paragraphs := range []int{10, 100, 1000} {
+		text := benchmarkText(paragraphs)
+		raw := fakeBoundaries(text)
+		b.Run(fmt.Sprintf("paragraphs=%d", paragraphs), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(text)))
+			for i := 0; i < b.N; i++ {
+				offsets := runeToByteOffsets(text)
+				boundaries := validateAndAdjustBoundaries(raw, text, offsets)
+				mergeBoundariesIntoSegments(boundaries, offsets, text, 10)
+			}
+		})
+	}
+}
+
+func BenchmarkFallbackSegmentBoundaries(b *testing.B) {
+	quietLogs(b)
+	text := benchmarkText(1000)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(text)))
+	for i := 0; i < b.N; i++ {
+		fallbackSegmentBoundaries(text)
+	}
+}
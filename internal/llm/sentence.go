@@ -0,0 +1,114 @@
+package llm
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+)
+
+// sentenceEnd marks where a sentence ends in a text: start is the byte offset just after its
+// terminating punctuation, end is where the next sentence begins (after closing quotes, brackets
+// and whitespace). Any byte offset in [start, end] is an acceptable segment boundary.
+type sentenceEnd struct {
+	start, end int
+}
+
+// sentenceAbbreviations are lowercase tokens whose trailing dot does not end a sentence
+// ("Dr. Smith", "z.B. Berlin"). Abbreviations that commonly close a sentence ("etc.", "usw.") are left out.
+var sentenceAbbreviations = map[string]bool{
+	"mr.": true, "mrs.": true, "ms.": true, "dr.": true, "prof.": true, "st.": true, "jr.": true, "sr.": true,
+	"vs.": true, "e.g.": true, "i.e.": true, "cf.": true, "fig.": true, "no.": true, "approx.": true,
+	"z.b.": true, "bzw.": true, "vgl.": true, "d.h.": true, "u.a.": true, "ca.": true, "nr.": true, "hr.": true, "fr.": true,
+	"mme.": true, "mlle.": true, "sra.": true, "sta.": true,
+}
+
+// findSentenceEnds splits text with Unicode sentence segmentation (UAX #29), which knows CJK (。！？),
+// Arabic (؟ ۔) and Devanagari (। ॥) terminators and does not break inside decimals or before a
+// lowercase word. Breaks that do not follow sentence-terminal punctuation (a bare newline) or that
+// follow a known abbreviation are dropped, so those sentences run on into the next one.
+func findSentenceEnds(text string) []sentenceEnd {
+	var ends []sentenceEnd
+	rest := text
+	state := -1
+	offset := 0
+	for len(rest) > 0 {
+		var sentence string
+		sentence, rest, state = uniseg.FirstSentenceInString(rest, state)
+		sentenceStart := offset
+		offset += len(sentence)
+
+		term := terminatorEnd(sentence)
+		if term < 0 || endsWithAbbreviation(sentence[:term]) {
+			continue
+		}
+		end := offset
+		for end < len(text) {
+			r, size := utf8.DecodeRuneInString(text[end:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			end += size
+		}
+		ends = append(ends, sentenceEnd{start: sentenceStart + term, end: end})
+	}
+	return ends
+}
+
+// terminatorEnd returns the byte offset just after the sentence-terminal punctuation that closes
+// sentence, skipping trailing whitespace and closing quotes/brackets, or -1 if there is none.
+func terminatorEnd(sentence string) int {
+	i := len(sentence)
+	for i > 0 {
+		r, size := utf8.DecodeLastRuneInString(sentence[:i])
+		switch {
+		case unicode.Is(unicode.Sentence_Terminal, r):
+			return i
+		case unicode.IsSpace(r), isClosingPunct(r):
+			i -= size
+		default:
+			return -1
+		}
+	}
+	return -1
+}
+
+// isClosingPunct reports whether r may follow a sentence terminator inside the same sentence.
+func isClosingPunct(r rune) bool {
+	return r == '"' || r == '\'' || r == '*' || unicode.In(r, unicode.Pe, unicode.Pf)
+}
+
+// endsWithAbbreviation reports whether s (ending in its terminator) ends with a known abbreviation.
+func endsWithAbbreviation(s string) bool {
+	word := s[strings.LastIndexFunc(s, unicode.IsSpace)+1:]
+	word = strings.TrimLeftFunc(word, func(r rune) bool { return unicode.In(r, unicode.Ps, unicode.Pi) || r == '"' })
+	return sentenceAbbreviations[strings.ToLower(word)]
+}
+
+// isSentenceBoundary reports whether bytePos lies between a sentence's terminating punctuation and
+// the start of the next sentence.
+func isSentenceBoundary(ends []sentenceEnd, bytePos int) bool {
+	for _, e := range ends {
+		if e.start > bytePos {
+			break
+		}
+		if bytePos <= e.end {
+			return true
+		}
+	}
+	return false
+}
+
+// findPreviousSentenceBoundary returns the start of the sentence following the last sentence that
+// ends at or before bytePos, or -1 if there is none.
+func findPreviousSentenceBoundary(ends []sentenceEnd, bytePos int) int {
+	pos := -1
+	for _, e := range ends {
+		if e.start > bytePos {
+			break
+		}
+		pos = e.end
+	}
+	return pos
+}
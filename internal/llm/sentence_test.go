@@ -0,0 +1,58 @@
+package llm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindSentenceEnds(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"ascii", "First one. Second one! Third?", []string{"First one. ", "Second one! ", "Third?"}},
+		{"cjk", "今天天气很好。我们去公园吧！你来吗？", []string{"今天天气很好。", "我们去公园吧！", "你来吗？"}},
+		{"arabic question mark", "هل أنت بخير؟ نعم.", []string{"هل أنت بخير؟ ", "نعم."}},
+		{"devanagari danda", "यह पहला वाक्य है। यह दूसरा है॥", []string{"यह पहला वाक्य है। ", "यह दूसरा है॥"}},
+		{"english abbreviation", "Dr. Smith arrived. He sat down.", []string{"Dr. Smith arrived. ", "He sat down."}},
+		{"german abbreviation", "Städte z.B. Berlin sind groß. Das stimmt.", []string{"Städte z.B. Berlin sind groß. ", "Das stimmt."}},
+		{"decimal", "Pi is 3.14 roughly. Yes.", []string{"Pi is 3.14 roughly. ", "Yes."}},
+		{"closing quote", `He said "stop." Then left.`, []string{`He said "stop." `, "Then left."}},
+		{"bare newline", "Heading\nBody text.", []string{"Heading\nBody text."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			start := 0
+			for _, e := range findSentenceEnds(tt.text) {
+				got = append(got, tt.text[start:e.end])
+				start = e.end
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findSentenceEnds(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAndAdjustBoundaries_NonASCII(t *testing.T) {
+	text := "今天天气很好。我们去公园吧！你来吗？"
+	byteOffsets := runeToByteOffsets(text)
+	// Boundary 9 falls inside the second sentence and must move back to 7 (after 。).
+	got := validateAndAdjustBoundaries([]int{9, 18}, text, byteOffsets)
+	if want := []int{7, 18}; !reflect.DeepEqual(got, want) {
+		t.Errorf("validateAndAdjustBoundaries = %v, want %v", got, want)
+	}
+
+	text = "Dr. Smith arrived late. He sat down."
+	byteOffsets = runeToByteOffsets(text)
+	// 24 (after "late. ") is a sentence end; 4 (after "Dr. ") is not.
+	got = validateAndAdjustBoundaries([]int{24, len(byteOffsets) - 1}, text, byteOffsets)
+	if want := []int{24, len(byteOffsets) - 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("validateAndAdjustBoundaries = %v, want %v", got, want)
+	}
+	if isSentenceBoundary(findSentenceEnds(text), 4) {
+		t.Error("isSentenceBoundary after \"Dr. \" = true, want false")
+	}
+}
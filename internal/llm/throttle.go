@@ -0,0 +1,414 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/google/generative-ai-go/genai"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/slowlog"
+	"github.com/tmc/langchaingo/llms"
+	"google.golang.org/api/googleapi"
+	unifiedgenai "google.golang.org/genai"
+)
+
+const (
+	// maxRateLimitRetries is how many times a call rejected with 429 is retried after the pause.
+	maxRateLimitRetries = 5
+	// minThrottledRate is the floor of a throttled family's call rate (calls per second).
+	minThrottledRate = 0.05
+	// defaultRateLimitPause is the first pause after a 429 without a retry delay; it doubles on each
+	// consecutive 429 up to maxRateLimitPause.
+	defaultRateLimitPause = 2 * time.Second
+	maxRateLimitPause     = time.Minute
+	// rateRecoverySteps is the number of successful calls a throttled family takes to climb back to
+	// the rate it ran at before the first 429.
+	rateRecoverySteps = 20
+)
+
+// SetAdaptiveRateLimit turns adaptive throttling on 429s on or off. When on, a call rejected for rate
+// limiting or quota pauses its model family for the server's retry delay (or an exponential backoff), then
+// halves the family's call rate (a token bucket) and retries, up to maxRateLimitRetries times. Each
+// success raises the rate again until it reaches the rate seen before throttling, when the bucket is lifted.
+func (c *Client) SetAdaptiveRateLimit(enabled bool) {
+	c.throttles = nil
+	if !enabled {
+		return
+	}
+	c.throttles = map[string]*rateController{}
+	for _, family := range []string{ModelFamilyPro, ModelFamilyFlash, ModelFamilyImage, ModelFamilyTTS} {
+		c.throttles[family] = newRateController(family)
+	}
+}
+
+// WaitUntilUnpaused blocks while any model family is paused after a 429, so callers can hold back new
+// work (e.g. segment dispatch) during a provider throttling window instead of starting calls that would
+// only queue. It returns early with the context's error.
+func (c *Client) WaitUntilUnpaused(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	for {
+		var wait time.Duration
+		for _, rc := range c.throttles {
+			wait = max(wait, rc.pauseRemaining())
+		}
+		if wait <= 0 {
+			return nil
+		}
+		if err := sleepCtx(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// call runs fn, one Gemini call to model, within the family's concurrency limit and adaptive rate limit,
+// retrying it after rate limit errors, and reports its outcome to the call observer.
+func (c *Client) call(ctx context.Context, model string, weight int64, fn func() error) error {
+	err := c.callWithRetries(ctx, model, weight, fn)
+	// Calls cut short by the caller (canceled jobs, shutdown) say nothing about Gemini
+	if c.observeCall != nil && ctx.Err() == nil {
+		c.observeCall(model, operation(), err)
+	}
+	return err
+}
+
+func (c *Client) callWithRetries(ctx context.Context, model string, weight int64, fn func() error) error {
+	rc := c.throttles[modelFamily(model)]
+	for attempt := 0; ; attempt++ {
+		if rc != nil {
+			if err := rc.wait(ctx); err != nil {
+				return err
+			}
+		}
+		release, err := c.acquire(ctx, model, weight)
+		if err != nil {
+			return err
+		}
+		start := time.Now()
+		err = fn()
+		release()
+		if ev := slowlog.Event(slowlog.KindLLM, c.slowCall, time.Since(start)); ev != nil {
+			ev.Str("model", model).Str("operation", operation()).Int("attempt", attempt+1).Bool("failed", err != nil).
+				Msg("Slow Gemini call")
+		}
+		if rc == nil {
+			return err
+		}
+		if !IsRateLimitError(err) {
+			if err == nil {
+				rc.onSuccess()
+			}
+			return err
+		}
+		rc.onRateLimited(retryDelay(err))
+		if attempt >= maxRateLimitRetries {
+			return err
+		}
+		log.Warn().Err(err).Str("model", model).Int("attempt", attempt+1).Msg("Gemini rate limited; retrying after pause")
+	}
+}
+
+// CallObserver receives the outcome of a Gemini call: the model, the client method making the call (e.g.
+// GenerateImage) and its error, nil on success.
+type CallObserver func(model, operation string, err error)
+
+// SetCallObserver makes every Gemini call report its outcome to observe; a call retried after rate limit
+// errors counts once.
+func (c *Client) SetCallObserver(observe CallObserver) {
+	c.observeCall = observe
+}
+
+// SetSlowCallThreshold makes Gemini calls taking at least d log a warning with the model and the client
+// method making the call; 0 disables it. The time waiting for a concurrency slot or a rate limit pause
+// does not count.
+func (c *Client) SetSlowCallThreshold(d time.Duration) {
+	c.slowCall = d
+}
+
+// operation returns the exported Client method on the stack, such as GenerateNarration, to identify a
+// call in logs ("" when there is none).
+func operation() string {
+	const prefix = "github.com/snappy-loop/stories/internal/llm.(*Client)."
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if method, ok := strings.CutPrefix(frame.Function, prefix); ok && method != "" && unicode.IsUpper(rune(method[0])) {
+			method, _, _ = strings.Cut(method, ".") // closures are Method.func1
+			return method
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// generateGenai runs a genai generation for modelName through call.
+func (c *Client) generateGenai(ctx context.Context, model *genai.GenerativeModel, modelName string, weight int64, parts ...genai.Part) (*genai.GenerateContentResponse, error) {
+	var resp *genai.GenerateContentResponse
+	err := c.call(ctx, modelName, weight, func() (err error) {
+		resp, err = model.GenerateContent(ctx, parts...)
+		return err
+	})
+	if err == nil && resp.UsageMetadata != nil {
+		recordUsage(ctx, modelName, resp.UsageMetadata.PromptTokenCount, resp.UsageMetadata.CandidatesTokenCount)
+	}
+	return resp, err
+}
+
+// generateLimited runs a langchaingo generation for modelName through call.
+func (c *Client) generateLimited(ctx context.Context, model llms.Model, modelName string, messages []llms.MessageContent, opts ...llms.CallOption) (*llms.ContentResponse, error) {
+	var resp *llms.ContentResponse
+	err := c.call(ctx, modelName, callWeightText, func() (err error) {
+		resp, err = model.GenerateContent(ctx, messages, opts...)
+		return err
+	})
+	if err == nil {
+		input, output := langchainUsage(resp)
+		recordUsage(ctx, modelName, input, output)
+	}
+	return resp, err
+}
+
+// IsRateLimitError reports whether err is Gemini rejecting a call for rate limiting or exhausted quota
+// (HTTP 429 / RESOURCE_EXHAUSTED), from any of the SDKs the client uses.
+func IsRateLimitError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var gerr *googleapi.Error
+	if errors.As(err, &gerr) {
+		return gerr.Code == http.StatusTooManyRequests
+	}
+	var uerr unifiedgenai.APIError
+	if errors.As(err, &uerr) {
+		return uerr.Code == http.StatusTooManyRequests
+	}
+	// langchaingo flattens SDK errors into text
+	msg := err.Error()
+	return strings.Contains(msg, "RESOURCE_EXHAUSTED") || strings.Contains(msg, "Error 429")
+}
+
+// transientErrorHints are the texts of retryable failures in errors langchaingo flattens into text.
+var transientErrorHints = []string{
+	"Error 500", "Error 502", "Error 503", "Error 504",
+	"UNAVAILABLE", "code = Unavailable", "DEADLINE_EXCEEDED", "code = DeadlineExceeded",
+}
+
+// IsTransientError reports whether err is worth retrying later rather than a problem with the request:
+// rate limiting, a Gemini server error (500, 502, 503, 504), or a timed out or dropped connection.
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if IsRateLimitError(err) {
+		return true
+	}
+	var gerr *googleapi.Error
+	if errors.As(err, &gerr) {
+		return isTransientStatus(gerr.Code)
+	}
+	var uerr unifiedgenai.APIError
+	if errors.As(err, &uerr) {
+		return isTransientStatus(uerr.Code)
+	}
+	var nerr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &nerr) {
+		return true
+	}
+	msg := err.Error()
+	for _, hint := range transientErrorHints {
+		if strings.Contains(msg, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+func isTransientStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryDelayRe matches the retry delay in a google.rpc.RetryInfo detail ("retryDelay": "17s") or in the
+// message text ("Please retry in 17.3s").
+var retryDelayRe = regexp.MustCompile(`(?i)(?:retryDelay"?\s*[:=]\s*"?|retry in )(\d+(?:\.\d+)?)s`)
+
+// retryDelay returns the delay the server asked for before retrying a rate limited call: the Retry-After
+// header, else a RetryInfo detail or message hint; 0 if none.
+func retryDelay(err error) time.Duration {
+	var gerr *googleapi.Error
+	if errors.As(err, &gerr) && gerr.Header != nil {
+		if v := gerr.Header.Get("Retry-After"); v != "" {
+			if secs, convErr := strconv.Atoi(v); convErr == nil && secs >= 0 {
+				return time.Duration(secs) * time.Second
+			}
+			if at, parseErr := http.ParseTime(v); parseErr == nil {
+				return max(time.Until(at), 0)
+			}
+		}
+	}
+	if m := retryDelayRe.FindStringSubmatch(err.Error()); m != nil {
+		if secs, convErr := strconv.ParseFloat(m[1], 64); convErr == nil {
+			return time.Duration(secs * float64(time.Second))
+		}
+	}
+	return 0
+}
+
+// rateController is the adaptive token bucket of one model family. It is inactive (rate 0) until the
+// first 429.
+type rateController struct {
+	family string
+	now    func() time.Time
+
+	mu          sync.Mutex
+	rate        float64 // calls per second while throttled; 0 when not throttled
+	ceiling     float64 // rate at which throttling is lifted: the rate observed before the first 429
+	tokens      float64
+	lastRefill  time.Time
+	pausedUntil time.Time
+	consecutive int // 429s since the last success
+	recent      callCounter
+	throttled   int64 // 429s since start
+}
+
+func newRateController(family string) *rateController {
+	return &rateController{family: family, now: time.Now}
+}
+
+// wait blocks until the family is not paused and, while throttled, a token is available.
+func (r *rateController) wait(ctx context.Context) error {
+	for {
+		r.mu.Lock()
+		now := r.now()
+		var d time.Duration
+		switch {
+		case now.Before(r.pausedUntil):
+			d = r.pausedUntil.Sub(now)
+		case r.rate == 0:
+			r.recent.add(now)
+			r.mu.Unlock()
+			return nil
+		default:
+			r.tokens = min(1, r.tokens+r.rate*now.Sub(r.lastRefill).Seconds())
+			r.lastRefill = now
+			if r.tokens >= 1 {
+				r.tokens--
+				r.recent.add(now)
+				r.mu.Unlock()
+				return nil
+			}
+			d = time.Duration((1 - r.tokens) / r.rate * float64(time.Second))
+		}
+		r.mu.Unlock()
+		if err := sleepCtx(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+// onRateLimited pauses the family for delay (or a backoff when the server gave none) and halves its rate.
+func (r *rateController) onRateLimited(delay time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	r.consecutive++
+	r.throttled++
+	if delay <= 0 {
+		delay = min(defaultRateLimitPause<<min(r.consecutive-1, 8), maxRateLimitPause)
+	}
+	if until := now.Add(delay); until.After(r.pausedUntil) {
+		r.pausedUntil = until
+	}
+	if r.rate == 0 {
+		observed := r.recent.perSecond(now)
+		r.ceiling = max(observed, 2*minThrottledRate)
+		r.rate = max(observed/2, minThrottledRate)
+	} else {
+		r.rate = max(r.rate/2, minThrottledRate)
+	}
+	// One token for the first call after the pause (typically the retry); the rest follow at the new rate
+	r.tokens = 1
+	r.lastRefill = r.pausedUntil
+	log.Warn().
+		Str("model_family", r.family).
+		Int64("pause_ms", delay.Milliseconds()).
+		Float64("rate_per_sec", r.rate).
+		Float64("ceiling_per_sec", r.ceiling).
+		Int64("gemini_"+r.family+"_rate_limited_total", r.throttled).
+		Msg("Gemini rate limited; throttling calls")
+}
+
+// onSuccess raises a throttled family's rate by a step, lifting throttling once it reaches the ceiling.
+func (r *rateController) onSuccess() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.consecutive = 0
+	if r.rate == 0 {
+		return
+	}
+	r.rate += r.ceiling / rateRecoverySteps
+	if r.rate >= r.ceiling {
+		r.rate = 0
+		log.Info().Str("model_family", r.family).Msg("Gemini throttling lifted")
+	}
+}
+
+func (r *rateController) pauseRemaining() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.pausedUntil.Sub(r.now())
+}
+
+// callCounter counts calls per second over the last minute.
+type callCounter struct {
+	secs   [60]int64
+	counts [60]int
+}
+
+func (c *callCounter) add(now time.Time) {
+	sec := now.Unix()
+	i := sec % 60
+	if c.secs[i] != sec {
+		c.secs[i], c.counts[i] = sec, 0
+	}
+	c.counts[i]++
+}
+
+// perSecond is the average call rate over the last minute.
+func (c *callCounter) perSecond(now time.Time) float64 {
+	sec, total := now.Unix(), 0
+	for i := range c.secs {
+		if sec-c.secs[i] < 60 {
+			total += c.counts[i]
+		}
+	}
+	return float64(total) / 60
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
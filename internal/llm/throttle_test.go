@@ -0,0 +1,169 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/api/googleapi"
+	unifiedgenai "google.golang.org/genai"
+)
+
+func TestIsRateLimitError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"googleapi 429", fmt.Errorf("gemini vision failed: %w", &googleapi.Error{Code: 429}), true},
+		{"googleapi 500", &googleapi.Error{Code: 500}, false},
+		{"unified 429", unifiedgenai.APIError{Code: 429, Status: "RESOURCE_EXHAUSTED"}, true},
+		{"langchaingo text", errors.New("googleai: rpc error: code = ResourceExhausted desc = RESOURCE_EXHAUSTED"), true},
+		{"other", errors.New("timeout"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		if got := IsRateLimitError(tt.err); got != tt.want {
+			t.Errorf("%s: IsRateLimitError() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"googleapi 503", fmt.Errorf("segment 2: %w", &googleapi.Error{Code: 503}), true},
+		{"googleapi 400", &googleapi.Error{Code: 400}, false},
+		{"unified 429", unifiedgenai.APIError{Code: 429, Status: "RESOURCE_EXHAUSTED"}, true},
+		{"unified 500", unifiedgenai.APIError{Code: 500, Status: "INTERNAL"}, true},
+		{"unified 403", unifiedgenai.APIError{Code: 403, Status: "PERMISSION_DENIED"}, false},
+		{"langchaingo text", errors.New("googleai: googleapi: Error 503: The model is overloaded"), true},
+		{"deadline", fmt.Errorf("narration generation failed: %w", context.DeadlineExceeded), true},
+		{"connection", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"safety block", errors.New("blocked: FinishReasonSafety"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		if got := IsTransientError(tt.err); got != tt.want {
+			t.Errorf("%s: IsTransientError() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want time.Duration
+	}{
+		{"Retry-After header", &googleapi.Error{Code: 429, Header: http.Header{"Retry-After": {"7"}}}, 7 * time.Second},
+		{"RetryInfo detail", unifiedgenai.APIError{Code: 429, Details: []map[string]any{{"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": "17s"}}}, 17 * time.Second},
+		{"message hint", errors.New("Quota exceeded. Please retry in 3.5s."), 3500 * time.Millisecond},
+		{"none", errors.New("Error 429"), 0},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.err); got != tt.want {
+			t.Errorf("%s: retryDelay() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRateController_ThrottleAndRecover(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	r := newRateController(ModelFamilyFlash)
+	r.now = func() time.Time { return now }
+	// 2 calls per second over the last minute
+	for i := 0; i < 60; i++ {
+		r.recent.add(now.Add(-time.Duration(i) * time.Second))
+		r.recent.add(now.Add(-time.Duration(i) * time.Second))
+	}
+
+	r.onRateLimited(0)
+	if r.rate != 1 || r.ceiling != 2 {
+		t.Fatalf("after first 429: rate = %v, ceiling = %v; want 1 and 2", r.rate, r.ceiling)
+	}
+	if got := r.pauseRemaining(); got != defaultRateLimitPause {
+		t.Errorf("pause = %v, want %v", got, defaultRateLimitPause)
+	}
+	r.onRateLimited(30 * time.Second)
+	if r.rate != 0.5 || r.pauseRemaining() != 30*time.Second {
+		t.Errorf("after second 429: rate = %v, pause = %v; want 0.5 and 30s", r.rate, r.pauseRemaining())
+	}
+
+	for i := 0; i < rateRecoverySteps; i++ {
+		r.onSuccess()
+	}
+	if r.rate != 0 {
+		t.Errorf("rate = %v after recovering, want 0 (unthrottled)", r.rate)
+	}
+}
+
+func TestCall_RetriesRateLimited(t *testing.T) {
+	c := &Client{}
+	c.SetAdaptiveRateLimit(true)
+	attempts := 0
+	err := c.call(context.Background(), "gemini-2.5-flash", callWeightText, func() error {
+		attempts++
+		if attempts == 1 {
+			return unifiedgenai.APIError{Code: 429, Message: "Please retry in 0.01s"}
+		}
+		return nil
+	})
+	if err != nil || attempts != 2 {
+		t.Fatalf("call() err = %v after %d attempts, want success on the second", err, attempts)
+	}
+	if rc := c.throttles[ModelFamilyFlash]; rc.throttled != 1 || rc.rate == 0 {
+		t.Errorf("flash controller: throttled = %d, rate = %v; want one 429 and an active bucket", rc.throttled, rc.rate)
+	}
+	if err := c.WaitUntilUnpaused(context.Background()); err != nil {
+		t.Errorf("WaitUntilUnpaused: %v", err)
+	}
+
+	// Other errors are returned without retrying
+	attempts = 0
+	boom := errors.New("boom")
+	if err := c.call(context.Background(), "gemini-3-pro-preview", callWeightText, func() error { attempts++; return boom }); err != boom || attempts != 1 {
+		t.Errorf("call() = %v after %d attempts, want boom after 1", err, attempts)
+	}
+}
+
+// SlowOperation is a Client method for TestCall_LogsSlowCalls, standing for GenerateNarration and the like.
+func (c *Client) SlowOperation(ctx context.Context) error {
+	return c.call(ctx, "gemini-2.5-flash", callWeightText, func() error {
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	})
+}
+
+func TestCall_LogsSlowCalls(t *testing.T) {
+	var buf bytes.Buffer
+	defer func(l zerolog.Logger) { log.Logger = l }(log.Logger)
+	log.Logger = zerolog.New(&buf)
+
+	c := &Client{}
+	if err := c.SlowOperation(context.Background()); err != nil || buf.Len() != 0 {
+		t.Fatalf("without threshold: err = %v, logged %q", err, buf.String())
+	}
+	c.SetSlowCallThreshold(time.Millisecond)
+	if err := c.SlowOperation(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("log = %q: %v", buf.String(), err)
+	}
+	if entry["slow_op"] != "llm" || entry["model"] != "gemini-2.5-flash" || entry["operation"] != "SlowOperation" || entry["level"] != "warn" {
+		t.Errorf("log entry = %v", entry)
+	}
+}
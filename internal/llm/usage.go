@@ -0,0 +1,120 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// TokenUsage counts the tokens of Gemini calls.
+type TokenUsage struct {
+	InputTokens  int64 `json:"input_tokens"`
+	OutputTokens int64 `json:"output_tokens"`
+}
+
+// Usage accumulates the token usage of the Gemini calls made with a context from WithUsage, per model.
+// It is safe for concurrent use (segments are generated in parallel); the zero value is ready to use.
+type Usage struct {
+	mu      sync.Mutex
+	byModel map[string]TokenUsage
+}
+
+// ByModel returns a copy of the usage per model.
+func (u *Usage) ByModel() map[string]TokenUsage {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	out := make(map[string]TokenUsage, len(u.byModel))
+	for model, t := range u.byModel {
+		out[model] = t
+	}
+	return out
+}
+
+func (u *Usage) add(model string, input, output int64) {
+	if input <= 0 && output <= 0 {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.byModel == nil {
+		u.byModel = make(map[string]TokenUsage)
+	}
+	t := u.byModel[model]
+	t.InputTokens += input
+	t.OutputTokens += output
+	u.byModel[model] = t
+}
+
+type usageKey struct{}
+
+// WithUsage returns a context whose Gemini calls add their token usage (as reported by the API) to u.
+// Responses served from the cache cost nothing and add nothing.
+func WithUsage(ctx context.Context, u *Usage) context.Context {
+	return context.WithValue(ctx, usageKey{}, u)
+}
+
+// recordUsage adds the tokens of a call to model to the usage of ctx, if any.
+func recordUsage(ctx context.Context, model string, input, output int32) {
+	if u, ok := ctx.Value(usageKey{}).(*Usage); ok && u != nil {
+		u.add(model, int64(input), int64(output))
+	}
+}
+
+// langchainUsage returns the token counts langchaingo reports in the generation info of a response.
+func langchainUsage(resp *llms.ContentResponse) (input, output int32) {
+	if resp == nil || len(resp.Choices) == 0 {
+		return 0, 0
+	}
+	info := resp.Choices[0].GenerationInfo
+	input, _ = info["input_tokens"].(int32)
+	output, _ = info["output_tokens"].(int32)
+	return input, output
+}
+
+// ModelPrice is the price of a model in USD per million tokens.
+type ModelPrice struct {
+	InputPerMillion  float64
+	OutputPerMillion float64
+}
+
+// Pricing maps model names to their prices; calls to models without a price are not counted in costs.
+type Pricing map[string]ModelPrice
+
+// ParsePricing parses prices given as model=input:output (USD per million input and output tokens, e.g.
+// gemini-2.5-flash=0.30:2.50).
+func ParsePricing(entries map[string]string) (Pricing, error) {
+	pricing := make(Pricing, len(entries))
+	for model, value := range entries {
+		in, out, ok := strings.Cut(value, ":")
+		if !ok {
+			return nil, fmt.Errorf("price of %s must be input:output, got %q", model, value)
+		}
+		inPrice, err := strconv.ParseFloat(strings.TrimSpace(in), 64)
+		if err != nil || inPrice < 0 {
+			return nil, fmt.Errorf("invalid input price of %s: %q", model, in)
+		}
+		outPrice, err := strconv.ParseFloat(strings.TrimSpace(out), 64)
+		if err != nil || outPrice < 0 {
+			return nil, fmt.Errorf("invalid output price of %s: %q", model, out)
+		}
+		pricing[model] = ModelPrice{InputPerMillion: inPrice, OutputPerMillion: outPrice}
+	}
+	return pricing, nil
+}
+
+// Cost returns the estimated cost in USD of usage.
+func (p Pricing) Cost(usage map[string]TokenUsage) float64 {
+	var cost float64
+	for model, t := range usage {
+		price, ok := p[model]
+		if !ok {
+			continue
+		}
+		cost += (float64(t.InputTokens)*price.InputPerMillion + float64(t.OutputTokens)*price.OutputPerMillion) / 1e6
+	}
+	return cost
+}
@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestUsage_Record(t *testing.T) {
+	recordUsage(context.Background(), "gemini-2.5-flash", 10, 5) // no usage on the context: ignored
+
+	u := &Usage{}
+	ctx := WithUsage(context.Background(), u)
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recordUsage(ctx, "gemini-2.5-flash", 100, 20)
+		}()
+	}
+	wg.Wait()
+	input, output := langchainUsage(&llms.ContentResponse{Choices: []*llms.ContentChoice{{
+		GenerationInfo: map[string]any{"input_tokens": int32(7), "output_tokens": int32(3)},
+	}}})
+	recordUsage(ctx, "gemini-2.5-pro", input, output)
+	recordUsage(ctx, "gemini-2.5-pro", 0, 0)
+
+	got := u.ByModel()
+	if got["gemini-2.5-flash"] != (TokenUsage{1000, 200}) || got["gemini-2.5-pro"] != (TokenUsage{7, 3}) || len(got) != 2 {
+		t.Errorf("usage = %+v", got)
+	}
+}
+
+func TestPricing(t *testing.T) {
+	pricing, err := ParsePricing(map[string]string{"flash": "0.30:2.50", "image": "0.30 : 30"})
+	if err != nil {
+		t.Fatalf("ParsePricing: %v", err)
+	}
+	cost := pricing.Cost(map[string]TokenUsage{
+		"flash":   {InputTokens: 1_000_000, OutputTokens: 200_000},
+		"image":   {InputTokens: 0, OutputTokens: 10_000},
+		"unknown": {InputTokens: 5_000_000},
+	})
+	if want := 0.30 + 0.50 + 0.30; math.Abs(cost-want) > 1e-9 {
+		t.Errorf("cost = %v, want %v", cost, want)
+	}
+
+	for _, bad := range []string{"0.30", "x:1", "1:-2"} {
+		if _, err := ParsePricing(map[string]string{"m": bad}); err == nil {
+			t.Errorf("ParsePricing(%q) succeeded", bad)
+		}
+	}
+}
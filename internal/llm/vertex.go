@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2/google"
+)
+
+// VertexAI selects Gemini on Vertex AI instead of the Gemini API. Calls authenticate with Application
+// Default Credentials: a service account key file named by GOOGLE_APPLICATION_CREDENTIALS, workload
+// identity on GKE, or the metadata server on GCE and Cloud Run. No API key is used.
+type VertexAI struct {
+	Project  string
+	Location string // region such as us-central1, or "global"
+}
+
+// vertexScope is the OAuth scope of Vertex AI calls.
+const vertexScope = "https://www.googleapis.com/auth/cloud-platform"
+
+func (v VertexAI) location() string {
+	if v.Location == "" {
+		return "global"
+	}
+	return v.Location
+}
+
+// host returns the Vertex AI API host of the location.
+func (v VertexAI) host() string {
+	if v.location() == "global" {
+		return "aiplatform.googleapis.com"
+	}
+	return v.location() + "-aiplatform.googleapis.com"
+}
+
+// path maps a Gemini API REST path (/v1beta/models/{model}:{method}) to the same method on the Vertex AI
+// publisher model (/v1/projects/{project}/locations/{location}/publishers/google/models/{model}:{method}).
+// Paths without a model are returned unchanged.
+func (v VertexAI) path(geminiPath string) string {
+	i := strings.Index(geminiPath, "/models/")
+	if i < 0 {
+		return geminiPath
+	}
+	return fmt.Sprintf("/v1/projects/%s/locations/%s/publishers/google%s", v.Project, v.location(), geminiPath[i:])
+}
+
+// vertexRoundTripper sends the Gemini API requests of generative-ai-go, which has no Vertex AI backend, to
+// Vertex AI; the request and response bodies of generateContent are the same on both.
+type vertexRoundTripper struct {
+	vertex VertexAI
+	next   http.RoundTripper
+}
+
+func (v *vertexRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req2 := req.Clone(req.Context())
+	req2.URL.Scheme = "https"
+	req2.URL.Host = v.vertex.host()
+	req2.URL.Path = v.vertex.path(req.URL.Path)
+	req2.URL.RawPath = ""
+	req2.Host = ""
+	return v.next.RoundTrip(req2)
+}
+
+// vertexHTTPClient returns an HTTP client authenticated with Application Default Credentials that routes
+// Gemini API requests to Vertex AI.
+func vertexHTTPClient(ctx context.Context, v VertexAI) (*http.Client, error) {
+	client, err := google.DefaultClient(ctx, vertexScope)
+	if err != nil {
+		return nil, fmt.Errorf("application default credentials: %w", err)
+	}
+	client.Transport = &vertexRoundTripper{vertex: v, next: client.Transport}
+	return client, nil
+}
@@ -0,0 +1,56 @@
+package llm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVertexAI_HostAndPath(t *testing.T) {
+	v := VertexAI{Project: "acme", Location: "europe-west4"}
+	if got := v.host(); got != "europe-west4-aiplatform.googleapis.com" {
+		t.Errorf("host() = %q", got)
+	}
+	got := v.path("/v1beta/models/gemini-3-pro-image-preview:generateContent")
+	want := "/v1/projects/acme/locations/europe-west4/publishers/google/models/gemini-3-pro-image-preview:generateContent"
+	if got != want {
+		t.Errorf("path() = %q, want %q", got, want)
+	}
+	if got := v.path("/v1beta/files"); got != "/v1beta/files" {
+		t.Errorf("path without model = %q, want unchanged", got)
+	}
+
+	global := VertexAI{Project: "acme"}
+	if got := global.host(); got != "aiplatform.googleapis.com" {
+		t.Errorf("global host() = %q", got)
+	}
+	if got := global.path("/v1beta/models/m:countTokens"); got != "/v1/projects/acme/locations/global/publishers/google/models/m:countTokens" {
+		t.Errorf("global path() = %q", got)
+	}
+}
+
+func TestVertexRoundTripper_RewritesRequest(t *testing.T) {
+	var seen *http.Request
+	rt := &vertexRoundTripper{
+		vertex: VertexAI{Project: "acme", Location: "us-central1"},
+		next: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			seen = req
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		}),
+	}
+	req := httptest.NewRequest(http.MethodPost, "https://generativelanguage.googleapis.com/v1beta/models/gemini-3-pro-image-preview:generateContent?alt=json", nil)
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	want := "https://us-central1-aiplatform.googleapis.com/v1/projects/acme/locations/us-central1/publishers/google/models/gemini-3-pro-image-preview:generateContent?alt=json"
+	if got := seen.URL.String(); got != want {
+		t.Errorf("rewritten URL = %q, want %q", got, want)
+	}
+	if req.URL.Host != "generativelanguage.googleapis.com" {
+		t.Errorf("original request was modified: %q", req.URL.Host)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
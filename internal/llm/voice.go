@@ -0,0 +1,77 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrVoiceCloningUnsupported is returned by providers that cannot create voices from a sample recording.
+var ErrVoiceCloningUnsupported = errors.New("voice cloning is not supported by this provider")
+
+// VoiceRegistration is a custom voice to register with a TTS provider.
+type VoiceRegistration struct {
+	Name         string
+	BaseVoice    string // provider voice the custom voice is based on
+	Style        string // speaking style instructions
+	ReferenceURL string // sample recording to clone the voice from
+}
+
+// VoiceProvider registers custom voices with a TTS provider. RegisterVoice returns the provider's ID of
+// the voice, which selects it in TTS calls, and provider-specific details to store with it.
+type VoiceProvider interface {
+	Name() string
+	RegisterVoice(ctx context.Context, reg *VoiceRegistration) (string, map[string]any, error)
+}
+
+// geminiVoices are the prebuilt voices of the Gemini TTS models
+var geminiVoices = []string{
+	"Achernar", "Achird", "Algenib", "Algieba", "Alnilam", "Aoede", "Autonoe", "Callirrhoe", "Charon",
+	"Despina", "Enceladus", "Erinome", "Fenrir", "Gacrux", "Iapetus", "Kore", "Laomedeia", "Leda", "Orus",
+	"Puck", "Pulcherrima", "Rasalgethi", "Sadachbia", "Sadaltager", "Schedar", "Sulafat", "Umbriel",
+	"Vindemiatrix", "Zephyr", "Zubenelgenubi",
+}
+
+// GeminiVoiceProvider registers custom voices for Gemini TTS: a prebuilt voice with an optional speaking
+// style. Gemini does not clone voices.
+type GeminiVoiceProvider struct{}
+
+// Name implements VoiceProvider.
+func (GeminiVoiceProvider) Name() string { return "gemini" }
+
+// RegisterVoice implements VoiceProvider; the voice ID is the prebuilt voice's name.
+func (GeminiVoiceProvider) RegisterVoice(_ context.Context, reg *VoiceRegistration) (string, map[string]any, error) {
+	if reg.ReferenceURL != "" {
+		return "", nil, ErrVoiceCloningUnsupported
+	}
+	for _, v := range geminiVoices {
+		if strings.EqualFold(v, reg.BaseVoice) {
+			return v, nil, nil
+		}
+	}
+	return "", nil, fmt.Errorf("unknown gemini voice %q", reg.BaseVoice)
+}
+
+type voiceKey struct{}
+
+// ttsVoiceOverride is a custom voice selected for a job's TTS calls
+type ttsVoiceOverride struct {
+	name  string
+	style string
+}
+
+// WithVoice returns a context that makes GenerateAudio speak with the Gemini voice name instead of the
+// configured one, adding the speaking style (if any) to the TTS instructions.
+func WithVoice(ctx context.Context, name, style string) context.Context {
+	if name == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, voiceKey{}, ttsVoiceOverride{name: name, style: style})
+}
+
+// voiceFromContext returns the voice set by WithVoice; ok is false when none is set.
+func voiceFromContext(ctx context.Context) (ttsVoiceOverride, bool) {
+	v, ok := ctx.Value(voiceKey{}).(ttsVoiceOverride)
+	return v, ok
+}
@@ -0,0 +1,22 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestGeminiVoiceProvider(t *testing.T) {
+	p := GeminiVoiceProvider{}
+	id, _, err := p.RegisterVoice(context.Background(), &VoiceRegistration{Name: "Narrator", BaseVoice: "puck"})
+	if err != nil || id != "Puck" {
+		t.Errorf("RegisterVoice(puck) = %q, %v; want Puck", id, err)
+	}
+	if _, _, err := p.RegisterVoice(context.Background(), &VoiceRegistration{BaseVoice: "Nobody"}); err == nil {
+		t.Error("expected error for unknown voice")
+	}
+	_, _, err = p.RegisterVoice(context.Background(), &VoiceRegistration{ReferenceURL: "https://example.com/a.wav"})
+	if !errors.Is(err, ErrVoiceCloningUnsupported) {
+		t.Errorf("err = %v, want ErrVoiceCloningUnsupported", err)
+	}
+}
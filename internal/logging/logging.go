@@ -0,0 +1,88 @@
+// Package logging configures the global zerolog logger of the services (api, worker, dispatcher, agents)
+// from the LOG_* settings: the level (LOG_LEVEL, overridden per binary by LOG_LEVELS), the
+// format of stderr (LOG_FORMAT: console for people, json for log pipelines) and optional sinks receiving
+// the same entries as JSON: a file (LOG_FILE) and syslog (LOG_SYSLOG). Every entry carries the binary as
+// component and its build as version, so entries of all binaries and releases can share a pipeline.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/buildinfo"
+)
+
+// Formats of stderr output.
+const (
+	FormatConsole = "console"
+	FormatJSON    = "json"
+)
+
+// Options are the LOG_* settings.
+type Options struct {
+	Level  string            // default level: trace, debug, info, warn, error (empty: info)
+	Levels map[string]string // levels by component, overriding Level
+	Format string            // FormatConsole (default) or FormatJSON
+	File   string            // path entries are appended to as JSON lines (empty: none)
+	Syslog string            // "local", or udp://host:port or tcp://host:port of a syslog server (empty: none)
+}
+
+// Setup points the global logger at the outputs of opts, for component. The returned function closes the
+// file and syslog sinks; call it when the binary exits.
+func Setup(component string, opts Options) (func(), error) {
+	levelName := opts.Level
+	if l, ok := opts.Levels[component]; ok {
+		levelName = l
+	}
+	level := zerolog.InfoLevel
+	if levelName != "" {
+		var err error
+		if level, err = zerolog.ParseLevel(levelName); err != nil || level == zerolog.NoLevel {
+			return nil, fmt.Errorf("invalid log level %q for %s", levelName, component)
+		}
+	}
+
+	var stderr io.Writer
+	switch opts.Format {
+	case "", FormatConsole:
+		stderr = zerolog.ConsoleWriter{Out: os.Stderr}
+	case FormatJSON:
+		stderr = os.Stderr
+	default:
+		return nil, fmt.Errorf("invalid log format %q: must be console or json", opts.Format)
+	}
+	writers := []io.Writer{stderr}
+	var closers []io.Closer
+	closeAll := func() {
+		for _, c := range closers {
+			c.Close()
+		}
+	}
+
+	if opts.File != "" {
+		f, err := os.OpenFile(opts.File, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		writers = append(writers, f)
+		closers = append(closers, f)
+	}
+	if opts.Syslog != "" {
+		w, conn, err := dialSyslog(opts.Syslog, "stories-"+component)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+		}
+		writers = append(writers, w)
+		closers = append(closers, conn)
+	}
+
+	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
+	zerolog.SetGlobalLevel(level)
+	log.Logger = zerolog.New(zerolog.MultiLevelWriter(writers...)).With().Timestamp().
+		Str("component", component).Str("version", buildinfo.Version).Logger()
+	return closeAll, nil
+}
@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func TestSetup(t *testing.T) {
+	defer func(l zerolog.Logger, level zerolog.Level) {
+		log.Logger = l
+		zerolog.SetGlobalLevel(level)
+	}(log.Logger, zerolog.GlobalLevel())
+
+	path := filepath.Join(t.TempDir(), "worker.log")
+	closeLogs, err := Setup("worker", Options{
+		Level: "info", Levels: map[string]string{"worker": "warn"}, Format: FormatJSON, File: path,
+	})
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	log.Info().Msg("filtered out")
+	log.Warn().Str("job_id", "j1").Msg("kept")
+	closeLogs()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("log file = %q, want the warning only", data)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("entry %q: %v", lines[0], err)
+	}
+	if entry["component"] != "worker" || entry["version"] == nil || entry["level"] != "warn" || entry["job_id"] != "j1" || entry["time"] == nil {
+		t.Errorf("entry = %v", entry)
+	}
+}
+
+func TestSetup_Invalid(t *testing.T) {
+	for name, opts := range map[string]Options{
+		"level":  {Level: "loud"},
+		"format": {Format: "xml"},
+		"syslog": {Syslog: "http://syslog:514"},
+	} {
+		if _, err := Setup("api", opts); err == nil {
+			t.Errorf("%s: Setup accepted %+v", name, opts)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package logging
+
+import (
+	"errors"
+	"io"
+)
+
+// dialSyslog fails: log/syslog is not available on this platform.
+func dialSyslog(addr, tag string) (io.Writer, io.Closer, error) {
+	return nil, nil, errors.New("syslog is not supported on this platform")
+}
@@ -0,0 +1,32 @@
+//go:build !windows && !plan9
+
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/syslog"
+	"net/url"
+
+	"github.com/rs/zerolog"
+)
+
+// dialSyslog connects to the local syslog daemon ("local") or a udp:// or tcp:// server, logging as tag
+// with the daemon facility at the entries' levels. It returns the writer and the connection.
+func dialSyslog(addr, tag string) (io.Writer, io.Closer, error) {
+	var w *syslog.Writer
+	var err error
+	if addr == "local" {
+		w, err = syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	} else {
+		u, parseErr := url.Parse(addr)
+		if parseErr != nil || (u.Scheme != "udp" && u.Scheme != "tcp") || u.Host == "" {
+			return nil, nil, fmt.Errorf("invalid syslog address %q: must be local, udp://host:port or tcp://host:port", addr)
+		}
+		w, err = syslog.Dial(u.Scheme, u.Host, syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return zerolog.SyslogLevelWriter(w), w, nil
+}
@@ -0,0 +1,92 @@
+// Package maintenance is the switch that pauses job intake for migrations and other maintenance. The state
+// is kept in the database (set through the admin API) and read by every API replica and worker: while it
+// is on the API refuses new jobs with 503 and Retry-After (reads keep working), the S3 ingestion and the
+// stuck job sweeper pause, and workers finish their in-flight jobs and stop consuming until it is off.
+package maintenance
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// Store is where the switch is kept (database.MaintenanceRepository).
+type Store interface {
+	Get(ctx context.Context) (*models.Maintenance, error)
+}
+
+// Switch reads the maintenance state, at most once per refresh interval. A nil *Switch is never on.
+type Switch struct {
+	store   Store
+	refresh time.Duration
+
+	mu       sync.Mutex
+	loadedAt time.Time
+	state    models.Maintenance
+}
+
+// minRefresh bounds how often Wait polls the store.
+const minRefresh = 10 * time.Millisecond
+
+// New creates a Switch reading store every refresh interval.
+func New(store Store, refresh time.Duration) *Switch {
+	if refresh < minRefresh {
+		refresh = minRefresh
+	}
+	return &Switch{store: store, refresh: refresh}
+}
+
+// State returns the maintenance state. A failed read keeps the previous state and is retried at the next
+// interval, so a database outage neither starts nor ends maintenance.
+func (s *Switch) State(ctx context.Context) models.Maintenance {
+	if s == nil {
+		return models.Maintenance{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loadedAt.IsZero() && time.Since(s.loadedAt) < s.refresh {
+		return s.state
+	}
+	s.loadedAt = time.Now()
+	state, err := s.store.Get(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to read maintenance mode; keeping the previous state")
+		return s.state
+	}
+	if state.Enabled != s.state.Enabled {
+		log.Info().Bool("maintenance", state.Enabled).Str("reason", state.Reason).Msg("Maintenance mode changed")
+	}
+	s.state = *state
+	return s.state
+}
+
+// Enabled reports whether maintenance mode is on.
+func (s *Switch) Enabled(ctx context.Context) bool {
+	return s.State(ctx).Enabled
+}
+
+// Wait blocks while maintenance mode is on, checking it every refresh interval. It returns ctx.Err() when
+// ctx is done first.
+func (s *Switch) Wait(ctx context.Context) error {
+	for s.Enabled(ctx) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.refresh):
+		}
+	}
+	return nil
+}
+
+// Invalidate makes the next check read the store, so admin changes apply at once in this process.
+func (s *Switch) Invalidate() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
@@ -0,0 +1,70 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+type fakeStore struct {
+	state models.Maintenance
+	err   error
+	reads int
+}
+
+func (s *fakeStore) Get(context.Context) (*models.Maintenance, error) {
+	s.reads++
+	if s.err != nil {
+		return nil, s.err
+	}
+	state := s.state
+	return &state, nil
+}
+
+func TestSwitch(t *testing.T) {
+	ctx := context.Background()
+	var off *Switch
+	if off.Enabled(ctx) {
+		t.Error("nil Switch is on")
+	}
+
+	store := &fakeStore{state: models.Maintenance{Enabled: true, RetryAfterSeconds: 600}}
+	s := New(store, time.Hour)
+	if !s.Enabled(ctx) || s.State(ctx).RetryAfterSeconds != 600 {
+		t.Errorf("State = %+v, want enabled with retry after 600s", s.State(ctx))
+	}
+	if store.reads != 1 {
+		t.Errorf("store read %d times, want once per refresh interval", store.reads)
+	}
+
+	// A failed read keeps maintenance on
+	store.err = errors.New("db down")
+	s.Invalidate()
+	if !s.Enabled(ctx) {
+		t.Error("maintenance ended on a failed read")
+	}
+}
+
+func TestWait(t *testing.T) {
+	store := &fakeStore{state: models.Maintenance{Enabled: true}}
+	s := New(store, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if err := s.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait while on = %v, want deadline exceeded", err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		s.mu.Lock()
+		store.state.Enabled = false
+		s.mu.Unlock()
+	}()
+	if err := s.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait = %v, want nil once maintenance ends", err)
+	}
+}
@@ -206,3 +206,13 @@ func TestMarkdownToHTML_Links(t *testing.T) {
 		t.Errorf("MarkdownToHTML(%q) = %v, want to contain %v", input, got, want)
 	}
 }
+
+func BenchmarkMarkdownToHTML(b *testing.B) {
+	text := strings.Repeat("## Heading\n\nSome **bold** and _italic_ text with `code` and a [link](https://example.com).\n\n"+
+		"1. First\n2. Second\n   - Nested\n\n| A | B |\n|---|---|\n| 1 | 2 |\n\n", 100)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(text)))
+	for i := 0; i < b.N; i++ {
+		MarkdownToHTML(text)
+	}
+}
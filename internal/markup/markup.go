@@ -1,13 +1,17 @@
 package markup
 
 import (
+	"fmt"
 	"html"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
 // ToHTML converts job output markup to basic HTML.
-// Markup format: [[SOURCE file_id=... filename="..."]], [[SEGMENT id=...]], [[IMAGE asset_id=...]], [[AUDIO asset_id=...]].
+// Markup format: [[SOURCE file_id=... filename="..."]], [[SEGMENT id=...]], [[IMAGE asset_id=...]], [[AUDIO asset_id=...]],
+// [[CITATION file_id=... pages=...]]. Citations become numbered footnotes listed after the segments, then
+// [[DISCLAIMER jurisdiction=... version=...]] blocks (compliance text of financial jobs) are shown last.
 // jobID is used to build asset URLs: /view/asset/{id}?job_id={jobID}
 func ToHTML(markup, jobID string) string {
 	if markup == "" {
@@ -16,12 +20,22 @@ func ToHTML(markup, jobID string) string {
 	var out strings.Builder
 	jobID = html.EscapeString(jobID)
 
-	// Skip SOURCE blocks (excluded from view output)
+	var disclaimers []string
+	markup = disclaimerRe.ReplaceAllStringFunc(markup, func(block string) string {
+		disclaimers = append(disclaimers, strings.TrimSpace(disclaimerRe.FindStringSubmatch(block)[2]))
+		return ""
+	})
+
+	// Skip SOURCE blocks (excluded from view output); their filenames label citation footnotes
 	// Pattern uses (?:[^"\\]|\\.)* to handle escaped quotes in filenames (e.g. filename="test\"file.pdf")
-	sourceRe := regexp.MustCompile(`(?s)\[\[SOURCE file_id=[^ \]]+\s+filename="(?:[^"\\]|\\.)*"\]\](.*?)\[\[/SOURCE\]\]`)
+	sourceRe := regexp.MustCompile(`(?s)\[\[SOURCE file_id=([^ \]]+)\s+filename=("(?:[^"\\]|\\.)*")\]\](.*?)\[\[/SOURCE\]\]`)
+	notes := &footnotes{filenames: map[string]string{}}
 	idx := 0
 	for _, m := range sourceRe.FindAllStringSubmatchIndex(markup, -1) {
 		out.WriteString(html.EscapeString(markup[idx:m[0]]))
+		if filename, err := strconv.Unquote(markup[m[4]:m[5]]); err == nil {
+			notes.filenames[markup[m[2]:m[3]]] = filename
+		}
 		idx = m[1]
 	}
 
@@ -36,7 +50,7 @@ func ToHTML(markup, jobID string) string {
 		out.WriteString(`<div class="segment" data-segment-id="`)
 		out.WriteString(segID)
 		out.WriteString(`">`)
-		out.WriteString(segmentInnerToHTML(inner, jobID))
+		out.WriteString(segmentInnerToHTML(inner, jobID, notes))
 		out.WriteString(`</div>`)
 		idx = m[1]
 	}
@@ -46,16 +60,66 @@ func ToHTML(markup, jobID string) string {
 		out.WriteString(html.EscapeString(markup[idx:]))
 	}
 
+	notes.writeList(&out)
+	for _, text := range disclaimers {
+		out.WriteString(`<aside class="disclaimer">`)
+		out.WriteString(strings.ReplaceAll(html.EscapeString(text), "\n", "<br>"))
+		out.WriteString(`</aside>`)
+	}
 	return out.String()
 }
 
-func segmentInnerToHTML(inner, jobID string) string {
+// disclaimerRe matches [[DISCLAIMER jurisdiction=... version=...]] blocks and their text.
+var disclaimerRe = regexp.MustCompile(`(?s)\[\[DISCLAIMER ([^\]]*)\]\]\n?(.*?)\[\[/DISCLAIMER\]\]\n*`)
+
+// citationRe matches [[CITATION file_id=... pages=3-5,8]] lines inside a segment.
+var citationRe = regexp.MustCompile(`\[\[CITATION file_id=([^ \]]+) pages=([0-9,-]+)\]\]\n?`)
+
+// footnotes numbers the citations of a document in order of appearance.
+type footnotes struct {
+	filenames map[string]string // file ID -> filename, from SOURCE blocks
+	entries   []string          // footnote texts (escaped HTML), numbered from 1
+}
+
+// add registers a citation and returns its footnote number.
+func (f *footnotes) add(fileID, pages string) int {
+	name := f.filenames[fileID]
+	if name == "" {
+		name = "File " + fileID
+	}
+	label := "p. "
+	if strings.ContainsAny(pages, ",-") {
+		label = "pp. "
+	}
+	pages = strings.ReplaceAll(strings.ReplaceAll(pages, "-", "\u2013"), ",", ", ")
+	f.entries = append(f.entries, html.EscapeString(name+", "+label+pages))
+	return len(f.entries)
+}
+
+// writeList writes the footnote list, if any citation was added.
+func (f *footnotes) writeList(b *strings.Builder) {
+	if len(f.entries) == 0 {
+		return
+	}
+	b.WriteString(`<ol class="footnotes">`)
+	for i, entry := range f.entries {
+		fmt.Fprintf(b, `<li id="cite-%d">%s</li>`, i+1, entry)
+	}
+	b.WriteString(`</ol>`)
+}
+
+func segmentInnerToHTML(inner, jobID string, notes *footnotes) string {
 	audioRe := regexp.MustCompile(`\[\[AUDIO asset_id=([a-fA-F0-9-]+)\]\]`)
 	imageRe := regexp.MustCompile(`\[\[IMAGE asset_id=([a-fA-F0-9-]+)\]\]`)
 
-	// Collect audio IDs, image IDs, and strip both to get segment text only
+	// Collect audio IDs, image IDs and citations, and strip them to get segment text only
 	var audioIDs, imageIDs []string
-	textOnly := audioRe.ReplaceAllString(inner, "")
+	var citations []int
+	for _, sub := range citationRe.FindAllStringSubmatch(inner, -1) {
+		citations = append(citations, notes.add(sub[1], sub[2]))
+	}
+	textOnly := citationRe.ReplaceAllString(inner, "")
+	textOnly = audioRe.ReplaceAllString(textOnly, "")
 	textOnly = imageRe.ReplaceAllString(textOnly, "")
 	// Collect in order (audios first, then images) for deterministic output
 	for _, sub := range audioRe.FindAllStringSubmatch(inner, -1) {
@@ -79,8 +143,15 @@ func segmentInnerToHTML(inner, jobID string) string {
 		b.WriteString(jobID)
 		b.WriteString(`"></audio>`)
 	}
-	// 2. Segment text (title + body)
+	// 2. Segment text (title + body), then its footnote references
 	emitSegmentText(&b, textOnly)
+	if len(citations) > 0 {
+		b.WriteString(`<p class="segment-citations">`)
+		for _, n := range citations {
+			fmt.Fprintf(&b, `<sup><a href="#cite-%d">[%d]</a></sup>`, n, n)
+		}
+		b.WriteString(`</p>`)
+	}
 	// 3. Image after segment
 	for _, id := range imageIDs {
 		id = html.EscapeString(id)
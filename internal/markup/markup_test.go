@@ -1,6 +1,7 @@
 package markup
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -201,3 +202,90 @@ func TestMarkdownToHTML_NoDoubleEscaping(t *testing.T) {
 		})
 	}
 }
+
+func TestToHTML_CitationFootnotes(t *testing.T) {
+	markup := `[[SOURCE file_id=f1 filename="report.pdf"]]
+Extracted.
+[[/SOURCE]]
+
+[[SEGMENT id=seg-1]]
+First part
+[[CITATION file_id=f1 pages=1-2]]
+[[AUDIO asset_id=aa-1]]
+[[/SEGMENT]]
+
+[[SEGMENT id=seg-2]]
+Second part
+[[CITATION file_id=f1 pages=3]]
+[[CITATION file_id=f2 pages=1,4-5]]
+[[/SEGMENT]]
+`
+	result := ToHTML(markup, "job-123")
+
+	if strings.Contains(result, "[[CITATION") {
+		t.Errorf("CITATION markers should be rendered, but found in output:\n%s", result)
+	}
+	for _, want := range []string{
+		`<sup><a href="#cite-1">[1]</a></sup>`,
+		`<sup><a href="#cite-2">[2]</a></sup><sup><a href="#cite-3">[3]</a></sup>`,
+		`<li id="cite-1">report.pdf, pp. 1–2</li>`,
+		`<li id="cite-2">report.pdf, p. 3</li>`,
+		`<li id="cite-3">File f2, pp. 1, 4–5</li>`,
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected %q in output:\n%s", want, result)
+		}
+	}
+	if !strings.HasSuffix(result, "</ol>") {
+		t.Errorf("footnotes should follow the segments:\n%s", result)
+	}
+}
+
+// benchmarkMarkup returns job output markup shaped like the pipeline's: a SOURCE block, then segments with
+// a title, markdown text, a citation, an image and an audio reference.
+func benchmarkMarkup(segments int) string {
+	var b strings.Builder
+	b.WriteString("[[SOURCE file_id=file-1 filename=\"notes.pdf\"]]\nExtracted text of the uploaded file.\n[[/SOURCE]]\n\n")
+	for i := 0; i < segments; i++ {
+		fmt.Fprintf(&b, "[[SEGMENT id=seg-%d]]\n# Part %d\n\n", i, i+1)
+		b.WriteString("The **Sun** is a star at the _center_ of the Solar System. Planets <orbit> it & so do comets.\n\n")
+		b.WriteString("- Mercury\n- Venus\n  - Earth's neighbour\n\n| Planet | Moons |\n|---|---|\n| Earth | 1 |\n| Mars | 2 |\n\n")
+		fmt.Fprintf(&b, "[[CITATION file_id=file-1 pages=%d-%d]]\n", i+1, i+2)
+		fmt.Fprintf(&b, "[[IMAGE asset_id=img-%d]]\n[[AUDIO asset_id=aud-%d]]\n[[/SEGMENT]]\n\n", i, i)
+	}
+	return b.String()
+}
+
+func BenchmarkToHTML(b *testing.B) {
+	for _, segments := range []int{5, 50, 500} {
+		markup := benchmarkMarkup(segments)
+		b.Run(fmt.Sprintf("segments=%d", segments), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(markup)))
+			for i := 0; i < b.N; i++ {
+				ToHTML(markup, "job-123")
+			}
+		})
+	}
+}
+
+func TestToHTML_Disclaimer(t *testing.T) {
+	markup := `[[SEGMENT id=seg-1]]
+Rates rose.
+[[CITATION file_id=f1 pages=2]]
+[[/SEGMENT]]
+
+[[DISCLAIMER jurisdiction=US version=3]]
+Not investment advice.
+Past performance <is not> a guarantee.
+[[/DISCLAIMER]]
+`
+	result := ToHTML(markup, "job-123")
+	if strings.Contains(result, "DISCLAIMER") {
+		t.Errorf("DISCLAIMER tags should be rendered, but found in output:\n%s", result)
+	}
+	want := `</ol><aside class="disclaimer">Not investment advice.<br>Past performance &lt;is not&gt; a guarantee.</aside>`
+	if !strings.HasSuffix(result, want) {
+		t.Errorf("expected the disclaimer after the footnotes:\n%s", result)
+	}
+}
@@ -0,0 +1,107 @@
+package markup
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// Paces of the estimated times in words per minute: silent reading and narration
+const (
+	ReadWordsPerMinute   = 238
+	ListenWordsPerMinute = 150
+)
+
+var (
+	sourceBlockRe = regexp.MustCompile(`(?s)\[\[SOURCE [^\]]*\]\].*?\[\[/SOURCE\]\]`)
+	segmentRe     = regexp.MustCompile(`(?s)\[\[SEGMENT id=([^ \]]+)\]\](.*?)\[\[/SEGMENT\]\]`)
+	inlineTagRe   = regexp.MustCompile(`\[\[(?:IMAGE|AUDIO|CITATION) [^\]]*\]\]`)
+	mdHeaderRe    = regexp.MustCompile(`(?m)^#{1,6} `)
+	mdLinkRe      = regexp.MustCompile(`\[([^\]]+)\]\([^)]+\)`)
+	mdMarkerRe    = regexp.MustCompile("\\*\\*|__|~~|`")
+)
+
+// SegmentText is the plain text of one segment of a markup document.
+type SegmentText struct {
+	ID   string
+	Text string
+}
+
+// Segments returns the plain text (title and body, see PlainText) of each segment in markup, in order.
+func Segments(markup string) []SegmentText {
+	var out []SegmentText
+	for _, m := range segmentRe.FindAllStringSubmatch(markup, -1) {
+		out = append(out, SegmentText{ID: m[1], Text: plainSegmentText(m[2])})
+	}
+	return out
+}
+
+// PlainText returns the text of markup's segments without tags or markdown syntax, segments separated by
+// a blank line. SOURCE blocks (the extracted files) are left out.
+func PlainText(markup string) string {
+	segments := Segments(sourceBlockRe.ReplaceAllString(markup, ""))
+	texts := make([]string, 0, len(segments))
+	for _, s := range segments {
+		if s.Text != "" {
+			texts = append(texts, s.Text)
+		}
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// plainSegmentText strips the asset and citation tags and the markdown syntax from a segment's content.
+func plainSegmentText(inner string) string {
+	text := inlineTagRe.ReplaceAllString(inner, "")
+	text = mdHeaderRe.ReplaceAllString(text, "")
+	text = mdLinkRe.ReplaceAllString(text, "$1")
+	text = mdMarkerRe.ReplaceAllString(text, "")
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// CountWords returns the number of words in text: whitespace-separated tokens with a letter or digit.
+func CountWords(text string) int {
+	n := 0
+	for _, f := range strings.Fields(text) {
+		if strings.IndexFunc(f, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// Stats returns the word and character counts of plain text (characters with runs of whitespace counted
+// once) and its reading and listening times, rounded up to whole seconds.
+func Stats(text string) models.TextStats {
+	words := CountWords(text)
+	return models.TextStats{
+		Words:         words,
+		Characters:    utf8.RuneCountInString(strings.Join(strings.Fields(text), " ")),
+		ReadSeconds:   (words*60 + ReadWordsPerMinute - 1) / ReadWordsPerMinute,
+		ListenSeconds: (words*60 + ListenWordsPerMinute - 1) / ListenWordsPerMinute,
+	}
+}
+
+// DocumentStats returns the stats of each segment in markup and of all of them together.
+func DocumentStats(markup string) *models.JobTextStats {
+	return SegmentStats(Segments(sourceBlockRe.ReplaceAllString(markup, "")))
+}
+
+// SegmentStats returns the stats of each segment's plain text and of all of them together, e.g. for a
+// job whose markup does not exist yet.
+func SegmentStats(segments []SegmentText) *models.JobTextStats {
+	texts := make([]string, len(segments))
+	out := &models.JobTextStats{Segments: make([]models.SegmentTextStats, len(segments))}
+	for i, s := range segments {
+		texts[i] = s.Text
+		out.Segments[i] = models.SegmentTextStats{SegmentID: s.ID, TextStats: Stats(s.Text)}
+	}
+	out.TextStats = Stats(strings.Join(texts, "\n\n"))
+	return out
+}
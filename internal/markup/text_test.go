@@ -0,0 +1,55 @@
+package markup
+
+import "testing"
+
+const statsMarkup = `[[SOURCE file_id=f1 filename="a.pdf"]]
+Extracted words that are not counted.
+[[/SOURCE]]
+
+[[SEGMENT id=s1]]
+# The **Intro**
+
+Read [the docs](https://example.com) — twice.
+[[CITATION file_id=f1 pages=2]]
+[[AUDIO asset_id=9b2c1f3e-0000-4000-8000-000000000002]]
+[[/SEGMENT]]
+
+[[SEGMENT id=s2]]
+# Part 2
+
+Use ` + "`go test`" + ` now.
+[[/SEGMENT]]
+`
+
+func TestPlainText(t *testing.T) {
+	want := "The Intro\n\nRead the docs — twice.\n\nPart 2\n\nUse go test now."
+	if got := PlainText(statsMarkup); got != want {
+		t.Errorf("PlainText = %q, want %q", got, want)
+	}
+	if got := PlainText(""); got != "" {
+		t.Errorf("PlainText(empty) = %q", got)
+	}
+}
+
+func TestCountWords(t *testing.T) {
+	if got := CountWords("Read the docs — twice. 42 - ok"); got != 6 {
+		t.Errorf("CountWords = %d, want 6 (dashes are not words)", got)
+	}
+}
+
+func TestDocumentStats(t *testing.T) {
+	stats := DocumentStats(statsMarkup)
+	if len(stats.Segments) != 2 || stats.Segments[0].SegmentID != "s1" || stats.Segments[1].SegmentID != "s2" {
+		t.Fatalf("segments = %+v", stats.Segments)
+	}
+	if s := stats.Segments[0]; s.Words != 6 || s.Characters != 32 {
+		t.Errorf("segment 1 = %+v", s)
+	}
+	if stats.Words != 12 {
+		t.Errorf("words = %d, want 12", stats.Words)
+	}
+	// 12 words: 12*60/238 = 3.03 -> 4 s reading, 12*60/150 = 4.8 -> 5 s listening
+	if stats.ReadSeconds != 4 || stats.ListenSeconds != 5 {
+		t.Errorf("times = %d/%d, want 4/5", stats.ReadSeconds, stats.ListenSeconds)
+	}
+}
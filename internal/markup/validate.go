@@ -0,0 +1,116 @@
+package markup
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// tagRe matches the markup tags (see ToHTML). Other [[...]] text, e.g. wiki links in segment text, is
+// left alone.
+var tagRe = regexp.MustCompile(`\[\[(/?)(SOURCE|SEGMENT|DISCLAIMER|IMAGE|AUDIO|CITATION)\b([^\]]*)\]\]`)
+
+// Attributes each tag must have, matched against the text after its name
+var tagAttrRes = map[string]*regexp.Regexp{
+	"SOURCE":     regexp.MustCompile(`^ file_id=[^ \]]+\s+filename="(?:[^"\\]|\\.)*"$`),
+	"SEGMENT":    regexp.MustCompile(`^ id=[^ \]]+$`),
+	"DISCLAIMER": regexp.MustCompile(`^ jurisdiction=[A-Z0-9-]+ version=[0-9]+$`),
+	"IMAGE":      regexp.MustCompile(`^ asset_id=([a-fA-F0-9-]+)$`),
+	"AUDIO":      regexp.MustCompile(`^ asset_id=([a-fA-F0-9-]+)$`),
+	"CITATION":   regexp.MustCompile(`^ file_id=[^ \]]+ pages=[0-9,-]+$`),
+}
+
+// Issue is one problem found by Validate, at a 1-based line of the markup.
+type Issue struct {
+	Line    int
+	Message string
+}
+
+// ValidationError is returned by Validate with every issue found, in document order.
+type ValidationError struct {
+	Issues []Issue
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Issues))
+	for i, issue := range e.Issues {
+		if i == 5 {
+			parts = append(parts, fmt.Sprintf("and %d more", len(e.Issues)-i))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("line %d: %s", issue.Line, issue.Message))
+	}
+	return "invalid markup: " + strings.Join(parts, "; ")
+}
+
+// Validate checks markup before it is saved or rendered: SOURCE, SEGMENT and DISCLAIMER blocks are
+// closed, not nested and have unique segment IDs; IMAGE, AUDIO and CITATION tags are inside a SEGMENT
+// and well formed. When assetIDs is non-nil, IMAGE and AUDIO must reference one of them. Text inside
+// SOURCE and DISCLAIMER blocks (extracted file content, compliance text) is not checked. Returns a *ValidationError listing the issues.
+func Validate(markup string, assetIDs map[string]bool) error {
+	var issues []Issue
+	add := func(pos int, format string, args ...any) {
+		issues = append(issues, Issue{Line: strings.Count(markup[:pos], "\n") + 1, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var open string // SOURCE, SEGMENT or DISCLAIMER block being read
+	openPos := 0
+	segmentIDs := make(map[string]bool)
+	for _, m := range tagRe.FindAllStringSubmatchIndex(markup, -1) {
+		closing := m[3] > m[2]
+		name := markup[m[4]:m[5]]
+		attrs := markup[m[6]:m[7]]
+		if (open == "SOURCE" || open == "DISCLAIMER") && !(closing && name == open) {
+			continue
+		}
+
+		switch {
+		case closing && isBlock(name):
+			if open != name {
+				add(m[0], "[[/%s]] without an open [[%s]]", name, name)
+				continue
+			}
+			open = ""
+		case closing:
+			add(m[0], "[[%s]] has no closing tag", name)
+		case isBlock(name):
+			if open != "" {
+				add(m[0], "[[%s]] inside [[%s]]", name, open)
+				continue
+			}
+			open, openPos = name, m[0]
+			if !tagAttrRes[name].MatchString(attrs) {
+				add(m[0], "malformed [[%s%s]]", name, attrs)
+			} else if name == "SEGMENT" {
+				id := strings.TrimPrefix(attrs, " id=")
+				if segmentIDs[id] {
+					add(m[0], "duplicate segment id %s", id)
+				}
+				segmentIDs[id] = true
+			}
+		default:
+			if open != "SEGMENT" {
+				add(m[0], "[[%s]] outside a [[SEGMENT]]", name)
+			}
+			sub := tagAttrRes[name].FindStringSubmatch(attrs)
+			switch {
+			case sub == nil:
+				add(m[0], "malformed [[%s%s]]", name, attrs)
+			case name != "CITATION" && assetIDs != nil && !assetIDs[strings.ToLower(sub[1])]:
+				add(m[0], "[[%s]] references unknown asset %s", name, sub[1])
+			}
+		}
+	}
+	if open != "" {
+		add(openPos, "unclosed [[%s]]", open)
+	}
+	if len(issues) > 0 {
+		return &ValidationError{Issues: issues}
+	}
+	return nil
+}
+
+// isBlock reports whether a tag opens a top-level block rather than being inline in a segment.
+func isBlock(name string) bool {
+	return name == "SOURCE" || name == "SEGMENT" || name == "DISCLAIMER"
+}
@@ -0,0 +1,83 @@
+package markup
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const (
+	imageID = "9b2c1f3e-0000-4000-8000-000000000001"
+	audioID = "9b2c1f3e-0000-4000-8000-000000000002"
+)
+
+func TestValidate(t *testing.T) {
+	valid := `[[SOURCE file_id=f1 filename="notes \"v2\".pdf"]]
+Extracted text with a stray [[SEGMENT id=x]] tag.
+[[/SOURCE]]
+
+[[SEGMENT id=s1]]
+# Intro
+
+See [[Wiki Page]] for more.
+
+[[CITATION file_id=f1 pages=3-5,8]]
+[[AUDIO asset_id=` + audioID + `]]
+[[IMAGE asset_id=` + imageID + `]]
+[[/SEGMENT]]
+
+[[DISCLAIMER jurisdiction=US version=2]]
+Not investment advice. [[IMAGE]] in compliance text is not checked.
+[[/DISCLAIMER]]
+`
+	assets := map[string]bool{imageID: true, audioID: true}
+	if err := Validate(valid, assets); err != nil {
+		t.Errorf("Validate(valid): %v", err)
+	}
+	if err := Validate("", assets); err != nil {
+		t.Errorf("Validate(empty): %v", err)
+	}
+
+	tests := []struct {
+		name, markup, want string
+		line               int
+	}{
+		{"unclosed segment", "[[SEGMENT id=s1]]\ntext", "unclosed [[SEGMENT]]", 1},
+		{"stray close", "text\n[[/SEGMENT]]", "without an open", 2},
+		{"nested", "[[SEGMENT id=s1]]\n[[SEGMENT id=s2]]\n[[/SEGMENT]]", "[[SEGMENT]] inside [[SEGMENT]]", 2},
+		{"source in segment", "[[SEGMENT id=s1]]\n[[SOURCE file_id=f filename=\"a\"]]\n[[/SEGMENT]]", "inside", 2},
+		{"asset outside segment", "[[IMAGE asset_id=" + imageID + "]]", "outside a [[SEGMENT]]", 1},
+		{"unknown asset", "[[SEGMENT id=s1]]\n[[AUDIO asset_id=abc]]\n[[/SEGMENT]]", "unknown asset abc", 2},
+		{"malformed", "[[SEGMENT id=s1]]\n[[CITATION file_id=f]]\n[[/SEGMENT]]", "malformed", 2},
+		{"closing inline", "[[SEGMENT id=s1]]\n[[/IMAGE]]\n[[/SEGMENT]]", "no closing tag", 2},
+		{"duplicate id", "[[SEGMENT id=s1]][[/SEGMENT]]\n[[SEGMENT id=s1]][[/SEGMENT]]", "duplicate segment id", 2},
+		{"unclosed source", "[[SOURCE file_id=f filename=\"a\"]]\n[[SEGMENT id=s1]][[/SEGMENT]]", "unclosed [[SOURCE]]", 1},
+		{"disclaimer in segment", "[[SEGMENT id=s1]]\n[[DISCLAIMER jurisdiction=US version=1]]\n[[/SEGMENT]]", "inside", 2},
+		{"malformed disclaimer", "[[DISCLAIMER jurisdiction=US]]\nText\n[[/DISCLAIMER]]", "malformed", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.markup, assets)
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Validate = %v, want *ValidationError", err)
+			}
+			if len(verr.Issues) != 1 || !strings.Contains(verr.Issues[0].Message, tt.want) || verr.Issues[0].Line != tt.line {
+				t.Errorf("issues = %+v, want one at line %d containing %q", verr.Issues, tt.line, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateWithoutAssetIDs(t *testing.T) {
+	if err := Validate("[[SEGMENT id=s1]]\n[[AUDIO asset_id=abc]]\n[[/SEGMENT]]", nil); err != nil {
+		t.Errorf("Validate without asset IDs: %v", err)
+	}
+}
+
+func TestValidationErrorTruncates(t *testing.T) {
+	err := Validate(strings.Repeat("[[/SEGMENT]]\n", 8), nil)
+	if err == nil || !strings.Contains(err.Error(), "and 3 more") {
+		t.Errorf("err = %v, want 5 issues and a count of the rest", err)
+	}
+}
@@ -0,0 +1,146 @@
+// Package mcpclient calls tools of external MCP servers (JSON-RPC 2.0 tools/call over HTTP), the client
+// side of what internal/mcpserver serves. Jobs use it to call a customer's tool per segment.
+package mcpclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxResponseBytes limits how much of a tool response is read.
+const maxResponseBytes = 1 << 20
+
+// Client calls MCP tools over HTTP.
+type Client struct {
+	httpCli *http.Client
+}
+
+// NewClient returns a client sending its calls with httpCli. Server URLs come from users, so the
+// worker passes an egress.Policy client, which refuses internal addresses and does not follow redirects.
+func NewClient(httpCli *http.Client) *Client {
+	return &Client{httpCli: httpCli}
+}
+
+// Result is the outcome of a tool call: the text of its content items (joined by newlines) and, when
+// that text is JSON, the decoded value.
+type Result struct {
+	Text string
+	JSON any // nil when Text is not JSON
+}
+
+// Value returns the decoded JSON of the result, or its text.
+func (r *Result) Value() any {
+	if r.JSON != nil {
+		return r.JSON
+	}
+	return r.Text
+}
+
+type callParams struct {
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+type rpcResponse struct {
+	Result *struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		IsError bool `json:"isError"`
+	} `json:"result"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// CallTool calls tool on the MCP server at serverURL with args. A non-empty token is sent as a bearer
+// token. Tool errors (isError results), JSON-RPC errors and non-2xx responses are returned as errors.
+// Servers answering with an event stream (streamable HTTP) are read up to their first message.
+func (c *Client) CallTool(ctx context.Context, serverURL, token, tool string, args map[string]any) (*Result, error) {
+	body, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  callParams{Name: tool, Arguments: args},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal MCP request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, serverURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set("User-Agent", "Stories-MCP-Client/1.0")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := c.httpCli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("read MCP response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("MCP request failed (HTTP %d)", resp.StatusCode)
+	}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		raw = firstEventData(raw)
+	}
+
+	var rpcResp rpcResponse
+	if err := json.Unmarshal(raw, &rpcResp); err != nil {
+		return nil, fmt.Errorf("decode MCP response: %w", err)
+	}
+	if rpcResp.Error != nil {
+		return nil, fmt.Errorf("MCP error %d: %s", rpcResp.Error.Code, rpcResp.Error.Message)
+	}
+	if rpcResp.Result == nil {
+		return nil, fmt.Errorf("MCP response has no result")
+	}
+	var texts []string
+	for _, item := range rpcResp.Result.Content {
+		if item.Type == "text" {
+			texts = append(texts, item.Text)
+		}
+	}
+	result := &Result{Text: strings.Join(texts, "\n")}
+	if rpcResp.Result.IsError {
+		return nil, fmt.Errorf("tool %s failed: %s", tool, result.Text)
+	}
+	var v any
+	if json.Unmarshal([]byte(result.Text), &v) == nil {
+		result.JSON = v
+	}
+	return result, nil
+}
+
+// firstEventData returns the data of the first event in a text/event-stream body.
+func firstEventData(raw []byte) []byte {
+	var data []string
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(make([]byte, 64*1024), maxResponseBytes)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" && len(data) > 0 {
+			break
+		}
+		if d, ok := strings.CutPrefix(line, "data:"); ok {
+			data = append(data, strings.TrimPrefix(d, " "))
+		}
+	}
+	return []byte(strings.Join(data, "\n"))
+}
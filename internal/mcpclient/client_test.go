@@ -0,0 +1,107 @@
+package mcpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/snappy-loop/stories/internal/egress"
+)
+
+// toolServer answers tools/call with handle's result for the call's arguments.
+func toolServer(t *testing.T, handle func(args map[string]any) any) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			Method string `json:"method"`
+			Params struct {
+				Name      string         `json:"name"`
+				Arguments map[string]any `json:"arguments"`
+			} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Method != "tools/call" {
+			t.Errorf("bad request: %v %s", err, req.Method)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": 1, "result": handle(req.Params.Arguments)})
+	}))
+}
+
+func TestCallTool(t *testing.T) {
+	srv := toolServer(t, func(args map[string]any) any {
+		return map[string]any{"content": []map[string]any{
+			{"type": "text", "text": fmt.Sprintf(`{"flagged": ["%s"]}`, args["text"])},
+		}}
+	})
+	defer srv.Close()
+
+	c := NewClient(&http.Client{Timeout: 5 * time.Second})
+	res, err := c.CallTool(context.Background(), srv.URL, "s3cret", "check_terminology", map[string]any{"text": "APR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, ok := res.Value().(map[string]any)
+	if !ok || fmt.Sprint(v["flagged"]) != "[APR]" {
+		t.Errorf("value = %#v", res.Value())
+	}
+
+	if _, err := c.CallTool(context.Background(), srv.URL, "wrong", "check_terminology", nil); err == nil || !strings.Contains(err.Error(), "HTTP 401") {
+		t.Errorf("bad token: %v", err)
+	}
+}
+
+func TestCallTool_ToolError(t *testing.T) {
+	srv := toolServer(t, func(args map[string]any) any {
+		return map[string]any{"content": []map[string]any{{"type": "text", "text": "glossary not found"}}, "isError": true}
+	})
+	defer srv.Close()
+
+	_, err := NewClient(&http.Client{Timeout: 5 * time.Second}).CallTool(context.Background(), srv.URL, "s3cret", "check_terminology", nil)
+	if err == nil || !strings.Contains(err.Error(), "glossary not found") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestCallTool_EventStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":1,\"result\":{\"content\":[{\"type\":\"text\",\"text\":\"ok\"}]}}\n\n")
+	}))
+	defer srv.Close()
+
+	res, err := NewClient(&http.Client{Timeout: 5 * time.Second}).CallTool(context.Background(), srv.URL, "", "ping", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Value() != "ok" {
+		t.Errorf("value = %#v", res.Value())
+	}
+}
+
+func TestCallTool_EgressPolicy(t *testing.T) {
+	internal := toolServer(t, func(map[string]any) any {
+		t.Error("the internal server was called")
+		return nil
+	})
+	defer internal.Close()
+	redirect := httptest.NewServer(http.RedirectHandler(internal.URL, http.StatusTemporaryRedirect))
+	defer redirect.Close()
+
+	_, err := NewClient(egress.NewPolicy(nil, nil, false).Client(5*time.Second)).CallTool(context.Background(), internal.URL, "s3cret", "ping", nil)
+	if !errors.Is(err, egress.ErrDenied) {
+		t.Errorf("loopback server: err = %v, want egress.ErrDenied", err)
+	}
+	_, err = NewClient(egress.NewPolicy(nil, nil, true).Client(5*time.Second)).CallTool(context.Background(), redirect.URL, "s3cret", "ping", nil)
+	if err == nil || !strings.Contains(err.Error(), "HTTP 307") {
+		t.Errorf("redirect: err = %v, want the 307 returned", err)
+	}
+}
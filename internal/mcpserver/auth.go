@@ -1,15 +1,31 @@
 package mcpserver
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strings"
 
+	"github.com/rs/zerolog/log"
 	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/quota"
 )
 
 // AuthMiddleware returns an http middleware that validates Authorization: Bearer <key>
-// using auth.Service. On failure it responds with 401 JSON and does not call next.
-func AuthMiddleware(authService *auth.Service) func(http.Handler) http.Handler {
+// using auth.Service, then the request signature (X-Stories-* headers, see auth.Sign;
+// target is "<METHOD> <path>") with verifier. A nil verifier does not check signatures.
+// On failure it responds with 401 JSON and does not call next; tools/call requests for a
+// tool the key's scopes do not grant get 403. Tool calls are charged to the key's quota
+// with quotaService (nil charges nothing) before they run: 429 when it is used up. Polling
+// async calls (get_operation) needs no scope and is free. The key's user and ID are set
+// in the request context (auth.UserIDKey, auth.APIKeyIDKey). Bodies over maxBodyBytes get 413;
+// POST bodies that are not a single JSON-RPC request get 400. The parsed request is what the
+// server serves, so the tool checked and charged is the tool that runs.
+func AuthMiddleware(authService *auth.Service, verifier *auth.SignatureVerifier, quotaService *quota.Service, maxBodyBytes int64) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
@@ -27,12 +43,82 @@ func AuthMiddleware(authService *auth.Service) func(http.Handler) http.Handler {
 				writeJSONError(w, http.StatusUnauthorized, "empty api key")
 				return
 			}
-			_, err := authService.ValidateAPIKey(r.Context(), apiKey)
+			storedKey, err := authService.ValidateAPIKey(r.Context(), apiKey)
 			if err != nil {
 				writeJSONError(w, http.StatusUnauthorized, "invalid api key")
 				return
 			}
+			sig := auth.RequestSignature{
+				Timestamp: r.Header.Get(auth.HeaderSignatureTimestamp),
+				Nonce:     r.Header.Get(auth.HeaderSignatureNonce),
+				Signature: r.Header.Get(auth.HeaderSignature),
+			}
+			// The signature covers the body, and scopes and quota apply to the tool it calls: read it
+			// and hand a copy to next
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					writeJSONError(w, http.StatusRequestEntityTooLarge, "request body too large")
+				} else {
+					writeJSONError(w, http.StatusBadRequest, "failed to read request body")
+				}
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			if err := verifier.Verify(r.Context(), apiKey, sig, r.Method+" "+r.URL.Path, body); err != nil {
+				writeJSONError(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+			ctx := context.WithValue(r.Context(), auth.UserIDKey, storedKey.UserID)
+			ctx = context.WithValue(ctx, auth.APIKeyIDKey, storedKey.ID)
+			if r.Method != http.MethodPost {
+				next.ServeHTTP(w, r.WithContext(ctx)) // refused by the server
+				return
+			}
+			req, err := decodeRequest(bytes.NewReader(body))
+			if err != nil {
+				writeJSONError(w, http.StatusBadRequest, "invalid JSON-RPC request")
+				return
+			}
+			r = r.WithContext(withRequest(ctx, req))
+			call, err := calledTool(req)
+			if err != nil {
+				writeJSONError(w, http.StatusBadRequest, "invalid tools/call params")
+				return
+			}
+			if call == nil || call.Name == toolGetOperation {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !storedKey.HasScope(models.AgentToolScope(call.Name)) {
+				writeJSONError(w, http.StatusForbidden, "api key is not allowed to call "+call.Name)
+				return
+			}
+			inputChars := int64(len(getStr(call.Arguments, "text")) + len(getStr(call.Arguments, "prompt")))
+			if err := quotaService.ChargeAgentCall(r.Context(), storedKey.ID, call.Name, inputChars); err != nil {
+				if errors.Is(err, quota.ErrQuotaExceeded) {
+					writeJSONError(w, http.StatusTooManyRequests, err.Error())
+				} else {
+					log.Error().Err(err).Str("tool", call.Name).Msg("Failed to charge agent call")
+					writeJSONError(w, http.StatusInternalServerError, "failed to check quota")
+				}
+				return
+			}
 			next.ServeHTTP(w, r)
 		})
 	}
 }
+
+// calledTool returns the tool call of a tools/call request, parsed as the server parses it, or nil for
+// other methods (tools/list, unknown methods the server rejects).
+func calledTool(req *jsonRPCRequest) (*toolsCallParams, error) {
+	if req.Method != "tools/call" {
+		return nil, nil
+	}
+	var params toolsCallParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
@@ -0,0 +1,75 @@
+package mcpserver_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/mcpserver"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/quota"
+	"github.com/snappy-loop/stories/internal/testutil"
+)
+
+func TestAuthMiddleware_ToolChecks(t *testing.T) {
+	db := testutil.Postgres(t)
+	ctx := context.Background()
+	user := testutil.CreateUser(t, db)
+	plain, key := testutil.CreateAPIKey(t, db, user.ID)
+	keys := database.NewAPIKeyRepository(db)
+	if err := keys.SetScopes(ctx, key.ID, []string{models.AgentToolScope("segment_text")}); err != nil {
+		t.Fatalf("set scopes: %v", err)
+	}
+
+	served := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { served++ })
+	handler := mcpserver.AuthMiddleware(auth.NewService(db), nil, quota.NewService(db), 1024)(next)
+	call := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+plain)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	used := func() int64 {
+		k, err := keys.GetByID(ctx, key.ID)
+		if err != nil {
+			t.Fatalf("get key: %v", err)
+		}
+		return k.UsedCharsInPeriod
+	}
+
+	image := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"generate_image","arguments":{"prompt":"a cat"}}}`
+	segment := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"segment_text","arguments":{"text":"Hello there"}}}`
+	for _, tc := range []struct {
+		name string
+		body string
+		want int
+	}{
+		{"tool outside the scopes", image, http.StatusForbidden},
+		// The server's decoder used to stop after the first value while the check rejected the whole body
+		{"trailing data", image + " x", http.StatusBadRequest},
+		{"second request", segment + segment, http.StatusBadRequest},
+		{"not JSON", "x", http.StatusBadRequest},
+		{"bad params", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":[1]}`, http.StatusBadRequest},
+		{"too large", `{"jsonrpc":"2.0","id":1,"method":"tools/list","pad":"` + strings.Repeat("a", 1024) + `"}`, http.StatusRequestEntityTooLarge},
+	} {
+		if got := call(tc.body); got != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, got, tc.want)
+		}
+	}
+	if served != 0 || used() != 0 {
+		t.Fatalf("refused requests: %d served, %d chars charged; want none", served, used())
+	}
+
+	if got := call(segment + "\n"); got != http.StatusOK || served != 1 {
+		t.Fatalf("allowed tool: status %d, served %d", got, served)
+	}
+	if got := used(); got != int64(len("Hello there")) {
+		t.Errorf("charged %d chars, want %d", got, len("Hello there"))
+	}
+}
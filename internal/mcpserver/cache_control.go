@@ -0,0 +1,22 @@
+package mcpserver
+
+import (
+	"context"
+
+	"github.com/snappy-loop/stories/internal/llm"
+)
+
+// cacheControlProp documents the cache_control argument of the cached tools.
+var cacheControlProp = schemaProp{
+	Type:        "string",
+	Description: "default (use and fill the cache), no-cache (recompute and refresh the cached result) or no-store (bypass the cache)",
+}
+
+// withCacheControl applies the cache_control argument of a segment_text or generate_image_prompt call.
+func withCacheControl(ctx context.Context, args map[string]interface{}) (context.Context, error) {
+	cc, err := llm.ParseCacheControl(getStr(args, "cache_control"))
+	if err != nil {
+		return ctx, err
+	}
+	return llm.WithCacheControl(ctx, cc), nil
+}
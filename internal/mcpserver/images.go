@@ -0,0 +1,74 @@
+package mcpserver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/auth"
+)
+
+const (
+	// DefaultInlineImageLimit is the largest image returned inline in an image content item
+	DefaultInlineImageLimit = 4 << 20
+	imageURLExpiry          = 24 * time.Hour
+)
+
+// imageStore stores images too large to return inline (storage.Client).
+type imageStore interface {
+	Upload(ctx context.Context, key string, data io.Reader, contentType string, contentLength int64) (string, error)
+	PublicURL(bucket, key string) string
+	GeneratePresignedURL(bucket, key string, expiration time.Duration) (string, error)
+}
+
+// SetStorage uploads generated images larger than the inline limit to S3 and returns their URL. Without
+// storage such images are refused.
+func (s *Server) SetStorage(storage imageStore) {
+	s.storage = storage
+}
+
+// SetInlineImageLimit sets the largest image returned inline (DefaultInlineImageLimit when n <= 0).
+func (s *Server) SetInlineImageLimit(n int) {
+	if n <= 0 {
+		n = DefaultInlineImageLimit
+	}
+	s.inlineImageLimit = n
+}
+
+// storeImage uploads a generated image under the caller's agents prefix (as the gRPC image server does) and
+// returns its public URL, else a presigned one.
+func (s *Server) storeImage(ctx context.Context, data []byte, mimeType string) (string, error) {
+	userID := "anonymous"
+	if u, ok := ctx.Value(auth.UserIDKey).(uuid.UUID); ok {
+		userID = u.String()
+	}
+	key := "agents/" + userID + "/image/" + uuid.New().String() + imageExtension(mimeType)
+	bucket, err := s.storage.Upload(ctx, key, bytes.NewReader(data), mimeType, int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("upload image to S3: %w", err)
+	}
+	if url := s.storage.PublicURL(bucket, key); url != "" {
+		return url, nil
+	}
+	url, err := s.storage.GeneratePresignedURL(bucket, key, imageURLExpiry)
+	if err != nil {
+		return "", fmt.Errorf("presign image URL: %w", err)
+	}
+	return url, nil
+}
+
+func imageExtension(mimeType string) string {
+	switch mimeType {
+	case "image/png":
+		return ".png"
+	case "image/jpeg", "image/jpg":
+		return ".jpg"
+	case "image/webp":
+		return ".webp"
+	default:
+		return ".bin"
+	}
+}
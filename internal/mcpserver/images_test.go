@@ -0,0 +1,71 @@
+package mcpserver
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/snappy-loop/stories/internal/llm"
+)
+
+type fakeImageAgent struct {
+	data []byte
+}
+
+func (a *fakeImageAgent) GenerateImagePrompt(context.Context, string, string) (string, error) {
+	return "", nil
+}
+
+func (a *fakeImageAgent) GenerateImage(context.Context, string) (*llm.Image, error) {
+	return &llm.Image{Data: bytes.NewReader(a.data), Size: int64(len(a.data)), MimeType: "image/png"}, nil
+}
+
+type fakeImageStore struct {
+	keys []string
+}
+
+func (s *fakeImageStore) Upload(_ context.Context, key string, data io.Reader, _ string, _ int64) (string, error) {
+	s.keys = append(s.keys, key)
+	_, err := io.Copy(io.Discard, data)
+	return "bucket", err
+}
+
+func (s *fakeImageStore) PublicURL(bucket, key string) string {
+	return "https://cdn.example.com/" + key
+}
+
+func (s *fakeImageStore) GeneratePresignedURL(string, string, time.Duration) (string, error) {
+	return "", nil
+}
+
+func TestCallGenerateImage(t *testing.T) {
+	ctx := context.Background()
+	agent := &fakeImageAgent{data: []byte("0123456789")}
+	s := NewServer(nil, agent, nil)
+	args := map[string]interface{}{"prompt": "a cat"}
+
+	got, _ := s.callGenerateImage(ctx, args)
+	r := got.(*toolsCallResult)
+	if r.IsError || len(r.Content) != 2 || r.Content[1].Type != "image" || r.Content[1].MimeType != "image/png" || r.Content[1].Data != "MDEyMzQ1Njc4OQ==" {
+		t.Fatalf("small image = %+v, want metadata and an inline image item", r)
+	}
+
+	s.SetInlineImageLimit(5)
+	got, _ = s.callGenerateImage(ctx, args)
+	if r := got.(*toolsCallResult); !r.IsError {
+		t.Errorf("large image without storage = %+v, want an error", r)
+	}
+
+	store := &fakeImageStore{}
+	s.SetStorage(store)
+	got, _ = s.callGenerateImage(ctx, args)
+	r = got.(*toolsCallResult)
+	if r.IsError || len(r.Content) != 2 || r.Content[1].Type != "resource_link" || r.Content[1].Data != "" || len(store.keys) != 1 {
+		t.Fatalf("large image = %+v, want it stored and linked", r)
+	}
+	if want := "https://cdn.example.com/" + store.keys[0]; r.Content[1].URI != want {
+		t.Errorf("uri = %q, want %q", r.Content[1].URI, want)
+	}
+}
@@ -0,0 +1,150 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/agentops"
+	"github.com/snappy-loop/stories/internal/auth"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// toolGetOperation polls an async tool call. It is not an agent tool: any key may poll its own operations.
+const toolGetOperation = "get_operation"
+
+// asyncProp documents the async argument every tool accepts when async operations are enabled.
+var asyncProp = schemaProp{
+	Type:        "boolean",
+	Description: "Run in the background: returns an operation_id at once; poll it with get_operation",
+}
+
+// SetOperations enables async tool calls (the async argument and get_operation). nil disables them.
+func (s *Server) SetOperations(ops *agentops.Manager) {
+	s.ops = ops
+}
+
+// withOperationTools adds the async argument to every tool and the get_operation tool when async
+// operations are enabled.
+func (s *Server) withOperationTools(result *toolsListResult) *toolsListResult {
+	if s.ops == nil {
+		return result
+	}
+	for i := range result.Tools {
+		result.Tools[i].InputSchema.Properties["async"] = asyncProp
+	}
+	result.Tools = append(result.Tools, mcpTool{
+		Name:        toolGetOperation,
+		Description: "Get the status of an async tool call; once it succeeded, its result follows the status",
+		InputSchema: inputSchema{
+			Type: "object",
+			Properties: map[string]schemaProp{
+				"operation_id": {Type: "string", Description: "operation_id returned by the async call"},
+			},
+			Required: []string{"operation_id"},
+		},
+	})
+	return result
+}
+
+// operationStatus is the first content item of get_operation results (and the only one of async calls).
+type operationStatus struct {
+	OperationID string `json:"operation_id"`
+	Tool        string `json:"tool,omitempty"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+}
+
+// startOperation runs a tool call in the background and returns its operation ID.
+func (s *Server) startOperation(ctx context.Context, params toolsCallParams) (interface{}, *rpcError) {
+	if !s.hasTool(params.Name) {
+		return nil, &rpcError{Code: -32602, Message: "Unknown tool: " + params.Name}
+	}
+	apiKeyID, err := auth.GetAPIKeyID(ctx)
+	if err != nil {
+		return nil, &rpcError{Code: -32603, Message: "Internal error"}
+	}
+	op, err := s.ops.Start(ctx, apiKeyID, params.Name, models.AgentProtocolMCP, func(ctx context.Context) ([]byte, error) {
+		result, rpcErr := s.callTool(ctx, params)
+		if rpcErr != nil {
+			return nil, errors.New(rpcErr.Message)
+		}
+		if r, ok := result.(*toolsCallResult); ok && r.IsError {
+			return nil, errors.New(resultText(r))
+		}
+		return json.Marshal(result)
+	})
+	if err != nil {
+		return errorResult(err.Error()), nil
+	}
+	return statusResult(operationStatus{OperationID: op.ID.String(), Tool: op.Tool, Status: op.Status}), nil
+}
+
+// callGetOperation returns the status of an operation of the caller's key and, once it succeeded, the
+// content of its result.
+func (s *Server) callGetOperation(ctx context.Context, args map[string]interface{}) (interface{}, *rpcError) {
+	if s.ops == nil {
+		return errorResult(agentops.ErrDisabled.Error()), nil
+	}
+	id, err := uuid.Parse(getStr(args, "operation_id"))
+	if err != nil {
+		return errorResult("invalid operation_id"), nil
+	}
+	apiKeyID, err := auth.GetAPIKeyID(ctx)
+	if err != nil {
+		return nil, &rpcError{Code: -32603, Message: "Internal error"}
+	}
+	op, err := s.ops.Get(ctx, apiKeyID, id, models.AgentProtocolMCP)
+	if err != nil {
+		return errorResult(err.Error()), nil
+	}
+	st := operationStatus{OperationID: op.ID.String(), Tool: op.Tool, Status: op.Status}
+	if op.Error != nil {
+		st.Error = *op.Error
+	}
+	out := statusResult(st)
+	if op.Status == models.AgentOperationSucceeded {
+		var result toolsCallResult
+		if err := json.Unmarshal(op.Result, &result); err != nil {
+			return errorResult("failed to decode operation result"), nil
+		}
+		out.Content = append(out.Content, result.Content...)
+	}
+	return out, nil
+}
+
+// hasTool reports whether name is a tool of tools/list.
+func (s *Server) hasTool(name string) bool {
+	list, _ := s.handleToolsList()
+	for _, t := range list.(*toolsListResult).Tools {
+		if t.Name == name && name != toolGetOperation {
+			return true
+		}
+	}
+	return false
+}
+
+func statusResult(st operationStatus) *toolsCallResult {
+	stJSON, _ := json.Marshal(st)
+	return &toolsCallResult{Content: []contentItem{{Type: "text", Text: string(stJSON)}}}
+}
+
+func errorResult(message string) *toolsCallResult {
+	return &toolsCallResult{
+		Content: []contentItem{{Type: "text", Text: message}},
+		IsError: true,
+	}
+}
+
+// resultText returns the text content of a tool result (its error message for failed calls).
+func resultText(r *toolsCallResult) string {
+	var parts []string
+	for _, c := range r.Content {
+		if c.Text != "" {
+			parts = append(parts, c.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
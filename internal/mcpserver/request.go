@@ -0,0 +1,38 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// errTrailingData is returned for request bodies with more than one JSON value.
+var errTrailingData = errors.New("unexpected data after the JSON-RPC request")
+
+type requestContextKey struct{}
+
+// decodeRequest parses a JSON-RPC request body. Anything after the request is an error: the auth middleware
+// checks scopes and charges quota on the request the server dispatches, so the two must read the same one.
+func decodeRequest(body io.Reader) (*jsonRPCRequest, error) {
+	dec := json.NewDecoder(body)
+	var req jsonRPCRequest
+	if err := dec.Decode(&req); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errTrailingData
+	}
+	return &req, nil
+}
+
+// withRequest returns ctx carrying the request the auth middleware parsed, which the server then serves
+// instead of reading the body again.
+func withRequest(ctx context.Context, req *jsonRPCRequest) context.Context {
+	return context.WithValue(ctx, requestContextKey{}, req)
+}
+
+func requestFromContext(ctx context.Context) (*jsonRPCRequest, bool) {
+	req, ok := ctx.Value(requestContextKey{}).(*jsonRPCRequest)
+	return req, ok
+}
@@ -0,0 +1,25 @@
+package mcpserver
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecodeRequest(t *testing.T) {
+	req, err := decodeRequest(strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"fact_check"}}` + "\n"))
+	if err != nil {
+		t.Fatalf("decodeRequest: %v", err)
+	}
+	if call, err := calledTool(req); err != nil || call == nil || call.Name != "fact_check" {
+		t.Errorf("calledTool = %+v, %v; want fact_check", call, err)
+	}
+	for _, body := range []string{`{"method":"tools/list"} x`, `{"method":"tools/list"}{"method":"tools/call"}`, `{"method":"tools/list"} 1`} {
+		if _, err := decodeRequest(strings.NewReader(body)); !errors.Is(err, errTrailingData) {
+			t.Errorf("decodeRequest(%q) err = %v, want errTrailingData", body, err)
+		}
+	}
+	if _, err := decodeRequest(strings.NewReader("")); err == nil {
+		t.Error("decodeRequest accepted an empty body")
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 
+	"github.com/snappy-loop/stories/internal/agentops"
 	"github.com/snappy-loop/stories/internal/agents"
 )
 
@@ -60,11 +62,14 @@ type toolsCallResult struct {
 	IsError bool          `json:"isError"`
 }
 
+// contentItem is a text, image (base64 data) or resource_link (uri) content item.
 type contentItem struct {
 	Type     string `json:"type"`
 	Text     string `json:"text,omitempty"`
 	Data     string `json:"data,omitempty"`
 	MimeType string `json:"mimeType,omitempty"`
+	URI      string `json:"uri,omitempty"`
+	Name     string `json:"name,omitempty"`
 }
 
 // Server implements MCP JSON-RPC 2.0 over HTTP (tools/list and tools/call).
@@ -72,6 +77,10 @@ type Server struct {
 	segmentAgent   agents.SegmentationAgent
 	imageAgent     agents.ImageAgent
 	factCheckAgent agents.FactCheckAgent
+	ops            *agentops.Manager
+	storage        imageStore
+	// inlineImageLimit is the largest image returned inline; larger ones are stored and linked
+	inlineImageLimit int
 }
 
 // NewServer returns a new MCP server that uses the given agents.
@@ -80,6 +89,7 @@ func NewServer(segmentAgent agents.SegmentationAgent, imageAgent agents.ImageAge
 		segmentAgent:   segmentAgent,
 		imageAgent:     imageAgent,
 		factCheckAgent: factCheckAgent,
+		inlineImageLimit: DefaultInlineImageLimit,
 	}
 }
 
@@ -93,10 +103,13 @@ func (s *Server) serveJSONRPC(w http.ResponseWriter, r *http.Request) {
 		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
-	var req jsonRPCRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeRPCError(w, req.ID, -32700, "Parse error")
-		return
+	req, ok := requestFromContext(r.Context())
+	if !ok {
+		var err error
+		if req, err = decodeRequest(r.Body); err != nil {
+			writeRPCError(w, nil, -32700, "Parse error")
+			return
+		}
 	}
 	if req.JSONRPC != "2.0" {
 		writeRPCError(w, req.ID, -32600, "Invalid Request")
@@ -128,7 +141,7 @@ func (s *Server) serveJSONRPC(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleToolsList() (interface{}, *rpcError) {
-	return &toolsListResult{
+	result := &toolsListResult{
 		Tools: []mcpTool{
 			{
 				Name:        "segment_text",
@@ -139,10 +152,24 @@ func (s *Server) handleToolsList() (interface{}, *rpcError) {
 						"text":           {Type: "string", Description: "Full text to segment"},
 						"segments_count": {Type: "number", Description: "Target number of segments"},
 						"input_type":     {Type: "string", Description: "educational, financial, or fictional"},
+						"cache_control":  cacheControlProp,
 					},
 					Required: []string{"text", "segments_count", "input_type"},
 				},
 			},
+			{
+				Name:        "extract_content",
+				Description: "Extract text from an image or PDF with Gemini vision (same extraction as job file uploads)",
+				InputSchema: inputSchema{
+					Type: "object",
+					Properties: map[string]schemaProp{
+						"data_base64": {Type: "string", Description: "Base64-encoded document or image"},
+						"mime_type":   {Type: "string", Description: "image/jpeg, image/png, image/gif, image/webp, or application/pdf"},
+						"input_type":  {Type: "string", Description: "educational, financial, or fictional"},
+					},
+					Required: []string{"data_base64", "mime_type"},
+				},
+			},
 			{
 				Name:        "generate_image_prompt",
 				Description: "Generate an image generation prompt from text",
@@ -150,14 +177,15 @@ func (s *Server) handleToolsList() (interface{}, *rpcError) {
 					Type: "object",
 					Properties: map[string]schemaProp{
 						"text":       {Type: "string", Description: "Text to describe as image"},
-						"input_type": {Type: "string", Description: "educational, financial, or fictional"},
+						"input_type":    {Type: "string", Description: "educational, financial, or fictional"},
+						"cache_control": cacheControlProp,
 					},
 					Required: []string{"text", "input_type"},
 				},
 			},
 			{
 				Name:        "generate_image",
-				Description: "Generate an image from a prompt",
+				Description: "Generate an image from a prompt; returns its metadata (JSON text) and the image, linked by URL when it is large",
 				InputSchema: inputSchema{
 					Type: "object",
 					Properties: map[string]schemaProp{
@@ -178,7 +206,8 @@ func (s *Server) handleToolsList() (interface{}, *rpcError) {
 				},
 			},
 		},
-	}, nil
+	}
+	return s.withOperationTools(result), nil
 }
 
 type toolsCallParams struct {
@@ -191,9 +220,22 @@ func (s *Server) handleToolsCall(ctx context.Context, paramsRaw json.RawMessage)
 	if err := json.Unmarshal(paramsRaw, &params); err != nil {
 		return nil, &rpcError{Code: -32602, Message: "Invalid params"}
 	}
+	if params.Name == toolGetOperation {
+		return s.callGetOperation(ctx, params.Arguments)
+	}
+	if s.ops != nil && getBool(params.Arguments, "async") {
+		return s.startOperation(ctx, params)
+	}
+	return s.callTool(ctx, params)
+}
+
+// callTool runs a tool call and returns its result.
+func (s *Server) callTool(ctx context.Context, params toolsCallParams) (interface{}, *rpcError) {
 	switch params.Name {
 	case "segment_text":
 		return s.callSegmentText(ctx, params.Arguments)
+	case "extract_content":
+		return s.callExtractContent(ctx, params.Arguments)
 	case "generate_image_prompt":
 		return s.callGenerateImagePrompt(ctx, params.Arguments)
 	case "generate_image":
@@ -214,6 +256,15 @@ func getStr(m map[string]interface{}, key string) string {
 	return ""
 }
 
+func getBool(m map[string]interface{}, key string) bool {
+	if v, ok := m[key]; ok {
+		if b, ok := v.(bool); ok {
+			return b
+		}
+	}
+	return false
+}
+
 func getNum(m map[string]interface{}, key string) int {
 	if v, ok := m[key]; ok {
 		switch n := v.(type) {
@@ -236,6 +287,13 @@ func (s *Server) callSegmentText(ctx context.Context, args map[string]interface{
 	if inputType == "" {
 		inputType = "educational"
 	}
+	ctx, err := withCacheControl(ctx, args)
+	if err != nil {
+		return &toolsCallResult{
+			Content: []contentItem{{Type: "text", Text: err.Error()}},
+			IsError: true,
+		}, nil
+	}
 	segments, err := s.segmentAgent.SegmentText(ctx, text, segmentsCount, inputType)
 	if err != nil {
 		return &toolsCallResult{
@@ -265,12 +323,44 @@ func (s *Server) callSegmentText(ctx context.Context, args map[string]interface{
 	}, nil
 }
 
+func (s *Server) callExtractContent(ctx context.Context, args map[string]interface{}) (interface{}, *rpcError) {
+	data, err := base64.StdEncoding.DecodeString(getStr(args, "data_base64"))
+	if err != nil {
+		return &toolsCallResult{
+			Content: []contentItem{{Type: "text", Text: "data_base64 is not valid base64"}},
+			IsError: true,
+		}, nil
+	}
+	inputType := getStr(args, "input_type")
+	if inputType == "" {
+		inputType = "educational"
+	}
+	text, err := s.segmentAgent.ExtractContent(ctx, data, getStr(args, "mime_type"), inputType)
+	if err != nil {
+		return &toolsCallResult{
+			Content: []contentItem{{Type: "text", Text: err.Error()}},
+			IsError: true,
+		}, nil
+	}
+	return &toolsCallResult{
+		Content: []contentItem{{Type: "text", Text: text}},
+		IsError: false,
+	}, nil
+}
+
 func (s *Server) callGenerateImagePrompt(ctx context.Context, args map[string]interface{}) (interface{}, *rpcError) {
 	text := getStr(args, "text")
 	inputType := getStr(args, "input_type")
 	if inputType == "" {
 		inputType = "educational"
 	}
+	ctx, err := withCacheControl(ctx, args)
+	if err != nil {
+		return &toolsCallResult{
+			Content: []contentItem{{Type: "text", Text: err.Error()}},
+			IsError: true,
+		}, nil
+	}
 	prompt, err := s.imageAgent.GenerateImagePrompt(ctx, text, inputType)
 	if err != nil {
 		return &toolsCallResult{
@@ -311,15 +401,34 @@ func (s *Server) callGenerateImage(ctx context.Context, args map[string]interfac
 		mimeType = "image/png"
 	}
 	meta := map[string]interface{}{
-		"data_base64": base64.StdEncoding.EncodeToString(data),
-		"mime_type":   mimeType,
-		"size":        img.Size,
-		"resolution":  img.Resolution,
-		"model":       img.Model,
+		"mime_type":  mimeType,
+		"size":       img.Size,
+		"resolution": img.Resolution,
+		"model":      img.Model,
+	}
+	// Small images are returned inline as an image item; larger ones are stored and linked, as MCP clients
+	// and their models handle large base64 payloads poorly
+	image := contentItem{Type: "image", Data: base64.StdEncoding.EncodeToString(data), MimeType: mimeType}
+	if len(data) > s.inlineImageLimit {
+		if s.storage == nil {
+			return &toolsCallResult{
+				Content: []contentItem{{Type: "text", Text: fmt.Sprintf("image is %d bytes, over the inline limit of %d bytes, and no storage is configured", len(data), s.inlineImageLimit)}},
+				IsError: true,
+			}, nil
+		}
+		url, err := s.storeImage(ctx, data, mimeType)
+		if err != nil {
+			return &toolsCallResult{
+				Content: []contentItem{{Type: "text", Text: err.Error()}},
+				IsError: true,
+			}, nil
+		}
+		meta["url"] = url
+		image = contentItem{Type: "resource_link", URI: url, Name: "image" + imageExtension(mimeType), MimeType: mimeType}
 	}
 	metaJSON, _ := json.Marshal(meta)
 	return &toolsCallResult{
-		Content: []contentItem{{Type: "text", Text: string(metaJSON)}},
+		Content: []contentItem{{Type: "text", Text: string(metaJSON)}, image},
 		IsError: false,
 	}, nil
 }
@@ -0,0 +1,242 @@
+// Package metering reports billable usage to Stripe metered billing: the dispatcher sends the usage ledger
+// entries of users linked to a Stripe customer as meter events (completed jobs, characters, audio seconds).
+// Each event carries an identifier derived from its ledger entry, so a resent event is not counted twice.
+package metering
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// batchSize is how many ledger entries are read at a time.
+const batchSize = 100
+
+// Config holds the reporter settings.
+type Config struct {
+	APIKey   string        // Stripe secret key; empty disables the reporter
+	APIURL   string        // Stripe API base URL
+	Interval time.Duration // how often unreported entries are sent
+
+	// Meter event names; an empty name does not report that usage
+	JobsEvent         string // completed jobs, 1 per job
+	CharsEvent        string // quota characters charged for new jobs
+	AudioSecondsEvent string // seconds of generated audio, rounded
+}
+
+// Enabled reports whether usage is reported to Stripe.
+func (c Config) Enabled() bool {
+	return c.APIKey != "" && c.Interval > 0
+}
+
+// Validate checks the API URL of an enabled reporter.
+func (c Config) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	u, err := url.Parse(c.APIURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("invalid Stripe API URL %q", c.APIURL)
+	}
+	return nil
+}
+
+// ledgerRepository is the subset of usage ledger DB operations used by the reporter.
+type ledgerRepository interface {
+	ListUnreported(ctx context.Context, limit int) ([]*models.UnreportedUsage, error)
+	MarkReported(ctx context.Context, id int64, reason string) error
+}
+
+// Event is a Stripe meter event.
+type Event struct {
+	Name       string
+	Identifier string // derived from the ledger entry; Stripe drops events repeating one
+	CustomerID string
+	Value      int64
+	Timestamp  time.Time
+}
+
+// eventFor returns the meter event of a ledger entry; ok is false for entries with nothing to report.
+func (c Config) eventFor(e *models.UnreportedUsage) (Event, bool) {
+	var name string
+	var value int64
+	switch e.Kind {
+	case models.UsageKindJobCompleted:
+		name, value = c.JobsEvent, 1
+	case models.UsageKindJobCreated:
+		name, value = c.CharsEvent, e.Chars
+	case models.UsageKindJobRun:
+		name, value = c.AudioSecondsEvent, int64(math.Round(e.AudioSeconds))
+	}
+	if name == "" || value <= 0 || e.StripeCustomerID == nil {
+		return Event{}, false
+	}
+	return Event{
+		Name:       name,
+		Identifier: "stories-usage-" + strconv.FormatInt(e.ID, 10),
+		CustomerID: *e.StripeCustomerID,
+		Value:      value,
+		Timestamp:  e.CreatedAt,
+	}, true
+}
+
+// rejectedError is a meter event Stripe refused (invalid customer, event name or timestamp): sending it
+// again would fail the same way.
+type rejectedError struct {
+	status  int
+	message string
+}
+
+func (e *rejectedError) Error() string {
+	return fmt.Sprintf("rejected by Stripe (%d): %s", e.status, e.message)
+}
+
+// Reporter sends unreported usage ledger entries to Stripe every Interval, in the elected dispatcher
+// replica. Entries are marked once Stripe accepted their event, or when they have none (no Stripe
+// customer, usage not metered) or Stripe rejected it; other failures are retried on the next tick.
+type Reporter struct {
+	cfg    Config
+	repo   ledgerRepository
+	client *http.Client
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewReporter creates a reporter.
+func NewReporter(cfg Config, repo ledgerRepository) *Reporter {
+	cfg.APIURL = strings.TrimRight(cfg.APIURL, "/")
+	return &Reporter{
+		cfg:      cfg,
+		repo:     repo,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start reports usage every Interval in the background until ctx is cancelled or Stop is called. It does
+// nothing when the reporter is disabled.
+func (r *Reporter) Start(ctx context.Context) {
+	if !r.cfg.Enabled() {
+		log.Info().Msg("Stripe usage reporting disabled")
+		return
+	}
+	ticker := time.NewTicker(r.cfg.Interval)
+	go func() {
+		defer ticker.Stop()
+		log.Info().Dur("interval", r.cfg.Interval).Msg("Stripe usage reporter started")
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.stopChan:
+				log.Info().Msg("Stripe usage reporter stopped")
+				return
+			case <-ticker.C:
+				r.RunOnce(ctx)
+			}
+		}
+	}()
+}
+
+// Stop stops the reporter. Safe to call multiple times.
+func (r *Reporter) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopChan)
+	})
+}
+
+// RunOnce reports the unreported entries, a batch at a time, until none are left or Stripe fails. It
+// returns the number of events sent.
+func (r *Reporter) RunOnce(ctx context.Context) int {
+	sent := 0
+	for {
+		entries, err := r.repo.ListUnreported(ctx, batchSize)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to list unreported usage")
+			return sent
+		}
+		for _, e := range entries {
+			reason := ""
+			if ev, ok := r.cfg.eventFor(e); ok {
+				err := r.send(ctx, ev)
+				var rejected *rejectedError
+				if errors.As(err, &rejected) {
+					log.Warn().Err(err).Int64("usage_id", e.ID).Str("event_name", ev.Name).Msg("Stripe rejected meter event")
+					reason = rejected.Error()
+				} else if err != nil {
+					log.Error().Err(err).Int64("usage_id", e.ID).Msg("Failed to report usage to Stripe, retrying later")
+					return sent
+				} else {
+					sent++
+				}
+			} else if e.StripeCustomerID == nil {
+				reason = "no Stripe customer"
+			}
+			if err := r.repo.MarkReported(ctx, e.ID, reason); err != nil {
+				log.Error().Err(err).Int64("usage_id", e.ID).Msg("Failed to mark usage reported")
+				return sent
+			}
+		}
+		if len(entries) < batchSize {
+			if sent > 0 {
+				log.Info().Int("events", sent).Msg("Reported usage to Stripe")
+			}
+			return sent
+		}
+	}
+}
+
+// send posts a meter event (POST /v1/billing/meter_events). The identifier doubles as the idempotency key.
+func (r *Reporter) send(ctx context.Context, ev Event) error {
+	form := url.Values{}
+	form.Set("event_name", ev.Name)
+	form.Set("identifier", ev.Identifier)
+	form.Set("timestamp", strconv.FormatInt(ev.Timestamp.Unix(), 10))
+	form.Set("payload[stripe_customer_id]", ev.CustomerID)
+	form.Set("payload[value]", strconv.FormatInt(ev.Value, 10))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.cfg.APIURL+"/v1/billing/meter_events", strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+r.cfg.APIKey)
+	req.Header.Set("Idempotency-Key", ev.Identifier)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var apiErr struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	message := strings.TrimSpace(string(body))
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Message != "" {
+		message = apiErr.Error.Message
+	}
+	// Bad requests (unknown customer or meter, timestamp too old) are permanent; authentication errors,
+	// rate limits and server errors are retried
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusNotFound {
+		return &rejectedError{status: resp.StatusCode, message: message}
+	}
+	return fmt.Errorf("stripe returned %d: %s", resp.StatusCode, message)
+}
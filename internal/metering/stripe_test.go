@@ -0,0 +1,113 @@
+package metering
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// fakeLedger serves unreported entries and records how each was marked.
+type fakeLedger struct {
+	entries []*models.UnreportedUsage
+	marked  map[int64]string
+}
+
+func (f *fakeLedger) ListUnreported(ctx context.Context, limit int) ([]*models.UnreportedUsage, error) {
+	var out []*models.UnreportedUsage
+	for _, e := range f.entries {
+		if _, ok := f.marked[e.ID]; !ok && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeLedger) MarkReported(ctx context.Context, id int64, reason string) error {
+	f.marked[id] = reason
+	return nil
+}
+
+func TestReporter_RunOnce(t *testing.T) {
+	customer := "cus_123"
+	at := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	ledger := &fakeLedger{
+		entries: []*models.UnreportedUsage{
+			{ID: 1, Kind: models.UsageKindJobCreated, Chars: 1200, StripeCustomerID: &customer, CreatedAt: at},
+			{ID: 2, Kind: models.UsageKindJobRun, AudioSeconds: 61.6, StripeCustomerID: &customer, CreatedAt: at},
+			{ID: 3, Kind: models.UsageKindJobCompleted, StripeCustomerID: &customer, CreatedAt: at},
+			{ID: 4, Kind: models.UsageKindJobCompleted, CreatedAt: at},                                       // no customer
+			{ID: 5, Kind: models.UsageKindJobRun, StripeCustomerID: &customer, CreatedAt: at},                // tokens only
+			{ID: 6, Kind: models.UsageKindJobCreated, Chars: 10, StripeCustomerID: &customer, CreatedAt: at}, // rejected
+		},
+		marked: map[int64]string{},
+	}
+	var events []string
+	failing := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/billing/meter_events" || r.Header.Get("Authorization") != "Bearer sk_test" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		r.ParseForm()
+		if r.Header.Get("Idempotency-Key") != r.PostForm.Get("identifier") {
+			t.Errorf("Idempotency-Key %q differs from identifier %q", r.Header.Get("Idempotency-Key"), r.PostForm.Get("identifier"))
+		}
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.PostForm.Get("identifier") == "stories-usage-6" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":"No such customer"}}`))
+			return
+		}
+		events = append(events, strings.Join([]string{
+			r.PostForm.Get("event_name"), r.PostForm.Get("identifier"), r.PostForm.Get("payload[stripe_customer_id]"),
+			r.PostForm.Get("payload[value]"), r.PostForm.Get("timestamp"),
+		}, " "))
+	}))
+	defer srv.Close()
+
+	r := NewReporter(Config{
+		APIKey: "sk_test", APIURL: srv.URL + "/", Interval: time.Minute,
+		JobsEvent: "jobs", CharsEvent: "chars", AudioSecondsEvent: "audio_seconds",
+	}, ledger)
+
+	// Stripe unavailable: nothing is marked, the entries are retried
+	if sent := r.RunOnce(context.Background()); sent != 0 || len(ledger.marked) != 0 {
+		t.Fatalf("sent %d, marked %v while Stripe fails", sent, ledger.marked)
+	}
+
+	failing = false
+	if sent := r.RunOnce(context.Background()); sent != 3 {
+		t.Errorf("sent = %d, want 3", sent)
+	}
+	want := []string{
+		"chars stories-usage-1 cus_123 1200 1792324800",
+		"audio_seconds stories-usage-2 cus_123 62 1792324800",
+		"jobs stories-usage-3 cus_123 1 1792324800",
+	}
+	if strings.Join(events, "\n") != strings.Join(want, "\n") {
+		t.Errorf("events:\n%s\nwant:\n%s", strings.Join(events, "\n"), strings.Join(want, "\n"))
+	}
+	if len(ledger.marked) != 6 || ledger.marked[1] != "" || ledger.marked[4] != "no Stripe customer" || ledger.marked[5] != "" ||
+		!strings.Contains(ledger.marked[6], "No such customer") {
+		t.Errorf("marked = %v", ledger.marked)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	if err := (Config{}).Validate(); err != nil {
+		t.Errorf("disabled config: %v", err)
+	}
+	if err := (Config{APIKey: "sk", Interval: time.Minute, APIURL: "api.stripe.com"}).Validate(); err == nil {
+		t.Error("URL without scheme accepted")
+	}
+	if err := (Config{APIKey: "sk", Interval: time.Minute, APIURL: "https://api.stripe.com"}).Validate(); err != nil {
+		t.Errorf("valid config: %v", err)
+	}
+}
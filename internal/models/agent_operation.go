@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Agent operation statuses
+const (
+	AgentOperationRunning   = "running"
+	AgentOperationSucceeded = "succeeded"
+	AgentOperationFailed    = "failed"
+)
+
+// Agent operation protocols: the format of an operation's result
+const (
+	AgentProtocolMCP  = "mcp"  // JSON MCP tools/call result
+	AgentProtocolGRPC = "grpc" // serialized response message of the gRPC method
+)
+
+// AgentOperation is an agent call running in the background (MCP tools called with async, gRPC *Async
+// methods). The caller polls it with get_operation / GetOperation until it is done; finished operations
+// are kept until ExpiresAt.
+type AgentOperation struct {
+	ID             uuid.UUID
+	APIKeyID       uuid.UUID
+	Tool           string
+	Protocol       string
+	Status         string
+	Result         []byte // inline result, or the result loaded from S3 (ResultS3Key)
+	ResultS3Bucket *string
+	ResultS3Key    *string // large results are stored in S3
+	Error          *string
+	CreatedAt      time.Time
+	FinishedAt     *time.Time
+	ExpiresAt      time.Time
+}
+
+// Done reports whether the operation has finished.
+func (o *AgentOperation) Done() bool {
+	return o.Status != AgentOperationRunning
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// API key scopes: a key with scopes can use only what they grant; a key without (nil Scopes) can use
+// everything. Agent tools are granted one by one (agents:segment_text) or all at once (agents:*), so an
+// agent deployment can hold a key limited to the tools it calls.
+const (
+	ScopeAPI         = "api"      // the REST API (/v1)
+	ScopeAgentsAll   = "agents:*" // every agent tool
+	scopeAgentPrefix = "agents:"
+)
+
+// AgentTools are the tools of the agents service: MCP tools and gRPC methods alike.
+var AgentTools = []string{
+	"segment_text", "extract_content", "generate_narration", "generate_audio",
+	"generate_image_prompt", "generate_image", "fact_check",
+}
+
+// AgentToolScope returns the scope granting an agent tool.
+func AgentToolScope(tool string) string {
+	return scopeAgentPrefix + tool
+}
+
+// HasScope reports whether the key may use scope.
+func (k *APIKey) HasScope(scope string) bool {
+	if k.Scopes == nil {
+		return true
+	}
+	for _, s := range k.Scopes {
+		if s == scope || (s == ScopeAgentsAll && strings.HasPrefix(scope, scopeAgentPrefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateScopes checks that each scope is api, agents:* or agents:<tool>.
+func ValidateScopes(scopes []string) error {
+	for _, s := range scopes {
+		if s == ScopeAPI || s == ScopeAgentsAll {
+			continue
+		}
+		known := false
+		for _, tool := range AgentTools {
+			known = known || s == AgentToolScope(tool)
+		}
+		if !known {
+			return fmt.Errorf("unknown scope %q (want %s, %s or %s<tool> with tool one of %s)",
+				s, ScopeAPI, ScopeAgentsAll, scopeAgentPrefix, strings.Join(AgentTools, ", "))
+		}
+	}
+	return nil
+}
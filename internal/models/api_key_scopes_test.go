@@ -0,0 +1,38 @@
+package models
+
+import "testing"
+
+func TestAPIKey_HasScope(t *testing.T) {
+	tests := []struct {
+		scopes []string
+		scope  string
+		want   bool
+	}{
+		{nil, ScopeAPI, true},
+		{nil, AgentToolScope("segment_text"), true},
+		{[]string{"agents:segment_text"}, AgentToolScope("segment_text"), true},
+		{[]string{"agents:segment_text"}, AgentToolScope("generate_image"), false},
+		{[]string{"agents:segment_text"}, ScopeAPI, false},
+		{[]string{ScopeAgentsAll}, AgentToolScope("fact_check"), true},
+		{[]string{ScopeAgentsAll}, ScopeAPI, false},
+		{[]string{ScopeAPI}, AgentToolScope("fact_check"), false},
+		{[]string{}, ScopeAPI, false},
+	}
+	for _, tt := range tests {
+		key := &APIKey{Scopes: tt.scopes}
+		if got := key.HasScope(tt.scope); got != tt.want {
+			t.Errorf("scopes %v: HasScope(%s) = %v, want %v", tt.scopes, tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestValidateScopes(t *testing.T) {
+	if err := ValidateScopes([]string{"api", "agents:*", "agents:generate_audio"}); err != nil {
+		t.Errorf("valid scopes: %v", err)
+	}
+	for _, bad := range []string{"agents:", "agents:translate", "admin", "API"} {
+		if err := ValidateScopes([]string{bad}); err == nil {
+			t.Errorf("scope %q accepted", bad)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package models
+
+// Job statuses (the job_status enum in the database)
+const (
+	JobStatusQueued         = "queued"
+	JobStatusRunning        = "running"
+	JobStatusAwaitingReview = "awaiting_review" // pipeline done, waiting for a reviewer (require_review)
+	JobStatusSucceeded      = "succeeded"
+	JobStatusFailed         = "failed"
+	JobStatusCanceled       = "canceled"
+)
+
+// JobStatuses lists every job status, in lifecycle order.
+var JobStatuses = []string{JobStatusQueued, JobStatusRunning, JobStatusAwaitingReview, JobStatusSucceeded, JobStatusFailed, JobStatusCanceled}
+
+// jobTransitions lists the statuses each job status may move to. running -> running is allowed so a
+// worker can restart a job whose previous worker crashed; terminal statuses have no way out.
+// awaiting_review -> queued is a reviewer asking for segments to be regenerated.
+var jobTransitions = map[string][]string{
+	JobStatusQueued:         {JobStatusRunning, JobStatusFailed, JobStatusCanceled},
+	JobStatusRunning:        {JobStatusRunning, JobStatusAwaitingReview, JobStatusSucceeded, JobStatusFailed, JobStatusCanceled},
+	JobStatusAwaitingReview: {JobStatusQueued, JobStatusSucceeded, JobStatusFailed, JobStatusCanceled},
+}
+
+// CanTransitionJob reports whether a job may move from one status to another.
+func CanTransitionJob(from, to string) bool {
+	for _, s := range jobTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// JobStatusSources returns the statuses from which a job may move to status.
+func JobStatusSources(to string) []string {
+	var from []string
+	for _, s := range []string{JobStatusQueued, JobStatusRunning, JobStatusAwaitingReview} {
+		if CanTransitionJob(s, to) {
+			from = append(from, s)
+		}
+	}
+	return from
+}
+
+// IsTerminalJobStatus reports whether status is final (succeeded, failed or canceled).
+func IsTerminalJobStatus(status string) bool {
+	return status == JobStatusSucceeded || status == JobStatusFailed || status == JobStatusCanceled
+}
+
+// Job progress steps, in pipeline order
+const (
+	JobStepQueued     = "queued"
+	JobStepWaiting    = "waiting"    // waiting for depends_on jobs to succeed
+	JobStepExtracting = "extracting" // reading uploaded files
+	JobStepSegmenting = "segmenting"
+	JobStepGenerating = "generating" // per-segment narration, audio and images
+	JobStepFinalizing = "finalizing" // building output markup
+	JobStepDone       = "done"
+	JobStepRetrying   = "retrying"    // waiting for a delayed retry after a transient failure
+	JobStepCostCapped = "cost_capped" // queued until the API key's cost cap resets with its quota period
+)
+
+// JobProgress is the roll-up of a job's pipeline position and segment statuses.
+type JobProgress struct {
+	Step              string `json:"step"`
+	SegmentsTotal     int    `json:"segments_total"`
+	SegmentsCompleted int    `json:"segments_completed"`
+	SegmentsFailed    int    `json:"segments_failed"`
+	Percent           int    `json:"percent"` // 0-100, see NewJobProgress
+}
+
+// NewJobProgress builds a progress roll-up. Percent weights the steps so it only moves forward:
+// segmenting 10%, segment generation 10-95% by finished (completed or failed) segments, finalizing 95%,
+// and 100% once the job succeeded. An empty step means the job has not been picked up yet.
+func NewJobProgress(status, step string, total, completed, failed int) *JobProgress {
+	if step == "" {
+		step = JobStepQueued
+	}
+	p := &JobProgress{Step: step, SegmentsTotal: total, SegmentsCompleted: completed, SegmentsFailed: failed}
+	switch {
+	case status == JobStatusSucceeded || step == JobStepDone:
+		p.Percent = 100
+	case step == JobStepFinalizing:
+		p.Percent = 95
+	case step == JobStepGenerating && total > 0:
+		p.Percent = 10 + 85*(completed+failed)/total
+	case step == JobStepGenerating, step == JobStepSegmenting:
+		p.Percent = 10
+	case step == JobStepExtracting:
+		p.Percent = 5
+	}
+	return p
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCanTransitionJob(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{JobStatusQueued, JobStatusRunning, true},
+		{JobStatusQueued, JobStatusFailed, true},
+		{JobStatusQueued, JobStatusSucceeded, false},
+		{JobStatusRunning, JobStatusRunning, true},
+		{JobStatusRunning, JobStatusSucceeded, true},
+		{JobStatusRunning, JobStatusQueued, false},
+		{JobStatusSucceeded, JobStatusRunning, false},
+		{JobStatusFailed, JobStatusRunning, false},
+		{JobStatusCanceled, JobStatusSucceeded, false},
+		{JobStatusRunning, JobStatusAwaitingReview, true},
+		{JobStatusAwaitingReview, JobStatusSucceeded, true},
+		{JobStatusAwaitingReview, JobStatusQueued, true},
+		{JobStatusAwaitingReview, JobStatusRunning, false},
+		{JobStatusQueued, JobStatusAwaitingReview, false},
+	}
+	for _, tt := range tests {
+		if got := CanTransitionJob(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransitionJob(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestJobStatusSources(t *testing.T) {
+	// queued is only re-entered when a reviewer asks for segments to be regenerated
+	if got := JobStatusSources(JobStatusQueued); len(got) != 1 || got[0] != JobStatusAwaitingReview {
+		t.Errorf("JobStatusSources(queued) = %v, want [awaiting_review]", got)
+	}
+	got := JobStatusSources(JobStatusSucceeded)
+	if len(got) != 2 || got[0] != JobStatusRunning || got[1] != JobStatusAwaitingReview {
+		t.Errorf("JobStatusSources(succeeded) = %v, want [running awaiting_review]", got)
+	}
+}
+
+func TestJobSetDurationMs(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	end := start.Add(1500 * time.Millisecond)
+
+	job := &Job{StartedAt: &start}
+	job.SetDurationMs()
+	if job.DurationMs != nil {
+		t.Fatalf("unfinished job: DurationMs = %d, want nil", *job.DurationMs)
+	}
+
+	job.FinishedAt = &end
+	job.SetDurationMs()
+	if job.DurationMs == nil || *job.DurationMs != 1500 {
+		t.Fatalf("DurationMs = %v, want 1500", job.DurationMs)
+	}
+}
+
+func TestNewJobProgress(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      string
+		step        string
+		total, done int
+		want        int
+		wantStep    string
+	}{
+		{"not picked up", JobStatusQueued, "", 0, 0, 0, JobStepQueued},
+		{"segmenting", JobStatusRunning, JobStepSegmenting, 0, 0, 10, JobStepSegmenting},
+		{"half generated", JobStatusRunning, JobStepGenerating, 4, 2, 52, JobStepGenerating},
+		{"finalizing", JobStatusRunning, JobStepFinalizing, 4, 4, 95, JobStepFinalizing},
+		{"succeeded", JobStatusSucceeded, JobStepDone, 4, 4, 100, JobStepDone},
+	}
+	for _, tt := range tests {
+		got := NewJobProgress(tt.status, tt.step, tt.total, tt.done, 0)
+		if got.Percent != tt.want || got.Step != tt.wantStep {
+			t.Errorf("%s: got step=%s percent=%d, want step=%s percent=%d", tt.name, got.Step, got.Percent, tt.wantStep, tt.want)
+		}
+	}
+}
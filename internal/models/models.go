@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,9 +9,34 @@ import (
 
 // User represents a user in the system
 type User struct {
-	ID        uuid.UUID `json:"id"`
-	Email     *string   `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
+	ID                 uuid.UUID `json:"id"`
+	Email              *string   `json:"email"`
+	EmailNotifications bool      `json:"email_notifications"` // email the user when their jobs complete or fail
+	Lexicon            []LexiconEntry `json:"lexicon,omitempty"` // pronunciations applied to all the user's jobs
+	Settings           *UserSettings  `json:"settings,omitempty"` // defaults for the user's new jobs
+	EgressAllowlist    []string       `json:"egress_allowlist,omitempty"` // hosts the user's webhooks and sinks may target (all when empty)
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// UserSettings are a user's defaults for new jobs (/v1/settings): CreateJob fills each field the request
+// omits with the setting, if set. Type also selects the narration and image style, and SegmentsCount the
+// number of pictures (one per segment).
+type UserSettings struct {
+	Type          string         `json:"type,omitempty"`
+	SegmentsCount int            `json:"segments_count,omitempty"`
+	AudioType     string         `json:"audio_type,omitempty"`
+	VoiceID       *uuid.UUID     `json:"voice_id,omitempty"`
+	Webhook       *WebhookConfig `json:"webhook,omitempty"`
+}
+
+// LexiconEntry is a pronunciation applied to narration scripts before TTS: occurrences of Term are
+// replaced with Replacement (a respelling such as "koo-ber-NET-eez"), or spoken as the IPA transcription.
+// Exactly one of Replacement and IPA is set.
+type LexiconEntry struct {
+	Term          string `json:"term"`
+	Replacement   string `json:"replacement,omitempty"`
+	IPA           string `json:"ipa,omitempty"`
+	CaseSensitive bool   `json:"case_sensitive,omitempty"` // match Term's case exactly (default ignores case)
 }
 
 // APIKey represents an API key for authentication
@@ -22,10 +48,40 @@ type APIKey struct {
 	QuotaPeriod       string    `json:"quota_period"` // daily, weekly, monthly, yearly
 	QuotaChars        int64     `json:"quota_chars"`
 	UsedCharsInPeriod int64     `json:"used_chars_in_period"`
+	CostCapUSD        *float64  `json:"cost_cap_usd,omitempty"` // estimated LLM cost allowed per period; nil is no cap
+	UsedCostInPeriod  float64   `json:"used_cost_in_period"`    // estimated LLM cost (USD) of the period's jobs
+	Scopes            []string  `json:"scopes,omitempty"`       // what the key may use (see HasScope); nil is unrestricted
 	PeriodStartedAt   time.Time `json:"period_started_at"`
 	CreatedAt         time.Time `json:"created_at"`
 }
 
+// QuotaPeriodDuration returns the length of a quota period (monthly when unknown).
+func QuotaPeriodDuration(period string) time.Duration {
+	switch period {
+	case "daily":
+		return 24 * time.Hour
+	case "weekly":
+		return 7 * 24 * time.Hour
+	case "monthly":
+		return 30 * 24 * time.Hour
+	case "yearly":
+		return 365 * 24 * time.Hour
+	default:
+		return 30 * 24 * time.Hour
+	}
+}
+
+// Cost cap actions (COST_CAP_ACTION): what happens to the new jobs of an API key that reached its cost cap.
+const (
+	CostCapReject = "reject" // job creation fails
+	CostCapQueue  = "queue"  // jobs are created and wait for the next quota period
+)
+
+// CostCapReached reports whether the key has a cost cap and its period's estimated cost has reached it.
+func (k *APIKey) CostCapReached() bool {
+	return k.CostCapUSD != nil && k.UsedCostInPeriod >= *k.CostCapUSD
+}
+
 // Job represents an enrichment job
 type Job struct {
 	ID            uuid.UUID  `json:"id"`
@@ -35,18 +91,101 @@ type Job struct {
 	InputType     string     `json:"input_type"` // educational, financial, fictional
 	SegmentsCount int        `json:"segments_count"`
 	AudioType     string     `json:"audio_type"` // free_speech, podcast
-	InputText     string     `json:"input_text"`
+	InputText     string     `json:"input_text,omitempty"`
 	InputSource   string     `json:"input_source"`   // text, files, mixed
 	ExtractedText *string    `json:"extracted_text,omitempty"`
 	OutputMarkup  *string    `json:"output_markup,omitempty"`
 	WebhookURL     *string    `json:"webhook_url,omitempty"`
 	WebhookSecret  *string    `json:"webhook_secret,omitempty"`
+	WebhookPayload *string    `json:"webhook_payload,omitempty"` // status, summary or full; nil means summary
+	WebhookEncryptionKey *string `json:"webhook_encryption_key,omitempty"` // PEM public key; webhook bodies are sent as a JWE when set
+	SegmentationStrategy string `json:"segmentation_strategy"` // llm or heuristic
 	FactCheckNeeded bool      `json:"fact_check_needed"`
+	Metadata       map[string]string `json:"metadata,omitempty"` // caller-supplied key/value labels
+	Tags           []string          `json:"tags,omitempty"`
 	ErrorCode      *string   `json:"error_code,omitempty"`
 	ErrorMessage   *string   `json:"error_message,omitempty"`
 	CreatedAt      time.Time `json:"created_at"`
 	StartedAt      *time.Time `json:"started_at,omitempty"`
 	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+	DurationMs     *int64     `json:"duration_ms,omitempty"` // finished_at - started_at; set by the repository when both are known
+	Progress       *JobProgress `json:"progress,omitempty"`    // set by the repository from the progress columns
+	ContentHash    *string      `json:"-"`                      // fingerprint of input and options, for dedupe
+	CharsCharged   int64        `json:"-"`                      // quota characters charged at creation, for stats
+	DuplicateOf    *uuid.UUID   `json:"duplicate_of,omitempty"` // job whose results this job reuses (dedupe)
+	Experiments    map[string]string `json:"experiments,omitempty"` // LLM experiment name -> variant the job ran with
+	QualityCheck   bool              `json:"quality_check"`         // score segments with the quality evaluator
+	RequireReview  bool              `json:"require_review"`        // stop in awaiting_review until a reviewer approves
+	NotifyEmail    *bool             `json:"notify_email,omitempty"` // email the creator on completion/failure; nil follows the user's setting
+	Lexicon        []LexiconEntry    `json:"lexicon,omitempty"`      // job pronunciations, overriding the user's lexicon
+	VoiceID        *uuid.UUID        `json:"voice_id,omitempty"`     // custom voice of the narration; nil uses the default voice
+	Jurisdiction   *string           `json:"jurisdiction,omitempty"` // selects the compliance disclaimer of financial jobs
+	DisclaimerJurisdiction *string   `json:"disclaimer_jurisdiction,omitempty"` // disclaimer applied (financial jobs), set by the worker
+	DisclaimerVersion      *int      `json:"disclaimer_version,omitempty"`
+	ExternalTool   *ExternalTool     `json:"external_tool,omitempty"` // MCP tool called per segment, results in segment metadata
+	DependsOn      []uuid.UUID       `json:"depends_on,omitempty"`    // jobs that must succeed before this one runs
+	LazyImages     bool              `json:"lazy_images,omitempty"`   // images are generated on their first download
+	Preview        *JobPreview       `json:"preview,omitempty"`       // card preview for job lists, set by the worker
+	ReviewRegeneration *ReviewRegeneration `json:"review_regeneration,omitempty"` // pending reviewer request, cleared once regenerated
+	ExpiresAt      *time.Time        `json:"expires_at,omitempty"`    // retention end; the job expiry loop deletes its assets after it
+	ExpiredAt      *time.Time        `json:"expired_at,omitempty"`    // set once the job has expired (GetJob answers 410)
+}
+
+// JobPreview summarizes a job's output for job lists (the index page): a JPEG sprite of square thumbnails
+// of the first segment images laid out left to right, the start of the narration and the audio duration.
+type JobPreview struct {
+	Thumbnail        string  `json:"thumbnail,omitempty"`       // data:image/jpeg;base64 URL of the sprite
+	ThumbnailTiles   int     `json:"thumbnail_tiles,omitempty"` // tiles in the sprite, each ThumbnailSize px square
+	ThumbnailSize    int     `json:"thumbnail_size,omitempty"`
+	NarrationExcerpt string  `json:"narration_excerpt,omitempty"`
+	DurationSeconds  float64 `json:"duration_seconds,omitempty"` // sum of the segment audio durations
+}
+
+// ExternalTool is an MCP server tool (e.g. a customer's terminology checker) the worker calls for each
+// segment with the segment's text, title, index and input type plus Arguments. The tool's result is
+// stored in the segment's metadata under the tool name.
+type ExternalTool struct {
+	ServerURL string         `json:"server_url"` // JSON-RPC endpoint of the MCP server (tools/call)
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments,omitempty"`  // fixed arguments sent with every call
+	AuthToken *string        `json:"auth_token,omitempty"` // sent as a bearer token
+	Required  bool           `json:"required,omitempty"`   // a failed call fails the segment instead of being recorded
+}
+
+// ReviewRegeneration is a reviewer's request to regenerate segments of a job awaiting review. It is both
+// the request body of POST /v1/jobs/{id}/review/regenerate and the pending request stored on the job.
+type ReviewRegeneration struct {
+	Segments []int  `json:"segments"`          // segment indices (idx)
+	Comment  string `json:"comment,omitempty"` // passed to the models as reviewer feedback
+}
+
+// ReviewApproval is the request body of POST /v1/jobs/{id}/review/approve.
+type ReviewApproval struct {
+	Comment string `json:"comment,omitempty"`
+}
+
+// ResultJobID returns the job whose segments and assets hold this job's results: the deduplication
+// source for duplicates, otherwise the job itself.
+func (j *Job) ResultJobID() uuid.UUID {
+	if j.DuplicateOf != nil {
+		return *j.DuplicateOf
+	}
+	return j.ID
+}
+
+// Expired reports whether the job's retention has ended at now, whether or not the job expiry loop has
+// deleted its assets yet.
+func (j *Job) Expired(now time.Time) bool {
+	return j.ExpiredAt != nil || (j.ExpiresAt != nil && !now.Before(*j.ExpiresAt))
+}
+
+// SetDurationMs fills DurationMs from StartedAt/FinishedAt, or clears it when the job has not finished processing.
+func (j *Job) SetDurationMs() {
+	j.DurationMs = nil
+	if j.StartedAt != nil && j.FinishedAt != nil {
+		ms := j.FinishedAt.Sub(*j.StartedAt).Milliseconds()
+		j.DurationMs = &ms
+	}
 }
 
 // File represents an uploaded file available for job processing
@@ -61,6 +200,20 @@ type File struct {
 	Status    string    `json:"status"` // pending, ready, failed, expired
 	ExpiresAt time.Time `json:"expires_at"`
 	CreatedAt time.Time `json:"created_at"`
+	// Default extraction options for jobs using the file (overridable per job with file_options)
+	ExtractionOptions *ExtractionOptions `json:"extraction_options,omitempty"`
+}
+
+// ExtractionOptions tune how vision extraction reads an uploaded file.
+type ExtractionOptions struct {
+	Language       string `json:"language,omitempty"`        // BCP 47 language of the content, e.g. "de" or "zh-Hant"
+	Handwriting    bool   `json:"handwriting,omitempty"`     // the content is handwritten
+	PreserveTables bool   `json:"preserve_tables,omitempty"` // keep tables as Markdown tables instead of describing them
+}
+
+// IsZero reports whether no option is set (nil included).
+func (o *ExtractionOptions) IsZero() bool {
+	return o == nil || *o == ExtractionOptions{}
 }
 
 // FileInResponse is File without S3 private fields for API responses (e.g. list files)
@@ -74,6 +227,8 @@ func (f File) ToInResponse() FileInResponse {
 		Status:    f.Status,
 		ExpiresAt: f.ExpiresAt,
 		CreatedAt: f.CreatedAt,
+
+		ExtractionOptions: f.ExtractionOptions,
 	}
 }
 
@@ -86,17 +241,66 @@ type FileInResponse struct {
 	Status    string    `json:"status"` // pending, ready, failed, expired
 	ExpiresAt time.Time `json:"expires_at"`
 	CreatedAt time.Time `json:"created_at"`
+
+	ExtractionOptions *ExtractionOptions `json:"extraction_options,omitempty"`
+}
+
+// FilePage is one page of a user's files, newest first
+type FilePage struct {
+	Files      []FileInResponse `json:"files"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+	HasMore    bool             `json:"has_more"`
 }
 
 // JobFile links jobs to files
 type JobFile struct {
-	ID              uuid.UUID  `json:"id"`
-	JobID           uuid.UUID  `json:"job_id"`
-	FileID          uuid.UUID  `json:"file_id"`
-	ProcessingOrder int        `json:"processing_order"`
-	ExtractedText   *string    `json:"extracted_text,omitempty"`
-	Status          string     `json:"status"` // pending, processing, succeeded, failed
-	CreatedAt       time.Time  `json:"created_at"`
+	ID              uuid.UUID    `json:"id"`
+	JobID           uuid.UUID    `json:"job_id"`
+	FileID          uuid.UUID    `json:"file_id"`
+	ProcessingOrder int          `json:"processing_order"`
+	ExtractedText   *string      `json:"extracted_text,omitempty"`
+	Status          string       `json:"status"` // pending, processing, succeeded, failed
+	CreatedAt       time.Time    `json:"created_at"`
+	Meta            *JobFileMeta `json:"meta,omitempty"`
+	// Options the file is extracted with: the job's file_options entry, else the file's defaults
+	ExtractionOptions *ExtractionOptions `json:"extraction_options,omitempty"`
+}
+
+// JobFileMeta is extraction metadata for a job file. PDFs extracted page by page record where each page's
+// text sits in ExtractedText.
+type JobFileMeta struct {
+	PageCount int                `json:"page_count,omitempty"`
+	Pages     []JobFilePageRange `json:"pages,omitempty"`
+	Strategy  string             `json:"strategy,omitempty"` // how the text was produced (ExtractionStrategy*); empty for plain vision
+}
+
+// How a job file's text was produced (JobFileMeta.Strategy); empty means Gemini vision alone. All but
+// local_first are fallbacks used when Gemini blocks extraction for recitation.
+const (
+	ExtractionStrategyLocalFirst       = "local_first"       // PDF text layer read locally; only pages without one sent to vision
+	ExtractionStrategyStrictParaphrase = "strict_paraphrase" // vision with a stricter paraphrase prompt
+	ExtractionStrategyChunked          = "chunked"           // PDF pages extracted in small chunks; blocked chunks use the local text
+	ExtractionStrategyLocalText        = "local_text"        // text layer read from the PDF without Gemini
+)
+
+// JobFilePageRange locates one source page in a job file's extracted text: [StartChar, EndChar) byte offsets.
+type JobFilePageRange struct {
+	Page      int    `json:"page"`
+	StartChar int    `json:"start_char"`
+	EndChar   int    `json:"end_char"`
+	Source    string `json:"source,omitempty"` // PageSourceVision or PageSourceTextLayer
+}
+
+// Where a page's extracted text came from (JobFilePageRange.Source).
+const (
+	PageSourceVision    = "vision"     // Gemini vision summary
+	PageSourceTextLayer = "text_layer" // the PDF's own text, read locally
+)
+
+// SegmentSourcePage is a page of an uploaded file that a segment's text was extracted from.
+type SegmentSourcePage struct {
+	FileID uuid.UUID `json:"file_id"`
+	Page   int       `json:"page"`
 }
 
 // SegmentFactCheck holds fact-check output for a segment (up to 512 chars).
@@ -108,6 +312,47 @@ type SegmentFactCheck struct {
 	CreatedAt     time.Time `json:"created_at"`
 }
 
+// Job event types recorded in the per-job event log
+const (
+	JobEventCreated               = "created"
+	JobEventQueued                = "queued"
+	JobEventPublishFailed         = "publish_failed"
+	JobEventPickedUp              = "picked_up"
+	JobEventSegmentationCompleted = "segmentation_completed"
+	JobEventSegmentFailed         = "segment_failed"
+	JobEventSucceeded             = "succeeded"
+	JobEventFailed                = "failed"
+	JobEventWebhookDelivered      = "webhook_delivered"
+	JobEventWebhookFailed         = "webhook_failed"
+	JobEventRequeued              = "requeued" // republished by an operator (storiesctl)
+	JobEventDeduplicated          = "deduplicated" // linked to an identical earlier job (dedupe)
+	JobEventAwaitingReview        = "awaiting_review"
+	JobEventReviewApproved        = "review_approved"
+	JobEventChangesRequested      = "changes_requested" // reviewer asked for segments to be regenerated
+	JobEventSegmentEdited         = "segment_edited"    // user edited a segment (PATCH); data.stage is requested or regenerated
+	JobEventAssetReplaced         = "asset_replaced"    // user uploaded a replacement image or audio
+	JobEventOutputRestored        = "output_restored"   // user restored an earlier output version
+	JobEventRetryScheduled        = "retry_scheduled"   // transient failure; the job is retried from a delayed retry topic
+	JobEventNotificationSent      = "notification_sent"   // job event delivered to one of the user's notification sinks
+	JobEventNotificationFailed    = "notification_failed" // job event could not be delivered to a notification sink
+	JobEventWaiting               = "waiting"             // a depends_on job has not succeeded yet
+	JobEventWebhookRedelivery     = "webhook_redelivery"  // user asked to redeliver the job's webhook
+	JobEventCostCapped            = "cost_capped"         // the API key reached its cost cap; the job waits for the next period
+	JobEventCanceled              = "canceled"            // canceled by the user (POST /v1/jobs/{id}/cancel)
+	JobEventRetryRequested        = "retry_requested"     // the user retried the failed job (POST /v1/jobs/{id}/retry)
+	JobEventWebhookUpdated        = "webhook_updated"     // the user changed the job's webhook (PATCH /v1/jobs/{id})
+)
+
+// JobEvent is one entry in a job's event timeline
+type JobEvent struct {
+	ID        uuid.UUID      `json:"id"`
+	JobID     uuid.UUID      `json:"job_id"`
+	Type      string         `json:"type"`
+	Message   string         `json:"message"`
+	Data      map[string]any `json:"data,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
 // Segment represents a text segment within a job
 type Segment struct {
 	ID          uuid.UUID `json:"id"`
@@ -116,10 +361,57 @@ type Segment struct {
 	StartChar   int       `json:"start_char"`
 	EndChar     int       `json:"end_char"`
 	Title       *string   `json:"title,omitempty"`
-	SegmentText string    `json:"segment_text"`
-	Status      string    `json:"status"` // queued, running, succeeded, failed
+	SegmentText string    `json:"segment_text,omitempty"`
+	Status      string    `json:"status"` // queued, running, succeeded, failed, canceled (the job was canceled first)
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	// Quality evaluator results (jobs with quality_check): scores 1-5, judge reasons, regenerations
+	NarrationScore       *int    `json:"narration_score,omitempty"`
+	ImagePromptScore     *int    `json:"image_prompt_score,omitempty"`
+	QualityNotes         *string `json:"quality_notes,omitempty"`
+	QualityRegenerations int     `json:"quality_regenerations,omitempty"`
+	// Script the audio was generated from, and when the segment was last edited by a user
+	NarrationText *string    `json:"narration_text,omitempty"`
+	EditedAt      *time.Time `json:"edited_at,omitempty"`
+	// Source pages of uploaded PDFs the segment was extracted from (files/mixed jobs with page-by-page extraction)
+	SourcePages []SegmentSourcePage `json:"source_pages,omitempty"`
+	// Results of the job's external tool by tool name ({"error": ...} for failed optional calls)
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// UpdateSegmentRequest is the request body of PATCH /v1/jobs/{id}/segments/{idx}. Changing segment_text
+// without narration_text regenerates the narration from the new text; the audio is always regenerated.
+type UpdateSegmentRequest struct {
+	SegmentText     *string `json:"segment_text,omitempty"`
+	NarrationText   *string `json:"narration_text,omitempty"`
+	RegenerateImage bool    `json:"regenerate_image,omitempty"`
+}
+
+// UpdateJobRequest is the request body of PATCH /v1/jobs/{id}: the webhook of a job that has not finished.
+// webhook.url is required; omitted secret, payload and encryption_key keep their current values, and an
+// empty secret or encryption_key removes it.
+type UpdateJobRequest struct {
+	Webhook *WebhookConfig `json:"webhook"`
+}
+
+// SegmentQuality is the quality evaluator's result for one segment. Nil scores mean the output
+// was not scored (evaluator unavailable or failed).
+type SegmentQuality struct {
+	NarrationScore   *int
+	ImagePromptScore *int
+	Notes            string
+	Regenerations    int
+}
+
+// AddNote appends the judge's reason for one output kind to Notes ("narration: ...; image_prompt: ...").
+func (q *SegmentQuality) AddNote(kind, reason string) {
+	if reason == "" {
+		return
+	}
+	if q.Notes != "" {
+		q.Notes += "; "
+	}
+	q.Notes += kind + ": " + reason
 }
 
 // Asset represents a generated asset (image or audio)
@@ -127,7 +419,7 @@ type Asset struct {
 	ID        uuid.UUID      `json:"id"`
 	JobID     uuid.UUID      `json:"job_id"`
 	SegmentID *uuid.UUID     `json:"segment_id,omitempty"`
-	Kind      string         `json:"kind"` // image, audio
+	Kind      string         `json:"kind"` // image, audio, rss (podcast feed of the job, no segment)
 	MimeType  string         `json:"mime_type"`
 	S3Bucket  string         `json:"s3_bucket"`
 	S3Key     string         `json:"s3_key"`
@@ -135,6 +427,16 @@ type Asset struct {
 	Checksum  *string        `json:"checksum,omitempty"`
 	Meta      map[string]any `json:"meta,omitempty"`
 	CreatedAt time.Time      `json:"created_at"`
+	// Version counts per segment and kind; superseded assets were replaced by a newer version
+	Version      int        `json:"version"`
+	SupersededAt *time.Time `json:"superseded_at,omitempty"`
+}
+
+// Pending reports whether the asset is an image of a lazy_images job that has not been generated yet
+// (meta.pending): its object does not exist until the first download.
+func (a *Asset) Pending() bool {
+	pending, _ := a.Meta["pending"].(bool)
+	return pending
 }
 
 // AssetInResponse is Asset without S3 private fields for API responses
@@ -149,6 +451,9 @@ func (a Asset) ToInResponse() AssetInResponse {
 		Checksum:  a.Checksum,
 		Meta:      a.Meta,
 		CreatedAt: a.CreatedAt,
+
+		Version:      a.Version,
+		SupersededAt: a.SupersededAt,
 	}
 }
 
@@ -162,6 +467,39 @@ type AssetInResponse struct {
 	Checksum  *string        `json:"checksum,omitempty"`
 	Meta      map[string]any `json:"meta,omitempty"`
 	CreatedAt time.Time      `json:"created_at"`
+	// Version counts per segment and kind; superseded assets were replaced by a newer version
+	Version      int        `json:"version"`
+	SupersededAt *time.Time `json:"superseded_at,omitempty"`
+}
+
+// Output version reasons: what changed a job's output when the version was recorded
+const (
+	OutputVersionGenerated          = "generated"
+	OutputVersionReviewRegeneration = "review_regeneration"
+	OutputVersionSegmentEdit        = "segment_edit"
+	OutputVersionAssetReplaced      = "asset_replaced"
+	OutputVersionRestored           = "restored"
+)
+
+// OutputVersion is a snapshot of a job's output (markup, segment texts and the assets they used), recorded
+// each time the output changes. Restoring a version makes its markup, texts and assets current again.
+type OutputVersion struct {
+	ID           uuid.UUID              `json:"id"`
+	JobID        uuid.UUID              `json:"job_id"`
+	Version      int                    `json:"version"`
+	Reason       string                 `json:"reason"`
+	OutputMarkup *string                `json:"output_markup,omitempty"`
+	Segments     []OutputVersionSegment `json:"segments,omitempty"`
+	Current      bool                   `json:"current"`
+	CreatedAt    time.Time              `json:"created_at"`
+}
+
+// OutputVersionSegment is one segment as it was in an output version
+type OutputVersionSegment struct {
+	Idx           int         `json:"idx"`
+	SegmentText   string      `json:"segment_text"`
+	NarrationText *string     `json:"narration_text,omitempty"`
+	AssetIDs      []uuid.UUID `json:"asset_ids"`
 }
 
 // WebhookDelivery represents a webhook delivery attempt
@@ -173,9 +511,123 @@ type WebhookDelivery struct {
 	Attempts      int        `json:"attempts"`
 	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty"`
 	LastError     *string    `json:"last_error,omitempty"`
+	Redeliveries  int        `json:"redeliveries"` // redeliver requests; Attempts counts the current round
 	CreatedAt     time.Time  `json:"created_at"`
 }
 
+// WebhookDeliveryAttempt is one POST of a webhook delivery, with the body exactly as sent.
+type WebhookDeliveryAttempt struct {
+	ID         uuid.UUID       `json:"id"`
+	DeliveryID uuid.UUID       `json:"delivery_id"`
+	Redelivery int             `json:"redelivery"` // 0 for the original delivery, n for the n-th redeliver request
+	Attempt    int             `json:"attempt"`    // 1-based within the round
+	Payload    json.RawMessage `json:"payload"`
+	StatusCode *int            `json:"status_code,omitempty"` // receiver's HTTP status; nil when no response
+	Error      *string         `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// WebhookDeliveryDetail is the response of GET /v1/webhook-deliveries/{id}.
+type WebhookDeliveryDetail struct {
+	*WebhookDelivery
+	History []*WebhookDeliveryAttempt `json:"history"` // oldest first
+}
+
+// Job events published to the events topic. The dispatcher routes each to the user's notification sinks;
+// the job's own webhook receives only the final job_completed, job_failed or job_canceled.
+const (
+	EventJobCompleted      = "job_completed"
+	EventJobFailed         = "job_failed"
+	EventJobCanceled       = "job_canceled"
+	EventJobAwaitingReview = "job_awaiting_review"
+	EventJobRetryScheduled = "job_retry_scheduled"
+)
+
+// NotificationEvents lists the job events notification sinks can subscribe to
+var NotificationEvents = []string{EventJobCompleted, EventJobFailed, EventJobCanceled, EventJobAwaitingReview, EventJobRetryScheduled}
+
+// Notification sink types
+const (
+	SinkTypeWebhook = "webhook" // signed JSON POST, like job webhooks
+	SinkTypeEmail   = "email"   // plain-text email via SMTP
+	SinkTypeSlack   = "slack"   // Slack incoming webhook
+	SinkTypeTeams   = "teams"   // Microsoft Teams incoming webhook
+	SinkTypeSNS     = "sns"     // AWS SNS topic
+)
+
+// NotificationSink is a destination for the job events of all of a user's jobs
+type NotificationSink struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"-"`
+	Type      string    `json:"type"`
+	Target    string    `json:"target"`             // URL, email address or SNS topic ARN
+	Secret    *string   `json:"-"`                  // HMAC secret of webhook sinks; never returned
+	Events    []string  `json:"events"`             // empty means all events
+	Template  *string   `json:"template,omitempty"` // message text template of slack and teams sinks
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Receives reports whether the sink subscribes to event.
+func (s *NotificationSink) Receives(event string) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, e := range s.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationDelivery is the delivery of one job event to one notification sink
+type NotificationDelivery struct {
+	ID            uuid.UUID  `json:"id"`
+	SinkID        *uuid.UUID `json:"sink_id,omitempty"` // nil for emails to the job creator
+	JobID         uuid.UUID  `json:"job_id"`
+	Event         string     `json:"event"`
+	Status        string     `json:"status"` // pending, sent, failed
+	Attempts      int        `json:"attempts"`
+	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty"`
+	LastError     *string    `json:"last_error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// CreateNotificationSinkRequest represents a request to add a notification sink
+type CreateNotificationSinkRequest struct {
+	Type   string   `json:"type"`
+	Target string   `json:"target"`
+	Secret *string  `json:"secret,omitempty"`
+	Events []string `json:"events,omitempty"`
+	// Template is a Go text/template for the message text of slack and teams sinks, executed with the
+	// notify.Event; omitted uses the built-in template
+	Template *string `json:"template,omitempty"`
+}
+
+// Voice is a custom voice registered with a TTS provider, selected per job with voice_id
+type Voice struct {
+	ID              uuid.UUID      `json:"id"`
+	UserID          uuid.UUID      `json:"-"`
+	Name            string         `json:"name"`
+	Provider        string         `json:"provider"`          // TTS provider, e.g. gemini
+	ProviderVoiceID string         `json:"provider_voice_id"` // the provider's name or ID of the voice
+	Style           *string        `json:"style,omitempty"`   // speaking style instructions added to TTS calls
+	ReferenceURL    *string        `json:"reference_url,omitempty"`
+	Meta            map[string]any `json:"meta,omitempty"` // provider-specific details
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+// CreateVoiceRequest is the request body of POST /v1/voices. A voice is based on one of the provider's
+// voices (base_voice) or, at providers that clone voices, on a sample recording (reference_url).
+type CreateVoiceRequest struct {
+	Name         string `json:"name"`
+	Provider     string `json:"provider,omitempty"` // defaults to gemini
+	BaseVoice    string `json:"base_voice,omitempty"`
+	Style        string `json:"style,omitempty"`
+	ReferenceURL string `json:"reference_url,omitempty"`
+}
+
 // CreateJobRequest represents a request to create a new job
 type CreateJobRequest struct {
 	Text            string         `json:"text,omitempty"`
@@ -185,19 +637,109 @@ type CreateJobRequest struct {
 	AudioType       string         `json:"audio_type"` // free_speech, podcast
 	FactCheckNeeded *bool          `json:"fact_check_needed,omitempty"`
 	Webhook         *WebhookConfig `json:"webhook,omitempty"`
+	// SegmentationStrategy is llm (default, Gemini with rule-based fallback) or heuristic (rule-based only, no LLM call)
+	SegmentationStrategy string `json:"segmentation_strategy,omitempty"`
+	// Metadata and Tags are stored with the job and echoed in GetJob and webhooks (e.g. integrator document IDs)
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+	// Dedupe links the job to an identical earlier job of the same user (same input and options, still
+	// queued or running, or succeeded) and reuses its results instead of running the pipeline again
+	Dedupe bool `json:"dedupe,omitempty"`
+	// QualityCheck scores each segment's narration and image prompt with an LLM judge and regenerates
+	// outputs scoring below the configured threshold
+	QualityCheck bool `json:"quality_check,omitempty"`
+	// RequireReview stops the job in awaiting_review after the pipeline; webhooks fire only after approval
+	RequireReview bool `json:"require_review,omitempty"`
+	// NotifyEmail emails the creator (the user's email address) when the job completes or fails; omitted
+	// follows the user's email_notifications setting
+	NotifyEmail *bool `json:"notify_email,omitempty"`
+	// Lexicon adds pronunciations for this job; entries override the user's lexicon for the same term
+	Lexicon []LexiconEntry `json:"lexicon,omitempty"`
+	// VoiceID narrates the job with one of the user's custom voices (/v1/voices)
+	VoiceID *uuid.UUID `json:"voice_id,omitempty"`
+	// Jurisdiction selects the compliance disclaimer appended to financial jobs (e.g. "US"); jobs without
+	// one, or whose jurisdiction has no disclaimer, get the DEFAULT disclaimer
+	Jurisdiction string `json:"jurisdiction,omitempty"`
+	// FileOptions overrides the extraction options of files in file_ids (by file ID) for this job
+	FileOptions map[uuid.UUID]*ExtractionOptions `json:"file_options,omitempty"`
+	// ExternalTool calls a tool of an external MCP server for each segment
+	ExternalTool *ExternalTool `json:"external_tool,omitempty"`
+	// DependsOn lists jobs of the same user that must succeed before this job runs; it fails if one of them
+	// fails. Without text and file_ids the job's input is the segments of the first of them.
+	DependsOn []uuid.UUID `json:"depends_on,omitempty"`
+	// LazyImages stores each segment's image prompt instead of generating the image; the image is generated
+	// when it is first downloaded (GET /v1/assets/{id}/content or the view page)
+	LazyImages bool `json:"lazy_images,omitempty"`
+	// ExpiresAt and RetentionDays (days from creation) set when the job expires: its assets are deleted and
+	// GET /v1/jobs/{id} answers 410. At most one of them may be set; without either the job is kept.
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	RetentionDays *int       `json:"retention_days,omitempty"`
+}
+
+// CloneJobRequest is the request body of POST /v1/jobs/{id}/clone. The clone gets the source job's input
+// (text and files) and options; set fields override the options. Metadata and tags replace the source's
+// when given.
+type CloneJobRequest struct {
+	Type                 *string           `json:"type,omitempty"`
+	SegmentsCount        *int              `json:"segments_count,omitempty"`
+	AudioType            *string           `json:"audio_type,omitempty"`
+	FactCheckNeeded      *bool             `json:"fact_check_needed,omitempty"`
+	SegmentationStrategy *string           `json:"segmentation_strategy,omitempty"`
+	QualityCheck         *bool             `json:"quality_check,omitempty"`
+	RequireReview        *bool             `json:"require_review,omitempty"`
+	NotifyEmail          *bool             `json:"notify_email,omitempty"`
+	Lexicon              []LexiconEntry    `json:"lexicon,omitempty"`
+	VoiceID              *uuid.UUID        `json:"voice_id,omitempty"`
+	Jurisdiction         *string           `json:"jurisdiction,omitempty"`
+	ExternalTool         *ExternalTool     `json:"external_tool,omitempty"`
+	DependsOn            []uuid.UUID       `json:"depends_on,omitempty"`
+	LazyImages           *bool             `json:"lazy_images,omitempty"`
+	Webhook              *WebhookConfig    `json:"webhook,omitempty"`
+	Metadata             map[string]string `json:"metadata,omitempty"`
+	Tags                 []string          `json:"tags,omitempty"`
+}
+
+// Segmentation strategies for jobs
+const (
+	SegmentationStrategyLLM       = "llm"
+	SegmentationStrategyHeuristic = "heuristic"
+)
+
+// JobListFilter narrows ListJobs results. Empty fields match all jobs.
+type JobListFilter struct {
+	Tags          []string          // job must carry every tag
+	Metadata      map[string]string // job metadata must contain every key/value pair
+	Statuses      []string          // job status must be one of these
+	Types         []string          // job type must be one of these
+	AudioTypes    []string          // job audio_type must be one of these
+	CreatedAfter  *time.Time        // jobs created at or after this time
+	CreatedBefore *time.Time        // jobs created before this time
+	Query         string            // words (web search syntax) the job's input_text must contain
 }
 
 // WebhookConfig represents webhook configuration for a job
 type WebhookConfig struct {
-	URL    string  `json:"url"`
-	Secret *string `json:"secret,omitempty"`
+	URL     string  `json:"url"`
+	Secret  *string `json:"secret,omitempty"`
+	Payload string  `json:"payload,omitempty"` // status, summary (default) or full
+	// EncryptionKey is a PEM public key (RSA or EC); when set the webhook body is a compact JWE encrypted
+	// for it (Content-Type application/jose) instead of plaintext JSON. See package webhookjwe.
+	EncryptionKey *string `json:"encryption_key,omitempty"`
 }
 
+// Webhook payload modes: how much of the job a webhook carries. Receivers fetch the rest from result_url.
+const (
+	WebhookPayloadStatus  = "status"  // job ID, status, timestamps, error
+	WebhookPayloadSummary = "summary" // status plus labels, duration and segment/asset counts
+	WebhookPayloadFull    = "full"    // summary plus output_markup
+)
+
 // CreateJobResponse represents the response when creating a job
 type CreateJobResponse struct {
-	JobID     uuid.UUID `json:"job_id"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
+	JobID       uuid.UUID  `json:"job_id"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DuplicateOf *uuid.UUID `json:"duplicate_of,omitempty"`
 }
 
 // UploadFileResponse returned after file upload
@@ -207,24 +749,300 @@ type UploadFileResponse struct {
 	MimeType  string    `json:"mime_type"`
 	SizeBytes int64     `json:"size_bytes"`
 	ExpiresAt time.Time `json:"expires_at"`
+
+	ExtractionOptions *ExtractionOptions `json:"extraction_options,omitempty"`
+}
+
+// PresignFileRequest is the body of POST /v1/files/presign: the file the client will upload to S3 and its
+// default extraction options (as the upload form fields)
+type PresignFileRequest struct {
+	Filename  string `json:"filename"`
+	MimeType  string `json:"mime_type"`
+	SizeBytes int64  `json:"size_bytes"`
+	ExtractionOptions
+}
+
+// PresignFileResponse is a pending file and the presigned request that uploads it: PUT the bytes to
+// UploadURL with UploadHeaders before UploadExpiresAt, then confirm the file
+type PresignFileResponse struct {
+	FileID          uuid.UUID         `json:"file_id"`
+	UploadURL       string            `json:"upload_url"`
+	UploadMethod    string            `json:"upload_method"`
+	UploadHeaders   map[string]string `json:"upload_headers"`
+	UploadExpiresAt time.Time         `json:"upload_expires_at"`
+	Filename        string            `json:"filename"`
+	MimeType        string            `json:"mime_type"`
+	SizeBytes       int64             `json:"size_bytes"`
+	ExpiresAt       time.Time         `json:"expires_at"`
+
+	ExtractionOptions *ExtractionOptions `json:"extraction_options,omitempty"`
+}
+
+// IngestedObject is an object picked up from the S3 ingestion prefix and the file and job created from it
+type IngestedObject struct {
+	ID        uuid.UUID  `json:"id"`
+	S3Bucket  string     `json:"s3_bucket"`
+	S3Key     string     `json:"s3_key"`
+	ETag      string     `json:"etag"`
+	APIKeyID  uuid.UUID  `json:"api_key_id"`
+	FileID    *uuid.UUID `json:"file_id,omitempty"`
+	JobID     *uuid.UUID `json:"job_id,omitempty"`
+	Status    string     `json:"status"` // processing, ingested, failed
+	Error     *string    `json:"error,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// S3EventNotification is the body of S3 (and MinIO) bucket event notifications; keys are URL-encoded
+type S3EventNotification struct {
+	Records []S3EventRecord `json:"Records"`
+}
+
+// S3EventRecord is one event of an S3EventNotification
+type S3EventRecord struct {
+	EventName string `json:"eventName"` // e.g. ObjectCreated:Put (s3:ObjectCreated:Put from MinIO)
+	S3        struct {
+		Bucket struct {
+			Name string `json:"name"`
+		} `json:"bucket"`
+		Object struct {
+			Key  string `json:"key"`
+			ETag string `json:"eTag"`
+			Size int64  `json:"size"`
+		} `json:"object"`
+	} `json:"s3"`
 }
 
 // JobFileResponse represents file extraction info in job status
 type JobFileResponse struct {
-	FileID        uuid.UUID `json:"file_id"`
-	Filename      string    `json:"filename"`
-	MimeType      string    `json:"mime_type"`
-	ExtractedText *string   `json:"extracted_text,omitempty"`
-	Status        string    `json:"status"`
+	FileID        uuid.UUID    `json:"file_id"`
+	Filename      string       `json:"filename"`
+	MimeType      string       `json:"mime_type"`
+	ExtractedText *string      `json:"extracted_text,omitempty"`
+	Status        string       `json:"status"`
+	Meta          *JobFileMeta `json:"meta,omitempty"`
+
+	ExtractionOptions *ExtractionOptions `json:"extraction_options,omitempty"`
 }
 
-// JobStatusResponse represents detailed job status
+// JobStatusResponse represents detailed job status.
+// Segments and Assets hold the first page only when SegmentsNextCursor / AssetsNextCursor are set;
+// fetch the rest from GET /v1/jobs/{id}/segments and /v1/jobs/{id}/assets.
 type JobStatusResponse struct {
-	Job       Job                  `json:"job"`
-	Segments  []*Segment           `json:"segments"`
-	Assets    []*AssetResponse     `json:"assets"`
-	Files     []*JobFileResponse   `json:"files"`
-	FactChecks []*SegmentFactCheck `json:"fact_checks,omitempty"`
+	Job                Job                 `json:"job"`
+	Segments           []*Segment          `json:"segments"`
+	SegmentsTotal      int                 `json:"segments_total"`
+	SegmentsNextCursor string              `json:"segments_next_cursor,omitempty"`
+	Assets             []*AssetResponse    `json:"assets"`
+	AssetsTotal        int                 `json:"assets_total"`
+	AssetsNextCursor   string              `json:"assets_next_cursor,omitempty"`
+	Files              []*JobFileResponse  `json:"files"`
+	FactChecks         []*SegmentFactCheck `json:"fact_checks,omitempty"`
+	Stats              *JobTextStats       `json:"stats,omitempty"`
+}
+
+// DefaultJurisdiction is the disclaimer of financial jobs without a jurisdiction or whose jurisdiction
+// has no disclaimer
+const DefaultJurisdiction = "DEFAULT"
+
+// Disclaimer is one version of a jurisdiction's compliance disclaimer for financial jobs
+type Disclaimer struct {
+	ID           uuid.UUID  `json:"id"`
+	Jurisdiction string     `json:"jurisdiction"`
+	Version      int        `json:"version"`
+	Text         string     `json:"text"`
+	CreatedAt    time.Time  `json:"created_at"`
+	RetiredAt    *time.Time `json:"retired_at,omitempty"`
+}
+
+// DisclaimerRequest is the body of PUT /admin/disclaimers/{jurisdiction}: the text of the next version
+type DisclaimerRequest struct {
+	Text string `json:"text"`
+}
+
+// FeatureFlag is the rollout of a feature flag (admin API)
+type FeatureFlag struct {
+	Name           string                 `json:"name"`
+	RolloutPercent int                    `json:"rollout_percent"`  // share of users the flag is enabled for
+	Source         string                 `json:"source,omitempty"` // admin, config (FEATURE_FLAGS) or default
+	Overrides      []*FeatureFlagOverride `json:"overrides,omitempty"`
+	UpdatedAt      *time.Time             `json:"updated_at,omitempty"` // set by the admin API
+}
+
+// FeatureFlagOverride turns a feature flag on or off for one user whatever its rollout
+type FeatureFlagOverride struct {
+	FlagName string    `json:"-"`
+	UserID   uuid.UUID `json:"user_id"`
+	Enabled  bool      `json:"enabled"`
+}
+
+// FeatureFlagRequest is the body of PUT /admin/feature-flags/{name}
+type FeatureFlagRequest struct {
+	RolloutPercent *int `json:"rollout_percent"`
+}
+
+// FeatureFlagOverrideRequest is the body of PUT /admin/feature-flags/{name}/users/{user_id}
+type FeatureFlagOverrideRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// Maintenance is the maintenance mode switch (admin API): while enabled, job intake is paused
+type Maintenance struct {
+	Enabled           bool       `json:"enabled"`
+	Reason            string     `json:"reason,omitempty"`
+	RetryAfterSeconds int        `json:"retry_after_seconds"` // Retry-After of refused job creations
+	UpdatedAt         *time.Time `json:"updated_at,omitempty"`
+}
+
+// MaintenanceRequest is the body of PUT /admin/maintenance
+type MaintenanceRequest struct {
+	Enabled           *bool  `json:"enabled"`
+	Reason            string `json:"reason,omitempty"`
+	RetryAfterSeconds *int   `json:"retry_after_seconds,omitempty"` // default 300
+}
+
+// JobStats aggregates jobs created in a window of whole UTC days (GET /v1/stats, GET /admin/stats)
+type JobStats struct {
+	Window          string             `json:"window"` // e.g. 30d
+	From            time.Time          `json:"from"`   // start of the first day
+	To              time.Time          `json:"to"`     // end of the window (now)
+	Totals          JobStatsBucket     `json:"totals"`
+	Days            []JobStatsBucket   `json:"days"` // oldest first, days without jobs included
+	TopFailureCodes []FailureCodeCount `json:"top_failure_codes"`
+}
+
+// JobStatsBucket aggregates the jobs created in a day (or the whole window for totals)
+type JobStatsBucket struct {
+	Date          string   `json:"date,omitempty"` // YYYY-MM-DD (UTC); empty for totals
+	Jobs          int      `json:"jobs"`
+	Succeeded     int      `json:"succeeded"`
+	Failed        int      `json:"failed"`
+	Canceled      int      `json:"canceled"`
+	SuccessRate   *float64 `json:"success_rate,omitempty"`   // succeeded / (succeeded + failed); nil before any finished
+	AvgDurationMs *int64   `json:"avg_duration_ms,omitempty"` // mean finished_at - started_at of finished jobs
+	CharsCharged  int64    `json:"chars_charged"`             // quota characters charged
+}
+
+// FailureCodeCount is how many jobs failed with an error code
+type FailureCodeCount struct {
+	Code  string `json:"code"`
+	Count int    `json:"count"`
+}
+
+// StorageDeletion is an S3 object of a deleted job waiting to be deleted by the storage cleanup loop
+type StorageDeletion struct {
+	ID        int64
+	S3Bucket  string
+	S3Key     string
+	JobID     *uuid.UUID
+	Attempts  int
+	LastError *string
+	CreatedAt time.Time
+}
+
+// Usage ledger entry kinds
+const (
+	UsageKindJobCreated   = "job_created"   // quota characters charged when the job was created
+	UsageKindJobRun       = "job_run"       // tokens, cost, audio and images of a job run, segment edit or regeneration
+	UsageKindJobCompleted = "job_completed" // a job succeeded (duplicates are not recorded)
+)
+
+// UsageEntry is a row of the usage ledger, the record of billable usage. The audio seconds and images of a
+// job run are counted by the repository from the assets the run created.
+type UsageEntry struct {
+	UserID       uuid.UUID
+	APIKeyID     uuid.UUID
+	JobID        uuid.UUID
+	Kind         string
+	Chars        int64
+	InputTokens  int64
+	OutputTokens int64
+	CostUSD      float64
+}
+
+// UnreportedUsage is a usage ledger entry not reported to Stripe metered billing yet
+type UnreportedUsage struct {
+	ID               int64
+	Kind             string
+	Chars            int64
+	AudioSeconds     float64
+	StripeCustomerID *string // Stripe customer of the entry's user; nil when the user has none
+	CreatedAt        time.Time
+}
+
+// UsageExport is the usage of each user in a billing period (GET /admin/usage/export)
+type UsageExport struct {
+	Period string           `json:"period"` // YYYY-MM
+	From   time.Time        `json:"from"`   // start of the period (UTC)
+	To     time.Time        `json:"to"`     // end of the period, exclusive
+	Users  []UserUsageTotal `json:"users"`  // users with usage in the period, by user ID
+}
+
+// UserUsageTotal sums a user's usage ledger entries in a period
+type UserUsageTotal struct {
+	UserID       uuid.UUID `json:"user_id"`
+	Email        string    `json:"email,omitempty"`
+	Jobs         int       `json:"jobs"` // jobs created in the period
+	Chars        int64     `json:"chars"`
+	InputTokens  int64     `json:"input_tokens"`
+	OutputTokens int64     `json:"output_tokens"`
+	AudioSeconds float64   `json:"audio_seconds"`
+	Images       int64     `json:"images"`
+	CostUSD      float64   `json:"cost_usd"` // estimated LLM cost at LLM_PRICING
+}
+
+// SegmentNarrationDiff shows what a segment's narration script changed relative to its source text
+type SegmentNarrationDiff struct {
+	SegmentID    uuid.UUID `json:"segment_id"`
+	Idx          int       `json:"idx"`
+	Title        *string   `json:"title,omitempty"`
+	HasNarration bool      `json:"has_narration"`
+	HTML         string    `json:"html,omitempty"` // escaped text with <del> (removed) and <ins> (added)
+	RemovedWords int       `json:"removed_words"`
+	AddedWords   int       `json:"added_words"`
+}
+
+// TextStats are the word and character counts of a text and its estimated reading and listening times
+type TextStats struct {
+	Words         int `json:"words"`
+	Characters    int `json:"characters"`
+	ReadSeconds   int `json:"read_seconds"`
+	ListenSeconds int `json:"listen_seconds"`
+}
+
+// SegmentTextStats are the text stats of one segment
+type SegmentTextStats struct {
+	SegmentID string `json:"segment_id"`
+	TextStats
+}
+
+// JobTextStats are the text stats of a job's output (all segments) and of each segment, in order
+type JobTextStats struct {
+	TextStats
+	Segments []SegmentTextStats `json:"segments"`
+}
+
+// JobPage is one page of a user's jobs (GET /v1/jobs)
+type JobPage struct {
+	Jobs       []*Job `json:"jobs"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// SegmentPage is one page of a job's segments
+type SegmentPage struct {
+	Segments   []*Segment `json:"segments"`
+	Total      int        `json:"total"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+	HasMore    bool       `json:"has_more"`
+}
+
+// AssetPage is one page of a job's assets
+type AssetPage struct {
+	Assets     []*AssetResponse `json:"assets"`
+	Total      int              `json:"total"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+	HasMore    bool             `json:"has_more"`
 }
 
 // AssetResponse represents asset metadata with download URL (S3 fields excluded)
@@ -0,0 +1,206 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/webhook"
+)
+
+// defaultChatTemplate is the message text of slack and teams sinks without a template
+const defaultChatTemplate = `{{.Subject}}{{with .Job.Error}}: {{.Message}}{{end}}`
+
+// maxChatText limits the rendered message text (Slack section text allows 3000 characters)
+const maxChatText = 3000
+
+// Heading returns the job's title, or "Story job <id>" when it has none.
+func (e *Event) Heading() string {
+	if e.Title != "" {
+		return e.Title
+	}
+	return "Story job " + e.Job.JobID.String()
+}
+
+// StatusText returns the event as a short status, e.g. "Completed" or "Awaiting review".
+func (e *Event) StatusText() string {
+	switch e.Event {
+	case models.EventJobCompleted:
+		return "Completed"
+	case models.EventJobFailed:
+		return "Failed"
+	case models.EventJobCanceled:
+		return "Canceled"
+	case models.EventJobAwaitingReview:
+		return "Awaiting review"
+	case models.EventJobRetryScheduled:
+		return "Retry scheduled"
+	default:
+		return e.Event
+	}
+}
+
+// Duration returns the job's processing time rounded to seconds, or "" when unknown.
+func (e *Event) Duration() string {
+	if e.Job.DurationMs == nil {
+		return ""
+	}
+	return (time.Duration(*e.Job.DurationMs) * time.Millisecond).Round(time.Second).String()
+}
+
+// ValidateTemplate checks that text parses and renders as the message template of a slack or teams sink.
+func ValidateTemplate(text string) error {
+	durationMs := int64(1000)
+	sample := &Event{
+		Event:      models.EventJobFailed,
+		OccurredAt: time.Now(),
+		Job: webhook.WebhookPayload{
+			JobID:      uuid.New(),
+			Status:     models.JobStatusFailed,
+			DurationMs: &durationMs,
+			Error:      &webhook.ErrorInfo{Code: "sample", Message: "sample"},
+		},
+	}
+	_, err := renderChatText(&text, sample)
+	return err
+}
+
+// renderChatText renders a sink's message template (defaultChatTemplate when unset) for ev. Invalid
+// templates are permanent errors.
+func renderChatText(text *string, ev *Event) (string, error) {
+	src := defaultChatTemplate
+	if text != nil && *text != "" {
+		src = *text
+	}
+	tmpl, err := template.New("message").Parse(src)
+	if err != nil {
+		return "", Permanent(fmt.Errorf("invalid template: %w", err))
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, ev); err != nil {
+		return "", Permanent(fmt.Errorf("failed to render template: %w", err))
+	}
+	out := strings.TrimSpace(b.String())
+	if len(out) > maxChatText {
+		out = out[:maxChatText]
+	}
+	return out, nil
+}
+
+// SlackSink posts a Block Kit message (title, status, duration, thumbnail and view button) to a Slack
+// incoming webhook URL.
+type SlackSink struct {
+	httpClient *http.Client
+}
+
+// NewSlackSink creates a Slack sink.
+func NewSlackSink(httpClient *http.Client) *SlackSink {
+	return &SlackSink{httpClient: httpClient}
+}
+
+// Send implements Sink.
+func (s *SlackSink) Send(ctx context.Context, sink *models.NotificationSink, ev *Event) error {
+	text, err := renderChatText(sink.Template, ev)
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, s.httpClient, sink.Target, slackMessage(ev, text))
+}
+
+// slackMessage builds the Block Kit message; text is also the notification fallback.
+func slackMessage(ev *Event, text string) map[string]any {
+	heading := ev.Heading()
+	if len(heading) > 150 { // header block limit
+		heading = heading[:150]
+	}
+	fields := []map[string]any{{"type": "mrkdwn", "text": "*Status*\n" + ev.StatusText()}}
+	if d := ev.Duration(); d != "" {
+		fields = append(fields, map[string]any{"type": "mrkdwn", "text": "*Duration*\n" + d})
+	}
+	section := map[string]any{"type": "section", "fields": fields}
+	if text != "" {
+		section["text"] = map[string]any{"type": "mrkdwn", "text": text}
+	}
+	if ev.ThumbnailURL != "" {
+		section["accessory"] = map[string]any{"type": "image", "image_url": ev.ThumbnailURL, "alt_text": heading}
+	}
+	blocks := []map[string]any{
+		{"type": "header", "text": map[string]any{"type": "plain_text", "text": heading}},
+		section,
+	}
+	if ev.ViewURL != "" {
+		blocks = append(blocks, map[string]any{
+			"type": "actions",
+			"elements": []map[string]any{{
+				"type": "button",
+				"text": map[string]any{"type": "plain_text", "text": "View story"},
+				"url":  ev.ViewURL,
+			}},
+		})
+	}
+	fallback := text
+	if fallback == "" {
+		fallback = ev.Subject()
+	}
+	return map[string]any{"text": fallback, "blocks": blocks}
+}
+
+// TeamsSink posts an Adaptive Card (title, thumbnail, text, status, duration and view action) to a
+// Microsoft Teams incoming webhook URL (a Workflows or legacy connector webhook).
+type TeamsSink struct {
+	httpClient *http.Client
+}
+
+// NewTeamsSink creates a Teams sink.
+func NewTeamsSink(httpClient *http.Client) *TeamsSink {
+	return &TeamsSink{httpClient: httpClient}
+}
+
+// Send implements Sink.
+func (s *TeamsSink) Send(ctx context.Context, sink *models.NotificationSink, ev *Event) error {
+	text, err := renderChatText(sink.Template, ev)
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, s.httpClient, sink.Target, teamsMessage(ev, text))
+}
+
+// teamsMessage builds the message wrapping the Adaptive Card.
+func teamsMessage(ev *Event, text string) map[string]any {
+	body := []map[string]any{
+		{"type": "TextBlock", "text": ev.Heading(), "weight": "Bolder", "size": "Medium", "wrap": true},
+	}
+	if ev.ThumbnailURL != "" {
+		body = append(body, map[string]any{"type": "Image", "url": ev.ThumbnailURL, "size": "Large", "altText": ev.Heading()})
+	}
+	if text != "" {
+		body = append(body, map[string]any{"type": "TextBlock", "text": text, "wrap": true})
+	}
+	facts := []map[string]any{{"title": "Status", "value": ev.StatusText()}}
+	if d := ev.Duration(); d != "" {
+		facts = append(facts, map[string]any{"title": "Duration", "value": d})
+	}
+	body = append(body, map[string]any{"type": "FactSet", "facts": facts})
+
+	card := map[string]any{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+	if ev.ViewURL != "" {
+		card["actions"] = []map[string]any{{"type": "Action.OpenUrl", "title": "View story", "url": ev.ViewURL}}
+	}
+	return map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content":     card,
+		}},
+	}
+}
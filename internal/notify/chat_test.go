@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// chatTestEvent is testEvent with a title, thumbnail and view URL
+func chatTestEvent() *Event {
+	ev := testEvent()
+	ev.Title = "The Water Cycle"
+	ev.ThumbnailURL = "https://api.example.com/view/asset/a1?job_id=6f1c1c8e-6f0e-4a47-9a53-6a3f7b0f1d2e"
+	return ev
+}
+
+func TestSlackSink(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		if strings.HasSuffix(r.URL.Path, "/revoked") {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	sink := NewSlackSink(srv.Client())
+	ev := chatTestEvent()
+	if err := sink.Send(context.Background(), &models.NotificationSink{Target: srv.URL + "/services/T0/B0/x"}, ev); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	wantText := ev.Subject() + ": image generation failed"
+	if got["text"] != wantText {
+		t.Errorf("text = %v, want %q", got["text"], wantText)
+	}
+	body, _ := json.Marshal(got["blocks"])
+	for _, want := range []string{`"text":"The Water Cycle"`, `*Status*\nFailed`, `*Duration*\n1m35s`, `"image_url":"` + ev.ThumbnailURL, `"url":"` + ev.ViewURL} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("blocks = %s, missing %s", body, want)
+		}
+	}
+
+	err := sink.Send(context.Background(), &models.NotificationSink{Target: srv.URL + "/revoked"}, ev)
+	if err == nil || retryable(err) {
+		t.Errorf("404 err = %v, want non-retryable error", err)
+	}
+}
+
+func TestTeamsSink(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	tmpl := `{{.Heading}} is {{.StatusText}} after {{.Duration}}`
+	ev := chatTestEvent()
+	err := NewTeamsSink(srv.Client()).Send(context.Background(), &models.NotificationSink{Target: srv.URL, Template: &tmpl}, ev)
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	body, _ := json.Marshal(got)
+	for _, want := range []string{
+		`"contentType":"application/vnd.microsoft.card.adaptive"`,
+		`"text":"The Water Cycle is Failed after 1m35s"`,
+		`"type":"Image","url":"` + ev.ThumbnailURL,
+		`{"title":"Duration","value":"1m35s"}`,
+		`"type":"Action.OpenUrl","url":"` + ev.ViewURL,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("message = %s, missing %s", body, want)
+		}
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	for _, tmpl := range []string{`{{.Heading}}: {{.StatusText}}`, `{{if .Job.Error}}{{.Job.Error.Code}}{{end}} {{.ViewURL}}`} {
+		if err := ValidateTemplate(tmpl); err != nil {
+			t.Errorf("ValidateTemplate(%q) = %v", tmpl, err)
+		}
+	}
+	for _, tmpl := range []string{`{{.Heading`, `{{.NoSuchField}}`} {
+		if err := ValidateTemplate(tmpl); err == nil {
+			t.Errorf("ValidateTemplate(%q) = nil, want error", tmpl)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// EmailSink sends the event's text as a plain-text email to the sink's address over SMTP, upgrading to
+// TLS when the server supports STARTTLS.
+type EmailSink struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+}
+
+// NewEmailSink creates an email sink. Without a username the server must accept mail unauthenticated.
+func NewEmailSink(host string, port int, username, password, from string) *EmailSink {
+	return &EmailSink{host: host, port: port, username: username, password: password, from: from}
+}
+
+// Send implements Sink.
+func (s *EmailSink) Send(ctx context.Context, sink *models.NotificationSink, ev *Event) error {
+	addr := net.JoinHostPort(s.host, strconv.Itoa(s.port))
+	conn, err := (&net.Dialer{Timeout: 30 * time.Second}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(time.Minute))
+	}
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if err := s.deliver(client, sink.Target, s.message(sink.Target, ev)); err != nil {
+		var smtpErr *textproto.Error
+		if errors.As(err, &smtpErr) && smtpErr.Code >= 500 {
+			// 5xx: rejected (unknown recipient, authentication failed); retrying will not help
+			return Permanent(err)
+		}
+		return err
+	}
+	return client.Quit()
+}
+
+func (s *EmailSink) deliver(client *smtp.Client, to string, msg []byte) error {
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+	if s.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+	if err := client.Mail(s.from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// message renders the email with its headers.
+func (s *EmailSink) message(to string, ev *Event) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", s.from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", ev.Subject())
+	fmt.Fprintf(&b, "Date: %s\r\n", ev.OccurredAt.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.Write(bytes.ReplaceAll([]byte(ev.Text()), []byte("\n"), []byte("\r\n")))
+	return b.Bytes()
+}
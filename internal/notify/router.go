@@ -0,0 +1,398 @@
+// Package notify routes job events from the events topic to the job's webhook, to the notification
+// sinks (webhook, email, Slack, Teams, SNS) its user configured and, when opted in, to its creator's email.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/config"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/egress"
+	"github.com/snappy-loop/stories/internal/kafka"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/webhook"
+)
+
+// Event is the job event delivered to notification sinks
+type Event struct {
+	Event      string                 `json:"event"` // models.EventJob*
+	OccurredAt time.Time              `json:"occurred_at"`
+	Job        webhook.WebhookPayload `json:"job"`
+	ViewURL    string                 `json:"view_url,omitempty"` // HTML view of the job's output
+	// Title is the first segment's title and ThumbnailURL the public URL of the job's first image, when
+	// the job has them
+	Title        string `json:"title,omitempty"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+}
+
+// Sink delivers job events to one type of notification sink (models.SinkType*). Errors are retried
+// with backoff unless they are a non-retryable *webhook.DeliveryError or wrapped with Permanent.
+type Sink interface {
+	Send(ctx context.Context, sink *models.NotificationSink, ev *Event) error
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a sink error as not worth retrying (e.g. the destination rejected the event).
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// retryable reports whether a failed delivery should be retried. Destinations refused by the egress
+// policy are not.
+func retryable(err error) bool {
+	if errors.Is(err, egress.ErrDenied) {
+		return false
+	}
+	var de *webhook.DeliveryError
+	if errors.As(err, &de) {
+		return de.IsRetryable()
+	}
+	var pe *permanentError
+	return !errors.As(err, &pe)
+}
+
+// Router implements kafka.MessageHandler: it delivers the final job_completed and job_failed events to
+// the job's webhook (webhook.DeliveryService) and the creator's email (creatorSink), and every event to
+// the user's subscribed sinks. Each sink
+// gets one immediate attempt; failed deliveries are retried in the background like job webhooks
+// (WEBHOOK_MAX_RETRIES, WEBHOOK_RETRY_BASE_DELAY, WEBHOOK_RETRY_MAX_DELAY).
+type Router struct {
+	config       *config.Config
+	webhooks     *webhook.DeliveryService
+	jobRepo      *database.JobRepository
+	userRepo     *database.UserRepository
+	segmentRepo  *database.SegmentRepository
+	assetRepo    *database.AssetRepository
+	sinkRepo     *database.NotificationSinkRepository
+	deliveryRepo *database.NotificationDeliveryRepository
+	eventRepo    *database.JobEventRepository
+	sinks        map[string]Sink
+
+	stopChan chan struct{}
+	ticker   *time.Ticker
+	stopOnce sync.Once
+}
+
+// NewRouter creates a router without sinks; add them with Register.
+func NewRouter(db *database.DB, cfg *config.Config, webhooks *webhook.DeliveryService) *Router {
+	return &Router{
+		config:       cfg,
+		webhooks:     webhooks,
+		jobRepo:      database.NewJobRepository(db),
+		userRepo:     database.NewUserRepository(db),
+		segmentRepo:  database.NewSegmentRepository(db),
+		assetRepo:    database.NewAssetRepository(db),
+		sinkRepo:     database.NewNotificationSinkRepository(db),
+		deliveryRepo: database.NewNotificationDeliveryRepository(db),
+		eventRepo:    database.NewJobEventRepository(db),
+		sinks:        make(map[string]Sink),
+		stopChan:     make(chan struct{}),
+	}
+}
+
+// Register sets the sink delivering to sinks of sinkType. Deliveries to sink types without one fail.
+func (r *Router) Register(sinkType string, sink Sink) {
+	r.sinks[sinkType] = sink
+}
+
+// HandleMessage routes one job event.
+func (r *Router) HandleMessage(ctx context.Context, msg *kafka.WebhookMessage) error {
+	log.Info().
+		Str("job_id", msg.JobID.String()).
+		Str("event", msg.Event).
+		Msg("Routing job event")
+
+	if msg.Event == models.EventJobCompleted || msg.Event == models.EventJobFailed || msg.Event == models.EventJobCanceled {
+		if err := r.webhooks.DeliverWebhook(ctx, msg.JobID); err != nil {
+			return err
+		}
+	}
+
+	job, err := r.jobRepo.GetByID(ctx, msg.JobID)
+	if err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
+	}
+	sinks, err := r.sinkRepo.ListForEvent(ctx, job.UserID, msg.Event)
+	if err != nil {
+		return fmt.Errorf("failed to list notification sinks: %w", err)
+	}
+	if msg.Event == models.EventJobCompleted || msg.Event == models.EventJobFailed {
+		creator, err := r.creatorSink(ctx, job)
+		if err != nil {
+			return err
+		}
+		if creator != nil {
+			sinks = append(sinks, creator)
+		}
+	}
+	if len(sinks) == 0 {
+		return nil
+	}
+
+	ev := r.buildEvent(ctx, job, msg.Event, time.Now())
+	for _, sink := range sinks {
+		delivery := &models.NotificationDelivery{
+			ID:        uuid.New(),
+			SinkID:    sinkID(sink),
+			JobID:     job.ID,
+			Event:     msg.Event,
+			Status:    "pending",
+			CreatedAt: ev.OccurredAt,
+		}
+		created, err := r.deliveryRepo.Create(ctx, delivery)
+		if err != nil {
+			return fmt.Errorf("failed to create notification delivery: %w", err)
+		}
+		if !created {
+			log.Debug().Str("job_id", job.ID.String()).Str("recipient", recipient(sink)).Msg("Notification already delivered, skipping duplicate")
+			continue
+		}
+		r.attempt(ctx, sink, delivery, ev)
+	}
+	return nil
+}
+
+// creatorSink returns the email sink of the job creator when they opted in to email notifications, for
+// this job (notify_email) or all their jobs (the user's email_notifications), and have an email address.
+func (r *Router) creatorSink(ctx context.Context, job *models.Job) (*models.NotificationSink, error) {
+	if _, ok := r.sinks[models.SinkTypeEmail]; !ok {
+		return nil, nil // SMTP not configured
+	}
+	if job.NotifyEmail != nil && !*job.NotifyEmail {
+		return nil, nil
+	}
+	user, err := r.userRepo.GetByID(ctx, job.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job creator: %w", err)
+	}
+	if job.NotifyEmail == nil && !user.EmailNotifications {
+		return nil, nil
+	}
+	if user.Email == nil || *user.Email == "" {
+		log.Debug().Str("job_id", job.ID.String()).Msg("Job creator has no email address, not notifying")
+		return nil, nil
+	}
+	return &models.NotificationSink{
+		UserID:  user.ID,
+		Type:    models.SinkTypeEmail,
+		Target:  *user.Email,
+		Events:  []string{models.EventJobCompleted, models.EventJobFailed},
+		Enabled: true,
+	}, nil
+}
+
+// sinkID returns the ID of a delivery's sink; nil for the job creator's email (creatorSink).
+func sinkID(sink *models.NotificationSink) *uuid.UUID {
+	if sink.ID == uuid.Nil {
+		return nil
+	}
+	return &sink.ID
+}
+
+// buildEvent builds the event sent to sinks. Sinks get the summary payload whatever the job webhook's mode.
+func (r *Router) buildEvent(ctx context.Context, job *models.Job, event string, occurredAt time.Time) *Event {
+	summary := *job
+	summary.WebhookPayload = nil
+	ev := &Event{
+		Event:      event,
+		OccurredAt: occurredAt,
+		Job:        r.webhooks.BuildPayload(ctx, &summary),
+	}
+	if segments, err := r.segmentRepo.ListByJobPage(ctx, job.ResultJobID(), -1, 1); err != nil {
+		log.Warn().Err(err).Str("job_id", job.ID.String()).Msg("Failed to get first segment for notification")
+	} else if len(segments) > 0 && segments[0].Title != nil {
+		ev.Title = *segments[0].Title
+	}
+	if r.config.PublicAPIURL == "" {
+		return ev
+	}
+	base := strings.TrimSuffix(r.config.PublicAPIURL, "/")
+	ev.ViewURL = base + "/view/" + job.ID.String()
+	if assets, err := r.assetRepo.ListByJob(ctx, job.ResultJobID()); err != nil {
+		log.Warn().Err(err).Str("job_id", job.ID.String()).Msg("Failed to list assets for notification")
+	} else {
+		for _, a := range assets {
+			if a.Kind == "image" {
+				ev.ThumbnailURL = base + "/view/asset/" + a.ID.String() + "?job_id=" + job.ID.String()
+				break
+			}
+		}
+	}
+	return ev
+}
+
+// attempt makes one delivery attempt and records the outcome; the delivery stays pending while a
+// retryable failure has retries left.
+func (r *Router) attempt(ctx context.Context, sink *models.NotificationSink, d *models.NotificationDelivery, ev *Event) {
+	d.Attempts++
+	now := time.Now()
+	d.LastAttemptAt = &now
+
+	err := r.send(ctx, sink, ev)
+	switch {
+	case err == nil:
+		d.Status = "sent"
+		d.LastError = nil
+	case !retryable(err) || d.Attempts >= r.config.WebhookMaxRetries:
+		d.Status = "failed"
+	}
+	if err != nil {
+		errMsg := err.Error()
+		d.LastError = &errMsg
+	}
+	if updateErr := r.deliveryRepo.Update(ctx, d); updateErr != nil {
+		log.Error().Err(updateErr).Str("delivery_id", d.ID.String()).Msg("Failed to update notification delivery")
+	}
+
+	logger := log.With().
+		Str("job_id", d.JobID.String()).
+		Str("event", d.Event).
+		Str("recipient", recipient(sink)).
+		Int("attempts", d.Attempts).
+		Logger()
+	switch d.Status {
+	case "sent":
+		logger.Info().Msg("Notification delivered")
+		r.recordDeliveryEvent(ctx, sink, d, nil)
+	case "failed":
+		logger.Error().Err(err).Msg("Notification delivery failed permanently")
+		r.recordDeliveryEvent(ctx, sink, d, err)
+	default:
+		logger.Warn().Err(err).Msg("Notification delivery failed - will retry")
+	}
+}
+
+// recipient describes a sink in logs and job events.
+func recipient(sink *models.NotificationSink) string {
+	if sink.ID == uuid.Nil {
+		return "the job creator's email"
+	}
+	return sink.Type + " sink " + sink.ID.String()
+}
+
+func (r *Router) send(ctx context.Context, sink *models.NotificationSink, ev *Event) error {
+	s, ok := r.sinks[sink.Type]
+	if !ok {
+		return Permanent(fmt.Errorf("%s sinks are not configured on this dispatcher", sink.Type))
+	}
+	switch sink.Type {
+	case models.SinkTypeWebhook, models.SinkTypeSlack, models.SinkTypeTeams:
+		if err := r.webhooks.CheckDestination(ctx, sink.UserID, sink.Target); err != nil {
+			return err
+		}
+	}
+	return s.Send(ctx, sink, ev)
+}
+
+// recordDeliveryEvent appends notification_sent (err nil) or notification_failed to the job's event log.
+func (r *Router) recordDeliveryEvent(ctx context.Context, sink *models.NotificationSink, d *models.NotificationDelivery, err error) {
+	ev := &models.JobEvent{
+		ID:        uuid.New(),
+		JobID:     d.JobID,
+		Type:      models.JobEventNotificationSent,
+		Message:   fmt.Sprintf("%s sent to %s", d.Event, recipient(sink)),
+		Data:      map[string]any{"sink_type": sink.Type, "event": d.Event, "attempts": d.Attempts},
+		CreatedAt: time.Now(),
+	}
+	if d.SinkID != nil {
+		ev.Data["sink_id"] = d.SinkID.String()
+	}
+	if err != nil {
+		ev.Type = models.JobEventNotificationFailed
+		ev.Message = fmt.Sprintf("%s could not be sent to %s after %d attempt(s): %v", d.Event, recipient(sink), d.Attempts, err)
+		ev.Data["error"] = err.Error()
+	}
+	if err := r.eventRepo.Create(ctx, ev); err != nil {
+		log.Warn().Err(err).Str("job_id", d.JobID.String()).Msg("Failed to record notification event")
+	}
+}
+
+// Start starts retrying pending deliveries every 10 seconds.
+func (r *Router) Start(ctx context.Context) {
+	r.ticker = time.NewTicker(10 * time.Second)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.stopChan:
+				return
+			case <-r.ticker.C:
+				r.retryPending(ctx)
+			}
+		}
+	}()
+}
+
+// Stop stops the retries. Safe to call multiple times.
+func (r *Router) Stop() {
+	r.stopOnce.Do(func() {
+		if r.ticker != nil {
+			r.ticker.Stop()
+		}
+		close(r.stopChan)
+	})
+}
+
+// retryPending retries the pending deliveries whose backoff has elapsed.
+func (r *Router) retryPending(ctx context.Context) {
+	deliveries, err := r.deliveryRepo.GetPending(ctx, 100)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get pending notification deliveries")
+		return
+	}
+	for _, d := range deliveries {
+		if !r.due(d, time.Now()) {
+			continue
+		}
+		job, err := r.jobRepo.GetByID(ctx, d.JobID)
+		if err != nil {
+			log.Error().Err(err).Str("delivery_id", d.ID.String()).Msg("Failed to get job for notification")
+			continue
+		}
+		var sink *models.NotificationSink
+		if d.SinkID != nil {
+			sink, err = r.sinkRepo.GetByID(ctx, *d.SinkID)
+		} else {
+			sink, err = r.creatorSink(ctx, job)
+		}
+		if err != nil {
+			log.Error().Err(err).Str("delivery_id", d.ID.String()).Msg("Failed to get notification sink")
+			continue
+		}
+		if sink == nil {
+			// The creator turned email notifications off or removed their address since
+			d.Status = "failed"
+			if err := r.deliveryRepo.Update(ctx, d); err != nil {
+				log.Error().Err(err).Str("delivery_id", d.ID.String()).Msg("Failed to update notification delivery")
+			}
+			continue
+		}
+		r.attempt(ctx, sink, d, r.buildEvent(ctx, job, d.Event, d.CreatedAt))
+	}
+}
+
+// due reports whether a pending delivery's exponential backoff has elapsed at now.
+func (r *Router) due(d *models.NotificationDelivery, now time.Time) bool {
+	if d.LastAttemptAt == nil {
+		return true
+	}
+	backoff := r.config.WebhookRetryBaseDelay * time.Duration(1<<uint(d.Attempts-1))
+	if backoff > r.config.WebhookRetryMaxDelay || backoff <= 0 {
+		backoff = r.config.WebhookRetryMaxDelay
+	}
+	return !now.Before(d.LastAttemptAt.Add(backoff))
+}
@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/webhook"
+)
+
+// Subject returns a one-line summary of the event, e.g. "Story job 1b9d... completed".
+func (e *Event) Subject() string {
+	var what string
+	switch e.Event {
+	case models.EventJobCompleted:
+		what = "completed"
+	case models.EventJobFailed:
+		what = "failed"
+	case models.EventJobCanceled:
+		what = "was canceled"
+	case models.EventJobAwaitingReview:
+		what = "is awaiting review"
+	case models.EventJobRetryScheduled:
+		what = "failed transiently and will be retried"
+	default:
+		what = e.Event
+	}
+	return fmt.Sprintf("Story job %s %s", e.Job.JobID, what)
+}
+
+// Text returns a plain-text description of the event for chat messages and emails.
+func (e *Event) Text() string {
+	var b strings.Builder
+	b.WriteString(e.Subject())
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "Status: %s\n", e.Job.Status)
+	if e.Job.Error != nil {
+		fmt.Fprintf(&b, "Error: %s (%s)\n", e.Job.Error.Message, e.Job.Error.Code)
+	}
+	if e.Job.SegmentsTotal != nil {
+		fmt.Fprintf(&b, "Segments: %d\n", *e.Job.SegmentsTotal)
+	}
+	if e.Job.DurationMs != nil {
+		fmt.Fprintf(&b, "Duration: %s\n", (time.Duration(*e.Job.DurationMs) * time.Millisecond).Round(time.Second))
+	}
+	if len(e.Job.Tags) > 0 {
+		fmt.Fprintf(&b, "Tags: %s\n", strings.Join(e.Job.Tags, ", "))
+	}
+	if e.ViewURL != "" {
+		fmt.Fprintf(&b, "View: %s\n", e.ViewURL)
+	}
+	if e.Job.ResultURL != "" {
+		fmt.Fprintf(&b, "Result: %s\n", e.Job.ResultURL)
+	}
+	return b.String()
+}
+
+// WebhookSink POSTs the event as JSON to the sink's URL, signed like job webhooks (X-GS-Signature).
+type WebhookSink struct {
+	webhooks *webhook.DeliveryService
+}
+
+// NewWebhookSink creates a webhook sink sending through the job webhook delivery service.
+func NewWebhookSink(webhooks *webhook.DeliveryService) *WebhookSink {
+	return &WebhookSink{webhooks: webhooks}
+}
+
+// Send implements Sink.
+func (s *WebhookSink) Send(ctx context.Context, sink *models.NotificationSink, ev *Event) error {
+	return s.webhooks.Post(ctx, sink.Target, ev, sink.Secret)
+}
+
+// postJSON POSTs payload as JSON, returning non-2xx responses as *webhook.DeliveryError.
+func postJSON(ctx context.Context, client *http.Client, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(fmt.Errorf("failed to create request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Stories-Webhook/1.0")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
+
+// checkResponse returns a *webhook.DeliveryError for non-2xx responses, reading at most 64KB of the body.
+func checkResponse(resp *http.Response) error {
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &webhook.DeliveryError{
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("sink returned status %d", resp.StatusCode),
+			Body:       string(respBody),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/config"
+	"github.com/snappy-loop/stories/internal/egress"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/webhook"
+)
+
+func testEvent() *Event {
+	segments := 4
+	durationMs := int64(95_000)
+	return &Event{
+		Event:      models.EventJobFailed,
+		OccurredAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Job: webhook.WebhookPayload{
+			JobID:         uuid.MustParse("6f1c1c8e-6f0e-4a47-9a53-6a3f7b0f1d2e"),
+			Status:        models.JobStatusFailed,
+			ResultURL:     "https://api.example.com/v1/jobs/6f1c1c8e-6f0e-4a47-9a53-6a3f7b0f1d2e",
+			SegmentsTotal: &segments,
+			DurationMs:    &durationMs,
+			Error:         &webhook.ErrorInfo{Code: "image_failed", Message: "image generation failed"},
+		},
+		ViewURL: "https://api.example.com/view/6f1c1c8e-6f0e-4a47-9a53-6a3f7b0f1d2e",
+	}
+}
+
+func TestEventText(t *testing.T) {
+	text := testEvent().Text()
+	for _, want := range []string{
+		"Story job 6f1c1c8e-6f0e-4a47-9a53-6a3f7b0f1d2e failed\n",
+		"Error: image generation failed (image_failed)",
+		"Segments: 4",
+		"Duration: 1m35s",
+		"View: https://api.example.com/view/",
+		"Result: https://api.example.com/v1/jobs/",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Text() = %q, missing %q", text, want)
+		}
+	}
+}
+
+func TestSNSSink(t *testing.T) {
+	var form url.Values
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		form, _ = url.ParseQuery(string(body))
+		auth = r.Header.Get("Authorization")
+		if form.Get("TopicArn") == "arn:aws:sns:eu-west-1:123456789012:busy" {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, "<ErrorResponse><Error><Code>Throttling</Code></Error></ErrorResponse>")
+			return
+		}
+		io.WriteString(w, "<PublishResponse/>")
+	}))
+	defer srv.Close()
+
+	creds := credentials.NewStaticCredentialsProvider("AKIDEXAMPLE", "secret", "")
+	sink := NewSNSSink(creds, srv.URL, srv.Client())
+	topic := "arn:aws:sns:eu-west-1:123456789012:stories"
+	if err := sink.Send(context.Background(), &models.NotificationSink{Target: topic}, testEvent()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if form.Get("Action") != "Publish" || form.Get("TopicArn") != topic {
+		t.Errorf("form = %v, want Publish to %s", form, topic)
+	}
+	var ev Event
+	if err := json.Unmarshal([]byte(form.Get("Message")), &ev); err != nil || ev.Event != models.EventJobFailed {
+		t.Errorf("Message = %q, want the event as JSON", form.Get("Message"))
+	}
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") || !strings.Contains(auth, "/eu-west-1/sns/aws4_request") {
+		t.Errorf("Authorization = %q, want SigV4 for sns in eu-west-1", auth)
+	}
+
+	err := sink.Send(context.Background(), &models.NotificationSink{Target: "arn:aws:sns:eu-west-1:123456789012:busy"}, testEvent())
+	if err == nil || !retryable(err) {
+		t.Errorf("throttled err = %v, want retryable error", err)
+	}
+	err = sink.Send(context.Background(), &models.NotificationSink{Target: "not-an-arn"}, testEvent())
+	if err == nil || retryable(err) {
+		t.Errorf("bad ARN err = %v, want non-retryable error", err)
+	}
+}
+
+func TestRouterDue(t *testing.T) {
+	r := &Router{config: &config.Config{WebhookRetryBaseDelay: 30 * time.Second, WebhookRetryMaxDelay: time.Hour}}
+	last := time.Now()
+	tests := []struct {
+		attempts int
+		after    time.Duration
+		want     bool
+	}{
+		{1, 29 * time.Second, false},
+		{1, 30 * time.Second, true},
+		{3, time.Minute, false},
+		{3, 2 * time.Minute, true},
+		{20, 59 * time.Minute, false},
+		{20, time.Hour, true},
+	}
+	for _, tt := range tests {
+		d := &models.NotificationDelivery{Attempts: tt.attempts, LastAttemptAt: &last}
+		if got := r.due(d, last.Add(tt.after)); got != tt.want {
+			t.Errorf("due(attempts=%d, after %s) = %v, want %v", tt.attempts, tt.after, got, tt.want)
+		}
+	}
+	if !r.due(&models.NotificationDelivery{}, last) {
+		t.Errorf("never attempted delivery is not due")
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	if !retryable(errors.New("connection reset")) {
+		t.Errorf("network error not retryable")
+	}
+	if retryable(Permanent(errors.New("rejected"))) {
+		t.Errorf("permanent error retryable")
+	}
+	if !retryable(&webhook.DeliveryError{StatusCode: http.StatusServiceUnavailable}) {
+		t.Errorf("503 not retryable")
+	}
+	if retryable(&webhook.DeliveryError{Message: "destination not allowed", Denied: true}) {
+		t.Errorf("denied destination retryable")
+	}
+	if retryable(fmt.Errorf("failed to send request: %w", egress.ErrDenied)) {
+		t.Errorf("request to a denied address retryable")
+	}
+}
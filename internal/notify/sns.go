@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/webhook"
+)
+
+// SNSSink publishes the event as JSON to the sink's SNS topic (target is the topic ARN; the region is
+// taken from it). Requests use the SNS query API signed with Signature Version 4.
+type SNSSink struct {
+	credentials aws.CredentialsProvider
+	endpoint    string // overrides https://sns.<region>.amazonaws.com/ when set
+	httpClient  *http.Client
+	signer      *v4.Signer
+}
+
+// NewSNSSink creates an SNS sink. endpoint may be empty to use the topic region's endpoint.
+func NewSNSSink(credentials aws.CredentialsProvider, endpoint string, httpClient *http.Client) *SNSSink {
+	return &SNSSink{
+		credentials: credentials,
+		endpoint:    endpoint,
+		httpClient:  httpClient,
+		signer:      v4.NewSigner(),
+	}
+}
+
+// Send implements Sink.
+func (s *SNSSink) Send(ctx context.Context, sink *models.NotificationSink, ev *Event) error {
+	region, err := snsTopicRegion(sink.Target)
+	if err != nil {
+		return Permanent(err)
+	}
+	message, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	subject := ev.Subject()
+	if len(subject) > 100 { // SNS limit
+		subject = subject[:100]
+	}
+	form := url.Values{
+		"Action":   {"Publish"},
+		"Version":  {"2010-03-31"},
+		"TopicArn": {sink.Target},
+		"Subject":  {subject},
+		"Message":  {string(message)},
+	}
+	body := form.Encode()
+
+	endpoint := s.endpoint
+	if endpoint == "" {
+		endpoint = "https://sns." + region + ".amazonaws.com/"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(body))
+	if err != nil {
+		return Permanent(fmt.Errorf("failed to create request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	creds, err := s.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get AWS credentials: %w", err)
+	}
+	hash := sha256.Sum256([]byte(body))
+	if err := s.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "sns", region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign SNS request: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	err = checkResponse(resp)
+	if de, ok := err.(*webhook.DeliveryError); ok && strings.Contains(de.Body, "<Code>Throttl") {
+		// SNS reports throttling as 400 Throttling; retry it like a 429
+		de.StatusCode = http.StatusTooManyRequests
+	}
+	return err
+}
+
+// snsTopicRegion returns the region of an SNS topic ARN (arn:aws:sns:<region>:<account>:<name>).
+func snsTopicRegion(arn string) (string, error) {
+	parts := strings.Split(arn, ":")
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "sns" || parts[3] == "" {
+		return "", fmt.Errorf("invalid SNS topic ARN %q", arn)
+	}
+	return parts[3], nil
+}
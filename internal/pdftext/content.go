@@ -0,0 +1,299 @@
+package pdftext
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// font decodes the strings shown with one font resource.
+type font struct {
+	toUnicode map[uint32]string // from the ToUnicode CMap; nil if the font has none
+	codeBytes int               // bytes per character code (2 for CID fonts)
+	composite bool              // Type0 font: codes are CIDs, unreadable without ToUnicode
+}
+
+// loadFonts returns the fonts of a page's resources by resource name.
+func (doc *document) loadFonts(resources dict) map[name]*font {
+	fonts := map[name]*font{}
+	for resName, ref := range doc.dict(resources["Font"]) {
+		fd := doc.dict(ref)
+		if fd == nil {
+			continue
+		}
+		f := &font{codeBytes: 1}
+		if fd["Subtype"] == name("Type0") {
+			f.composite = true
+			f.codeBytes = 2
+		}
+		if s, ok := doc.resolve(fd["ToUnicode"]).(*stream); ok {
+			if data, err := doc.decode(s); err == nil {
+				f.toUnicode, f.codeBytes = parseCMap(data, f.codeBytes)
+			}
+		}
+		fonts[resName] = f
+	}
+	return fonts
+}
+
+// parseCMap reads bfchar and bfrange mappings of a ToUnicode CMap. The code length comes from the
+// codespace ranges, defaulting to defaultBytes.
+func parseCMap(data []byte, defaultBytes int) (map[uint32]string, int) {
+	m := map[uint32]string{}
+	codeBytes := defaultBytes
+	l := &lexer{buf: data}
+	var operands []object
+	for {
+		obj, ok := l.parseObject()
+		if !ok {
+			break
+		}
+		kw, isKw := obj.(keyword)
+		if !isKw {
+			operands = append(operands, obj)
+			continue
+		}
+		switch kw {
+		case "endcodespacerange":
+			if len(operands) >= 1 {
+				if lo, ok := operands[0].([]byte); ok && len(lo) > 0 {
+					codeBytes = len(lo)
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].([]byte)
+				dst, ok2 := operands[i+1].([]byte)
+				if ok1 && ok2 {
+					m[codeValue(src)] = utf16BE(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].([]byte)
+				hi, ok2 := operands[i+1].([]byte)
+				if !ok1 || !ok2 {
+					continue
+				}
+				start, end := codeValue(lo), codeValue(hi)
+				if end < start || end-start > 0xFFFF {
+					continue
+				}
+				switch dst := operands[i+2].(type) {
+				case []byte:
+					base := []rune(utf16BE(dst))
+					if len(base) == 0 {
+						continue
+					}
+					for code := start; code <= end; code++ {
+						r := append([]rune(nil), base...)
+						r[len(r)-1] += rune(code - start)
+						m[code] = string(r)
+					}
+				case array:
+					for j, d := range dst {
+						if b, ok := d.([]byte); ok && start+uint32(j) <= end {
+							m[start+uint32(j)] = utf16BE(b)
+						}
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+	return m, codeBytes
+}
+
+func codeValue(b []byte) uint32 {
+	var v uint32
+	for _, c := range b {
+		v = v<<8 | uint32(c)
+	}
+	return v
+}
+
+func utf16BE(b []byte) string {
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(u))
+}
+
+// decodeString maps a shown string to text. Without a ToUnicode CMap, single-byte fonts are read as
+// Latin-1 (close to the standard encodings for ASCII text) and composite fonts yield nothing.
+func (f *font) decodeString(s []byte) string {
+	var b strings.Builder
+	if f == nil {
+		f = &font{codeBytes: 1}
+	}
+	step := max(f.codeBytes, 1)
+	for i := 0; i+step <= len(s); i += step {
+		code := codeValue(s[i : i+step])
+		if f.toUnicode != nil {
+			if t, ok := f.toUnicode[code]; ok {
+				b.WriteString(t)
+				continue
+			}
+		}
+		if !f.composite && step == 1 {
+			b.WriteRune(rune(code))
+		}
+	}
+	return b.String()
+}
+
+// pageText runs a page's content stream and returns the text it shows, with line breaks where the
+// text position moves to a new line.
+func (doc *document) pageText(p page) string {
+	fonts := doc.loadFonts(p.resources)
+	var cur *font
+	var out strings.Builder
+	lastY, haveY := 0.0, false
+	newline := func() {
+		if out.Len() > 0 && !strings.HasSuffix(out.String(), "\n") {
+			out.WriteByte('\n')
+		}
+	}
+	space := func() {
+		if s := out.String(); out.Len() > 0 && !strings.HasSuffix(s, " ") && !strings.HasSuffix(s, "\n") {
+			out.WriteByte(' ')
+		}
+	}
+	moveTo := func(y float64) {
+		if haveY && y != lastY {
+			newline()
+		} else {
+			space()
+		}
+		lastY, haveY = y, true
+	}
+
+	l := &lexer{buf: doc.contents(p)}
+	var operands []object
+	for {
+		obj, ok := l.parseObject()
+		if !ok {
+			break
+		}
+		kw, isKw := obj.(keyword)
+		if !isKw {
+			operands = append(operands, obj)
+			continue
+		}
+		switch kw {
+		case "Tf":
+			if len(operands) >= 2 {
+				if n, ok := operands[0].(name); ok {
+					cur = fonts[n]
+				}
+			}
+		case "Tj":
+			if len(operands) >= 1 {
+				if s, ok := operands[0].([]byte); ok {
+					out.WriteString(cur.decodeString(s))
+				}
+			}
+		case "'", "\"":
+			newline()
+			if len(operands) >= 1 {
+				if s, ok := operands[len(operands)-1].([]byte); ok {
+					out.WriteString(cur.decodeString(s))
+				}
+			}
+		case "TJ":
+			if len(operands) >= 1 {
+				if a, ok := operands[0].(array); ok {
+					for _, el := range a {
+						switch v := el.(type) {
+						case []byte:
+							out.WriteString(cur.decodeString(v))
+						case float64:
+							// Large negative adjustments (in thousandths of an em) separate words
+							if v < -200 {
+								space()
+							}
+						}
+					}
+				}
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				if ty, ok := operands[1].(float64); ok && ty != 0 {
+					newline()
+					lastY += ty
+				} else {
+					space()
+				}
+			}
+		case "T*":
+			newline()
+		case "Tm":
+			if len(operands) >= 6 {
+				if y, ok := operands[5].(float64); ok {
+					moveTo(y)
+				}
+			}
+		case "ET":
+			space()
+		case "ID":
+			skipInlineImage(l)
+		}
+		operands = operands[:0]
+	}
+	return cleanText(out.String())
+}
+
+// skipInlineImage moves past inline image data (after ID) up to the EI operator.
+func skipInlineImage(l *lexer) {
+	for i := l.pos; i+2 < len(l.buf); i++ {
+		if l.buf[i] == 'E' && l.buf[i+1] == 'I' && isSpace(l.buf[i-1]) && (i+2 == len(l.buf) || isSpace(l.buf[i+2])) {
+			l.pos = i + 2
+			return
+		}
+	}
+	l.pos = len(l.buf)
+}
+
+// cleanText drops control characters and trims each line.
+func cleanText(s string) string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.Map(func(r rune) rune {
+			if r == '\t' {
+				return ' '
+			}
+			if unicode.IsControl(r) || r == unicode.ReplacementChar {
+				return -1
+			}
+			return r
+		}, line)
+		line = strings.Join(strings.Fields(line), " ")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ExtractPages returns the text of each page of a PDF in page order. Pages without a text layer
+// (scanned images) or in fonts that cannot be decoded are empty strings.
+func ExtractPages(data []byte) (texts []string, err error) {
+	// Uploaded PDFs are untrusted: a parser bug on a crafted file fails this file, not the worker
+	defer func() {
+		if r := recover(); r != nil {
+			texts, err = nil, fmt.Errorf("parse PDF: %v", r)
+		}
+	}()
+	doc, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	pages := doc.pages()
+	texts = make([]string, len(pages))
+	for i, p := range pages {
+		texts[i] = doc.pageText(p)
+	}
+	return texts, nil
+}
@@ -0,0 +1,317 @@
+// Package pdftext extracts the text layer of PDFs without external dependencies. It reads objects by
+// scanning the file (not the xref table), so it tolerates damaged cross-reference data, and decodes
+// Flate-compressed object and content streams. Text in fonts with a ToUnicode CMap or a simple
+// (single-byte) encoding is decoded; scanned pages have no text layer and come back empty.
+package pdftext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+)
+
+var (
+	// ErrNotPDF is returned for data that does not start with a PDF header.
+	ErrNotPDF = errors.New("not a PDF")
+	// ErrEncrypted is returned for encrypted PDFs, whose streams cannot be read without the key.
+	ErrEncrypted = errors.New("PDF is encrypted")
+)
+
+// maxStreamBytes bounds the decoded size of a single stream.
+const maxStreamBytes = 64 << 20
+
+// objectHeaderRe matches "n g obj" object headers.
+var objectHeaderRe = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// trailerRe matches the start of a classic trailer dictionary.
+var trailerRe = regexp.MustCompile(`trailer\s*<<`)
+
+// document holds the parsed objects of a PDF, by object number (the last definition wins, as with
+// incremental updates).
+type document struct {
+	objects map[int]object
+	trailer dict
+}
+
+func parseDocument(data []byte) (*document, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\r\n "), []byte("%PDF")) {
+		return nil, ErrNotPDF
+	}
+	doc := &document{objects: map[int]object{}, trailer: dict{}}
+	skipUntil := 0 // end of the last stream read; headers inside stream data are not objects
+	for _, m := range objectHeaderRe.FindAllSubmatchIndex(data, -1) {
+		if m[0] < skipUntil || (m[0] > 0 && !isSpace(data[m[0]-1]) && !isDelim(data[m[0]-1])) {
+			continue
+		}
+		var num int
+		fmt.Sscanf(string(data[m[2]:m[3]]), "%d", &num)
+		l := &lexer{buf: data, pos: m[1]}
+		obj, ok := l.parseObject()
+		if !ok {
+			continue
+		}
+		if d, isDict := obj.(dict); isDict {
+			if s, end := readStreamData(data, l.pos, d); s != nil {
+				obj = s
+				skipUntil = end
+			}
+		}
+		doc.objects[num] = obj
+	}
+
+	// Objects inside compressed object streams (PDF 1.5+)
+	var objStms []int
+	for num, obj := range doc.objects {
+		if s, ok := obj.(*stream); ok && s.hdr["Type"] == name("ObjStm") {
+			objStms = append(objStms, num)
+		}
+	}
+	sort.Ints(objStms)
+	for _, num := range objStms {
+		doc.loadObjectStream(doc.objects[num].(*stream))
+	}
+
+	// Trailer: classic trailer dictionaries and cross-reference streams both carry /Root and /Encrypt
+	for _, idx := range trailerRe.FindAllIndex(data, -1) {
+		l := &lexer{buf: data, pos: idx[1] - 2}
+		if d, ok := l.parseObject(); ok {
+			if td, ok := d.(dict); ok {
+				for k, v := range td {
+					doc.trailer[k] = v
+				}
+			}
+		}
+	}
+	for _, obj := range doc.objects {
+		if s, ok := obj.(*stream); ok && s.hdr["Type"] == name("XRef") {
+			for _, k := range []name{"Root", "Encrypt"} {
+				if v, ok := s.hdr[k]; ok {
+					doc.trailer[k] = v
+				}
+			}
+		}
+	}
+	if _, ok := doc.trailer["Encrypt"]; ok {
+		return nil, ErrEncrypted
+	}
+	return doc, nil
+}
+
+// readStreamData returns the stream following a dictionary that ends at pos and the offset where its data
+// ends, or nil if no stream follows.
+func readStreamData(data []byte, pos int, hdr dict) (*stream, int) {
+	l := &lexer{buf: data, pos: pos}
+	l.skipSpace()
+	if !bytes.HasPrefix(data[l.pos:], []byte("stream")) {
+		return nil, 0
+	}
+	start := l.pos + len("stream")
+	if start < len(data) && data[start] == '\r' {
+		start++
+	}
+	if start < len(data) && data[start] == '\n' {
+		start++
+	}
+	if n, ok := offset(hdr["Length"], len(data)-start); ok {
+		end := start + n
+		rest := bytes.TrimLeft(data[end:min(end+32, len(data))], "\r\n\t ")
+		if bytes.HasPrefix(rest, []byte("endstream")) {
+			return &stream{hdr: hdr, data: data[start:end]}, end
+		}
+	}
+	end := bytes.Index(data[start:], []byte("endstream"))
+	if end < 0 {
+		return nil, 0
+	}
+	return &stream{hdr: hdr, data: bytes.TrimRight(data[start:start+end], "\r\n")}, start + end
+}
+
+func (doc *document) loadObjectStream(s *stream) {
+	data, err := doc.decode(s)
+	if err != nil {
+		return
+	}
+	n, okN := offset(s.hdr["N"], len(data))
+	first, okFirst := offset(s.hdr["First"], len(data))
+	if !okN || !okFirst {
+		return
+	}
+	l := &lexer{buf: data[:first]}
+	for i := 0; i < n; i++ {
+		num, ok1 := l.parseObject()
+		off, ok2 := l.parseObject()
+		numF, isNum := num.(float64)
+		_, isOff := off.(float64)
+		if !ok1 || !ok2 || !isNum || !isOff {
+			return
+		}
+		rel, ok := offset(off, len(data)-first-1)
+		if !ok {
+			continue
+		}
+		pos := first + rel
+		if _, exists := doc.objects[int(numF)]; exists {
+			continue // a later uncompressed definition wins
+		}
+		ol := &lexer{buf: data, pos: pos}
+		if obj, ok := ol.parseObject(); ok {
+			doc.objects[int(numF)] = obj
+		}
+	}
+}
+
+// offset returns a number of the file (a length, count or offset) as an int if it is an integer in
+// [0, max]; crafted files carry negative, fractional or huge values.
+func offset(obj object, max int) (int, bool) {
+	f, ok := obj.(float64)
+	if !ok || math.IsNaN(f) || f < 0 || f > float64(max) || f != math.Trunc(f) {
+		return 0, false
+	}
+	return int(f), true
+}
+
+// resolve follows references.
+func (doc *document) resolve(obj object) object {
+	for i := 0; i < 32; i++ {
+		r, ok := obj.(ref)
+		if !ok {
+			return obj
+		}
+		obj = doc.objects[r.num]
+	}
+	return nil
+}
+
+func (doc *document) dict(obj object) dict {
+	switch v := doc.resolve(obj).(type) {
+	case dict:
+		return v
+	case *stream:
+		return v.hdr
+	}
+	return nil
+}
+
+// decode returns the decoded data of a stream. Only FlateDecode (without predictors) is supported.
+func (doc *document) decode(s *stream) ([]byte, error) {
+	var filters []object
+	switch f := doc.resolve(s.hdr["Filter"]).(type) {
+	case nil:
+	case name:
+		filters = []object{f}
+	case array:
+		filters = f
+	}
+	data := s.data
+	for _, f := range filters {
+		if doc.resolve(f) != name("FlateDecode") {
+			return nil, fmt.Errorf("unsupported filter %v", f)
+		}
+		if parms := doc.dict(s.hdr["DecodeParms"]); parms != nil {
+			if p, _ := parms["Predictor"].(float64); p > 1 {
+				return nil, fmt.Errorf("unsupported predictor %v", p)
+			}
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		// Streams with a bad checksum or trailing garbage still yield what was decoded
+		decoded, _ := io.ReadAll(io.LimitReader(zr, maxStreamBytes))
+		_ = zr.Close()
+		data = decoded
+	}
+	return data, nil
+}
+
+// page is a leaf of the page tree with its inherited resources.
+type page struct {
+	hdr       dict
+	resources dict
+}
+
+// pages returns the document's pages in order by walking the page tree from the catalog; without a
+// usable catalog, page objects are returned in object-number order.
+func (doc *document) pages() []page {
+	var out []page
+	visited := map[int]bool{}
+	var walk func(obj object, inherited dict)
+	walk = func(obj object, inherited dict) {
+		if r, ok := obj.(ref); ok {
+			if visited[r.num] {
+				return
+			}
+			visited[r.num] = true
+		}
+		node := doc.dict(obj)
+		if node == nil {
+			return
+		}
+		resources := inherited
+		if res := doc.dict(node["Resources"]); res != nil {
+			resources = res
+		}
+		if kids, ok := doc.resolve(node["Kids"]).(array); ok {
+			for _, kid := range kids {
+				walk(kid, resources)
+			}
+			return
+		}
+		if node["Type"] == name("Page") || node["Contents"] != nil {
+			out = append(out, page{hdr: node, resources: resources})
+		}
+	}
+	if catalog := doc.dict(doc.trailer["Root"]); catalog != nil {
+		walk(catalog["Pages"], nil)
+	}
+	if len(out) > 0 {
+		return out
+	}
+
+	var nums []int
+	for num, obj := range doc.objects {
+		if d := doc.dict(obj); d != nil && d["Type"] == name("Page") {
+			nums = append(nums, num)
+		}
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		d := doc.dict(doc.objects[num])
+		res := doc.dict(d["Resources"])
+		if res == nil {
+			res = doc.dict(doc.dict(d["Parent"])["Resources"])
+		}
+		out = append(out, page{hdr: d, resources: res})
+	}
+	return out
+}
+
+// contents returns the decoded content stream(s) of a page, concatenated.
+func (doc *document) contents(p page) []byte {
+	var parts []object
+	switch c := doc.resolve(p.hdr["Contents"]).(type) {
+	case *stream:
+		parts = []object{c}
+	case array:
+		parts = c
+	}
+	var out []byte
+	for _, part := range parts {
+		s, ok := doc.resolve(part).(*stream)
+		if !ok {
+			continue
+		}
+		data, err := doc.decode(s)
+		if err != nil {
+			continue
+		}
+		out = append(out, data...)
+		out = append(out, '\n')
+	}
+	return out
+}
@@ -0,0 +1,298 @@
+package pdftext
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// PDF object model: only what text extraction needs.
+type (
+	name   string
+	dict   map[name]object
+	array  []object
+	ref    struct{ num, gen int }
+	object any // nil, bool, float64, name, []byte (string), dict, array, ref, keyword, *stream
+)
+
+// keyword is a bare token: an operator in content streams, or obj/endobj/stream/R in file structure.
+type keyword string
+
+// stream is a dictionary followed by (still encoded) stream data.
+type stream struct {
+	hdr  dict
+	data []byte
+}
+
+// lexer tokenizes PDF syntax (file structure, content streams and CMaps share the same token rules).
+type lexer struct {
+	buf []byte
+	pos int
+}
+
+// token kinds returned by next
+const (
+	tokEOF = iota
+	tokNumber
+	tokName
+	tokString
+	tokKeyword
+	tokDictOpen
+	tokDictClose
+	tokArrayOpen
+	tokArrayClose
+)
+
+type token struct {
+	kind int
+	num  float64
+	str  []byte // name, string or keyword bytes
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isDelim(c byte) bool {
+	return bytes.IndexByte([]byte("()<>[]{}/%"), c) >= 0
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.buf) {
+		c := l.buf[l.pos]
+		if isSpace(c) {
+			l.pos++
+		} else if c == '%' {
+			for l.pos < len(l.buf) && l.buf[l.pos] != '\n' && l.buf[l.pos] != '\r' {
+				l.pos++
+			}
+		} else {
+			return
+		}
+	}
+}
+
+func (l *lexer) next() token {
+	l.skipSpace()
+	if l.pos >= len(l.buf) {
+		return token{kind: tokEOF}
+	}
+	c := l.buf[l.pos]
+	switch {
+	case c == '/':
+		l.pos++
+		start := l.pos
+		for l.pos < len(l.buf) && !isSpace(l.buf[l.pos]) && !isDelim(l.buf[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokName, str: decodeNameEscapes(l.buf[start:l.pos])}
+	case c == '(':
+		return token{kind: tokString, str: l.literalString()}
+	case c == '<':
+		if l.pos+1 < len(l.buf) && l.buf[l.pos+1] == '<' {
+			l.pos += 2
+			return token{kind: tokDictOpen}
+		}
+		return token{kind: tokString, str: l.hexString()}
+	case c == '>':
+		l.pos++
+		if l.pos < len(l.buf) && l.buf[l.pos] == '>' {
+			l.pos++
+		}
+		return token{kind: tokDictClose}
+	case c == '[':
+		l.pos++
+		return token{kind: tokArrayOpen}
+	case c == ']':
+		l.pos++
+		return token{kind: tokArrayClose}
+	case c == '{' || c == '}' || c == ')':
+		l.pos++
+		return l.next()
+	}
+	start := l.pos
+	for l.pos < len(l.buf) && !isSpace(l.buf[l.pos]) && !isDelim(l.buf[l.pos]) {
+		l.pos++
+	}
+	word := l.buf[start:l.pos]
+	if n, err := strconv.ParseFloat(string(word), 64); err == nil {
+		return token{kind: tokNumber, num: n}
+	}
+	return token{kind: tokKeyword, str: word}
+}
+
+func decodeNameEscapes(b []byte) []byte {
+	if bytes.IndexByte(b, '#') < 0 {
+		return b
+	}
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		if b[i] == '#' && i+2 < len(b) {
+			if v, err := strconv.ParseUint(string(b[i+1:i+3]), 16, 8); err == nil {
+				out = append(out, byte(v))
+				i += 2
+				continue
+			}
+		}
+		out = append(out, b[i])
+	}
+	return out
+}
+
+// literalString reads a (...) string with balanced parentheses and backslash escapes.
+func (l *lexer) literalString() []byte {
+	l.pos++ // (
+	var out []byte
+	depth := 1
+	for l.pos < len(l.buf) {
+		c := l.buf[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+		case '\\':
+			if l.pos >= len(l.buf) {
+				return out
+			}
+			e := l.buf[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				if l.pos < len(l.buf) && l.buf[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.buf) && l.buf[l.pos] >= '0' && l.buf[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.buf[l.pos]-'0')
+						l.pos++
+					}
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// hexString reads a <...> string; an odd final digit is padded with 0.
+func (l *lexer) hexString() []byte {
+	l.pos++ // <
+	var out []byte
+	var hi byte
+	half := false
+	for l.pos < len(l.buf) {
+		c := l.buf[l.pos]
+		l.pos++
+		if c == '>' {
+			break
+		}
+		var v byte
+		switch {
+		case c >= '0' && c <= '9':
+			v = c - '0'
+		case c >= 'a' && c <= 'f':
+			v = c - 'a' + 10
+		case c >= 'A' && c <= 'F':
+			v = c - 'A' + 10
+		default:
+			continue
+		}
+		if half {
+			out = append(out, hi<<4|v)
+		} else {
+			hi = v
+		}
+		half = !half
+	}
+	if half {
+		out = append(out, hi<<4)
+	}
+	return out
+}
+
+// parseObject reads one object. Integers followed by "gen R" become references. Returns the end
+// token (keyword, dict or array close) as a keyword when no object starts at the current position.
+func (l *lexer) parseObject() (object, bool) {
+	t := l.next()
+	switch t.kind {
+	case tokEOF:
+		return nil, false
+	case tokNumber:
+		if t.num == float64(int(t.num)) && t.num >= 0 {
+			save := l.pos
+			if gen := l.next(); gen.kind == tokNumber && gen.num == float64(int(gen.num)) {
+				if r := l.next(); r.kind == tokKeyword && string(r.str) == "R" {
+					return ref{num: int(t.num), gen: int(gen.num)}, true
+				}
+			}
+			l.pos = save
+		}
+		return t.num, true
+	case tokName:
+		return name(t.str), true
+	case tokString:
+		return t.str, true
+	case tokDictOpen:
+		d := dict{}
+		for {
+			key := l.next()
+			if key.kind != tokName {
+				return d, true // >> or malformed
+			}
+			v, ok := l.parseObject()
+			if !ok {
+				return d, true
+			}
+			if _, isKw := v.(keyword); isKw {
+				continue
+			}
+			d[name(key.str)] = v
+		}
+	case tokArrayOpen:
+		var a array
+		for {
+			save := l.pos
+			if t := l.next(); t.kind == tokArrayClose || t.kind == tokEOF {
+				return a, true
+			}
+			l.pos = save
+			v, ok := l.parseObject()
+			if !ok {
+				return a, true
+			}
+			a = append(a, v)
+		}
+	case tokKeyword:
+		switch string(t.str) {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		case "null":
+			return nil, true
+		}
+		return keyword(t.str), true
+	}
+	return keyword(""), true
+}
@@ -0,0 +1,125 @@
+package pdftext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// buildPDF writes objects (numbered from 1) into a minimal PDF with a trailer pointing at object 1.
+func buildPDF(objects ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	for i, obj := range objects {
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	b.WriteString("trailer\n<< /Root 1 0 R /Size 9 >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+func streamObj(dict string, data []byte) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+func flate(data string) []byte {
+	var b bytes.Buffer
+	zw := zlib.NewWriter(&b)
+	zw.Write([]byte(data))
+	zw.Close()
+	return b.Bytes()
+}
+
+func TestExtractPages(t *testing.T) {
+	cmap := `/CIDInit /ProcSet findresource begin
+begincmap
+1 begincodespacerange <0000> <FFFF> endcodespacerange
+2 beginbfchar <0001> <0048> <0002> <0069> endbfchar
+1 beginbfrange <0010> <0012> <0410> endbfrange
+endcmap`
+	data := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R 5 0 R] /Count 3 /Resources << /Font << /F1 6 0 R /F2 7 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 8 0 R >>",
+		"<< /Type /Page /Parent 2 0 R /Contents [9 0 R 10 0 R] >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 11 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /Noto /ToUnicode 12 0 R >>",
+		streamObj("", []byte("BT /F1 12 Tf 72 720 Td (Hello \\(world\\)) Tj 0 -14 Td [(Sec) 20 (ond) -300 (line)] TJ ET")),
+		streamObj("/Filter /FlateDecode", flate("BT /F2 12 Tf 1 0 0 1 72 700 Tm <00010002> Tj ET")),
+		streamObj("", []byte("BT /F2 12 Tf 1 0 0 1 72 680 Tm <001000110012> Tj ET")),
+		streamObj("", []byte("q 100 0 0 100 0 0 cm BI /W 1 /H 1 /BPC 8 /CS /G ID \x00\xff EI Q")),
+		streamObj("", []byte(cmap)),
+	)
+
+	got, err := ExtractPages(data)
+	if err != nil {
+		t.Fatalf("ExtractPages: %v", err)
+	}
+	want := []string{"Hello (world)\nSecond line", "Hi\nАБВ", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractPages() = %q, want %q", got, want)
+	}
+}
+
+func TestExtractPages_ObjectStream(t *testing.T) {
+	// Catalog, page tree and page live in a compressed object stream (object 2), as PDF 1.5+ writers emit them
+	packed := []string{
+		"<< /Type /Catalog /Pages 4 0 R >>",
+		"<< /Type /Pages /Kids [5 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 4 0 R /Contents 1 0 R /Resources << >> >>",
+	}
+	var header, body string
+	for i, obj := range packed {
+		header += fmt.Sprintf("%d %d ", i+3, len(body))
+		body += obj + " "
+	}
+	data := buildPDF(
+		streamObj("", []byte("BT (Packed) Tj ET")),
+		streamObj(fmt.Sprintf("/Type /ObjStm /N 3 /First %d /Filter /FlateDecode", len(header)), flate(header+body)),
+	)
+	data = bytes.Replace(data, []byte("/Root 1 0 R"), []byte("/Root 3 0 R"), 1)
+
+	got, err := ExtractPages(data)
+	if err != nil {
+		t.Fatalf("ExtractPages: %v", err)
+	}
+	if want := []string{"Packed"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractPages() = %q, want %q", got, want)
+	}
+}
+
+func TestExtractPages_CraftedObjectStream(t *testing.T) {
+	// Out-of-range numbers in an object stream header are ignored instead of panicking
+	for _, hdr := range []string{
+		"/Type /ObjStm /N 1 /First -5",
+		"/Type /ObjStm /N 1 /First 1e300",
+		"/Type /ObjStm /N -1 /First 4",
+		"/Type /ObjStm /N 99999999999999999999 /First 4",
+	} {
+		data := buildPDF(
+			"<< /Type /Catalog /Pages 3 0 R >>",
+			streamObj(hdr, []byte("3 -9 << /Type /Pages /Kids [] /Count 0 >>")),
+		)
+		if _, err := ExtractPages(data); err != nil {
+			t.Errorf("%s: %v", hdr, err)
+		}
+	}
+	// A stream whose /Length overflows the file
+	data := buildPDF("<< /Type /Catalog >>", "<< /Length 9000000000000000000 >>\nstream\nBT (x) Tj ET\nendstream")
+	if _, err := ExtractPages(data); err != nil {
+		t.Errorf("huge /Length: %v", err)
+	}
+}
+
+func TestExtractPages_Errors(t *testing.T) {
+	if _, err := ExtractPages([]byte("hello")); !errors.Is(err, ErrNotPDF) {
+		t.Errorf("non-PDF: err = %v, want ErrNotPDF", err)
+	}
+	encrypted := append(buildPDF("<< /Type /Catalog >>"), []byte("trailer\n<< /Encrypt 9 0 R >>\n")...)
+	if _, err := ExtractPages(encrypted); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("encrypted: err = %v, want ErrEncrypted", err)
+	}
+}
@@ -0,0 +1,182 @@
+// Package podcast builds podcast RSS feeds (RSS 2.0 with the iTunes namespace, as required by Apple
+// Podcasts and Spotify) from a job's segments: one episode per segment audio.
+package podcast
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const itunesNS = "http://www.itunes.com/dtds/podcast-1.0.dtd"
+
+// Feed is a podcast: the job's metadata and its episodes, oldest first.
+type Feed struct {
+	Title       string
+	Description string
+	Link        string // web page of the podcast (the job's view page)
+	FeedURL     string // URL the feed is served from (atom:link self)
+	ImageURL    string // artwork; directories require a square JPEG or PNG of 1400-3000 px
+	Language    string // e.g. "en"
+	Author      string
+	Category    string // iTunes category, e.g. "Education"
+	Episodes    []Episode
+}
+
+// Episode is one segment's narration.
+type Episode struct {
+	GUID        string // stable across feed regenerations (the segment ID)
+	Number      int    // 1-based
+	Title       string
+	Description string
+	AudioURL    string
+	AudioType   string // MIME type, e.g. audio/wav
+	AudioSize   int64  // bytes (enclosure length)
+	Duration    time.Duration
+	ImageURL    string // episode artwork (the segment image); empty uses the feed's
+	PubDate     time.Time
+}
+
+type rss struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Itunes  string   `xml:"xmlns:itunes,attr"`
+	Atom    string   `xml:"xmlns:atom,attr"`
+	Channel channel  `xml:"channel"`
+}
+
+type channel struct {
+	Title       string       `xml:"title"`
+	Link        string       `xml:"link"`
+	AtomLink    *atomLink    `xml:"atom:link,omitempty"`
+	Description string       `xml:"description"`
+	Language    string       `xml:"language"`
+	Author      string       `xml:"itunes:author,omitempty"`
+	Summary     string       `xml:"itunes:summary"`
+	Image       *itunesImage `xml:"itunes:image,omitempty"`
+	Category    *category    `xml:"itunes:category,omitempty"`
+	Explicit    string       `xml:"itunes:explicit"`
+	Type        string       `xml:"itunes:type"`
+	Items       []item       `xml:"item"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type itunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+type category struct {
+	Text string `xml:"text,attr"`
+}
+
+type item struct {
+	Title       string       `xml:"title"`
+	Description string       `xml:"description"`
+	GUID        guid         `xml:"guid"`
+	PubDate     string       `xml:"pubDate"`
+	Enclosure   enclosure    `xml:"enclosure"`
+	Duration    string       `xml:"itunes:duration,omitempty"`
+	Episode     int          `xml:"itunes:episode"`
+	EpisodeType string       `xml:"itunes:episodeType"`
+	Image       *itunesImage `xml:"itunes:image,omitempty"`
+}
+
+type guid struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+type enclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// Build renders the feed as RSS XML. Episodes without an audio URL are skipped.
+func Build(f *Feed) ([]byte, error) {
+	if f.Title == "" {
+		return nil, fmt.Errorf("podcast feed needs a title")
+	}
+	ch := channel{
+		Title:       f.Title,
+		Link:        f.Link,
+		Description: f.Description,
+		Language:    f.Language,
+		Author:      f.Author,
+		Summary:     f.Description,
+		Explicit:    "false",
+		Type:        "serial", // episodes are parts of one story, listened to in order
+	}
+	if ch.Language == "" {
+		ch.Language = "en"
+	}
+	if f.FeedURL != "" {
+		ch.AtomLink = &atomLink{Href: f.FeedURL, Rel: "self", Type: "application/rss+xml"}
+	}
+	if f.ImageURL != "" {
+		ch.Image = &itunesImage{Href: f.ImageURL}
+	}
+	if f.Category != "" {
+		ch.Category = &category{Text: f.Category}
+	}
+	for _, ep := range f.Episodes {
+		if ep.AudioURL == "" {
+			continue
+		}
+		it := item{
+			Title:       ep.Title,
+			Description: ep.Description,
+			GUID:        guid{Value: ep.GUID},
+			PubDate:     ep.PubDate.UTC().Format(time.RFC1123Z),
+			Enclosure:   enclosure{URL: ep.AudioURL, Length: ep.AudioSize, Type: ep.AudioType},
+			Episode:     ep.Number,
+			EpisodeType: "full",
+		}
+		if ep.Duration > 0 {
+			it.Duration = formatDuration(ep.Duration)
+		}
+		if ep.ImageURL != "" {
+			it.Image = &itunesImage{Href: ep.ImageURL}
+		}
+		ch.Items = append(ch.Items, it)
+	}
+	if len(ch.Items) == 0 {
+		return nil, fmt.Errorf("podcast feed has no episodes with audio")
+	}
+
+	out, err := xml.MarshalIndent(rss{
+		Version: "2.0",
+		Itunes:  itunesNS,
+		Atom:    "http://www.w3.org/2005/Atom",
+		Channel: ch,
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal podcast feed: %w", err)
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// formatDuration formats d as HH:MM:SS (itunes:duration), rounded to seconds.
+func formatDuration(d time.Duration) string {
+	s := int(d.Round(time.Second).Seconds())
+	return fmt.Sprintf("%02d:%02d:%02d", s/3600, s/60%60, s%60)
+}
+
+// Summary shortens text to at most n characters on a word boundary, for channel and episode descriptions.
+func Summary(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) <= n {
+		return text
+	}
+	cut := strings.LastIndex(text[:n], " ")
+	if cut <= 0 {
+		cut = n
+	}
+	return strings.TrimRight(text[:cut], ",.;:") + "…"
+}
@@ -0,0 +1,61 @@
+package podcast
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuild(t *testing.T) {
+	pub := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	out, err := Build(&Feed{
+		Title:       "The Water Cycle",
+		Description: "How water moves & changes",
+		Link:        "https://api.example.com/view/j1",
+		FeedURL:     "https://api.example.com/view/j1/podcast.rss",
+		ImageURL:    "https://api.example.com/view/asset/i1?job_id=j1",
+		Category:    "Education",
+		Episodes: []Episode{
+			{GUID: "s1", Number: 1, Title: "Evaporation", AudioURL: "https://api.example.com/view/asset/a1?job_id=j1&x=1", AudioType: "audio/wav", AudioSize: 1234, Duration: 3723 * time.Second, ImageURL: "https://api.example.com/view/asset/i1?job_id=j1", PubDate: pub},
+			{GUID: "s2", Number: 2, Title: "No audio"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	xml := string(out)
+	for _, want := range []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"`,
+		`<atom:link href="https://api.example.com/view/j1/podcast.rss" rel="self" type="application/rss+xml"></atom:link>`,
+		`<description>How water moves &amp; changes</description>`,
+		`<language>en</language>`,
+		`<itunes:category text="Education"></itunes:category>`,
+		`<itunes:type>serial</itunes:type>`,
+		`<guid isPermaLink="false">s1</guid>`,
+		`<pubDate>Sun, 01 Mar 2026 12:00:00 +0000</pubDate>`,
+		`<enclosure url="https://api.example.com/view/asset/a1?job_id=j1&amp;x=1" length="1234" type="audio/wav"></enclosure>`,
+		`<itunes:duration>01:02:03</itunes:duration>`,
+		`<itunes:episode>1</itunes:episode>`,
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("feed missing %s\n%s", want, xml)
+		}
+	}
+	if strings.Contains(xml, "No audio") {
+		t.Errorf("episode without audio included:\n%s", xml)
+	}
+
+	if _, err := Build(&Feed{Title: "Empty", Episodes: []Episode{{Title: "No audio"}}}); err == nil {
+		t.Error("Build without episodes: want error")
+	}
+}
+
+func TestSummary(t *testing.T) {
+	if got := Summary("  Short\n text. ", 100); got != "Short text." {
+		t.Errorf("Summary = %q", got)
+	}
+	if got := Summary("Water evaporates, condenses and falls as rain.", 22); got != "Water evaporates…" {
+		t.Errorf("Summary = %q", got)
+	}
+}
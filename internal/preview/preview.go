@@ -0,0 +1,111 @@
+// Package preview builds the small previews stored on jobs for job lists: a sprite of thumbnails of the
+// first segment images and a narration excerpt, so cards render without fetching assets.
+package preview
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	_ "image/gif" // decoders of the image formats Gemini and replacement uploads produce
+	_ "image/png"
+)
+
+const (
+	// TileSize is the width and height of each thumbnail in a sprite, in pixels
+	TileSize = 96
+	// MaxTiles is the number of images (first segments first) a sprite holds at most
+	MaxTiles = 4
+	// ExcerptLength is the length of narration excerpts, in characters
+	ExcerptLength = 200
+
+	jpegQuality = 70
+)
+
+// ErrNoImages is returned by Sprite when none of the images could be decoded.
+var ErrNoImages = errors.New("no decodable images")
+
+// Sprite decodes up to MaxTiles images (PNG, JPEG or GIF; others are skipped), crops each to a centered
+// square, scales it to TileSize and lays the tiles out left to right in one JPEG. It returns the JPEG
+// and the number of tiles.
+func Sprite(images [][]byte) ([]byte, int, error) {
+	var tiles []image.Image
+	for _, data := range images {
+		if len(tiles) == MaxTiles {
+			break
+		}
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			continue
+		}
+		tiles = append(tiles, thumbnail(img, TileSize))
+	}
+	if len(tiles) == 0 {
+		return nil, 0, ErrNoImages
+	}
+
+	sprite := image.NewRGBA(image.Rect(0, 0, TileSize*len(tiles), TileSize))
+	for i, tile := range tiles {
+		draw.Draw(sprite, image.Rect(i*TileSize, 0, (i+1)*TileSize, TileSize), tile, image.Point{}, draw.Src)
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, sprite, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, 0, fmt.Errorf("failed to encode sprite: %w", err)
+	}
+	return buf.Bytes(), len(tiles), nil
+}
+
+// thumbnail crops img to its centered square and scales it to size x size, averaging the source pixels
+// each target pixel covers.
+func thumbnail(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+
+	out := image.NewRGBA(image.Rect(0, 0, size, size))
+	for ty := 0; ty < size; ty++ {
+		sy0, sy1 := y0+ty*side/size, y0+(ty+1)*side/size
+		sy1 = max(sy1, sy0+1)
+		for tx := 0; tx < size; tx++ {
+			sx0, sx1 := x0+tx*side/size, x0+(tx+1)*side/size
+			sx1 = max(sx1, sx0+1)
+			var r, g, bl, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, _ := img.At(sx, sy).RGBA()
+					r, g, bl, n = r+uint64(cr), g+uint64(cg), bl+uint64(cb), n+1
+				}
+			}
+			out.SetRGBA(tx, ty, color.RGBA{R: uint8(r / n >> 8), G: uint8(g / n >> 8), B: uint8(bl / n >> 8), A: 0xff})
+		}
+	}
+	return out
+}
+
+// Excerpt returns the first n characters of text with whitespace collapsed, cut at the last word boundary
+// and ended with an ellipsis when text is longer.
+func Excerpt(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	runes := []rune(text)[:n]
+	cut := len(runes)
+	for i := len(runes) - 1; i > n/2; i-- {
+		if unicode.IsSpace(runes[i]) {
+			cut = i
+			break
+		}
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) + "…"
+}
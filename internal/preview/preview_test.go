@@ -0,0 +1,74 @@
+package preview
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func encodePNG(t *testing.T, w, h int, c color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSprite(t *testing.T) {
+	red := encodePNG(t, 400, 200, color.RGBA{R: 255, A: 255})
+	blue := encodePNG(t, 50, 80, color.RGBA{B: 255, A: 255})
+	images := [][]byte{red, []byte("not an image"), blue, red, blue, red}
+
+	data, tiles, err := Sprite(images)
+	if err != nil {
+		t.Fatalf("Sprite: %v", err)
+	}
+	if tiles != MaxTiles {
+		t.Errorf("tiles = %d, want %d (undecodable skipped, capped)", tiles, MaxTiles)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("sprite is not a JPEG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != TileSize*MaxTiles || b.Dy() != TileSize {
+		t.Errorf("sprite size = %v", b)
+	}
+	// Tile colors survive scaling (small images are scaled up) and JPEG compression
+	for i, wantRed := range []bool{true, false, true, false} {
+		r, _, b, _ := img.At(i*TileSize+TileSize/2, TileSize/2).RGBA()
+		if gotRed := r > b; gotRed != wantRed {
+			t.Errorf("tile %d: r=%d b=%d, want red=%v", i, r>>8, b>>8, wantRed)
+		}
+	}
+
+	if _, _, err := Sprite([][]byte{[]byte("nope")}); !errors.Is(err, ErrNoImages) {
+		t.Errorf("err = %v, want ErrNoImages", err)
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	if got := Excerpt("  Short\n text. ", 200); got != "Short text." {
+		t.Errorf("Excerpt = %q", got)
+	}
+	long := strings.Repeat("Ünïcode words, ", 30)
+	got := Excerpt(long, 50)
+	if !strings.HasSuffix(got, "…") || utf8.RuneCountInString(got) > 51 {
+		t.Errorf("Excerpt = %q", got)
+	}
+	if strings.Contains(got, ",…") || strings.Contains(got, " …") {
+		t.Errorf("Excerpt not cut at a word boundary: %q", got)
+	}
+}
@@ -0,0 +1,39 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// errJobCanceled stops the pipeline of a job canceled while it runs (POST /v1/jobs/{id}/cancel).
+var errJobCanceled = errors.New("job canceled")
+
+// canceled reports whether the job has been canceled. The pipeline checks it between steps and before each
+// segment, so a canceled job stops after the calls in flight; a failed check lets the job go on.
+func (p *JobProcessor) canceled(ctx context.Context, jobID uuid.UUID) bool {
+	canceled, err := p.jobRepo.IsCanceled(ctx, jobID)
+	if err != nil {
+		log.Warn().Err(err).Str("job_id", jobID.String()).Msg("Failed to check whether job was canceled")
+		return false
+	}
+	return canceled
+}
+
+// finishCanceled wraps up a job whose pipeline stopped because it was canceled: segments it did not finish
+// are marked canceled (the API marked those it knew of) and the markup keeps the finished segments. The
+// API already recorded the event and published job_canceled.
+func (p *JobProcessor) finishCanceled(ctx context.Context, job *models.Job, startedAt time.Time) {
+	if _, err := p.segmentRepo.CancelUnfinished(ctx, job.ID); err != nil {
+		log.Error().Err(err).Str("job_id", job.ID.String()).Msg("Failed to mark unfinished segments canceled")
+	}
+	p.updatePartialMarkup(ctx, job.ID)
+	log.Info().
+		Str("job_id", job.ID.String()).
+		Int64("duration_ms", time.Since(startedAt).Milliseconds()).
+		Msg("Job canceled, pipeline stopped")
+}
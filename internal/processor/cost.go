@@ -0,0 +1,127 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/llm"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// apiKeyCostRepository reads API keys' cost caps and charges them the estimated cost of jobs.
+type apiKeyCostRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.APIKey, error)
+	AddCost(ctx context.Context, keyID uuid.UUID, costUSD float64) error
+}
+
+// SetPricing sets the per-model token prices used to estimate the LLM cost of each job run, which is
+// added to the job's API key for its cost cap; without it no cost is tracked.
+func (p *JobProcessor) SetPricing(pricing llm.Pricing) {
+	p.pricing = pricing
+}
+
+// usageLedgerRepository records job runs and completed jobs in the usage ledger (GET /admin/usage/export,
+// Stripe metered billing).
+type usageLedgerRepository interface {
+	RecordRun(ctx context.Context, e *models.UsageEntry, since time.Time) error
+	RecordCompleted(ctx context.Context, jobID uuid.UUID) error
+}
+
+// recordCompleted records a job that succeeded in the usage ledger. A failure is logged: the job is done.
+func (p *JobProcessor) recordCompleted(ctx context.Context, jobID uuid.UUID) {
+	if p.usageLedger == nil {
+		return
+	}
+	if err := p.usageLedger.RecordCompleted(ctx, jobID); err != nil {
+		log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to record completed job usage")
+	}
+}
+
+// meterCost returns a context collecting the token usage of the job's Gemini calls and a function, to be
+// deferred, charging their estimated cost to the job's API key and recording the run in the usage ledger.
+// Failed runs are charged too: Gemini bills the calls regardless.
+func (p *JobProcessor) meterCost(ctx context.Context, job *models.Job) (context.Context, func()) {
+	if len(p.pricing) == 0 && p.usageLedger == nil {
+		return ctx, func() {}
+	}
+	startedAt := time.Now()
+	usage := &llm.Usage{}
+	ctx = llm.WithUsage(ctx, usage)
+	return ctx, func() {
+		// Charge even when the run was cut short (shutdown, cancellation)
+		ctx := context.WithoutCancel(ctx)
+		byModel := usage.ByModel()
+		cost := p.pricing.Cost(byModel)
+		if p.usageLedger != nil {
+			entry := &models.UsageEntry{
+				UserID:   job.UserID,
+				APIKeyID: job.APIKeyID,
+				JobID:    job.ID,
+				Kind:     models.UsageKindJobRun,
+				CostUSD:  cost,
+			}
+			for _, t := range byModel {
+				entry.InputTokens += t.InputTokens
+				entry.OutputTokens += t.OutputTokens
+			}
+			if err := p.usageLedger.RecordRun(ctx, entry, startedAt); err != nil {
+				log.Error().Err(err).Str("job_id", job.ID.String()).Msg("Failed to record job run usage")
+			}
+		}
+		if cost <= 0 {
+			return
+		}
+		if err := p.apiKeyRepo.AddCost(ctx, job.APIKeyID, cost); err != nil {
+			log.Error().Err(err).Str("job_id", job.ID.String()).Float64("cost_usd", cost).Msg("Failed to charge job cost to API key")
+			return
+		}
+		log.Info().
+			Str("job_id", job.ID.String()).
+			Str("api_key_id", job.APIKeyID.String()).
+			Float64("cost_usd", cost).
+			Interface("tokens", byModel).
+			Msg("Charged estimated LLM cost")
+	}
+}
+
+// costCapped holds a queued job whose API key has reached its cost cap when COST_CAP_ACTION is queue (with
+// reject, the API refuses such jobs instead). The job stays queued with progress step cost_capped until
+// the key's quota period ends, when the stuck job sweeper requeues it. It reports whether the job is held.
+func (p *JobProcessor) costCapped(ctx context.Context, job *models.Job) (bool, error) {
+	if p.config.CostCapAction != models.CostCapQueue {
+		return false, nil
+	}
+	key, err := p.apiKeyRepo.GetByID(ctx, job.APIKeyID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get API key: %w", err)
+	}
+	until, capped := costCapHold(key, time.Now())
+	if !capped {
+		return false, nil
+	}
+	held, err := p.jobRepo.HoldQueued(ctx, job.ID, until, models.JobStepCostCapped)
+	if err != nil {
+		return false, fmt.Errorf("failed to hold job: %w", err)
+	}
+	if !held {
+		// Canceled or started by another delivery since we read it
+		return true, nil
+	}
+	p.recordEvent(ctx, job.ID, models.JobEventCostCapped, fmt.Sprintf("API key cost cap reached; waiting until %s", until.Format(time.RFC3339)), map[string]any{
+		"used_cost_usd": key.UsedCostInPeriod,
+		"cost_cap_usd":  *key.CostCapUSD,
+		"until":         until,
+	})
+	log.Info().Str("job_id", job.ID.String()).Str("api_key_id", key.ID.String()).Time("until", until).Msg("Job held: API key cost cap reached")
+	return true, nil
+}
+
+// costCapHold returns the end of key's quota period and whether jobs must wait for it: the key reached its
+// cost cap and the period has not ended yet (the next job created resets an ended period's usage).
+func costCapHold(key *models.APIKey, now time.Time) (time.Time, bool) {
+	until := key.PeriodStartedAt.Add(models.QuotaPeriodDuration(key.QuotaPeriod))
+	return until, key.CostCapReached() && now.Before(until)
+}
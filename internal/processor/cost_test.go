@@ -0,0 +1,32 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+func TestCostCapHold(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	capUSD := 5.0
+	key := &models.APIKey{QuotaPeriod: "daily", PeriodStartedAt: now.Add(-2 * time.Hour), CostCapUSD: &capUSD, UsedCostInPeriod: 5}
+
+	until, held := costCapHold(key, now)
+	if !held || !until.Equal(now.Add(22*time.Hour)) {
+		t.Errorf("hold = %v until %v, want held until the end of the day period", held, until)
+	}
+	// The period has ended: the next job created resets the usage
+	if _, held := costCapHold(key, now.Add(23*time.Hour)); held {
+		t.Error("held after the period ended")
+	}
+	key.UsedCostInPeriod = 4.99
+	if _, held := costCapHold(key, now); held {
+		t.Error("held under the cap")
+	}
+	key.CostCapUSD = nil
+	key.UsedCostInPeriod = 100
+	if _, held := costCapHold(key, now); held {
+		t.Error("held without a cap")
+	}
+}
@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// processDuplicate finishes a duplicate job (created with dedupe) from its source job's results. While
+// the source is still queued or running there is nothing to do here: the source's worker resolves its
+// duplicates when it finishes (see resolveDuplicates).
+func (p *JobProcessor) processDuplicate(ctx context.Context, job *models.Job) error {
+	source, err := p.jobRepo.GetByID(ctx, *job.DuplicateOf)
+	if err != nil {
+		return fmt.Errorf("failed to get source job: %w", err)
+	}
+	if !models.IsTerminalJobStatus(source.Status) {
+		log.Info().
+			Str("job_id", job.ID.String()).
+			Str("duplicate_of", source.ID.String()).
+			Str("source_status", source.Status).
+			Msg("Duplicate job waits for its source job")
+		return nil
+	}
+	p.completeDuplicate(ctx, job.ID, source)
+	return nil
+}
+
+// resolveDuplicates completes the queued duplicates of a job that has just finished.
+func (p *JobProcessor) resolveDuplicates(ctx context.Context, sourceID uuid.UUID) {
+	ids, err := p.jobRepo.ListQueuedDuplicates(ctx, sourceID)
+	if err != nil {
+		log.Error().Err(err).Str("job_id", sourceID.String()).Msg("Failed to list duplicate jobs")
+		return
+	}
+	if len(ids) == 0 {
+		return
+	}
+	source, err := p.jobRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		log.Error().Err(err).Str("job_id", sourceID.String()).Msg("Failed to reload job for its duplicates")
+		return
+	}
+	for _, id := range ids {
+		p.completeDuplicate(ctx, id, source)
+	}
+}
+
+// completeDuplicate copies a finished source job's outcome (status, output markup, disclaimer, preview,
+// error and progress) to a duplicate, moving it through running like any other job, then records the
+// event and publishes its webhook. If the duplicate was already completed by another worker, nothing happens.
+func (p *JobProcessor) completeDuplicate(ctx context.Context, jobID uuid.UUID, source *models.Job) {
+	if err := p.updateJobStatus(ctx, jobID, models.JobStatusRunning, nil, nil); err != nil {
+		if !errors.Is(err, database.ErrInvalidJobTransition) {
+			log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to start duplicate job")
+		}
+		return
+	}
+	if source.OutputMarkup != nil {
+		if err := p.jobRepo.UpdateMarkup(ctx, jobID, *source.OutputMarkup); err != nil {
+			log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to copy output markup to duplicate job")
+		}
+	}
+	if source.DisclaimerJurisdiction != nil && source.DisclaimerVersion != nil {
+		if err := p.jobRepo.SetDisclaimer(ctx, jobID, *source.DisclaimerJurisdiction, *source.DisclaimerVersion); err != nil {
+			log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to copy disclaimer to duplicate job")
+		}
+	}
+	if source.Preview != nil {
+		if err := p.jobRepo.UpdatePreview(ctx, jobID, source.Preview); err != nil {
+			log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to copy preview to duplicate job")
+		}
+	}
+	p.warnProgress(jobID, p.jobRepo.CopyProgress(ctx, jobID, source.ID))
+	if err := p.updateJobStatus(ctx, jobID, source.Status, source.ErrorCode, source.ErrorMessage); err != nil {
+		if !errors.Is(err, database.ErrInvalidJobTransition) {
+			log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to finish duplicate job")
+		}
+		return
+	}
+
+	eventType, webhookEvent := models.JobEventSucceeded, models.EventJobCompleted
+	if source.Status != models.JobStatusSucceeded {
+		eventType, webhookEvent = models.JobEventFailed, models.EventJobFailed
+	}
+	p.recordEvent(ctx, jobID, eventType, "Results copied from job "+source.ID.String(), map[string]any{
+		"duplicate_of":  source.ID.String(),
+		"source_status": source.Status,
+	})
+	p.publishWebhookEvent(ctx, jobID, webhookEvent)
+	p.resolveDependents(ctx, jobID)
+	log.Info().
+		Str("job_id", jobID.String()).
+		Str("duplicate_of", source.ID.String()).
+		Str("status", source.Status).
+		Msg("Duplicate job completed from source job")
+}
@@ -0,0 +1,120 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// ErrCodeDependencyFailed is set on jobs failed because a depends_on job failed or was canceled.
+const ErrCodeDependencyFailed = "dependency_failed"
+
+// SetJobPublisher sets the publisher used to queue jobs whose dependencies have finished; without it they
+// wait for the stuck job sweeper.
+func (p *JobProcessor) SetJobPublisher(jp jobPublisher) {
+	p.jobPublisher = jp
+}
+
+// dependenciesReady reports whether every depends_on job of a queued job has succeeded. While one is still
+// unfinished the job waits (its dependency's worker republishes it, see resolveDependents); when one failed
+// or was canceled the job is failed here and false is returned as well.
+func (p *JobProcessor) dependenciesReady(ctx context.Context, job *models.Job) (bool, error) {
+	var waiting []string
+	for _, id := range job.DependsOn {
+		parent, err := p.jobRepo.GetByID(ctx, id)
+		if err != nil {
+			return false, fmt.Errorf("failed to get depends_on job %s: %w", id, err)
+		}
+		switch parent.Status {
+		case models.JobStatusSucceeded:
+		case models.JobStatusFailed, models.JobStatusCanceled:
+			p.failDependent(ctx, job.ID, parent)
+			return false, nil
+		default:
+			waiting = append(waiting, id.String())
+		}
+	}
+	if len(waiting) == 0 {
+		return true, nil
+	}
+	p.warnProgress(job.ID, p.jobRepo.UpdateProgressStep(ctx, job.ID, models.JobStepWaiting))
+	p.recordEvent(ctx, job.ID, models.JobEventWaiting, "Waiting for jobs "+strings.Join(waiting, ", "), map[string]any{
+		"waiting_for": waiting,
+	})
+	log.Info().Str("job_id", job.ID.String()).Strs("waiting_for", waiting).Msg("Job waits for its dependencies")
+	return false, nil
+}
+
+// failDependent fails a queued job whose dependency failed or was canceled, and passes the failure on to
+// the jobs waiting for it.
+func (p *JobProcessor) failDependent(ctx context.Context, jobID uuid.UUID, parent *models.Job) {
+	errCode := ErrCodeDependencyFailed
+	errMsg := fmt.Sprintf("depends_on job %s %s", parent.ID, parent.Status)
+	if err := p.updateJobStatus(ctx, jobID, models.JobStatusFailed, &errCode, &errMsg); err != nil {
+		if !errors.Is(err, database.ErrInvalidJobTransition) {
+			log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to fail job with a failed dependency")
+		}
+		return
+	}
+	p.recordEvent(ctx, jobID, models.JobEventFailed, "Job failed: "+errMsg, map[string]any{
+		"error_code": errCode,
+		"depends_on": parent.ID.String(),
+	})
+	p.publishWebhookEvent(ctx, jobID, models.EventJobFailed)
+	p.resolveDuplicates(ctx, jobID)
+	p.resolveDependents(ctx, jobID)
+}
+
+// resolveDependents republishes the queued jobs depending on a job that has just finished: they start when
+// all their dependencies have succeeded and fail when this one did not.
+func (p *JobProcessor) resolveDependents(ctx context.Context, jobID uuid.UUID) {
+	if p.jobPublisher == nil {
+		return
+	}
+	ids, err := p.jobRepo.ListQueuedDependents(ctx, jobID)
+	if err != nil {
+		log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to list dependent jobs")
+		return
+	}
+	for _, id := range ids {
+		if err := p.jobPublisher.PublishJob(ctx, id, ""); err != nil {
+			log.Error().Err(err).Str("job_id", id.String()).Str("depends_on", jobID.String()).Msg("Failed to queue dependent job")
+		}
+	}
+}
+
+// dependencyInput returns the input of a job without text or files: the titles and texts of the segments of
+// its first dependency, in order.
+func (p *JobProcessor) dependencyInput(ctx context.Context, job *models.Job) (string, error) {
+	if len(job.DependsOn) == 0 {
+		return "", fmt.Errorf("no text to segment: job has no input and no depends_on job")
+	}
+	parent, err := p.jobRepo.GetByID(ctx, job.DependsOn[0])
+	if err != nil {
+		return "", fmt.Errorf("failed to get depends_on job: %w", err)
+	}
+	segments, err := p.segmentRepo.ListByJob(ctx, parent.ResultJobID())
+	if err != nil {
+		return "", fmt.Errorf("failed to list segments of depends_on job: %w", err)
+	}
+	var b strings.Builder
+	for _, seg := range segments {
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		if seg.Title != nil && *seg.Title != "" {
+			b.WriteString(*seg.Title + "\n\n")
+		}
+		b.WriteString(seg.SegmentText)
+	}
+	if strings.TrimSpace(b.String()) == "" {
+		return "", fmt.Errorf("no text to segment: depends_on job %s has no segments", parent.ID)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/ssml"
+)
+
+// disclaimerBreak separates the disclaimer from the narration before it
+const disclaimerBreak = `<break strength="strong"/>`
+
+// resolveDisclaimer picks the compliance disclaimer of a financial job (any input type whose profile sets
+// disclaimer), the latest version for its jurisdiction or else the DEFAULT one, and records it on the
+// job. A job that already recorded one (a restart) keeps it, so its narration and markup do not change
+// version mid-run. Jobs of other types, or with no disclaimer configured, get none.
+func (p *JobProcessor) resolveDisclaimer(ctx context.Context, job *models.Job) {
+	if !p.inputTypes.Get(job.InputType).Disclaimer || job.DisclaimerVersion != nil {
+		return
+	}
+	logger := log.With().Str("job_id", job.ID.String()).Logger()
+	var disclaimer *models.Disclaimer
+	var err error
+	if job.Jurisdiction != nil {
+		disclaimer, err = p.disclaimerRepo.Latest(ctx, *job.Jurisdiction)
+	}
+	if disclaimer == nil && (err == nil || errors.Is(err, database.ErrDisclaimerNotFound)) {
+		disclaimer, err = p.disclaimerRepo.Latest(ctx, models.DefaultJurisdiction)
+	}
+	if err != nil {
+		if errors.Is(err, database.ErrDisclaimerNotFound) {
+			logger.Warn().Msg("No disclaimer configured for financial job")
+		} else {
+			logger.Error().Err(err).Msg("Failed to get disclaimer for financial job")
+		}
+		return
+	}
+	if err := p.jobRepo.SetDisclaimer(ctx, job.ID, disclaimer.Jurisdiction, disclaimer.Version); err != nil {
+		logger.Error().Err(err).Msg("Failed to record job disclaimer")
+		return
+	}
+	job.DisclaimerJurisdiction = &disclaimer.Jurisdiction
+	job.DisclaimerVersion = &disclaimer.Version
+	logger.Info().
+		Str("jurisdiction", disclaimer.Jurisdiction).
+		Int("version", disclaimer.Version).
+		Msg("Disclaimer applied to financial job")
+}
+
+// jobDisclaimer returns the disclaimer recorded on the job, or nil when it has none.
+func (p *JobProcessor) jobDisclaimer(ctx context.Context, job *models.Job) (*models.Disclaimer, error) {
+	if job.DisclaimerJurisdiction == nil || job.DisclaimerVersion == nil {
+		return nil, nil
+	}
+	d, err := p.disclaimerRepo.Get(ctx, *job.DisclaimerJurisdiction, *job.DisclaimerVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job disclaimer: %w", err)
+	}
+	return d, nil
+}
+
+// appendDisclaimer appends the job's disclaimer to the narration script of its last segment (idx), so
+// it is spoken at the end of the audio. Scripts of other segments, and scripts already ending with it,
+// are returned as is.
+func (p *JobProcessor) appendDisclaimer(ctx context.Context, job *models.Job, idx int, script string) (string, error) {
+	if job.DisclaimerVersion == nil {
+		return script, nil
+	}
+	count, err := p.segmentRepo.CountByJob(ctx, job.ID)
+	if err != nil {
+		return script, fmt.Errorf("failed to count segments: %w", err)
+	}
+	if idx != count-1 {
+		return script, nil
+	}
+	disclaimer, err := p.jobDisclaimer(ctx, job)
+	if err != nil || disclaimer == nil {
+		return script, err
+	}
+	return withDisclaimer(script, disclaimer.Text), nil
+}
+
+// withDisclaimer appends text to script after a strong break, or without the break when the script
+// already has the maximum number of them. A script containing text is returned as is.
+func withDisclaimer(script, text string) string {
+	if strings.Contains(script, text) {
+		return script
+	}
+	script = strings.TrimSpace(script)
+	if script == "" {
+		return text
+	}
+	if withBreak := script + " " + disclaimerBreak + " " + text; ssml.Validate(withBreak) == nil {
+		return withBreak
+	}
+	return script + "\n\n" + text
+}
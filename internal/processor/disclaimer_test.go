@@ -0,0 +1,29 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/snappy-loop/stories/internal/ssml"
+)
+
+func TestWithDisclaimer(t *testing.T) {
+	const text = "Not investment advice."
+	got := withDisclaimer("Rates rose. ", text)
+	if got != `Rates rose. <break strength="strong"/> Not investment advice.` {
+		t.Errorf("withDisclaimer = %q", got)
+	}
+	if again := withDisclaimer(got, text); again != got {
+		t.Errorf("second append changed the script: %q", again)
+	}
+	if got := withDisclaimer("", text); got != text {
+		t.Errorf("empty script: %q, want the disclaimer alone", got)
+	}
+
+	// No room for another break: appended as a new paragraph, still valid
+	full := strings.Repeat("a<break/>", ssml.MaxBreaks) + "end."
+	got = withDisclaimer(full, text)
+	if !strings.HasSuffix(got, "end.\n\n"+text) || ssml.Validate(got) != nil {
+		t.Errorf("script with max breaks: %q", got)
+	}
+}
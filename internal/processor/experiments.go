@@ -0,0 +1,46 @@
+package processor
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/llm"
+)
+
+// assignExperiments puts the job in its LLM experiment variants: stores them on the job (so outputs can be
+// compared by variant) and returns a context that makes the LLM client follow them.
+func (p *JobProcessor) assignExperiments(ctx context.Context, jobID uuid.UUID) (context.Context, map[string]string) {
+	variants := p.llmClient.AssignVariants(jobID)
+	if len(variants) == 0 {
+		return ctx, nil
+	}
+	if err := p.jobRepo.UpdateExperiments(ctx, jobID, variants); err != nil {
+		log.Warn().Err(err).Str("job_id", jobID.String()).Msg("Failed to store job experiment variants")
+	}
+	return llm.WithVariants(ctx, jobID, variants), variants
+}
+
+// logExperimentOutcome logs the job-level result once per experiment the job took part in, so variants
+// can be compared by success rate, duration and failed segments alongside the per-call llm_experiment_call logs.
+func (p *JobProcessor) logExperimentOutcome(ctx context.Context, jobID uuid.UUID, variants map[string]string, status string, duration time.Duration) {
+	if len(variants) == 0 {
+		return
+	}
+	var segmentsTotal, segmentsFailed int
+	if job, err := p.jobRepo.GetByID(ctx, jobID); err == nil && job.Progress != nil {
+		segmentsTotal, segmentsFailed = job.Progress.SegmentsTotal, job.Progress.SegmentsFailed
+	}
+	for name, variant := range variants {
+		log.Info().
+			Str("job_id", jobID.String()).
+			Str("experiment", name).
+			Str("variant", variant).
+			Str("status", status).
+			Int64("duration_ms", duration.Milliseconds()).
+			Int("segments_total", segmentsTotal).
+			Int("segments_failed", segmentsFailed).
+			Msg("llm_experiment_job")
+	}
+}
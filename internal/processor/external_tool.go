@@ -0,0 +1,66 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/mcpclient"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// callExternalTool calls the job's external MCP tool with a segment and stores the result in the
+// segment's metadata under the tool name. A failed call of a required tool is returned; otherwise the
+// error is recorded in the metadata ({"error": ...}) and the segment goes on. The server URL is checked
+// against the egress policy and the owner's egress allow list before every call.
+func (p *JobProcessor) callExternalTool(ctx context.Context, job *models.Job, segmentID uuid.UUID, idx int, title *string, text string) error {
+	tool := job.ExternalTool
+	if tool == nil {
+		return nil
+	}
+	args := make(map[string]any, len(tool.Arguments)+4)
+	for k, v := range tool.Arguments {
+		args[k] = v
+	}
+	args["text"] = text
+	args["segment_idx"] = idx
+	args["input_type"] = job.InputType
+	if title != nil {
+		args["title"] = *title
+	}
+	token := ""
+	if tool.AuthToken != nil {
+		token = *tool.AuthToken
+	}
+
+	var value any
+	var result *mcpclient.Result
+	err := p.checkDestination(ctx, job.UserID, tool.ServerURL)
+	if err == nil {
+		result, err = p.mcpClient.CallTool(ctx, tool.ServerURL, token, tool.Tool, args)
+	}
+	if err != nil {
+		if tool.Required {
+			return fmt.Errorf("external tool %s failed: %w", tool.Tool, err)
+		}
+		log.Warn().Err(err).Str("job_id", job.ID.String()).Int("segment", idx).Str("tool", tool.Tool).Msg("External tool call failed")
+		value = map[string]any{"error": err.Error()}
+	} else {
+		value = result.Value()
+	}
+	if err := p.segmentRepo.MergeMetadata(ctx, segmentID, map[string]any{tool.Tool: value}); err != nil {
+		return fmt.Errorf("failed to save external tool result: %w", err)
+	}
+	return nil
+}
+
+// checkDestination checks url against the egress policy and the egress allow list of userID, read on
+// every call so that changes apply to running jobs.
+func (p *JobProcessor) checkDestination(ctx context.Context, userID uuid.UUID, url string) error {
+	allowlist, err := p.userRepo.GetEgressAllowlist(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get egress allow list: %w", err)
+	}
+	return p.egress.CheckURL(url, allowlist)
+}
@@ -0,0 +1,115 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/llm"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/pdftext"
+)
+
+// recitationChunkPages is the number of PDF pages per request in the chunked recitation fallback.
+const recitationChunkPages = 2
+
+// extractFile extracts a file's text. PDFs with a text layer are read locally first when enabled, with only
+// their scanned pages sent to vision; everything else goes to Gemini vision. When Gemini blocks the response
+// for recitation (common with published documents the user owns), it falls back in order to a stricter
+// paraphrase prompt, chunked page extraction (PDFs), and the PDF's own text layer; JobFileMeta.Strategy
+// records which one produced the text. opts carry the file's extraction options; handwritten files and files
+// whose tables are preserved always go to vision, since a text layer has neither.
+func (p *MultiFileProcessor) extractFile(ctx context.Context, data []byte, mimeType, inputType string, opts llm.ExtractOptions) (string, *models.JobFileMeta, error) {
+	if p.localPDFText && mimeType == llm.PDFMimeType && !opts.Handwriting && !opts.PreserveTables {
+		if text, meta, ok, err := p.extractPDFLocalFirst(ctx, data, inputType, opts); ok {
+			return text, meta, err
+		}
+	}
+
+	text, meta, err := p.visionExtract(ctx, data, mimeType, inputType, opts)
+	if err == nil || !llm.IsRecitationError(err) {
+		return text, meta, err
+	}
+	log.Warn().Err(err).Str("mime_type", mimeType).Msg("Extraction blocked for recitation; retrying with stricter paraphrase")
+
+	strict := opts
+	strict.StrictParaphrase = true
+	text, meta, err = p.visionExtract(ctx, data, mimeType, inputType, strict)
+	if err == nil {
+		return text, withStrategy(meta, models.ExtractionStrategyStrictParaphrase), nil
+	}
+	if !llm.IsRecitationError(err) || mimeType != llm.PDFMimeType {
+		return "", nil, err
+	}
+
+	// PDFs: the document's own text layer backs up chunks Gemini still refuses
+	localPages, localErr := pdftext.ExtractPages(data)
+	if localErr != nil {
+		log.Warn().Err(localErr).Msg("Local PDF text extraction failed")
+	}
+	pageCount := len(localPages)
+	if pageCount == 0 {
+		pageCount = llm.PDFPageCount(data)
+	}
+	if pageCount > 0 {
+		log.Warn().Int("pages", pageCount).Msg("Extraction still blocked for recitation; extracting in page chunks")
+		text, meta, err := p.extractChunked(ctx, data, inputType, pageCount, localPages, strict)
+		if err != nil {
+			return "", nil, err
+		}
+		if text != "" {
+			return text, meta, nil
+		}
+	}
+	return "", nil, fmt.Errorf("extraction blocked for recitation and the PDF has no usable text layer: %w", err)
+}
+
+// extractChunked extracts a PDF recitationChunkPages pages at a time with the strict prompt. Chunks that are
+// still blocked use the local text of their pages. If Gemini blocked every chunk the result is the local text
+// alone (strategy local_text); an empty result means neither produced any text.
+func (p *MultiFileProcessor) extractChunked(ctx context.Context, data []byte, inputType string, pageCount int, localPages []string, opts llm.ExtractOptions) (string, *models.JobFileMeta, error) {
+	var text pageTextBuilder
+	extractedChunks := 0
+	for first := 1; first <= pageCount; first += recitationChunkPages {
+		last := min(first+recitationChunkPages-1, pageCount)
+		pages, err := p.llmClient.ExtractPDFPages(ctx, data, inputType, first, last, opts)
+		if err != nil && !llm.IsRecitationError(err) {
+			return "", nil, fmt.Errorf("pages %d-%d: %w", first, last, err)
+		}
+		if err == nil && len(pages) > 0 {
+			extractedChunks++
+			for _, page := range pages {
+				text.add(page.Page, page.Text, models.PageSourceVision)
+			}
+			continue
+		}
+		for page := first; page <= last && page <= len(localPages); page++ {
+			text.add(page, localPages[page-1], models.PageSourceTextLayer)
+		}
+	}
+	if text.empty() {
+		return "", nil, nil
+	}
+	strategy := models.ExtractionStrategyChunked
+	if extractedChunks == 0 {
+		strategy = models.ExtractionStrategyLocalText
+	}
+	return text.String(), withStrategy(text.meta(pageCount), strategy), nil
+}
+
+// extractOptions converts a job file's extraction options to the LLM client's.
+func extractOptions(o *models.ExtractionOptions) llm.ExtractOptions {
+	if o == nil {
+		return llm.ExtractOptions{}
+	}
+	return llm.ExtractOptions{Language: o.Language, Handwriting: o.Handwriting, PreserveTables: o.PreserveTables}
+}
+
+// withStrategy records the extraction strategy in meta, creating it if needed.
+func withStrategy(meta *models.JobFileMeta, strategy string) *models.JobFileMeta {
+	if meta == nil {
+		meta = &models.JobFileMeta{}
+	}
+	meta.Strategy = strategy
+	return meta
+}
@@ -0,0 +1,50 @@
+package processor
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// jobHeartbeatInterval is how often the worker refreshes the heartbeat of the job it processes
+	jobHeartbeatInterval = 30 * time.Second
+	// jobHeartbeatTimeout is how old a heartbeat gets before the job is considered abandoned by its worker
+	jobHeartbeatTimeout = 3 * jobHeartbeatInterval
+)
+
+// claimRun claims a job for this worker and refreshes its heartbeat until release is called, so that
+// another delivery of the job (a Kafka redelivery or a sweeper requeue) does not restart it while it runs
+// and the stuck job sweeper leaves it alone. claimed is false when another worker's heartbeat is fresh.
+func (p *JobProcessor) claimRun(ctx context.Context, jobID uuid.UUID) (release func(), claimed bool, err error) {
+	claimed, err = p.jobRepo.ClaimRun(ctx, jobID, time.Now().Add(-jobHeartbeatTimeout))
+	if err != nil || !claimed {
+		return nil, false, err
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(jobHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := p.jobRepo.Heartbeat(ctx, jobID); err != nil {
+					log.Warn().Err(err).Str("job_id", jobID.String()).Msg("Failed to refresh job heartbeat")
+				}
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+		if err := p.jobRepo.ReleaseRun(context.WithoutCancel(ctx), jobID); err != nil {
+			log.Warn().Err(err).Str("job_id", jobID.String()).Msg("Failed to release job heartbeat")
+		}
+	}, true, nil
+}
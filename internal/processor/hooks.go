@@ -0,0 +1,83 @@
+package processor
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/hooks"
+	"github.com/snappy-loop/stories/internal/llm"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// hookEvent returns the job part of a hook event.
+func hookEvent(stage string, job *models.Job) *hooks.Event {
+	return &hooks.Event{
+		Stage:     stage,
+		JobID:     job.ID,
+		InputType: job.InputType,
+		AudioType: job.AudioType,
+		Metadata:  job.Metadata,
+	}
+}
+
+// runSegmentationHooks lets after_segmentation hooks rewrite the titles and texts of the segments before
+// they are saved. ids are the database IDs the segments will be saved with.
+func (p *JobProcessor) runSegmentationHooks(ctx context.Context, job *models.Job, segments []*llm.Segment, ids []uuid.UUID) error {
+	if !p.hooks.Has(hooks.StageAfterSegmentation) {
+		return nil
+	}
+	ev := hookEvent(hooks.StageAfterSegmentation, job)
+	ev.Segments = make([]hooks.Segment, len(segments))
+	for i, seg := range segments {
+		ev.Segments[i] = hooks.Segment{ID: ids[i], Idx: i, Text: seg.Text}
+		if seg.Title != nil {
+			ev.Segments[i].Title = *seg.Title
+		}
+	}
+	if err := p.hooks.Run(ctx, ev); err != nil {
+		return err
+	}
+	for i, seg := range segments {
+		seg.Text = ev.Segments[i].Text
+		if title := strings.TrimSpace(ev.Segments[i].Title); title != "" {
+			seg.Title = &title
+		}
+	}
+	return nil
+}
+
+// runBeforeTTSHooks returns the script to synthesize after before_tts hooks. Like the lexicon, hooks only
+// change what is spoken: the saved narration text stays as generated.
+func (p *JobProcessor) runBeforeTTSHooks(ctx context.Context, job *models.Job, segmentID uuid.UUID, idx int, text, script string) (string, error) {
+	if !p.hooks.Has(hooks.StageBeforeTTS) {
+		return script, nil
+	}
+	ev := hookEvent(hooks.StageBeforeTTS, job)
+	ev.Segment = &hooks.Segment{ID: segmentID, Idx: idx, Text: text}
+	ev.Script = script
+	if err := p.hooks.Run(ctx, ev); err != nil {
+		return "", err
+	}
+	return ev.Script, nil
+}
+
+// runAssetHooks notifies after_asset_upload hooks of a stored segment asset.
+func (p *JobProcessor) runAssetHooks(ctx context.Context, job *models.Job, idx int, asset *models.Asset) error {
+	if !p.hooks.Has(hooks.StageAfterAssetUpload) {
+		return nil
+	}
+	ev := hookEvent(hooks.StageAfterAssetUpload, job)
+	ev.Asset = &hooks.Asset{
+		ID:        asset.ID,
+		Kind:      asset.Kind,
+		MimeType:  asset.MimeType,
+		S3Key:     asset.S3Key,
+		SizeBytes: asset.SizeBytes,
+	}
+	if asset.SegmentID != nil {
+		ev.Asset.SegmentID = *asset.SegmentID
+		ev.Segment = &hooks.Segment{ID: *asset.SegmentID, Idx: idx}
+	}
+	return p.hooks.Run(ctx, ev)
+}
@@ -1,20 +1,33 @@
 package processor
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/anomaly"
 	"github.com/snappy-loop/stories/internal/config"
 	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/egress"
+	"github.com/snappy-loop/stories/internal/features"
+	"github.com/snappy-loop/stories/internal/hooks"
+	"github.com/snappy-loop/stories/internal/inputtype"
 	"github.com/snappy-loop/stories/internal/kafka"
 	"github.com/snappy-loop/stories/internal/llm"
+	"github.com/snappy-loop/stories/internal/markup"
+	"github.com/snappy-loop/stories/internal/mcpclient"
 	"github.com/snappy-loop/stories/internal/models"
 	"github.com/snappy-loop/stories/internal/storage"
+	"github.com/snappy-loop/stories/internal/waveform"
 )
 
 // JobProcessor handles job processing pipeline
@@ -26,11 +39,27 @@ type JobProcessor struct {
 	jobFileRepo     *database.JobFileRepository
 	fileRepo        *database.FileRepository
 	factCheckRepo   *database.FactCheckRepository
+	eventRepo       *database.JobEventRepository
+	versionRepo     *database.JobVersionRepository
+	userRepo        *database.UserRepository
+	voiceRepo       *database.VoiceRepository
+	disclaimerRepo  *database.DisclaimerRepository
+	inputTypes      *inputtype.Registry // nil uses the built-in input types
+	hooks           *hooks.Registry     // nil runs no pipeline hooks
+	features        *features.Flags     // nil uses the built-in flag defaults
+	anomalies       *anomaly.Monitor    // counts finished jobs; nil counts nothing
+	pricing         llm.Pricing         // estimates jobs' LLM cost; nil tracks no cost
+	apiKeyRepo      apiKeyCostRepository
+	usageLedger     usageLedgerRepository // records job runs for usage exports; nil records nothing
 	inputRegistry   *InputProcessorRegistry
 	llmClient       *llm.Client
+	mcpClient       *mcpclient.Client // calls jobs' external tools
+	egress          *egress.Policy    // checks external tool URLs with their owner's egress allow list
+	jobPublisher    jobPublisher      // queues jobs whose dependencies finished; nil leaves them to the sweeper
 	storageClient   *storage.Client
 	webhookProducer *kafka.Producer
 	config          *config.Config
+	workerID        string // recorded in the job event log (hostname)
 }
 
 // NewJobProcessor creates a new job processor
@@ -45,6 +74,8 @@ func NewJobProcessor(
 	fileRepo *database.FileRepository,
 	factCheckRepo *database.FactCheckRepository,
 ) *JobProcessor {
+	workerID, _ := os.Hostname()
+	policy := egress.NewPolicy(cfg.EgressAllowHosts, cfg.EgressDenyHosts, cfg.EgressAllowPrivate)
 	return &JobProcessor{
 		db:              db,
 		jobRepo:         database.NewJobRepository(db),
@@ -53,14 +84,45 @@ func NewJobProcessor(
 		jobFileRepo:     jobFileRepo,
 		fileRepo:        fileRepo,
 		factCheckRepo:   factCheckRepo,
+		eventRepo:       database.NewJobEventRepository(db),
+		versionRepo:     database.NewJobVersionRepository(db),
+		userRepo:        database.NewUserRepository(db),
+		voiceRepo:       database.NewVoiceRepository(db),
+		disclaimerRepo:  database.NewDisclaimerRepository(db),
+		apiKeyRepo:      database.NewAPIKeyRepository(db),
+		usageLedger:     database.NewUsageLedgerRepository(db),
 		inputRegistry:   inputRegistry,
 		llmClient:       llmClient,
+		mcpClient:       mcpclient.NewClient(policy.Client(cfg.ExternalToolTimeout)),
+		egress:          policy,
 		storageClient:   storageClient,
 		webhookProducer: webhookProducer,
 		config:          cfg,
+		workerID:        workerID,
 	}
 }
 
+// SetInputTypes sets the input type profiles (podcast categories, disclaimers); without it the built-in
+// types are used.
+func (p *JobProcessor) SetInputTypes(r *inputtype.Registry) {
+	p.inputTypes = r
+}
+
+// SetHooks sets the pipeline hooks (PIPELINE_HOOKS) run after segmentation, before TTS and after asset upload.
+func (p *JobProcessor) SetHooks(r *hooks.Registry) {
+	p.hooks = r
+}
+
+// SetFeatures sets the feature flags gating pipeline steps being rolled out (FEATURE_FLAGS and the admin API).
+func (p *JobProcessor) SetFeatures(f *features.Flags) {
+	p.features = f
+}
+
+// SetAnomalyMonitor makes the processor count the jobs it finishes, by error code, in m's failure rates.
+func (p *JobProcessor) SetAnomalyMonitor(m *anomaly.Monitor) {
+	p.anomalies = m
+}
+
 // audioExtension returns the file extension for an audio MIME type (e.g. "audio/wav" -> "wav").
 func audioExtension(mimeType string) string {
 	switch mimeType {
@@ -107,10 +169,58 @@ func (p *JobProcessor) ProcessJob(ctx context.Context, jobID uuid.UUID) error {
 			Msg("Job already processed")
 		return nil
 	}
+	release, claimed, err := p.claimRun(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to claim job: %w", err)
+	}
+	if !claimed {
+		log.Warn().Str("job_id", jobID.String()).Str("status", job.Status).Msg("Job is being processed by another worker, skipping")
+		return nil
+	}
+	defer release()
+	ctx, chargeCost := p.meterCost(ctx, job)
+	defer chargeCost()
+
+	// Duplicates (dedupe) reuse their source job's results instead of running the pipeline
+	if job.DuplicateOf != nil {
+		return p.processDuplicate(ctx, job)
+	}
+
+	// Reviewer-requested regeneration (require_review) redoes only the listed segments
+	if job.ReviewRegeneration != nil {
+		return p.processReviewRegeneration(ctx, job)
+	}
+	if job.Status == models.JobStatusAwaitingReview {
+		log.Warn().Str("job_id", jobID.String()).Msg("Job is awaiting review, nothing to process")
+		return nil
+	}
+
+	// Jobs with depends_on wait until all the jobs they depend on have succeeded
+	if job.Status == models.JobStatusQueued && len(job.DependsOn) > 0 {
+		if ready, err := p.dependenciesReady(ctx, job); !ready || err != nil {
+			return err
+		}
+	}
+	if job.Status == models.JobStatusQueued {
+		if held, err := p.costCapped(ctx, job); held || err != nil {
+			return err
+		}
+	}
 
-	// Idempotent restart: if status is "running", a previous worker may have crashed before
-	// finishing. Clear partial segments and assets so we don't create duplicates when we
-	// re-run the pipeline (segments table has no unique constraint on (job_id, idx)).
+	// Update job status to running. The update is conditional, so if another worker finished the
+	// job since we read it, the transition is rejected and this delivery is dropped.
+	if err := p.updateJobStatus(ctx, jobID, "running", nil, nil); err != nil {
+		if errors.Is(err, database.ErrInvalidJobTransition) {
+			log.Warn().Err(err).Str("job_id", jobID.String()).Msg("Job can no longer be started, skipping")
+			return nil
+		}
+		log.Error().Err(err).Msg("Failed to update job status to running")
+	}
+
+	// Idempotent restart: if status was "running", a previous worker crashed before finishing (a live
+	// one would have kept its heartbeat fresh and claimRun would have failed). Clear partial segments
+	// and assets so we don't create duplicates when we re-run the pipeline (segments table has no
+	// unique constraint on (job_id, idx)).
 	if job.Status == "running" {
 		log.Info().
 			Str("job_id", jobID.String()).
@@ -123,52 +233,164 @@ func (p *JobProcessor) ProcessJob(ctx context.Context, jobID uuid.UUID) error {
 		}
 	}
 
-	// Update job status to running
-	if err := p.updateJobStatus(ctx, jobID, "running", nil, nil); err != nil {
-		log.Error().Err(err).Msg("Failed to update job status to running")
+	firstStep := models.JobStepSegmenting
+	if job.InputSource == "files" || job.InputSource == "mixed" {
+		firstStep = models.JobStepExtracting
+	}
+	p.warnProgress(jobID, p.jobRepo.ResetProgress(ctx, jobID, firstStep))
+	startedAt := time.Now()
+	p.resolveDisclaimer(ctx, job)
+	ctx, variants := p.assignExperiments(ctx, jobID)
+	ctx = p.withVoice(p.withLexicon(ctx, job), job)
+	pickedUp := map[string]any{
+		"worker":  p.workerID,
+		"restart": job.Status == "running",
 	}
+	if variants != nil {
+		pickedUp["experiments"] = variants
+	}
+	p.recordEvent(ctx, jobID, models.JobEventPickedUp, "Picked up by worker "+p.workerID, pickedUp)
 
 	// Process job with error handling
 	if err := p.processJobPipeline(ctx, job); err != nil {
+		if errors.Is(err, errJobCanceled) {
+			p.finishCanceled(ctx, job, startedAt)
+			return nil
+		}
+		if p.scheduleRetry(ctx, jobID, err, startedAt) {
+			return kafka.Retry(err)
+		}
 		log.Error().
 			Err(err).
 			Str("job_id", jobID.String()).
+			Int64("duration_ms", time.Since(startedAt).Milliseconds()).
 			Msg("Job processing failed")
 
 		// Update job status to failed
 		errCode := "processing_error"
 		errMsg := err.Error()
 		if err := p.updateJobStatus(ctx, jobID, "failed", &errCode, &errMsg); err != nil {
+			if errors.Is(err, database.ErrInvalidJobTransition) {
+				log.Warn().Err(err).Str("job_id", jobID.String()).Msg("Job finished elsewhere, not marking failed")
+				return nil
+			}
 			log.Error().Err(err).Msg("Failed to update job status to failed")
 		}
+		p.recordEvent(ctx, jobID, models.JobEventFailed, "Job failed: "+errMsg, map[string]any{
+			"error_code":  errCode,
+			"duration_ms": time.Since(startedAt).Milliseconds(),
+		})
 
 		// Publish webhook event for failure
-		p.publishWebhookEvent(ctx, jobID, "job_failed")
+		p.publishWebhookEvent(ctx, jobID, models.EventJobFailed)
+		p.resolveDuplicates(ctx, jobID)
+		p.resolveDependents(ctx, jobID)
+		p.logExperimentOutcome(ctx, jobID, variants, models.JobStatusFailed, time.Since(startedAt))
 
 		return err
 	}
 
+	// Jobs with require_review wait for a reviewer; webhooks and duplicates follow the approval
+	if job.RequireReview {
+		if err := p.updateJobStatus(ctx, jobID, models.JobStatusAwaitingReview, nil, nil); err != nil {
+			if errors.Is(err, database.ErrInvalidJobTransition) {
+				log.Warn().Err(err).Str("job_id", jobID.String()).Msg("Job finished elsewhere, not marking awaiting review")
+				return nil
+			}
+			log.Error().Err(err).Msg("Failed to update job status to awaiting_review")
+		}
+		p.warnProgress(jobID, p.jobRepo.UpdateProgressStep(ctx, jobID, models.JobStepDone))
+		p.recordEvent(ctx, jobID, models.JobEventAwaitingReview, "Job awaiting review", map[string]any{
+			"duration_ms": time.Since(startedAt).Milliseconds(),
+		})
+		p.publishWebhookEvent(ctx, jobID, models.EventJobAwaitingReview)
+		p.logExperimentOutcome(ctx, jobID, variants, models.JobStatusAwaitingReview, time.Since(startedAt))
+		log.Info().
+			Str("job_id", jobID.String()).
+			Int64("duration_ms", time.Since(startedAt).Milliseconds()).
+			Msg("Job processing completed, awaiting review")
+		return nil
+	}
+
 	// Update job status to succeeded
 	if err := p.updateJobStatus(ctx, jobID, "succeeded", nil, nil); err != nil {
+		if errors.Is(err, database.ErrInvalidJobTransition) {
+			log.Warn().Err(err).Str("job_id", jobID.String()).Msg("Job finished elsewhere, not marking succeeded")
+			return nil
+		}
 		log.Error().Err(err).Msg("Failed to update job status to succeeded")
 	}
+	p.warnProgress(jobID, p.jobRepo.UpdateProgressStep(ctx, jobID, models.JobStepDone))
+	p.recordEvent(ctx, jobID, models.JobEventSucceeded, "Job succeeded", map[string]any{
+		"duration_ms": time.Since(startedAt).Milliseconds(),
+	})
+	p.recordCompleted(ctx, jobID)
 
 	// Publish webhook event for success
-	p.publishWebhookEvent(ctx, jobID, "job_completed")
+	p.publishWebhookEvent(ctx, jobID, models.EventJobCompleted)
+	p.resolveDuplicates(ctx, jobID)
+	p.resolveDependents(ctx, jobID)
+	p.logExperimentOutcome(ctx, jobID, variants, models.JobStatusSucceeded, time.Since(startedAt))
 
 	log.Info().
 		Str("job_id", jobID.String()).
+		Int64("duration_ms", time.Since(startedAt).Milliseconds()).
 		Msg("Job processing completed successfully")
 
 	return nil
 }
 
+// scheduleRetry parks the job for a delayed retry when err is transient (e.g. a Gemini outage) and its
+// message has a retry tier left (kafka.NextRetry), and reports whether it did. The job stays running;
+// the retry restarts it from scratch.
+func (p *JobProcessor) scheduleRetry(ctx context.Context, jobID uuid.UUID, err error, startedAt time.Time) bool {
+	retry, ok := kafka.NextRetry(ctx)
+	if !ok || ctx.Err() != nil || !llm.IsTransientError(err) {
+		return false
+	}
+	retryAt := time.Now().Add(retry.Delay)
+	scheduled, dbErr := p.jobRepo.ScheduleRetry(ctx, jobID, retryAt)
+	if dbErr != nil {
+		log.Error().Err(dbErr).Str("job_id", jobID.String()).Msg("Failed to schedule job retry, failing job")
+		return false
+	}
+	if !scheduled {
+		return false
+	}
+	p.recordEvent(ctx, jobID, models.JobEventRetryScheduled, "Transient failure, retry scheduled: "+err.Error(), map[string]any{
+		"attempt":     retry.Attempt + 1,
+		"retry_at":    retryAt.UTC().Format(time.RFC3339),
+		"duration_ms": time.Since(startedAt).Milliseconds(),
+	})
+	p.publishWebhookEvent(ctx, jobID, models.EventJobRetryScheduled)
+	return true
+}
+
 // processJobPipeline executes the full processing pipeline
 func (p *JobProcessor) processJobPipeline(ctx context.Context, job *models.Job) error {
+	// A failed job retried by its user (POST /v1/jobs/{id}/retry) keeps the segments of its failed run
+	if job.Status == models.JobStatusQueued {
+		existing, err := p.segmentRepo.ListByJob(ctx, job.ID)
+		if err != nil {
+			return fmt.Errorf("failed to list segments: %w", err)
+		}
+		if len(existing) > 0 {
+			return p.resumeJobPipeline(ctx, job, existing)
+		}
+	}
+
 	// Step 0: Resolve input to text. For files/mixed, extract from files via vision and combine with optional input text.
 	// The result (including all extracted file text) is segmented and used for narration, audio, and images.
 	textToSegment := job.InputText
-	if job.InputSource == "files" || job.InputSource == "mixed" {
+	var sourcePages sourcePageIndex
+	if job.InputSource == "job" {
+		// No text or files: the input is the segments of the first depends_on job
+		text, err := p.dependencyInput(ctx, job)
+		if err != nil {
+			return err
+		}
+		textToSegment = text
+	} else if job.InputSource == "files" || job.InputSource == "mixed" {
 		if p.inputRegistry == nil {
 			return fmt.Errorf("input processor required for input_source=%s", job.InputSource)
 		}
@@ -192,6 +414,7 @@ func (p *JobProcessor) processJobPipeline(ctx context.Context, job *models.Job)
 			log.Warn().Err(err).Msg("Failed to update job extracted_text")
 		}
 		job.ExtractedText = &combined
+		sourcePages = newSourcePageIndex(combined, jobFiles)
 	} else if p.inputRegistry != nil {
 		processor := p.inputRegistry.GetProcessor(job.InputSource)
 		if processor != nil {
@@ -203,16 +426,43 @@ func (p *JobProcessor) processJobPipeline(ctx context.Context, job *models.Job)
 		}
 	}
 
+	if p.canceled(ctx, job.ID) {
+		return errJobCanceled
+	}
+
 	// Step 1: Segment the text (includes extracted file content when input is files/mixed)
 	log.Info().Str("job_id", job.ID.String()).Msg("Step 1: Segmenting text")
-	segments, err := p.llmClient.SegmentText(ctx, textToSegment, job.SegmentsCount, job.InputType)
-	if err != nil {
-		return fmt.Errorf("segmentation failed: %w", err)
+	p.warnProgress(job.ID, p.jobRepo.UpdateProgressStep(ctx, job.ID, models.JobStepSegmenting))
+	segmentStart := time.Now()
+	var segments []*llm.Segment
+	var err error
+	if job.SegmentationStrategy == models.SegmentationStrategyHeuristic {
+		segments = p.llmClient.SegmentTextHeuristic(textToSegment, job.SegmentsCount)
+	} else {
+		segments, err = p.llmClient.SegmentText(ctx, textToSegment, job.SegmentsCount, job.InputType)
+		if err != nil {
+			return fmt.Errorf("segmentation failed: %w", err)
+		}
 	}
+	segmentationMs := time.Since(segmentStart).Milliseconds()
+	p.recordEvent(ctx, job.ID, models.JobEventSegmentationCompleted,
+		fmt.Sprintf("Segmentation done in %d ms", segmentationMs),
+		map[string]any{"duration_ms": segmentationMs, "segments": len(segments), "strategy": job.SegmentationStrategy})
 
-	// Save segments to database and keep their IDs for asset foreign keys.
-	// Sanitize text to valid UTF-8 so PostgreSQL never sees invalid byte sequences.
+	// Keep the segments' IDs for asset foreign keys; after_segmentation hooks see them too.
 	segmentIDs := make([]uuid.UUID, len(segments))
+	for i := range segmentIDs {
+		segmentIDs[i] = uuid.New()
+	}
+	if err := p.runSegmentationHooks(ctx, job, segments, segmentIDs); err != nil {
+		return err
+	}
+	if p.canceled(ctx, job.ID) {
+		return errJobCanceled
+	}
+
+	// Save segments to database.
+	// Sanitize text to valid UTF-8 so PostgreSQL never sees invalid byte sequences.
 	for i, seg := range segments {
 		titleVal := ""
 		if seg.Title != nil {
@@ -222,7 +472,7 @@ func (p *JobProcessor) processJobPipeline(ctx context.Context, job *models.Job)
 			titleVal = fmt.Sprintf("Part %d", i+1)
 		}
 		segment := &models.Segment{
-			ID:          uuid.New(),
+			ID:          segmentIDs[i],
 			JobID:       job.ID,
 			Idx:         i,
 			StartChar:   seg.StartChar,
@@ -232,8 +482,8 @@ func (p *JobProcessor) processJobPipeline(ctx context.Context, job *models.Job)
 			Status:      "queued",
 			CreatedAt:   time.Now(),
 			UpdatedAt:   time.Now(),
+			SourcePages: sourcePages.pagesFor(seg.StartChar, seg.EndChar),
 		}
-		segmentIDs[i] = segment.ID
 
 		if err := p.segmentRepo.Create(ctx, segment); err != nil {
 			return fmt.Errorf("failed to save segment %d: %w", i, err)
@@ -247,6 +497,28 @@ func (p *JobProcessor) processJobPipeline(ctx context.Context, job *models.Job)
 
 	// Step 2: Process each segment asynchronously with limited concurrency
 	log.Info().Str("job_id", job.ID.String()).Msg("Step 2: Processing segments (async)")
+	work := make([]segmentWork, len(segments))
+	for i, seg := range segments {
+		work[i] = segmentWork{seg: seg, idx: i, id: segmentIDs[i]}
+	}
+	if err := p.generateSegments(ctx, job, work, len(segments), 0); err != nil {
+		return err
+	}
+	return p.finishPipeline(ctx, job)
+}
+
+// segmentWork is a segment for generateSegments: its text, index and database ID (the assets' FK).
+type segmentWork struct {
+	seg *llm.Segment
+	idx int
+	id  uuid.UUID
+}
+
+// generateSegments generates the segments in work concurrently (MaxConcurrentSegments), updating the
+// progress (total segments, done already finished) and the partial markup as they complete. It returns
+// errJobCanceled when the job was canceled meanwhile, else the first segment error.
+func (p *JobProcessor) generateSegments(ctx context.Context, job *models.Job, work []segmentWork, total, done int) error {
+	p.warnProgress(job.ID, p.jobRepo.StartSegmentProgress(ctx, job.ID, total, done))
 
 	concurrency := p.config.MaxConcurrentSegments
 	if concurrency < 1 {
@@ -256,40 +528,61 @@ func (p *JobProcessor) processJobPipeline(ctx context.Context, job *models.Job)
 	var wg sync.WaitGroup
 	var firstErr error
 	var mu sync.Mutex
+	var markupMu sync.Mutex  // serializes partial markup writes so a later one always has more segments
+	var canceled atomic.Bool // set by the first segment to find the job canceled; the rest are skipped
 
-	for i := range segments {
-		idx := i
-		segCopy := segments[i]
-		segmentID := segmentIDs[i]
+	for _, w := range work {
 		wg.Add(1)
-		go func(seg *llm.Segment) {
+		go func(w segmentWork) {
 			defer wg.Done()
 			sem <- struct{}{}
 			defer func() { <-sem }()
+			// Hold back while Gemini is throttling us rather than starting calls that would only queue
+			_ = p.llmClient.WaitUntilUnpaused(ctx)
+			if canceled.Load() || p.canceled(ctx, job.ID) {
+				canceled.Store(true)
+				return
+			}
 
 			log.Info().
 				Str("job_id", job.ID.String()).
-				Int("segment", idx+1).
-				Int("total", len(segments)).
+				Int("segment", w.idx+1).
+				Int("total", total).
 				Msg("Processing segment")
 
-			if err := p.processSegment(ctx, job, seg, idx, segmentID); err != nil {
+			segErr := p.processSegment(ctx, job, w.seg, w.idx, w.id, "")
+			p.warnProgress(job.ID, p.jobRepo.IncrementSegmentProgress(ctx, job.ID, segErr != nil))
+			if segErr == nil {
+				markupMu.Lock()
+				p.updatePartialMarkup(ctx, job.ID)
+				markupMu.Unlock()
+			}
+			if err := segErr; err != nil {
+				p.recordEvent(ctx, job.ID, models.JobEventSegmentFailed,
+					fmt.Sprintf("Segment %d failed: %v", w.idx, err),
+					map[string]any{"segment_idx": w.idx, "error": err.Error()})
 				mu.Lock()
 				if firstErr == nil {
-					firstErr = fmt.Errorf("segment %d: %w", idx, err)
+					firstErr = fmt.Errorf("segment %d: %w", w.idx, err)
 				}
 				mu.Unlock()
 			}
-		}(segCopy)
+		}(w)
 	}
 
 	wg.Wait()
-	if firstErr != nil {
-		return firstErr
+	if canceled.Load() || p.canceled(ctx, job.ID) {
+		return errJobCanceled
 	}
+	return firstErr
+}
 
+// finishPipeline builds and saves the output markup of a job whose segments are generated, and updates its
+// output version, podcast feed and preview.
+func (p *JobProcessor) finishPipeline(ctx context.Context, job *models.Job) error {
 	// Step 3: Generate output markup
 	log.Info().Str("job_id", job.ID.String()).Msg("Step 3: Generating output markup")
+	p.warnProgress(job.ID, p.jobRepo.UpdateProgressStep(ctx, job.ID, models.JobStepFinalizing))
 	markup, err := p.generateOutputMarkup(ctx, job.ID)
 	if err != nil {
 		return fmt.Errorf("failed to generate markup: %w", err)
@@ -299,26 +592,61 @@ func (p *JobProcessor) processJobPipeline(ctx context.Context, job *models.Job)
 	if err := p.jobRepo.UpdateMarkup(ctx, job.ID, markup); err != nil {
 		return fmt.Errorf("failed to save markup: %w", err)
 	}
+	p.recordVersion(ctx, job.ID, models.OutputVersionGenerated)
+	p.updatePodcastFeed(ctx, job)
+	p.updatePreview(ctx, job)
 
 	return nil
 }
 
 // processSegment processes a single segment. segmentID is the database segment ID (used for asset FK).
-func (p *JobProcessor) processSegment(ctx context.Context, job *models.Job, seg *llm.Segment, idx int, segmentID uuid.UUID) error {
+// A non-empty feedback (reviewer comment) regenerates narration and image prompt with it.
+func (p *JobProcessor) processSegment(ctx context.Context, job *models.Job, seg *llm.Segment, idx int, segmentID uuid.UUID, feedback string) error {
 	// Update segment status to running
 	if err := p.segmentRepo.UpdateStatus(ctx, job.ID, idx, "running"); err != nil {
 		log.Error().Err(err).Msg("Failed to update segment status")
 	}
 
 	// Generate narration script
-	script, err := p.llmClient.GenerateNarration(ctx, seg.Text, job.AudioType, job.InputType)
+	var script string
+	var err error
+	if feedback != "" {
+		script, err = p.llmClient.RegenerateNarration(ctx, seg.Text, job.AudioType, job.InputType, feedback)
+	} else {
+		script, err = p.llmClient.GenerateNarration(ctx, seg.Text, job.AudioType, job.InputType)
+	}
 	if err != nil {
 		p.segmentRepo.UpdateStatus(ctx, job.ID, idx, "failed")
 		return fmt.Errorf("narration generation failed: %w", err)
 	}
 
+	// Optional quality gate: score outputs with the LLM judge and regenerate them below the threshold
+	var quality *models.SegmentQuality
+	if job.QualityCheck {
+		quality = &models.SegmentQuality{}
+		script = p.checkNarrationQuality(ctx, job, seg, idx, script, quality)
+	}
+
+	// Financial jobs end with their compliance disclaimer
+	if script, err = p.appendDisclaimer(ctx, job, idx, script); err != nil {
+		p.segmentRepo.UpdateStatus(ctx, job.ID, idx, "failed")
+		return err
+	}
+
+	if script != "" {
+		if err := p.segmentRepo.UpdateNarration(ctx, segmentID, script); err != nil {
+			log.Warn().Err(err).Str("job_id", job.ID.String()).Int("segment", idx).Msg("Failed to save narration text")
+		}
+	}
+
+	spoken, err := p.runBeforeTTSHooks(ctx, job, segmentID, idx, seg.Text, script)
+	if err != nil {
+		p.segmentRepo.UpdateStatus(ctx, job.ID, idx, "failed")
+		return err
+	}
+
 	// Generate audio (Gemini Pro)
-	audio, err := p.llmClient.GenerateAudio(ctx, script, job.AudioType)
+	audio, err := p.llmClient.GenerateAudio(ctx, spoken, job.AudioType)
 	if err != nil {
 		log.Error().Err(err).
 			Str("job_id", job.ID.String()).
@@ -328,6 +656,98 @@ func (p *JobProcessor) processSegment(ctx context.Context, job *models.Job, seg
 		return fmt.Errorf("audio generation failed: %w", err)
 	}
 
+	if _, err := p.saveAudio(ctx, job, idx, segmentID, audio); err != nil {
+		if errors.Is(err, errUploadFailed) {
+			p.segmentRepo.UpdateStatus(ctx, job.ID, idx, "failed")
+		}
+		return err
+	}
+
+	// Generate image prompt
+	var imagePrompt string
+	if feedback != "" {
+		imagePrompt, err = p.llmClient.RegenerateImagePrompt(ctx, seg.Text, job.InputType, feedback)
+	} else {
+		imagePrompt, err = p.llmClient.GenerateImagePrompt(ctx, seg.Text, job.InputType)
+	}
+	if err != nil {
+		p.segmentRepo.UpdateStatus(ctx, job.ID, idx, "failed")
+		return fmt.Errorf("image prompt generation failed: %w", err)
+	}
+	if quality != nil {
+		imagePrompt = p.checkImagePromptQuality(ctx, job, seg, idx, imagePrompt, quality)
+	}
+
+	// Generate image; lazy_images jobs only record the prompt, the API generates the image on first download
+	if job.LazyImages {
+		if _, err := p.savePendingImage(ctx, job, idx, segmentID, imagePrompt); err != nil {
+			return err
+		}
+	} else {
+		image, err := p.llmClient.GenerateImage(ctx, imagePrompt)
+		if err != nil {
+			p.segmentRepo.UpdateStatus(ctx, job.ID, idx, "failed")
+			return fmt.Errorf("image generation failed: %w", err)
+		}
+
+		if _, err := p.saveImage(ctx, job, idx, segmentID, image); err != nil {
+			if errors.Is(err, errUploadFailed) {
+				p.segmentRepo.UpdateStatus(ctx, job.ID, idx, "failed")
+			}
+			return err
+		}
+	}
+
+	// Optional fact-check (non-fatal: log only on error). The flag is checked again here: jobs queued or
+	// cloned before it was turned off for the user skip the step.
+	if job.FactCheckNeeded && p.factCheckRepo != nil && p.features.Enabled(ctx, features.FactCheck, job.UserID) {
+		factCheckText, err := p.llmClient.FactCheckSegment(ctx, seg.Text)
+		if err != nil {
+			log.Warn().Err(err).Str("job_id", job.ID.String()).Int("segment", idx).Msg("Fact-check failed, skipping for segment")
+		} else if factCheckText != "" {
+			fc := &models.SegmentFactCheck{
+				ID:            uuid.New(),
+				SegmentID:     segmentID,
+				JobID:         job.ID,
+				FactCheckText: factCheckText,
+				CreatedAt:     time.Now(),
+			}
+			if err := p.factCheckRepo.Create(ctx, fc); err != nil {
+				log.Warn().Err(err).Str("job_id", job.ID.String()).Int("segment", idx).Msg("Failed to save fact-check for segment")
+			}
+		}
+	}
+
+	// Optional external MCP tool (e.g. a customer's terminology checker); results go to segment metadata
+	if err := p.callExternalTool(ctx, job, segmentID, idx, seg.Title, seg.Text); err != nil {
+		p.segmentRepo.UpdateStatus(ctx, job.ID, idx, "failed")
+		return err
+	}
+
+	if quality != nil {
+		if err := p.segmentRepo.UpdateQuality(ctx, segmentID, quality); err != nil {
+			log.Warn().Err(err).Str("job_id", job.ID.String()).Int("segment", idx).Msg("Failed to save segment quality scores")
+		}
+	}
+
+	// Update segment status to succeeded
+	if err := p.segmentRepo.UpdateStatus(ctx, job.ID, idx, "succeeded"); err != nil {
+		log.Error().Err(err).Msg("Failed to update segment status to succeeded")
+	}
+
+	log.Info().
+		Str("job_id", job.ID.String()).
+		Int("segment", idx).
+		Msg("Segment processing complete")
+
+	return nil
+}
+
+// errUploadFailed marks asset upload failures (the segment is failed; asset row failures are not).
+var errUploadFailed = errors.New("upload failed")
+
+// saveAudio uploads a segment's narration audio to S3 and records the asset (DB segment ID for the FK).
+func (p *JobProcessor) saveAudio(ctx context.Context, job *models.Job, idx int, segmentID uuid.UUID, audio *llm.Audio) (*models.Asset, error) {
 	log.Debug().
 		Str("job_id", job.ID.String()).
 		Int("segment", idx).
@@ -340,55 +760,73 @@ func (p *JobProcessor) processSegment(ctx context.Context, job *models.Job, seg
 	if mimeType == "" {
 		mimeType = "audio/wav"
 	}
-	ext := audioExtension(mimeType)
-	audioKey := fmt.Sprintf("jobs/%s/segments/%d/audio.%s", job.ID, idx, ext)
-	if err := p.storageClient.Upload(ctx, audioKey, audio.Data, mimeType, audio.Size); err != nil {
-		p.segmentRepo.UpdateStatus(ctx, job.ID, idx, "failed")
-		return fmt.Errorf("audio upload failed: %w", err)
+	version, err := p.assetRepo.NextVersion(ctx, segmentID, "audio")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audio version: %w", err)
+	}
+	audioKey := segmentAssetKey(job.ID, idx, "audio", version, audioExtension(mimeType))
+	data, err := io.ReadAll(audio.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio: %w", err)
+	}
+	audioBucket, err := p.storageClient.Upload(ctx, audioKey, bytes.NewReader(data), mimeType, audio.Size)
+	if err != nil {
+		return nil, fmt.Errorf("audio %w: %w", errUploadFailed, err)
 	}
 
-	// Save audio asset (use DB segment ID for FK)
 	audioAsset := &models.Asset{
 		ID:        uuid.New(),
 		JobID:     job.ID,
 		SegmentID: &segmentID,
 		Kind:      "audio",
 		MimeType:  mimeType,
-		S3Bucket:  p.config.S3Bucket,
+		S3Bucket:  audioBucket,
 		S3Key:     audioKey,
 		SizeBytes: audio.Size,
+		Version:   version,
 		Meta: map[string]any{
 			"duration": audio.Duration,
 			"model":    audio.Model,
 		},
 		CreatedAt: time.Now(),
 	}
-
+	addWaveform(audioAsset, data)
 	if err := p.assetRepo.Create(ctx, audioAsset); err != nil {
-		return fmt.Errorf("failed to save audio asset: %w", err)
+		return nil, fmt.Errorf("failed to save audio asset: %w", err)
 	}
-
-	// Generate image prompt
-	imagePrompt, err := p.llmClient.GenerateImagePrompt(ctx, seg.Text, job.InputType)
-	if err != nil {
-		p.segmentRepo.UpdateStatus(ctx, job.ID, idx, "failed")
-		return fmt.Errorf("image prompt generation failed: %w", err)
+	if err := p.runAssetHooks(ctx, job, idx, audioAsset); err != nil {
+		return nil, err
 	}
+	return audioAsset, nil
+}
 
-	// Generate image
-	image, err := p.llmClient.GenerateImage(ctx, imagePrompt)
+// addWaveform stores the peaks of WAV audio in the asset's meta ("peaks", served by /view/asset/{id}/peaks)
+// and replaces the estimated duration with the actual one. Other formats keep the estimate.
+func addWaveform(asset *models.Asset, data []byte) {
+	if asset.MimeType != "audio/wav" {
+		return
+	}
+	w, err := waveform.FromWAV(data, waveform.DefaultPeaks)
 	if err != nil {
-		p.segmentRepo.UpdateStatus(ctx, job.ID, idx, "failed")
-		return fmt.Errorf("image generation failed: %w", err)
+		log.Warn().Err(err).Str("asset_id", asset.ID.String()).Msg("Failed to compute audio waveform")
+		return
 	}
+	asset.Meta["peaks"] = w.Peaks
+	asset.Meta["duration"] = w.Duration
+}
 
+// saveImage uploads a segment's illustration to S3 and records the asset (DB segment ID for the FK).
+func (p *JobProcessor) saveImage(ctx context.Context, job *models.Job, idx int, segmentID uuid.UUID, image *llm.Image) (*models.Asset, error) {
 	// Use actual format from Gemini so Content-Type and file extension match payload.
 	imgMimeType := image.MimeType
 	if imgMimeType == "" {
 		imgMimeType = "image/png"
 	}
-	imgExt := imageExtension(imgMimeType)
-	imageKey := fmt.Sprintf("jobs/%s/segments/%d/image.%s", job.ID, idx, imgExt)
+	version, err := p.assetRepo.NextVersion(ctx, segmentID, "image")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image version: %w", err)
+	}
+	imageKey := segmentAssetKey(job.ID, idx, "image", version, imageExtension(imgMimeType))
 
 	log.Debug().
 		Str("job_id", job.ID.String()).
@@ -397,67 +835,71 @@ func (p *JobProcessor) processSegment(ctx context.Context, job *models.Job, seg
 		Str("mime_type", imgMimeType).
 		Msg("Image from Gemini, uploading to S3")
 
-	// Upload image to S3
-	if err := p.storageClient.Upload(ctx, imageKey, image.Data, imgMimeType, image.Size); err != nil {
-		p.segmentRepo.UpdateStatus(ctx, job.ID, idx, "failed")
-		return fmt.Errorf("image upload failed: %w", err)
+	imageBucket, err := p.storageClient.Upload(ctx, imageKey, image.Data, imgMimeType, image.Size)
+	if err != nil {
+		return nil, fmt.Errorf("image %w: %w", errUploadFailed, err)
 	}
 
-	// Save image asset (use DB segment ID for FK)
 	imageAsset := &models.Asset{
 		ID:        uuid.New(),
 		JobID:     job.ID,
 		SegmentID: &segmentID,
 		Kind:      "image",
 		MimeType:  imgMimeType,
-		S3Bucket:  p.config.S3Bucket,
+		S3Bucket:  imageBucket,
 		S3Key:     imageKey,
 		SizeBytes: image.Size,
+		Version:   version,
 		Meta: map[string]any{
 			"resolution": image.Resolution,
 			"model":      image.Model,
 		},
 		CreatedAt: time.Now(),
 	}
-
 	if err := p.assetRepo.Create(ctx, imageAsset); err != nil {
-		return fmt.Errorf("failed to save image asset: %w", err)
+		return nil, fmt.Errorf("failed to save image asset: %w", err)
 	}
-
-	// Optional fact-check (non-fatal: log only on error)
-	if job.FactCheckNeeded && p.factCheckRepo != nil {
-		factCheckText, err := p.llmClient.FactCheckSegment(ctx, seg.Text)
-		if err != nil {
-			log.Warn().Err(err).Str("job_id", job.ID.String()).Int("segment", idx).Msg("Fact-check failed, skipping for segment")
-		} else if factCheckText != "" {
-			fc := &models.SegmentFactCheck{
-				ID:            uuid.New(),
-				SegmentID:     segmentID,
-				JobID:         job.ID,
-				FactCheckText: factCheckText,
-				CreatedAt:     time.Now(),
-			}
-			if err := p.factCheckRepo.Create(ctx, fc); err != nil {
-				log.Warn().Err(err).Str("job_id", job.ID.String()).Int("segment", idx).Msg("Failed to save fact-check for segment")
-			}
-		}
+	if err := p.runAssetHooks(ctx, job, idx, imageAsset); err != nil {
+		return nil, err
 	}
+	return imageAsset, nil
+}
 
-	// Update segment status to succeeded
-	if err := p.segmentRepo.UpdateStatus(ctx, job.ID, idx, "succeeded"); err != nil {
-		log.Error().Err(err).Msg("Failed to update segment status to succeeded")
+// segmentAssetKey returns the S3 key of a segment asset version: audio.wav for the first version, audio.v2.wav
+// and so on for regenerated ones, so earlier versions are never overwritten.
+func segmentAssetKey(jobID uuid.UUID, idx int, kind string, version int, ext string) string {
+	if version <= 1 {
+		return fmt.Sprintf("jobs/%s/segments/%d/%s.%s", jobID, idx, kind, ext)
 	}
+	return fmt.Sprintf("jobs/%s/segments/%d/%s.v%d.%s", jobID, idx, kind, version, ext)
+}
 
-	log.Info().
-		Str("job_id", job.ID.String()).
-		Int("segment", idx).
-		Msg("Segment processing complete")
+// recordVersion snapshots the job's output after it changed. Failures are logged; the output itself is saved.
+func (p *JobProcessor) recordVersion(ctx context.Context, jobID uuid.UUID, reason string) {
+	if err := p.versionRepo.Record(ctx, jobID, reason); err != nil {
+		log.Error().Err(err).Str("job_id", jobID.String()).Str("reason", reason).Msg("Failed to record output version")
+	}
+}
 
-	return nil
+// updatePartialMarkup saves the markup of the segments completed so far, so GetJob and the view page
+// show partial results while a job runs. Failures are logged; the final markup is written at the end.
+func (p *JobProcessor) updatePartialMarkup(ctx context.Context, jobID uuid.UUID) {
+	markup, err := p.buildOutputMarkup(ctx, jobID, true)
+	if err == nil {
+		err = p.jobRepo.UpdateMarkup(ctx, jobID, markup)
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("job_id", jobID.String()).Msg("Failed to update partial markup")
+	}
 }
 
 // generateOutputMarkup generates the final markup with asset references and file sources
 func (p *JobProcessor) generateOutputMarkup(ctx context.Context, jobID uuid.UUID) (string, error) {
+	return p.buildOutputMarkup(ctx, jobID, false)
+}
+
+// buildOutputMarkup generates the markup of all segments, or only the succeeded ones when completedOnly.
+func (p *JobProcessor) buildOutputMarkup(ctx context.Context, jobID uuid.UUID, completedOnly bool) (string, error) {
 	// Get job files (for SOURCE blocks)
 	var jobFiles []*models.JobFile
 	if p.jobFileRepo != nil {
@@ -482,14 +924,16 @@ func (p *JobProcessor) generateOutputMarkup(ctx context.Context, jobID uuid.UUID
 
 	// Build asset map by segment
 	assetsBySegment := make(map[uuid.UUID][]*models.Asset)
+	assetIDs := make(map[string]bool, len(assets))
 	for _, asset := range assets {
 		if asset.SegmentID != nil {
 			assetsBySegment[*asset.SegmentID] = append(assetsBySegment[*asset.SegmentID], asset)
 		}
+		assetIDs[asset.ID.String()] = true
 	}
 
 	// Generate markup: SOURCE blocks first (file extractions)
-	markup := ""
+	doc := ""
 	for _, jf := range jobFiles {
 		if jf.ExtractedText != nil && *jf.ExtractedText != "" {
 			filename := ""
@@ -499,41 +943,100 @@ func (p *JobProcessor) generateOutputMarkup(ctx context.Context, jobID uuid.UUID
 					filename = file.Filename
 				}
 			}
-			markup += fmt.Sprintf("[[SOURCE file_id=%s filename=%q]]\n", jf.FileID, filename)
-			markup += *jf.ExtractedText + "\n[[/SOURCE]]\n\n"
+			doc += fmt.Sprintf("[[SOURCE file_id=%s filename=%q]]\n", jf.FileID, filename)
+			doc += *jf.ExtractedText + "\n[[/SOURCE]]\n\n"
 		}
 	}
 
 	for _, segment := range segments {
-		markup += fmt.Sprintf("[[SEGMENT id=%s]]\n", segment.ID)
+		if completedOnly && segment.Status != "succeeded" {
+			continue
+		}
+		doc += fmt.Sprintf("[[SEGMENT id=%s]]\n", segment.ID)
 
 		if segment.Title != nil {
-			markup += fmt.Sprintf("# %s\n\n", *segment.Title)
+			doc += fmt.Sprintf("# %s\n\n", *segment.Title)
 		}
 
-		markup += segment.SegmentText + "\n\n"
+		doc += segment.SegmentText + "\n\n"
+
+		// Cite the uploaded file pages the segment was extracted from
+		for _, citation := range citationMarkers(segment.SourcePages) {
+			doc += citation + "\n"
+		}
 
 		// Add asset references
 		for _, asset := range assetsBySegment[segment.ID] {
 			if asset.Kind == "image" {
-				markup += fmt.Sprintf("[[IMAGE asset_id=%s]]\n", asset.ID)
+				doc += fmt.Sprintf("[[IMAGE asset_id=%s]]\n", asset.ID)
 			} else if asset.Kind == "audio" {
-				markup += fmt.Sprintf("[[AUDIO asset_id=%s]]\n", asset.ID)
+				doc += fmt.Sprintf("[[AUDIO asset_id=%s]]\n", asset.ID)
 			}
 		}
 
-		markup += "[[/SEGMENT]]\n\n"
+		doc += "[[/SEGMENT]]\n\n"
 	}
 
-	return markup, nil
+	// Financial jobs end with the compliance disclaimer recorded on the job
+	job, err := p.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get job: %w", err)
+	}
+	disclaimer, err := p.jobDisclaimer(ctx, job)
+	if err != nil {
+		return "", err
+	}
+	if disclaimer != nil {
+		doc += fmt.Sprintf("[[DISCLAIMER jurisdiction=%s version=%d]]\n%s\n[[/DISCLAIMER]]\n", disclaimer.Jurisdiction, disclaimer.Version, disclaimer.Text)
+	}
+
+	// Segment or extracted text containing markup tags would break the document; never save it
+	if err := markup.Validate(doc, assetIDs); err != nil {
+		return "", err
+	}
+	return doc, nil
 }
 
 // updateJobStatus updates the job status in the database
 func (p *JobProcessor) updateJobStatus(ctx context.Context, jobID uuid.UUID, status string, errorCode, errorMessage *string) error {
-	return p.jobRepo.UpdateStatus(ctx, jobID, status, errorCode, errorMessage)
+	if err := p.jobRepo.UpdateStatus(ctx, jobID, status, errorCode, errorMessage); err != nil {
+		return err
+	}
+	switch {
+	case status == models.JobStatusSucceeded:
+		p.anomalies.RecordJob("")
+	case status == models.JobStatusFailed && errorCode != nil:
+		p.anomalies.RecordJob(*errorCode)
+	}
+	return nil
+}
+
+// warnProgress logs a failed progress update. Progress is informational and never fails the job.
+func (p *JobProcessor) warnProgress(jobID uuid.UUID, err error) {
+	if err != nil {
+		log.Warn().Err(err).Str("job_id", jobID.String()).Msg("Failed to update job progress")
+	}
+}
+
+// recordEvent appends an entry to the job's event log. Failures are logged only and never fail the job.
+func (p *JobProcessor) recordEvent(ctx context.Context, jobID uuid.UUID, eventType, message string, data map[string]any) {
+	if p.eventRepo == nil {
+		return
+	}
+	ev := &models.JobEvent{
+		ID:        uuid.New(),
+		JobID:     jobID,
+		Type:      eventType,
+		Message:   message,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+	if err := p.eventRepo.Create(ctx, ev); err != nil {
+		log.Warn().Err(err).Str("job_id", jobID.String()).Str("event", eventType).Msg("Failed to record job event")
+	}
 }
 
-// publishWebhookEvent publishes a webhook event to Kafka so the dispatcher can deliver webhooks.
+// publishWebhookEvent publishes a job event to Kafka so the dispatcher can deliver webhooks and notifications.
 func (p *JobProcessor) publishWebhookEvent(ctx context.Context, jobID uuid.UUID, event string) {
 	if p.webhookProducer == nil {
 		log.Warn().Str("job_id", jobID.String()).Str("event", event).Msg("Webhook producer not configured, skipping publish")
@@ -0,0 +1,40 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// lazyImageMimeType is the MIME type recorded for a pending image until it is generated; the API records
+// the generated image's actual type.
+const lazyImageMimeType = "image/png"
+
+// savePendingImage records a segment's image for a lazy_images job without generating it: a pending asset
+// (meta.pending, no object yet) referenced by the markup like any image, and the prompt the API generates
+// it from on its first download (services.LazyImageService). after_asset_upload hooks are not notified.
+func (p *JobProcessor) savePendingImage(ctx context.Context, job *models.Job, idx int, segmentID uuid.UUID, prompt string) (*models.Asset, error) {
+	version, err := p.assetRepo.NextVersion(ctx, segmentID, "image")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image version: %w", err)
+	}
+	asset := &models.Asset{
+		ID:        uuid.New(),
+		JobID:     job.ID,
+		SegmentID: &segmentID,
+		Kind:      "image",
+		MimeType:  lazyImageMimeType,
+		S3Bucket:  p.storageClient.Bucket(),
+		S3Key:     segmentAssetKey(job.ID, idx, "image", version, imageExtension(lazyImageMimeType)),
+		Version:   version,
+		Meta:      map[string]any{"pending": true},
+		CreatedAt: time.Now(),
+	}
+	if err := p.assetRepo.CreatePending(ctx, asset, prompt); err != nil {
+		return nil, fmt.Errorf("failed to save pending image asset: %w", err)
+	}
+	return asset, nil
+}
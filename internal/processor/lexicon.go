@@ -0,0 +1,23 @@
+package processor
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/lexicon"
+	"github.com/snappy-loop/stories/internal/llm"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// withLexicon returns a context that makes TTS apply the job's pronunciations: the creator's lexicon,
+// read when the audio is generated, with the job's own entries overriding it. A failure to read the
+// user's lexicon is logged and the job's entries are used alone.
+func (p *JobProcessor) withLexicon(ctx context.Context, job *models.Job) context.Context {
+	var userLexicon []models.LexiconEntry
+	if user, err := p.userRepo.GetByID(ctx, job.UserID); err != nil {
+		log.Warn().Err(err).Str("job_id", job.ID.String()).Msg("Failed to get user lexicon")
+	} else {
+		userLexicon = user.Lexicon
+	}
+	return llm.WithLexicon(ctx, lexicon.Merge(userLexicon, job.Lexicon))
+}
@@ -0,0 +1,91 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"unicode"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/llm"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/pdftext"
+)
+
+// minTextLayerChars is the number of letters and digits a page's text layer needs to be used instead of vision.
+// Pages below it are scanned, image-only, or carry little more than a caption.
+const minTextLayerChars = 32
+
+// hasTextLayer reports whether locally extracted page text is substantial enough to skip vision.
+func hasTextLayer(text string) bool {
+	n := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			n++
+			if n >= minTextLayerChars {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// extractPDFLocalFirst reads a PDF's text layer and sends only pages without one to Gemini vision, in
+// runs of consecutive pages of at most pagesPerBatch. ok is false when the PDF cannot be read locally
+// or has no text layer at all; the caller then extracts it with vision as a whole.
+func (p *MultiFileProcessor) extractPDFLocalFirst(ctx context.Context, data []byte, inputType string, opts llm.ExtractOptions) (string, *models.JobFileMeta, bool, error) {
+	// Uploaded PDFs are untrusted; ExtractPages turns parser failures (crafted files included) into errors
+	local, err := pdftext.ExtractPages(data)
+	if err != nil {
+		log.Debug().Err(err).Msg("PDF text layer unavailable; using vision")
+		return "", nil, false, nil
+	}
+	scanned := 0
+	for _, text := range local {
+		if !hasTextLayer(text) {
+			scanned++
+		}
+	}
+	if len(local) == 0 || scanned == len(local) {
+		return "", nil, false, nil
+	}
+
+	batch := max(p.pagesPerBatch, 1)
+	var text pageTextBuilder
+	for page := 1; page <= len(local); {
+		if hasTextLayer(local[page-1]) {
+			text.add(page, local[page-1], models.PageSourceTextLayer)
+			page++
+			continue
+		}
+		last := page
+		for last < len(local) && last-page+1 < batch && !hasTextLayer(local[last]) {
+			last++
+		}
+		pages, err := p.visionPages(ctx, data, inputType, page, last, opts)
+		if err != nil {
+			return "", nil, true, fmt.Errorf("pages %d-%d: %w", page, last, err)
+		}
+		for _, vp := range pages {
+			text.add(vp.Page, vp.Text, models.PageSourceVision)
+		}
+		page = last + 1
+	}
+	log.Info().Int("pages", len(local)).Int("vision_pages", scanned).Msg("Extracted PDF text layer locally")
+	return text.String(), withStrategy(text.meta(len(local)), models.ExtractionStrategyLocalFirst), true, nil
+}
+
+// visionPages extracts pages first..last with vision, retrying with the strict paraphrase prompt on a
+// recitation block. Pages still blocked are left out (they have no text layer to fall back to).
+func (p *MultiFileProcessor) visionPages(ctx context.Context, data []byte, inputType string, first, last int, opts llm.ExtractOptions) ([]llm.ExtractedPage, error) {
+	pages, err := p.llmClient.ExtractPDFPages(ctx, data, inputType, first, last, opts)
+	if err == nil || !llm.IsRecitationError(err) {
+		return pages, err
+	}
+	opts.StrictParaphrase = true
+	pages, err = p.llmClient.ExtractPDFPages(ctx, data, inputType, first, last, opts)
+	if llm.IsRecitationError(err) {
+		log.Warn().Int("first", first).Int("last", last).Msg("Scanned PDF pages blocked for recitation; skipping them")
+		return nil, nil
+	}
+	return pages, err
+}
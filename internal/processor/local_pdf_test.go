@@ -0,0 +1,74 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/snappy-loop/stories/internal/llm"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+func TestHasTextLayer(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"", false},
+		{"Figure 3", false},
+		{"   . , ; --- ... ", false},
+		{"The quarterly report shows revenue growth across regions.", true},
+		{"今天天气很好我们去公园吧你来吗今天天气很好我们去公园吧你来吗今天天气", true},
+	}
+	for _, tt := range tests {
+		if got := hasTextLayer(tt.text); got != tt.want {
+			t.Errorf("hasTextLayer(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestExtractPDFLocalFirst_TextOnly(t *testing.T) {
+	page1 := "Chapter one introduces the main characters and setting."
+	page2 := "Chapter two follows them across the mountains in winter."
+	var pdf strings.Builder
+	pdf.WriteString("%PDF-1.4\n")
+	pdf.WriteString("1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n")
+	pdf.WriteString("2 0 obj << /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >> endobj\n")
+	pdf.WriteString("3 0 obj << /Type /Page /Parent 2 0 R /Contents 5 0 R >> endobj\n")
+	pdf.WriteString("4 0 obj << /Type /Page /Parent 2 0 R /Contents 6 0 R >> endobj\n")
+	for i, text := range []string{page1, page2} {
+		content := fmt.Sprintf("BT 72 720 Td (%s) Tj ET", text)
+		fmt.Fprintf(&pdf, "%d 0 obj << /Length %d >>\nstream\n%s\nendstream endobj\n", i+5, len(content), content)
+	}
+	pdf.WriteString("trailer << /Root 1 0 R >>\n%%EOF\n")
+
+	// No LLM client: a PDF whose pages all have a text layer never reaches vision
+	p := &MultiFileProcessor{pagesPerBatch: 10, localPDFText: true}
+	text, meta, ok, err := p.extractPDFLocalFirst(context.Background(), []byte(pdf.String()), "fictional", llm.ExtractOptions{})
+	if err != nil || !ok {
+		t.Fatalf("extractPDFLocalFirst() ok=%v err=%v", ok, err)
+	}
+	if want := page1 + "\n\n" + page2; text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+	if meta.Strategy != models.ExtractionStrategyLocalFirst || meta.PageCount != 2 || len(meta.Pages) != 2 {
+		t.Fatalf("meta = %+v", meta)
+	}
+	for i, r := range meta.Pages {
+		if r.Page != i+1 || r.Source != models.PageSourceTextLayer || text[r.StartChar:r.EndChar] != []string{page1, page2}[i] {
+			t.Errorf("page range %d = %+v", i, r)
+		}
+	}
+
+	// Not a readable PDF: left to vision
+	if _, _, ok, _ := p.extractPDFLocalFirst(context.Background(), []byte("not a pdf"), "fictional", llm.ExtractOptions{}); ok {
+		t.Error("extractPDFLocalFirst() ok = true for unreadable data")
+	}
+
+	// A crafted PDF the parser rejects (it used to panic on a negative /First) is left to vision too
+	crafted := "%PDF-1.7\n1 0 obj\n<< /Type /ObjStm /N 1 /First -5 /Length 4 >>\nstream\n1 0 \nendstream\nendobj\ntrailer << /Root 1 0 R >>\n%%EOF\n"
+	if text, _, ok, err := p.extractPDFLocalFirst(context.Background(), []byte(crafted), "fictional", llm.ExtractOptions{}); ok || err != nil || text != "" {
+		t.Errorf("extractPDFLocalFirst(crafted) = %q, ok=%v, err=%v; want it left to vision", text, ok, err)
+	}
+}
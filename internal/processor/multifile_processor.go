@@ -20,6 +20,8 @@ type MultiFileProcessor struct {
 	storageClient *storage.Client
 	fileRepo      *database.FileRepository
 	jobFileRepo   *database.JobFileRepository
+	pagesPerBatch int  // PDF pages extracted per vision request; 0 sends each PDF whole
+	localPDFText  bool // read PDF text layers locally and send only scanned pages to vision
 }
 
 // NewMultiFileProcessor creates a new MultiFileProcessor
@@ -37,6 +39,16 @@ func NewMultiFileProcessor(
 	}
 }
 
+// SetPDFPagesPerBatch enables page-by-page PDF extraction with n pages per vision request (0 disables it).
+func (p *MultiFileProcessor) SetPDFPagesPerBatch(n int) {
+	p.pagesPerBatch = n
+}
+
+// SetLocalPDFText enables reading the text layer of PDFs before vision extraction (see extractPDFLocalFirst).
+func (p *MultiFileProcessor) SetLocalPDFText(enabled bool) {
+	p.localPDFText = enabled
+}
+
 // Name returns the processor name
 func (p *MultiFileProcessor) Name() string {
 	return "MultiFileProcessor"
@@ -61,6 +73,12 @@ func (p *MultiFileProcessor) Process(ctx context.Context, job *models.Job, jobFi
 	})
 
 	for _, jf := range jobFiles {
+		// Text already extracted for this file and input type (cloned jobs) is reused as-is
+		if jf.Status == "succeeded" && jf.ExtractedText != nil {
+			parts = append(parts, *jf.ExtractedText)
+			continue
+		}
+
 		file, err := p.fileRepo.GetByID(ctx, jf.FileID)
 		if err != nil {
 			log.Error().Err(err).Str("file_id", jf.FileID.String()).Msg("Failed to get file for extraction")
@@ -68,7 +86,7 @@ func (p *MultiFileProcessor) Process(ctx context.Context, job *models.Job, jobFi
 			return "", fmt.Errorf("file %s: %w", jf.FileID.String(), err)
 		}
 
-		rc, err := p.storageClient.GetObject(ctx, file.S3Key)
+		rc, err := p.storageClient.GetObject(ctx, file.S3Bucket, file.S3Key)
 		if err != nil {
 			log.Error().Err(err).Str("s3_key", file.S3Key).Msg("Failed to download file from S3")
 			_ = p.jobFileRepo.UpdateExtraction(ctx, jf.ID, nil, "failed")
@@ -81,7 +99,7 @@ func (p *MultiFileProcessor) Process(ctx context.Context, job *models.Job, jobFi
 			return "", fmt.Errorf("read file %s: %w", file.Filename, err)
 		}
 
-		extracted, err := p.llmClient.ExtractContent(ctx, data, file.MimeType, job.InputType)
+		extracted, meta, err := p.extractFile(ctx, data, file.MimeType, job.InputType, extractOptions(jf.ExtractionOptions))
 		if err != nil {
 			log.Error().Err(err).Str("file_id", jf.FileID.String()).Msg("Gemini vision extraction failed")
 			_ = p.jobFileRepo.UpdateExtraction(ctx, jf.ID, nil, "failed")
@@ -90,12 +108,84 @@ func (p *MultiFileProcessor) Process(ctx context.Context, job *models.Job, jobFi
 
 		jf.ExtractedText = &extracted
 		jf.Status = "succeeded"
+		jf.Meta = meta
 		if err := p.jobFileRepo.UpdateExtraction(ctx, jf.ID, &extracted, "succeeded"); err != nil {
 			log.Warn().Err(err).Str("job_file_id", jf.ID.String()).Msg("Failed to update job_file extraction")
 		}
+		if meta != nil {
+			if err := p.jobFileRepo.UpdateMeta(ctx, jf.ID, meta); err != nil {
+				log.Warn().Err(err).Str("job_file_id", jf.ID.String()).Msg("Failed to update job_file page metadata")
+			}
+		}
 
 		parts = append(parts, extracted)
 	}
 
 	return strings.Join(parts, "\n\n---\n\n"), nil
 }
+
+// pdfPageCount returns the page count of a PDF to extract page by page, or 0 to extract the file in one request
+// (not a PDF, page-by-page extraction disabled, or pages could not be counted).
+func (p *MultiFileProcessor) pdfPageCount(mimeType string, data []byte) int {
+	if p.pagesPerBatch <= 0 || mimeType != llm.PDFMimeType {
+		return 0
+	}
+	return llm.PDFPageCount(data)
+}
+
+// visionExtract extracts a file with Gemini vision: PDFs page by page when enabled, anything else in one request.
+func (p *MultiFileProcessor) visionExtract(ctx context.Context, data []byte, mimeType, inputType string, opts llm.ExtractOptions) (string, *models.JobFileMeta, error) {
+	if pageCount := p.pdfPageCount(mimeType, data); pageCount > 0 {
+		return p.extractPDFPages(ctx, data, inputType, pageCount, opts)
+	}
+	extracted, err := p.llmClient.ExtractContentWithOptions(ctx, data, mimeType, inputType, opts)
+	return extracted, nil, err
+}
+
+// extractPDFPages extracts a PDF in batches of pagesPerBatch pages and joins the page texts, recording where
+// each page sits in the result. Falls back to whole-document extraction if no batch returned any text.
+func (p *MultiFileProcessor) extractPDFPages(ctx context.Context, data []byte, inputType string, pageCount int, opts llm.ExtractOptions) (string, *models.JobFileMeta, error) {
+	var text pageTextBuilder
+	for first := 1; first <= pageCount; first += p.pagesPerBatch {
+		last := min(first+p.pagesPerBatch-1, pageCount)
+		pages, err := p.llmClient.ExtractPDFPages(ctx, data, inputType, first, last, opts)
+		if err != nil {
+			return "", nil, fmt.Errorf("pages %d-%d: %w", first, last, err)
+		}
+		for _, page := range pages {
+			text.add(page.Page, page.Text, models.PageSourceVision)
+		}
+	}
+	if text.empty() {
+		log.Warn().Int("pages", pageCount).Msg("Page-by-page PDF extraction returned no text; extracting whole document")
+		extracted, err := p.llmClient.ExtractContentWithOptions(ctx, data, llm.PDFMimeType, inputType, opts)
+		return extracted, nil, err
+	}
+	return text.String(), text.meta(pageCount), nil
+}
+
+// pageTextBuilder joins page texts with blank lines and records each page's range in the result.
+type pageTextBuilder struct {
+	b     strings.Builder
+	pages []models.JobFilePageRange
+}
+
+func (t *pageTextBuilder) add(page int, text, source string) {
+	if text == "" {
+		return
+	}
+	if t.b.Len() > 0 {
+		t.b.WriteString("\n\n")
+	}
+	start := t.b.Len()
+	t.b.WriteString(text)
+	t.pages = append(t.pages, models.JobFilePageRange{Page: page, StartChar: start, EndChar: t.b.Len(), Source: source})
+}
+
+func (t *pageTextBuilder) empty() bool { return t.b.Len() == 0 }
+
+func (t *pageTextBuilder) String() string { return t.b.String() }
+
+func (t *pageTextBuilder) meta(pageCount int) *models.JobFileMeta {
+	return &models.JobFileMeta{PageCount: pageCount, Pages: t.pages}
+}
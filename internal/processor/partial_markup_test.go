@@ -0,0 +1,73 @@
+package processor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/testutil"
+)
+
+func TestUpdatePartialMarkup(t *testing.T) {
+	db := testutil.Postgres(t)
+	p, _ := newTestProcessor(t, db, geminiTestEndpoint(t))
+	ctx := context.Background()
+	jobRepo := database.NewJobRepository(db)
+	segmentRepo := database.NewSegmentRepository(db)
+
+	// newJob returns a running job whose first segment succeeded and whose second is in status second
+	newJob := func(second string) *models.Job {
+		job := testutil.CreateJob(t, db)
+		if err := jobRepo.UpdateStatus(ctx, job.ID, models.JobStatusRunning, nil, nil); err != nil {
+			t.Fatalf("start job: %v", err)
+		}
+		now := time.Now()
+		for i, status := range []string{"succeeded", second} {
+			seg := &models.Segment{ID: uuid.New(), JobID: job.ID, Idx: i, SegmentText: []string{"First part.", "Second part."}[i],
+				Status: status, CreatedAt: now, UpdatedAt: now}
+			if err := segmentRepo.Create(ctx, seg); err != nil {
+				t.Fatalf("create segment %d: %v", i, err)
+			}
+		}
+		return job
+	}
+	markupOf := func(jobID uuid.UUID) string {
+		t.Helper()
+		job, err := jobRepo.GetByID(ctx, jobID)
+		if err != nil {
+			t.Fatalf("get job: %v", err)
+		}
+		if job.OutputMarkup == nil {
+			return ""
+		}
+		return *job.OutputMarkup
+	}
+
+	// One of two segments succeeded, the other failed
+	job := newJob("failed")
+	p.updatePartialMarkup(ctx, job.ID)
+	if got := markupOf(job.ID); !strings.Contains(got, "First part.") || strings.Contains(got, "Second part.") || strings.Count(got, "[[SEGMENT ") != 1 {
+		t.Errorf("partial markup = %q, want the succeeded segment only", got)
+	}
+
+	// Canceled while the second segment was generating (POST /v1/jobs/{id}/cancel)
+	job = newJob("running")
+	if err := jobRepo.UpdateStatus(ctx, job.ID, models.JobStatusCanceled, nil, nil); err != nil {
+		t.Fatalf("cancel job: %v", err)
+	}
+	p.finishCanceled(ctx, job, time.Now())
+	if got := markupOf(job.ID); !strings.Contains(got, "First part.") || strings.Contains(got, "Second part.") {
+		t.Errorf("markup after cancel = %q, want the succeeded segment only", got)
+	}
+	segments, err := segmentRepo.ListByJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("list segments: %v", err)
+	}
+	if len(segments) != 2 || segments[0].Status != "succeeded" || segments[1].Status != "canceled" {
+		t.Errorf("segments after cancel = %v, want the unfinished one canceled", segments)
+	}
+}
@@ -0,0 +1,176 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/snappy-loop/stories/internal/config"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/kafka"
+	"github.com/snappy-loop/stories/internal/llm"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/storage"
+	"github.com/snappy-loop/stories/internal/testutil"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	testutil.Cleanup()
+	os.Exit(code)
+}
+
+// geminiTestEndpoint returns a Gemini endpoint for t that never leaves the machine: the cassette
+// testdata/cassettes/<test>.json if it exists, else the deterministic FakeGemini. With LLM_RECORD=1 and
+// GEMINI_API_KEY set, it records real Gemini responses into the cassette instead.
+func geminiTestEndpoint(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join("testdata", "cassettes", strings.ReplaceAll(t.Name(), "/", "_")+".json")
+	var handler http.Handler
+	switch {
+	case os.Getenv("LLM_RECORD") == "1":
+		cassette, err := llm.NewCassette(path, llm.CassetteRecord)
+		if err != nil {
+			t.Fatalf("cassette: %v", err)
+		}
+		cassette.APIKey = os.Getenv("GEMINI_API_KEY")
+		t.Cleanup(func() {
+			if err := cassette.Save(); err != nil {
+				t.Errorf("save cassette: %v", err)
+			}
+		})
+		handler = cassette
+	case fileExists(path):
+		cassette, err := llm.NewCassette(path, llm.CassetteReplay)
+		if err != nil {
+			t.Fatalf("cassette: %v", err)
+		}
+		t.Cleanup(func() {
+			if misses := cassette.Misses(); len(misses) > 0 {
+				t.Errorf("cassette %s has no recording for %v; re-record with LLM_RECORD=1", path, misses)
+			}
+		})
+		handler = cassette
+	default:
+		handler = llm.NewFakeGemini()
+	}
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// newTestProcessor returns a JobProcessor on db calling Gemini at endpoint, and its storage client.
+func newTestProcessor(t *testing.T, db *database.DB, endpoint string) (*JobProcessor, *storage.Client) {
+	t.Helper()
+	storageClient, bucket := testutil.S3(t)
+	llmClient := llm.NewClient("test-key", nil, "gemini-2.5-flash-lite", "gemini-3-pro-preview", "", "", "",
+		endpoint, "", "", database.NewBoundaryCacheRepository(db), "")
+	cfg := &config.Config{MaxConcurrentSegments: 2, S3Bucket: bucket}
+	p := NewJobProcessor(db, llmClient, storageClient, nil, cfg, NewInputProcessorRegistry(NewTextProcessor()),
+		database.NewJobFileRepository(db), database.NewFileRepository(db), database.NewFactCheckRepository(db))
+	return p, storageClient
+}
+
+func TestJobProcessor_TextPipeline(t *testing.T) {
+	db := testutil.Postgres(t)
+	p, storageClient := newTestProcessor(t, db, geminiTestEndpoint(t))
+	ctx := context.Background()
+	job := testutil.CreateJob(t, db)
+	jobRepo := database.NewJobRepository(db)
+
+	if err := p.ProcessJob(ctx, job.ID); err != nil {
+		t.Fatalf("ProcessJob: %v", err)
+	}
+
+	got, err := jobRepo.GetByID(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	if got.Status != "succeeded" {
+		t.Fatalf("status = %q, want succeeded (error: %v)", got.Status, got.ErrorMessage)
+	}
+	segments, err := database.NewSegmentRepository(db).ListByJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("list segments: %v", err)
+	}
+	if len(segments) != 2 {
+		t.Fatalf("segments = %d, want 2", len(segments))
+	}
+	assets, err := database.NewAssetRepository(db).ListByJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("list assets: %v", err)
+	}
+	if len(assets) != 4 {
+		t.Fatalf("assets = %d, want an audio and an image per segment", len(assets))
+	}
+	for _, asset := range assets {
+		obj, err := storageClient.GetObject(ctx, asset.S3Bucket, asset.S3Key)
+		if err != nil {
+			t.Errorf("asset %s not uploaded: %v", asset.S3Key, err)
+			continue
+		}
+		obj.Close()
+	}
+	if got.OutputMarkup == nil || strings.Count(*got.OutputMarkup, "[[SEGMENT ") != 2 {
+		t.Errorf("markup = %v, want 2 segments", got.OutputMarkup)
+	}
+}
+
+func TestJobProcessor_TransientFailureSchedulesRetry(t *testing.T) {
+	db := testutil.Postgres(t)
+	// The image model has an outage; everything else works
+	fake := llm.NewFakeGemini()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "-image") {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":{"code":500,"message":"Internal error encountered.","status":"INTERNAL"}}`))
+			return
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	p, _ := newTestProcessor(t, db, srv.URL)
+	jobRepo := database.NewJobRepository(db)
+	job := testutil.CreateJob(t, db)
+
+	ctx := kafka.WithNextRetry(context.Background(), kafka.RetryInfo{Attempt: 0, Delay: 5 * time.Minute})
+	err := p.ProcessJob(ctx, job.ID)
+	var retryErr *kafka.RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("ProcessJob = %v, want a RetryError", err)
+	}
+	got, err := jobRepo.GetByID(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("get job: %v", err)
+	}
+	if got.Status != models.JobStatusRunning || got.Progress == nil || got.Progress.Step != models.JobStepRetrying {
+		t.Errorf("job = %s/%v, want running and retrying", got.Status, got.Progress)
+	}
+	stuck, err := jobRepo.ListStuck(context.Background(), models.JobStatusRunning, time.Now().Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("list stuck: %v", err)
+	}
+	if len(stuck) != 0 {
+		t.Errorf("job waiting for its retry is listed as stuck")
+	}
+
+	// On the last tier there is no retry left and the job fails
+	if err := p.ProcessJob(context.Background(), job.ID); err == nil || errors.As(err, &retryErr) {
+		t.Fatalf("ProcessJob without retries = %v, want a plain error", err)
+	}
+	if got, _ := jobRepo.GetByID(context.Background(), job.ID); got.Status != models.JobStatusFailed {
+		t.Errorf("status = %s, want failed", got.Status)
+	}
+}
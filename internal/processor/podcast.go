@@ -0,0 +1,158 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/inputtype"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/podcast"
+)
+
+// updatePodcastFeed stores the RSS feed of a podcast job (one episode per segment audio) as its "rss"
+// asset, superseding the previous feed; it is served at /view/{id}/podcast.rss. Other jobs are skipped.
+// Failures are logged: the feed is a by-product and never fails the job.
+func (p *JobProcessor) updatePodcastFeed(ctx context.Context, job *models.Job) {
+	if job.AudioType != "podcast" {
+		return
+	}
+	logger := log.With().Str("job_id", job.ID.String()).Logger()
+	if p.config.PublicAPIURL == "" {
+		logger.Warn().Msg("PUBLIC_API_URL not set, not generating podcast feed")
+		return
+	}
+
+	segments, err := p.segmentRepo.ListByJob(ctx, job.ID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to list segments for podcast feed")
+		return
+	}
+	assets, err := p.assetRepo.ListByJob(ctx, job.ID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to list assets for podcast feed")
+		return
+	}
+	feed := buildPodcastFeed(job, segments, assets, strings.TrimSuffix(p.config.PublicAPIURL, "/"), p.inputTypes)
+	body, err := podcast.Build(feed)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to build podcast feed")
+		return
+	}
+
+	version := 1
+	var previous []*models.Asset
+	for _, a := range assets {
+		if a.Kind == "rss" {
+			previous = append(previous, a)
+			version = max(version, a.Version+1)
+		}
+	}
+	key := fmt.Sprintf("jobs/%s/podcast.rss", job.ID)
+	if version > 1 {
+		key = fmt.Sprintf("jobs/%s/podcast.v%d.rss", job.ID, version)
+	}
+	bucket, err := p.storageClient.Upload(ctx, key, bytes.NewReader(body), "application/rss+xml", int64(len(body)))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to upload podcast feed")
+		return
+	}
+	asset := &models.Asset{
+		ID:        uuid.New(),
+		JobID:     job.ID,
+		Kind:      "rss",
+		MimeType:  "application/rss+xml",
+		S3Bucket:  bucket,
+		S3Key:     key,
+		SizeBytes: int64(len(body)),
+		Version:   version,
+		Meta:      map[string]any{"episodes": len(feed.Episodes)},
+		CreatedAt: time.Now(),
+	}
+	if err := p.assetRepo.Create(ctx, asset); err != nil {
+		logger.Error().Err(err).Msg("Failed to save podcast feed asset")
+		return
+	}
+	for _, a := range previous {
+		if err := p.assetRepo.Supersede(ctx, a.ID); err != nil {
+			logger.Warn().Err(err).Str("asset_id", a.ID.String()).Msg("Failed to supersede previous podcast feed")
+		}
+	}
+	logger.Info().Int("episodes", len(feed.Episodes)).Int("version", version).Msg("Podcast feed updated")
+}
+
+// buildPodcastFeed maps a job to a podcast: the channel is the job (title and description from
+// metadata, else its first segment; artwork from the first segment image) and each segment with audio
+// is an episode; the category is the input type's. Asset URLs use the public /view/asset route.
+func buildPodcastFeed(job *models.Job, segments []*models.Segment, assets []*models.Asset, baseURL string, inputTypes *inputtype.Registry) *podcast.Feed {
+	assetURL := func(a *models.Asset) string {
+		return fmt.Sprintf("%s/view/asset/%s?job_id=%s", baseURL, a.ID, job.ID)
+	}
+	audio := make(map[uuid.UUID]*models.Asset)
+	images := make(map[uuid.UUID]*models.Asset)
+	for _, a := range assets {
+		if a.SegmentID == nil {
+			continue
+		}
+		switch a.Kind {
+		case "audio":
+			audio[*a.SegmentID] = a
+		case "image":
+			images[*a.SegmentID] = a
+		}
+	}
+
+	feed := &podcast.Feed{
+		Title:       job.Metadata["title"],
+		Description: job.Metadata["description"],
+		Link:        fmt.Sprintf("%s/view/%s", baseURL, job.ID),
+		FeedURL:     fmt.Sprintf("%s/view/%s/podcast.rss", baseURL, job.ID),
+		Language:    job.Metadata["language"],
+		Author:      job.Metadata["author"],
+		Category:    inputTypes.Get(job.InputType).PodcastCategory,
+	}
+	for i, seg := range segments {
+		title := fmt.Sprintf("Part %d", seg.Idx+1)
+		if seg.Title != nil && *seg.Title != "" {
+			title = *seg.Title
+		}
+		if i == 0 {
+			if feed.Title == "" {
+				feed.Title = title
+			}
+			if feed.Description == "" {
+				feed.Description = podcast.Summary(seg.SegmentText, 4000)
+			}
+		}
+		a, ok := audio[seg.ID]
+		if !ok {
+			continue
+		}
+		ep := podcast.Episode{
+			GUID:        seg.ID.String(),
+			Number:      seg.Idx + 1,
+			Title:       title,
+			Description: podcast.Summary(seg.SegmentText, 4000),
+			AudioURL:    assetURL(a),
+			AudioType:   a.MimeType,
+			AudioSize:   a.SizeBytes,
+			// episodes are published a minute apart in segment order so apps list them in order
+			PubDate: job.CreatedAt.Add(time.Duration(seg.Idx) * time.Minute),
+		}
+		if secs, ok := a.Meta["duration"].(float64); ok {
+			ep.Duration = time.Duration(secs * float64(time.Second))
+		}
+		if img, ok := images[seg.ID]; ok {
+			ep.ImageURL = assetURL(img)
+			if feed.ImageURL == "" {
+				feed.ImageURL = ep.ImageURL
+			}
+		}
+		feed.Episodes = append(feed.Episodes, ep)
+	}
+	return feed
+}
@@ -0,0 +1,48 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+func TestBuildPodcastFeed(t *testing.T) {
+	job := &models.Job{
+		ID:        uuid.New(),
+		InputType: "educational",
+		AudioType: "podcast",
+		Metadata:  map[string]string{"author": "Ms. Rivera"},
+		CreatedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+	title := "Evaporation"
+	s1 := &models.Segment{ID: uuid.New(), Idx: 0, Title: &title, SegmentText: "Water evaporates."}
+	s2 := &models.Segment{ID: uuid.New(), Idx: 1, SegmentText: "Clouds form."}
+	s3 := &models.Segment{ID: uuid.New(), Idx: 2, SegmentText: "Audio failed."}
+	assets := []*models.Asset{
+		{ID: uuid.New(), SegmentID: &s1.ID, Kind: "audio", MimeType: "audio/wav", SizeBytes: 100, Meta: map[string]any{"duration": 12.5}},
+		{ID: uuid.New(), SegmentID: &s1.ID, Kind: "image", MimeType: "image/png"},
+		{ID: uuid.New(), SegmentID: &s2.ID, Kind: "audio", MimeType: "audio/wav", SizeBytes: 200},
+		{ID: uuid.New(), Kind: "rss"},
+	}
+
+	feed := buildPodcastFeed(job, []*models.Segment{s1, s2, s3}, assets, "https://api.example.com", nil)
+	if feed.Title != "Evaporation" || feed.Description != "Water evaporates." || feed.Author != "Ms. Rivera" || feed.Category != "Education" {
+		t.Errorf("feed = %+v", feed)
+	}
+	if feed.FeedURL != "https://api.example.com/view/"+job.ID.String()+"/podcast.rss" {
+		t.Errorf("FeedURL = %q", feed.FeedURL)
+	}
+	if len(feed.Episodes) != 2 {
+		t.Fatalf("episodes = %d, want 2 (segments with audio)", len(feed.Episodes))
+	}
+	ep1, ep2 := feed.Episodes[0], feed.Episodes[1]
+	wantAudio := "https://api.example.com/view/asset/" + assets[0].ID.String() + "?job_id=" + job.ID.String()
+	if ep1.AudioURL != wantAudio || ep1.Duration != 12500*time.Millisecond || ep1.ImageURL == "" || feed.ImageURL != ep1.ImageURL {
+		t.Errorf("episode 1 = %+v", ep1)
+	}
+	if ep2.Title != "Part 2" || ep2.Number != 2 || ep2.Duration != 0 || !ep2.PubDate.After(ep1.PubDate) {
+		t.Errorf("episode 2 = %+v", ep2)
+	}
+}
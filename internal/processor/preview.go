@@ -0,0 +1,106 @@
+package processor
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/preview"
+	"github.com/snappy-loop/stories/internal/ssml"
+)
+
+// maxPreviewImageBytes bounds the image objects read for a preview thumbnail.
+const maxPreviewImageBytes = 20 << 20
+
+// updatePreview stores the job's card preview (models.JobPreview) from its current segments and assets.
+// Pending images (lazy_images) have no object yet and are left out of the sprite. Failures are logged:
+// the preview is a by-product and never fails the job.
+func (p *JobProcessor) updatePreview(ctx context.Context, job *models.Job) {
+	logger := log.With().Str("job_id", job.ID.String()).Logger()
+	segments, err := p.segmentRepo.ListByJob(ctx, job.ID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to list segments for preview")
+		return
+	}
+	assets, err := p.assetRepo.ListByJob(ctx, job.ID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to list assets for preview")
+		return
+	}
+
+	images, jobPreview := buildPreview(segments, assets)
+	var data [][]byte
+	for _, a := range images {
+		rc, err := p.storageClient.GetObject(ctx, a.S3Bucket, a.S3Key)
+		if err != nil {
+			logger.Warn().Err(err).Str("asset_id", a.ID.String()).Msg("Failed to fetch image for preview")
+			continue
+		}
+		b, err := io.ReadAll(io.LimitReader(rc, maxPreviewImageBytes))
+		rc.Close()
+		if err != nil {
+			logger.Warn().Err(err).Str("asset_id", a.ID.String()).Msg("Failed to read image for preview")
+			continue
+		}
+		data = append(data, b)
+	}
+	if len(data) > 0 {
+		sprite, tiles, err := preview.Sprite(data)
+		if err != nil {
+			logger.Warn().Err(err).Msg("Failed to build preview thumbnail")
+		} else {
+			jobPreview.Thumbnail = "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(sprite)
+			jobPreview.ThumbnailTiles = tiles
+			jobPreview.ThumbnailSize = preview.TileSize
+		}
+	}
+
+	if err := p.jobRepo.UpdatePreview(ctx, job.ID, jobPreview); err != nil {
+		logger.Error().Err(err).Msg("Failed to save preview")
+		return
+	}
+	logger.Debug().Int("tiles", jobPreview.ThumbnailTiles).Msg("Preview updated")
+}
+
+// buildPreview returns the job's preview without its thumbnail (narration excerpt from the first
+// segments' scripts, else their text, and total audio duration) and the image assets to tile, first
+// segments first.
+func buildPreview(segments []*models.Segment, assets []*models.Asset) ([]*models.Asset, *models.JobPreview) {
+	segments = append([]*models.Segment(nil), segments...)
+	sort.Slice(segments, func(i, j int) bool { return segments[i].Idx < segments[j].Idx })
+
+	images := make(map[uuid.UUID]*models.Asset)
+	jobPreview := &models.JobPreview{}
+	for _, a := range assets {
+		switch {
+		case a.Kind == "audio":
+			if secs, ok := a.Meta["duration"].(float64); ok {
+				jobPreview.DurationSeconds += secs
+			}
+		case a.Kind == "image" && a.SegmentID != nil && !a.Pending():
+			images[*a.SegmentID] = a
+		}
+	}
+
+	var tiles []*models.Asset
+	var narration strings.Builder
+	for _, seg := range segments {
+		if img, ok := images[seg.ID]; ok && len(tiles) < preview.MaxTiles {
+			tiles = append(tiles, img)
+		}
+		if narration.Len() <= preview.ExcerptLength*4 {
+			text := seg.SegmentText
+			if seg.NarrationText != nil && *seg.NarrationText != "" {
+				text = ssml.Strip(*seg.NarrationText)
+			}
+			narration.WriteString(text + " ")
+		}
+	}
+	jobPreview.NarrationExcerpt = preview.Excerpt(narration.String(), preview.ExcerptLength)
+	return tiles, jobPreview
+}
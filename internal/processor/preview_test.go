@@ -0,0 +1,38 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+func TestBuildPreview(t *testing.T) {
+	script := `Hello <break time="500ms"/>listeners.`
+	s1 := &models.Segment{ID: uuid.New(), Idx: 0, SegmentText: "Raw text.", NarrationText: &script}
+	s2 := &models.Segment{ID: uuid.New(), Idx: 1, SegmentText: strings.Repeat("More words here. ", 20)}
+	s3 := &models.Segment{ID: uuid.New(), Idx: 2, SegmentText: "Pending image."}
+	assets := []*models.Asset{
+		{ID: uuid.New(), SegmentID: &s2.ID, Kind: "image", MimeType: "image/png"},
+		{ID: uuid.New(), SegmentID: &s1.ID, Kind: "image", MimeType: "image/jpeg"},
+		{ID: uuid.New(), SegmentID: &s3.ID, Kind: "image", Meta: map[string]any{"pending": true}},
+		{ID: uuid.New(), SegmentID: &s1.ID, Kind: "audio", Meta: map[string]any{"duration": 12.5}},
+		{ID: uuid.New(), SegmentID: &s2.ID, Kind: "audio", Meta: map[string]any{"duration": 30.0}},
+		{ID: uuid.New(), Kind: "rss"},
+	}
+
+	images, p := buildPreview([]*models.Segment{s3, s2, s1}, assets)
+	if len(images) != 2 || images[0] != assets[1] || images[1] != assets[0] {
+		t.Errorf("images = %v, want segment 0 then segment 1 (pending left out)", images)
+	}
+	if p.DurationSeconds != 42.5 {
+		t.Errorf("DurationSeconds = %v, want 42.5", p.DurationSeconds)
+	}
+	if !strings.HasPrefix(p.NarrationExcerpt, "Hello listeners. More words here.") || !strings.HasSuffix(p.NarrationExcerpt, "…") {
+		t.Errorf("NarrationExcerpt = %q", p.NarrationExcerpt)
+	}
+	if p.Thumbnail != "" {
+		t.Errorf("Thumbnail = %q, want none before the images are fetched", p.Thumbnail)
+	}
+}
@@ -0,0 +1,95 @@
+package processor
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/llm"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// checkNarrationQuality scores a segment's narration for faithfulness (jobs with quality_check) and
+// regenerates it below the threshold. Returns the script to use.
+func (p *JobProcessor) checkNarrationQuality(ctx context.Context, job *models.Job, seg *llm.Segment, idx int, script string, q *models.SegmentQuality) string {
+	if script == "" {
+		return script // no narration (TTS is skipped), nothing to score
+	}
+	script, score, regenerations := p.improveOutput(ctx, job.ID, idx, "narration", script,
+		func(candidate string) (*llm.QualityScore, error) {
+			return p.llmClient.ScoreNarration(ctx, seg.Text, candidate)
+		},
+		func(feedback string) (string, error) {
+			return p.llmClient.RegenerateNarration(ctx, seg.Text, job.AudioType, job.InputType, feedback)
+		})
+	q.Regenerations += regenerations
+	if score != nil {
+		q.NarrationScore = &score.Score
+		q.AddNote("narration", score.Reason)
+	}
+	return script
+}
+
+// checkImagePromptQuality scores a segment's image prompt for relevance (jobs with quality_check) and
+// regenerates it below the threshold. Returns the prompt to use.
+func (p *JobProcessor) checkImagePromptQuality(ctx context.Context, job *models.Job, seg *llm.Segment, idx int, imagePrompt string, q *models.SegmentQuality) string {
+	imagePrompt, score, regenerations := p.improveOutput(ctx, job.ID, idx, "image_prompt", imagePrompt,
+		func(candidate string) (*llm.QualityScore, error) {
+			return p.llmClient.ScoreImagePrompt(ctx, seg.Text, job.InputType, candidate)
+		},
+		func(feedback string) (string, error) {
+			return p.llmClient.RegenerateImagePrompt(ctx, seg.Text, job.InputType, feedback)
+		})
+	q.Regenerations += regenerations
+	if score != nil {
+		q.ImagePromptScore = &score.Score
+		q.AddNote("image_prompt", score.Reason)
+	}
+	return imagePrompt
+}
+
+// improveOutput scores output and, while the best version scores below QualityMinScore, regenerates it
+// with the judge's reason as feedback, up to QualityMaxRegenerations times. Returns the best-scoring
+// version, its score (nil when the evaluator failed; the output is then used unscored) and the number
+// of regenerations.
+func (p *JobProcessor) improveOutput(ctx context.Context, jobID uuid.UUID, idx int, kind, output string,
+	score func(string) (*llm.QualityScore, error), regenerate func(feedback string) (string, error)) (string, *llm.QualityScore, int) {
+	logger := log.With().Str("job_id", jobID.String()).Int("segment", idx).Str("kind", kind).Logger()
+
+	best, err := score(output)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Quality scoring failed, keeping output unscored")
+		return output, nil, 0
+	}
+	regenerations := 0
+	for best.Score < p.config.QualityMinScore && regenerations < p.config.QualityMaxRegenerations {
+		regenerations++
+		candidate, err := regenerate(best.Reason)
+		if err != nil || strings.TrimSpace(candidate) == "" {
+			logger.Warn().Err(err).Msg("Quality regeneration produced no output")
+			break
+		}
+		candidateScore, err := score(candidate)
+		if err != nil {
+			logger.Warn().Err(err).Msg("Quality scoring of regenerated output failed")
+			break
+		}
+		logger.Info().
+			Int("score", best.Score).
+			Int("regenerated_score", candidateScore.Score).
+			Int("attempt", regenerations).
+			Msg("quality_regenerated")
+		if candidateScore.Score > best.Score {
+			output, best = candidate, candidateScore
+		}
+	}
+	if best.Score < p.config.QualityMinScore {
+		logger.Warn().
+			Int("score", best.Score).
+			Int("min_score", p.config.QualityMinScore).
+			Str("reason", best.Reason).
+			Msg("quality_below_threshold")
+	}
+	return output, best, regenerations
+}
@@ -0,0 +1,56 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/config"
+	"github.com/snappy-loop/stories/internal/llm"
+)
+
+func TestImproveOutput(t *testing.T) {
+	p := &JobProcessor{config: &config.Config{QualityMinScore: 3, QualityMaxRegenerations: 2}}
+	scores := map[string]int{"bad": 1, "better": 2, "good": 4}
+	score := func(s string) (*llm.QualityScore, error) {
+		return &llm.QualityScore{Score: scores[s], Reason: "reason for " + s}, nil
+	}
+
+	tests := []struct {
+		name      string
+		output    string
+		versions  []string // returned by successive regenerations
+		want      string
+		wantScore int
+		wantRegen int
+	}{
+		{"good enough", "good", nil, "good", 4, 0},
+		{"fixed on second try", "bad", []string{"better", "good"}, "good", 4, 2},
+		{"keeps best when still low", "better", []string{"bad", "bad"}, "better", 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var feedback []string
+			regenerate := func(fb string) (string, error) {
+				feedback = append(feedback, fb)
+				return tt.versions[len(feedback)-1], nil
+			}
+			got, s, regen := p.improveOutput(context.Background(), uuid.New(), 0, "narration", tt.output, score, regenerate)
+			if got != tt.want || s == nil || s.Score != tt.wantScore || regen != tt.wantRegen {
+				t.Errorf("improveOutput = %q, %+v, %d; want %q, score %d, %d regenerations", got, s, regen, tt.want, tt.wantScore, tt.wantRegen)
+			}
+			if len(feedback) > 0 && feedback[0] != "reason for "+tt.output {
+				t.Errorf("first regeneration feedback = %q", feedback[0])
+			}
+		})
+	}
+
+	// An unavailable evaluator leaves the output unscored and does not regenerate.
+	failing := func(string) (*llm.QualityScore, error) { return nil, errors.New("judge down") }
+	got, s, regen := p.improveOutput(context.Background(), uuid.New(), 0, "image_prompt", "bad", failing,
+		func(string) (string, error) { t.Fatal("regenerated without a score"); return "", nil })
+	if got != "bad" || s != nil || regen != 0 {
+		t.Errorf("improveOutput with failing judge = %q, %+v, %d", got, s, regen)
+	}
+}
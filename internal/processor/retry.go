@@ -0,0 +1,48 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/llm"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// resumeJobPipeline continues a failed job its user retried from the segments of the failed run: succeeded
+// segments are kept, the others are reset (their partial assets superseded) and generated again, and the
+// output is rebuilt. Input extraction and segmentation are skipped.
+func (p *JobProcessor) resumeJobPipeline(ctx context.Context, job *models.Job, segments []*models.Segment) error {
+	work := retryWork(segments)
+	for _, w := range work {
+		if err := p.segmentRepo.ResetForRegeneration(ctx, w.id); err != nil {
+			return fmt.Errorf("failed to reset segment %d: %w", w.idx, err)
+		}
+	}
+	log.Info().
+		Str("job_id", job.ID.String()).
+		Int("segments", len(work)).
+		Int("kept", len(segments)-len(work)).
+		Msg("Resuming retried job")
+
+	if err := p.generateSegments(ctx, job, work, len(segments), len(segments)-len(work)); err != nil {
+		return err
+	}
+	return p.finishPipeline(ctx, job)
+}
+
+// retryWork returns the segments of a failed run that must be generated again: all but the succeeded ones.
+func retryWork(segments []*models.Segment) []segmentWork {
+	var work []segmentWork
+	for _, s := range segments {
+		if s.Status == "succeeded" {
+			continue
+		}
+		work = append(work, segmentWork{
+			seg: &llm.Segment{ID: s.ID, StartChar: s.StartChar, EndChar: s.EndChar, Title: s.Title, Text: s.SegmentText},
+			idx: s.Idx,
+			id:  s.ID,
+		})
+	}
+	return work
+}
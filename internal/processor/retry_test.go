@@ -0,0 +1,26 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+func TestRetryWork(t *testing.T) {
+	segments := []*models.Segment{
+		{ID: uuid.New(), Idx: 0, Status: "succeeded", SegmentText: "one"},
+		{ID: uuid.New(), Idx: 1, Status: "failed", SegmentText: "two"},
+		{ID: uuid.New(), Idx: 2, Status: "queued", SegmentText: "three"},
+	}
+	work := retryWork(segments)
+	if len(work) != 2 || work[0].idx != 1 || work[1].idx != 2 {
+		t.Fatalf("work = %+v, want segments 1 and 2", work)
+	}
+	if work[0].id != segments[1].ID || work[0].seg.ID != segments[1].ID || work[0].seg.Text != "two" {
+		t.Errorf("work[0] = %+v, seg = %+v", work[0], work[0].seg)
+	}
+	if got := retryWork(segments[:1]); len(got) != 0 {
+		t.Errorf("all succeeded: work = %+v, want none", got)
+	}
+}
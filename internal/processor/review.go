@@ -0,0 +1,101 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/llm"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// defaultReviewFeedback is passed to the LLM when the reviewer asked for new versions without a comment.
+const defaultReviewFeedback = "The reviewer asked for a new version."
+
+// processReviewRegeneration regenerates the segments a reviewer asked for (require_review jobs), rebuilds
+// the output markup and puts the job back into awaiting_review. Segments that fail again are left failed
+// for the reviewer to see; the job itself is not failed.
+func (p *JobProcessor) processReviewRegeneration(ctx context.Context, job *models.Job) error {
+	req := job.ReviewRegeneration
+	logger := log.With().Str("job_id", job.ID.String()).Ints("segments", req.Segments).Logger()
+
+	if err := p.updateJobStatus(ctx, job.ID, models.JobStatusRunning, nil, nil); err != nil {
+		if errors.Is(err, database.ErrInvalidJobTransition) {
+			logger.Warn().Err(err).Msg("Review regeneration can no longer be started, skipping")
+			return nil
+		}
+		logger.Error().Err(err).Msg("Failed to update job status to running")
+	}
+	startedAt := time.Now()
+	if len(job.Experiments) > 0 {
+		ctx = llm.WithVariants(ctx, job.ID, job.Experiments)
+	}
+	ctx = p.withVoice(p.withLexicon(ctx, job), job)
+	p.recordEvent(ctx, job.ID, models.JobEventPickedUp, "Picked up by worker "+p.workerID+" for review regeneration",
+		map[string]any{"worker": p.workerID, "segments": req.Segments})
+
+	segments, err := p.segmentRepo.ListByJob(ctx, job.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list segments: %w", err)
+	}
+	requested := make(map[int]bool, len(req.Segments))
+	for _, idx := range req.Segments {
+		requested[idx] = true
+	}
+	feedback := req.Comment
+	if feedback == "" {
+		feedback = defaultReviewFeedback
+	}
+
+	failed := 0
+	for _, segment := range segments {
+		if !requested[segment.Idx] {
+			continue
+		}
+		if err := p.segmentRepo.ResetForRegeneration(ctx, segment.ID); err != nil {
+			return fmt.Errorf("failed to reset segment %d: %w", segment.Idx, err)
+		}
+		seg := &llm.Segment{ID: segment.ID, Title: segment.Title, Text: segment.SegmentText}
+		if err := p.processSegment(ctx, job, seg, segment.Idx, segment.ID, feedback); err != nil {
+			failed++
+			p.recordEvent(ctx, job.ID, models.JobEventSegmentFailed,
+				fmt.Sprintf("Segment %d failed: %v", segment.Idx, err),
+				map[string]any{"segment_idx": segment.Idx, "error": err.Error()})
+		}
+	}
+
+	markup, err := p.generateOutputMarkup(ctx, job.ID)
+	if err != nil {
+		return fmt.Errorf("failed to generate markup: %w", err)
+	}
+	if err := p.jobRepo.UpdateMarkup(ctx, job.ID, markup); err != nil {
+		return fmt.Errorf("failed to save markup: %w", err)
+	}
+	p.recordVersion(ctx, job.ID, models.OutputVersionReviewRegeneration)
+	p.updatePodcastFeed(ctx, job)
+	p.updatePreview(ctx, job)
+	if err := p.jobRepo.ClearReviewRegeneration(ctx, job.ID); err != nil {
+		return fmt.Errorf("failed to clear review regeneration: %w", err)
+	}
+
+	if err := p.updateJobStatus(ctx, job.ID, models.JobStatusAwaitingReview, nil, nil); err != nil {
+		if errors.Is(err, database.ErrInvalidJobTransition) {
+			logger.Warn().Err(err).Msg("Job finished elsewhere, not marking awaiting review")
+			return nil
+		}
+		logger.Error().Err(err).Msg("Failed to update job status to awaiting_review")
+	}
+	p.recordEvent(ctx, job.ID, models.JobEventAwaitingReview, "Regenerated segments awaiting review", map[string]any{
+		"segments":        req.Segments,
+		"segments_failed": failed,
+		"duration_ms":     time.Since(startedAt).Milliseconds(),
+	})
+	logger.Info().
+		Int("segments_failed", failed).
+		Int64("duration_ms", time.Since(startedAt).Milliseconds()).
+		Msg("Review regeneration completed")
+	return nil
+}
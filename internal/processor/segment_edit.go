@@ -0,0 +1,147 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/llm"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// ProcessSegmentEdit regenerates one segment after a user edit (PATCH /v1/jobs/{id}/segments/{idx}): the
+// audio from the stored narration (written anew from the segment text when the edit cleared it) and,
+// when asked, the image. The new assets supersede the old ones, the job's markup is rebuilt and recorded
+// as a new output version; the job's own status does not change. A failed regeneration leaves the segment failed with its previous assets.
+func (p *JobProcessor) ProcessSegmentEdit(ctx context.Context, jobID uuid.UUID, idx int, regenerateImage bool) error {
+	logger := log.With().Str("job_id", jobID.String()).Int("segment", idx).Logger()
+	job, err := p.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
+	}
+	segment, err := p.segmentRepo.GetByJobIdx(ctx, jobID, idx)
+	if err != nil {
+		return fmt.Errorf("failed to get segment: %w", err)
+	}
+	if segment.Status != "queued" {
+		logger.Warn().Str("status", segment.Status).Msg("Segment edit already processed, skipping")
+		return nil
+	}
+	if len(job.Experiments) > 0 {
+		ctx = llm.WithVariants(ctx, job.ID, job.Experiments)
+	}
+	ctx = p.withVoice(p.withLexicon(ctx, job), job)
+	ctx, chargeCost := p.meterCost(ctx, job)
+	defer chargeCost()
+
+	startedAt := time.Now()
+	if err := p.segmentRepo.UpdateStatus(ctx, jobID, idx, "running"); err != nil {
+		logger.Error().Err(err).Msg("Failed to update segment status")
+	}
+	if err := p.regenerateEditedSegment(ctx, job, segment, regenerateImage); err != nil {
+		logger.Error().Err(err).Msg("Segment edit regeneration failed")
+		if err := p.segmentRepo.UpdateStatus(ctx, jobID, idx, "failed"); err != nil {
+			logger.Error().Err(err).Msg("Failed to update segment status to failed")
+		}
+		p.recordEvent(ctx, jobID, models.JobEventSegmentFailed,
+			fmt.Sprintf("Segment %d failed after edit: %v", idx, err),
+			map[string]any{"segment_idx": idx, "error": err.Error(), "edit": true})
+		return nil
+	}
+
+	markup, err := p.generateOutputMarkup(ctx, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to generate markup: %w", err)
+	}
+	if err := p.jobRepo.UpdateMarkup(ctx, jobID, markup); err != nil {
+		return fmt.Errorf("failed to save markup: %w", err)
+	}
+	if err := p.segmentRepo.UpdateStatus(ctx, jobID, idx, "succeeded"); err != nil {
+		logger.Error().Err(err).Msg("Failed to update segment status to succeeded")
+	}
+	p.recordVersion(ctx, jobID, models.OutputVersionSegmentEdit)
+	p.updatePodcastFeed(ctx, job)
+	p.updatePreview(ctx, job)
+	p.recordEvent(ctx, jobID, models.JobEventSegmentEdited, fmt.Sprintf("Segment %d regenerated after edit", idx), map[string]any{
+		"segment_idx":      idx,
+		"stage":            "regenerated",
+		"regenerate_image": regenerateImage,
+		"duration_ms":      time.Since(startedAt).Milliseconds(),
+	})
+	logger.Info().Int64("duration_ms", time.Since(startedAt).Milliseconds()).Msg("Segment edit regenerated")
+	return nil
+}
+
+// regenerateEditedSegment produces the edited segment's new audio (and image) and swaps them in for the
+// previous versions.
+func (p *JobProcessor) regenerateEditedSegment(ctx context.Context, job *models.Job, segment *models.Segment, regenerateImage bool) error {
+	saved := ""
+	if segment.NarrationText != nil {
+		saved = *segment.NarrationText
+	}
+	script := saved
+	if script == "" {
+		var err error
+		script, err = p.llmClient.GenerateNarration(ctx, segment.SegmentText, job.AudioType, job.InputType)
+		if err != nil {
+			return fmt.Errorf("narration generation failed: %w", err)
+		}
+	}
+	// The last segment of a financial job keeps its disclaimer through edits
+	script, err := p.appendDisclaimer(ctx, job, segment.Idx, script)
+	if err != nil {
+		return err
+	}
+	if script != saved {
+		if err := p.segmentRepo.UpdateNarration(ctx, segment.ID, script); err != nil {
+			return fmt.Errorf("failed to save narration text: %w", err)
+		}
+	}
+
+	spoken, err := p.runBeforeTTSHooks(ctx, job, segment.ID, segment.Idx, segment.SegmentText, script)
+	if err != nil {
+		return err
+	}
+	audio, err := p.llmClient.GenerateAudio(ctx, spoken, job.AudioType)
+	if err != nil {
+		return fmt.Errorf("audio generation failed: %w", err)
+	}
+	audioAsset, err := p.saveAudio(ctx, job, segment.Idx, segment.ID, audio)
+	if err != nil {
+		return err
+	}
+	if err := p.assetRepo.SupersedeSegmentAssets(ctx, segment.ID, "audio", audioAsset.ID); err != nil {
+		return fmt.Errorf("failed to replace audio asset: %w", err)
+	}
+	// The external tool's result refers to the segment text, which the edit may have changed
+	if err := p.callExternalTool(ctx, job, segment.ID, segment.Idx, segment.Title, segment.SegmentText); err != nil {
+		return err
+	}
+
+	if !regenerateImage {
+		return nil
+	}
+	imagePrompt, err := p.llmClient.GenerateImagePrompt(ctx, segment.SegmentText, job.InputType)
+	if err != nil {
+		return fmt.Errorf("image prompt generation failed: %w", err)
+	}
+	var imageAsset *models.Asset
+	if job.LazyImages {
+		imageAsset, err = p.savePendingImage(ctx, job, segment.Idx, segment.ID, imagePrompt)
+	} else {
+		var image *llm.Image
+		if image, err = p.llmClient.GenerateImage(ctx, imagePrompt); err != nil {
+			return fmt.Errorf("image generation failed: %w", err)
+		}
+		imageAsset, err = p.saveImage(ctx, job, segment.Idx, segment.ID, image)
+	}
+	if err != nil {
+		return err
+	}
+	if err := p.assetRepo.SupersedeSegmentAssets(ctx, segment.ID, "image", imageAsset.ID); err != nil {
+		return fmt.Errorf("failed to replace image asset: %w", err)
+	}
+	return nil
+}
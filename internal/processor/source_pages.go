@@ -0,0 +1,98 @@
+package processor
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// sourcePageSpan is one extracted PDF page located in the combined text that was segmented.
+type sourcePageSpan struct {
+	start, end int
+	page       models.SegmentSourcePage
+}
+
+// sourcePageIndex maps byte ranges of the combined input text to the uploaded file pages they were extracted from.
+type sourcePageIndex []sourcePageSpan
+
+// newSourcePageIndex locates each job file's extracted text in combined (in processing order) and translates
+// its recorded page ranges into combined-text offsets. Files without page metadata are skipped.
+func newSourcePageIndex(combined string, jobFiles []*models.JobFile) sourcePageIndex {
+	var index sourcePageIndex
+	cursor := 0
+	for _, jf := range jobFiles {
+		if jf.ExtractedText == nil || *jf.ExtractedText == "" {
+			continue
+		}
+		offset := strings.Index(combined[cursor:], *jf.ExtractedText)
+		if offset < 0 {
+			continue
+		}
+		offset += cursor
+		cursor = offset + len(*jf.ExtractedText)
+		if jf.Meta == nil {
+			continue
+		}
+		for _, r := range jf.Meta.Pages {
+			index = append(index, sourcePageSpan{
+				start: offset + r.StartChar,
+				end:   offset + r.EndChar,
+				page:  models.SegmentSourcePage{FileID: jf.FileID, Page: r.Page},
+			})
+		}
+	}
+	return index
+}
+
+// pagesFor returns the source pages overlapping [start, end) of the combined text, in order.
+func (idx sourcePageIndex) pagesFor(start, end int) []models.SegmentSourcePage {
+	var pages []models.SegmentSourcePage
+	for _, span := range idx {
+		if span.start < end && start < span.end {
+			pages = append(pages, span.page)
+		}
+	}
+	return pages
+}
+
+// citationMarkers returns one [[CITATION file_id=... pages=...]] line per source file of a segment, in order
+// of first appearance, with the file's pages collapsed into ranges (e.g. pages=3-5,8).
+func citationMarkers(pages []models.SegmentSourcePage) []string {
+	var fileIDs []uuid.UUID
+	byFile := make(map[uuid.UUID][]int)
+	for _, sp := range pages {
+		if _, ok := byFile[sp.FileID]; !ok {
+			fileIDs = append(fileIDs, sp.FileID)
+		}
+		byFile[sp.FileID] = append(byFile[sp.FileID], sp.Page)
+	}
+	markers := make([]string, 0, len(fileIDs))
+	for _, fileID := range fileIDs {
+		markers = append(markers, fmt.Sprintf("[[CITATION file_id=%s pages=%s]]", fileID, formatPageRanges(byFile[fileID])))
+	}
+	return markers
+}
+
+// formatPageRanges formats page numbers as sorted, comma-separated ranges: [3 4 5 8] -> "3-5,8".
+func formatPageRanges(pages []int) string {
+	pages = append([]int(nil), pages...)
+	sort.Ints(pages)
+	var parts []string
+	for i := 0; i < len(pages); {
+		j := i
+		for j+1 < len(pages) && pages[j+1] <= pages[j]+1 {
+			j++
+		}
+		if pages[j] == pages[i] {
+			parts = append(parts, strconv.Itoa(pages[i]))
+		} else {
+			parts = append(parts, strconv.Itoa(pages[i])+"-"+strconv.Itoa(pages[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
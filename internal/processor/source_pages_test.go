@@ -0,0 +1,65 @@
+package processor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+func TestSourcePageIndex(t *testing.T) {
+	pdfText := "Page one text.\n\nPage two text."
+	imageText := "An image."
+	pdf := &models.JobFile{
+		FileID:        uuid.New(),
+		ExtractedText: &pdfText,
+		Meta: &models.JobFileMeta{PageCount: 2, Pages: []models.JobFilePageRange{
+			{Page: 1, StartChar: 0, EndChar: 14},
+			{Page: 2, StartChar: 16, EndChar: 30},
+		}},
+	}
+	image := &models.JobFile{FileID: uuid.New(), ExtractedText: &imageText}
+	combined := strings.Join([]string{"Typed intro.", pdfText, imageText}, "\n\n---\n\n")
+	index := newSourcePageIndex(combined, []*models.JobFile{pdf, image})
+
+	pdfStart := strings.Index(combined, pdfText)
+	page1 := models.SegmentSourcePage{FileID: pdf.FileID, Page: 1}
+	page2 := models.SegmentSourcePage{FileID: pdf.FileID, Page: 2}
+	tests := []struct {
+		name       string
+		start, end int
+		want       []models.SegmentSourcePage
+	}{
+		{"intro only", 0, 12, nil},
+		{"first page", pdfStart, pdfStart + 14, []models.SegmentSourcePage{page1}},
+		{"spans both pages", pdfStart + 5, pdfStart + 20, []models.SegmentSourcePage{page1, page2}},
+		{"intro through first page", 0, pdfStart + 3, []models.SegmentSourcePage{page1}},
+		{"image only", strings.Index(combined, imageText), len(combined), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := index.pagesFor(tt.start, tt.end); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pagesFor(%d, %d) = %+v, want %+v", tt.start, tt.end, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCitationMarkers(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	pages := []models.SegmentSourcePage{
+		{FileID: a, Page: 4}, {FileID: a, Page: 5}, {FileID: b, Page: 1}, {FileID: a, Page: 3}, {FileID: a, Page: 8},
+	}
+	want := []string{
+		"[[CITATION file_id=" + a.String() + " pages=3-5,8]]",
+		"[[CITATION file_id=" + b.String() + " pages=1]]",
+	}
+	if got := citationMarkers(pages); !reflect.DeepEqual(got, want) {
+		t.Errorf("citationMarkers() = %q, want %q", got, want)
+	}
+	if got := citationMarkers(nil); len(got) != 0 {
+		t.Errorf("citationMarkers(nil) = %q, want none", got)
+	}
+}
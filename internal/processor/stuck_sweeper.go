@@ -0,0 +1,265 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/config"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/maintenance"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// stuckSweepBatch is the maximum number of jobs handled per status per sweep.
+const stuckSweepBatch = 100
+
+// Error codes set on jobs failed by the sweeper.
+const (
+	ErrCodeStuckInQueue  = "stuck_in_queue"
+	ErrCodeWorkerTimeout = "worker_timeout"
+)
+
+type stuckJobStore interface {
+	ListStuck(ctx context.Context, status string, before time.Time, limit int) ([]*models.Job, error)
+	FailIfStatus(ctx context.Context, jobID uuid.UUID, expectedStatus, errorCode, errorMessage string) (bool, error)
+}
+
+type stuckEventStore interface {
+	Create(ctx context.Context, ev *models.JobEvent) error
+	CountByType(ctx context.Context, jobID uuid.UUID, eventType string) (int, *time.Time, error)
+}
+
+type jobPublisher interface {
+	PublishJob(ctx context.Context, jobID uuid.UUID, traceID string) error
+}
+
+type webhookPublisher interface {
+	PublishWebhook(ctx context.Context, jobID uuid.UUID, event, traceID string) error
+}
+
+// SweepStats counts what a single sweep did.
+type SweepStats struct {
+	Requeued int
+	Failed   int
+	Errors   int
+}
+
+// StuckJobSweeper periodically finds jobs stuck in queued (e.g. the Kafka publish failed) or
+// running (e.g. the worker died mid-job), republishes them, and fails them with a clear error once
+// they have been requeued StuckMaxRequeues times.
+type StuckJobSweeper struct {
+	jobs         stuckJobStore
+	events       stuckEventStore
+	jobProducer  jobPublisher
+	webhooks     webhookPublisher
+	interval     time.Duration
+	queuedAfter  time.Duration
+	runningAfter time.Duration
+	maxRequeues  int
+	maintenance  *maintenance.Switch // sweeps are skipped while it is on
+
+	mu     sync.Mutex
+	totals SweepStats // cumulative, reported with every non-empty sweep
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewStuckJobSweeper creates a sweeper. jobProducer publishes to the jobs topic, webhooks to the webhooks topic.
+func NewStuckJobSweeper(db *database.DB, jobProducer jobPublisher, webhooks webhookPublisher, cfg *config.Config) *StuckJobSweeper {
+	return newStuckJobSweeper(database.NewJobRepository(db), database.NewJobEventRepository(db), jobProducer, webhooks, cfg)
+}
+
+func newStuckJobSweeper(jobs stuckJobStore, events stuckEventStore, jobProducer jobPublisher, webhooks webhookPublisher, cfg *config.Config) *StuckJobSweeper {
+	return &StuckJobSweeper{
+		jobs:         jobs,
+		events:       events,
+		jobProducer:  jobProducer,
+		webhooks:     webhooks,
+		interval:     cfg.StuckSweepInterval,
+		queuedAfter:  cfg.StuckQueuedAfter,
+		runningAfter: cfg.StuckRunningAfter,
+		maxRequeues:  cfg.StuckMaxRequeues,
+		stopChan:     make(chan struct{}),
+	}
+}
+
+// Start runs the sweeper in the background until ctx is cancelled or Stop is called.
+// It does nothing when the configured interval is zero.
+func (s *StuckJobSweeper) Start(ctx context.Context) {
+	if s.interval <= 0 {
+		log.Info().Msg("Stuck job sweeper disabled")
+		return
+	}
+	ticker := time.NewTicker(s.interval)
+
+	go func() {
+		defer ticker.Stop()
+		log.Info().
+			Dur("interval", s.interval).
+			Dur("queued_after", s.queuedAfter).
+			Dur("running_after", s.runningAfter).
+			Int("max_requeues", s.maxRequeues).
+			Msg("Stuck job sweeper started")
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.stopChan:
+				log.Info().Msg("Stuck job sweeper stopped")
+				return
+			case <-ticker.C:
+				if s.maintenance.Enabled(ctx) {
+					// Jobs wait in the queue on purpose; requeueing or failing them would undo the pause
+					continue
+				}
+				s.Sweep(ctx)
+			}
+		}
+	}()
+}
+
+// SetMaintenance pauses the sweeper while maintenance mode is on.
+func (s *StuckJobSweeper) SetMaintenance(m *maintenance.Switch) {
+	s.maintenance = m
+}
+
+// Stop stops the sweeper. Safe to call multiple times.
+func (s *StuckJobSweeper) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+	})
+}
+
+// Sweep runs one pass over queued and running jobs and returns what it did.
+func (s *StuckJobSweeper) Sweep(ctx context.Context) SweepStats {
+	var stats SweepStats
+	now := time.Now()
+	s.sweepStatus(ctx, "queued", s.queuedAfter, now, &stats)
+	s.sweepStatus(ctx, "running", s.runningAfter, now, &stats)
+
+	if stats == (SweepStats{}) {
+		return stats
+	}
+
+	s.mu.Lock()
+	s.totals.Requeued += stats.Requeued
+	s.totals.Failed += stats.Failed
+	s.totals.Errors += stats.Errors
+	totals := s.totals
+	s.mu.Unlock()
+
+	// Structured fields double as metrics: stuck_jobs_* per sweep and *_total since start.
+	ev := log.Warn()
+	if stats.Failed > 0 || stats.Errors > 0 {
+		ev = log.Error().Bool("alert", true)
+	}
+	ev.Int("stuck_jobs_requeued", stats.Requeued).
+		Int("stuck_jobs_failed", stats.Failed).
+		Int("stuck_jobs_errors", stats.Errors).
+		Int("stuck_jobs_requeued_total", totals.Requeued).
+		Int("stuck_jobs_failed_total", totals.Failed).
+		Int("stuck_jobs_errors_total", totals.Errors).
+		Msg("Stuck jobs detected")
+	return stats
+}
+
+func (s *StuckJobSweeper) sweepStatus(ctx context.Context, status string, threshold time.Duration, now time.Time, stats *SweepStats) {
+	jobs, err := s.jobs.ListStuck(ctx, status, now.Add(-threshold), stuckSweepBatch)
+	if err != nil {
+		log.Error().Err(err).Str("status", status).Msg("Failed to list stuck jobs")
+		stats.Errors++
+		return
+	}
+
+	for _, job := range jobs {
+		requeues, lastRequeue, err := s.events.CountByType(ctx, job.ID, models.JobEventRequeued)
+		if err != nil {
+			log.Error().Err(err).Str("job_id", job.ID.String()).Msg("Failed to count job requeues")
+			stats.Errors++
+			continue
+		}
+		// Give a requeued job a full threshold to make progress before acting again.
+		if lastRequeue != nil && now.Sub(*lastRequeue) < threshold {
+			continue
+		}
+
+		if requeues >= s.maxRequeues {
+			s.fail(ctx, job, status, threshold, requeues, stats)
+			continue
+		}
+		s.requeue(ctx, job, status, requeues, stats)
+	}
+}
+
+func (s *StuckJobSweeper) requeue(ctx context.Context, job *models.Job, status string, requeues int, stats *SweepStats) {
+	if err := s.jobProducer.PublishJob(ctx, job.ID, ""); err != nil {
+		log.Error().Err(err).Str("job_id", job.ID.String()).Msg("Failed to republish stuck job")
+		stats.Errors++
+		return
+	}
+	s.recordEvent(ctx, job.ID, models.JobEventRequeued, fmt.Sprintf("Requeued by sweeper: stuck in %s", status), map[string]any{
+		"reason":  "stuck_" + status,
+		"attempt": requeues + 1,
+	})
+	log.Warn().
+		Str("job_id", job.ID.String()).
+		Str("status", status).
+		Int("attempt", requeues+1).
+		Msg("Requeued stuck job")
+	stats.Requeued++
+}
+
+func (s *StuckJobSweeper) fail(ctx context.Context, job *models.Job, status string, threshold time.Duration, requeues int, stats *SweepStats) {
+	code := ErrCodeStuckInQueue
+	if status == "running" {
+		code = ErrCodeWorkerTimeout
+	}
+	msg := fmt.Sprintf("job made no progress in %s for over %s after %d requeue(s)", status, threshold, requeues)
+
+	updated, err := s.jobs.FailIfStatus(ctx, job.ID, status, code, msg)
+	if err != nil {
+		log.Error().Err(err).Str("job_id", job.ID.String()).Msg("Failed to fail stuck job")
+		stats.Errors++
+		return
+	}
+	if !updated {
+		// The job moved on since we listed it; nothing to do.
+		return
+	}
+
+	s.recordEvent(ctx, job.ID, models.JobEventFailed, "Job failed: "+msg, map[string]any{
+		"error_code": code,
+	})
+	if s.webhooks != nil {
+		if err := s.webhooks.PublishWebhook(ctx, job.ID, models.EventJobFailed, ""); err != nil {
+			log.Error().Err(err).Str("job_id", job.ID.String()).Msg("Failed to publish webhook event for stuck job")
+		}
+	}
+	log.Error().
+		Bool("alert", true).
+		Str("job_id", job.ID.String()).
+		Str("status", status).
+		Str("error_code", code).
+		Msg("Failed stuck job")
+	stats.Failed++
+}
+
+func (s *StuckJobSweeper) recordEvent(ctx context.Context, jobID uuid.UUID, eventType, message string, data map[string]any) {
+	ev := &models.JobEvent{
+		ID:        uuid.New(),
+		JobID:     jobID,
+		Type:      eventType,
+		Message:   message,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+	if err := s.events.Create(ctx, ev); err != nil {
+		log.Warn().Err(err).Str("job_id", jobID.String()).Str("event", eventType).Msg("Failed to record job event")
+	}
+}
@@ -0,0 +1,113 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/config"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// fakeStuckJobStore returns fixed stuck jobs per status and records failures.
+type fakeStuckJobStore struct {
+	stuck  map[string][]*models.Job
+	failed map[uuid.UUID]string // job ID -> error code
+}
+
+func (f *fakeStuckJobStore) ListStuck(_ context.Context, status string, _ time.Time, _ int) ([]*models.Job, error) {
+	return f.stuck[status], nil
+}
+
+func (f *fakeStuckJobStore) FailIfStatus(_ context.Context, jobID uuid.UUID, expectedStatus, errorCode, _ string) (bool, error) {
+	for _, job := range f.stuck[expectedStatus] {
+		if job.ID == jobID {
+			f.failed[jobID] = errorCode
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakeStuckEventStore keeps events in memory.
+type fakeStuckEventStore struct {
+	events []*models.JobEvent
+}
+
+func (f *fakeStuckEventStore) Create(_ context.Context, ev *models.JobEvent) error {
+	f.events = append(f.events, ev)
+	return nil
+}
+
+func (f *fakeStuckEventStore) CountByType(_ context.Context, jobID uuid.UUID, eventType string) (int, *time.Time, error) {
+	var n int
+	var last *time.Time
+	for _, ev := range f.events {
+		if ev.JobID == jobID && ev.Type == eventType {
+			n++
+			t := ev.CreatedAt
+			last = &t
+		}
+	}
+	return n, last, nil
+}
+
+type recordingPublisher struct {
+	jobs     []uuid.UUID
+	webhooks []string
+}
+
+func (p *recordingPublisher) PublishJob(_ context.Context, jobID uuid.UUID, _ string) error {
+	p.jobs = append(p.jobs, jobID)
+	return nil
+}
+
+func (p *recordingPublisher) PublishWebhook(_ context.Context, _ uuid.UUID, event, _ string) error {
+	p.webhooks = append(p.webhooks, event)
+	return nil
+}
+
+func TestStuckJobSweeper_RequeuesThenFails(t *testing.T) {
+	queued := &models.Job{ID: uuid.New(), Status: "queued"}
+	running := &models.Job{ID: uuid.New(), Status: "running"}
+	jobs := &fakeStuckJobStore{
+		stuck:  map[string][]*models.Job{"queued": {queued}, "running": {running}},
+		failed: make(map[uuid.UUID]string),
+	}
+	events := &fakeStuckEventStore{}
+	pub := &recordingPublisher{}
+	cfg := &config.Config{StuckQueuedAfter: time.Minute, StuckRunningAfter: time.Minute, StuckMaxRequeues: 1}
+	s := newStuckJobSweeper(jobs, events, pub, pub, cfg)
+
+	stats := s.Sweep(context.Background())
+	if stats.Requeued != 2 || stats.Failed != 0 {
+		t.Fatalf("first sweep: got %+v, want 2 requeued", stats)
+	}
+	if len(pub.jobs) != 2 {
+		t.Fatalf("published %d jobs, want 2", len(pub.jobs))
+	}
+
+	// A second sweep right away leaves recently requeued jobs alone.
+	if stats := s.Sweep(context.Background()); stats != (SweepStats{}) {
+		t.Fatalf("immediate second sweep: got %+v, want no action", stats)
+	}
+
+	// Once the requeue is older than the threshold, the requeue budget is exhausted and jobs fail.
+	for _, ev := range events.events {
+		ev.CreatedAt = ev.CreatedAt.Add(-2 * time.Minute)
+	}
+	stats = s.Sweep(context.Background())
+	if stats.Failed != 2 || stats.Requeued != 0 {
+		t.Fatalf("third sweep: got %+v, want 2 failed", stats)
+	}
+	if jobs.failed[queued.ID] != ErrCodeStuckInQueue {
+		t.Errorf("queued job error code = %q, want %q", jobs.failed[queued.ID], ErrCodeStuckInQueue)
+	}
+	if jobs.failed[running.ID] != ErrCodeWorkerTimeout {
+		t.Errorf("running job error code = %q, want %q", jobs.failed[running.ID], ErrCodeWorkerTimeout)
+	}
+	if len(pub.webhooks) != 2 || pub.webhooks[0] != "job_failed" {
+		t.Errorf("webhooks = %v, want two job_failed", pub.webhooks)
+	}
+}
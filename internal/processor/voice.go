@@ -0,0 +1,38 @@
+package processor
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/llm"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// withVoice returns a context that makes TTS speak with the job's custom voice. Jobs without one, whose
+// voice was deleted or belongs to a provider the worker cannot synthesize with use the default voice.
+func (p *JobProcessor) withVoice(ctx context.Context, job *models.Job) context.Context {
+	if job.VoiceID == nil {
+		return ctx
+	}
+	logger := log.With().Str("job_id", job.ID.String()).Str("voice_id", job.VoiceID.String()).Logger()
+	voice, err := p.voiceRepo.GetByID(ctx, *job.VoiceID)
+	if err != nil {
+		if errors.Is(err, database.ErrVoiceNotFound) {
+			logger.Warn().Msg("Job voice was deleted, using the default voice")
+		} else {
+			logger.Error().Err(err).Msg("Failed to get job voice, using the default voice")
+		}
+		return ctx
+	}
+	if voice.Provider != (llm.GeminiVoiceProvider{}).Name() {
+		logger.Warn().Str("provider", voice.Provider).Msg("No TTS for the voice's provider, using the default voice")
+		return ctx
+	}
+	style := ""
+	if voice.Style != nil {
+		style = *voice.Style
+	}
+	return llm.WithVoice(ctx, voice.ProviderVoiceID, style)
+}
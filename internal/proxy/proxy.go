@@ -0,0 +1,86 @@
+// Package proxy configures the outbound HTTP proxy of a component (webhook delivery, Gemini calls, S3).
+// Each component has a setting: empty uses HTTP_PROXY, HTTPS_PROXY and NO_PROXY from the environment
+// (what Go clients do by default), Direct connects without a proxy, and a proxy URL sends the
+// component's requests through that proxy, except those to hosts matched by NO_PROXY.
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Direct is the setting of components connecting without a proxy whatever the environment says.
+const Direct = "direct"
+
+// Validate checks a component's setting: empty, Direct or an http, https or socks5 URL with a host.
+func Validate(setting string) error {
+	_, err := parse(setting)
+	return err
+}
+
+// parse returns the proxy URL of a setting (nil for the environment and Direct).
+func parse(setting string) (*url.URL, error) {
+	if setting == "" || setting == Direct {
+		return nil, nil
+	}
+	u, err := url.Parse(setting)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err // without the URL and its password
+		}
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy URL %s: scheme must be http, https or socks5", Redact(setting))
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %s: no host", Redact(setting))
+	}
+	return u, nil
+}
+
+// Func returns the http.Transport.Proxy function of a setting. Invalid settings (see Validate) fail every
+// request rather than bypass the proxy.
+func Func(setting string) func(*http.Request) (*url.URL, error) {
+	u, err := parse(setting)
+	switch {
+	case err != nil:
+		return func(*http.Request) (*url.URL, error) { return nil, err }
+	case setting == Direct:
+		return nil
+	case u == nil:
+		return http.ProxyFromEnvironment
+	}
+	noProxy := os.Getenv("NO_PROXY")
+	if noProxy == "" {
+		noProxy = os.Getenv("no_proxy")
+	}
+	proxyFor := (&httpproxy.Config{HTTPProxy: u.String(), HTTPSProxy: u.String(), NoProxy: noProxy}).ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFor(req.URL)
+	}
+}
+
+// Transport returns a clone of http.DefaultTransport using the setting's proxy.
+func Transport(setting string) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = Func(setting)
+	return t
+}
+
+// Redact returns the setting without the proxy's password, for logs.
+func Redact(setting string) string {
+	u, err := url.Parse(setting)
+	if err != nil || u.User == nil {
+		return setting
+	}
+	return u.Redacted()
+}
@@ -1,8 +1,14 @@
+// Package quota charges API keys' character quota outside job creation: calls made through the agents
+// service (MCP tools, gRPC methods) count against the caller's quota like the jobs they could stand in for.
 package quota
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,9 +16,20 @@ import (
 	"github.com/snappy-loop/stories/internal/models"
 )
 
+// ErrQuotaExceeded is returned when an API key has not enough characters left in its period.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// apiKeyRepository is the subset of API key DB operations used by the quota service.
+type apiKeyRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.APIKey, error)
+	UpdateUsage(ctx context.Context, keyID uuid.UUID, chars int64, periodStartedAt time.Time) error
+}
+
 // Service handles quota management
 type Service struct {
-	apiKeyRepo *database.APIKeyRepository
+	apiKeyRepo   apiKeyRepository
+	weights      Weights
+	charsPerFile int64
 }
 
 // NewService creates a new quota service
@@ -22,19 +39,24 @@ func NewService(db *database.DB) *Service {
 	}
 }
 
+// SetAgentCharges sets how agent calls are charged: a call's input characters (charsPerFile for
+// extract_content, as a job's file) times its tool's weight. Tools without a weight weigh 1.
+func (s *Service) SetAgentCharges(weights Weights, charsPerFile int64) {
+	s.weights = weights
+	s.charsPerFile = charsPerFile
+}
+
 // CheckAndConsume checks if quota is available and consumes it
 func (s *Service) CheckAndConsume(ctx context.Context, apiKeyID uuid.UUID, charsNeeded int64) error {
 	// Get API key
-	apiKey, err := s.getAPIKeyByID(ctx, apiKeyID)
+	apiKey, err := s.apiKeyRepo.GetByID(ctx, apiKeyID)
 	if err != nil {
 		return fmt.Errorf("failed to get api key: %w", err)
 	}
 
 	// Check if period needs to be reset
 	now := time.Now()
-	periodDuration := s.getPeriodDuration(apiKey.QuotaPeriod)
-
-	if now.Sub(apiKey.PeriodStartedAt) > periodDuration {
+	if now.Sub(apiKey.PeriodStartedAt) > models.QuotaPeriodDuration(apiKey.QuotaPeriod) {
 		// Reset period
 		apiKey.UsedCharsInPeriod = 0
 		apiKey.PeriodStartedAt = now
@@ -42,7 +64,7 @@ func (s *Service) CheckAndConsume(ctx context.Context, apiKeyID uuid.UUID, chars
 
 	// Check quota
 	if apiKey.UsedCharsInPeriod+charsNeeded > apiKey.QuotaChars {
-		return fmt.Errorf("quota exceeded: %d/%d chars used", apiKey.UsedCharsInPeriod, apiKey.QuotaChars)
+		return fmt.Errorf("%w: %d/%d chars used", ErrQuotaExceeded, apiKey.UsedCharsInPeriod, apiKey.QuotaChars)
 	}
 
 	// Update usage
@@ -53,23 +75,55 @@ func (s *Service) CheckAndConsume(ctx context.Context, apiKeyID uuid.UUID, chars
 	return nil
 }
 
-func (s *Service) getAPIKeyByID(ctx context.Context, apiKeyID uuid.UUID) (*models.APIKey, error) {
-	// This would need a new repository method
-	// For now, return a placeholder error
-	return nil, fmt.Errorf("not implemented")
+// ChargeAgentCall charges an agent call to the caller's API key before it runs. inputChars is the size of
+// the call's text (text, script or prompt); it is ignored for extract_content. Calls weighing nothing are
+// free. A nil service charges nothing.
+func (s *Service) ChargeAgentCall(ctx context.Context, apiKeyID uuid.UUID, tool string, inputChars int64) error {
+	if s == nil {
+		return nil
+	}
+	chars := s.AgentCallChars(tool, inputChars)
+	if chars <= 0 {
+		return nil
+	}
+	return s.CheckAndConsume(ctx, apiKeyID, chars)
+}
+
+// AgentCallChars returns the characters an agent call is charged.
+func (s *Service) AgentCallChars(tool string, inputChars int64) int64 {
+	if tool == "extract_content" {
+		inputChars = s.charsPerFile
+	}
+	return int64(math.Ceil(float64(inputChars) * s.weights.Weight(tool)))
+}
+
+// Weights are per-tool multipliers of the characters agent calls are charged (AGENTS_QUOTA_WEIGHTS).
+type Weights map[string]float64
+
+// Weight returns the weight of tool, 1 when unset.
+func (w Weights) Weight(tool string) float64 {
+	if v, ok := w[tool]; ok {
+		return v
+	}
+	return 1
 }
 
-func (s *Service) getPeriodDuration(period string) time.Duration {
-	switch period {
-	case "daily":
-		return 24 * time.Hour
-	case "weekly":
-		return 7 * 24 * time.Hour
-	case "monthly":
-		return 30 * 24 * time.Hour
-	case "yearly":
-		return 365 * 24 * time.Hour
-	default:
-		return 30 * 24 * time.Hour
+// ParseWeights parses tool=weight settings; weights are non-negative numbers, 0 making the tool free.
+func ParseWeights(settings map[string]string) (Weights, error) {
+	weights := Weights{}
+	for tool, value := range settings {
+		known := false
+		for _, t := range models.AgentTools {
+			known = known || t == tool
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown agent tool %q (want one of %s)", tool, strings.Join(models.AgentTools, ", "))
+		}
+		w, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || w < 0 || math.IsInf(w, 0) || math.IsNaN(w) {
+			return nil, fmt.Errorf("invalid weight %q for %s: want a non-negative number", value, tool)
+		}
+		weights[tool] = w
 	}
+	return weights, nil
 }
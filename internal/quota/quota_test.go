@@ -0,0 +1,103 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// fakeAPIKeyRepo holds one API key and applies usage updates to it.
+type fakeAPIKeyRepo struct {
+	key *models.APIKey
+}
+
+func (f *fakeAPIKeyRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	if id != f.key.ID {
+		return nil, errors.New("api key not found")
+	}
+	k := *f.key
+	return &k, nil
+}
+
+func (f *fakeAPIKeyRepo) UpdateUsage(ctx context.Context, keyID uuid.UUID, chars int64, periodStartedAt time.Time) error {
+	if !f.key.PeriodStartedAt.Equal(periodStartedAt) {
+		f.key.UsedCharsInPeriod = 0
+		f.key.PeriodStartedAt = periodStartedAt
+	}
+	f.key.UsedCharsInPeriod += chars
+	return nil
+}
+
+func TestChargeAgentCall(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeAPIKeyRepo{key: &models.APIKey{
+		ID: uuid.New(), QuotaPeriod: "monthly", QuotaChars: 10000, PeriodStartedAt: time.Now().Add(-time.Hour),
+	}}
+	weights, err := ParseWeights(map[string]string{"generate_image": "50", "fact_check": "0", "generate_audio": "1.5"})
+	if err != nil {
+		t.Fatalf("ParseWeights: %v", err)
+	}
+	s := &Service{apiKeyRepo: repo}
+	s.SetAgentCharges(weights, 1000)
+
+	calls := []struct {
+		tool  string
+		chars int64
+		want  int64 // used chars after the call
+	}{
+		{"segment_text", 1200, 1200},
+		{"fact_check", 5000, 1200},      // free
+		{"generate_image", 40, 3200},    // 40 * 50
+		{"generate_audio", 3, 3205},     // ceil(4.5)
+		{"extract_content", 9999, 4205}, // charged as a file
+	}
+	for _, c := range calls {
+		if err := s.ChargeAgentCall(ctx, repo.key.ID, c.tool, c.chars); err != nil {
+			t.Fatalf("%s: %v", c.tool, err)
+		}
+		if repo.key.UsedCharsInPeriod != c.want {
+			t.Errorf("after %s: used %d, want %d", c.tool, repo.key.UsedCharsInPeriod, c.want)
+		}
+	}
+
+	if err := s.ChargeAgentCall(ctx, repo.key.ID, "segment_text", 6000); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("over quota: err = %v, want ErrQuotaExceeded", err)
+	}
+	if repo.key.UsedCharsInPeriod != 4205 {
+		t.Errorf("refused call charged: used %d", repo.key.UsedCharsInPeriod)
+	}
+
+	// An ended period starts over
+	repo.key.PeriodStartedAt = time.Now().Add(-31 * 24 * time.Hour)
+	if err := s.ChargeAgentCall(ctx, repo.key.ID, "segment_text", 6000); err != nil {
+		t.Fatalf("new period: %v", err)
+	}
+	if repo.key.UsedCharsInPeriod != 6000 {
+		t.Errorf("new period: used %d, want 6000", repo.key.UsedCharsInPeriod)
+	}
+
+	var off *Service
+	if err := off.ChargeAgentCall(ctx, repo.key.ID, "segment_text", 1e9); err != nil {
+		t.Errorf("nil service: %v", err)
+	}
+}
+
+func TestParseWeights(t *testing.T) {
+	for _, bad := range []map[string]string{
+		{"translate": "1"},
+		{"segment_text": "-1"},
+		{"segment_text": "x"},
+	} {
+		if _, err := ParseWeights(bad); err == nil {
+			t.Errorf("ParseWeights(%v) succeeded", bad)
+		}
+	}
+	w, err := ParseWeights(nil)
+	if err != nil || w.Weight("generate_image") != 1 {
+		t.Errorf("defaults: %v, %v", w, err)
+	}
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// pruneEvery is how many Allow calls pass between sweeps of full buckets.
+const pruneEvery = 1024
+
+// MemoryLimiter keeps the buckets in process memory: each API replica enforces the limit on its own.
+type MemoryLimiter struct {
+	rate  float64 // tokens per second
+	burst float64
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+}
+
+type bucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// NewMemoryLimiter creates a limiter of perMinute requests per key with bursts of up to burst (at least 1).
+func NewMemoryLimiter(perMinute, burst int) *MemoryLimiter {
+	return &MemoryLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(max(burst, 1)),
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token from key's bucket.
+func (l *MemoryLimiter) Allow(_ context.Context, key string) (Result, error) {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.calls++; l.calls%pruneEvery == 0 {
+		l.prune(now)
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, at: now}
+		l.buckets[key] = b
+	}
+	b.tokens = l.refill(b, now)
+	b.at = now
+	if b.tokens < 1 {
+		wait := time.Duration(math.Ceil((1 - b.tokens) / l.rate * float64(time.Second)))
+		return Result{RetryAfter: wait}, nil
+	}
+	b.tokens--
+	return Result{Allowed: true, Remaining: int(b.tokens)}, nil
+}
+
+func (l *MemoryLimiter) refill(b *bucket, now time.Time) float64 {
+	return min(l.burst, b.tokens+now.Sub(b.at).Seconds()*l.rate)
+}
+
+// prune drops full buckets, which behave like missing ones. Callers hold l.mu.
+func (l *MemoryLimiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		if l.refill(b, now) >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
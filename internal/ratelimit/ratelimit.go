@@ -0,0 +1,96 @@
+// Package ratelimit provides the per-key request rate limits of the API and the shared concurrency limits
+// of Gemini calls. Limits live in process memory (LIMITS_BACKEND=memory) or in Redis
+// (LIMITS_BACKEND=redis), where every API replica and worker draws from the same buckets and slots.
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/snappy-loop/stories/internal/cache"
+)
+
+// Backends selectable with LIMITS_BACKEND.
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+// DefaultLease is how long the slots of RedisSemaphore holders outlive a crashed process.
+const DefaultLease = time.Minute
+
+// CheckBackend validates LIMITS_BACKEND: redis needs REDIS_URL.
+func CheckBackend(backend, redisURL string) error {
+	switch backend {
+	case BackendMemory:
+		return nil
+	case BackendRedis:
+		if redisURL == "" {
+			return errors.New("LIMITS_BACKEND=redis requires REDIS_URL")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown LIMITS_BACKEND %q (want memory or redis)", backend)
+	}
+}
+
+// Result is the outcome of Limiter.Allow.
+type Result struct {
+	Allowed    bool
+	Remaining  int           // requests left in the bucket
+	RetryAfter time.Duration // when not allowed, until the next request would be
+}
+
+// Limiter is a token bucket per key: perMinute tokens are added each minute up to burst, and each
+// request takes one.
+type Limiter interface {
+	Allow(ctx context.Context, key string) (Result, error)
+}
+
+// redisClient is the part of *cache.Redis the Redis limits use.
+type redisClient interface {
+	Do(ctx context.Context, args ...string) (any, error)
+}
+
+// script is a Lua script run with EVALSHA, loaded with EVAL when the server does not have it yet.
+type script struct {
+	src string
+	sha string
+}
+
+func newScript(src string) *script {
+	sum := sha1.Sum([]byte(src))
+	return &script{src: src, sha: hex.EncodeToString(sum[:])}
+}
+
+func (s *script) run(ctx context.Context, r redisClient, keys []string, args ...string) (any, error) {
+	cmd := append([]string{"EVALSHA", s.sha, strconv.Itoa(len(keys))}, keys...)
+	reply, err := r.Do(ctx, append(cmd, args...)...)
+	var redisErr cache.RedisError
+	if errors.As(err, &redisErr) && strings.HasPrefix(string(redisErr), "NOSCRIPT") {
+		cmd[0], cmd[1] = "EVAL", s.src
+		reply, err = r.Do(ctx, append(cmd, args...)...)
+	}
+	return reply, err
+}
+
+// int64s converts a script's array reply of integers.
+func int64s(reply any, n int) ([]int64, error) {
+	arr, ok := reply.([]any)
+	if !ok || len(arr) != n {
+		return nil, fmt.Errorf("ratelimit: unexpected script reply %v", reply)
+	}
+	out := make([]int64, n)
+	for i, v := range arr {
+		if out[i], ok = v.(int64); !ok {
+			return nil, fmt.Errorf("ratelimit: unexpected script reply %v", reply)
+		}
+	}
+	return out, nil
+}
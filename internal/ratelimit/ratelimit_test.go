@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/snappy-loop/stories/internal/testutil"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := NewMemoryLimiter(60, 2)
+	l.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i, want := range []Result{{Allowed: true, Remaining: 1}, {Allowed: true}, {RetryAfter: time.Second}} {
+		if got, _ := l.Allow(ctx, "a"); got != want {
+			t.Errorf("call %d = %+v, want %+v", i, got, want)
+		}
+	}
+	if got, _ := l.Allow(ctx, "b"); !got.Allowed {
+		t.Errorf("other key = %+v, want allowed", got)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if got, _ := l.Allow(ctx, "a"); got.Allowed || got.RetryAfter != 500*time.Millisecond {
+		t.Errorf("after 0.5s = %+v, want retry after 500ms", got)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if got, _ := l.Allow(ctx, "a"); !got.Allowed {
+		t.Errorf("after 1s = %+v, want allowed", got)
+	}
+
+	now = now.Add(time.Hour)
+	l.prune(now)
+	if len(l.buckets) != 0 {
+		t.Errorf("buckets after prune = %d, want 0", len(l.buckets))
+	}
+}
+
+func TestRedisLimiter(t *testing.T) {
+	r := testutil.Redis(t)
+	l := NewRedisLimiter(r, testutil.KeyPrefix(), 60, 2)
+	ctx := context.Background()
+
+	for i, wantAllowed := range []bool{true, true, false} {
+		got, err := l.Allow(ctx, "key")
+		if err != nil {
+			t.Fatalf("Allow: %v", err)
+		}
+		if got.Allowed != wantAllowed {
+			t.Errorf("call %d allowed = %v, want %v", i, got.Allowed, wantAllowed)
+		}
+		if !got.Allowed && (got.RetryAfter <= 0 || got.RetryAfter > time.Second) {
+			t.Errorf("retry after = %s, want (0, 1s]", got.RetryAfter)
+		}
+	}
+}
+
+// TestRedisSemaphore asserts semaphores of the same name share their slots and release them.
+func TestRedisSemaphore(t *testing.T) {
+	r := testutil.Redis(t)
+	name := testutil.KeyPrefix() + "sem"
+	a := NewRedisSemaphore(r, name, 2, time.Second)
+	b := NewRedisSemaphore(r, name, 2, time.Second)
+	ctx := context.Background()
+
+	var running, peak atomic.Int64
+	var wg sync.WaitGroup
+	for i := range 6 {
+		sem := a
+		if i%2 == 1 {
+			sem = b
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := sem.Acquire(ctx, 1)
+			if err != nil {
+				t.Errorf("Acquire: %v", err)
+				return
+			}
+			n := running.Add(1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			time.Sleep(20 * time.Millisecond)
+			running.Add(-1)
+			release()
+		}()
+	}
+	wg.Wait()
+	if got := peak.Load(); got != 2 {
+		t.Errorf("peak concurrency = %d, want 2", got)
+	}
+
+	release, err := a.Acquire(ctx, 5) // clamped to the size
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	short, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := b.Acquire(short, 1); err != context.DeadlineExceeded {
+		t.Errorf("Acquire while full = %v, want deadline exceeded", err)
+	}
+	release()
+}
@@ -0,0 +1,219 @@
+package ratelimit
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// tokenBucketScript refills and takes from the bucket at KEYS[1] (a hash of tokens and their time in ms)
+// with ARGV perMinute and burst, returning {allowed, remaining, wait_ms}. Time is the server's, so
+// replicas with skewed clocks agree; Redis >= 5 replicates the script's effects, which permits TIME.
+var tokenBucketScript = newScript(`
+local rate = tonumber(ARGV[1]) / 60000
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(state[1]) or burst
+local at = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - at) * rate)
+local allowed, wait = 0, 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  wait = math.ceil((1 - tokens) / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'at', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate) + 1000)
+return {allowed, math.floor(tokens), wait}
+`)
+
+// RedisLimiter keeps the buckets in Redis, shared by every API replica.
+type RedisLimiter struct {
+	r         redisClient
+	prefix    string
+	perMinute int
+	burst     int
+}
+
+// NewRedisLimiter creates a limiter of perMinute (> 0) requests per key with bursts of up to burst, whose
+// buckets are stored at prefix+key.
+func NewRedisLimiter(r redisClient, prefix string, perMinute, burst int) *RedisLimiter {
+	return &RedisLimiter{r: r, prefix: prefix, perMinute: perMinute, burst: max(burst, 1)}
+}
+
+// Allow takes a token from key's bucket.
+func (l *RedisLimiter) Allow(ctx context.Context, key string) (Result, error) {
+	reply, err := tokenBucketScript.run(ctx, l.r, []string{l.prefix + key},
+		strconv.Itoa(l.perMinute), strconv.Itoa(l.burst))
+	if err != nil {
+		return Result{}, fmt.Errorf("rate limit: %w", err)
+	}
+	v, err := int64s(reply, 3)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Allowed: v[0] == 1, Remaining: int(v[1]), RetryAfter: time.Duration(v[2]) * time.Millisecond}, nil
+}
+
+// acquireScript admits holder ARGV[1] of weight ARGV[2] into the semaphore at KEYS[1] of size ARGV[3] for
+// a lease of ARGV[4] ms. The semaphore is a sorted set of "<weight>:<id>" holders scored by lease expiry;
+// expired holders (of crashed processes) are dropped first. Returns 1 when admitted.
+var acquireScript = newScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+local used = 0
+for _, holder in ipairs(redis.call('ZRANGE', KEYS[1], 0, -1)) do
+  used = used + tonumber(string.match(holder, '^(%d+):'))
+end
+if used + tonumber(ARGV[2]) > tonumber(ARGV[3]) then
+  return 0
+end
+redis.call('ZADD', KEYS[1], now + tonumber(ARGV[4]), ARGV[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return 1
+`)
+
+// renewScript extends holder ARGV[1]'s lease by ARGV[2] ms; 0 means it was lost.
+var renewScript = newScript(`
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+  return 0
+end
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+redis.call('ZADD', KEYS[1], now + tonumber(ARGV[2]), ARGV[1])
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return 1
+`)
+
+// Polling interval bounds of RedisSemaphore.Acquire.
+const (
+	minPoll = 10 * time.Millisecond
+	maxPoll = 250 * time.Millisecond
+)
+
+// RedisSemaphore is a weighted semaphore in Redis, shared by every process using the same name. Holders
+// keep a lease renewed while they run, so the slots of a crashed process free up after the lease.
+// Waiters poll, so unlike llm's in-process semaphore they are not served strictly in arrival order.
+type RedisSemaphore struct {
+	r     redisClient
+	name  string
+	key   string
+	size  int64
+	lease time.Duration
+
+	waits     atomic.Int64 // acquisitions that had to wait
+	waitNanos atomic.Int64 // total time spent waiting
+	timeouts  atomic.Int64 // acquisitions whose context ended while waiting
+}
+
+// NewRedisSemaphore creates a semaphore of size slots stored at key "limits:sem:"+name. Its waits are
+// logged as <name>_{waits_total,wait_ms_total,timeouts_total}.
+func NewRedisSemaphore(r redisClient, name string, size int64, lease time.Duration) *RedisSemaphore {
+	return &RedisSemaphore{r: r, name: name, key: "limits:sem:" + name, size: size, lease: lease}
+}
+
+// Acquire takes weight slots (at most the semaphore's size), waiting until they fit or ctx ends. The
+// returned release must be called once the caller is done with them.
+func (s *RedisSemaphore) Acquire(ctx context.Context, weight int64) (release func(), err error) {
+	weight = min(max(weight, 1), s.size)
+	holder := strconv.FormatInt(weight, 10) + ":" + randomID()
+	lease := strconv.FormatInt(s.lease.Milliseconds(), 10)
+	args := []string{holder, strconv.FormatInt(weight, 10), strconv.FormatInt(s.size, 10), lease}
+
+	var start time.Time
+	poll := minPoll
+	for {
+		reply, err := acquireScript.run(ctx, s.r, []string{s.key}, args...)
+		if err != nil {
+			return nil, fmt.Errorf("acquire %s: %w", s.name, err)
+		}
+		if reply == int64(1) {
+			break
+		}
+		if start.IsZero() {
+			start = time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			s.timeouts.Add(1)
+			return nil, ctx.Err()
+		case <-time.After(poll/2 + rand.N(poll/2+1)):
+		}
+		poll = min(2*poll, maxPoll)
+	}
+	if !start.IsZero() {
+		s.recordWait(time.Since(start))
+	}
+
+	done := make(chan struct{})
+	go s.renew(holder, lease, done)
+	return func() {
+		close(done)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := s.r.Do(ctx, "ZREM", s.key, holder); err != nil {
+			log.Warn().Err(err).Str("semaphore", s.name).Msg("Failed to release semaphore slots; they free up when the lease ends")
+		}
+	}, nil
+}
+
+// renew extends holder's lease every third of it until done is closed.
+func (s *RedisSemaphore) renew(holder, lease string, done <-chan struct{}) {
+	ticker := time.NewTicker(s.lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), s.lease/3)
+			reply, err := renewScript.run(ctx, s.r, []string{s.key}, holder, lease)
+			cancel()
+			if err != nil {
+				log.Warn().Err(err).Str("semaphore", s.name).Msg("Failed to renew semaphore lease")
+			} else if reply == int64(0) && !closed(done) {
+				log.Warn().Str("semaphore", s.name).Msg("Semaphore lease expired while held; slots may be oversubscribed")
+			}
+		}
+	}
+}
+
+// recordWait logs a waiting acquisition with the running totals, named like llm's in-process limits.
+func (s *RedisSemaphore) recordWait(wait time.Duration) {
+	waits := s.waits.Add(1)
+	total := s.waitNanos.Add(int64(wait))
+	log.Info().
+		Str("semaphore", s.name).
+		Int64("limit", s.size).
+		Int64("wait_ms", wait.Milliseconds()).
+		Int64(s.name+"_waits_total", waits).
+		Int64(s.name+"_wait_ms_total", time.Duration(total).Milliseconds()).
+		Int64(s.name+"_timeouts_total", s.timeouts.Load()).
+		Msg("Waited for a shared concurrency slot")
+}
+
+func closed(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+func randomID() string {
+	b := make([]byte, 8)
+	crand.Read(b)
+	return hex.EncodeToString(b)
+}
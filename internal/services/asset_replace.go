@@ -0,0 +1,160 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/waveform"
+)
+
+// ErrAssetNotReplaceable is returned by ReplaceAssetContent while the asset's job is still being generated.
+var ErrAssetNotReplaceable = errors.New("asset cannot be replaced while its job is being generated")
+
+// replacementTypes lists, per asset kind, the accepted MIME types of a replacement upload with the content
+// types http.DetectContentType reports for them and the file extension used in S3.
+var replacementTypes = map[string]map[string]struct {
+	sniffed []string
+	ext     string
+}{
+	"image": {
+		"image/png":  {[]string{"image/png"}, "png"},
+		"image/jpeg": {[]string{"image/jpeg"}, "jpg"},
+		"image/webp": {[]string{"image/webp"}, "webp"},
+	},
+	"audio": {
+		"audio/wav":  {[]string{"audio/wave"}, "wav"},
+		"audio/mpeg": {[]string{"audio/mpeg", "application/octet-stream"}, "mp3"}, // MP3s without an ID3 tag are not sniffed
+		"audio/ogg":  {[]string{"application/ogg"}, "ogg"},
+	},
+}
+
+// SetAssetStorage sets the object storage used for asset replacement uploads.
+func (s *JobService) SetAssetStorage(st assetStorage) {
+	s.storage = st
+}
+
+// ReplaceAssetContent replaces a generated image or audio asset with the user's own upload. The upload must
+// be of the asset's kind (checked against the declared MIME type and the content) and at most MaxFileSize.
+// It is stored as the next version of the asset and takes the old one's place in the job's markup; the old
+// asset is superseded (kept, with its S3 object, so the previous output version can be restored). Assets of
+// expired jobs fail with ErrJobExpired.
+func (s *JobService) ReplaceAssetContent(ctx context.Context, assetID, userID uuid.UUID, mimeType string, data io.Reader) (*models.Asset, error) {
+	if s.storage == nil {
+		return nil, fmt.Errorf("storage not configured")
+	}
+	asset, err := s.GetAsset(ctx, assetID, userID)
+	if err != nil {
+		return nil, err
+	}
+	job, err := s.jobRepo.GetByID(ctx, asset.JobID)
+	if err != nil {
+		return nil, fmt.Errorf("job not found: %w", err)
+	}
+	if job.Expired(time.Now()) {
+		return nil, ErrJobExpired
+	}
+	if job.Status != models.JobStatusSucceeded && job.Status != models.JobStatusAwaitingReview {
+		return nil, ErrAssetNotReplaceable
+	}
+
+	mimeType = strings.ToLower(strings.TrimSpace(strings.Split(mimeType, ";")[0]))
+	accepted, ok := replacementTypes[asset.Kind][mimeType]
+	if !ok {
+		return nil, fmt.Errorf("validation error: unsupported content type %q for %s asset", mimeType, asset.Kind)
+	}
+
+	// Same size enforcement as file uploads: read at most MaxFileSize+1 bytes (Content-Length is not trusted)
+	buf := new(bytes.Buffer)
+	n, err := io.Copy(buf, io.LimitReader(data, s.config.MaxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("validation error: empty upload")
+	}
+	if n > s.config.MaxFileSize {
+		return nil, fmt.Errorf("validation error: file size exceeds maximum of %d bytes", s.config.MaxFileSize)
+	}
+	sniffed := strings.Split(http.DetectContentType(buf.Bytes()), ";")[0]
+	if !contains(accepted.sniffed, sniffed) {
+		return nil, fmt.Errorf("validation error: content does not look like %s (detected %s)", mimeType, sniffed)
+	}
+
+	version := 1
+	if asset.SegmentID != nil {
+		if version, err = s.assetRepo.NextVersion(ctx, *asset.SegmentID, asset.Kind); err != nil {
+			return nil, fmt.Errorf("failed to get asset version: %w", err)
+		}
+	}
+
+	replacement := &models.Asset{
+		ID:        uuid.New(),
+		JobID:     asset.JobID,
+		SegmentID: asset.SegmentID,
+		Kind:      asset.Kind,
+		MimeType:  mimeType,
+		SizeBytes: n,
+		Version:   version,
+		Meta: map[string]any{
+			"source":   "user_upload",
+			"replaces": asset.ID.String(),
+		},
+		CreatedAt: time.Now(),
+	}
+	replacement.S3Key = fmt.Sprintf("jobs/%s/replacements/%s.%s", asset.JobID, replacement.ID, accepted.ext)
+	if mimeType == "audio/wav" {
+		// Same waveform data as generated audio; other audio formats have none
+		if w, err := waveform.FromWAV(buf.Bytes(), waveform.DefaultPeaks); err != nil {
+			log.Warn().Err(err).Str("asset_id", asset.ID.String()).Msg("Failed to compute waveform of replacement audio")
+		} else {
+			replacement.Meta["peaks"] = w.Peaks
+			replacement.Meta["duration"] = w.Duration
+		}
+	}
+
+	bucket, err := s.storage.Upload(ctx, replacement.S3Key, bytes.NewReader(buf.Bytes()), mimeType, n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload to storage: %w", err)
+	}
+	replacement.S3Bucket = bucket
+	if err := s.assetRepo.Replace(ctx, asset, replacement); err != nil {
+		_ = s.storage.Delete(ctx, bucket, replacement.S3Key)
+		return nil, fmt.Errorf("failed to replace asset: %w", err)
+	}
+
+	s.recordEvent(ctx, asset.JobID, models.JobEventAssetReplaced, fmt.Sprintf("%s asset replaced by upload", asset.Kind), map[string]any{
+		"asset_id":    replacement.ID.String(),
+		"replaces":    asset.ID.String(),
+		"kind":        asset.Kind,
+		"mime_type":   mimeType,
+		"size_bytes":  n,
+		"version":     version,
+		"uploaded_by": userID.String(),
+	})
+	log.Info().
+		Str("job_id", asset.JobID.String()).
+		Str("asset_id", replacement.ID.String()).
+		Str("replaces", asset.ID.String()).
+		Int64("size", n).
+		Msg("Asset replaced")
+
+	return replacement, nil
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
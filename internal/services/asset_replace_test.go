@@ -0,0 +1,133 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/config"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// memStorage records uploaded and deleted object keys.
+type memStorage struct {
+	objects map[string][]byte
+	deleted []string
+}
+
+func (m *memStorage) Upload(ctx context.Context, key string, data io.Reader, contentType string, contentLength int64) (string, error) {
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return "", err
+	}
+	m.objects[key] = b
+	return "stories-assets", nil
+}
+
+func (m *memStorage) Delete(ctx context.Context, bucket, key string) error {
+	m.deleted = append(m.deleted, key)
+	return nil
+}
+
+// replaceableAssetRepo holds one asset and records replacements.
+type replaceableAssetRepo struct {
+	fakeAssetRepo
+	asset    *models.Asset
+	replaced *models.Asset
+}
+
+func (r *replaceableAssetRepo) GetByID(ctx context.Context, assetID uuid.UUID) (*models.Asset, error) {
+	if r.asset == nil || r.asset.ID != assetID {
+		return nil, errNotFound
+	}
+	clone := *r.asset
+	return &clone, nil
+}
+
+func (r *replaceableAssetRepo) NextVersion(ctx context.Context, segmentID uuid.UUID, kind string) (int, error) {
+	return r.asset.Version + 1, nil
+}
+
+func (r *replaceableAssetRepo) Replace(ctx context.Context, old, replacement *models.Asset) error {
+	r.replaced = replacement
+	r.asset = replacement
+	return nil
+}
+
+func TestReplaceAssetContent(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	jobRepo := newFakeJobRepo()
+	jobID := uuid.New()
+	jobRepo.Create(ctx, &models.Job{ID: jobID, UserID: userID, Status: models.JobStatusSucceeded, CreatedAt: time.Now()})
+	segmentID := uuid.New()
+	oldKey := "jobs/" + jobID.String() + "/segments/0/image.png"
+	assets := &replaceableAssetRepo{asset: &models.Asset{
+		ID: uuid.New(), JobID: jobID, SegmentID: &segmentID, Kind: "image", MimeType: "image/png", S3Key: oldKey, Version: 1,
+	}}
+	store := &memStorage{objects: map[string][]byte{}}
+	svc := NewJobService(
+		&fakeJobRepoGetByIDErr{fakeJobRepo: jobRepo},
+		fakeSegmentRepo{},
+		assets,
+		fakeJobFileRepo{},
+		newFakeFileRepo(),
+		fakeFactCheckRepo{},
+		&fakeJobEventRepo{},
+		newFakeAPIKeyRepo(nil),
+		noopJobPublisher{},
+		&config.Config{MaxFileSize: 64},
+	)
+	svc.SetAssetStorage(store)
+	oldID := assets.asset.ID
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 16)...)
+
+	for _, tc := range []struct {
+		name, mime string
+		data       []byte
+	}{
+		{"audio for an image", "audio/wav", png},
+		{"content not matching type", "image/png", []byte("GIF89a not a png at all")},
+		{"too large", "image/png", append(png, make([]byte, 64)...)},
+		{"empty", "image/png", nil},
+	} {
+		if _, err := svc.ReplaceAssetContent(ctx, oldID, userID, tc.mime, bytes.NewReader(tc.data)); err == nil || !strings.HasPrefix(err.Error(), "validation error") {
+			t.Errorf("%s: got %v, want validation error", tc.name, err)
+		}
+	}
+	if _, err := svc.ReplaceAssetContent(ctx, oldID, uuid.New(), "image/png", bytes.NewReader(png)); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("other user: got %v, want access denied", err)
+	}
+
+	got, err := svc.ReplaceAssetContent(ctx, oldID, userID, "image/png; charset=binary", bytes.NewReader(png))
+	if err != nil {
+		t.Fatalf("ReplaceAssetContent: %v", err)
+	}
+	if got.ID == oldID || got.JobID != jobID || got.SegmentID == nil || *got.SegmentID != segmentID || got.Kind != "image" {
+		t.Errorf("replacement = %+v", got)
+	}
+	if got.Meta["replaces"] != oldID.String() || got.Meta["source"] != "user_upload" {
+		t.Errorf("replacement meta = %v", got.Meta)
+	}
+	if !bytes.Equal(store.objects[got.S3Key], png) {
+		t.Errorf("uploaded object %q not stored", got.S3Key)
+	}
+	if got.Version != 2 {
+		t.Errorf("replacement version = %d, want 2", got.Version)
+	}
+	if len(store.deleted) != 0 {
+		t.Errorf("deleted keys = %v, want the replaced object kept", store.deleted)
+	}
+
+	runningID := uuid.New()
+	jobRepo.Create(ctx, &models.Job{ID: runningID, UserID: userID, Status: models.JobStatusRunning, CreatedAt: time.Now()})
+	assets.asset = &models.Asset{ID: uuid.New(), JobID: runningID, Kind: "image"}
+	if _, err := svc.ReplaceAssetContent(ctx, assets.asset.ID, userID, "image/png", bytes.NewReader(png)); !errors.Is(err, ErrAssetNotReplaceable) {
+		t.Errorf("running job: got %v, want ErrAssetNotReplaceable", err)
+	}
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// ErrJobNotCancelable is returned by CancelJob when the job already succeeded, failed or was canceled.
+var ErrJobNotCancelable = errors.New("job is already finished")
+
+// CancelJob cancels a queued, running or awaiting review job. The status changes right away; a worker
+// running the job notices it before its next pipeline step or segment, stops and keeps what it finished.
+// Unfinished segments are marked canceled, the job_canceled event is published (the job's webhook and
+// notification sinks) and jobs waiting for this one (duplicates, depends_on) are queued to resolve.
+func (s *JobService) CancelJob(ctx context.Context, jobID, userID uuid.UUID) (*models.JobStatusResponse, error) {
+	job, err := s.ownedJob(ctx, jobID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.jobRepo.UpdateStatus(ctx, jobID, models.JobStatusCanceled, nil, nil); err != nil {
+		if errors.Is(err, database.ErrInvalidJobTransition) {
+			return nil, ErrJobNotCancelable
+		}
+		return nil, fmt.Errorf("failed to cancel job: %w", err)
+	}
+
+	canceledSegments, err := s.segmentRepo.CancelUnfinished(ctx, jobID)
+	if err != nil {
+		log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to mark unfinished segments canceled")
+	}
+	s.recordEvent(ctx, jobID, models.JobEventCanceled, "Canceled by user", map[string]any{
+		"user_id":           userID.String(),
+		"previous_status":   job.Status,
+		"canceled_segments": canceledSegments,
+	})
+	if s.webhooks != nil {
+		if err := s.webhooks.PublishWebhook(ctx, jobID, models.EventJobCanceled, ""); err != nil {
+			log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to publish webhook event to Kafka")
+		}
+	}
+	s.requeueDuplicates(ctx, jobID)
+	s.requeueDependents(ctx, jobID)
+
+	log.Info().Str("job_id", jobID.String()).Str("previous_status", job.Status).Msg("Job canceled")
+	return s.GetJob(ctx, jobID, userID)
+}
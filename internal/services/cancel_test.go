@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// cancelingSegmentRepo counts the unfinished segments it was asked to cancel.
+type cancelingSegmentRepo struct {
+	fakeSegmentRepo
+	unfinished int64
+}
+
+func (r *cancelingSegmentRepo) CancelUnfinished(context.Context, uuid.UUID) (int64, error) {
+	n := r.unfinished
+	r.unfinished = 0
+	return n, nil
+}
+
+func TestCancelJob(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	jobRepo := newFakeJobRepo()
+	jobID := uuid.New()
+	jobRepo.Create(ctx, &models.Job{ID: jobID, UserID: userID, Status: models.JobStatusRunning, CreatedAt: time.Now()})
+	depID := uuid.New()
+	jobRepo.Create(ctx, &models.Job{ID: depID, UserID: userID, Status: models.JobStatusQueued, DependsOn: []uuid.UUID{jobID}, CreatedAt: time.Now()})
+	publisher := &recordingPublisher{}
+	events := &fakeJobEventRepo{}
+	svc := newReviewTestService(jobRepo, publisher, events)
+	segments := &cancelingSegmentRepo{unfinished: 2}
+	svc.segmentRepo = segments
+
+	if _, err := svc.CancelJob(ctx, jobID, uuid.New()); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Fatalf("expected access denied for other user, got %v", err)
+	}
+
+	got, err := svc.CancelJob(ctx, jobID, userID)
+	if err != nil {
+		t.Fatalf("CancelJob: %v", err)
+	}
+	if got.Job.Status != models.JobStatusCanceled {
+		t.Errorf("status = %s, want canceled", got.Job.Status)
+	}
+	if len(publisher.webhooks) != 1 || publisher.webhooks[0] != models.EventJobCanceled {
+		t.Errorf("webhooks = %v, want [job_canceled]", publisher.webhooks)
+	}
+	// The dependent job is queued so the worker fails it
+	if len(publisher.jobs) != 1 || publisher.jobs[0] != depID {
+		t.Errorf("republished jobs = %v, want the dependent %s", publisher.jobs, depID)
+	}
+	evs, _ := events.ListByJob(ctx, jobID)
+	if len(evs) != 1 || evs[0].Type != models.JobEventCanceled || evs[0].Data["canceled_segments"] != int64(2) ||
+		evs[0].Data["previous_status"] != models.JobStatusRunning {
+		t.Errorf("unexpected events %+v", evs)
+	}
+
+	if _, err := svc.CancelJob(ctx, jobID, userID); !errors.Is(err, ErrJobNotCancelable) {
+		t.Errorf("second cancel: got %v, want ErrJobNotCancelable", err)
+	}
+	if len(publisher.webhooks) != 1 {
+		t.Errorf("webhook published again on a rejected cancel")
+	}
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// jobClone describes the job a new job is cloned from: its ID and the job files whose text it already
+// extracted (by file ID), which the new job reuses instead of extracting again.
+type jobClone struct {
+	sourceID  uuid.UUID
+	extracted map[uuid.UUID]*models.JobFile
+}
+
+// extractedText returns the source's extracted text for fileID, or nil (also for a nil clone).
+func (c *jobClone) extractedText(fileID uuid.UUID) *string {
+	if c == nil || c.extracted[fileID] == nil {
+		return nil
+	}
+	return c.extracted[fileID].ExtractedText
+}
+
+// extractedMeta returns the extraction metadata (PDF page offsets) of the source's text for fileID, or nil.
+func (c *jobClone) extractedMeta(fileID uuid.UUID) *models.JobFileMeta {
+	if c == nil || c.extracted[fileID] == nil {
+		return nil
+	}
+	return c.extracted[fileID].Meta
+}
+
+// CloneJob creates a new job with the input and options of an earlier job, with the options set in req
+// overriding the source's. Text the source extracted from its files is reused when the input type is
+// unchanged (extraction depends on it); segmentation boundaries come from the boundary cache since the
+// text is the same. The clone is a normal job: it is charged quota and runs the full pipeline.
+func (s *JobService) CloneJob(ctx context.Context, sourceID, userID, apiKeyID uuid.UUID, req *models.CloneJobRequest) (*models.CreateJobResponse, error) {
+	if req == nil {
+		req = &models.CloneJobRequest{}
+	}
+	source, err := s.ownedJob(ctx, sourceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	jobFiles, err := s.jobFileRepo.ListByJob(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source job files: %w", err)
+	}
+
+	create := cloneRequest(source, req)
+	clone := &jobClone{sourceID: sourceID, extracted: map[uuid.UUID]*models.JobFile{}}
+	for _, jf := range jobFiles {
+		create.FileIDs = append(create.FileIDs, jf.FileID)
+		if jf.ExtractionOptions != nil {
+			if create.FileOptions == nil {
+				create.FileOptions = map[uuid.UUID]*models.ExtractionOptions{}
+			}
+			create.FileOptions[jf.FileID] = jf.ExtractionOptions
+		}
+		if create.Type == source.InputType && jf.Status == "succeeded" && jf.ExtractedText != nil {
+			clone.extracted[jf.FileID] = jf
+		}
+	}
+
+	resp, err := s.createJob(ctx, create, userID, apiKeyID, clone)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().
+		Str("job_id", resp.JobID.String()).
+		Str("cloned_from", sourceID.String()).
+		Int("reused_extractions", len(clone.extracted)).
+		Msg("Job cloned")
+	return resp, nil
+}
+
+// cloneRequest builds the create request of a clone of source: the source's input and options, with the
+// options set in req taking precedence. File IDs are added by the caller.
+func cloneRequest(source *models.Job, req *models.CloneJobRequest) *models.CreateJobRequest {
+	create := &models.CreateJobRequest{
+		Type:                 source.InputType,
+		SegmentsCount:        source.SegmentsCount,
+		AudioType:            source.AudioType,
+		FactCheckNeeded:      &source.FactCheckNeeded,
+		SegmentationStrategy: source.SegmentationStrategy,
+		QualityCheck:         source.QualityCheck,
+		RequireReview:        source.RequireReview,
+		NotifyEmail:          source.NotifyEmail,
+		Lexicon:              source.Lexicon,
+		VoiceID:              source.VoiceID,
+		ExternalTool:         source.ExternalTool,
+		DependsOn:            source.DependsOn,
+		LazyImages:           source.LazyImages,
+		Metadata:             source.Metadata,
+		Tags:                 source.Tags,
+	}
+	if source.Jurisdiction != nil {
+		create.Jurisdiction = *source.Jurisdiction
+	}
+	if source.InputText != "[pending extraction]" {
+		create.Text = source.InputText
+	}
+	if source.WebhookURL != nil {
+		create.Webhook = &models.WebhookConfig{URL: *source.WebhookURL, Secret: source.WebhookSecret, EncryptionKey: source.WebhookEncryptionKey}
+		if source.WebhookPayload != nil {
+			create.Webhook.Payload = *source.WebhookPayload
+		}
+	}
+
+	if req.Type != nil {
+		create.Type = *req.Type
+	}
+	if req.SegmentsCount != nil {
+		create.SegmentsCount = *req.SegmentsCount
+	}
+	if req.AudioType != nil {
+		create.AudioType = *req.AudioType
+	}
+	if req.FactCheckNeeded != nil {
+		create.FactCheckNeeded = req.FactCheckNeeded
+	}
+	if req.SegmentationStrategy != nil {
+		create.SegmentationStrategy = *req.SegmentationStrategy
+	}
+	if req.QualityCheck != nil {
+		create.QualityCheck = *req.QualityCheck
+	}
+	if req.RequireReview != nil {
+		create.RequireReview = *req.RequireReview
+	}
+	if req.NotifyEmail != nil {
+		create.NotifyEmail = req.NotifyEmail
+	}
+	if req.Lexicon != nil {
+		create.Lexicon = req.Lexicon
+	}
+	if req.VoiceID != nil {
+		create.VoiceID = req.VoiceID
+	}
+	if req.Jurisdiction != nil {
+		create.Jurisdiction = *req.Jurisdiction
+	}
+	if req.ExternalTool != nil {
+		create.ExternalTool = req.ExternalTool
+	}
+	if req.DependsOn != nil {
+		create.DependsOn = req.DependsOn
+	}
+	if req.LazyImages != nil {
+		create.LazyImages = *req.LazyImages
+	}
+	if req.Webhook != nil {
+		create.Webhook = req.Webhook
+	}
+	if req.Metadata != nil {
+		create.Metadata = req.Metadata
+	}
+	if req.Tags != nil {
+		create.Tags = req.Tags
+	}
+	return create
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/config"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// memJobFileRepo stores job_file links per job.
+type memJobFileRepo struct {
+	byJob map[uuid.UUID][]*models.JobFile
+}
+
+func (r *memJobFileRepo) Create(ctx context.Context, jf *models.JobFile) error {
+	r.byJob[jf.JobID] = append(r.byJob[jf.JobID], jf)
+	return nil
+}
+
+func (r *memJobFileRepo) ListByJob(ctx context.Context, jobID uuid.UUID) ([]*models.JobFile, error) {
+	return r.byJob[jobID], nil
+}
+
+func TestCloneJob(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	jobRepo := newFakeJobRepo()
+	files := newFakeFileRepo()
+	jobFiles := &memJobFileRepo{byJob: map[uuid.UUID][]*models.JobFile{}}
+	events := &fakeJobEventRepo{}
+	svc := NewJobService(
+		&fakeJobRepoGetByIDErr{fakeJobRepo: jobRepo},
+		fakeSegmentRepo{},
+		fakeAssetRepo{},
+		jobFiles,
+		files,
+		fakeFactCheckRepo{},
+		events,
+		newFakeAPIKeyRepo(nil),
+		noopJobPublisher{},
+		&config.Config{MaxFilesPerJob: 10, MaxInputLength: 1000, MaxSegmentsCount: 20},
+	)
+
+	// Source: a files job whose file has since expired; its extracted text is still reusable
+	fileID := uuid.New()
+	files.byID[fileID] = &models.File{ID: fileID, UserID: userID, Status: "ready", ExpiresAt: time.Now().Add(-time.Hour)}
+	webhook := "https://example.com/hook"
+	sourceID := uuid.New()
+	jobRepo.Create(ctx, &models.Job{
+		ID: sourceID, UserID: userID, Status: models.JobStatusSucceeded, InputType: "educational", SegmentsCount: 4,
+		AudioType: "free_speech", InputText: "[pending extraction]", InputSource: "files", WebhookURL: &webhook,
+		SegmentationStrategy: models.SegmentationStrategyLLM, Tags: []string{"draft"}, CreatedAt: time.Now(),
+	})
+	extracted := "Chapter one."
+	jobFiles.Create(ctx, &models.JobFile{ID: uuid.New(), JobID: sourceID, FileID: fileID, Status: "succeeded", ExtractedText: &extracted})
+
+	if _, err := svc.CloneJob(ctx, sourceID, uuid.New(), uuid.New(), nil); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("other user: got %v, want access denied", err)
+	}
+	zero := 0
+	if _, err := svc.CloneJob(ctx, sourceID, userID, uuid.New(), &models.CloneJobRequest{SegmentsCount: &zero}); err == nil || !strings.HasPrefix(err.Error(), "validation error") {
+		t.Errorf("invalid override: got %v, want validation error", err)
+	}
+
+	six, podcast := 6, "podcast"
+	resp, err := svc.CloneJob(ctx, sourceID, userID, uuid.New(), &models.CloneJobRequest{SegmentsCount: &six, AudioType: &podcast})
+	if err != nil {
+		t.Fatalf("CloneJob: %v", err)
+	}
+	clone, _ := jobRepo.GetByID(ctx, resp.JobID)
+	if clone.SegmentsCount != 6 || clone.AudioType != "podcast" || clone.InputType != "educational" || clone.InputSource != "files" {
+		t.Errorf("clone options = %+v", clone)
+	}
+	if clone.WebhookURL == nil || *clone.WebhookURL != webhook || len(clone.Tags) != 1 {
+		t.Errorf("clone webhook/tags not copied: %+v", clone)
+	}
+	links := jobFiles.byJob[resp.JobID]
+	if len(links) != 1 || links[0].Status != "succeeded" || links[0].ExtractedText == nil || *links[0].ExtractedText != extracted {
+		t.Errorf("clone job files = %+v, want the source's extraction reused", links)
+	}
+
+	// The jurisdiction (of financial disclaimers) is copied unless overridden
+	us, eu := "US", "eu"
+	jobRepo.jobs[sourceID].Jurisdiction = &us
+	for _, tt := range []struct {
+		override *string
+		want     string
+	}{{nil, "US"}, {&eu, "EU"}} {
+		resp, err := svc.CloneJob(ctx, sourceID, userID, uuid.New(), &models.CloneJobRequest{Jurisdiction: tt.override})
+		if err != nil {
+			t.Fatalf("CloneJob with jurisdiction: %v", err)
+		}
+		if clone, _ := jobRepo.GetByID(ctx, resp.JobID); clone.Jurisdiction == nil || *clone.Jurisdiction != tt.want {
+			t.Errorf("clone jurisdiction = %v, want %s", clone.Jurisdiction, tt.want)
+		}
+	}
+
+	// A different input type changes extraction, so the (expired) file must be extracted again
+	fictional := "fictional"
+	if _, err := svc.CloneJob(ctx, sourceID, userID, uuid.New(), &models.CloneJobRequest{Type: &fictional}); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("type override with expired file: got %v, want expired error", err)
+	}
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// encodeTimeCursor returns an opaque pagination cursor for a (created_at, id) position.
+func encodeTimeCursor(t time.Time, id uuid.UUID) string {
+	raw := t.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeTimeCursor parses a cursor produced by encodeTimeCursor.
+func decodeTimeCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, fmt.Errorf("invalid cursor")
+	}
+	tsPart, idPart, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, fmt.Errorf("invalid cursor")
+	}
+	t, err := time.Parse(time.RFC3339Nano, tsPart)
+	if err != nil {
+		return time.Time{}, uuid.Nil, fmt.Errorf("invalid cursor")
+	}
+	id, err := uuid.Parse(idPart)
+	if err != nil {
+		return time.Time{}, uuid.Nil, fmt.Errorf("invalid cursor")
+	}
+	return t, id, nil
+}
+
+// encodeIndexCursor returns an opaque pagination cursor for a segment index.
+func encodeIndexCursor(idx int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(idx)))
+}
+
+// decodeIndexCursor parses a cursor produced by encodeIndexCursor.
+func decodeIndexCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	idx, err := strconv.Atoi(string(raw))
+	if err != nil || idx < 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return idx, nil
+}
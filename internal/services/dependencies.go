@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// maxJobDependencies limits depends_on
+const maxJobDependencies = 10
+
+// validateDependsOn checks the size of depends_on and that it lists each job once.
+func validateDependsOn(ids []uuid.UUID) error {
+	if len(ids) > maxJobDependencies {
+		return fmt.Errorf("depends_on exceeds maximum of %d jobs", maxJobDependencies)
+	}
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return fmt.Errorf("duplicate depends_on job: %s", id.String())
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// checkDependencies checks that the jobs a new job depends on are the user's and have not failed or been
+// canceled (the new job would fail right away).
+func (s *JobService) checkDependencies(ctx context.Context, ids []uuid.UUID, userID uuid.UUID) error {
+	for _, id := range ids {
+		parent, err := s.jobRepo.GetByID(ctx, id)
+		if err != nil || parent == nil || parent.UserID != userID {
+			return fmt.Errorf("depends_on job %s not found", id.String())
+		}
+		if parent.Status == models.JobStatusFailed || parent.Status == models.JobStatusCanceled {
+			return fmt.Errorf("depends_on job %s is %s", id.String(), parent.Status)
+		}
+	}
+	return nil
+}
+
+// requeueDependents publishes the queued jobs depending on a job that has just succeeded (reviewer
+// approval), so the worker starts those whose dependencies have all succeeded.
+func (s *JobService) requeueDependents(ctx context.Context, jobID uuid.UUID) {
+	ids, err := s.jobRepo.ListQueuedDependents(ctx, jobID)
+	if err != nil {
+		log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to list dependent jobs")
+		return
+	}
+	for _, id := range ids {
+		s.publishJob(ctx, id)
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// ErrInvalidDisclaimer wraps the reasons a disclaimer cannot be saved
+var ErrInvalidDisclaimer = errors.New("invalid disclaimer")
+
+// maxDisclaimerLen limits disclaimer texts, which are narrated at the end of every financial job
+const maxDisclaimerLen = 2000
+
+// jurisdictionRe matches normalized jurisdictions: country or region codes such as "US", "EU" or "US-NY"
+var jurisdictionRe = regexp.MustCompile(`^[A-Z0-9-]{2,16}$`)
+
+// DisclaimerService manages the versioned compliance disclaimers appended to financial jobs (admin API).
+type DisclaimerService struct {
+	disclaimerRepo *database.DisclaimerRepository
+}
+
+// NewDisclaimerService creates a new DisclaimerService
+func NewDisclaimerService(disclaimerRepo *database.DisclaimerRepository) *DisclaimerService {
+	return &DisclaimerService{disclaimerRepo: disclaimerRepo}
+}
+
+// SaveDisclaimer saves text as the next version of the jurisdiction's disclaimer; jobs processed from
+// now on get it, jobs already processed keep the version they recorded.
+func (s *DisclaimerService) SaveDisclaimer(ctx context.Context, jurisdiction string, req *models.DisclaimerRequest) (*models.Disclaimer, error) {
+	jurisdiction, err := normalizeJurisdiction(jurisdiction)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDisclaimer, err)
+	}
+	text := strings.TrimSpace(req.Text)
+	if text == "" || len(text) > maxDisclaimerLen {
+		return nil, fmt.Errorf("%w: text must be 1-%d characters", ErrInvalidDisclaimer, maxDisclaimerLen)
+	}
+	d, err := s.disclaimerRepo.Create(ctx, jurisdiction, text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save disclaimer: %w", err)
+	}
+	return d, nil
+}
+
+// ListDisclaimers returns the active disclaimer of each jurisdiction
+func (s *DisclaimerService) ListDisclaimers(ctx context.Context) ([]*models.Disclaimer, error) {
+	disclaimers, err := s.disclaimerRepo.ListActive(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disclaimers: %w", err)
+	}
+	if disclaimers == nil {
+		disclaimers = []*models.Disclaimer{}
+	}
+	return disclaimers, nil
+}
+
+// DisclaimerVersions returns all versions of a jurisdiction's disclaimer, oldest first;
+// database.ErrDisclaimerNotFound when it has none
+func (s *DisclaimerService) DisclaimerVersions(ctx context.Context, jurisdiction string) ([]*models.Disclaimer, error) {
+	jurisdiction, err := normalizeJurisdiction(jurisdiction)
+	if err != nil {
+		return nil, database.ErrDisclaimerNotFound
+	}
+	versions, err := s.disclaimerRepo.ListVersions(ctx, jurisdiction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disclaimer versions: %w", err)
+	}
+	if len(versions) == 0 {
+		return nil, database.ErrDisclaimerNotFound
+	}
+	return versions, nil
+}
+
+// RetireDisclaimer stops appending the jurisdiction's disclaimer (its jobs fall back to DEFAULT);
+// database.ErrDisclaimerNotFound when it has no active version
+func (s *DisclaimerService) RetireDisclaimer(ctx context.Context, jurisdiction string) error {
+	jurisdiction, err := normalizeJurisdiction(jurisdiction)
+	if err != nil {
+		return database.ErrDisclaimerNotFound
+	}
+	return s.disclaimerRepo.Retire(ctx, jurisdiction)
+}
+
+// normalizeJurisdiction upper-cases a jurisdiction and checks it is 2-16 letters, digits or dashes.
+func normalizeJurisdiction(jurisdiction string) (string, error) {
+	j := strings.ToUpper(strings.TrimSpace(jurisdiction))
+	if !jurisdictionRe.MatchString(j) {
+		return "", fmt.Errorf("jurisdiction must be 2-16 letters, digits or dashes (e.g. US, EU, US-NY)")
+	}
+	return j, nil
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+func TestNormalizeJurisdiction(t *testing.T) {
+	tests := []struct {
+		in, want string
+		ok       bool
+	}{
+		{"US", "US", true},
+		{" us-ny ", "US-NY", true},
+		{"default", "DEFAULT", true},
+		{"U", "", false},
+		{"U.S.", "", false},
+		{strings.Repeat("A", 17), "", false},
+	}
+	for _, tt := range tests {
+		got, err := normalizeJurisdiction(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("normalizeJurisdiction(%q) = %q, %v; want %q (ok %v)", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSaveDisclaimer_Validation(t *testing.T) {
+	svc := NewDisclaimerService(nil) // validation fails before the repository is used
+	tests := map[string]struct {
+		jurisdiction, text string
+	}{
+		"bad jurisdiction": {"U.S.", "Not investment advice."},
+		"empty text":       {"US", "  "},
+		"long text":        {"US", strings.Repeat("x", maxDisclaimerLen+1)},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := svc.SaveDisclaimer(context.Background(), tt.jurisdiction, &models.DisclaimerRequest{Text: tt.text})
+			if !errors.Is(err, ErrInvalidDisclaimer) {
+				t.Errorf("err = %v, want ErrInvalidDisclaimer", err)
+			}
+		})
+	}
+}
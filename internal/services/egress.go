@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/egress"
+)
+
+// ErrInvalidEgressAllowlist wraps the reasons an egress allow list is rejected
+var ErrInvalidEgressAllowlist = errors.New("invalid egress allow list")
+
+// SetEgress sets the egress policy and the repository of users' egress allow lists (/v1/egress-allowlist)
+// checked against job and default webhook URLs; without them webhook URLs are not checked when jobs are
+// created (the dispatcher still checks them when delivering).
+func (s *JobService) SetEgress(policy *egress.Policy, r egressAllowlistRepository) {
+	s.egress = policy
+	s.egressRepo = r
+}
+
+// GetEgressAllowlist returns the hosts the user's webhooks and notification sinks may target (empty when
+// unrestricted).
+func (s *JobService) GetEgressAllowlist(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	if s.egressRepo == nil {
+		return nil, fmt.Errorf("egress allow lists are not available")
+	}
+	hosts, err := s.egressRepo.GetEgressAllowlist(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if hosts == nil {
+		hosts = []string{}
+	}
+	return hosts, nil
+}
+
+// UpdateEgressAllowlist replaces the user's egress allow list (an empty list lifts the restriction).
+// Hosts are lowercased and deduplicated; each also allows its subdomains.
+func (s *JobService) UpdateEgressAllowlist(ctx context.Context, userID uuid.UUID, hosts []string) ([]string, error) {
+	if s.egressRepo == nil {
+		return nil, fmt.Errorf("egress allow lists are not available")
+	}
+	if err := egress.ValidateHosts(hosts); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEgressAllowlist, err)
+	}
+	hosts = egress.NormalizeHosts(hosts)
+	if err := s.egressRepo.SetEgressAllowlist(ctx, userID, hosts); err != nil {
+		return nil, err
+	}
+	if hosts == nil {
+		hosts = []string{}
+	}
+	return hosts, nil
+}
+
+// checkWebhookURL checks a webhook URL of the user against the egress policy and their allow list. Refused
+// URLs wrap egress.ErrDenied.
+func (s *JobService) checkWebhookURL(ctx context.Context, userID uuid.UUID, url string) error {
+	return checkDestination(ctx, s.egress, s.egressRepo, userID, url)
+}
+
+// checkDestination checks url against policy and the user's allow list (nothing when policy is nil).
+func checkDestination(ctx context.Context, policy *egress.Policy, r egressAllowlistRepository, userID uuid.UUID, url string) error {
+	if policy == nil {
+		return nil
+	}
+	var allowlist []string
+	if r != nil {
+		var err error
+		if allowlist, err = r.GetEgressAllowlist(ctx, userID); err != nil {
+			return fmt.Errorf("failed to get egress allow list: %w", err)
+		}
+	}
+	return policy.CheckURL(url, allowlist)
+}
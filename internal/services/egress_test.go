@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/config"
+	"github.com/snappy-loop/stories/internal/egress"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// fakeEgressRepo holds egress allow lists by user ID
+type fakeEgressRepo map[uuid.UUID][]string
+
+func (f fakeEgressRepo) GetEgressAllowlist(_ context.Context, userID uuid.UUID) ([]string, error) {
+	return f[userID], nil
+}
+
+func (f fakeEgressRepo) SetEgressAllowlist(_ context.Context, userID uuid.UUID, hosts []string) error {
+	f[userID] = hosts
+	return nil
+}
+
+func TestCreateJob_Egress(t *testing.T) {
+	cfg := &config.Config{MaxFilesPerJob: 10, MaxInputLength: 50000, MaxSegmentsCount: 5}
+	userID := uuid.New()
+	apiKey := &models.APIKey{ID: uuid.New(), UserID: userID, QuotaChars: 100000, PeriodStartedAt: time.Now(), QuotaPeriod: "monthly"}
+	svc := NewJobService(newFakeJobRepo(), fakeSegmentRepo{}, fakeAssetRepo{}, fakeJobFileRepo{}, newFakeFileRepo(),
+		fakeFactCheckRepo{}, nil, newFakeAPIKeyRepo(apiKey), noopJobPublisher{}, cfg)
+	svc.SetUserSettings(fakeSettingsRepo{})
+	svc.SetEgress(egress.NewPolicy(nil, []string{"blocked.example.net"}, false), fakeEgressRepo{})
+	ctx := context.Background()
+
+	create := func(url string) error {
+		req := &models.CreateJobRequest{Text: "Hello.", Type: "educational", SegmentsCount: 1, AudioType: "free_speech",
+			Webhook: &models.WebhookConfig{URL: url}}
+		_, err := svc.CreateJob(ctx, req, userID, apiKey.ID)
+		return err
+	}
+	for _, url := range []string{"http://127.0.0.1:8080/hook", "http://169.254.169.254/latest", "https://blocked.example.net/hook"} {
+		if err := create(url); err == nil || !errors.Is(err, egress.ErrDenied) || !strings.HasPrefix(err.Error(), "validation error") {
+			t.Errorf("webhook %s: err = %v, want a validation error wrapping egress.ErrDenied", url, err)
+		}
+	}
+	if err := create("https://hooks.example.com/x"); err != nil {
+		t.Fatalf("public webhook: %v", err)
+	}
+	createWithTool := func(url string) error {
+		req := &models.CreateJobRequest{Text: "Hello.", Type: "educational", SegmentsCount: 1, AudioType: "free_speech",
+			ExternalTool: &models.ExternalTool{ServerURL: url, Tool: "check"}}
+		_, err := svc.CreateJob(ctx, req, userID, apiKey.ID)
+		return err
+	}
+	for _, url := range []string{"http://127.0.0.1:8080/mcp", "http://[::ffff:169.254.169.254]/latest", "https://mcp.blocked.example.net"} {
+		if err := createWithTool(url); err == nil || !errors.Is(err, egress.ErrDenied) || !strings.HasPrefix(err.Error(), "validation error") {
+			t.Errorf("external tool %s: err = %v, want a validation error wrapping egress.ErrDenied", url, err)
+		}
+	}
+	if err := createWithTool("https://mcp.example.com"); err != nil {
+		t.Fatalf("public external tool: %v", err)
+	}
+
+	hosts, err := svc.UpdateEgressAllowlist(ctx, userID, []string{"Example.org.", "example.org"})
+	if err != nil {
+		t.Fatalf("UpdateEgressAllowlist: %v", err)
+	}
+	if !slices.Equal(hosts, []string{"example.org"}) {
+		t.Errorf("hosts = %v, want [example.org]", hosts)
+	}
+	if err := create("https://hooks.example.com/x"); !errors.Is(err, egress.ErrDenied) {
+		t.Errorf("webhook outside the allow list: err = %v, want egress.ErrDenied", err)
+	}
+	if err := create("https://hooks.example.org/x"); err != nil {
+		t.Errorf("webhook in the allow list: %v", err)
+	}
+	if err := createWithTool("https://mcp.example.com"); !errors.Is(err, egress.ErrDenied) {
+		t.Errorf("external tool outside the allow list: err = %v, want egress.ErrDenied", err)
+	}
+	settings := &models.UserSettings{Webhook: &models.WebhookConfig{URL: "https://hooks.example.com/x"}}
+	if _, err := svc.UpdateSettings(ctx, userID, settings); !errors.Is(err, ErrInvalidSettings) {
+		t.Errorf("default webhook outside the allow list: err = %v, want ErrInvalidSettings", err)
+	}
+
+	if _, err := svc.UpdateEgressAllowlist(ctx, userID, []string{"https://example.org"}); !errors.Is(err, ErrInvalidEgressAllowlist) {
+		t.Errorf("URL as host: err = %v, want ErrInvalidEgressAllowlist", err)
+	}
+	if hosts, err := svc.UpdateEgressAllowlist(ctx, userID, nil); err != nil || len(hosts) != 0 {
+		t.Errorf("clearing the allow list = %v, %v", hosts, err)
+	}
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// Limits of a job's external tool
+const (
+	maxExternalToolArgumentsBytes = 16 * 1024
+	maxExternalToolURLLen         = 2048
+)
+
+// toolNameRe matches MCP tool names (letters, digits, underscores, dashes and dots, at most 128)
+var toolNameRe = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,128}$`)
+
+// externalToolReservedArgs are the arguments the worker sets for each segment.
+var externalToolReservedArgs = []string{"text", "title", "segment_idx", "input_type"}
+
+// validateExternalTool checks a job's external MCP tool: an absolute http(s) server URL, a valid tool
+// name and small fixed arguments that don't collide with the per-segment ones. The URL is checked against
+// the egress policy by checkExternalToolURL.
+func validateExternalTool(tool *models.ExternalTool) error {
+	if tool == nil {
+		return nil
+	}
+	u, err := url.Parse(tool.ServerURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(tool.ServerURL) > maxExternalToolURLLen {
+		return fmt.Errorf("invalid external_tool.server_url: must be an absolute http or https URL")
+	}
+	if !toolNameRe.MatchString(tool.Tool) {
+		return fmt.Errorf("invalid external_tool.tool: must be 1-128 letters, digits, '_', '-' or '.'")
+	}
+	for _, name := range externalToolReservedArgs {
+		if _, ok := tool.Arguments[name]; ok {
+			return fmt.Errorf("invalid external_tool.arguments: %q is set by the worker for each segment", name)
+		}
+	}
+	if b, err := json.Marshal(tool.Arguments); err != nil || len(b) > maxExternalToolArgumentsBytes {
+		return fmt.Errorf("invalid external_tool.arguments: must be a JSON object of at most %d bytes", maxExternalToolArgumentsBytes)
+	}
+	return nil
+}
+
+// checkExternalToolURL checks the server URL of a job's external tool against the egress policy and the
+// user's allow list, like webhook URLs (the worker checks it again before each call). Refused URLs wrap
+// egress.ErrDenied.
+func (s *JobService) checkExternalToolURL(ctx context.Context, userID uuid.UUID, tool *models.ExternalTool) error {
+	if tool == nil {
+		return nil
+	}
+	return checkDestination(ctx, s.egress, s.egressRepo, userID, tool.ServerURL)
+}
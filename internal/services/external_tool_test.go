@@ -0,0 +1,43 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+func TestValidateExternalTool(t *testing.T) {
+	valid := &models.ExternalTool{
+		ServerURL: "https://terms.example.com/mcp",
+		Tool:      "check_terminology",
+		Arguments: map[string]any{"glossary": "retail-banking"},
+	}
+	if err := validateExternalTool(valid); err != nil {
+		t.Errorf("valid tool: %v", err)
+	}
+	if err := validateExternalTool(nil); err != nil {
+		t.Errorf("no tool: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		tool models.ExternalTool
+		want string
+	}{
+		{"relative url", models.ExternalTool{ServerURL: "/mcp", Tool: "check"}, "server_url"},
+		{"ftp url", models.ExternalTool{ServerURL: "ftp://terms.example.com", Tool: "check"}, "server_url"},
+		{"empty tool", models.ExternalTool{ServerURL: "https://terms.example.com"}, "external_tool.tool"},
+		{"tool with spaces", models.ExternalTool{ServerURL: "https://terms.example.com", Tool: "check terms"}, "external_tool.tool"},
+		{"reserved argument", models.ExternalTool{ServerURL: "https://terms.example.com", Tool: "check", Arguments: map[string]any{"text": "x"}}, `"text"`},
+		{"large arguments", models.ExternalTool{ServerURL: "https://terms.example.com", Tool: "check", Arguments: map[string]any{"glossary": strings.Repeat("x", maxExternalToolArgumentsBytes)}}, "at most"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateExternalTool(&tt.tool)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want error containing %s", err, tt.want)
+			}
+		})
+	}
+}
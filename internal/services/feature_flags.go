@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/features"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// ErrInvalidFeatureFlag wraps the reasons a feature flag change is refused
+var ErrInvalidFeatureFlag = errors.New("invalid feature flag")
+
+// FeatureFlagService manages the feature flag rollouts and per-user overrides (admin API).
+type FeatureFlagService struct {
+	flagRepo *database.FeatureFlagRepository
+	flags    *features.Flags
+}
+
+// NewFeatureFlagService creates a new FeatureFlagService; flags is invalidated on every change so this
+// replica applies it at once (the others within FEATURE_FLAGS_REFRESH).
+func NewFeatureFlagService(flagRepo *database.FeatureFlagRepository, flags *features.Flags) *FeatureFlagService {
+	return &FeatureFlagService{flagRepo: flagRepo, flags: flags}
+}
+
+// ListFeatureFlags returns every known, configured or admin-set flag with its effective rollout and
+// overrides, by name
+func (s *FeatureFlagService) ListFeatureFlags(ctx context.Context) ([]*models.FeatureFlag, error) {
+	adminSet, err := s.flagRepo.ListRollouts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feature flags: %w", err)
+	}
+	overrides, err := s.flagRepo.ListOverrides(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feature flag overrides: %w", err)
+	}
+	s.flags.Invalidate()
+
+	byName := make(map[string]*models.FeatureFlag)
+	for name, percent := range s.flags.Rollouts(ctx) {
+		byName[name] = &models.FeatureFlag{Name: name, RolloutPercent: percent, Source: s.flags.Source(ctx, name)}
+	}
+	for _, f := range adminSet {
+		byName[f.Name] = f
+	}
+	for _, o := range overrides {
+		f := byName[o.FlagName]
+		if f == nil {
+			// Overridden for some users only: off for everyone else
+			f = &models.FeatureFlag{Name: o.FlagName, Source: "default"}
+			byName[o.FlagName] = f
+		}
+		f.Overrides = append(f.Overrides, o)
+	}
+
+	out := make([]*models.FeatureFlag, 0, len(byName))
+	for _, f := range byName {
+		out = append(out, f)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// SetRollout sets the share of users a flag is enabled for, replacing its FEATURE_FLAGS rollout
+func (s *FeatureFlagService) SetRollout(ctx context.Context, name string, req *models.FeatureFlagRequest) error {
+	if !features.ValidName(name) {
+		return fmt.Errorf("%w: name must be lower-case letters, digits and underscores", ErrInvalidFeatureFlag)
+	}
+	if req.RolloutPercent == nil || *req.RolloutPercent < 0 || *req.RolloutPercent > 100 {
+		return fmt.Errorf("%w: rollout_percent must be 0-100", ErrInvalidFeatureFlag)
+	}
+	if err := s.flagRepo.SetRollout(ctx, name, *req.RolloutPercent); err != nil {
+		return fmt.Errorf("failed to save feature flag: %w", err)
+	}
+	s.flags.Invalidate()
+	return nil
+}
+
+// ResetRollout removes the admin-set rollout of a flag, so FEATURE_FLAGS or its default applies again;
+// database.ErrFeatureFlagNotFound when it has none
+func (s *FeatureFlagService) ResetRollout(ctx context.Context, name string) error {
+	if err := s.flagRepo.DeleteRollout(ctx, name); err != nil {
+		return err
+	}
+	s.flags.Invalidate()
+	return nil
+}
+
+// SetOverride turns a flag on or off for one user whatever its rollout;
+// database.ErrFeatureFlagUserNotFound when the user does not exist
+func (s *FeatureFlagService) SetOverride(ctx context.Context, name string, userID uuid.UUID, req *models.FeatureFlagOverrideRequest) error {
+	if !features.ValidName(name) {
+		return fmt.Errorf("%w: name must be lower-case letters, digits and underscores", ErrInvalidFeatureFlag)
+	}
+	if req.Enabled == nil {
+		return fmt.Errorf("%w: enabled is required", ErrInvalidFeatureFlag)
+	}
+	if err := s.flagRepo.SetOverride(ctx, name, userID, *req.Enabled); err != nil {
+		return err
+	}
+	s.flags.Invalidate()
+	return nil
+}
+
+// DeleteOverride removes a user's override of a flag; database.ErrFeatureFlagNotFound when there is none
+func (s *FeatureFlagService) DeleteOverride(ctx context.Context, name string, userID uuid.UUID) error {
+	if err := s.flagRepo.DeleteOverride(ctx, name, userID); err != nil {
+		return err
+	}
+	s.flags.Invalidate()
+	return nil
+}
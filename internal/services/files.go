@@ -3,9 +3,12 @@ package services
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -26,11 +29,24 @@ var allowedMimeTypes = map[string]bool{
 	"application/pdf":  true,
 }
 
+// languageTagRe loosely matches BCP 47 language tags ("de", "pt-BR", "zh-Hant")
+var languageTagRe = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// validateExtractionOptions checks per-file extraction options; nil is valid.
+func validateExtractionOptions(o *models.ExtractionOptions) error {
+	if o == nil || o.Language == "" {
+		return nil
+	}
+	if len(o.Language) > 35 || !languageTagRe.MatchString(o.Language) {
+		return fmt.Errorf("invalid language %q: use a BCP 47 tag such as \"de\" or \"pt-BR\"", o.Language)
+	}
+	return nil
+}
+
 // FileService handles file upload and management
 type FileService struct {
 	fileRepo   *database.FileRepository
 	storage    *storage.Client
-	bucket     string
 	config     *config.Config
 }
 
@@ -38,23 +54,28 @@ type FileService struct {
 func NewFileService(
 	fileRepo *database.FileRepository,
 	storage *storage.Client,
-	bucket string,
 	cfg *config.Config,
 ) *FileService {
 	return &FileService{
 		fileRepo: fileRepo,
 		storage:  storage,
-		bucket:   bucket,
 		config:   cfg,
 	}
 }
 
 // UploadFile uploads a file to S3 and creates a file record.
 // Size is enforced by limiting the stream to MaxFileSize; the actual bytes read are recorded (client-reported size is ignored).
-func (s *FileService) UploadFile(ctx context.Context, userID uuid.UUID, filename, mimeType string, data io.Reader) (*models.UploadFileResponse, error) {
+// opts are the file's default extraction options (nil for none).
+func (s *FileService) UploadFile(ctx context.Context, userID uuid.UUID, filename, mimeType string, data io.Reader, opts *models.ExtractionOptions) (*models.UploadFileResponse, error) {
 	if !allowedMimeTypes[mimeType] {
 		return nil, fmt.Errorf("unsupported mime type: %s", mimeType)
 	}
+	if err := validateExtractionOptions(opts); err != nil {
+		return nil, err
+	}
+	if opts.IsZero() {
+		opts = nil
+	}
 
 	// Sanitize filename
 	filename = filepath.Base(filename)
@@ -79,7 +100,8 @@ func (s *FileService) UploadFile(ctx context.Context, userID uuid.UUID, filename
 	expiresAt := time.Now().Add(time.Duration(s.config.FileExpirationHrs) * time.Hour)
 	s3Key := fmt.Sprintf("files/%s/%s", userID.String(), fileID.String()+getExtension(filename, mimeType))
 
-	if err := s.storage.Upload(ctx, s3Key, bytes.NewReader(buf.Bytes()), mimeType, actualSize); err != nil {
+	bucket, err := s.storage.Upload(ctx, s3Key, bytes.NewReader(buf.Bytes()), mimeType, actualSize)
+	if err != nil {
 		return nil, fmt.Errorf("failed to upload to storage: %w", err)
 	}
 
@@ -89,15 +111,17 @@ func (s *FileService) UploadFile(ctx context.Context, userID uuid.UUID, filename
 		Filename:  filename,
 		MimeType:  mimeType,
 		SizeBytes: actualSize,
-		S3Bucket:  s.bucket,
+		S3Bucket:  bucket,
 		S3Key:     s3Key,
 		Status:    "ready",
 		ExpiresAt: expiresAt,
 		CreatedAt: time.Now(),
+
+		ExtractionOptions: opts,
 	}
 
 	if err := s.fileRepo.Create(ctx, file); err != nil {
-		_ = s.storage.Delete(ctx, s3Key)
+		_ = s.storage.Delete(ctx, bucket, s3Key)
 		return nil, fmt.Errorf("failed to create file record: %w", err)
 	}
 
@@ -108,30 +132,220 @@ func (s *FileService) UploadFile(ctx context.Context, userID uuid.UUID, filename
 		Int64("size", actualSize).
 		Msg("File uploaded")
 
+	return uploadFileResponse(file), nil
+}
+
+func uploadFileResponse(file *models.File) *models.UploadFileResponse {
 	return &models.UploadFileResponse{
 		FileID:    file.ID,
 		Filename:  file.Filename,
 		MimeType:  file.MimeType,
 		SizeBytes: file.SizeBytes,
 		ExpiresAt: file.ExpiresAt,
+
+		ExtractionOptions: file.ExtractionOptions,
+	}
+}
+
+var (
+	// ErrFileNotUploaded is returned by ConfirmUpload while the object of a presigned upload is missing or
+	// being replaced.
+	ErrFileNotUploaded = errors.New("file has not been uploaded yet")
+	// ErrFileUploadFailed is returned by ConfirmUpload for a file whose uploaded object failed verification.
+	ErrFileUploadFailed = errors.New("file upload failed verification; request a new upload URL")
+)
+
+// uploadStagingPrefix is the key prefix of presigned uploads until ConfirmUpload copies them under files/
+const uploadStagingPrefix = "uploads/"
+
+// PresignUpload creates a pending file and a presigned PUT that uploads it straight to S3, so large files
+// do not pass through the API. The file can be used by jobs once ConfirmUpload has verified the object.
+func (s *FileService) PresignUpload(ctx context.Context, userID uuid.UUID, req *models.PresignFileRequest) (*models.PresignFileResponse, error) {
+	if !allowedMimeTypes[req.MimeType] {
+		return nil, fmt.Errorf("validation error: unsupported mime type: %s", req.MimeType)
+	}
+	if req.SizeBytes <= 0 {
+		return nil, fmt.Errorf("validation error: size_bytes is required")
+	}
+	if req.SizeBytes > s.config.MaxFileSize {
+		return nil, fmt.Errorf("validation error: file size exceeds maximum of %d bytes", s.config.MaxFileSize)
+	}
+	opts := &req.ExtractionOptions
+	if err := validateExtractionOptions(opts); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	if opts.IsZero() {
+		opts = nil
+	}
+	filename := filepath.Base(req.Filename)
+	if filename == "" || filename == "." || filename == "/" {
+		filename = "upload"
+	}
+
+	now := time.Now()
+	fileID := uuid.New()
+	// The client uploads to a staging key; ConfirmUpload copies the checked object to the file's key, so
+	// the presigned URL, valid until it expires, cannot replace the file afterwards.
+	s3Key := uploadStagingPrefix + fmt.Sprintf("%s/%s", userID.String(), fileID.String()+getExtension(filename, req.MimeType))
+	upload, err := s.storage.PresignUpload(ctx, s3Key, req.MimeType, req.SizeBytes, s.config.FileUploadURLExpiry)
+	if err != nil {
+		return nil, err
+	}
+	file := &models.File{
+		ID:        fileID,
+		UserID:    userID,
+		Filename:  filename,
+		MimeType:  req.MimeType,
+		SizeBytes: req.SizeBytes,
+		S3Bucket:  s.storage.Bucket(),
+		S3Key:     s3Key,
+		Status:    "pending",
+		ExpiresAt: now.Add(time.Duration(s.config.FileExpirationHrs) * time.Hour),
+		CreatedAt: now,
+
+		ExtractionOptions: opts,
+	}
+	if err := s.fileRepo.Create(ctx, file); err != nil {
+		return nil, fmt.Errorf("failed to create file record: %w", err)
+	}
+
+	return &models.PresignFileResponse{
+		FileID:          file.ID,
+		UploadURL:       upload.URL,
+		UploadMethod:    http.MethodPut,
+		UploadHeaders:   upload.Headers,
+		UploadExpiresAt: now.Add(s.config.FileUploadURLExpiry),
+		Filename:        file.Filename,
+		MimeType:        file.MimeType,
+		SizeBytes:       file.SizeBytes,
+		ExpiresAt:       file.ExpiresAt,
+
+		ExtractionOptions: file.ExtractionOptions,
 	}, nil
 }
 
-// ListFiles returns files for a user, optionally filtered by status
-func (s *FileService) ListFiles(ctx context.Context, userID uuid.UUID, status string) ([]*models.File, error) {
-	return s.fileRepo.ListByUser(ctx, userID, status)
+// ConfirmUpload verifies the object of a pending file created by PresignUpload (it exists with the declared
+// size and type), copies it from the staging key to the file's key (only if it is still the object checked)
+// and marks the file ready. Confirming a ready file again returns it. A mismatching object
+// is deleted and the file marked failed.
+func (s *FileService) ConfirmUpload(ctx context.Context, fileID, userID uuid.UUID) (*models.UploadFileResponse, error) {
+	file, err := s.fileRepo.GetByIDAndUser(ctx, fileID, userID)
+	if err != nil {
+		return nil, err
+	}
+	switch file.Status {
+	case "ready":
+		return uploadFileResponse(file), nil
+	case "pending":
+	default:
+		return nil, ErrFileUploadFailed
+	}
+
+	info, err := s.storage.HeadObject(ctx, file.S3Bucket, file.S3Key)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil, ErrFileNotUploaded
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.Size != file.SizeBytes || !strings.EqualFold(info.ContentType, file.MimeType) {
+		log.Warn().
+			Str("file_id", file.ID.String()).
+			Int64("size", info.Size).
+			Str("content_type", info.ContentType).
+			Msg("Uploaded object does not match the declared file")
+		if err := s.fileRepo.MarkUploaded(ctx, file.ID, "failed"); err != nil {
+			return nil, err
+		}
+		if err := s.storage.Delete(ctx, file.S3Bucket, file.S3Key); err != nil {
+			log.Warn().Err(err).Str("key", file.S3Key).Msg("Failed to delete rejected upload from S3")
+		}
+		return nil, ErrFileUploadFailed
+	}
+	key := file.S3Key
+	if strings.HasPrefix(key, uploadStagingPrefix) {
+		key = "files/" + strings.TrimPrefix(key, uploadStagingPrefix)
+		err := s.storage.CopyObject(ctx, file.S3Key, key, info.ETag)
+		if errors.Is(err, storage.ErrObjectChanged) {
+			return nil, ErrFileNotUploaded
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := s.fileRepo.MarkUploadedAt(ctx, file.ID, key); err != nil {
+		return nil, err
+	}
+	if key != file.S3Key {
+		if err := s.storage.Delete(ctx, file.S3Bucket, file.S3Key); err != nil {
+			log.Warn().Err(err).Str("key", file.S3Key).Msg("Failed to delete staged upload from S3")
+		}
+	}
+	file.Status = "ready"
+	file.S3Key = key
+
+	log.Info().
+		Str("file_id", file.ID.String()).
+		Str("user_id", userID.String()).
+		Int64("size", file.SizeBytes).
+		Msg("File uploaded directly to S3")
+
+	return uploadFileResponse(file), nil
+}
+
+// ErrFileInUse is returned by DeleteFile for a file that a job which has not finished reads.
+var ErrFileInUse = errors.New("file is used by a job that has not finished; cancel the job or wait for it to finish")
+
+// ListFiles returns a page of a user's files, newest first, optionally filtered by status. limit is 1-100
+// (default 20); cursor is the previous page's NextCursor.
+func (s *FileService) ListFiles(ctx context.Context, userID uuid.UUID, status string, limit int, cursor string) (*models.FilePage, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	var afterCreatedAt *time.Time
+	afterID := uuid.Nil
+	if cursor != "" {
+		t, id, err := decodeTimeCursor(cursor)
+		if err != nil {
+			return nil, fmt.Errorf("validation error: %w", err)
+		}
+		afterCreatedAt, afterID = &t, id
+	}
+	files, err := s.fileRepo.ListByUser(ctx, userID, status, limit+1, afterCreatedAt, afterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	page := &models.FilePage{}
+	if len(files) > limit {
+		files = files[:limit]
+		last := files[limit-1]
+		page.HasMore = true
+		page.NextCursor = encodeTimeCursor(last.CreatedAt, last.ID)
+	}
+	page.Files = make([]models.FileInResponse, len(files))
+	for i, f := range files {
+		page.Files[i] = f.ToInResponse()
+	}
+	return page, nil
 }
 
-// DeleteFile deletes a file (S3 object and DB record) if owned by user
+// DeleteFile deletes a file (DB record, then S3 object) if owned by user and no job that has not finished
+// reads it (ErrFileInUse).
 func (s *FileService) DeleteFile(ctx context.Context, fileID, userID uuid.UUID) error {
 	file, err := s.fileRepo.GetByIDAndUser(ctx, fileID, userID)
 	if err != nil {
 		return err
 	}
-	if err := s.storage.Delete(ctx, file.S3Key); err != nil {
+	if err := s.fileRepo.DeleteByIDAndUser(ctx, fileID, userID); err != nil {
+		if errors.Is(err, database.ErrFileInUse) {
+			return ErrFileInUse
+		}
+		return err
+	}
+	if err := s.storage.Delete(ctx, file.S3Bucket, file.S3Key); err != nil {
 		log.Warn().Err(err).Str("key", file.S3Key).Msg("Failed to delete file from S3")
 	}
-	return s.fileRepo.DeleteByIDAndUser(ctx, fileID, userID)
+	return nil
 }
 
 // GetFileByIDAndUser returns a file if owned by user (for handlers)
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/config"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/maintenance"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/storage"
+)
+
+// ErrIngestRejected wraps the reasons an ingested object cannot become a job (unsupported type, too
+// large, disabled API key, invalid job defaults); such objects are recorded as failed and left in place.
+var ErrIngestRejected = errors.New("object rejected")
+
+// ingestBatch is the maximum number of objects listed per poll
+const ingestBatch = 100
+
+// IngestService turns objects dropped under the S3 ingestion prefix into a file and a job with the
+// configured defaults. Objects are found by polling the prefix or pushed by S3 event notifications;
+// each (key, etag) is ingested once and deleted from the prefix once its job is created.
+type IngestService struct {
+	repo        *database.IngestRepository
+	apiKeyRepo  *database.APIKeyRepository
+	files       *FileService
+	jobs        *JobService
+	storage     *storage.Client
+	config      *config.Config
+	routes      map[string]uuid.UUID // folder -> API key ID
+	maintenance *maintenance.Switch  // polls are skipped while it is on
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewIngestService creates an IngestService; it fails when INGEST_ROUTES is invalid.
+func NewIngestService(db *database.DB, files *FileService, jobs *JobService, storageClient *storage.Client, cfg *config.Config) (*IngestService, error) {
+	routes, err := ParseIngestRoutes(cfg.IngestRoutes)
+	if err != nil {
+		return nil, err
+	}
+	return &IngestService{
+		repo:       database.NewIngestRepository(db),
+		apiKeyRepo: database.NewAPIKeyRepository(db),
+		files:      files,
+		jobs:       jobs,
+		storage:    storageClient,
+		config:     cfg,
+		routes:     routes,
+		stopChan:   make(chan struct{}),
+	}, nil
+}
+
+// ParseIngestRoutes parses INGEST_ROUTES ("folder=<api key id>,...") into a map of folder to API key ID.
+func ParseIngestRoutes(spec string) (map[string]uuid.UUID, error) {
+	routes := make(map[string]uuid.UUID)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		folder, id, ok := strings.Cut(entry, "=")
+		folder = strings.TrimSpace(folder)
+		if !ok || folder == "" || strings.Contains(folder, "/") {
+			return nil, fmt.Errorf("invalid ingest route %q: want folder=<api key id>", entry)
+		}
+		apiKeyID, err := uuid.Parse(strings.TrimSpace(id))
+		if err != nil {
+			return nil, fmt.Errorf("invalid ingest route %q: %w", entry, err)
+		}
+		if _, dup := routes[folder]; dup {
+			return nil, fmt.Errorf("duplicate ingest route for folder %q", folder)
+		}
+		routes[folder] = apiKeyID
+	}
+	return routes, nil
+}
+
+// splitIngestKey splits <prefix><folder>/<path> into the folder and the file name (the last element of
+// path). ok is false for keys outside the prefix and for folder markers.
+func splitIngestKey(prefix, key string) (folder, name string, ok bool) {
+	rest, found := strings.CutPrefix(key, prefix)
+	if !found || strings.HasSuffix(rest, "/") {
+		return "", "", false
+	}
+	folder, file, found := strings.Cut(rest, "/")
+	if !found || folder == "" || file == "" {
+		return "", "", false
+	}
+	return folder, path.Base(file), true
+}
+
+// ingestMimeType returns the MIME type of an ingested file by its extension; "" when it is not an
+// allowed upload type.
+func ingestMimeType(name string) string {
+	mimeType, _, _ := strings.Cut(mime.TypeByExtension(strings.ToLower(path.Ext(name))), ";")
+	if !allowedMimeTypes[mimeType] {
+		return ""
+	}
+	return mimeType
+}
+
+// Start polls the ingestion prefix in the background until ctx is cancelled or Stop is called. It does
+// nothing when the poll interval is zero (ingestion then relies on S3 event notifications).
+func (s *IngestService) Start(ctx context.Context) {
+	interval := s.config.IngestPollInterval
+	if interval <= 0 {
+		log.Info().Msg("S3 ingestion polling disabled")
+		return
+	}
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		log.Info().
+			Str("prefix", s.config.IngestPrefix).
+			Int("routes", len(s.routes)).
+			Dur("interval", interval).
+			Msg("S3 ingestion started")
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.stopChan:
+				log.Info().Msg("S3 ingestion stopped")
+				return
+			case <-ticker.C:
+				if s.maintenance.Enabled(ctx) {
+					continue
+				}
+				s.Poll(ctx)
+			}
+		}
+	}()
+}
+
+// SetMaintenance pauses polling while maintenance mode is on; dropped objects wait in the prefix.
+func (s *IngestService) SetMaintenance(m *maintenance.Switch) {
+	s.maintenance = m
+}
+
+// Stop stops polling. Safe to call multiple times.
+func (s *IngestService) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+	})
+}
+
+// Poll lists the ingestion prefix once and ingests the objects found.
+func (s *IngestService) Poll(ctx context.Context) {
+	objects, err := s.storage.ListObjects(ctx, s.config.IngestPrefix, ingestBatch)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list S3 ingestion prefix")
+		return
+	}
+	for _, obj := range objects {
+		if err := s.Ingest(ctx, obj.Key, obj.ETag, obj.Size); err != nil {
+			log.Error().Err(err).Str("key", obj.Key).Msg("Failed to ingest object")
+		}
+	}
+}
+
+// HandleS3Event ingests the objects created in an S3 event notification, skipping other events and
+// buckets. It returns how many objects were ingested and the transient failures (to be redelivered).
+func (s *IngestService) HandleS3Event(ctx context.Context, ev *models.S3EventNotification) (int, error) {
+	var errs []error
+	ingested := 0
+	for _, rec := range ev.Records {
+		if !strings.Contains(rec.EventName, "ObjectCreated:") || rec.S3.Bucket.Name != s.config.S3Bucket {
+			continue
+		}
+		key, err := url.QueryUnescape(rec.S3.Object.Key)
+		if err != nil {
+			log.Warn().Err(err).Str("key", rec.S3.Object.Key).Msg("Invalid object key in S3 event")
+			continue
+		}
+		if err := s.Ingest(ctx, key, strings.Trim(rec.S3.Object.ETag, `"`), rec.S3.Object.Size); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		ingested++
+	}
+	return ingested, errors.Join(errs...)
+}
+
+// Ingest creates a file and a job from the object at key. Objects outside the prefix, in unrouted
+// folders or already claimed are skipped; rejected objects are recorded as failed. Other errors
+// release the claim and are returned, so the object is retried.
+func (s *IngestService) Ingest(ctx context.Context, key, etag string, size int64) error {
+	folder, name, ok := splitIngestKey(s.config.IngestPrefix, key)
+	if !ok {
+		return nil
+	}
+	apiKeyID, ok := s.routes[folder]
+	if !ok {
+		log.Debug().Str("key", key).Str("folder", folder).Msg("No ingest route for folder, skipping object")
+		return nil
+	}
+
+	obj := &models.IngestedObject{
+		ID:        uuid.New(),
+		S3Bucket:  s.config.S3Bucket,
+		S3Key:     key,
+		ETag:      etag,
+		APIKeyID:  apiKeyID,
+		Status:    "processing",
+		CreatedAt: time.Now(),
+	}
+	claimed, err := s.repo.Claim(ctx, obj)
+	if err != nil {
+		return fmt.Errorf("failed to claim object: %w", err)
+	}
+	if !claimed {
+		return nil
+	}
+
+	err = s.ingest(ctx, obj, name, size)
+	if errors.Is(err, ErrIngestRejected) {
+		msg := err.Error()
+		obj.Status = "failed"
+		obj.Error = &msg
+		log.Warn().Err(err).Str("key", key).Msg("Ingested object rejected")
+		return s.repo.Update(ctx, obj)
+	}
+	if err != nil {
+		if relErr := s.repo.Release(ctx, obj.ID); relErr != nil {
+			log.Error().Err(relErr).Str("key", key).Msg("Failed to release ingest claim")
+		}
+		return err
+	}
+
+	obj.Status = "ingested"
+	if err := s.repo.Update(ctx, obj); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Failed to record ingested object")
+	}
+	if err := s.storage.Delete(ctx, s.config.S3Bucket, key); err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Failed to delete ingested object")
+	}
+	log.Info().
+		Str("key", key).
+		Str("file_id", obj.FileID.String()).
+		Str("job_id", obj.JobID.String()).
+		Msg("Object ingested")
+	return nil
+}
+
+// ingest uploads the object as a file of the route's user and creates a job with the ingest defaults.
+func (s *IngestService) ingest(ctx context.Context, obj *models.IngestedObject, name string, size int64) error {
+	apiKey, err := s.apiKeyRepo.GetByID(ctx, obj.APIKeyID)
+	if err != nil {
+		return fmt.Errorf("failed to get API key %s: %w", obj.APIKeyID, err)
+	}
+	if apiKey.Status != "active" {
+		return fmt.Errorf("%w: API key %s is %s", ErrIngestRejected, apiKey.ID, apiKey.Status)
+	}
+	mimeType := ingestMimeType(name)
+	if mimeType == "" {
+		return fmt.Errorf("%w: unsupported file type %q", ErrIngestRejected, path.Ext(name))
+	}
+	if size > s.config.MaxFileSize {
+		return fmt.Errorf("%w: file size exceeds maximum of %d bytes", ErrIngestRejected, s.config.MaxFileSize)
+	}
+
+	body, err := s.storage.GetObject(ctx, obj.S3Bucket, obj.S3Key)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	file, err := s.files.UploadFile(ctx, apiKey.UserID, name, mimeType, body, nil)
+	if err != nil {
+		return err
+	}
+	obj.FileID = &file.FileID
+
+	job, err := s.jobs.CreateJob(ctx, &models.CreateJobRequest{
+		FileIDs:       []uuid.UUID{file.FileID},
+		Type:          s.config.IngestJobType,
+		SegmentsCount: s.config.IngestSegmentsCount,
+		AudioType:     s.config.IngestAudioType,
+	}, apiKey.UserID, apiKey.ID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrIngestRejected, err)
+	}
+	obj.JobID = &job.JobID
+	return nil
+}
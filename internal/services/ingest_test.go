@@ -0,0 +1,68 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestParseIngestRoutes(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	routes, err := ParseIngestRoutes(" marketing=" + a.String() + ", legal = " + b.String() + ",")
+	if err != nil {
+		t.Fatalf("ParseIngestRoutes: %v", err)
+	}
+	if len(routes) != 2 || routes["marketing"] != a || routes["legal"] != b {
+		t.Errorf("routes = %v", routes)
+	}
+
+	if routes, err := ParseIngestRoutes(""); err != nil || len(routes) != 0 {
+		t.Errorf("empty spec = %v, %v; want no routes", routes, err)
+	}
+
+	for spec, want := range map[string]string{
+		"marketing":                            "want folder=<api key id>",
+		"=" + a.String():                       "want folder=<api key id>",
+		"a/b=" + a.String():                    "want folder=<api key id>",
+		"marketing=not-a-uuid":                 "invalid ingest route",
+		"x=" + a.String() + ",x=" + b.String(): "duplicate",
+	} {
+		if _, err := ParseIngestRoutes(spec); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseIngestRoutes(%q) err = %v, want %q", spec, err, want)
+		}
+	}
+}
+
+func TestSplitIngestKey(t *testing.T) {
+	tests := []struct {
+		key          string
+		folder, name string
+		ok           bool
+	}{
+		{"ingest/marketing/brochure.pdf", "marketing", "brochure.pdf", true},
+		{"ingest/marketing/2026/q1/brochure.pdf", "marketing", "brochure.pdf", true},
+		{"ingest/marketing/", "", "", false},
+		{"ingest/brochure.pdf", "", "", false},
+		{"files/u/brochure.pdf", "", "", false},
+	}
+	for _, tt := range tests {
+		folder, name, ok := splitIngestKey("ingest/", tt.key)
+		if folder != tt.folder || name != tt.name || ok != tt.ok {
+			t.Errorf("splitIngestKey(%q) = %q, %q, %v; want %q, %q, %v", tt.key, folder, name, ok, tt.folder, tt.name, tt.ok)
+		}
+	}
+}
+
+func TestIngestMimeType(t *testing.T) {
+	for name, want := range map[string]string{
+		"report.PDF": "application/pdf",
+		"scan.jpg":   "image/jpeg",
+		"notes.txt":  "",
+		"archive":    "",
+	} {
+		if got := ingestMimeType(name); got != want {
+			t.Errorf("ingestMimeType(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
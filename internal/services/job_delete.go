@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+var (
+	// ErrJobNotDeletable is returned by DeleteJob for a job that is queued, running or awaiting review.
+	ErrJobNotDeletable = errors.New("only finished jobs can be deleted; cancel the job first")
+	// ErrJobHasDuplicates is returned by DeleteJob for a job whose results duplicates (dedupe) show.
+	ErrJobHasDuplicates = errors.New("other jobs show this job's results; delete its duplicates first")
+)
+
+// DeleteJob deletes a finished job with its segments, assets, events, output versions and webhook
+// deliveries. The S3 objects of its assets are queued for the storage cleanup loop, so the call does not
+// wait for S3. Uploaded files stay (they belong to the user, not the job), as do usage ledger entries.
+// Expired jobs can be deleted too.
+func (s *JobService) DeleteJob(ctx context.Context, jobID, userID uuid.UUID) error {
+	job, err := s.ownedJobRecord(ctx, jobID, userID)
+	if err != nil {
+		return err
+	}
+	if !models.IsTerminalJobStatus(job.Status) {
+		return ErrJobNotDeletable
+	}
+	objects, err := s.jobRepo.Delete(ctx, jobID)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrInvalidJobTransition):
+			return ErrJobNotDeletable
+		case errors.Is(err, database.ErrJobHasDuplicates):
+			return ErrJobHasDuplicates
+		}
+		return fmt.Errorf("failed to delete job: %w", err)
+	}
+	log.Info().
+		Str("job_id", jobID.String()).
+		Str("user_id", userID.String()).
+		Int64("objects", objects).
+		Msg("Job deleted; S3 objects queued for deletion")
+	return nil
+}
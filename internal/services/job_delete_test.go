@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+func TestDeleteJob(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	jobRepo := newFakeJobRepo()
+	svc := newReviewTestService(jobRepo, &recordingPublisher{}, &fakeJobEventRepo{})
+	newJob := func(status string, duplicateOf *uuid.UUID) uuid.UUID {
+		id := uuid.New()
+		jobRepo.Create(ctx, &models.Job{ID: id, UserID: userID, Status: status, DuplicateOf: duplicateOf, CreatedAt: time.Now()})
+		return id
+	}
+	done := newJob(models.JobStatusSucceeded, nil)
+	running := newJob(models.JobStatusRunning, nil)
+	source := newJob(models.JobStatusSucceeded, nil)
+	newJob(models.JobStatusSucceeded, &source)
+
+	if err := svc.DeleteJob(ctx, done, uuid.New()); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Fatalf("expected access denied for other user, got %v", err)
+	}
+	if err := svc.DeleteJob(ctx, running, userID); !errors.Is(err, ErrJobNotDeletable) {
+		t.Errorf("running job: got %v, want ErrJobNotDeletable", err)
+	}
+	if err := svc.DeleteJob(ctx, source, userID); !errors.Is(err, ErrJobHasDuplicates) {
+		t.Errorf("job with duplicates: got %v, want ErrJobHasDuplicates", err)
+	}
+	if err := svc.DeleteJob(ctx, done, userID); err != nil {
+		t.Fatalf("DeleteJob: %v", err)
+	}
+	if j, _ := jobRepo.GetByID(ctx, done); j != nil {
+		t.Error("job still exists after DeleteJob")
+	}
+}
+
+// fakeStorageDeletions is an in-memory storage_deletions queue.
+type fakeStorageDeletions struct {
+	due    []*models.StorageDeletion
+	done   []int64
+	failed map[int64]time.Time
+}
+
+func (f *fakeStorageDeletions) ListDue(ctx context.Context, limit int) ([]*models.StorageDeletion, error) {
+	return f.due, nil
+}
+
+func (f *fakeStorageDeletions) Done(ctx context.Context, id int64) error {
+	f.done = append(f.done, id)
+	return nil
+}
+
+func (f *fakeStorageDeletions) Failed(ctx context.Context, id int64, lastError string, next time.Time) error {
+	f.failed[id] = next
+	return nil
+}
+
+// flakyDeleter fails to delete the keys in fail.
+type flakyDeleter struct {
+	fail map[string]bool
+}
+
+func (d flakyDeleter) Delete(ctx context.Context, bucket, key string) error {
+	if d.fail[key] {
+		return errors.New("s3 unavailable")
+	}
+	return nil
+}
+
+func TestStorageCleanupService_RunOnce(t *testing.T) {
+	repo := &fakeStorageDeletions{
+		due: []*models.StorageDeletion{
+			{ID: 1, S3Bucket: "b", S3Key: "jobs/a.png"},
+			{ID: 2, S3Bucket: "b", S3Key: "jobs/b.wav", Attempts: 2},
+		},
+		failed: map[int64]time.Time{},
+	}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	svc := NewStorageCleanupService(repo, flakyDeleter{fail: map[string]bool{"jobs/b.wav": true}}, 30*time.Second)
+	svc.now = func() time.Time { return now }
+
+	if deleted := svc.RunOnce(context.Background()); deleted != 1 {
+		t.Errorf("deleted = %d, want 1", deleted)
+	}
+	if len(repo.done) != 1 || repo.done[0] != 1 {
+		t.Errorf("done = %v, want [1]", repo.done)
+	}
+	// Third failure: 30s doubled twice
+	if next, ok := repo.failed[2]; !ok || !next.Equal(now.Add(2*time.Minute)) {
+		t.Errorf("failed = %v, want object 2 retried at %v", repo.failed, now.Add(2*time.Minute))
+	}
+	if got := storageCleanupBackoff(30*time.Second, 20); got != time.Hour {
+		t.Errorf("backoff after 20 attempts = %v, want 1h", got)
+	}
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	jobExpiryBatch   = 100
+	maxRetentionDays = 3650
+)
+
+// ErrJobExpired is returned for jobs whose retention (expires_at or retention_days) has ended.
+var ErrJobExpired = errors.New("job expired")
+
+// validateJobRetention checks a create request's expires_at and retention_days: at most one of them, a
+// future expiry and a bounded number of days.
+func validateJobRetention(expiresAt *time.Time, retentionDays *int, now time.Time) error {
+	if expiresAt != nil && retentionDays != nil {
+		return fmt.Errorf("expires_at and retention_days are mutually exclusive")
+	}
+	if expiresAt != nil && !expiresAt.After(now) {
+		return fmt.Errorf("expires_at must be in the future")
+	}
+	if retentionDays != nil && (*retentionDays < 1 || *retentionDays > maxRetentionDays) {
+		return fmt.Errorf("retention_days must be between 1 and %d", maxRetentionDays)
+	}
+	return nil
+}
+
+// jobExpiresAt returns when a job created at createdAt expires, or nil when it is kept.
+func jobExpiresAt(expiresAt *time.Time, retentionDays *int, createdAt time.Time) *time.Time {
+	switch {
+	case expiresAt != nil:
+		t := expiresAt.UTC()
+		return &t
+	case retentionDays != nil:
+		t := createdAt.AddDate(0, 0, *retentionDays)
+		return &t
+	}
+	return nil
+}
+
+// jobExpiryRepository is the subset of job DB operations used by the expiry loop.
+type jobExpiryRepository interface {
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error)
+	Expire(ctx context.Context, jobID uuid.UUID, now time.Time) (int64, bool, error)
+}
+
+// JobExpiryService expires jobs whose retention has ended in the background: their assets are deleted
+// (the S3 objects queued for StorageCleanupService) and GET /v1/jobs/{id} answers 410.
+type JobExpiryService struct {
+	repo     jobExpiryRepository
+	interval time.Duration
+	now      func() time.Time
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewJobExpiryService creates a new JobExpiryService running every interval.
+func NewJobExpiryService(repo jobExpiryRepository, interval time.Duration) *JobExpiryService {
+	return &JobExpiryService{
+		repo:     repo,
+		interval: interval,
+		now:      time.Now,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start runs the expiry every interval until ctx is done or Stop is called; 0 disables it.
+func (s *JobExpiryService) Start(ctx context.Context) {
+	if s.interval <= 0 {
+		log.Info().Msg("Job expiry disabled")
+		return
+	}
+	ticker := time.NewTicker(s.interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.stopChan:
+				return
+			case <-ticker.C:
+				s.RunOnce(ctx)
+			}
+		}
+	}()
+}
+
+// Stop stops the expiry. Safe to call multiple times.
+func (s *JobExpiryService) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+	})
+}
+
+// RunOnce expires the jobs that are due and reports how many were expired.
+func (s *JobExpiryService) RunOnce(ctx context.Context) int {
+	now := s.now()
+	due, err := s.repo.ListExpired(ctx, now, jobExpiryBatch)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list expired jobs")
+		return 0
+	}
+	expired := 0
+	for _, jobID := range due {
+		objects, ok, err := s.repo.Expire(ctx, jobID, now)
+		if err != nil {
+			log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to expire job")
+			continue
+		}
+		if !ok {
+			continue
+		}
+		log.Info().Str("job_id", jobID.String()).Int64("objects", objects).Msg("Job expired; S3 objects queued for deletion")
+		expired++
+	}
+	return expired
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+func TestValidateJobRetention(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	future, past := now.Add(time.Hour), now.Add(-time.Hour)
+	days, zero := 30, 0
+	for _, tc := range []struct {
+		name      string
+		expiresAt *time.Time
+		days      *int
+		ok        bool
+	}{
+		{"none", nil, nil, true},
+		{"expires_at", &future, nil, true},
+		{"retention_days", nil, &days, true},
+		{"both", &future, &days, false},
+		{"past", &past, nil, false},
+		{"zero days", nil, &zero, false},
+	} {
+		if err := validateJobRetention(tc.expiresAt, tc.days, now); (err == nil) != tc.ok {
+			t.Errorf("%s: err = %v, want ok %v", tc.name, err, tc.ok)
+		}
+	}
+	if got := jobExpiresAt(nil, &days, now); got == nil || !got.Equal(now.AddDate(0, 0, 30)) {
+		t.Errorf("retention_days expiry = %v, want %v", got, now.AddDate(0, 0, 30))
+	}
+	if got := jobExpiresAt(nil, nil, now); got != nil {
+		t.Errorf("no retention: expiry = %v, want nil", got)
+	}
+}
+
+func TestExpiredJob(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	jobRepo := newFakeJobRepo()
+	svc := newReviewTestService(jobRepo, &recordingPublisher{}, &fakeJobEventRepo{})
+	past := time.Now().Add(-time.Minute)
+	jobID := uuid.New()
+	jobRepo.Create(ctx, &models.Job{ID: jobID, UserID: userID, Status: models.JobStatusSucceeded, ExpiresAt: &past, CreatedAt: time.Now()})
+
+	if _, err := svc.GetJob(ctx, jobID, userID); !errors.Is(err, ErrJobExpired) {
+		t.Errorf("GetJob: got %v, want ErrJobExpired", err)
+	}
+	if _, err := svc.ListSegments(ctx, jobID, userID, 10, ""); !errors.Is(err, ErrJobExpired) {
+		t.Errorf("ListSegments: got %v, want ErrJobExpired", err)
+	}
+	if err := svc.DeleteJob(ctx, jobID, userID); err != nil {
+		t.Errorf("DeleteJob of expired job: %v", err)
+	}
+}
+
+// fakeJobExpiry records the jobs expired by the expiry loop.
+type fakeJobExpiry struct {
+	due     []uuid.UUID
+	changed map[uuid.UUID]bool // jobs that can no longer expire
+	expired []uuid.UUID
+}
+
+func (f *fakeJobExpiry) ListExpired(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error) {
+	return f.due, nil
+}
+
+func (f *fakeJobExpiry) Expire(ctx context.Context, jobID uuid.UUID, now time.Time) (int64, bool, error) {
+	if f.changed[jobID] {
+		return 0, false, nil
+	}
+	f.expired = append(f.expired, jobID)
+	return 2, true, nil
+}
+
+func TestJobExpiryService_RunOnce(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	repo := &fakeJobExpiry{due: []uuid.UUID{a, b}, changed: map[uuid.UUID]bool{b: true}}
+	svc := NewJobExpiryService(repo, time.Minute)
+
+	if expired := svc.RunOnce(context.Background()); expired != 1 {
+		t.Errorf("expired = %d, want 1", expired)
+	}
+	if len(repo.expired) != 1 || repo.expired[0] != a {
+		t.Errorf("expired jobs = %v, want [%v]", repo.expired, a)
+	}
+}
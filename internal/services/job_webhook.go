@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/egress"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// ErrJobFinished is returned by UpdateJob for jobs that succeeded, failed or were canceled: their webhook
+// may already be sent.
+var ErrJobFinished = errors.New("job is already finished")
+
+// UpdateJob sets or changes the webhook of a queued, running or awaiting review job, e.g. to fix a mistyped
+// URL. The dispatcher reads the job's webhook when it delivers it, so the job's webhook goes to the new
+// destination. The webhook is checked as at job creation (payload mode, encryption key, egress policy).
+func (s *JobService) UpdateJob(ctx context.Context, jobID, userID uuid.UUID, req *models.UpdateJobRequest) (*models.JobStatusResponse, error) {
+	if req.Webhook == nil {
+		return nil, fmt.Errorf("validation error: webhook is required")
+	}
+	if req.Webhook.URL == "" {
+		return nil, fmt.Errorf("validation error: webhook.url is required")
+	}
+	job, err := s.ownedJob(ctx, jobID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if models.IsTerminalJobStatus(job.Status) {
+		return nil, ErrJobFinished
+	}
+
+	update := &models.Job{
+		ID:                   job.ID,
+		WebhookURL:           &req.Webhook.URL,
+		WebhookSecret:        job.WebhookSecret,
+		WebhookPayload:       job.WebhookPayload,
+		WebhookEncryptionKey: job.WebhookEncryptionKey,
+	}
+	if req.Webhook.Secret != nil {
+		update.WebhookSecret = emptyToNil(req.Webhook.Secret)
+	}
+	if req.Webhook.Payload != "" {
+		update.WebhookPayload = &req.Webhook.Payload
+	}
+	if req.Webhook.EncryptionKey != nil {
+		update.WebhookEncryptionKey = emptyToNil(req.Webhook.EncryptionKey)
+	}
+	if err := s.validateWebhook(ctx, userID, update); err != nil {
+		return nil, err
+	}
+	if err := s.jobRepo.UpdateWebhook(ctx, update); err != nil {
+		if errors.Is(err, database.ErrInvalidJobTransition) {
+			return nil, ErrJobFinished
+		}
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	data := map[string]any{"user_id": userID.String()}
+	if job.WebhookURL != nil && *job.WebhookURL != req.Webhook.URL {
+		data["previous_url"] = *job.WebhookURL
+	}
+	s.recordEvent(ctx, jobID, models.JobEventWebhookUpdated, "Webhook updated by user", data)
+
+	log.Info().Str("job_id", jobID.String()).Msg("Job webhook updated")
+	return s.GetJob(ctx, jobID, userID)
+}
+
+// validateWebhook checks the webhook of an updated job like validateCreateJobRequest and createJob do.
+func (s *JobService) validateWebhook(ctx context.Context, userID uuid.UUID, job *models.Job) error {
+	if job.WebhookPayload != nil {
+		switch *job.WebhookPayload {
+		case models.WebhookPayloadStatus, models.WebhookPayloadSummary, models.WebhookPayloadFull:
+		default:
+			return fmt.Errorf("validation error: invalid webhook.payload: must be status, summary or full")
+		}
+	}
+	if err := validateWebhookEncryptionKey(job.WebhookEncryptionKey); err != nil {
+		return fmt.Errorf("validation error: %w", err)
+	}
+	if err := s.checkWebhookURL(ctx, userID, *job.WebhookURL); err != nil {
+		if errors.Is(err, egress.ErrDenied) {
+			return fmt.Errorf("validation error: %w", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// emptyToNil returns nil for an empty string, which removes an optional webhook setting.
+func emptyToNil(s *string) *string {
+	if *s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+func TestUpdateJobWebhook(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	jobRepo := newFakeJobRepo()
+	oldURL, secret, payload := "https://example.com/hoook", "s3cret", models.WebhookPayloadFull
+	jobID := uuid.New()
+	jobRepo.Create(ctx, &models.Job{ID: jobID, UserID: userID, Status: models.JobStatusRunning, WebhookURL: &oldURL, WebhookSecret: &secret, WebhookPayload: &payload, CreatedAt: time.Now()})
+	doneID := uuid.New()
+	jobRepo.Create(ctx, &models.Job{ID: doneID, UserID: userID, Status: models.JobStatusSucceeded, CreatedAt: time.Now()})
+	events := &fakeJobEventRepo{}
+	svc := newReviewTestService(jobRepo, &recordingPublisher{}, events)
+
+	newURL := "https://example.com/hook"
+	req := &models.UpdateJobRequest{Webhook: &models.WebhookConfig{URL: newURL}}
+	if _, err := svc.UpdateJob(ctx, jobID, uuid.New(), req); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Fatalf("expected access denied for other user, got %v", err)
+	}
+	if _, err := svc.UpdateJob(ctx, doneID, userID, req); !errors.Is(err, ErrJobFinished) {
+		t.Errorf("finished job: got %v, want ErrJobFinished", err)
+	}
+	if _, err := svc.UpdateJob(ctx, jobID, userID, &models.UpdateJobRequest{}); err == nil || !strings.HasPrefix(err.Error(), "validation error") {
+		t.Errorf("missing webhook: got %v, want validation error", err)
+	}
+	bad := &models.UpdateJobRequest{Webhook: &models.WebhookConfig{URL: newURL, Payload: "everything"}}
+	if _, err := svc.UpdateJob(ctx, jobID, userID, bad); err == nil || !strings.HasPrefix(err.Error(), "validation error") {
+		t.Errorf("bad payload mode: got %v, want validation error", err)
+	}
+
+	if _, err := svc.UpdateJob(ctx, jobID, userID, req); err != nil {
+		t.Fatalf("UpdateJob: %v", err)
+	}
+	job, _ := jobRepo.GetByID(ctx, jobID)
+	if *job.WebhookURL != newURL || job.WebhookSecret == nil || *job.WebhookSecret != secret || *job.WebhookPayload != payload {
+		t.Errorf("webhook = %s %v %v, want the new URL with the secret and payload kept", *job.WebhookURL, job.WebhookSecret, job.WebhookPayload)
+	}
+	evs, _ := events.ListByJob(ctx, jobID)
+	if len(evs) == 0 || evs[0].Type != models.JobEventWebhookUpdated || evs[0].Data["previous_url"] != oldURL {
+		t.Errorf("unexpected events %+v", evs)
+	}
+
+	empty := ""
+	if _, err := svc.UpdateJob(ctx, jobID, userID, &models.UpdateJobRequest{Webhook: &models.WebhookConfig{URL: newURL, Secret: &empty}}); err != nil {
+		t.Fatalf("UpdateJob: %v", err)
+	}
+	if job, _ := jobRepo.GetByID(ctx, jobID); job.WebhookSecret != nil {
+		t.Errorf("secret = %q, want it removed", *job.WebhookSecret)
+	}
+}
@@ -2,14 +2,23 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 	"github.com/snappy-loop/stories/internal/config"
 	"github.com/snappy-loop/stories/internal/database"
-	"github.com/snappy-loop/stories/internal/kafka"
+	"github.com/snappy-loop/stories/internal/egress"
+	"github.com/snappy-loop/stories/internal/inputtype"
+	"github.com/snappy-loop/stories/internal/lexicon"
+	"github.com/snappy-loop/stories/internal/markup"
 	"github.com/snappy-loop/stories/internal/models"
 )
 
@@ -21,8 +30,19 @@ type JobService struct {
 	jobFileRepo    jobFileRepository
 	fileRepo       fileRepository
 	factCheckRepo  factCheckRepository
+	jobEventRepo   jobEventRepository
 	apiKeyRepo     apiKeyRepository
 	jobPublisher   JobPublisher
+	webhooks       WebhookPublisher
+	storage        assetStorage
+	versionRepo    outputVersionRepository
+	voiceRepo      voiceRepository
+	settingsRepo   settingsRepository
+	deliveryRepo   webhookDeliveryRepository
+	usageLedger    usageLedger
+	egress         *egress.Policy
+	egressRepo     egressAllowlistRepository
+	inputTypes     *inputtype.Registry
 	config         *config.Config
 }
 
@@ -34,6 +54,7 @@ func NewJobService(
 	jobFileRepo jobFileRepository,
 	fileRepo fileRepository,
 	factCheckRepo factCheckRepository,
+	jobEventRepo jobEventRepository,
 	apiKeyRepo apiKeyRepository,
 	jobPublisher JobPublisher,
 	cfg *config.Config,
@@ -45,46 +66,86 @@ func NewJobService(
 		jobFileRepo:   jobFileRepo,
 		fileRepo:      fileRepo,
 		factCheckRepo: factCheckRepo,
+		jobEventRepo:  jobEventRepo,
 		apiKeyRepo:    apiKeyRepo,
 		jobPublisher:  jobPublisher,
 		config:        cfg,
 	}
 }
 
-// NewJobServiceFromDB creates a new JobService from a database connection and optional Kafka producer (for production).
+// NewJobServiceFromDB creates a new JobService from a database connection and optional job publisher (for production).
 func NewJobServiceFromDB(
 	db *database.DB,
-	kafkaProducer *kafka.Producer,
+	publisher JobPublisher,
 	cfg *config.Config,
 ) *JobService {
-	var publisher JobPublisher
-	if kafkaProducer != nil {
-		publisher = kafkaProducer
-	}
-	return NewJobService(
+	svc := NewJobService(
 		database.NewJobRepository(db),
 		database.NewSegmentRepository(db),
 		database.NewAssetRepository(db),
 		database.NewJobFileRepository(db),
 		database.NewFileRepository(db),
 		database.NewFactCheckRepository(db),
+		database.NewJobEventRepository(db),
 		database.NewAPIKeyRepository(db),
 		publisher,
 		cfg,
 	)
+	svc.SetOutputVersions(database.NewJobVersionRepository(db))
+	svc.SetVoices(database.NewVoiceRepository(db))
+	svc.SetUserSettings(database.NewUserRepository(db))
+	svc.SetWebhookDeliveries(database.NewWebhookDeliveryRepository(db))
+	svc.SetUsageLedger(database.NewUsageLedgerRepository(db))
+	svc.SetEgress(egress.NewPolicy(cfg.EgressAllowHosts, cfg.EgressDenyHosts, cfg.EgressAllowPrivate), database.NewUserRepository(db))
+	return svc
+}
+
+// SetInputTypes sets the input types jobs may use (see inputtype.Parse); without it the built-in types.
+func (s *JobService) SetInputTypes(r *inputtype.Registry) {
+	s.inputTypes = r
+}
+
+// SetVoices sets the repository of custom voices selected with voice_id; without it jobs cannot use them.
+func (s *JobService) SetVoices(r voiceRepository) {
+	s.voiceRepo = r
 }
 
 // CreateJob creates a new job
 func (s *JobService) CreateJob(ctx context.Context, req *models.CreateJobRequest, userID, apiKeyID uuid.UUID) (*models.CreateJobResponse, error) {
+	if err := s.applySettings(ctx, req, userID); err != nil {
+		return nil, err
+	}
+	return s.createJob(ctx, req, userID, apiKeyID, nil)
+}
+
+// createJob creates a job from req. clone is set when the job is a copy of an earlier one (CloneJob).
+func (s *JobService) createJob(ctx context.Context, req *models.CreateJobRequest, userID, apiKeyID uuid.UUID, clone *jobClone) (*models.CreateJobResponse, error) {
 	// Validate request
 	if err := s.validateCreateJobRequest(req); err != nil {
 		return nil, fmt.Errorf("validation error: %w", err)
 	}
+	if req.Webhook != nil {
+		if err := s.checkWebhookURL(ctx, userID, req.Webhook.URL); err != nil {
+			if errors.Is(err, egress.ErrDenied) {
+				return nil, fmt.Errorf("validation error: %w", err)
+			}
+			return nil, err
+		}
+	}
+	if err := s.checkExternalToolURL(ctx, userID, req.ExternalTool); err != nil {
+		if errors.Is(err, egress.ErrDenied) {
+			return nil, fmt.Errorf("validation error: %w", err)
+		}
+		return nil, err
+	}
 
 	// Determine input source and input text
 	inputSource := "text"
 	inputText := req.Text
-	if len(req.FileIDs) > 0 {
+	if req.Text == "" && len(req.FileIDs) == 0 {
+		// Validation allows this only with depends_on: the input is the first dependency's segments
+		inputSource = "job"
+	} else if len(req.FileIDs) > 0 {
 		if inputText != "" {
 			inputSource = "mixed"
 		} else {
@@ -93,13 +154,19 @@ func (s *JobService) CreateJob(ctx context.Context, req *models.CreateJobRequest
 		}
 	}
 
-	// Validate files exist, belong to user, are ready, and not expired
+	// Validate files exist, belong to user, are ready, and not expired (files whose extracted text a
+	// clone reuses only need to exist)
 	now := time.Now()
-	for _, fileID := range req.FileIDs {
+	fileOptions := make([]*models.ExtractionOptions, len(req.FileIDs))
+	for i, fileID := range req.FileIDs {
 		file, err := s.fileRepo.GetByIDAndUser(ctx, fileID, userID)
 		if err != nil {
 			return nil, fmt.Errorf("file %s not found or not owned by you", fileID.String())
 		}
+		fileOptions[i] = resolveExtractionOptions(req.FileOptions[fileID], file.ExtractionOptions)
+		if clone.extractedText(fileID) != nil {
+			continue
+		}
 		if file.Status != "ready" {
 			return nil, fmt.Errorf("file %s is not available (status: %s)", fileID.String(), file.Status)
 		}
@@ -108,10 +175,45 @@ func (s *JobService) CreateJob(ctx context.Context, req *models.CreateJobRequest
 		}
 	}
 
-	// Quota: text chars + 1000 per file
+	if req.VoiceID != nil {
+		if s.voiceRepo == nil {
+			return nil, fmt.Errorf("custom voices are not available")
+		}
+		if _, err := s.voiceRepo.GetByIDAndUser(ctx, *req.VoiceID, userID); err != nil {
+			return nil, fmt.Errorf("voice %s not found or not owned by you", req.VoiceID.String())
+		}
+	}
+
+	if err := s.checkDependencies(ctx, req.DependsOn, userID); err != nil {
+		return nil, err
+	}
+
+	segmentationStrategy := req.SegmentationStrategy
+	if segmentationStrategy == "" {
+		segmentationStrategy = s.segmentationStrategy()
+	}
+	contentHash := jobContentHash(req, segmentationStrategy, fileOptions)
+
+	// Dedupe: reuse an identical earlier job's results instead of running (and charging for) the pipeline
+	var dedupeSource *models.Job
+	if req.Dedupe {
+		source, err := s.jobRepo.FindDedupeSource(ctx, userID, contentHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up duplicate job: %w", err)
+		}
+		dedupeSource = source
+	}
+
+	// Quota: text chars + 1000 per file (a dependency's segments used as input count as one file)
 	charsNeeded := int64(len(req.Text)) + int64(len(req.FileIDs))*int64(s.config.CharsPerFile)
+	if inputSource == "job" {
+		charsNeeded = int64(s.config.CharsPerFile)
+	}
+	if dedupeSource != nil {
+		charsNeeded = 0
+	}
 	apiKey, err := s.apiKeyRepo.GetByID(ctx, apiKeyID)
-	if err == nil {
+	if err == nil && charsNeeded > 0 {
 		if err := s.checkAndUpdateQuota(ctx, apiKey, charsNeeded); err != nil {
 			return nil, err
 		}
@@ -137,12 +239,38 @@ func (s *JobService) CreateJob(ctx context.Context, req *models.CreateJobRequest
 		InputText:       inputText,
 		InputSource:     inputSource,
 		FactCheckNeeded: factCheckNeeded,
+		QualityCheck:    req.QualityCheck,
+		RequireReview:   req.RequireReview,
+		NotifyEmail:     req.NotifyEmail,
+		Lexicon:         req.Lexicon,
+		VoiceID:         req.VoiceID,
+		ExternalTool:    req.ExternalTool,
+		DependsOn:       req.DependsOn,
+		LazyImages:      req.LazyImages,
+		Metadata:        req.Metadata,
+		Tags:            req.Tags,
+		CharsCharged:    charsNeeded,
 		CreatedAt:       time.Now(),
 	}
+	job.ExpiresAt = jobExpiresAt(req.ExpiresAt, req.RetentionDays, job.CreatedAt)
+	job.SegmentationStrategy = segmentationStrategy
+	job.ContentHash = &contentHash
+	if req.Jurisdiction != "" {
+		job.Jurisdiction = &req.Jurisdiction
+	}
+	if dedupeSource != nil {
+		job.DuplicateOf = &dedupeSource.ID
+	}
 
 	if req.Webhook != nil {
 		job.WebhookURL = &req.Webhook.URL
 		job.WebhookSecret = req.Webhook.Secret
+		if req.Webhook.Payload != "" {
+			job.WebhookPayload = &req.Webhook.Payload
+		}
+		if req.Webhook.EncryptionKey != nil && *req.Webhook.EncryptionKey != "" {
+			job.WebhookEncryptionKey = req.Webhook.EncryptionKey
+		}
 	}
 
 	// Save to database
@@ -159,19 +287,42 @@ func (s *JobService) CreateJob(ctx context.Context, req *models.CreateJobRequest
 			ProcessingOrder: order,
 			Status:          "pending",
 			CreatedAt:       time.Now(),
+
+			ExtractionOptions: fileOptions[order],
+		}
+		if text := clone.extractedText(fileID); text != nil {
+			jf.ExtractedText = text
+			jf.Meta = clone.extractedMeta(fileID)
+			jf.Status = "succeeded"
 		}
 		if err := s.jobFileRepo.Create(ctx, jf); err != nil {
 			return nil, fmt.Errorf("failed to link file to job: %w", err)
 		}
 	}
 
-	// Publish to Kafka (no-op when jobPublisher is nil, e.g. in tests)
-	if s.jobPublisher != nil {
-		traceID := uuid.New().String()
-		if err := s.jobPublisher.PublishJob(ctx, job.ID, traceID); err != nil {
-			log.Error().Err(err).Str("job_id", job.ID.String()).Msg("Failed to publish job to Kafka")
-		}
+	created := map[string]any{
+		"input_source": inputSource,
+		"files":        len(req.FileIDs),
 	}
+	if len(req.DependsOn) > 0 {
+		created["depends_on"] = req.DependsOn
+	}
+	if clone != nil {
+		created["cloned_from"] = clone.sourceID.String()
+		created["reused_extractions"] = len(clone.extracted)
+	}
+	s.recordEvent(ctx, job.ID, models.JobEventCreated, "Job created", created)
+	s.recordChars(ctx, job)
+	if dedupeSource != nil {
+		s.recordEvent(ctx, job.ID, models.JobEventDeduplicated, "Reusing results of job "+dedupeSource.ID.String(), map[string]any{
+			"duplicate_of":  dedupeSource.ID.String(),
+			"source_status": dedupeSource.Status,
+		})
+	}
+
+	// Publish to Kafka (no-op when jobPublisher is nil, e.g. in tests). Duplicates are published too:
+	// the worker copies the source's results once it has finished.
+	s.publishJob(ctx, job.ID)
 
 	log.Info().
 		Str("job_id", job.ID.String()).
@@ -181,32 +332,76 @@ func (s *JobService) CreateJob(ctx context.Context, req *models.CreateJobRequest
 		Msg("Job created")
 
 	return &models.CreateJobResponse{
-		JobID:     job.ID,
-		Status:    job.Status,
-		CreatedAt: job.CreatedAt,
+		JobID:       job.ID,
+		Status:      job.Status,
+		CreatedAt:   job.CreatedAt,
+		DuplicateOf: job.DuplicateOf,
 	}, nil
 }
 
-// GetJob retrieves a job with its segments and assets (assets include public URLs)
-func (s *JobService) GetJob(ctx context.Context, jobID, userID uuid.UUID) (*models.JobStatusResponse, error) {
-	job, err := s.jobRepo.GetByID(ctx, jobID)
-	if err != nil {
-		return nil, fmt.Errorf("job not found: %w", err)
+// resolveExtractionOptions returns the options a job extracts a file with: the job's file_options entry if
+// given, else the file's upload defaults; nil when none is set.
+func resolveExtractionOptions(override, defaults *models.ExtractionOptions) *models.ExtractionOptions {
+	opts := defaults
+	if override != nil {
+		opts = override
+	}
+	if opts.IsZero() {
+		return nil
 	}
+	return opts
+}
 
-	// Verify ownership
-	if job.UserID != userID {
-		return nil, fmt.Errorf("access denied")
+// jobContentHash fingerprints a job's input and every option that affects its output, so jobs with
+// the same hash produce the same results. Metadata, tags and webhooks are not part of it. fileOptions
+// are the resolved extraction options of req.FileIDs, in order.
+func jobContentHash(req *models.CreateJobRequest, segmentationStrategy string, fileOptions []*models.ExtractionOptions) string {
+	factCheck := req.FactCheckNeeded != nil && *req.FactCheckNeeded
+	// Options are left out when no file has any, so hashes of jobs without them are unchanged
+	hasOptions := false
+	for _, o := range fileOptions {
+		hasOptions = hasOptions || o != nil
+	}
+	if !hasOptions {
+		fileOptions = nil
+	}
+	fingerprint, _ := json.Marshal(struct {
+		Text                 string                      `json:"text"`
+		FileIDs              []uuid.UUID                 `json:"file_ids"`
+		Type                 string                      `json:"type"`
+		SegmentsCount        int                         `json:"segments_count"`
+		AudioType            string                      `json:"audio_type"`
+		FactCheck            bool                        `json:"fact_check"`
+		SegmentationStrategy string                      `json:"segmentation_strategy"`
+		QualityCheck         bool                        `json:"quality_check"`
+		RequireReview        bool                        `json:"require_review"`
+		FileOptions          []*models.ExtractionOptions `json:"file_options,omitempty"`
+		Lexicon              []models.LexiconEntry       `json:"lexicon,omitempty"`
+		VoiceID              *uuid.UUID                  `json:"voice_id,omitempty"`
+		Jurisdiction         string                      `json:"jurisdiction,omitempty"`
+		ExternalTool         *models.ExternalTool        `json:"external_tool,omitempty"`
+		DependsOn            []uuid.UUID                 `json:"depends_on,omitempty"`
+		LazyImages           bool                        `json:"lazy_images,omitempty"`
+	}{req.Text, req.FileIDs, req.Type, req.SegmentsCount, req.AudioType, factCheck, segmentationStrategy, req.QualityCheck, req.RequireReview, fileOptions, req.Lexicon, req.VoiceID, req.Jurisdiction, req.ExternalTool, req.DependsOn, req.LazyImages})
+	h := sha256.Sum256(fingerprint)
+	return hex.EncodeToString(h[:])
+}
+
+// GetJob retrieves a job with its segments and assets (assets include public URLs). Expired jobs return
+// ErrJobExpired.
+func (s *JobService) GetJob(ctx context.Context, jobID, userID uuid.UUID) (*models.JobStatusResponse, error) {
+	job, err := s.ownedJob(ctx, jobID, userID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Get segments
-	segments, err := s.segmentRepo.ListByJob(ctx, jobID)
+	// Get first page of segments and assets; large jobs continue via ListSegments/ListAssets.
+	// Duplicates show their source job's segments and assets.
+	segPage, err := s.segmentPage(ctx, job.ResultJobID(), s.detailPageSize(), "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get segments: %w", err)
 	}
-
-	// Get assets and attach public URLs
-	assets, err := s.assetRepo.ListByJob(ctx, jobID)
+	assetPage, err := s.assetPage(ctx, job.ResultJobID(), s.detailPageSize(), "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get assets: %w", err)
 	}
@@ -225,14 +420,199 @@ func (s *JobService) GetJob(ctx context.Context, jobID, userID uuid.UUID) (*mode
 	}
 
 	return &models.JobStatusResponse{
-		Job:        *job,
-		Segments:   segments,
-		Assets:     s.buildAssetResponses(assets),
-		Files:      filesResp,
-		FactChecks: factChecks,
+		Job:                *job,
+		Segments:           segPage.Segments,
+		SegmentsTotal:      segPage.Total,
+		SegmentsNextCursor: segPage.NextCursor,
+		Assets:             assetPage.Assets,
+		AssetsTotal:        assetPage.Total,
+		AssetsNextCursor:   assetPage.NextCursor,
+		Files:              filesResp,
+		FactChecks:         factChecks,
+		Stats:              jobTextStats(job, segPage.Segments),
 	}, nil
 }
 
+// jobTextStats returns the word counts and reading/listening times of a job's output markup or, before
+// it has any, of the given segments (title and text).
+func jobTextStats(job *models.Job, segments []*models.Segment) *models.JobTextStats {
+	if job.OutputMarkup != nil && *job.OutputMarkup != "" {
+		return markup.DocumentStats(*job.OutputMarkup)
+	}
+	if len(segments) == 0 {
+		return nil
+	}
+	texts := make([]markup.SegmentText, len(segments))
+	for i, seg := range segments {
+		text := seg.SegmentText
+		if seg.Title != nil && *seg.Title != "" {
+			text = *seg.Title + "\n" + text
+		}
+		texts[i] = markup.SegmentText{ID: seg.ID.String(), Text: text}
+	}
+	return markup.SegmentStats(texts)
+}
+
+// ListSegments returns a page of segments for a job owned by the user
+func (s *JobService) ListSegments(ctx context.Context, jobID, userID uuid.UUID, limit int, cursor string) (*models.SegmentPage, error) {
+	job, err := s.ownedJob(ctx, jobID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.segmentPage(ctx, job.ResultJobID(), clampPageLimit(limit), cursor)
+}
+
+// ListAssets returns a page of assets for a job owned by the user
+func (s *JobService) ListAssets(ctx context.Context, jobID, userID uuid.UUID, limit int, cursor string) (*models.AssetPage, error) {
+	job, err := s.ownedJob(ctx, jobID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.assetPage(ctx, job.ResultJobID(), clampPageLimit(limit), cursor)
+}
+
+// checkJobOwner returns an error if the job does not exist or belongs to another user
+func (s *JobService) checkJobOwner(ctx context.Context, jobID, userID uuid.UUID) error {
+	_, err := s.ownedJob(ctx, jobID, userID)
+	return err
+}
+
+// ownedJob returns the job if it exists, belongs to the user and has not expired (ErrJobExpired)
+func (s *JobService) ownedJob(ctx context.Context, jobID, userID uuid.UUID) (*models.Job, error) {
+	job, err := s.ownedJobRecord(ctx, jobID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Expired(time.Now()) {
+		return nil, ErrJobExpired
+	}
+	return job, nil
+}
+
+// ownedJobRecord returns the job if it exists and belongs to the user, expired or not
+func (s *JobService) ownedJobRecord(ctx context.Context, jobID, userID uuid.UUID) (*models.Job, error) {
+	job, err := s.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("job not found: %w", err)
+	}
+	if job.UserID != userID {
+		return nil, fmt.Errorf("access denied")
+	}
+	return job, nil
+}
+
+// segmentPage loads one page of segments (limit+1 rows to detect has_more) and the total count.
+func (s *JobService) segmentPage(ctx context.Context, jobID uuid.UUID, limit int, cursor string) (*models.SegmentPage, error) {
+	afterIdx := -1
+	if cursor != "" {
+		idx, err := decodeIndexCursor(cursor)
+		if err != nil {
+			return nil, fmt.Errorf("validation error: %w", err)
+		}
+		afterIdx = idx
+	}
+	segments, err := s.segmentRepo.ListByJobPage(ctx, jobID, afterIdx, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.segmentRepo.CountByJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	page := &models.SegmentPage{Segments: segments, Total: total}
+	if len(segments) > limit {
+		page.Segments = segments[:limit]
+		page.HasMore = true
+		page.NextCursor = encodeIndexCursor(page.Segments[limit-1].Idx)
+	}
+	return page, nil
+}
+
+// assetPage loads one page of assets ordered by (created_at, id) and the total count.
+func (s *JobService) assetPage(ctx context.Context, jobID uuid.UUID, limit int, cursor string) (*models.AssetPage, error) {
+	var afterCreatedAt *time.Time
+	afterID := uuid.Nil
+	if cursor != "" {
+		t, id, err := decodeTimeCursor(cursor)
+		if err != nil {
+			return nil, fmt.Errorf("validation error: %w", err)
+		}
+		afterCreatedAt, afterID = &t, id
+	}
+	assets, err := s.assetRepo.ListByJobPage(ctx, jobID, afterCreatedAt, afterID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.assetRepo.CountByJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	page := &models.AssetPage{Total: total}
+	if len(assets) > limit {
+		assets = assets[:limit]
+		last := assets[limit-1]
+		page.HasMore = true
+		page.NextCursor = encodeTimeCursor(last.CreatedAt, last.ID)
+	}
+	page.Assets = s.buildAssetResponses(assets)
+	return page, nil
+}
+
+// detailPageSize is the number of segments/assets embedded in GetJob
+func (s *JobService) detailPageSize() int {
+	if s.config.JobDetailPageSize > 0 {
+		return s.config.JobDetailPageSize
+	}
+	return 100
+}
+
+// clampPageLimit applies the default (50) and maximum (100) page size for list endpoints
+func clampPageLimit(limit int) int {
+	if limit <= 0 {
+		return 50
+	}
+	if limit > 100 {
+		return 100
+	}
+	return limit
+}
+
+// ListJobEvents returns the event timeline for a job owned by the user
+func (s *JobService) ListJobEvents(ctx context.Context, jobID, userID uuid.UUID) ([]*models.JobEvent, error) {
+	if err := s.checkJobOwner(ctx, jobID, userID); err != nil {
+		return nil, err
+	}
+	if s.jobEventRepo == nil {
+		return []*models.JobEvent{}, nil
+	}
+	events, err := s.jobEventRepo.ListByJob(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job events: %w", err)
+	}
+	if events == nil {
+		events = []*models.JobEvent{}
+	}
+	return events, nil
+}
+
+// recordEvent appends an entry to the job's event log. Failures are logged only; events never fail a request.
+func (s *JobService) recordEvent(ctx context.Context, jobID uuid.UUID, eventType, message string, data map[string]any) {
+	if s.jobEventRepo == nil {
+		return
+	}
+	ev := &models.JobEvent{
+		ID:        uuid.New(),
+		JobID:     jobID,
+		Type:      eventType,
+		Message:   message,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+	if err := s.jobEventRepo.Create(ctx, ev); err != nil {
+		log.Warn().Err(err).Str("job_id", jobID.String()).Str("event", eventType).Msg("Failed to record job event")
+	}
+}
+
 // buildAssetResponses converts assets to response objects with download URLs.
 func (s *JobService) buildAssetResponses(assets []*models.Asset) []*models.AssetResponse {
 	out := make([]*models.AssetResponse, len(assets))
@@ -259,11 +639,11 @@ func (s *JobService) GetJobByID(ctx context.Context, jobID uuid.UUID) (*models.J
 	if err != nil {
 		return nil, fmt.Errorf("job not found: %w", err)
 	}
-	segments, err := s.segmentRepo.ListByJob(ctx, jobID)
+	segments, err := s.segmentRepo.ListByJob(ctx, job.ResultJobID())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get segments: %w", err)
 	}
-	assets, err := s.assetRepo.ListByJob(ctx, jobID)
+	assets, err := s.assetRepo.ListByJob(ctx, job.ResultJobID())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get assets: %w", err)
 	}
@@ -279,6 +659,7 @@ func (s *JobService) GetJobByID(ctx context.Context, jobID uuid.UUID) (*models.J
 		Assets:     s.buildAssetResponses(assets),
 		Files:      filesResp,
 		FactChecks: factChecks,
+		Stats:      jobTextStats(job, segments),
 	}, nil
 }
 
@@ -290,6 +671,9 @@ func (s *JobService) buildJobFileResponses(ctx context.Context, jobFiles []*mode
 			FileID:        jf.FileID,
 			ExtractedText: jf.ExtractedText,
 			Status:        jf.Status,
+			Meta:          jf.Meta,
+
+			ExtractionOptions: jf.ExtractionOptions,
 		}
 		file, err := s.fileRepo.GetByID(ctx, jf.FileID)
 		if err == nil {
@@ -317,6 +701,24 @@ func (s *JobService) GetAsset(ctx context.Context, assetID, userID uuid.UUID) (*
 	return asset, nil
 }
 
+// GetPodcastFeed returns the current podcast feed (rss asset) of a podcast job (for view route, no user check)
+func (s *JobService) GetPodcastFeed(ctx context.Context, jobID uuid.UUID) (*models.Asset, error) {
+	job, err := s.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("job not found: %w", err)
+	}
+	assets, err := s.assetRepo.ListByJob(ctx, job.ResultJobID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list assets: %w", err)
+	}
+	for _, asset := range assets {
+		if asset.Kind == "rss" {
+			return asset, nil
+		}
+	}
+	return nil, fmt.Errorf("podcast feed not found")
+}
+
 // GetAssetByJobID returns an asset by ID if it belongs to the given job (for view route, no user check)
 func (s *JobService) GetAssetByJobID(ctx context.Context, assetID, jobID uuid.UUID) (*models.Asset, error) {
 	asset, err := s.assetRepo.GetByID(ctx, assetID)
@@ -324,30 +726,94 @@ func (s *JobService) GetAssetByJobID(ctx context.Context, assetID, jobID uuid.UU
 		return nil, fmt.Errorf("asset not found: %w", err)
 	}
 	if asset.JobID != jobID {
-		return nil, fmt.Errorf("asset not found")
+		// Duplicates (dedupe) serve their source job's assets
+		job, err := s.jobRepo.GetByID(ctx, jobID)
+		if err != nil || job.ResultJobID() != asset.JobID {
+			return nil, fmt.Errorf("asset not found")
+		}
 	}
 	return asset, nil
 }
 
-// ListJobs lists jobs for a user
-func (s *JobService) ListJobs(ctx context.Context, userID uuid.UUID, limit int, cursor *time.Time) ([]*models.Job, error) {
+// ListJobs lists jobs for a user, newest first. cursor is the next_cursor of a previous page;
+// a bare RFC3339 timestamp is still accepted for clients written against the old API.
+func (s *JobService) ListJobs(ctx context.Context, userID uuid.UUID, limit int, cursor string, filter models.JobListFilter) (*models.JobPage, error) {
 	if limit <= 0 || limit > 100 {
 		limit = 20
 	}
+	if err := s.validateJobListFilter(filter); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+
+	var afterCreatedAt *time.Time
+	afterID := uuid.Nil
+	if cursor != "" {
+		t, id, err := decodeTimeCursor(cursor)
+		if err != nil {
+			legacy, perr := time.Parse(time.RFC3339, cursor)
+			if perr != nil {
+				return nil, fmt.Errorf("validation error: %w", err)
+			}
+			t, id = legacy, uuid.Nil
+		}
+		afterCreatedAt, afterID = &t, id
+	}
 
-	jobs, err := s.jobRepo.ListByUser(ctx, userID, limit, cursor)
+	jobs, err := s.jobRepo.ListByUser(ctx, userID, limit+1, afterCreatedAt, afterID, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list jobs: %w", err)
 	}
 
-	return jobs, nil
+	page := &models.JobPage{Jobs: jobs}
+	if page.Jobs == nil {
+		page.Jobs = []*models.Job{}
+	}
+	if len(jobs) > limit {
+		page.Jobs = jobs[:limit]
+		last := page.Jobs[limit-1]
+		page.HasMore = true
+		page.NextCursor = encodeTimeCursor(last.CreatedAt, last.ID)
+	}
+	return page, nil
+}
+
+// maxJobQueryLength limits the free-text query of ListJobs.
+const maxJobQueryLength = 200
+
+// validateJobListFilter checks the values of ListJobs filters.
+func (s *JobService) validateJobListFilter(filter models.JobListFilter) error {
+	for _, status := range filter.Statuses {
+		if !slices.Contains(models.JobStatuses, status) {
+			return fmt.Errorf("invalid status: must be one of %s", strings.Join(models.JobStatuses, ", "))
+		}
+	}
+	for _, t := range filter.Types {
+		if !s.inputTypes.Has(t) {
+			return fmt.Errorf("invalid type: must be one of %s", strings.Join(s.inputTypes.Names(), ", "))
+		}
+	}
+	for _, audioType := range filter.AudioTypes {
+		if audioType != "free_speech" && audioType != "podcast" {
+			return fmt.Errorf("invalid audio_type: must be free_speech or podcast")
+		}
+	}
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && !filter.CreatedAfter.Before(*filter.CreatedBefore) {
+		return fmt.Errorf("created_after must be before created_before")
+	}
+	if len(filter.Query) > maxJobQueryLength {
+		return fmt.Errorf("q exceeds maximum length of %d characters", maxJobQueryLength)
+	}
+	return nil
 }
 
 // validateCreateJobRequest validates a create job request
 func (s *JobService) validateCreateJobRequest(req *models.CreateJobRequest) error {
-	if req.Text == "" && len(req.FileIDs) == 0 {
+	if req.Text == "" && len(req.FileIDs) == 0 && len(req.DependsOn) == 0 {
 		return fmt.Errorf("either text or file_ids is required")
 	}
+	if err := validateDependsOn(req.DependsOn); err != nil {
+		return err
+	}
 
 	if len(req.FileIDs) > s.config.MaxFilesPerJob {
 		return fmt.Errorf("file_ids exceeds maximum of %d files", s.config.MaxFilesPerJob)
@@ -363,13 +829,21 @@ func (s *JobService) validateCreateJobRequest(req *models.CreateJobRequest) erro
 			seen[fileID] = true
 		}
 	}
+	for fileID, opts := range req.FileOptions {
+		if !slices.Contains(req.FileIDs, fileID) {
+			return fmt.Errorf("file_options: file %s is not in file_ids", fileID.String())
+		}
+		if err := validateExtractionOptions(opts); err != nil {
+			return fmt.Errorf("file_options: %w", err)
+		}
+	}
 
 	if len(req.Text) > s.config.MaxInputLength {
 		return fmt.Errorf("text exceeds maximum length of %d characters", s.config.MaxInputLength)
 	}
 
-	if req.Type != "educational" && req.Type != "financial" && req.Type != "fictional" {
-		return fmt.Errorf("invalid type: must be educational, financial, or fictional")
+	if !s.inputTypes.Has(req.Type) {
+		return fmt.Errorf("invalid type: must be one of %s", strings.Join(s.inputTypes.Names(), ", "))
 	}
 
 	if req.SegmentsCount < 1 || req.SegmentsCount > s.config.MaxSegmentsCount {
@@ -380,6 +854,84 @@ func (s *JobService) validateCreateJobRequest(req *models.CreateJobRequest) erro
 		return fmt.Errorf("invalid audio_type: must be free_speech or podcast")
 	}
 
+	switch req.SegmentationStrategy {
+	case "", models.SegmentationStrategyLLM, models.SegmentationStrategyHeuristic:
+	default:
+		return fmt.Errorf("invalid segmentation_strategy: must be llm or heuristic")
+	}
+
+	if req.Webhook != nil {
+		switch req.Webhook.Payload {
+		case "", models.WebhookPayloadStatus, models.WebhookPayloadSummary, models.WebhookPayloadFull:
+		default:
+			return fmt.Errorf("invalid webhook.payload: must be status, summary or full")
+		}
+		if err := validateWebhookEncryptionKey(req.Webhook.EncryptionKey); err != nil {
+			return err
+		}
+	}
+
+	if err := lexicon.Validate(req.Lexicon); err != nil {
+		return err
+	}
+
+	if req.Jurisdiction != "" {
+		jurisdiction, err := normalizeJurisdiction(req.Jurisdiction)
+		if err != nil {
+			return err
+		}
+		req.Jurisdiction = jurisdiction
+	}
+
+	if err := validateExternalTool(req.ExternalTool); err != nil {
+		return err
+	}
+
+	if err := validateJobRetention(req.ExpiresAt, req.RetentionDays, time.Now()); err != nil {
+		return err
+	}
+
+	return validateJobLabels(req.Metadata, req.Tags)
+}
+
+// segmentationStrategy returns the configured default strategy, falling back to llm when unset or unknown.
+func (s *JobService) segmentationStrategy() string {
+	if s.config != nil && s.config.SegmentationStrategy == models.SegmentationStrategyHeuristic {
+		return models.SegmentationStrategyHeuristic
+	}
+	return models.SegmentationStrategyLLM
+}
+
+// Limits for caller-supplied job labels
+const (
+	maxJobMetadataKeys     = 50
+	maxJobMetadataKeyLen   = 64
+	maxJobMetadataValueLen = 512
+	maxJobTags             = 20
+	maxJobTagLen           = 64
+)
+
+// validateJobLabels checks metadata and tags sizes so labels stay small enough to echo in every response.
+func validateJobLabels(metadata map[string]string, tags []string) error {
+	if len(metadata) > maxJobMetadataKeys {
+		return fmt.Errorf("metadata exceeds maximum of %d keys", maxJobMetadataKeys)
+	}
+	for k, v := range metadata {
+		if k == "" || len(k) > maxJobMetadataKeyLen {
+			return fmt.Errorf("metadata keys must be 1-%d characters", maxJobMetadataKeyLen)
+		}
+		if len(v) > maxJobMetadataValueLen {
+			return fmt.Errorf("metadata value for %q exceeds %d characters", k, maxJobMetadataValueLen)
+		}
+	}
+	if len(tags) > maxJobTags {
+		return fmt.Errorf("tags exceeds maximum of %d", maxJobTags)
+	}
+	for _, t := range tags {
+		if t == "" || len(t) > maxJobTagLen {
+			return fmt.Errorf("tags must be 1-%d characters", maxJobTagLen)
+		}
+	}
 	return nil
 }
 
@@ -387,14 +939,20 @@ func (s *JobService) validateCreateJobRequest(req *models.CreateJobRequest) erro
 func (s *JobService) checkAndUpdateQuota(ctx context.Context, apiKey *models.APIKey, charsNeeded int64) error {
 	// Check if period needs to be reset
 	now := time.Now()
-	periodDuration := s.getPeriodDuration(apiKey.QuotaPeriod)
+	periodDuration := models.QuotaPeriodDuration(apiKey.QuotaPeriod)
 
 	if now.Sub(apiKey.PeriodStartedAt) > periodDuration {
 		// Reset period
 		apiKey.UsedCharsInPeriod = 0
+		apiKey.UsedCostInPeriod = 0
 		apiKey.PeriodStartedAt = now
 	}
 
+	// With COST_CAP_ACTION=queue the job is created and the worker holds it until the next period
+	if apiKey.CostCapReached() && s.config.CostCapAction != models.CostCapQueue {
+		return fmt.Errorf("cost cap reached: %.2f/%.2f USD used", apiKey.UsedCostInPeriod, *apiKey.CostCapUSD)
+	}
+
 	// Check quota
 	if apiKey.UsedCharsInPeriod+charsNeeded > apiKey.QuotaChars {
 		return fmt.Errorf("quota exceeded: %d/%d chars used", apiKey.UsedCharsInPeriod, apiKey.QuotaChars)
@@ -407,18 +965,3 @@ func (s *JobService) checkAndUpdateQuota(ctx context.Context, apiKey *models.API
 
 	return nil
 }
-
-func (s *JobService) getPeriodDuration(period string) time.Duration {
-	switch period {
-	case "daily":
-		return 24 * time.Hour
-	case "weekly":
-		return 7 * 24 * time.Hour
-	case "monthly":
-		return 30 * 24 * time.Hour
-	case "yearly":
-		return 365 * 24 * time.Hour
-	default:
-		return 30 * 24 * time.Hour
-	}
-}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,24 +12,54 @@ import (
 // JobPublisher publishes job messages (e.g. to Kafka). May be nil to skip publishing.
 type JobPublisher interface {
 	PublishJob(ctx context.Context, jobID uuid.UUID, traceID string) error
+	PublishSegmentEdit(ctx context.Context, jobID uuid.UUID, idx int, regenerateImage bool, traceID string) error
+}
+
+// WebhookPublisher publishes webhook events (e.g. to Kafka). May be nil to skip publishing.
+type WebhookPublisher interface {
+	PublishWebhook(ctx context.Context, jobID uuid.UUID, event, traceID string) error
 }
 
 // jobRepository is the subset of job DB operations used by JobService.
 type jobRepository interface {
 	Create(ctx context.Context, job *models.Job) error
 	GetByID(ctx context.Context, jobID uuid.UUID) (*models.Job, error)
-	ListByUser(ctx context.Context, userID uuid.UUID, limit int, cursor *time.Time) ([]*models.Job, error)
+	ListByUser(ctx context.Context, userID uuid.UUID, limit int, afterCreatedAt *time.Time, afterID uuid.UUID, filter models.JobListFilter) ([]*models.Job, error)
+	FindDedupeSource(ctx context.Context, userID uuid.UUID, contentHash string) (*models.Job, error)
+	ListQueuedDuplicates(ctx context.Context, sourceID uuid.UUID) ([]uuid.UUID, error)
+	ListQueuedDependents(ctx context.Context, jobID uuid.UUID) ([]uuid.UUID, error)
+	ApproveReview(ctx context.Context, jobID uuid.UUID) error
+	RequestRegeneration(ctx context.Context, jobID uuid.UUID, req *models.ReviewRegeneration) error
+	UpdateStatus(ctx context.Context, jobID uuid.UUID, status string, errorCode, errorMessage *string) error
+	ResetForRetry(ctx context.Context, jobID uuid.UUID) error
+	UpdateWebhook(ctx context.Context, job *models.Job) error
+	Delete(ctx context.Context, jobID uuid.UUID) (int64, error)
 }
 
 // segmentRepository is the subset of segment DB operations used by JobService.
 type segmentRepository interface {
 	ListByJob(ctx context.Context, jobID uuid.UUID) ([]*models.Segment, error)
+	ListByJobPage(ctx context.Context, jobID uuid.UUID, afterIdx, limit int) ([]*models.Segment, error)
+	CountByJob(ctx context.Context, jobID uuid.UUID) (int, error)
+	GetByJobIdx(ctx context.Context, jobID uuid.UUID, idx int) (*models.Segment, error)
+	ApplyEdit(ctx context.Context, segmentID uuid.UUID, req *models.UpdateSegmentRequest) error
+	CancelUnfinished(ctx context.Context, jobID uuid.UUID) (int64, error)
 }
 
 // assetRepository is the subset of asset DB operations used by JobService.
 type assetRepository interface {
 	GetByID(ctx context.Context, assetID uuid.UUID) (*models.Asset, error)
 	ListByJob(ctx context.Context, jobID uuid.UUID) ([]*models.Asset, error)
+	ListByJobPage(ctx context.Context, jobID uuid.UUID, afterCreatedAt *time.Time, afterID uuid.UUID, limit int) ([]*models.Asset, error)
+	CountByJob(ctx context.Context, jobID uuid.UUID) (int, error)
+	Replace(ctx context.Context, old, replacement *models.Asset) error
+	NextVersion(ctx context.Context, segmentID uuid.UUID, kind string) (int, error)
+}
+
+// assetStorage is the subset of object storage operations used for asset replacement uploads.
+type assetStorage interface {
+	Upload(ctx context.Context, key string, data io.Reader, contentType string, contentLength int64) (string, error)
+	Delete(ctx context.Context, bucket, key string) error
 }
 
 // jobFileRepository is the subset of job_file DB operations used by JobService.
@@ -54,3 +85,46 @@ type apiKeyRepository interface {
 type factCheckRepository interface {
 	ListByJob(ctx context.Context, jobID uuid.UUID) ([]*models.SegmentFactCheck, error)
 }
+
+// outputVersionRepository is the subset of job output version DB operations used by JobService.
+type outputVersionRepository interface {
+	List(ctx context.Context, jobID uuid.UUID) ([]*models.OutputVersion, error)
+	Get(ctx context.Context, jobID uuid.UUID, version int) (*models.OutputVersion, error)
+	Restore(ctx context.Context, jobID uuid.UUID, version int) error
+}
+
+// jobEventRepository is the subset of job event DB operations used by JobService.
+type jobEventRepository interface {
+	Create(ctx context.Context, ev *models.JobEvent) error
+	ListByJob(ctx context.Context, jobID uuid.UUID) ([]*models.JobEvent, error)
+}
+
+// voiceRepository is the subset of voice DB operations used by JobService.
+type voiceRepository interface {
+	GetByIDAndUser(ctx context.Context, id, userID uuid.UUID) (*models.Voice, error)
+}
+
+// settingsRepository is the subset of user DB operations used by JobService for job defaults.
+type settingsRepository interface {
+	GetSettings(ctx context.Context, userID uuid.UUID) (*models.UserSettings, error)
+	SetSettings(ctx context.Context, userID uuid.UUID, settings *models.UserSettings) error
+}
+
+// egressAllowlistRepository is the subset of user DB operations used for users' egress allow lists.
+type egressAllowlistRepository interface {
+	GetEgressAllowlist(ctx context.Context, userID uuid.UUID) ([]string, error)
+	SetEgressAllowlist(ctx context.Context, userID uuid.UUID, hosts []string) error
+}
+
+// webhookDeliveryRepository is the subset of webhook delivery DB operations used by JobService.
+type webhookDeliveryRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error)
+	ListAttempts(ctx context.Context, deliveryID uuid.UUID) ([]*models.WebhookDeliveryAttempt, error)
+	Redeliver(ctx context.Context, id uuid.UUID) (*models.WebhookDelivery, error)
+}
+
+// usageLedger records the characters charged for jobs, and jobs approved by reviewers, in the usage ledger.
+type usageLedger interface {
+	Record(ctx context.Context, e *models.UsageEntry) error
+	RecordCompleted(ctx context.Context, jobID uuid.UUID) error
+}
@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"testing"
@@ -9,6 +11,8 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/snappy-loop/stories/internal/config"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/inputtype"
 	"github.com/snappy-loop/stories/internal/models"
 )
 
@@ -17,6 +21,10 @@ type noopJobPublisher struct{}
 
 func (noopJobPublisher) PublishJob(context.Context, uuid.UUID, string) error { return nil }
 
+func (noopJobPublisher) PublishSegmentEdit(context.Context, uuid.UUID, int, bool, string) error {
+	return nil
+}
+
 // fakeJobRepo is an in-memory job repository for tests.
 type fakeJobRepo struct {
 	mu     sync.Mutex
@@ -57,25 +65,200 @@ type errT struct{ msg string }
 
 func (e *errT) Error() string { return e.msg }
 
-func (f *fakeJobRepo) ListByUser(ctx context.Context, userID uuid.UUID, limit int, cursor *time.Time) ([]*models.Job, error) {
+func (f *fakeJobRepo) ListByUser(ctx context.Context, userID uuid.UUID, limit int, afterCreatedAt *time.Time, afterID uuid.UUID, filter models.JobListFilter) ([]*models.Job, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	list := f.byUser[userID]
-	if list == nil {
-		return []*models.Job{}, nil
+	list := append([]*models.Job(nil), f.byUser[userID]...)
+	// Same order as the real query: created_at DESC, id DESC
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].CreatedAt.After(list[j].CreatedAt)
+		}
+		return list[i].ID.String() > list[j].ID.String()
+	})
+	out := []*models.Job{}
+	for _, j := range list {
+		if afterCreatedAt != nil {
+			if j.CreatedAt.After(*afterCreatedAt) {
+				continue
+			}
+			if j.CreatedAt.Equal(*afterCreatedAt) && j.ID.String() >= afterID.String() {
+				continue
+			}
+		}
+		if !matchesFilter(j, filter) {
+			continue
+		}
+		if len(out) == limit {
+			break
+		}
+		clone := *j
+		out = append(out, &clone)
 	}
-	if limit <= 0 || limit > 100 {
-		limit = 20
+	return out, nil
+}
+
+func (f *fakeJobRepo) FindDedupeSource(ctx context.Context, userID uuid.UUID, contentHash string) (*models.Job, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var found *models.Job
+	for _, j := range f.byUser[userID] {
+		if j.ContentHash == nil || *j.ContentHash != contentHash || j.DuplicateOf != nil || j.Expired(time.Now()) {
+			continue
+		}
+		switch j.Status {
+		case models.JobStatusQueued, models.JobStatusRunning, models.JobStatusAwaitingReview, models.JobStatusSucceeded:
+		default:
+			continue
+		}
+		if found == nil || j.CreatedAt.After(found.CreatedAt) {
+			found = j
+		}
 	}
-	if len(list) > limit {
-		list = list[:limit]
+	if found == nil {
+		return nil, nil
 	}
-	out := make([]*models.Job, len(list))
-	for i, j := range list {
-		clone := *j
-		out[i] = &clone
+	clone := *found
+	return &clone, nil
+}
+
+func (f *fakeJobRepo) ListQueuedDuplicates(ctx context.Context, sourceID uuid.UUID) ([]uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var ids []uuid.UUID
+	for _, j := range f.jobs {
+		if j.DuplicateOf != nil && *j.DuplicateOf == sourceID && j.Status == models.JobStatusQueued {
+			ids = append(ids, j.ID)
+		}
 	}
-	return out, nil
+	return ids, nil
+}
+
+func (f *fakeJobRepo) ListQueuedDependents(ctx context.Context, jobID uuid.UUID) ([]uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var ids []uuid.UUID
+	for _, j := range f.jobs {
+		if slices.Contains(j.DependsOn, jobID) && j.Status == models.JobStatusQueued {
+			ids = append(ids, j.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (f *fakeJobRepo) ApproveReview(ctx context.Context, jobID uuid.UUID) error {
+	return f.moveFromReview(jobID, models.JobStatusSucceeded, nil)
+}
+
+func (f *fakeJobRepo) RequestRegeneration(ctx context.Context, jobID uuid.UUID, req *models.ReviewRegeneration) error {
+	return f.moveFromReview(jobID, models.JobStatusQueued, req)
+}
+
+// UpdateStatus mirrors the conditional status update: only allowed transitions happen.
+func (f *fakeJobRepo) UpdateStatus(ctx context.Context, jobID uuid.UUID, status string, errorCode, errorMessage *string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	j, ok := f.jobs[jobID]
+	if !ok || !models.CanTransitionJob(j.Status, status) {
+		return database.ErrInvalidJobTransition
+	}
+	j.Status = status
+	j.ErrorCode, j.ErrorMessage = errorCode, errorMessage
+	return nil
+}
+
+// ResetForRetry mirrors the conditional retry reset: only failed jobs are queued again.
+func (f *fakeJobRepo) ResetForRetry(ctx context.Context, jobID uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	j, ok := f.jobs[jobID]
+	if !ok || j.Status != models.JobStatusFailed {
+		return database.ErrInvalidJobTransition
+	}
+	j.Status = models.JobStatusQueued
+	j.ErrorCode, j.ErrorMessage = nil, nil
+	return nil
+}
+
+// UpdateWebhook mirrors the conditional webhook update: finished jobs keep their webhook.
+func (f *fakeJobRepo) UpdateWebhook(ctx context.Context, job *models.Job) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	j, ok := f.jobs[job.ID]
+	if !ok || models.IsTerminalJobStatus(j.Status) {
+		return database.ErrInvalidJobTransition
+	}
+	j.WebhookURL, j.WebhookSecret = job.WebhookURL, job.WebhookSecret
+	j.WebhookPayload, j.WebhookEncryptionKey = job.WebhookPayload, job.WebhookEncryptionKey
+	return nil
+}
+
+// Delete mirrors the conditional delete: only finished jobs without duplicates go, with one object per asset.
+func (f *fakeJobRepo) Delete(ctx context.Context, jobID uuid.UUID) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	j, ok := f.jobs[jobID]
+	if !ok {
+		return 0, errNotFound
+	}
+	if !models.IsTerminalJobStatus(j.Status) {
+		return 0, database.ErrInvalidJobTransition
+	}
+	for _, other := range f.jobs {
+		if other.DuplicateOf != nil && *other.DuplicateOf == jobID {
+			return 0, database.ErrJobHasDuplicates
+		}
+	}
+	delete(f.jobs, jobID)
+	return 2, nil
+}
+
+// moveFromReview mirrors the conditional review updates: only jobs awaiting review move.
+func (f *fakeJobRepo) moveFromReview(jobID uuid.UUID, status string, req *models.ReviewRegeneration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	j, ok := f.jobs[jobID]
+	if !ok || j.Status != models.JobStatusAwaitingReview {
+		return database.ErrInvalidJobTransition
+	}
+	j.Status = status
+	j.ReviewRegeneration = req
+	return nil
+}
+
+// matchesFilter mirrors the filters of the real ListByUser query; the full-text query is approximated by
+// requiring every word in input_text.
+func matchesFilter(j *models.Job, filter models.JobListFilter) bool {
+	if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, j.Status) ||
+		len(filter.Types) > 0 && !slices.Contains(filter.Types, j.InputType) ||
+		len(filter.AudioTypes) > 0 && !slices.Contains(filter.AudioTypes, j.AudioType) ||
+		filter.CreatedAfter != nil && j.CreatedAt.Before(*filter.CreatedAfter) ||
+		filter.CreatedBefore != nil && !j.CreatedAt.Before(*filter.CreatedBefore) {
+		return false
+	}
+	for _, word := range strings.Fields(strings.ToLower(filter.Query)) {
+		if !slices.Contains(strings.Fields(strings.ToLower(j.InputText)), word) {
+			return false
+		}
+	}
+	for _, want := range filter.Tags {
+		found := false
+		for _, t := range j.Tags {
+			if t == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for k, v := range filter.Metadata {
+		if j.Metadata[k] != v {
+			return false
+		}
+	}
+	return true
 }
 
 // fakeSegmentRepo returns empty segments.
@@ -85,6 +268,26 @@ func (fakeSegmentRepo) ListByJob(context.Context, uuid.UUID) ([]*models.Segment,
 	return nil, nil
 }
 
+func (fakeSegmentRepo) ListByJobPage(context.Context, uuid.UUID, int, int) ([]*models.Segment, error) {
+	return nil, nil
+}
+
+func (fakeSegmentRepo) CountByJob(context.Context, uuid.UUID) (int, error) {
+	return 0, nil
+}
+
+func (fakeSegmentRepo) GetByJobIdx(context.Context, uuid.UUID, int) (*models.Segment, error) {
+	return nil, errNotFound
+}
+
+func (fakeSegmentRepo) ApplyEdit(context.Context, uuid.UUID, *models.UpdateSegmentRequest) error {
+	return nil
+}
+
+func (fakeSegmentRepo) CancelUnfinished(context.Context, uuid.UUID) (int64, error) {
+	return 0, nil
+}
+
 // fakeAssetRepo returns empty list; GetByID returns not found.
 type fakeAssetRepo struct{}
 
@@ -92,10 +295,46 @@ func (fakeAssetRepo) ListByJob(context.Context, uuid.UUID) ([]*models.Asset, err
 	return nil, nil
 }
 
+func (fakeAssetRepo) Replace(context.Context, *models.Asset, *models.Asset) error {
+	return nil
+}
+
 func (fakeAssetRepo) GetByID(context.Context, uuid.UUID) (*models.Asset, error) {
 	return nil, errNotFound
 }
 
+func (fakeAssetRepo) ListByJobPage(context.Context, uuid.UUID, *time.Time, uuid.UUID, int) ([]*models.Asset, error) {
+	return nil, nil
+}
+
+func (fakeAssetRepo) CountByJob(context.Context, uuid.UUID) (int, error) {
+	return 0, nil
+}
+
+func (fakeAssetRepo) NextVersion(context.Context, uuid.UUID, string) (int, error) {
+	return 1, nil
+}
+
+// pagedSegmentRepo serves a fixed list of segments with real paging semantics.
+type pagedSegmentRepo struct {
+	fakeSegmentRepo
+	segments []*models.Segment
+}
+
+func (p pagedSegmentRepo) ListByJobPage(_ context.Context, _ uuid.UUID, afterIdx, limit int) ([]*models.Segment, error) {
+	var out []*models.Segment
+	for _, seg := range p.segments {
+		if seg.Idx > afterIdx && len(out) < limit {
+			out = append(out, seg)
+		}
+	}
+	return out, nil
+}
+
+func (p pagedSegmentRepo) CountByJob(context.Context, uuid.UUID) (int, error) {
+	return len(p.segments), nil
+}
+
 // fakeFactCheckRepo returns empty fact-checks for tests.
 type fakeFactCheckRepo struct{}
 
@@ -103,6 +342,31 @@ func (fakeFactCheckRepo) ListByJob(context.Context, uuid.UUID) ([]*models.Segmen
 	return nil, nil
 }
 
+// fakeJobEventRepo stores events in memory.
+type fakeJobEventRepo struct {
+	mu     sync.Mutex
+	events []*models.JobEvent
+}
+
+func (f *fakeJobEventRepo) Create(ctx context.Context, ev *models.JobEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, ev)
+	return nil
+}
+
+func (f *fakeJobEventRepo) ListByJob(ctx context.Context, jobID uuid.UUID) ([]*models.JobEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []*models.JobEvent
+	for _, ev := range f.events {
+		if ev.JobID == jobID {
+			out = append(out, ev)
+		}
+	}
+	return out, nil
+}
+
 // fakeJobFileRepo does nothing for Create; ListByJob returns empty.
 type fakeJobFileRepo struct{}
 
@@ -189,6 +453,7 @@ func TestCreateJob_ValidationErrors(t *testing.T) {
 		fakeJobFileRepo{},
 		newFakeFileRepo(),
 		fakeFactCheckRepo{},
+		nil,
 		newFakeAPIKeyRepo(apiKey),
 		noopJobPublisher{},
 		cfg,
@@ -196,6 +461,7 @@ func TestCreateJob_ValidationErrors(t *testing.T) {
 	ctx := context.Background()
 	userID := uuid.New()
 	apiKeyID := apiKey.ID
+	fileID := uuid.New()
 
 	tests := []struct {
 		name string
@@ -207,6 +473,11 @@ func TestCreateJob_ValidationErrors(t *testing.T) {
 		{"segments_count too low", &models.CreateJobRequest{Text: "Some text", Type: "educational", SegmentsCount: 0, AudioType: "free_speech"}, "segments_count must be between 1 and 5"},
 		{"segments_count too high", &models.CreateJobRequest{Text: "Some text", Type: "educational", SegmentsCount: 100, AudioType: "free_speech"}, "segments_count must be between 1 and 5"},
 		{"invalid audio_type", &models.CreateJobRequest{Text: "Some text", Type: "educational", SegmentsCount: 2, AudioType: "invalid"}, "invalid audio_type"},
+		{"invalid segmentation_strategy", &models.CreateJobRequest{Text: "Some text", Type: "educational", SegmentsCount: 2, AudioType: "free_speech", SegmentationStrategy: "magic"}, "invalid segmentation_strategy"},
+		{"invalid webhook payload", &models.CreateJobRequest{Text: "Some text", Type: "educational", SegmentsCount: 2, AudioType: "free_speech", Webhook: &models.WebhookConfig{URL: "https://example.com/hook", Payload: "everything"}}, "invalid webhook.payload"},
+		{"file_options for unknown file", &models.CreateJobRequest{Text: "Some text", Type: "educational", SegmentsCount: 2, AudioType: "free_speech", FileOptions: map[uuid.UUID]*models.ExtractionOptions{uuid.New(): {Handwriting: true}}}, "is not in file_ids"},
+		{"invalid jurisdiction", &models.CreateJobRequest{Text: "Some text", Type: "financial", SegmentsCount: 2, AudioType: "free_speech", Jurisdiction: "U.S."}, "jurisdiction must be"},
+		{"invalid file_options language", &models.CreateJobRequest{Type: "educational", SegmentsCount: 2, AudioType: "free_speech", FileIDs: []uuid.UUID{fileID}, FileOptions: map[uuid.UUID]*models.ExtractionOptions{fileID: {Language: "not a tag"}}}, "invalid language"},
 	}
 
 	for _, tt := range tests {
@@ -222,6 +493,41 @@ func TestCreateJob_ValidationErrors(t *testing.T) {
 	}
 }
 
+func TestCreateJob_InputTypes(t *testing.T) {
+	userID := uuid.New()
+	apiKey := &models.APIKey{ID: uuid.New(), UserID: userID, QuotaChars: 100000, PeriodStartedAt: time.Now(), QuotaPeriod: "monthly"}
+	svc := NewJobService(
+		&fakeJobRepoGetByIDErr{fakeJobRepo: newFakeJobRepo()},
+		fakeSegmentRepo{},
+		fakeAssetRepo{},
+		fakeJobFileRepo{},
+		newFakeFileRepo(),
+		fakeFactCheckRepo{},
+		nil,
+		newFakeAPIKeyRepo(apiKey),
+		noopJobPublisher{},
+		&config.Config{MaxInputLength: 1000, MaxSegmentsCount: 5},
+	)
+	ctx := context.Background()
+	req := func() *models.CreateJobRequest {
+		return &models.CreateJobRequest{Text: "Whisk the eggs.", Type: "recipe", SegmentsCount: 2, AudioType: "free_speech"}
+	}
+
+	_, err := svc.CreateJob(ctx, req(), userID, apiKey.ID)
+	if err == nil || !strings.Contains(err.Error(), "must be one of educational, fictional, financial") {
+		t.Errorf("unconfigured type: got %v, want invalid type", err)
+	}
+
+	inputTypes, err := inputtype.Parse(`[{"name": "recipe", "narration_style": "Warm cooking narration."}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.SetInputTypes(inputTypes)
+	if _, err := svc.CreateJob(ctx, req(), userID, apiKey.ID); err != nil {
+		t.Errorf("configured type: %v", err)
+	}
+}
+
 func TestCreateJob_Success(t *testing.T) {
 	cfg := &config.Config{
 		MaxFilesPerJob:     10,
@@ -252,6 +558,7 @@ func TestCreateJob_Success(t *testing.T) {
 		fakeJobFileRepo{},
 		newFakeFileRepo(),
 		fakeFactCheckRepo{},
+		nil,
 		newFakeAPIKeyRepo(apiKey),
 		noopJobPublisher{},
 		cfg,
@@ -321,6 +628,7 @@ func TestGetJob_AccessDenied(t *testing.T) {
 		fakeJobFileRepo{},
 		newFakeFileRepo(),
 		fakeFactCheckRepo{},
+		nil,
 		newFakeAPIKeyRepo(nil),
 		noopJobPublisher{},
 		config.Load(),
@@ -346,6 +654,7 @@ func TestListJobs_LimitClamping(t *testing.T) {
 		fakeJobFileRepo{},
 		newFakeFileRepo(),
 		fakeFactCheckRepo{},
+		nil,
 		newFakeAPIKeyRepo(nil),
 		noopJobPublisher{},
 		config.Load(),
@@ -353,19 +662,490 @@ func TestListJobs_LimitClamping(t *testing.T) {
 	ctx := context.Background()
 	userID := uuid.New()
 
-	jobs, err := svc.ListJobs(ctx, userID, 0, nil)
+	page, err := svc.ListJobs(ctx, userID, 0, "", models.JobListFilter{})
 	if err != nil {
 		t.Fatalf("ListJobs(0): %v", err)
 	}
-	if jobs == nil {
+	if page.Jobs == nil {
 		t.Error("ListJobs(0) returned nil slice")
 	}
 
-	jobs, err = svc.ListJobs(ctx, userID, 500, nil)
+	page, err = svc.ListJobs(ctx, userID, 500, "", models.JobListFilter{})
 	if err != nil {
 		t.Fatalf("ListJobs(500): %v", err)
 	}
-	if jobs == nil {
+	if page.Jobs == nil {
 		t.Error("ListJobs(500) returned nil slice")
 	}
 }
+
+// TestListJobs_CursorSameTimestamp asserts jobs created in the same instant are all returned exactly once.
+func TestListJobs_Filters(t *testing.T) {
+	jobRepo := newFakeJobRepo()
+	svc := NewJobService(jobRepo, fakeSegmentRepo{}, fakeAssetRepo{}, fakeJobFileRepo{}, newFakeFileRepo(),
+		fakeFactCheckRepo{}, nil, newFakeAPIKeyRepo(nil), noopJobPublisher{}, config.Load())
+	ctx := context.Background()
+	userID := uuid.New()
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	newJob := func(status, inputType, audioType, text string, day int) uuid.UUID {
+		id := uuid.New()
+		jobRepo.Create(ctx, &models.Job{ID: id, UserID: userID, Status: status, InputType: inputType, AudioType: audioType,
+			InputText: text, CreatedAt: base.AddDate(0, 0, day)})
+		return id
+	}
+	failedPodcast := newJob(models.JobStatusFailed, "financial", "podcast", "Quarterly earnings call", 0)
+	succeeded := newJob(models.JobStatusSucceeded, "educational", "free_speech", "The water cycle explained", 1)
+	canceled := newJob(models.JobStatusCanceled, "educational", "podcast", "Photosynthesis and the water cycle", 2)
+
+	after, before := base.AddDate(0, 0, 1), base.AddDate(0, 0, 2)
+	for _, tc := range []struct {
+		name   string
+		filter models.JobListFilter
+		want   []uuid.UUID
+	}{
+		{"status", models.JobListFilter{Statuses: []string{models.JobStatusFailed, models.JobStatusCanceled}}, []uuid.UUID{canceled, failedPodcast}},
+		{"type", models.JobListFilter{Types: []string{"educational"}}, []uuid.UUID{canceled, succeeded}},
+		{"audio type", models.JobListFilter{AudioTypes: []string{"podcast"}}, []uuid.UUID{canceled, failedPodcast}},
+		{"created range", models.JobListFilter{CreatedAfter: &after, CreatedBefore: &before}, []uuid.UUID{succeeded}},
+		{"query", models.JobListFilter{Query: "water cycle", Types: []string{"educational"}, AudioTypes: []string{"podcast"}}, []uuid.UUID{canceled}},
+	} {
+		page, err := svc.ListJobs(ctx, userID, 20, "", tc.filter)
+		if err != nil {
+			t.Fatalf("%s: ListJobs: %v", tc.name, err)
+		}
+		var got []uuid.UUID
+		for _, j := range page.Jobs {
+			got = append(got, j.ID)
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: jobs = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	for _, bad := range []models.JobListFilter{
+		{Statuses: []string{"done"}},
+		{Types: []string{"poetry"}},
+		{AudioTypes: []string{"music"}},
+		{CreatedAfter: &before, CreatedBefore: &after},
+		{Query: strings.Repeat("x", maxJobQueryLength+1)},
+	} {
+		if _, err := svc.ListJobs(ctx, userID, 20, "", bad); err == nil || !strings.HasPrefix(err.Error(), "validation error") {
+			t.Errorf("ListJobs(%+v) error = %v, want a validation error", bad, err)
+		}
+	}
+}
+
+func TestListSegments_Pagination(t *testing.T) {
+	userID := uuid.New()
+	jobID := uuid.New()
+	jobRepo := newFakeJobRepo()
+	jobRepo.Create(context.Background(), &models.Job{ID: jobID, UserID: userID, Status: "succeeded", CreatedAt: time.Now()})
+
+	segRepo := pagedSegmentRepo{}
+	for i := 0; i < 5; i++ {
+		segRepo.segments = append(segRepo.segments, &models.Segment{ID: uuid.New(), JobID: jobID, Idx: i})
+	}
+
+	svc := NewJobService(
+		&fakeJobRepoGetByIDErr{fakeJobRepo: jobRepo},
+		segRepo,
+		fakeAssetRepo{},
+		fakeJobFileRepo{},
+		newFakeFileRepo(),
+		fakeFactCheckRepo{},
+		nil,
+		newFakeAPIKeyRepo(nil),
+		noopJobPublisher{},
+		&config.Config{JobDetailPageSize: 2},
+	)
+	ctx := context.Background()
+
+	var seen []int
+	cursor := ""
+	for pages := 0; pages < 10; pages++ {
+		page, err := svc.ListSegments(ctx, jobID, userID, 2, cursor)
+		if err != nil {
+			t.Fatalf("ListSegments: %v", err)
+		}
+		if page.Total != 5 {
+			t.Errorf("total = %d, want 5", page.Total)
+		}
+		for _, seg := range page.Segments {
+			seen = append(seen, seg.Idx)
+		}
+		if !page.HasMore {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	if len(seen) != 5 || seen[0] != 0 || seen[4] != 4 {
+		t.Errorf("paged idx = %v, want 0..4", seen)
+	}
+
+	got, err := svc.GetJob(ctx, jobID, userID)
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	if len(got.Segments) != 2 || got.SegmentsTotal != 5 || got.SegmentsNextCursor == "" {
+		t.Errorf("GetJob first page: %d segments, total %d, cursor %q", len(got.Segments), got.SegmentsTotal, got.SegmentsNextCursor)
+	}
+
+	if _, err := svc.ListSegments(ctx, jobID, userID, 2, "not-a-cursor"); err == nil || !strings.Contains(err.Error(), "validation error") {
+		t.Errorf("expected validation error for bad cursor, got %v", err)
+	}
+	if _, err := svc.ListSegments(ctx, jobID, uuid.New(), 2, ""); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("expected access denied, got %v", err)
+	}
+}
+
+func TestListJobs_CursorSameTimestamp(t *testing.T) {
+	jobRepo := newFakeJobRepo()
+	userID := uuid.New()
+	created := time.Now().Truncate(time.Second)
+	for i := 0; i < 5; i++ {
+		jobRepo.Create(context.Background(), &models.Job{ID: uuid.New(), UserID: userID, Status: "queued", CreatedAt: created})
+	}
+	svc := NewJobService(
+		jobRepo,
+		fakeSegmentRepo{},
+		fakeAssetRepo{},
+		fakeJobFileRepo{},
+		newFakeFileRepo(),
+		fakeFactCheckRepo{},
+		nil,
+		newFakeAPIKeyRepo(nil),
+		noopJobPublisher{},
+		config.Load(),
+	)
+	ctx := context.Background()
+
+	seen := make(map[uuid.UUID]int)
+	cursor := ""
+	for pages := 0; pages < 10; pages++ {
+		page, err := svc.ListJobs(ctx, userID, 2, cursor, models.JobListFilter{})
+		if err != nil {
+			t.Fatalf("ListJobs: %v", err)
+		}
+		for _, j := range page.Jobs {
+			seen[j.ID]++
+		}
+		if !page.HasMore {
+			if page.NextCursor != "" {
+				t.Errorf("last page should not have next_cursor")
+			}
+			break
+		}
+		cursor = page.NextCursor
+	}
+	if len(seen) != 5 {
+		t.Errorf("saw %d distinct jobs, want 5", len(seen))
+	}
+	for id, n := range seen {
+		if n != 1 {
+			t.Errorf("job %s returned %d times", id, n)
+		}
+	}
+
+	if _, err := svc.ListJobs(ctx, userID, 2, "garbage!", models.JobListFilter{}); err == nil || !strings.Contains(err.Error(), "validation error") {
+		t.Errorf("expected validation error for bad cursor, got %v", err)
+	}
+}
+
+func TestCreateJob_RecordsEvents(t *testing.T) {
+	cfg := &config.Config{MaxFilesPerJob: 10, MaxInputLength: 50000, MaxSegmentsCount: 5, CharsPerFile: 1000}
+	userID := uuid.New()
+	apiKey := &models.APIKey{ID: uuid.New(), UserID: userID, QuotaChars: 100000, PeriodStartedAt: time.Now(), QuotaPeriod: "monthly"}
+	events := &fakeJobEventRepo{}
+	svc := NewJobService(
+		&fakeJobRepoGetByIDErr{fakeJobRepo: newFakeJobRepo()},
+		fakeSegmentRepo{},
+		fakeAssetRepo{},
+		fakeJobFileRepo{},
+		newFakeFileRepo(),
+		fakeFactCheckRepo{},
+		events,
+		newFakeAPIKeyRepo(apiKey),
+		noopJobPublisher{},
+		cfg,
+	)
+	ctx := context.Background()
+
+	resp, err := svc.CreateJob(ctx, &models.CreateJobRequest{Text: "Hello.", Type: "educational", SegmentsCount: 1, AudioType: "free_speech"}, userID, apiKey.ID)
+	if err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+
+	got, err := svc.ListJobEvents(ctx, resp.JobID, userID)
+	if err != nil {
+		t.Fatalf("ListJobEvents: %v", err)
+	}
+	if len(got) != 2 || got[0].Type != models.JobEventCreated || got[1].Type != models.JobEventQueued {
+		types := make([]string, len(got))
+		for i, ev := range got {
+			types[i] = ev.Type
+		}
+		t.Errorf("event types = %v, want [created queued]", types)
+	}
+
+	if _, err := svc.ListJobEvents(ctx, resp.JobID, uuid.New()); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("expected access denied for other user, got %v", err)
+	}
+}
+
+func TestCreateJob_MetadataAndTags(t *testing.T) {
+	cfg := &config.Config{MaxFilesPerJob: 10, MaxInputLength: 50000, MaxSegmentsCount: 5, CharsPerFile: 1000}
+	userID := uuid.New()
+	apiKey := &models.APIKey{ID: uuid.New(), UserID: userID, QuotaChars: 100000, PeriodStartedAt: time.Now(), QuotaPeriod: "monthly"}
+	jobRepo := newFakeJobRepo()
+	svc := NewJobService(
+		&fakeJobRepoGetByIDErr{fakeJobRepo: jobRepo},
+		fakeSegmentRepo{},
+		fakeAssetRepo{},
+		fakeJobFileRepo{},
+		newFakeFileRepo(),
+		fakeFactCheckRepo{},
+		nil,
+		newFakeAPIKeyRepo(apiKey),
+		noopJobPublisher{},
+		cfg,
+	)
+	ctx := context.Background()
+
+	base := models.CreateJobRequest{Text: "Hello.", Type: "educational", SegmentsCount: 1, AudioType: "free_speech"}
+	tagged := base
+	tagged.Metadata = map[string]string{"doc_id": "D-42"}
+	tagged.Tags = []string{"finance", "q3"}
+	resp, err := svc.CreateJob(ctx, &tagged, userID, apiKey.ID)
+	if err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	if _, err := svc.CreateJob(ctx, &base, userID, apiKey.ID); err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+
+	got, err := svc.GetJob(ctx, resp.JobID, userID)
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	if got.Job.Metadata["doc_id"] != "D-42" || len(got.Job.Tags) != 2 {
+		t.Errorf("labels not stored: metadata=%v tags=%v", got.Job.Metadata, got.Job.Tags)
+	}
+
+	page, err := svc.ListJobs(ctx, userID, 20, "", models.JobListFilter{Tags: []string{"q3"}, Metadata: map[string]string{"doc_id": "D-42"}})
+	if err != nil {
+		t.Fatalf("ListJobs: %v", err)
+	}
+	if len(page.Jobs) != 1 || page.Jobs[0].ID != resp.JobID {
+		t.Errorf("filtered ListJobs returned %d jobs, want only the tagged one", len(page.Jobs))
+	}
+
+	tooMany := base
+	tooMany.Tags = make([]string, maxJobTags+1)
+	for i := range tooMany.Tags {
+		tooMany.Tags[i] = "t"
+	}
+	if _, err := svc.CreateJob(ctx, &tooMany, userID, apiKey.ID); err == nil || !strings.Contains(err.Error(), "tags exceeds maximum") {
+		t.Errorf("expected tags limit error, got %v", err)
+	}
+}
+
+func TestCreateJob_Dedupe(t *testing.T) {
+	cfg := &config.Config{MaxFilesPerJob: 10, MaxInputLength: 50000, MaxSegmentsCount: 5, CharsPerFile: 1000}
+	userID := uuid.New()
+	apiKey := &models.APIKey{ID: uuid.New(), UserID: userID, QuotaChars: 100000, PeriodStartedAt: time.Now(), QuotaPeriod: "monthly"}
+	jobRepo := newFakeJobRepo()
+	svc := NewJobService(
+		&fakeJobRepoGetByIDErr{fakeJobRepo: jobRepo},
+		fakeSegmentRepo{},
+		fakeAssetRepo{},
+		fakeJobFileRepo{},
+		newFakeFileRepo(),
+		fakeFactCheckRepo{},
+		nil,
+		newFakeAPIKeyRepo(apiKey),
+		noopJobPublisher{},
+		cfg,
+	)
+	ctx := context.Background()
+
+	req := models.CreateJobRequest{Text: "Hello.", Type: "educational", SegmentsCount: 1, AudioType: "free_speech", Dedupe: true}
+	first, err := svc.CreateJob(ctx, &req, userID, apiKey.ID)
+	if err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	if first.DuplicateOf != nil {
+		t.Fatalf("first job linked to %s, want no source", first.DuplicateOf)
+	}
+
+	// The quota is used up: a duplicate is free, anything else is rejected
+	apiKey.UsedCharsInPeriod = apiKey.QuotaChars
+	second, err := svc.CreateJob(ctx, &req, userID, apiKey.ID)
+	if err != nil {
+		t.Fatalf("CreateJob duplicate: %v", err)
+	}
+	if second.DuplicateOf == nil || *second.DuplicateOf != first.JobID {
+		t.Errorf("duplicate_of = %v, want %s", second.DuplicateOf, first.JobID)
+	}
+	got, err := svc.GetJob(ctx, second.JobID, userID)
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	if got.Job.DuplicateOf == nil || *got.Job.DuplicateOf != first.JobID {
+		t.Errorf("stored duplicate_of = %v, want %s", got.Job.DuplicateOf, first.JobID)
+	}
+
+	other := req
+	other.SegmentsCount = 2
+	if _, err := svc.CreateJob(ctx, &other, userID, apiKey.ID); err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("different options must not dedupe, got %v", err)
+	}
+	noDedupe := req
+	noDedupe.Dedupe = false
+	if _, err := svc.CreateJob(ctx, &noDedupe, userID, apiKey.ID); err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Errorf("dedupe=false must not link, got %v", err)
+	}
+}
+
+func TestCreateJob_CostCap(t *testing.T) {
+	cfg := &config.Config{MaxFilesPerJob: 10, MaxInputLength: 50000, MaxSegmentsCount: 5, CharsPerFile: 1000}
+	userID := uuid.New()
+	capUSD := 10.0
+	apiKey := &models.APIKey{ID: uuid.New(), UserID: userID, QuotaChars: 100000, PeriodStartedAt: time.Now(), QuotaPeriod: "monthly",
+		CostCapUSD: &capUSD, UsedCostInPeriod: 10.5}
+	svc := NewJobService(newFakeJobRepo(), fakeSegmentRepo{}, fakeAssetRepo{}, fakeJobFileRepo{}, newFakeFileRepo(),
+		fakeFactCheckRepo{}, nil, newFakeAPIKeyRepo(apiKey), noopJobPublisher{}, cfg)
+	ctx := context.Background()
+	req := models.CreateJobRequest{Text: "Hello.", Type: "educational", SegmentsCount: 1, AudioType: "free_speech"}
+
+	if _, err := svc.CreateJob(ctx, &req, userID, apiKey.ID); err == nil || err.Error() != "cost cap reached: 10.50/10.00 USD used" {
+		t.Errorf("expected cost cap error, got %v", err)
+	}
+	// queue: the job is created and waits in the worker
+	cfg.CostCapAction = models.CostCapQueue
+	if _, err := svc.CreateJob(ctx, &req, userID, apiKey.ID); err != nil {
+		t.Errorf("CreateJob with queue action: %v", err)
+	}
+	// A new period starts from zero
+	cfg.CostCapAction = models.CostCapReject
+	apiKey.PeriodStartedAt = time.Now().Add(-31 * 24 * time.Hour)
+	if _, err := svc.CreateJob(ctx, &req, userID, apiKey.ID); err != nil {
+		t.Errorf("CreateJob in a new period: %v", err)
+	}
+}
+
+func TestCreateJob_DependsOn(t *testing.T) {
+	cfg := &config.Config{MaxFilesPerJob: 10, MaxInputLength: 50000, MaxSegmentsCount: 5, CharsPerFile: 1000}
+	userID := uuid.New()
+	apiKey := &models.APIKey{ID: uuid.New(), UserID: userID, QuotaChars: 100000, PeriodStartedAt: time.Now(), QuotaPeriod: "monthly"}
+	jobRepo := newFakeJobRepo()
+	svc := NewJobService(
+		&fakeJobRepoGetByIDErr{fakeJobRepo: jobRepo},
+		fakeSegmentRepo{},
+		fakeAssetRepo{},
+		fakeJobFileRepo{},
+		newFakeFileRepo(),
+		fakeFactCheckRepo{},
+		nil,
+		newFakeAPIKeyRepo(apiKey),
+		noopJobPublisher{},
+		cfg,
+	)
+	ctx := context.Background()
+
+	base := models.CreateJobRequest{Text: "Hello.", Type: "educational", SegmentsCount: 1, AudioType: "free_speech"}
+	parent, err := svc.CreateJob(ctx, &base, userID, apiKey.ID)
+	if err != nil {
+		t.Fatalf("CreateJob parent: %v", err)
+	}
+
+	// Without text or files the input is the parent's segments
+	req := models.CreateJobRequest{Type: "educational", SegmentsCount: 1, AudioType: "free_speech", DependsOn: []uuid.UUID{parent.JobID}}
+	child, err := svc.CreateJob(ctx, &req, userID, apiKey.ID)
+	if err != nil {
+		t.Fatalf("CreateJob dependent: %v", err)
+	}
+	got, err := svc.GetJob(ctx, child.JobID, userID)
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	if got.Job.InputSource != "job" {
+		t.Errorf("input_source = %q, want job", got.Job.InputSource)
+	}
+	if len(got.Job.DependsOn) != 1 || got.Job.DependsOn[0] != parent.JobID {
+		t.Errorf("depends_on = %v, want [%s]", got.Job.DependsOn, parent.JobID)
+	}
+
+	otherUser, err := svc.CreateJob(ctx, &base, uuid.New(), apiKey.ID)
+	if err != nil {
+		t.Fatalf("CreateJob other user: %v", err)
+	}
+	failed, err := svc.CreateJob(ctx, &base, userID, apiKey.ID)
+	if err != nil {
+		t.Fatalf("CreateJob failed parent: %v", err)
+	}
+	jobRepo.jobs[failed.JobID].Status = models.JobStatusFailed
+	tests := []struct {
+		name      string
+		dependsOn []uuid.UUID
+		wantErr   string
+	}{
+		{"unknown job", []uuid.UUID{uuid.New()}, "not found"},
+		{"other user's job", []uuid.UUID{otherUser.JobID}, "not found"},
+		{"failed job", []uuid.UUID{failed.JobID}, "is failed"},
+		{"duplicate job", []uuid.UUID{parent.JobID, parent.JobID}, "duplicate depends_on"},
+		{"too many jobs", make([]uuid.UUID, maxJobDependencies+1), "exceeds maximum"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := req
+			r.DependsOn = tt.dependsOn
+			if _, err := svc.CreateJob(ctx, &r, userID, apiKey.ID); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveExtractionOptions(t *testing.T) {
+	defaults := &models.ExtractionOptions{Language: "de"}
+	override := &models.ExtractionOptions{Handwriting: true}
+	if got := resolveExtractionOptions(nil, defaults); got != defaults {
+		t.Errorf("without override = %+v, want the file defaults", got)
+	}
+	if got := resolveExtractionOptions(override, defaults); got != override {
+		t.Errorf("with override = %+v, want the override", got)
+	}
+	// An empty override clears the file's defaults for the job
+	if got := resolveExtractionOptions(&models.ExtractionOptions{}, defaults); got != nil {
+		t.Errorf("with empty override = %+v, want nil", got)
+	}
+}
+
+func TestJobContentHash_FileOptions(t *testing.T) {
+	req := &models.CreateJobRequest{FileIDs: []uuid.UUID{uuid.New()}, Type: "educational", SegmentsCount: 2, AudioType: "podcast"}
+	plain := jobContentHash(req, "llm", nil)
+	if got := jobContentHash(req, "llm", []*models.ExtractionOptions{nil}); got != plain {
+		t.Error("unset options should not change the hash")
+	}
+	if got := jobContentHash(req, "llm", []*models.ExtractionOptions{{PreserveTables: true}}); got == plain {
+		t.Error("extraction options should change the hash")
+	}
+}
+
+func TestJobTextStats(t *testing.T) {
+	title := "Intro"
+	segments := []*models.Segment{{ID: uuid.New(), Title: &title, SegmentText: "One two three."}}
+	stats := jobTextStats(&models.Job{}, segments)
+	if stats == nil || stats.Words != 4 || len(stats.Segments) != 1 || stats.Segments[0].SegmentID != segments[0].ID.String() {
+		t.Errorf("stats from segments = %+v, want 4 words in one segment", stats)
+	}
+
+	markup := "[[SEGMENT id=s1]]\n# Intro\n\nOne two.\n[[/SEGMENT]]\n"
+	stats = jobTextStats(&models.Job{OutputMarkup: &markup}, segments)
+	if stats == nil || stats.Words != 3 || stats.Segments[0].SegmentID != "s1" {
+		t.Errorf("stats from markup = %+v, want 3 words of segment s1", stats)
+	}
+	if jobTextStats(&models.Job{}, nil) != nil {
+		t.Error("want no stats without markup or segments")
+	}
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/llm"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// ErrImageGenerating is returned by LazyImageService.Generate while another API replica generates the image.
+var ErrImageGenerating = errors.New("image is being generated")
+
+// lazyImageLease bounds how long one replica may take to generate a pending image before another may try.
+const lazyImageLease = 2 * time.Minute
+
+// ImageGenerator generates an image from a prompt (llm.Client).
+type ImageGenerator interface {
+	GenerateImage(ctx context.Context, prompt string) (*llm.Image, error)
+}
+
+// pendingImageRepository is the subset of asset DB operations used to generate pending images.
+type pendingImageRepository interface {
+	GetByID(ctx context.Context, assetID uuid.UUID) (*models.Asset, error)
+	ClaimPendingImage(ctx context.Context, assetID uuid.UUID, lease time.Duration) (string, error)
+	ReleasePendingImage(ctx context.Context, assetID uuid.UUID) error
+	CompletePendingImage(ctx context.Context, asset *models.Asset) error
+}
+
+// LazyImageService generates the images of lazy_images jobs on their first download: the worker stored
+// only the prompt (models.Asset.Pending), the image is generated here, uploaded to the asset's key and
+// recorded, and later downloads read it from storage like any asset.
+type LazyImageService struct {
+	assets    pendingImageRepository
+	generator ImageGenerator
+	storage   assetStorage
+
+	mu       sync.Mutex
+	inflight map[uuid.UUID]*lazyImageCall // concurrent downloads in this replica share one generation
+}
+
+type lazyImageCall struct {
+	done  chan struct{}
+	asset *models.Asset
+	err   error
+}
+
+// NewLazyImageService creates a new LazyImageService
+func NewLazyImageService(assets pendingImageRepository, generator ImageGenerator, storage assetStorage) *LazyImageService {
+	return &LazyImageService{
+		assets:    assets,
+		generator: generator,
+		storage:   storage,
+		inflight:  make(map[uuid.UUID]*lazyImageCall),
+	}
+}
+
+// Generate returns asset with its image generated: asset itself unless it is pending, else the asset as
+// recorded after generating and uploading its image. Concurrent calls for the same asset wait for one
+// generation; ErrImageGenerating means another replica holds it. A failed generation is tried again on
+// the next call.
+func (s *LazyImageService) Generate(ctx context.Context, asset *models.Asset) (*models.Asset, error) {
+	if !asset.Pending() {
+		return asset, nil
+	}
+	s.mu.Lock()
+	call, ok := s.inflight[asset.ID]
+	if !ok {
+		call = &lazyImageCall{done: make(chan struct{})}
+		s.inflight[asset.ID] = call
+		s.mu.Unlock()
+		// Detached from the request: a client giving up does not waste the generation for the next one
+		call.asset, call.err = s.generate(context.WithoutCancel(ctx), asset)
+		s.mu.Lock()
+		delete(s.inflight, asset.ID)
+		close(call.done)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.asset, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *LazyImageService) generate(ctx context.Context, asset *models.Asset) (*models.Asset, error) {
+	prompt, err := s.assets.ClaimPendingImage(ctx, asset.ID, lazyImageLease)
+	if errors.Is(err, database.ErrPendingImageClaimed) {
+		return nil, ErrImageGenerating
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		// Generated by another replica since asset was read
+		return s.assets.GetByID(ctx, asset.ID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim pending image: %w", err)
+	}
+
+	generated, err := s.generateClaimed(ctx, asset, prompt)
+	if err != nil {
+		if releaseErr := s.assets.ReleasePendingImage(ctx, asset.ID); releaseErr != nil {
+			log.Warn().Err(releaseErr).Str("asset_id", asset.ID.String()).Msg("Failed to release pending image")
+		}
+		return nil, err
+	}
+	return generated, nil
+}
+
+// generateClaimed generates, uploads and records the image of a pending asset claimed for this call.
+func (s *LazyImageService) generateClaimed(ctx context.Context, asset *models.Asset, prompt string) (*models.Asset, error) {
+	start := time.Now()
+	image, err := s.generator.GenerateImage(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("image generation failed: %w", err)
+	}
+	data, err := io.ReadAll(image.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+
+	generated := *asset
+	generated.MimeType = image.MimeType
+	if generated.MimeType == "" {
+		generated.MimeType = asset.MimeType
+	}
+	// The worker chose the key for a PNG; keep the extension in line with the actual format
+	if t, ok := replacementTypes["image"][generated.MimeType]; ok {
+		generated.S3Key = strings.TrimSuffix(asset.S3Key, path.Ext(asset.S3Key)) + "." + t.ext
+	}
+	generated.SizeBytes = int64(len(data))
+	generated.Meta = map[string]any{
+		"resolution": image.Resolution,
+		"model":      image.Model,
+	}
+	if generated.S3Bucket, err = s.storage.Upload(ctx, generated.S3Key, bytes.NewReader(data), generated.MimeType, generated.SizeBytes); err != nil {
+		return nil, fmt.Errorf("failed to upload image: %w", err)
+	}
+	if err := s.assets.CompletePendingImage(ctx, &generated); err != nil {
+		return nil, fmt.Errorf("failed to record generated image: %w", err)
+	}
+	log.Info().
+		Str("asset_id", asset.ID.String()).
+		Str("job_id", asset.JobID.String()).
+		Int64("size_bytes", generated.SizeBytes).
+		Int64("duration_ms", time.Since(start).Milliseconds()).
+		Msg("Lazy image generated")
+	return &generated, nil
+}
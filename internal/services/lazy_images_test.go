@@ -0,0 +1,199 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/llm"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// fakePendingImages holds one pending asset and its prompt.
+type fakePendingImages struct {
+	mu        sync.Mutex
+	asset     *models.Asset
+	prompt    string
+	pending   bool
+	claimed   bool
+	completed int
+}
+
+func (f *fakePendingImages) GetByID(ctx context.Context, assetID uuid.UUID) (*models.Asset, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	clone := *f.asset
+	return &clone, nil
+}
+
+func (f *fakePendingImages) ClaimPendingImage(ctx context.Context, assetID uuid.UUID, lease time.Duration) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case !f.pending:
+		return "", sql.ErrNoRows
+	case f.claimed:
+		return "", database.ErrPendingImageClaimed
+	}
+	f.claimed = true
+	return f.prompt, nil
+}
+
+func (f *fakePendingImages) ReleasePendingImage(ctx context.Context, assetID uuid.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.claimed = false
+	return nil
+}
+
+func (f *fakePendingImages) CompletePendingImage(ctx context.Context, asset *models.Asset) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	clone := *asset
+	f.asset = &clone
+	f.pending = false
+	f.completed++
+	return nil
+}
+
+// fakeImageGenerator returns a JPEG-typed image, blocking on release when set.
+type fakeImageGenerator struct {
+	calls   atomic.Int32
+	prompts []string
+	release chan struct{}
+	err     error
+}
+
+func (g *fakeImageGenerator) GenerateImage(ctx context.Context, prompt string) (*llm.Image, error) {
+	g.calls.Add(1)
+	if g.release != nil {
+		<-g.release
+	}
+	if g.err != nil {
+		return nil, g.err
+	}
+	g.prompts = append(g.prompts, prompt)
+	data := []byte("jpeg bytes")
+	return &llm.Image{Data: bytes.NewReader(data), Size: int64(len(data)), MimeType: "image/jpeg", Resolution: "1024x1024", Model: "image-model"}, nil
+}
+
+func newPendingImage() *fakePendingImages {
+	jobID := uuid.New()
+	return &fakePendingImages{
+		asset: &models.Asset{
+			ID: uuid.New(), JobID: jobID, Kind: "image", MimeType: "image/png",
+			S3Key: "jobs/" + jobID.String() + "/segments/0/image.v2.png", Version: 2,
+			Meta: map[string]any{"pending": true},
+		},
+		prompt:  "a lighthouse at dusk",
+		pending: true,
+	}
+}
+
+func TestLazyImageService_Generate(t *testing.T) {
+	ctx := context.Background()
+	repo := newPendingImage()
+	gen := &fakeImageGenerator{}
+	store := &memStorage{objects: map[string][]byte{}}
+	svc := NewLazyImageService(repo, gen, store)
+
+	pending, _ := repo.GetByID(ctx, repo.asset.ID)
+	asset, err := svc.Generate(ctx, pending)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if asset.Pending() || asset.MimeType != "image/jpeg" || asset.SizeBytes != 10 || asset.Meta["model"] != "image-model" {
+		t.Errorf("generated asset = %+v", asset)
+	}
+	wantKey := "jobs/" + asset.JobID.String() + "/segments/0/image.v2.jpg"
+	if asset.S3Key != wantKey || string(store.objects[wantKey]) != "jpeg bytes" {
+		t.Errorf("key = %s, stored %v; want %s", asset.S3Key, store.objects, wantKey)
+	}
+	if len(gen.prompts) != 1 || gen.prompts[0] != "a lighthouse at dusk" {
+		t.Errorf("prompts = %v", gen.prompts)
+	}
+	if repo.completed != 1 || repo.asset.Pending() {
+		t.Errorf("pending image not completed: %+v", repo.asset)
+	}
+
+	// Assets that are not pending, including one generated since it was read, are not generated again
+	if _, err := svc.Generate(ctx, asset); err != nil {
+		t.Fatalf("Generate of generated asset: %v", err)
+	}
+	again, err := svc.Generate(ctx, pending)
+	if err != nil || again.S3Key != wantKey {
+		t.Errorf("Generate of stale pending asset = %+v, %v", again, err)
+	}
+	if n := gen.calls.Load(); n != 1 {
+		t.Errorf("GenerateImage calls = %d, want 1", n)
+	}
+}
+
+func TestLazyImageService_GenerateConcurrent(t *testing.T) {
+	ctx := context.Background()
+	repo := newPendingImage()
+	gen := &fakeImageGenerator{release: make(chan struct{})}
+	svc := NewLazyImageService(repo, gen, &memStorage{objects: map[string][]byte{}})
+
+	pending := repo.asset
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.Generate(ctx, pending)
+			errs <- err
+		}()
+	}
+	// Let the other calls join before the generation finishes; late ones find the image generated
+	for gen.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(gen.release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Generate: %v", err)
+		}
+	}
+	if n := gen.calls.Load(); n != 1 {
+		t.Errorf("GenerateImage calls = %d, want 1", n)
+	}
+}
+
+func TestLazyImageService_GenerateFailures(t *testing.T) {
+	ctx := context.Background()
+
+	// Claimed by another replica
+	repo := newPendingImage()
+	repo.claimed = true
+	svc := NewLazyImageService(repo, &fakeImageGenerator{}, &memStorage{objects: map[string][]byte{}})
+	if _, err := svc.Generate(ctx, repo.asset); !errors.Is(err, ErrImageGenerating) {
+		t.Errorf("err = %v, want ErrImageGenerating", err)
+	}
+
+	// A failed generation releases the claim for the next download
+	repo = newPendingImage()
+	gen := &fakeImageGenerator{err: errors.New("quota exceeded")}
+	svc = NewLazyImageService(repo, gen, &memStorage{objects: map[string][]byte{}})
+	if _, err := svc.Generate(ctx, repo.asset); err == nil {
+		t.Fatal("Generate succeeded, want error")
+	}
+	if repo.claimed || !repo.pending {
+		t.Errorf("claimed = %v, pending = %v after failure", repo.claimed, repo.pending)
+	}
+	gen.err = nil
+	if _, err := svc.Generate(ctx, repo.asset); err != nil {
+		t.Errorf("retry: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/maintenance"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// ErrInvalidMaintenance wraps the reasons a maintenance mode change is refused
+var ErrInvalidMaintenance = errors.New("invalid maintenance")
+
+const (
+	defaultMaintenanceRetryAfter = 300
+	maxMaintenanceRetryAfter     = 86400
+	maxMaintenanceReasonLen      = 500
+)
+
+// MaintenanceService turns maintenance mode on and off (admin API).
+type MaintenanceService struct {
+	repo *database.MaintenanceRepository
+	sw   *maintenance.Switch
+}
+
+// NewMaintenanceService creates a new MaintenanceService; sw is invalidated on every change so this
+// replica applies it at once (the others and the workers within MAINTENANCE_REFRESH).
+func NewMaintenanceService(repo *database.MaintenanceRepository, sw *maintenance.Switch) *MaintenanceService {
+	return &MaintenanceService{repo: repo, sw: sw}
+}
+
+// GetMaintenance returns the maintenance mode state
+func (s *MaintenanceService) GetMaintenance(ctx context.Context) (*models.Maintenance, error) {
+	m, err := s.repo.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get maintenance mode: %w", err)
+	}
+	return m, nil
+}
+
+// SetMaintenance turns maintenance mode on or off
+func (s *MaintenanceService) SetMaintenance(ctx context.Context, req *models.MaintenanceRequest) (*models.Maintenance, error) {
+	if req.Enabled == nil {
+		return nil, fmt.Errorf("%w: enabled is required", ErrInvalidMaintenance)
+	}
+	if len(req.Reason) > maxMaintenanceReasonLen {
+		return nil, fmt.Errorf("%w: reason exceeds %d characters", ErrInvalidMaintenance, maxMaintenanceReasonLen)
+	}
+	retryAfter := defaultMaintenanceRetryAfter
+	if req.RetryAfterSeconds != nil {
+		retryAfter = *req.RetryAfterSeconds
+		if retryAfter < 1 || retryAfter > maxMaintenanceRetryAfter {
+			return nil, fmt.Errorf("%w: retry_after_seconds must be 1-%d", ErrInvalidMaintenance, maxMaintenanceRetryAfter)
+		}
+	}
+	m, err := s.repo.Set(ctx, *req.Enabled, req.Reason, retryAfter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set maintenance mode: %w", err)
+	}
+	s.sw.Invalidate()
+	return m, nil
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/ssml"
+	"github.com/snappy-loop/stories/internal/textdiff"
+)
+
+// NarrationDiff returns, for each segment of a job owned by the user, a word diff from the segment text
+// to its narration script (control tags removed), so reviewers can check the narration stays faithful
+// to the source. Segments without a narration yet have no diff.
+func (s *JobService) NarrationDiff(ctx context.Context, jobID, userID uuid.UUID) ([]*models.SegmentNarrationDiff, error) {
+	job, err := s.ownedJob(ctx, jobID, userID)
+	if err != nil {
+		return nil, err
+	}
+	segments, err := s.segmentRepo.ListByJob(ctx, job.ResultJobID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get segments: %w", err)
+	}
+
+	diffs := make([]*models.SegmentNarrationDiff, len(segments))
+	for i, seg := range segments {
+		d := &models.SegmentNarrationDiff{SegmentID: seg.ID, Idx: seg.Idx, Title: seg.Title}
+		if seg.NarrationText != nil && *seg.NarrationText != "" {
+			ops := textdiff.Words(seg.SegmentText, ssml.Strip(*seg.NarrationText))
+			d.HasNarration = true
+			d.HTML = textdiff.HTML(ops)
+			d.RemovedWords, d.AddedWords = textdiff.Counts(ops)
+		}
+		diffs[i] = d
+	}
+	return diffs, nil
+}
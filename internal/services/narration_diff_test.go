@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/config"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+func TestNarrationDiff(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	jobRepo := newFakeJobRepo()
+	jobID := uuid.New()
+	jobRepo.Create(ctx, &models.Job{ID: jobID, UserID: userID, Status: models.JobStatusSucceeded, CreatedAt: time.Now()})
+	narration := `Revenue rose <break time="1s"/> <emphasis>sharply</emphasis> in Q3.`
+	segments := listSegmentRepo{jobID: jobID, segments: []*models.Segment{
+		{ID: uuid.New(), JobID: jobID, Idx: 0, SegmentText: "Revenue rose 5% in Q3.", NarrationText: &narration},
+		{ID: uuid.New(), JobID: jobID, Idx: 1, SegmentText: "Pending."},
+	}}
+	svc := NewJobService(
+		&fakeJobRepoGetByIDErr{fakeJobRepo: jobRepo},
+		segments,
+		fakeAssetRepo{},
+		fakeJobFileRepo{},
+		newFakeFileRepo(),
+		fakeFactCheckRepo{},
+		&fakeJobEventRepo{},
+		newFakeAPIKeyRepo(nil),
+		noopJobPublisher{},
+		&config.Config{},
+	)
+
+	diffs, err := svc.NarrationDiff(ctx, jobID, userID)
+	if err != nil {
+		t.Fatalf("NarrationDiff: %v", err)
+	}
+	if len(diffs) != 2 {
+		t.Fatalf("diffs = %+v, want two", diffs)
+	}
+	d := diffs[0]
+	if !d.HasNarration || !strings.Contains(d.HTML, "<del>5%</del>") || !strings.Contains(d.HTML, "<ins>sharply</ins>") || strings.Contains(d.HTML, "break") {
+		t.Errorf("diff = %+v, want 5%% removed, sharply added and no tags", d)
+	}
+	if d.RemovedWords != 1 || d.AddedWords != 1 {
+		t.Errorf("counts = -%d +%d, want -1 +1", d.RemovedWords, d.AddedWords)
+	}
+	if diffs[1].HasNarration || diffs[1].HTML != "" {
+		t.Errorf("segment without narration = %+v, want no diff", diffs[1])
+	}
+	if _, err := svc.NarrationDiff(ctx, jobID, uuid.New()); err == nil {
+		t.Error("other user: want error")
+	}
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/egress"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/notify"
+)
+
+// ErrInvalidNotificationSink wraps the reasons a sink cannot be created
+var ErrInvalidNotificationSink = errors.New("invalid notification sink")
+
+// maxNotificationSinks limits the sinks per user
+const maxNotificationSinks = 20
+
+// maxNotificationTemplate limits the length of a sink's message template
+const maxNotificationTemplate = 2000
+
+// snsTopicARNRe matches SNS topic ARNs (arn:aws:sns:<region>:<account>:<name>)
+var snsTopicARNRe = regexp.MustCompile(`^arn:aws[a-z-]*:sns:[a-z0-9-]+:\d{12}:[A-Za-z0-9_-]{1,256}(\.fifo)?$`)
+
+// NotificationService manages users' notification sinks
+type NotificationService struct {
+	sinkRepo   *database.NotificationSinkRepository
+	egress     *egress.Policy
+	egressRepo egressAllowlistRepository
+}
+
+// NewNotificationService creates a new NotificationService
+func NewNotificationService(sinkRepo *database.NotificationSinkRepository) *NotificationService {
+	return &NotificationService{sinkRepo: sinkRepo}
+}
+
+// SetEgress sets the egress policy and the repository of users' egress allow lists checked against the
+// targets of webhook, Slack and Teams sinks; without them targets are only checked when delivering.
+func (s *NotificationService) SetEgress(policy *egress.Policy, r egressAllowlistRepository) {
+	s.egress = policy
+	s.egressRepo = r
+}
+
+// CreateSink adds a notification sink receiving the job events of all of the user's jobs
+func (s *NotificationService) CreateSink(ctx context.Context, userID uuid.UUID, req *models.CreateNotificationSinkRequest) (*models.NotificationSink, error) {
+	if err := validateNotificationSink(req); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNotificationSink, err)
+	}
+	switch req.Type {
+	case models.SinkTypeWebhook, models.SinkTypeSlack, models.SinkTypeTeams:
+		if err := checkDestination(ctx, s.egress, s.egressRepo, userID, req.Target); err != nil {
+			if errors.Is(err, egress.ErrDenied) {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidNotificationSink, err)
+			}
+			return nil, err
+		}
+	}
+	existing, err := s.sinkRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification sinks: %w", err)
+	}
+	if len(existing) >= maxNotificationSinks {
+		return nil, fmt.Errorf("%w: at most %d sinks are allowed", ErrInvalidNotificationSink, maxNotificationSinks)
+	}
+
+	sink := &models.NotificationSink{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Type:      req.Type,
+		Target:    req.Target,
+		Secret:    req.Secret,
+		Events:    req.Events,
+		Template:  req.Template,
+		Enabled:   true,
+		CreatedAt: time.Now(),
+	}
+	if sink.Events == nil {
+		sink.Events = []string{}
+	}
+	if err := s.sinkRepo.Create(ctx, sink); err != nil {
+		return nil, fmt.Errorf("failed to create notification sink: %w", err)
+	}
+	return sink, nil
+}
+
+// ListSinks returns the user's notification sinks
+func (s *NotificationService) ListSinks(ctx context.Context, userID uuid.UUID) ([]*models.NotificationSink, error) {
+	sinks, err := s.sinkRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification sinks: %w", err)
+	}
+	if sinks == nil {
+		sinks = []*models.NotificationSink{}
+	}
+	return sinks, nil
+}
+
+// DeleteSink removes one of the user's sinks; database.ErrNotificationSinkNotFound when there is none
+func (s *NotificationService) DeleteSink(ctx context.Context, userID, sinkID uuid.UUID) error {
+	return s.sinkRepo.Delete(ctx, sinkID, userID)
+}
+
+// validateNotificationSink checks the sink type, its target, its template and the subscribed events.
+func validateNotificationSink(req *models.CreateNotificationSinkRequest) error {
+	switch req.Type {
+	case models.SinkTypeWebhook, models.SinkTypeSlack, models.SinkTypeTeams:
+		u, err := url.Parse(req.Target)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("invalid target: %s sinks need an http(s) URL", req.Type)
+		}
+		if req.Type != models.SinkTypeWebhook && u.Scheme != "https" {
+			return fmt.Errorf("invalid target: %s sinks need an https incoming webhook URL", req.Type)
+		}
+	case models.SinkTypeEmail:
+		addr, err := mail.ParseAddress(req.Target)
+		if err != nil || addr.Address != req.Target {
+			return fmt.Errorf("invalid target: email sinks need a plain email address")
+		}
+	case models.SinkTypeSNS:
+		if !snsTopicARNRe.MatchString(req.Target) {
+			return fmt.Errorf("invalid target: sns sinks need an SNS topic ARN")
+		}
+	default:
+		return fmt.Errorf("invalid type: must be webhook, email, slack, teams or sns")
+	}
+	if req.Secret != nil && req.Type != models.SinkTypeWebhook {
+		return fmt.Errorf("secret is only supported by webhook sinks")
+	}
+	if req.Template != nil {
+		if req.Type != models.SinkTypeSlack && req.Type != models.SinkTypeTeams {
+			return fmt.Errorf("template is only supported by slack and teams sinks")
+		}
+		if len(*req.Template) > maxNotificationTemplate {
+			return fmt.Errorf("template must be at most %d characters", maxNotificationTemplate)
+		}
+		if err := notify.ValidateTemplate(*req.Template); err != nil {
+			return err
+		}
+	}
+	for _, event := range req.Events {
+		if !slices.Contains(models.NotificationEvents, event) {
+			return fmt.Errorf("invalid event %q: must be one of %v", event, models.NotificationEvents)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+func TestValidateNotificationSink(t *testing.T) {
+	secret := "s3cret"
+	tmpl := "{{.Heading}}: {{.StatusText}}"
+	badTmpl := "{{.Heading"
+	tests := []struct {
+		name    string
+		req     models.CreateNotificationSinkRequest
+		wantErr string
+	}{
+		{"webhook", models.CreateNotificationSinkRequest{Type: "webhook", Target: "https://example.com/hook", Secret: &secret}, ""},
+		{"slack", models.CreateNotificationSinkRequest{Type: "slack", Target: "https://hooks.slack.com/services/T0/B0/x"}, ""},
+		{"email", models.CreateNotificationSinkRequest{Type: "email", Target: "ops@example.com", Events: []string{"job_failed"}}, ""},
+		{"teams with template", models.CreateNotificationSinkRequest{Type: "teams", Target: "https://example.webhook.office.com/webhookb2/x", Template: &tmpl}, ""},
+		{"sns", models.CreateNotificationSinkRequest{Type: "sns", Target: "arn:aws:sns:us-east-1:123456789012:stories-events"}, ""},
+		{"unknown type", models.CreateNotificationSinkRequest{Type: "pager", Target: "x"}, "invalid type"},
+		{"webhook not a URL", models.CreateNotificationSinkRequest{Type: "webhook", Target: "example.com/hook"}, "http(s) URL"},
+		{"slack over http", models.CreateNotificationSinkRequest{Type: "slack", Target: "http://hooks.slack.com/x"}, "https"},
+		{"email with name", models.CreateNotificationSinkRequest{Type: "email", Target: "Ops <ops@example.com>"}, "plain email address"},
+		{"sns not an ARN", models.CreateNotificationSinkRequest{Type: "sns", Target: "stories-events"}, "SNS topic ARN"},
+		{"secret on slack", models.CreateNotificationSinkRequest{Type: "slack", Target: "https://hooks.slack.com/x", Secret: &secret}, "only supported by webhook"},
+		{"teams over http", models.CreateNotificationSinkRequest{Type: "teams", Target: "http://example.webhook.office.com/x"}, "https"},
+		{"invalid template", models.CreateNotificationSinkRequest{Type: "slack", Target: "https://hooks.slack.com/x", Template: &badTmpl}, "invalid template"},
+		{"template on webhook", models.CreateNotificationSinkRequest{Type: "webhook", Target: "https://example.com/hook", Template: &tmpl}, "only supported by slack and teams"},
+		{"unknown event", models.CreateNotificationSinkRequest{Type: "email", Target: "ops@example.com", Events: []string{"job_started"}}, "invalid event"},
+	}
+	for _, tt := range tests {
+		err := validateNotificationSink(&tt.req)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// ErrOutputNotRestorable is returned by RestoreOutputVersion when the job is not finished (versions can be
+// restored on succeeded jobs and jobs awaiting review) or one of its segments is being regenerated.
+var ErrOutputNotRestorable = errors.New("output version cannot be restored now")
+
+// SetOutputVersions sets the repository of job output versions (snapshots recorded by the worker).
+func (s *JobService) SetOutputVersions(r outputVersionRepository) {
+	s.versionRepo = r
+}
+
+// ListOutputVersions returns the output versions of a job, oldest first. Duplicates list the versions of
+// the job they reuse.
+func (s *JobService) ListOutputVersions(ctx context.Context, jobID, userID uuid.UUID) ([]*models.OutputVersion, error) {
+	if s.versionRepo == nil {
+		return nil, fmt.Errorf("output versions not configured")
+	}
+	job, err := s.ownedJob(ctx, jobID, userID)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := s.versionRepo.List(ctx, job.ResultJobID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list output versions: %w", err)
+	}
+	if versions == nil {
+		versions = []*models.OutputVersion{}
+	}
+	return versions, nil
+}
+
+// GetOutputVersion returns one output version of a job with its markup and segment texts.
+func (s *JobService) GetOutputVersion(ctx context.Context, jobID, userID uuid.UUID, version int) (*models.OutputVersion, error) {
+	if s.versionRepo == nil {
+		return nil, fmt.Errorf("output versions not configured")
+	}
+	job, err := s.ownedJob(ctx, jobID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.versionRepo.Get(ctx, job.ResultJobID(), version)
+}
+
+// RestoreOutputVersion makes an earlier output version current: its markup, segment texts and assets
+// replace the current ones and the result is recorded as a new version, so a restore can itself be undone.
+func (s *JobService) RestoreOutputVersion(ctx context.Context, jobID, userID uuid.UUID, version int) (*models.OutputVersion, error) {
+	if s.versionRepo == nil {
+		return nil, fmt.Errorf("output versions not configured")
+	}
+	job, err := s.ownedJob(ctx, jobID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if job.DuplicateOf != nil {
+		return nil, fmt.Errorf("validation error: job reuses the output of job %s; restore versions there", job.DuplicateOf)
+	}
+	if err := s.versionRepo.Restore(ctx, jobID, version); err != nil {
+		if errors.Is(err, database.ErrInvalidJobTransition) || errors.Is(err, database.ErrSegmentBusy) {
+			return nil, ErrOutputNotRestorable
+		}
+		return nil, err
+	}
+
+	s.recordEvent(ctx, jobID, models.JobEventOutputRestored, fmt.Sprintf("Output version %d restored", version), map[string]any{
+		"version": version,
+		"user_id": userID.String(),
+	})
+	log.Info().Str("job_id", jobID.String()).Int("version", version).Msg("Output version restored")
+
+	versions, err := s.versionRepo.List(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load restored version: %w", err)
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("restored version not found: job_id=%s", jobID)
+	}
+	return s.versionRepo.Get(ctx, jobID, versions[len(versions)-1].Version)
+}
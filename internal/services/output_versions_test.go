@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/config"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// memVersionRepo keeps output versions per job; Restore appends a "restored" copy of the version.
+type memVersionRepo struct {
+	versions map[uuid.UUID][]*models.OutputVersion
+	busy     bool
+}
+
+func (r *memVersionRepo) List(ctx context.Context, jobID uuid.UUID) ([]*models.OutputVersion, error) {
+	list := r.versions[jobID]
+	for i, v := range list {
+		v.Current = i == len(list)-1
+	}
+	return list, nil
+}
+
+func (r *memVersionRepo) Get(ctx context.Context, jobID uuid.UUID, version int) (*models.OutputVersion, error) {
+	list, _ := r.List(ctx, jobID)
+	for _, v := range list {
+		if v.Version == version {
+			return v, nil
+		}
+	}
+	return nil, errNotFound
+}
+
+func (r *memVersionRepo) Restore(ctx context.Context, jobID uuid.UUID, version int) error {
+	if r.busy {
+		return database.ErrSegmentBusy
+	}
+	v, err := r.Get(ctx, jobID, version)
+	if err != nil {
+		return err
+	}
+	restored := *v
+	restored.Version = len(r.versions[jobID]) + 1
+	restored.Reason = models.OutputVersionRestored
+	r.versions[jobID] = append(r.versions[jobID], &restored)
+	return nil
+}
+
+func TestOutputVersions(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	jobRepo := newFakeJobRepo()
+	jobID, dupID := uuid.New(), uuid.New()
+	jobRepo.Create(ctx, &models.Job{ID: jobID, UserID: userID, Status: models.JobStatusSucceeded, CreatedAt: time.Now()})
+	jobRepo.Create(ctx, &models.Job{ID: dupID, UserID: userID, Status: models.JobStatusSucceeded, DuplicateOf: &jobID, CreatedAt: time.Now()})
+	first, second := "[[SEGMENT 0]] first", "[[SEGMENT 0]] edited"
+	versions := &memVersionRepo{versions: map[uuid.UUID][]*models.OutputVersion{jobID: {
+		{JobID: jobID, Version: 1, Reason: models.OutputVersionGenerated, OutputMarkup: &first},
+		{JobID: jobID, Version: 2, Reason: models.OutputVersionSegmentEdit, OutputMarkup: &second},
+	}}}
+	events := &fakeJobEventRepo{}
+	svc := NewJobService(
+		&fakeJobRepoGetByIDErr{fakeJobRepo: jobRepo},
+		fakeSegmentRepo{},
+		fakeAssetRepo{},
+		fakeJobFileRepo{},
+		newFakeFileRepo(),
+		fakeFactCheckRepo{},
+		events,
+		newFakeAPIKeyRepo(nil),
+		noopJobPublisher{},
+		&config.Config{},
+	)
+	svc.SetOutputVersions(versions)
+
+	list, err := svc.ListOutputVersions(ctx, dupID, userID)
+	if err != nil {
+		t.Fatalf("ListOutputVersions(duplicate): %v", err)
+	}
+	if len(list) != 2 || !list[1].Current || list[0].Current {
+		t.Errorf("duplicate versions = %+v, want the source job's two versions with v2 current", list)
+	}
+	if _, err := svc.ListOutputVersions(ctx, jobID, uuid.New()); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("other user: got %v, want access denied", err)
+	}
+	if _, err := svc.RestoreOutputVersion(ctx, dupID, userID, 1); err == nil || !strings.HasPrefix(err.Error(), "validation error") {
+		t.Errorf("restore on duplicate: got %v, want validation error", err)
+	}
+
+	versions.busy = true
+	if _, err := svc.RestoreOutputVersion(ctx, jobID, userID, 1); !errors.Is(err, ErrOutputNotRestorable) {
+		t.Errorf("busy segment: got %v, want ErrOutputNotRestorable", err)
+	}
+	versions.busy = false
+
+	got, err := svc.RestoreOutputVersion(ctx, jobID, userID, 1)
+	if err != nil {
+		t.Fatalf("RestoreOutputVersion: %v", err)
+	}
+	if got.Version != 3 || got.Reason != models.OutputVersionRestored || !got.Current || got.OutputMarkup == nil || *got.OutputMarkup != first {
+		t.Errorf("restored version = %+v", got)
+	}
+	if len(events.events) != 1 || events.events[0].Type != models.JobEventOutputRestored {
+		t.Errorf("events = %+v, want one output_restored", events.events)
+	}
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+var (
+	// ErrJobNotRetryable is returned by RetryJob when the job has not failed.
+	ErrJobNotRetryable = errors.New("only failed jobs can be retried")
+	// ErrDuplicateNotRetryable is returned by RetryJob for a duplicate (dedupe): it only copies its source.
+	ErrDuplicateNotRetryable = errors.New("duplicate jobs copy the results of their source job; retry the source job instead")
+)
+
+// RetryJob queues a failed job again. It keeps what the failed run produced: the worker reuses the
+// extracted text of the job's files and its segments, and only generates the segments that did not
+// succeed (a job that failed before segmentation is segmented again, from the boundary cache when the text
+// was segmented before). The retry is not charged against the quota again.
+func (s *JobService) RetryJob(ctx context.Context, jobID, userID uuid.UUID) (*models.JobStatusResponse, error) {
+	job, err := s.ownedJob(ctx, jobID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if job.DuplicateOf != nil {
+		return nil, ErrDuplicateNotRetryable
+	}
+	if err := s.jobRepo.ResetForRetry(ctx, jobID); err != nil {
+		if errors.Is(err, database.ErrInvalidJobTransition) {
+			return nil, ErrJobNotRetryable
+		}
+		return nil, fmt.Errorf("failed to retry job: %w", err)
+	}
+
+	data := map[string]any{"user_id": userID.String()}
+	if job.ErrorCode != nil {
+		data["error_code"] = *job.ErrorCode
+	}
+	s.recordEvent(ctx, jobID, models.JobEventRetryRequested, "Retry requested by user", data)
+	s.publishJob(ctx, jobID)
+
+	log.Info().Str("job_id", jobID.String()).Msg("Failed job queued for retry")
+	return s.GetJob(ctx, jobID, userID)
+}
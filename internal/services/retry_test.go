@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+func TestRetryJob(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	jobRepo := newFakeJobRepo()
+	code, msg := "processing_error", "segment 1: tts failed"
+	jobID := uuid.New()
+	jobRepo.Create(ctx, &models.Job{ID: jobID, UserID: userID, Status: models.JobStatusFailed, ErrorCode: &code, ErrorMessage: &msg, CreatedAt: time.Now()})
+	dupID := uuid.New()
+	jobRepo.Create(ctx, &models.Job{ID: dupID, UserID: userID, Status: models.JobStatusFailed, DuplicateOf: &jobID, CreatedAt: time.Now()})
+	publisher := &recordingPublisher{}
+	events := &fakeJobEventRepo{}
+	svc := newReviewTestService(jobRepo, publisher, events)
+
+	if _, err := svc.RetryJob(ctx, jobID, uuid.New()); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Fatalf("expected access denied for other user, got %v", err)
+	}
+	if _, err := svc.RetryJob(ctx, dupID, userID); !errors.Is(err, ErrDuplicateNotRetryable) {
+		t.Errorf("retry of duplicate: got %v, want ErrDuplicateNotRetryable", err)
+	}
+
+	got, err := svc.RetryJob(ctx, jobID, userID)
+	if err != nil {
+		t.Fatalf("RetryJob: %v", err)
+	}
+	if got.Job.Status != models.JobStatusQueued || got.Job.ErrorCode != nil {
+		t.Errorf("job = %+v, want queued without error", got.Job)
+	}
+	if len(publisher.jobs) != 1 || publisher.jobs[0] != jobID {
+		t.Errorf("published jobs = %v, want [%s]", publisher.jobs, jobID)
+	}
+	evs, _ := events.ListByJob(ctx, jobID)
+	if len(evs) == 0 || evs[0].Type != models.JobEventRetryRequested || evs[0].Data["error_code"] != code {
+		t.Errorf("unexpected events %+v", evs)
+	}
+
+	if _, err := svc.RetryJob(ctx, jobID, userID); !errors.Is(err, ErrJobNotRetryable) {
+		t.Errorf("retry of queued job: got %v, want ErrJobNotRetryable", err)
+	}
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// ErrJobNotAwaitingReview is returned by the review actions when the job is not in awaiting_review
+// (it was created without require_review, is still being generated, or was already approved).
+var ErrJobNotAwaitingReview = errors.New("job is not awaiting review")
+
+// maxReviewCommentLength caps reviewer comments, which are passed to the LLM as feedback.
+const maxReviewCommentLength = 2000
+
+// SetWebhookPublisher sets the publisher used for the job_completed webhook sent on approval.
+func (s *JobService) SetWebhookPublisher(p WebhookPublisher) {
+	s.webhooks = p
+}
+
+// ApproveJob approves a job awaiting review: it becomes succeeded, its job_completed webhook is sent and
+// jobs deduplicated against it are queued again so the worker can complete them.
+func (s *JobService) ApproveJob(ctx context.Context, jobID, userID uuid.UUID, req *models.ReviewApproval) (*models.JobStatusResponse, error) {
+	if req == nil {
+		req = &models.ReviewApproval{}
+	}
+	if len(req.Comment) > maxReviewCommentLength {
+		return nil, fmt.Errorf("validation error: comment exceeds maximum length of %d", maxReviewCommentLength)
+	}
+	if _, err := s.ownedJob(ctx, jobID, userID); err != nil {
+		return nil, err
+	}
+	if err := s.jobRepo.ApproveReview(ctx, jobID); err != nil {
+		if errors.Is(err, database.ErrInvalidJobTransition) {
+			return nil, ErrJobNotAwaitingReview
+		}
+		return nil, fmt.Errorf("failed to approve job: %w", err)
+	}
+
+	data := map[string]any{"user_id": userID.String()}
+	if req.Comment != "" {
+		data["comment"] = req.Comment
+	}
+	s.recordEvent(ctx, jobID, models.JobEventReviewApproved, "Approved by reviewer", data)
+	s.recordEvent(ctx, jobID, models.JobEventSucceeded, "Job succeeded", nil)
+	s.recordCompleted(ctx, jobID)
+
+	if s.webhooks != nil {
+		if err := s.webhooks.PublishWebhook(ctx, jobID, models.EventJobCompleted, ""); err != nil {
+			log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to publish webhook event to Kafka")
+		}
+	}
+	s.requeueDuplicates(ctx, jobID)
+	s.requeueDependents(ctx, jobID)
+
+	log.Info().Str("job_id", jobID.String()).Str("user_id", userID.String()).Msg("Job approved")
+	return s.GetJob(ctx, jobID, userID)
+}
+
+// RequestSegmentRegeneration sends a job awaiting review back to the worker to regenerate the given
+// segments, using the reviewer's comment as feedback. The job returns to awaiting_review afterwards.
+func (s *JobService) RequestSegmentRegeneration(ctx context.Context, jobID, userID uuid.UUID, req *models.ReviewRegeneration) (*models.JobStatusResponse, error) {
+	if req == nil || len(req.Segments) == 0 {
+		return nil, fmt.Errorf("validation error: segments is required")
+	}
+	if len(req.Comment) > maxReviewCommentLength {
+		return nil, fmt.Errorf("validation error: comment exceeds maximum length of %d", maxReviewCommentLength)
+	}
+	job, err := s.ownedJob(ctx, jobID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != models.JobStatusAwaitingReview {
+		return nil, ErrJobNotAwaitingReview
+	}
+
+	count, err := s.segmentRepo.CountByJob(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count segments: %w", err)
+	}
+	seen := make(map[int]bool, len(req.Segments))
+	segments := make([]int, 0, len(req.Segments))
+	for _, idx := range req.Segments {
+		if idx < 0 || idx >= count {
+			return nil, fmt.Errorf("validation error: segment %d does not exist (job has %d segments)", idx, count)
+		}
+		if !seen[idx] {
+			seen[idx] = true
+			segments = append(segments, idx)
+		}
+	}
+	regeneration := &models.ReviewRegeneration{Segments: segments, Comment: req.Comment}
+
+	if err := s.jobRepo.RequestRegeneration(ctx, jobID, regeneration); err != nil {
+		if errors.Is(err, database.ErrInvalidJobTransition) {
+			return nil, ErrJobNotAwaitingReview
+		}
+		return nil, fmt.Errorf("failed to request regeneration: %w", err)
+	}
+
+	data := map[string]any{"user_id": userID.String(), "segments": segments}
+	if req.Comment != "" {
+		data["comment"] = req.Comment
+	}
+	s.recordEvent(ctx, jobID, models.JobEventChangesRequested,
+		fmt.Sprintf("Reviewer requested regeneration of %d segment(s)", len(segments)), data)
+	s.publishJob(ctx, jobID)
+
+	return s.GetJob(ctx, jobID, userID)
+}
+
+// requeueDuplicates publishes the queued duplicates of an approved job again: they wait for their source,
+// and the worker only completes them from a terminal source.
+func (s *JobService) requeueDuplicates(ctx context.Context, jobID uuid.UUID) {
+	ids, err := s.jobRepo.ListQueuedDuplicates(ctx, jobID)
+	if err != nil {
+		log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to list duplicate jobs")
+		return
+	}
+	for _, id := range ids {
+		s.publishJob(ctx, id)
+	}
+}
+
+// publishJob queues a job for the worker and records the outcome in its event log (no-op without a publisher).
+func (s *JobService) publishJob(ctx context.Context, jobID uuid.UUID) {
+	if s.jobPublisher == nil {
+		return
+	}
+	traceID := uuid.New().String()
+	if err := s.jobPublisher.PublishJob(ctx, jobID, traceID); err != nil {
+		log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to publish job to Kafka")
+		s.recordEvent(ctx, jobID, models.JobEventPublishFailed, "Failed to queue job", map[string]any{"error": err.Error()})
+		return
+	}
+	s.recordEvent(ctx, jobID, models.JobEventQueued, "Job queued for processing", map[string]any{"trace_id": traceID})
+}
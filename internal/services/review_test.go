@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/config"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// recordingPublisher records published jobs, segment edits and webhook events.
+type recordingPublisher struct {
+	mu       sync.Mutex
+	jobs     []uuid.UUID
+	webhooks []string
+	edits    []int
+}
+
+func (p *recordingPublisher) PublishJob(ctx context.Context, jobID uuid.UUID, traceID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.jobs = append(p.jobs, jobID)
+	return nil
+}
+
+func (p *recordingPublisher) PublishSegmentEdit(ctx context.Context, jobID uuid.UUID, idx int, regenerateImage bool, traceID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.edits = append(p.edits, idx)
+	return nil
+}
+
+func (p *recordingPublisher) PublishWebhook(ctx context.Context, jobID uuid.UUID, event, traceID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.webhooks = append(p.webhooks, event)
+	return nil
+}
+
+// countingSegmentRepo reports a fixed number of segments per job.
+type countingSegmentRepo struct {
+	fakeSegmentRepo
+	count int
+}
+
+func (r countingSegmentRepo) CountByJob(context.Context, uuid.UUID) (int, error) {
+	return r.count, nil
+}
+
+func newReviewTestService(jobRepo *fakeJobRepo, publisher *recordingPublisher, events *fakeJobEventRepo) *JobService {
+	svc := NewJobService(
+		&fakeJobRepoGetByIDErr{fakeJobRepo: jobRepo},
+		countingSegmentRepo{count: 3},
+		fakeAssetRepo{},
+		fakeJobFileRepo{},
+		newFakeFileRepo(),
+		fakeFactCheckRepo{},
+		events,
+		newFakeAPIKeyRepo(nil),
+		publisher,
+		&config.Config{},
+	)
+	svc.SetWebhookPublisher(publisher)
+	return svc
+}
+
+func TestApproveJob(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	jobRepo := newFakeJobRepo()
+	jobID := uuid.New()
+	jobRepo.Create(ctx, &models.Job{ID: jobID, UserID: userID, Status: models.JobStatusAwaitingReview, RequireReview: true, CreatedAt: time.Now()})
+	dupID := uuid.New()
+	jobRepo.Create(ctx, &models.Job{ID: dupID, UserID: userID, Status: models.JobStatusQueued, DuplicateOf: &jobID, CreatedAt: time.Now()})
+	publisher := &recordingPublisher{}
+	events := &fakeJobEventRepo{}
+	svc := newReviewTestService(jobRepo, publisher, events)
+	ledger := &fakeUsageLedger{}
+	svc.SetUsageLedger(ledger)
+
+	if _, err := svc.ApproveJob(ctx, jobID, uuid.New(), nil); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Fatalf("expected access denied for other user, got %v", err)
+	}
+
+	got, err := svc.ApproveJob(ctx, jobID, userID, &models.ReviewApproval{Comment: "looks good"})
+	if err != nil {
+		t.Fatalf("ApproveJob: %v", err)
+	}
+	if got.Job.Status != models.JobStatusSucceeded {
+		t.Errorf("status = %s, want succeeded", got.Job.Status)
+	}
+	if len(publisher.webhooks) != 1 || publisher.webhooks[0] != "job_completed" {
+		t.Errorf("webhooks = %v, want [job_completed]", publisher.webhooks)
+	}
+	if len(publisher.jobs) != 1 || publisher.jobs[0] != dupID {
+		t.Errorf("republished jobs = %v, want the queued duplicate %s", publisher.jobs, dupID)
+	}
+	evs, _ := events.ListByJob(ctx, jobID)
+	if len(evs) != 2 || evs[0].Type != models.JobEventReviewApproved || evs[1].Type != models.JobEventSucceeded {
+		t.Errorf("unexpected events %v", evs)
+	}
+	if len(ledger.completed) != 1 || ledger.completed[0] != jobID {
+		t.Errorf("completed usage = %v, want [%s]", ledger.completed, jobID)
+	}
+
+	if _, err := svc.ApproveJob(ctx, jobID, userID, nil); !errors.Is(err, ErrJobNotAwaitingReview) {
+		t.Errorf("second approval: got %v, want ErrJobNotAwaitingReview", err)
+	}
+	if len(publisher.webhooks) != 1 {
+		t.Errorf("webhook published again on a rejected approval")
+	}
+}
+
+func TestRequestSegmentRegeneration(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	jobRepo := newFakeJobRepo()
+	jobID := uuid.New()
+	jobRepo.Create(ctx, &models.Job{ID: jobID, UserID: userID, Status: models.JobStatusAwaitingReview, RequireReview: true, CreatedAt: time.Now()})
+	publisher := &recordingPublisher{}
+	svc := newReviewTestService(jobRepo, publisher, &fakeJobEventRepo{})
+
+	for _, req := range []*models.ReviewRegeneration{
+		{},
+		{Segments: []int{3}},
+		{Segments: []int{-1}},
+	} {
+		if _, err := svc.RequestSegmentRegeneration(ctx, jobID, userID, req); err == nil || !strings.HasPrefix(err.Error(), "validation error") {
+			t.Errorf("segments %v: got %v, want validation error", req.Segments, err)
+		}
+	}
+
+	got, err := svc.RequestSegmentRegeneration(ctx, jobID, userID, &models.ReviewRegeneration{Segments: []int{2, 0, 2}, Comment: "Less formal"})
+	if err != nil {
+		t.Fatalf("RequestSegmentRegeneration: %v", err)
+	}
+	if got.Job.Status != models.JobStatusQueued {
+		t.Errorf("status = %s, want queued", got.Job.Status)
+	}
+	if rr := got.Job.ReviewRegeneration; rr == nil || len(rr.Segments) != 2 || rr.Segments[0] != 2 || rr.Segments[1] != 0 || rr.Comment != "Less formal" {
+		t.Errorf("review_regeneration = %+v, want segments [2 0] with the comment", rr)
+	}
+	if len(publisher.jobs) != 1 || publisher.jobs[0] != jobID {
+		t.Errorf("published jobs = %v, want %s", publisher.jobs, jobID)
+	}
+	if len(publisher.webhooks) != 0 {
+		t.Errorf("no webhook expected before approval, got %v", publisher.webhooks)
+	}
+
+	if _, err := svc.RequestSegmentRegeneration(ctx, jobID, userID, &models.ReviewRegeneration{Segments: []int{0}}); !errors.Is(err, ErrJobNotAwaitingReview) {
+		t.Errorf("regeneration of a queued job: got %v, want ErrJobNotAwaitingReview", err)
+	}
+}
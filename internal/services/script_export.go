@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/teleprompter"
+)
+
+// ErrNoScript is returned by GetScript for a job without segments yet.
+var ErrNoScript = errors.New("job has no narration script yet")
+
+// GetScript returns the narration scripts of a job owned by the user as a teleprompter document: the
+// title from the job's metadata (else its first segment) and one section per segment. Segments without
+// a narration script (not generated yet or failed) show their segment text.
+func (s *JobService) GetScript(ctx context.Context, jobID, userID uuid.UUID) (*teleprompter.Script, error) {
+	job, err := s.ownedJob(ctx, jobID, userID)
+	if err != nil {
+		return nil, err
+	}
+	segments, err := s.segmentRepo.ListByJob(ctx, job.ResultJobID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get segments: %w", err)
+	}
+	if len(segments) == 0 {
+		return nil, ErrNoScript
+	}
+
+	script := &teleprompter.Script{Title: job.Metadata["title"]}
+	for _, seg := range segments {
+		title := fmt.Sprintf("Part %d", seg.Idx+1)
+		if seg.Title != nil && *seg.Title != "" {
+			title = *seg.Title
+		}
+		narration := seg.SegmentText
+		if seg.NarrationText != nil && *seg.NarrationText != "" {
+			narration = *seg.NarrationText
+		}
+		script.Sections = append(script.Sections, teleprompter.Section{Number: seg.Idx + 1, Title: title, Narration: narration})
+	}
+	if script.Title == "" {
+		script.Title = script.Sections[0].Title
+	}
+	return script, nil
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/config"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// listSegmentRepo lists fixed segments of one job.
+type listSegmentRepo struct {
+	fakeSegmentRepo
+	jobID    uuid.UUID
+	segments []*models.Segment
+}
+
+func (r listSegmentRepo) ListByJob(ctx context.Context, jobID uuid.UUID) ([]*models.Segment, error) {
+	if jobID != r.jobID {
+		return nil, nil
+	}
+	return r.segments, nil
+}
+
+func TestGetScript(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	jobRepo := newFakeJobRepo()
+	jobID, dupID, emptyID := uuid.New(), uuid.New(), uuid.New()
+	jobRepo.Create(ctx, &models.Job{ID: jobID, UserID: userID, Status: models.JobStatusSucceeded, CreatedAt: time.Now()})
+	jobRepo.Create(ctx, &models.Job{ID: dupID, UserID: userID, Status: models.JobStatusSucceeded, DuplicateOf: &jobID, CreatedAt: time.Now()})
+	jobRepo.Create(ctx, &models.Job{ID: emptyID, UserID: userID, Status: models.JobStatusQueued, CreatedAt: time.Now()})
+	title, narration := "Intro", "Welcome <break/> to the show."
+	segments := listSegmentRepo{jobID: jobID, segments: []*models.Segment{
+		{ID: uuid.New(), JobID: jobID, Idx: 0, Title: &title, SegmentText: "Welcome.", NarrationText: &narration},
+		{ID: uuid.New(), JobID: jobID, Idx: 1, SegmentText: "Not narrated yet."},
+	}}
+	svc := NewJobService(
+		&fakeJobRepoGetByIDErr{fakeJobRepo: jobRepo},
+		segments,
+		fakeAssetRepo{},
+		fakeJobFileRepo{},
+		newFakeFileRepo(),
+		fakeFactCheckRepo{},
+		&fakeJobEventRepo{},
+		newFakeAPIKeyRepo(nil),
+		noopJobPublisher{},
+		&config.Config{},
+	)
+
+	script, err := svc.GetScript(ctx, dupID, userID)
+	if err != nil {
+		t.Fatalf("GetScript(duplicate): %v", err)
+	}
+	if script.Title != "Intro" || len(script.Sections) != 2 {
+		t.Fatalf("script = %+v, want the source job's two sections titled Intro", script)
+	}
+	if s := script.Sections[0]; s.Number != 1 || s.Narration != narration {
+		t.Errorf("section 1 = %+v", s)
+	}
+	if s := script.Sections[1]; s.Title != "Part 2" || s.Narration != "Not narrated yet." {
+		t.Errorf("section 2 = %+v, want segment text fallback", s)
+	}
+	if _, err := svc.GetScript(ctx, emptyID, userID); !errors.Is(err, ErrNoScript) {
+		t.Errorf("no segments: got %v, want ErrNoScript", err)
+	}
+	if _, err := svc.GetScript(ctx, jobID, uuid.New()); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("other user: got %v, want access denied", err)
+	}
+}
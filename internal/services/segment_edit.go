@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/ssml"
+)
+
+// ErrSegmentNotEditable is returned by UpdateSegment when the job is not finished (segments can be edited on
+// succeeded jobs and jobs awaiting review) or the segment is still being generated.
+var ErrSegmentNotEditable = errors.New("segment cannot be edited now")
+
+// maxNarrationTextLength caps an edited narration script (the TTS input of one segment).
+const maxNarrationTextLength = 10000
+
+// UpdateSegment stores a user's edit of one segment and queues regeneration of just that segment's audio
+// (and image with regenerate_image). The worker rebuilds the job's markup once the new assets are stored.
+func (s *JobService) UpdateSegment(ctx context.Context, jobID, userID uuid.UUID, idx int, req *models.UpdateSegmentRequest) (*models.Segment, error) {
+	if err := s.validateUpdateSegmentRequest(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	job, err := s.ownedJob(ctx, jobID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if job.DuplicateOf != nil {
+		return nil, fmt.Errorf("validation error: job reuses the segments of job %s; edit them there", job.DuplicateOf)
+	}
+	if job.Status != models.JobStatusSucceeded && job.Status != models.JobStatusAwaitingReview {
+		return nil, ErrSegmentNotEditable
+	}
+
+	segment, err := s.segmentRepo.GetByJobIdx(ctx, jobID, idx)
+	if err != nil {
+		return nil, fmt.Errorf("segment not found: %w", err)
+	}
+	if err := s.segmentRepo.ApplyEdit(ctx, segment.ID, req); err != nil {
+		if errors.Is(err, database.ErrSegmentBusy) {
+			return nil, ErrSegmentNotEditable
+		}
+		return nil, fmt.Errorf("failed to update segment: %w", err)
+	}
+
+	s.recordEvent(ctx, jobID, models.JobEventSegmentEdited, fmt.Sprintf("Segment %d edited", idx), map[string]any{
+		"segment_idx":      idx,
+		"stage":            "requested",
+		"segment_text":     req.SegmentText != nil,
+		"narration_text":   req.NarrationText != nil,
+		"regenerate_image": req.RegenerateImage,
+	})
+	if s.jobPublisher != nil {
+		traceID := uuid.New().String()
+		if err := s.jobPublisher.PublishSegmentEdit(ctx, jobID, idx, req.RegenerateImage, traceID); err != nil {
+			log.Error().Err(err).Str("job_id", jobID.String()).Int("segment", idx).Msg("Failed to publish segment edit to Kafka")
+			s.recordEvent(ctx, jobID, models.JobEventPublishFailed, "Failed to queue segment edit", map[string]any{
+				"segment_idx": idx,
+				"error":       err.Error(),
+			})
+		}
+	}
+
+	return s.segmentRepo.GetByJobIdx(ctx, jobID, idx)
+}
+
+func (s *JobService) validateUpdateSegmentRequest(req *models.UpdateSegmentRequest) error {
+	if req.SegmentText == nil && req.NarrationText == nil {
+		return fmt.Errorf("segment_text or narration_text is required")
+	}
+	if req.SegmentText != nil {
+		if strings.TrimSpace(*req.SegmentText) == "" {
+			return fmt.Errorf("segment_text must not be empty")
+		}
+		if s.config.MaxInputLength > 0 && len(*req.SegmentText) > s.config.MaxInputLength {
+			return fmt.Errorf("segment_text exceeds maximum length of %d", s.config.MaxInputLength)
+		}
+	}
+	if req.NarrationText != nil {
+		if strings.TrimSpace(*req.NarrationText) == "" {
+			return fmt.Errorf("narration_text must not be empty")
+		}
+		if len(*req.NarrationText) > maxNarrationTextLength {
+			return fmt.Errorf("narration_text exceeds maximum length of %d", maxNarrationTextLength)
+		}
+		if err := ssml.Validate(*req.NarrationText); err != nil {
+			return fmt.Errorf("narration_text: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/config"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// editableSegmentRepo holds one segment and applies edits like the real ApplyEdit.
+type editableSegmentRepo struct {
+	fakeSegmentRepo
+	segment *models.Segment
+}
+
+func (r *editableSegmentRepo) GetByJobIdx(ctx context.Context, jobID uuid.UUID, idx int) (*models.Segment, error) {
+	if r.segment.JobID != jobID || r.segment.Idx != idx {
+		return nil, errNotFound
+	}
+	clone := *r.segment
+	return &clone, nil
+}
+
+func (r *editableSegmentRepo) ApplyEdit(ctx context.Context, segmentID uuid.UUID, req *models.UpdateSegmentRequest) error {
+	if r.segment.Status != "succeeded" && r.segment.Status != "failed" {
+		return database.ErrSegmentBusy
+	}
+	if req.SegmentText != nil {
+		r.segment.SegmentText = *req.SegmentText
+		r.segment.NarrationText = nil
+	}
+	if req.NarrationText != nil {
+		r.segment.NarrationText = req.NarrationText
+	}
+	r.segment.Status = "queued"
+	return nil
+}
+
+func TestUpdateSegment(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	jobRepo := newFakeJobRepo()
+	jobID := uuid.New()
+	jobRepo.Create(ctx, &models.Job{ID: jobID, UserID: userID, Status: models.JobStatusSucceeded, CreatedAt: time.Now()})
+	script := "Welcome to the show."
+	segments := &editableSegmentRepo{segment: &models.Segment{
+		ID: uuid.New(), JobID: jobID, Idx: 1, SegmentText: "Welcome.", NarrationText: &script, Status: "succeeded",
+	}}
+	publisher := &recordingPublisher{}
+	svc := NewJobService(
+		&fakeJobRepoGetByIDErr{fakeJobRepo: jobRepo},
+		segments,
+		fakeAssetRepo{},
+		fakeJobFileRepo{},
+		newFakeFileRepo(),
+		fakeFactCheckRepo{},
+		&fakeJobEventRepo{},
+		newFakeAPIKeyRepo(nil),
+		publisher,
+		&config.Config{MaxInputLength: 100},
+	)
+
+	empty := "  "
+	for _, req := range []*models.UpdateSegmentRequest{
+		{},
+		{NarrationText: &empty},
+		{SegmentText: func() *string { s := strings.Repeat("a", 101); return &s }()},
+	} {
+		if _, err := svc.UpdateSegment(ctx, jobID, userID, 1, req); err == nil || !strings.HasPrefix(err.Error(), "validation error") {
+			t.Errorf("%+v: got %v, want validation error", req, err)
+		}
+	}
+
+	fixed := "Welcome to the show, friends."
+	got, err := svc.UpdateSegment(ctx, jobID, userID, 1, &models.UpdateSegmentRequest{NarrationText: &fixed})
+	if err != nil {
+		t.Fatalf("UpdateSegment: %v", err)
+	}
+	if got.Status != "queued" || got.NarrationText == nil || *got.NarrationText != fixed || got.SegmentText != "Welcome." {
+		t.Errorf("segment after edit = %+v", got)
+	}
+	if len(publisher.edits) != 1 || publisher.edits[0] != 1 {
+		t.Errorf("published edits = %v, want [1]", publisher.edits)
+	}
+
+	// The segment is regenerating: a second edit must wait
+	if _, err := svc.UpdateSegment(ctx, jobID, userID, 1, &models.UpdateSegmentRequest{NarrationText: &fixed}); !errors.Is(err, ErrSegmentNotEditable) {
+		t.Errorf("edit of a queued segment: got %v, want ErrSegmentNotEditable", err)
+	}
+	if _, err := svc.UpdateSegment(ctx, jobID, userID, 7, &models.UpdateSegmentRequest{NarrationText: &fixed}); err == nil || !strings.Contains(err.Error(), "segment not found") {
+		t.Errorf("unknown segment: got %v, want segment not found", err)
+	}
+	if _, err := svc.UpdateSegment(ctx, jobID, uuid.New(), 1, &models.UpdateSegmentRequest{NarrationText: &fixed}); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("other user: got %v, want access denied", err)
+	}
+
+	runningID := uuid.New()
+	jobRepo.Create(ctx, &models.Job{ID: runningID, UserID: userID, Status: models.JobStatusRunning, CreatedAt: time.Now()})
+	if _, err := svc.UpdateSegment(ctx, runningID, userID, 0, &models.UpdateSegmentRequest{NarrationText: &fixed}); !errors.Is(err, ErrSegmentNotEditable) {
+		t.Errorf("edit on a running job: got %v, want ErrSegmentNotEditable", err)
+	}
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// ErrInvalidSettings wraps the reasons user settings are rejected
+var ErrInvalidSettings = errors.New("invalid settings")
+
+// SetUserSettings sets the repository of the users' job defaults (/v1/settings); without it CreateJob
+// uses the request as is.
+func (s *JobService) SetUserSettings(r settingsRepository) {
+	s.settingsRepo = r
+}
+
+// GetSettings returns the user's defaults for new jobs (empty when none are set).
+func (s *JobService) GetSettings(ctx context.Context, userID uuid.UUID) (*models.UserSettings, error) {
+	if s.settingsRepo == nil {
+		return nil, fmt.Errorf("settings are not available")
+	}
+	settings, err := s.settingsRepo.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = &models.UserSettings{}
+	}
+	return settings, nil
+}
+
+// UpdateSettings replaces the user's defaults for new jobs (an empty object clears them). Each setting
+// must be valid in a job request; voice_id must be one of the user's voices.
+func (s *JobService) UpdateSettings(ctx context.Context, userID uuid.UUID, settings *models.UserSettings) (*models.UserSettings, error) {
+	if s.settingsRepo == nil {
+		return nil, fmt.Errorf("settings are not available")
+	}
+	if err := s.validateSettings(ctx, userID, settings); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSettings, err)
+	}
+	if err := s.settingsRepo.SetSettings(ctx, userID, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// validateSettings checks the settings against the rules of validateCreateJobRequest for the same fields.
+func (s *JobService) validateSettings(ctx context.Context, userID uuid.UUID, settings *models.UserSettings) error {
+	if settings.Type != "" && !s.inputTypes.Has(settings.Type) {
+		return fmt.Errorf("invalid type: must be one of %s", strings.Join(s.inputTypes.Names(), ", "))
+	}
+	if settings.SegmentsCount < 0 || settings.SegmentsCount > s.config.MaxSegmentsCount {
+		return fmt.Errorf("segments_count must be between 1 and %d", s.config.MaxSegmentsCount)
+	}
+	switch settings.AudioType {
+	case "", "free_speech", "podcast":
+	default:
+		return fmt.Errorf("invalid audio_type: must be free_speech or podcast")
+	}
+	if settings.Webhook != nil {
+		if settings.Webhook.URL == "" {
+			return fmt.Errorf("webhook.url is required")
+		}
+		switch settings.Webhook.Payload {
+		case "", models.WebhookPayloadStatus, models.WebhookPayloadSummary, models.WebhookPayloadFull:
+		default:
+			return fmt.Errorf("invalid webhook.payload: must be status, summary or full")
+		}
+		if err := validateWebhookEncryptionKey(settings.Webhook.EncryptionKey); err != nil {
+			return err
+		}
+		if err := s.checkWebhookURL(ctx, userID, settings.Webhook.URL); err != nil {
+			return err
+		}
+	}
+	if settings.VoiceID != nil {
+		if s.voiceRepo == nil {
+			return fmt.Errorf("custom voices are not available")
+		}
+		if _, err := s.voiceRepo.GetByIDAndUser(ctx, *settings.VoiceID, userID); err != nil {
+			return fmt.Errorf("voice %s not found or not owned by you", settings.VoiceID.String())
+		}
+	}
+	return nil
+}
+
+// applySettings fills the fields req omits with the user's settings. A default voice deleted since it was
+// set is skipped so that it does not fail every new job.
+func (s *JobService) applySettings(ctx context.Context, req *models.CreateJobRequest, userID uuid.UUID) error {
+	if s.settingsRepo == nil {
+		return nil
+	}
+	settings, err := s.settingsRepo.GetSettings(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get settings: %w", err)
+	}
+	if settings == nil {
+		return nil
+	}
+	if req.Type == "" {
+		req.Type = settings.Type
+	}
+	if req.SegmentsCount == 0 {
+		req.SegmentsCount = settings.SegmentsCount
+	}
+	if req.AudioType == "" {
+		req.AudioType = settings.AudioType
+	}
+	if req.Webhook == nil && settings.Webhook != nil {
+		webhook := *settings.Webhook
+		req.Webhook = &webhook
+	}
+	if req.VoiceID == nil && settings.VoiceID != nil {
+		if s.voiceRepo == nil {
+			return nil
+		}
+		if _, err := s.voiceRepo.GetByIDAndUser(ctx, *settings.VoiceID, userID); err != nil {
+			log.Warn().Err(err).Str("user_id", userID.String()).Str("voice_id", settings.VoiceID.String()).
+				Msg("Default voice not found, using the server's voice")
+			return nil
+		}
+		voiceID := *settings.VoiceID
+		req.VoiceID = &voiceID
+	}
+	return nil
+}
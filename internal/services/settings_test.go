@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/config"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// fakeSettingsRepo holds settings by user ID
+type fakeSettingsRepo map[uuid.UUID]*models.UserSettings
+
+func (f fakeSettingsRepo) GetSettings(_ context.Context, userID uuid.UUID) (*models.UserSettings, error) {
+	return f[userID], nil
+}
+
+func (f fakeSettingsRepo) SetSettings(_ context.Context, userID uuid.UUID, settings *models.UserSettings) error {
+	f[userID] = settings
+	return nil
+}
+
+func TestCreateJob_Settings(t *testing.T) {
+	cfg := &config.Config{MaxFilesPerJob: 10, MaxInputLength: 50000, MaxSegmentsCount: 5}
+	userID := uuid.New()
+	apiKey := &models.APIKey{ID: uuid.New(), UserID: userID, QuotaChars: 100000, PeriodStartedAt: time.Now(), QuotaPeriod: "monthly"}
+	jobRepo := newFakeJobRepo()
+	svc := NewJobService(jobRepo, fakeSegmentRepo{}, fakeAssetRepo{}, fakeJobFileRepo{}, newFakeFileRepo(),
+		fakeFactCheckRepo{}, nil, newFakeAPIKeyRepo(apiKey), noopJobPublisher{}, cfg)
+	voice := &models.Voice{ID: uuid.New(), UserID: userID}
+	svc.SetVoices(fakeVoiceRepo{voice.ID: voice})
+	svc.SetUserSettings(fakeSettingsRepo{})
+	ctx := context.Background()
+
+	got, err := svc.GetSettings(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetSettings: %v", err)
+	}
+	if *got != (models.UserSettings{}) {
+		t.Errorf("settings = %+v, want empty", got)
+	}
+	if _, err := svc.CreateJob(ctx, &models.CreateJobRequest{Text: "Hello."}, userID, apiKey.ID); err == nil {
+		t.Error("expected a validation error without settings")
+	}
+
+	settings := &models.UserSettings{
+		Type:          "fictional",
+		SegmentsCount: 3,
+		AudioType:     "podcast",
+		VoiceID:       &voice.ID,
+		Webhook:       &models.WebhookConfig{URL: "https://example.com/hook", Payload: models.WebhookPayloadStatus},
+	}
+	if _, err := svc.UpdateSettings(ctx, userID, settings); err != nil {
+		t.Fatalf("UpdateSettings: %v", err)
+	}
+
+	resp, err := svc.CreateJob(ctx, &models.CreateJobRequest{Text: "Hello."}, userID, apiKey.ID)
+	if err != nil {
+		t.Fatalf("CreateJob with settings: %v", err)
+	}
+	job := jobRepo.jobs[resp.JobID]
+	if job.InputType != "fictional" || job.SegmentsCount != 3 || job.AudioType != "podcast" {
+		t.Errorf("job type/segments/audio = %s/%d/%s, want the settings", job.InputType, job.SegmentsCount, job.AudioType)
+	}
+	if job.VoiceID == nil || *job.VoiceID != voice.ID {
+		t.Errorf("job voice_id = %v, want %s", job.VoiceID, voice.ID)
+	}
+	if job.WebhookURL == nil || *job.WebhookURL != "https://example.com/hook" {
+		t.Errorf("job webhook_url = %v, want the default webhook", job.WebhookURL)
+	}
+
+	// Fields in the request win
+	resp, err = svc.CreateJob(ctx, &models.CreateJobRequest{Text: "Hello.", Type: "educational", SegmentsCount: 1, AudioType: "free_speech"}, userID, apiKey.ID)
+	if err != nil {
+		t.Fatalf("CreateJob overriding settings: %v", err)
+	}
+	job = jobRepo.jobs[resp.JobID]
+	if job.InputType != "educational" || job.SegmentsCount != 1 || job.AudioType != "free_speech" {
+		t.Errorf("job type/segments/audio = %s/%d/%s, want the request's", job.InputType, job.SegmentsCount, job.AudioType)
+	}
+
+	// A deleted default voice is skipped
+	svc.SetVoices(fakeVoiceRepo{})
+	resp, err = svc.CreateJob(ctx, &models.CreateJobRequest{Text: "Hello."}, userID, apiKey.ID)
+	if err != nil {
+		t.Fatalf("CreateJob with a deleted default voice: %v", err)
+	}
+	if job := jobRepo.jobs[resp.JobID]; job.VoiceID != nil {
+		t.Errorf("job voice_id = %s, want none", job.VoiceID)
+	}
+}
+
+func TestUpdateSettings_Validation(t *testing.T) {
+	cfg := &config.Config{MaxSegmentsCount: 5}
+	svc := NewJobService(newFakeJobRepo(), fakeSegmentRepo{}, fakeAssetRepo{}, fakeJobFileRepo{}, newFakeFileRepo(),
+		fakeFactCheckRepo{}, nil, nil, noopJobPublisher{}, cfg)
+	svc.SetVoices(fakeVoiceRepo{})
+	svc.SetUserSettings(fakeSettingsRepo{})
+	voiceID := uuid.New()
+
+	tests := []struct {
+		name     string
+		settings models.UserSettings
+		wantErr  string
+	}{
+		{"unknown type", models.UserSettings{Type: "poetry"}, "invalid type"},
+		{"too many segments", models.UserSettings{SegmentsCount: 6}, "segments_count"},
+		{"unknown audio type", models.UserSettings{AudioType: "radio"}, "invalid audio_type"},
+		{"webhook without url", models.UserSettings{Webhook: &models.WebhookConfig{}}, "webhook.url"},
+		{"unknown webhook payload", models.UserSettings{Webhook: &models.WebhookConfig{URL: "https://example.com", Payload: "all"}}, "webhook.payload"},
+		{"unknown voice", models.UserSettings{VoiceID: &voiceID}, "not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.UpdateSettings(context.Background(), uuid.New(), &tt.settings)
+			if !errors.Is(err, ErrInvalidSettings) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// ErrInvalidStatsWindow is returned for a window other than 1d to 365d
+var ErrInvalidStatsWindow = errors.New("window must be 1d to 365d")
+
+const (
+	defaultStatsWindowDays = 30
+	maxStatsWindowDays     = 365
+	topFailureCodes        = 10
+)
+
+// jobStatsRepository is the subset of job DB operations used for statistics.
+type jobStatsRepository interface {
+	Stats(ctx context.Context, userID *uuid.UUID, from time.Time) ([]models.JobStatsBucket, models.JobStatsBucket, error)
+	TopFailureCodes(ctx context.Context, userID *uuid.UUID, from time.Time, limit int) ([]models.FailureCodeCount, error)
+}
+
+// StatsService aggregates job statistics per user (GET /v1/stats) and globally (GET /admin/stats).
+type StatsService struct {
+	repo jobStatsRepository
+	now  func() time.Time
+}
+
+// NewStatsService creates a new StatsService
+func NewStatsService(repo jobStatsRepository) *StatsService {
+	return &StatsService{repo: repo, now: time.Now}
+}
+
+// Stats aggregates the jobs of userID (all users when nil) created in window: a number of whole UTC days
+// ending today, such as 7d ("" is 30d).
+func (s *StatsService) Stats(ctx context.Context, userID *uuid.UUID, window string) (*models.JobStats, error) {
+	days, err := parseStatsWindow(window)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now().UTC()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -(days - 1))
+
+	buckets, totals, err := s.repo.Stats(ctx, userID, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate jobs: %w", err)
+	}
+	codes, err := s.repo.TopFailureCodes(ctx, userID, from, topFailureCodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate failure codes: %w", err)
+	}
+
+	stats := &models.JobStats{
+		Window:          strconv.Itoa(days) + "d",
+		From:            from,
+		To:              now,
+		Totals:          totals,
+		Days:            make([]models.JobStatsBucket, 0, days),
+		TopFailureCodes: codes,
+	}
+	setSuccessRate(&stats.Totals)
+	byDate := make(map[string]models.JobStatsBucket, len(buckets))
+	for _, b := range buckets {
+		byDate[b.Date] = b
+	}
+	for d := from; !d.After(now); d = d.AddDate(0, 0, 1) {
+		date := d.Format(time.DateOnly)
+		b, ok := byDate[date]
+		if !ok {
+			b = models.JobStatsBucket{Date: date}
+		}
+		setSuccessRate(&b)
+		stats.Days = append(stats.Days, b)
+	}
+	return stats, nil
+}
+
+// parseStatsWindow parses a window of whole days ("30d").
+func parseStatsWindow(window string) (int, error) {
+	if window == "" {
+		return defaultStatsWindowDays, nil
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(window, "d"))
+	if err != nil || !strings.HasSuffix(window, "d") || n < 1 || n > maxStatsWindowDays {
+		return 0, ErrInvalidStatsWindow
+	}
+	return n, nil
+}
+
+// setSuccessRate sets b's share of succeeded jobs among those that succeeded or failed (canceled and
+// unfinished jobs do not count).
+func setSuccessRate(b *models.JobStatsBucket) {
+	if finished := b.Succeeded + b.Failed; finished > 0 {
+		rate := float64(b.Succeeded) / float64(finished)
+		b.SuccessRate = &rate
+	}
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// fakeJobStats returns fixed buckets and records the arguments of the last call.
+type fakeJobStats struct {
+	days   []models.JobStatsBucket
+	totals models.JobStatsBucket
+	codes  []models.FailureCodeCount
+	userID *uuid.UUID
+	from   time.Time
+}
+
+func (f *fakeJobStats) Stats(ctx context.Context, userID *uuid.UUID, from time.Time) ([]models.JobStatsBucket, models.JobStatsBucket, error) {
+	f.userID, f.from = userID, from
+	return f.days, f.totals, nil
+}
+
+func (f *fakeJobStats) TopFailureCodes(ctx context.Context, userID *uuid.UUID, from time.Time, limit int) ([]models.FailureCodeCount, error) {
+	return f.codes, nil
+}
+
+func TestStatsService_Stats(t *testing.T) {
+	repo := &fakeJobStats{
+		days:   []models.JobStatsBucket{{Date: "2026-10-17", Jobs: 4, Succeeded: 3, Failed: 1, CharsCharged: 900}},
+		totals: models.JobStatsBucket{Jobs: 4, Succeeded: 3, Failed: 1, CharsCharged: 900},
+		codes:  []models.FailureCodeCount{{Code: "tts_failed", Count: 1}},
+	}
+	svc := NewStatsService(repo)
+	svc.now = func() time.Time { return time.Date(2026, 10, 18, 15, 30, 0, 0, time.UTC) }
+	userID := uuid.New()
+
+	stats, err := svc.Stats(context.Background(), &userID, "7d")
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if want := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC); !repo.from.Equal(want) || stats.Window != "7d" || *repo.userID != userID {
+		t.Errorf("from = %v, window = %s; want %v, 7d", repo.from, stats.Window, want)
+	}
+	if len(stats.Days) != 7 || stats.Days[0].Date != "2026-10-12" || stats.Days[6].Date != "2026-10-18" {
+		t.Fatalf("days = %+v, want 2026-10-12 to 2026-10-18", stats.Days)
+	}
+	if d := stats.Days[5]; d.Jobs != 4 || d.SuccessRate == nil || *d.SuccessRate != 0.75 {
+		t.Errorf("2026-10-17 = %+v", d)
+	}
+	if d := stats.Days[6]; d.Jobs != 0 || d.SuccessRate != nil {
+		t.Errorf("day without jobs = %+v", d)
+	}
+	if stats.Totals.SuccessRate == nil || *stats.Totals.SuccessRate != 0.75 || len(stats.TopFailureCodes) != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	if stats, err := svc.Stats(context.Background(), nil, ""); err != nil || len(stats.Days) != 30 {
+		t.Errorf("default window: %v days, %v", len(stats.Days), err)
+	}
+	for _, window := range []string{"0d", "366d", "7", "1w", "-3d"} {
+		if _, err := svc.Stats(context.Background(), nil, window); !errors.Is(err, ErrInvalidStatsWindow) {
+			t.Errorf("window %q: err = %v, want ErrInvalidStatsWindow", window, err)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+const (
+	storageCleanupBatch    = 100
+	storageCleanupMaxDelay = time.Hour
+)
+
+// storageDeletionRepository is the subset of storage deletion DB operations used by the cleanup loop.
+type storageDeletionRepository interface {
+	ListDue(ctx context.Context, limit int) ([]*models.StorageDeletion, error)
+	Done(ctx context.Context, id int64) error
+	Failed(ctx context.Context, id int64, lastError string, next time.Time) error
+}
+
+// objectDeleter deletes S3 objects.
+type objectDeleter interface {
+	Delete(ctx context.Context, bucket, key string) error
+}
+
+// StorageCleanupService deletes the S3 objects of deleted jobs (DELETE /v1/jobs/{id}) in the background,
+// retrying failed deletes with exponential backoff (from the interval up to an hour) until they succeed.
+type StorageCleanupService struct {
+	repo     storageDeletionRepository
+	storage  objectDeleter
+	interval time.Duration
+	now      func() time.Time
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewStorageCleanupService creates a new StorageCleanupService running every interval.
+func NewStorageCleanupService(repo storageDeletionRepository, storage objectDeleter, interval time.Duration) *StorageCleanupService {
+	return &StorageCleanupService{
+		repo:     repo,
+		storage:  storage,
+		interval: interval,
+		now:      time.Now,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start runs the cleanup every interval until ctx is done or Stop is called; 0 disables it.
+func (s *StorageCleanupService) Start(ctx context.Context) {
+	if s.interval <= 0 {
+		log.Info().Msg("Storage cleanup disabled")
+		return
+	}
+	ticker := time.NewTicker(s.interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.stopChan:
+				return
+			case <-ticker.C:
+				s.RunOnce(ctx)
+			}
+		}
+	}()
+}
+
+// Stop stops the cleanup. Safe to call multiple times.
+func (s *StorageCleanupService) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+	})
+}
+
+// RunOnce deletes the objects that are due and reports how many were deleted.
+func (s *StorageCleanupService) RunOnce(ctx context.Context) int {
+	due, err := s.repo.ListDue(ctx, storageCleanupBatch)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list S3 objects to delete")
+		return 0
+	}
+	deleted := 0
+	for _, d := range due {
+		if err := s.storage.Delete(ctx, d.S3Bucket, d.S3Key); err != nil {
+			next := s.now().Add(storageCleanupBackoff(s.interval, d.Attempts+1))
+			log.Warn().Err(err).Str("key", d.S3Key).Int("attempts", d.Attempts+1).Time("next_attempt_at", next).Msg("Failed to delete S3 object of deleted job")
+			if err := s.repo.Failed(ctx, d.ID, err.Error(), next); err != nil {
+				log.Error().Err(err).Int64("id", d.ID).Msg("Failed to record S3 delete attempt")
+			}
+			continue
+		}
+		if err := s.repo.Done(ctx, d.ID); err != nil {
+			log.Error().Err(err).Int64("id", d.ID).Msg("Failed to dequeue deleted S3 object")
+			continue
+		}
+		deleted++
+	}
+	if len(due) > 0 {
+		log.Info().Int("deleted", deleted).Int("failed", len(due)-deleted).Msg("Storage cleanup ran")
+	}
+	return deleted
+}
+
+// storageCleanupBackoff returns the wait after a delete failed attempts times: base doubling per
+// attempt, at most storageCleanupMaxDelay.
+func storageCleanupBackoff(base time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < storageCleanupMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, storageCleanupMaxDelay)
+}
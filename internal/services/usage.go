@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// ErrInvalidUsagePeriod is returned for a period that is not a month (YYYY-MM)
+var ErrInvalidUsagePeriod = errors.New("period must be a month (YYYY-MM)")
+
+// SetUsageLedger sets the ledger the characters charged for new jobs are recorded in (for usage exports);
+// without it nothing is recorded.
+func (s *JobService) SetUsageLedger(l usageLedger) {
+	s.usageLedger = l
+}
+
+// recordChars records the quota characters charged for a new job. A failure is logged: the job exists and
+// was charged already.
+func (s *JobService) recordChars(ctx context.Context, job *models.Job) {
+	if s.usageLedger == nil || job.CharsCharged <= 0 {
+		return
+	}
+	err := s.usageLedger.Record(ctx, &models.UsageEntry{
+		UserID:   job.UserID,
+		APIKeyID: job.APIKeyID,
+		JobID:    job.ID,
+		Kind:     models.UsageKindJobCreated,
+		Chars:    job.CharsCharged,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("job_id", job.ID.String()).Int64("chars", job.CharsCharged).Msg("Failed to record usage")
+	}
+}
+
+// recordCompleted records a job that succeeded (approved by its reviewer) in the usage ledger. A failure
+// is logged: the job is done.
+func (s *JobService) recordCompleted(ctx context.Context, jobID uuid.UUID) {
+	if s.usageLedger == nil {
+		return
+	}
+	if err := s.usageLedger.RecordCompleted(ctx, jobID); err != nil {
+		log.Error().Err(err).Str("job_id", jobID.String()).Msg("Failed to record completed job usage")
+	}
+}
+
+// usageTotalsRepository is the subset of usage ledger DB operations used for exports.
+type usageTotalsRepository interface {
+	TotalsByUser(ctx context.Context, from, to time.Time) ([]models.UserUsageTotal, error)
+}
+
+// UsageExportService sums the usage ledger per user and month for billing (GET /admin/usage/export).
+type UsageExportService struct {
+	repo usageTotalsRepository
+	now  func() time.Time
+}
+
+// NewUsageExportService creates a new UsageExportService
+func NewUsageExportService(repo usageTotalsRepository) *UsageExportService {
+	return &UsageExportService{repo: repo, now: time.Now}
+}
+
+// Export returns each user's usage in period, a UTC month such as 2026-09 ("" is the previous month, the
+// one usually invoiced). The current month is exported as far as it goes.
+func (s *UsageExportService) Export(ctx context.Context, period string) (*models.UsageExport, error) {
+	from, err := parseUsagePeriod(period, s.now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	to := from.AddDate(0, 1, 0)
+	users, err := s.repo.TotalsByUser(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum usage: %w", err)
+	}
+	return &models.UsageExport{
+		Period: from.Format("2006-01"),
+		From:   from,
+		To:     to,
+		Users:  users,
+	}, nil
+}
+
+// parseUsagePeriod returns the start of the month period names, or of the month before now's when empty.
+func parseUsagePeriod(period string, now time.Time) (time.Time, error) {
+	if period == "" {
+		return time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	from, err := time.Parse("2006-01", period)
+	if err != nil {
+		return time.Time{}, ErrInvalidUsagePeriod
+	}
+	return from, nil
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/config"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// fakeUsageLedger keeps recorded entries and returns fixed totals, recording the range asked for.
+type fakeUsageLedger struct {
+	entries   []*models.UsageEntry
+	completed []uuid.UUID
+	totals    []models.UserUsageTotal
+	from, to  time.Time
+}
+
+func (f *fakeUsageLedger) Record(ctx context.Context, e *models.UsageEntry) error {
+	f.entries = append(f.entries, e)
+	return nil
+}
+
+func (f *fakeUsageLedger) RecordCompleted(ctx context.Context, jobID uuid.UUID) error {
+	f.completed = append(f.completed, jobID)
+	return nil
+}
+
+func (f *fakeUsageLedger) TotalsByUser(ctx context.Context, from, to time.Time) ([]models.UserUsageTotal, error) {
+	f.from, f.to = from, to
+	return f.totals, nil
+}
+
+func TestCreateJob_RecordsUsage(t *testing.T) {
+	cfg := &config.Config{MaxFilesPerJob: 10, MaxInputLength: 50000, MaxSegmentsCount: 5, CharsPerFile: 1000}
+	userID := uuid.New()
+	apiKey := &models.APIKey{ID: uuid.New(), UserID: userID, QuotaChars: 100000, PeriodStartedAt: time.Now(), QuotaPeriod: "monthly"}
+	svc := NewJobService(newFakeJobRepo(), fakeSegmentRepo{}, fakeAssetRepo{}, fakeJobFileRepo{}, newFakeFileRepo(),
+		fakeFactCheckRepo{}, nil, newFakeAPIKeyRepo(apiKey), noopJobPublisher{}, cfg)
+	ledger := &fakeUsageLedger{}
+	svc.SetUsageLedger(ledger)
+
+	resp, err := svc.CreateJob(context.Background(), &models.CreateJobRequest{Text: "Hello.", Type: "educational", SegmentsCount: 1, AudioType: "free_speech"}, userID, apiKey.ID)
+	if err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	if len(ledger.entries) != 1 {
+		t.Fatalf("entries = %d, want 1", len(ledger.entries))
+	}
+	e := ledger.entries[0]
+	if e.Kind != models.UsageKindJobCreated || e.JobID != resp.JobID || e.UserID != userID || e.APIKeyID != apiKey.ID || e.Chars != int64(len("Hello.")) {
+		t.Errorf("entry = %+v", e)
+	}
+}
+
+func TestUsageExportService_Export(t *testing.T) {
+	ledger := &fakeUsageLedger{totals: []models.UserUsageTotal{{UserID: uuid.New(), Jobs: 2, Chars: 1200, CostUSD: 0.42}}}
+	svc := NewUsageExportService(ledger)
+	svc.now = func() time.Time { return time.Date(2026, 1, 18, 15, 30, 0, 0, time.UTC) }
+	ctx := context.Background()
+
+	export, err := svc.Export(ctx, "")
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if export.Period != "2025-12" || !ledger.from.Equal(time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)) || !ledger.to.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("default period = %s [%v, %v), want the previous month", export.Period, ledger.from, ledger.to)
+	}
+	if len(export.Users) != 1 || export.Users[0].Chars != 1200 {
+		t.Errorf("users = %+v", export.Users)
+	}
+
+	export, err = svc.Export(ctx, "2026-01")
+	if err != nil || export.Period != "2026-01" || !export.To.Equal(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Export(2026-01) = %+v, %v", export, err)
+	}
+	for _, bad := range []string{"2026", "2026-13", "january", "2026-01-01"} {
+		if _, err := svc.Export(ctx, bad); !errors.Is(err, ErrInvalidUsagePeriod) {
+			t.Errorf("Export(%q) error = %v, want ErrInvalidUsagePeriod", bad, err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/llm"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// ErrInvalidVoice wraps the reasons a voice cannot be registered (including providers that cannot clone)
+var ErrInvalidVoice = errors.New("invalid voice")
+
+// Limits of custom voices
+const (
+	maxVoices        = 50
+	maxVoiceNameLen  = 64
+	maxVoiceStyleLen = 500
+	defaultProvider  = "gemini"
+)
+
+// VoiceService manages users' custom voices, registered with pluggable TTS providers.
+type VoiceService struct {
+	voiceRepo *database.VoiceRepository
+	providers map[string]llm.VoiceProvider
+}
+
+// NewVoiceService creates a new VoiceService registering voices with the given providers.
+func NewVoiceService(voiceRepo *database.VoiceRepository, providers ...llm.VoiceProvider) *VoiceService {
+	s := &VoiceService{voiceRepo: voiceRepo, providers: make(map[string]llm.VoiceProvider)}
+	for _, p := range providers {
+		s.providers[p.Name()] = p
+	}
+	return s
+}
+
+// CreateVoice registers a voice with its provider and stores it for the user.
+func (s *VoiceService) CreateVoice(ctx context.Context, userID uuid.UUID, req *models.CreateVoiceRequest) (*models.Voice, error) {
+	if err := validateCreateVoiceRequest(req); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidVoice, err)
+	}
+	providerName := req.Provider
+	if providerName == "" {
+		providerName = defaultProvider
+	}
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown provider %q", ErrInvalidVoice, providerName)
+	}
+	existing, err := s.voiceRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list voices: %w", err)
+	}
+	if len(existing) >= maxVoices {
+		return nil, fmt.Errorf("%w: at most %d voices are allowed", ErrInvalidVoice, maxVoices)
+	}
+	for _, v := range existing {
+		if strings.EqualFold(v.Name, req.Name) {
+			return nil, fmt.Errorf("%w: a voice named %q already exists", ErrInvalidVoice, req.Name)
+		}
+	}
+
+	providerVoiceID, meta, err := provider.RegisterVoice(ctx, &llm.VoiceRegistration{
+		Name:         req.Name,
+		BaseVoice:    req.BaseVoice,
+		Style:        req.Style,
+		ReferenceURL: req.ReferenceURL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidVoice, providerName, err)
+	}
+
+	voice := &models.Voice{
+		ID:              uuid.New(),
+		UserID:          userID,
+		Name:            req.Name,
+		Provider:        providerName,
+		ProviderVoiceID: providerVoiceID,
+		Meta:            meta,
+		CreatedAt:       time.Now(),
+	}
+	if req.Style != "" {
+		voice.Style = &req.Style
+	}
+	if req.ReferenceURL != "" {
+		voice.ReferenceURL = &req.ReferenceURL
+	}
+	if err := s.voiceRepo.Create(ctx, voice); err != nil {
+		return nil, fmt.Errorf("failed to create voice: %w", err)
+	}
+	return voice, nil
+}
+
+// ListVoices returns the user's voices
+func (s *VoiceService) ListVoices(ctx context.Context, userID uuid.UUID) ([]*models.Voice, error) {
+	voices, err := s.voiceRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list voices: %w", err)
+	}
+	if voices == nil {
+		voices = []*models.Voice{}
+	}
+	return voices, nil
+}
+
+// DeleteVoice removes one of the user's voices; database.ErrVoiceNotFound when there is none
+func (s *VoiceService) DeleteVoice(ctx context.Context, userID, voiceID uuid.UUID) error {
+	return s.voiceRepo.Delete(ctx, voiceID, userID)
+}
+
+// validateCreateVoiceRequest checks the name, style and source of a voice (a base voice or a sample URL).
+func validateCreateVoiceRequest(req *models.CreateVoiceRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > maxVoiceNameLen {
+		return fmt.Errorf("name must be 1-%d characters", maxVoiceNameLen)
+	}
+	if len(req.Style) > maxVoiceStyleLen {
+		return fmt.Errorf("style exceeds %d characters", maxVoiceStyleLen)
+	}
+	if (req.BaseVoice == "") == (req.ReferenceURL == "") {
+		return fmt.Errorf("exactly one of base_voice and reference_url is required")
+	}
+	if req.ReferenceURL != "" {
+		u, err := url.Parse(req.ReferenceURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("reference_url must be an https URL")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/config"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+func TestValidateCreateVoiceRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     models.CreateVoiceRequest
+		wantErr string
+	}{
+		{"base voice", models.CreateVoiceRequest{Name: "Narrator", BaseVoice: "Puck", Style: "calm and slow"}, ""},
+		{"reference", models.CreateVoiceRequest{Name: "CEO", Provider: "acme", ReferenceURL: "https://example.com/ceo.wav"}, ""},
+		{"no name", models.CreateVoiceRequest{Name: "  ", BaseVoice: "Puck"}, "name must be"},
+		{"no source", models.CreateVoiceRequest{Name: "Narrator"}, "exactly one of"},
+		{"both sources", models.CreateVoiceRequest{Name: "Narrator", BaseVoice: "Puck", ReferenceURL: "https://example.com/a.wav"}, "exactly one of"},
+		{"reference over http", models.CreateVoiceRequest{Name: "CEO", ReferenceURL: "http://example.com/ceo.wav"}, "https"},
+		{"long style", models.CreateVoiceRequest{Name: "Narrator", BaseVoice: "Puck", Style: strings.Repeat("x", 501)}, "style exceeds"},
+	}
+	for _, tt := range tests {
+		err := validateCreateVoiceRequest(&tt.req)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// fakeVoiceRepo holds voices by ID
+type fakeVoiceRepo map[uuid.UUID]*models.Voice
+
+func (f fakeVoiceRepo) GetByIDAndUser(_ context.Context, id, userID uuid.UUID) (*models.Voice, error) {
+	if v, ok := f[id]; ok && v.UserID == userID {
+		return v, nil
+	}
+	return nil, database.ErrVoiceNotFound
+}
+
+func TestCreateJob_Voice(t *testing.T) {
+	cfg := &config.Config{MaxFilesPerJob: 10, MaxInputLength: 50000, MaxSegmentsCount: 5}
+	userID := uuid.New()
+	apiKey := &models.APIKey{ID: uuid.New(), UserID: userID, QuotaChars: 100000, PeriodStartedAt: time.Now(), QuotaPeriod: "monthly"}
+	jobRepo := newFakeJobRepo()
+	svc := NewJobService(jobRepo, fakeSegmentRepo{}, fakeAssetRepo{}, fakeJobFileRepo{}, newFakeFileRepo(),
+		fakeFactCheckRepo{}, nil, newFakeAPIKeyRepo(apiKey), noopJobPublisher{}, cfg)
+	ctx := context.Background()
+	voice := &models.Voice{ID: uuid.New(), UserID: userID, Provider: "gemini", ProviderVoiceID: "Puck"}
+	other := &models.Voice{ID: uuid.New(), UserID: uuid.New(), Provider: "gemini", ProviderVoiceID: "Kore"}
+	newReq := func(voiceID uuid.UUID) *models.CreateJobRequest {
+		return &models.CreateJobRequest{Text: "Some text.", Type: "educational", SegmentsCount: 1, AudioType: "free_speech", VoiceID: &voiceID}
+	}
+
+	if _, err := svc.CreateJob(ctx, newReq(voice.ID), userID, apiKey.ID); err == nil {
+		t.Error("expected error without a voice repository")
+	}
+	svc.SetVoices(fakeVoiceRepo{voice.ID: voice, other.ID: other})
+
+	resp, err := svc.CreateJob(ctx, newReq(voice.ID), userID, apiKey.ID)
+	if err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	if job := jobRepo.jobs[resp.JobID]; job.VoiceID == nil || *job.VoiceID != voice.ID {
+		t.Errorf("job voice_id = %v, want %s", job.VoiceID, voice.ID)
+	}
+	if _, err := svc.CreateJob(ctx, newReq(other.ID), userID, apiKey.ID); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("another user's voice: err = %v, want not found", err)
+	}
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// ErrWebhookDeliveryNotFound is returned for unknown deliveries and those of other users' jobs.
+var ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+
+// ErrWebhookDeliveryPending is returned when redelivering a delivery still being attempted.
+var ErrWebhookDeliveryPending = errors.New("webhook delivery is still pending")
+
+// SetWebhookDeliveries sets the repository of job webhook deliveries; without it they cannot be
+// inspected or redelivered.
+func (s *JobService) SetWebhookDeliveries(r webhookDeliveryRepository) {
+	s.deliveryRepo = r
+}
+
+// GetWebhookDelivery returns a delivery of one of the user's jobs with its attempts.
+func (s *JobService) GetWebhookDelivery(ctx context.Context, deliveryID, userID uuid.UUID) (*models.WebhookDeliveryDetail, error) {
+	delivery, err := s.ownedDelivery(ctx, deliveryID, userID)
+	if err != nil {
+		return nil, err
+	}
+	attempts, err := s.deliveryRepo.ListAttempts(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.WebhookDeliveryDetail{WebhookDelivery: delivery, History: attempts}, nil
+}
+
+// RedeliverWebhook queues a sent or failed delivery to be sent again with the payload of its first
+// attempt. The dispatcher sends it within a retry tick, with the usual retries.
+func (s *JobService) RedeliverWebhook(ctx context.Context, deliveryID, userID uuid.UUID) (*models.WebhookDelivery, error) {
+	if _, err := s.ownedDelivery(ctx, deliveryID, userID); err != nil {
+		return nil, err
+	}
+	delivery, err := s.deliveryRepo.Redeliver(ctx, deliveryID)
+	if errors.Is(err, database.ErrWebhookDeliveryPending) {
+		return nil, ErrWebhookDeliveryPending
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.recordEvent(ctx, delivery.JobID, models.JobEventWebhookRedelivery,
+		fmt.Sprintf("Webhook redelivery #%d requested", delivery.Redeliveries),
+		map[string]any{"delivery_id": delivery.ID.String(), "redelivery": delivery.Redeliveries})
+	return delivery, nil
+}
+
+// ownedDelivery returns the delivery if it belongs to one of the user's jobs.
+func (s *JobService) ownedDelivery(ctx context.Context, deliveryID, userID uuid.UUID) (*models.WebhookDelivery, error) {
+	if s.deliveryRepo == nil {
+		return nil, fmt.Errorf("webhook deliveries are not available")
+	}
+	delivery, err := s.deliveryRepo.GetByID(ctx, deliveryID)
+	if errors.Is(err, database.ErrWebhookDeliveryNotFound) {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkJobOwner(ctx, delivery.JobID, userID); err != nil {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	return delivery, nil
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/config"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// fakeDeliveryRepo holds deliveries by ID
+type fakeDeliveryRepo map[uuid.UUID]*models.WebhookDelivery
+
+func (f fakeDeliveryRepo) GetByID(_ context.Context, id uuid.UUID) (*models.WebhookDelivery, error) {
+	if d, ok := f[id]; ok {
+		return d, nil
+	}
+	return nil, database.ErrWebhookDeliveryNotFound
+}
+
+func (f fakeDeliveryRepo) ListAttempts(_ context.Context, id uuid.UUID) ([]*models.WebhookDeliveryAttempt, error) {
+	return []*models.WebhookDeliveryAttempt{{DeliveryID: id, Attempt: 1, Payload: []byte(`{}`)}}, nil
+}
+
+func (f fakeDeliveryRepo) Redeliver(_ context.Context, id uuid.UUID) (*models.WebhookDelivery, error) {
+	d := f[id]
+	if d.Status == "pending" {
+		return nil, database.ErrWebhookDeliveryPending
+	}
+	d.Status, d.Attempts = "pending", 0
+	d.Redeliveries++
+	return d, nil
+}
+
+func TestRedeliverWebhook(t *testing.T) {
+	jobRepo := newFakeJobRepo()
+	svc := NewJobService(jobRepo, fakeSegmentRepo{}, fakeAssetRepo{}, fakeJobFileRepo{}, newFakeFileRepo(),
+		fakeFactCheckRepo{}, nil, newFakeAPIKeyRepo(&models.APIKey{ID: uuid.New()}), noopJobPublisher{}, &config.Config{})
+	owner := uuid.New()
+	job := &models.Job{ID: uuid.New(), UserID: owner, Status: "succeeded"}
+	if err := jobRepo.Create(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+	sent := &models.WebhookDelivery{ID: uuid.New(), JobID: job.ID, Status: "sent", Attempts: 2}
+	pending := &models.WebhookDelivery{ID: uuid.New(), JobID: job.ID, Status: "pending"}
+	svc.SetWebhookDeliveries(fakeDeliveryRepo{sent.ID: sent, pending.ID: pending})
+	ctx := context.Background()
+
+	if _, err := svc.RedeliverWebhook(ctx, sent.ID, uuid.New()); !errors.Is(err, ErrWebhookDeliveryNotFound) {
+		t.Errorf("other user's delivery: err = %v, want ErrWebhookDeliveryNotFound", err)
+	}
+	if _, err := svc.RedeliverWebhook(ctx, uuid.New(), owner); !errors.Is(err, ErrWebhookDeliveryNotFound) {
+		t.Errorf("unknown delivery: err = %v, want ErrWebhookDeliveryNotFound", err)
+	}
+	if _, err := svc.RedeliverWebhook(ctx, pending.ID, owner); !errors.Is(err, ErrWebhookDeliveryPending) {
+		t.Errorf("pending delivery: err = %v, want ErrWebhookDeliveryPending", err)
+	}
+	got, err := svc.RedeliverWebhook(ctx, sent.ID, owner)
+	if err != nil {
+		t.Fatalf("RedeliverWebhook: %v", err)
+	}
+	if got.Status != "pending" || got.Attempts != 0 || got.Redeliveries != 1 {
+		t.Errorf("delivery = %+v, want pending with no attempts and 1 redelivery", got)
+	}
+
+	detail, err := svc.GetWebhookDelivery(ctx, sent.ID, owner)
+	if err != nil {
+		t.Fatalf("GetWebhookDelivery: %v", err)
+	}
+	if len(detail.History) != 1 || detail.ID != sent.ID {
+		t.Errorf("detail = %+v, want the delivery with its attempt", detail)
+	}
+}
@@ -0,0 +1,22 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/snappy-loop/stories/pkg/webhookjwe"
+)
+
+// maxWebhookEncryptionKeyLen bounds the PEM of a webhook's encryption key (an RSA 8192-bit key is ~1.5 KB)
+const maxWebhookEncryptionKeyLen = 8 * 1024
+
+// validateWebhookEncryptionKey checks a webhook's optional encryption key: a PEM public key payloads can
+// be encrypted with (see package webhookjwe).
+func validateWebhookEncryptionKey(key *string) error {
+	if key == nil || *key == "" {
+		return nil
+	}
+	if _, err := webhookjwe.ParsePublicKey(*key); err != nil || len(*key) > maxWebhookEncryptionKeyLen {
+		return fmt.Errorf("invalid webhook.encryption_key: must be a PEM RSA (2048 bits or more) or EC (P-256, P-384, P-521) public key")
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func TestValidateWebhookEncryptionKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	valid := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	empty, invalid := "", "not a key"
+
+	for _, tt := range []struct {
+		name    string
+		key     *string
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"empty", &empty, false},
+		{"EC public key", &valid, false},
+		{"invalid", &invalid, true},
+	} {
+		if err := validateWebhookEncryptionKey(tt.key); (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
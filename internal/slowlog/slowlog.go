@@ -0,0 +1,46 @@
+// Package slowlog reports operations slower than their threshold (SLOW_*_THRESHOLD) as structured
+// warnings, so degradation of the database, Gemini or S3 shows in the logs before jobs start timing out.
+// Every warning carries slow_op (db, llm or s3), duration_ms and threshold_ms; callers add the
+// operation's identifiers.
+package slowlog
+
+import (
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Kinds of operations (the slow_op field).
+const (
+	KindDB  = "db"
+	KindLLM = "llm"
+	KindS3  = "s3"
+)
+
+// Event returns a warning for an operation of kind that took elapsed, or nil when it took less than
+// threshold or threshold is 0. zerolog ignores calls on a nil event, so callers chain fields and Msg
+// without checking.
+func Event(kind string, threshold, elapsed time.Duration) *zerolog.Event {
+	if threshold <= 0 || elapsed < threshold {
+		return nil
+	}
+	return log.Warn().
+		Str("slow_op", kind).
+		Int64("duration_ms", elapsed.Milliseconds()).
+		Int64("threshold_ms", threshold.Milliseconds())
+}
+
+// maxQueryLen is the length of the query text logged with slow queries
+const maxQueryLen = 200
+
+// Query returns query on one line, whitespace collapsed and cut to maxQueryLen bytes, for logs. The
+// arguments are never logged.
+func Query(query string) string {
+	q := strings.Join(strings.Fields(query), " ")
+	if len(q) > maxQueryLen {
+		q = q[:maxQueryLen] + "..."
+	}
+	return q
+}
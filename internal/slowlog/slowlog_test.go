@@ -0,0 +1,28 @@
+package slowlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvent(t *testing.T) {
+	if Event(KindDB, 0, time.Hour) != nil {
+		t.Error("threshold 0 logged")
+	}
+	if Event(KindDB, time.Second, time.Millisecond) != nil {
+		t.Error("fast operation logged")
+	}
+	if Event(KindDB, time.Second, time.Second) == nil {
+		t.Error("operation at the threshold not logged")
+	}
+}
+
+func TestQuery(t *testing.T) {
+	if got, want := Query("\n\t\tSELECT id\n\t\tFROM jobs  WHERE id = $1\n\t"), "SELECT id FROM jobs WHERE id = $1"; got != want {
+		t.Errorf("Query = %q, want %q", got, want)
+	}
+	if got := Query(strings.Repeat("x ", 300)); len(got) != maxQueryLen+3 {
+		t.Errorf("len(Query(long)) = %d, want %d", len(got), maxQueryLen+3)
+	}
+}
@@ -0,0 +1,238 @@
+// Package ssml parses the SSML-style control tags allowed in narration scripts and translates them for
+// TTS: <break time="1s"/> (or strength="weak" ... "x-strong") splits the script where silence is
+// inserted, <emphasis>words</emphasis> marks words to stress and <say-as interpret-as="characters">API</say-as>
+// (or "digits") spells its text out. Other text, including "<" in prose, is left as is.
+package ssml
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Limits of the control tags in one script
+const (
+	DefaultBreak = 750 * time.Millisecond
+	MaxBreak     = 5 * time.Second
+	MaxBreaks    = 20 // each break adds a TTS call
+)
+
+// breakStrengths are the pauses of <break strength="...">
+var breakStrengths = map[string]time.Duration{
+	"none":     0,
+	"x-weak":   200 * time.Millisecond,
+	"weak":     350 * time.Millisecond,
+	"medium":   DefaultBreak,
+	"strong":   1250 * time.Millisecond,
+	"x-strong": 2 * time.Second,
+}
+
+var (
+	tagRe  = regexp.MustCompile(`<(/?)(break|emphasis|say-as)\b([^<>]*?)(/?)>`)
+	attrRe = regexp.MustCompile(`([a-z-]+)\s*=\s*"([^"]*)"`)
+)
+
+// Chunk is the text up to a break, with the tags translated, and the silence that follows it.
+type Chunk struct {
+	Text  string
+	Pause time.Duration
+}
+
+// Script is a parsed narration script.
+type Script struct {
+	Chunks   []Chunk
+	Emphasis bool // some text is emphasized (wrapped in asterisks in the chunk text)
+}
+
+// Text returns the translated text of all chunks, without the pauses.
+func (s *Script) Text() string {
+	parts := make([]string, 0, len(s.Chunks))
+	for _, c := range s.Chunks {
+		if c.Text != "" {
+			parts = append(parts, c.Text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Pause returns the total silence of the script's breaks.
+func (s *Script) Pause() time.Duration {
+	var d time.Duration
+	for _, c := range s.Chunks {
+		d += c.Pause
+	}
+	return d
+}
+
+// Span is a run of a script between control tags: plain, emphasized or say-as text, or a break.
+type Span struct {
+	Text     string // untranslated text; say-as text is not spelled out
+	Emphasis bool
+	SayAs    bool
+	Break    bool
+	Pause    time.Duration // silence of a break
+}
+
+// Parse parses script's control tags. Emphasized text is wrapped in asterisks and say-as text spelled
+// out with spaces between its characters. Unclosed, nested or misplaced tags and invalid attributes are
+// errors. A script without tags is a single chunk.
+func Parse(script string) (*Script, error) {
+	spans, err := Spans(script)
+	if err != nil {
+		return nil, err
+	}
+	s := &Script{}
+	var b strings.Builder
+	for _, sp := range spans {
+		switch {
+		case sp.Break:
+			s.Chunks = append(s.Chunks, Chunk{Text: strings.TrimSpace(b.String()), Pause: sp.Pause})
+			b.Reset()
+		case sp.Emphasis:
+			b.WriteString("*" + sp.Text + "*")
+			s.Emphasis = true
+		case sp.SayAs:
+			b.WriteString(spellOut(sp.Text))
+		default:
+			b.WriteString(sp.Text)
+		}
+	}
+	if text := strings.TrimSpace(b.String()); text != "" || len(s.Chunks) == 0 {
+		s.Chunks = append(s.Chunks, Chunk{Text: text})
+	}
+	return s, nil
+}
+
+// Spans splits script into the text between its control tags and its breaks, in order, for showing the
+// script to people rather than TTS (e.g. in a teleprompter export). Errors are those of Parse.
+func Spans(script string) ([]Span, error) {
+	var spans []Span
+	var open *Span // emphasis or say-as being parsed
+	breaks := 0
+	last := 0
+	for _, m := range tagRe.FindAllStringSubmatchIndex(script, -1) {
+		closing := m[3] > m[2]
+		name := script[m[4]:m[5]]
+		attrs, err := parseAttrs(script[m[6]:m[7]])
+		if err != nil {
+			return nil, fmt.Errorf("<%s>: %w", name, err)
+		}
+		selfClosing := m[9] > m[8]
+		if open != nil {
+			open.Text += script[last:m[0]]
+		} else if text := script[last:m[0]]; text != "" {
+			spans = append(spans, Span{Text: text})
+		}
+		last = m[1]
+
+		switch {
+		case name == "break":
+			if closing || !selfClosing {
+				return nil, fmt.Errorf("<break> must be self-closing (<break/>)")
+			}
+			if open != nil {
+				return nil, fmt.Errorf("<break/> inside <%s>", spanTag(open))
+			}
+			pause, err := breakDuration(attrs)
+			if err != nil {
+				return nil, err
+			}
+			if breaks++; breaks > MaxBreaks {
+				return nil, fmt.Errorf("more than %d <break/> tags", MaxBreaks)
+			}
+			spans = append(spans, Span{Break: true, Pause: pause})
+		case selfClosing:
+			return nil, fmt.Errorf("<%s/> has no text", name)
+		case !closing:
+			if open != nil {
+				return nil, fmt.Errorf("<%s> inside <%s>", name, spanTag(open))
+			}
+			if name == "emphasis" {
+				open = &Span{Emphasis: true}
+			} else {
+				if as := attrs["interpret-as"]; as != "characters" && as != "digits" {
+					return nil, fmt.Errorf(`<say-as> needs interpret-as="characters" or "digits"`)
+				}
+				open = &Span{SayAs: true}
+			}
+		default:
+			if open == nil || spanTag(open) != name {
+				return nil, fmt.Errorf("unexpected </%s>", name)
+			}
+			spans = append(spans, *open)
+			open = nil
+		}
+	}
+	if open != nil {
+		return nil, fmt.Errorf("unclosed <%s>", spanTag(open))
+	}
+	if text := script[last:]; text != "" {
+		spans = append(spans, Span{Text: text})
+	}
+	return spans, nil
+}
+
+// Validate checks script's control tags (see Parse).
+func Validate(script string) error {
+	_, err := Parse(script)
+	return err
+}
+
+// Strip removes the control tags from script, keeping the text they wrap.
+func Strip(script string) string {
+	return tagRe.ReplaceAllString(script, "")
+}
+
+// parseAttrs parses the attributes of a tag; anything but name="value" pairs is an error.
+func parseAttrs(s string) (map[string]string, error) {
+	attrs := make(map[string]string)
+	rest := attrRe.ReplaceAllStringFunc(s, func(a string) string {
+		m := attrRe.FindStringSubmatch(a)
+		attrs[m[1]] = m[2]
+		return ""
+	})
+	if strings.TrimSpace(rest) != "" {
+		return nil, fmt.Errorf("invalid attributes %q", strings.TrimSpace(s))
+	}
+	return attrs, nil
+}
+
+// breakDuration returns the pause of a <break/>: its time (e.g. "500ms", "1.5s"), else its strength,
+// else DefaultBreak.
+func breakDuration(attrs map[string]string) (time.Duration, error) {
+	if t, ok := attrs["time"]; ok {
+		d, err := time.ParseDuration(t)
+		if err != nil || d < 0 || d > MaxBreak {
+			return 0, fmt.Errorf("<break/> time must be a duration up to %s, got %q", MaxBreak, t)
+		}
+		return d, nil
+	}
+	if strength, ok := attrs["strength"]; ok {
+		d, ok := breakStrengths[strength]
+		if !ok {
+			return 0, fmt.Errorf("invalid <break/> strength %q", strength)
+		}
+		return d, nil
+	}
+	return DefaultBreak, nil
+}
+
+// spanTag returns the name of the tag that opened an emphasis or say-as span.
+func spanTag(sp *Span) string {
+	if sp.Emphasis {
+		return "emphasis"
+	}
+	return "say-as"
+}
+
+// spellOut separates the characters of text (ignoring spaces) with spaces, e.g. "API" -> "A P I".
+func spellOut(text string) string {
+	var chars []string
+	for _, r := range text {
+		if r != ' ' {
+			chars = append(chars, string(r))
+		}
+	}
+	return strings.Join(chars, " ")
+}
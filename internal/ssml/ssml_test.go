@@ -0,0 +1,110 @@
+package ssml
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	s, err := Parse(`Welcome. <break time="1.5s"/> An <say-as interpret-as="characters">API</say-as> is <emphasis>an interface</emphasis>.<break strength="weak"/>Next: x < y.`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Chunk{
+		{Text: "Welcome.", Pause: 1500 * time.Millisecond},
+		{Text: "An A P I is *an interface*.", Pause: 350 * time.Millisecond},
+		{Text: "Next: x < y."},
+	}
+	if len(s.Chunks) != len(want) {
+		t.Fatalf("chunks = %+v, want %+v", s.Chunks, want)
+	}
+	for i := range want {
+		if s.Chunks[i] != want[i] {
+			t.Errorf("chunk %d = %+v, want %+v", i, s.Chunks[i], want[i])
+		}
+	}
+	if !s.Emphasis {
+		t.Error("Emphasis = false, want true")
+	}
+	if s.Pause() != 1850*time.Millisecond {
+		t.Errorf("Pause = %s, want 1.85s", s.Pause())
+	}
+	if got := s.Text(); got != "Welcome. An A P I is *an interface*. Next: x < y." {
+		t.Errorf("Text = %q", got)
+	}
+}
+
+func TestParseWithoutTags(t *testing.T) {
+	s, err := Parse("Just a script.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Chunks) != 1 || s.Chunks[0].Text != "Just a script." || s.Emphasis {
+		t.Errorf("got %+v, want one chunk", s)
+	}
+}
+
+func TestParseDefaultAndLeadingBreak(t *testing.T) {
+	s, err := Parse(`<break/>Hello`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Chunks) != 2 || s.Chunks[0] != (Chunk{Pause: DefaultBreak}) || s.Chunks[1].Text != "Hello" {
+		t.Errorf("chunks = %+v, want a leading %s pause", s.Chunks, DefaultBreak)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"unclosed emphasis":   "<emphasis>loud",
+		"stray close":         "quiet</emphasis>",
+		"nested":              "<emphasis><say-as interpret-as=\"characters\">A</say-as></emphasis>",
+		"break in emphasis":   "<emphasis>a<break/>b</emphasis>",
+		"break not closed":    "<break time=\"1s\">",
+		"long break":          "<break time=\"10s\"/>",
+		"bad time":            "<break time=\"soon\"/>",
+		"bad strength":        "<break strength=\"huge\"/>",
+		"bad interpret-as":    "<say-as interpret-as=\"date\">today</say-as>",
+		"malformed attribute": "<break time=1s/>",
+		"too many breaks":     strings.Repeat("a<break/>", MaxBreaks+1),
+	}
+	for name, script := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := Validate(script); err == nil {
+				t.Errorf("Validate(%q) = nil, want error", script)
+			}
+		})
+	}
+}
+
+func TestStrip(t *testing.T) {
+	got := Strip(`Hi <break time="1s"/>the <emphasis>big</emphasis> <say-as interpret-as="characters">API</say-as>.`)
+	if got != "Hi the big API." {
+		t.Errorf("Strip = %q", got)
+	}
+}
+
+func TestSpans(t *testing.T) {
+	spans, err := Spans(`Hi <break time="1s"/>the <emphasis>big</emphasis> <say-as interpret-as="characters">API</say-as>.`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Span{
+		{Text: "Hi "},
+		{Break: true, Pause: time.Second},
+		{Text: "the "},
+		{Text: "big", Emphasis: true},
+		{Text: " "},
+		{Text: "API", SayAs: true},
+		{Text: "."},
+	}
+	if len(spans) != len(want) {
+		t.Fatalf("spans = %+v, want %+v", spans, want)
+	}
+	for i := range want {
+		if spans[i] != want[i] {
+			t.Errorf("span %d = %+v, want %+v", i, spans[i], want[i])
+		}
+	}
+}
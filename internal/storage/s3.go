@@ -1,27 +1,50 @@
 package storage
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/rs/zerolog/log"
+	"github.com/snappy-loop/stories/internal/proxy"
+	"github.com/snappy-loop/stories/internal/slowlog"
 )
 
+// ErrObjectNotFound is returned (wrapped) by HeadObject for objects that do not exist.
+var ErrObjectNotFound = errors.New("object not found")
+
+// ErrObjectChanged is returned (wrapped) by CopyObject when the source no longer has the expected ETag.
+var ErrObjectChanged = errors.New("object changed")
+
 // Client wraps S3 storage operations
 type Client struct {
 	s3Client  *s3.Client
 	bucket    string
-	publicURL string // optional base URL for public bucket (e.g. http://localhost:9000/stories-assets)
+	publicURL string        // optional base URL for public bucket (e.g. http://localhost:9000/stories-assets)
+	slowOp    time.Duration // operations taking longer are logged, see SetSlowOpThreshold
+
+	// Upload resilience, see SetUploadRetries and SetFallbackBucket
+	uploadRetries    int
+	uploadRetryDelay time.Duration
+	verifyUploads    bool
+	fallbackBucket   string
 }
 
-// NewClient creates a new S3 storage client
-func NewClient(endpoint, region, bucket, accessKey, secretKey string, useSSL bool, publicURL string) (*Client, error) {
+// NewClient creates a new S3 storage client. proxySetting selects its outbound proxy (see package proxy;
+// empty uses the environment's).
+func NewClient(endpoint, region, bucket, accessKey, secretKey string, useSSL bool, publicURL, proxySetting string) (*Client, error) {
 	// Build config options
 	configOpts := []func(*config.LoadOptions) error{
 		config.WithRegion(region),
@@ -33,6 +56,13 @@ func NewClient(endpoint, region, bucket, accessKey, secretKey string, useSSL boo
 		configOpts = append(configOpts, config.WithBaseEndpoint(endpoint))
 	}
 
+	if proxySetting != "" {
+		httpClient := awshttp.NewBuildableClient().WithTransportOptions(func(t *http.Transport) {
+			t.Proxy = proxy.Func(proxySetting)
+		})
+		configOpts = append(configOpts, config.WithHTTPClient(httpClient))
+	}
+
 	// Load config with credentials
 	cfg, err := config.LoadDefaultConfig(context.Background(), configOpts...)
 	if err != nil {
@@ -51,6 +81,7 @@ func NewClient(endpoint, region, bucket, accessKey, secretKey string, useSSL boo
 	log.Info().
 		Str("endpoint", endpoint).
 		Str("bucket", bucket).
+		Str("proxy", proxy.Redact(proxySetting)).
 		Msg("S3 client initialized")
 
 	return &Client{
@@ -60,9 +91,53 @@ func NewClient(endpoint, region, bucket, accessKey, secretKey string, useSSL boo
 	}, nil
 }
 
-// PublicURL returns the public URL for an object key. Empty if publicURL was not configured.
-func (c *Client) PublicURL(key string) string {
-	if c.publicURL == "" {
+// SetSlowOpThreshold makes S3 operations taking at least d log a warning with the operation, bucket and
+// key; 0 disables it. GetObject counts the time to the response headers, not reading the body.
+func (c *Client) SetSlowOpThreshold(d time.Duration) {
+	c.slowOp = d
+}
+
+// SetUploadRetries makes a failed upload retry up to retries times, waiting delay, then twice as long
+// before each further attempt, on top of the SDK's own quick retries. With verify, each upload is
+// checked with a HEAD request: the stored size and ETag must match what was sent and returned, else the
+// attempt counts as failed.
+func (c *Client) SetUploadRetries(retries int, delay time.Duration, verify bool) {
+	c.uploadRetries = retries
+	c.uploadRetryDelay = delay
+	c.verifyUploads = verify
+}
+
+// SetFallbackBucket makes uploads that still fail after their retries go to bucket (same endpoint and
+// credentials) instead. Upload returns the bucket used; callers record it, and the read methods take it.
+func (c *Client) SetFallbackBucket(bucket string) {
+	c.fallbackBucket = bucket
+}
+
+// Bucket returns the primary bucket.
+func (c *Client) Bucket() string {
+	return c.bucket
+}
+
+// bucketFor returns the bucket holding an object recorded as stored in bucket: the fallback bucket when
+// it was stored there, else the primary bucket (objects recorded before a bucket rename included).
+func (c *Client) bucketFor(bucket string) string {
+	if c.fallbackBucket != "" && bucket == c.fallbackBucket {
+		return c.fallbackBucket
+	}
+	return c.bucket
+}
+
+// logSlow logs operation op on key (or prefix) in bucket, started at start, when it reached the threshold.
+func (c *Client) logSlow(op, bucket, key string, start time.Time, err error) {
+	if ev := slowlog.Event(slowlog.KindS3, c.slowOp, time.Since(start)); ev != nil {
+		ev.Str("operation", op).Str("bucket", bucket).Str("key", key).Bool("failed", err != nil).Msg("Slow S3 operation")
+	}
+}
+
+// PublicURL returns the public URL for an object key in bucket. Empty if publicURL was not configured or
+// the object is in the fallback bucket.
+func (c *Client) PublicURL(bucket, key string) string {
+	if c.publicURL == "" || c.bucketFor(bucket) != c.bucket {
 		return ""
 	}
 	if c.publicURL[len(c.publicURL)-1] == '/' {
@@ -71,35 +146,121 @@ func (c *Client) PublicURL(key string) string {
 	return c.publicURL + "/" + key
 }
 
-// Upload uploads data to S3. contentLength must be > 0; S3-compatible backends (e.g. R2) require the Content-Length header.
-func (c *Client) Upload(ctx context.Context, key string, data io.Reader, contentType string, contentLength int64) error {
+// Upload uploads data to S3 and returns the bucket it is stored in: the primary bucket, or the fallback
+// bucket when the primary one kept failing (see SetUploadRetries and SetFallbackBucket). contentLength
+// must be > 0; S3-compatible backends (e.g. R2) require the Content-Length header. Data that cannot seek
+// is read into memory first when a second attempt is possible.
+func (c *Client) Upload(ctx context.Context, key string, data io.Reader, contentType string, contentLength int64) (string, error) {
+	body, ok := data.(io.ReadSeeker)
+	if !ok {
+		if c.uploadRetries == 0 && c.fallbackBucket == "" {
+			if err := c.putObject(ctx, c.bucket, key, data, contentType, contentLength); err != nil {
+				return "", err
+			}
+			return c.bucket, nil
+		}
+		buf, err := io.ReadAll(data)
+		if err != nil {
+			return "", fmt.Errorf("failed to read upload: %w", err)
+		}
+		body = bytes.NewReader(buf)
+	}
+
+	err := c.uploadWithRetries(ctx, c.bucket, key, body, contentType, contentLength)
+	if err == nil {
+		return c.bucket, nil
+	}
+	if c.fallbackBucket == "" || ctx.Err() != nil {
+		return "", err
+	}
+	log.Warn().Err(err).
+		Str("bucket", c.bucket).
+		Str("fallback_bucket", c.fallbackBucket).
+		Str("key", key).
+		Msg("S3 upload failed, uploading to the fallback bucket")
+	if fallbackErr := c.uploadWithRetries(ctx, c.fallbackBucket, key, body, contentType, contentLength); fallbackErr != nil {
+		return "", fmt.Errorf("%w (fallback bucket: %v)", err, fallbackErr)
+	}
+	return c.fallbackBucket, nil
+}
+
+// uploadWithRetries uploads body to bucket, retrying with exponential backoff.
+func (c *Client) uploadWithRetries(ctx context.Context, bucket, key string, body io.ReadSeeker, contentType string, contentLength int64) error {
+	delay := c.uploadRetryDelay
+	for attempt := 1; ; attempt++ {
+		if _, err := body.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to rewind upload: %w", err)
+		}
+		err := c.putObject(ctx, bucket, key, body, contentType, contentLength)
+		if err == nil {
+			return nil
+		}
+		if attempt > c.uploadRetries || ctx.Err() != nil {
+			return err
+		}
+		log.Warn().Err(err).
+			Str("bucket", bucket).
+			Str("key", key).
+			Int("attempt", attempt).
+			Dur("retry_in", delay).
+			Msg("S3 upload failed, retrying")
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// putObject makes one upload attempt, verified with a HEAD request when enabled.
+func (c *Client) putObject(ctx context.Context, bucket, key string, body io.Reader, contentType string, contentLength int64) error {
 	input := &s3.PutObjectInput{
-		Bucket:      aws.String(c.bucket),
-		Key:         aws.String(key),
-		Body:        data,
-		ContentType: aws.String(contentType),
+		Bucket:        aws.String(bucket),
+		Key:           aws.String(key),
+		Body:          body,
+		ContentType:   aws.String(contentType),
 		ContentLength: aws.Int64(contentLength),
 	}
-	_, err := c.s3Client.PutObject(ctx, input)
-
+	start := time.Now()
+	out, err := c.s3Client.PutObject(ctx, input)
+	c.logSlow("PutObject", bucket, key, start, err)
 	if err != nil {
 		return fmt.Errorf("failed to upload to S3: %w", err)
 	}
 
+	if c.verifyUploads {
+		start = time.Now()
+		head, err := c.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		})
+		c.logSlow("HeadObject", bucket, key, start, err)
+		if err != nil {
+			return fmt.Errorf("failed to verify S3 upload: %w", err)
+		}
+		if size := aws.ToInt64(head.ContentLength); size != contentLength {
+			return fmt.Errorf("S3 upload verification failed: %d bytes stored, %d sent", size, contentLength)
+		}
+		if etag := aws.ToString(out.ETag); etag != "" && aws.ToString(head.ETag) != etag {
+			return fmt.Errorf("S3 upload verification failed: ETag %s stored, %s returned by the upload", aws.ToString(head.ETag), etag)
+		}
+	}
+
 	log.Info().
-		Str("bucket", c.bucket).
+		Str("bucket", bucket).
 		Str("key", key).
 		Msg("File uploaded to S3")
-
 	return nil
 }
 
-// GeneratePresignedURL generates a presigned URL for downloading an object
-func (c *Client) GeneratePresignedURL(key string, expiration time.Duration) (string, error) {
+// GeneratePresignedURL generates a presigned URL for downloading an object stored in bucket (as
+// returned by Upload)
+func (c *Client) GeneratePresignedURL(bucket, key string, expiration time.Duration) (string, error) {
 	presignClient := s3.NewPresignClient(c.s3Client)
 
 	req, err := presignClient.PresignGetObject(context.Background(), &s3.GetObjectInput{
-		Bucket: aws.String(c.bucket),
+		Bucket: aws.String(c.bucketFor(bucket)),
 		Key:    aws.String(key),
 	}, func(opts *s3.PresignOptions) {
 		opts.Expires = expiration
@@ -112,31 +273,117 @@ func (c *Client) GeneratePresignedURL(key string, expiration time.Duration) (str
 	return req.URL, nil
 }
 
-// Delete deletes an object from S3
-func (c *Client) Delete(ctx context.Context, key string) error {
+// PresignedUpload is a presigned PUT: the client sends the object to URL with Headers
+type PresignedUpload struct {
+	URL     string
+	Headers map[string]string
+}
+
+// PresignUpload returns a presigned PUT of key to the primary bucket (direct uploads have no fallback),
+// valid for expiration. The signature covers contentType and contentLength, so S3 refuses other ones.
+func (c *Client) PresignUpload(ctx context.Context, key, contentType string, contentLength int64, expiration time.Duration) (*PresignedUpload, error) {
+	presignClient := s3.NewPresignClient(c.s3Client)
+
+	req, err := presignClient.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(c.bucket),
+		Key:           aws.String(key),
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(contentLength),
+	}, func(opts *s3.PresignOptions) {
+		opts.Expires = expiration
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign upload: %w", err)
+	}
+
+	headers := make(map[string]string)
+	for name, values := range req.SignedHeader {
+		if !strings.EqualFold(name, "Host") && len(values) > 0 {
+			headers[name] = values[0]
+		}
+	}
+	return &PresignedUpload{URL: req.URL, Headers: headers}, nil
+}
+
+// HeadObject returns the size and content type of an object stored in bucket (as returned by Upload)
+func (c *Client) HeadObject(ctx context.Context, bucket, key string) (*ObjectInfo, error) {
+	bucket = c.bucketFor(bucket)
+	start := time.Now()
+	head, err := c.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	c.logSlow("HeadObject", bucket, key, start, err)
+
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object metadata from S3: %w", err)
+	}
+
+	return &ObjectInfo{
+		Key:         key,
+		ETag:        strings.Trim(aws.ToString(head.ETag), `"`),
+		Size:        aws.ToInt64(head.ContentLength),
+		ContentType: aws.ToString(head.ContentType),
+	}, nil
+}
+
+// CopyObject copies the object at srcKey to dstKey in the primary bucket, only if it still has etag (as
+// returned by HeadObject), so the copy is the object that was checked.
+func (c *Client) CopyObject(ctx context.Context, srcKey, dstKey, etag string) error {
+	start := time.Now()
+	_, err := c.s3Client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:            aws.String(c.bucket),
+		Key:               aws.String(dstKey),
+		CopySource:        aws.String(url.PathEscape(c.bucket + "/" + srcKey)),
+		CopySourceIfMatch: aws.String(`"` + etag + `"`),
+	})
+	c.logSlow("CopyObject", c.bucket, dstKey, start, err)
+
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusPreconditionFailed {
+		return fmt.Errorf("%w: %s", ErrObjectChanged, srcKey)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to copy object in S3: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes an object stored in bucket (as returned by Upload) from S3
+func (c *Client) Delete(ctx context.Context, bucket, key string) error {
+	bucket = c.bucketFor(bucket)
+	start := time.Now()
 	_, err := c.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
-		Bucket: aws.String(c.bucket),
+		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
 	})
+	c.logSlow("DeleteObject", bucket, key, start, err)
 
 	if err != nil {
 		return fmt.Errorf("failed to delete from S3: %w", err)
 	}
 
 	log.Info().
-		Str("bucket", c.bucket).
+		Str("bucket", bucket).
 		Str("key", key).
 		Msg("File deleted from S3")
 
 	return nil
 }
 
-// GetObject retrieves an object from S3
-func (c *Client) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+// GetObject retrieves an object stored in bucket (as returned by Upload) from S3
+func (c *Client) GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	bucket = c.bucketFor(bucket)
+	start := time.Now()
 	result, err := c.s3Client.GetObject(ctx, &s3.GetObjectInput{
-		Bucket: aws.String(c.bucket),
+		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
 	})
+	c.logSlow("GetObject", bucket, key, start, err)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get object from S3: %w", err)
@@ -144,3 +391,39 @@ func (c *Client) GetObject(ctx context.Context, key string) (io.ReadCloser, erro
 
 	return result.Body, nil
 }
+
+// ObjectInfo describes a listed object
+type ObjectInfo struct {
+	Key         string
+	ETag        string // without quotes
+	Size        int64
+	ContentType string // set by HeadObject only
+}
+
+// ListObjects lists up to limit objects whose keys start with prefix, in key order
+func (c *Client) ListObjects(ctx context.Context, prefix string, limit int) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	paginator := s3.NewListObjectsV2Paginator(c.s3Client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(c.bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() && len(objects) < limit {
+		start := time.Now()
+		page, err := paginator.NextPage(ctx)
+		c.logSlow("ListObjectsV2", c.bucket, prefix, start, err)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects in S3: %w", err)
+		}
+		for _, obj := range page.Contents {
+			if len(objects) == limit {
+				break
+			}
+			objects = append(objects, ObjectInfo{
+				Key:  aws.ToString(obj.Key),
+				ETag: strings.Trim(aws.ToString(obj.ETag), `"`),
+				Size: aws.ToInt64(obj.Size),
+			})
+		}
+	}
+	return objects, nil
+}
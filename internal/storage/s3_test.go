@@ -0,0 +1,251 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakeS3 serves path-style PUT (including copies) and HEAD requests (/bucket/key) from memory.
+type fakeS3 struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	types    map[string]string // path -> Content-Type of the PUT
+	failPuts map[string]int    // bucket -> PUTs still to fail with 503
+	puts     map[string]int    // bucket -> PUTs received
+	truncate bool              // store one byte less than sent
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	bucket, _, _ := strings.Cut(path, "/")
+	switch r.Method {
+	case http.MethodPut:
+		if src := r.Header.Get("X-Amz-Copy-Source"); src != "" {
+			f.copyObject(w, r, path, src)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		f.puts[bucket]++
+		if f.failPuts[bucket] > 0 {
+			f.failPuts[bucket]--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if f.truncate && len(body) > 0 {
+			body = body[:len(body)-1]
+		}
+		f.objects[path] = body
+		f.types[path] = r.Header.Get("Content-Type")
+		w.Header().Set("ETag", etag(body))
+	case http.MethodHead:
+		body, ok := f.objects[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Header().Set("Content-Type", f.types[path])
+		w.Header().Set("ETag", etag(body))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// copyObject copies the object at src (bucket/key, escaped) to path, honoring x-amz-copy-source-if-match.
+func (f *fakeS3) copyObject(w http.ResponseWriter, r *http.Request, path, src string) {
+	src, _ = url.PathUnescape(src)
+	body, ok := f.objects[src]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if match := r.Header.Get("X-Amz-Copy-Source-If-Match"); match != "" && match != etag(body) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		fmt.Fprint(w, `<Error><Code>PreconditionFailed</Code><Message>At least one of the pre-conditions you specified did not hold</Message></Error>`)
+		return
+	}
+	f.objects[path] = body
+	f.types[path] = f.types[src]
+	fmt.Fprintf(w, `<CopyObjectResult><ETag>%s</ETag></CopyObjectResult>`, etag(body))
+}
+
+func etag(body []byte) string {
+	return fmt.Sprintf(`"%x"`, md5.Sum(body))
+}
+
+func newTestClient(t *testing.T, fake *fakeS3) *Client {
+	t.Helper()
+	fake.objects = map[string][]byte{}
+	fake.types = map[string]string{}
+	fake.puts = map[string]int{}
+	if fake.failPuts == nil {
+		fake.failPuts = map[string]int{}
+	}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	return &Client{
+		s3Client: s3.New(s3.Options{
+			Region:       "us-east-1",
+			BaseEndpoint: aws.String(srv.URL),
+			UsePathStyle: true,
+			Credentials:  credentials.NewStaticCredentialsProvider("key", "secret", ""),
+			Retryer:      aws.NopRetryer{},
+		}),
+		bucket: "primary",
+	}
+}
+
+func TestUpload_RetriesThenSucceeds(t *testing.T) {
+	fake := &fakeS3{failPuts: map[string]int{"primary": 2}}
+	c := newTestClient(t, fake)
+	c.SetUploadRetries(2, time.Millisecond, true)
+
+	// A plain io.Reader is buffered so it can be sent again
+	bucket, err := c.Upload(context.Background(), "a/b.mp3", io.LimitReader(strings.NewReader("audio data"), 10), "audio/mpeg", 10)
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if bucket != "primary" {
+		t.Errorf("bucket = %q, want primary", bucket)
+	}
+	if fake.puts["primary"] != 3 {
+		t.Errorf("PUTs = %d, want 3", fake.puts["primary"])
+	}
+	if got := string(fake.objects["primary/a/b.mp3"]); got != "audio data" {
+		t.Errorf("stored %q, want %q", got, "audio data")
+	}
+}
+
+func TestUpload_RetriesExhausted(t *testing.T) {
+	fake := &fakeS3{failPuts: map[string]int{"primary": 5}}
+	c := newTestClient(t, fake)
+	c.SetUploadRetries(1, time.Millisecond, false)
+
+	if _, err := c.Upload(context.Background(), "k", strings.NewReader("data"), "text/plain", 4); err == nil {
+		t.Fatal("Upload succeeded, want error")
+	}
+	if fake.puts["primary"] != 2 {
+		t.Errorf("PUTs = %d, want 2", fake.puts["primary"])
+	}
+}
+
+func TestUpload_VerifySizeMismatch(t *testing.T) {
+	fake := &fakeS3{truncate: true}
+	c := newTestClient(t, fake)
+	c.SetUploadRetries(1, time.Millisecond, true)
+
+	_, err := c.Upload(context.Background(), "k", strings.NewReader("data"), "text/plain", 4)
+	if err == nil || !strings.Contains(err.Error(), "verification failed") {
+		t.Fatalf("err = %v, want verification failure", err)
+	}
+	if fake.puts["primary"] != 2 {
+		t.Errorf("PUTs = %d, want 2 (a failed verification is retried)", fake.puts["primary"])
+	}
+
+	// Without verification the truncated object goes unnoticed
+	c.SetUploadRetries(0, 0, false)
+	if _, err := c.Upload(context.Background(), "k", strings.NewReader("data"), "text/plain", 4); err != nil {
+		t.Errorf("unverified Upload: %v", err)
+	}
+}
+
+func TestUpload_FallbackBucket(t *testing.T) {
+	fake := &fakeS3{failPuts: map[string]int{"primary": 10}}
+	c := newTestClient(t, fake)
+	c.publicURL = "http://cdn.example/assets"
+	c.SetUploadRetries(1, time.Millisecond, true)
+	c.SetFallbackBucket("fallback")
+
+	bucket, err := c.Upload(context.Background(), "k.png", strings.NewReader("png"), "image/png", 3)
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if bucket != "fallback" {
+		t.Errorf("bucket = %q, want fallback", bucket)
+	}
+	if _, ok := fake.objects["fallback/k.png"]; !ok {
+		t.Error("object not stored in the fallback bucket")
+	}
+	if u := c.PublicURL(bucket, "k.png"); u != "" {
+		t.Errorf("PublicURL in the fallback bucket = %q, want empty", u)
+	}
+	if u := c.PublicURL("primary", "k.png"); u != "http://cdn.example/assets/k.png" {
+		t.Errorf("PublicURL = %q", u)
+	}
+}
+
+func TestPresignUpload(t *testing.T) {
+	fake := &fakeS3{}
+	c := newTestClient(t, fake)
+	ctx := context.Background()
+
+	up, err := c.PresignUpload(ctx, "files/u/f.pdf", "application/pdf", 4, time.Minute)
+	if err != nil {
+		t.Fatalf("PresignUpload: %v", err)
+	}
+	if !strings.Contains(up.URL, "X-Amz-Signature=") || up.Headers["Content-Type"] != "application/pdf" {
+		t.Fatalf("upload = %+v, want a signed URL and the content type to send", up)
+	}
+	req, _ := http.NewRequest(http.MethodPut, up.URL, strings.NewReader("%PDF"))
+	for name, value := range up.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	info, err := c.HeadObject(ctx, "", "files/u/f.pdf")
+	if err != nil || info.Size != 4 || info.ContentType != "application/pdf" {
+		t.Errorf("HeadObject = %+v, %v; want the uploaded PDF", info, err)
+	}
+	if _, err := c.HeadObject(ctx, "", "files/u/missing.pdf"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("HeadObject of a missing object: err = %v, want ErrObjectNotFound", err)
+	}
+}
+
+func TestCopyObject(t *testing.T) {
+	fake := &fakeS3{}
+	c := newTestClient(t, fake)
+	ctx := context.Background()
+	if _, err := c.Upload(ctx, "uploads/u/f.pdf", strings.NewReader("%PDF"), "application/pdf", 4); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	info, err := c.HeadObject(ctx, "", "uploads/u/f.pdf")
+	if err != nil {
+		t.Fatalf("HeadObject: %v", err)
+	}
+
+	if err := c.CopyObject(ctx, "uploads/u/f.pdf", "files/u/f.pdf", info.ETag); err != nil {
+		t.Fatalf("CopyObject: %v", err)
+	}
+	if got, err := c.HeadObject(ctx, "", "files/u/f.pdf"); err != nil || got.ETag != info.ETag || got.ContentType != "application/pdf" {
+		t.Errorf("copy = %+v, %v; want the uploaded PDF", got, err)
+	}
+
+	// The source was replaced after it was checked
+	if _, err := c.Upload(ctx, "uploads/u/f.pdf", strings.NewReader("%PDF-2"), "application/pdf", 6); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if err := c.CopyObject(ctx, "uploads/u/f.pdf", "files/u/g.pdf", info.ETag); !errors.Is(err, ErrObjectChanged) {
+		t.Errorf("copy of a replaced object: err = %v, want ErrObjectChanged", err)
+	}
+}
@@ -0,0 +1,130 @@
+package teleprompter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// renderText renders the script as plain text: emphasized text in asterisks, cues in brackets.
+func renderText(title string, sections []section) []byte {
+	var b strings.Builder
+	b.WriteString(title + "\n" + strings.Repeat("=", len([]rune(title))) + "\n")
+	for _, sec := range sections {
+		fmt.Fprintf(&b, "\n%d. %s (~%s)\n", sec.Number, sec.Title, sec.Duration)
+		for _, p := range sec.Paragraphs {
+			b.WriteString("\n")
+			for _, r := range p {
+				if r.Bold {
+					b.WriteString("*" + r.Text + "*")
+				} else {
+					b.WriteString(r.Text)
+				}
+			}
+			b.WriteString("\n")
+		}
+	}
+	return []byte(b.String())
+}
+
+// htmlTemplate is a standalone page in large type on a dark background, readable from a distance.
+var htmlTemplate = template.Must(template.New("script").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { background: #111; color: #f5f5f5; font-family: Georgia, serif; font-size: 2rem; line-height: 1.6; max-width: 40em; margin: 2rem auto; padding: 0 1.5rem; }
+h1 { font-size: 2.4rem; }
+h2 { font-family: sans-serif; font-size: 1.2rem; color: #9ca3af; border-top: 1px solid #333; padding-top: 1.5rem; margin-top: 3rem; }
+.time { font-weight: normal; }
+.cue { color: #f0b429; font-style: italic; font-size: 0.8em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{range .Sections}}<section>
+<h2>{{.Number}}. {{.Title}} <span class="time">(~{{.Duration}})</span></h2>
+{{range .Paragraphs}}<p>{{range .}}{{if .Cue}}<span class="cue">{{.Text}}</span>{{else if .Bold}}<strong>{{.Text}}</strong>{{else}}{{.Text}}{{end}}{{end}}</p>
+{{end}}</section>
+{{end}}</body>
+</html>
+`))
+
+// renderHTML renders the script as an HTML page.
+func renderHTML(title string, sections []section) ([]byte, error) {
+	var b bytes.Buffer
+	if err := htmlTemplate.Execute(&b, map[string]any{"Title": title, "Sections": sections}); err != nil {
+		return nil, fmt.Errorf("failed to render script HTML: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// Parts of the Word document besides word/document.xml
+const (
+	docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/></Types>`
+	docxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/></Relationships>`
+)
+
+// renderDocx renders the script as a minimal Word (OOXML) document with direct formatting only, so it
+// needs no styles part: 14pt body text, bold headings, cues in grey italics.
+func renderDocx(title string, sections []section) ([]byte, error) {
+	var doc strings.Builder
+	doc.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	doc.WriteString(`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>`)
+	docxParagraph(&doc, []run{{Text: title, Bold: true}}, 40)
+	for _, sec := range sections {
+		docxParagraph(&doc, []run{
+			{Text: fmt.Sprintf("%d. %s ", sec.Number, sec.Title), Bold: true},
+			{Text: "(~" + sec.Duration + ")", Cue: true},
+		}, 32)
+		for _, p := range sec.Paragraphs {
+			docxParagraph(&doc, p, 28)
+		}
+	}
+	doc.WriteString(`</w:body></w:document>`)
+
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for _, part := range []struct{ name, content string }{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxRels},
+		{"word/document.xml", doc.String()},
+	} {
+		w, err := zw.Create(part.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", part.name, err)
+		}
+		if _, err := w.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", part.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write script docx: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// docxParagraph writes a paragraph of runs in halfPoints font size.
+func docxParagraph(doc *strings.Builder, runs []run, halfPoints int) {
+	doc.WriteString(`<w:p><w:pPr><w:spacing w:after="240"/></w:pPr>`)
+	for _, r := range runs {
+		doc.WriteString(`<w:r><w:rPr>`)
+		if r.Bold {
+			doc.WriteString(`<w:b/>`)
+		}
+		if r.Cue {
+			doc.WriteString(`<w:i/><w:color w:val="888888"/>`)
+		}
+		fmt.Fprintf(doc, `<w:sz w:val="%d"/></w:rPr><w:t xml:space="preserve">`, halfPoints)
+		xml.EscapeText(doc, []byte(r.Text))
+		doc.WriteString(`</w:t></w:r>`)
+	}
+	doc.WriteString(`</w:p>`)
+}
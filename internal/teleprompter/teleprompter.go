@@ -0,0 +1,141 @@
+// Package teleprompter renders a job's narration scripts as a reading document (plain text, HTML or
+// Word) for customers who record their own narration instead of using TTS. Control tags are shown as
+// reading cues: breaks as "[pause 1s]", emphasized text in bold (asterisks in plain text).
+package teleprompter
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/snappy-loop/stories/internal/ssml"
+)
+
+// Formats of the document
+const (
+	FormatText = "txt"
+	FormatHTML = "html"
+	FormatDocx = "docx"
+)
+
+// WordsPerMinute is the narration pace of the estimated reading times.
+const WordsPerMinute = 150
+
+// ErrUnsupportedFormat is returned by Render for a format other than txt, html and docx.
+var ErrUnsupportedFormat = errors.New("unsupported script format")
+
+var contentTypes = map[string]string{
+	FormatText: "text/plain; charset=utf-8",
+	FormatHTML: "text/html; charset=utf-8",
+	FormatDocx: "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+}
+
+// Script is the document: the job's title and one section per segment, in order.
+type Script struct {
+	Title    string
+	Sections []Section
+}
+
+// Section is one segment's narration script.
+type Section struct {
+	Number    int // 1-based
+	Title     string
+	Narration string // may contain control tags
+}
+
+// run is a piece of a paragraph: text, emphasized text or a reading cue.
+type run struct {
+	Text string
+	Bold bool
+	Cue  bool
+}
+
+// section is a Section prepared for rendering.
+type section struct {
+	Number     int
+	Title      string
+	Duration   string // estimated reading time, m:ss
+	Paragraphs [][]run
+}
+
+// ContentType returns the MIME type of format, or "" if it is not supported.
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// Render renders s in format (FormatText, FormatHTML or FormatDocx).
+func Render(s *Script, format string) ([]byte, error) {
+	sections := make([]section, len(s.Sections))
+	for i, sec := range s.Sections {
+		paragraphs, d := parseNarration(sec.Narration)
+		sections[i] = section{Number: sec.Number, Title: sec.Title, Duration: formatDuration(d), Paragraphs: paragraphs}
+	}
+	switch format {
+	case FormatText:
+		return renderText(s.Title, sections), nil
+	case FormatHTML:
+		return renderHTML(s.Title, sections)
+	case FormatDocx:
+		return renderDocx(s.Title, sections)
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedFormat, format)
+	}
+}
+
+// parseNarration splits a narration script into paragraphs (at line breaks) of runs and estimates its
+// reading time. A script with invalid tags is shown without them.
+func parseNarration(narration string) ([][]run, time.Duration) {
+	spans, err := ssml.Spans(narration)
+	if err != nil {
+		spans = []ssml.Span{{Text: ssml.Strip(narration)}}
+	}
+	var paragraphs [][]run
+	var current []run
+	words := 0
+	var pause time.Duration
+	for _, sp := range spans {
+		if sp.Break {
+			if sp.Pause > 0 {
+				current = append(current, run{Text: "[pause " + formatPause(sp.Pause) + "]", Cue: true})
+				pause += sp.Pause
+			}
+			continue
+		}
+		words += len(strings.Fields(sp.Text))
+		for i, line := range strings.Split(sp.Text, "\n") {
+			if i > 0 && len(current) > 0 {
+				paragraphs = append(paragraphs, current)
+				current = nil
+			}
+			if len(current) == 0 {
+				line = strings.TrimLeft(line, " \t\r")
+			}
+			if line != "" {
+				current = append(current, run{Text: line, Bold: sp.Emphasis})
+			}
+		}
+	}
+	if len(current) > 0 {
+		paragraphs = append(paragraphs, current)
+	}
+	for _, p := range paragraphs {
+		p[len(p)-1].Text = strings.TrimRight(p[len(p)-1].Text, " \t\r")
+	}
+	if len(paragraphs) == 0 {
+		paragraphs = [][]run{{{Text: "[no narration]", Cue: true}}}
+	}
+	return paragraphs, time.Duration(words)*time.Minute/WordsPerMinute + pause
+}
+
+// formatPause formats a break's pause in seconds, e.g. "1.5s".
+func formatPause(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
+
+// formatDuration formats d as m:ss, rounded to seconds.
+func formatDuration(d time.Duration) string {
+	s := int(d.Round(time.Second).Seconds())
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
@@ -0,0 +1,97 @@
+package teleprompter
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+var script = &Script{
+	Title: "Deploying <apps>",
+	Sections: []Section{
+		{Number: 1, Title: "Intro", Narration: `Welcome. <break time="1.5s"/> An <say-as interpret-as="characters">API</say-as> is <emphasis>an interface</emphasis>.
+Second paragraph.`},
+		{Number: 2, Title: "Empty"},
+	},
+}
+
+func TestRenderText(t *testing.T) {
+	out, err := Render(script, FormatText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Deploying <apps>\n================\n" +
+		"\n1. Intro (~0:05)\n\nWelcome. [pause 1.5s] An API is *an interface*.\n\nSecond paragraph.\n" +
+		"\n2. Empty (~0:00)\n\n[no narration]\n"
+	if string(out) != want {
+		t.Errorf("text =\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	out, err := Render(script, FormatHTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<title>Deploying &lt;apps&gt;</title>",
+		`<span class="cue">[pause 1.5s]</span>`,
+		"<strong>an interface</strong>",
+		"<p>Second paragraph.</p>",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("HTML missing %q", want)
+		}
+	}
+}
+
+func TestRenderDocx(t *testing.T) {
+	out, err := Render(script, FormatDocx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(out), int64(len(out)))
+	if err != nil {
+		t.Fatalf("not a zip: %v", err)
+	}
+	parts := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(data)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "word/document.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("missing part %s", name)
+		}
+	}
+	doc := parts["word/document.xml"]
+	for _, want := range []string{"Deploying &lt;apps&gt;", "<w:b/>", "an interface", "[pause 1.5s]", "Second paragraph."} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("document.xml missing %q", want)
+		}
+	}
+}
+
+func TestRenderInvalidTagsAndFormat(t *testing.T) {
+	out, err := Render(&Script{Title: "T", Sections: []Section{{Number: 1, Title: "A", Narration: "<emphasis>unclosed"}}}, FormatText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "\nunclosed\n") {
+		t.Errorf("text = %q, want tags stripped", out)
+	}
+	if _, err := Render(script, "pdf"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("err = %v, want ErrUnsupportedFormat", err)
+	}
+	if ContentType("pdf") != "" || ContentType(FormatDocx) == "" {
+		t.Error("unexpected ContentType")
+	}
+}
@@ -0,0 +1,69 @@
+package testutil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/models"
+)
+
+// CreateUser inserts a user.
+func CreateUser(t testing.TB, db *database.DB) *models.User {
+	t.Helper()
+	email := "user-" + randomHex(4) + "@example.com"
+	user := &models.User{ID: uuid.New(), Email: &email, CreatedAt: time.Now()}
+	if err := database.NewUserRepository(db).Create(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+// CreateAPIKey inserts an active API key with a monthly quota of a million characters for userID and
+// returns it with its plaintext value (for Authorization headers).
+func CreateAPIKey(t testing.TB, db *database.DB, userID uuid.UUID) (string, *models.APIKey) {
+	t.Helper()
+	plain, key, err := database.NewAPIKeyRepository(db).CreateAPIKey(context.Background(), userID, 1_000_000, "monthly")
+	if err != nil {
+		t.Fatalf("create API key: %v", err)
+	}
+	return plain, key
+}
+
+// NewJob returns a queued two-segment educational text job of key's user, not yet inserted; adjust the
+// fields and insert it with InsertJob.
+func NewJob(key *models.APIKey) *models.Job {
+	return &models.Job{
+		ID:                   uuid.New(),
+		UserID:               key.UserID,
+		APIKeyID:             key.ID,
+		Status:               models.JobStatusQueued,
+		InputType:            "educational",
+		SegmentsCount:        2,
+		AudioType:            "free_speech",
+		InputText:            "The Sun is a star at the center of the Solar System. It is very hot!\n\nEight planets orbit the Sun. Earth is the third planet.",
+		InputSource:          "text",
+		SegmentationStrategy: models.SegmentationStrategyLLM,
+		CreatedAt:            time.Now(),
+	}
+}
+
+// InsertJob inserts job.
+func InsertJob(t testing.TB, db *database.DB, job *models.Job) {
+	t.Helper()
+	if err := database.NewJobRepository(db).Create(context.Background(), job); err != nil {
+		t.Fatalf("create job: %v", err)
+	}
+}
+
+// CreateJob inserts a NewJob for a new user and API key.
+func CreateJob(t testing.TB, db *database.DB) *models.Job {
+	t.Helper()
+	user := CreateUser(t, db)
+	_, key := CreateAPIKey(t, db, user.ID)
+	job := NewJob(key)
+	InsertJob(t, db, job)
+	return job
+}
@@ -0,0 +1,147 @@
+// Package testutil provides the backing services of integration tests (Postgres, Kafka, MinIO, Redis) and
+// builders for test data.
+//
+// Each service is taken from an env var when set (TEST_DATABASE_URL, TEST_KAFKA_BROKERS, TEST_S3_ENDPOINT,
+// TEST_REDIS_URL), as CI does with service containers, and otherwise started in Docker on first use. Tests that need a
+// service skip when neither is available, so `go test ./...` keeps working on machines without Docker.
+// Containers are shared by the tests of a package; packages call Cleanup from TestMain to remove them:
+//
+//	func TestMain(m *testing.M) {
+//		code := m.Run()
+//		testutil.Cleanup()
+//		os.Exit(code)
+//	}
+package testutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startupTimeout bounds how long a container may take to accept connections.
+const startupTimeout = 90 * time.Second
+
+// errNoDocker marks services that cannot be started because Docker is missing.
+var errNoDocker = errors.New("docker not available")
+
+var (
+	containersMu sync.Mutex
+	containers   []string
+)
+
+// Cleanup removes the containers started by this package. Safe to call when none were started.
+func Cleanup() {
+	containersMu.Lock()
+	ids := containers
+	containers = nil
+	containersMu.Unlock()
+	for _, id := range ids {
+		_ = exec.Command("docker", "rm", "-f", "-v", id).Run()
+	}
+}
+
+// service is a backing service started at most once per test binary.
+type service struct {
+	once sync.Once
+	addr string
+	err  error
+}
+
+// get returns the service address from env, or starts the service with start.
+func (s *service) get(t testing.TB, env string, start func() (string, error)) string {
+	t.Helper()
+	if v := os.Getenv(env); v != "" {
+		return v
+	}
+	s.once.Do(func() {
+		if !dockerAvailable() {
+			s.err = errNoDocker
+			return
+		}
+		s.addr, s.err = start()
+	})
+	if errors.Is(s.err, errNoDocker) {
+		t.Skipf("%s not set and Docker is not available", env)
+	}
+	if s.err != nil {
+		t.Fatalf("start service for %s: %v", env, s.err)
+	}
+	return s.addr
+}
+
+func dockerAvailable() bool {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return exec.CommandContext(ctx, "docker", "info").Run() == nil
+}
+
+// runContainer starts image detached with args (docker run flags) and cmd, and returns its ID. The
+// container is removed by Cleanup.
+func runContainer(image string, args []string, cmd ...string) (string, error) {
+	runArgs := append([]string{"run", "-d", "--label", "stories-testutil=1"}, args...)
+	runArgs = append(append(runArgs, image), cmd...)
+	out, err := exec.Command("docker", runArgs...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("docker run %s: %w: %s", image, err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("docker run %s: %w", image, err)
+	}
+	id := strings.TrimSpace(string(out))
+	containersMu.Lock()
+	containers = append(containers, id)
+	containersMu.Unlock()
+	return id, nil
+}
+
+// hostPort returns the host address (127.0.0.1:port) a container port is published on.
+func hostPort(id string, port int) (string, error) {
+	out, err := exec.Command("docker", "port", id, fmt.Sprintf("%d/tcp", port)).Output()
+	if err != nil {
+		return "", fmt.Errorf("docker port %s: %w", id, err)
+	}
+	// One line per address family, e.g. "127.0.0.1:49153"
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if strings.HasPrefix(line, "127.0.0.1:") || strings.HasPrefix(line, "0.0.0.0:") {
+			return "127.0.0.1:" + line[strings.LastIndex(line, ":")+1:], nil
+		}
+	}
+	return "", fmt.Errorf("container %s does not publish port %d", id, port)
+}
+
+// freePort returns a free local TCP port, for services that must advertise their host port.
+func freePort() (int, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port, nil
+}
+
+// waitFor retries ready until it succeeds or startupTimeout passes.
+func waitFor(what string, ready func() error) error {
+	deadline := time.Now().Add(startupTimeout)
+	for {
+		err := ready()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s not ready after %s: %w", what, startupTimeout, err)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
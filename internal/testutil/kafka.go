@@ -0,0 +1,63 @@
+package testutil
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+var redpanda service
+
+// KafkaBrokers returns the brokers of TEST_KAFKA_BROKERS (comma-separated) or of a Redpanda container, the
+// Kafka the compose stack runs.
+func KafkaBrokers(t testing.TB) []string {
+	t.Helper()
+	return strings.Split(redpanda.get(t, "TEST_KAFKA_BROKERS", startRedpanda), ",")
+}
+
+// KafkaTopic creates a topic with a unique name for t and returns the name.
+func KafkaTopic(t testing.TB, brokers []string) string {
+	t.Helper()
+	topic := "test-" + randomHex(8)
+	conn, err := kafka.Dial("tcp", brokers[0])
+	if err != nil {
+		t.Fatalf("dial kafka: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.CreateTopics(kafka.TopicConfig{Topic: topic, NumPartitions: 1, ReplicationFactor: 1}); err != nil {
+		t.Fatalf("create topic: %v", err)
+	}
+	return topic
+}
+
+func startRedpanda() (string, error) {
+	// Kafka clients connect to the advertised address, so the host port is chosen up front
+	port, err := freePort()
+	if err != nil {
+		return "", err
+	}
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	_, err = runContainer("docker.redpanda.com/redpandadata/redpanda:v24.3.1",
+		[]string{"-p", fmt.Sprintf("%s:%d", addr, port)},
+		"redpanda", "start",
+		"--kafka-addr", fmt.Sprintf("0.0.0.0:%d", port),
+		"--advertise-kafka-addr", addr,
+		"--mode", "dev-container",
+		"--smp", "1",
+	)
+	if err != nil {
+		return "", err
+	}
+	err = waitFor("redpanda", func() error {
+		conn, err := kafka.Dial("tcp", addr)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		_, err = conn.Brokers()
+		return err
+	})
+	return addr, err
+}
@@ -0,0 +1,83 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/snappy-loop/stories/internal/proxy"
+	"github.com/snappy-loop/stories/internal/storage"
+)
+
+const (
+	minioRegion    = "us-east-1"
+	minioAccessKey = "minioadmin"
+	minioSecretKey = "minioadmin"
+)
+
+var minio service
+
+// S3 returns a storage client on a fresh bucket for t, and the bucket name. The server is TEST_S3_ENDPOINT
+// (with TEST_S3_ACCESS_KEY and TEST_S3_SECRET_KEY, default minioadmin) or a MinIO container.
+func S3(t testing.TB) (*storage.Client, string) {
+	t.Helper()
+	endpoint := minio.get(t, "TEST_S3_ENDPOINT", startMinio)
+	accessKey, secretKey := envOr("TEST_S3_ACCESS_KEY", minioAccessKey), envOr("TEST_S3_SECRET_KEY", minioSecretKey)
+
+	bucket := "test-" + randomHex(8)
+	s3Client := s3.New(s3.Options{
+		Region:       minioRegion,
+		BaseEndpoint: aws.String(endpoint),
+		Credentials:  credentials.NewStaticCredentialsProvider(accessKey, secretKey, ""),
+		UsePathStyle: true,
+	})
+	if _, err := s3Client.CreateBucket(context.Background(), &s3.CreateBucketInput{Bucket: aws.String(bucket)}); err != nil {
+		t.Fatalf("create bucket: %v", err)
+	}
+
+	client, err := storage.NewClient(endpoint, minioRegion, bucket, accessKey, secretKey, false, "", proxy.Direct)
+	if err != nil {
+		t.Fatalf("storage client: %v", err)
+	}
+	return client, bucket
+}
+
+func startMinio() (string, error) {
+	id, err := runContainer("minio/minio:latest", []string{
+		"-e", "MINIO_ROOT_USER=" + minioAccessKey,
+		"-e", "MINIO_ROOT_PASSWORD=" + minioSecretKey,
+		"-p", "127.0.0.1::9000",
+	}, "server", "/data")
+	if err != nil {
+		return "", err
+	}
+	addr, err := hostPort(id, 9000)
+	if err != nil {
+		return "", err
+	}
+	endpoint := "http://" + addr
+	err = waitFor("minio", func() error {
+		resp, err := http.Get(endpoint + "/minio/health/live")
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("health status %d", resp.StatusCode)
+		}
+		return nil
+	})
+	return endpoint, err
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
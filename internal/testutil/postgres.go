@@ -0,0 +1,90 @@
+package testutil
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"testing"
+
+	_ "github.com/lib/pq"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/migrations"
+)
+
+var postgres service
+
+// Postgres returns a connection to a fresh, migrated database for t, dropped when t ends. The server is
+// TEST_DATABASE_URL (any database on it; the user must be allowed to create databases) or a
+// postgres:16-alpine container.
+func Postgres(t testing.TB) *database.DB {
+	t.Helper()
+	serverURL := postgres.get(t, "TEST_DATABASE_URL", startPostgres)
+
+	admin, err := sql.Open("postgres", serverURL)
+	if err != nil {
+		t.Fatalf("open postgres: %v", err)
+	}
+	defer admin.Close()
+	name := "test_" + randomHex(8)
+	if _, err := admin.Exec("CREATE DATABASE " + name); err != nil {
+		t.Fatalf("create database: %v", err)
+	}
+	t.Cleanup(func() {
+		admin, err := sql.Open("postgres", serverURL)
+		if err != nil {
+			return
+		}
+		defer admin.Close()
+		_, _ = admin.Exec("DROP DATABASE IF EXISTS " + name + " WITH (FORCE)")
+	})
+
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		t.Fatalf("parse database URL: %v", err)
+	}
+	u.Path = "/" + name
+	db, err := database.Connect(u.String())
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := migrations.Run(db.SQLDB()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func startPostgres() (string, error) {
+	id, err := runContainer("postgres:16-alpine", []string{
+		"-e", "POSTGRES_USER=stories",
+		"-e", "POSTGRES_PASSWORD=stories_test_password",
+		"-e", "POSTGRES_DB=stories_test",
+		"-p", "127.0.0.1::5432",
+	})
+	if err != nil {
+		return "", err
+	}
+	addr, err := hostPort(id, 5432)
+	if err != nil {
+		return "", err
+	}
+	dsn := fmt.Sprintf("postgres://stories:stories_test_password@%s/stories_test?sslmode=disable", addr)
+	// The image's init runs a temporary server on the Unix socket only, so a TCP ping means the real one is up
+	err = waitFor("postgres", func() error {
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		return db.Ping()
+	})
+	return dsn, err
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
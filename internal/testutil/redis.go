@@ -0,0 +1,48 @@
+package testutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/snappy-loop/stories/internal/cache"
+)
+
+var redis service
+
+// Redis returns a client of TEST_REDIS_URL or of a redis:7-alpine container. Tests share the server, so
+// they should put their keys under KeyPrefix.
+func Redis(t testing.TB) *cache.Redis {
+	t.Helper()
+	client, err := cache.NewRedis(redis.get(t, "TEST_REDIS_URL", startRedis), 4)
+	if err != nil {
+		t.Fatalf("redis client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// KeyPrefix returns a prefix unique to this call for keys of shared servers.
+func KeyPrefix() string {
+	return "test:" + randomHex(8) + ":"
+}
+
+func startRedis() (string, error) {
+	id, err := runContainer("redis:7-alpine", []string{"-p", "127.0.0.1::6379"})
+	if err != nil {
+		return "", err
+	}
+	addr, err := hostPort(id, 6379)
+	if err != nil {
+		return "", err
+	}
+	url := "redis://" + addr
+	err = waitFor("redis", func() error {
+		client, err := cache.NewRedis(url, 1)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+		return client.Ping(context.Background())
+	})
+	return url, err
+}
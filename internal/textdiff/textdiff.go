@@ -0,0 +1,217 @@
+// Package textdiff computes word-level differences between two texts, e.g. a segment's source text and
+// its narration script, and renders them as HTML with <del> and <ins>.
+package textdiff
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// MaxEdits bounds the diff search; texts that differ by more edits are shown as entirely replaced.
+const MaxEdits = 2000
+
+// Kind is the kind of a diff operation.
+type Kind int
+
+const (
+	Equal Kind = iota
+	Delete
+	Insert
+)
+
+// Op is a run of text that is in both texts (Equal), only in the old one (Delete) or only in the new one
+// (Insert).
+type Op struct {
+	Kind Kind
+	Text string
+}
+
+// tokenRe splits text into words, punctuation characters and runs of whitespace.
+var tokenRe = regexp.MustCompile(`[\p{L}\p{N}]+|[^\p{L}\p{N}\s]|\s+`)
+
+// Words diffs old and new by word (punctuation and whitespace are separate tokens; any whitespace
+// matches any other) and returns the operations turning old into new, adjacent ones of a kind merged.
+func Words(old, new string) []Op {
+	a, b := tokenRe.FindAllString(old, -1), tokenRe.FindAllString(new, -1)
+	ka, kb := keys(a), keys(b)
+
+	// Common prefix and suffix are equal; diff only the middle
+	pre := 0
+	for pre < len(ka) && pre < len(kb) && ka[pre] == kb[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(ka)-pre && suf < len(kb)-pre && ka[len(ka)-1-suf] == kb[len(kb)-1-suf] {
+		suf++
+	}
+
+	var ops []Op
+	add := func(kind Kind, tokens []string) {
+		if len(tokens) == 0 {
+			return
+		}
+		text := strings.Join(tokens, "")
+		if n := len(ops); n > 0 && ops[n-1].Kind == kind {
+			ops[n-1].Text += text
+			return
+		}
+		ops = append(ops, Op{Kind: kind, Text: text})
+	}
+	add(Equal, b[:pre])
+	midA, midB := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	script, ok := myers(ka[pre:len(ka)-suf], kb[pre:len(kb)-suf])
+	if !ok {
+		add(Delete, midA)
+		add(Insert, midB)
+	} else {
+		i, j := 0, 0
+		for _, e := range script {
+			switch e {
+			case Equal:
+				// whitespace keys match any whitespace: show the new text's
+				add(Equal, midB[j:j+1])
+				i++
+				j++
+			case Delete:
+				add(Delete, midA[i:i+1])
+				i++
+			case Insert:
+				add(Insert, midB[j:j+1])
+				j++
+			}
+		}
+	}
+	add(Equal, b[len(b)-suf:])
+	return ops
+}
+
+// keys returns the comparison keys of tokens: whitespace collapses to a single space.
+func keys(tokens []string) []string {
+	out := make([]string, len(tokens))
+	for i, t := range tokens {
+		if strings.TrimSpace(t) == "" {
+			out[i] = " "
+		} else {
+			out[i] = t
+		}
+	}
+	return out
+}
+
+// myers returns the shortest edit script turning a into b, one Kind per token step (Myers' O(ND)
+// algorithm), or false when it needs more than MaxEdits edits.
+func myers(a, b []string) ([]Kind, bool) {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		script := make([]Kind, 0, n+m)
+		for range n {
+			script = append(script, Delete)
+		}
+		for range m {
+			script = append(script, Insert)
+		}
+		return script, true
+	}
+	maxD := min(n+m, MaxEdits)
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	var trace [][]int
+	for d := 0; d <= maxD; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // down: insert
+			} else {
+				x = v[offset+k-1] + 1 // right: delete
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// backtrack walks the saved V arrays (trace[d] holds v[-d-1..d+1] before step d) from (n, m) to the start.
+func backtrack(trace [][]int, n, m int) []Kind {
+	var rev []Kind
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := func(k int) int { return trace[d][k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v(k-1) < v(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := 0
+		if d > 0 {
+			prevX = v(prevK)
+		}
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			rev = append(rev, Equal)
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				rev = append(rev, Insert)
+			} else {
+				rev = append(rev, Delete)
+			}
+		}
+		x, y = prevX, prevY
+	}
+	script := make([]Kind, len(rev))
+	for i, e := range rev {
+		script[len(rev)-1-i] = e
+	}
+	return script
+}
+
+// HTML renders ops as escaped HTML: deleted text in <del>, inserted text in <ins>.
+func HTML(ops []Op) string {
+	var b strings.Builder
+	for _, op := range ops {
+		text := html.EscapeString(op.Text)
+		switch op.Kind {
+		case Delete:
+			b.WriteString("<del>" + text + "</del>")
+		case Insert:
+			b.WriteString("<ins>" + text + "</ins>")
+		default:
+			b.WriteString(text)
+		}
+	}
+	return b.String()
+}
+
+// Counts returns the number of words (tokens with a letter or digit) deleted and inserted by ops.
+func Counts(ops []Op) (deleted, inserted int) {
+	for _, op := range ops {
+		n := 0
+		for _, t := range tokenRe.FindAllString(op.Text, -1) {
+			if strings.IndexFunc(t, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+				n++
+			}
+		}
+		switch op.Kind {
+		case Delete:
+			deleted += n
+		case Insert:
+			inserted += n
+		}
+	}
+	return deleted, inserted
+}
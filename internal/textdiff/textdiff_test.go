@@ -0,0 +1,66 @@
+package textdiff
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	ops := Words("Revenue rose 5% in Q3.", "Revenue rose sharply, by 5%, in the third quarter.")
+	got := HTML(ops)
+	want := "Revenue rose <ins>sharply, by </ins>5%<ins>,</ins> in <del>Q3</del><ins>the third quarter</ins>."
+	if got != want {
+		t.Errorf("HTML = %q, want %q", got, want)
+	}
+	deleted, inserted := Counts(ops)
+	if deleted != 1 || inserted != 5 {
+		t.Errorf("Counts = %d, %d, want 1, 5", deleted, inserted)
+	}
+}
+
+func TestWordsEscapesAndIdentical(t *testing.T) {
+	if got := HTML(Words("a <b> c", "a <b>  c")); got != "a &lt;b&gt;  c" {
+		t.Errorf("HTML = %q, want the new text with equal whitespace", got)
+	}
+	if ops := Words("", "new"); len(ops) != 1 || ops[0] != (Op{Insert, "new"}) {
+		t.Errorf("ops = %+v, want one insert", ops)
+	}
+}
+
+// TestWordsRoundTrip checks that the ops rebuild both texts for random edits.
+func TestWordsRoundTrip(t *testing.T) {
+	vocab := strings.Fields("the a cash flow rose fell 5% 2024 , . risk fund")
+	rnd := rand.New(rand.NewSource(1))
+	text := func(n int) string {
+		words := make([]string, n)
+		for i := range words {
+			words[i] = vocab[rnd.Intn(len(vocab))]
+		}
+		return strings.Join(words, " ")
+	}
+	for i := 0; i < 200; i++ {
+		old, new := text(rnd.Intn(30)), text(rnd.Intn(30))
+		var a, b strings.Builder
+		for _, op := range Words(old, new) {
+			if op.Kind != Insert {
+				a.WriteString(op.Text)
+			}
+			if op.Kind != Delete {
+				b.WriteString(op.Text)
+			}
+		}
+		if a.String() != old || b.String() != new {
+			t.Fatalf("Words(%q, %q) rebuilds %q, %q", old, new, a.String(), b.String())
+		}
+	}
+}
+
+func TestWordsTooManyEdits(t *testing.T) {
+	old := strings.Repeat("x ", MaxEdits)
+	new := strings.Repeat("y ", MaxEdits)
+	ops := Words(old, new)
+	if len(ops) < 2 || ops[0].Kind != Delete || ops[1].Kind != Insert {
+		t.Errorf("got %d ops, want the texts replaced by a delete and an insert", len(ops))
+	}
+}
@@ -0,0 +1,133 @@
+// Package waveform computes peaks data (a downsampled amplitude array) from audio, so players can draw
+// a waveform without downloading the audio.
+package waveform
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// DefaultPeaks is the number of peaks stored per audio asset
+const DefaultPeaks = 200
+
+// Waveform is the peaks data of an audio file.
+type Waveform struct {
+	Peaks    []float64 `json:"peaks"`    // max absolute amplitude per bucket, 0-1 of full scale
+	Duration float64   `json:"duration"` // seconds
+}
+
+// ErrUnsupported is returned for audio that is not PCM or IEEE float WAV.
+var ErrUnsupported = errors.New("unsupported audio format")
+
+type wavFormat struct {
+	format        uint16 // 1 PCM, 3 IEEE float
+	channels      int
+	sampleRate    int
+	bitsPerSample int
+}
+
+// FromWAV computes n peaks from a WAV file (8, 16, 24 or 32-bit PCM, or 32-bit float). Each peak is the
+// largest absolute sample of any channel in its bucket of frames, rounded to two decimals. Audio shorter
+// than n frames has one peak per frame.
+func FromWAV(data []byte, n int) (*Waveform, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("invalid number of peaks %d", n)
+	}
+	f, pcm, err := parseWAV(data)
+	if err != nil {
+		return nil, err
+	}
+	bytesPerSample := f.bitsPerSample / 8
+	frameSize := bytesPerSample * f.channels
+	frames := len(pcm) / frameSize
+
+	w := &Waveform{Duration: math.Round(float64(frames)/float64(f.sampleRate)*1000) / 1000}
+	if frames == 0 {
+		w.Peaks = []float64{}
+		return w, nil
+	}
+	buckets := min(n, frames)
+	w.Peaks = make([]float64, buckets)
+	for b := 0; b < buckets; b++ {
+		start, end := b*frames/buckets, (b+1)*frames/buckets
+		var peak float64
+		for i := start * frameSize; i < end*frameSize; i += bytesPerSample {
+			peak = max(peak, math.Abs(sample(pcm[i:i+bytesPerSample], f)))
+		}
+		w.Peaks[b] = math.Round(min(peak, 1)*100) / 100
+	}
+	return w, nil
+}
+
+// parseWAV returns the format and the sample data of a RIFF WAVE file.
+func parseWAV(data []byte) (*wavFormat, []byte, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, nil, fmt.Errorf("%w: not a WAV file", ErrUnsupported)
+	}
+	var f *wavFormat
+	for pos := 12; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		body := data[pos+8:]
+		if size < 0 || size > len(body) {
+			size = len(body) // streamed WAVs have 0xFFFFFFFF or truncated sizes
+		}
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, nil, fmt.Errorf("%w: short fmt chunk", ErrUnsupported)
+			}
+			f = &wavFormat{
+				format:        binary.LittleEndian.Uint16(body[0:2]),
+				channels:      int(binary.LittleEndian.Uint16(body[2:4])),
+				sampleRate:    int(binary.LittleEndian.Uint32(body[4:8])),
+				bitsPerSample: int(binary.LittleEndian.Uint16(body[14:16])),
+			}
+			if f.format == 0xFFFE && size >= 26 { // WAVE_FORMAT_EXTENSIBLE: the sub-format GUID starts with the format
+				f.format = binary.LittleEndian.Uint16(body[24:26])
+			}
+		case "data":
+			if f == nil {
+				return nil, nil, fmt.Errorf("%w: data before fmt chunk", ErrUnsupported)
+			}
+			if err := f.validate(); err != nil {
+				return nil, nil, err
+			}
+			return f, body[:size], nil
+		}
+		pos += 8 + size + size%2 // chunks are padded to even sizes
+	}
+	return nil, nil, fmt.Errorf("%w: no data chunk", ErrUnsupported)
+}
+
+func (f *wavFormat) validate() error {
+	if f.channels < 1 || f.sampleRate < 1 {
+		return fmt.Errorf("%w: %d channels at %d Hz", ErrUnsupported, f.channels, f.sampleRate)
+	}
+	switch {
+	case f.format == 1 && (f.bitsPerSample == 8 || f.bitsPerSample == 16 || f.bitsPerSample == 24 || f.bitsPerSample == 32):
+	case f.format == 3 && f.bitsPerSample == 32:
+	default:
+		return fmt.Errorf("%w: format %d with %d bits per sample", ErrUnsupported, f.format, f.bitsPerSample)
+	}
+	return nil
+}
+
+// sample decodes one sample as a fraction of full scale (-1 to 1).
+func sample(b []byte, f *wavFormat) float64 {
+	switch {
+	case f.format == 3:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	case f.bitsPerSample == 8: // unsigned
+		return (float64(b[0]) - 128) / 128
+	case f.bitsPerSample == 16:
+		return float64(int16(binary.LittleEndian.Uint16(b))) / 32768
+	case f.bitsPerSample == 24:
+		v := int32(b[0]) | int32(b[1])<<8 | int32(int8(b[2]))<<16
+		return float64(v) / 8388608
+	default:
+		return float64(int32(binary.LittleEndian.Uint32(b))) / 2147483648
+	}
+}
@@ -0,0 +1,108 @@
+package waveform
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// wav builds a RIFF WAVE file with the given format and sample data.
+func wav(format, channels uint16, rate uint32, bits uint16, pcm []byte) []byte {
+	b := []byte("RIFF\x00\x00\x00\x00WAVE")
+	b = append(b, "fmt "...)
+	b = binary.LittleEndian.AppendUint32(b, 16)
+	b = binary.LittleEndian.AppendUint16(b, format)
+	b = binary.LittleEndian.AppendUint16(b, channels)
+	b = binary.LittleEndian.AppendUint32(b, rate)
+	b = binary.LittleEndian.AppendUint32(b, rate*uint32(channels*bits/8))
+	b = binary.LittleEndian.AppendUint16(b, channels*bits/8)
+	b = binary.LittleEndian.AppendUint16(b, bits)
+	b = append(b, "data"...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(pcm)))
+	b = append(b, pcm...)
+	binary.LittleEndian.PutUint32(b[4:8], uint32(len(b)-8))
+	return b
+}
+
+func pcm16(samples ...int16) []byte {
+	var b []byte
+	for _, s := range samples {
+		b = binary.LittleEndian.AppendUint16(b, uint16(s))
+	}
+	return b
+}
+
+func TestFromWAV(t *testing.T) {
+	// 8 mono frames at 4 Hz: 2 seconds, 4 peaks of 2 frames each
+	data := wav(1, 1, 4, 16, pcm16(0, 16384, -32768, 100, 3277, -3277, 0, 0))
+	w, err := FromWAV(data, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []float64{0.5, 1, 0.1, 0}
+	if len(w.Peaks) != len(want) {
+		t.Fatalf("peaks = %v, want %v", w.Peaks, want)
+	}
+	for i := range want {
+		if w.Peaks[i] != want[i] {
+			t.Errorf("peaks[%d] = %v, want %v", i, w.Peaks[i], want[i])
+		}
+	}
+	if w.Duration != 2 {
+		t.Errorf("duration = %v, want 2", w.Duration)
+	}
+}
+
+func TestFromWAVStereoTakesLoudestChannel(t *testing.T) {
+	data := wav(1, 2, 2, 16, pcm16(0, -16384, 8192, 0))
+	w, err := FromWAV(data, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.Peaks) != 2 || w.Peaks[0] != 0.5 || w.Peaks[1] != 0.25 {
+		t.Errorf("peaks = %v, want [0.5 0.25] (one per frame)", w.Peaks)
+	}
+	if w.Duration != 1 {
+		t.Errorf("duration = %v, want 1", w.Duration)
+	}
+}
+
+func TestFromWAV8Bit(t *testing.T) {
+	w, err := FromWAV(wav(1, 1, 1, 8, []byte{128, 0}), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Peaks[0] != 0 || w.Peaks[1] != 1 {
+		t.Errorf("peaks = %v, want [0 1]", w.Peaks)
+	}
+}
+
+func TestFromWAVEmpty(t *testing.T) {
+	w, err := FromWAV(wav(1, 1, 8000, 16, nil), DefaultPeaks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.Peaks) != 0 || w.Duration != 0 {
+		t.Errorf("got %+v, want no peaks", w)
+	}
+}
+
+func TestFromWAVErrors(t *testing.T) {
+	tests := map[string][]byte{
+		"not wav":      []byte("ID3\x03 mp3 data"),
+		"adpcm":        wav(2, 1, 8000, 4, []byte{0, 0}),
+		"no data":      wav(1, 1, 8000, 16, nil)[:36],
+		"zero rate":    wav(1, 1, 0, 16, pcm16(1)),
+		"float 64-bit": wav(3, 1, 8000, 64, make([]byte, 8)),
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := FromWAV(data, DefaultPeaks); !errors.Is(err, ErrUnsupported) {
+				t.Errorf("err = %v, want ErrUnsupported", err)
+			}
+		})
+	}
+	if _, err := FromWAV(wav(1, 1, 8000, 16, pcm16(1)), 0); err == nil {
+		t.Error("expected error for zero peaks")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -19,29 +20,48 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/snappy-loop/stories/internal/config"
 	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/egress"
 	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/proxy"
+	"github.com/snappy-loop/stories/pkg/webhookjwe"
 )
 
-// DeliveryService handles webhook delivery with retries
+// DeliveryService handles webhook delivery with retries. Requests go through the egress policy
+// (EGRESS_*) and the job owner's egress allow list; refused destinations fail without retries.
 type DeliveryService struct {
 	db           *database.DB
 	httpClient   *http.Client
+	egress       *egress.Policy
 	config       *config.Config
 	jobRepo      *database.JobRepository
+	userRepo     *database.UserRepository
 	deliveryRepo *database.WebhookDeliveryRepository
+	eventRepo    *database.JobEventRepository
+	segmentRepo  jobCounter
+	assetRepo    jobCounter
 	retryWorker  *RetryWorker
 }
 
+// jobCounter counts a job's rows (segments, assets) for summary and full payloads.
+type jobCounter interface {
+	CountByJob(ctx context.Context, jobID uuid.UUID) (int, error)
+}
+
 // NewDeliveryService creates a new webhook delivery service
 func NewDeliveryService(db *database.DB, cfg *config.Config) *DeliveryService {
+	policy := egress.NewPolicy(cfg.EgressAllowHosts, cfg.EgressDenyHosts, cfg.EgressAllowPrivate)
+	policy.SetProxy(proxy.Func(cfg.WebhookProxy))
 	service := &DeliveryService{
-		db: db,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		db:           db,
+		httpClient:   policy.Client(30 * time.Second),
+		egress:       policy,
 		config:       cfg,
 		jobRepo:      database.NewJobRepository(db),
+		userRepo:     database.NewUserRepository(db),
 		deliveryRepo: database.NewWebhookDeliveryRepository(db),
+		eventRepo:    database.NewJobEventRepository(db),
+		segmentRepo:  database.NewSegmentRepository(db),
+		assetRepo:    database.NewAssetRepository(db),
 	}
 
 	// Initialize retry worker
@@ -60,13 +80,101 @@ func (s *DeliveryService) Stop() {
 	s.retryWorker.Stop()
 }
 
-// WebhookPayload represents the webhook payload
+// WebhookPayload represents the webhook payload. Which fields are set depends on the job's
+// payload mode (models.WebhookPayload*); result_url always points at GET /v1/jobs/{id}.
 type WebhookPayload struct {
-	JobID        uuid.UUID  `json:"job_id"`
-	Status       string     `json:"status"`
-	FinishedAt   time.Time  `json:"finished_at"`
-	OutputMarkup *string    `json:"output_markup,omitempty"`
-	Error        *ErrorInfo `json:"error,omitempty"`
+	JobID         uuid.UUID         `json:"job_id"`
+	Status        string            `json:"status"`
+	Payload       string            `json:"payload"` // payload mode: status, summary or full
+	ResultURL     string            `json:"result_url,omitempty"`
+	StartedAt     *time.Time        `json:"started_at,omitempty"`
+	FinishedAt    time.Time         `json:"finished_at"`
+	DurationMs    *int64            `json:"duration_ms,omitempty"` // processing time (finished_at - started_at)
+	SegmentsTotal *int              `json:"segments_total,omitempty"`
+	AssetsTotal   *int              `json:"assets_total,omitempty"`
+	OutputMarkup  *string           `json:"output_markup,omitempty"`
+	Error         *ErrorInfo        `json:"error,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	Tags          []string          `json:"tags,omitempty"`
+}
+
+// BuildPayload builds the webhook payload for a finished job according to its payload mode.
+func (s *DeliveryService) BuildPayload(ctx context.Context, job *models.Job) WebhookPayload {
+	finishedAt := time.Now()
+	if job.FinishedAt != nil {
+		finishedAt = *job.FinishedAt
+	}
+	mode := models.WebhookPayloadSummary
+	if job.WebhookPayload != nil && *job.WebhookPayload != "" {
+		mode = *job.WebhookPayload
+	}
+
+	payload := WebhookPayload{
+		JobID:      job.ID,
+		Status:     job.Status,
+		Payload:    mode,
+		ResultURL:  resultURL(s.config.PublicAPIURL, job.ID),
+		StartedAt:  job.StartedAt,
+		FinishedAt: finishedAt,
+	}
+
+	if job.ErrorCode != nil && job.ErrorMessage != nil {
+		payload.Error = &ErrorInfo{
+			Code:    *job.ErrorCode,
+			Message: *job.ErrorMessage,
+		}
+	}
+
+	if mode == models.WebhookPayloadStatus {
+		return payload
+	}
+
+	payload.DurationMs = job.DurationMs
+	payload.Metadata = job.Metadata
+	payload.Tags = job.Tags
+	if n, err := s.segmentRepo.CountByJob(ctx, job.ResultJobID()); err != nil {
+		log.Warn().Err(err).Str("job_id", job.ID.String()).Msg("Failed to count segments for webhook payload")
+	} else {
+		payload.SegmentsTotal = &n
+	}
+	if n, err := s.assetRepo.CountByJob(ctx, job.ResultJobID()); err != nil {
+		log.Warn().Err(err).Str("job_id", job.ID.String()).Msg("Failed to count assets for webhook payload")
+	} else {
+		payload.AssetsTotal = &n
+	}
+
+	if mode == models.WebhookPayloadFull {
+		payload.OutputMarkup = job.OutputMarkup
+	}
+	return payload
+}
+
+// encodePayload returns the webhook body of job: its payload as JSON or, when the job has an encryption
+// key, that JSON as a compact JWE encrypted for the key.
+func (s *DeliveryService) encodePayload(ctx context.Context, job *models.Job) ([]byte, error) {
+	body, err := json.Marshal(s.BuildPayload(ctx, job))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	if job.WebhookEncryptionKey == nil || *job.WebhookEncryptionKey == "" {
+		return body, nil
+	}
+	key, err := webhookjwe.ParsePublicKey(*job.WebhookEncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse webhook encryption key: %w", err)
+	}
+	if body, err = webhookjwe.Encrypt(body, key); err != nil {
+		return nil, fmt.Errorf("failed to encrypt payload: %w", err)
+	}
+	return body, nil
+}
+
+// resultURL returns the API URL of a job, or "" when no public API URL is configured.
+func resultURL(baseURL string, jobID uuid.UUID) string {
+	if baseURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/v1/jobs/" + jobID.String()
 }
 
 // ErrorInfo represents error information in the webhook
@@ -80,6 +188,7 @@ type DeliveryError struct {
 	StatusCode int
 	Message    string
 	Body       string
+	Denied     bool // the egress policy refused the destination
 }
 
 func (e *DeliveryError) Error() string {
@@ -88,6 +197,10 @@ func (e *DeliveryError) Error() string {
 
 // IsRetryable determines if an error should be retried
 func (e *DeliveryError) IsRetryable() bool {
+	// Refused destinations stay refused
+	if e.Denied {
+		return false
+	}
 	// Retry on 5xx server errors
 	if e.StatusCode >= 500 && e.StatusCode < 600 {
 		return true
@@ -131,24 +244,10 @@ func (s *DeliveryService) DeliverWebhook(ctx context.Context, jobID uuid.UUID) e
 		return nil
 	}
 
-	// Create webhook payload
-	finishedAt := time.Now()
-	if job.FinishedAt != nil {
-		finishedAt = *job.FinishedAt
-	}
-
-	payload := WebhookPayload{
-		JobID:        job.ID,
-		Status:       job.Status,
-		FinishedAt:   finishedAt,
-		OutputMarkup: job.OutputMarkup,
-	}
-
-	if job.ErrorCode != nil && job.ErrorMessage != nil {
-		payload.Error = &ErrorInfo{
-			Code:    *job.ErrorCode,
-			Message: *job.ErrorMessage,
-		}
+	// Create webhook payload; every attempt sends these exact bytes
+	body, err := s.encodePayload(ctx, job)
+	if err != nil {
+		return err
 	}
 
 	// Create delivery record
@@ -177,7 +276,11 @@ func (s *DeliveryService) DeliverWebhook(ctx context.Context, jobID uuid.UUID) e
 	now := time.Now()
 	delivery.LastAttemptAt = &now
 
-	err = s.sendWebhook(ctx, *job.WebhookURL, payload, job.WebhookSecret)
+	status, err := 0, s.CheckDestination(ctx, job.UserID, *job.WebhookURL)
+	if err == nil {
+		status, err = s.PostBody(ctx, *job.WebhookURL, body, job.WebhookSecret)
+	}
+	s.recordAttempt(ctx, delivery, body, status, err)
 
 	if err == nil {
 		// Success on first attempt
@@ -191,6 +294,7 @@ func (s *DeliveryService) DeliverWebhook(ctx context.Context, jobID uuid.UUID) e
 			Str("job_id", job.ID.String()).
 			Str("url", *job.WebhookURL).
 			Msg("Webhook delivered successfully on first attempt")
+		s.recordDeliveryEvent(ctx, delivery, nil)
 
 		return nil
 	}
@@ -214,6 +318,7 @@ func (s *DeliveryService) DeliverWebhook(ctx context.Context, jobID uuid.UUID) e
 			Str("url", *job.WebhookURL).
 			Int("status_code", deliveryErr.StatusCode).
 			Msg("Webhook delivery failed with permanent error - not retrying")
+		s.recordDeliveryEvent(ctx, delivery, err)
 
 		// Return nil to not block consumer - error is logged and recorded
 		return nil
@@ -236,10 +341,13 @@ func (s *DeliveryService) DeliverWebhook(ctx context.Context, jobID uuid.UUID) e
 	return nil
 }
 
-// RetryWorker handles background retry of failed webhook deliveries
+// RetryWorker handles background retry of failed webhook deliveries. Each tick dispatches the due
+// deliveries to a bounded pool (WEBHOOK_RETRY_WORKERS, at most WEBHOOK_RETRY_PER_HOST per destination
+// host) without waiting for them, so a slow endpoint only delays its own retries.
 type RetryWorker struct {
 	service  *DeliveryService
 	config   *config.Config
+	pool     *retryPool
 	stopChan chan struct{}
 	ticker   *time.Ticker
 	stopOnce sync.Once
@@ -250,6 +358,7 @@ func NewRetryWorker(service *DeliveryService, cfg *config.Config) *RetryWorker {
 	return &RetryWorker{
 		service:  service,
 		config:   cfg,
+		pool:     newRetryPool(cfg.WebhookRetryWorkers, cfg.WebhookRetryPerHost),
 		stopChan: make(chan struct{}),
 	}
 }
@@ -277,7 +386,7 @@ func (w *RetryWorker) Start(ctx context.Context) {
 	}()
 }
 
-// Stop stops the retry worker. Safe to call multiple times.
+// Stop stops the retry worker and waits for the retries in flight. Safe to call multiple times.
 func (w *RetryWorker) Stop() {
 	w.stopOnce.Do(func() {
 		if w.ticker != nil {
@@ -285,12 +394,18 @@ func (w *RetryWorker) Stop() {
 		}
 		close(w.stopChan)
 	})
+	w.pool.wait()
 }
 
-// processPendingDeliveries processes pending webhook deliveries
+// processPendingDeliveries dispatches the due pending deliveries to the pool. Deliveries already in
+// flight are skipped, and those whose host is at its cap wait for a later tick.
 func (w *RetryWorker) processPendingDeliveries(ctx context.Context) {
-	// Get pending deliveries
-	deliveries, err := w.service.deliveryRepo.GetPendingDeliveries(ctx, 100)
+	if w.pool.full() {
+		return
+	}
+	// Get pending deliveries; a host gets at most a tenth of the batch so ten hosts with backlogs still fit
+	batch := w.config.WebhookRetryBatch
+	deliveries, err := w.service.deliveryRepo.GetPendingDeliveries(ctx, batch, max(batch/10, w.pool.perHost))
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get pending deliveries")
 		return
@@ -300,71 +415,89 @@ func (w *RetryWorker) processPendingDeliveries(ctx context.Context) {
 		return
 	}
 
-	log.Info().Int("count", len(deliveries)).Msg("Processing pending webhook deliveries")
-
+	dispatched, hostBusy := 0, 0
 	for _, delivery := range deliveries {
+		if w.pool.full() {
+			break
+		}
+		if w.pool.busy(delivery.ID) {
+			continue
+		}
 		// Check if it's time to retry based on exponential backoff; may mark delivery as failed if max retries exceeded
 		if !w.shouldRetryOrMarkFailed(ctx, delivery) {
 			continue
 		}
-
-		// Get job details
-		job, err := w.service.jobRepo.GetByID(ctx, delivery.JobID)
-		if err != nil {
-			// Record this as a failed attempt so we don't retry forever (e.g. job deleted)
-			delivery.Attempts++
-			now := time.Now()
-			delivery.LastAttemptAt = &now
-			errMsg := fmt.Sprintf("job not found: %v", err)
-			delivery.LastError = &errMsg
-
-			if delivery.Attempts >= w.config.WebhookMaxRetries {
-				delivery.Status = "failed"
-				log.Error().
-					Err(err).
-					Str("delivery_id", delivery.ID.String()).
-					Str("job_id", delivery.JobID.String()).
-					Int("attempts", delivery.Attempts).
-					Msg("Failed to get job for delivery - marking as failed after max retries")
-			} else {
-				log.Warn().
-					Err(err).
-					Str("delivery_id", delivery.ID.String()).
-					Str("job_id", delivery.JobID.String()).
-					Int("attempts", delivery.Attempts).
-					Int("max_retries", w.config.WebhookMaxRetries).
-					Msg("Failed to get job for delivery - will retry")
-			}
-
-			if updateErr := w.service.deliveryRepo.Update(ctx, delivery); updateErr != nil {
-				log.Error().Err(updateErr).Msg("Failed to update delivery record after job lookup error")
-			}
+		if !w.pool.tryAcquire(delivery.ID, deliveryHost(delivery.URL)) {
+			hostBusy++
 			continue
 		}
+		dispatched++
+		go func(delivery *models.WebhookDelivery) {
+			defer w.pool.release(delivery.ID)
+			w.processDelivery(ctx, delivery)
+		}(delivery)
+	}
 
-		// Build payload
-		finishedAt := time.Now()
-		if job.FinishedAt != nil {
-			finishedAt = *job.FinishedAt
-		}
+	if dispatched > 0 || hostBusy > 0 {
+		log.Info().
+			Int("count", len(deliveries)).
+			Int("dispatched", dispatched).
+			Int("host_busy", hostBusy).
+			Int("in_flight", w.pool.running()).
+			Msg("Processing pending webhook deliveries")
+	}
+}
 
-		payload := WebhookPayload{
-			JobID:        job.ID,
-			Status:       job.Status,
-			FinishedAt:   finishedAt,
-			OutputMarkup: job.OutputMarkup,
+// processDelivery retries one due delivery.
+func (w *RetryWorker) processDelivery(ctx context.Context, delivery *models.WebhookDelivery) {
+	// Get job details
+	job, err := w.service.jobRepo.GetByID(ctx, delivery.JobID)
+	if err != nil {
+		// Record this as a failed attempt so we don't retry forever (e.g. job deleted)
+		delivery.Attempts++
+		now := time.Now()
+		delivery.LastAttemptAt = &now
+		errMsg := fmt.Sprintf("job not found: %v", err)
+		delivery.LastError = &errMsg
+
+		if delivery.Attempts >= w.config.WebhookMaxRetries {
+			delivery.Status = "failed"
+			log.Error().
+				Err(err).
+				Str("delivery_id", delivery.ID.String()).
+				Str("job_id", delivery.JobID.String()).
+				Int("attempts", delivery.Attempts).
+				Msg("Failed to get job for delivery - marking as failed after max retries")
+		} else {
+			log.Warn().
+				Err(err).
+				Str("delivery_id", delivery.ID.String()).
+				Str("job_id", delivery.JobID.String()).
+				Int("attempts", delivery.Attempts).
+				Int("max_retries", w.config.WebhookMaxRetries).
+				Msg("Failed to get job for delivery - will retry")
 		}
 
-		if job.ErrorCode != nil && job.ErrorMessage != nil {
-			payload.Error = &ErrorInfo{
-				Code:    *job.ErrorCode,
-				Message: *job.ErrorMessage,
-			}
+		if updateErr := w.service.deliveryRepo.Update(ctx, delivery); updateErr != nil {
+			log.Error().Err(updateErr).Msg("Failed to update delivery record after job lookup error")
 		}
+		return
+	}
 
-		// Attempt delivery
-		w.retryDelivery(ctx, job, delivery, payload)
+	// Resend the body of the first attempt; deliveries without one get a fresh payload
+	body, err := w.service.deliveryRepo.FirstPayload(ctx, delivery.ID)
+	if err != nil {
+		log.Warn().Err(err).Str("delivery_id", delivery.ID.String()).Msg("Failed to load stored webhook payload; rebuilding it")
 	}
+	if body == nil {
+		if body, err = w.service.encodePayload(ctx, job); err != nil {
+			log.Error().Err(err).Str("delivery_id", delivery.ID.String()).Msg("Failed to encode webhook payload")
+			return
+		}
+	}
+
+	// Attempt delivery
+	w.retryDelivery(ctx, job, delivery, body)
 }
 
 // shouldRetryOrMarkFailed returns true if the delivery should be retried now (backoff elapsed).
@@ -383,6 +516,11 @@ func (w *RetryWorker) shouldRetryOrMarkFailed(ctx context.Context, delivery *mod
 			Str("job_id", delivery.JobID.String()).
 			Int("attempts", delivery.Attempts).
 			Msg("Webhook delivery failed permanently after max retries")
+		var lastErr error
+		if delivery.LastError != nil {
+			lastErr = errors.New(*delivery.LastError)
+		}
+		w.service.recordDeliveryEvent(ctx, delivery, lastErr)
 
 		return false
 	}
@@ -407,14 +545,18 @@ func (w *RetryWorker) shouldRetryOrMarkFailed(ctx context.Context, delivery *mod
 }
 
 // retryDelivery attempts to redeliver a webhook
-func (w *RetryWorker) retryDelivery(ctx context.Context, job *models.Job, delivery *models.WebhookDelivery, payload WebhookPayload) {
+func (w *RetryWorker) retryDelivery(ctx context.Context, job *models.Job, delivery *models.WebhookDelivery, body []byte) {
 	// Update attempt count
 	delivery.Attempts++
 	now := time.Now()
 	delivery.LastAttemptAt = &now
 
 	// Attempt delivery
-	err := w.service.sendWebhook(ctx, delivery.URL, payload, job.WebhookSecret)
+	status, err := 0, w.service.CheckDestination(ctx, job.UserID, delivery.URL)
+	if err == nil {
+		status, err = w.service.PostBody(ctx, delivery.URL, body, job.WebhookSecret)
+	}
+	w.service.recordAttempt(ctx, delivery, body, status, err)
 
 	if err == nil {
 		// Success
@@ -428,6 +570,7 @@ func (w *RetryWorker) retryDelivery(ctx context.Context, job *models.Job, delive
 			Str("url", delivery.URL).
 			Int("attempts", delivery.Attempts).
 			Msg("Webhook delivered successfully after retry")
+		w.service.recordDeliveryEvent(ctx, delivery, nil)
 
 		return
 	}
@@ -455,6 +598,7 @@ func (w *RetryWorker) retryDelivery(ctx context.Context, job *models.Job, delive
 			Str("url", delivery.URL).
 			Int("status_code", deliveryErr.StatusCode).
 			Msg("Webhook delivery failed with permanent error - not retrying")
+		w.service.recordDeliveryEvent(ctx, delivery, err)
 	}
 
 	// Update delivery record
@@ -463,25 +607,100 @@ func (w *RetryWorker) retryDelivery(ctx context.Context, job *models.Job, delive
 	}
 }
 
-// sendWebhook sends the webhook HTTP request
-func (s *DeliveryService) sendWebhook(ctx context.Context, url string, payload WebhookPayload, secret *string) error {
+// recordAttempt stores an attempt of delivery (already counted in delivery.Attempts) with its body and
+// outcome. Failures are logged only.
+func (s *DeliveryService) recordAttempt(ctx context.Context, delivery *models.WebhookDelivery, body []byte, status int, postErr error) {
+	attempt := &models.WebhookDeliveryAttempt{
+		DeliveryID: delivery.ID,
+		Redelivery: delivery.Redeliveries,
+		Attempt:    delivery.Attempts,
+		Payload:    body,
+	}
+	if postErr != nil {
+		msg := postErr.Error()
+		attempt.Error = &msg
+	}
+	if status != 0 {
+		attempt.StatusCode = &status
+	}
+	if err := s.deliveryRepo.CreateAttempt(ctx, attempt); err != nil {
+		log.Warn().Err(err).Str("delivery_id", delivery.ID.String()).Msg("Failed to record webhook delivery attempt")
+	}
+}
+
+// recordDeliveryEvent appends webhook_delivered (deliveryErr nil) or webhook_failed to the job's event log.
+// Only final outcomes are recorded; transient failures that will be retried are not.
+func (s *DeliveryService) recordDeliveryEvent(ctx context.Context, delivery *models.WebhookDelivery, deliveryErr error) {
+	if s.eventRepo == nil {
+		return
+	}
+	ev := &models.JobEvent{
+		ID:        uuid.New(),
+		JobID:     delivery.JobID,
+		Type:      models.JobEventWebhookDelivered,
+		Message:   fmt.Sprintf("Webhook delivered after %d attempt(s)", delivery.Attempts),
+		Data:      map[string]any{"attempts": delivery.Attempts, "delivery_id": delivery.ID.String()},
+		CreatedAt: time.Now(),
+	}
+	if deliveryErr != nil {
+		ev.Type = models.JobEventWebhookFailed
+		ev.Message = fmt.Sprintf("Webhook failed after %d attempt(s): %v", delivery.Attempts, deliveryErr)
+		ev.Data["error"] = deliveryErr.Error()
+		var de *DeliveryError
+		if errors.As(deliveryErr, &de) {
+			ev.Data["status_code"] = de.StatusCode
+		}
+	}
+	if err := s.eventRepo.Create(ctx, ev); err != nil {
+		log.Warn().Err(err).Str("job_id", delivery.JobID.String()).Msg("Failed to record webhook event")
+	}
+}
+
+// CheckDestination checks url against the egress policy and the egress allow list of the user owning
+// the job or sink. Refused destinations are returned as a non-retryable *DeliveryError; the allow list
+// is read on every call so that changes apply to pending retries.
+func (s *DeliveryService) CheckDestination(ctx context.Context, userID uuid.UUID, url string) error {
+	allowlist, err := s.userRepo.GetEgressAllowlist(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get egress allow list: %w", err)
+	}
+	if err := s.egress.CheckURL(url, allowlist); err != nil {
+		return &DeliveryError{Message: err.Error(), Denied: true}
+	}
+	return nil
+}
+
+// Post sends payload as a webhook HTTP request to url, signed with secret when set. Non-2xx responses
+// are returned as *DeliveryError.
+func (s *DeliveryService) Post(ctx context.Context, url string, payload any, secret *string) error {
 	// Marshal payload
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("failed to marshal payload: %w", err)
 	}
+	_, err = s.PostBody(ctx, url, body, secret)
+	return err
+}
 
+// PostBody sends an already encoded payload, such as the stored body of an earlier attempt, and returns
+// the response status (0 when there was no response).
+func (s *DeliveryService) PostBody(ctx context.Context, url string, body []byte, secret *string) (int, error) {
 	// Create request
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return 0, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	// Bodies are JSON objects or compact JWEs (encrypted payloads)
+	if len(body) > 0 && body[0] != '{' {
+		req.Header.Set("Content-Type", webhookjwe.ContentType)
+	} else {
+		req.Header.Set("Content-Type", "application/json")
+	}
 	req.Header.Set("User-Agent", "Stories-Webhook/1.0")
 	req.Header.Set("X-GS-Timestamp", fmt.Sprintf("%d", time.Now().Unix()))
 
-	// Add signature if secret is provided
+	// Add signature if secret is provided; it covers the body as sent (the JWE when encrypted)
 	if secret != nil && *secret != "" {
 		signature := generateSignature(body, *secret)
 		req.Header.Set("X-GS-Signature", signature)
@@ -489,9 +708,13 @@ func (s *DeliveryService) sendWebhook(ctx context.Context, url string, payload W
 
 	// Send request
 	resp, err := s.httpClient.Do(req)
+	if errors.Is(err, egress.ErrDenied) {
+		// Resolved to a refused address - permanent
+		return 0, &DeliveryError{Message: fmt.Sprintf("failed to send request: %v", err), Denied: true}
+	}
 	if err != nil {
 		// Network error - retryable
-		return fmt.Errorf("failed to send request: %w", err)
+		return 0, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -504,14 +727,14 @@ func (s *DeliveryService) sendWebhook(ctx context.Context, url string, payload W
 
 	// Check response status
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return &DeliveryError{
+		return resp.StatusCode, &DeliveryError{
 			StatusCode: resp.StatusCode,
 			Message:    fmt.Sprintf("webhook returned status %d", resp.StatusCode),
 			Body:       string(respBody),
 		}
 	}
 
-	return nil
+	return resp.StatusCode, nil
 }
 
 // generateSignature generates HMAC-SHA256 signature for the payload
@@ -0,0 +1,214 @@
+package webhook
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/snappy-loop/stories/internal/config"
+	"github.com/snappy-loop/stories/internal/database"
+	"github.com/snappy-loop/stories/internal/egress"
+	"github.com/snappy-loop/stories/internal/models"
+	"github.com/snappy-loop/stories/internal/testutil"
+	"github.com/snappy-loop/stories/pkg/webhookjwe"
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	testutil.Cleanup()
+	os.Exit(code)
+}
+
+// TestPostBody_Egress asserts requests resolving to a refused address fail permanently.
+func TestPostBody_Egress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	s := &DeliveryService{httpClient: egress.NewPolicy(nil, nil, false).Client(time.Second)}
+	_, err := s.PostBody(context.Background(), srv.URL, []byte(`{}`), nil)
+	var de *DeliveryError
+	if !errors.As(err, &de) || !de.Denied || de.IsRetryable() {
+		t.Errorf("err = %v, want a non-retryable denied *DeliveryError", err)
+	}
+
+	s.httpClient = egress.NewPolicy(nil, nil, true).Client(time.Second)
+	if status, err := s.PostBody(context.Background(), srv.URL, []byte(`{}`), nil); err != nil || status != http.StatusOK {
+		t.Errorf("with private addresses allowed = %d, %v", status, err)
+	}
+}
+
+// fakeCounter returns a fixed count for every job.
+type fakeCounter int
+
+func (c fakeCounter) CountByJob(ctx context.Context, jobID uuid.UUID) (int, error) {
+	return int(c), nil
+}
+
+// TestBuildPayload asserts the fields set by each payload mode, the summary default and result_url.
+func TestBuildPayload(t *testing.T) {
+	markup := "[[SEGMENT 0]] Once upon a time."
+	duration := int64(1500)
+	mode := func(m string) *string { return &m }
+	jobID := uuid.New()
+
+	tests := []struct {
+		name          string
+		mode          *string
+		publicAPIURL  string
+		wantMode      string
+		wantCounts    bool
+		wantMarkup    bool
+		wantResultURL string
+	}{
+		{"status", mode(models.WebhookPayloadStatus), "", models.WebhookPayloadStatus, false, false, ""},
+		{"summary", mode(models.WebhookPayloadSummary), "", models.WebhookPayloadSummary, true, false, ""},
+		{"full", mode(models.WebhookPayloadFull), "", models.WebhookPayloadFull, true, true, ""},
+		{"default is summary", nil, "", models.WebhookPayloadSummary, true, false, ""},
+		{"empty is summary", mode(""), "", models.WebhookPayloadSummary, true, false, ""},
+		{"result url", nil, "https://api.example.com", models.WebhookPayloadSummary, true, false,
+			"https://api.example.com/v1/jobs/" + jobID.String()},
+		{"result url trailing slash", mode(models.WebhookPayloadStatus), "https://api.example.com/",
+			models.WebhookPayloadStatus, false, false, "https://api.example.com/v1/jobs/" + jobID.String()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &DeliveryService{
+				config:      &config.Config{PublicAPIURL: tt.publicAPIURL},
+				segmentRepo: fakeCounter(3),
+				assetRepo:   fakeCounter(5),
+			}
+			job := &models.Job{ID: jobID, Status: models.JobStatusSucceeded, WebhookPayload: tt.mode,
+				OutputMarkup: &markup, DurationMs: &duration}
+			payload := s.BuildPayload(context.Background(), job)
+
+			if payload.Payload != tt.wantMode {
+				t.Errorf("payload mode = %q, want %q", payload.Payload, tt.wantMode)
+			}
+			if payload.ResultURL != tt.wantResultURL {
+				t.Errorf("result_url = %q, want %q", payload.ResultURL, tt.wantResultURL)
+			}
+			if tt.wantCounts {
+				if payload.SegmentsTotal == nil || *payload.SegmentsTotal != 3 || payload.AssetsTotal == nil || *payload.AssetsTotal != 5 {
+					t.Errorf("counts = %v, %v, want 3 segments and 5 assets", payload.SegmentsTotal, payload.AssetsTotal)
+				}
+				if payload.DurationMs == nil || *payload.DurationMs != duration {
+					t.Errorf("duration_ms = %v, want %d", payload.DurationMs, duration)
+				}
+			} else if payload.SegmentsTotal != nil || payload.AssetsTotal != nil || payload.DurationMs != nil {
+				t.Errorf("status payload has counts or duration: %+v", payload)
+			}
+			if got := payload.OutputMarkup != nil; got != tt.wantMarkup {
+				t.Errorf("output_markup set = %v, want %v", got, tt.wantMarkup)
+			}
+		})
+	}
+}
+
+// TestEncodePayload_Encrypted asserts jobs with an encryption key get a JWE body, sent as application/jose
+// and signed as sent.
+func TestEncodePayload_Encrypted(t *testing.T) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	publicPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	mode := models.WebhookPayloadStatus
+	job := &models.Job{ID: uuid.New(), Status: models.JobStatusSucceeded, WebhookPayload: &mode, WebhookEncryptionKey: &publicPEM}
+
+	s := &DeliveryService{config: &config.Config{}, httpClient: egress.NewPolicy(nil, nil, true).Client(time.Second)}
+	body, err := s.encodePayload(context.Background(), job)
+	if err != nil {
+		t.Fatalf("encodePayload: %v", err)
+	}
+	plaintext, err := webhookjwe.Decrypt(body, private)
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	var payload WebhookPayload
+	if err := json.Unmarshal(plaintext, &payload); err != nil || payload.JobID != job.ID {
+		t.Errorf("decrypted payload = %s, %v", plaintext, err)
+	}
+
+	var contentType, signature string
+	var received []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType, signature = r.Header.Get("Content-Type"), r.Header.Get("X-GS-Signature")
+		received, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+	secret := "s3cret"
+	if _, err := s.PostBody(context.Background(), srv.URL, body, &secret); err != nil {
+		t.Fatalf("PostBody: %v", err)
+	}
+	if contentType != webhookjwe.ContentType || string(received) != string(body) || signature != generateSignature(body, secret) {
+		t.Errorf("request = %s %q signed %s, want the JWE as %s", contentType, received, signature, webhookjwe.ContentType)
+	}
+}
+
+// TestDeliverWebhook_AfterRetry asserts a failed job retried through the API delivers the outcome of the
+// retried run, not only the failure.
+func TestDeliverWebhook_AfterRetry(t *testing.T) {
+	db := testutil.Postgres(t)
+	ctx := context.Background()
+	var received []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+		received = append(received, payload.Status)
+	}))
+	defer srv.Close()
+
+	user := testutil.CreateUser(t, db)
+	_, key := testutil.CreateAPIKey(t, db, user.ID)
+	job := testutil.NewJob(key)
+	job.WebhookURL = &srv.URL
+	testutil.InsertJob(t, db, job)
+	jobRepo := database.NewJobRepository(db)
+	s := NewDeliveryService(db, &config.Config{EgressAllowPrivate: true})
+
+	finish := func(status string, errorCode, errorMessage *string) {
+		t.Helper()
+		if err := jobRepo.UpdateStatus(ctx, job.ID, models.JobStatusRunning, nil, nil); err != nil {
+			t.Fatalf("start job: %v", err)
+		}
+		if err := jobRepo.UpdateStatus(ctx, job.ID, status, errorCode, errorMessage); err != nil {
+			t.Fatalf("finish job as %s: %v", status, err)
+		}
+		if err := s.DeliverWebhook(ctx, job.ID); err != nil {
+			t.Fatalf("DeliverWebhook after %s: %v", status, err)
+		}
+	}
+
+	code, message := "SEGMENT_FAILED", "segment 1 failed"
+	finish(models.JobStatusFailed, &code, &message)
+	if err := jobRepo.ResetForRetry(ctx, job.ID); err != nil {
+		t.Fatalf("ResetForRetry: %v", err)
+	}
+	finish(models.JobStatusSucceeded, nil, nil)
+
+	if len(received) != 2 || received[0] != models.JobStatusFailed || received[1] != models.JobStatusSucceeded {
+		t.Errorf("delivered statuses = %v, want [failed succeeded]", received)
+	}
+	deliveries, err := database.NewWebhookDeliveryRepository(db).GetByJobID(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("GetByJobID: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != "sent" {
+		t.Errorf("deliveries = %+v, want the retried run's sent delivery only", deliveries)
+	}
+}
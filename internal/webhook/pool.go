@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// retryPool tracks the retries in flight: at most size at once and perHost per destination host, each
+// delivery once. A slow endpoint thus holds at most perHost of the slots while others keep being retried.
+type retryPool struct {
+	size    int
+	perHost int
+
+	mu       sync.Mutex
+	inFlight map[uuid.UUID]string // delivery -> host
+	hosts    map[string]int
+	wg       sync.WaitGroup
+}
+
+func newRetryPool(size, perHost int) *retryPool {
+	return &retryPool{
+		size:     max(size, 1),
+		perHost:  max(perHost, 1),
+		inFlight: make(map[uuid.UUID]string),
+		hosts:    make(map[string]int),
+	}
+}
+
+// busy reports whether the delivery is already being retried.
+func (p *retryPool) busy(id uuid.UUID) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.inFlight[id]
+	return ok
+}
+
+// full reports whether every slot is taken.
+func (p *retryPool) full() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.inFlight) >= p.size
+}
+
+// tryAcquire takes a slot for the delivery to host; false when the pool or the host is at its cap, or
+// the delivery is in flight.
+func (p *retryPool) tryAcquire(id uuid.UUID, host string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.inFlight[id]; ok || len(p.inFlight) >= p.size || p.hosts[host] >= p.perHost {
+		return false
+	}
+	p.inFlight[id] = host
+	p.hosts[host]++
+	p.wg.Add(1)
+	return true
+}
+
+// release frees the delivery's slot.
+func (p *retryPool) release(id uuid.UUID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	host, ok := p.inFlight[id]
+	if !ok {
+		return
+	}
+	delete(p.inFlight, id)
+	if p.hosts[host]--; p.hosts[host] <= 0 {
+		delete(p.hosts, host)
+	}
+	p.wg.Done()
+}
+
+// running returns the number of retries in flight.
+func (p *retryPool) running() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.inFlight)
+}
+
+// wait blocks until no retry is in flight.
+func (p *retryPool) wait() {
+	p.wg.Wait()
+}
+
+// deliveryHost is the host a webhook URL is sent to (the whole URL when it does not parse).
+func deliveryHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return strings.ToLower(u.Host)
+}
@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestRetryPool(t *testing.T) {
+	p := newRetryPool(3, 2)
+	slow := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	other := uuid.New()
+
+	if !p.tryAcquire(slow[0], "slow.example.com") || !p.tryAcquire(slow[1], "slow.example.com") {
+		t.Fatal("first two deliveries to a host not admitted")
+	}
+	if p.tryAcquire(slow[2], "slow.example.com") {
+		t.Error("third delivery to a host admitted over the per-host cap")
+	}
+	if p.tryAcquire(slow[0], "slow.example.com") {
+		t.Error("delivery in flight admitted twice")
+	}
+	if !p.tryAcquire(other, "fast.example.com") {
+		t.Error("delivery to another host not admitted")
+	}
+	if !p.full() || p.tryAcquire(uuid.New(), "third.example.com") {
+		t.Error("delivery admitted over the pool size")
+	}
+
+	p.release(slow[0])
+	if !p.tryAcquire(slow[2], "slow.example.com") {
+		t.Error("delivery not admitted after its host freed a slot")
+	}
+	for _, id := range append(slow[1:], other) {
+		p.release(id)
+	}
+	p.release(other) // no-op
+	p.wait()
+	if p.running() != 0 || len(p.hosts) != 0 {
+		t.Errorf("after release: running = %d, hosts = %v", p.running(), p.hosts)
+	}
+}
+
+func TestDeliveryHost(t *testing.T) {
+	tests := map[string]string{
+		"https://Hooks.Example.com/a?b=c": "hooks.example.com",
+		"http://localhost:8080/hook":      "localhost:8080",
+		"not a url":                       "not a url",
+	}
+	for raw, want := range tests {
+		if got := deliveryHost(raw); got != want {
+			t.Errorf("deliveryHost(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
@@ -0,0 +1,2 @@
+-- ListJobs pages by (created_at, id) so jobs with identical timestamps are neither skipped nor repeated
+CREATE INDEX IF NOT EXISTS idx_jobs_user_created_id ON jobs(user_id, created_at DESC, id DESC);
@@ -0,0 +1,11 @@
+-- Per-job event log (created, queued, picked up, segmentation done, segment failed, webhook delivered, ...)
+CREATE TABLE job_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    job_id UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    event_type VARCHAR(64) NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    data JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_job_events_job_id ON job_events(job_id, created_at);
@@ -0,0 +1,6 @@
+-- Caller-supplied labels for correlating jobs with integrator documents; echoed in GetJob and webhooks
+ALTER TABLE jobs ADD COLUMN metadata JSONB;
+ALTER TABLE jobs ADD COLUMN tags JSONB;
+
+CREATE INDEX idx_jobs_metadata ON jobs USING GIN (metadata jsonb_path_ops);
+CREATE INDEX idx_jobs_tags ON jobs USING GIN (tags jsonb_path_ops);
@@ -0,0 +1,2 @@
+-- Per-webhook payload mode: status, summary or full (NULL = summary)
+ALTER TABLE jobs ADD COLUMN webhook_payload TEXT;
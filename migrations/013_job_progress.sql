@@ -0,0 +1,5 @@
+-- Job progress roll-up maintained by the worker (current step and segment counters)
+ALTER TABLE jobs ADD COLUMN progress_step TEXT;
+ALTER TABLE jobs ADD COLUMN segments_total INT NOT NULL DEFAULT 0;
+ALTER TABLE jobs ADD COLUMN segments_completed INT NOT NULL DEFAULT 0;
+ALTER TABLE jobs ADD COLUMN segments_failed INT NOT NULL DEFAULT 0;
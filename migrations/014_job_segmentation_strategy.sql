@@ -0,0 +1,2 @@
+-- How the worker segments a job: llm (Gemini with heuristic fallback) or heuristic (no LLM call)
+ALTER TABLE jobs ADD COLUMN segmentation_strategy TEXT NOT NULL DEFAULT 'llm';
@@ -0,0 +1,6 @@
+-- Boundary cache keys now include a prompt version and an options fingerprint
+-- ("v<version>:<options>:<text hash>"). Entries keyed by the bare text hash were computed without
+-- regard to segments_count or input_type and can never be looked up again.
+DELETE FROM segment_boundaries_cache WHERE text_hash NOT LIKE 'v%:%';
+
+COMMENT ON COLUMN segment_boundaries_cache.text_hash IS 'cache key: v<prompt version>:<options fingerprint>:<sha256 of lowercased text>';
@@ -0,0 +1,8 @@
+-- Job deduplication: content_hash fingerprints the input and processing options; a job created with
+-- dedupe=true that matches an earlier job links to it via duplicate_of and reuses its results.
+ALTER TABLE jobs ADD COLUMN content_hash TEXT;
+ALTER TABLE jobs ADD COLUMN duplicate_of UUID REFERENCES jobs(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_jobs_user_content_hash ON jobs(user_id, content_hash, created_at DESC)
+    WHERE content_hash IS NOT NULL AND duplicate_of IS NULL;
+CREATE INDEX idx_jobs_duplicate_of ON jobs(duplicate_of) WHERE duplicate_of IS NOT NULL;
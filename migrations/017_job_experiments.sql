@@ -0,0 +1,5 @@
+-- LLM A/B experiments: the variant (control or treatment) of each experiment a job was assigned to,
+-- as {"<experiment name>": "<variant>"}. NULL when no experiments were configured.
+ALTER TABLE jobs ADD COLUMN experiments JSONB;
+
+CREATE INDEX idx_jobs_experiments ON jobs USING GIN (experiments) WHERE experiments IS NOT NULL;
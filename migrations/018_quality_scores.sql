@@ -0,0 +1,9 @@
+-- Quality scoring: jobs created with quality_check=true run an LLM-as-judge evaluator on every segment.
+-- Scores are 1-5 (narration faithfulness to the segment text, image prompt relevance); quality_notes holds
+-- the judge's reasons and quality_regenerations how many times outputs were regenerated below the threshold.
+ALTER TABLE jobs ADD COLUMN quality_check BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE segments ADD COLUMN narration_score SMALLINT CHECK (narration_score BETWEEN 1 AND 5);
+ALTER TABLE segments ADD COLUMN image_prompt_score SMALLINT CHECK (image_prompt_score BETWEEN 1 AND 5);
+ALTER TABLE segments ADD COLUMN quality_notes TEXT;
+ALTER TABLE segments ADD COLUMN quality_regenerations INT NOT NULL DEFAULT 0;
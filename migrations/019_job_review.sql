@@ -0,0 +1,9 @@
+-- Human review: jobs created with require_review=true stop in awaiting_review after the pipeline instead of
+-- succeeded. Reviewers approve (-> succeeded, webhooks fire) or request regeneration of specific segments;
+-- the pending request is kept in review_regeneration ({"segments": [idx...], "comment": "..."}) until the
+-- worker has regenerated them. review_requested_at lets the stuck job sweeper time the regeneration run.
+ALTER TYPE job_status ADD VALUE IF NOT EXISTS 'awaiting_review' AFTER 'running';
+
+ALTER TABLE jobs ADD COLUMN require_review BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE jobs ADD COLUMN review_regeneration JSONB;
+ALTER TABLE jobs ADD COLUMN review_requested_at TIMESTAMPTZ;
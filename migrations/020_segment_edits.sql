@@ -0,0 +1,5 @@
+-- Segment editing: narration_text keeps the script the audio was generated from so users can fix a single
+-- word and regenerate only that segment's audio (PATCH /v1/jobs/{id}/segments/{idx}); edited_at marks
+-- segments changed after generation.
+ALTER TABLE segments ADD COLUMN narration_text TEXT;
+ALTER TABLE segments ADD COLUMN edited_at TIMESTAMPTZ;
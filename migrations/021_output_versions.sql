@@ -0,0 +1,18 @@
+-- Versioned outputs: regenerated, edited and replaced assets are kept (superseded_at set) instead of being
+-- deleted or overwritten in S3; version counts per segment and kind. job_output_versions snapshots the
+-- output markup and segment texts each time a job's output changes, so earlier versions can be restored.
+ALTER TABLE assets ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE assets ADD COLUMN superseded_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_assets_segment_kind ON assets(segment_id, kind) WHERE segment_id IS NOT NULL;
+
+CREATE TABLE job_output_versions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    job_id UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    reason VARCHAR(64) NOT NULL,
+    output_markup TEXT,
+    segments JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (job_id, version)
+);
@@ -0,0 +1,4 @@
+-- Page-by-page PDF extraction: job_files.meta records the page count and where each page's text sits in
+-- extracted_text; segments.source_pages lists the uploaded file pages each segment was taken from.
+ALTER TABLE job_files ADD COLUMN meta JSONB;
+ALTER TABLE segments ADD COLUMN source_pages JSONB;
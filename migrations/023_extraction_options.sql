@@ -0,0 +1,4 @@
+-- Per-file extraction options (language hint, handwriting, table preservation): defaults set at upload
+-- on files, and the options each job extracts the file with on job_files.
+ALTER TABLE files ADD COLUMN extraction_options JSONB;
+ALTER TABLE job_files ADD COLUMN extraction_options JSONB;
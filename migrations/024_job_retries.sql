@@ -0,0 +1,3 @@
+-- When a running job's retry is due after a transient failure (Kafka retry topics); the stuck job
+-- sweeper does not consider the job stuck before then.
+ALTER TABLE jobs ADD COLUMN retry_at TIMESTAMPTZ;
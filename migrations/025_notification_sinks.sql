@@ -0,0 +1,30 @@
+-- Per-user notification sinks: the dispatcher routes each job event (job_completed, job_failed, ...) of a
+-- user's jobs to their sinks besides the job's own webhook. events lists the event types a sink receives;
+-- empty means all. notification_deliveries records one delivery per sink, job and event (at most once).
+CREATE TABLE notification_sinks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(16) NOT NULL, -- webhook, email, slack, sns
+    target TEXT NOT NULL,      -- URL, email address or SNS topic ARN
+    secret TEXT,               -- HMAC secret of webhook sinks
+    events TEXT[] NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_notification_sinks_user_id ON notification_sinks(user_id);
+
+CREATE TABLE notification_deliveries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    sink_id UUID NOT NULL REFERENCES notification_sinks(id) ON DELETE CASCADE,
+    job_id UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    event VARCHAR(64) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending', -- pending, sent, failed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_attempt_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (sink_id, job_id, event)
+);
+
+CREATE INDEX idx_notification_deliveries_status ON notification_deliveries(status, created_at);
@@ -0,0 +1,8 @@
+-- Email notifications to the job creator on completion or failure: opt-in per user
+-- (users.email_notifications) or per job (jobs.notify_email, overriding the user's setting when set).
+-- Their deliveries have no sink and are unique per job and event.
+ALTER TABLE users ADD COLUMN email_notifications BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE jobs ADD COLUMN notify_email BOOLEAN;
+
+ALTER TABLE notification_deliveries ALTER COLUMN sink_id DROP NOT NULL;
+CREATE UNIQUE INDEX idx_notification_deliveries_creator ON notification_deliveries(job_id, event) WHERE sink_id IS NULL;
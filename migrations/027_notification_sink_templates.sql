@@ -0,0 +1,3 @@
+-- Slack and Teams sinks render their message text from an optional per-sink Go text/template (NULL uses
+-- the built-in template); type can now also be teams (Microsoft Teams incoming webhook).
+ALTER TABLE notification_sinks ADD COLUMN template TEXT;
@@ -0,0 +1,17 @@
+-- S3 drop-folder ingestion: one row per object (bucket, key, etag) picked up under INGEST_PREFIX, so the
+-- poller and the S3 event webhook (and several API replicas) ingest each upload once. A re-upload has a
+-- new etag and is ingested again.
+CREATE TABLE ingested_objects (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    s3_bucket VARCHAR(255) NOT NULL,
+    s3_key TEXT NOT NULL,
+    etag VARCHAR(255) NOT NULL,
+    api_key_id UUID NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    file_id UUID REFERENCES files(id) ON DELETE SET NULL,
+    job_id UUID REFERENCES jobs(id) ON DELETE SET NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'processing', -- processing, ingested, failed
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (s3_bucket, s3_key, etag)
+);
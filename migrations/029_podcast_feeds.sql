@@ -0,0 +1,2 @@
+-- Podcast jobs get an RSS feed asset (one episode per segment audio), regenerated when segments change.
+ALTER TYPE asset_kind ADD VALUE IF NOT EXISTS 'rss';
@@ -0,0 +1,5 @@
+-- Pronunciation lexicons applied to narration scripts before TTS: the user's (users.lexicon, managed with
+-- /v1/lexicon) and the job's own entries (jobs.lexicon), which override the user's for the same term.
+-- Both are JSON arrays of {"term", "replacement" | "ipa", "case_sensitive"}.
+ALTER TABLE users ADD COLUMN lexicon JSONB;
+ALTER TABLE jobs ADD COLUMN lexicon JSONB;
@@ -0,0 +1,17 @@
+-- Custom voices: a voice registered with a TTS provider (provider_voice_id is the provider's name or ID
+-- of it, e.g. a Gemini prebuilt voice) and optional speaking style, selected per job with jobs.voice_id.
+-- reference_url is the voice sample given to providers that clone voices.
+CREATE TABLE voices (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(64) NOT NULL,
+    provider VARCHAR(32) NOT NULL,
+    provider_voice_id TEXT NOT NULL,
+    style TEXT,
+    reference_url TEXT,
+    meta JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (user_id, name)
+);
+
+ALTER TABLE jobs ADD COLUMN voice_id UUID REFERENCES voices(id) ON DELETE SET NULL;
@@ -0,0 +1,16 @@
+-- Compliance disclaimers appended to financial jobs: one text per jurisdiction (e.g. US, EU, or DEFAULT
+-- for jobs without one), versioned so each job records the exact text it got. Saving a disclaimer adds the
+-- next version; retiring a jurisdiction stops applying it but keeps its versions for the jobs that used them.
+CREATE TABLE disclaimers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    jurisdiction VARCHAR(16) NOT NULL,
+    version INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    retired_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (jurisdiction, version)
+);
+
+ALTER TABLE jobs ADD COLUMN jurisdiction VARCHAR(16);
+ALTER TABLE jobs ADD COLUMN disclaimer_jurisdiction VARCHAR(16);
+ALTER TABLE jobs ADD COLUMN disclaimer_version INTEGER;
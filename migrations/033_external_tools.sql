@@ -0,0 +1,5 @@
+-- External MCP tool called by the worker for each segment of a job (jobs.external_tool: JSON object of
+-- server_url, tool, arguments, auth_token, required) and the results stored with the segments
+-- (segments.metadata: JSON object keyed by tool name).
+ALTER TABLE jobs ADD COLUMN external_tool JSONB;
+ALTER TABLE segments ADD COLUMN metadata JSONB;
@@ -0,0 +1,4 @@
+-- Job dependencies: jobs.depends_on is a JSON array of job IDs that must succeed before the job runs.
+-- The worker defers the job while one of them is unfinished and republishes it when they finish.
+ALTER TABLE jobs ADD COLUMN depends_on JSONB;
+CREATE INDEX idx_jobs_depends_on ON jobs USING GIN (depends_on);
@@ -0,0 +1,3 @@
+-- Workspace defaults for new jobs (/v1/settings): users.settings is a JSON object with any of type,
+-- segments_count, audio_type, voice_id and webhook, filled into job requests that omit them.
+ALTER TABLE users ADD COLUMN settings JSONB;
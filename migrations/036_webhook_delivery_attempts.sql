@@ -0,0 +1,17 @@
+-- Every webhook delivery attempt with the request body exactly as sent, so a delivery can be redelivered
+-- (POST /v1/webhook-deliveries/{id}/redeliver) with the same payload. redelivery numbers the round of
+-- attempts: 0 for the original delivery, then one per redeliver request.
+ALTER TABLE webhook_deliveries ADD COLUMN redeliveries INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE webhook_delivery_attempts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    delivery_id UUID NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+    redelivery INTEGER NOT NULL DEFAULT 0,
+    attempt INTEGER NOT NULL,
+    payload TEXT NOT NULL,
+    status_code INTEGER,
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts(delivery_id, created_at);
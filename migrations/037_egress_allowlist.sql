@@ -0,0 +1,3 @@
+-- Per-user egress allow lists (/v1/egress-allowlist): when set, the user's job webhooks and notification
+-- sinks may only target these hosts and their subdomains, on top of the server's EGRESS_* settings.
+ALTER TABLE users ADD COLUMN egress_allowlist TEXT[];
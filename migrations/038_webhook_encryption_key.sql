@@ -0,0 +1,3 @@
+-- Webhook payload encryption: when set, the job's webhook bodies are sent as a JWE encrypted with this
+-- PEM public key (RSA-OAEP-256 or ECDH-ES with A256GCM) instead of plaintext JSON.
+ALTER TABLE jobs ADD COLUMN webhook_encryption_key TEXT;
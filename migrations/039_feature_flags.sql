@@ -0,0 +1,16 @@
+-- Feature flags for the gradual rollout of pipeline steps (package features). A row set through the admin
+-- API replaces the FEATURE_FLAGS rollout of its flag; an override turns a flag on or off for one user
+-- whatever the rollout.
+CREATE TABLE feature_flags (
+    name VARCHAR(64) PRIMARY KEY,
+    rollout_percent SMALLINT NOT NULL CHECK (rollout_percent BETWEEN 0 AND 100),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE feature_flag_overrides (
+    flag_name VARCHAR(64) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (flag_name, user_id)
+);
@@ -0,0 +1,11 @@
+-- Maintenance mode (admin API): while enabled the API refuses new jobs with 503 and workers stop taking
+-- jobs once their in-flight ones finish, so migrations can run on an idle pipeline. A single row.
+CREATE TABLE maintenance (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    reason TEXT,
+    retry_after_seconds INTEGER NOT NULL DEFAULT 300,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+INSERT INTO maintenance (id) VALUES (TRUE);
@@ -0,0 +1,11 @@
+-- Lazy images: jobs created with lazy_images=true store each segment's image prompt instead of generating
+-- the image. The asset row is created right away (meta.pending = true, size 0) so the markup can reference
+-- it; the API generates and uploads the image on its first download and deletes the pending row.
+-- claimed_until leases generation to one API replica at a time.
+ALTER TABLE jobs ADD COLUMN lazy_images BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE pending_images (
+    asset_id UUID PRIMARY KEY REFERENCES assets(id) ON DELETE CASCADE,
+    prompt TEXT NOT NULL,
+    claimed_until TIMESTAMP WITH TIME ZONE
+);
@@ -0,0 +1,4 @@
+-- Job previews: a thumbnail sprite of the first segment images, a narration excerpt and the total audio
+-- duration, stored on the job by the worker when its output is (re)generated so job lists can render
+-- cards without fetching segments or assets. NULL until the job's first output.
+ALTER TABLE jobs ADD COLUMN preview JSONB;
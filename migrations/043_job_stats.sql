@@ -0,0 +1,9 @@
+-- Job statistics (GET /v1/stats, /admin/stats): the quota characters charged when a job was created, so
+-- consumption can be aggregated per user and day. Jobs created before this migration are estimated from
+-- their input text and files (at the default 1000 characters per file); duplicates are charged nothing.
+-- The aggregates scan a window of created_at through idx_jobs_created_at (idx_jobs_user_id per user).
+ALTER TABLE jobs ADD COLUMN chars_charged BIGINT NOT NULL DEFAULT 0;
+
+UPDATE jobs SET chars_charged = OCTET_LENGTH(input_text)
+    + 1000 * (SELECT COUNT(*) FROM job_files WHERE job_files.job_id = jobs.id)
+WHERE duplicate_of IS NULL;
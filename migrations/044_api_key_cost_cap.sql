@@ -0,0 +1,5 @@
+-- Cost guardrails: the estimated LLM cost (token usage at LLM_PRICING) of the jobs of an API key in its
+-- current quota period, and an optional cap on it. NULL cost_cap_usd means no cap. The worker adds each
+-- job's cost when it finishes; the period resets with used_chars_in_period.
+ALTER TABLE api_keys ADD COLUMN cost_cap_usd NUMERIC(12, 2);
+ALTER TABLE api_keys ADD COLUMN used_cost_in_period NUMERIC(14, 6) NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Job cancellation (POST /v1/jobs/{id}/cancel): segments a canceled job never finished are marked canceled,
+-- so the segments of a partially generated job tell which parts exist.
+ALTER TYPE segment_status ADD VALUE IF NOT EXISTS 'canceled';
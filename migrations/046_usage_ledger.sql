@@ -0,0 +1,26 @@
+-- Usage ledger (GET /admin/usage/export): one row per billable event, kept when the job is deleted. The
+-- API records the quota characters charged when a job is created (kind job_created); the worker records
+-- each job run, segment edit or review regeneration (kind job_run) with the tokens Gemini reported, their
+-- estimated cost at LLM_PRICING, and the audio seconds and images generated in the run.
+CREATE TABLE usage_ledger (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    api_key_id UUID,
+    job_id UUID,
+    kind TEXT NOT NULL,
+    chars BIGINT NOT NULL DEFAULT 0,
+    input_tokens BIGINT NOT NULL DEFAULT 0,
+    output_tokens BIGINT NOT NULL DEFAULT 0,
+    audio_seconds NUMERIC(12, 3) NOT NULL DEFAULT 0,
+    images INT NOT NULL DEFAULT 0,
+    cost_usd NUMERIC(14, 6) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_usage_ledger_created_at ON usage_ledger(created_at);
+
+-- Jobs created before the ledger are entered with their characters, so earlier periods can be exported
+INSERT INTO usage_ledger (user_id, api_key_id, job_id, kind, chars, created_at)
+SELECT user_id, api_key_id, id, 'job_created', chars_charged, created_at
+FROM jobs
+WHERE chars_charged > 0;
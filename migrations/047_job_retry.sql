@@ -0,0 +1,3 @@
+-- Retrying failed jobs (POST /v1/jobs/{id}/retry): when the retry was requested. The stuck job sweeper
+-- counts a retried job's idle time from it rather than from its first start.
+ALTER TABLE jobs ADD COLUMN retry_requested_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,15 @@
+-- Job deletion (DELETE /v1/jobs/{id}): the S3 objects of a deleted job's assets are queued here in the
+-- transaction that deletes the job, and removed by the API's storage cleanup loop, which retries failed
+-- deletes with backoff until they succeed.
+CREATE TABLE storage_deletions (
+    id BIGSERIAL PRIMARY KEY,
+    s3_bucket TEXT NOT NULL,
+    s3_key TEXT NOT NULL,
+    job_id UUID,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_storage_deletions_next_attempt_at ON storage_deletions(next_attempt_at);
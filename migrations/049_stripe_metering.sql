@@ -0,0 +1,12 @@
+-- Stripe metered billing (STRIPE_API_KEY): users are linked to their Stripe customer (storiesctl
+-- set-stripe-customer) and the dispatcher reports usage ledger entries as meter events, marking each entry
+-- once reported. The worker and the API record completed jobs in the ledger (kind job_completed).
+ALTER TABLE users ADD COLUMN stripe_customer_id TEXT;
+
+ALTER TABLE usage_ledger ADD COLUMN stripe_reported_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE usage_ledger ADD COLUMN stripe_error TEXT;
+
+-- Usage recorded before the integration is not reported
+UPDATE usage_ledger SET stripe_reported_at = created_at;
+
+CREATE INDEX idx_usage_ledger_stripe_unreported ON usage_ledger(id) WHERE stripe_reported_at IS NULL;
@@ -0,0 +1,4 @@
+-- ListJobs filters (GET /v1/jobs?status=&q=): status filters use the user's jobs by status, and the free-text
+-- query searches input_text with the simple text search configuration (no stemming, any language).
+CREATE INDEX idx_jobs_user_status_created ON jobs(user_id, status, created_at DESC, id DESC);
+CREATE INDEX idx_jobs_input_text_search ON jobs USING GIN (to_tsvector('simple', input_text));
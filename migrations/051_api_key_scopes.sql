@@ -0,0 +1,3 @@
+-- API key scopes (storiesctl set-scopes): api for the REST API, agents:<tool> or agents:* for the agents
+-- service's MCP tools and gRPC methods. NULL leaves the key unrestricted.
+ALTER TABLE api_keys ADD COLUMN scopes TEXT[];
@@ -0,0 +1,7 @@
+-- Job retention: expires_at is set at creation (expires_at or retention_days of POST /v1/jobs); the job
+-- expiry loop sets expired_at once it has passed, queueing the job's S3 objects in storage_deletions and
+-- deleting its assets. Expired jobs answer 410.
+ALTER TABLE jobs ADD COLUMN expires_at TIMESTAMPTZ;
+ALTER TABLE jobs ADD COLUMN expired_at TIMESTAMPTZ;
+
+CREATE INDEX idx_jobs_expires_at ON jobs (expires_at) WHERE expires_at IS NOT NULL AND expired_at IS NULL;
@@ -0,0 +1,20 @@
+-- Long-running agent calls (MCP tools called with async, gRPC GenerateImageAsync): the agents service runs
+-- them in the background and callers poll get_operation / GetOperation. Results are kept here, or in S3
+-- (result_s3_key) when large, until expires_at; the agents service then deletes the row and queues the
+-- S3 object in storage_deletions.
+CREATE TABLE agent_operations (
+    id UUID PRIMARY KEY,
+    api_key_id UUID NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    tool TEXT NOT NULL,
+    protocol TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'running',
+    result BYTEA,
+    result_s3_bucket TEXT,
+    result_s3_key TEXT,
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_agent_operations_expires_at ON agent_operations(expires_at);
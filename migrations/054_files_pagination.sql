@@ -0,0 +1,2 @@
+-- GET /v1/files pages through a user's files newest first by (created_at, id).
+CREATE INDEX idx_files_user_created ON files(user_id, created_at DESC, id DESC);
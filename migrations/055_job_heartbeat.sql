@@ -0,0 +1,4 @@
+-- Job heartbeat: the worker processing a job claims it by setting heartbeat_at, refreshes it every 30s and
+-- with every progress update, and clears it when it stops. Another delivery of the job is skipped while the
+-- heartbeat is fresh, and the stuck job sweeper compares running jobs by it rather than by started_at.
+ALTER TABLE jobs ADD COLUMN heartbeat_at TIMESTAMPTZ;
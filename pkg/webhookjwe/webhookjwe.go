@@ -0,0 +1,344 @@
+// Package webhookjwe encrypts webhook payloads for their receiver as JSON Web Encryption (RFC 7516) in compact
+// serialization, so a job's markup does not travel or rest in plaintext outside the receiver. A webhook's
+// key is a PEM public key: RSA keys (2048 bits or more) use RSA-OAEP-256, EC keys on P-256, P-384 or
+// P-521 use ECDH-ES; the content is always encrypted with A256GCM. The protected header carries the
+// key's RFC 7638 thumbprint as kid, so receivers rotating keys can pick the matching private key.
+//
+// Any JOSE library decrypts the payloads. Go receivers can import this package and use ParsePrivateKey
+// and Decrypt; it depends on the standard library only.
+package webhookjwe
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ContentType is the Content-Type of requests whose body is a compact JWE.
+const ContentType = "application/jose"
+
+// Algorithms of the payloads.
+const (
+	AlgRSAOAEP256 = "RSA-OAEP-256"
+	AlgECDHES     = "ECDH-ES"
+	EncA256GCM    = "A256GCM"
+)
+
+// minRSABits is the smallest RSA key accepted
+const minRSABits = 2048
+
+// ErrInvalidKey is wrapped by the errors of keys ParsePublicKey and ParsePrivateKey refuse.
+var ErrInvalidKey = errors.New("invalid key")
+
+// header is the JOSE protected header
+type header struct {
+	Alg string `json:"alg"`
+	Enc string `json:"enc"`
+	Cty string `json:"cty,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Epk *jwk   `json:"epk,omitempty"`
+}
+
+// jwk is an RSA or EC public key as a JSON Web Key
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	E   string `json:"e,omitempty"`
+	N   string `json:"n,omitempty"`
+}
+
+var b64 = base64.RawURLEncoding
+
+// ParsePublicKey parses a PEM public key ("PUBLIC KEY", or "RSA PUBLIC KEY" for PKCS #1) and checks
+// that payloads can be encrypted with it.
+func ParsePublicKey(pemKey string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(pemKey)))
+	if block == nil {
+		return nil, fmt.Errorf("%w: not a PEM public key", ErrInvalidKey)
+	}
+	var key crypto.PublicKey
+	var err error
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%w: PEM block is %q, want PUBLIC KEY", ErrInvalidKey, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	if _, err := toJWK(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ParsePrivateKey parses a PEM private key (PKCS #8, PKCS #1 or SEC 1), for receivers and tests.
+func ParsePrivateKey(pemKey string) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(pemKey)))
+	if block == nil {
+		return nil, fmt.Errorf("%w: not a PEM private key", ErrInvalidKey)
+	}
+	var key crypto.PrivateKey
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%w: PEM block is %q, want PRIVATE KEY", ErrInvalidKey, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	return key, nil
+}
+
+// Thumbprint returns the RFC 7638 SHA-256 thumbprint of key, base64url-encoded: the kid of its payloads.
+func Thumbprint(key crypto.PublicKey) (string, error) {
+	k, err := toJWK(key)
+	if err != nil {
+		return "", err
+	}
+	// The required members in lexicographic order, without whitespace
+	var members string
+	if k.Kty == "RSA" {
+		members = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, k.E, k.N)
+	} else {
+		members = fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, k.Crv, k.X, k.Y)
+	}
+	sum := sha256.Sum256([]byte(members))
+	return b64.EncodeToString(sum[:]), nil
+}
+
+// Encrypt encrypts plaintext (a JSON document) for key and returns the compact JWE.
+func Encrypt(plaintext []byte, key crypto.PublicKey) ([]byte, error) {
+	kid, err := Thumbprint(key)
+	if err != nil {
+		return nil, err
+	}
+	h := header{Enc: EncA256GCM, Cty: "application/json", Kid: kid}
+	var cek, encryptedKey []byte
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		h.Alg = AlgRSAOAEP256
+		cek = make([]byte, 32)
+		if _, err := rand.Read(cek); err != nil {
+			return nil, err
+		}
+		if encryptedKey, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, key, cek, nil); err != nil {
+			return nil, fmt.Errorf("failed to encrypt content key: %w", err)
+		}
+	case *ecdsa.PublicKey:
+		h.Alg = AlgECDHES
+		pub, err := key.ECDH()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+		}
+		ephemeral, err := pub.Curve().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		z, err := ephemeral.ECDH(pub)
+		if err != nil {
+			return nil, err
+		}
+		cek = concatKDF(z, EncA256GCM, nil, nil, 256)
+		epk, err := ecdhJWK(ephemeral.PublicKey(), key.Curve)
+		if err != nil {
+			return nil, err
+		}
+		h.Epk = epk
+	}
+
+	protected, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	encodedHeader := b64.EncodeToString(protected)
+	gcm, err := newGCM(cek)
+	if err != nil {
+		return nil, err
+	}
+	iv := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	sealed := gcm.Seal(nil, iv, plaintext, []byte(encodedHeader))
+	ciphertext, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	return []byte(strings.Join([]string{
+		encodedHeader,
+		b64.EncodeToString(encryptedKey),
+		b64.EncodeToString(iv),
+		b64.EncodeToString(ciphertext),
+		b64.EncodeToString(tag),
+	}, ".")), nil
+}
+
+// Decrypt decrypts a compact JWE produced by Encrypt with the private key matching the webhook's public
+// key (*rsa.PrivateKey or *ecdsa.PrivateKey, as returned by ParsePrivateKey).
+func Decrypt(compact []byte, key crypto.PrivateKey) ([]byte, error) {
+	parts := strings.Split(string(bytes.TrimSpace(compact)), ".")
+	if len(parts) != 5 {
+		return nil, errors.New("not a compact JWE")
+	}
+	var raw [5][]byte
+	for i, part := range parts {
+		b, err := b64.DecodeString(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWE part %d: %w", i, err)
+		}
+		raw[i] = b
+	}
+	var h header
+	if err := json.Unmarshal(raw[0], &h); err != nil {
+		return nil, fmt.Errorf("invalid JWE header: %w", err)
+	}
+	if h.Enc != EncA256GCM {
+		return nil, fmt.Errorf("unsupported enc %q", h.Enc)
+	}
+
+	var cek []byte
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		if h.Alg != AlgRSAOAEP256 {
+			return nil, fmt.Errorf("alg %q does not match an RSA key", h.Alg)
+		}
+		var err error
+		if cek, err = rsa.DecryptOAEP(sha256.New(), nil, key, raw[1], nil); err != nil {
+			return nil, fmt.Errorf("failed to decrypt content key: %w", err)
+		}
+	case *ecdsa.PrivateKey:
+		if h.Alg != AlgECDHES || h.Epk == nil {
+			return nil, fmt.Errorf("alg %q does not match an EC key", h.Alg)
+		}
+		priv, err := key.ECDH()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+		}
+		epk, err := h.Epk.ecdhPublicKey(priv.Curve())
+		if err != nil {
+			return nil, err
+		}
+		z, err := priv.ECDH(epk)
+		if err != nil {
+			return nil, err
+		}
+		cek = concatKDF(z, EncA256GCM, nil, nil, 256)
+	default:
+		return nil, fmt.Errorf("%w: unsupported private key type %T", ErrInvalidKey, key)
+	}
+
+	gcm, err := newGCM(cek)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw[2]) != gcm.NonceSize() {
+		return nil, errors.New("invalid JWE initialization vector")
+	}
+	plaintext, err := gcm.Open(nil, raw[2], append(raw[3], raw[4]...), []byte(parts[0]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt payload: %w", err)
+	}
+	return plaintext, nil
+}
+
+func newGCM(cek []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// concatKDF derives the content key of ECDH-ES (RFC 7518 section 4.6.2) from the shared secret z. One
+// SHA-256 round covers keys up to 256 bits.
+func concatKDF(z []byte, algID string, apu, apv []byte, keyBits int) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, uint32(1))
+	buf.Write(z)
+	binary.Write(&buf, binary.BigEndian, uint32(len(algID)))
+	buf.WriteString(algID)
+	binary.Write(&buf, binary.BigEndian, uint32(len(apu)))
+	buf.Write(apu)
+	binary.Write(&buf, binary.BigEndian, uint32(len(apv)))
+	buf.Write(apv)
+	binary.Write(&buf, binary.BigEndian, uint32(keyBits))
+	sum := sha256.Sum256(buf.Bytes())
+	return sum[:keyBits/8]
+}
+
+// curves maps the supported curves to their JWK names
+var curves = map[elliptic.Curve]string{
+	elliptic.P256(): "P-256",
+	elliptic.P384(): "P-384",
+	elliptic.P521(): "P-521",
+}
+
+// toJWK returns key as a JWK, refusing keys payloads cannot be encrypted with.
+func toJWK(key crypto.PublicKey) (*jwk, error) {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if key.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("%w: RSA keys must have at least %d bits", ErrInvalidKey, minRSABits)
+		}
+		return &jwk{Kty: "RSA", E: b64.EncodeToString(big.NewInt(int64(key.E)).Bytes()), N: b64.EncodeToString(key.N.Bytes())}, nil
+	case *ecdsa.PublicKey:
+		pub, err := key.ECDH()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+		}
+		return ecdhJWK(pub, key.Curve)
+	default:
+		return nil, fmt.Errorf("%w: %T keys are not supported, use RSA or EC (P-256, P-384, P-521)", ErrInvalidKey, key)
+	}
+}
+
+// ecdhJWK returns an EC public key as a JWK.
+func ecdhJWK(pub *ecdh.PublicKey, curve elliptic.Curve) (*jwk, error) {
+	name, ok := curves[curve]
+	if !ok {
+		return nil, fmt.Errorf("%w: curve %s is not supported, use P-256, P-384 or P-521", ErrInvalidKey, curve.Params().Name)
+	}
+	point := pub.Bytes() // 0x04 || x || y, each padded to the field size
+	size := (len(point) - 1) / 2
+	return &jwk{Kty: "EC", Crv: name, X: b64.EncodeToString(point[1 : 1+size]), Y: b64.EncodeToString(point[1+size:])}, nil
+}
+
+// ecdhPublicKey returns the epk of a header as a key on curve.
+func (k *jwk) ecdhPublicKey(curve ecdh.Curve) (*ecdh.PublicKey, error) {
+	x, errX := b64.DecodeString(k.X)
+	y, errY := b64.DecodeString(k.Y)
+	if k.Kty != "EC" || errX != nil || errY != nil || len(x) != len(y) {
+		return nil, errors.New("invalid JWE epk")
+	}
+	pub, err := curve.NewPublicKey(append(append([]byte{4}, x...), y...))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWE epk: %w", err)
+	}
+	return pub, nil
+}